@@ -0,0 +1,85 @@
+// Package audit contains the audit log business logic (the usecase layer).
+// Entries are written by the usecases that make the changes (see
+// user.WithAuditLog); this package reads them back.
+package audit
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"veemon/entity"
+	"veemon/repository/audit_repository"
+)
+
+// ErrInvalidRange is returned by List when From is not before To.
+var ErrInvalidRange = errors.New("invalid date range")
+
+// Defaults and limits List applies to paging.
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+type UseCase interface {
+	List(ctx context.Context, input ListInput) (*ListOutput, error)
+}
+
+// ListInput filters the log; empty fields match everything.
+type ListInput struct {
+	Page         int
+	Size         int
+	ActorID      string
+	ResourceType string
+	ResourceID   string
+	// From and To bound the entries' creation time: at or after From and
+	// before To.
+	From time.Time
+	To   time.Time
+}
+
+// ListOutput is one page of entries, newest first, with the page and size
+// actually applied.
+type ListOutput struct {
+	Entries []entity.AuditLog
+	Total   int64
+	Page    int
+	Size    int
+}
+
+type useCase struct {
+	repo audit_repository.Repository
+}
+
+func NewUseCase(repo audit_repository.Repository) UseCase {
+	return &useCase{repo: repo}
+}
+
+func (uc *useCase) List(ctx context.Context, input ListInput) (*ListOutput, error) {
+	if !input.From.IsZero() && !input.To.IsZero() && !input.From.Before(input.To) {
+		return nil, ErrInvalidRange
+	}
+	page, size := input.Page, input.Size
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 {
+		size = DefaultPageSize
+	} else if size > MaxPageSize {
+		size = MaxPageSize
+	}
+
+	entries, total, err := uc.repo.FindAll(ctx, audit_repository.ListParams{
+		Page:         page,
+		Size:         size,
+		ActorID:      input.ActorID,
+		ResourceType: input.ResourceType,
+		ResourceID:   input.ResourceID,
+		From:         input.From,
+		To:           input.To,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ListOutput{Entries: entries, Total: total, Page: page, Size: size}, nil
+}
@@ -0,0 +1,56 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"veemon/entity"
+	"veemon/repository/audit_repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingRepo returns no entries and remembers the parameters it was
+// asked for.
+type recordingRepo struct {
+	audit_repository.Repository
+	params audit_repository.ListParams
+}
+
+func (r *recordingRepo) FindAll(_ context.Context, params audit_repository.ListParams) ([]entity.AuditLog, int64, error) {
+	r.params = params
+	return nil, 0, nil
+}
+
+func TestList_DefaultsAndCapsPaging(t *testing.T) {
+	tests := []struct {
+		name               string
+		page, size         int
+		wantPage, wantSize int
+	}{
+		{"defaults", 0, 0, 1, DefaultPageSize},
+		{"caps size", 2, 500, 2, MaxPageSize},
+		{"keeps valid", 3, 50, 3, 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &recordingRepo{}
+			out, err := NewUseCase(repo).List(context.Background(), ListInput{Page: tt.page, Size: tt.size, ActorID: "admin-1"})
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPage, out.Page)
+			assert.Equal(t, tt.wantSize, out.Size)
+			assert.Equal(t, audit_repository.ListParams{Page: tt.wantPage, Size: tt.wantSize, ActorID: "admin-1"}, repo.params)
+		})
+	}
+}
+
+func TestList_RejectsEmptyOrInvertedRange(t *testing.T) {
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, to := range []time.Time{at, at.Add(-time.Hour)} {
+		_, err := NewUseCase(&recordingRepo{}).List(context.Background(), ListInput{From: at, To: to})
+		assert.ErrorIs(t, err, ErrInvalidRange)
+	}
+}
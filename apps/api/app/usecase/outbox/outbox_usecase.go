@@ -0,0 +1,259 @@
+// Package outbox contains the transactional outbox business logic (the
+// usecase layer). Messages are staged with Add inside the transaction of the
+// change they report, so they commit or roll back with it; Relay publishes
+// the committed ones to RabbitMQ. Delivery is at least once: a message whose
+// confirm arrives but whose SentAt does not commit is published again, so
+// consumers deduplicate by envelope ID.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"veemon/entity"
+	"veemon/pkg/database"
+	"veemon/pkg/events"
+	"veemon/pkg/rabbitmq"
+	"veemon/pkg/resilience"
+	"veemon/repository/outbox_repository"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.uber.org/zap"
+)
+
+// ErrNoPublisher is returned by Relay without WithPublisher.
+var ErrNoPublisher = errors.New("outbox: no publisher")
+
+// Defaults for the relay.
+const (
+	DefaultBatchSize = 100
+	DefaultRetention = 7 * 24 * time.Hour
+	// A message that fails to publish is retried after RetryBaseDelay,
+	// doubling per attempt up to RetryMaxDelay.
+	RetryBaseDelay = time.Second
+	RetryMaxDelay  = 5 * time.Minute
+)
+
+// Publisher publishes one message. *rabbitmq.Client satisfies it.
+type Publisher interface {
+	Publish(ctx context.Context, opts rabbitmq.PublishOptions, message interface{}) error
+}
+
+// BacklogRecorder exports the relay's backlog. *metrics.Metrics satisfies it.
+type BacklogRecorder interface {
+	SetOutboxBacklog(pending int64, lag time.Duration)
+}
+
+type UseCase interface {
+	// Add stages env for publication to exchange with routingKey, along
+	// with the trace context in ctx.
+	Add(ctx context.Context, exchange, routingKey string, env events.Envelope) error
+	// Relay publishes one batch of due messages with publisher confirms,
+	// returning how many were sent. A message that fails is rescheduled
+	// with backoff.
+	Relay(ctx context.Context) (int, error)
+	// Cleanup deletes the messages sent more than the retention ago,
+	// returning how many.
+	Cleanup(ctx context.Context) (int64, error)
+}
+
+type Option func(*useCase)
+
+// WithTransactor claims and marks each batch in one transaction, so
+// concurrent relays never publish the same message.
+func WithTransactor(tx database.Transactor) Option {
+	return func(uc *useCase) {
+		uc.tx = tx
+	}
+}
+
+// WithPublisher makes Relay publish through p, retrying each message as
+// retry says before rescheduling it.
+func WithPublisher(p Publisher, retry resilience.Config) Option {
+	return func(uc *useCase) {
+		uc.publisher = p
+		uc.retry = retry
+	}
+}
+
+// WithBatchSize caps the messages one Relay publishes; n < 1 keeps
+// DefaultBatchSize.
+func WithBatchSize(n int) Option {
+	return func(uc *useCase) {
+		if n > 0 {
+			uc.batchSize = n
+		}
+	}
+}
+
+// WithRetention sets how long Cleanup keeps sent messages; d <= 0 keeps
+// DefaultRetention.
+func WithRetention(d time.Duration) Option {
+	return func(uc *useCase) {
+		if d > 0 {
+			uc.retention = d
+		}
+	}
+}
+
+// WithBacklogRecorder reports the backlog after every Relay.
+func WithBacklogRecorder(r BacklogRecorder) Option {
+	return func(uc *useCase) {
+		uc.recorder = r
+	}
+}
+
+// WithLogger logs messages that failed to publish.
+func WithLogger(l *zap.Logger) Option {
+	return func(uc *useCase) {
+		uc.logger = l
+	}
+}
+
+// noTx runs fn directly, without a transaction.
+type noTx struct{}
+
+func (noTx) Do(ctx context.Context, fn func(context.Context) error) error { return fn(ctx) }
+
+type useCase struct {
+	repo      outbox_repository.Repository
+	tx        database.Transactor
+	publisher Publisher
+	retry     resilience.Config
+	executor  *resilience.SimpleExecutor
+	batchSize int
+	retention time.Duration
+	recorder  BacklogRecorder
+	logger    *zap.Logger
+	now       func() time.Time
+}
+
+func NewUseCase(repo outbox_repository.Repository, opts ...Option) UseCase {
+	uc := &useCase{
+		repo:      repo,
+		tx:        noTx{},
+		batchSize: DefaultBatchSize,
+		retention: DefaultRetention,
+		logger:    zap.NewNop(),
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(uc)
+	}
+	if uc.publisher != nil {
+		uc.executor = resilience.NewSimple("outbox.relay", uc.retry, uc.logger)
+	}
+	return uc
+}
+
+func (uc *useCase) Add(ctx context.Context, exchange, routingKey string, env events.Envelope) error {
+	payload, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	msg := &entity.OutboxMessage{
+		Exchange:      exchange,
+		RoutingKey:    routingKey,
+		Payload:       payload,
+		NextAttemptAt: uc.now(),
+	}
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) > 0 {
+		if msg.Headers, err = json.Marshal(carrier); err != nil {
+			return err
+		}
+	}
+	return uc.repo.Create(ctx, msg)
+}
+
+func (uc *useCase) Relay(ctx context.Context) (int, error) {
+	if uc.publisher == nil {
+		return 0, ErrNoPublisher
+	}
+	sent := 0
+	err := uc.tx.Do(ctx, func(ctx context.Context) error {
+		msgs, err := uc.repo.ClaimDue(ctx, uc.now(), uc.batchSize)
+		if err != nil {
+			return err
+		}
+		for i := range msgs {
+			m := &msgs[i]
+			if err := uc.publish(ctx, m); err != nil {
+				attempts := m.Attempts + 1
+				uc.logger.Warn("outbox message not published",
+					zap.String("id", m.ID),
+					zap.String("routing_key", m.RoutingKey),
+					zap.Int("attempts", attempts),
+					zap.Error(err),
+				)
+				if err := uc.repo.MarkFailed(ctx, m.ID, attempts, uc.now().Add(backoff(attempts)), err.Error()); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := uc.repo.MarkSent(ctx, m.ID, uc.now()); err != nil {
+				return err
+			}
+			sent++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	uc.recordBacklog(ctx)
+	return sent, nil
+}
+
+// publish publishes m under the trace it was staged in.
+func (uc *useCase) publish(ctx context.Context, m *entity.OutboxMessage) error {
+	if len(m.Headers) > 0 {
+		carrier := propagation.MapCarrier{}
+		if err := json.Unmarshal(m.Headers, &carrier); err == nil {
+			ctx = otel.GetTextMapPropagator().Extract(ctx, carrier)
+		}
+	}
+	return uc.executor.Run(ctx, func(ctx context.Context) error {
+		return uc.publisher.Publish(ctx, rabbitmq.PublishOptions{
+			Exchange:    m.Exchange,
+			RoutingKey:  m.RoutingKey,
+			ContentType: events.ContentTypeJSON,
+			Confirm:     true,
+		}, m.Payload)
+	})
+}
+
+// recordBacklog reports the unsent messages and the age of the oldest. A
+// failed count is only logged: the batch itself went through.
+func (uc *useCase) recordBacklog(ctx context.Context) {
+	if uc.recorder == nil {
+		return
+	}
+	pending, oldest, err := uc.repo.Backlog(ctx)
+	if err != nil {
+		uc.logger.Warn("count outbox backlog", zap.Error(err))
+		return
+	}
+	var lag time.Duration
+	if !oldest.IsZero() {
+		lag = uc.now().Sub(oldest)
+	}
+	uc.recorder.SetOutboxBacklog(pending, lag)
+}
+
+func (uc *useCase) Cleanup(ctx context.Context) (int64, error) {
+	return uc.repo.DeleteSentBefore(ctx, uc.now().Add(-uc.retention))
+}
+
+// backoff is the delay before the next attempt after attempts failures.
+func backoff(attempts int) time.Duration {
+	d := RetryBaseDelay
+	for i := 1; i < attempts && d < RetryMaxDelay; i++ {
+		d *= 2
+	}
+	return min(d, RetryMaxDelay)
+}
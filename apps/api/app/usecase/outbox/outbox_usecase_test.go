@@ -0,0 +1,167 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"veemon/entity"
+	"veemon/pkg/events"
+	"veemon/pkg/rabbitmq"
+	"veemon/pkg/resilience"
+	"veemon/repository/outbox_repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// memRepo keeps messages in memory; every unsent message is due.
+type memRepo struct {
+	outbox_repository.Repository
+	msgs []*entity.OutboxMessage
+}
+
+func (r *memRepo) Create(_ context.Context, m *entity.OutboxMessage) error {
+	m.ID = "msg-" + string(rune('a'+len(r.msgs)))
+	r.msgs = append(r.msgs, m)
+	return nil
+}
+
+func (r *memRepo) ClaimDue(_ context.Context, _ time.Time, limit int) ([]entity.OutboxMessage, error) {
+	var out []entity.OutboxMessage
+	for _, m := range r.msgs {
+		if m.SentAt == nil && len(out) < limit {
+			out = append(out, *m)
+		}
+	}
+	return out, nil
+}
+
+func (r *memRepo) find(id string) *entity.OutboxMessage {
+	for _, m := range r.msgs {
+		if m.ID == id {
+			return m
+		}
+	}
+	return nil
+}
+
+func (r *memRepo) MarkSent(_ context.Context, id string, at time.Time) error {
+	r.find(id).SentAt = &at
+	return nil
+}
+
+func (r *memRepo) MarkFailed(_ context.Context, id string, attempts int, next time.Time, lastErr string) error {
+	m := r.find(id)
+	m.Attempts, m.NextAttemptAt, m.LastError = attempts, next, lastErr
+	return nil
+}
+
+func (r *memRepo) Backlog(context.Context) (int64, time.Time, error) {
+	var n int64
+	var oldest time.Time
+	for _, m := range r.msgs {
+		if m.SentAt == nil {
+			n++
+			if oldest.IsZero() || m.CreatedAt.Before(oldest) {
+				oldest = m.CreatedAt
+			}
+		}
+	}
+	return n, oldest, nil
+}
+
+// fakePublisher fails the routing keys in fail and records the rest, with
+// the trace each was published under.
+type fakePublisher struct {
+	fail      map[string]bool
+	published []rabbitmq.PublishOptions
+	traces    []trace.TraceID
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, opts rabbitmq.PublishOptions, _ interface{}) error {
+	if p.fail[opts.RoutingKey] {
+		return errors.New("nacked")
+	}
+	p.published = append(p.published, opts)
+	p.traces = append(p.traces, trace.SpanContextFromContext(ctx).TraceID())
+	return nil
+}
+
+type backlog struct {
+	pending int64
+	lag     time.Duration
+}
+
+func (b *backlog) SetOutboxBacklog(pending int64, lag time.Duration) { b.pending, b.lag = pending, lag }
+
+func noRetry() resilience.Config {
+	cfg := resilience.DefaultConfig()
+	cfg.RetryMaxAttempts = 1
+	return cfg
+}
+
+func TestRelay_PublishesAndReschedulesFailures(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	repo := &memRepo{}
+	pub := &fakePublisher{fail: map[string]bool{"user.deleted": true}}
+	rec := &backlog{}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	uc := NewUseCase(repo, WithPublisher(pub, noRetry()), WithBacklogRecorder(rec)).(*useCase)
+	uc.now = func() time.Time { return now }
+
+	traceID := trace.TraceID{1}
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID, SpanID: trace.SpanID{2}, TraceFlags: trace.FlagsSampled,
+	}))
+	require.NoError(t, uc.Add(ctx, "user.events", "user.updated", events.Envelope{ID: "e1", Type: "user.updated"}))
+	require.NoError(t, uc.Add(context.Background(), "user.events", "user.deleted", events.Envelope{ID: "e2", Type: "user.deleted"}))
+	repo.msgs[1].CreatedAt = now.Add(-time.Minute)
+
+	sent, err := uc.Relay(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	require.Len(t, pub.published, 1)
+	assert.Equal(t, rabbitmq.PublishOptions{
+		Exchange: "user.events", RoutingKey: "user.updated", ContentType: events.ContentTypeJSON, Confirm: true,
+	}, pub.published[0])
+	assert.Equal(t, traceID, pub.traces[0], "published outside the staging request's trace")
+	assert.NotNil(t, repo.msgs[0].SentAt)
+
+	failed := repo.msgs[1]
+	assert.Nil(t, failed.SentAt)
+	assert.Equal(t, 1, failed.Attempts)
+	assert.Equal(t, now.Add(RetryBaseDelay), failed.NextAttemptAt)
+	assert.NotEmpty(t, failed.LastError)
+	assert.Equal(t, backlog{pending: 1, lag: time.Minute}, *rec)
+}
+
+func TestAdd_StoresEnvelope(t *testing.T) {
+	repo := &memRepo{}
+	env := events.Envelope{ID: "e1", Type: "user.registered", Version: 1}
+
+	require.NoError(t, NewUseCase(repo).Add(context.Background(), "user.events", "user.registered", env))
+
+	require.Len(t, repo.msgs, 1)
+	var got events.Envelope
+	require.NoError(t, json.Unmarshal(repo.msgs[0].Payload, &got))
+	assert.Equal(t, env, got)
+	assert.False(t, repo.msgs[0].NextAttemptAt.IsZero(), "not due")
+}
+
+func TestRelay_NeedsPublisher(t *testing.T) {
+	_, err := NewUseCase(&memRepo{}).Relay(context.Background())
+	assert.ErrorIs(t, err, ErrNoPublisher)
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, backoff(1))
+	assert.Equal(t, 8*time.Second, backoff(4))
+	assert.Equal(t, RetryMaxDelay, backoff(30))
+}
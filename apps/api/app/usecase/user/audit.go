@@ -0,0 +1,83 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"veemon/entity"
+	"veemon/pkg/logger"
+	"veemon/pkg/middleware"
+	"veemon/repository/audit_repository"
+)
+
+// WithAuditLog makes CreateUser, UpdateUser, DeleteUser, RestoreUser and
+// PurgeUser record each change in repo, inside the transaction that makes
+// it, so no change commits unrecorded, and RecordImpersonation write its
+// entry there. The actor is the AuthContext in ctx
+// and the request ID the one logger.ContextWithRequestID stored.
+func WithAuditLog(repo audit_repository.Repository) Option {
+	return func(uc *useCase) {
+		uc.auditLog = repo
+	}
+}
+
+// audit records action on the user, given as it was before and after; either
+// may be nil. Without WithAuditLog it does nothing.
+func (uc *useCase) audit(ctx context.Context, action string, before, after *entity.User) error {
+	if uc.auditLog == nil {
+		return nil
+	}
+	entry := auditEntry(ctx, action)
+	var err error
+	if before != nil {
+		entry.ResourceID = before.ID
+		if entry.Before, err = json.Marshal(before); err != nil {
+			return err
+		}
+	}
+	if after != nil {
+		entry.ResourceID = after.ID
+		if entry.After, err = json.Marshal(after); err != nil {
+			return err
+		}
+	}
+	return uc.auditLog.Create(ctx, entry)
+}
+
+// impersonationDetails is the After of an AuditActionImpersonate entry.
+type impersonationDetails struct {
+	ExpiresAt time.Time `json:"expiresAt"`
+	Reason    string    `json:"reason,omitempty"`
+}
+
+func (uc *useCase) RecordImpersonation(ctx context.Context, targetID string, expiresAt time.Time, reason string) error {
+	if uc.auditLog == nil {
+		return nil
+	}
+	entry := auditEntry(ctx, entity.AuditActionImpersonate)
+	entry.ResourceID = targetID
+	var err error
+	if entry.After, err = json.Marshal(impersonationDetails{ExpiresAt: expiresAt.UTC(), Reason: reason}); err != nil {
+		return err
+	}
+	return uc.auditLog.Create(ctx, entry)
+}
+
+// auditEntry starts an entry about a user, attributed to the caller in ctx.
+func auditEntry(ctx context.Context, action string) *entity.AuditLog {
+	entry := &entity.AuditLog{
+		Action:       action,
+		ResourceType: entity.AuditResourceUser,
+		RequestID:    logger.RequestIDFromContext(ctx),
+	}
+	if a, ok := middleware.AuthFromContext(ctx); ok && a != nil {
+		entry.ActorID = a.UserID
+		// The superadmin behind an impersonation token is the one
+		// accountable for what it does.
+		if a.IsImpersonated {
+			entry.ActorID = a.ActorID
+		}
+	}
+	return entry
+}
@@ -0,0 +1,140 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"veemon/entity"
+	"veemon/internal/fixtures"
+	"veemon/pkg/logger"
+	"veemon/pkg/middleware"
+	"veemon/repository"
+	"veemon/repository/audit_repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAuditLog records entries, noting whether each was written inside a
+// fakeTx transaction.
+type fakeAuditLog struct {
+	audit_repository.Repository
+	entries []entity.AuditLog
+	inTx    []bool
+	err     error
+}
+
+func (f *fakeAuditLog) Create(ctx context.Context, entry *entity.AuditLog) error {
+	if f.err != nil {
+		return f.err
+	}
+	v, _ := ctx.Value(inTxKey{}).(bool)
+	f.entries = append(f.entries, *entry)
+	f.inTx = append(f.inTx, v)
+	return nil
+}
+
+func adminCtx() context.Context {
+	ctx := middleware.WithAuthContext(context.Background(), &middleware.AuthContext{UserID: "admin-1", Roles: []string{"admin"}})
+	return logger.ContextWithRequestID(ctx, "req-1")
+}
+
+func TestAudit_UpdateUserRecordsBeforeAndAfter(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	log := &fakeAuditLog{}
+	uc := NewUseCase(mockRepo, WithTransactor(&fakeTx{}), WithAuditLog(log))
+	ctx := adminCtx()
+
+	before := fixtures.User().WithID("user-1").WithName("Old").Build()
+	after := fixtures.User().WithID("user-1").WithName("New").Build()
+	mockRepo.On("FindByID", inTx, "user-1").Return(before, nil)
+	mockRepo.On("UpdateFields", inTx, "user-1", map[string]interface{}{"name": "New"}, (*time.Time)(nil)).Return(after, nil)
+
+	_, err := uc.UpdateUser(ctx, "user-1", UpdateInput{Name: ptr("New")})
+
+	require.NoError(t, err)
+	require.Len(t, log.entries, 1)
+	e := log.entries[0]
+	assert.True(t, log.inTx[0], "entry written outside the update's transaction")
+	assert.Equal(t, "admin-1", e.ActorID)
+	assert.Equal(t, entity.AuditActionUpdate, e.Action)
+	assert.Equal(t, entity.AuditResourceUser, e.ResourceType)
+	assert.Equal(t, "user-1", e.ResourceID)
+	assert.Equal(t, "req-1", e.RequestID)
+	var b, a map[string]any
+	require.NoError(t, json.Unmarshal(e.Before, &b))
+	require.NoError(t, json.Unmarshal(e.After, &a))
+	assert.Equal(t, "Old", b["name"])
+	assert.Equal(t, "New", a["name"])
+	assert.NotContains(t, a, "password")
+}
+
+func TestAudit_DeleteUserRecordsBeforeOnly(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	log := &fakeAuditLog{}
+	uc := NewUseCase(mockRepo, WithTransactor(&fakeTx{}), WithAuditLog(log))
+
+	mockRepo.On("FindByID", inTx, "user-1").Return(fixtures.User().WithID("user-1").Build(), nil)
+	mockRepo.On("Delete", inTx, "user-1").Return(nil)
+
+	require.NoError(t, uc.DeleteUser(adminCtx(), "user-1"))
+
+	require.Len(t, log.entries, 1)
+	assert.Equal(t, entity.AuditActionDelete, log.entries[0].Action)
+	assert.Equal(t, "user-1", log.entries[0].ResourceID)
+	assert.NotEmpty(t, log.entries[0].Before)
+	assert.Empty(t, log.entries[0].After)
+}
+
+func TestAudit_ImpersonatorIsTheActor(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	log := &fakeAuditLog{}
+	uc := NewUseCase(mockRepo, WithAuditLog(log))
+	ctx := middleware.WithAuthContext(context.Background(), &middleware.AuthContext{
+		UserID: "user-9", IsImpersonated: true, ActorID: "root-1",
+	})
+
+	mockRepo.On("FindByID", ctx, "user-1").Return(fixtures.User().WithID("user-1").Build(), nil)
+	mockRepo.On("Delete", ctx, "user-1").Return(nil)
+
+	require.NoError(t, uc.DeleteUser(ctx, "user-1"))
+
+	require.Len(t, log.entries, 1)
+	assert.Equal(t, "root-1", log.entries[0].ActorID)
+}
+
+// A change whose entry cannot be written fails with it, so the transaction
+// rolls the change back.
+func TestAudit_FailureFailsTheChange(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := NewUseCase(mockRepo, WithTransactor(&fakeTx{}), WithAuditLog(&fakeAuditLog{err: errors.New("disk full")}))
+
+	mockRepo.On("FindByEmail", inTx, "new@example.com").Return(nil, repository.ErrNotFound)
+	mockRepo.On("Create", inTx, mock.AnythingOfType("*entity.User")).Return(nil).Run(assignID("u1"))
+
+	_, err := uc.CreateUser(adminCtx(), CreateUserInput{Email: "new@example.com", Password: "Password123", Name: "New"})
+
+	assert.EqualError(t, err, "disk full")
+}
+
+func TestAudit_RecordImpersonation(t *testing.T) {
+	log := &fakeAuditLog{}
+	uc := NewUseCase(new(MockUserRepository), WithAuditLog(log))
+	expiresAt := time.Date(2026, 3, 1, 10, 15, 0, 0, time.FixedZone("WIB", 7*3600))
+
+	require.NoError(t, uc.RecordImpersonation(adminCtx(), "user-1", expiresAt, "ticket 42"))
+
+	require.Len(t, log.entries, 1)
+	e := log.entries[0]
+	assert.Equal(t, "admin-1", e.ActorID)
+	assert.Equal(t, entity.AuditActionImpersonate, e.Action)
+	assert.Equal(t, entity.AuditResourceUser, e.ResourceType)
+	assert.Equal(t, "user-1", e.ResourceID)
+	assert.Equal(t, "req-1", e.RequestID)
+	assert.Empty(t, e.Before)
+	assert.JSONEq(t, `{"expiresAt":"2026-03-01T03:15:00Z","reason":"ticket 42"}`, string(e.After))
+}
@@ -0,0 +1,170 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+
+	"veemon/entity"
+	"veemon/pkg/notify"
+	"veemon/pkg/passwordreset"
+	"veemon/repository"
+	"veemon/repository/user_repository"
+
+	"github.com/lib/pq"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrInvitesUnavailable is returned by CreateUser for an invitation without
+// WithInvites, or when its store has no Redis.
+var ErrInvitesUnavailable = errors.New("user invitations unavailable")
+
+// InviteNotifier announces invitations so the user can be sent their
+// token. *passwordreset.Notifier satisfies it.
+type InviteNotifier interface {
+	UserInvited(ctx context.Context, inv passwordreset.Invited) error
+}
+
+// WithInvites enables CreateUser invitations, keeping their tokens in
+// tokens and announcing them through notifier. The tokens are redeemed by
+// ResetPassword, so tokens must share its key space with the password reset
+// store (a passwordreset.Store on the same Redis, typically with a longer
+// TTL).
+func WithInvites(tokens ResetTokenStore, notifier InviteNotifier) Option {
+	return func(uc *useCase) {
+		uc.inviteTokens = tokens
+		uc.inviteNotifier = notifier
+	}
+}
+
+// CreateUserInput is an account created by an administrator.
+type CreateUserInput struct {
+	Email string
+	// Password is ignored when Invite is set.
+	Password    string
+	Name        string
+	Phone       string
+	CompanyCode string
+	// Roles are normalized with entity.ParseRoles; empty means
+	// entity.DefaultRoles.
+	Roles []string
+	// Status is an entity.UserStatus; empty means active.
+	Status string
+	// Invite creates the account with a random password nobody knows and
+	// sends the user a token to choose their own.
+	Invite bool
+}
+
+func (uc *useCase) CreateUser(ctx context.Context, input CreateUserInput) (*entity.User, error) {
+	user, err := uc.newUser(input)
+	if err != nil {
+		return nil, err
+	}
+	password := input.Password
+	if input.Invite {
+		if password, err = unusablePassword(); err != nil {
+			return nil, err
+		}
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	user.Password = string(hashedPassword)
+
+	err = uc.tx.Do(ctx, func(ctx context.Context) error {
+		existing, err := uc.userRepo.FindByEmail(ctx, input.Email)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+		if existing != nil {
+			return ErrEmailExists
+		}
+		if err := uc.checkCompanyLimit(ctx, input.CompanyCode); err != nil {
+			return err
+		}
+		if err := uc.userRepo.Create(ctx, user); err != nil {
+			if errors.Is(err, user_repository.ErrDuplicateEmail) {
+				return ErrEmailExists
+			}
+			return err
+		}
+		if err := uc.audit(ctx, entity.AuditActionCreate, nil, user); err != nil {
+			return err
+		}
+		if err := uc.stage(ctx, notify.UserRegistered, user); err != nil {
+			return err
+		}
+		// Inside the transaction, so an invitation that cannot be sent
+		// leaves no account its user could never get into.
+		if input.Invite {
+			return uc.invite(ctx, user)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	uc.publish(ctx, notify.UserRegistered, user)
+	return user, nil
+}
+
+// newUser builds the account input describes, without its password.
+func (uc *useCase) newUser(input CreateUserInput) (*entity.User, error) {
+	roles := entity.DefaultRoles
+	if len(input.Roles) > 0 {
+		var err error
+		if roles, err = entity.ParseRoles(input.Roles); err != nil {
+			return nil, err
+		}
+	}
+	status := entity.UserStatusActive
+	if input.Status != "" {
+		status = entity.UserStatus(input.Status)
+		if !status.Valid() {
+			return nil, ErrInvalidStatus
+		}
+	}
+	if input.Invite && (uc.inviteTokens == nil || uc.inviteNotifier == nil) {
+		return nil, ErrInvitesUnavailable
+	}
+	return &entity.User{
+		Email:       input.Email,
+		Name:        input.Name,
+		Phone:       input.Phone,
+		Status:      status,
+		Roles:       pq.StringArray(roles),
+		CompanyCode: input.CompanyCode,
+	}, nil
+}
+
+// invite issues user an invitation token and announces it.
+func (uc *useCase) invite(ctx context.Context, user *entity.User) error {
+	token, expiresAt, err := uc.inviteTokens.Issue(ctx, user.ID)
+	if err != nil {
+		if errors.Is(err, passwordreset.ErrUnavailable) {
+			return ErrInvitesUnavailable
+		}
+		return err
+	}
+	return uc.inviteNotifier.UserInvited(ctx, passwordreset.Invited{
+		UserID:    user.ID,
+		Email:     user.Email,
+		Name:      user.Name,
+		Token:     token,
+		ExpiresAt: expiresAt.UTC(),
+	})
+}
+
+// unusablePassword returns a random password for an invited account; it is
+// never shown to anyone, so the account cannot be signed into until its user
+// redeems the invitation.
+func unusablePassword() (string, error) {
+	// bcrypt reads at most 72 bytes; 48 random bytes encode to 64.
+	b := make([]byte, 48)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
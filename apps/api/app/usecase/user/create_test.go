@@ -0,0 +1,156 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"veemon/entity"
+	"veemon/internal/fixtures"
+	"veemon/pkg/passwordreset"
+	"veemon/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fakeInvites is an in-memory invitation store and InviteNotifier.
+type fakeInvites struct {
+	fakeResets
+	invited   []passwordreset.Invited
+	notifyErr error
+}
+
+func (f *fakeInvites) UserInvited(_ context.Context, inv passwordreset.Invited) error {
+	if f.notifyErr != nil {
+		return f.notifyErr
+	}
+	f.invited = append(f.invited, inv)
+	return nil
+}
+
+// assignID makes a mocked Create behave like the database, which assigns
+// the ID.
+func assignID(id string) func(mock.Arguments) {
+	return func(args mock.Arguments) { args.Get(1).(*entity.User).ID = id }
+}
+
+func TestCreateUser_Defaults(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := NewUseCase(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("FindByEmail", ctx, "new@example.com").Return(nil, repository.ErrNotFound)
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*entity.User")).Return(nil).Run(assignID("u1"))
+
+	got, err := uc.CreateUser(ctx, CreateUserInput{Email: "new@example.com", Password: "Password123", Name: "New"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "u1", got.ID)
+	assert.Equal(t, entity.UserStatusActive, got.Status)
+	assert.Equal(t, []string(entity.DefaultRoles), []string(got.Roles))
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(got.Password), []byte("Password123")))
+}
+
+func TestCreateUser_RolesAndStatus(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := NewUseCase(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("FindByEmail", ctx, "new@example.com").Return(nil, repository.ErrNotFound)
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*entity.User")).Return(nil)
+
+	got, err := uc.CreateUser(ctx, CreateUserInput{
+		Email:       "new@example.com",
+		Password:    "Password123",
+		Name:        "New",
+		Roles:       []string{" Auditor", "user", "auditor"},
+		Status:      "pending",
+		CompanyCode: "ACME",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"auditor", "user"}, []string(got.Roles))
+	assert.Equal(t, entity.UserStatusPending, got.Status)
+	assert.Equal(t, "ACME", got.CompanyCode)
+}
+
+func TestCreateUser_RejectsInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   CreateUserInput
+		wantErr error
+	}{
+		{"bad role", CreateUserInput{Roles: []string{"not a role"}}, entity.ErrInvalidRoles},
+		{"bad status", CreateUserInput{Status: "banned"}, ErrInvalidStatus},
+		{"invite without WithInvites", CreateUserInput{Invite: true}, ErrInvitesUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			uc := NewUseCase(mockRepo)
+			tt.input.Email, tt.input.Password, tt.input.Name = "new@example.com", "Password123", "New"
+
+			_, err := uc.CreateUser(context.Background(), tt.input)
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestCreateUser_EmailExists(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := NewUseCase(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("FindByEmail", ctx, "taken@example.com").Return(fixtures.User().WithEmail("taken@example.com").Build(), nil)
+
+	_, err := uc.CreateUser(ctx, CreateUserInput{Email: "taken@example.com", Password: "Password123", Name: "New"})
+
+	assert.ErrorIs(t, err, ErrEmailExists)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCreateUser_Invite(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	invites := &fakeInvites{fakeResets: fakeResets{tokens: map[string]string{}}}
+	uc := NewUseCase(mockRepo, WithInvites(invites, invites))
+	ctx := context.Background()
+
+	mockRepo.On("FindByEmail", ctx, "invited@example.com").Return(nil, repository.ErrNotFound)
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*entity.User")).Return(nil).Run(assignID("u1"))
+
+	got, err := uc.CreateUser(ctx, CreateUserInput{Email: "invited@example.com", Password: "Password123", Name: "Invited", Invite: true})
+
+	require.NoError(t, err)
+	// The password sent alongside an invitation is not used.
+	assert.Error(t, bcrypt.CompareHashAndPassword([]byte(got.Password), []byte("Password123")))
+	require.Len(t, invites.invited, 1)
+	inv := invites.invited[0]
+	assert.Equal(t, "u1", inv.UserID)
+	assert.Equal(t, "invited@example.com", inv.Email)
+	assert.Equal(t, "u1", invites.tokens[inv.Token])
+	assert.Equal(t, time.UTC, inv.ExpiresAt.Location())
+}
+
+// An invitation that cannot be announced fails the transaction the account
+// was created in.
+func TestCreateUser_InviteFailureFailsTransaction(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	tx := &fakeTx{}
+	invites := &fakeInvites{fakeResets: fakeResets{tokens: map[string]string{}}, notifyErr: errors.New("broker down")}
+	uc := NewUseCase(mockRepo, WithTransactor(tx), WithInvites(invites, invites))
+	ctx := context.Background()
+
+	mockRepo.On("FindByEmail", inTx, "invited@example.com").Return(nil, repository.ErrNotFound)
+	mockRepo.On("Create", inTx, mock.AnythingOfType("*entity.User")).Return(nil).Run(assignID("u1"))
+
+	_, err := uc.CreateUser(ctx, CreateUserInput{Email: "invited@example.com", Name: "Invited", Invite: true})
+
+	assert.EqualError(t, err, "broker down")
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,51 @@
+package user
+
+import (
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"veemon/entity"
+	"veemon/repository/user_repository"
+)
+
+// encodeCursor returns the opaque cursor for the position of u in
+// (created_at, id) order.
+func encodeCursor(u *entity.UserSummary) string {
+	raw := u.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + u.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeCursor parses a cursor made by encodeCursor.
+func decodeCursor(s string) (*user_repository.Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	ts, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return nil, ErrInvalidCursor
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &user_repository.Cursor{CreatedAt: createdAt, ID: id}, nil
+}
+
+// keysetSort reports whether a page sorted by sortBy can be continued by a
+// cursor.
+func keysetSort(sortBy string) bool {
+	return sortBy == "created_at"
+}
+
+// nextCursor returns the cursor continuing after users, a page ListAll
+// read with one probe row beyond size, and the page without the probe row.
+// The cursor is empty when no probe row came back (nothing follows).
+func nextCursor(size int, users []entity.UserSummary) (string, []entity.UserSummary) {
+	if len(users) <= size {
+		return "", users
+	}
+	page := users[:size]
+	return encodeCursor(&page[size-1]), page
+}
@@ -0,0 +1,103 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"veemon/entity"
+	"veemon/repository"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DefaultDeletionGracePeriod is how long a self-service deletion can be
+// cancelled before the account is purged.
+const DefaultDeletionGracePeriod = 14 * 24 * time.Hour
+
+// deletionDue returns when an account whose deletion was requested at
+// requestedAt is purged.
+func (uc *useCase) deletionDue(requestedAt time.Time) time.Time {
+	return requestedAt.Add(uc.deletionGrace)
+}
+
+func (uc *useCase) RequestDeletion(ctx context.Context, userID string) (time.Time, error) {
+	var due time.Time
+	err := uc.tx.Do(ctx, func(ctx context.Context) error {
+		user, err := uc.userRepo.FindByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		// A repeated request keeps the original schedule rather than pushing
+		// the purge further out.
+		if user.DeletionRequestedAt != nil {
+			due = uc.deletionDue(*user.DeletionRequestedAt)
+			return nil
+		}
+		// CancelDeletion reactivates the account, so only an active one may
+		// schedule its deletion: a deactivated user must not be able to
+		// reactivate themselves through the round trip.
+		if user.Status != entity.UserStatusActive {
+			return ErrUserNotActive
+		}
+
+		requestedAt := uc.now().UTC()
+		if _, err := uc.userRepo.UpdateFields(ctx, userID, map[string]interface{}{
+			"status":                entity.UserStatusInactive,
+			"deletion_requested_at": requestedAt,
+		}, nil); err != nil {
+			return err
+		}
+		due = uc.deletionDue(requestedAt)
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return time.Time{}, ErrNotFound
+		}
+		return time.Time{}, err
+	}
+	return due, nil
+}
+
+func (uc *useCase) CancelDeletion(ctx context.Context, email, password string) (*entity.User, error) {
+	user, err := uc.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			// Same timing equalization as Login.
+			_ = bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+			return nil, ErrInvalidCreds
+		}
+		return nil, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return nil, ErrInvalidCreds
+	}
+
+	// Once the grace period is over the account is as good as purged, even
+	// if the purge job has not reached it yet.
+	if user.DeletionRequestedAt == nil || !uc.now().Before(uc.deletionDue(*user.DeletionRequestedAt)) {
+		return nil, ErrNoDeletionPending
+	}
+
+	var updated *entity.User
+	err = uc.tx.Do(ctx, func(ctx context.Context) error {
+		var err error
+		updated, err = uc.userRepo.UpdateFields(ctx, user.ID, map[string]interface{}{
+			"status":                entity.UserStatusActive,
+			"deletion_requested_at": nil,
+		}, nil)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (uc *useCase) PurgeExpiredDeletions(ctx context.Context, now time.Time) (int64, error) {
+	return uc.userRepo.PurgeDeletionsRequestedBefore(ctx, now.Add(-uc.deletionGrace))
+}
@@ -0,0 +1,166 @@
+package user
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"veemon/entity"
+	"veemon/internal/fixtures"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var deletionNow = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// newDeletionUseCase returns a usecase with a 14-day grace period whose clock
+// reads deletionNow.
+func newDeletionUseCase(repo *MockUserRepository) *useCase {
+	uc := NewUseCase(repo, WithDeletionGracePeriod(14*24*time.Hour)).(*useCase)
+	uc.now = func() time.Time { return deletionNow }
+	return uc
+}
+
+func pendingUser(requestedAt time.Time) *entity.User {
+	return fixtures.User().WithID("u1").WithEmail("leaving@example.com").DeletionRequested(requestedAt).Build()
+}
+
+func TestRequestDeletion_DeactivatesAndSchedulesPurge(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := newDeletionUseCase(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("FindByID", ctx, "u1").Return(&entity.User{ID: "u1", Status: entity.UserStatusActive}, nil)
+	mockRepo.On("UpdateFields", ctx, "u1", map[string]interface{}{
+		"status":                entity.UserStatusInactive,
+		"deletion_requested_at": deletionNow,
+	}, (*time.Time)(nil)).Return(&entity.User{ID: "u1"}, nil)
+
+	due, err := uc.RequestDeletion(ctx, "u1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC), due)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRequestDeletion_RepeatKeepsOriginalSchedule(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := newDeletionUseCase(mockRepo)
+	ctx := context.Background()
+	requestedAt := deletionNow.Add(-3 * 24 * time.Hour)
+
+	mockRepo.On("FindByID", ctx, "u1").Return(pendingUser(requestedAt), nil)
+
+	due, err := uc.RequestDeletion(ctx, "u1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, requestedAt.Add(14*24*time.Hour), due)
+	mockRepo.AssertNotCalled(t, "UpdateFields", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// A deactivated account cannot schedule its deletion, so it cannot cancel
+// one to come back active.
+func TestRequestDeletion_InactiveAccountCannotReactivateItself(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := newDeletionUseCase(mockRepo)
+	ctx := context.Background()
+
+	deactivated := fixtures.User().WithID("u1").WithEmail("leaving@example.com").WithStatus(entity.UserStatusInactive).Build()
+	mockRepo.On("FindByID", ctx, "u1").Return(deactivated, nil)
+	mockRepo.On("FindByEmail", ctx, "leaving@example.com").Return(deactivated, nil)
+
+	_, err := uc.RequestDeletion(ctx, "u1")
+	assert.ErrorIs(t, err, ErrUserNotActive)
+
+	user, err := uc.CancelDeletion(ctx, "leaving@example.com", fixtures.Password)
+	assert.ErrorIs(t, err, ErrNoDeletionPending)
+	assert.Nil(t, user)
+	mockRepo.AssertNotCalled(t, "UpdateFields", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCancelDeletion_GracePeriod(t *testing.T) {
+	grace := 14 * 24 * time.Hour
+	tests := []struct {
+		name        string
+		requestedAt time.Time
+		wantErr     error
+	}{
+		{"just requested", deletionNow, nil},
+		{"one second before the deadline", deletionNow.Add(-grace + time.Second), nil},
+		{"at the deadline", deletionNow.Add(-grace), ErrNoDeletionPending},
+		{"past the deadline", deletionNow.Add(-grace - time.Hour), ErrNoDeletionPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			uc := newDeletionUseCase(mockRepo)
+			ctx := context.Background()
+
+			mockRepo.On("FindByEmail", ctx, "leaving@example.com").Return(pendingUser(tt.requestedAt), nil)
+			if tt.wantErr == nil {
+				mockRepo.On("UpdateFields", ctx, "u1", map[string]interface{}{
+					"status":                entity.UserStatusActive,
+					"deletion_requested_at": nil,
+				}, (*time.Time)(nil)).Return(&entity.User{ID: "u1", Status: entity.UserStatusActive}, nil)
+			}
+
+			user, err := uc.CancelDeletion(ctx, "leaving@example.com", fixtures.Password)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				mockRepo.AssertNotCalled(t, "UpdateFields", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, entity.UserStatusActive, user.Status)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCancelDeletion_RejectsBadCredentialsAndNoPendingDeletion(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := newDeletionUseCase(mockRepo)
+	ctx := context.Background()
+
+	notPending := pendingUser(deletionNow)
+	notPending.Email, notPending.DeletionRequestedAt = "staying@example.com", nil
+	mockRepo.On("FindByEmail", ctx, "leaving@example.com").Return(pendingUser(deletionNow), nil)
+	mockRepo.On("FindByEmail", ctx, "staying@example.com").Return(notPending, nil)
+
+	_, err := uc.CancelDeletion(ctx, "leaving@example.com", "WrongPassword1")
+	assert.ErrorIs(t, err, ErrInvalidCreds)
+
+	_, err = uc.CancelDeletion(ctx, "staying@example.com", fixtures.Password)
+	assert.ErrorIs(t, err, ErrNoDeletionPending)
+
+	mockRepo.AssertNotCalled(t, "UpdateFields", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestLogin_PendingDeletionRejected(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := newDeletionUseCase(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("FindByEmail", ctx, "leaving@example.com").Return(pendingUser(deletionNow), nil)
+
+	_, err := uc.Login(ctx, "leaving@example.com", fixtures.Password)
+
+	assert.ErrorIs(t, err, ErrDeletionPending)
+}
+
+func TestPurgeExpiredDeletions_CutoffIsGracePeriodAgo(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := newDeletionUseCase(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("PurgeDeletionsRequestedBefore", ctx, time.Date(2026, 2, 15, 12, 0, 0, 0, time.UTC)).Return(int64(3), nil)
+
+	n, err := uc.PurgeExpiredDeletions(ctx, deletionNow)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,29 @@
+package user
+
+import (
+	"context"
+
+	"veemon/entity"
+	"veemon/repository/user_repository"
+)
+
+// ExportBatchSize is how many users ExportAll reads per query.
+const ExportBatchSize = 500
+
+// ExportAll walks every page ListAll would return for input, sorted and
+// filtered the same way, without offsets or a total count; Page, Size and
+// Cursor are ignored. Only one batch is held at a time, so the caller can
+// stream an export of any size.
+func (uc *useCase) ExportAll(ctx context.Context, input ListInput, fn func([]entity.UserSummary) error) error {
+	if err := ValidateSort(input.SortBy, input.SortOrder); err != nil {
+		return err
+	}
+	eff := EffectiveParams(input)
+	return uc.userRepo.FindAllInBatches(ctx, user_repository.ListParams{
+		Search:         input.Search,
+		SortBy:         eff.SortBy,
+		SortOrder:      eff.SortOrder,
+		Columns:        input.Columns,
+		IncludeDeleted: input.IncludeDeleted,
+	}, ExportBatchSize, fn)
+}
@@ -0,0 +1,66 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"veemon/entity"
+	"veemon/repository/user_repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportAll_AppliesTheListFilters(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := NewUseCase(mockRepo)
+	ctx := context.Background()
+
+	// An empty sort column defaults as in ListAll; paging is ignored.
+	mockRepo.On("FindAllInBatches", ctx, user_repository.ListParams{
+		Search: "ann", SortBy: DefaultSortBy, SortOrder: "asc", Columns: []string{"email"}, IncludeDeleted: true,
+	}, ExportBatchSize).Return([][]entity.UserSummary{{{ID: "u1"}, {ID: "u2"}}, {{ID: "u3"}}}, nil)
+
+	var got []string
+	err := uc.ExportAll(ctx, ListInput{Page: 7, Size: 3, Search: "ann", SortOrder: "asc", Columns: []string{"email"}, IncludeDeleted: true},
+		func(batch []entity.UserSummary) error {
+			for _, u := range batch {
+				got = append(got, u.ID)
+			}
+			return nil
+		})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"u1", "u2", "u3"}, got)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestExportAll_RejectsAnUnknownSort(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := NewUseCase(mockRepo)
+
+	err := uc.ExportAll(context.Background(), ListInput{SortBy: "password"}, func([]entity.UserSummary) error { return nil })
+
+	assert.ErrorIs(t, err, ErrInvalidSortField)
+	mockRepo.AssertNotCalled(t, "FindAllInBatches")
+}
+
+func TestExportAll_StopsAtTheCallersError(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := NewUseCase(mockRepo)
+	ctx := context.Background()
+	errGone := errors.New("client went away")
+
+	mockRepo.On("FindAllInBatches", ctx, user_repository.ListParams{SortBy: DefaultSortBy, SortOrder: DefaultSortOrder}, ExportBatchSize).
+		Return([][]entity.UserSummary{{{ID: "u1"}}, {{ID: "u2"}}}, nil)
+
+	calls := 0
+	err := uc.ExportAll(ctx, ListInput{}, func([]entity.UserSummary) error {
+		calls++
+		return errGone
+	})
+
+	assert.ErrorIs(t, err, errGone)
+	assert.Equal(t, 1, calls)
+}
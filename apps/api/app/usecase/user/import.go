@@ -0,0 +1,243 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"slices"
+	"sort"
+	"sync"
+
+	"veemon/entity"
+	"veemon/pkg/notify"
+	"veemon/repository/user_repository"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ImportStatus is the outcome of one imported row.
+type ImportStatus string
+
+const (
+	ImportCreated ImportStatus = "created"
+	// ImportSkippedDuplicate: the email belongs to a live account or to an
+	// earlier row of the same batch.
+	ImportSkippedDuplicate ImportStatus = "skipped_duplicate"
+	ImportFailed           ImportStatus = "error"
+)
+
+// ImportRow is one row of a bulk import. Row is its position in the source
+// (a CSV line number), echoed in its result.
+type ImportRow struct {
+	Row int
+	CreateUserInput
+}
+
+// ImportResult is what ImportUsers did with an ImportRow.
+type ImportResult struct {
+	Row    int
+	Email  string
+	Status ImportStatus
+	// UserID is the created account's ID.
+	UserID string
+	// Err says why a row was skipped or failed: ErrEmailExists,
+	// ErrInvalidStatus, ErrInvitesUnavailable, an error wrapping
+	// entity.ErrInvalidRoles, or an *entity.CompanyUserLimitError.
+	Err error
+}
+
+// ImportUsers creates the accounts of rows, as CreateUser would, in one
+// transaction. Rows whose email is taken are skipped and rows CreateUser
+// would refuse fail, without affecting the others; the returned error is
+// for the batch as a whole, none of which was then created.
+func (uc *useCase) ImportUsers(ctx context.Context, rows []ImportRow) ([]ImportResult, error) {
+	results := make([]ImportResult, len(rows))
+	pending := make([]*entity.User, len(rows))
+	passwords := make([]string, len(rows))
+	for i, row := range rows {
+		results[i] = ImportResult{Row: row.Row, Email: row.Email}
+		user, err := uc.newUser(row.CreateUserInput)
+		if err != nil {
+			results[i].Status, results[i].Err = ImportFailed, err
+			continue
+		}
+		password := row.Password
+		if row.Invite {
+			if password, err = unusablePassword(); err != nil {
+				return nil, err
+			}
+		}
+		pending[i], passwords[i] = user, password
+	}
+	if err := hashPasswords(pending, passwords); err != nil {
+		return nil, err
+	}
+
+	var created []*entity.User
+	var err error
+	// A registration committing between the email check and the insert
+	// fails the whole batch; the second attempt sees it and skips the row.
+	for attempt := 0; attempt < 2; attempt++ {
+		created, err = uc.importBatch(ctx, rows, pending, results)
+		if !errors.Is(err, ErrEmailExists) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range created {
+		uc.publish(ctx, notify.UserRegistered, user)
+	}
+	return results, nil
+}
+
+// hashPasswords sets the Password of each non-nil user to the bcrypt hash of
+// the matching password, hashing on every CPU: at the default cost one hash
+// takes tens of milliseconds, which a batch would otherwise pay in series.
+func hashPasswords(users []*entity.User, passwords []string) error {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+	sem := make(chan struct{}, runtime.GOMAXPROCS(0))
+	for i, user := range users {
+		if user == nil {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			hash, err := bcrypt.GenerateFromPassword([]byte(passwords[i]), bcrypt.DefaultCost)
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+				return
+			}
+			user.Password = string(hash)
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// importBatch runs one attempt at the batch's transaction, filling in the
+// results of the rows it reaches and returning the users it created.
+func (uc *useCase) importBatch(ctx context.Context, rows []ImportRow, pending []*entity.User, results []ImportResult) ([]*entity.User, error) {
+	var created []*entity.User
+	if !slices.ContainsFunc(pending, func(u *entity.User) bool { return u != nil }) {
+		return nil, nil
+	}
+	err := uc.tx.Do(ctx, func(ctx context.Context) error {
+		created = nil
+		emails := make([]string, 0, len(pending))
+		for i, user := range pending {
+			if user != nil {
+				results[i].Status, results[i].UserID, results[i].Err = "", "", nil
+				emails = append(emails, user.Email)
+			}
+		}
+		taken, err := uc.userRepo.FindExistingEmails(ctx, emails)
+		if err != nil {
+			return err
+		}
+		seen := make(map[string]bool, len(emails))
+		for _, email := range taken {
+			seen[email] = true
+		}
+
+		var accepted []int
+		for i, user := range pending {
+			if user == nil {
+				continue
+			}
+			if seen[user.Email] {
+				results[i].Status, results[i].Err = ImportSkippedDuplicate, ErrEmailExists
+				continue
+			}
+			seen[user.Email] = true
+			accepted = append(accepted, i)
+		}
+		if accepted, err = uc.withinCompanyLimits(ctx, pending, accepted, results); err != nil {
+			return err
+		}
+		if len(accepted) == 0 {
+			return nil
+		}
+
+		users := make([]*entity.User, len(accepted))
+		for j, i := range accepted {
+			users[j] = pending[i]
+		}
+		if err := uc.userRepo.CreateBatch(ctx, users); err != nil {
+			if errors.Is(err, user_repository.ErrDuplicateEmail) {
+				return ErrEmailExists
+			}
+			return err
+		}
+		for _, i := range accepted {
+			user := pending[i]
+			if err := uc.audit(ctx, entity.AuditActionCreate, nil, user); err != nil {
+				return err
+			}
+			if err := uc.stage(ctx, notify.UserRegistered, user); err != nil {
+				return err
+			}
+			if rows[i].Invite {
+				if err := uc.invite(ctx, user); err != nil {
+					return err
+				}
+			}
+			results[i].Status, results[i].UserID, results[i].Err = ImportCreated, user.ID, nil
+		}
+		created = users
+		return nil
+	})
+	return created, err
+}
+
+// withinCompanyLimits returns the accepted rows that fit under the company
+// user limit, failing the rest. It locks each company's count as
+// checkCompanyLimit does, in sorted order so concurrent imports cannot
+// deadlock.
+func (uc *useCase) withinCompanyLimits(ctx context.Context, pending []*entity.User, accepted []int, results []ImportResult) ([]int, error) {
+	if uc.companyUserLimit <= 0 {
+		return accepted, nil
+	}
+	counts := make(map[string]int64)
+	for _, i := range accepted {
+		if code := pending[i].CompanyCode; code != "" {
+			counts[code] = 0
+		}
+	}
+	codes := make([]string, 0, len(counts))
+	for code := range counts {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		n, err := uc.userRepo.LockCompanyUsers(ctx, code)
+		if err != nil {
+			return nil, err
+		}
+		counts[code] = n
+	}
+
+	fits := make([]int, 0, len(accepted))
+	for _, i := range accepted {
+		code := pending[i].CompanyCode
+		if code != "" {
+			if counts[code] >= int64(uc.companyUserLimit) {
+				results[i].Status = ImportFailed
+				results[i].Err = &entity.CompanyUserLimitError{CompanyCode: code, Limit: uc.companyUserLimit}
+				continue
+			}
+			counts[code]++
+		}
+		fits = append(fits, i)
+	}
+	return fits, nil
+}
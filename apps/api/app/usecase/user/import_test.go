@@ -0,0 +1,141 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"veemon/entity"
+	"veemon/repository/user_repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func importRows(inputs ...CreateUserInput) []ImportRow {
+	rows := make([]ImportRow, len(inputs))
+	for i, in := range inputs {
+		rows[i] = ImportRow{Row: i + 2, CreateUserInput: in}
+	}
+	return rows
+}
+
+// assignIDs makes a mocked CreateBatch behave like the database.
+func assignIDs(args mock.Arguments) {
+	for i, u := range args.Get(1).([]*entity.User) {
+		u.ID = "u" + string(rune('1'+i))
+	}
+}
+
+func TestImportUsers_ReportsEachRow(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := NewUseCase(mockRepo, WithTransactor(&fakeTx{}))
+	ctx := context.Background()
+
+	mockRepo.On("FindExistingEmails", inTx, []string{"a@example.com", "taken@example.com", "a@example.com"}).
+		Return([]string{"taken@example.com"}, nil)
+	var created []*entity.User
+	mockRepo.On("CreateBatch", inTx, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		assignIDs(args)
+		created = args.Get(1).([]*entity.User)
+	})
+
+	results, err := uc.ImportUsers(ctx, importRows(
+		CreateUserInput{Email: "a@example.com", Password: "Password123", Name: "A", Roles: []string{"Auditor"}},
+		CreateUserInput{Email: "taken@example.com", Password: "Password123", Name: "Taken"},
+		CreateUserInput{Email: "bad@example.com", Password: "Password123", Name: "Bad", Status: "archived"},
+		CreateUserInput{Email: "a@example.com", Password: "Password123", Name: "A again"},
+	))
+
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+	assert.Equal(t, ImportResult{Row: 2, Email: "a@example.com", Status: ImportCreated, UserID: "u1"}, results[0])
+	assert.Equal(t, ImportSkippedDuplicate, results[1].Status)
+	assert.ErrorIs(t, results[1].Err, ErrEmailExists)
+	assert.Equal(t, ImportFailed, results[2].Status)
+	assert.ErrorIs(t, results[2].Err, ErrInvalidStatus)
+	assert.Equal(t, ImportSkippedDuplicate, results[3].Status, "second row with the same email")
+
+	require.Len(t, created, 1)
+	assert.Equal(t, []string{"auditor"}, []string(created[0].Roles))
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(created[0].Password), []byte("Password123")))
+}
+
+func TestImportUsers_CompanyLimit(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := NewUseCase(mockRepo, WithTransactor(&fakeTx{}), WithCompanyUserLimit(3))
+	ctx := context.Background()
+
+	mockRepo.On("FindExistingEmails", inTx, mock.Anything).Return([]string{}, nil)
+	mockRepo.On("LockCompanyUsers", inTx, "ACME").Return(int64(2), nil)
+	mockRepo.On("CreateBatch", inTx, mock.MatchedBy(func(users []*entity.User) bool { return len(users) == 2 })).
+		Return(nil).Run(assignIDs)
+
+	results, err := uc.ImportUsers(ctx, importRows(
+		CreateUserInput{Email: "1@example.com", Password: "Password123", Name: "One", CompanyCode: "ACME"},
+		CreateUserInput{Email: "2@example.com", Password: "Password123", Name: "Two", CompanyCode: "ACME"},
+		CreateUserInput{Email: "3@example.com", Password: "Password123", Name: "Three"},
+	))
+
+	require.NoError(t, err)
+	assert.Equal(t, ImportCreated, results[0].Status)
+	var limitErr *entity.CompanyUserLimitError
+	assert.Equal(t, ImportFailed, results[1].Status)
+	assert.ErrorAs(t, results[1].Err, &limitErr)
+	assert.Equal(t, ImportCreated, results[2].Status, "no company, no limit")
+	mockRepo.AssertExpectations(t)
+}
+
+// An email registered between the check and the insert fails the batch's
+// INSERT; the retry skips it and creates the rest.
+func TestImportUsers_RetriesAfterAConcurrentRegistration(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	tx := &fakeTx{}
+	uc := NewUseCase(mockRepo, WithTransactor(tx))
+	ctx := context.Background()
+
+	mockRepo.On("FindExistingEmails", inTx, mock.Anything).Return([]string{}, nil).Once()
+	mockRepo.On("CreateBatch", inTx, mock.Anything).Return(user_repository.ErrDuplicateEmail).Once()
+	mockRepo.On("FindExistingEmails", inTx, mock.Anything).Return([]string{"b@example.com"}, nil).Once()
+	mockRepo.On("CreateBatch", inTx, mock.MatchedBy(func(users []*entity.User) bool { return len(users) == 1 })).
+		Return(nil).Run(assignIDs).Once()
+
+	results, err := uc.ImportUsers(ctx, importRows(
+		CreateUserInput{Email: "a@example.com", Password: "Password123", Name: "A"},
+		CreateUserInput{Email: "b@example.com", Password: "Password123", Name: "B"},
+	))
+
+	require.NoError(t, err)
+	assert.Equal(t, ImportCreated, results[0].Status)
+	assert.Equal(t, ImportSkippedDuplicate, results[1].Status)
+	assert.Equal(t, 2, tx.calls)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestImportUsers_BatchFailure(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := NewUseCase(mockRepo, WithTransactor(&fakeTx{}))
+	ctx := context.Background()
+
+	mockRepo.On("FindExistingEmails", inTx, mock.Anything).Return(nil, errors.New("connection reset"))
+
+	_, err := uc.ImportUsers(ctx, importRows(CreateUserInput{Email: "a@example.com", Password: "Password123", Name: "A"}))
+
+	assert.EqualError(t, err, "connection reset")
+	mockRepo.AssertNotCalled(t, "CreateBatch", mock.Anything, mock.Anything)
+}
+
+func TestImportUsers_InvitesNeedAStore(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := NewUseCase(mockRepo)
+	ctx := context.Background()
+
+	results, err := uc.ImportUsers(ctx, importRows(CreateUserInput{Email: "a@example.com", Name: "A", Invite: true}))
+
+	require.NoError(t, err)
+	assert.Equal(t, ImportFailed, results[0].Status)
+	assert.ErrorIs(t, results[0].Err, ErrInvitesUnavailable)
+	mockRepo.AssertNotCalled(t, "CreateBatch", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,35 @@
+package user
+
+import (
+	"context"
+
+	"veemon/entity"
+	"veemon/pkg/userevents"
+)
+
+// EventOutbox stages user events for a relay to publish.
+// *userevents.Outbox satisfies it.
+type EventOutbox interface {
+	Enqueue(ctx context.Context, e userevents.Event) error
+}
+
+// WithEventOutbox makes Register, CreateUser, UpdateUser, DeleteUser,
+// RestoreUser and PurgeUser stage their userevents.Event in o, inside the
+// transaction that makes the change, instead of publishing it through
+// WithDomainEvents after the commit. The event then survives a crash between
+// the two. Login records no change, so its event is still published
+// directly.
+func WithEventOutbox(o EventOutbox) Option {
+	return func(uc *useCase) {
+		uc.outbox = o
+	}
+}
+
+// stage enqueues the user event in the outbox. Without WithEventOutbox it
+// does nothing.
+func (uc *useCase) stage(ctx context.Context, eventType string, user *entity.User) error {
+	if uc.outbox == nil {
+		return nil
+	}
+	return uc.outbox.Enqueue(ctx, uc.domainEvent(eventType, user))
+}
@@ -0,0 +1,133 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"veemon/entity"
+	"veemon/pkg/passwordreset"
+	"veemon/repository"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrInvalidResetToken is returned by ResetPassword for a token that was
+	// never issued, has expired or was already used.
+	ErrInvalidResetToken = passwordreset.ErrInvalidToken
+	// ErrPasswordResetUnavailable is returned by RequestPasswordReset and
+	// ResetPassword without WithPasswordReset, or when its store has no
+	// Redis.
+	ErrPasswordResetUnavailable = passwordreset.ErrUnavailable
+)
+
+// ResetTokenStore issues and redeems single-use password reset tokens.
+// *passwordreset.Store satisfies it.
+type ResetTokenStore interface {
+	Issue(ctx context.Context, userID string) (string, time.Time, error)
+	// Consume returns passwordreset.ErrInvalidToken for a token it cannot
+	// redeem.
+	Consume(ctx context.Context, token string) (string, error)
+}
+
+// ResetNotifier announces password reset requests so the user can be sent
+// their token. *passwordreset.Notifier satisfies it.
+type ResetNotifier interface {
+	PasswordResetRequested(ctx context.Context, req passwordreset.Requested) error
+}
+
+// WithPasswordReset enables RequestPasswordReset and ResetPassword, keeping
+// tokens in tokens and announcing requests through notifier.
+func WithPasswordReset(tokens ResetTokenStore, notifier ResetNotifier) Option {
+	return func(uc *useCase) {
+		uc.resetTokens = tokens
+		uc.resetNotifier = notifier
+	}
+}
+
+func (uc *useCase) ChangePassword(ctx context.Context, userID, currentPassword, newPassword string) error {
+	user, err := uc.userRepo.FindCredentialsByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrNotFound
+		}
+		return err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(currentPassword)); err != nil {
+		return ErrInvalidCreds
+	}
+	return uc.setPassword(ctx, userID, newPassword)
+}
+
+func (uc *useCase) RequestPasswordReset(ctx context.Context, email string) error {
+	if uc.resetTokens == nil || uc.resetNotifier == nil {
+		return ErrPasswordResetUnavailable
+	}
+	user, err := uc.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	// Only accounts that could sign in afterwards get a token; the others
+	// are answered the same way so the endpoint reveals nothing.
+	if user.Status != entity.UserStatusActive {
+		return nil
+	}
+
+	token, expiresAt, err := uc.resetTokens.Issue(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	return uc.resetNotifier.PasswordResetRequested(ctx, passwordreset.Requested{
+		UserID:    user.ID,
+		Email:     user.Email,
+		Name:      user.Name,
+		Token:     token,
+		ExpiresAt: expiresAt.UTC(),
+	})
+}
+
+func (uc *useCase) ResetPassword(ctx context.Context, token, newPassword string) (*entity.User, error) {
+	if uc.resetTokens == nil {
+		return nil, ErrPasswordResetUnavailable
+	}
+	userID, err := uc.resetTokens.Consume(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	user, err := uc.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		// Deleted since the token was issued.
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidResetToken
+		}
+		return nil, err
+	}
+	if err := uc.setPassword(ctx, userID, newPassword); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, ErrInvalidResetToken
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// setPassword stores a bcrypt hash of password for userID.
+func (uc *useCase) setPassword(ctx context.Context, userID, password string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	if _, err := uc.userRepo.UpdateFields(ctx, userID, map[string]interface{}{
+		"password": string(hashedPassword),
+	}, nil); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
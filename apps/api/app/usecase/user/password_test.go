@@ -0,0 +1,152 @@
+package user
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"veemon/entity"
+	"veemon/internal/fixtures"
+	"veemon/pkg/passwordreset"
+	"veemon/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fakeResets is an in-memory ResetTokenStore and ResetNotifier.
+type fakeResets struct {
+	tokens    map[string]string
+	requested []passwordreset.Requested
+}
+
+func (f *fakeResets) Issue(_ context.Context, userID string) (string, time.Time, error) {
+	token := "token-" + userID
+	f.tokens[token] = userID
+	return token, time.Date(2026, 3, 1, 12, 30, 0, 0, time.UTC), nil
+}
+
+func (f *fakeResets) Consume(_ context.Context, token string) (string, error) {
+	userID, ok := f.tokens[token]
+	if !ok {
+		return "", passwordreset.ErrInvalidToken
+	}
+	delete(f.tokens, token)
+	return userID, nil
+}
+
+func (f *fakeResets) PasswordResetRequested(_ context.Context, req passwordreset.Requested) error {
+	f.requested = append(f.requested, req)
+	return nil
+}
+
+func newResetUseCase(repo *MockUserRepository) (UseCase, *fakeResets) {
+	resets := &fakeResets{tokens: map[string]string{}}
+	return NewUseCase(repo, WithPasswordReset(resets, resets)), resets
+}
+
+// passwordUpdate matches an UpdateFields call setting a hash of password.
+func passwordUpdate(password string) interface{} {
+	return mock.MatchedBy(func(fields map[string]interface{}) bool {
+		hash, ok := fields["password"].(string)
+		return ok && len(fields) == 1 && bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+	})
+}
+
+func TestChangePassword(t *testing.T) {
+	tests := []struct {
+		name    string
+		current string
+		wantErr error
+	}{
+		{"correct current password", fixtures.Password, nil},
+		{"wrong current password", "WrongPassword1", ErrInvalidCreds},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			uc := NewUseCase(mockRepo)
+			ctx := context.Background()
+
+			mockRepo.On("FindCredentialsByID", ctx, "u1").Return(fixtures.User().WithID("u1").Build(), nil)
+			mockRepo.On("UpdateFields", ctx, "u1", passwordUpdate("N3wPassword"), (*time.Time)(nil)).Return(&entity.User{ID: "u1"}, nil).Maybe()
+
+			err := uc.ChangePassword(ctx, "u1", tt.current, "N3wPassword")
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			if tt.wantErr == nil {
+				mockRepo.AssertExpectations(t)
+			} else {
+				mockRepo.AssertNotCalled(t, "UpdateFields", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestRequestPasswordReset(t *testing.T) {
+	tests := []struct {
+		name       string
+		user       *entity.User
+		findErr    error
+		wantNotify bool
+	}{
+		{"active account", fixtures.User().WithID("u1").WithEmail("a@example.com").Build(), nil, true},
+		{"unknown email", nil, repository.ErrNotFound, false},
+		{"inactive account", fixtures.User().WithID("u1").WithEmail("a@example.com").WithStatus(entity.UserStatusInactive).Build(), nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			uc, resets := newResetUseCase(mockRepo)
+			ctx := context.Background()
+			mockRepo.On("FindByEmail", ctx, "a@example.com").Return(tt.user, tt.findErr)
+
+			require.NoError(t, uc.RequestPasswordReset(ctx, "a@example.com"))
+
+			if !tt.wantNotify {
+				assert.Empty(t, resets.requested)
+				assert.Empty(t, resets.tokens)
+				return
+			}
+			require.Len(t, resets.requested, 1)
+			got := resets.requested[0]
+			assert.Equal(t, "u1", got.UserID)
+			assert.Equal(t, "a@example.com", got.Email)
+			assert.Equal(t, "u1", resets.tokens[got.Token])
+		})
+	}
+}
+
+func TestPasswordReset_Unavailable(t *testing.T) {
+	uc := NewUseCase(new(MockUserRepository))
+	ctx := context.Background()
+
+	assert.ErrorIs(t, uc.RequestPasswordReset(ctx, "a@example.com"), ErrPasswordResetUnavailable)
+	_, err := uc.ResetPassword(ctx, "token", "N3wPassword")
+	assert.ErrorIs(t, err, ErrPasswordResetUnavailable)
+}
+
+func TestResetPassword(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc, resets := newResetUseCase(mockRepo)
+	ctx := context.Background()
+	resets.tokens["good"] = "u1"
+	resets.tokens["orphan"] = "gone"
+
+	mockRepo.On("FindByID", ctx, "u1").Return(fixtures.User().WithID("u1").Build(), nil)
+	mockRepo.On("FindByID", ctx, "gone").Return(nil, repository.ErrNotFound)
+	mockRepo.On("UpdateFields", ctx, "u1", passwordUpdate("N3wPassword"), (*time.Time)(nil)).Return(&entity.User{ID: "u1"}, nil).Once()
+
+	user, err := uc.ResetPassword(ctx, "good", "N3wPassword")
+	require.NoError(t, err)
+	assert.Equal(t, "u1", user.ID)
+
+	// Tokens are single-use, and one whose account is gone is just invalid.
+	for _, token := range []string{"good", "orphan", "unknown"} {
+		_, err := uc.ResetPassword(ctx, token, "N3wPassword")
+		assert.ErrorIs(t, err, ErrInvalidResetToken, "token %q", token)
+	}
+	mockRepo.AssertExpectations(t)
+}
@@ -3,22 +3,72 @@ package user
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"time"
 
 	"veemon/entity"
+	"veemon/pkg/database"
+	"veemon/pkg/notify"
+	"veemon/pkg/userevents"
+	"veemon/repository"
+	"veemon/repository/audit_repository"
 	"veemon/repository/user_repository"
 
+	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
-	"gorm.io/gorm"
 )
 
 var (
-	ErrEmailExists   = errors.New("email already registered")
+	ErrEmailExists = errors.New("email already registered")
+	// ErrEmailDeleted is returned by Register under DeletedEmailBlock when the
+	// email belongs to a soft-deleted account.
+	ErrEmailDeleted  = errors.New("email belongs to a deleted account")
 	ErrNotFound      = errors.New("user not found")
 	ErrInvalidCreds  = errors.New("invalid credentials")
 	ErrUserNotActive = errors.New("user account is not active")
+	// ErrInvalidCursor is returned by ListAll for a cursor it did not issue,
+	// or one combined with a sort column other than created_at.
+	ErrInvalidCursor = errors.New("invalid pagination cursor")
+	// ErrDeletionPending is returned by Login for an account its owner asked
+	// to delete; CancelDeletion reactivates it during the grace period.
+	ErrDeletionPending = errors.New("account deletion is pending")
+	// ErrNoDeletionPending is returned by CancelDeletion when the account has
+	// no deletion it can still cancel.
+	ErrNoDeletionPending = errors.New("no pending account deletion")
+	// ErrVersionMismatch is returned by UpdateUser when the user changed
+	// after the version the caller expected.
+	ErrVersionMismatch = errors.New("user was modified since it was read")
+	// ErrVersionRequired is returned by UpdateUser under
+	// WithRequireUpdateVersion when the caller sent no expected version.
+	ErrVersionRequired = errors.New("expected version required")
+	// ErrInvalidSortField is returned by ListAll and ExportAll for a sortBy
+	// other than one of SortColumns, or a sortOrder other than asc or desc.
+	ErrInvalidSortField = user_repository.ErrInvalidSortField
+	// ErrInvalidStatus is returned by UpdateUser and CreateUser for a status
+	// that is not an entity.UserStatus value.
+	ErrInvalidStatus = errors.New("invalid user status")
+	// ErrNameRequired is returned by UpdateUser when the name is set to "".
+	ErrNameRequired = errors.New("name cannot be empty")
+	// ErrNotDeleted is returned by RestoreUser for a user that is not
+	// soft-deleted.
+	ErrNotDeleted = errors.New("user is not deleted")
 )
 
+// DefaultMaxOffset is the deepest row offset ListAll serves without a cursor.
+const DefaultMaxOffset = 10000
+
+// OffsetLimitError is returned by ListAll when page and size reach past the
+// configured maximum offset.
+type OffsetLimitError struct {
+	Max int
+}
+
+func (e *OffsetLimitError) Error() string {
+	return fmt.Sprintf("offset pagination is limited to the first %d rows", e.Max)
+}
+
 // dummyPasswordHash is a valid bcrypt hash of an arbitrary value, compared
 // against on the user-not-found login path to equalize timing and mitigate
 // user enumeration. Generated once at package init at the same cost as real
@@ -37,10 +87,48 @@ type UseCase interface {
 	Register(ctx context.Context, input RegisterInput) (*RegisterOutput, error)
 	Login(ctx context.Context, email, password string) (*entity.User, error)
 	GetProfile(ctx context.Context, userID string) (*entity.User, error)
-	ListAll(ctx context.Context, input ListInput) ([]entity.User, int64, error)
-	GetUser(ctx context.Context, userID string) (*entity.User, error)
+	ListAll(ctx context.Context, input ListInput) (*ListOutput, error)
+	// ExportAll calls fn with every user ListAll would list for input, in
+	// batches of ExportBatchSize.
+	ExportAll(ctx context.Context, input ListInput, fn func([]entity.UserSummary) error) error
+	// GetUser loads a user; non-empty columns restricts the columns read.
+	GetUser(ctx context.Context, userID string, columns ...string) (*entity.User, error)
+	// CreateUser creates an account on an administrator's behalf, failing
+	// with an error wrapping entity.ErrInvalidRoles for unacceptable roles.
+	CreateUser(ctx context.Context, input CreateUserInput) (*entity.User, error)
+	// ImportUsers creates a batch of accounts in one transaction, reporting
+	// each row's outcome.
+	ImportUsers(ctx context.Context, rows []ImportRow) ([]ImportResult, error)
 	UpdateUser(ctx context.Context, userID string, input UpdateInput) (*entity.User, error)
 	DeleteUser(ctx context.Context, userID string) error
+	// RestoreUser undoes DeleteUser, failing with ErrEmailExists if the
+	// email has since been taken by another live user.
+	RestoreUser(ctx context.Context, userID string) (*entity.User, error)
+	// PurgeUser removes a user, deleted or not, for good.
+	PurgeUser(ctx context.Context, userID string) error
+	// RecordImpersonation writes the audit log entry for an impersonation
+	// token the caller in ctx was issued for targetID.
+	RecordImpersonation(ctx context.Context, targetID string, expiresAt time.Time, reason string) error
+	// RequestDeletion deactivates the account and schedules it to be purged
+	// once the grace period has elapsed, returning when that will be. It
+	// fails with ErrUserNotActive for an account that is not active.
+	RequestDeletion(ctx context.Context, userID string) (time.Time, error)
+	// CancelDeletion checks the credentials as Login does and reactivates an
+	// account whose deletion is still within its grace period.
+	CancelDeletion(ctx context.Context, email, password string) (*entity.User, error)
+	// PurgeExpiredDeletions anonymizes and soft-deletes the accounts whose
+	// grace period had elapsed by now, returning how many were purged.
+	PurgeExpiredDeletions(ctx context.Context, now time.Time) (int64, error)
+	// ChangePassword replaces the password of userID after checking
+	// currentPassword, failing with ErrInvalidCreds if it is wrong.
+	ChangePassword(ctx context.Context, userID, currentPassword, newPassword string) error
+	// RequestPasswordReset issues a reset token for the active account with
+	// this email and announces it; unknown or inactive emails are silently
+	// ignored so callers cannot probe for accounts.
+	RequestPasswordReset(ctx context.Context, email string) error
+	// ResetPassword redeems a reset token and sets the password of its
+	// account, which it returns.
+	ResetPassword(ctx context.Context, token, newPassword string) (*entity.User, error)
 }
 
 type RegisterInput struct {
@@ -48,6 +136,9 @@ type RegisterInput struct {
 	Password string
 	Name     string
 	Phone    string
+	// CompanyCode assigns the new account to a company, subject to the
+	// company user limit. Public self-registration leaves it empty.
+	CompanyCode string
 }
 
 type RegisterOutput struct {
@@ -62,66 +153,373 @@ type ListInput struct {
 	Search    string
 	SortBy    string
 	SortOrder string
+	// Columns restricts the columns read; empty reads every UserSummary
+	// column.
+	Columns []string
+	// Cursor continues after a previous page (see NextCursor); Page is then
+	// ignored.
+	Cursor string
+	// IncludeDeleted also lists soft-deleted users.
+	IncludeDeleted bool
+}
+
+// ListOutput is one page of users, with the parameters that produced it.
+type ListOutput struct {
+	Users  []entity.UserSummary
+	Total  int64
+	Params EffectiveListParams
+	// NextCursor continues after Users in created_at order. It is empty on
+	// the last page and when sorting by another column, which a keyset
+	// cursor cannot continue.
+	NextCursor string
+}
+
+// EffectiveListParams are the paging and sort parameters ListAll applied:
+// the input's after defaulting, clamping and whitelisting.
+type EffectiveListParams struct {
+	Page      int
+	Size      int
+	SortBy    string
+	SortOrder string
 }
 
+// Defaults and limits EffectiveParams applies to a ListInput.
+const (
+	DefaultPageSize  = 10
+	MaxPageSize      = 100
+	DefaultSortBy    = "created_at"
+	DefaultSortOrder = "desc"
+)
+
+// SortColumns lists the columns ListAll sorts by: the repository's
+// whitelist, in alphabetical order.
+func SortColumns() []string {
+	return user_repository.SortColumns()
+}
+
+// ValidateSort returns ErrInvalidSortField unless sortBy is empty or one of
+// SortColumns and sortOrder is empty, "asc" or "desc".
+func ValidateSort(sortBy, sortOrder string) error {
+	return user_repository.ValidateSort(sortBy, sortOrder)
+}
+
+// EffectiveParams returns the parameters ListAll applies for input: page at
+// least 1, size defaulted to DefaultPageSize and capped at MaxPageSize, and
+// an empty sort column or direction replaced by the default. Sorts that
+// ValidateSort refuses are left as they are; ListAll rejects them.
+func EffectiveParams(input ListInput) EffectiveListParams {
+	p := EffectiveListParams{
+		Page:      input.Page,
+		Size:      input.Size,
+		SortBy:    input.SortBy,
+		SortOrder: input.SortOrder,
+	}
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.Size < 1 {
+		p.Size = DefaultPageSize
+	} else if p.Size > MaxPageSize {
+		p.Size = MaxPageSize
+	}
+	if p.SortBy == "" {
+		p.SortBy = DefaultSortBy
+	}
+	if p.SortOrder == "" {
+		p.SortOrder = DefaultSortOrder
+	}
+	return p
+}
+
+// UpdateInput holds the fields to change; a nil field is left as it is.
+// Phone set to "" clears it; Name and Status cannot be cleared.
 type UpdateInput struct {
-	Name   string
-	Phone  string
-	Status string
+	Name   *string
+	Phone  *string
+	Status *string
+	// ExpectedUpdatedAt, when set, makes the update conditional on the user's
+	// UpdatedAt still being this value (optimistic concurrency).
+	ExpectedUpdatedAt *time.Time
+}
+
+// DeletedEmailPolicy decides what Register does when the email matches a
+// soft-deleted account.
+type DeletedEmailPolicy string
+
+const (
+	// DeletedEmailNew creates a fresh account alongside the deleted one.
+	DeletedEmailNew DeletedEmailPolicy = "new"
+	// DeletedEmailReactivate restores the deleted account, keeping its ID so
+	// rows referencing the user stay linked.
+	DeletedEmailReactivate DeletedEmailPolicy = "reactivate"
+	// DeletedEmailBlock refuses the registration with ErrEmailDeleted.
+	DeletedEmailBlock DeletedEmailPolicy = "block"
+)
+
+// Valid reports whether p is a known policy.
+func (p DeletedEmailPolicy) Valid() bool {
+	switch p {
+	case DeletedEmailNew, DeletedEmailReactivate, DeletedEmailBlock:
+		return true
+	}
+	return false
+}
+
+// Option configures the usecase.
+type Option func(*useCase)
+
+// WithDeletedEmailPolicy sets how Register treats soft-deleted emails. The
+// default (and what an empty p means) is DeletedEmailNew.
+func WithDeletedEmailPolicy(p DeletedEmailPolicy) Option {
+	return func(uc *useCase) {
+		if p != "" {
+			uc.deletedEmailPolicy = p
+		}
+	}
+}
+
+// WithTransactor makes multi-step methods (Register, UpdateUser, DeleteUser)
+// run their repository calls in one transaction. Without it each call
+// commits on its own.
+func WithTransactor(tx database.Transactor) Option {
+	return func(uc *useCase) {
+		if tx != nil {
+			uc.tx = tx
+		}
+	}
+}
+
+// WithMaxOffset caps how deep ListAll pages by offset; deeper pages must use
+// a cursor. n <= 0 keeps DefaultMaxOffset.
+func WithMaxOffset(n int) Option {
+	return func(uc *useCase) {
+		if n > 0 {
+			uc.maxOffset = n
+		}
+	}
 }
 
+// WithDeletionGracePeriod sets how long a self-service deletion can be
+// cancelled before the account is purged. d <= 0 keeps
+// DefaultDeletionGracePeriod.
+func WithDeletionGracePeriod(d time.Duration) Option {
+	return func(uc *useCase) {
+		if d > 0 {
+			uc.deletionGrace = d
+		}
+	}
+}
+
+// WithEventPublisher makes Register, UpdateUser and DeleteUser publish a
+// notify.Event after each successful change.
+func WithEventPublisher(p notify.Publisher) Option {
+	return func(uc *useCase) {
+		uc.events = p
+	}
+}
+
+// EventPublisher announces user domain events to other services. Like
+// notify.Publisher it is fire-and-forget. *userevents.Notifier satisfies it.
+type EventPublisher interface {
+	Publish(ctx context.Context, e userevents.Event)
+}
+
+// WithDomainEvents makes Register, CreateUser, UpdateUser, DeleteUser,
+// RestoreUser, PurgeUser and Login publish a userevents.Event through p
+// (only Login with WithEventOutbox).
+func WithDomainEvents(p EventPublisher) Option {
+	return func(uc *useCase) {
+		uc.domainEvents = p
+	}
+}
+
+// WithCompanyUserLimit caps the live users per company: Register fails with
+// *entity.CompanyUserLimitError rather than add a company's n+1th user.
+// n <= 0 (the default) means no limit. The check is race-free only with
+// WithTransactor, since the company lock is held for the transaction.
+func WithCompanyUserLimit(n int) Option {
+	return func(uc *useCase) {
+		uc.companyUserLimit = n
+	}
+}
+
+// WithRequireUpdateVersion makes UpdateUser refuse updates without
+// UpdateInput.ExpectedUpdatedAt with ErrVersionRequired, so no client can
+// overwrite a change it has not seen.
+func WithRequireUpdateVersion(require bool) Option {
+	return func(uc *useCase) {
+		uc.requireUpdateVersion = require
+	}
+}
+
+// noTx runs fn directly, without a transaction.
+type noTx struct{}
+
+func (noTx) Do(ctx context.Context, fn func(context.Context) error) error { return fn(ctx) }
+
 type useCase struct {
-	userRepo user_repository.Repository
+	userRepo             user_repository.Repository
+	tx                   database.Transactor
+	deletedEmailPolicy   DeletedEmailPolicy
+	maxOffset            int
+	deletionGrace        time.Duration
+	companyUserLimit     int
+	requireUpdateVersion bool
+	events               notify.Publisher
+	domainEvents         EventPublisher
+	outbox               EventOutbox
+	resetTokens          ResetTokenStore
+	resetNotifier        ResetNotifier
+	inviteTokens         ResetTokenStore
+	inviteNotifier       InviteNotifier
+	auditLog             audit_repository.Repository
+	now                  func() time.Time
 }
 
-func NewUseCase(userRepo user_repository.Repository) UseCase {
-	return &useCase{userRepo: userRepo}
+func NewUseCase(userRepo user_repository.Repository, opts ...Option) UseCase {
+	uc := &useCase{
+		userRepo:           userRepo,
+		tx:                 noTx{},
+		deletedEmailPolicy: DeletedEmailNew,
+		maxOffset:          DefaultMaxOffset,
+		deletionGrace:      DefaultDeletionGracePeriod,
+		domainEvents:       userevents.Nop{},
+		now:                time.Now,
+	}
+	for _, opt := range opts {
+		opt(uc)
+	}
+	return uc
 }
 
 func (uc *useCase) Register(ctx context.Context, input RegisterInput) (*RegisterOutput, error) {
-	// Check if email exists
+	// Hash before the transaction so bcrypt's cost is not paid while holding
+	// a connection.
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	var user *entity.User
+	err = uc.tx.Do(ctx, func(ctx context.Context) error {
+		var err error
+		if user, err = uc.register(ctx, input, string(hashedPassword)); err != nil {
+			return err
+		}
+		return uc.stage(ctx, notify.UserRegistered, user)
+	})
+	if err != nil {
+		return nil, err
+	}
+	uc.publish(ctx, notify.UserRegistered, user)
+	return &RegisterOutput{
+		ID:    user.ID,
+		Email: user.Email,
+		Name:  user.Name,
+	}, nil
+}
+
+func (uc *useCase) register(ctx context.Context, input RegisterInput, hashedPassword string) (*entity.User, error) {
+	// A fast path only: the unique index is what enforces uniqueness (see
+	// Create below). Checking first spares the common duplicate a failed
+	// insert, which in Postgres aborts the whole transaction.
 	existing, err := uc.userRepo.FindByEmail(ctx, input.Email)
-	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
 		return nil, err
 	}
 	if existing != nil {
 		return nil, ErrEmailExists
 	}
 
-	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
-	if err != nil {
+	var deleted *entity.User
+	if uc.deletedEmailPolicy != DeletedEmailNew {
+		deleted, err = uc.userRepo.FindByEmailIncludingDeleted(ctx, input.Email)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+		if deleted != nil && !deleted.DeletedAt.Valid {
+			// A live row appeared between the two reads.
+			return nil, ErrEmailExists
+		}
+		if deleted != nil && uc.deletedEmailPolicy == DeletedEmailBlock {
+			return nil, ErrEmailDeleted
+		}
+	}
+
+	if err := uc.checkCompanyLimit(ctx, input.CompanyCode); err != nil {
 		return nil, err
 	}
+	if deleted != nil {
+		return uc.reactivate(ctx, deleted.ID, input, hashedPassword)
+	}
 
 	user := &entity.User{
-		Email:    input.Email,
-		Password: string(hashedPassword),
-		Name:     input.Name,
-		Phone:    input.Phone,
-		Status:   entity.UserStatusActive,
+		Email:       input.Email,
+		Password:    hashedPassword,
+		Name:        input.Name,
+		Phone:       input.Phone,
+		Status:      entity.UserStatusActive,
+		Roles:       pq.StringArray(entity.DefaultRoles),
+		CompanyCode: input.CompanyCode,
 	}
 
 	if err := uc.userRepo.Create(ctx, user); err != nil {
 		// Closes the check-then-insert race: two concurrent registrations pass
 		// the FindByEmail check, but the unique index rejects the second insert.
-		if errors.Is(err, gorm.ErrDuplicatedKey) {
+		if errors.Is(err, user_repository.ErrDuplicateEmail) {
 			return nil, ErrEmailExists
 		}
 		return nil, err
 	}
+	return user, nil
+}
+
+// checkCompanyLimit locks companyCode's user count for the rest of the
+// transaction and fails if one more user would exceed the limit.
+func (uc *useCase) checkCompanyLimit(ctx context.Context, companyCode string) error {
+	if uc.companyUserLimit <= 0 || companyCode == "" {
+		return nil
+	}
+	n, err := uc.userRepo.LockCompanyUsers(ctx, companyCode)
+	if err != nil {
+		return err
+	}
+	if n >= int64(uc.companyUserLimit) {
+		return &entity.CompanyUserLimitError{CompanyCode: companyCode, Limit: uc.companyUserLimit}
+	}
+	return nil
+}
 
-	return &RegisterOutput{
-		ID:    user.ID,
-		Email: user.Email,
-		Name:  user.Name,
-	}, nil
+// reactivate restores a soft-deleted account as input registers it: the
+// new password, name, phone and company, and the default roles. Only the ID
+// is kept, so whoever knows a deleted admin's email cannot inherit the
+// admin's roles. The account comes back as pending so it goes through
+// activation again instead of regaining access on the strength of the email
+// alone.
+func (uc *useCase) reactivate(ctx context.Context, id string, input RegisterInput, hashedPassword string) (*entity.User, error) {
+	user, err := uc.userRepo.Restore(ctx, id, map[string]interface{}{
+		"password":     hashedPassword,
+		"status":       entity.UserStatusPending,
+		"name":         input.Name,
+		"phone":        input.Phone,
+		"company_code": input.CompanyCode,
+		"roles":        pq.StringArray(entity.DefaultRoles),
+	})
+	if err != nil {
+		// Another registration either restored this row or created a live
+		// one with the same email first.
+		if errors.Is(err, repository.ErrNotFound) || errors.Is(err, user_repository.ErrDuplicateEmail) {
+			return nil, ErrEmailExists
+		}
+		return nil, err
+	}
+	return user, nil
 }
 
 func (uc *useCase) Login(ctx context.Context, email, password string) (*entity.User, error) {
 	user, err := uc.userRepo.FindByEmail(ctx, email)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+		if errors.Is(err, repository.ErrNotFound) {
 			// Perform a dummy hash comparison so the not-found path takes
 			// roughly the same time as the wrong-password path, mitigating
 			// user enumeration via response timing.
@@ -137,17 +535,21 @@ func (uc *useCase) Login(ctx context.Context, email, password string) (*entity.U
 
 	// Only active accounts may authenticate. Deactivated (inactive) or
 	// not-yet-activated (pending) users are rejected even with valid credentials.
+	if user.DeletionRequestedAt != nil {
+		return nil, ErrDeletionPending
+	}
 	if user.Status != entity.UserStatusActive {
 		return nil, ErrUserNotActive
 	}
 
+	uc.domainEvents.Publish(ctx, uc.domainEvent(userevents.LoggedIn, user))
 	return user, nil
 }
 
 func (uc *useCase) GetProfile(ctx context.Context, userID string) (*entity.User, error) {
 	user, err := uc.userRepo.FindByID(ctx, userID)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+		if errors.Is(err, repository.ErrNotFound) {
 			return nil, ErrNotFound
 		}
 		return nil, err
@@ -155,20 +557,86 @@ func (uc *useCase) GetProfile(ctx context.Context, userID string) (*entity.User,
 	return user, nil
 }
 
-func (uc *useCase) ListAll(ctx context.Context, input ListInput) ([]entity.User, int64, error) {
-	return uc.userRepo.FindAll(ctx, user_repository.ListParams{
-		Page:      input.Page,
-		Size:      input.Size,
-		Search:    input.Search,
-		SortBy:    input.SortBy,
-		SortOrder: input.SortOrder,
-	})
+func (uc *useCase) ListAll(ctx context.Context, input ListInput) (*ListOutput, error) {
+	if err := ValidateSort(input.SortBy, input.SortOrder); err != nil {
+		return nil, err
+	}
+	eff := EffectiveParams(input)
+	params := user_repository.ListParams{
+		Page:           eff.Page,
+		Size:           eff.Size,
+		Search:         input.Search,
+		SortBy:         eff.SortBy,
+		SortOrder:      eff.SortOrder,
+		Columns:        input.Columns,
+		IncludeDeleted: input.IncludeDeleted,
+	}
+
+	if input.Cursor == "" {
+		// The database still reads and discards every skipped row, so deep
+		// offsets cost as much as a full scan.
+		if (eff.Page-1)*eff.Size > uc.maxOffset {
+			return nil, &OffsetLimitError{Max: uc.maxOffset}
+		}
+	} else {
+		if !keysetSort(eff.SortBy) {
+			return nil, ErrInvalidCursor
+		}
+		after, err := decodeCursor(input.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		params.After = after
+	}
+
+	// NextCursor reads the keyset columns whatever the caller projected.
+	if len(params.Columns) > 0 && keysetSort(eff.SortBy) {
+		params.Columns = withColumns(params.Columns, "id", "created_at")
+	}
+	// Only created_at order can be continued by a cursor; the probe row
+	// tells a full last page apart from one with more after it.
+	params.Probe = keysetSort(eff.SortBy)
+	users, total, err := uc.userRepo.FindAll(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	out := &ListOutput{Users: users, Total: total, Params: eff}
+	if params.Probe {
+		out.NextCursor, out.Users = nextCursor(eff.Size, users)
+	}
+	return out, nil
 }
 
-func (uc *useCase) GetUser(ctx context.Context, userID string) (*entity.User, error) {
-	user, err := uc.userRepo.FindByID(ctx, userID)
+// withColumns returns columns plus any of extra it lacks.
+func withColumns(columns []string, extra ...string) []string {
+	out := append([]string(nil), columns...)
+	for _, e := range extra {
+		found := false
+		for _, c := range columns {
+			if c == e {
+				found = true
+				break
+			}
+		}
+		if !found {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func (uc *useCase) GetUser(ctx context.Context, userID string, columns ...string) (*entity.User, error) {
+	var (
+		user *entity.User
+		err  error
+	)
+	if len(columns) > 0 {
+		user, err = uc.userRepo.FindByIDWithColumns(ctx, userID, columns)
+	} else {
+		user, err = uc.userRepo.FindByID(ctx, userID)
+	}
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+		if errors.Is(err, repository.ErrNotFound) {
 			return nil, ErrNotFound
 		}
 		return nil, err
@@ -177,43 +645,227 @@ func (uc *useCase) GetUser(ctx context.Context, userID string) (*entity.User, er
 }
 
 func (uc *useCase) UpdateUser(ctx context.Context, userID string, input UpdateInput) (*entity.User, error) {
+	if uc.requireUpdateVersion && input.ExpectedUpdatedAt == nil {
+		return nil, ErrVersionRequired
+	}
+
 	// Build a column-scoped update so only changed fields are written; this
 	// avoids the lost-update hazard of read-modify-write with Save.
 	fields := map[string]interface{}{}
-	if input.Name != "" {
-		fields["name"] = input.Name
+	if input.Name != nil {
+		if *input.Name == "" {
+			return nil, ErrNameRequired
+		}
+		fields["name"] = *input.Name
 	}
-	if input.Phone != "" {
-		fields["phone"] = input.Phone
+	if input.Phone != nil {
+		fields["phone"] = *input.Phone
 	}
-	if input.Status != "" {
-		fields["status"] = input.Status
+	if input.Status != nil {
+		if !entity.UserStatus(*input.Status).Valid() {
+			return nil, ErrInvalidStatus
+		}
+		fields["status"] = *input.Status
 	}
 
-	// No changes requested: return the current record (or not-found).
+	// No changes requested: return the current record (or not-found),
+	// still failing a stale expected version.
 	if len(fields) == 0 {
-		return uc.GetUser(ctx, userID)
+		user, err := uc.GetUser(ctx, userID)
+		if err == nil && input.ExpectedUpdatedAt != nil && !user.UpdatedAt.Equal(*input.ExpectedUpdatedAt) {
+			return nil, ErrVersionMismatch
+		}
+		return user, err
 	}
 
-	user, err := uc.userRepo.UpdateFields(ctx, userID, fields)
+	// UpdateFields writes then re-reads; the transaction makes the returned
+	// row the one this update produced.
+	var user *entity.User
+	err := uc.tx.Do(ctx, func(ctx context.Context) error {
+		// The row as it was, for the audit log.
+		var before *entity.User
+		var err error
+		if uc.auditLog != nil {
+			if before, err = uc.userRepo.FindByID(ctx, userID); err != nil {
+				return err
+			}
+		}
+		user, err = uc.userRepo.UpdateFields(ctx, userID, fields, input.ExpectedUpdatedAt)
+		if err != nil {
+			return err
+		}
+		if err := uc.audit(ctx, entity.AuditActionUpdate, before, user); err != nil {
+			return err
+		}
+		return uc.stage(ctx, notify.UserUpdated, user)
+	})
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+		if errors.Is(err, repository.ErrNotFound) {
 			return nil, ErrNotFound
 		}
+		if errors.Is(err, user_repository.ErrModified) {
+			return nil, ErrVersionMismatch
+		}
 		return nil, err
 	}
 
+	uc.publish(ctx, notify.UserUpdated, user)
 	return user, nil
 }
 
 func (uc *useCase) DeleteUser(ctx context.Context, userID string) error {
-	_, err := uc.userRepo.FindByID(ctx, userID)
+	var user *entity.User
+	err := uc.tx.Do(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = uc.userRepo.FindByID(ctx, userID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		if err := uc.userRepo.Delete(ctx, userID); err != nil {
+			return err
+		}
+		if err := uc.audit(ctx, entity.AuditActionDelete, user, nil); err != nil {
+			return err
+		}
+		return uc.stage(ctx, notify.UserDeleted, user)
+	})
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return ErrNotFound
+		return err
+	}
+	uc.publish(ctx, notify.UserDeleted, user)
+	return nil
+}
+
+func (uc *useCase) RestoreUser(ctx context.Context, userID string) (*entity.User, error) {
+	var user *entity.User
+	err := uc.tx.Do(ctx, func(ctx context.Context) error {
+		deleted, err := uc.userRepo.FindByIDIncludingDeleted(ctx, userID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+		if !deleted.DeletedAt.Valid {
+			return ErrNotDeleted
+		}
+		// A fast path only, as in register: the unique index on live emails
+		// has the last word when Restore runs.
+		existing, err := uc.userRepo.FindByEmail(ctx, deleted.Email)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return err
 		}
+		if existing != nil {
+			return ErrEmailExists
+		}
+		if err := uc.checkCompanyLimit(ctx, deleted.CompanyCode); err != nil {
+			return err
+		}
+		user, err = uc.userRepo.Restore(ctx, userID, nil)
+		if err != nil {
+			if errors.Is(err, user_repository.ErrDuplicateEmail) {
+				return ErrEmailExists
+			}
+			// Restored or purged by someone else meanwhile.
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrNotDeleted
+			}
+			return err
+		}
+		if err := uc.audit(ctx, entity.AuditActionRestore, deleted, user); err != nil {
+			return err
+		}
+		return uc.stage(ctx, notify.UserUpdated, user)
+	})
+	if err != nil {
+		return nil, err
+	}
+	uc.publish(ctx, notify.UserUpdated, user)
+	return user, nil
+}
+
+func (uc *useCase) PurgeUser(ctx context.Context, userID string) error {
+	var user *entity.User
+	err := uc.tx.Do(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = uc.userRepo.FindByIDIncludingDeleted(ctx, userID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+		if err := uc.userRepo.HardDelete(ctx, userID); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+		if err := uc.audit(ctx, entity.AuditActionPurge, user, nil); err != nil {
+			return err
+		}
+		// Clients and consumers were told about a soft delete already.
+		if user.DeletedAt.Valid {
+			return nil
+		}
+		return uc.stage(ctx, notify.UserDeleted, user)
+	})
+	if err != nil {
 		return err
 	}
+	if !user.DeletedAt.Valid {
+		uc.publish(ctx, notify.UserDeleted, user)
+	}
+	return nil
+}
+
+// publish raises a user event once its transaction has committed, so
+// clients and other services are never told about a change that was rolled
+// back. eventType is one of the notify types, which double as the
+// userevents types. With WithEventOutbox the domain event was staged in the
+// transaction instead.
+func (uc *useCase) publish(ctx context.Context, eventType string, user *entity.User) {
+	if user == nil {
+		return
+	}
+	if uc.outbox == nil {
+		uc.domainEvents.Publish(ctx, uc.domainEvent(eventType, user))
+	}
+	if uc.events == nil {
+		return
+	}
+	data, err := json.Marshal(userEvent{ID: user.ID, Email: user.Email, Name: user.Name})
+	if err != nil {
+		return
+	}
+	uc.events.Publish(ctx, notify.Event{
+		Type:        eventType,
+		CompanyCode: user.CompanyCode,
+		OccurredAt:  uc.now().UTC(),
+		Data:        data,
+	})
+}
+
+func (uc *useCase) domainEvent(eventType string, user *entity.User) userevents.Event {
+	return userevents.Event{
+		Type:       eventType,
+		OccurredAt: uc.now().UTC(),
+		User: userevents.User{
+			ID:          user.ID,
+			Email:       user.Email,
+			Name:        user.Name,
+			CompanyCode: user.CompanyCode,
+		},
+	}
+}
 
-	return uc.userRepo.Delete(ctx, userID)
+// userEvent is the Data of a user notification.
+type userEvent struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+	Name  string `json:"name"`
 }
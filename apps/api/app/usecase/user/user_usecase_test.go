@@ -2,15 +2,24 @@ package user
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"veemon/entity"
+	"veemon/internal/fixtures"
+	"veemon/pkg/notify"
+	"veemon/pkg/userevents"
+	"veemon/repository"
 	"veemon/repository/user_repository"
 
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"golang.org/x/crypto/bcrypt"
-	"gorm.io/gorm"
 )
 
 // MockUserRepository is a mock implementation of user_repository.Repository
@@ -31,6 +40,30 @@ func (m *MockUserRepository) FindByID(ctx context.Context, id string) (*entity.U
 	return args.Get(0).(*entity.User), args.Error(1)
 }
 
+func (m *MockUserRepository) FindByIDIncludingDeleted(ctx context.Context, id string) (*entity.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
+func (m *MockUserRepository) FindCredentialsByID(ctx context.Context, id string) (*entity.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
+func (m *MockUserRepository) FindByIDWithColumns(ctx context.Context, id string, columns []string) (*entity.User, error) {
+	args := m.Called(ctx, id, columns)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
 func (m *MockUserRepository) FindByEmail(ctx context.Context, email string) (*entity.User, error) {
 	args := m.Called(ctx, email)
 	if args.Get(0) == nil {
@@ -39,12 +72,54 @@ func (m *MockUserRepository) FindByEmail(ctx context.Context, email string) (*en
 	return args.Get(0).(*entity.User), args.Error(1)
 }
 
-func (m *MockUserRepository) FindAll(ctx context.Context, params user_repository.ListParams) ([]entity.User, int64, error) {
+func (m *MockUserRepository) FindAll(ctx context.Context, params user_repository.ListParams) ([]entity.UserSummary, int64, error) {
 	args := m.Called(ctx, params)
-	return args.Get(0).([]entity.User), args.Get(1).(int64), args.Error(2)
+	return args.Get(0).([]entity.UserSummary), args.Get(1).(int64), args.Error(2)
+}
+
+// FindAllInBatches hands fn the batches given to Return, then returns its
+// error.
+func (m *MockUserRepository) FindAllInBatches(ctx context.Context, params user_repository.ListParams, batchSize int, fn func([]entity.UserSummary) error) error {
+	args := m.Called(ctx, params, batchSize)
+	batches, _ := args.Get(0).([][]entity.UserSummary)
+	for _, batch := range batches {
+		if err := fn(batch); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
 }
 
-func (m *MockUserRepository) UpdateFields(ctx context.Context, id string, fields map[string]interface{}) (*entity.User, error) {
+func (m *MockUserRepository) FindExistingEmails(ctx context.Context, emails []string) ([]string, error) {
+	args := m.Called(ctx, emails)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockUserRepository) CreateBatch(ctx context.Context, users []*entity.User) error {
+	args := m.Called(ctx, users)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdateFields(ctx context.Context, id string, fields map[string]interface{}, expectedUpdatedAt *time.Time) (*entity.User, error) {
+	args := m.Called(ctx, id, fields, expectedUpdatedAt)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
+func (m *MockUserRepository) FindByEmailIncludingDeleted(ctx context.Context, email string) (*entity.User, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
+func (m *MockUserRepository) Restore(ctx context.Context, id string, fields map[string]interface{}) (*entity.User, error) {
 	args := m.Called(ctx, id, fields)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -57,6 +132,21 @@ func (m *MockUserRepository) Delete(ctx context.Context, id string) error {
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) HardDelete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) PurgeDeletionsRequestedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	args := m.Called(ctx, cutoff)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUserRepository) LockCompanyUsers(ctx context.Context, companyCode string) (int64, error) {
+	args := m.Called(ctx, companyCode)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func TestRegister_Success(t *testing.T) {
 	mockRepo := new(MockUserRepository)
 	uc := NewUseCase(mockRepo)
@@ -70,7 +160,7 @@ func TestRegister_Success(t *testing.T) {
 	}
 
 	// Mock FindByEmail returns not found
-	mockRepo.On("FindByEmail", ctx, input.Email).Return(nil, gorm.ErrRecordNotFound)
+	mockRepo.On("FindByEmail", ctx, input.Email).Return(nil, repository.ErrNotFound)
 
 	// Mock Create succeeds
 	mockRepo.On("Create", ctx, mock.AnythingOfType("*entity.User")).Return(nil)
@@ -95,10 +185,7 @@ func TestRegister_EmailExists(t *testing.T) {
 		Name:     "Test User",
 	}
 
-	existingUser := &entity.User{
-		ID:    "existing-id",
-		Email: input.Email,
-	}
+	existingUser := fixtures.User().WithEmail(input.Email).Build()
 
 	// Mock FindByEmail returns existing user
 	mockRepo.On("FindByEmail", ctx, input.Email).Return(existingUser, nil)
@@ -120,30 +207,157 @@ func TestRegister_DuplicateKeyRace(t *testing.T) {
 
 	// FindByEmail says the user does not exist (both concurrent requests pass),
 	// but the unique index rejects the insert with a duplicate-key error.
-	mockRepo.On("FindByEmail", ctx, input.Email).Return(nil, gorm.ErrRecordNotFound)
-	mockRepo.On("Create", ctx, mock.AnythingOfType("*entity.User")).Return(gorm.ErrDuplicatedKey)
+	mockRepo.On("FindByEmail", ctx, input.Email).Return(nil, repository.ErrNotFound)
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*entity.User")).Return(user_repository.ErrDuplicateEmail)
 
 	_, err := uc.Register(ctx, input)
 	assert.ErrorIs(t, err, ErrEmailExists)
 	mockRepo.AssertExpectations(t)
 }
 
-func TestLogin_InactiveUserRejected(t *testing.T) {
+func softDeletedUser(email string) *entity.User {
+	return fixtures.User().WithID("original-id").WithEmail(email).WithName("Old Name").Deleted().Build()
+}
+
+func TestRegister_DeletedEmail_NewPolicyCreatesAccount(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	uc := NewUseCase(mockRepo)
+	uc := NewUseCase(mockRepo, WithDeletedEmailPolicy(DeletedEmailNew))
+	ctx := context.Background()
+
+	input := RegisterInput{Email: "back@example.com", Password: "Password123", Name: "Back"}
+
+	// The deleted row is never consulted; a fresh row is inserted.
+	mockRepo.On("FindByEmail", ctx, input.Email).Return(nil, repository.ErrNotFound)
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*entity.User")).Return(nil)
+
+	result, err := uc.Register(ctx, input)
+	assert.NoError(t, err)
+	assert.Equal(t, input.Email, result.Email)
+	mockRepo.AssertNotCalled(t, "FindByEmailIncludingDeleted", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "Restore", mock.Anything, mock.Anything, mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRegister_DeletedEmail_ReactivateRestoresOriginalAccount(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := NewUseCase(mockRepo, WithDeletedEmailPolicy(DeletedEmailReactivate))
+	ctx := context.Background()
+
+	input := RegisterInput{Email: "back@example.com", Password: "NewPassword123", Name: "Back", Phone: "+6281234567890"}
+	deleted := softDeletedUser(input.Email)
+	deleted.Roles, deleted.CompanyCode = pq.StringArray{"admin"}, "ACME"
+
+	mockRepo.On("FindByEmail", ctx, input.Email).Return(nil, repository.ErrNotFound)
+	mockRepo.On("FindByEmailIncludingDeleted", ctx, input.Email).Return(deleted, nil)
+
+	var restored map[string]interface{}
+	mockRepo.On("Restore", ctx, deleted.ID, mock.Anything).
+		Run(func(args mock.Arguments) { restored = args.Get(2).(map[string]interface{}) }).
+		Return(fixtures.User().WithID(deleted.ID).WithEmail(deleted.Email).WithStatus(entity.UserStatusPending).Build(), nil)
+
+	result, err := uc.Register(ctx, input)
+	assert.NoError(t, err)
+	// Same id: anything referencing the user stays linked to it.
+	assert.Equal(t, deleted.ID, result.ID)
+	assert.Equal(t, entity.UserStatusPending, restored["status"])
+	// Nothing but the ID survives from the deleted account.
+	assert.Equal(t, pq.StringArray(entity.DefaultRoles), restored["roles"])
+	assert.Equal(t, "Back", restored["name"])
+	assert.Equal(t, "+6281234567890", restored["phone"])
+	assert.Equal(t, "", restored["company_code"])
+	hash, _ := restored["password"].(string)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(hash), []byte(input.Password)))
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRegister_DeletedEmail_ReactivateWithoutDeletedRowCreatesAccount(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := NewUseCase(mockRepo, WithDeletedEmailPolicy(DeletedEmailReactivate))
 	ctx := context.Background()
 
-	hash, err := bcrypt.GenerateFromPassword([]byte("Password123"), bcrypt.DefaultCost)
+	input := RegisterInput{Email: "fresh@example.com", Password: "Password123", Name: "Fresh"}
+
+	mockRepo.On("FindByEmail", ctx, input.Email).Return(nil, repository.ErrNotFound)
+	mockRepo.On("FindByEmailIncludingDeleted", ctx, input.Email).Return(nil, repository.ErrNotFound)
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*entity.User")).Return(nil)
+
+	_, err := uc.Register(ctx, input)
 	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRegister_DeletedEmail_ReactivateRace(t *testing.T) {
+	tests := []struct {
+		name       string
+		restoreErr error
+	}{
+		{"row already restored", repository.ErrNotFound},
+		{"live row created first", user_repository.ErrDuplicateEmail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			uc := NewUseCase(mockRepo, WithDeletedEmailPolicy(DeletedEmailReactivate))
+			ctx := context.Background()
+
+			input := RegisterInput{Email: "race@example.com", Password: "Password123", Name: "Race"}
+			deleted := softDeletedUser(input.Email)
+
+			mockRepo.On("FindByEmail", ctx, input.Email).Return(nil, repository.ErrNotFound)
+			mockRepo.On("FindByEmailIncludingDeleted", ctx, input.Email).Return(deleted, nil)
+			mockRepo.On("Restore", ctx, deleted.ID, mock.Anything).Return(nil, tt.restoreErr)
+
+			_, err := uc.Register(ctx, input)
+			assert.ErrorIs(t, err, ErrEmailExists)
+		})
+	}
+}
+
+func TestRegister_DeletedEmail_BlockRejects(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := NewUseCase(mockRepo, WithDeletedEmailPolicy(DeletedEmailBlock))
+	ctx := context.Background()
 
-	mockRepo.On("FindByEmail", ctx, "inactive@example.com").Return(&entity.User{
-		ID:       "u1",
-		Email:    "inactive@example.com",
-		Password: string(hash),
-		Status:   entity.UserStatusInactive,
-	}, nil)
+	input := RegisterInput{Email: "back@example.com", Password: "Password123", Name: "Back"}
 
-	_, err = uc.Login(ctx, "inactive@example.com", "Password123")
+	mockRepo.On("FindByEmail", ctx, input.Email).Return(nil, repository.ErrNotFound)
+	mockRepo.On("FindByEmailIncludingDeleted", ctx, input.Email).Return(softDeletedUser(input.Email), nil)
+
+	result, err := uc.Register(ctx, input)
+	assert.ErrorIs(t, err, ErrEmailDeleted)
+	assert.Nil(t, result)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "Restore", mock.Anything, mock.Anything, mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRegister_DeletedEmail_BlockAllowsUnusedEmail(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := NewUseCase(mockRepo, WithDeletedEmailPolicy(DeletedEmailBlock))
+	ctx := context.Background()
+
+	input := RegisterInput{Email: "fresh@example.com", Password: "Password123", Name: "Fresh"}
+
+	mockRepo.On("FindByEmail", ctx, input.Email).Return(nil, repository.ErrNotFound)
+	mockRepo.On("FindByEmailIncludingDeleted", ctx, input.Email).Return(nil, repository.ErrNotFound)
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*entity.User")).Return(nil)
+
+	_, err := uc.Register(ctx, input)
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestLogin_InactiveUserRejected(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := NewUseCase(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("FindByEmail", ctx, "inactive@example.com").Return(
+		fixtures.User().WithEmail("inactive@example.com").WithStatus(entity.UserStatusInactive).Build(), nil)
+
+	_, err := uc.Login(ctx, "inactive@example.com", fixtures.Password)
 	assert.ErrorIs(t, err, ErrUserNotActive)
 	mockRepo.AssertExpectations(t)
 }
@@ -154,12 +368,7 @@ func TestGetProfile_Success(t *testing.T) {
 	ctx := context.Background()
 
 	userID := "user-123"
-	expectedUser := &entity.User{
-		ID:     userID,
-		Email:  "test@example.com",
-		Name:   "Test User",
-		Status: entity.UserStatusActive,
-	}
+	expectedUser := fixtures.User().WithID(userID).Build()
 
 	mockRepo.On("FindByID", ctx, userID).Return(expectedUser, nil)
 
@@ -179,7 +388,7 @@ func TestGetProfile_NotFound(t *testing.T) {
 
 	userID := "non-existent"
 
-	mockRepo.On("FindByID", ctx, userID).Return(nil, gorm.ErrRecordNotFound)
+	mockRepo.On("FindByID", ctx, userID).Return(nil, repository.ErrNotFound)
 
 	result, err := uc.GetProfile(ctx, userID)
 
@@ -189,6 +398,22 @@ func TestGetProfile_NotFound(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestGetUser_WithColumnsUsesProjection(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := NewUseCase(mockRepo)
+	ctx := context.Background()
+
+	columns := []string{"id", "name"}
+	mockRepo.On("FindByIDWithColumns", ctx, "user-123", columns).Return(&entity.User{ID: "user-123", Name: "Picked"}, nil)
+
+	result, err := uc.GetUser(ctx, "user-123", columns...)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Picked", result.Name)
+	mockRepo.AssertNotCalled(t, "FindByID", mock.Anything, mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestListAll_Success(t *testing.T) {
 	mockRepo := new(MockUserRepository)
 	uc := NewUseCase(mockRepo)
@@ -202,41 +427,228 @@ func TestListAll_Success(t *testing.T) {
 		SortOrder: "desc",
 	}
 
-	expectedUsers := []entity.User{
-		{ID: "user-1", Email: "user1@example.com", Name: "User 1"},
-		{ID: "user-2", Email: "user2@example.com", Name: "User 2"},
-	}
+	expectedUsers := []entity.UserSummary{fixtures.User().Build().Summary(), fixtures.User().Build().Summary()}
 	expectedTotal := int64(2)
 
 	mockRepo.On("FindAll", ctx, mock.AnythingOfType("user_repository.ListParams")).Return(expectedUsers, expectedTotal, nil)
 
-	users, total, err := uc.ListAll(ctx, input)
+	out, err := uc.ListAll(ctx, input)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedTotal, out.Total)
+	assert.Len(t, out.Users, 2)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestEffectiveParams(t *testing.T) {
+	tests := []struct {
+		name  string
+		input ListInput
+		want  EffectiveListParams
+	}{
+		{"defaulted", ListInput{}, EffectiveListParams{Page: 1, Size: 10, SortBy: "created_at", SortOrder: "desc"}},
+		{"valid", ListInput{Page: 3, Size: 25, SortBy: "name", SortOrder: "asc"}, EffectiveListParams{Page: 3, Size: 25, SortBy: "name", SortOrder: "asc"}},
+		{"corrected", ListInput{Page: -2, Size: 500, SortBy: "status"}, EffectiveListParams{Page: 1, Size: 100, SortBy: "status", SortOrder: "desc"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, EffectiveParams(tt.input))
+		})
+	}
+}
+
+func TestListAll_AppliesAndReturnsEffectiveParams(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := NewUseCase(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("FindAll", ctx, mock.MatchedBy(func(p user_repository.ListParams) bool {
+		return p.Page == 1 && p.Size == 100 && p.SortBy == "created_at" && p.SortOrder == "desc"
+	})).Return([]entity.UserSummary{}, int64(0), nil)
+
+	out, err := uc.ListAll(ctx, ListInput{Size: 1000})
+
+	assert.NoError(t, err)
+	assert.Equal(t, EffectiveListParams{Page: 1, Size: 100, SortBy: "created_at", SortOrder: "desc"}, out.Params)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestListAll_RejectsSortsOutsideTheWhitelist(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := NewUseCase(mockRepo)
+
+	for _, input := range []ListInput{{SortBy: "password"}, {SortOrder: "sideways"}, {SortBy: "created_at; DROP TABLE users--"}} {
+		_, err := uc.ListAll(context.Background(), input)
+		assert.ErrorIs(t, err, ErrInvalidSortField, "%+v", input)
+	}
+	mockRepo.AssertNotCalled(t, "FindAll")
+}
+
+func TestListAll_MaxOffsetBoundary(t *testing.T) {
+	tests := []struct {
+		name    string
+		page    int
+		wantErr bool
+	}{
+		{"offset exactly at max", 101, false},
+		{"offset one page past max", 102, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			uc := NewUseCase(mockRepo, WithMaxOffset(1000))
+			ctx := context.Background()
+			if !tt.wantErr {
+				mockRepo.On("FindAll", ctx, mock.AnythingOfType("user_repository.ListParams")).Return([]entity.UserSummary{}, int64(0), nil)
+			}
+
+			_, err := uc.ListAll(ctx, ListInput{Page: tt.page, Size: 10})
+
+			if tt.wantErr {
+				var limitErr *OffsetLimitError
+				assert.ErrorAs(t, err, &limitErr)
+				assert.Equal(t, 1000, limitErr.Max)
+				mockRepo.AssertNotCalled(t, "FindAll", mock.Anything, mock.Anything)
+			} else {
+				assert.NoError(t, err)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListAll_DefaultMaxOffset(t *testing.T) {
+	uc := NewUseCase(new(MockUserRepository))
+
+	_, err := uc.ListAll(context.Background(), ListInput{Page: DefaultMaxOffset + 2, Size: 1})
+
+	var limitErr *OffsetLimitError
+	assert.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, DefaultMaxOffset, limitErr.Max)
+}
+
+func TestListAll_CursorModeIgnoresMaxOffset(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := NewUseCase(mockRepo, WithMaxOffset(10))
+	ctx := context.Background()
+	createdAt := time.Date(2026, 1, 15, 10, 30, 0, 123, time.UTC)
+	cursor := encodeCursor(&entity.UserSummary{ID: "user-9", CreatedAt: createdAt})
+
+	mockRepo.On("FindAll", ctx, mock.MatchedBy(func(p user_repository.ListParams) bool {
+		return p.After != nil && p.After.ID == "user-9" && p.After.CreatedAt.Equal(createdAt)
+	})).Return([]entity.UserSummary{{ID: "user-10"}}, int64(50), nil)
+
+	// A page number far past the limit is ignored in cursor mode.
+	out, err := uc.ListAll(ctx, ListInput{Page: 1000, Size: 10, Cursor: cursor})
+
+	assert.NoError(t, err)
+	assert.Len(t, out.Users, 1)
+	assert.Equal(t, int64(50), out.Total)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestListAll_CursorProjectionKeepsKeysetColumns(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := NewUseCase(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("FindAll", ctx, mock.MatchedBy(func(p user_repository.ListParams) bool {
+		return assert.ObjectsAreEqual([]string{"name", "id", "created_at"}, p.Columns)
+	})).Return([]entity.UserSummary{}, int64(0), nil)
+
+	_, err := uc.ListAll(ctx, ListInput{Page: 1, Size: 10, Columns: []string{"name"}})
 
 	assert.NoError(t, err)
-	assert.Equal(t, expectedTotal, total)
-	assert.Len(t, users, 2)
 	mockRepo.AssertExpectations(t)
 }
 
+func TestListAll_InvalidCursor(t *testing.T) {
+	valid := encodeCursor(&entity.UserSummary{ID: "user-1", CreatedAt: time.Now()})
+	tests := []struct {
+		name   string
+		cursor string
+		sortBy string
+	}{
+		{"not base64", "%%%", ""},
+		{"missing separator", base64.RawURLEncoding.EncodeToString([]byte("user-1")), ""},
+		{"bad timestamp", base64.RawURLEncoding.EncodeToString([]byte("yesterday|user-1")), ""},
+		{"sorted by another column", valid, "name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			uc := NewUseCase(mockRepo)
+
+			_, err := uc.ListAll(context.Background(), ListInput{Page: 1, Size: 10, SortBy: tt.sortBy, Cursor: tt.cursor})
+
+			assert.ErrorIs(t, err, ErrInvalidCursor)
+			mockRepo.AssertNotCalled(t, "FindAll", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestListAll_NextCursor(t *testing.T) {
+	page := func(n int) []entity.UserSummary {
+		users := make([]entity.UserSummary, n)
+		for i := range users {
+			users[i] = fixtures.User().WithID(fmt.Sprintf("user-%d", i+1)).WithCreatedAt(fixtures.Epoch.Add(-time.Duration(i) * time.Hour)).Build().Summary()
+		}
+		return users
+	}
+	tests := []struct {
+		name      string
+		sortBy    string
+		rows      int
+		wantProbe bool
+		wantLen   int
+		wantAfter string
+	}{
+		{"more rows follow", "created_at", 3, true, 2, "user-2"},
+		{"full last page", "created_at", 2, true, 2, ""},
+		{"short last page", "created_at", 1, true, 1, ""},
+		{"name order cannot be continued", "name", 2, false, 2, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			uc := NewUseCase(mockRepo)
+			ctx := context.Background()
+			mockRepo.On("FindAll", ctx, mock.MatchedBy(func(p user_repository.ListParams) bool {
+				return p.Size == 2 && p.Probe == tt.wantProbe
+			})).Return(page(tt.rows), int64(10), nil)
+
+			out, err := uc.ListAll(ctx, ListInput{Size: 2, SortBy: tt.sortBy})
+
+			assert.NoError(t, err)
+			assert.Len(t, out.Users, tt.wantLen)
+			if tt.wantAfter == "" {
+				assert.Empty(t, out.NextCursor)
+				return
+			}
+			after, err := decodeCursor(out.NextCursor)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantAfter, after.ID)
+			assert.True(t, after.CreatedAt.Equal(out.Users[len(out.Users)-1].CreatedAt))
+		})
+	}
+}
+
 func TestUpdateUser_Success(t *testing.T) {
 	mockRepo := new(MockUserRepository)
 	uc := NewUseCase(mockRepo)
 	ctx := context.Background()
 
 	userID := "user-123"
-	updatedUser := &entity.User{
-		ID:     userID,
-		Email:  "test@example.com",
-		Name:   "New Name",
-		Phone:  "089876543210",
-		Status: entity.UserStatusActive,
-	}
+	updatedUser := fixtures.User().WithID(userID).WithName("New Name").WithPhone("089876543210").Build()
 
-	mockRepo.On("UpdateFields", ctx, userID, mock.AnythingOfType("map[string]interface {}")).Return(updatedUser, nil)
+	mockRepo.On("UpdateFields", ctx, userID, mock.AnythingOfType("map[string]interface {}"), (*time.Time)(nil)).Return(updatedUser, nil)
 
 	result, err := uc.UpdateUser(ctx, userID, UpdateInput{
-		Name:  "New Name",
-		Phone: "089876543210",
+		Name:  ptr("New Name"),
+		Phone: ptr("089876543210"),
 	})
 
 	assert.NoError(t, err)
@@ -246,13 +658,81 @@ func TestUpdateUser_Success(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestUpdateUser_PartialUpdate(t *testing.T) {
+	ctx := context.Background()
+	current := fixtures.User().WithID("user-123").Build()
+
+	tests := []struct {
+		name   string
+		input  UpdateInput
+		fields map[string]interface{} // nil: nothing is written
+	}{
+		{"name only keeps phone and status", UpdateInput{Name: ptr("New Name")}, map[string]interface{}{"name": "New Name"}},
+		{"phone only", UpdateInput{Phone: ptr("0811")}, map[string]interface{}{"phone": "0811"}},
+		{"empty phone clears it", UpdateInput{Phone: ptr("")}, map[string]interface{}{"phone": ""}},
+		{"status only", UpdateInput{Status: ptr("inactive")}, map[string]interface{}{"status": "inactive"}},
+		{"all fields", UpdateInput{Name: ptr("N"), Phone: ptr("0811"), Status: ptr("pending")}, map[string]interface{}{"name": "N", "phone": "0811", "status": "pending"}},
+		{"no fields reads the user", UpdateInput{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			if tt.fields == nil {
+				mockRepo.On("FindByID", ctx, "user-123").Return(current, nil)
+			} else {
+				mockRepo.On("UpdateFields", ctx, "user-123", tt.fields, (*time.Time)(nil)).Return(current, nil)
+			}
+
+			got, err := NewUseCase(mockRepo).UpdateUser(ctx, "user-123", tt.input)
+
+			assert.NoError(t, err)
+			assert.Equal(t, current, got)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateUser_InvalidStatus(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+
+	_, err := NewUseCase(mockRepo).UpdateUser(context.Background(), "user-123", UpdateInput{Name: ptr("N"), Status: ptr("banned")})
+
+	assert.ErrorIs(t, err, ErrInvalidStatus)
+	mockRepo.AssertNotCalled(t, "UpdateFields", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Name and status are required on a user, so setting either to "" is an
+// error rather than a clear.
+func TestUpdateUser_CannotClearNameOrStatus(t *testing.T) {
+	tests := []struct {
+		name  string
+		input UpdateInput
+		want  error
+	}{
+		{"empty name", UpdateInput{Name: ptr(""), Phone: ptr("0811")}, ErrNameRequired},
+		{"empty status", UpdateInput{Status: ptr("")}, ErrInvalidStatus},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+
+			_, err := NewUseCase(mockRepo).UpdateUser(context.Background(), "user-123", tt.input)
+
+			assert.ErrorIs(t, err, tt.want)
+			mockRepo.AssertNotCalled(t, "UpdateFields", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
+
 func TestDeleteUser_Success(t *testing.T) {
 	mockRepo := new(MockUserRepository)
 	uc := NewUseCase(mockRepo)
 	ctx := context.Background()
 
 	userID := "user-123"
-	existingUser := &entity.User{ID: userID}
+	existingUser := fixtures.User().WithID(userID).Build()
 
 	mockRepo.On("FindByID", ctx, userID).Return(existingUser, nil)
 	mockRepo.On("Delete", ctx, userID).Return(nil)
@@ -270,7 +750,7 @@ func TestDeleteUser_NotFound(t *testing.T) {
 
 	userID := "non-existent"
 
-	mockRepo.On("FindByID", ctx, userID).Return(nil, gorm.ErrRecordNotFound)
+	mockRepo.On("FindByID", ctx, userID).Return(nil, repository.ErrNotFound)
 
 	err := uc.DeleteUser(ctx, userID)
 
@@ -278,3 +758,481 @@ func TestDeleteUser_NotFound(t *testing.T) {
 	assert.Equal(t, ErrNotFound, err)
 	mockRepo.AssertExpectations(t)
 }
+
+func TestRestoreUser(t *testing.T) {
+	deleted := fixtures.User().WithID("u1").WithEmail("back@example.com").Deleted().Build()
+	tests := []struct {
+		name    string
+		found   *entity.User
+		findErr error
+		taken   *entity.User
+		wantErr error
+	}{
+		{"soft-deleted user", deleted, nil, nil, nil},
+		{"email re-registered", deleted, nil, fixtures.User().WithEmail("back@example.com").Build(), ErrEmailExists},
+		{"live user", fixtures.User().WithID("u1").Build(), nil, nil, ErrNotDeleted},
+		{"unknown user", nil, repository.ErrNotFound, nil, ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			uc := NewUseCase(mockRepo)
+			ctx := context.Background()
+			mockRepo.On("FindByIDIncludingDeleted", ctx, "u1").Return(tt.found, tt.findErr)
+			if tt.taken != nil {
+				mockRepo.On("FindByEmail", ctx, "back@example.com").Return(tt.taken, nil)
+			} else {
+				mockRepo.On("FindByEmail", ctx, "back@example.com").Return(nil, repository.ErrNotFound).Maybe()
+			}
+			mockRepo.On("Restore", ctx, "u1", map[string]interface{}(nil)).Return(fixtures.User().WithID("u1").Build(), nil).Maybe()
+
+			got, err := uc.RestoreUser(ctx, "u1")
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			if tt.wantErr == nil {
+				assert.Equal(t, "u1", got.ID)
+				mockRepo.AssertCalled(t, "Restore", ctx, "u1", map[string]interface{}(nil))
+			} else {
+				mockRepo.AssertNotCalled(t, "Restore", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+// The unique index catches an email taken after the check.
+func TestRestoreUser_DuplicateKeyRace(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := NewUseCase(mockRepo)
+	ctx := context.Background()
+	mockRepo.On("FindByIDIncludingDeleted", ctx, "u1").Return(fixtures.User().WithID("u1").WithEmail("back@example.com").Deleted().Build(), nil)
+	mockRepo.On("FindByEmail", ctx, "back@example.com").Return(nil, repository.ErrNotFound)
+	mockRepo.On("Restore", ctx, "u1", map[string]interface{}(nil)).Return(nil, user_repository.ErrDuplicateEmail)
+
+	_, err := uc.RestoreUser(ctx, "u1")
+
+	assert.ErrorIs(t, err, ErrEmailExists)
+}
+
+func TestPurgeUser(t *testing.T) {
+	for _, u := range []*entity.User{fixtures.User().WithID("u1").Build(), fixtures.User().WithID("u1").Deleted().Build()} {
+		mockRepo := new(MockUserRepository)
+		uc := NewUseCase(mockRepo)
+		ctx := context.Background()
+		mockRepo.On("FindByIDIncludingDeleted", ctx, "u1").Return(u, nil)
+		mockRepo.On("HardDelete", ctx, "u1").Return(nil)
+
+		assert.NoError(t, uc.PurgeUser(ctx, "u1"))
+		mockRepo.AssertExpectations(t)
+	}
+
+	mockRepo := new(MockUserRepository)
+	uc := NewUseCase(mockRepo)
+	mockRepo.On("FindByIDIncludingDeleted", mock.Anything, "gone").Return(nil, repository.ErrNotFound)
+	assert.ErrorIs(t, uc.PurgeUser(context.Background(), "gone"), ErrNotFound)
+	mockRepo.AssertNotCalled(t, "HardDelete", mock.Anything, mock.Anything)
+}
+
+func TestListAll_IncludeDeleted(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := NewUseCase(mockRepo)
+	ctx := context.Background()
+	mockRepo.On("FindAll", ctx, mock.MatchedBy(func(p user_repository.ListParams) bool { return p.IncludeDeleted })).
+		Return([]entity.UserSummary{}, int64(0), nil)
+
+	_, err := uc.ListAll(ctx, ListInput{IncludeDeleted: true})
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+type inTxKey struct{}
+
+// fakeTx marks the context it hands to fn, so expectations can require that
+// a repository call ran inside the transaction.
+type fakeTx struct {
+	calls int
+}
+
+func (f *fakeTx) Do(ctx context.Context, fn func(context.Context) error) error {
+	f.calls++
+	return fn(context.WithValue(ctx, inTxKey{}, true))
+}
+
+var inTx = mock.MatchedBy(func(ctx context.Context) bool {
+	v, _ := ctx.Value(inTxKey{}).(bool)
+	return v
+})
+
+func TestDeleteUser_ReadAndDeleteShareTransaction(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	tx := &fakeTx{}
+	uc := NewUseCase(mockRepo, WithTransactor(tx))
+	ctx := context.Background()
+
+	mockRepo.On("FindByID", inTx, "user-123").Return(fixtures.User().WithID("user-123").Build(), nil)
+	mockRepo.On("Delete", inTx, "user-123").Return(nil)
+
+	assert.NoError(t, uc.DeleteUser(ctx, "user-123"))
+	assert.Equal(t, 1, tx.calls)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRegister_LookupAndCreateShareTransaction(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	tx := &fakeTx{}
+	uc := NewUseCase(mockRepo, WithTransactor(tx), WithDeletedEmailPolicy(DeletedEmailBlock))
+	ctx := context.Background()
+
+	mockRepo.On("FindByEmail", inTx, "new@example.com").Return(nil, repository.ErrNotFound)
+	mockRepo.On("FindByEmailIncludingDeleted", inTx, "new@example.com").Return(nil, repository.ErrNotFound)
+	mockRepo.On("Create", inTx, mock.AnythingOfType("*entity.User")).Return(nil)
+
+	_, err := uc.Register(ctx, RegisterInput{Email: "new@example.com", Password: "password123", Name: "New"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, tx.calls)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUpdateUser_TransactionErrorMapsNotFound(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := NewUseCase(mockRepo, WithTransactor(&fakeTx{}))
+	ctx := context.Background()
+
+	mockRepo.On("UpdateFields", inTx, "missing", map[string]interface{}{"name": "X"}, (*time.Time)(nil)).Return(nil, repository.ErrNotFound)
+
+	_, err := uc.UpdateUser(ctx, "missing", UpdateInput{Name: ptr("X")})
+
+	assert.ErrorIs(t, err, ErrNotFound)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUpdateUser_ExpectedVersion(t *testing.T) {
+	ctx := context.Background()
+	version := fixtures.Epoch
+	stale := fixtures.Epoch.Add(-time.Minute)
+	current := fixtures.User().WithID("user-123").Build()
+
+	t.Run("match passes the version to the repository", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("UpdateFields", ctx, "user-123", map[string]interface{}{"name": "B"}, &version).Return(current, nil)
+
+		_, err := NewUseCase(mockRepo).UpdateUser(ctx, "user-123", UpdateInput{Name: ptr("B"), ExpectedUpdatedAt: &version})
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("UpdateFields", ctx, "user-123", map[string]interface{}{"name": "B"}, &stale).Return(nil, user_repository.ErrModified)
+
+		_, err := NewUseCase(mockRepo).UpdateUser(ctx, "user-123", UpdateInput{Name: ptr("B"), ExpectedUpdatedAt: &stale})
+
+		assert.ErrorIs(t, err, ErrVersionMismatch)
+	})
+
+	t.Run("mismatch without changes", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("FindByID", ctx, "user-123").Return(current, nil)
+
+		_, err := NewUseCase(mockRepo).UpdateUser(ctx, "user-123", UpdateInput{ExpectedUpdatedAt: &stale})
+
+		assert.ErrorIs(t, err, ErrVersionMismatch)
+	})
+
+	t.Run("absent is unconditional unless required", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("UpdateFields", ctx, "user-123", map[string]interface{}{"name": "B"}, (*time.Time)(nil)).Return(current, nil)
+
+		_, err := NewUseCase(mockRepo).UpdateUser(ctx, "user-123", UpdateInput{Name: ptr("B")})
+		assert.NoError(t, err)
+
+		_, err = NewUseCase(mockRepo, WithRequireUpdateVersion(true)).UpdateUser(ctx, "user-123", UpdateInput{Name: ptr("B")})
+		assert.ErrorIs(t, err, ErrVersionRequired)
+		mockRepo.AssertNumberOfCalls(t, "UpdateFields", 1)
+	})
+}
+
+// recordingPublisher collects published events.
+type recordingPublisher struct {
+	events []notify.Event
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, e notify.Event) {
+	p.events = append(p.events, e)
+}
+
+func TestUserEventsPublishedAfterCommit(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	events := &recordingPublisher{}
+	uc := NewUseCase(mockRepo, WithTransactor(&fakeTx{}), WithEventPublisher(events))
+	ctx := context.Background()
+	user := fixtures.User().WithID("user-123").WithEmail("a@example.com").WithName("A").WithCompany("COMPANY-001").Build()
+
+	mockRepo.On("FindByEmail", inTx, "new@example.com").Return(nil, repository.ErrNotFound)
+	mockRepo.On("Create", inTx, mock.AnythingOfType("*entity.User")).Return(nil)
+	mockRepo.On("UpdateFields", inTx, user.ID, map[string]interface{}{"name": "B"}, (*time.Time)(nil)).Return(user, nil)
+	mockRepo.On("FindByID", inTx, user.ID).Return(user, nil)
+	mockRepo.On("Delete", inTx, user.ID).Return(nil)
+	mockRepo.On("FindByID", inTx, "missing").Return(nil, repository.ErrNotFound)
+
+	_, err := uc.Register(ctx, RegisterInput{Email: "new@example.com", Password: "password123", Name: "New"})
+	assert.NoError(t, err)
+	_, err = uc.UpdateUser(ctx, user.ID, UpdateInput{Name: ptr("B")})
+	assert.NoError(t, err)
+	assert.NoError(t, uc.DeleteUser(ctx, user.ID))
+	assert.ErrorIs(t, uc.DeleteUser(ctx, "missing"), ErrNotFound)
+
+	if assert.Len(t, events.events, 3) {
+		assert.Equal(t, notify.UserRegistered, events.events[0].Type)
+		assert.JSONEq(t, `{"id":"","email":"new@example.com","name":"New"}`, string(events.events[0].Data))
+		assert.Equal(t, notify.UserUpdated, events.events[1].Type)
+		assert.Equal(t, "COMPANY-001", events.events[1].CompanyCode)
+		assert.Equal(t, notify.UserDeleted, events.events[2].Type)
+		assert.JSONEq(t, `{"id":"user-123","email":"a@example.com","name":"A"}`, string(events.events[2].Data))
+	}
+}
+
+// recordingDomainEvents collects published domain events.
+type recordingDomainEvents struct {
+	events []userevents.Event
+}
+
+func (p *recordingDomainEvents) Publish(_ context.Context, e userevents.Event) {
+	p.events = append(p.events, e)
+}
+
+func TestDomainEventsPublishedAfterCommit(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	events := &recordingDomainEvents{}
+	uc := NewUseCase(mockRepo, WithTransactor(&fakeTx{}), WithDomainEvents(events))
+	ctx := context.Background()
+	user := fixtures.User().WithID("user-123").WithEmail("a@example.com").WithName("A").WithCompany("COMPANY-001").Build()
+
+	mockRepo.On("FindByEmail", inTx, "new@example.com").Return(nil, repository.ErrNotFound)
+	mockRepo.On("Create", inTx, mock.AnythingOfType("*entity.User")).Return(nil)
+	mockRepo.On("UpdateFields", inTx, user.ID, map[string]interface{}{"name": "B"}, (*time.Time)(nil)).Return(user, nil)
+	mockRepo.On("FindByID", inTx, user.ID).Return(user, nil)
+	mockRepo.On("Delete", inTx, user.ID).Return(nil)
+	mockRepo.On("FindByEmail", ctx, user.Email).Return(user, nil)
+
+	_, err := uc.Register(ctx, RegisterInput{Email: "new@example.com", Password: "password123", Name: "New"})
+	assert.NoError(t, err)
+	_, err = uc.UpdateUser(ctx, user.ID, UpdateInput{Name: ptr("B")})
+	assert.NoError(t, err)
+	assert.NoError(t, uc.DeleteUser(ctx, user.ID))
+	_, err = uc.Login(ctx, user.Email, "wrong-password")
+	assert.ErrorIs(t, err, ErrInvalidCreds)
+	_, err = uc.Login(ctx, user.Email, fixtures.Password)
+	assert.NoError(t, err)
+
+	var types []string
+	for _, e := range events.events {
+		types = append(types, e.Type)
+	}
+	assert.Equal(t, []string{userevents.Registered, userevents.Updated, userevents.Deleted, userevents.LoggedIn}, types)
+	if assert.Len(t, events.events, 4) {
+		assert.Equal(t, userevents.User{ID: "user-123", Email: "a@example.com", Name: "A", CompanyCode: "COMPANY-001"}, events.events[3].User)
+		assert.False(t, events.events[3].OccurredAt.IsZero())
+	}
+}
+
+// fakeOutbox records staged events, noting whether each was staged inside a
+// fakeTx transaction.
+type fakeOutbox struct {
+	events []userevents.Event
+	inTx   []bool
+	err    error
+}
+
+func (o *fakeOutbox) Enqueue(ctx context.Context, e userevents.Event) error {
+	if o.err != nil {
+		return o.err
+	}
+	v, _ := ctx.Value(inTxKey{}).(bool)
+	o.events = append(o.events, e)
+	o.inTx = append(o.inTx, v)
+	return nil
+}
+
+func TestEventOutbox_StagesInTheTransaction(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	outbox := &fakeOutbox{}
+	direct := &recordingDomainEvents{}
+	uc := NewUseCase(mockRepo, WithTransactor(&fakeTx{}), WithDomainEvents(direct), WithEventOutbox(outbox))
+	ctx := context.Background()
+	user := fixtures.User().WithID("user-123").WithEmail("a@example.com").Build()
+
+	mockRepo.On("FindByID", inTx, user.ID).Return(user, nil)
+	mockRepo.On("Delete", inTx, user.ID).Return(nil)
+	mockRepo.On("FindByEmail", ctx, user.Email).Return(user, nil)
+
+	assert.NoError(t, uc.DeleteUser(ctx, user.ID))
+	_, err := uc.Login(ctx, user.Email, fixtures.Password)
+	assert.NoError(t, err)
+
+	if assert.Len(t, outbox.events, 1) {
+		assert.Equal(t, userevents.Deleted, outbox.events[0].Type)
+		assert.True(t, outbox.inTx[0], "event staged outside the delete's transaction")
+	}
+	// Only the login, which changes nothing, bypasses the outbox.
+	if assert.Len(t, direct.events, 1) {
+		assert.Equal(t, userevents.LoggedIn, direct.events[0].Type)
+	}
+}
+
+func TestEventOutbox_FailureFailsTheChange(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := NewUseCase(mockRepo, WithTransactor(&fakeTx{}), WithEventOutbox(&fakeOutbox{err: errors.New("disk full")}))
+
+	mockRepo.On("FindByID", inTx, "user-1").Return(fixtures.User().WithID("user-1").Build(), nil)
+	mockRepo.On("Delete", inTx, "user-1").Return(nil)
+
+	assert.EqualError(t, uc.DeleteUser(context.Background(), "user-1"), "disk full")
+}
+
+func TestRegister_CompanyUserLimit(t *testing.T) {
+	ctx := context.Background()
+	input := RegisterInput{Email: "new@example.com", Password: "password123", Name: "New", CompanyCode: "COMPANY-001"}
+
+	t.Run("at the limit", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		uc := NewUseCase(mockRepo, WithCompanyUserLimit(3))
+		mockRepo.On("FindByEmail", ctx, input.Email).Return(nil, repository.ErrNotFound)
+		mockRepo.On("LockCompanyUsers", ctx, "COMPANY-001").Return(int64(3), nil)
+
+		_, err := uc.Register(ctx, input)
+
+		var limitErr *entity.CompanyUserLimitError
+		if assert.ErrorAs(t, err, &limitErr) {
+			assert.Equal(t, 3, limitErr.Limit)
+		}
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("under the limit", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		uc := NewUseCase(mockRepo, WithCompanyUserLimit(3))
+		mockRepo.On("FindByEmail", ctx, input.Email).Return(nil, repository.ErrNotFound)
+		mockRepo.On("LockCompanyUsers", ctx, "COMPANY-001").Return(int64(2), nil)
+		mockRepo.On("Create", ctx, mock.MatchedBy(func(u *entity.User) bool { return u.CompanyCode == "COMPANY-001" })).Return(nil)
+
+		_, err := uc.Register(ctx, input)
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("no company", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		uc := NewUseCase(mockRepo, WithCompanyUserLimit(3))
+		mockRepo.On("FindByEmail", ctx, input.Email).Return(nil, repository.ErrNotFound)
+		mockRepo.On("Create", ctx, mock.AnythingOfType("*entity.User")).Return(nil)
+
+		_, err := uc.Register(ctx, RegisterInput{Email: input.Email, Password: input.Password, Name: input.Name})
+		assert.NoError(t, err)
+		mockRepo.AssertNotCalled(t, "LockCompanyUsers", mock.Anything, mock.Anything)
+	})
+
+	// The restored account joins the company it registers with, not the
+	// one the deleted account had.
+	t.Run("reactivation counts", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		uc := NewUseCase(mockRepo, WithCompanyUserLimit(3), WithDeletedEmailPolicy(DeletedEmailReactivate))
+		deleted := softDeletedUser(input.Email)
+		deleted.CompanyCode = "COMPANY-001"
+		mockRepo.On("FindByEmail", ctx, input.Email).Return(nil, repository.ErrNotFound)
+		mockRepo.On("FindByEmailIncludingDeleted", ctx, input.Email).Return(deleted, nil)
+		mockRepo.On("LockCompanyUsers", ctx, "COMPANY-002").Return(int64(3), nil)
+
+		_, err := uc.Register(ctx, RegisterInput{Email: input.Email, Password: input.Password, Name: input.Name, CompanyCode: "COMPANY-002"})
+
+		var limitErr *entity.CompanyUserLimitError
+		assert.ErrorAs(t, err, &limitErr)
+		mockRepo.AssertNotCalled(t, "Restore", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+// lockingRepo is an in-memory repository whose LockCompanyUsers behaves like
+// the advisory lock: it blocks until the holding transaction ends.
+type lockingRepo struct {
+	user_repository.Repository
+
+	lock  sync.Mutex // the company lock
+	mu    sync.Mutex // guards users
+	users map[string]string
+}
+
+type releaseKey struct{}
+
+func (r *lockingRepo) FindByEmail(_ context.Context, email string) (*entity.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[email]; ok {
+		return &entity.User{Email: email}, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *lockingRepo) LockCompanyUsers(ctx context.Context, companyCode string) (int64, error) {
+	r.lock.Lock()
+	*ctx.Value(releaseKey{}).(*func()) = r.lock.Unlock
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var n int64
+	for _, c := range r.users {
+		if c == companyCode {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *lockingRepo) Create(_ context.Context, u *entity.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users[u.Email] = u.CompanyCode
+	return nil
+}
+
+// lockingTx releases whatever lock fn took once fn returns, as a commit
+// releases a transaction-scoped advisory lock.
+type lockingTx struct{}
+
+func (lockingTx) Do(ctx context.Context, fn func(context.Context) error) error {
+	release := func() {}
+	defer func() { release() }()
+	return fn(context.WithValue(ctx, releaseKey{}, &release))
+}
+
+func TestRegister_CompanyUserLimitConcurrent(t *testing.T) {
+	repo := &lockingRepo{users: map[string]string{"a@example.com": "C", "b@example.com": "C"}}
+	uc := NewUseCase(repo, WithTransactor(lockingTx{}), WithCompanyUserLimit(3))
+
+	const n = 8
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = uc.Register(context.Background(), RegisterInput{
+				Email: fmt.Sprintf("u%d@example.com", i), Password: "password123", Name: "U", CompanyCode: "C",
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range errs {
+		var limitErr *entity.CompanyUserLimitError
+		switch {
+		case err == nil:
+			succeeded++
+		case !assert.ErrorAs(t, err, &limitErr):
+			return
+		}
+	}
+	assert.Equal(t, 1, succeeded)
+	assert.Len(t, repo.users, 3)
+}
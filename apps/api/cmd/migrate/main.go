@@ -3,31 +3,48 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"veemon/config"
 	"veemon/database/migrate"
 	"veemon/database/seeds"
+	"veemon/database/transfer"
+	"veemon/migrations"
+	"veemon/pkg/database"
+	"veemon/pkg/events"
+	"veemon/pkg/journal"
+	"veemon/pkg/rabbitmq"
 
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 	_ "github.com/lib/pq"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 func main() {
+	global := flag.NewFlagSet("migrate", flag.ExitOnError)
+	migrationsPath := global.String("path", "", "read migrations from this directory instead of the ones embedded in the binary")
+	global.Usage = printUsage
+	_ = global.Parse(os.Args[1:])
+	args := global.Args()
+
 	// Define commands
-	if len(os.Args) < 2 {
+	if len(args) < 1 {
 		printUsage()
 		os.Exit(1)
 	}
 
-	command := os.Args[1]
+	command := args[0]
 
 	// Load configuration
 	cfg, err := config.New()
@@ -36,53 +53,80 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Build database URL
-	dbURL := fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s",
-		cfg.DBUser,
-		cfg.DBPassword,
-		cfg.DBHost,
-		cfg.DBPort,
-		cfg.DBName,
-		cfg.DBSSLMode,
-	)
+	dbURL := migrate.DatabaseURL(cfg.DB.User, cfg.DB.Password, cfg.DB.Host, cfg.DB.Port, cfg.DB.Name, cfg.DB.SSLMode)
 
-	// Get migrations path
-	migrationsPath := getMigrationsPath()
+	if cfg.DB.TablePrefix != "" || cfg.DB.SingularTable {
+		// stderr, so export-users can stream to stdout.
+		fmt.Fprintln(os.Stderr, "WARNING: DB_TABLE_PREFIX/DB_SINGULAR_TABLE are ignored by the SQL migrations, "+
+			"which create unprefixed tables. Use DB_AUTO_MIGRATE=true for prefixed schemas.")
+	}
 
 	switch command {
 	case "up", "migrate":
-		runMigrate(dbURL, migrationsPath)
+		runMigrate(dbURL, *migrationsPath)
 	case "down":
-		runDown(dbURL, migrationsPath)
+		runDown(dbURL, *migrationsPath)
 	case "rollback":
-		runRollback(dbURL, migrationsPath)
+		runRollback(dbURL, *migrationsPath)
 	case "status", "version":
-		runStatus(dbURL, migrationsPath)
+		runStatus(dbURL, *migrationsPath)
+	case "steps":
+		if len(args) < 2 {
+			fmt.Println("Usage: migrate steps <n>")
+			os.Exit(1)
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n == 0 {
+			fmt.Printf("Invalid step count: %s (want a non-zero integer)\n", args[1])
+			os.Exit(1)
+		}
+		runSteps(dbURL, *migrationsPath, n)
+	case "goto":
+		if len(args) < 2 {
+			fmt.Println("Usage: migrate goto <version>")
+			os.Exit(1)
+		}
+		version, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			fmt.Printf("Invalid version: %s\n", args[1])
+			os.Exit(1)
+		}
+		runGoto(dbURL, *migrationsPath, uint(version))
+	case "plan":
+		runPlan(dbURL, *migrationsPath)
 	case "force":
-		if len(os.Args) < 3 {
+		if len(args) < 2 {
 			fmt.Println("Usage: migrate force <version>")
 			os.Exit(1)
 		}
-		version, err := strconv.Atoi(os.Args[2])
+		version, err := strconv.Atoi(args[1])
 		if err != nil {
-			fmt.Printf("Invalid version: %s\n", os.Args[2])
+			fmt.Printf("Invalid version: %s\n", args[1])
 			os.Exit(1)
 		}
-		runForce(dbURL, migrationsPath, version)
+		runForce(dbURL, *migrationsPath, version)
 	case "create":
-		if len(os.Args) < 3 {
-			fmt.Println("Usage: migrate create <name>")
-			os.Exit(1)
+		createDir := *migrationsPath
+		if createDir == "" {
+			createDir = getMigrationsPath()
 		}
-		runCreate(migrationsPath, os.Args[2])
+		runCreate(createDir, cfg.DB.MigrationFormat, args[1:])
 	case "seed":
-		runSeed(cfg)
+		runSeed(cfg, args[1:])
 	case "fresh":
-		runFresh(dbURL, migrationsPath, cfg)
+		runFresh(dbURL, *migrationsPath, cfg, args[1:])
 	case "refresh":
-		runRefresh(dbURL, migrationsPath, cfg)
+		runRefresh(dbURL, *migrationsPath, cfg, args[1:])
 	case "reset":
-		runReset(dbURL, migrationsPath)
+		runReset(dbURL, *migrationsPath)
+	case "export-users":
+		runExportUsers(cfg, args[1:])
+	case "import-users":
+		runImportUsers(cfg, args[1:])
+	case "journal-replay":
+		runJournalReplay(cfg, args[1:])
+	case "replay-events":
+		runReplayEvents(cfg, args[1:])
 	default:
 		fmt.Printf("Unknown command: %s\n", command)
 		printUsage()
@@ -94,29 +138,101 @@ func printUsage() {
 	fmt.Println(`Database Migration Tool
 
 Usage:
-  migrate <command> [arguments]
+  migrate [--path <dir>] <command> [arguments]
+
+  Migrations are embedded in the binary; --path reads them from a directory
+  instead (create writes to it, default ./migrations).
 
 Commands:
   up, migrate     Run all pending migrations
   down            Rollback all migrations
   rollback        Rollback the last migration
   status          Show current migration version
+  steps <n>       Apply the next n migrations, or roll back -n
+  goto <version>  Migrate up or down to version (0 rolls back all)
+  plan            List pending migrations without running them
   force <version> Force set migration version (use with caution)
   create <name>   Create a new migration file
-  seed            Run database seeders
+                    --format <f>  sequential or timestamp (default DB_MIGRATION_FORMAT)
+                    --dir <path>  migrations directory (default ./migrations)
+                    --tx          wrap the up and down SQL in BEGIN/COMMIT
+  seed            Run the database seeders enabled in ENVIRONMENT
+                    --only <a,b>  run just these seeders
+                    --list        list the registered seeders
+                    --force       run destructive seeders in production and
+                                  rerun seeders that already ran
+                    --fail-fast   stop at the first error
+                    --fake --users <n> [--seed-value <s>]
+                                  insert n generated users for load testing;
+                                  the same seed value yields the same users
   fresh           Drop all tables and re-run all migrations
   refresh         Rollback all migrations and re-run them
   reset           Rollback all migrations
+  export-users    Write all users as JSON lines
+                    --out <file|->  destination (default stdout)
+                    --anonymize     replace names, emails, phones and passwords
+  import-users    Upsert users (by email) from JSON lines
+                    --in <file|->   source (default stdin)
+                    --dry-run       report what would change, write nothing
+                    --password <p>  password for created users without a hash
+                    --override-user-limit --reason <r>
+                                    ignore COMPANY_MAX_USERS (audit-logged)
+  journal-replay  Re-send journaled requests (see JOURNAL_ENABLED)
+                    --file <f>           journal file, or
+                    --stream <name>      Redis stream (JOURNAL_SINK=redis)
+                    --base-url <url>     target (default http://localhost:HTTP_PORT)
+                    --token <t>          bearer token for authenticated entries
+                    --redacted-value <v> substitute for masked body values
+                    --dry-run            print requests without sending
+  replay-events   Re-publish user events to RabbitMQ, e.g. to rebuild a read model
+                    --type <t,...>   user.registered, user.updated, user.deleted (default all)
+                    --from <date>    skip events before it (YYYY-MM-DD or RFC 3339)
+                    --rate <n/s>     publish rate, e.g. 200/s or 6000/m (default 100/s)
+                    --after <offset> resume after the offset an earlier run reported
+                    --exchange <x>   target exchange (default the events exchange)
+                    --dry-run        count the events without publishing
 
 Examples:
   migrate up
+  migrate --path ./migrations up
   migrate rollback
+  migrate steps -2
+  migrate goto 3
+  migrate plan
   migrate create add_users_table
+  migrate create add_orders_table --format timestamp --tx
   migrate seed
+  migrate seed --only users --force
+  migrate seed --fake --users 10000 --seed-value 42
   migrate fresh
-  migrate force 1`)
+  migrate force 1
+  migrate export-users --out users.jsonl --anonymize
+  migrate import-users --in users.jsonl --dry-run
+  migrate journal-replay --file journal/requests.jsonl --base-url http://scratch:3000 --token $TOKEN
+  migrate replay-events --type user.registered --from 2025-01-01 --rate 200/s`)
 }
 
+// newMigrator reads the migrations embedded in the binary, or the ones in
+// migrationsPath when --path is given.
+func newMigrator(dbURL, migrationsPath string) *migrate.Migrator {
+	var (
+		m   *migrate.Migrator
+		err error
+	)
+	if migrationsPath != "" {
+		m, err = migrate.New(migrate.Config{DatabaseURL: dbURL, MigrationsPath: migrationsPath})
+	} else {
+		m, err = migrate.NewFromFS(migrate.Config{DatabaseURL: dbURL}, migrations.FS)
+	}
+	if err != nil {
+		fmt.Printf("Failed to create migrator: %v\n", err)
+		os.Exit(1)
+	}
+	return m
+}
+
+// getMigrationsPath finds the source tree's migrations directory, where
+// create writes new files.
 func getMigrationsPath() string {
 	// Try to find migrations directory
 	paths := []string{
@@ -144,14 +260,7 @@ func getMigrationsPath() string {
 func runMigrate(dbURL, migrationsPath string) {
 	fmt.Println("Running migrations...")
 
-	m, err := migrate.New(migrate.Config{
-		DatabaseURL:    dbURL,
-		MigrationsPath: migrationsPath,
-	})
-	if err != nil {
-		fmt.Printf("Failed to create migrator: %v\n", err)
-		os.Exit(1)
-	}
+	m := newMigrator(dbURL, migrationsPath)
 	defer func() { _ = m.Close() }()
 
 	if err := m.Up(); err != nil {
@@ -166,14 +275,7 @@ func runMigrate(dbURL, migrationsPath string) {
 func runDown(dbURL, migrationsPath string) {
 	fmt.Println("Rolling back all migrations...")
 
-	m, err := migrate.New(migrate.Config{
-		DatabaseURL:    dbURL,
-		MigrationsPath: migrationsPath,
-	})
-	if err != nil {
-		fmt.Printf("Failed to create migrator: %v\n", err)
-		os.Exit(1)
-	}
+	m := newMigrator(dbURL, migrationsPath)
 	defer func() { _ = m.Close() }()
 
 	if err := m.Down(); err != nil {
@@ -187,14 +289,7 @@ func runDown(dbURL, migrationsPath string) {
 func runRollback(dbURL, migrationsPath string) {
 	fmt.Println("Rolling back last migration...")
 
-	m, err := migrate.New(migrate.Config{
-		DatabaseURL:    dbURL,
-		MigrationsPath: migrationsPath,
-	})
-	if err != nil {
-		fmt.Printf("Failed to create migrator: %v\n", err)
-		os.Exit(1)
-	}
+	m := newMigrator(dbURL, migrationsPath)
 	defer func() { _ = m.Close() }()
 
 	if err := m.Rollback(); err != nil {
@@ -206,15 +301,69 @@ func runRollback(dbURL, migrationsPath string) {
 	fmt.Printf("Rollback complete. Current version: %d (dirty: %v)\n", version, dirty)
 }
 
-func runStatus(dbURL, migrationsPath string) {
-	m, err := migrate.New(migrate.Config{
-		DatabaseURL:    dbURL,
-		MigrationsPath: migrationsPath,
-	})
+func runSteps(dbURL, migrationsPath string, n int) {
+	if n > 0 {
+		fmt.Printf("Applying %d migration(s)...\n", n)
+	} else {
+		fmt.Printf("Rolling back %d migration(s)...\n", -n)
+	}
+
+	m := newMigrator(dbURL, migrationsPath)
+	defer func() { _ = m.Close() }()
+
+	if err := m.Steps(n); err != nil {
+		fmt.Printf("Steps failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	version, dirty, _ := m.Version()
+	fmt.Printf("Steps complete. Current version: %d (dirty: %v)\n", version, dirty)
+}
+
+func runGoto(dbURL, migrationsPath string, version uint) {
+	fmt.Printf("Migrating to version %d...\n", version)
+
+	m := newMigrator(dbURL, migrationsPath)
+	defer func() { _ = m.Close() }()
+
+	if err := m.Goto(version); err != nil {
+		fmt.Printf("Goto failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	current, dirty, _ := m.Version()
+	fmt.Printf("Goto complete. Current version: %d (dirty: %v)\n", current, dirty)
+}
+
+// runPlan prints the migrations "up" would run, one file per line, so CI can
+// show them before a deploy applies them.
+func runPlan(dbURL, migrationsPath string) {
+	m := newMigrator(dbURL, migrationsPath)
+	defer func() { _ = m.Close() }()
+
+	pending, err := m.Plan()
 	if err != nil {
-		fmt.Printf("Failed to create migrator: %v\n", err)
+		fmt.Printf("Plan failed: %v\n", err)
 		os.Exit(1)
 	}
+
+	if version, dirty, err := m.Version(); err != nil {
+		fmt.Println("Current version: none")
+	} else {
+		fmt.Printf("Current version: %d (dirty: %v)\n", version, dirty)
+	}
+	if len(pending) == 0 {
+		fmt.Println("No pending migrations.")
+		return
+	}
+	fmt.Printf("Pending migrations: %d\n", len(pending))
+	for _, p := range pending {
+		fmt.Printf("  %d\t%s\n", p.Version, p.File)
+	}
+}
+
+func runStatus(dbURL, migrationsPath string) {
+	m := newMigrator(dbURL, migrationsPath)
 	defer func() { _ = m.Close() }()
 
 	version, dirty, err := m.Version()
@@ -232,14 +381,7 @@ func runStatus(dbURL, migrationsPath string) {
 func runForce(dbURL, migrationsPath string, version int) {
 	fmt.Printf("Forcing migration version to %d...\n", version)
 
-	m, err := migrate.New(migrate.Config{
-		DatabaseURL:    dbURL,
-		MigrationsPath: migrationsPath,
-	})
-	if err != nil {
-		fmt.Printf("Failed to create migrator: %v\n", err)
-		os.Exit(1)
-	}
+	m := newMigrator(dbURL, migrationsPath)
 	defer func() { _ = m.Close() }()
 
 	if err := m.Force(version); err != nil {
@@ -250,68 +392,109 @@ func runForce(dbURL, migrationsPath string, version int) {
 	fmt.Printf("Migration version forced to %d\n", version)
 }
 
-func runCreate(migrationsPath, name string) {
-	timestamp := time.Now().Format("20060102150405")
-
-	// Get the next migration number
-	files, err := os.ReadDir(migrationsPath)
-	if err != nil && !os.IsNotExist(err) {
-		fmt.Printf("Failed to read migrations directory: %v\n", err)
+func runCreate(migrationsPath, format string, args []string) {
+	fs := flag.NewFlagSet("create", flag.ExitOnError)
+	fs.StringVar(&format, "format", format, "version numbering: sequential or timestamp (default DB_MIGRATION_FORMAT)")
+	dir := fs.String("dir", migrationsPath, "migrations directory")
+	tx := fs.Bool("tx", false, "wrap the templates in BEGIN/COMMIT")
+	_ = fs.Parse(args)
+	// Flags may follow the name too: create add_orders --tx.
+	if fs.NArg() < 1 {
+		fmt.Println("Usage: migrate create [--format sequential|timestamp] [--dir <path>] [--tx] <name>")
 		os.Exit(1)
 	}
+	name := fs.Arg(0)
+	_ = fs.Parse(fs.Args()[1:])
 
-	maxNum := 0
-	for _, f := range files {
-		if f.IsDir() {
-			continue
-		}
-		var num int
-		if _, err := fmt.Sscanf(f.Name(), "%06d_", &num); err == nil {
-			if num > maxNum {
-				maxNum = num
-			}
-		}
-	}
-
-	nextNum := maxNum + 1
-
-	// Create migration files
-	upFile := filepath.Join(migrationsPath, fmt.Sprintf("%06d_%s.up.sql", nextNum, name))
-	downFile := filepath.Join(migrationsPath, fmt.Sprintf("%06d_%s.down.sql", nextNum, name))
-
-	upContent := fmt.Sprintf("-- %06d_%s.up.sql\n-- Created at: %s\n\n-- Add your migration SQL here\n", nextNum, name, timestamp)
-	downContent := fmt.Sprintf("-- %06d_%s.down.sql\n-- Created at: %s\n\n-- Add your rollback SQL here\n", nextNum, name, timestamp)
-
-	if err := os.WriteFile(upFile, []byte(upContent), 0600); err != nil {
-		fmt.Printf("Failed to create up migration: %v\n", err)
-		os.Exit(1)
-	}
-
-	if err := os.WriteFile(downFile, []byte(downContent), 0600); err != nil {
-		fmt.Printf("Failed to create down migration: %v\n", err)
+	upFile, downFile, err := migrate.Create(*dir, name, migrate.CreateOptions{Format: format, Tx: *tx})
+	if err != nil {
+		fmt.Printf("Failed to create migration: %v\n", err)
 		os.Exit(1)
 	}
 
 	fmt.Printf("Created migration files:\n  %s\n  %s\n", upFile, downFile)
 }
 
-func runSeed(cfg *config.Config) {
-	fmt.Println("Running seeders...")
-
+// openDB connects with the server's table naming, so seeds and imports land
+// in the tables it reads.
+func openDB(cfg *config.Config) *gorm.DB {
 	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s TimeZone=%s",
-		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName, cfg.DBSSLMode, cfg.DBTimezone)
+		cfg.DB.Host, cfg.DB.Port, cfg.DB.User, cfg.DB.Password, cfg.DB.Name, cfg.DB.SSLMode, cfg.DB.Timezone)
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		NamingStrategy: database.NamingStrategy(cfg.DB.TablePrefix, cfg.DB.SingularTable),
+	})
 	if err != nil {
 		fmt.Printf("Failed to connect to database: %v\n", err)
 		os.Exit(1)
 	}
+	return db
+}
+
+func runSeed(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	failFast := fs.Bool("fail-fast", false, "stop at the first failed row instead of reporting every failure")
+	only := fs.String("only", "", "comma-separated seeders to run (default all enabled in ENVIRONMENT)")
+	list := fs.Bool("list", false, "list the registered seeders and exit")
+	force := fs.Bool("force", false, "run destructive seeders in production and rerun seeders that already ran")
+	fake := fs.Bool("fake", false, "insert generated users for load testing instead of running the seeders")
+	fakeUsers := fs.Int("users", 1000, "with --fake: users to generate")
+	seedValue := fs.Uint64("seed-value", 0, "with --fake: seed for reproducible data (default random)")
+	batchSize := fs.Int("batch-size", seeds.DefaultFakeBatchSize, "with --fake: users per INSERT")
+	_ = fs.Parse(args)
+
+	if *fake {
+		if cfg.Environment == seeds.Production && !*force {
+			fmt.Println("Refusing to insert fake users in production without --force")
+			os.Exit(1)
+		}
+		runSeedFake(cfg, seeds.FakeOptions{Users: *fakeUsers, Seed: *seedValue, BatchSize: *batchSize})
+		return
+	}
+
+	if *list {
+		for _, d := range seeds.Registered() {
+			envs := "any"
+			if len(d.Environments) > 0 {
+				envs = strings.Join(d.Environments, ",")
+			}
+			fmt.Printf("  %-20s environments: %s  destructive: %v\n", d.Name, envs, d.Destructive)
+		}
+		return
+	}
+
+	opts := seeds.SeedAllOptions{FailFast: *failFast, Environment: cfg.Environment, Force: *force}
+	if *only != "" {
+		for _, name := range strings.Split(*only, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				opts.Only = append(opts.Only, name)
+			}
+		}
+	}
+
+	fmt.Printf("Running seeders (environment: %s)...\n", opts.Environment)
+
+	db := openDB(cfg)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer cancel()
 
 	seeder := seeds.New(db)
-	if err := seeder.SeedAll(ctx); err != nil {
+	report, err := seeder.SeedAll(ctx, opts)
+	for _, res := range report.Results {
+		if res.NotRun != "" {
+			fmt.Printf("  %s: not run (%s)\n", res.Seeder, res.NotRun)
+			continue
+		}
+		fmt.Printf("  %s: %d created, %d skipped, %d failed\n", res.Seeder, len(res.Created), len(res.Skipped), len(res.Failed))
+		for _, key := range res.Created {
+			fmt.Printf("    created %s\n", key)
+		}
+		for _, f := range res.Failed {
+			fmt.Printf("    failed %s: %s\n", f.Key, f.Reason)
+		}
+	}
+	if err != nil {
 		fmt.Printf("Seeding failed: %v\n", err)
 		os.Exit(1)
 	}
@@ -319,18 +502,30 @@ func runSeed(cfg *config.Config) {
 	fmt.Println("Seeding complete!")
 }
 
-func runFresh(dbURL, migrationsPath string, cfg *config.Config) {
-	fmt.Println("Running fresh migration (drop all and migrate)...")
+func runSeedFake(cfg *config.Config, opts seeds.FakeOptions) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
-	// First, rollback all migrations
-	m, err := migrate.New(migrate.Config{
-		DatabaseURL:    dbURL,
-		MigrationsPath: migrationsPath,
-	})
+	fmt.Printf("Inserting %d fake users...\n", opts.Users)
+	start := time.Now()
+	opts.Progress = func(done int) {
+		fmt.Printf("  %d/%d users (%.0f/s)\n", done, opts.Users, float64(done)/time.Since(start).Seconds())
+	}
+	report, err := seeds.New(openDB(cfg)).SeedFake(ctx, opts)
 	if err != nil {
-		fmt.Printf("Failed to create migrator: %v\n", err)
+		fmt.Printf("Fake seeding failed (seed %d): %v\n", report.Seed, err)
 		os.Exit(1)
 	}
+	fmt.Printf("Fake seeding complete: %d created, %d skipped in %s (%.0f users/s), seed %d, password %s\n",
+		report.Created, report.Skipped, report.Elapsed.Round(time.Millisecond),
+		float64(report.Created+report.Skipped)/report.Elapsed.Seconds(), report.Seed, seeds.FakePassword)
+}
+
+func runFresh(dbURL, migrationsPath string, cfg *config.Config, args []string) {
+	fmt.Println("Running fresh migration (drop all and migrate)...")
+
+	// First, rollback all migrations
+	m := newMigrator(dbURL, migrationsPath)
 
 	_ = m.Down() // Ignore error if no migrations
 	_ = m.Close()
@@ -343,7 +538,7 @@ func runFresh(dbURL, migrationsPath string, cfg *config.Config) {
 	flag.BoolVar(&seedFlag, "seed", false, "Run seeders after migration")
 
 	// Check if --seed flag is present in remaining args
-	for _, arg := range os.Args[2:] {
+	for _, arg := range args {
 		if arg == "--seed" || arg == "-seed" {
 			seedFlag = true
 			break
@@ -351,20 +546,20 @@ func runFresh(dbURL, migrationsPath string, cfg *config.Config) {
 	}
 
 	if seedFlag {
-		runSeed(cfg)
+		runSeed(cfg, nil)
 	}
 }
 
-func runRefresh(dbURL, migrationsPath string, cfg *config.Config) {
+func runRefresh(dbURL, migrationsPath string, cfg *config.Config, args []string) {
 	fmt.Println("Refreshing migrations (rollback and migrate)...")
 
 	runReset(dbURL, migrationsPath)
 	runMigrate(dbURL, migrationsPath)
 
 	// Check if --seed flag is present
-	for _, arg := range os.Args[2:] {
+	for _, arg := range args {
 		if arg == "--seed" || arg == "-seed" {
-			runSeed(cfg)
+			runSeed(cfg, nil)
 			break
 		}
 	}
@@ -374,3 +569,278 @@ func runReset(dbURL, migrationsPath string) {
 	fmt.Println("Resetting database (rollback all)...")
 	runDown(dbURL, migrationsPath)
 }
+
+func runExportUsers(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("export-users", flag.ExitOnError)
+	out := fs.String("out", "-", "output file, - for stdout")
+	anonymize := fs.Bool("anonymize", false, "replace personal data")
+	batchSize := fs.Int("batch-size", transfer.DefaultBatchSize, "users per query")
+	_ = fs.Parse(args)
+
+	// Progress goes to stderr so stdout can carry the data.
+	w := os.Stdout
+	if *out != "-" {
+		f, err := os.OpenFile(*out, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create %s: %v\n", *out, err)
+			os.Exit(1)
+		}
+		defer func() { _ = f.Close() }()
+		w = f
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	n, err := transfer.Export(ctx, openDB(cfg), w, transfer.ExportOptions{
+		Anonymize: *anonymize,
+		BatchSize: *batchSize,
+		Progress:  func(n int) { fmt.Fprintf(os.Stderr, "  exported %d users\n", n) },
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Export failed after %d users: %v\n", n, err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "Export complete: %d users (anonymized: %v)\n", n, *anonymize)
+}
+
+func runImportUsers(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("import-users", flag.ExitOnError)
+	in := fs.String("in", "-", "input file, - for stdin")
+	dryRun := fs.Bool("dry-run", false, "report what would change without writing")
+	password := fs.String("password", "", "password for created users without a hash")
+	batchSize := fs.Int("batch-size", transfer.DefaultBatchSize, "users per transaction")
+	override := fs.Bool("override-user-limit", false, "import even if a company exceeds COMPANY_MAX_USERS (audit-logged)")
+	reason := fs.String("reason", "", "why the user limit is overridden; required with --override-user-limit")
+	_ = fs.Parse(args)
+
+	limit := cfg.CompanyMaxUsers
+	if *override {
+		if strings.TrimSpace(*reason) == "" {
+			fmt.Println("--override-user-limit requires --reason")
+			os.Exit(1)
+		}
+		auditLog, err := zap.NewProduction()
+		if err != nil {
+			auditLog = zap.NewNop()
+		}
+		auditLog.Info("audit: company user limit overridden",
+			zap.String("audit_event", "company.user_limit_override"),
+			zap.String("command", "import-users"),
+			zap.String("operator", os.Getenv("USER")),
+			zap.String("input", *in),
+			zap.Int("limit", limit),
+			zap.String("reason", *reason),
+			zap.Bool("dry_run", *dryRun),
+		)
+		_ = auditLog.Sync()
+		limit = 0
+	}
+
+	r := os.Stdin
+	if *in != "-" {
+		f, err := os.Open(*in)
+		if err != nil {
+			fmt.Printf("Failed to open %s: %v\n", *in, err)
+			os.Exit(1)
+		}
+		defer func() { _ = f.Close() }()
+		r = f
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	verb := "Importing"
+	if *dryRun {
+		verb = "Dry run: checking"
+	}
+	fmt.Printf("%s users from %s...\n", verb, *in)
+	stats, err := transfer.Import(ctx, openDB(cfg), r, transfer.ImportOptions{
+		DryRun:             *dryRun,
+		BatchSize:          *batchSize,
+		DefaultPassword:    *password,
+		MaxUsersPerCompany: limit,
+		Progress: func(s transfer.ImportStats) {
+			fmt.Printf("  %d created, %d updated, %d unchanged\n", s.Created, s.Updated, s.Unchanged)
+		},
+	})
+	for _, e := range stats.Invalid {
+		fmt.Printf("  skipped %v\n", e)
+	}
+	if err != nil {
+		fmt.Printf("Import failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	summary := "Import complete"
+	if *dryRun {
+		summary = "Dry run complete (nothing written)"
+	}
+	fmt.Printf("%s: %d created, %d updated, %d unchanged, %d invalid\n",
+		summary, stats.Created, stats.Updated, stats.Unchanged, len(stats.Invalid))
+	if len(stats.Invalid) > 0 {
+		os.Exit(1)
+	}
+}
+
+func runJournalReplay(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("journal-replay", flag.ExitOnError)
+	file := fs.String("file", "", "journal file written by JOURNAL_SINK=file")
+	stream := fs.String("stream", "", "Redis stream written by JOURNAL_SINK=redis")
+	baseURL := fs.String("base-url", fmt.Sprintf("http://localhost:%d", cfg.HTTP.Port), "target base URL")
+	tok := fs.String("token", "", "bearer token sent in place of the original credentials")
+	redacted := fs.String("redacted-value", "", "value substituted for masked body fields")
+	dryRun := fs.Bool("dry-run", false, "print the requests without sending them")
+	_ = fs.Parse(args)
+
+	if (*file == "") == (*stream == "") {
+		fmt.Println("Usage: migrate journal-replay (--file <path> | --stream <name>) [--base-url <url>] [--token <t>] [--dry-run]")
+		os.Exit(1)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var entries []journal.Entry
+	var err error
+	if *file != "" {
+		f, openErr := os.Open(*file)
+		if openErr != nil {
+			fmt.Printf("Failed to open %s: %v\n", *file, openErr)
+			os.Exit(1)
+		}
+		entries, err = journal.ReadEntries(f)
+		_ = f.Close()
+	} else {
+		rc, redisErr := config.NewRedis(cfg, zap.NewNop())
+		if redisErr != nil {
+			fmt.Printf("Failed to connect to Redis: %v\n", redisErr)
+			os.Exit(1)
+		}
+		entries, err = journal.ReadStream(ctx, rc, *stream)
+		_ = rc.Close()
+	}
+	if err != nil {
+		fmt.Printf("Failed to read journal: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Replaying %d requests against %s (dry run: %v)...\n", len(entries), *baseURL, *dryRun)
+	stats, err := journal.Replay(ctx, entries, journal.ReplayOptions{
+		BaseURL:       *baseURL,
+		Token:         *tok,
+		RedactedValue: *redacted,
+		DryRun:        *dryRun,
+		Client:        &http.Client{Timeout: 30 * time.Second},
+		Out:           os.Stdout,
+	})
+	if err != nil {
+		fmt.Printf("Replay stopped: %v\n", err)
+		os.Exit(1)
+	}
+	if !*dryRun {
+		fmt.Printf("Replay complete: %d sent, %d matched the recorded status, %d differed\n",
+			stats.Sent, stats.Matched, stats.Mismatched)
+	}
+}
+
+func runReplayEvents(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("replay-events", flag.ExitOnError)
+	types := fs.String("type", "", "comma-separated event types (default all)")
+	from := fs.String("from", "", "skip events before this date (YYYY-MM-DD or RFC 3339)")
+	rateFlag := fs.String("rate", "100/s", "publish rate, e.g. 200/s or 6000/m")
+	after := fs.String("after", "", "resume after this offset, as reported by an interrupted run")
+	exchange := fs.String("exchange", config.EventsExchange, "exchange to publish to; the routing key is the event type")
+	batchSize := fs.Int("batch-size", events.DefaultReplayBatchSize, "events per query")
+	dryRun := fs.Bool("dry-run", false, "count the events without publishing")
+	_ = fs.Parse(args)
+
+	perSecond, err := events.ParseRate(*rateFlag)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	offset, err := events.ParseOffset(*after)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	opts := transfer.UserEventsOptions{Source: cfg.ServiceName}
+	if *types != "" {
+		opts.Types = strings.Split(*types, ",")
+	}
+	if *from != "" {
+		if opts.From, err = time.Parse(time.DateOnly, *from); err != nil {
+			if opts.From, err = time.Parse(time.RFC3339, *from); err != nil {
+				fmt.Printf("Invalid --from %q: want YYYY-MM-DD or RFC 3339\n", *from)
+				os.Exit(1)
+			}
+		}
+	}
+	src, err := transfer.UserEvents(openDB(cfg), opts)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	var pub events.ReplayPublisher
+	if !*dryRun {
+		client, err := config.NewRabbitMQ(cfg, zap.NewNop())
+		if err != nil {
+			fmt.Printf("Failed to connect to RabbitMQ: %v\n", err)
+			os.Exit(1)
+		}
+		defer func() { _ = client.Close() }()
+		pub = rabbitReplayPublisher{client: client, exchange: *exchange}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Printf("Replaying user events to %q at %s (dry run: %v)...\n", *exchange, *rateFlag, *dryRun)
+	progress, err := events.Replay(ctx, src, pub, events.ReplayOptions{
+		Rate:      perSecond,
+		After:     offset,
+		BatchSize: *batchSize,
+		DryRun:    *dryRun,
+		Progress: func(p events.ReplayProgress) {
+			fmt.Printf("  %d/%d events (%.0f/s, ETA %s), offset %s\n", p.Published, p.Total,
+				float64(p.Published)/p.Elapsed.Seconds(), p.ETA().Round(time.Second), p.Offset)
+		},
+	})
+	if err != nil {
+		fmt.Printf("Replay stopped after %d events: %v\n", progress.Published, err)
+		if !progress.Offset.IsZero() {
+			fmt.Printf("Resume with: --after '%s'\n", progress.Offset)
+		}
+		os.Exit(1)
+	}
+	if *dryRun {
+		fmt.Printf("Dry run complete: %d events would be published\n", progress.Published)
+		return
+	}
+	fmt.Printf("Replay complete: %d events published in %s\n", progress.Published, progress.Elapsed.Round(time.Second))
+}
+
+// rabbitReplayPublisher publishes replayed envelopes with publisher
+// confirms, routed by event type.
+type rabbitReplayPublisher struct {
+	client   *rabbitmq.Client
+	exchange string
+}
+
+func (p rabbitReplayPublisher) PublishReplay(ctx context.Context, env events.Envelope, headers map[string]any) error {
+	body, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	return p.client.PublishConfirmed(ctx, p.exchange, env.Type, amqp.Publishing{
+		ContentType:  events.ContentTypeJSON,
+		MessageId:    env.ID,
+		Type:         env.Type,
+		Timestamp:    env.OccurredAt,
+		DeliveryMode: amqp.Persistent,
+		Headers:      amqp.Table(headers),
+		Body:         body,
+	})
+}
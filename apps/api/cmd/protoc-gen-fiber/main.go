@@ -29,7 +29,12 @@ const (
 	responsePkg   = protogen.GoImportPath("veemon/pkg/response")
 	errorsPkg     = protogen.GoImportPath("veemon/pkg/errors")
 	protoPkg      = protogen.GoImportPath("google.golang.org/protobuf/proto")
+	bindingPkg    = protogen.GoImportPath("veemon/pkg/binding")
 	contextPkg    = protogen.GoImportPath("context")
+	grpcPkg       = protogen.GoImportPath("google.golang.org/grpc")
+	metadataPkg   = protogen.GoImportPath("google.golang.org/grpc/metadata")
+	peerPkg       = protogen.GoImportPath("google.golang.org/grpc/peer")
+	netPkg        = protogen.GoImportPath("net")
 	timePkg       = protogen.GoImportPath("time")
 	emptyFullName = "google.protobuf.Empty"
 )
@@ -158,21 +163,54 @@ func generateService(g *protogen.GeneratedFile, svc *protogen.Service) error {
 
 	// --- Per-method handler constructors ---
 	for _, rt := range routes {
-		generateHandler(g, svcName, rt.m, rt.r)
+		generateHandler(g, svcName, "/"+fullName+"/"+string(rt.m.Desc.Name()), rt.m, rt.r)
 	}
 
 	// --- Shared helpers (one set per service) ---
-	g.P("// _", svcName, "_ctx propagates the auth context (if present) into the")
-	g.P("// request context passed to the handler.")
-	g.P("func _", svcName, "_ctx(c *", fiberCtx, ") ", g.QualifiedGoIdent(contextPkg.Ident("Context")), " {")
+	md := g.QualifiedGoIdent(metadataPkg.Ident("MD"))
+	g.P("// _", svcName, "_ctx builds the context passed to the handler: the auth")
+	g.P("// context (if present), the request headers as incoming gRPC metadata, the")
+	g.P("// client IP as the gRPC peer, and a transport stream that turns")
+	g.P("// grpc.SetHeader into response headers, so a handler reads and writes")
+	g.P("// headers and sees its caller the same way over both transports.")
+	g.P("func _", svcName, "_ctx(c *", fiberCtx, ", method string) ", g.QualifiedGoIdent(contextPkg.Ident("Context")), " {")
 	g.P("\tctx := c.UserContext()")
 	g.P("\tif ac, ok := ", g.QualifiedGoIdent(middlewarePkg.Ident("GetAuthContext")), "(c); ok {")
 	g.P("\t\tctx = ", g.QualifiedGoIdent(middlewarePkg.Ident("WithAuthContext")), "(ctx, ac)")
 	g.P("\t}")
-	g.P("\treturn ctx")
+	g.P("\tmd := ", md, "{}")
+	g.P("\tc.Request().Header.VisitAll(func(k, v []byte) {")
+	g.P("\t\tmd.Append(string(k), string(v))")
+	g.P("\t})")
+	g.P("\tctx = ", g.QualifiedGoIdent(metadataPkg.Ident("NewIncomingContext")), "(ctx, md)")
+	g.P("\tctx = ", g.QualifiedGoIdent(peerPkg.Ident("NewContext")), "(ctx, &", g.QualifiedGoIdent(peerPkg.Ident("Peer")), "{Addr: &", g.QualifiedGoIdent(netPkg.Ident("IPAddr")), "{IP: ", g.QualifiedGoIdent(netPkg.Ident("ParseIP")), "(c.IP())}})")
+	g.P("\treturn ", g.QualifiedGoIdent(grpcPkg.Ident("NewContextWithServerTransportStream")), "(ctx, &_", svcName, "_stream{c: c, method: method})")
 	g.P("}")
 	g.P()
 
+	g.P("// _", svcName, "_stream writes header metadata set by a handler to the HTTP")
+	g.P("// response. HTTP/1.1 responses have no trailers, so trailers become headers.")
+	g.P("type _", svcName, "_stream struct {")
+	g.P("\tc      *", fiberCtx)
+	g.P("\tmethod string")
+	g.P("}")
+	g.P()
+	g.P("func (s *_", svcName, "_stream) Method() string { return s.method }")
+	g.P()
+	g.P("func (s *_", svcName, "_stream) SetHeader(md ", md, ") error {")
+	g.P("\tfor k, vs := range md {")
+	g.P("\t\tfor _, v := range vs {")
+	g.P("\t\t\ts.c.Append(k, v)")
+	g.P("\t\t}")
+	g.P("\t}")
+	g.P("\treturn nil")
+	g.P("}")
+	g.P()
+	g.P("func (s *_", svcName, "_stream) SendHeader(md ", md, ") error { return s.SetHeader(md) }")
+	g.P()
+	g.P("func (s *_", svcName, "_stream) SetTrailer(md ", md, ") error { return s.SetHeader(md) }")
+	g.P()
+
 	g.P("// _", svcName, "_error renders a handler error: AppError values keep their")
 	g.P("// status/message; anything else becomes a sanitized 500.")
 	g.P("func _", svcName, "_error(c *", fiberCtx, ", err error) error {")
@@ -183,6 +221,24 @@ func generateService(g *protogen.GeneratedFile, svc *protogen.Service) error {
 	g.P("}")
 	g.P()
 
+	g.P("// _", svcName, "_bind parses the request body after checking its Content-Type")
+	g.P("// against the route's accepted media types, so form or multipart bodies can")
+	g.P("// never reach a JSON route through BodyParser. JSON bodies are decoded by")
+	g.P("// binding.ProtoJSON, which rejects unknown fields on strict routes.")
+	g.P("func _", svcName, "_bind(c *", fiberCtx, ", req any, strict bool, consumes ...string) error {")
+	g.P("\tif !", g.QualifiedGoIdent(middlewarePkg.Ident("ContentTypeAllowed")), "(c.Get(", g.QualifiedGoIdent(fiberPkg.Ident("HeaderContentType")), "), consumes...) {")
+	g.P("\t\treturn ", g.QualifiedGoIdent(errorsPkg.Ident("UnsupportedMediaType")), "(\"unsupported content type\")")
+	g.P("\t}")
+	g.P("\tif m, ok := req.(", g.QualifiedGoIdent(protoPkg.Ident("Message")), "); ok && c.Is(\"json\") {")
+	g.P("\t\treturn ", g.QualifiedGoIdent(bindingPkg.Ident("ProtoJSON")), "(c.Body(), m, strict)")
+	g.P("\t}")
+	g.P("\tif err := c.BodyParser(req); err != nil {")
+	g.P("\t\treturn ", g.QualifiedGoIdent(errorsPkg.Ident("BadRequest")), "(400, \"invalid request body\")")
+	g.P("\t}")
+	g.P("\treturn nil")
+	g.P("}")
+	g.P()
+
 	g.P("// _", svcName, "_rateLimit builds a fixed-window per-IP limiter middleware.")
 	g.P("func _", svcName, "_rateLimit(max int, window ", g.QualifiedGoIdent(timePkg.Ident("Duration")), ") ", fiberHandler, " {")
 	g.P("\tcfg := ", g.QualifiedGoIdent(middlewarePkg.Ident("DefaultRateLimitConfig")), "()")
@@ -195,7 +251,7 @@ func generateService(g *protogen.GeneratedFile, svc *protogen.Service) error {
 	return nil
 }
 
-func generateHandler(g *protogen.GeneratedFile, svcName string, m *protogen.Method, r *veemon.Route) {
+func generateHandler(g *protogen.GeneratedFile, svcName, fullMethod string, m *protogen.Method, r *veemon.Route) {
 	fiberCtx := g.QualifiedGoIdent(fiberPkg.Ident("Ctx"))
 	fiberHandler := g.QualifiedGoIdent(fiberPkg.Ident("Handler"))
 	reqType := g.QualifiedGoIdent(m.Input.GoIdent)
@@ -210,14 +266,23 @@ func generateHandler(g *protogen.GeneratedFile, svcName string, m *protogen.Meth
 		g.P("\t\tvar req ", reqType)
 		// Body binding first, so path params take precedence over body values.
 		if r.GetBody() {
-			g.P("\t\tif err := c.BodyParser(&req); err != nil {")
-			g.P("\t\t\treturn ", g.QualifiedGoIdent(responsePkg.Ident("BadRequest")), "(c, 400, \"invalid request body\")")
+			consumes := r.GetConsumes()
+			if len(consumes) == 0 {
+				consumes = []string{"application/json"}
+			}
+			quoted := make([]string, len(consumes))
+			for i, ct := range consumes {
+				quoted[i] = strconv(ct)
+			}
+			g.P("\t\tif err := _", svcName, "_bind(c, &req, ", fmt.Sprint(r.GetStrict()), ", ", strings.Join(quoted, ", "), "); err != nil {")
+			g.P("\t\t\treturn _", svcName, "_error(c, err)")
 			g.P("\t\t}")
 		}
 		pathParams := bindPathParams(g, m, r)
-		// Query binding for GET requests: any scalar field not bound via a path
-		// param is populated from the query string (json_name as the key).
-		if strings.EqualFold(r.GetMethod(), "GET") {
+		// Query binding for GET and DELETE requests: any scalar field not
+		// bound via a path param is populated from the query string
+		// (json_name as the key).
+		if strings.EqualFold(r.GetMethod(), "GET") || strings.EqualFold(r.GetMethod(), "DELETE") {
 			bindQueryParams(g, m, pathParams)
 		}
 	}
@@ -226,8 +291,9 @@ func generateHandler(g *protogen.GeneratedFile, svcName string, m *protogen.Meth
 	if !isEmpty {
 		reqArg = "&req"
 	}
-	g.P("\t\tctx := _", svcName, "_ctx(c)")
+	g.P("\t\tctx, span := ", g.QualifiedGoIdent(middlewarePkg.Ident("StartHandlerSpan")), "(_", svcName, "_ctx(c, ", strconv(fullMethod), "), ", strconv(fullMethod), ")")
 	g.P("\t\tres, err := srv.", m.GoName, "(ctx, ", reqArg, ")")
+	g.P("\t\t", g.QualifiedGoIdent(middlewarePkg.Ident("EndHandlerSpan")), "(span, err)")
 	g.P("\t\tif err != nil {")
 	g.P("\t\t\treturn _", svcName, "_error(c, err)")
 	g.P("\t\t}")
@@ -275,6 +341,13 @@ func bindQueryParams(g *protogen.GeneratedFile, m *protogen.Method, skip map[str
 }
 
 func writeResponse(g *protogen.GeneratedFile, m *protogen.Method, r *veemon.Route) {
+	// A string request field named "fields" is a sparse fieldset: the
+	// response carries only the listed json names.
+	fieldsArg := ""
+	if f := findField(m.Input, "fields"); f != nil && f.Desc.Kind() == protoreflect.StringKind && !f.Desc.IsList() {
+		fieldsArg = ", req." + f.GoName
+	}
+
 	switch r.GetResponse() {
 	case veemon.ResponseStyle_RESPONSE_STYLE_CREATED:
 		g.P("\t\treturn ", g.QualifiedGoIdent(responsePkg.Ident("CreatedProto")), "(c, res)")
@@ -294,8 +367,16 @@ func writeResponse(g *protogen.GeneratedFile, m *protogen.Method, r *veemon.Rout
 		if metaField != nil {
 			metaArg = "res." + metaField.GoName
 		}
+		if fieldsArg != "" {
+			g.P("\t\treturn ", g.QualifiedGoIdent(responsePkg.Ident("SuccessProtoListFields")), "(c, items, ", metaArg, fieldsArg, ")")
+			return
+		}
 		g.P("\t\treturn ", g.QualifiedGoIdent(responsePkg.Ident("SuccessProtoList")), "(c, items, ", metaArg, ")")
 	default:
+		if fieldsArg != "" {
+			g.P("\t\treturn ", g.QualifiedGoIdent(responsePkg.Ident("SuccessProtoFields")), "(c, res", fieldsArg, ")")
+			return
+		}
 		g.P("\t\treturn ", g.QualifiedGoIdent(responsePkg.Ident("SuccessProto")), "(c, res)")
 	}
 }
@@ -304,23 +385,36 @@ func writeResponse(g *protogen.GeneratedFile, m *protogen.Method, r *veemon.Rout
 
 func needAuth(r *veemon.Route) bool {
 	a := r.GetAuth()
-	return a != nil && (a.GetRequired() || len(a.GetRoles()) > 0)
+	return a != nil && (a.GetRequired() || len(a.GetRoles()) > 0 || len(a.GetPermissions()) > 0)
 }
 
 func authConfigLiteral(g *protogen.GeneratedFile, r *veemon.Route) string {
 	authConfig := g.QualifiedGoIdent(middlewarePkg.Ident("AuthConfig"))
-	a := r.GetAuth()
-	if a == nil || (!a.GetRequired() && len(a.GetRoles()) == 0) {
+	if !needAuth(r) {
 		return authConfig + "{NeedAuth: false, AllowedRoles: nil}"
 	}
-	if len(a.GetRoles()) == 0 {
+	a := r.GetAuth()
+	var fields []string
+	switch {
+	case len(a.GetPermissions()) > 0:
+		fields = append(fields, "RequiredPermissions: []string{"+quoteAll(a.GetPermissions())+"}")
+	case len(a.GetRoles()) > 0:
+		fields = append(fields, "AllowedRoles: []string{"+quoteAll(a.GetRoles())+"}")
+	default:
 		return authConfig + "{NeedAuth: true, AllowedRoles: nil}"
 	}
-	quoted := make([]string, len(a.GetRoles()))
-	for i, role := range a.GetRoles() {
-		quoted[i] = strconv(role)
+	if a.GetRoleMode() == veemon.RoleMode_ROLE_MODE_MONITOR {
+		fields = append(fields, "Mode: "+g.QualifiedGoIdent(middlewarePkg.Ident("AuthModeMonitor")))
+	}
+	return fmt.Sprintf("%s{NeedAuth: true, %s}", authConfig, strings.Join(fields, ", "))
+}
+
+func quoteAll(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = strconv(v)
 	}
-	return fmt.Sprintf("%s{NeedAuth: true, AllowedRoles: []string{%s}}", authConfig, strings.Join(quoted, ", "))
+	return strings.Join(quoted, ", ")
 }
 
 // listFields returns the single repeated field (the list payload) and the first
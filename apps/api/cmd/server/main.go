@@ -3,6 +3,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"net"
 	"os"
@@ -11,12 +12,20 @@ import (
 	"time"
 
 	"veemon/config"
+	"veemon/pkg/lifecycle"
 
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 )
 
 func main() {
+	validateOnly := flag.Bool("validate-only", false,
+		"load and validate the configuration, print every problem, and exit (non-zero when invalid)")
+	flag.Parse()
+	if *validateOnly {
+		os.Exit(validateConfig())
+	}
+
 	// Load configuration
 	cfg, err := config.New()
 	if err != nil {
@@ -35,26 +44,26 @@ func main() {
 		panic(fmt.Sprintf("Failed to initialize logger: %v", err))
 	}
 	defer func() { _ = log.Sync() }()
+	lifecycle.SetLogger(log.Logger)
+	config.LogSensitiveOverrides(cfg, log.Logger)
 
 	log.Info("Starting application",
 		zap.String("service", cfg.ServiceName),
 		zap.String("environment", cfg.Environment),
-		zap.Int("http_port", cfg.HTTPPort),
-		zap.Int("grpc_port", cfg.GRPCPort),
-		zap.Bool("prefork", cfg.Prefork),
+		zap.Int("http_port", cfg.HTTP.Port),
+		zap.Int("grpc_port", cfg.HTTP.GRPCPort),
+		zap.Bool("prefork", cfg.HTTP.Prefork),
 	)
 
 	// Initialize OpenTelemetry
 	ctx := context.Background()
-	otel, err := config.NewTelemetry(ctx, cfg)
-	if err != nil {
+	if _, err := config.NewTelemetry(ctx, cfg, log.Logger); err != nil {
 		log.Fatal("Failed to initialize telemetry", zap.Error(err))
 	}
-	defer func() { _ = otel.Shutdown(ctx) }()
 
 	log.Info("OpenTelemetry initialized",
-		zap.Bool("enabled", cfg.OTelEnabled),
-		zap.String("exporter", cfg.OTelExporterType),
+		zap.Bool("enabled", cfg.Observability.Tracing.Enabled),
+		zap.String("exporter", cfg.Observability.Tracing.ExporterType),
 	)
 
 	// Initialize database
@@ -64,14 +73,13 @@ func main() {
 	}
 
 	// Initialize Redis
-	redisClient, err := config.NewRedis(cfg)
+	redisClient, err := config.NewRedis(cfg, log.Logger)
 	if err != nil {
 		log.Warn("Failed to connect to Redis, caching disabled", zap.Error(err))
 	} else {
-		defer func() { _ = redisClient.Close() }()
 		log.Info("Redis connection established",
-			zap.String("host", cfg.RedisHost),
-			zap.Int("port", cfg.RedisPort),
+			zap.String("host", cfg.Redis.Host),
+			zap.Int("port", cfg.Redis.Port),
 		)
 	}
 
@@ -80,15 +88,17 @@ func main() {
 	if err != nil {
 		log.Warn("Failed to connect to RabbitMQ, messaging disabled", zap.Error(err))
 	} else {
-		defer func() { _ = rabbitClient.Close() }()
 		log.Info("RabbitMQ connection established",
-			zap.String("host", cfg.RabbitMQHost),
-			zap.Int("port", cfg.RabbitMQPort),
+			zap.String("host", cfg.RabbitMQ.Host),
+			zap.Int("port", cfg.RabbitMQ.Port),
 		)
 	}
 
+	// Runtime-tunable knobs, shared by every replica through Redis.
+	runtimeCfg := config.NewRuntimeConfig(redisClient, log.Logger)
+
 	// Create Fiber app
-	app := config.NewFiber(cfg, log.Logger)
+	app := config.NewFiber(cfg, runtimeCfg, log.Logger)
 
 	// Bootstrap application (wire layers, routes, health checks)
 	result, err := config.Bootstrap(&config.BootstrapConfig{
@@ -98,6 +108,8 @@ func main() {
 		Cfg:      cfg,
 		Redis:    redisClient,
 		RabbitMQ: rabbitClient,
+
+		RuntimeConfig: runtimeCfg,
 	})
 	if err != nil {
 		log.Fatal("Failed to bootstrap application", zap.Error(err))
@@ -107,27 +119,29 @@ func main() {
 	errChan := make(chan error, 2)
 
 	go func() {
-		lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPCPort))
+		lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.HTTP.GRPCPort))
 		if err != nil {
 			errChan <- fmt.Errorf("gRPC listen error: %w", err)
 			return
 		}
-		log.Info("gRPC server listening", zap.Int("port", cfg.GRPCPort))
+		log.Info("gRPC server listening", zap.Int("port", cfg.HTTP.GRPCPort))
 		if err := result.GRPCServer.Serve(lis); err != nil && err != grpc.ErrServerStopped {
 			errChan <- fmt.Errorf("gRPC serve error: %w", err)
 		}
 	}()
 
 	go func() {
-		log.Info("HTTP server listening", zap.Int("port", cfg.HTTPPort))
-		if err := app.Listen(fmt.Sprintf(":%d", cfg.HTTPPort)); err != nil {
+		log.Info("HTTP server listening", zap.Int("port", cfg.HTTP.Port))
+		if err := app.Listen(fmt.Sprintf(":%d", cfg.HTTP.Port)); err != nil {
 			errChan <- fmt.Errorf("fiber listen error: %w", err)
 		}
 	}()
 
 	// Wait for a shutdown signal or a fatal server error. Both paths fall
-	// through to the same graceful shutdown so deferred cleanup (telemetry
-	// flush, Redis/RabbitMQ close, log sync) always runs — we never os.Exit here.
+	// through to the same graceful shutdown so every registered lifecycle hook
+	// runs before we exit, in order: fail /ready and the gRPC health service
+	// and wait SHUTDOWN_DRAIN_DELAY, stop gRPC and HTTP, close
+	// RabbitMQ/Redis/DB, flush telemetry. Each hook is logged as it runs.
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
@@ -140,38 +154,33 @@ func main() {
 		exitCode = 1
 	}
 
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	// The drain delay comes on top of the time the servers need to stop.
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second+cfg.HTTP.ShutdownDrainDelay)
 	defer cancel()
 
-	// 1. Drain HTTP: stop accepting new connections, let in-flight requests finish.
-	if err := app.ShutdownWithContext(shutdownCtx); err != nil {
-		log.Error("Fiber shutdown error", zap.Error(err))
-	}
-
-	// 2. Stop gRPC gracefully, bounded by the shutdown deadline; force-stop on timeout.
-	grpcStopped := make(chan struct{})
-	go func() {
-		result.GRPCServer.GracefulStop()
-		close(grpcStopped)
-	}()
-	select {
-	case <-grpcStopped:
-	case <-shutdownCtx.Done():
-		log.Warn("gRPC graceful stop timed out; forcing stop")
-		result.GRPCServer.Stop()
-	}
-
-	// 3. Close the database connection pool.
-	if sqlDB, err := db.DB(); err == nil {
-		_ = sqlDB.Close()
+	if err := lifecycle.Shutdown(shutdownCtx); err != nil {
+		log.Warn("Shutdown finished with errors", zap.Error(err))
+	} else {
+		log.Info("Servers stopped gracefully")
 	}
 
-	log.Info("Servers stopped gracefully")
-
-	// Flush deferred cleanup (see defers above) before exiting non-zero.
 	if exitCode != 0 {
 		_ = log.Sync()
-		_ = otel.Shutdown(context.Background())
 		os.Exit(exitCode)
 	}
 }
+
+// validateConfig loads and validates the configuration without connecting
+// to anything, for CI smoke checks, and returns the exit code.
+func validateConfig() int {
+	cfg, err := config.New()
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Println("configuration is valid")
+	return 0
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"crypto/subtle"
+	"errors"
+
+	"veemon/pkg/events"
+	"veemon/pkg/metrics"
+	"veemon/pkg/rabbitmq"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// consumerControl is the slice of *rabbitmq.Client the admin routes drive.
+type consumerControl interface {
+	ConsumerStates() []rabbitmq.QueueState
+	PauseQueue(queue string) error
+	ResumeQueue(queue string) error
+	PeekQueue(queue string, limit int) ([]rabbitmq.PeekedMessage, error)
+}
+
+const (
+	defaultPeekLimit = 10
+	maxPeekLimit     = 100
+)
+
+// newAdminApp builds the worker's HTTP listener: /metrics, and when
+// adminToken is set the consumer controls under /admin/consumers and a peek
+// at dlq under /admin/dlq. Without a token the admin routes are not
+// registered at all.
+func newAdminApp(consumers consumerControl, dlq string, m *metrics.Metrics, metricsToken, adminToken string, log *zap.Logger) *fiber.App {
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Get("/metrics", bearerAuth(metricsToken, true), m.Handler())
+
+	if adminToken == "" {
+		return app
+	}
+	admin := app.Group("/admin/consumers", bearerAuth(adminToken, false))
+	admin.Get("/", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"success": true, "data": consumers.ConsumerStates()})
+	})
+	admin.Post("/:queue/pause", consumerAction(consumers.PauseQueue, "pause", log))
+	admin.Post("/:queue/resume", consumerAction(consumers.ResumeQueue, "resume", log))
+	app.Get("/admin/dlq", bearerAuth(adminToken, false), peekDLQ(consumers, dlq))
+	return app
+}
+
+// peekedMessage is the JSON view of a DLQ message. Bodies are shown as the
+// redacted, truncated preview the worker logs, never in full.
+type peekedMessage struct {
+	Failure         *rabbitmq.Failure      `json:"failure"`
+	RejectionReason string                 `json:"rejectionReason,omitempty"`
+	ContentType     string                 `json:"contentType"`
+	Redelivered     bool                   `json:"redelivered"`
+	Headers         map[string]interface{} `json:"headers"`
+	BodySize        int                    `json:"bodySize"`
+	BodyPreview     string                 `json:"bodyPreview"`
+}
+
+// peekDLQ lists up to ?limit= messages from the head of the DLQ, with the
+// failure headers the consumer attached, without removing them.
+func peekDLQ(consumers consumerControl, dlq string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		limit := c.QueryInt("limit", defaultPeekLimit)
+		if limit < 1 || limit > maxPeekLimit {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   fiber.Map{"code": fiber.StatusBadRequest, "message": "limit must be between 1 and 100"},
+			})
+		}
+		msgs, err := consumers.PeekQueue(dlq, limit)
+		if err != nil {
+			return err
+		}
+		out := make([]peekedMessage, 0, len(msgs))
+		for _, m := range msgs {
+			reason, _ := m.Headers[rabbitmq.RejectionReasonHeader].(string)
+			out = append(out, peekedMessage{
+				Failure:         m.Failure,
+				RejectionReason: reason,
+				ContentType:     m.ContentType,
+				Redelivered:     m.Redelivered,
+				Headers:         m.Headers,
+				BodySize:        len(m.Body),
+				BodyPreview:     events.Preview(m.Body),
+			})
+		}
+		return c.JSON(fiber.Map{"success": true, "data": fiber.Map{"queue": dlq, "messages": out}})
+	}
+}
+
+func consumerAction(action func(queue string) error, name string, log *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		queue := c.Params("queue")
+		if err := action(queue); err != nil {
+			if errors.Is(err, rabbitmq.ErrUnknownQueue) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"success": false,
+					"error":   fiber.Map{"code": fiber.StatusNotFound, "message": "no consumers for queue"},
+				})
+			}
+			return err
+		}
+		log.Warn("consumer state changed via admin endpoint",
+			zap.String("queue", queue), zap.String("action", name), zap.String("ip", c.IP()))
+		return c.JSON(fiber.Map{"success": true, "data": fiber.Map{"queue": queue, "action": name}})
+	}
+}
+
+// bearerAuth requires `Authorization: Bearer <token>`. An empty token lets
+// every request through when open is true (the /metrics convention) and
+// rejects every request otherwise.
+func bearerAuth(token string, open bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if token == "" && open {
+			return c.Next()
+		}
+		got := c.Get("Authorization")
+		if token == "" || subtle.ConstantTimeCompare([]byte(got), []byte("Bearer "+token)) != 1 {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"success": false,
+				"error":   fiber.Map{"code": fiber.StatusUnauthorized, "message": "unauthorized"},
+			})
+		}
+		return c.Next()
+	}
+}
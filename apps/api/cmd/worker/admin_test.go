@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"veemon/pkg/metrics"
+	"veemon/pkg/rabbitmq"
+
+	"github.com/gofiber/fiber/v2"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+type fakeConsumers struct{ paused map[string]bool }
+
+func (f *fakeConsumers) ConsumerStates() []rabbitmq.QueueState {
+	return []rabbitmq.QueueState{{Queue: "default_queue", State: "running", Consumers: 5}}
+}
+
+func (f *fakeConsumers) PauseQueue(q string) error { return f.set(q, true) }
+
+func (f *fakeConsumers) ResumeQueue(q string) error { return f.set(q, false) }
+
+func (f *fakeConsumers) PeekQueue(q string, limit int) ([]rabbitmq.PeekedMessage, error) {
+	headers := amqp.Table{
+		rabbitmq.FailureReasonHeader: "send email: smtp 421",
+		rabbitmq.FailureTypeHeader:   "error",
+		rabbitmq.FailedAtHeader:      "2026-03-10T09:00:00Z",
+		rabbitmq.ConsumerTagHeader:   "worker-consumer-2",
+		rabbitmq.TraceIDHeader:       "4bf92f3577b34da6a3ce929d0e0e4736",
+	}
+	msgs := []rabbitmq.PeekedMessage{{
+		ContentType: "application/json",
+		Body:        []byte(`{"to":"a@b.c","password":"hunter2"}`),
+		Headers:     headers,
+		Failure:     rabbitmq.FailureFromHeaders(headers),
+	}}
+	if q != DeadLetterQueue {
+		return nil, fmt.Errorf("peeked %s", q)
+	}
+	if limit < len(msgs) {
+		msgs = msgs[:limit]
+	}
+	return msgs, nil
+}
+
+func (f *fakeConsumers) set(q string, paused bool) error {
+	if q != "default_queue" {
+		return fmt.Errorf("%w: %s", rabbitmq.ErrUnknownQueue, q)
+	}
+	f.paused[q] = paused
+	return nil
+}
+
+func call(t *testing.T, app *fiber.App, method, path, token string) (int, string) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestAdminApp_ConsumerControls(t *testing.T) {
+	fake := &fakeConsumers{paused: map[string]bool{}}
+	app := newAdminApp(fake, DeadLetterQueue, metrics.New("worker_test"), "", "s3cret", zap.NewNop())
+
+	if code, _ := call(t, app, "POST", "/admin/consumers/default_queue/pause", ""); code != fiber.StatusUnauthorized {
+		t.Fatalf("pause without token = %d, want 401", code)
+	}
+	if code, _ := call(t, app, "POST", "/admin/consumers/default_queue/pause", "wrong"); code != fiber.StatusUnauthorized {
+		t.Fatalf("pause with wrong token = %d, want 401", code)
+	}
+
+	if code, _ := call(t, app, "POST", "/admin/consumers/default_queue/pause", "s3cret"); code != fiber.StatusOK || !fake.paused["default_queue"] {
+		t.Fatalf("pause = %d, paused=%v", code, fake.paused["default_queue"])
+	}
+	if code, _ := call(t, app, "POST", "/admin/consumers/default_queue/resume", "s3cret"); code != fiber.StatusOK || fake.paused["default_queue"] {
+		t.Fatalf("resume = %d, paused=%v", code, fake.paused["default_queue"])
+	}
+	if code, _ := call(t, app, "POST", "/admin/consumers/other/pause", "s3cret"); code != fiber.StatusNotFound {
+		t.Fatalf("pause unknown queue = %d, want 404", code)
+	}
+
+	code, body := call(t, app, "GET", "/admin/consumers", "s3cret")
+	if code != fiber.StatusOK || !strings.Contains(body, `"queue":"default_queue"`) {
+		t.Fatalf("list = %d %s", code, body)
+	}
+}
+
+func TestAdminApp_DisabledWithoutToken(t *testing.T) {
+	app := newAdminApp(&fakeConsumers{paused: map[string]bool{}}, DeadLetterQueue, metrics.New("worker_test"), "", "", zap.NewNop())
+
+	if code, _ := call(t, app, "POST", "/admin/consumers/default_queue/pause", ""); code != fiber.StatusNotFound {
+		t.Fatalf("admin route without WORKER_ADMIN_TOKEN = %d, want 404", code)
+	}
+	if code, _ := call(t, app, "GET", "/metrics", ""); code != fiber.StatusOK {
+		t.Fatalf("/metrics = %d, want 200", code)
+	}
+}
+
+func TestAdminApp_PeekDLQShowsFailureHeaders(t *testing.T) {
+	app := newAdminApp(&fakeConsumers{paused: map[string]bool{}}, DeadLetterQueue, metrics.New("worker_test"), "", "s3cret", zap.NewNop())
+
+	if code, _ := call(t, app, "GET", "/admin/dlq", ""); code != fiber.StatusUnauthorized {
+		t.Fatalf("peek without token = %d, want 401", code)
+	}
+	if code, _ := call(t, app, "GET", "/admin/dlq?limit=500", "s3cret"); code != fiber.StatusBadRequest {
+		t.Fatalf("peek with limit=500 = %d, want 400", code)
+	}
+
+	code, body := call(t, app, "GET", "/admin/dlq", "s3cret")
+	if code != fiber.StatusOK {
+		t.Fatalf("peek = %d %s", code, body)
+	}
+	for _, want := range []string{
+		`"queue":"default_queue.dlq"`,
+		`"failure":{"reason":"send email: smtp 421","type":"error","failedAt":"2026-03-10T09:00:00Z","consumerTag":"worker-consumer-2","traceId":"4bf92f3577b34da6a3ce929d0e0e4736"}`,
+		`"bodySize":35`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("peek body missing %s:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "hunter2") {
+		t.Errorf("peek leaked a secret from the body: %s", body)
+	}
+}
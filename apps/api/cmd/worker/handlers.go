@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"veemon/pkg/events"
+	"veemon/pkg/mailer"
+	"veemon/pkg/metrics"
+	"veemon/pkg/passwordreset"
+	"veemon/pkg/rabbitmq"
+	"veemon/pkg/userevents"
+	"veemon/pkg/worker"
+
+	"go.uber.org/zap"
+)
+
+// newRouter registers the worker's message handlers. Register specific
+// patterns before general ones: the first match handles the message.
+func newRouter(log *zap.Logger, mail mailer.Sender) *worker.Router {
+	r := worker.NewRouter(worker.WithLogger(log), worker.WithRecorder(handlerMetrics{}))
+
+	r.Handle(mailer.EventTypeEmail, func(ctx context.Context, msg *worker.Message) error {
+		if msg.Envelope == nil {
+			err := fmt.Errorf("%w: email without an envelope", events.ErrMalformed)
+			return rabbitmq.Reject(err.Error(), err)
+		}
+		if err := mailer.Dispatch(ctx, mail, msg.Envelope); err != nil {
+			if events.IsPermanent(err) {
+				return rabbitmq.Reject(err.Error(), err)
+			}
+			return fmt.Errorf("send email: %w", err)
+		}
+		return nil
+	}, worker.WithRetry(3, time.Second))
+
+	r.Handle(userevents.Registered, worker.JSON(func(ctx context.Context, msg *worker.Message, u userevents.User) error {
+		// TODO: send a welcome email, e.g. via mailer.Dispatch with a
+		// "welcome" template.
+		log.Info("Welcome email stub", zap.String("event_id", msg.Envelope.ID), zap.String("user_id", u.ID))
+		return nil
+	}), worker.WithName("welcome-email"))
+
+	r.Handle(passwordreset.EventTypeRequested, worker.JSON(func(ctx context.Context, _ *worker.Message, req passwordreset.Requested) error {
+		return sendLink(ctx, mail, req.Email, "Reset your password", "password_reset", req.Name, req.Link, req.ExpiresAt)
+	}), worker.WithName("password-reset-email"), worker.WithRetry(3, time.Second))
+
+	r.Handle(passwordreset.EventTypeInvited, worker.JSON(func(ctx context.Context, _ *worker.Message, inv passwordreset.Invited) error {
+		return sendLink(ctx, mail, inv.Email, "You have been invited", "invitation", inv.Name, inv.Link, inv.ExpiresAt)
+	}), worker.WithName("invitation-email"), worker.WithRetry(3, time.Second))
+
+	r.Handle(DefaultRoutingKey, func(ctx context.Context, msg *worker.Message) error {
+		// TODO: Implement your business logic here, or register a handler
+		// per routing key above.
+		fields := []zap.Field{
+			zap.String("routing_key", msg.RoutingKey),
+			zap.String("content_type", msg.ContentType),
+		}
+		if msg.Envelope != nil {
+			fields = append(fields,
+				zap.String("event_id", msg.Envelope.ID),
+				zap.String("event_type", msg.Envelope.Type),
+			)
+		}
+		log.Info("Message processed successfully", fields...)
+		return nil
+	})
+
+	return r
+}
+
+// sendLink mails a single-use link. An event without a link (the API has no
+// FRONTEND_BASE_URLS) or a template that cannot render is dead-lettered,
+// since retrying cannot fix it.
+func sendLink(ctx context.Context, mail mailer.Sender, to, subject, template, name, link string, expiresAt time.Time) error {
+	if link == "" {
+		err := fmt.Errorf("%w: %s event without a link", events.ErrMalformed, template)
+		return rabbitmq.Reject(err.Error(), err)
+	}
+	err := mail.Send(ctx, mailer.Message{
+		To:           to,
+		Subject:      subject,
+		TemplateName: template,
+		Data: map[string]any{
+			"Name":      name,
+			"Link":      link,
+			"ExpiresAt": expiresAt.UTC().Format(time.RFC1123),
+		},
+	})
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, mailer.ErrTemplateNotFound), errors.Is(err, mailer.ErrRender), errors.Is(err, mailer.ErrNoRecipient):
+		return rabbitmq.Reject(err.Error(), err)
+	default:
+		return fmt.Errorf("send %s email: %w", template, err)
+	}
+}
+
+// handlerMetrics records handler outcomes once metrics are initialised; the
+// worker only enables them when WORKER_METRICS_PORT is set.
+type handlerMetrics struct{}
+
+func (handlerMetrics) ObserveHandler(handler, outcome string, d time.Duration) {
+	if m := metrics.Get(); m != nil {
+		m.ObserveHandler(handler, outcome, d)
+	}
+}
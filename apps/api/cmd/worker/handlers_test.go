@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"veemon/pkg/events"
+	"veemon/pkg/mailer"
+	"veemon/pkg/rabbitmq"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+type captureSender struct{ sent []mailer.Message }
+
+func (s *captureSender) Send(_ context.Context, msg mailer.Message) error {
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+func linkDelivery(routingKey, body string) amqp.Delivery {
+	return amqp.Delivery{RoutingKey: routingKey, ContentType: events.ContentTypeJSON, Body: []byte(body)}
+}
+
+func TestRouter_MailsResetAndInviteLinks(t *testing.T) {
+	mail := &captureSender{}
+	r := newRouter(zap.NewNop(), mail)
+
+	for _, tc := range []struct{ key, template, body string }{
+		{"user.password_reset_requested", "password_reset",
+			`{"id":"e1","type":"user.password_reset_requested","occurredAt":"2026-03-01T12:00:00Z","data":{"email":"a@example.com","name":"A","token":"t","link":"https://app.example.com/reset-password?token=t","expiresAt":"2026-03-01T12:30:00Z"}}`},
+		{"user.invited", "invitation",
+			`{"id":"e2","type":"user.invited","occurredAt":"2026-03-01T12:00:00Z","data":{"email":"a@example.com","name":"A","token":"t","link":"https://app.example.com/accept-invite?token=t","expiresAt":"2026-03-04T12:00:00Z"}}`},
+	} {
+		if err := r.Dispatch(context.Background(), linkDelivery(tc.key, tc.body)); err != nil {
+			t.Fatalf("%s: %v", tc.key, err)
+		}
+		got := mail.sent[len(mail.sent)-1]
+		if got.TemplateName != tc.template || got.To != "a@example.com" || got.Data["Link"] == "" {
+			t.Fatalf("%s: sent %+v", tc.key, got)
+		}
+	}
+}
+
+func TestRouter_RejectsResetWithoutLink(t *testing.T) {
+	mail := &captureSender{}
+	r := newRouter(zap.NewNop(), mail)
+
+	err := r.Dispatch(context.Background(), linkDelivery("user.password_reset_requested",
+		`{"id":"e1","type":"user.password_reset_requested","occurredAt":"2026-03-01T12:00:00Z","data":{"email":"a@example.com","token":"t"}}`))
+
+	var rejectErr *rabbitmq.RejectError
+	if !errors.As(err, &rejectErr) || len(mail.sent) != 0 {
+		t.Fatalf("err = %v, sent %d; want a rejection and nothing sent", err, len(mail.sent))
+	}
+}
@@ -3,35 +3,53 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"veemon/app/usecase/outbox"
+	"veemon/app/usecase/user"
 	"veemon/config"
+	"veemon/pkg/activeusers"
+	"veemon/pkg/database"
+	"veemon/pkg/lifecycle"
+	"veemon/pkg/metrics"
+	"veemon/pkg/passwordreset"
 	"veemon/pkg/rabbitmq"
+	"veemon/pkg/resilience"
+	"veemon/pkg/scheduler"
+	"veemon/pkg/userevents"
+	"veemon/repository/outbox_repository"
+	"veemon/repository/user_repository"
 
-	amqp "github.com/rabbitmq/amqp091-go"
 	"go.uber.org/zap"
 )
 
 const (
 	// Queue names - adjust these to match your queues
 	DefaultQueue = "default_queue"
+	// DeadLetterQueue holds messages rejected as permanently unprocessable.
+	DeadLetterQueue = DefaultQueue + ".dlq"
 
 	// Exchange configuration
-	DefaultExchange     = "default_exchange"
+	DefaultExchange     = config.EventsExchange
 	DefaultExchangeType = "topic"
 
 	// Routing keys
-	DefaultRoutingKey = "default.#"
+	DefaultRoutingKey      = "default.#"
+	NotificationRoutingKey = "notification.#"
 
 	// Consumer configuration
 	ConsumerTag       = "worker-consumer"
 	PrefetchCount     = 10
 	ConcurrentWorkers = 5
+	// DrainTimeout bounds how long shutdown waits for in-flight messages.
+	DrainTimeout = 25 * time.Second
+	// OutboxCleanupInterval is how often sent outbox messages older than
+	// OUTBOX_RETENTION are deleted.
+	OutboxCleanupInterval = time.Hour
 )
 
 func main() {
@@ -47,6 +65,8 @@ func main() {
 		panic(fmt.Sprintf("Failed to initialize logger: %v", err))
 	}
 	defer func() { _ = log.Sync() }()
+	lifecycle.SetLogger(log.Logger)
+	config.LogSensitiveOverrides(cfg, log.Logger)
 
 	log.Info("Starting worker",
 		zap.String("service", cfg.ServiceName+"-worker"),
@@ -55,15 +75,13 @@ func main() {
 
 	// Initialize OpenTelemetry
 	ctx := context.Background()
-	otel, err := config.NewTelemetry(ctx, cfg)
-	if err != nil {
+	if _, err := config.NewTelemetry(ctx, cfg, log.Logger); err != nil {
 		log.Fatal("Failed to initialize telemetry", zap.Error(err))
 	}
-	defer func() { _ = otel.Shutdown(ctx) }()
 
 	log.Info("OpenTelemetry initialized",
-		zap.Bool("enabled", cfg.OTelEnabled),
-		zap.String("exporter", cfg.OTelExporterType),
+		zap.Bool("enabled", cfg.Observability.Tracing.Enabled),
+		zap.String("exporter", cfg.Observability.Tracing.ExporterType),
 	)
 
 	// Initialize database (optional - only if worker needs database access)
@@ -74,26 +92,31 @@ func main() {
 	log.Info("Database connection established")
 
 	// Initialize Redis (optional - only if worker needs caching)
-	redisClient, err := config.NewRedis(cfg)
+	redisClient, err := config.NewRedis(cfg, log.Logger)
 	if err != nil {
 		log.Warn("Failed to connect to Redis, caching disabled", zap.Error(err))
 	} else {
-		defer func() { _ = redisClient.Close() }()
 		log.Info("Redis connection established",
-			zap.String("host", cfg.RedisHost),
-			zap.Int("port", cfg.RedisPort),
+			zap.String("host", cfg.Redis.Host),
+			zap.Int("port", cfg.Redis.Port),
 		)
 	}
 
+	// Initialize mailer (renders and delivers notification.email events)
+	mail, err := config.NewMailer(cfg, log.Logger)
+	if err != nil {
+		log.Fatal("Failed to initialize mailer", zap.Error(err))
+	}
+	log.Info("Mailer initialized", zap.String("driver", cfg.MailDriver))
+
 	// Initialize RabbitMQ
 	rabbitClient, err := config.NewRabbitMQ(cfg, log.Logger)
 	if err != nil {
 		log.Fatal("Failed to connect to RabbitMQ", zap.Error(err))
 	}
-	defer func() { _ = rabbitClient.Close() }()
 	log.Info("RabbitMQ connection established",
-		zap.String("host", cfg.RabbitMQHost),
-		zap.Int("port", cfg.RabbitMQPort),
+		zap.String("host", cfg.RabbitMQ.Host),
+		zap.Int("port", cfg.RabbitMQ.Port),
 	)
 
 	// Set up RabbitMQ topology
@@ -105,6 +128,25 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Stop consumers accepting new work (prefetched messages go back to the
+	// queue), then wait (bounded) for in-flight messages to finish before the
+	// RabbitMQ connection is closed.
+	lifecycle.RegisterWithTimeout("consumers", lifecycle.PriorityServers, DrainTimeout, func(shutdownCtx context.Context) error {
+		cancel()
+		stats := rabbitClient.WaitConsumers(shutdownCtx)
+		log.Info("Consumers drained",
+			zap.Int64("handled", stats.Handled),
+			zap.Int64("requeued", stats.Requeued),
+			zap.Int64("unfinished", stats.Unfinished),
+		)
+		if err := shutdownCtx.Err(); err != nil {
+			return fmt.Errorf("consumers did not drain: %w", err)
+		}
+		return nil
+	})
+
+	router := newRouter(log.Logger, mail)
+
 	// Start consumers. Each consumer runs on its own channel, sets its own QoS,
 	// and self-heals across connection/channel drops.
 	for i := 0; i < ConcurrentWorkers; i++ {
@@ -116,14 +158,16 @@ func main() {
 			zap.String("queue", DefaultQueue),
 		)
 
-		if err := rabbitClient.ConsumeWithHandler(ctx, rabbitmq.ConsumeOptions{
-			Queue:         DefaultQueue,
-			ConsumerTag:   consumerTag,
-			AutoAck:       false,
-			PrefetchCount: PrefetchCount,
-		}, func(handlerCtx context.Context, msg amqp.Delivery) error {
-			return handleMessage(handlerCtx, msg, log.Logger, db, redisClient)
-		}); err != nil {
+		if _, err := rabbitClient.ConsumeWithHandler(ctx, rabbitmq.ConsumeOptions{
+			Queue:           DefaultQueue,
+			ConsumerTag:     consumerTag,
+			AutoAck:         false,
+			PrefetchCount:   PrefetchCount,
+			DeadLetterQueue: DeadLetterQueue,
+			// The queue has no broker-side dead-letter exchange, so without
+			// this a message failing twice would be discarded.
+			DeadLetterFailures: true,
+		}, router.Dispatch); err != nil {
 			log.Fatal("Failed to start consumer", zap.Int("worker_id", workerID), zap.Error(err))
 		}
 	}
@@ -133,6 +177,90 @@ func main() {
 		zap.Int("prefetch_count", PrefetchCount),
 	)
 
+	// Metrics + consumer admin listener.
+	if cfg.WorkerMetricsPort > 0 {
+		m := metrics.Init(cfg.ServiceName)
+		rabbitClient.EnableMetrics(m)
+		rabbitClient.OnReconnect(m.RecordRabbitMQReconnect)
+		if err := database.EnableMetrics(db, m); err != nil {
+			log.Fatal("Failed to enable database metrics", zap.Error(err))
+		}
+		adminApp := newAdminApp(rabbitClient, DeadLetterQueue, m, cfg.Observability.MetricsAuthToken, cfg.WorkerAdminToken, log.Logger)
+		addr := fmt.Sprintf(":%d", cfg.WorkerMetricsPort)
+		go func() {
+			if err := adminApp.Listen(addr); err != nil {
+				log.Error("Worker HTTP listener stopped", zap.Error(err))
+			}
+		}()
+		lifecycle.RegisterWithTimeout("worker-http", lifecycle.PriorityServers, 5*time.Second, adminApp.ShutdownWithContext)
+		log.Info("Worker HTTP listener started",
+			zap.String("addr", addr),
+			zap.Bool("admin_enabled", cfg.WorkerAdminToken != ""),
+		)
+	}
+
+	// Scheduled jobs. Each is safe to run on every replica at once: purging
+	// is an idempotent UPDATE, the active-user gauges are read-only counts,
+	// relays skip the outbox rows another relay has claimed, and outbox
+	// cleanup is an idempotent DELETE.
+	jobs := scheduler.New(log.Logger)
+	if cfg.OutboxEnabled {
+		opts := []outbox.Option{
+			outbox.WithTransactor(database.NewTxManager(db)),
+			outbox.WithPublisher(rabbitClient, resilience.DefaultConfig()),
+			outbox.WithBatchSize(cfg.OutboxBatchSize),
+			outbox.WithRetention(cfg.OutboxRetention),
+			outbox.WithLogger(log.Logger),
+		}
+		if m := metrics.Get(); m != nil {
+			opts = append(opts, outbox.WithBacklogRecorder(m))
+		}
+		relay := outbox.NewUseCase(outbox_repository.New(db), opts...)
+		jobs.Every("relay-outbox", cfg.OutboxRelayInterval, func(ctx context.Context) error {
+			_, err := relay.Relay(ctx)
+			return err
+		})
+		jobs.Every("clean-outbox", OutboxCleanupInterval, func(ctx context.Context) error {
+			n, err := relay.Cleanup(ctx)
+			if err != nil {
+				return err
+			}
+			if n > 0 {
+				log.Info("Deleted sent outbox messages", zap.Int64("count", n))
+			}
+			return nil
+		})
+	}
+	if cfg.AccountPurgeIntervalMinutes > 0 {
+		users := user.NewUseCase(user_repository.New(db),
+			user.WithDeletionGracePeriod(cfg.AccountDeletionGrace()),
+		)
+		jobs.Every("purge-deleted-accounts", time.Duration(cfg.AccountPurgeIntervalMinutes)*time.Minute, func(ctx context.Context) error {
+			n, err := users.PurgeExpiredDeletions(ctx, time.Now())
+			if err != nil {
+				return err
+			}
+			if n > 0 {
+				log.Info("Purged accounts past their deletion grace period", zap.Int64("count", n))
+			}
+			return nil
+		})
+	}
+	// The gauges are served on the worker's /metrics, so they need it.
+	if m := metrics.Get(); cfg.ActiveUsersRefreshMinutes > 0 && redisClient != nil && m != nil {
+		activity := activeusers.New(redisClient)
+		jobs.Every("publish-active-users", time.Duration(cfg.ActiveUsersRefreshMinutes)*time.Minute, func(ctx context.Context) error {
+			return activity.Publish(ctx, m)
+		})
+	}
+	jobs.Start(ctx)
+	lifecycle.RegisterWithTimeout("scheduler", lifecycle.PriorityServers, DrainTimeout, jobs.Stop)
+	log.Info("Scheduled jobs started",
+		zap.Bool("outbox_enabled", cfg.OutboxEnabled),
+		zap.Int("account_purge_interval_minutes", cfg.AccountPurgeIntervalMinutes),
+		zap.Int("active_users_refresh_minutes", cfg.ActiveUsersRefreshMinutes),
+	)
+
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -140,15 +268,12 @@ func main() {
 	<-quit
 	log.Info("Shutting down worker...")
 
-	// Stop consumers accepting new work, then wait (bounded) for in-flight
-	// messages to finish before closing the connection.
-	cancel()
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer shutdownCancel()
-	rabbitClient.WaitConsumers(shutdownCtx)
 
-	if shutdownCtx.Err() != nil {
-		log.Warn("Shutdown timeout reached before all consumers drained")
+	if err := lifecycle.Shutdown(shutdownCtx); err != nil {
+		log.Warn("Shutdown finished with errors", zap.Error(err))
+		return
 	}
 	log.Info("Worker stopped gracefully")
 }
@@ -173,6 +298,12 @@ func setupTopology(client *rabbitmq.Client, log *zap.Logger) error {
 		zap.String("type", DefaultExchangeType),
 	)
 
+	// The outbox relay publishes user events here; a publish to a missing
+	// exchange would close the channel.
+	if err := client.DeclareExchange(userevents.Exchange, "topic", true, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed to declare user events exchange: %w", err)
+	}
+
 	// Declare queue
 	queue, err := client.DeclareQueue(
 		DefaultQueue,
@@ -192,61 +323,66 @@ func setupTopology(client *rabbitmq.Client, log *zap.Logger) error {
 		zap.Int("consumers", queue.Consumers),
 	)
 
-	// Bind queue to exchange
-	if err := client.BindQueue(
-		DefaultQueue,
-		DefaultRoutingKey,
-		DefaultExchange,
+	// Declare the dead-letter queue. Rejected messages are republished to it
+	// directly via the default exchange, so it needs no binding.
+	dlq, err := client.DeclareQueue(
+		DeadLetterQueue,
+		true,  // durable
+		false, // auto-delete
+		false, // exclusive
 		false, // no-wait
 		nil,   // args
-	); err != nil {
-		return fmt.Errorf("failed to bind queue: %w", err)
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare dead-letter queue: %w", err)
 	}
 
-	log.Info("Queue bound to exchange",
-		zap.String("queue", DefaultQueue),
-		zap.String("exchange", DefaultExchange),
-		zap.String("routing_key", DefaultRoutingKey),
+	log.Info("Dead-letter queue declared",
+		zap.String("queue", dlq.Name),
+		zap.Int("messages", dlq.Messages),
 	)
 
-	return nil
-}
-
-// handleMessage processes a single message
-func handleMessage(ctx context.Context, msg amqp.Delivery, log *zap.Logger, db interface{}, redis interface{}) error {
-	log.Info("Processing message",
-		zap.String("routing_key", msg.RoutingKey),
-		zap.String("content_type", msg.ContentType),
-		zap.Int("body_size", len(msg.Body)),
-	)
+	// Bind queue to exchange
+	for _, routingKey := range []string{DefaultRoutingKey, NotificationRoutingKey} {
+		if err := client.BindQueue(
+			DefaultQueue,
+			routingKey,
+			DefaultExchange,
+			false, // no-wait
+			nil,   // args
+		); err != nil {
+			return fmt.Errorf("failed to bind queue: %w", err)
+		}
 
-	// Parse message body. Do NOT log the raw body or decoded payload — messages
-	// may contain PII or secrets. Log only non-sensitive metadata.
-	var payload map[string]interface{}
-	if err := json.Unmarshal(msg.Body, &payload); err != nil {
-		log.Error("Failed to unmarshal message",
-			zap.Error(err),
-			zap.String("routing_key", msg.RoutingKey),
-			zap.Int("body_size", len(msg.Body)),
+		log.Info("Queue bound to exchange",
+			zap.String("queue", DefaultQueue),
+			zap.String("exchange", DefaultExchange),
+			zap.String("routing_key", routingKey),
 		)
-		return fmt.Errorf("invalid message format: %w", err)
 	}
 
-	// TODO: Implement your business logic here
-	// Example:
-	// - Process the message based on routing key
-	// - Update database records
-	// - Call external APIs
-	// - Send notifications
-	// - Cache results in Redis
+	// The password reset and invitation emails are announced on the events
+	// exchange by the API.
+	for _, routingKey := range []string{passwordreset.EventTypeRequested, passwordreset.EventTypeInvited} {
+		if err := client.BindQueue(DefaultQueue, routingKey, DefaultExchange, false, nil); err != nil {
+			return fmt.Errorf("failed to bind queue: %w", err)
+		}
+		log.Info("Queue bound to exchange",
+			zap.String("queue", DefaultQueue),
+			zap.String("exchange", DefaultExchange),
+			zap.String("routing_key", routingKey),
+		)
+	}
 
-	log.Info("Message processed successfully",
-		zap.String("routing_key", msg.RoutingKey),
-		zap.Int("fields", len(payload)),
+	// The welcome-email handler consumes user registrations.
+	if err := client.BindQueue(DefaultQueue, userevents.Registered, userevents.Exchange, false, nil); err != nil {
+		return fmt.Errorf("failed to bind queue to user events: %w", err)
+	}
+	log.Info("Queue bound to exchange",
+		zap.String("queue", DefaultQueue),
+		zap.String("exchange", userevents.Exchange),
+		zap.String("routing_key", userevents.Registered),
 	)
 
-	// Simulate processing time (remove this in production)
-	time.Sleep(100 * time.Millisecond)
-
 	return nil
 }
@@ -3,24 +3,47 @@ package config
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"strings"
+	"time"
 
+	"veemon/app/usecase/audit"
+	"veemon/app/usecase/outbox"
 	"veemon/app/usecase/user"
 	"veemon/docs"
 	"veemon/handler"
 	pb_user "veemon/handler/grpc/user"
+	"veemon/pkg/activeusers"
 	"veemon/pkg/authguard"
+	"veemon/pkg/authz"
+	"veemon/pkg/database"
 	"veemon/pkg/errors"
+	"veemon/pkg/health"
+	"veemon/pkg/idempotency"
+	"veemon/pkg/journal"
+	"veemon/pkg/lifecycle"
+	"veemon/pkg/mailer"
 	"veemon/pkg/metrics"
 	"veemon/pkg/middleware"
+	"veemon/pkg/notify"
+	"veemon/pkg/passwordreset"
 	"veemon/pkg/rabbitmq"
 	"veemon/pkg/redis"
+	"veemon/pkg/runtimeconfig"
+	"veemon/pkg/telemetry"
 	"veemon/pkg/token"
+	"veemon/pkg/urlpolicy"
+	"veemon/pkg/userevents"
+	"veemon/repository/audit_repository"
+	"veemon/repository/outbox_repository"
 	"veemon/repository/user_repository"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 	"gorm.io/gorm"
 )
@@ -33,6 +56,9 @@ type BootstrapConfig struct {
 	Cfg      *Config
 	Redis    *redis.Client
 	RabbitMQ *rabbitmq.Client
+	// RuntimeConfig serves the admin runtime-config API; nil means a store
+	// of defaults that cannot be changed.
+	RuntimeConfig *runtimeconfig.Store
 }
 
 // BootstrapResult holds the wired components ready to be started.
@@ -43,58 +69,388 @@ type BootstrapResult struct {
 // Bootstrap wires repositories, usecases, handlers, and routes.
 func Bootstrap(b *BootstrapConfig) (*BootstrapResult, error) {
 	// Layers
-	userRepo := user_repository.New(b.DB)
-	userUC := user.NewUseCase(userRepo)
-	tokenService, err := token.NewTokenService(b.Cfg.JWTSecret, b.Cfg.JWTExpiration)
+	hub, events := newNotifier(b)
+	userRepo := user_repository.NewCached(user_repository.New(b.DB), b.Redis, b.Cfg.Redis.UserCacheTTL)
+	auditRepo := audit_repository.New(b.DB)
+	userUC := user.NewUseCase(userRepo,
+		user.WithTransactor(database.NewTxManager(b.DB)),
+		user.WithDeletedEmailPolicy(user.DeletedEmailPolicy(b.Cfg.RegisterDeletedEmail)),
+		user.WithMaxOffset(b.Cfg.MaxOffset),
+		user.WithDeletionGracePeriod(b.Cfg.AccountDeletionGrace()),
+		user.WithCompanyUserLimit(b.Cfg.CompanyMaxUsers),
+		user.WithRequireUpdateVersion(b.Cfg.UpdateRequireIfMatch),
+		user.WithEventPublisher(events),
+		user.WithDomainEvents(newUserEvents(b)),
+		eventOutboxOption(b),
+		user.WithAuditLog(auditRepo),
+		passwordResetOption(b),
+		inviteOption(b),
+	)
+	tokenService, err := newTokenService(b.Cfg.Auth)
 	if err != nil {
 		return nil, fmt.Errorf("init token service: %w", err)
 	}
 	// Login lockout + token revocation, backed by Redis (no-op if Redis is nil).
-	guard := authguard.New(b.Redis, b.Cfg.LoginMaxAttempts, b.Cfg.LoginLockoutMinutes)
-	userHandler := handler.NewUserHandler(userUC, tokenService, guard, b.Log)
+	guard := authguard.New(b.Redis, b.Cfg.Auth.LoginMaxAttempts, b.Cfg.Auth.LoginLockoutMinutes, guardOptions(b)...)
+	runtimeCfg := b.RuntimeConfig
+	if runtimeCfg == nil {
+		runtimeCfg = runtimeconfig.New(nil, RuntimeConfigSchema(), b.Log)
+	}
+	handlerOpts := []handler.Option{handler.WithRuntimeConfig(runtimeCfg), handler.WithAuditLog(audit.NewUseCase(auditRepo))}
+	if b.Cfg.ImpersonationNotify {
+		if b.RabbitMQ != nil {
+			handlerOpts = append(handlerOpts, handler.WithImpersonationNotifier(
+				mailer.NewQueueSender(b.RabbitMQ, EventsExchange, b.Cfg.ServiceName)))
+		} else {
+			b.Log.Warn("IMPERSONATION_NOTIFY is set but RabbitMQ is unavailable; impersonated users will not be emailed")
+		}
+	}
+	userHandler := handler.NewUserHandler(userUC, tokenService, guard, b.Log, handlerOpts...)
 
 	// Token validator
-	tokenValidator := createTokenValidator(tokenService, guard)
+	tokenValidator := createTokenValidator(tokenService, guard, activeusers.New(b.Redis))
+	middleware.SetAuthModeOverride(middleware.AuthMode(b.Cfg.Auth.RoleMode))
+	if b.Cfg.Auth.RoleMode != "" {
+		b.Log.Warn("AUTH_ROLE_MODE overrides per-route role checks", zap.String("mode", b.Cfg.Auth.RoleMode))
+	}
+	if b.Cfg.Auth.PolicyFile != "" {
+		policy, err := authz.Load(b.Cfg.Auth.PolicyFile)
+		if err != nil {
+			return nil, err
+		}
+		middleware.SetPermissionPolicy(policy)
+		b.Log.Info("Loaded permission policy", zap.String("file", b.Cfg.Auth.PolicyFile), zap.Int("roles", len(policy)))
+	}
 
 	// Observability routes
 	registerObservabilityRoutes(b.App, b.Cfg)
+	if b.Redis != nil {
+		b.Redis.EnableMetrics(metrics.Get(), b.Cfg.Redis.StatsInterval)
+	}
+	if b.RabbitMQ != nil {
+		b.RabbitMQ.EnableMetrics(metrics.Get())
+		b.RabbitMQ.OnReconnect(metrics.Get().RecordRabbitMQReconnect)
+	}
+	if err := database.EnableMetrics(b.DB, metrics.Get()); err != nil {
+		return nil, err
+	}
+	if err := sampleDBStats(b); err != nil {
+		return nil, err
+	}
+
+	// Mutation journal (debug environments); must precede the API routes.
+	if err := registerJournal(b); err != nil {
+		return nil, err
+	}
+
+	// Idempotency-Key support; must precede the API routes.
+	registerIdempotency(b)
 
 	// Health check
-	registerHealthChecks(b)
+	checks := registerHealthChecks(b)
+
+	// Public metadata (error code catalog).
+	registerMetaRoutes(b.App, tokenService)
+
+	// Streamed, so not declarable in the .proto; ahead of the generated
+	// GET /api/v1/users/:id, which would take "export" for an id.
+	registerUserExport(b, userUC, tokenValidator)
 
 	// HTTP routes (generated from veemon.route options in the .proto).
 	pb_user.RegisterUserApiRoutes(b.App, userHandler, tokenValidator)
+	// Websockets and multipart uploads cannot be declared in the .proto;
+	// registered by hand.
+	b.App.Get("/api/v1/ws/notifications", handler.NotificationsHandler(hub, tokenValidator, handler.NotificationsConfig{}, b.Log))
+	registerUserImport(b, userUC, tokenValidator)
 
-	// gRPC server. Interceptor order (outermost first): recovery catches panics
-	// from everything downstream, then logging, then auth. Tracing is attached
-	// via the OTel stats handler.
+	// gRPC server. Interceptor order (outermost first): metrics, so recovered
+	// panics count as Internal; the request ID, as RequestIDMiddleware does
+	// for HTTP; recovery, catching panics from everything downstream;
+	// logging; auth; then the handler-layer span, named like the one the
+	// REST routes start. Streaming calls (the health Watch) get the same
+	// metrics, request ID, recovery, auth and span. The server span,
+	// continuing the caller's trace, comes from the OTel stats handler.
+	grpcAuth := grpcAuthConfig()
 	grpcServer := grpc.NewServer(
 		grpc.StatsHandler(otelgrpc.NewServerHandler()),
 		grpc.ChainUnaryInterceptor(
+			middleware.GRPCMetricsInterceptor(metrics.Get()),
+			middleware.GRPCRequestIDInterceptor(),
 			middleware.GRPCRecoveryInterceptor(b.Log),
 			middleware.GRPCLoggingInterceptor(b.Log),
-			middleware.GRPCAuthInterceptor(tokenValidator, pb_user.UserApiAuthConfig),
+			middleware.GRPCAuthInterceptor(tokenValidator, grpcAuth),
+			middleware.GRPCTracingInterceptor(),
+		),
+		grpc.ChainStreamInterceptor(
+			middleware.GRPCStreamMetricsInterceptor(metrics.Get()),
+			middleware.GRPCStreamRequestIDInterceptor(),
+			middleware.GRPCStreamRecoveryInterceptor(b.Log),
+			middleware.GRPCStreamAuthInterceptor(tokenValidator, grpcAuth),
+			middleware.GRPCStreamTracingInterceptor(),
 		),
 	)
 	pb_user.RegisterUserApiServer(grpcServer, userHandler)
+	// grpc.health.v1, following the same checks as /ready.
+	grpcHealth := health.NewGRPCWatcher(checks, 0, pb_user.UserApi_ServiceDesc.ServiceName)
+	healthpb.RegisterHealthServer(grpcServer, grpcHealth.Server())
+	grpcHealth.Start()
 	// Reflection eases local debugging (grpcurl) but exposes the full service
 	// surface; keep it out of production.
 	if b.Cfg.Environment != "production" {
 		reflection.Register(grpcServer)
 	}
 
+	registerServerShutdown(b.App, grpcServer)
+	registerDrain(b, checks, grpcHealth)
+
 	return &BootstrapResult{
 		GRPCServer: grpcServer,
 	}, nil
 }
 
+// registerServerShutdown drains HTTP before gRPC (same priority, so the
+// later registration runs first) ahead of the clients both depend on.
+func registerServerShutdown(app *fiber.App, grpcServer *grpc.Server) {
+	// Force-stop gRPC if in-flight RPCs outlive the hook deadline.
+	lifecycle.Register("grpc", lifecycle.PriorityServers, func(ctx context.Context) error {
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+			return nil
+		case <-ctx.Done():
+			grpcServer.Stop()
+			return fmt.Errorf("graceful stop timed out; forced: %w", ctx.Err())
+		}
+	})
+	// Stop accepting connections and let in-flight requests finish.
+	lifecycle.RegisterWithTimeout("http", lifecycle.PriorityServers, 15*time.Second, app.ShutdownWithContext)
+}
+
+// registerDrain fails /ready and the gRPC health service as soon as
+// shutdown starts, then keeps serving for SHUTDOWN_DRAIN_DELAY: load
+// balancers take a few seconds to notice, and closing the listeners before
+// they do turns their in-flight routing into 502s.
+func registerDrain(b *BootstrapConfig, checks *health.Registry, grpcHealth *health.GRPCWatcher) {
+	delay := b.Cfg.HTTP.ShutdownDrainDelay
+	lifecycle.RegisterWithTimeout("drain", lifecycle.PriorityDrain, delay+time.Second, func(ctx context.Context) error {
+		checks.Drain()
+		grpcHealth.Shutdown()
+		b.Log.Info("Readiness failing; waiting for load balancers to drain", zap.Duration("delay", delay))
+		select {
+		case <-time.After(delay):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+// registerJournal installs the mutation journal when JOURNAL_ENABLED is set
+// (Validate keeps it out of production).
+func registerJournal(b *BootstrapConfig) error {
+	if !b.Cfg.JournalEnabled {
+		return nil
+	}
+	var sink journal.Sink
+	switch b.Cfg.JournalSink {
+	case "redis":
+		if b.Redis == nil {
+			b.Log.Warn("JOURNAL_SINK=redis but Redis is unavailable; requests will not be journaled")
+			return nil
+		}
+		sink = journal.NewStreamSink(b.Redis, b.Cfg.JournalStream, b.Cfg.JournalStreamMaxLen)
+	default:
+		fs, err := journal.NewFileSink(b.Cfg.JournalFile, int64(b.Cfg.JournalMaxSizeMB)<<20, b.Cfg.JournalMaxFiles)
+		if err != nil {
+			return err
+		}
+		sink = fs
+	}
+	b.App.Use(journal.Middleware(sink, b.Log))
+	lifecycle.Register("journal", lifecycle.PriorityClients, func(context.Context) error { return sink.Close() })
+	b.Log.Warn("mutation journal enabled; request bodies are recorded", zap.String("sink", b.Cfg.JournalSink))
+	return nil
+}
+
+// idempotentRoutes honor the Idempotency-Key header. List a route here only
+// if replaying its stored response to a retry is correct.
+var idempotentRoutes = []string{"/api/v1/auth/register"}
+
+// registerIdempotency mounts the idempotency middleware on idempotentRoutes;
+// without Redis the header is ignored.
+func registerIdempotency(b *BootstrapConfig) {
+	if b.Redis == nil {
+		b.Log.Warn("Redis unavailable; Idempotency-Key headers will be ignored")
+		return
+	}
+	mw := idempotency.Middleware(b.Redis, idempotency.Config{
+		TTL: b.Cfg.Redis.IdempotencyTTL,
+		// Outlives any request, which REQUEST_TIMEOUT bounds.
+		LockTTL: 2 * b.Cfg.HTTP.RequestTimeout,
+	}, b.Log)
+	for _, path := range idempotentRoutes {
+		b.App.Use(path, mw)
+	}
+}
+
+// passwordResetOption enables the forgot-password flow, which needs Redis
+// for its tokens and RabbitMQ to get them mailed. Without either, its
+// endpoints answer 503.
+func passwordResetOption(b *BootstrapConfig) user.Option {
+	if b.Redis == nil || b.RabbitMQ == nil {
+		b.Log.Warn("password reset disabled: it needs both Redis and RabbitMQ")
+		return user.WithPasswordReset(nil, nil)
+	}
+	return user.WithPasswordReset(
+		passwordreset.NewStore(b.Redis, b.Cfg.Auth.PasswordResetTTL),
+		passwordreset.NewNotifier(b.RabbitMQ, EventsExchange, b.Cfg.ServiceName, frontendLinks(b)),
+	)
+}
+
+// inviteOption enables invitations for users created by administrators.
+// Invitation tokens are redeemed through the password reset endpoint, so
+// they need the same Redis and RabbitMQ.
+func inviteOption(b *BootstrapConfig) user.Option {
+	if b.Redis == nil || b.RabbitMQ == nil {
+		return user.WithInvites(nil, nil)
+	}
+	return user.WithInvites(
+		passwordreset.NewStore(b.Redis, b.Cfg.Auth.InviteTTL),
+		passwordreset.NewNotifier(b.RabbitMQ, EventsExchange, b.Cfg.ServiceName, frontendLinks(b)),
+	)
+}
+
+// frontendLinks builds the reset and invite links from FRONTEND_BASE_URLS.
+// Without a usable base URL the events carry only the token.
+func frontendLinks(b *BootstrapConfig) passwordreset.LinkBuilder {
+	policy, err := NewURLPolicy(b.Cfg)
+	if err != nil {
+		b.Log.Warn("password reset and invite links disabled", zap.Error(err))
+		return nil
+	}
+	if len(urlpolicy.ParseList(b.Cfg.FrontendBaseURLs)) == 0 {
+		b.Log.Warn("password reset and invite links disabled: FRONTEND_BASE_URLS is empty")
+		return nil
+	}
+	return policy
+}
+
+// guardOptions configures the login lockout's window and IP limit, and
+// announces locks on the events exchange when RabbitMQ is available.
+func guardOptions(b *BootstrapConfig) []authguard.Option {
+	opts := []authguard.Option{
+		authguard.WithWindow(time.Duration(b.Cfg.Auth.LoginWindowMinutes) * time.Minute),
+		authguard.WithIPLimit(b.Cfg.Auth.LoginMaxAttemptsPerIP),
+		authguard.WithLogger(b.Log),
+	}
+	if b.RabbitMQ != nil {
+		opts = append(opts, authguard.WithLockNotifier(
+			authguard.NewNotifier(b.RabbitMQ, EventsExchange, b.Cfg.ServiceName)))
+	}
+	return opts
+}
+
+// newUserEvents returns the publisher of user domain events: the
+// user.events exchange when RabbitMQ is available, otherwise nowhere.
+func newUserEvents(b *BootstrapConfig) user.EventPublisher {
+	if b.RabbitMQ == nil {
+		b.Log.Warn("RabbitMQ unavailable; user events will not be published")
+		return userevents.Nop{}
+	}
+	// Publishing to a missing exchange closes the channel, so declare it up
+	// front rather than rely on a consumer having done so.
+	if err := b.RabbitMQ.DeclareExchange(userevents.Exchange, "topic", true, false, false, false, nil); err != nil {
+		b.Log.Warn("declare user events exchange", zap.Error(err))
+	}
+	return userevents.NewNotifier(b.RabbitMQ, userevents.Exchange, b.Cfg.ServiceName,
+		userevents.WithLogger(b.Log),
+		userevents.WithDropRecorder(eventDrops{}),
+	)
+}
+
+// eventOutboxOption stages user events in the transactional outbox, for the
+// worker to relay, unless OUTBOX_ENABLED is off.
+func eventOutboxOption(b *BootstrapConfig) user.Option {
+	if !b.Cfg.OutboxEnabled {
+		return user.WithEventOutbox(nil)
+	}
+	return user.WithEventOutbox(userevents.NewOutbox(
+		outbox.NewUseCase(outbox_repository.New(b.DB)), b.Cfg.ServiceName))
+}
+
+// eventDrops counts dropped user events on the global metrics, which are
+// initialised after the usecases are built.
+type eventDrops struct{}
+
+func (eventDrops) RecordEventDropped(eventType string) {
+	if m := metrics.Get(); m != nil {
+		m.RecordEventDropped(eventType)
+	}
+}
+
+// newNotifier returns the hub notification websockets subscribe to and the
+// publisher user events go to: a Redis relay reaching every replica's hub
+// when Redis is available, otherwise the local hub alone.
+func newNotifier(b *BootstrapConfig) (*notify.Hub, notify.Publisher) {
+	hub := notify.NewHub(b.Cfg.NotifyBufferSize)
+	// Hijacked websocket connections outlive the HTTP server's shutdown;
+	// closing the hub ends them.
+	lifecycle.Register("notifications", lifecycle.PriorityServers, func(context.Context) error {
+		hub.Close()
+		return nil
+	})
+	if b.Redis == nil {
+		return hub, hub
+	}
+
+	relay := notify.NewRedisRelay(b.Redis, b.Cfg.NotifyChannel, hub, b.Log)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		relay.Run(ctx)
+	}()
+	// Registered after Redis, so it runs before the pool is closed.
+	lifecycle.Register("notify-relay", lifecycle.PriorityClients, func(ctx context.Context) error {
+		cancel()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	return hub, relay
+}
+
 func registerObservabilityRoutes(app *fiber.App, cfg *Config) {
 	m := metrics.Init(cfg.ServiceName)
-	app.Use(m.Middleware())
-	app.Get("/metrics", metricsAuth(cfg.MetricsAuthToken), m.Handler())
+	app.Use(m.Middleware(middleware.MetricsSkipper(cfg.Observability.SkipPaths)))
+	// With OTLP metrics the registry is pushed to the collector instead.
+	if cfg.Observability.Tracing.MetricsExporter != telemetry.MetricsOTLP {
+		app.Get("/metrics", metricsAuth(cfg.Observability.MetricsAuthToken), m.Handler())
+	}
 	docs.SetupScalar(app)
 }
 
+// sampleDBStats exports the connection pool stats until shutdown. Registered
+// after the database, so it stops before the pool is closed.
+func sampleDBStats(b *BootstrapConfig) error {
+	stop, err := database.SampleStats(b.DB, metrics.Get(), b.Cfg.DB.StatsInterval)
+	if err != nil {
+		return fmt.Errorf("sample db stats: %w", err)
+	}
+	lifecycle.Register("db-stats", lifecycle.PriorityClients, func(context.Context) error {
+		stop()
+		return nil
+	})
+	return nil
+}
+
 // metricsAuth optionally guards the /metrics endpoint with a bearer token. When
 // no token is configured it is a pass-through (restrict at the network layer).
 func metricsAuth(token string) fiber.Handler {
@@ -109,18 +465,26 @@ func metricsAuth(token string) fiber.Handler {
 	}
 }
 
-func createTokenValidator(tokenService *token.TokenService, guard *authguard.Guard) middleware.TokenValidator {
+func createTokenValidator(tokenService *token.TokenService, guard *authguard.Guard, activity *activeusers.Tracker) middleware.TokenValidator {
 	return func(tokenStr string) (*middleware.AuthContext, error) {
 		claims, err := tokenService.ValidateToken(tokenStr)
 		if err != nil {
 			return nil, err
 		}
 
-		// Reject tokens that have been revoked (logout / refresh rotation).
-		if guard.IsRevoked(context.Background(), claims.TokenID) {
+		// Reject tokens that have been revoked, one at a time (logout /
+		// refresh rotation) or all of a user's at once (account deletion).
+		if guard.IsRevoked(context.Background(), claims.TokenID) ||
+			guard.IsUserRevoked(context.Background(), claims.UserID, claims.IssuedAt) {
 			return nil, token.ErrInvalidToken
 		}
 
+		// Count the user as active, but not a superadmin acting as them.
+		// Best effort: a Redis error must not fail the request.
+		if !claims.Impersonation {
+			_ = activity.Record(context.Background(), claims.UserID)
+		}
+
 		return &middleware.AuthContext{
 			UserID:      claims.UserID,
 			Email:       claims.Email,
@@ -129,69 +493,146 @@ func createTokenValidator(tokenService *token.TokenService, guard *authguard.Gua
 			Token:       tokenStr,
 			TokenID:     claims.TokenID,
 			ExpiresAt:   claims.ExpiresAt,
+
+			IsImpersonated: claims.Impersonation,
+			ActorID:        claims.ActorID,
 		}, nil
 	}
 }
 
-func registerHealthChecks(b *BootstrapConfig) {
-	b.App.Get("/health", func(c *fiber.Ctx) error {
-		return c.JSON(fiber.Map{
-			"status":  "ok",
-			"service": b.Cfg.ServiceName,
-		})
-	})
+// registerUserImport serves the CSV user import to callers allowed to create
+// users, a few uploads per minute each: every row costs a bcrypt hash.
+func registerUserImport(b *BootstrapConfig, userUC user.UseCase, validator middleware.TokenValidator) {
+	limit := middleware.DefaultRateLimitConfig()
+	limit.Max, limit.Duration = 5, time.Minute
+	b.App.Post("/api/v1/users/import",
+		middleware.AuthMiddleware(validator, middleware.AuthConfig{NeedAuth: true, RequiredPermissions: []string{handler.UserImportPermission}}),
+		middleware.UserRateLimiter(limit),
+		handler.UserImportHandler(userUC, handler.UserImportConfig{
+			MaxRows:   b.Cfg.UserImportMaxRows,
+			BatchSize: b.Cfg.UserImportBatchSize,
+		}, b.Log),
+	)
+}
 
-	b.App.Get("/ready", func(c *fiber.Ctx) error {
-		checks := make(map[string]string)
+// registerUserExport serves the user export to callers with users.export,
+// a few exports per minute each. HTTP_WRITE_TIMEOUT bounds writing a whole
+// response, far less than a large export needs, so the export's responses
+// get USER_EXPORT_TIMEOUT instead.
+func registerUserExport(b *BootstrapConfig, userUC user.UseCase, validator middleware.TokenValidator) {
+	timeout := b.Cfg.UserExportTimeout
+	if timeout <= 0 {
+		timeout = handler.DefaultUserExportTimeout
+	}
+	srv := b.App.Server()
+	srv.HeaderReceived = routeWriteTimeout(b.App.Config(), fiber.MethodGet, handler.UserExportPath, timeout, srv.HeaderReceived)
 
-		// Check database
-		sqlDB, err := b.DB.DB()
-		if err != nil || sqlDB.Ping() != nil {
-			checks["database"] = "unhealthy"
-		} else {
-			checks["database"] = "healthy"
-		}
-
-		// Check Redis
-		if b.Redis != nil {
-			conn := b.Redis.Conn()
-			_, err := conn.Do("PING")
-			_ = conn.Close()
-			if err != nil {
-				checks["redis"] = "unhealthy"
-			} else {
-				checks["redis"] = "healthy"
-			}
-		} else {
-			checks["redis"] = "disabled"
-		}
+	limit := middleware.DefaultRateLimitConfig()
+	limit.Max, limit.Duration = 5, time.Minute
+	b.App.Get(handler.UserExportPath,
+		middleware.AuthMiddleware(validator, middleware.AuthConfig{NeedAuth: true, RequiredPermissions: []string{handler.UserExportPermission}}),
+		middleware.UserRateLimiter(limit),
+		handler.UserExportHandler(userUC, handler.UserExportConfig{Timeout: timeout}, b.Log),
+	)
+}
 
-		// Check RabbitMQ (actually verify the connection, not just non-nil).
-		if b.RabbitMQ != nil {
-			if err := b.RabbitMQ.Ping(); err != nil {
-				checks["rabbitmq"] = "unhealthy"
-			} else {
-				checks["rabbitmq"] = "healthy"
-			}
-		} else {
-			checks["rabbitmq"] = "disabled"
+// routeWriteTimeout returns a HeaderReceived hook giving requests for
+// method and path the write timeout, on top of whatever next (the hook it
+// replaces, if any) returns. The path is matched as Fiber routes it: without
+// the query, unescaped with UnescapePath, case-insensitively unless
+// CaseSensitive, and ignoring trailing slashes unless StrictRouting.
+func routeWriteTimeout(cfg fiber.Config, method, path string, timeout time.Duration, next func(*fasthttp.RequestHeader) fasthttp.RequestConfig) func(*fasthttp.RequestHeader) fasthttp.RequestConfig {
+	route := routingPath(cfg, path)
+	return func(h *fasthttp.RequestHeader) fasthttp.RequestConfig {
+		var rc fasthttp.RequestConfig
+		if next != nil {
+			rc = next(h)
 		}
-
-		allHealthy := true
-		for _, v := range checks {
-			if v == "unhealthy" {
-				allHealthy = false
-				break
+		if string(h.Method()) != method {
+			return rc
+		}
+		var uri fasthttp.URI
+		if err := uri.Parse(h.Host(), h.RequestURI()); err != nil {
+			return rc
+		}
+		p := string(uri.PathOriginal())
+		if cfg.UnescapePath {
+			if unescaped, err := url.PathUnescape(p); err == nil {
+				p = unescaped
 			}
 		}
-
-		status := fiber.StatusOK
-		if !allHealthy {
-			status = fiber.StatusServiceUnavailable
+		if routingPath(cfg, p) == route {
+			rc.WriteTimeout = timeout
 		}
+		return rc
+	}
+}
 
-		return c.Status(status).JSON(fiber.Map{
-			"status": checks,
-		})
-	})
+// routingPath normalises p the way Fiber does before matching routes.
+func routingPath(cfg fiber.Config, p string) string {
+	if !cfg.CaseSensitive {
+		p = strings.ToLower(p)
+	}
+	if !cfg.StrictRouting && len(p) > 1 {
+		p = strings.TrimRight(p, "/")
+	}
+	return p
+}
+
+func registerMetaRoutes(app *fiber.App, tokenService *token.TokenService) {
+	app.Get("/api/v1/meta/error-codes", handler.ErrorCodesHandler())
+	app.Get(token.KeysPath, handler.TokenKeysHandler(tokenService))
+}
+
+// newTokenService issues v4.local tokens with JWT_SECRET (still accepting
+// those of JWT_SECRET_PREVIOUS), or in public mode v4.public tokens signed
+// with the first of TOKEN_SIGNING_KEYS.
+func newTokenService(cfg AuthConfig) (*token.TokenService, error) {
+	if cfg.TokenMode != token.ModePublic {
+		return token.NewTokenService(cfg.JWTSecret, cfg.JWTExpiration, cfg.JWTSecretPrevious...)
+	}
+	keys, err := token.ParseSigningKeys(cfg.TokenSigningKeys)
+	if err != nil {
+		return nil, err
+	}
+	return token.NewPublicTokenService(keys, cfg.JWTExpiration, cfg.TokenKeyOverlap)
+}
+
+// grpcAuthConfig is the generated UserApi policy plus the health service,
+// which is public like /ready.
+func grpcAuthConfig() map[string]middleware.AuthConfig {
+	cfg := make(map[string]middleware.AuthConfig, len(pb_user.UserApiAuthConfig)+2)
+	for method, policy := range pb_user.UserApiAuthConfig {
+		cfg[method] = policy
+	}
+	cfg[healthpb.Health_Check_FullMethodName] = middleware.AuthConfig{}
+	cfg[healthpb.Health_Watch_FullMethodName] = middleware.AuthConfig{}
+	return cfg
+}
+
+// registerHealthChecks serves /health and /ready, which checks the
+// database and whichever of Redis and RabbitMQ are configured, and returns
+// the registry for the gRPC health service.
+func registerHealthChecks(b *BootstrapConfig) *health.Registry {
+	checks := health.New(
+		health.WithTimeout(b.Cfg.Observability.HealthCheckTimeout),
+		health.WithCacheTTL(b.Cfg.Observability.HealthCacheTTL),
+		health.WithLogger(b.Log),
+	)
+	checks.Register(health.Database(b.DB))
+	if replicas := database.ReplicasOf(b.DB); replicas != nil {
+		checks.Register(health.DatabaseReplicas(replicas))
+	}
+	if b.Redis != nil {
+		checks.Register(health.Redis(b.Redis))
+	} else {
+		checks.Disable("redis")
+	}
+	if b.RabbitMQ != nil {
+		checks.Register(health.RabbitMQ(b.RabbitMQ))
+	} else {
+		checks.Disable("rabbitmq")
+	}
+	checks.Mount(b.App, b.Cfg.ServiceName)
+	return checks
 }
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+func TestRouteWriteTimeout_MatchesLikeFiberRouting(t *testing.T) {
+	header := func(method, uri string) *fasthttp.RequestHeader {
+		var h fasthttp.RequestHeader
+		h.SetMethod(method)
+		h.SetRequestURI(uri)
+		h.SetHost("api.example.com")
+		return &h
+	}
+	prev := func(*fasthttp.RequestHeader) fasthttp.RequestConfig {
+		return fasthttp.RequestConfig{MaxRequestBodySize: 1024}
+	}
+	hook := routeWriteTimeout(fiber.Config{}, fiber.MethodGet, "/api/v1/users/export", time.Hour, prev)
+
+	for _, tc := range []struct {
+		method, uri string
+		want        time.Duration
+	}{
+		{fiber.MethodGet, "/api/v1/users/export", time.Hour},
+		{fiber.MethodGet, "/api/v1/users/export?format=csv", time.Hour},
+		{fiber.MethodGet, "/api/v1/users/export/", time.Hour},
+		{fiber.MethodGet, "/API/v1/Users/Export", time.Hour},
+		{fiber.MethodGet, "http://api.example.com/api/v1/users/export", time.Hour},
+		{fiber.MethodPost, "/api/v1/users/export", 0},
+		{fiber.MethodGet, "/api/v1/users", 0},
+		{fiber.MethodGet, "/api/v1/users/exports", 0},
+	} {
+		rc := hook(header(tc.method, tc.uri))
+		assert.Equal(t, tc.want, rc.WriteTimeout, "%s %s", tc.method, tc.uri)
+		assert.Equal(t, 1024, rc.MaxRequestBodySize, "%s %s: the previous hook's config must be kept", tc.method, tc.uri)
+	}
+
+	strict := routeWriteTimeout(fiber.Config{StrictRouting: true, CaseSensitive: true}, fiber.MethodGet, "/api/v1/users/export", time.Hour, nil)
+	assert.Zero(t, strict(header(fiber.MethodGet, "/api/v1/users/export/")).WriteTimeout)
+	assert.Zero(t, strict(header(fiber.MethodGet, "/API/v1/users/export")).WriteTimeout)
+	assert.Equal(t, time.Hour, strict(header(fiber.MethodGet, "/api/v1/users/export")).WriteTimeout)
+}
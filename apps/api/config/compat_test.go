@@ -0,0 +1,235 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"veemon/pkg/database"
+)
+
+// envCompat lists every environment variable with a value to set and the
+// effective value it must produce, i.e. what the consuming package received
+// before the config was split into sections. Durations that used to be bare
+// numbers keep their unit.
+var envCompat = []struct {
+	key   string
+	value string
+	got   func(*Config) any
+	want  any
+}{
+	{"SERVICE_NAME", "svc", func(c *Config) any { return c.ServiceName }, "svc"},
+	{"SERVICE_NAME", "svc", func(c *Config) any { return c.Observability.Log.ServiceName }, "svc"},
+	{"ENVIRONMENT", "staging", func(c *Config) any { return c.Environment }, "staging"},
+	{"ENVIRONMENT", "staging", func(c *Config) any { return c.Observability.Log.Environment }, "staging"},
+	{"ENVIRONMENT", "staging", func(c *Config) any { return c.Observability.Tracing.Environment }, "staging"},
+
+	{"HTTP_PORT", "8080", func(c *Config) any { return c.HTTP.Port }, 8080},
+	{"GRPC_PORT", "9090", func(c *Config) any { return c.HTTP.GRPCPort }, 9090},
+	{"PREFORK", "true", func(c *Config) any { return c.HTTP.Prefork }, true},
+	{"HTTP_READ_TIMEOUT", "11", func(c *Config) any { return c.HTTP.ReadTimeout }, 11 * time.Second},
+	{"HTTP_WRITE_TIMEOUT", "12", func(c *Config) any { return c.HTTP.WriteTimeout }, 12 * time.Second},
+	{"HTTP_IDLE_TIMEOUT", "13", func(c *Config) any { return c.HTTP.IdleTimeout }, 13 * time.Second},
+	{"REQUEST_TIMEOUT", "14", func(c *Config) any { return c.HTTP.RequestTimeout }, 14 * time.Second},
+	{"HTTP_BODY_LIMIT_KB", "64", func(c *Config) any { return c.HTTP.BodyLimitKB }, 64},
+	{"CORS_ORIGINS", "https://a.example.com", func(c *Config) any { return c.HTTP.CORSOrigins }, "https://a.example.com"},
+	{"SHUTDOWN_DRAIN_DELAY", "3s", func(c *Config) any { return c.HTTP.ShutdownDrainDelay }, 3 * time.Second},
+
+	{"DB_HOST", "db", func(c *Config) any { return c.DB.Host }, "db"},
+	{"DB_PORT", "6543", func(c *Config) any { return c.DB.Port }, 6543},
+	{"DB_USER", "u", func(c *Config) any { return c.DB.User }, "u"},
+	{"DB_PASSWORD", "p", func(c *Config) any { return c.DB.Password }, "p"},
+	{"DB_NAME", "n", func(c *Config) any { return c.DB.Name }, "n"},
+	{"DB_TIMEZONE", "UTC", func(c *Config) any { return c.DB.Timezone }, "UTC"},
+	{"DB_SSL_MODE", "require", func(c *Config) any { return c.DB.SSLMode }, "require"},
+	{"DB_PREPARE_STMT", "false", func(c *Config) any { return c.DB.PrepareStmt }, false},
+	{"DB_SKIP_DEFAULT_TRANSACTION", "true", func(c *Config) any { return c.DB.SkipDefaultTransaction }, true},
+	{"DB_POOLER_MODE", "transaction", func(c *Config) any { return c.DB.PoolerMode }, database.PoolerTransaction},
+	{"DB_MAX_IDLE_CONNS", "3", func(c *Config) any { return c.DB.MaxIdleConns }, 3},
+	{"DB_MAX_OPEN_CONNS", "7", func(c *Config) any { return c.DB.MaxOpenConns }, 7},
+	{"DB_CONN_MAX_LIFETIME", "45", func(c *Config) any { return c.DB.ConnMaxLifetime }, 45 * time.Minute},
+	{"DB_CONN_MAX_IDLE_TIME", "5m", func(c *Config) any { return c.DB.ConnMaxIdleTime }, 5 * time.Minute},
+	{"DB_REPLICA_HOSTS", "r1, r2:6543", func(c *Config) any { return c.DB.ReplicaHosts }, []string{"r1", "r2:6543"}},
+	{"DB_AUTO_MIGRATE", "true", func(c *Config) any { return c.DB.AutoMigrate }, true},
+	{"DB_AUTO_MIGRATE_SOURCE", "sql", func(c *Config) any { return c.DB.AutoMigrateSource }, AutoMigrateSQL},
+	{"DB_MIGRATION_FORMAT", "timestamp", func(c *Config) any { return c.DB.MigrationFormat }, "timestamp"},
+	{"DB_STATS_INTERVAL", "30", func(c *Config) any { return c.DB.StatsInterval }, 30 * time.Second},
+	{"DB_TABLE_PREFIX", "grst_", func(c *Config) any { return c.DB.TablePrefix }, "grst_"},
+	{"DB_SINGULAR_TABLE", "true", func(c *Config) any { return c.DB.SingularTable }, true},
+	{"DB_LOG_QUERIES", "true", func(c *Config) any { return c.DB.LogQueries }, true},
+	{"DB_LOG_QUERY_SAMPLE_RATE", "10", func(c *Config) any { return c.DB.QueryLogSampleRate }, 10},
+	{"DB_LOG_REDACTION", "sensitive", func(c *Config) any { return c.DB.QueryLogRedaction }, database.RedactSensitive},
+	{"DB_LOG_SENSITIVE_COLUMNS", " ssn, ,iban ", func(c *Config) any { return c.DB.QueryLogSensitiveColumns }, []string{"ssn", "iban"}},
+
+	{"REDIS_HOST", "cache", func(c *Config) any { return c.Redis.Host }, "cache"},
+	{"REDIS_PORT", "6380", func(c *Config) any { return c.Redis.Port }, 6380},
+	{"REDIS_PASSWORD", "rp", func(c *Config) any { return c.Redis.Password }, "rp"},
+	{"REDIS_DB", "2", func(c *Config) any { return c.Redis.DB }, 2},
+	{"REDIS_MAX_IDLE", "4", func(c *Config) any { return c.Redis.MaxIdle }, 4},
+	{"REDIS_MAX_ACTIVE", "8", func(c *Config) any { return c.Redis.MaxActive }, 8},
+	{"REDIS_IDLE_TIMEOUT", "100", func(c *Config) any { return c.Redis.IdleTimeout }, 100},
+	{"REDIS_DIAL_TIMEOUT", "6", func(c *Config) any { return c.Redis.DialTimeout }, 6},
+	{"REDIS_READ_TIMEOUT", "7", func(c *Config) any { return c.Redis.ReadTimeout }, 7},
+	{"REDIS_WRITE_TIMEOUT", "8", func(c *Config) any { return c.Redis.WriteTimeout }, 8},
+	{"REDIS_SLOW_THRESHOLD_MS", "-1", func(c *Config) any { return c.Redis.SlowThreshold }, -time.Millisecond},
+	{"REDIS_STATS_INTERVAL", "30", func(c *Config) any { return c.Redis.StatsInterval }, 30 * time.Second},
+	{"REDIS_SCAN_COUNT", "500", func(c *Config) any { return c.Redis.ScanCount }, 500},
+	{"REDIS_MODE", "sentinel", func(c *Config) any { return c.Redis.Mode }, "sentinel"},
+	{"REDIS_SENTINEL_MASTER", "mymaster", func(c *Config) any { return c.Redis.SentinelMaster }, "mymaster"},
+	{"REDIS_SENTINEL_ADDRS", "s1:26379, s2:26379", func(c *Config) any { return c.Redis.SentinelAddrs }, []string{"s1:26379", "s2:26379"}},
+	{"REDIS_SENTINEL_PASSWORD", "sp", func(c *Config) any { return c.Redis.SentinelPassword }, "sp"},
+	{"REDIS_CLUSTER_ADDRS", "n1:6379,n2:6379", func(c *Config) any { return c.Redis.ClusterAddrs }, []string{"n1:6379", "n2:6379"}},
+	{"REDIS_TLS_ENABLED", "true", func(c *Config) any { return c.Redis.TLSEnabled }, true},
+	{"REDIS_TLS_SKIP_VERIFY", "true", func(c *Config) any { return c.Redis.TLSSkipVerify }, true},
+	{"REDIS_TLS_CA_FILE", "/etc/redis/ca.pem", func(c *Config) any { return c.Redis.TLSCAFile }, "/etc/redis/ca.pem"},
+	{"REDIS_TLS_CERT_FILE", "/etc/redis/client.pem", func(c *Config) any { return c.Redis.TLSCertFile }, "/etc/redis/client.pem"},
+	{"REDIS_TLS_KEY_FILE", "/etc/redis/client.key", func(c *Config) any { return c.Redis.TLSKeyFile }, "/etc/redis/client.key"},
+	{"USER_CACHE_TTL", "90s", func(c *Config) any { return c.Redis.UserCacheTTL }, 90 * time.Second},
+	{"IDEMPOTENCY_TTL", "1h", func(c *Config) any { return c.Redis.IdempotencyTTL }, time.Hour},
+
+	{"RABBITMQ_HOST", "mq", func(c *Config) any { return c.RabbitMQ.Host }, "mq"},
+	{"RABBITMQ_PORT", "5673", func(c *Config) any { return c.RabbitMQ.Port }, 5673},
+	{"RABBITMQ_USER", "mu", func(c *Config) any { return c.RabbitMQ.User }, "mu"},
+	{"RABBITMQ_PASSWORD", "mp", func(c *Config) any { return c.RabbitMQ.Password }, "mp"},
+	{"RABBITMQ_VHOST", "/v", func(c *Config) any { return c.RabbitMQ.VHost }, "/v"},
+	{"RABBITMQ_TLS_ENABLED", "true", func(c *Config) any { return c.RabbitMQ.TLSEnabled }, true},
+	{"RABBITMQ_TLS_SKIP_VERIFY", "true", func(c *Config) any { return c.RabbitMQ.TLSSkipVerify }, true},
+	{"RABBITMQ_TLS_CA_FILE", "/etc/mq/ca.pem", func(c *Config) any { return c.RabbitMQ.TLSCAFile }, "/etc/mq/ca.pem"},
+	{"RABBITMQ_TLS_CERT_FILE", "/etc/mq/client.pem", func(c *Config) any { return c.RabbitMQ.TLSCertFile }, "/etc/mq/client.pem"},
+	{"RABBITMQ_TLS_KEY_FILE", "/etc/mq/client.key", func(c *Config) any { return c.RabbitMQ.TLSKeyFile }, "/etc/mq/client.key"},
+
+	{"JWT_SECRET", "s3cret", func(c *Config) any { return c.Auth.JWTSecret }, "s3cret"},
+	{"JWT_SECRET_PREVIOUS", "old2, old1", func(c *Config) any { return c.Auth.JWTSecretPrevious }, []string{"old2", "old1"}},
+	{"JWT_EXPIRATION", "2", func(c *Config) any { return c.Auth.JWTExpiration }, 2},
+	{"TOKEN_MODE", "public", func(c *Config) any { return c.Auth.TokenMode }, "public"},
+	{"TOKEN_SIGNING_KEYS", "a@1, b@2,", func(c *Config) any { return c.Auth.TokenSigningKeys }, []string{"a@1", "b@2"}},
+	{"TOKEN_KEY_OVERLAP", "36h", func(c *Config) any { return c.Auth.TokenKeyOverlap }, 36 * time.Hour},
+	{"LOGIN_MAX_ATTEMPTS", "9", func(c *Config) any { return c.Auth.LoginMaxAttempts }, 9},
+	{"LOGIN_LOCKOUT_MINUTES", "20", func(c *Config) any { return c.Auth.LoginLockoutMinutes }, 20},
+	{"LOGIN_WINDOW_MINUTES", "10", func(c *Config) any { return c.Auth.LoginWindowMinutes }, 10},
+	{"LOGIN_MAX_ATTEMPTS_PER_IP", "50", func(c *Config) any { return c.Auth.LoginMaxAttemptsPerIP }, 50},
+	{"PASSWORD_RESET_TTL", "45m", func(c *Config) any { return c.Auth.PasswordResetTTL }, 45 * time.Minute},
+	{"INVITE_TTL", "48h", func(c *Config) any { return c.Auth.InviteTTL }, 48 * time.Hour},
+	{"AUTH_ROLE_MODE", "monitor", func(c *Config) any { return c.Auth.RoleMode }, "monitor"},
+	{"AUTHZ_POLICY_FILE", "policy.json", func(c *Config) any { return c.Auth.PolicyFile }, "policy.json"},
+
+	{"OTEL_ENABLED", "false", func(c *Config) any { return c.Observability.Tracing.Enabled }, false},
+	{"OTEL_ENDPOINT", "otel:4317", func(c *Config) any { return c.Observability.Tracing.Endpoint }, "otel:4317"},
+	{"OTEL_SERVICE_NAME", "otel-svc", func(c *Config) any { return c.Observability.Tracing.ServiceName }, "otel-svc"},
+	{"OTEL_EXPORTER_TYPE", "otlp", func(c *Config) any { return c.Observability.Tracing.ExporterType }, "otlp"},
+	{"OTEL_SAMPLE_RATIO", "0.25", func(c *Config) any { return c.Observability.Tracing.SampleRatio }, 0.25},
+	{"OTEL_SAMPLER", "never", func(c *Config) any { return c.Observability.Tracing.Sampler }, "never"},
+	{"OTEL_HEADERS", "x-api-key=abc", func(c *Config) any { return c.Observability.Tracing.Headers }, "x-api-key=abc"},
+	{"METRICS_EXPORTER", "prometheus", func(c *Config) any { return c.Observability.Tracing.MetricsExporter }, "prometheus"},
+	{"OTEL_METRICS_INTERVAL", "15s", func(c *Config) any { return c.Observability.Tracing.MetricsInterval }, 15 * time.Second},
+	{"LOG_LEVEL", "debug", func(c *Config) any { return c.Observability.Log.Level }, "debug"},
+	{"LOG_FORMAT", "json", func(c *Config) any { return c.Observability.Log.Format }, "json"},
+	{"METRICS_AUTH_TOKEN", "mt", func(c *Config) any { return c.Observability.MetricsAuthToken }, "mt"},
+	{"OBSERVABILITY_SKIP_PATHS", "/health, /docs/*", func(c *Config) any { return c.Observability.SkipPaths }, []string{"/health", "/docs/*"}},
+	{"LOG_SLOW_REQUEST_MS", "250", func(c *Config) any { return c.Observability.SlowRequestThreshold }, 250 * time.Millisecond},
+	{"LOG_SLOW_ROUTES", "/api/v1/users/import=10s, /reports/*=2000", func(c *Config) any { return c.Observability.SlowRoutes }, []string{"/api/v1/users/import=10s", "/reports/*=2000"}},
+	{"LOG_SUCCESS_SAMPLE_RATE", "10", func(c *Config) any { return c.Observability.SuccessSampleRate }, 10},
+	{"LOG_HTTP_BODIES", "true", func(c *Config) any { return c.Observability.LogHTTPBodies }, true},
+	{"LOG_HTTP_BODY_MAX_BYTES", "1024", func(c *Config) any { return c.Observability.HTTPBodyMaxBytes }, 1024},
+	{"LOG_HTTP_SENSITIVE_FIELDS", "ssn, iban", func(c *Config) any { return c.Observability.HTTPSensitiveFields }, []string{"ssn", "iban"}},
+	{"HEALTH_CHECK_TIMEOUT", "500ms", func(c *Config) any { return c.Observability.HealthCheckTimeout }, 500 * time.Millisecond},
+	{"HEALTH_CACHE_TTL", "0s", func(c *Config) any { return c.Observability.HealthCacheTTL }, time.Duration(0)},
+
+	{"REGISTER_DELETED_EMAIL", "block", func(c *Config) any { return c.RegisterDeletedEmail }, "block"},
+	{"MAX_OFFSET", "500", func(c *Config) any { return c.MaxOffset }, 500},
+	{"COMPANY_MAX_USERS", "50", func(c *Config) any { return c.CompanyMaxUsers }, 50},
+	{"USER_IMPORT_MAX_ROWS", "250", func(c *Config) any { return c.UserImportMaxRows }, 250},
+	{"USER_IMPORT_BATCH_SIZE", "25", func(c *Config) any { return c.UserImportBatchSize }, 25},
+	{"USER_EXPORT_TIMEOUT", "90s", func(c *Config) any { return c.UserExportTimeout }, 90 * time.Second},
+	{"UPDATE_REQUIRE_IF_MATCH", "true", func(c *Config) any { return c.UpdateRequireIfMatch }, true},
+	{"ACCOUNT_DELETION_GRACE_DAYS", "3", func(c *Config) any { return c.AccountDeletionGraceDays }, 3},
+	{"ACCOUNT_PURGE_INTERVAL_MINUTES", "4", func(c *Config) any { return c.AccountPurgeIntervalMinutes }, 4},
+	{"ACTIVE_USERS_REFRESH_MINUTES", "6", func(c *Config) any { return c.ActiveUsersRefreshMinutes }, 6},
+	{"IMPERSONATION_NOTIFY", "true", func(c *Config) any { return c.ImpersonationNotify }, true},
+	{"JOURNAL_ENABLED", "true", func(c *Config) any { return c.JournalEnabled }, true},
+	{"JOURNAL_SINK", "redis", func(c *Config) any { return c.JournalSink }, "redis"},
+	{"JOURNAL_FILE", "j.jsonl", func(c *Config) any { return c.JournalFile }, "j.jsonl"},
+	{"JOURNAL_MAX_SIZE_MB", "2", func(c *Config) any { return c.JournalMaxSizeMB }, 2},
+	{"JOURNAL_MAX_FILES", "3", func(c *Config) any { return c.JournalMaxFiles }, 3},
+	{"JOURNAL_STREAM", "js", func(c *Config) any { return c.JournalStream }, "js"},
+	{"JOURNAL_STREAM_MAXLEN", "99", func(c *Config) any { return c.JournalStreamMaxLen }, int64(99)},
+	{"OUTBOX_ENABLED", "false", func(c *Config) any { return c.OutboxEnabled }, false},
+	{"OUTBOX_RELAY_INTERVAL", "5s", func(c *Config) any { return c.OutboxRelayInterval }, 5 * time.Second},
+	{"OUTBOX_BATCH_SIZE", "25", func(c *Config) any { return c.OutboxBatchSize }, 25},
+	{"OUTBOX_RETENTION", "24h", func(c *Config) any { return c.OutboxRetention }, 24 * time.Hour},
+	{"NOTIFY_CHANNEL", "nc", func(c *Config) any { return c.NotifyChannel }, "nc"},
+	{"NOTIFY_BUFFER_SIZE", "16", func(c *Config) any { return c.NotifyBufferSize }, 16},
+	{"WORKER_METRICS_PORT", "9100", func(c *Config) any { return c.WorkerMetricsPort }, 9100},
+	{"WORKER_ADMIN_TOKEN", "wt", func(c *Config) any { return c.WorkerAdminToken }, "wt"},
+	{"MAIL_DRIVER", "smtp", func(c *Config) any { return c.MailDriver }, "smtp"},
+	{"MAIL_FROM", "a@example.com", func(c *Config) any { return c.MailFrom }, "a@example.com"},
+	{"MAIL_DEFAULT_LOCALE", "id", func(c *Config) any { return c.MailDefaultLocale }, "id"},
+	{"MAIL_OUTBOX_DIR", "outbox", func(c *Config) any { return c.MailOutboxDir }, "outbox"},
+	{"SMTP_HOST", "smtp", func(c *Config) any { return c.SMTPHost }, "smtp"},
+	{"SMTP_PORT", "25", func(c *Config) any { return c.SMTPPort }, 25},
+	{"SMTP_USERNAME", "su", func(c *Config) any { return c.SMTPUsername }, "su"},
+	{"SMTP_PASSWORD", "sp", func(c *Config) any { return c.SMTPPassword }, "sp"},
+	{"SMTP_STARTTLS", "false", func(c *Config) any { return c.SMTPStartTLS }, false},
+	{"FRONTEND_BASE_URLS", "https://app.example.com", func(c *Config) any { return c.FrontendBaseURLs }, "https://app.example.com"},
+}
+
+func TestNew_EnvVarsKeepTheirEffectiveValues(t *testing.T) {
+	t.Chdir(t.TempDir()) // no dotenv files
+	for _, e := range envCompat {
+		t.Setenv(e.key, e.value)
+	}
+
+	cfg, err := New()
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	for _, e := range envCompat {
+		if got := e.got(cfg); !reflect.DeepEqual(got, e.want) {
+			t.Errorf("%s=%q: got %#v, want %#v", e.key, e.value, got, e.want)
+		}
+	}
+
+	// A new key needs a row here, so its mapping is checked too.
+	covered := map[string]bool{}
+	for _, e := range envCompat {
+		covered[e.key] = true
+	}
+	for _, key := range configKeys() {
+		if !covered[key] {
+			t.Errorf("%s has no envCompat row", key)
+		}
+	}
+}
+
+func TestNew_LegacyDurationUnits(t *testing.T) {
+	t.Chdir(t.TempDir())
+	for key := range durationUnits {
+		unsetEnv(t, key)
+	}
+
+	cfg, err := New()
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defaults := map[string][2]time.Duration{
+		"DB_CONN_MAX_LIFETIME":    {cfg.DB.ConnMaxLifetime, time.Hour},
+		"REDIS_SLOW_THRESHOLD_MS": {cfg.Redis.SlowThreshold, 50 * time.Millisecond},
+		"HTTP_READ_TIMEOUT":       {cfg.HTTP.ReadTimeout, 15 * time.Second},
+		"REQUEST_TIMEOUT":         {cfg.HTTP.RequestTimeout, 30 * time.Second},
+	}
+	for key, d := range defaults {
+		if d[0] != d[1] {
+			t.Errorf("default %s = %s, want %s", key, d[0], d[1])
+		}
+	}
+
+	// Go duration strings are accepted as well as bare numbers.
+	t.Setenv("DB_CONN_MAX_LIFETIME", "90s")
+	t.Setenv("REDIS_SLOW_THRESHOLD_MS", "1.5")
+	if cfg, err = New(); err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if cfg.DB.ConnMaxLifetime != 90*time.Second || cfg.Redis.SlowThreshold != 1500*time.Microsecond {
+		t.Errorf("got %s and %s, want 1m30s and 1.5ms", cfg.DB.ConnMaxLifetime, cfg.Redis.SlowThreshold)
+	}
+}
@@ -3,10 +3,19 @@ package config
 
 import (
 	"context"
-	"encoding/hex"
+	"errors"
 	"fmt"
-	"reflect"
+	"io/fs"
+	"os"
 	"strings"
+	"time"
+
+	"veemon/app/usecase/user"
+	"veemon/handler"
+	"veemon/pkg/database"
+	"veemon/pkg/rabbitmq"
+	"veemon/pkg/redis"
+	"veemon/pkg/token"
 
 	"github.com/spf13/viper"
 )
@@ -16,91 +25,140 @@ import (
 // publicly known token-signing key.
 const placeholderJWTSecret = "your-secret-key-change-in-production"
 
+// Config is the whole configuration, one environment variable per field.
+// Infrastructure settings are grouped in sections, each the config struct
+// of the package it configures (or embedding it), so a new setting is a
+// field and a default rather than a copy in a config.New* function. The
+// sections are squashed, so their env var names are unchanged.
 type Config struct {
 	ServiceName string `mapstructure:"SERVICE_NAME"`
 	Environment string `mapstructure:"ENVIRONMENT"`
 
-	// HTTP Server
-	HTTPPort         int  `mapstructure:"HTTP_PORT"`
-	Prefork          bool `mapstructure:"PREFORK"`
-	HTTPReadTimeout  int  `mapstructure:"HTTP_READ_TIMEOUT"`  // seconds
-	HTTPWriteTimeout int  `mapstructure:"HTTP_WRITE_TIMEOUT"` // seconds
-	HTTPIdleTimeout  int  `mapstructure:"HTTP_IDLE_TIMEOUT"`  // seconds
-	// RequestTimeout bounds how long a single request's downstream work
-	// (DB/Redis/etc.) may run before its context is canceled. seconds.
-	RequestTimeout int `mapstructure:"REQUEST_TIMEOUT"`
-
-	// gRPC Server
-	GRPCPort int `mapstructure:"GRPC_PORT"`
-
-	// Database
-	DBHost     string `mapstructure:"DB_HOST"`
-	DBPort     int    `mapstructure:"DB_PORT"`
-	DBUser     string `mapstructure:"DB_USER"`
-	DBPassword string `mapstructure:"DB_PASSWORD"`
-	DBName     string `mapstructure:"DB_NAME"`
-	DBTimezone string `mapstructure:"DB_TIMEZONE"`
-	DBSSLMode  string `mapstructure:"DB_SSL_MODE"`
-
-	// Database Performance
-	DBPrepareStmt            bool `mapstructure:"DB_PREPARE_STMT"`             // Enable prepared statement cache
-	DBSkipDefaultTransaction bool `mapstructure:"DB_SKIP_DEFAULT_TRANSACTION"` // Disable transactions for better performance
-
-	// Database connection pool
-	DBMaxIdleConns    int `mapstructure:"DB_MAX_IDLE_CONNS"`
-	DBMaxOpenConns    int `mapstructure:"DB_MAX_OPEN_CONNS"`
-	DBConnMaxLifetime int `mapstructure:"DB_CONN_MAX_LIFETIME"` // minutes
-
-	// DBAutoMigrate runs GORM AutoMigrate on startup. Defaults to false —
-	// golang-migrate SQL migrations are the source of truth. Enable only for
-	// local development convenience.
-	DBAutoMigrate bool `mapstructure:"DB_AUTO_MIGRATE"`
-
-	// Redis
-	RedisHost         string `mapstructure:"REDIS_HOST"`
-	RedisPort         int    `mapstructure:"REDIS_PORT"`
-	RedisPassword     string `mapstructure:"REDIS_PASSWORD"`
-	RedisDB           int    `mapstructure:"REDIS_DB"`
-	RedisMaxIdle      int    `mapstructure:"REDIS_MAX_IDLE"`
-	RedisMaxActive    int    `mapstructure:"REDIS_MAX_ACTIVE"`
-	RedisIdleTimeout  int    `mapstructure:"REDIS_IDLE_TIMEOUT"`  // seconds
-	RedisDialTimeout  int    `mapstructure:"REDIS_DIAL_TIMEOUT"`  // seconds
-	RedisReadTimeout  int    `mapstructure:"REDIS_READ_TIMEOUT"`  // seconds
-	RedisWriteTimeout int    `mapstructure:"REDIS_WRITE_TIMEOUT"` // seconds
-
-	// Login protection (account lockout after repeated failures)
-	LoginMaxAttempts    int `mapstructure:"LOGIN_MAX_ATTEMPTS"`
-	LoginLockoutMinutes int `mapstructure:"LOGIN_LOCKOUT_MINUTES"`
-
-	// RabbitMQ
-	RabbitMQHost     string `mapstructure:"RABBITMQ_HOST"`
-	RabbitMQPort     int    `mapstructure:"RABBITMQ_PORT"`
-	RabbitMQUser     string `mapstructure:"RABBITMQ_USER"`
-	RabbitMQPassword string `mapstructure:"RABBITMQ_PASSWORD"`
-	RabbitMQVHost    string `mapstructure:"RABBITMQ_VHOST"`
-
-	// JWT
-	JWTSecret     string `mapstructure:"JWT_SECRET"`
-	JWTExpiration int    `mapstructure:"JWT_EXPIRATION"`
-
-	// CORS
-	CORSOrigins string `mapstructure:"CORS_ORIGINS"`
-
-	// OpenTelemetry
-	OTelEnabled      bool    `mapstructure:"OTEL_ENABLED"`
-	OTelEndpoint     string  `mapstructure:"OTEL_ENDPOINT"`
-	OTelServiceName  string  `mapstructure:"OTEL_SERVICE_NAME"`
-	OTelExporterType string  `mapstructure:"OTEL_EXPORTER_TYPE"`
-	OTelSampleRatio  float64 `mapstructure:"OTEL_SAMPLE_RATIO"` // 0.0-1.0; parent-based ratio sampler
+	HTTP          HTTPConfig          `mapstructure:",squash"`
+	DB            DBConfig            `mapstructure:",squash"`
+	Redis         RedisConfig         `mapstructure:",squash"`
+	RabbitMQ      rabbitmq.Config     `mapstructure:",squash"`
+	Auth          AuthConfig          `mapstructure:",squash"`
+	Observability ObservabilityConfig `mapstructure:",squash"`
+
+	// Registration: what to do when the email matches a soft-deleted account
+	// (new | reactivate | block).
+	RegisterDeletedEmail string `mapstructure:"REGISTER_DELETED_EMAIL"`
+
+	// Deepest row offset list endpoints page to; deeper pages need a cursor.
+	MaxOffset int `mapstructure:"MAX_OFFSET"`
+
+	// Live users allowed per company, enforced on registration and
+	// `migrate import-users`. There is no company table yet, so one limit
+	// applies to every company; 0 means unlimited.
+	CompanyMaxUsers int `mapstructure:"COMPANY_MAX_USERS"`
+
+	// POST /api/v1/users/import: most rows one CSV may have, and rows
+	// created per transaction.
+	UserImportMaxRows   int `mapstructure:"USER_IMPORT_MAX_ROWS"`
+	UserImportBatchSize int `mapstructure:"USER_IMPORT_BATCH_SIZE"`
+	// GET /api/v1/users/export: how long one export may run, writing
+	// included.
+	UserExportTimeout time.Duration `mapstructure:"USER_EXPORT_TIMEOUT"`
+
+	// Refuse PUT /api/v1/users/:id without If-Match (expectedUpdatedAt over
+	// gRPC) with 428, instead of letting the last write win.
+	UpdateRequireIfMatch bool `mapstructure:"UPDATE_REQUIRE_IF_MATCH"`
+
+	// Self-service account deletion: days a deletion can still be cancelled
+	// before the account is purged, and how often the worker purges (minutes,
+	// 0 disables purging in that worker).
+	AccountDeletionGraceDays    int `mapstructure:"ACCOUNT_DELETION_GRACE_DAYS"`
+	AccountPurgeIntervalMinutes int `mapstructure:"ACCOUNT_PURGE_INTERVAL_MINUTES"`
+
+	// How often the worker republishes the active_users gauges from the
+	// HyperLogLogs the API fills (minutes, 0 disables).
+	ActiveUsersRefreshMinutes int `mapstructure:"ACTIVE_USERS_REFRESH_MINUTES"`
+
+	// Email users when a superadmin issues an impersonation token for them
+	// (queued through RabbitMQ for the worker to send).
+	ImpersonationNotify bool `mapstructure:"IMPERSONATION_NOTIFY"`
+
+	// Mutation journal (non-production only): records POST/PUT/PATCH/DELETE
+	// requests under /api/v1 for `migrate journal-replay`. JournalSink is
+	// "file" (rotated at JournalMaxSizeMB, keeping JournalMaxFiles backups) or
+	// "redis" (a stream capped at JournalStreamMaxLen entries).
+	JournalEnabled      bool   `mapstructure:"JOURNAL_ENABLED"`
+	JournalSink         string `mapstructure:"JOURNAL_SINK"`
+	JournalFile         string `mapstructure:"JOURNAL_FILE"`
+	JournalMaxSizeMB    int    `mapstructure:"JOURNAL_MAX_SIZE_MB"`
+	JournalMaxFiles     int    `mapstructure:"JOURNAL_MAX_FILES"`
+	JournalStream       string `mapstructure:"JOURNAL_STREAM"`
+	JournalStreamMaxLen int64  `mapstructure:"JOURNAL_STREAM_MAXLEN"`
+
+	// Notification websocket (GET /api/v1/ws/notifications): user events are
+	// relayed between replicas on the NotifyChannel Redis pub/sub channel, and
+	// each connection queues up to NotifyBufferSize events before it is
+	// disconnected as a slow client.
+	NotifyChannel    string `mapstructure:"NOTIFY_CHANNEL"`
+	NotifyBufferSize int    `mapstructure:"NOTIFY_BUFFER_SIZE"`
+
+	// Transactional outbox: user events are staged in the outbox_messages
+	// table in the transaction of the change, and the worker relays them to
+	// RabbitMQ every OutboxRelayInterval, OutboxBatchSize at a time. Sent
+	// messages are deleted after OutboxRetention. Disabled, the API
+	// publishes user events directly after the commit.
+	OutboxEnabled       bool          `mapstructure:"OUTBOX_ENABLED"`
+	OutboxRelayInterval time.Duration `mapstructure:"OUTBOX_RELAY_INTERVAL"`
+	OutboxBatchSize     int           `mapstructure:"OUTBOX_BATCH_SIZE"`
+	OutboxRetention     time.Duration `mapstructure:"OUTBOX_RETENTION"`
+
+	// Worker HTTP listener: /metrics plus, when WORKER_ADMIN_TOKEN is set,
+	// the /admin/consumers pause/resume endpoints. Port 0 disables it.
+	WorkerMetricsPort int    `mapstructure:"WORKER_METRICS_PORT"`
+	WorkerAdminToken  string `mapstructure:"WORKER_ADMIN_TOKEN"`
+
+	// Mail. MAIL_DRIVER "log" captures emails (log, or .eml files under
+	// MAIL_OUTBOX_DIR) instead of sending, and is refused in production;
+	// "smtp" delivers via SMTP_*.
+	MailDriver        string `mapstructure:"MAIL_DRIVER"`
+	MailFrom          string `mapstructure:"MAIL_FROM"`
+	MailDefaultLocale string `mapstructure:"MAIL_DEFAULT_LOCALE"`
+	MailOutboxDir     string `mapstructure:"MAIL_OUTBOX_DIR"`
+	SMTPHost          string `mapstructure:"SMTP_HOST"`
+	SMTPPort          int    `mapstructure:"SMTP_PORT"`
+	SMTPUsername      string `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword      string `mapstructure:"SMTP_PASSWORD"`
+	SMTPStartTLS      bool   `mapstructure:"SMTP_STARTTLS"`
+
+	// FrontendBaseURLs lists the frontend origins (comma-separated) that
+	// email links and post-action redirects may point at. The first is used
+	// to build links. See pkg/urlpolicy.
+	FrontendBaseURLs string `mapstructure:"FRONTEND_BASE_URLS"`
+
+	// sources records, per key, every layer that supplied a value, highest
+	// precedence first. See SensitiveOverrides.
+	sources map[string][]string
+}
 
-	// Observability
-	// MetricsAuthToken, when set, requires `Authorization: Bearer <token>` on
-	// the /metrics endpoint. Empty means open (restrict at the network layer).
-	MetricsAuthToken string `mapstructure:"METRICS_AUTH_TOKEN"`
+// Configuration layers, lowest precedence first: code defaults, .env,
+// .env.<ENVIRONMENT>, then real environment variables (including secrets
+// exported by Infisical). A variable that is set in the environment always
+// wins, even when it is set to the empty string.
+const (
+	sourceEnv       = "env"
+	sourceInfisical = "infisical"
+)
 
-	// Logger
-	LogLevel  string `mapstructure:"LOG_LEVEL"`
-	LogFormat string `mapstructure:"LOG_FORMAT"`
+// sensitiveKeys are the keys whose shadowed sources are reported at startup.
+var sensitiveKeys = []string{
+	"JWT_SECRET",
+	"JWT_SECRET_PREVIOUS",
+	"TOKEN_SIGNING_KEYS",
+	"DB_PASSWORD",
+	"REDIS_PASSWORD",
+	"REDIS_SENTINEL_PASSWORD",
+	"RABBITMQ_PASSWORD",
+	"SMTP_PASSWORD",
+	"METRICS_AUTH_TOKEN",
+	"OTEL_HEADERS",
+	"WORKER_ADMIN_TOKEN",
+	"INFISICAL_CLIENT_SECRET",
 }
 
 func New() (*Config, error) {
@@ -109,15 +167,16 @@ func New() (*Config, error) {
 	// Set defaults
 	setDefaults(v)
 
-	// Read from .env file
-	v.SetConfigFile(".env")
-	v.SetConfigType("env")
-
-	// Read config file (ignore error if not found)
-	_ = v.ReadInConfig() //nolint:errcheck
+	// Dotenv files are read into defaults, not config, so they can never
+	// outrank the environment: viper ranks env above defaults unconditionally.
+	fileSources, err := loadEnvFiles(v, envFiles(v))
+	if err != nil {
+		return nil, err
+	}
 
 	// Read from environment variables.
 	v.AutomaticEnv()
+	v.AllowEmptyEnv(true)
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	// Explicitly bind every Config key to its env var. viper's Unmarshal does
 	// NOT consult AutomaticEnv for keys it isn't otherwise aware of (e.g. those
@@ -125,26 +184,137 @@ func New() (*Config, error) {
 	// like JWT_SECRET would be silently dropped without this binding.
 	bindEnvs(v)
 
-	if err := loadRemoteEnvironment(context.Background(), v); err != nil {
+	fromInfisical, err := loadRemoteEnvironment(context.Background(), v)
+	if err != nil {
 		return nil, err
 	}
 
+	applyDurationUnits(v)
+
 	var cfg Config
-	if err := v.Unmarshal(&cfg); err != nil {
+	if err := v.Unmarshal(&cfg, decodeHooks); err != nil {
 		return nil, err
 	}
+	if !v.IsSet("DB_AUTO_MIGRATE") {
+		cfg.DB.AutoMigrate = cfg.Environment == "development"
+	}
+	if !v.IsSet("MAIL_DRIVER") {
+		cfg.MailDriver = "log"
+		if cfg.Environment == "production" {
+			cfg.MailDriver = "smtp"
+		}
+	}
+	cfg.sources = collectSources(fileSources, fromInfisical)
 
+	var problems violations
+	cfg.validateValues(&problems)
+	if err := problems.err(); err != nil {
+		return nil, err
+	}
 	return &cfg, nil
 }
 
+// envFiles returns the dotenv files to layer, lowest precedence first. The
+// environment name comes from the real environment, else from .env, else the
+// default.
+func envFiles(defaults *viper.Viper) []string {
+	environment, ok := os.LookupEnv("ENVIRONMENT")
+	if !ok {
+		environment = defaults.GetString("ENVIRONMENT")
+		if base, err := readEnvFile(".env"); err == nil {
+			if e, ok := base["environment"]; ok {
+				environment = e
+			}
+		}
+	}
+	files := []string{".env"}
+	if environment = strings.TrimSpace(environment); environment != "" {
+		files = append(files, ".env."+environment)
+	}
+	return files
+}
+
+// readEnvFile parses one dotenv file; keys come back lower-cased, as viper
+// stores them.
+func readEnvFile(path string) (map[string]string, error) {
+	f := viper.New()
+	f.SetConfigFile(path)
+	f.SetConfigType("env")
+	if err := f.ReadInConfig(); err != nil {
+		return nil, err
+	}
+	out := make(map[string]string, len(f.AllKeys()))
+	for _, k := range f.AllKeys() {
+		out[k] = f.GetString(k)
+	}
+	return out, nil
+}
+
+// loadEnvFiles applies files as defaults in order, so later files override
+// earlier ones, and returns which files defined each (upper-cased) key,
+// highest precedence first. Missing files are skipped.
+func loadEnvFiles(v *viper.Viper, files []string) (map[string][]string, error) {
+	sources := map[string][]string{}
+	for _, path := range files {
+		values, err := readEnvFile(path)
+		if err != nil {
+			var notFound viper.ConfigFileNotFoundError
+			if errors.As(err, &notFound) || errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+		for k, val := range values {
+			v.SetDefault(k, val)
+			key := strings.ToUpper(k)
+			sources[key] = append([]string{path}, sources[key]...)
+		}
+	}
+	return sources, nil
+}
+
+// collectSources merges the file layers with the environment for every
+// Config key.
+func collectSources(files map[string][]string, fromInfisical []string) map[string][]string {
+	infisical := make(map[string]bool, len(fromInfisical))
+	for _, k := range fromInfisical {
+		infisical[k] = true
+	}
+
+	out := map[string][]string{}
+	for _, key := range configKeys() {
+		var layers []string
+		if infisical[key] {
+			layers = append(layers, sourceInfisical)
+		} else if _, ok := os.LookupEnv(key); ok {
+			layers = append(layers, sourceEnv)
+		}
+		layers = append(layers, files[key]...)
+		if len(layers) > 0 {
+			out[key] = layers
+		}
+	}
+	return out
+}
+
+// SensitiveOverrides reports the security-sensitive keys that more than one
+// layer supplied, mapped to their layers (winner first). Values are never
+// included, so the result is safe to log.
+func (c *Config) SensitiveOverrides() map[string][]string {
+	out := map[string][]string{}
+	for _, key := range sensitiveKeys {
+		if layers := c.sources[key]; len(layers) > 1 {
+			out[key] = layers
+		}
+	}
+	return out
+}
+
 // bindEnvs binds every mapstructure-tagged Config field to its environment
 // variable so viper.Unmarshal reliably picks up env-only overrides.
 func bindEnvs(v *viper.Viper) {
-	t := reflect.TypeOf(Config{})
-	for i := 0; i < t.NumField(); i++ {
-		if tag := t.Field(i).Tag.Get("mapstructure"); tag != "" {
-			_ = v.BindEnv(tag)
-		}
+	for _, key := range configKeys() {
+		_ = v.BindEnv(key)
 	}
 }
 
@@ -161,6 +331,8 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("HTTP_WRITE_TIMEOUT", 30)
 	v.SetDefault("HTTP_IDLE_TIMEOUT", 60)
 	v.SetDefault("REQUEST_TIMEOUT", 30)
+	v.SetDefault("HTTP_BODY_LIMIT_KB", 1024)
+	v.SetDefault("SHUTDOWN_DRAIN_DELAY", "5s")
 
 	// Database
 	v.SetDefault("DB_HOST", "localhost")
@@ -172,6 +344,7 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("DB_SSL_MODE", "disable")
 
 	// Database Performance
+	v.SetDefault("DB_POOLER_MODE", string(database.PoolerNone))
 	v.SetDefault("DB_PREPARE_STMT", true)              // Enable prepared statement cache for better performance
 	v.SetDefault("DB_SKIP_DEFAULT_TRANSACTION", false) // Keep transactions enabled by default for data consistency
 
@@ -179,11 +352,27 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("DB_MAX_IDLE_CONNS", 10)
 	v.SetDefault("DB_MAX_OPEN_CONNS", 100)
 	v.SetDefault("DB_CONN_MAX_LIFETIME", 60) // minutes
+	v.SetDefault("DB_CONN_MAX_IDLE_TIME", "10m")
+	v.SetDefault("DB_REPLICA_HOSTS", "")
 
-	// Schema management: golang-migrate is the source of truth; AutoMigrate off.
-	v.SetDefault("DB_AUTO_MIGRATE", false)
+	// Schema management: golang-migrate is the source of truth. DB_AUTO_MIGRATE
+	// has no default here: New turns it on in development only.
+	v.SetDefault("DB_AUTO_MIGRATE_SOURCE", AutoMigrateGorm)
+	v.SetDefault("DB_MIGRATION_FORMAT", "sequential")
+	v.SetDefault("DB_STATS_INTERVAL", 15) // seconds
+
+	// Table naming
+	v.SetDefault("DB_TABLE_PREFIX", "")
+	v.SetDefault("DB_SINGULAR_TABLE", false)
+
+	// SQL query logging
+	v.SetDefault("DB_LOG_QUERIES", false)
+	v.SetDefault("DB_LOG_QUERY_SAMPLE_RATE", 1)
+	v.SetDefault("DB_LOG_REDACTION", "all")
+	v.SetDefault("DB_LOG_SENSITIVE_COLUMNS", "password,email,phone,token,secret")
 
 	// Redis
+	v.SetDefault("REDIS_MODE", redis.ModeStandalone)
 	v.SetDefault("REDIS_HOST", "localhost")
 	v.SetDefault("REDIS_PORT", 6379)
 	v.SetDefault("REDIS_PASSWORD", "")
@@ -194,10 +383,74 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("REDIS_DIAL_TIMEOUT", 5)
 	v.SetDefault("REDIS_READ_TIMEOUT", 3)
 	v.SetDefault("REDIS_WRITE_TIMEOUT", 3)
+	v.SetDefault("REDIS_SLOW_THRESHOLD_MS", 50)
+	v.SetDefault("REDIS_STATS_INTERVAL", 15)
+	v.SetDefault("REDIS_SCAN_COUNT", 100)
+	v.SetDefault("REDIS_SENTINEL_MASTER", "")
+	v.SetDefault("REDIS_SENTINEL_ADDRS", "")
+	v.SetDefault("REDIS_SENTINEL_PASSWORD", "")
+	v.SetDefault("REDIS_CLUSTER_ADDRS", "")
+	v.SetDefault("REDIS_TLS_ENABLED", false)
+	v.SetDefault("REDIS_TLS_SKIP_VERIFY", false)
+	v.SetDefault("REDIS_TLS_CA_FILE", "")
+	v.SetDefault("REDIS_TLS_CERT_FILE", "")
+	v.SetDefault("REDIS_TLS_KEY_FILE", "")
+	v.SetDefault("USER_CACHE_TTL", "5m")
+	v.SetDefault("IDEMPOTENCY_TTL", "24h")
 
 	// Login protection
 	v.SetDefault("LOGIN_MAX_ATTEMPTS", 5)
 	v.SetDefault("LOGIN_LOCKOUT_MINUTES", 15)
+	v.SetDefault("LOGIN_WINDOW_MINUTES", 15)
+	v.SetDefault("LOGIN_MAX_ATTEMPTS_PER_IP", 20)
+	v.SetDefault("PASSWORD_RESET_TTL", "30m")
+	v.SetDefault("INVITE_TTL", "72h")
+	v.SetDefault("AUTH_ROLE_MODE", "")
+	v.SetDefault("AUTHZ_POLICY_FILE", "")
+
+	// Token format
+	v.SetDefault("TOKEN_MODE", token.ModeLocal)
+	v.SetDefault("TOKEN_KEY_OVERLAP", "0s") // 0 = the token lifetime
+
+	// Registration
+	v.SetDefault("REGISTER_DELETED_EMAIL", "new")
+
+	// Pagination
+	v.SetDefault("MAX_OFFSET", user.DefaultMaxOffset)
+	v.SetDefault("COMPANY_MAX_USERS", 0)
+	v.SetDefault("USER_IMPORT_MAX_ROWS", handler.DefaultUserImportMaxRows)
+	v.SetDefault("USER_IMPORT_BATCH_SIZE", handler.DefaultUserImportBatchSize)
+	v.SetDefault("USER_EXPORT_TIMEOUT", handler.DefaultUserExportTimeout.String())
+	v.SetDefault("UPDATE_REQUIRE_IF_MATCH", false)
+
+	// Account deletion
+	v.SetDefault("ACCOUNT_DELETION_GRACE_DAYS", 14)
+	v.SetDefault("ACCOUNT_PURGE_INTERVAL_MINUTES", 60)
+
+	// Active users
+	v.SetDefault("ACTIVE_USERS_REFRESH_MINUTES", 5)
+
+	// Impersonation
+	v.SetDefault("IMPERSONATION_NOTIFY", false)
+
+	// Mutation journal
+	v.SetDefault("JOURNAL_ENABLED", false)
+	v.SetDefault("JOURNAL_SINK", "file")
+	v.SetDefault("JOURNAL_FILE", "journal/requests.jsonl")
+	v.SetDefault("JOURNAL_MAX_SIZE_MB", 10)
+	v.SetDefault("JOURNAL_MAX_FILES", 5)
+	v.SetDefault("JOURNAL_STREAM", "journal:requests")
+	v.SetDefault("JOURNAL_STREAM_MAXLEN", 10000)
+
+	// Notification websocket
+	v.SetDefault("NOTIFY_CHANNEL", "notifications")
+	v.SetDefault("NOTIFY_BUFFER_SIZE", 64)
+
+	// Transactional outbox
+	v.SetDefault("OUTBOX_ENABLED", true)
+	v.SetDefault("OUTBOX_RELAY_INTERVAL", "1s")
+	v.SetDefault("OUTBOX_BATCH_SIZE", 100)
+	v.SetDefault("OUTBOX_RETENTION", "168h")
 
 	// RabbitMQ
 	v.SetDefault("RABBITMQ_HOST", "localhost")
@@ -205,6 +458,23 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("RABBITMQ_USER", "guest")
 	v.SetDefault("RABBITMQ_PASSWORD", "guest")
 	v.SetDefault("RABBITMQ_VHOST", "/")
+	v.SetDefault("RABBITMQ_TLS_ENABLED", false)
+	v.SetDefault("RABBITMQ_TLS_SKIP_VERIFY", false)
+	v.SetDefault("RABBITMQ_TLS_CA_FILE", "")
+	v.SetDefault("RABBITMQ_TLS_CERT_FILE", "")
+	v.SetDefault("RABBITMQ_TLS_KEY_FILE", "")
+
+	// Worker
+	v.SetDefault("WORKER_METRICS_PORT", 9091)
+	v.SetDefault("WORKER_ADMIN_TOKEN", "")
+
+	// Mail. MAIL_DRIVER has no default here: New picks smtp in production
+	// and log elsewhere.
+	v.SetDefault("MAIL_FROM", "no-reply@example.com")
+	v.SetDefault("MAIL_DEFAULT_LOCALE", "en")
+	v.SetDefault("SMTP_HOST", "localhost")
+	v.SetDefault("SMTP_PORT", 587)
+	v.SetDefault("SMTP_STARTTLS", true)
 
 	// JWT
 	// NOTE: JWT_SECRET has no default on purpose — a shipped default is a
@@ -215,16 +485,32 @@ func setDefaults(v *viper.Viper) {
 	// CORS
 	v.SetDefault("CORS_ORIGINS", "*")
 
+	// Frontend links (the apps/web dev server)
+	v.SetDefault("FRONTEND_BASE_URLS", "http://localhost:5173")
+
 	// OpenTelemetry
 	v.SetDefault("OTEL_ENABLED", true)
 	v.SetDefault("OTEL_ENDPOINT", "localhost:4317")
 	v.SetDefault("OTEL_SERVICE_NAME", "veemon")
 	v.SetDefault("OTEL_EXPORTER_TYPE", "noop")
+	v.SetDefault("OTEL_SAMPLER", "ratio")
 	v.SetDefault("OTEL_SAMPLE_RATIO", 1.0)
+	v.SetDefault("OTEL_HEADERS", "")
+	v.SetDefault("METRICS_EXPORTER", "prometheus")
+	v.SetDefault("OTEL_METRICS_INTERVAL", "60s")
+	v.SetDefault("OBSERVABILITY_SKIP_PATHS", "/health,/ready,/metrics,/docs/*")
+	v.SetDefault("LOG_SLOW_REQUEST_MS", 1000)
+	v.SetDefault("LOG_SLOW_ROUTES", "")
+	v.SetDefault("LOG_SUCCESS_SAMPLE_RATE", 1)
+	v.SetDefault("LOG_HTTP_BODIES", false)
+	v.SetDefault("LOG_HTTP_BODY_MAX_BYTES", 4096)
+	v.SetDefault("LOG_HTTP_SENSITIVE_FIELDS", "")
+	v.SetDefault("HEALTH_CHECK_TIMEOUT", "2s")
+	v.SetDefault("HEALTH_CACHE_TTL", "2s")
 
 	// Logger
 	v.SetDefault("LOG_LEVEL", "info")
-	v.SetDefault("LOG_FORMAT", "json")
+	v.SetDefault("LOG_FORMAT", "") // json in every environment except development
 
 	// Infisical
 	v.SetDefault("INFISICAL_ENABLED", false)
@@ -246,50 +532,13 @@ func MustNew() *Config {
 	return cfg
 }
 
-// Validate checks that security-sensitive configuration is safe to run with.
-// It is called explicitly by processes that mint or verify tokens (the API
-// server) so the process fails fast rather than silently accepting a weak or
-// publicly known signing key.
-func (c *Config) Validate() error {
-	// Fiber prefork re-execs the whole binary per child; each child would then
-	// try to bind the same gRPC port and split the Prometheus registry. This
-	// topology embeds a gRPC server, so prefork is unsupported — scale out with
-	// multiple replicas instead.
-	if c.Prefork {
-		return fmt.Errorf("PREFORK is not supported with the embedded gRPC server; run multiple replicas to scale horizontally")
-	}
-
-	s := c.JWTSecret
-	switch {
-	case s == "":
-		return fmt.Errorf("JWT_SECRET is not set; generate one with `token.GenerateSecretKey` and set JWT_SECRET")
-	case s == placeholderJWTSecret:
-		return fmt.Errorf("JWT_SECRET still uses the insecure placeholder value; set a unique secret before starting")
-	}
-
-	// Accept a 64-char hex string (32 bytes) …
-	if len(s) == 64 {
-		if _, err := hex.DecodeString(s); err == nil {
-			return c.validateCORS()
-		}
-	}
-	// … or a raw secret of at least 32 bytes.
-	if len(s) < 32 {
-		return fmt.Errorf("JWT_SECRET must be a 64-character hex string or at least 32 bytes (got %d bytes)", len(s))
-	}
-	return c.validateCORS()
-}
-
-// validateCORS rejects a wildcard CORS origin in production, where it would
-// allow any site to make credentialed cross-origin requests.
-func (c *Config) validateCORS() error {
-	if c.Environment == "production" && strings.TrimSpace(c.CORSOrigins) == "*" {
-		return fmt.Errorf("CORS_ORIGINS must not be '*' in production; set explicit allowed origins")
-	}
-	return nil
+// AccountDeletionGrace is ACCOUNT_DELETION_GRACE_DAYS as a duration. A
+// non-positive value leaves the usecase default in place.
+func (c *Config) AccountDeletionGrace() time.Duration {
+	return time.Duration(c.AccountDeletionGraceDays) * 24 * time.Hour
 }
 
-func loadRemoteEnvironment(ctx context.Context, v *viper.Viper) error {
+func loadRemoteEnvironment(ctx context.Context, v *viper.Viper) ([]string, error) {
 	infisicalCfg := InfisicalConfig{
 		Enabled:                v.GetBool("INFISICAL_ENABLED"),
 		SiteURL:                v.GetString("INFISICAL_SITE_URL"),
@@ -306,14 +555,13 @@ func loadRemoteEnvironment(ctx context.Context, v *viper.Viper) error {
 		OrganizationSlug:       v.GetString("INFISICAL_ORGANIZATION_SLUG"),
 	}
 	if !infisicalCfg.Enabled {
-		return nil
+		return nil, nil
 	}
 
 	secrets, err := loadInfisicalSecrets(ctx, infisicalCfg)
 	if err != nil {
-		return fmt.Errorf("load infisical secrets: %w", err)
+		return nil, fmt.Errorf("load infisical secrets: %w", err)
 	}
 
-	applyInfisicalSecrets(secrets, infisicalCfg.Override)
-	return nil
+	return applyInfisicalSecrets(secrets, infisicalCfg.Override), nil
 }
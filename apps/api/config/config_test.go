@@ -1,10 +1,46 @@
 package config
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
+
+	"veemon/pkg/database"
+	"veemon/pkg/redis"
+	"veemon/pkg/telemetry"
 )
 
+// baseline fills in the settings a test leaves unset with valid values, so
+// Validate reports only what the test is about.
+func baseline(c Config) *Config {
+	if c.HTTP.Port == 0 {
+		c.HTTP.Port = 3000
+	}
+	if c.HTTP.GRPCPort == 0 {
+		c.HTTP.GRPCPort = 50051
+	}
+	if c.DB.Port == 0 {
+		c.DB.Port = 5432
+	}
+	if c.DB.SSLMode == "" {
+		c.DB.SSLMode = "disable"
+	}
+	if c.Redis.Port == 0 {
+		c.Redis.Port = 6379
+	}
+	if c.RabbitMQ.Port == 0 {
+		c.RabbitMQ.Port = 5672
+	}
+	if c.Observability.Tracing.ExporterType == "" {
+		c.Observability.Tracing.ExporterType = "noop"
+	}
+	return &c
+}
+
 func TestConfig_Validate_JWTSecret(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -22,7 +58,162 @@ func TestConfig_Validate_JWTSecret(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			cfg := &Config{JWTSecret: tt.secret}
+			cfg := baseline(Config{Auth: AuthConfig{JWTSecret: tt.secret}})
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_Validate_RegisterDeletedEmail(t *testing.T) {
+	secret := strings.Repeat("a", 32)
+	for _, policy := range []string{"", "new", "reactivate", "block"} {
+		cfg := baseline(Config{Auth: AuthConfig{JWTSecret: secret}, RegisterDeletedEmail: policy})
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("policy %q: unexpected error %v", policy, err)
+		}
+	}
+
+	cfg := baseline(Config{Auth: AuthConfig{JWTSecret: secret}, RegisterDeletedEmail: "restore"})
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "REGISTER_DELETED_EMAIL") {
+		t.Errorf("unknown policy: got %v, want REGISTER_DELETED_EMAIL error", err)
+	}
+}
+
+func TestConfig_Validate_DBPoolerMode(t *testing.T) {
+	secret := strings.Repeat("a", 32)
+	for _, mode := range []database.PoolerMode{"", "none", "session", "transaction"} {
+		cfg := baseline(Config{Auth: AuthConfig{JWTSecret: secret}, DB: DBConfig{Config: database.Config{PoolerMode: mode}}})
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("mode %q: unexpected error %v", mode, err)
+		}
+	}
+
+	cfg := baseline(Config{Auth: AuthConfig{JWTSecret: secret}, DB: DBConfig{Config: database.Config{PoolerMode: "statement"}}})
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "DB_POOLER_MODE") {
+		t.Errorf("unknown mode: got %v, want DB_POOLER_MODE error", err)
+	}
+}
+
+func TestConfig_Validate_RedisMode(t *testing.T) {
+	secret := strings.Repeat("a", 32)
+	for _, rc := range []redis.Config{
+		{Port: 6379},
+		{Mode: "standalone", Port: 6379},
+		{Mode: "sentinel", SentinelMaster: "mymaster", SentinelAddrs: []string{"s1:26379"}},
+		{Mode: "cluster", ClusterAddrs: []string{"n1:6379"}},
+	} {
+		cfg := baseline(Config{Auth: AuthConfig{JWTSecret: secret}})
+		cfg.Redis.Config = rc
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("%+v: unexpected error %v", rc, err)
+		}
+	}
+
+	for want, rc := range map[string]redis.Config{
+		"REDIS_MODE":            {Mode: "replicated", Port: 6379},
+		"REDIS_SENTINEL_MASTER": {Mode: "sentinel", SentinelAddrs: []string{"s1:26379"}},
+		"REDIS_SENTINEL_ADDRS":  {Mode: "sentinel", SentinelMaster: "mymaster"},
+		"REDIS_CLUSTER_ADDRS":   {Mode: "cluster"},
+		"REDIS_DB":              {Mode: "cluster", ClusterAddrs: []string{"n1:6379"}, DB: 2},
+	} {
+		cfg := baseline(Config{Auth: AuthConfig{JWTSecret: secret}})
+		cfg.Redis.Config = rc
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%+v: got %v, want %s error", rc, err, want)
+		}
+	}
+}
+
+func TestConfig_Validate_RequestLogging(t *testing.T) {
+	secret := strings.Repeat("a", 32)
+	cfg := baseline(Config{Auth: AuthConfig{JWTSecret: secret}, Observability: ObservabilityConfig{
+		SlowRequestThreshold: time.Second,
+		SlowRoutes:           []string{"/api/v1/users/import=10s"},
+		SuccessSampleRate:    10,
+	}})
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if lc := cfg.Observability.RequestLogging(); len(lc.SlowRoutes) != 1 || lc.SlowRoutes[0].Threshold != 10*time.Second {
+		t.Errorf("RequestLogging().SlowRoutes = %+v", lc.SlowRoutes)
+	}
+
+	cfg.Observability.SlowRoutes = []string{"/api/v1/users/import"}
+	cfg.Observability.SuccessSampleRate = -1
+	err := cfg.Validate()
+	for _, key := range []string{"LOG_SLOW_ROUTES", "LOG_SUCCESS_SAMPLE_RATE"} {
+		if err == nil || !strings.Contains(err.Error(), key) {
+			t.Errorf("got %v, want %s error", err, key)
+		}
+	}
+}
+
+func TestConfig_Validate_AuthRoleMode(t *testing.T) {
+	secret := strings.Repeat("a", 32)
+	for _, mode := range []string{"", "enforce", "monitor"} {
+		cfg := baseline(Config{Auth: AuthConfig{JWTSecret: secret, RoleMode: mode}})
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("mode %q: unexpected error %v", mode, err)
+		}
+	}
+
+	cfg := baseline(Config{Auth: AuthConfig{JWTSecret: secret, RoleMode: "off"}})
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "AUTH_ROLE_MODE") {
+		t.Errorf("unknown mode: got %v, want AUTH_ROLE_MODE error", err)
+	}
+}
+
+func TestConfig_Validate_TokenMode(t *testing.T) {
+	key := strings.Repeat("ab", 32) + "@2026-01-02T00:00:00Z"
+	tests := []struct {
+		name    string
+		auth    AuthConfig
+		wantErr string
+	}{
+		{"local needs JWT_SECRET", AuthConfig{TokenMode: "local"}, "JWT_SECRET"},
+		{"public without JWT_SECRET", AuthConfig{TokenMode: "public", TokenSigningKeys: []string{key}}, ""},
+		{"public without keys", AuthConfig{TokenMode: "public"}, "TOKEN_SIGNING_KEYS"},
+		{"public with a bad key", AuthConfig{TokenMode: "public", TokenSigningKeys: []string{"abcd@2026-01-02T00:00:00Z"}}, "TOKEN_SIGNING_KEYS"},
+		{"unknown mode", AuthConfig{TokenMode: "jwt", JWTSecret: strings.Repeat("a", 32)}, "TOKEN_MODE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (baseline(Config{Auth: tt.auth})).Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got %v, want %s error", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_Validate_FrontendBaseURLs(t *testing.T) {
+	secret := strings.Repeat("a", 32)
+	tests := []struct {
+		name    string
+		env     string
+		urls    string
+		wantErr bool
+	}{
+		{"unset", "development", "", false},
+		{"dev http", "development", "http://localhost:5173", false},
+		{"list", "development", "https://app.example.com, https://admin.example.com", false},
+		{"not a URL", "development", "app.example.com", true},
+		{"javascript scheme", "development", "javascript:alert(1)", true},
+		{"production https", "production", "https://app.example.com", false},
+		{"production http", "production", "https://app.example.com,http://app.example.com", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := baseline(Config{Auth: AuthConfig{JWTSecret: secret}, Environment: tt.env, HTTP: HTTPConfig{CORSOrigins: "https://app.example.com"}, FrontendBaseURLs: tt.urls})
 			err := cfg.Validate()
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
@@ -30,3 +221,346 @@ func TestConfig_Validate_JWTSecret(t *testing.T) {
 		})
 	}
 }
+
+// writeEnvFiles creates the given dotenv files in a fresh directory and makes
+// it the working directory for the test.
+func writeEnvFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	t.Chdir(dir)
+}
+
+// unsetEnv clears key for the duration of the test.
+func unsetEnv(t *testing.T, key string) {
+	t.Helper()
+	t.Setenv(key, "")
+	_ = os.Unsetenv(key)
+}
+
+func TestNew_EnvironmentOverridesEnvFiles(t *testing.T) {
+	files := map[string]string{
+		".env": "ENVIRONMENT=staging\n" +
+			"DB_HOST=file-host\n" +
+			"DB_PORT=1111\n" +
+			"PREFORK=true\n" +
+			"REDIS_HOST=file-redis\n" +
+			"LOG_LEVEL=debug\n" +
+			"JWT_SECRET=file-secret\n",
+		".env.staging": "LOG_LEVEL=warn\n" +
+			"REDIS_PORT=7000\n",
+	}
+
+	tests := []struct {
+		name  string
+		env   map[string]string // values set in the real environment
+		unset []string
+		check func(*Config) (got, want any)
+	}{
+		{"string: env beats .env", map[string]string{"DB_HOST": "env-host"}, nil,
+			func(c *Config) (any, any) { return c.DB.Host, "env-host" }},
+		{"int: env beats .env", map[string]string{"DB_PORT": "2222"}, nil,
+			func(c *Config) (any, any) { return c.DB.Port, 2222 }},
+		{"bool: env false beats .env true", map[string]string{"PREFORK": "false"}, nil,
+			func(c *Config) (any, any) { return c.HTTP.Prefork, false }},
+		{"empty env still beats .env", map[string]string{"JWT_SECRET": ""}, nil,
+			func(c *Config) (any, any) { return c.Auth.JWTSecret, "" }},
+		{".env beats code default", nil, []string{"REDIS_HOST"},
+			func(c *Config) (any, any) { return c.Redis.Host, "file-redis" }},
+		{".env.<environment> beats .env", nil, []string{"LOG_LEVEL", "ENVIRONMENT"},
+			func(c *Config) (any, any) { return c.Observability.Log.Level, "warn" }},
+		{"env beats .env.<environment>", map[string]string{"REDIS_PORT": "6390"}, nil,
+			func(c *Config) (any, any) { return c.Redis.Port, 6390 }},
+		{"environment chosen by env selects the layer", map[string]string{"ENVIRONMENT": "production"}, []string{"LOG_LEVEL"},
+			func(c *Config) (any, any) { return c.Observability.Log.Level, "debug" }},
+		{"code default when no layer sets it", nil, []string{"GRPC_PORT"},
+			func(c *Config) (any, any) { return c.HTTP.GRPCPort, 50051 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeEnvFiles(t, files)
+			unsetEnv(t, "ENVIRONMENT")
+			for _, k := range tt.unset {
+				unsetEnv(t, k)
+			}
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			cfg, err := New()
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if got, want := tt.check(cfg); got != want {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestNew_DBAutoMigrateDefaultsOnInDevelopmentOnly(t *testing.T) {
+	tests := []struct {
+		environment, setting string
+		want                 bool
+	}{
+		{"development", "", true},
+		{"staging", "", false},
+		{"production", "", false},
+		{"production", "true", true},
+		{"development", "false", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.environment+"/"+tt.setting, func(t *testing.T) {
+			writeEnvFiles(t, nil)
+			t.Setenv("ENVIRONMENT", tt.environment)
+			unsetEnv(t, "DB_AUTO_MIGRATE")
+			if tt.setting != "" {
+				t.Setenv("DB_AUTO_MIGRATE", tt.setting)
+			}
+
+			cfg, err := New()
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if cfg.DB.AutoMigrate != tt.want {
+				t.Errorf("AutoMigrate = %v, want %v", cfg.DB.AutoMigrate, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew_SensitiveOverridesReportSourcesWithoutValues(t *testing.T) {
+	writeEnvFiles(t, map[string]string{
+		".env":            "ENVIRONMENT=production\nDB_PASSWORD=file-pw\nJWT_SECRET=file-secret\n",
+		".env.production": "DB_PASSWORD=prod-file-pw\n",
+	})
+	unsetEnv(t, "ENVIRONMENT")
+	unsetEnv(t, "DB_PASSWORD")
+	t.Setenv("JWT_SECRET", "env-secret")
+
+	cfg, err := New()
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	got := cfg.SensitiveOverrides()
+	want := map[string][]string{
+		"JWT_SECRET":  {"env", ".env"},
+		"DB_PASSWORD": {".env.production", ".env"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("SensitiveOverrides() = %v, want %v", got, want)
+	}
+}
+
+func TestConfig_Validate_Journal(t *testing.T) {
+	secret := strings.Repeat("a", 32)
+	for _, sink := range []string{"file", "redis"} {
+		cfg := baseline(Config{Auth: AuthConfig{JWTSecret: secret}, Environment: "staging", JournalEnabled: true, JournalSink: sink})
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("sink %q: unexpected error %v", sink, err)
+		}
+	}
+
+	cfg := baseline(Config{Auth: AuthConfig{JWTSecret: secret}, Environment: "staging", JournalEnabled: true, JournalSink: "kafka"})
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "JOURNAL_SINK") {
+		t.Errorf("unknown sink: got %v, want JOURNAL_SINK error", err)
+	}
+
+	cfg = baseline(Config{Auth: AuthConfig{JWTSecret: secret}, Environment: "production", HTTP: HTTPConfig{CORSOrigins: "https://app.example.com"},
+		FrontendBaseURLs: "https://app.example.com", JournalEnabled: true, JournalSink: "file"})
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "JOURNAL_ENABLED") {
+		t.Errorf("production: got %v, want JOURNAL_ENABLED error", err)
+	}
+}
+
+func TestConfig_Validate_HTTPBodyLogging(t *testing.T) {
+	secret := strings.Repeat("a", 32)
+	cfg := baseline(Config{Auth: AuthConfig{JWTSecret: secret}, Environment: "staging",
+		Observability: ObservabilityConfig{LogHTTPBodies: true, HTTPBodyMaxBytes: 4096}})
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("staging: unexpected error %v", err)
+	}
+
+	cfg = baseline(Config{Auth: AuthConfig{JWTSecret: secret}, Environment: "production", HTTP: HTTPConfig{CORSOrigins: "https://app.example.com"},
+		FrontendBaseURLs: "https://app.example.com", Observability: ObservabilityConfig{LogHTTPBodies: true, HTTPBodyMaxBytes: 4096}})
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "LOG_HTTP_BODIES") {
+		t.Errorf("production: got %v, want LOG_HTTP_BODIES error", err)
+	}
+}
+
+func TestConfig_Validate_MetricsExporter(t *testing.T) {
+	secret := strings.Repeat("a", 32)
+	cfg := baseline(Config{Auth: AuthConfig{JWTSecret: secret}})
+	cfg.Observability.Tracing = telemetry.Config{Enabled: true, ExporterType: "otlphttp", MetricsExporter: telemetry.MetricsOTLP}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("otlphttp: unexpected error %v", err)
+	}
+
+	cfg.Observability.Tracing.ExporterType = "stdout"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "METRICS_EXPORTER") {
+		t.Errorf("stdout: got %v, want METRICS_EXPORTER error", err)
+	}
+}
+
+func TestConfig_Validate_ShutdownDrainDelay(t *testing.T) {
+	secret := strings.Repeat("a", 32)
+	for _, tc := range []struct {
+		delay time.Duration
+		ok    bool
+	}{{0, true}, {5 * time.Second, true}, {-time.Second, false}, {2 * time.Minute, false}} {
+		cfg := baseline(Config{Auth: AuthConfig{JWTSecret: secret}})
+		cfg.HTTP.ShutdownDrainDelay = tc.delay
+		err := cfg.Validate()
+		if tc.ok && err != nil {
+			t.Errorf("%s: unexpected error %v", tc.delay, err)
+		}
+		if !tc.ok && (err == nil || !strings.Contains(err.Error(), "SHUTDOWN_DRAIN_DELAY")) {
+			t.Errorf("%s: got %v, want SHUTDOWN_DRAIN_DELAY error", tc.delay, err)
+		}
+	}
+}
+
+func TestConfig_Validate_DBPool(t *testing.T) {
+	cfg := baseline(Config{Auth: AuthConfig{JWTSecret: strings.Repeat("a", 32)}})
+	cfg.DB.MaxOpenConns = -1
+	cfg.DB.ConnMaxIdleTime = -time.Minute
+	err := cfg.Validate()
+	for _, key := range []string{"DB_MAX_OPEN_CONNS", "DB_CONN_MAX_IDLE_TIME"} {
+		if err == nil || !strings.Contains(err.Error(), key) {
+			t.Errorf("got %v, want %s error", err, key)
+		}
+	}
+
+	cfg.DB.MaxOpenConns, cfg.DB.ConnMaxIdleTime = 0, 0 // defaults
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("zero values: unexpected error %v", err)
+	}
+}
+
+func TestConfig_Validate_UserImportExport(t *testing.T) {
+	cfg := baseline(Config{Auth: AuthConfig{JWTSecret: strings.Repeat("a", 32)}})
+	cfg.UserImportMaxRows = -1
+	cfg.UserImportBatchSize = maxUserImportBatchSize + 1
+	cfg.UserExportTimeout = -time.Second
+	err := cfg.Validate()
+	for _, key := range []string{"USER_IMPORT_MAX_ROWS", "USER_IMPORT_BATCH_SIZE", "USER_EXPORT_TIMEOUT"} {
+		if err == nil || !strings.Contains(err.Error(), key) {
+			t.Errorf("got %v, want %s error", err, key)
+		}
+	}
+
+	cfg.UserImportMaxRows, cfg.UserImportBatchSize, cfg.UserExportTimeout = 5000, maxUserImportBatchSize, time.Hour
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestConfig_Validate_ReportsEveryViolation(t *testing.T) {
+	cfg := baseline(Config{
+		Environment: "production",
+		HTTP:        HTTPConfig{Port: 70000, CORSOrigins: "*"},
+		DB:          DBConfig{Config: database.Config{SSLMode: "sometimes"}},
+		Auth:        AuthConfig{JWTSecret: placeholderJWTSecret},
+
+		FrontendBaseURLs: "http://app.example.com",
+	})
+	cfg.Observability.Tracing.ExporterType = "jaeger"
+	cfg.Observability.Log.Level = "verbose"
+
+	err := cfg.Validate()
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Validate() = %v, want a *ValidationError", err)
+	}
+	want := []string{"HTTP_PORT", "DB_SSL_MODE", "OTEL_EXPORTER_TYPE", "LOG_LEVEL", "CORS_ORIGINS", "JWT_SECRET", "FRONTEND_BASE_URLS"}
+	if got := verr.Keys(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys() = %v, want %v", got, want)
+	}
+	if !strings.HasPrefix(err.Error(), "invalid configuration (7 problems):\n  - HTTP_PORT must be a port between 1 and 65535 (got 70000)") {
+		t.Errorf("Error() = %q", err)
+	}
+
+	var serr *SettingError
+	if !errors.As(err, &serr) || serr.Key != "HTTP_PORT" {
+		t.Errorf("errors.As(*SettingError) = %+v, want the HTTP_PORT violation", serr)
+	}
+}
+
+func TestNew_MailDriverDefaultsToSMTPInProduction(t *testing.T) {
+	tests := []struct {
+		environment, setting, want string
+		wantErr                    bool
+	}{
+		{"development", "", "log", false},
+		{"staging", "", "log", false},
+		{"production", "", "smtp", false},
+		{"production", "log", "", true},
+		{"development", "smtp", "smtp", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.environment+"/"+tt.setting, func(t *testing.T) {
+			writeEnvFiles(t, nil)
+			t.Setenv("ENVIRONMENT", tt.environment)
+			unsetEnv(t, "MAIL_DRIVER")
+			if tt.setting != "" {
+				t.Setenv("MAIL_DRIVER", tt.setting)
+			}
+
+			cfg, err := New()
+			if tt.wantErr {
+				var verr *ValidationError
+				if !errors.As(err, &verr) || !reflect.DeepEqual(verr.Keys(), []string{"MAIL_DRIVER"}) {
+					t.Fatalf("New() error = %v, want a MAIL_DRIVER violation", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if cfg.MailDriver != tt.want {
+				t.Errorf("MailDriver = %q, want %q", cfg.MailDriver, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew_RejectsInvalidValues(t *testing.T) {
+	writeEnvFiles(t, nil)
+	t.Setenv("GRPC_PORT", "0")
+	t.Setenv("OTEL_EXPORTER_TYPE", "zipkin")
+	// Token keys and serving policy are Validate's; New does not need them.
+	t.Setenv("JWT_SECRET", "")
+	t.Setenv("PREFORK", "true")
+
+	_, err := New()
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("New() error = %v, want a *ValidationError", err)
+	}
+	if got, want := verr.Keys(), []string{"GRPC_PORT", "OTEL_EXPORTER_TYPE"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys() = %v, want %v", got, want)
+	}
+}
+
+func TestConfig_Validate_JWTSecretPrevious(t *testing.T) {
+	secret := strings.Repeat("a", 32)
+	cfg := baseline(Config{Auth: AuthConfig{JWTSecret: secret, JWTSecretPrevious: []string{strings.Repeat("ab", 32), strings.Repeat("b", 32)}}})
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("valid previous secrets: unexpected error %v", err)
+	}
+
+	cfg = baseline(Config{Auth: AuthConfig{JWTSecret: secret, JWTSecretPrevious: []string{"short", placeholderJWTSecret}}})
+	var verr *ValidationError
+	if !errors.As(cfg.Validate(), &verr) {
+		t.Fatal("weak previous secrets: want a *ValidationError")
+	}
+	if got, want := verr.Keys(), []string{"JWT_SECRET_PREVIOUS", "JWT_SECRET_PREVIOUS"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys() = %v, want %v", got, want)
+	}
+}
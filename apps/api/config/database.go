@@ -1,47 +1,157 @@
 package config
 
 import (
-	"time"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
 
+	"veemon/database/migrate"
+	"veemon/migrations"
 	"veemon/pkg/database"
+	"veemon/pkg/lifecycle"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
 func NewDatabase(cfg *Config, log *zap.Logger) (*gorm.DB, error) {
-	db, err := database.New(database.Config{
-		Host:     cfg.DBHost,
-		Port:     cfg.DBPort,
-		User:     cfg.DBUser,
-		Password: cfg.DBPassword,
-		Name:     cfg.DBName,
-		SSLMode:  cfg.DBSSLMode,
-		Timezone: cfg.DBTimezone,
-
-		// Performance optimizations
-		PrepareStmt:            cfg.DBPrepareStmt,
-		SkipDefaultTransaction: cfg.DBSkipDefaultTransaction,
-
-		// Connection pool
-		MaxIdleConns:    cfg.DBMaxIdleConns,
-		MaxOpenConns:    cfg.DBMaxOpenConns,
-		ConnMaxLifetime: time.Duration(cfg.DBConnMaxLifetime) * time.Minute,
-	}, log)
+	db, err := database.New(cfg.DB.Config, log)
 	if err != nil {
 		return nil, err
 	}
 
-	// golang-migrate SQL migrations are the source of truth. AutoMigrate is
-	// opt-in (DB_AUTO_MIGRATE) for local development convenience only, so
-	// production schema changes always go through reviewed migrations.
-	if cfg.DBAutoMigrate {
+	// golang-migrate SQL migrations are the source of truth. DB_AUTO_MIGRATE
+	// is on by default in development only, so production schema changes go
+	// through reviewed migrations and a stale schema stops startup. The SQL migrations hard-code their table names, so a custom naming
+	// strategy only matches the schema when GORM AutoMigrate created it.
+	gormSchema := cfg.DB.AutoMigrate && cfg.DB.AutoMigrateSource != AutoMigrateSQL
+	if (cfg.DB.TablePrefix != "" || cfg.DB.SingularTable) && !gormSchema {
+		log.Warn("DB_TABLE_PREFIX/DB_SINGULAR_TABLE are set but the SQL migrations ignore them; "+
+			"queries will target tables the migrations did not create unless they exist already",
+			zap.String("table_prefix", cfg.DB.TablePrefix),
+			zap.Bool("singular_table", cfg.DB.SingularTable),
+		)
+	}
+
+	if cfg.DB.AutoMigrate {
+		err = migrateOnStart(db, cfg, log)
+	} else {
+		err = checkSchema(db, cfg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	lifecycle.Register("database", lifecycle.PriorityClients, func(context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		if replicas := database.ReplicasOf(db); replicas != nil {
+			return errors.Join(replicas.Close(), sqlDB.Close())
+		}
+		return sqlDB.Close()
+	})
+
+	return db, nil
+}
+
+// ErrSchemaBehind is returned by NewDatabase when DB_AUTO_MIGRATE is off and
+// the database lacks migrations or tables this build needs.
+var ErrSchemaBehind = errors.New("database schema is behind this build")
+
+// migrateLockKey names the advisory lock that lets one replica at a time
+// migrate on startup.
+const migrateLockKey = "veemon:migrate"
+
+// migrateOnStart brings the schema up to date under migrateLockKey, logging
+// each change before it is applied. Replicas that start together wait for
+// the first one and then find nothing left to do.
+func migrateOnStart(db *gorm.DB, cfg *Config, log *zap.Logger) error {
+	// A session-level lock needs one connection for the whole migration.
+	return db.Connection(func(conn *gorm.DB) error {
+		if err := conn.Exec("SELECT pg_advisory_lock(hashtext(?))", migrateLockKey).Error; err != nil {
+			return fmt.Errorf("acquire migration lock: %w", err)
+		}
+		defer conn.Exec("SELECT pg_advisory_unlock(hashtext(?))", migrateLockKey)
+
+		if cfg.DB.AutoMigrateSource == AutoMigrateSQL {
+			m, err := embeddedMigrator(cfg)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = m.Close() }()
+			pending, err := m.Plan()
+			if err != nil {
+				return err
+			}
+			for _, p := range pending {
+				log.Info("applying migration", zap.Uint("version", p.Version), zap.String("file", p.File))
+			}
+			if err := m.Up(); err != nil {
+				return err
+			}
+			log.Info("schema migrated", zap.String("source", AutoMigrateSQL), zap.Int("migrations", len(pending)))
+			return nil
+		}
+
 		log.Warn("DB_AUTO_MIGRATE is enabled; GORM AutoMigrate is running. " +
 			"Use golang-migrate (`make migrate`) as the source of truth in production.")
-		if err := database.AutoMigrate(db); err != nil {
-			return nil, err
+		plan, err := database.AutoMigratePlan(conn)
+		if err != nil {
+			return fmt.Errorf("plan auto-migrate: %w", err)
+		}
+		for _, change := range plan {
+			log.Info("auto-migrate", zap.String("change", change))
 		}
+		if err := database.AutoMigrate(conn); err != nil {
+			return err
+		}
+		log.Info("schema migrated", zap.String("source", AutoMigrateGorm), zap.Int("changes", len(plan)))
+		return nil
+	})
+}
+
+// checkSchema returns ErrSchemaBehind, naming what is missing, when the
+// database is not at the schema this build expects.
+func checkSchema(db *gorm.DB, cfg *Config) error {
+	// Prefixed schemas come from GORM AutoMigrate, not the SQL migrations.
+	if cfg.DB.TablePrefix != "" || cfg.DB.SingularTable {
+		plan, err := database.AutoMigratePlan(db)
+		if err != nil {
+			return fmt.Errorf("check schema: %w", err)
+		}
+		if len(plan) > 0 {
+			return fmt.Errorf("%w: %s; set DB_AUTO_MIGRATE=true to apply", ErrSchemaBehind, strings.Join(plan, ", "))
+		}
+		return nil
 	}
 
-	return db, nil
+	m, err := embeddedMigrator(cfg)
+	if err != nil {
+		return fmt.Errorf("check schema: %w", err)
+	}
+	defer func() { _ = m.Close() }()
+	pending, err := m.Plan()
+	if err != nil {
+		return fmt.Errorf("check schema: %w", err)
+	}
+	if len(pending) > 0 {
+		files := make([]string, len(pending))
+		for i, p := range pending {
+			files[i] = p.File
+		}
+		return fmt.Errorf("%w: %d migration(s) not applied (%s); run `migrate up`, or set DB_AUTO_MIGRATE=true and DB_AUTO_MIGRATE_SOURCE=sql",
+			ErrSchemaBehind, len(pending), strings.Join(files, ", "))
+	}
+	return nil
+}
+
+// embeddedMigrator reads the SQL migrations embedded in the binary over a
+// connection of its own.
+func embeddedMigrator(cfg *Config) (*migrate.Migrator, error) {
+	return migrate.NewFromFS(migrate.Config{
+		DatabaseURL: migrate.DatabaseURL(cfg.DB.User, cfg.DB.Password, cfg.DB.Host, cfg.DB.Port, cfg.DB.Name, cfg.DB.SSLMode),
+	}, migrations.FS)
 }
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"veemon/pkg/middleware"
+	"veemon/pkg/runtimeconfig"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -11,17 +12,19 @@ import (
 	"go.uber.org/zap"
 )
 
-func NewFiber(cfg *Config, log *zap.Logger) *fiber.App {
+func NewFiber(cfg *Config, runtimeCfg *runtimeconfig.Store, log *zap.Logger) *fiber.App {
 	app := fiber.New(fiber.Config{
 		AppName:               cfg.ServiceName,
 		DisableStartupMessage: true,
 		ErrorHandler:          NewErrorHandler(log),
-		Prefork:               cfg.Prefork,
+		Prefork:               cfg.HTTP.Prefork,
 		// Bound slow/idle connections so a stuck client cannot hold a worker
 		// indefinitely. Values are conservative defaults; tune per workload.
-		ReadTimeout:  time.Duration(cfg.HTTPReadTimeout) * time.Second,
-		WriteTimeout: time.Duration(cfg.HTTPWriteTimeout) * time.Second,
-		IdleTimeout:  time.Duration(cfg.HTTPIdleTimeout) * time.Second,
+		ReadTimeout:  cfg.HTTP.ReadTimeout,
+		WriteTimeout: cfg.HTTP.WriteTimeout,
+		IdleTimeout:  cfg.HTTP.IdleTimeout,
+		// Oversized bodies are refused with 413 before they are buffered.
+		BodyLimit: cfg.HTTP.BodyLimitKB * 1024,
 	})
 
 	// Security response headers (X-Frame-Options, X-Content-Type-Options, etc.)
@@ -29,12 +32,12 @@ func NewFiber(cfg *Config, log *zap.Logger) *fiber.App {
 
 	// CORS
 	corsConfig := cors.Config{
-		AllowOrigins: cfg.CORSOrigins,
+		AllowOrigins: cfg.HTTP.CORSOrigins,
 		AllowMethods: "GET,POST,PUT,DELETE,PATCH,OPTIONS",
-		AllowHeaders: "Origin,Content-Type,Accept,Authorization,X-Request-ID,X-Trace-ID",
+		AllowHeaders: "Origin,Content-Type,Accept,Authorization,X-Request-ID,X-Trace-ID,Idempotency-Key",
 	}
 	// AllowCredentials cannot be used with wildcard origins
-	if cfg.CORSOrigins != "*" {
+	if cfg.HTTP.CORSOrigins != "*" {
 		corsConfig.AllowCredentials = true
 	}
 	app.Use(cors.New(corsConfig))
@@ -43,13 +46,24 @@ func NewFiber(cfg *Config, log *zap.Logger) *fiber.App {
 	// Recovery so that a panic (recovered below into a 500) is still logged and
 	// traced; Recovery wraps the handler so it can turn panics into responses.
 	app.Use(middleware.RequestIDMiddleware())
-	app.Use(middleware.TracingMiddleware(cfg.ServiceName))
-	app.Use(middleware.LoggerMiddleware(log))
-	// Global per-IP rate limit as a coarse abuse guard. Stricter, endpoint-
-	// specific limits are applied on auth routes during route registration.
-	app.Use(middleware.RateLimitMiddleware(middleware.DefaultRateLimitConfig()))
+	if cfg.Observability.LogHTTPBodies {
+		log.Warn("HTTP body logging enabled; bodies are logged with sensitive fields masked")
+		app.Use(middleware.BodyLogMiddleware(log, cfg.Observability.BodyLogging()))
+	}
+	app.Use(middleware.TracingMiddleware(cfg.ServiceName, cfg.Observability.SkipPaths))
+	app.Use(middleware.LoggerMiddleware(log, cfg.Observability.RequestLogging()))
+	// Inside the logger so an abandoned request is logged as a 499.
+	app.Use(middleware.ClientDisconnectMiddleware(middleware.DefaultDisconnectPollInterval))
+	// Global per-IP rate limit as a coarse abuse guard, tunable at runtime.
+	// Stricter, endpoint-specific limits are applied on auth routes during
+	// route registration.
+	app.Use(middleware.TunableRateLimitMiddleware(middleware.DefaultRateLimitConfig(), func() (int, time.Duration) {
+		return runtimeCfg.Int(RateLimitMaxKey), runtimeCfg.Duration(RateLimitWindowKey)
+	}))
 	app.Use(middleware.RecoveryMiddleware(log))
-	app.Use(middleware.TimeoutMiddleware(time.Duration(cfg.RequestTimeout) * time.Second))
+	app.Use(middleware.TimeoutMiddleware(cfg.HTTP.RequestTimeout))
+	// Innermost, so it sees the handler's response before anything logs it.
+	app.Use(middleware.ConditionalGetMiddleware())
 
 	return app
 }
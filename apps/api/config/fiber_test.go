@@ -0,0 +1,47 @@
+package config
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestNewFiber_RefusesOversizedBodies(t *testing.T) {
+	cfg := &Config{ServiceName: "test_service", HTTP: HTTPConfig{CORSOrigins: "*", BodyLimitKB: 1}}
+	app := NewFiber(cfg, NewRuntimeConfig(nil, zap.NewNop()), zap.NewNop())
+	app.Post("/echo", func(c *fiber.Ctx) error {
+		return c.SendStatus(http.StatusNoContent)
+	})
+
+	// app.Test bypasses the server's error handler, so serve on a socket.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = app.Listener(ln) }()
+	t.Cleanup(func() { _ = app.Shutdown() })
+
+	post := func(size int) *http.Response {
+		resp, err := http.Post("http://"+ln.Addr().String()+"/echo", "application/json", strings.NewReader(strings.Repeat("x", size)))
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		return resp
+	}
+
+	assert.Equal(t, http.StatusNoContent, post(1024).StatusCode)
+
+	resp := post(1025)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	var body struct {
+		Error struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, body.Error.Code)
+}
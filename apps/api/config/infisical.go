@@ -90,7 +90,10 @@ func (cfg InfisicalConfig) validate() error {
 	return nil
 }
 
-func applyInfisicalSecrets(secrets map[string]string, override bool) {
+// applyInfisicalSecrets exports secrets as environment variables and returns
+// the keys it actually set.
+func applyInfisicalSecrets(secrets map[string]string, override bool) []string {
+	var applied []string
 	for key, value := range secrets {
 		if !override {
 			currentValue, ok := os.LookupEnv(key)
@@ -99,5 +102,7 @@ func applyInfisicalSecrets(secrets map[string]string, override bool) {
 			}
 		}
 		_ = os.Setenv(key, value)
+		applied = append(applied, key)
 	}
+	return applied
 }
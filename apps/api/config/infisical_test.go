@@ -33,8 +33,8 @@ func TestNewLoadsInfisicalSecretsBeforeUnmarshal(t *testing.T) {
 	cfg, err := New()
 
 	require.NoError(t, err)
-	assert.Equal(t, "from-infisical", cfg.DBPassword)
-	assert.Equal(t, "infisical_db", cfg.DBName)
+	assert.Equal(t, "from-infisical", cfg.DB.Password)
+	assert.Equal(t, "infisical_db", cfg.DB.Name)
 }
 
 func TestNewKeepsExistingEnvOverInfisicalByDefault(t *testing.T) {
@@ -53,7 +53,7 @@ func TestNewKeepsExistingEnvOverInfisicalByDefault(t *testing.T) {
 	cfg, err := New()
 
 	require.NoError(t, err)
-	assert.Equal(t, "from-process-env", cfg.DBPassword)
+	assert.Equal(t, "from-process-env", cfg.DB.Password)
 }
 
 func TestNewCanOverrideExistingEnvWithInfisical(t *testing.T) {
@@ -73,7 +73,7 @@ func TestNewCanOverrideExistingEnvWithInfisical(t *testing.T) {
 	cfg, err := New()
 
 	require.NoError(t, err)
-	assert.Equal(t, "from-infisical", cfg.DBPassword)
+	assert.Equal(t, "from-infisical", cfg.DB.Password)
 }
 
 func TestNewSkipsInfisicalWhenDisabled(t *testing.T) {
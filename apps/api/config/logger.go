@@ -2,13 +2,28 @@ package config
 
 import (
 	"veemon/pkg/logger"
+
+	"go.uber.org/zap"
 )
 
 func NewLogger(cfg *Config) (*logger.Logger, error) {
-	return logger.New(logger.Config{
-		Level:       cfg.LogLevel,
-		Format:      cfg.LogFormat,
-		Environment: cfg.Environment,
-		ServiceName: cfg.ServiceName,
-	})
+	return logger.New(cfg.Observability.Log)
+}
+
+// LogSensitiveOverrides records which layer supplied each security-sensitive
+// key that was defined in more than one place (e.g. a container env var
+// shadowing a baked-in .env). Only key names and sources are logged.
+func LogSensitiveOverrides(cfg *Config, log *zap.Logger) {
+	overrides := cfg.SensitiveOverrides()
+	for _, key := range sensitiveKeys {
+		layers, ok := overrides[key]
+		if !ok {
+			continue
+		}
+		log.Info("Sensitive configuration key overridden",
+			zap.String("key", key),
+			zap.String("source", layers[0]),
+			zap.Strings("shadowed", layers[1:]),
+		)
+	}
 }
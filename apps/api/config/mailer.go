@@ -0,0 +1,32 @@
+package config
+
+import (
+	"fmt"
+
+	"veemon/pkg/mailer"
+	"veemon/pkg/resilience"
+
+	"go.uber.org/zap"
+)
+
+// NewMailer builds the Sender that actually delivers mail (used by the
+// worker). The API publishes through mailer.QueueSender instead.
+func NewMailer(cfg *Config, log *zap.Logger) (mailer.Sender, error) {
+	registry := mailer.NewRegistry(nil, cfg.MailDefaultLocale)
+
+	switch cfg.MailDriver {
+	case "", "log":
+		return mailer.NewLogSender(registry, cfg.MailFrom, cfg.MailOutboxDir, log), nil
+	case "smtp":
+		return mailer.NewSMTPSender(mailer.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.MailFrom,
+			StartTLS: cfg.SMTPStartTLS,
+		}, registry, resilience.DefaultConfig(), log), nil
+	default:
+		return nil, fmt.Errorf("unknown MAIL_DRIVER %q (want \"log\" or \"smtp\")", cfg.MailDriver)
+	}
+}
@@ -26,7 +26,7 @@ func TestRegisterObservabilityRoutes(t *testing.T) {
 
 func TestMetricsAuthToken(t *testing.T) {
 	app := fiber.New()
-	registerObservabilityRoutes(app, &Config{ServiceName: "test_service", MetricsAuthToken: "secret"})
+	registerObservabilityRoutes(app, &Config{ServiceName: "test_service", Observability: ObservabilityConfig{MetricsAuthToken: "secret"}})
 
 	// Without the token, /metrics is rejected.
 	unauth, err := app.Test(httptest.NewRequest(http.MethodGet, "/metrics", nil))
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"veemon/docs"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// undocumentedRoutes are registered on purpose without an OpenAPI entry:
+// operator-facing endpoints and the docs UI itself.
+var undocumentedRoutes = map[string]bool{
+	"GET /metrics":           true,
+	"GET /docs/openapi.json": true,
+	"GET /docs/*":            true,
+}
+
+var fiberParam = regexp.MustCompile(`:([A-Za-z0-9_]+)\??`)
+
+// bootTestApp wires the real application routes onto a fresh Fiber app. The
+// database is a dry-run handle and Redis/RabbitMQ are disabled, so nothing
+// dials out.
+func bootTestApp(t *testing.T) *fiber.App {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=dryrun"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatalf("open dry-run db: %v", err)
+	}
+
+	app := fiber.New()
+	_, err = Bootstrap(&BootstrapConfig{
+		DB:  db,
+		App: app,
+		Log: zap.NewNop(),
+		Cfg: &Config{
+			ServiceName: "drift_test",
+			Environment: "test",
+			Auth:        AuthConfig{JWTSecret: strings.Repeat("k", 32), JWTExpiration: 1},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Bootstrap: %v", err)
+	}
+	return app
+}
+
+// registeredRoutes returns "METHOD /path" for every route the app serves,
+// with Fiber params rewritten to OpenAPI templates. Fiber's implicit HEAD
+// twins of GET routes are skipped.
+func registeredRoutes(app *fiber.App) map[string]bool {
+	out := map[string]bool{}
+	for _, r := range app.GetRoutes(true) {
+		if r.Method == http.MethodHead {
+			continue
+		}
+		out[r.Method+" "+fiberParam.ReplaceAllString(r.Path, "{$1}")] = true
+	}
+	return out
+}
+
+func documentedRoutes(spec *docs.Document) map[string]bool {
+	out := map[string]bool{}
+	for path, item := range spec.Paths {
+		for method := range item.Operations() {
+			out[method+" "+path] = true
+		}
+	}
+	return out
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestOpenAPISpec_MatchesRegisteredRoutes(t *testing.T) {
+	routes := registeredRoutes(bootTestApp(t))
+	documented := documentedRoutes(docs.GetOpenAPISpec())
+
+	for _, r := range sortedKeys(routes) {
+		if !documented[r] && !undocumentedRoutes[r] {
+			t.Errorf("route %s is registered but not documented by any docs module", r)
+		}
+	}
+	for _, r := range sortedKeys(documented) {
+		if !routes[r] {
+			t.Errorf("route %s is documented but not registered", r)
+		}
+	}
+	for _, r := range sortedKeys(undocumentedRoutes) {
+		if !routes[r] {
+			t.Errorf("allowlisted route %s is no longer registered; drop it from undocumentedRoutes", r)
+		}
+	}
+}
+
+func TestOpenAPISpec_IsValidOpenAPI3(t *testing.T) {
+	raw, err := json.Marshal(docs.GetOpenAPISpec())
+	if err != nil {
+		t.Fatalf("marshal spec: %v", err)
+	}
+
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(raw)
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		t.Fatalf("spec is not valid OpenAPI 3: %v", err)
+	}
+}
@@ -1,17 +1,25 @@
 package config
 
 import (
+	"context"
+
+	"veemon/pkg/lifecycle"
 	"veemon/pkg/rabbitmq"
 
 	"go.uber.org/zap"
 )
 
+// EventsExchange is the topic exchange the API publishes events to and the
+// worker consumes from.
+const EventsExchange = "default_exchange"
+
 func NewRabbitMQ(cfg *Config, log *zap.Logger) (*rabbitmq.Client, error) {
-	return rabbitmq.New(rabbitmq.Config{
-		Host:     cfg.RabbitMQHost,
-		Port:     cfg.RabbitMQPort,
-		User:     cfg.RabbitMQUser,
-		Password: cfg.RabbitMQPassword,
-		VHost:    cfg.RabbitMQVHost,
-	}, log)
+	client, err := rabbitmq.New(cfg.RabbitMQ, log)
+	if err != nil {
+		return nil, err
+	}
+	lifecycle.Register("rabbitmq", lifecycle.PriorityClients, func(context.Context) error {
+		return client.Close()
+	})
+	return client, nil
 }
@@ -1,20 +1,23 @@
 package config
 
 import (
+	"context"
+
+	"veemon/pkg/lifecycle"
 	"veemon/pkg/redis"
+
+	"go.uber.org/zap"
 )
 
-func NewRedis(cfg *Config) (*redis.Client, error) {
-	return redis.New(redis.Config{
-		Host:         cfg.RedisHost,
-		Port:         cfg.RedisPort,
-		Password:     cfg.RedisPassword,
-		DB:           cfg.RedisDB,
-		MaxIdle:      cfg.RedisMaxIdle,
-		MaxActive:    cfg.RedisMaxActive,
-		IdleTimeout:  cfg.RedisIdleTimeout,
-		DialTimeout:  cfg.RedisDialTimeout,
-		ReadTimeout:  cfg.RedisReadTimeout,
-		WriteTimeout: cfg.RedisWriteTimeout,
+func NewRedis(cfg *Config, log *zap.Logger) (*redis.Client, error) {
+	rc := cfg.Redis.Config
+	rc.Logger = log
+	client, err := redis.New(rc)
+	if err != nil {
+		return nil, err
+	}
+	lifecycle.Register("redis", lifecycle.PriorityClients, func(context.Context) error {
+		return client.Close()
 	})
+	return client, nil
 }
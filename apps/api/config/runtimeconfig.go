@@ -0,0 +1,78 @@
+package config
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"veemon/pkg/lifecycle"
+	"veemon/pkg/middleware"
+	"veemon/pkg/redis"
+	"veemon/pkg/runtimeconfig"
+
+	"go.uber.org/zap"
+)
+
+// Runtime config keys. Add a knob by declaring it in RuntimeConfigSchema and
+// reading it with the typed getter where it applies.
+const (
+	RateLimitMaxKey    = "ratelimit.global.max"
+	RateLimitWindowKey = "ratelimit.global.window"
+)
+
+// RuntimeConfigSchema lists every key GET/PUT /api/v1/admin/runtime-config
+// accepts. Defaults apply until overridden, and whenever Redis is down.
+func RuntimeConfigSchema() []runtimeconfig.Key {
+	defaults := middleware.DefaultRateLimitConfig()
+	return []runtimeconfig.Key{
+		{
+			Name:        RateLimitMaxKey,
+			Type:        runtimeconfig.Int,
+			Default:     strconv.Itoa(defaults.Max),
+			Description: "Requests per client IP per window across the whole API, before the per-route limits",
+			Validate:    runtimeconfig.IntRange(1, 1_000_000),
+		},
+		{
+			Name:        RateLimitWindowKey,
+			Type:        runtimeconfig.Duration,
+			Default:     defaults.Duration.String(),
+			Description: "Window of the global rate limit",
+			Validate:    runtimeconfig.DurationRange(time.Second, time.Hour),
+		},
+	}
+}
+
+// NewRuntimeConfig returns the runtime config store and, with Redis, keeps it
+// in sync with the other replicas until shutdown.
+func NewRuntimeConfig(client *redis.Client, log *zap.Logger) *runtimeconfig.Store {
+	store := runtimeconfig.New(client, RuntimeConfigSchema(), log)
+	if client == nil {
+		log.Warn("Redis is unavailable; runtime config serves its defaults and cannot be changed")
+		return store
+	}
+
+	// Load before serving so the first requests see current values.
+	loadCtx, cancelLoad := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := store.Load(loadCtx); err != nil {
+		log.Warn("runtime config load failed; serving defaults until it succeeds", zap.Error(err))
+	}
+	cancelLoad()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		store.Run(ctx)
+	}()
+	// Registered after Redis, so it runs before the pool is closed.
+	lifecycle.Register("runtime-config", lifecycle.PriorityClients, func(ctx context.Context) error {
+		cancel()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	return store
+}
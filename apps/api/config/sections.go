@@ -0,0 +1,430 @@
+package config
+
+import (
+	"encoding/hex"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"veemon/pkg/database"
+	"veemon/pkg/logger"
+	"veemon/pkg/middleware"
+	"veemon/pkg/redis"
+	"veemon/pkg/telemetry"
+	"veemon/pkg/token"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/viper"
+	"go.uber.org/zap/zapcore"
+)
+
+// HTTPConfig configures the HTTP and gRPC listeners.
+type HTTPConfig struct {
+	Port     int  `mapstructure:"HTTP_PORT"`
+	GRPCPort int  `mapstructure:"GRPC_PORT"`
+	Prefork  bool `mapstructure:"PREFORK"`
+
+	ReadTimeout  time.Duration `mapstructure:"HTTP_READ_TIMEOUT"`
+	WriteTimeout time.Duration `mapstructure:"HTTP_WRITE_TIMEOUT"`
+	IdleTimeout  time.Duration `mapstructure:"HTTP_IDLE_TIMEOUT"`
+	// RequestTimeout bounds how long a single request's downstream work
+	// (DB/Redis/etc.) may run before its context is canceled.
+	RequestTimeout time.Duration `mapstructure:"REQUEST_TIMEOUT"`
+	// BodyLimitKB caps request bodies; larger ones are refused with 413
+	// before they are read into memory.
+	BodyLimitKB int `mapstructure:"HTTP_BODY_LIMIT_KB"`
+
+	CORSOrigins string `mapstructure:"CORS_ORIGINS"`
+
+	// ShutdownDrainDelay is how long shutdown keeps serving with /ready and
+	// the gRPC health service failing, so load balancers stop routing here
+	// before the listeners close.
+	ShutdownDrainDelay time.Duration `mapstructure:"SHUTDOWN_DRAIN_DELAY"`
+}
+
+// maxShutdownDrainDelay keeps the drain well inside a typical termination
+// grace period, which must also cover stopping the servers.
+const maxShutdownDrainDelay = time.Minute
+
+func (h HTTPConfig) validate(v *violations) {
+	v.port("HTTP_PORT", h.Port)
+	v.port("GRPC_PORT", h.GRPCPort)
+	if h.ShutdownDrainDelay < 0 || h.ShutdownDrainDelay > maxShutdownDrainDelay {
+		v.add("SHUTDOWN_DRAIN_DELAY", "SHUTDOWN_DRAIN_DELAY must be between 0 and %s (got %s)", maxShutdownDrainDelay, h.ShutdownDrainDelay)
+	}
+}
+
+// validatePolicy rejects listener settings that are unsafe or unsupported
+// for the API server.
+func (h HTTPConfig) validatePolicy(environment string, v *violations) {
+	// Fiber prefork re-execs the whole binary per child; each child would then
+	// try to bind the same gRPC port and split the Prometheus registry. This
+	// topology embeds a gRPC server, so prefork is unsupported — scale out with
+	// multiple replicas instead.
+	if h.Prefork {
+		v.add("PREFORK", "PREFORK is not supported with the embedded gRPC server; run multiple replicas to scale horizontally")
+	}
+	// A wildcard CORS origin in production would allow any site to make
+	// credentialed cross-origin requests.
+	if environment == "production" && strings.TrimSpace(h.CORSOrigins) == "*" {
+		v.add("CORS_ORIGINS", "CORS_ORIGINS must not be '*' in production; set explicit allowed origins")
+	}
+}
+
+// DBConfig is database.Config plus the schema settings applied by NewDatabase.
+type DBConfig struct {
+	database.Config `mapstructure:",squash"`
+
+	// AutoMigrate brings the schema up to date on startup, under an advisory
+	// lock so one replica applies it. Defaults to true in development and
+	// false otherwise; when false, startup fails if the schema is behind.
+	AutoMigrate bool `mapstructure:"DB_AUTO_MIGRATE"`
+
+	// AutoMigrateSource is what DB_AUTO_MIGRATE runs: gorm (AutoMigrate from
+	// the entities) or sql (the golang-migrate migrations embedded in the
+	// binary, as `migrate up` applies them).
+	AutoMigrateSource string `mapstructure:"DB_AUTO_MIGRATE_SOURCE"`
+
+	// MigrationFormat numbers the files `migrate create` writes: sequential
+	// (000007_name) or timestamp (20260301093000_name), which does not
+	// collide when two branches add a migration.
+	MigrationFormat string `mapstructure:"DB_MIGRATION_FORMAT"`
+
+	// StatsInterval is how often the open connection count is sampled
+	// into db_connections_open.
+	StatsInterval time.Duration `mapstructure:"DB_STATS_INTERVAL"`
+}
+
+// DB_AUTO_MIGRATE_SOURCE values.
+const (
+	AutoMigrateGorm = "gorm"
+	AutoMigrateSQL  = "sql"
+)
+
+// dbSSLModes are the sslmode values libpq accepts.
+var dbSSLModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+func (d DBConfig) validate(v *violations) {
+	v.port("DB_PORT", d.Port)
+	v.oneOf("DB_SSL_MODE", d.SSLMode, dbSSLModes...)
+	v.oneOf("DB_MIGRATION_FORMAT", d.MigrationFormat, "", "sequential", "timestamp")
+	v.oneOf("DB_AUTO_MIGRATE_SOURCE", d.AutoMigrateSource, "", AutoMigrateGorm, AutoMigrateSQL)
+	switch d.PoolerMode {
+	case "", database.PoolerNone, database.PoolerSession, database.PoolerTransaction:
+	default:
+		v.add("DB_POOLER_MODE", "DB_POOLER_MODE must be none, session or transaction (got %q)", d.PoolerMode)
+	}
+	switch d.QueryLogRedaction {
+	case "", database.RedactAll, database.RedactSensitive, database.RedactNone:
+	default:
+		v.add("DB_LOG_REDACTION", "DB_LOG_REDACTION must be all, sensitive or none (got %q)", d.QueryLogRedaction)
+	}
+	// Zero falls back to the pool default (see database.New); negative is a
+	// typo rather than a way to disable a limit.
+	v.nonNegative("DB_MAX_IDLE_CONNS", d.MaxIdleConns)
+	v.nonNegative("DB_MAX_OPEN_CONNS", d.MaxOpenConns)
+	v.nonNegativeDuration("DB_CONN_MAX_LIFETIME", d.ConnMaxLifetime)
+	v.nonNegativeDuration("DB_CONN_MAX_IDLE_TIME", d.ConnMaxIdleTime)
+	for _, addr := range d.ReplicaHosts {
+		if _, _, err := database.SplitReplicaHost(addr, d.Port); err != nil {
+			v.add("DB_REPLICA_HOSTS", "%v", err)
+		}
+	}
+}
+
+// RedisConfig is redis.Config plus how often the pool stats are exported.
+type RedisConfig struct {
+	redis.Config `mapstructure:",squash"`
+
+	StatsInterval time.Duration `mapstructure:"REDIS_STATS_INTERVAL"`
+	// UserCacheTTL is how long users read by id stay cached in Redis (see
+	// user_repository.NewCached); under one second disables the cache.
+	UserCacheTTL time.Duration `mapstructure:"USER_CACHE_TTL"`
+	// IdempotencyTTL is how long responses to requests carrying an
+	// Idempotency-Key are replayed (see pkg/idempotency).
+	IdempotencyTTL time.Duration `mapstructure:"IDEMPOTENCY_TTL"`
+}
+
+func (r RedisConfig) validate(v *violations) {
+	v.oneOf("REDIS_MODE", r.Mode, "", redis.ModeStandalone, redis.ModeSentinel, redis.ModeCluster)
+	switch r.Mode {
+	case redis.ModeSentinel:
+		if r.SentinelMaster == "" {
+			v.add("REDIS_SENTINEL_MASTER", "REDIS_SENTINEL_MASTER is required when REDIS_MODE is sentinel")
+		}
+		if len(r.SentinelAddrs) == 0 {
+			v.add("REDIS_SENTINEL_ADDRS", "REDIS_SENTINEL_ADDRS is required when REDIS_MODE is sentinel")
+		}
+	case redis.ModeCluster:
+		if len(r.ClusterAddrs) == 0 {
+			v.add("REDIS_CLUSTER_ADDRS", "REDIS_CLUSTER_ADDRS is required when REDIS_MODE is cluster")
+		}
+		if r.DB != 0 {
+			v.add("REDIS_DB", "REDIS_DB must be 0 when REDIS_MODE is cluster (got %d)", r.DB)
+		}
+	default:
+		// Sentinel and cluster modes take host:port lists instead.
+		v.port("REDIS_PORT", r.Port)
+	}
+}
+
+// AuthConfig configures token signing and login protection.
+type AuthConfig struct {
+	JWTSecret     string `mapstructure:"JWT_SECRET"`
+	JWTExpiration int    `mapstructure:"JWT_EXPIRATION"` // hours
+	// JWTSecretPrevious lists secrets rotated out of JWT_SECRET, newest
+	// first; tokens they encrypted still validate until they expire.
+	JWTSecretPrevious []string `mapstructure:"JWT_SECRET_PREVIOUS"`
+
+	// TokenMode selects v4.local tokens encrypted with JWTSecret ("local")
+	// or v4.public tokens signed with TokenSigningKeys ("public"), whose
+	// public keys are served at /.well-known/token-keys.
+	TokenMode string `mapstructure:"TOKEN_MODE"`
+	// TokenSigningKeys are "<ed25519 seed hex>@<RFC 3339 created_at>",
+	// newest (signing) first; see token.ParseSigningKeys.
+	TokenSigningKeys []string `mapstructure:"TOKEN_SIGNING_KEYS"`
+	// TokenKeyOverlap is how long a retired key still verifies tokens; 0
+	// means the token lifetime.
+	TokenKeyOverlap time.Duration `mapstructure:"TOKEN_KEY_OVERLAP"`
+
+	// Login protection (account and IP lockout after repeated failures)
+	LoginMaxAttempts    int `mapstructure:"LOGIN_MAX_ATTEMPTS"`
+	LoginLockoutMinutes int `mapstructure:"LOGIN_LOCKOUT_MINUTES"`
+	// LoginWindowMinutes is how far back failures count towards a lockout.
+	LoginWindowMinutes int `mapstructure:"LOGIN_WINDOW_MINUTES"`
+	// LoginMaxAttemptsPerIP locks a client IP after this many failures
+	// within the window, across all accounts; 0 disables it.
+	LoginMaxAttemptsPerIP int `mapstructure:"LOGIN_MAX_ATTEMPTS_PER_IP"`
+
+	// PasswordResetTTL is how long a forgot-password token stays valid.
+	PasswordResetTTL time.Duration `mapstructure:"PASSWORD_RESET_TTL"`
+	// InviteTTL is how long the token sent to a user created by an
+	// administrator without a password stays valid.
+	InviteTTL time.Duration `mapstructure:"INVITE_TTL"`
+
+	// Emergency override for every route's role checks (enforce | monitor).
+	// Empty uses each route's own mode from the proto.
+	RoleMode string `mapstructure:"AUTH_ROLE_MODE"`
+
+	// JSON role→permissions map replacing authz.DefaultPolicy; empty keeps
+	// the built-in one.
+	PolicyFile string `mapstructure:"AUTHZ_POLICY_FILE"`
+}
+
+func (a AuthConfig) validate(v *violations) {
+	switch middleware.AuthMode(a.RoleMode) {
+	case "", middleware.AuthModeEnforce, middleware.AuthModeMonitor:
+	default:
+		v.add("AUTH_ROLE_MODE", "AUTH_ROLE_MODE must be empty, enforce or monitor (got %q)", a.RoleMode)
+	}
+	switch a.TokenMode {
+	case "", token.ModeLocal, token.ModePublic:
+	default:
+		v.add("TOKEN_MODE", "TOKEN_MODE must be local or public (got %q)", a.TokenMode)
+	}
+}
+
+// validateKeys checks the token-signing material of the configured
+// TOKEN_MODE. Only processes that mint or verify tokens need it.
+func (a AuthConfig) validateKeys(v *violations) {
+	switch a.TokenMode {
+	case "", token.ModeLocal:
+	case token.ModePublic:
+		// Public tokens are signed with TOKEN_SIGNING_KEYS; JWT_SECRET is unused.
+		if _, err := token.ParseSigningKeys(a.TokenSigningKeys); err != nil {
+			v.add("TOKEN_SIGNING_KEYS", "TOKEN_SIGNING_KEYS: %v", err)
+		}
+		return
+	default:
+		// Reported by validate.
+		return
+	}
+
+	validateSecret(v, "JWT_SECRET", a.JWTSecret)
+	for _, s := range a.JWTSecretPrevious {
+		validateSecret(v, "JWT_SECRET_PREVIOUS", s)
+	}
+}
+
+// validateSecret applies token.NewTokenService's rules for secrets to the
+// value of key.
+func validateSecret(v *violations, key, s string) {
+	switch {
+	case s == "":
+		v.add(key, "%s is not set; generate one with `token.GenerateSecretKey` and set %s", key, key)
+		return
+	case s == placeholderJWTSecret:
+		v.add(key, "%s still uses the insecure placeholder value; set a unique secret before starting", key)
+		return
+	}
+	// Accept a 64-char hex string (32 bytes) …
+	if len(s) == 64 {
+		if _, err := hex.DecodeString(s); err == nil {
+			return
+		}
+	}
+	// … or a raw secret of at least 32 bytes.
+	if len(s) < 32 {
+		v.add(key, "%s must be a 64-character hex string or at least 32 bytes (got %d bytes)", key, len(s))
+	}
+}
+
+// ObservabilityConfig configures tracing, logging and the /metrics endpoint.
+type ObservabilityConfig struct {
+	Tracing telemetry.Config `mapstructure:",squash"`
+	Log     logger.Config    `mapstructure:",squash"`
+
+	// MetricsAuthToken, when set, requires `Authorization: Bearer <token>` on
+	// the /metrics endpoint. Empty means open (restrict at the network layer).
+	MetricsAuthToken string `mapstructure:"METRICS_AUTH_TOKEN"`
+	// SkipPaths lists paths (trailing "/*" wildcards allowed) that produce no
+	// spans, request logs or HTTP metrics.
+	SkipPaths []string `mapstructure:"OBSERVABILITY_SKIP_PATHS"`
+	// HealthCheckTimeout bounds each /ready dependency check.
+	HealthCheckTimeout time.Duration `mapstructure:"HEALTH_CHECK_TIMEOUT"`
+	// HealthCacheTTL is how long a /ready report is reused; 0 checks on
+	// every probe.
+	HealthCacheTTL time.Duration `mapstructure:"HEALTH_CACHE_TTL"`
+	// SlowRequestThreshold logs requests at least this slow at Warn with
+	// slow=true; 0 disables it. SlowRoutes override it per path with
+	// "pattern=threshold" entries (see middleware.ParseSlowRoutes).
+	SlowRequestThreshold time.Duration `mapstructure:"LOG_SLOW_REQUEST_MS"`
+	SlowRoutes           []string      `mapstructure:"LOG_SLOW_ROUTES"`
+	// SuccessSampleRate logs 1 in N of the 2xx requests that are not slow.
+	SuccessSampleRate int `mapstructure:"LOG_SUCCESS_SAMPLE_RATE"`
+	// LogHTTPBodies logs request and response bodies up to HTTPBodyMaxBytes,
+	// masking HTTPSensitiveFields on top of middleware.DefaultSensitiveFields.
+	// For debugging only: it is refused in production.
+	LogHTTPBodies       bool     `mapstructure:"LOG_HTTP_BODIES"`
+	HTTPBodyMaxBytes    int      `mapstructure:"LOG_HTTP_BODY_MAX_BYTES"`
+	HTTPSensitiveFields []string `mapstructure:"LOG_HTTP_SENSITIVE_FIELDS"`
+}
+
+// RequestLogging returns the LoggerMiddleware configuration.
+func (o ObservabilityConfig) RequestLogging() middleware.LoggerConfig {
+	routes, _ := middleware.ParseSlowRoutes(o.SlowRoutes) // checked by validate
+	return middleware.LoggerConfig{
+		SkipPaths:         o.SkipPaths,
+		SlowThreshold:     o.SlowRequestThreshold,
+		SlowRoutes:        routes,
+		SuccessSampleRate: o.SuccessSampleRate,
+	}
+}
+
+// BodyLogging returns the BodyLogMiddleware configuration.
+func (o ObservabilityConfig) BodyLogging() middleware.BodyLogConfig {
+	return middleware.BodyLogConfig{
+		SkipPaths:       o.SkipPaths,
+		MaxBytes:        o.HTTPBodyMaxBytes,
+		SensitiveFields: o.HTTPSensitiveFields,
+	}
+}
+
+func (o ObservabilityConfig) validate(v *violations) {
+	v.oneOf("OTEL_EXPORTER_TYPE", o.Tracing.ExporterType, "otlp", "otlphttp", "stdout", "noop")
+	v.oneOf("OTEL_SAMPLER", o.Tracing.Sampler, "", telemetry.SamplerAlways, telemetry.SamplerNever, telemetry.SamplerRatio)
+	if _, err := telemetry.ParseHeaders(o.Tracing.Headers); err != nil {
+		v.add("OTEL_HEADERS", "OTEL_HEADERS: %v", err)
+	}
+	v.oneOf("METRICS_EXPORTER", o.Tracing.MetricsExporter, "", telemetry.MetricsPrometheus, telemetry.MetricsOTLP)
+	if o.Tracing.MetricsExporter == telemetry.MetricsOTLP &&
+		(!o.Tracing.Enabled || (o.Tracing.ExporterType != "otlp" && o.Tracing.ExporterType != "otlphttp")) {
+		v.add("METRICS_EXPORTER", "METRICS_EXPORTER=otlp needs OTEL_ENABLED=true and OTEL_EXPORTER_TYPE otlp or otlphttp")
+	}
+	if o.Tracing.MetricsInterval < 0 {
+		v.add("OTEL_METRICS_INTERVAL", "OTEL_METRICS_INTERVAL must be positive (got %s)", o.Tracing.MetricsInterval)
+	}
+	if r := o.Tracing.SampleRatio; r < 0 || r > 1 {
+		v.add("OTEL_SAMPLE_RATIO", "OTEL_SAMPLE_RATIO must be between 0 and 1 (got %g)", r)
+	}
+	if _, err := zapcore.ParseLevel(o.Log.Level); err != nil {
+		v.add("LOG_LEVEL", "LOG_LEVEL must be debug, info, warn, error, dpanic, panic or fatal (got %q)", o.Log.Level)
+	}
+	v.oneOf("LOG_FORMAT", o.Log.Format, "", logger.FormatJSON, logger.FormatConsole)
+	if o.SlowRequestThreshold < 0 {
+		v.add("LOG_SLOW_REQUEST_MS", "LOG_SLOW_REQUEST_MS must be 0 (disabled) or positive (got %s)", o.SlowRequestThreshold)
+	}
+	if _, err := middleware.ParseSlowRoutes(o.SlowRoutes); err != nil {
+		v.add("LOG_SLOW_ROUTES", "LOG_SLOW_ROUTES: %v", err)
+	}
+	if o.SuccessSampleRate < 0 {
+		v.add("LOG_SUCCESS_SAMPLE_RATE", "LOG_SUCCESS_SAMPLE_RATE must be 0 or positive (got %d)", o.SuccessSampleRate)
+	}
+	if o.LogHTTPBodies && o.HTTPBodyMaxBytes <= 0 {
+		v.add("LOG_HTTP_BODY_MAX_BYTES", "LOG_HTTP_BODY_MAX_BYTES must be positive (got %d)", o.HTTPBodyMaxBytes)
+	}
+}
+
+// durationUnits gives the unit of duration keys that predate Go duration
+// strings: a bare number is read in that unit, so DB_CONN_MAX_LIFETIME=60
+// still means an hour, while "90s" style values work too.
+var durationUnits = map[string]time.Duration{
+	"HTTP_READ_TIMEOUT":       time.Second,
+	"HTTP_WRITE_TIMEOUT":      time.Second,
+	"HTTP_IDLE_TIMEOUT":       time.Second,
+	"REQUEST_TIMEOUT":         time.Second,
+	"DB_CONN_MAX_LIFETIME":    time.Minute,
+	"DB_STATS_INTERVAL":       time.Second,
+	"REDIS_SLOW_THRESHOLD_MS": time.Millisecond,
+	"LOG_SLOW_REQUEST_MS":     time.Millisecond,
+	"REDIS_STATS_INTERVAL":    time.Second,
+}
+
+// applyDurationUnits rewrites bare numbers of the durationUnits keys into
+// durations, after every layer is loaded.
+func applyDurationUnits(v *viper.Viper) {
+	for key, unit := range durationUnits {
+		n, err := strconv.ParseFloat(strings.TrimSpace(v.GetString(key)), 64)
+		if err != nil {
+			continue
+		}
+		v.Set(key, time.Duration(n*float64(unit)))
+	}
+}
+
+// decodeHooks convert env strings into the section field types: durations,
+// and comma-separated lists with blank entries dropped.
+var decodeHooks = viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+	mapstructure.StringToTimeDurationHookFunc(),
+	splitListHook,
+))
+
+func splitListHook(from, to reflect.Type, data any) (any, error) {
+	if from.Kind() != reflect.String || to != reflect.TypeOf([]string(nil)) {
+		return data, nil
+	}
+	return splitList(data.(string)), nil
+}
+
+// splitList splits a comma-separated setting, dropping blank entries.
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// configKeys returns the env var of every Config field, descending into
+// squashed sections. Keys shared by sections (ENVIRONMENT) repeat.
+func configKeys() []string {
+	var keys []string
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := f.Tag.Get("mapstructure")
+			switch {
+			case tag == ",squash":
+				walk(f.Type)
+			case tag != "" && tag != "-":
+				keys = append(keys, tag)
+			}
+		}
+	}
+	walk(reflect.TypeOf(Config{}))
+	return keys
+}
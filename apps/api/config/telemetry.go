@@ -3,16 +3,27 @@ package config
 import (
 	"context"
 
+	"veemon/pkg/lifecycle"
+	"veemon/pkg/metrics"
 	"veemon/pkg/telemetry"
+
+	"go.uber.org/zap"
 )
 
-func NewTelemetry(ctx context.Context, cfg *Config) (*telemetry.Telemetry, error) {
-	return telemetry.New(ctx, telemetry.Config{
-		ServiceName:  cfg.OTelServiceName,
-		Environment:  cfg.Environment,
-		Endpoint:     cfg.OTelEndpoint,
-		ExporterType: cfg.OTelExporterType,
-		SampleRatio:  cfg.OTelSampleRatio,
-		Enabled:      cfg.OTelEnabled,
-	})
+// NewTelemetry installs the tracer provider and, with METRICS_EXPORTER=otlp,
+// the meter provider that pushes pkg/metrics. The exporter connects lazily,
+// so an unreachable collector only earns a warning.
+func NewTelemetry(ctx context.Context, cfg *Config, log *zap.Logger) (*telemetry.Telemetry, error) {
+	t, err := telemetry.New(ctx, cfg.Observability.Tracing, metrics.Producer())
+	if err != nil {
+		return nil, err
+	}
+	lifecycle.Register("telemetry", lifecycle.PriorityTelemetry, t.Shutdown)
+	go func() {
+		if err := t.CheckCollector(ctx); err != nil {
+			log.Warn("Trace collector unreachable; spans are dropped until it is up",
+				zap.String("endpoint", cfg.Observability.Tracing.Endpoint), zap.Error(err))
+		}
+	}()
+	return t, nil
 }
@@ -0,0 +1,11 @@
+package config
+
+import "veemon/pkg/urlpolicy"
+
+// NewURLPolicy builds the frontend URL policy from FRONTEND_BASE_URLS. Flows
+// that email links (verification, password reset, invites) build them with
+// BuildFrontendURL, and endpoints that accept a return URL check it with
+// ValidateReturnURL.
+func NewURLPolicy(cfg *Config) (*urlpolicy.Policy, error) {
+	return urlpolicy.New(urlpolicy.ParseList(cfg.FrontendBaseURLs))
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"veemon/app/usecase/user"
+	"veemon/pkg/urlpolicy"
+)
+
+// maxUserImportBatchSize bounds the accounts one import transaction holds
+// locks and bcrypt work for.
+const maxUserImportBatchSize = 1000
+
+// SettingError is one setting the process cannot run with. Key is its
+// environment variable; Message says what is wrong and names the key.
+type SettingError struct {
+	Key     string
+	Message string
+}
+
+func (e *SettingError) Error() string { return e.Message }
+
+// ValidationError lists every invalid setting, in the order they were
+// checked, so one failed start reports all of them.
+type ValidationError struct {
+	Errors []*SettingError
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Errors) == 1 {
+		return "invalid configuration: " + e.Errors[0].Message
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "invalid configuration (%d problems):", len(e.Errors))
+	for _, se := range e.Errors {
+		b.WriteString("\n  - ")
+		b.WriteString(se.Message)
+	}
+	return b.String()
+}
+
+// Unwrap exposes each SettingError to errors.As.
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, se := range e.Errors {
+		errs[i] = se
+	}
+	return errs
+}
+
+// Keys returns the environment variables of the invalid settings.
+func (e *ValidationError) Keys() []string {
+	keys := make([]string, len(e.Errors))
+	for i, se := range e.Errors {
+		keys[i] = se.Key
+	}
+	return keys
+}
+
+// violations collects SettingErrors while a Config is checked.
+type violations []*SettingError
+
+func (v *violations) add(key, format string, args ...any) {
+	*v = append(*v, &SettingError{Key: key, Message: fmt.Sprintf(format, args...)})
+}
+
+// port requires a TCP port number.
+func (v *violations) port(key string, port int) {
+	if port < 1 || port > 65535 {
+		v.add(key, "%s must be a port between 1 and 65535 (got %d)", key, port)
+	}
+}
+
+// nonNegative requires a count where 0 selects the default.
+func (v *violations) nonNegative(key string, n int) {
+	if n < 0 {
+		v.add(key, "%s must be 0 (default) or positive (got %d)", key, n)
+	}
+}
+
+// nonNegativeDuration requires a duration where 0 selects the default.
+func (v *violations) nonNegativeDuration(key string, d time.Duration) {
+	if d < 0 {
+		v.add(key, "%s must be 0 (default) or positive (got %s)", key, d)
+	}
+}
+
+// oneOf requires value to be one of allowed.
+func (v *violations) oneOf(key, value string, allowed ...string) {
+	if slices.Contains(allowed, value) {
+		return
+	}
+	shown := slices.DeleteFunc(slices.Clone(allowed), func(a string) bool { return a == "" })
+	v.add(key, "%s must be one of %s (got %q)", key, strings.Join(shown, ", "), value)
+}
+
+func (v violations) err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	return &ValidationError{Errors: v}
+}
+
+// Validate checks the whole configuration: the values New already checked,
+// plus the policies for serving (production rules, unsupported listener
+// settings) and the token-signing material. It is called explicitly by
+// processes that mint or verify tokens (the API server) so the process fails
+// fast rather than silently accepting a weak or publicly known signing key.
+// The error is a *ValidationError listing every invalid setting.
+func (c *Config) Validate() error {
+	var v violations
+	c.validateValues(&v)
+	c.validatePolicy(&v)
+	return v.err()
+}
+
+// validateValues rejects values no process can run with: ports out of
+// range, unknown modes, unparsable lists. New runs it, so the worker and
+// migrate commands fail fast on them too. Each section validates its own
+// keys first.
+func (c *Config) validateValues(v *violations) {
+	c.HTTP.validate(v)
+	c.DB.validate(v)
+	c.Redis.validate(v)
+	v.port("RABBITMQ_PORT", c.RabbitMQ.Port)
+	c.Auth.validate(v)
+	c.Observability.validate(v)
+
+	if c.WorkerMetricsPort != 0 {
+		v.port("WORKER_METRICS_PORT", c.WorkerMetricsPort)
+	}
+	if c.MailDriver == "smtp" {
+		v.port("SMTP_PORT", c.SMTPPort)
+	}
+	// The worker sends password reset and invite links; captured emails
+	// would leave them in its logs or outbox. Checked here rather than in
+	// validatePolicy because the worker only runs New.
+	if c.Environment == "production" && c.MailDriver == "log" {
+		v.add("MAIL_DRIVER", "MAIL_DRIVER must not be log in production")
+	}
+
+	if p := c.RegisterDeletedEmail; p != "" && !user.DeletedEmailPolicy(p).Valid() {
+		v.add("REGISTER_DELETED_EMAIL", "REGISTER_DELETED_EMAIL must be one of new, reactivate, block (got %q)", c.RegisterDeletedEmail)
+	}
+	if _, err := urlpolicy.New(urlpolicy.ParseList(c.FrontendBaseURLs)); err != nil {
+		v.add("FRONTEND_BASE_URLS", "FRONTEND_BASE_URLS: %v", err)
+	}
+	if c.CompanyMaxUsers < 0 {
+		v.add("COMPANY_MAX_USERS", "COMPANY_MAX_USERS must be 0 (unlimited) or positive (got %d)", c.CompanyMaxUsers)
+	}
+	// Zero means the handler's default.
+	v.nonNegative("USER_IMPORT_MAX_ROWS", c.UserImportMaxRows)
+	if c.UserImportBatchSize < 0 || c.UserImportBatchSize > maxUserImportBatchSize {
+		v.add("USER_IMPORT_BATCH_SIZE", "USER_IMPORT_BATCH_SIZE must be between 1 and %d (got %d)", maxUserImportBatchSize, c.UserImportBatchSize)
+	}
+	v.nonNegativeDuration("USER_EXPORT_TIMEOUT", c.UserExportTimeout)
+	if c.OutboxEnabled {
+		if c.OutboxRelayInterval <= 0 {
+			v.add("OUTBOX_RELAY_INTERVAL", "OUTBOX_RELAY_INTERVAL must be positive (got %s)", c.OutboxRelayInterval)
+		}
+		if c.OutboxBatchSize < 1 {
+			v.add("OUTBOX_BATCH_SIZE", "OUTBOX_BATCH_SIZE must be positive (got %d)", c.OutboxBatchSize)
+		}
+	}
+	if c.JournalEnabled && c.JournalSink != "file" && c.JournalSink != "redis" {
+		v.add("JOURNAL_SINK", "JOURNAL_SINK must be file or redis (got %q)", c.JournalSink)
+	}
+}
+
+// validatePolicy applies the rules for serving traffic, stricter in
+// production.
+func (c *Config) validatePolicy(v *violations) {
+	c.HTTP.validatePolicy(c.Environment, v)
+	c.Auth.validateKeys(v)
+	if c.Environment != "production" {
+		return
+	}
+	// The mutation journal stores request bodies.
+	if c.JournalEnabled {
+		v.add("JOURNAL_ENABLED", "JOURNAL_ENABLED must not be set in production")
+	}
+	// Body logs hold whatever redaction misses.
+	if c.Observability.LogHTTPBodies {
+		v.add("LOG_HTTP_BODIES", "LOG_HTTP_BODIES must not be set in production")
+	}
+	// Links sent to users must never use plain http.
+	for _, b := range urlpolicy.ParseList(c.FrontendBaseURLs) {
+		if !strings.HasPrefix(strings.ToLower(b), "https://") {
+			v.add("FRONTEND_BASE_URLS", "FRONTEND_BASE_URLS must use https in production (got %q)", b)
+			break
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+// Version formats for Create.
+const (
+	// FormatSequential numbers migrations 000001, 000002, ...
+	FormatSequential = "sequential"
+	// FormatTimestamp numbers migrations by their UTC creation time,
+	// 20260301093000, so migrations added on two branches do not collide.
+	FormatTimestamp = "timestamp"
+)
+
+// timestampLayout is the version layout of FormatTimestamp.
+const timestampLayout = "20060102150405"
+
+var (
+	// ErrDuplicateVersion is returned by Create when two migrations in the
+	// directory share a version, which golang-migrate refuses to run.
+	ErrDuplicateVersion = errors.New("duplicate migration version")
+	// ErrVersionTaken is returned by Create when the new migration's version
+	// is not above every existing one.
+	ErrVersionTaken = errors.New("migration version already taken")
+)
+
+var migrationName = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// CreateOptions configures Create.
+type CreateOptions struct {
+	// Format is FormatSequential (the default) or FormatTimestamp.
+	Format string
+	// Tx wraps both templates in BEGIN/COMMIT.
+	Tx bool
+	// Now is the creation time; zero means time.Now.
+	Now time.Time
+}
+
+// Create writes the up and down files of a new migration called name to dir,
+// creating dir if needed, and returns their paths. It writes nothing when dir
+// already holds two migrations with the same version or when the new version
+// would not sort after every existing one.
+func Create(dir, name string, opts CreateOptions) (up, down string, err error) {
+	if !migrationName.MatchString(name) {
+		return "", "", fmt.Errorf("invalid migration name %q: use letters, digits and underscores", name)
+	}
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	now = now.UTC()
+
+	latest, err := latestVersion(dir)
+	if err != nil {
+		return "", "", err
+	}
+
+	var version string
+	switch opts.Format {
+	case "", FormatSequential:
+		version = fmt.Sprintf("%06d", latest+1)
+	case FormatTimestamp:
+		version = now.Format(timestampLayout)
+		if v, _ := strconv.ParseUint(version, 10, 64); uint(v) <= latest {
+			return "", "", fmt.Errorf("%w: %s is not after the latest version %d", ErrVersionTaken, version, latest)
+		}
+	default:
+		return "", "", fmt.Errorf("unknown migration format %q: want %s or %s", opts.Format, FormatSequential, FormatTimestamp)
+	}
+	prefix := version + "_" + name
+
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return "", "", fmt.Errorf("failed to create migrations directory: %w", err)
+	}
+	up = filepath.Join(dir, prefix+".up.sql")
+	down = filepath.Join(dir, prefix+".down.sql")
+	created := now.Format(time.RFC3339)
+	if err := writeNew(up, template(prefix+".up.sql", created, "migration", opts.Tx)); err != nil {
+		return "", "", err
+	}
+	if err := writeNew(down, template(prefix+".down.sql", created, "rollback", opts.Tx)); err != nil {
+		_ = os.Remove(up)
+		return "", "", err
+	}
+	return up, down, nil
+}
+
+// latestVersion returns the highest migration version in dir, 0 for an empty
+// or missing dir, or ErrDuplicateVersion.
+func latestVersion(dir string) (uint, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	names := map[uint]string{}
+	var latest uint
+	var dups []string
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		m, err := source.Parse(e.Name())
+		if err != nil {
+			continue
+		}
+		if other, ok := names[m.Version]; ok && other != m.Identifier {
+			dups = append(dups, fmt.Sprintf("%d (%s, %s)", m.Version, other, m.Identifier))
+		}
+		names[m.Version] = m.Identifier
+		latest = max(latest, m.Version)
+	}
+	if len(dups) > 0 {
+		sort.Strings(dups)
+		return 0, fmt.Errorf("%w: %s", ErrDuplicateVersion, strings.Join(dups, "; "))
+	}
+	return latest, nil
+}
+
+func template(file, created, kind string, tx bool) string {
+	body := fmt.Sprintf("-- Add your %s SQL here\n", kind)
+	if tx {
+		body = "BEGIN;\n\n" + body + "\nCOMMIT;\n"
+	}
+	return fmt.Sprintf("-- %s\n-- Created at: %s\n\n%s", file, created, body)
+}
+
+// writeNew writes a file that must not exist yet.
+func writeNew(path, content string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Base(path), err)
+	}
+	if _, err := f.WriteString(content); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	return f.Close()
+}
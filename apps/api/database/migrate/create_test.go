@@ -0,0 +1,85 @@
+package migrate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var createdAt = time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+
+func touch(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0600))
+	}
+}
+
+func TestCreate_Sequential(t *testing.T) {
+	dir := t.TempDir()
+	touch(t, dir, "000001_a.up.sql", "000001_a.down.sql", "000006_b.up.sql", "000006_b.down.sql", "notes.txt")
+
+	up, down, err := Create(dir, "add_orders", CreateOptions{Now: createdAt})
+
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "000007_add_orders.up.sql"), up)
+	assert.Equal(t, filepath.Join(dir, "000007_add_orders.down.sql"), down)
+	content, err := os.ReadFile(up)
+	require.NoError(t, err)
+	assert.Equal(t, "-- 000007_add_orders.up.sql\n-- Created at: 2026-03-01T09:30:00Z\n\n-- Add your migration SQL here\n", string(content))
+}
+
+func TestCreate_TimestampInAMissingDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "migrations")
+
+	up, _, err := Create(dir, "add_orders", CreateOptions{Format: FormatTimestamp, Now: createdAt.In(time.FixedZone("WIB", 7*3600))})
+
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "20260301093000_add_orders.up.sql"), up, "versions are UTC")
+}
+
+func TestCreate_Tx(t *testing.T) {
+	dir := t.TempDir()
+
+	_, down, err := Create(dir, "add_orders", CreateOptions{Tx: true, Now: createdAt})
+
+	require.NoError(t, err)
+	content, err := os.ReadFile(down)
+	require.NoError(t, err)
+	assert.Equal(t, "-- 000001_add_orders.down.sql\n-- Created at: 2026-03-01T09:30:00Z\n\nBEGIN;\n\n-- Add your rollback SQL here\n\nCOMMIT;\n", string(content))
+}
+
+func TestCreate_Refuses(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []string
+		migName  string
+		opts     CreateOptions
+		want     error
+	}{
+		{"duplicate version", []string{"000007_a.up.sql", "000007_a.down.sql", "000007_b.up.sql", "000007_b.down.sql"}, "c", CreateOptions{}, ErrDuplicateVersion},
+		{"timestamp not after the latest", []string{"20260301093000_a.up.sql"}, "b", CreateOptions{Format: FormatTimestamp}, ErrVersionTaken},
+		{"unknown format", nil, "b", CreateOptions{Format: "semver"}, nil},
+		{"name with a path", nil, "../b", CreateOptions{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			touch(t, dir, tt.existing...)
+			tt.opts.Now = createdAt
+
+			_, _, err := Create(dir, tt.migName, tt.opts)
+
+			require.Error(t, err)
+			if tt.want != nil {
+				assert.ErrorIs(t, err, tt.want)
+			}
+			entries, _ := os.ReadDir(dir)
+			assert.Len(t, entries, len(tt.existing), "nothing is written")
+		})
+	}
+}
@@ -5,25 +5,58 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 )
 
 // Migrator handles database migrations
 type Migrator struct {
-	m *migrate.Migrate
+	m    *migrate.Migrate
+	fsys fs.FS
 }
 
 // Config holds migration configuration
 type Config struct {
-	DatabaseURL    string
+	DatabaseURL string
+	// MigrationsPath is the directory New reads migrations from; NewFromFS
+	// ignores it.
 	MigrationsPath string
 }
 
-// New creates a new Migrator instance
+// DatabaseURL returns the postgres:// URL golang-migrate connects with,
+// escaping the credentials.
+func DatabaseURL(user, password, host string, port int, name, sslMode string) string {
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(user, password),
+		Host:     host + ":" + strconv.Itoa(port),
+		Path:     "/" + name,
+		RawQuery: url.Values{"sslmode": {sslMode}}.Encode(),
+	}
+	return u.String()
+}
+
+// New creates a new Migrator instance reading cfg.MigrationsPath
 func New(cfg Config) (*Migrator, error) {
+	return NewFromFS(cfg, os.DirFS(cfg.MigrationsPath))
+}
+
+// NewFromFS creates a Migrator reading the migrations at the root of fsys,
+// such as the embedded migrations.FS.
+func NewFromFS(cfg Config, fsys fs.FS) (*Migrator, error) {
+	src, err := iofs.New(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
 	db, err := sql.Open("postgres", cfg.DatabaseURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
@@ -38,16 +71,12 @@ func New(cfg Config) (*Migrator, error) {
 		return nil, fmt.Errorf("failed to create postgres driver: %w", err)
 	}
 
-	m, err := migrate.NewWithDatabaseInstance(
-		fmt.Sprintf("file://%s", cfg.MigrationsPath),
-		"postgres",
-		driver,
-	)
+	m, err := migrate.NewWithInstance("iofs", src, "postgres", driver)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create migrate instance: %w", err)
 	}
 
-	return &Migrator{m: m}, nil
+	return &Migrator{m: m, fsys: fsys}, nil
 }
 
 // Up runs all pending migrations
@@ -74,6 +103,44 @@ func (mg *Migrator) Steps(n int) error {
 	return nil
 }
 
+// Goto migrates up or down to version, which must be a migration in the
+// source directory; 0 rolls back everything.
+func (mg *Migrator) Goto(version uint) error {
+	if version == 0 {
+		return mg.Down()
+	}
+	if err := mg.m.Migrate(version); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("migration to version %d failed: %w", version, err)
+	}
+	return nil
+}
+
+// Plan returns the up migrations that Up would run, oldest first, without
+// running them. In a dirty database the failed migration is listed again.
+func (mg *Migrator) Plan() ([]Migration, error) {
+	all, err := List(mg.fsys)
+	if err != nil {
+		return nil, err
+	}
+	version, dirty, err := mg.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return all, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	pending := Pending(all, version)
+	if dirty {
+		// The failed migration is the one at version; it is retried after a
+		// force to the previous version.
+		i := len(all) - len(pending)
+		if i > 0 && all[i-1].Version == version {
+			pending = all[i-1:]
+		}
+	}
+	return pending, nil
+}
+
 // Rollback rolls back the last migration
 func (mg *Migrator) Rollback() error {
 	return mg.Steps(-1)
@@ -98,3 +165,39 @@ func (mg *Migrator) Close() error {
 	}
 	return dbErr
 }
+
+// Migration is an up migration file in the migrations directory.
+type Migration struct {
+	Version uint
+	Name    string
+	File    string
+}
+
+// List returns the up migrations at the root of fsys, ordered by version.
+// Files that do not follow the <version>_<name>.up.<ext> pattern are
+// ignored, as golang-migrate ignores them.
+func List(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+	var out []Migration
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		m, err := source.Parse(e.Name())
+		if err != nil || m.Direction != source.Up {
+			continue
+		}
+		out = append(out, Migration{Version: m.Version, Name: m.Identifier, File: e.Name()})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+// Pending returns the migrations newer than version.
+func Pending(all []Migration, version uint) []Migration {
+	i := sort.Search(len(all), func(i int) bool { return all[i].Version > version })
+	return all[i:]
+}
@@ -0,0 +1,63 @@
+package migrate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"veemon/migrations"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestList_UpMigrationsInVersionOrder(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"000010_add_index.up.sql", "000010_add_index.down.sql",
+		"000002_add_column.up.sql", "000002_add_column.down.sql",
+		"000001_create_users_table.up.sql", "000001_create_users_table.down.sql",
+		"README.md",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0600))
+	}
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "000003_dir.up.sql"), 0700))
+
+	got, err := List(os.DirFS(dir))
+
+	require.NoError(t, err)
+	assert.Equal(t, []Migration{
+		{Version: 1, Name: "create_users_table", File: "000001_create_users_table.up.sql"},
+		{Version: 2, Name: "add_column", File: "000002_add_column.up.sql"},
+		{Version: 10, Name: "add_index", File: "000010_add_index.up.sql"},
+	}, got)
+}
+
+func TestList_MissingDirectory(t *testing.T) {
+	_, err := List(os.DirFS(filepath.Join(t.TempDir(), "missing")))
+	assert.Error(t, err)
+}
+
+func TestPending(t *testing.T) {
+	all := []Migration{{Version: 1}, {Version: 2}, {Version: 10}}
+
+	assert.Equal(t, all, Pending(all, 0))
+	assert.Equal(t, all[2:], Pending(all, 2))
+	assert.Equal(t, all[2:], Pending(all, 5), "a version between files")
+	assert.Empty(t, Pending(all, 10))
+}
+
+func TestList_EmbeddedMigrationsMatchTheDirectory(t *testing.T) {
+	embedded, err := List(migrations.FS)
+	require.NoError(t, err)
+	onDisk, err := List(os.DirFS("../../migrations"))
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, embedded)
+	assert.Equal(t, onDisk, embedded)
+}
+
+func TestDatabaseURL_EscapesCredentials(t *testing.T) {
+	assert.Equal(t, "postgres://app:p%40ss%2Fword@db:5432/veemon_db?sslmode=require",
+		DatabaseURL("app", "p@ss/word", "db", 5432, "veemon_db", "require"))
+}
@@ -0,0 +1,183 @@
+package seeds
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"veemon/entity"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm/clause"
+)
+
+// FakePassword is the password of every user SeedFake creates.
+const FakePassword = "Password123!"
+
+// DefaultFakeBatchSize is how many fake users SeedFake inserts per statement.
+const DefaultFakeBatchSize = 500
+
+// FakeOptions configures SeedFake.
+type FakeOptions struct {
+	// Users is how many users to generate.
+	Users int
+	// Seed makes the generated users reproducible: the same seed yields the
+	// same IDs, names, emails, statuses, roles and companies. 0 picks one at
+	// random, reported in FakeReport.Seed.
+	Seed uint64
+	// BatchSize is the users per INSERT; 0 means DefaultFakeBatchSize.
+	BatchSize int
+	// Now anchors CreatedAt, which is spread over the year before it; zero
+	// means time.Now.
+	Now time.Time
+	// Progress, if set, is called after each batch with the users written.
+	Progress func(done int)
+}
+
+// FakeReport is the outcome of SeedFake.
+type FakeReport struct {
+	Seed    uint64
+	Created int
+	Skipped int // email already taken, e.g. by an earlier run with the seed
+	Elapsed time.Duration
+}
+
+// SeedFake inserts opts.Users generated users for load testing, all with
+// FakePassword. Rows are written with CreateInBatches and ON CONFLICT DO
+// NOTHING on email, so rerunning a seed skips the users it already created.
+func (s *Seeder) SeedFake(ctx context.Context, opts FakeOptions) (FakeReport, error) {
+	if opts.Seed == 0 {
+		opts.Seed = rand.Uint64()
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultFakeBatchSize
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	report := FakeReport{Seed: opts.Seed}
+	start := time.Now()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(FakePassword), bcrypt.DefaultCost)
+	if err != nil {
+		return report, fmt.Errorf("hash password: %w", err)
+	}
+	gen := NewFakeUsers(opts.Seed, string(hash), opts.Now)
+	for done := 0; done < opts.Users; {
+		batch := make([]entity.User, min(opts.BatchSize, opts.Users-done))
+		for i := range batch {
+			batch[i] = gen.Next()
+		}
+		res := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+			Columns:     []clause.Column{{Name: "email"}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "deleted_at IS NULL"}}},
+			DoNothing:   true,
+		}).CreateInBatches(batch, opts.BatchSize)
+		if res.Error != nil {
+			report.Elapsed = time.Since(start)
+			return report, fmt.Errorf("insert users %d-%d: %w", done+1, done+len(batch), res.Error)
+		}
+		done += len(batch)
+		report.Created += int(res.RowsAffected)
+		report.Skipped += len(batch) - int(res.RowsAffected)
+		if opts.Progress != nil {
+			opts.Progress(done)
+		}
+	}
+	report.Elapsed = time.Since(start)
+	return report, nil
+}
+
+var (
+	fakeFirstNames = []string{
+		"Budi", "Siti", "Agus", "Dewi", "Rizky", "Putri", "Andi", "Ayu", "Hendra", "Rina",
+		"Fajar", "Indah", "Yoga", "Maya", "Bayu", "Sari", "Dimas", "Nur", "Eko", "Lestari",
+		"James", "Maria", "David", "Sarah", "Michael", "Anna", "Daniel", "Laura", "Kevin", "Grace",
+	}
+	fakeLastNames = []string{
+		"Santoso", "Wijaya", "Pratama", "Saputra", "Hidayat", "Kusuma", "Nugroho", "Setiawan", "Wibowo", "Halim",
+		"Gunawan", "Siregar", "Lubis", "Simanjuntak", "Tanoto", "Hartono", "Susanto", "Rahman", "Putra", "Utami",
+		"Smith", "Johnson", "Lee", "Brown", "Garcia", "Tan", "Wong", "Lim", "Chen", "Taylor",
+	}
+	fakeDomains = []string{"example.com", "example.org", "example.net"}
+)
+
+// weighted picks one of choices, each as likely as its weight.
+type weighted[T any] struct {
+	choices []T
+	weights []int
+}
+
+func (w weighted[T]) pick(r *rand.Rand) T {
+	total := 0
+	for _, n := range w.weights {
+		total += n
+	}
+	n := r.IntN(total)
+	for i, wt := range w.weights {
+		if n < wt {
+			return w.choices[i]
+		}
+		n -= wt
+	}
+	return w.choices[len(w.choices)-1]
+}
+
+var (
+	fakeStatuses = weighted[entity.UserStatus]{
+		choices: []entity.UserStatus{entity.UserStatusActive, entity.UserStatusInactive, entity.UserStatusPending},
+		weights: []int{80, 12, 8},
+	}
+	fakeRoles = weighted[[]string]{
+		choices: [][]string{{"user"}, {"employee"}, {"employee", "user"}, {"admin"}, {"auditor"}},
+		weights: []int{60, 25, 10, 4, 1},
+	}
+)
+
+// FakeUsers generates a reproducible sequence of valid users.
+type FakeUsers struct {
+	src  *rand.ChaCha8
+	rng  *rand.Rand
+	hash string
+	now  time.Time
+	n    int
+}
+
+// NewFakeUsers returns the sequence seed generates. Every user gets the
+// password hash and a CreatedAt in the year before now.
+func NewFakeUsers(seed uint64, passwordHash string, now time.Time) *FakeUsers {
+	var key [32]byte
+	binary.LittleEndian.PutUint64(key[:], seed)
+	src := rand.NewChaCha8(key)
+	return &FakeUsers{src: src, rng: rand.New(src), hash: passwordHash, now: now}
+}
+
+// Next returns the next user. Emails are unique within the sequence.
+func (g *FakeUsers) Next() entity.User {
+	g.n++
+	r := g.rng
+	first := fakeFirstNames[r.IntN(len(fakeFirstNames))]
+	last := fakeLastNames[r.IntN(len(fakeLastNames))]
+	created := g.now.Add(-time.Duration(r.Int64N(int64(365 * 24 * time.Hour)))).Truncate(time.Microsecond)
+	id, err := uuid.NewRandomFromReader(g.src)
+	if err != nil {
+		// ChaCha8 reads never fail.
+		panic(err)
+	}
+	return entity.User{
+		ID:          id.String(),
+		Email:       fmt.Sprintf("%s.%s.%d@%s", strings.ToLower(first), strings.ToLower(last), g.n, fakeDomains[r.IntN(len(fakeDomains))]),
+		Password:    g.hash,
+		Name:        first + " " + last,
+		Phone:       fmt.Sprintf("08%010d", r.Int64N(1e10)),
+		Status:      fakeStatuses.pick(r),
+		Roles:       append([]string(nil), fakeRoles.pick(r)...),
+		CompanyCode: fmt.Sprintf("COMPANY-%03d", 1+r.IntN(20)),
+		CreatedAt:   created,
+		UpdatedAt:   created,
+	}
+}
@@ -0,0 +1,64 @@
+package seeds
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"veemon/entity"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+var fakeNow = time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFakeUsers_ReproducibleAndValid(t *testing.T) {
+	a, b := NewFakeUsers(42, "hash", fakeNow), NewFakeUsers(42, "hash", fakeNow)
+	emails := map[string]bool{}
+	for range 1000 {
+		u := a.Next()
+		require.Equal(t, u, b.Next(), "same seed, same users")
+
+		require.False(t, emails[u.Email], "duplicate email %s", u.Email)
+		emails[u.Email] = true
+		require.True(t, u.Status.Valid(), u.Status)
+		_, err := entity.ParseRoles(u.Roles)
+		require.NoError(t, err)
+		require.Regexp(t, `^08\d{10}$`, u.Phone)
+		require.Regexp(t, `^COMPANY-0[0-2]\d$`, u.CompanyCode)
+		require.Len(t, strings.Fields(u.Name), 2)
+		require.Equal(t, "hash", u.Password)
+		require.True(t, u.CreatedAt.Before(fakeNow) && u.CreatedAt.After(fakeNow.AddDate(-1, 0, 0)), u.CreatedAt)
+	}
+
+	require.NotEqual(t, NewFakeUsers(42, "hash", fakeNow).Next(), NewFakeUsers(43, "hash", fakeNow).Next())
+}
+
+func TestSeedFake_InsertsInBatches(t *testing.T) {
+	db, mock := newMock(t)
+	// 5 users in batches of 2; the last user's email is taken.
+	for _, returned := range []int{2, 2, 0} {
+		rows := sqlmock.NewRows([]string{"roles"})
+		for range returned {
+			rows.AddRow("{user}")
+		}
+		mock.ExpectBegin()
+		mock.ExpectQuery(insertUser).WillReturnRows(rows)
+		mock.ExpectCommit()
+	}
+	var progress []int
+
+	report, err := New(db).SeedFake(context.Background(), FakeOptions{
+		Users: 5, Seed: 7, BatchSize: 2, Now: fakeNow,
+		Progress: func(done int) { progress = append(progress, done) },
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), report.Seed)
+	require.Equal(t, 4, report.Created)
+	require.Equal(t, 1, report.Skipped)
+	require.Equal(t, []int{2, 4, 5}, progress)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,113 @@
+//go:build integration
+
+// Integration tests that require a real PostgreSQL (run with:
+//
+//	go test -tags integration ./database/seeds/...
+//
+// with DB_* env vars pointing at a reachable database).
+package seeds_test
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+
+	"veemon/database/seeds"
+	"veemon/entity"
+	"veemon/pkg/database"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// freshDB returns a DB whose users and seed history tables are created empty
+// under a per-run prefix and dropped afterwards, so the shared schema is
+// untouched.
+func freshDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	port, _ := strconv.Atoi(envOr("DB_PORT", "5432"))
+	db, err := database.New(database.Config{
+		Host:        envOr("DB_HOST", "localhost"),
+		Port:        port,
+		User:        envOr("DB_USER", "postgres"),
+		Password:    envOr("DB_PASSWORD", "postgres"),
+		Name:        envOr("DB_NAME", "veemon_db"),
+		SSLMode:     envOr("DB_SSL_MODE", "disable"),
+		Timezone:    envOr("DB_TIMEZONE", "UTC"),
+		TablePrefix: "seed_" + uuid.NewString()[:8] + "_",
+	}, zap.NewNop())
+	require.NoError(t, err)
+	require.NoError(t, database.AutoMigrate(db))
+	t.Cleanup(func() { _ = db.Migrator().DropTable(&entity.User{}, &entity.SeedHistory{}) })
+	return db
+}
+
+func countUsers(t *testing.T, db *gorm.DB) int64 {
+	t.Helper()
+	var n int64
+	require.NoError(t, db.Model(&entity.User{}).Count(&n).Error)
+	return n
+}
+
+// Two SeedUsers racing on one database, without SeedAll's lock, both
+// succeed: whichever insert loses a conflict skips instead of failing.
+func TestIntegration_ConcurrentSeedUsers(t *testing.T) {
+	db := freshDB(t)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	results := make([]seeds.Result, 2)
+	errs := make([]error, 2)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = seeds.New(db).SeedUsers(ctx)
+		}()
+	}
+	wg.Wait()
+
+	require.NoError(t, errs[0])
+	require.NoError(t, errs[1])
+	require.EqualValues(t, 7, countUsers(t, db))
+	require.Equal(t, 7, len(results[0].Created)+len(results[1].Created), "each user is created exactly once")
+	require.Equal(t, 7, len(results[0].Skipped)+len(results[1].Skipped))
+}
+
+// Concurrent SeedAll runs take turns on the advisory lock: one creates
+// everything and the other finds it in the seed history.
+func TestIntegration_ConcurrentSeedAll(t *testing.T) {
+	db := freshDB(t)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	reports := make([]seeds.Report, 2)
+	errs := make([]error, 2)
+	for i := range reports {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reports[i], errs[i] = seeds.New(db).SeedAll(ctx, seeds.SeedAllOptions{})
+		}()
+	}
+	wg.Wait()
+
+	require.NoError(t, errs[0])
+	require.NoError(t, errs[1])
+	require.EqualValues(t, 7, countUsers(t, db))
+	created := []int{len(reports[0].Results[0].Created), len(reports[1].Results[0].Created)}
+	require.ElementsMatch(t, []int{7, 0}, created)
+	notRun := []bool{reports[0].Results[0].NotRun != "", reports[1].Results[0].NotRun != ""}
+	require.ElementsMatch(t, []bool{false, true}, notRun)
+}
@@ -0,0 +1,98 @@
+package seeds
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// Production is the ENVIRONMENT in which destructive seeders need Force.
+const Production = "production"
+
+// Definition is a seeder registered by name.
+type Definition struct {
+	// Name selects the seeder in `migrate seed --only` and keys its seed
+	// history.
+	Name string
+	// Environments lists the ENVIRONMENT values the seeder may run in;
+	// empty means any.
+	Environments []string
+	// Destructive marks a seeder that overwrites or deletes rows. It does
+	// not run in production without Force.
+	Destructive bool
+	// Run seeds with s, which holds the run's lock and options.
+	Run func(s *Seeder, ctx context.Context) (Result, error)
+}
+
+// registry holds the registered seeders in the order SeedAll runs them.
+var registry []Definition
+
+// Register adds d to the seeders SeedAll runs, after the ones already
+// registered. It panics if the name is taken; call it from an init function.
+func Register(d Definition) {
+	if _, ok := lookup(d.Name); ok {
+		panic(fmt.Sprintf("seeds: seeder %q registered twice", d.Name))
+	}
+	registry = append(registry, d)
+}
+
+// Registered returns the registered seeders in run order.
+func Registered() []Definition {
+	return slices.Clone(registry)
+}
+
+func lookup(name string) (Definition, bool) {
+	for _, d := range registry {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	return Definition{}, false
+}
+
+// holdReason says why d may not run in environment, or returns "".
+func (d Definition) holdReason(environment string, force bool) string {
+	if len(d.Environments) > 0 && !slices.Contains(d.Environments, environment) {
+		return fmt.Sprintf("not enabled in %s", environment)
+	}
+	if d.Destructive && environment == Production && !force {
+		return "destructive; pass --force to run it in production"
+	}
+	return ""
+}
+
+// step is a selected seeder, held back when hold is set.
+type step struct {
+	def  Definition
+	hold string
+}
+
+// selectSeeders returns the seeders opts selects in registration order. A
+// guard holds back a seeder SeedAll would run by default, but fails the run
+// for one named in opts.Only.
+func selectSeeders(opts SeedAllOptions) ([]step, error) {
+	env := opts.environment()
+	if len(opts.Only) == 0 {
+		steps := make([]step, len(registry))
+		for i, d := range registry {
+			steps[i] = step{def: d, hold: d.holdReason(env, opts.Force)}
+		}
+		return steps, nil
+	}
+	for _, name := range opts.Only {
+		d, ok := lookup(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown seeder %q", name)
+		}
+		if reason := d.holdReason(env, opts.Force); reason != "" {
+			return nil, fmt.Errorf("seeder %q: %s", name, reason)
+		}
+	}
+	var steps []step
+	for _, d := range registry {
+		if slices.Contains(opts.Only, d.Name) {
+			steps = append(steps, step{def: d})
+		}
+	}
+	return steps, nil
+}
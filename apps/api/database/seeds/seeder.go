@@ -1,10 +1,18 @@
 // Package seeds provides database seeders.
+//
+// Seeders register by name (see Register) and are idempotent: they insert
+// with ON CONFLICT DO NOTHING on each row's natural key, so rows that exist,
+// or that a concurrent run inserts first, are skipped rather than failing.
+// SeedAll additionally serializes whole runs with an advisory lock and
+// records each seeder that completes in the seed history, so later runs
+// skip it.
 package seeds
 
 import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"veemon/entity"
@@ -12,11 +20,16 @@ import (
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// seedLockKey names the advisory lock that serializes SeedAll runs.
+const seedLockKey = "veemon:seed"
+
 // Seeder handles database seeding
 type Seeder struct {
-	db *gorm.DB
+	db       *gorm.DB
+	failFast bool
 }
 
 // New creates a new Seeder instance
@@ -24,33 +37,172 @@ func New(db *gorm.DB) *Seeder {
 	return &Seeder{db: db}
 }
 
-// SeedAll runs all seeders
-func (s *Seeder) SeedAll(ctx context.Context) error {
-	seeders := []func(context.Context) error{
-		s.SeedUsers,
+// Failure is a row a seeder could not write.
+type Failure struct {
+	Key    string
+	Reason string
+}
+
+// Result is what one seeder did, by natural key (e.g. email).
+type Result struct {
+	Seeder  string
+	Created []string
+	Skipped []string // already present
+	Failed  []Failure
+	// NotRun says why the seeder did not run, e.g. it already ran or is not
+	// enabled in the environment; empty if it ran.
+	NotRun string
+}
+
+// Err summarizes the failures, or returns nil.
+func (r Result) Err() error {
+	if len(r.Failed) == 0 {
+		return nil
+	}
+	reasons := make([]string, len(r.Failed))
+	for i, f := range r.Failed {
+		reasons[i] = f.Key + ": " + f.Reason
 	}
+	return fmt.Errorf("%s: %d failed (%s)", r.Seeder, len(r.Failed), strings.Join(reasons, "; "))
+}
+
+// Report is the outcome of SeedAll, one Result per seeder that ran.
+type Report struct {
+	Results []Result
+}
 
-	for _, seeder := range seeders {
-		if err := seeder(ctx); err != nil {
-			return err
+// Err joins the failures of every seeder, or returns nil.
+func (r Report) Err() error {
+	var errs []error
+	for _, res := range r.Results {
+		if err := res.Err(); err != nil {
+			errs = append(errs, err)
 		}
 	}
+	return errors.Join(errs...)
+}
+
+// SeedAllOptions configures SeedAll.
+type SeedAllOptions struct {
+	// FailFast stops at the first failed row instead of seeding the rest
+	// and reporting every failure.
+	FailFast bool
+	// Only runs just the named seeders. Empty runs every registered seeder
+	// enabled in Environment.
+	Only []string
+	// Environment is the ENVIRONMENT seeded; empty means development.
+	Environment string
+	// Force runs destructive seeders in production and reruns seeders the
+	// seed history records as done.
+	Force bool
+}
+
+func (o SeedAllOptions) environment() string {
+	if o.Environment == "" {
+		return "development"
+	}
+	return o.Environment
+}
+
+// SeedAll runs the registered seeders, holding an advisory lock so
+// concurrent runs (e.g. two CI jobs) take turns. Every seeder runs even if an
+// earlier one failed, unless opts.FailFast is set; the report lists what each
+// created, skipped and failed, and why any did not run. A seeder that
+// completes without failures is recorded in the seed history and not run
+// again unless opts.Force is set. The error is non-nil if opts names an
+// unknown seeder or one its guards forbid, if the lock could not be taken,
+// or if any row failed.
+func (s *Seeder) SeedAll(ctx context.Context, opts SeedAllOptions) (Report, error) {
+	var report Report
+	steps, err := selectSeeders(opts)
+	if err != nil {
+		return report, err
+	}
+	// A session-level lock needs one connection for the whole run.
+	err = s.db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		if err := conn.Exec("SELECT pg_advisory_lock(hashtext(?))", seedLockKey).Error; err != nil {
+			return fmt.Errorf("acquire seed lock: %w", err)
+		}
+		defer conn.WithContext(context.Background()).Exec("SELECT pg_advisory_unlock(hashtext(?))", seedLockKey)
+
+		locked := &Seeder{db: conn, failFast: opts.FailFast}
+		for _, st := range steps {
+			if st.hold != "" {
+				report.Results = append(report.Results, Result{Seeder: st.def.Name, NotRun: st.hold})
+				continue
+			}
+			if !opts.Force {
+				ranAt, err := locked.ranAt(ctx, st.def.Name)
+				if err != nil {
+					return fmt.Errorf("read seed history: %w", err)
+				}
+				if !ranAt.IsZero() {
+					report.Results = append(report.Results, Result{Seeder: st.def.Name, NotRun: "already ran at " + ranAt.UTC().Format(time.RFC3339)})
+					continue
+				}
+			}
+			res, err := st.def.Run(locked, ctx)
+			report.Results = append(report.Results, res)
+			if err != nil {
+				if opts.FailFast {
+					return err
+				}
+				continue
+			}
+			if err := locked.record(ctx, res, opts.environment()); err != nil {
+				return fmt.Errorf("record seed history: %w", err)
+			}
+		}
+		return report.Err()
+	})
+	return report, err
+}
+
+// ranAt returns when the named seeder last completed, or the zero time.
+func (s *Seeder) ranAt(ctx context.Context, name string) (time.Time, error) {
+	var h entity.SeedHistory
+	res := s.db.WithContext(ctx).Where("name = ?", name).Limit(1).Find(&h)
+	return h.RanAt, res.Error
+}
+
+// record writes res to the seed history, replacing an earlier entry.
+func (s *Seeder) record(ctx context.Context, res Result, environment string) error {
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		UpdateAll: true,
+	}).Create(&entity.SeedHistory{
+		Name:        res.Seeder,
+		Environment: environment,
+		Created:     len(res.Created),
+		Skipped:     len(res.Skipped),
+		RanAt:       time.Now(),
+	}).Error
+}
 
-	return nil
+// The users seeder creates accounts with published passwords, so it never
+// runs in production.
+func init() {
+	Register(Definition{
+		Name:         "users",
+		Environments: []string{"development", "staging"},
+		Run:          (*Seeder).SeedUsers,
+	})
 }
 
-// SeedUsers seeds the users table
-func (s *Seeder) SeedUsers(ctx context.Context) error {
-	fmt.Println("Seeding users...")
+// seedUser is a user SeedUsers creates.
+type seedUser struct {
+	Email       string
+	Password    string
+	Name        string
+	Phone       string
+	Roles       []string
+	CompanyCode string
+}
 
-	users := []struct {
-		Email       string
-		Password    string
-		Name        string
-		Phone       string
-		Roles       []string
-		CompanyCode string
-	}{
+// SeedUsers seeds the users table, keyed on email.
+func (s *Seeder) SeedUsers(ctx context.Context) (Result, error) {
+	res := Result{Seeder: "users"}
+	users := []seedUser{
 		{
 			Email:       "superadmin@example.com",
 			Password:    "SuperAdmin123!",
@@ -67,6 +219,14 @@ func (s *Seeder) SeedUsers(ctx context.Context) error {
 			Roles:       []string{"admin"},
 			CompanyCode: "COMPANY-001",
 		},
+		{
+			Email:       "auditor@example.com",
+			Password:    "Auditor123!",
+			Name:        "Read-only Auditor",
+			Phone:       "081234567896",
+			Roles:       []string{"auditor"},
+			CompanyCode: "COMPANY-001",
+		},
 		{
 			Email:       "employee1@example.com",
 			Password:    "Employee123!",
@@ -91,48 +251,65 @@ func (s *Seeder) SeedUsers(ctx context.Context) error {
 			Roles:       []string{"user"},
 			CompanyCode: "COMPANY-001",
 		},
+		{
+			Email:       "gateway@example.com",
+			Password:    "Gateway123!",
+			Name:        "API Gateway",
+			Phone:       "081234567895",
+			Roles:       []string{"service"},
+			CompanyCode: "COMPANY-001",
+		},
 	}
 
 	for _, u := range users {
-		// Check if user already exists. Only a "record not found" result means
-		// we should insert; any other error is a real failure to surface, not a
-		// signal to blindly create a duplicate.
-		var existingUser entity.User
-		result := s.db.WithContext(ctx).Where("email = ?", u.Email).First(&existingUser)
-		switch {
-		case result.Error == nil:
-			fmt.Printf("  User %s already exists, skipping...\n", u.Email)
-			continue
-		case !errors.Is(result.Error, gorm.ErrRecordNotFound):
-			return fmt.Errorf("failed to check existing user %s: %w", u.Email, result.Error)
+		if err := ctx.Err(); err != nil {
+			res.Failed = append(res.Failed, Failure{Key: u.Email, Reason: err.Error()})
+			break
 		}
-
-		// Hash password
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
-		if err != nil {
-			return fmt.Errorf("failed to hash password for %s: %w", u.Email, err)
-		}
-
-		user := entity.User{
-			ID:          uuid.New().String(),
-			Email:       u.Email,
-			Password:    string(hashedPassword),
-			Name:        u.Name,
-			Phone:       u.Phone,
-			Status:      entity.UserStatusActive,
-			Roles:       u.Roles,
-			CompanyCode: u.CompanyCode,
-			CreatedAt:   time.Now(),
-			UpdatedAt:   time.Now(),
-		}
-
-		if err := s.db.WithContext(ctx).Create(&user).Error; err != nil {
-			return fmt.Errorf("failed to seed user %s: %w", u.Email, err)
+		created, err := s.insertUser(ctx, u)
+		switch {
+		case err != nil:
+			res.Failed = append(res.Failed, Failure{Key: u.Email, Reason: err.Error()})
+			if s.failFast {
+				return res, res.Err()
+			}
+		case created:
+			res.Created = append(res.Created, u.Email)
+		default:
+			res.Skipped = append(res.Skipped, u.Email)
 		}
-
-		fmt.Printf("  Created user: %s (%s)\n", u.Name, u.Email)
 	}
+	return res, res.Err()
+}
 
-	fmt.Println("Users seeded successfully!")
-	return nil
+// insertUser creates a user unless a live one has the email, reporting
+// whether it did.
+func (s *Seeder) insertUser(ctx context.Context, u seedUser) (bool, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return false, fmt.Errorf("hash password: %w", err)
+	}
+	now := time.Now()
+	user := entity.User{
+		ID:          uuid.New().String(),
+		Email:       u.Email,
+		Password:    string(hashedPassword),
+		Name:        u.Name,
+		Phone:       u.Phone,
+		Status:      entity.UserStatusActive,
+		Roles:       u.Roles,
+		CompanyCode: u.CompanyCode,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	result := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "email"}},
+		// Matches the partial unique index (email WHERE deleted_at IS NULL).
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "deleted_at IS NULL"}}},
+		DoNothing:   true,
+	}).Create(&user)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
 }
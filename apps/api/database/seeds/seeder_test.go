@@ -0,0 +1,217 @@
+package seeds
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func newMock(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{})
+	require.NoError(t, err)
+	return db, mock
+}
+
+const insertUser = `INSERT INTO "users" .* ON CONFLICT \("email"\)\s+WHERE deleted_at IS NULL DO NOTHING`
+
+func expectLock(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(`SELECT pg_advisory_lock\(hashtext\(\$1\)\)`).WithArgs(seedLockKey).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func expectUnlock(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(`SELECT pg_advisory_unlock\(hashtext\(\$1\)\)`).WithArgs(seedLockKey).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+// expectHistory answers the seed history lookup for users: never ran, or
+// ran at ranAt.
+func expectHistory(mock sqlmock.Sqlmock, ranAt ...time.Time) {
+	rows := sqlmock.NewRows([]string{"name", "environment", "created", "skipped", "ran_at"})
+	for _, at := range ranAt {
+		rows.AddRow("users", "development", 7, 0, at)
+	}
+	mock.ExpectQuery(`SELECT \* FROM "seed_histories" WHERE name = \$1`).WithArgs("users", 1).WillReturnRows(rows)
+}
+
+func expectRecord(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO "seed_histories" .* ON CONFLICT \("name"\) DO UPDATE`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+}
+
+// expectInserts answers one insert per seeded user: "created", "skipped"
+// (the conflict left nothing to insert) or an error.
+func expectInserts(mock sqlmock.Sqlmock, outcomes ...any) {
+	for _, o := range outcomes {
+		mock.ExpectBegin()
+		e := mock.ExpectQuery(insertUser)
+		switch o {
+		case "created":
+			e.WillReturnRows(sqlmock.NewRows([]string{"roles"}).AddRow("{user}"))
+			mock.ExpectCommit()
+		case "skipped":
+			e.WillReturnRows(sqlmock.NewRows([]string{"roles"}))
+			mock.ExpectCommit()
+		default:
+			e.WillReturnError(o.(error))
+			mock.ExpectRollback()
+		}
+	}
+}
+
+func TestSeedAll_ReportsEveryUserPastFailures(t *testing.T) {
+	db, mock := newMock(t)
+	expectLock(mock)
+	expectHistory(mock)
+	expectInserts(mock, "created", "skipped", errors.New("boom"), "created", "created", "skipped", "created")
+	expectUnlock(mock)
+
+	report, err := New(db).SeedAll(context.Background(), SeedAllOptions{})
+
+	require.ErrorContains(t, err, "auditor@example.com: boom")
+	require.Len(t, report.Results, 1)
+	res := report.Results[0]
+	require.Equal(t, "users", res.Seeder)
+	require.Equal(t, []string{"superadmin@example.com", "employee1@example.com", "employee2@example.com", "gateway@example.com"}, res.Created)
+	require.Equal(t, []string{"admin@example.com", "user@example.com"}, res.Skipped)
+	require.Equal(t, []Failure{{Key: "auditor@example.com", Reason: "boom"}}, res.Failed)
+	require.Equal(t, err.Error(), report.Err().Error())
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSeedAll_FailFastStopsAtFirstFailure(t *testing.T) {
+	db, mock := newMock(t)
+	expectLock(mock)
+	expectHistory(mock)
+	expectInserts(mock, "created", errors.New("boom"))
+	expectUnlock(mock)
+
+	report, err := New(db).SeedAll(context.Background(), SeedAllOptions{FailFast: true})
+
+	require.ErrorContains(t, err, "admin@example.com: boom")
+	require.Equal(t, []string{"superadmin@example.com"}, report.Results[0].Created)
+	require.Len(t, report.Results[0].Failed, 1)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSeedAll_AllSkippedOnRerun(t *testing.T) {
+	db, mock := newMock(t)
+	expectLock(mock)
+	expectHistory(mock)
+	expectInserts(mock, "skipped", "skipped", "skipped", "skipped", "skipped", "skipped", "skipped")
+	expectRecord(mock)
+	expectUnlock(mock)
+
+	report, err := New(db).SeedAll(context.Background(), SeedAllOptions{})
+
+	require.NoError(t, err)
+	require.Empty(t, report.Results[0].Created)
+	require.Len(t, report.Results[0].Skipped, 7)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSeedAll_LockFailure(t *testing.T) {
+	db, mock := newMock(t)
+	mock.ExpectExec(`SELECT pg_advisory_lock`).WillReturnError(errors.New("canceled"))
+
+	report, err := New(db).SeedAll(context.Background(), SeedAllOptions{})
+
+	require.ErrorContains(t, err, "acquire seed lock")
+	require.Empty(t, report.Results)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSeedAll_SkipsSeedersInTheHistoryUnlessForced(t *testing.T) {
+	ranAt := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	db, mock := newMock(t)
+	expectLock(mock)
+	expectHistory(mock, ranAt)
+	expectUnlock(mock)
+
+	report, err := New(db).SeedAll(context.Background(), SeedAllOptions{})
+
+	require.NoError(t, err)
+	require.Equal(t, []Result{{Seeder: "users", NotRun: "already ran at 2026-03-01T09:30:00Z"}}, report.Results)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	db, mock = newMock(t)
+	expectLock(mock)
+	expectInserts(mock, "skipped", "skipped", "skipped", "skipped", "skipped", "skipped", "created")
+	expectRecord(mock)
+	expectUnlock(mock)
+
+	report, err = New(db).SeedAll(context.Background(), SeedAllOptions{Force: true})
+
+	require.NoError(t, err)
+	require.Equal(t, []string{"gateway@example.com"}, report.Results[0].Created)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSeedAll_Guards(t *testing.T) {
+	saved := registry
+	t.Cleanup(func() { registry = saved })
+	var resets int
+	Register(Definition{Name: "reset", Destructive: true, Run: func(*Seeder, context.Context) (Result, error) {
+		resets++
+		return Result{Seeder: "reset"}, nil
+	}})
+
+	t.Run("production holds back users and destructive seeders", func(t *testing.T) {
+		db, mock := newMock(t)
+		expectLock(mock)
+		expectUnlock(mock)
+
+		report, err := New(db).SeedAll(context.Background(), SeedAllOptions{Environment: Production})
+
+		require.NoError(t, err)
+		require.Equal(t, []Result{
+			{Seeder: "users", NotRun: "not enabled in production"},
+			{Seeder: "reset", NotRun: "destructive; pass --force to run it in production"},
+		}, report.Results)
+		require.Zero(t, resets)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("naming a held-back seeder fails before the lock", func(t *testing.T) {
+		for _, only := range []string{"users", "reset", "payslips"} {
+			db, mock := newMock(t)
+
+			_, err := New(db).SeedAll(context.Background(), SeedAllOptions{Environment: Production, Only: []string{only}})
+
+			require.ErrorContains(t, err, only, "--only %s", only)
+			require.NoError(t, mock.ExpectationsWereMet())
+		}
+	})
+
+	t.Run("force runs a destructive seeder in production", func(t *testing.T) {
+		db, mock := newMock(t)
+		expectLock(mock)
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO "seed_histories"`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		expectUnlock(mock)
+
+		report, err := New(db).SeedAll(context.Background(), SeedAllOptions{Environment: Production, Only: []string{"reset"}, Force: true})
+
+		require.NoError(t, err)
+		require.Equal(t, []Result{{Seeder: "reset"}}, report.Results)
+		require.Equal(t, 1, resets)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRegister_PanicsOnADuplicateName(t *testing.T) {
+	require.Panics(t, func() { Register(Definition{Name: "users"}) })
+}
@@ -0,0 +1,166 @@
+package transfer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"veemon/entity"
+	"veemon/pkg/events"
+	"veemon/pkg/notify"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserEventTypes are the event types UserEvents can reconstruct.
+var UserEventTypes = []string{notify.UserRegistered, notify.UserUpdated, notify.UserDeleted}
+
+// UserEventsOptions configures UserEvents.
+type UserEventsOptions struct {
+	// Types limits the events to these types; empty means all of
+	// UserEventTypes.
+	Types []string
+	// From skips events that occurred before it.
+	From time.Time
+	// Source is recorded on every envelope, e.g. the service name.
+	Source string
+}
+
+// userEvent is the Data of a reconstructed user event.
+type userEvent struct {
+	ID          string `json:"id"`
+	Email       string `json:"email"`
+	Name        string `json:"name"`
+	CompanyCode string `json:"companyCode,omitempty"`
+}
+
+// userEventRow is one row of the events query.
+type userEventRow struct {
+	Type        string
+	ID          string
+	Email       string
+	Name        string
+	CompanyCode string
+	OccurredAt  time.Time
+}
+
+// UserEvents returns an events.Source that reconstructs user events from the
+// current users table, soft-deleted rows included:
+//
+//	user.registered  every user, at created_at
+//	user.updated     users changed since creation, at updated_at
+//	user.deleted     soft-deleted users, at deleted_at
+//
+// The table holds only the latest state, so earlier updates are lost and
+// every event carries the current email and name (anonymized, for purged
+// accounts). Offsets are keyed "<user id>/<type>", and envelope IDs are
+// derived from type, user and time, so replaying the same row twice yields
+// the same ID for consumers to deduplicate.
+func UserEvents(db *gorm.DB, opts UserEventsOptions) (events.Source, error) {
+	types := opts.Types
+	if len(types) == 0 {
+		types = UserEventTypes
+	}
+	for _, t := range types {
+		if !isUserEventType(t) {
+			return nil, fmt.Errorf("unknown event type %q (want %s)", t, strings.Join(UserEventTypes, ", "))
+		}
+	}
+	return &userEventSource{db: db, types: types, from: opts.From, source: opts.Source}, nil
+}
+
+func isUserEventType(t string) bool {
+	for _, known := range UserEventTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+type userEventSource struct {
+	db     *gorm.DB
+	types  []string
+	from   time.Time
+	source string
+}
+
+// query selects the events after the offset as rows of an "e" subquery
+// with the userEventRow columns.
+func (s *userEventSource) query(ctx context.Context, after events.Offset) (*gorm.DB, error) {
+	parts := make([]string, len(s.types))
+	args := make([]any, len(s.types))
+	for i, t := range s.types {
+		column := "created_at"
+		q := s.db.Unscoped().Model(&entity.User{})
+		switch t {
+		case notify.UserUpdated:
+			column = "updated_at"
+			q = q.Where("updated_at > created_at")
+		case notify.UserDeleted:
+			column = "deleted_at"
+			q = q.Where("deleted_at IS NOT NULL")
+		}
+		parts[i] = "(?)"
+		args[i] = q.Select("CAST(? AS text) AS type, id, email, name, company_code, "+column+" AS occurred_at", t)
+	}
+	union := s.db.Raw(strings.Join(parts, " UNION ALL "), args...)
+
+	q := s.db.WithContext(ctx).Table("(?) AS e", union)
+	if !s.from.IsZero() {
+		q = q.Where("occurred_at >= ?", s.from)
+	}
+	if !after.IsZero() {
+		id, typ, ok := strings.Cut(after.Key, "/")
+		if !ok {
+			return nil, fmt.Errorf("offset key %q is not <user id>/<type>", after.Key)
+		}
+		q = q.Where("(occurred_at, id, type) > (?, ?, ?)", after.At, id, typ)
+	}
+	return q, nil
+}
+
+func (s *userEventSource) Count(ctx context.Context, after events.Offset) (int, error) {
+	q, err := s.query(ctx, after)
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	if err := q.Count(&n).Error; err != nil {
+		return 0, err
+	}
+	return int(n), nil
+}
+
+func (s *userEventSource) Next(ctx context.Context, after events.Offset, limit int) ([]events.ReplayEvent, error) {
+	q, err := s.query(ctx, after)
+	if err != nil {
+		return nil, err
+	}
+	var rows []userEventRow
+	if err := q.Order("occurred_at, id, type").Limit(limit).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make([]events.ReplayEvent, len(rows))
+	for i, r := range rows {
+		data, err := json.Marshal(userEvent{ID: r.ID, Email: r.Email, Name: r.Name, CompanyCode: r.CompanyCode})
+		if err != nil {
+			return nil, err
+		}
+		at := r.OccurredAt.UTC()
+		out[i] = events.ReplayEvent{
+			Offset: events.Offset{At: at, Key: r.ID + "/" + r.Type},
+			Envelope: events.Envelope{
+				ID:         uuid.NewSHA1(uuid.NameSpaceURL, []byte("urn:veemon:"+r.Type+":"+r.ID+":"+at.Format(time.RFC3339Nano))).String(),
+				Type:       r.Type,
+				Source:     s.source,
+				OccurredAt: at,
+				Data:       data,
+			},
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,59 @@
+package transfer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"veemon/pkg/events"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserEvents_ReconstructsInOffsetOrder(t *testing.T) {
+	db, mock := mockDB(t)
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	src, err := UserEvents(db, UserEventsOptions{Types: []string{"user.registered", "user.deleted"}, From: from, Source: "veemon"})
+	require.NoError(t, err)
+
+	at := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	after := events.Offset{At: at, Key: id1 + "/user.registered"}
+	mock.ExpectQuery(`SELECT \* FROM \(\(SELECT CAST\(\$1 AS text\) AS type, id, email, name, company_code, created_at AS occurred_at FROM "users"\) `+
+		`UNION ALL \(SELECT CAST\(\$2 AS text\) AS type, id, email, name, company_code, deleted_at AS occurred_at FROM "users" WHERE deleted_at IS NOT NULL\)\) AS e `+
+		`WHERE occurred_at >= \$3 AND \(occurred_at, id, type\) > \(\$4, \$5, \$6\) ORDER BY occurred_at, id, type LIMIT \$7`).
+		WithArgs("user.registered", "user.deleted", from, at, id1, "user.registered", 2).
+		WillReturnRows(sqlmock.NewRows([]string{"type", "id", "email", "name", "company_code", "occurred_at"}).
+			AddRow("user.registered", id2, "joe@example.com", "Joe", "ACME", at).
+			AddRow("user.deleted", id1, "user-"+id1+"@anonymized.invalid", "User 0b6c1f2e", "", at.Add(time.Hour)))
+
+	batch, err := src.Next(context.Background(), after, 2)
+	require.NoError(t, err)
+	require.Len(t, batch, 2)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	first := batch[0]
+	assert.Equal(t, events.Offset{At: at, Key: id2 + "/user.registered"}, first.Offset)
+	assert.Equal(t, "user.registered", first.Envelope.Type)
+	assert.Equal(t, "veemon", first.Envelope.Source)
+	assert.JSONEq(t, `{"id":"`+id2+`","email":"joe@example.com","name":"Joe","companyCode":"ACME"}`, string(first.Envelope.Data))
+	assert.Equal(t, "user.deleted", batch[1].Envelope.Type)
+
+	// Envelope IDs are stable across replays and distinct across events.
+	again, err := UserEvents(db, UserEventsOptions{})
+	require.NoError(t, err)
+	mock.ExpectQuery(`SELECT \* FROM`).
+		WillReturnRows(sqlmock.NewRows([]string{"type", "id", "email", "name", "company_code", "occurred_at"}).
+			AddRow("user.registered", id2, "joe@example.com", "Joe", "ACME", at))
+	replayed, err := again.Next(context.Background(), events.Offset{}, 10)
+	require.NoError(t, err)
+	assert.Equal(t, first.Envelope.ID, replayed[0].Envelope.ID)
+	assert.NotEqual(t, first.Envelope.ID, batch[1].Envelope.ID)
+}
+
+func TestUserEvents_RejectsUnknownType(t *testing.T) {
+	db, _ := mockDB(t)
+	_, err := UserEvents(db, UserEventsOptions{Types: []string{"user.renamed"}})
+	assert.ErrorContains(t, err, `unknown event type "user.renamed"`)
+}
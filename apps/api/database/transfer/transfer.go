@@ -0,0 +1,411 @@
+// Package transfer exports users as JSON lines and imports them back, for
+// cloning a (usually anonymized) production dataset into another environment.
+//
+// Both directions stream: Export reads the table in batches and Import
+// upserts in batches, so neither holds the dataset in memory. They take an
+// io.Writer/io.Reader and report progress through a callback, so the same
+// functions back `migrate export-users`/`import-users` and any job runner.
+//
+// Only live (not soft-deleted) users are exported. This schema has no other
+// user-owned tables to carry along.
+//
+// UserEvents reads the same table as a stream of user events, for
+// `migrate replay-events`.
+package transfer
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"sort"
+	"time"
+
+	"veemon/entity"
+	"veemon/repository/user_repository"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultBatchSize is used when an Options BatchSize is zero.
+const DefaultBatchSize = 500
+
+// Record is one exported user: the public UserProfile fields plus the
+// password hash, which Anonymize drops.
+type Record struct {
+	ID           string    `json:"id"`
+	Email        string    `json:"email"`
+	Name         string    `json:"name"`
+	Phone        string    `json:"phone,omitempty"`
+	Status       string    `json:"status"`
+	Roles        []string  `json:"roles"`
+	CompanyCode  string    `json:"companyCode,omitempty"`
+	PasswordHash string    `json:"passwordHash,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+func recordFrom(u *entity.User) Record {
+	return Record{
+		ID:           u.ID,
+		Email:        u.Email,
+		Name:         u.Name,
+		Phone:        u.Phone,
+		Status:       string(u.Status),
+		Roles:        []string(u.Roles),
+		CompanyCode:  u.CompanyCode,
+		PasswordHash: u.Password,
+		CreatedAt:    u.CreatedAt,
+		UpdatedAt:    u.UpdatedAt,
+	}
+}
+
+// Anonymize replaces r's personal data following the same rules as the
+// account purge: an undeliverable per-user email, a placeholder name, and no
+// phone or password. The ID keeps the email unique and stable across
+// exports; status, roles and company are kept so the dataset stays realistic.
+func Anonymize(r *Record) {
+	r.Email = "user-" + r.ID + "@anonymized.invalid"
+	r.Name = "User " + r.ID[:min(8, len(r.ID))]
+	r.Phone = ""
+	r.PasswordHash = ""
+}
+
+// ExportOptions configures Export.
+type ExportOptions struct {
+	Anonymize bool
+	BatchSize int
+	// Progress, when set, receives the running total after each batch.
+	Progress func(exported int)
+}
+
+// Export writes every live user to w as one JSON object per line, ordered by
+// ID, and returns how many it wrote.
+func Export(ctx context.Context, db *gorm.DB, w io.Writer, opts ExportOptions) (int, error) {
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+
+	exported := 0
+	var users []entity.User
+	result := db.WithContext(ctx).Order("id").FindInBatches(&users, batchSize, func(_ *gorm.DB, _ int) error {
+		for i := range users {
+			r := recordFrom(&users[i])
+			if opts.Anonymize {
+				Anonymize(&r)
+			}
+			if err := enc.Encode(r); err != nil {
+				return fmt.Errorf("write user %s: %w", r.ID, err)
+			}
+		}
+		exported += len(users)
+		if opts.Progress != nil {
+			opts.Progress(exported)
+		}
+		return nil
+	})
+	if result.Error != nil {
+		return exported, result.Error
+	}
+	return exported, bw.Flush()
+}
+
+// ImportOptions configures Import.
+type ImportOptions struct {
+	// DryRun classifies every record without writing anything.
+	DryRun    bool
+	BatchSize int
+	// DefaultPassword, when set, is the password of accounts created from
+	// records without a hash (e.g. anonymized ones) so QA can log in as them.
+	// Otherwise such accounts get no usable password. Existing accounts keep
+	// theirs.
+	DefaultPassword string
+	// MaxUsersPerCompany, when positive, stops the import with a
+	// *entity.CompanyUserLimitError rather than let a batch take a company
+	// past that many live users, existing ones included.
+	MaxUsersPerCompany int
+	// Progress, when set, receives the running stats after each batch.
+	Progress func(ImportStats)
+}
+
+// LineError is a record Import skipped because it failed validation.
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e LineError) Error() string { return fmt.Sprintf("line %d: %v", e.Line, e.Err) }
+
+// ImportStats counts what Import did, or in a dry run what it would do.
+type ImportStats struct {
+	Created   int
+	Updated   int
+	Unchanged int
+	Invalid   []LineError
+}
+
+// updateColumns are overwritten when an imported email already exists. The
+// existing row keeps its ID so references to it stay valid.
+var updateColumns = []string{"name", "phone", "status", "roles", "company_code", "password", "updated_at"}
+
+type line struct {
+	no  int
+	rec Record
+}
+
+// Import reads JSON lines from r and upserts them by email in batches, one
+// transaction per batch. Invalid records are skipped and listed in the
+// stats; a malformed line or a database error stops the import.
+func Import(ctx context.Context, db *gorm.DB, r io.Reader, opts ImportOptions) (ImportStats, error) {
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	var defaultHash string
+	if opts.DefaultPassword != "" {
+		h, err := bcrypt.GenerateFromPassword([]byte(opts.DefaultPassword), bcrypt.DefaultCost)
+		if err != nil {
+			return ImportStats{}, fmt.Errorf("hash default password: %w", err)
+		}
+		defaultHash = string(h)
+	}
+
+	var stats ImportStats
+	batch := make([]line, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := importBatch(ctx, db, batch, defaultHash, opts, &stats); err != nil {
+			return err
+		}
+		batch = batch[:0]
+		if opts.Progress != nil {
+			opts.Progress(stats)
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for no := 1; scanner.Scan(); no++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return stats, LineError{Line: no, Err: err}
+		}
+		if err := rec.validate(); err != nil {
+			stats.Invalid = append(stats.Invalid, LineError{Line: no, Err: err})
+			continue
+		}
+		batch = append(batch, line{no: no, rec: rec})
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return stats, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return stats, err
+	}
+	return stats, flush()
+}
+
+var validStatuses = map[string]bool{
+	string(entity.UserStatusActive):   true,
+	string(entity.UserStatusInactive): true,
+	string(entity.UserStatusPending):  true,
+}
+
+// validate mirrors the column constraints and the checks registration
+// applies, so an import cannot create a user the API would reject. It
+// normalizes Roles in place.
+func (r *Record) validate() error {
+	if r.ID != "" {
+		if _, err := uuid.Parse(r.ID); err != nil {
+			return fmt.Errorf("id %q is not a UUID", r.ID)
+		}
+	}
+	if addr, err := mail.ParseAddress(r.Email); err != nil || addr.Address != r.Email || len(r.Email) > 255 {
+		return fmt.Errorf("invalid email %q", r.Email)
+	}
+	if r.Name == "" || len(r.Name) > 100 {
+		return errors.New("name must be 1-100 characters")
+	}
+	if len(r.Phone) > 20 {
+		return errors.New("phone must be at most 20 characters")
+	}
+	if len(r.CompanyCode) > 50 {
+		return errors.New("companyCode must be at most 50 characters")
+	}
+	if !validStatuses[r.Status] {
+		return fmt.Errorf("unknown status %q", r.Status)
+	}
+	roles, err := entity.ParseRoles(r.Roles)
+	if err != nil {
+		return err
+	}
+	if len(roles) == 0 {
+		return errors.New("roles must not be empty")
+	}
+	r.Roles = roles
+	return nil
+}
+
+func importBatch(ctx context.Context, db *gorm.DB, batch []line, defaultHash string, opts ImportOptions, stats *ImportStats) error {
+	emails := make([]string, len(batch))
+	for i, l := range batch {
+		emails[i] = l.rec.Email
+	}
+	var existing []entity.User
+	if err := db.WithContext(ctx).Where("email IN ?", emails).Find(&existing).Error; err != nil {
+		return fmt.Errorf("lines %d-%d: look up existing users: %w", batch[0].no, batch[len(batch)-1].no, err)
+	}
+	byEmail := make(map[string]*entity.User, len(existing))
+	companyOf := make(map[string]string, len(existing))
+	for i := range existing {
+		byEmail[existing[i].Email] = &existing[i]
+		companyOf[existing[i].Email] = existing[i].CompanyCode
+	}
+
+	// The file may repeat an email; the last occurrence wins, as it would
+	// with sequential upserts, and ON CONFLICT cannot touch a row twice.
+	pending := make(map[string]int, len(batch))
+	var upserts []entity.User
+	for _, l := range batch {
+		u := toEntity(l.rec)
+		cur, ok := byEmail[u.Email]
+		switch {
+		case !ok:
+			if u.Password == "" {
+				u.Password = defaultHash
+			}
+			stats.Created++
+		default:
+			// A record without a hash (e.g. anonymized) keeps the
+			// existing password rather than resetting it.
+			if u.Password == "" {
+				u.Password = cur.Password
+			}
+			if sameUser(cur, &u) {
+				stats.Unchanged++
+				continue
+			}
+			stats.Updated++
+		}
+		cp := u
+		byEmail[u.Email] = &cp
+		if i, dup := pending[u.Email]; dup {
+			upserts[i] = u
+			continue
+		}
+		pending[u.Email] = len(upserts)
+		upserts = append(upserts, u)
+	}
+	if len(upserts) == 0 {
+		return nil
+	}
+	gains := companyGains(upserts, companyOf)
+	if opts.DryRun {
+		return checkCompanyLimits(ctx, db, gains, opts.MaxUsersPerCompany, batch)
+	}
+
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkCompanyLimits(ctx, tx, gains, opts.MaxUsersPerCompany, batch); err != nil {
+			return err
+		}
+		err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "email"}},
+			// Matches the partial unique index (email WHERE deleted_at IS NULL).
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "deleted_at IS NULL"}}},
+			DoUpdates:   clause.AssignmentColumns(updateColumns),
+		}).Create(&upserts).Error
+		if err != nil {
+			return fmt.Errorf("lines %d-%d: upsert: %w", batch[0].no, batch[len(batch)-1].no, err)
+		}
+		return nil
+	})
+}
+
+// companyGains counts, per company, the users upserts add to it: new users,
+// and existing ones moving in from another company. Users moving out are not
+// subtracted, so the check errs on the side of refusing.
+func companyGains(upserts []entity.User, companyOf map[string]string) map[string]int {
+	gains := make(map[string]int)
+	for _, u := range upserts {
+		if u.CompanyCode == "" {
+			continue
+		}
+		if cur, ok := companyOf[u.Email]; ok && cur == u.CompanyCode {
+			continue
+		}
+		gains[u.CompanyCode]++
+	}
+	return gains
+}
+
+// checkCompanyLimits fails if any company would exceed limit after gains.
+// Inside a transaction it holds each company's lock until commit, taken in
+// sorted order so concurrent imports cannot deadlock.
+func checkCompanyLimits(ctx context.Context, db *gorm.DB, gains map[string]int, limit int, batch []line) error {
+	if limit <= 0 || len(gains) == 0 {
+		return nil
+	}
+	companies := make([]string, 0, len(gains))
+	for c := range gains {
+		companies = append(companies, c)
+	}
+	sort.Strings(companies)
+	repo := user_repository.New(db)
+	for _, c := range companies {
+		n, err := repo.LockCompanyUsers(ctx, c)
+		if err != nil {
+			return fmt.Errorf("lines %d-%d: count users of company %s: %w", batch[0].no, batch[len(batch)-1].no, c, err)
+		}
+		if n+int64(gains[c]) > int64(limit) {
+			return fmt.Errorf("lines %d-%d: %w", batch[0].no, batch[len(batch)-1].no,
+				&entity.CompanyUserLimitError{CompanyCode: c, Limit: limit})
+		}
+	}
+	return nil
+}
+
+func toEntity(r Record) entity.User {
+	return entity.User{
+		ID:          r.ID,
+		Email:       r.Email,
+		Password:    r.PasswordHash,
+		Name:        r.Name,
+		Phone:       r.Phone,
+		Status:      entity.UserStatus(r.Status),
+		Roles:       pq.StringArray(r.Roles),
+		CompanyCode: r.CompanyCode,
+		CreatedAt:   r.CreatedAt,
+		UpdatedAt:   r.UpdatedAt,
+	}
+}
+
+func sameUser(a, b *entity.User) bool {
+	if a.Name != b.Name || a.Phone != b.Phone || a.Status != b.Status ||
+		a.CompanyCode != b.CompanyCode || a.Password != b.Password || len(a.Roles) != len(b.Roles) {
+		return false
+	}
+	for i := range a.Roles {
+		if a.Roles[i] != b.Roles[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,184 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"veemon/entity"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func mockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{})
+	require.NoError(t, err)
+	return db, mock
+}
+
+var userColumns = []string{"id", "email", "password", "name", "phone", "status", "roles", "company_code", "created_at", "updated_at"}
+
+const (
+	id1 = "0b6c1f2e-8a57-4a55-9b0c-3f1d2e4a5b61"
+	id2 = "5d2e7c1a-4b3f-4e8d-a9c6-7f0e1d2c3b4a"
+)
+
+func TestExport_Anonymize(t *testing.T) {
+	db, mock := mockDB(t)
+	now := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"."deleted_at" IS NULL ORDER BY id`).
+		WillReturnRows(sqlmock.NewRows(userColumns).
+			AddRow(id1, "jane@example.com", "$2a$hash", "Jane Doe", "0812", "active", "{admin}", "ACME", now, now).
+			AddRow(id2, "joe@example.com", "$2a$hash", "Joe", "", "inactive", "{user}", "", now, now))
+
+	var out bytes.Buffer
+	var progress []int
+	n, err := Export(context.Background(), db, &out, ExportOptions{Anonymize: true, Progress: func(n int) { progress = append(progress, n) }})
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []int{2}, progress)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var rec Record
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &rec))
+	assert.Equal(t, "user-"+id1+"@anonymized.invalid", rec.Email)
+	assert.Equal(t, "User 0b6c1f2e", rec.Name)
+	assert.Empty(t, rec.Phone)
+	assert.Empty(t, rec.PasswordHash)
+	assert.Equal(t, []string{"admin"}, rec.Roles, "non-personal fields are kept")
+	assert.Equal(t, "ACME", rec.CompanyCode)
+	assert.NotContains(t, out.String(), "jane@example.com")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecordValidate(t *testing.T) {
+	valid := Record{Email: "a@example.com", Name: "A", Status: "active", Roles: []string{"user"}}
+	require.NoError(t, valid.validate())
+
+	cases := map[string]func(*Record){
+		"bad id":         func(r *Record) { r.ID = "42" },
+		"bad email":      func(r *Record) { r.Email = "not-an-email" },
+		"display email":  func(r *Record) { r.Email = "A <a@example.com>" },
+		"empty name":     func(r *Record) { r.Name = "" },
+		"long phone":     func(r *Record) { r.Phone = strings.Repeat("1", 21) },
+		"unknown status": func(r *Record) { r.Status = "banned" },
+		"no roles":       func(r *Record) { r.Roles = nil },
+	}
+	for name, mutate := range cases {
+		r := valid
+		mutate(&r)
+		assert.Error(t, r.validate(), name)
+	}
+}
+
+func jsonl(t *testing.T, recs ...any) string {
+	t.Helper()
+	var b strings.Builder
+	for _, r := range recs {
+		if s, ok := r.(string); ok {
+			b.WriteString(s + "\n")
+			continue
+		}
+		line, err := json.Marshal(r)
+		require.NoError(t, err)
+		b.Write(append(line, '\n'))
+	}
+	return b.String()
+}
+
+func TestImport_DryRunReportsWithoutWriting(t *testing.T) {
+	db, mock := mockDB(t)
+	now := time.Now()
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE email IN \(\$1,\$2,\$3\)`).
+		WillReturnRows(sqlmock.NewRows(userColumns).
+			AddRow(id1, "same@example.com", "h", "Same", "", "active", "{user}", "", now, now).
+			AddRow(id2, "changed@example.com", "h", "Old name", "", "active", "{user}", "", now, now))
+
+	in := jsonl(t,
+		Record{Email: "same@example.com", Name: "Same", Status: "active", Roles: []string{"user"}},
+		Record{Email: "changed@example.com", Name: "New name", Status: "active", Roles: []string{"user"}},
+		"",
+		Record{Email: "bad", Name: "Bad", Status: "active", Roles: []string{"user"}},
+		Record{Email: "new@example.com", Name: "New", Status: "active", Roles: []string{"user"}},
+	)
+	stats, err := Import(context.Background(), db, strings.NewReader(in), ImportOptions{DryRun: true})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Created)
+	assert.Equal(t, 1, stats.Updated)
+	assert.Equal(t, 1, stats.Unchanged, "a record without a hash keeps the existing password")
+	require.Len(t, stats.Invalid, 1)
+	assert.Equal(t, 4, stats.Invalid[0].Line)
+	assert.NoError(t, mock.ExpectationsWereMet(), "a dry run only reads")
+}
+
+func TestImport_UpsertsByEmailPerBatch(t *testing.T) {
+	db, mock := mockDB(t)
+	in := jsonl(t,
+		Record{ID: id1, Email: "a@example.com", Name: "A", Status: "active", Roles: []string{"user"}, PasswordHash: "h"},
+		Record{ID: id2, Email: "b@example.com", Name: "B", Status: "active", Roles: []string{"user"}, PasswordHash: "h"},
+	)
+	for _, email := range []string{"a@example.com", "b@example.com"} {
+		mock.ExpectQuery(`SELECT \* FROM "users" WHERE email IN \(\$1\)`).WithArgs(email).
+			WillReturnRows(sqlmock.NewRows(userColumns))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "users" .* ON CONFLICT \("email"\)\s+WHERE deleted_at IS NULL DO UPDATE SET "name"="excluded"."name"`).
+			WillReturnRows(sqlmock.NewRows([]string{"roles"}).AddRow("{user}"))
+		mock.ExpectCommit()
+	}
+
+	var progress []ImportStats
+	stats, err := Import(context.Background(), db, strings.NewReader(in), ImportOptions{
+		BatchSize: 1,
+		Progress:  func(s ImportStats) { progress = append(progress, s) },
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Created)
+	assert.Len(t, progress, 2)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestImport_MalformedLineStops(t *testing.T) {
+	db, _ := mockDB(t)
+	_, err := Import(context.Background(), db, strings.NewReader("{not json\n"), ImportOptions{DryRun: true})
+	var lineErr LineError
+	require.ErrorAs(t, err, &lineErr)
+	assert.Equal(t, 1, lineErr.Line)
+}
+
+func TestImport_StopsAtCompanyUserLimit(t *testing.T) {
+	db, mock := mockDB(t)
+	in := jsonl(t,
+		Record{Email: "a@example.com", Name: "A", Status: "active", Roles: []string{"user"}, CompanyCode: "C"},
+		Record{Email: "b@example.com", Name: "B", Status: "active", Roles: []string{"user"}, CompanyCode: "C"},
+	)
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE email IN \(\$1,\$2\)`).
+		WillReturnRows(sqlmock.NewRows(userColumns))
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock\(hashtext\(\$1\)\)`).WithArgs("company_users:C").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "users" WHERE company_code = \$1`).WithArgs("C").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+	mock.ExpectRollback()
+
+	_, err := Import(context.Background(), db, strings.NewReader(in), ImportOptions{MaxUsersPerCompany: 5})
+
+	var limitErr *entity.CompanyUserLimitError
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, "C", limitErr.CompanyCode)
+	assert.NoError(t, mock.ExpectationsWereMet(), "nothing is written past the limit")
+}
@@ -0,0 +1,168 @@
+package docs
+
+// adminModule documents the runtime configuration and audit log endpoints.
+func adminModule(d *Document) {
+	d.AddPath("/api/v1/admin/runtime-config", &PathItem{
+		Get: &Operation{
+			Tags:        []string{"Admin"},
+			Summary:     "List runtime config",
+			Description: "Lists every runtime-tunable key with its type, current value on this replica, default and description.\n\n**Access**: requires the `runtime_config.read` permission (`superadmin` by default).\n\n**Keys**: `ratelimit.global.max` (int) and `ratelimit.global.window` (duration) — the global per-IP rate limit.",
+			OperationID: "getRuntimeConfig",
+			Security:    BearerAuth,
+			Responses: map[string]*Response{
+				"200": {
+					Description: "Runtime config keys",
+					Content:     JSON(Ref("RuntimeConfigResponse")),
+				},
+				"401": {Description: "Not authenticated"},
+				"403": {Description: "Forbidden — requires the `runtime_config.read` permission"},
+			},
+		},
+		Put: &Operation{
+			Tags:        []string{"Admin"},
+			Summary:     "Update runtime config",
+			Description: "Sets and resets runtime config keys. The change is stored in Redis and reaches every replica within about a second, without a restart; it survives restarts until reset.\n\n**Validation**: every key and value is checked before anything is written, so a request with one bad entry changes nothing.\n\n**Access**: requires the `runtime_config.write` permission (`superadmin` by default), and cannot be called with an impersonation token.\n\n**Audit**: each changed key is logged with the actor and its old and new value.",
+			OperationID: "updateRuntimeConfig",
+			Security:    BearerAuth,
+			RequestBody: &RequestBody{
+				Required: true,
+				Content:  JSON(Ref("UpdateRuntimeConfigRequest")),
+			},
+			Responses: map[string]*Response{
+				"200": {
+					Description: "Runtime config after the change",
+					Content:     JSON(Ref("RuntimeConfigResponse")),
+				},
+				"400": {Description: "Unknown key (code `40007`), or an empty request or invalid value (code `40008`)"},
+				"401": {Description: "Not authenticated"},
+				"403": {Description: "Forbidden — requires the `runtime_config.write` permission, or the caller is impersonating"},
+				"503": {Description: "Redis is unreachable or not configured (code `50301`); current values stay in effect"},
+			},
+		},
+	})
+	d.AddPath("/api/v1/audit-logs", &PathItem{
+		Get: &Operation{
+			Tags:        []string{"Admin"},
+			Summary:     "List audit log entries",
+			Description: "Pages through the record of administrative changes, newest first. Creating, updating, deleting, restoring and purging a user each write an entry in the same transaction as the change, so a change is never committed without one.\n\n**Entries** carry the actor (the superadmin behind an impersonation token), the action, the resource, its JSON before and after the change, and the request ID from `X-Request-ID`.\n\n**Access**: requires the `audit_logs.read` permission (`superadmin` by default).",
+			OperationID: "listAuditLogs",
+			Security:    BearerAuth,
+			Parameters: []*Parameter{
+				{
+					Name:        "page",
+					In:          "query",
+					Description: "Page number (1-indexed). Defaults to 1.",
+					Schema:      &Schema{Type: "integer", Default: 1, Minimum: Number(1)},
+				},
+				{
+					Name:        "size",
+					In:          "query",
+					Description: "Entries per page, 1 to 100. Defaults to 20.",
+					Schema:      &Schema{Type: "integer", Default: 20, Minimum: Number(1), Maximum: Number(100)},
+				},
+				{
+					Name:        "actorId",
+					In:          "query",
+					Description: "Only changes made by this user.",
+					Schema:      &Schema{Type: "string", MaxLength: 64},
+				},
+				{
+					Name:        "resourceType",
+					In:          "query",
+					Description: "Only changes to this kind of resource.",
+					Schema:      &Schema{Type: "string", MaxLength: 50, Example: "user"},
+				},
+				{
+					Name:        "resourceId",
+					In:          "query",
+					Description: "Only changes to this resource.",
+					Schema:      &Schema{Type: "string", MaxLength: 64},
+				},
+				{
+					Name:        "from",
+					In:          "query",
+					Description: "Only entries created at or after this RFC 3339 time.",
+					Schema:      &Schema{Type: "string", Format: "date-time", Example: "2026-01-01T00:00:00Z"},
+				},
+				{
+					Name:        "to",
+					In:          "query",
+					Description: "Only entries created before this RFC 3339 time; must be after `from`.",
+					Schema:      &Schema{Type: "string", Format: "date-time", Example: "2026-02-01T00:00:00Z"},
+				},
+			},
+			Responses: map[string]*Response{
+				"200": {
+					Description: "One page of entries with pagination metadata",
+					Content:     JSON(Ref("ListAuditLogsResponse")),
+				},
+				"400": {Description: "Validation error — an out-of-range `page` or `size`, a malformed `from` or `to`, or `to` not after `from`"},
+				"401": {Description: "Not authenticated"},
+				"403": {Description: "Forbidden — requires the `audit_logs.read` permission"},
+			},
+		},
+	})
+	d.AddSchema("AuditLogEntry", &Schema{
+		Type:        "object",
+		Description: "One administrative change",
+		Properties: map[string]*Schema{
+			"id":           {Type: "string", Format: "uuid"},
+			"actorId":      {Type: "string", Description: "The caller, or the superadmin behind an impersonation token; empty for changes made without one"},
+			"action":       {Type: "string", Enum: []string{"create", "update", "delete", "restore", "purge", "impersonate"}, Example: "update"},
+			"resourceType": {Type: "string", Example: "user"},
+			"resourceId":   {Type: "string", Example: "550e8400-e29b-41d4-a716-446655440000"},
+			"before":       {Type: "object", Nullable: true, Description: "The resource before the change; null for a creation"},
+			"after":        {Type: "object", Nullable: true, Description: "The resource after the change; null for a deletion"},
+			"requestId":    {Type: "string", Description: "`X-Request-ID` of the request that made the change"},
+			"createdAt":    {Type: "string", Format: "date-time", Example: "2026-03-01T09:30:00Z"},
+		},
+	})
+	d.AddSchema("ListAuditLogsResponse", &Schema{
+		Type:        "object",
+		Description: "One page of audit log entries, newest first",
+		Properties: map[string]*Schema{
+			"success": {Type: "boolean", Example: true},
+			"data": {
+				Type:  "array",
+				Items: Ref("AuditLogEntry"),
+			},
+			"meta": Ref("Pagination"),
+		},
+	})
+	d.AddSchema("RuntimeConfigEntry", &Schema{
+		Type:        "object",
+		Description: "A runtime-tunable key",
+		Properties: map[string]*Schema{
+			"key":          {Type: "string", Example: "ratelimit.global.max"},
+			"type":         {Type: "string", Enum: []string{"int", "bool", "duration", "string"}, Description: "How values are written; durations use Go syntax such as `90s` or `5m`", Example: "int"},
+			"value":        {Type: "string", Description: "Value in effect on this replica", Example: "200"},
+			"defaultValue": {Type: "string", Example: "100"},
+			"overridden":   {Type: "boolean", Description: "Whether the value was set through this API rather than being the default", Example: true},
+			"description":  {Type: "string", Example: "Requests per client IP per window across the whole API, before the per-route limits"},
+		},
+	})
+	d.AddSchema("RuntimeConfigResponse", &Schema{
+		Type:        "object",
+		Description: "Every runtime-tunable key",
+		Properties: map[string]*Schema{
+			"success": {Type: "boolean", Example: true},
+			"data": {
+				Type: "object",
+				Properties: map[string]*Schema{
+					"entries": {
+						Type:  "array",
+						Items: Ref("RuntimeConfigEntry"),
+					},
+				},
+			},
+		},
+	})
+	d.AddSchema("UpdateRuntimeConfigRequest", &Schema{
+		Type:        "object",
+		Description: "Keys to set and keys to reset; at least one of the two must be non-empty",
+		Properties: map[string]*Schema{
+			"values": {Type: "object", AdditionalProperties: &Schema{Type: "string"}, Description: "New values by key, each written as a string of the key's type", Example: map[string]string{"ratelimit.global.max": "200", "ratelimit.global.window": "30s"}},
+			"reset":  {Type: "array", Items: &Schema{Type: "string"}, Description: "Keys to revert to their defaults", Example: []string{}},
+		},
+	})
+}
@@ -0,0 +1,452 @@
+package docs
+
+import pb "veemon/handler/grpc/user"
+
+// authModule documents the /api/v1/auth endpoints. Request bodies are
+// derived from the DTOs their handlers validate.
+func authModule(d *Document) {
+	d.AddPath("/api/v1/auth/register", &PathItem{
+		Post: &Operation{
+			Tags:        []string{"Auth"},
+			Summary:     "Register a new user account",
+			Description: "Creates a new user account with the provided email, password, and name. The email must be unique across all accounts. After successful registration, the user receives a confirmation with their generated UUID. The account starts in `pending` status and the user should proceed to the **Login** endpoint to obtain an access token.\n\n**Password requirements**: minimum 8 characters, maximum 72 (bcrypt's limit), with an upper-case letter, a lower-case letter and a digit.\n\n**Duplicate email**: returns `409 Conflict` if the email is already registered.\n\n**Deleted accounts**: when the email belongs to a soft-deleted account the outcome depends on `REGISTER_DELETED_EMAIL` — a new account is created (`new`), the old account is restored under its original id with `pending` status, the new password, name and phone, and the default `user` role, keeping none of its old roles (`reactivate`), or the request fails with `409` and code `40902` (`block`).\n\n**Retries**: send an `Idempotency-Key` header (e.g. a UUID) to retry safely. A repeat of the same request with the same key gets the first response again, marked `Idempotent-Replayed: true`, for `IDEMPOTENCY_TTL`; the account is created once.",
+			OperationID: "register",
+			Parameters: []*Parameter{
+				{
+					Name:        "Idempotency-Key",
+					In:          "header",
+					Description: "Client-chosen key, at most 255 characters, that makes retries of this request return its first response instead of running again. Reusing it for a different body fails with `422` (code `42201`); a repeat sent while the first is still running waits briefly, then fails with `409` (code `40905`). Ignored when the server has no Redis.",
+					Schema:      &Schema{Type: "string", MaxLength: 255, Example: "4f0c1b8e-6f1e-4d8a-9a57-2b8f3f1c9d21"},
+				},
+			},
+			RequestBody: &RequestBody{
+				Required:    true,
+				Description: "User registration payload with email, password, display name, and optional phone number",
+				Content:     JSON(Ref("RegisterRequest")),
+			},
+			Responses: map[string]*Response{
+				"415": {Ref: "#/components/responses/UnsupportedMediaType"},
+				"201": {
+					Description: "Account created successfully — returns the new user's ID, email, and name",
+					Content:     JSON(Ref("RegisterResponse")),
+				},
+				"400": {
+					Description: "Validation error — missing required fields, invalid email format, password too short, or fields the request does not define (listed in `error.details`)",
+					Content:     JSON(Ref("ErrorResponse")),
+				},
+				"409": {
+					Description: "Conflict — a user with this email address already exists (`40901`), or it belongs to a deleted account that may not be re-registered (`40902`), or the account's company already has `COMPANY_MAX_USERS` users (`40904`), or a request with the same `Idempotency-Key` is still running (`40905`)",
+					Content:     JSON(Ref("ErrorResponse")),
+				},
+				"422": {
+					Description: "The `Idempotency-Key` was already used for a different request (code `42201`)",
+					Content:     JSON(Ref("ErrorResponse")),
+				},
+			},
+		},
+	})
+	d.AddPath("/api/v1/auth/login", &PathItem{
+		Post: &Operation{
+			Tags:        []string{"Auth"},
+			Summary:     "Authenticate and obtain access token",
+			Description: "Authenticates a user with email and password credentials. On success, returns a PASETO v4 access token (symmetric encryption) along with the user's profile information. The token should be included in subsequent requests via the `Authorization: Bearer <token>` header.\n\n**Token format**: `v4.local.xxxxx...` (PASETO v4 local/symmetric)\n\n**Token expiration**: configurable via `JWT_EXPIRATION` environment variable (default: 24 hours)\n\n**Invalid credentials**: returns `401 Unauthorized` with a generic error message (does not reveal whether the email exists).",
+			OperationID: "login",
+			RequestBody: &RequestBody{
+				Required:    true,
+				Description: "Login credentials — email address and password",
+				Content:     JSON(Ref("LoginRequest")),
+			},
+			Responses: map[string]*Response{
+				"415": {Ref: "#/components/responses/UnsupportedMediaType"},
+				"400": {
+					Description: "Validation error — missing or malformed fields, or fields the request does not define (listed in `error.details`)",
+					Content:     JSON(Ref("ErrorResponse")),
+				},
+				"200": {
+					Description: "Authentication successful — returns PASETO access token and user profile",
+					Content:     JSON(Ref("LoginResponse")),
+				},
+				"401": {
+					Description: "Authentication failed — invalid email or password",
+					Content:     JSON(Ref("ErrorResponse")),
+				},
+				"403": {
+					Description: "Account is not active, or (code `40301`) its owner scheduled it for deletion — `POST /api/v1/auth/reactivate` cancels that during the grace period",
+					Content:     JSON(Ref("ErrorResponse")),
+				},
+			},
+		},
+	})
+	d.AddPath("/api/v1/auth/reactivate", &PathItem{
+		Post: &Operation{
+			Tags:        []string{"Auth"},
+			Summary:     "Cancel a pending account deletion",
+			Description: "Cancels a self-service deletion (see `DELETE /api/v1/auth/me`) while its grace period is running, by confirming the account's email and password. The account becomes `active` again and the response is the same as a login.\n\n**Lockout**: failed attempts count towards the same per-account lockout as login.",
+			OperationID: "reactivateAccount",
+			RequestBody: &RequestBody{
+				Required:    true,
+				Description: "The account's credentials",
+				Content:     JSON(Ref("LoginRequest")),
+			},
+			Responses: map[string]*Response{
+				"415": {Ref: "#/components/responses/UnsupportedMediaType"},
+				"200": {
+					Description: "Deletion cancelled — returns PASETO access token and user profile",
+					Content:     JSON(Ref("LoginResponse")),
+				},
+				"401": {
+					Description: "Invalid email or password",
+					Content:     JSON(Ref("ErrorResponse")),
+				},
+				"409": {
+					Description: "No pending deletion, or its grace period is over (code `40903`)",
+					Content:     JSON(Ref("ErrorResponse")),
+				},
+				"429": {Description: "Too many failed attempts — the account is temporarily locked"},
+			},
+		},
+	})
+	d.AddPath("/api/v1/auth/refresh", &PathItem{
+		Post: &Operation{
+			Tags:        []string{"Auth"},
+			Summary:     "Refresh access token",
+			Description: "Issues a new PASETO access token using the current valid token. Use this endpoint to extend the user's session without requiring re-authentication. The old token remains valid until its original expiration time (stateless — no token rotation).\n\n**When to use**: call this before the current token expires to maintain an active session.\n\n**Requires**: valid, non-expired PASETO token in the `Authorization` header.",
+			OperationID: "refreshToken",
+			Security:    BearerAuth,
+			Responses: map[string]*Response{
+				"200": {
+					Description: "New access token issued successfully",
+					Content:     JSON(Ref("RefreshTokenResponse")),
+				},
+				"401": {
+					Description: "Token is invalid, expired, or missing",
+					Content:     JSON(Ref("ErrorResponse")),
+				},
+				"403": {Description: "Account is not active, or the token is an impersonation token (those cannot be refreshed)"},
+			},
+		},
+	})
+	d.AddPath("/api/v1/auth/me", &PathItem{
+		Get: &Operation{
+			Tags:        []string{"Auth"},
+			Summary:     "Get current user profile",
+			Description: "Returns the full profile of the currently authenticated user, including their ID, email, name, phone, status, and account creation timestamp. This endpoint extracts the user identity from the PASETO token and fetches the latest profile data from the database.\n\n**Use case**: display the logged-in user's profile in the UI, verify token claims against the database, or retrieve the latest user status.\n\n**Polling**: send the `ETag` of the last response in `If-None-Match`; while the profile is unchanged the answer is `304 Not Modified` with no body.",
+			OperationID: "getMe",
+			Security:    BearerAuth,
+			Parameters: []*Parameter{
+				{Ref: "#/components/parameters/IfNoneMatch"},
+			},
+			Responses: map[string]*Response{
+				"200": {
+					Description: "Current user's profile data retrieved successfully",
+					Headers: map[string]*Header{
+						"ETag": {Ref: "#/components/headers/UserETag"},
+					},
+					Content: JSON(Ref("UserProfileResponse")),
+				},
+				"304": {Ref: "#/components/responses/NotModified"},
+				"401": {
+					Description: "Not authenticated — token is invalid, expired, or missing",
+					Content:     JSON(Ref("ErrorResponse")),
+				},
+			},
+		},
+		Delete: &Operation{
+			Tags:        []string{"Auth"},
+			Summary:     "Delete own account",
+			Description: "Schedules deletion of the caller's account. The account is set to `inactive` and every token issued to it is revoked at once; after the grace period (`ACCOUNT_DELETION_GRACE_DAYS`, default 14 days) it is anonymized and soft-deleted. Until then `POST /api/v1/auth/reactivate` cancels the deletion. Repeating the request keeps the original schedule. An account that is not `active` gets `403`.\n\n**Impersonation**: not allowed with an impersonation token (`403`).",
+			OperationID: "deleteMe",
+			Security:    BearerAuth,
+			Responses: map[string]*Response{
+				"200": {
+					Description: "Deletion scheduled",
+					Content:     JSON(Ref("DeleteMeResponse")),
+				},
+				"401": {
+					Description: "Not authenticated — token is invalid, expired, or missing",
+					Content:     JSON(Ref("ErrorResponse")),
+				},
+				"403": {Description: "Called with an impersonation token"},
+			},
+		},
+	})
+	d.AddPath("/api/v1/auth/change-password", &PathItem{
+		Post: &Operation{
+			Tags:        []string{"Auth"},
+			Summary:     "Change password",
+			Description: "Replaces the caller's password after confirming the current one. The new password must be 8–72 characters with an upper-case letter, a lower-case letter and a digit. Other sessions stay signed in.\n\n**Lockout**: a wrong current password (`400`, code `40009`) counts towards the same per-account lockout as login.\n\n**Impersonation**: not allowed with an impersonation token (`403`).",
+			OperationID: "changePassword",
+			Security:    BearerAuth,
+			RequestBody: &RequestBody{
+				Required: true,
+				Content:  JSON(Ref("ChangePasswordRequest")),
+			},
+			Responses: map[string]*Response{
+				"415": {Ref: "#/components/responses/UnsupportedMediaType"},
+				"200": {
+					Description: "Password changed",
+					Content:     JSON(Ref("MessageResponse")),
+				},
+				"400": {
+					Description: "The new password fails validation, or the current password is incorrect (code `40009`)",
+					Content:     JSON(Ref("ErrorResponse")),
+				},
+				"401": {
+					Description: "Not authenticated — token is invalid or missing",
+					Content:     JSON(Ref("ErrorResponse")),
+				},
+				"429": {Description: "Too many failed attempts — the account is temporarily locked"},
+			},
+		},
+	})
+	d.AddPath("/api/v1/auth/forgot-password", &PathItem{
+		Post: &Operation{
+			Tags:        []string{"Auth"},
+			Summary:     "Request a password reset",
+			Description: "Publishes a `user.password_reset_requested` event carrying a single-use reset token for the active account with this email; the worker mails it. The token expires after `PASSWORD_RESET_TTL` (default 30 minutes) and is redeemed with `POST /api/v1/auth/reset-password`.\n\n**No enumeration**: the response is the same whether or not the email belongs to an account.\n\n**Availability**: needs Redis and RabbitMQ; without them the endpoint answers `503` (code `50302`).",
+			OperationID: "forgotPassword",
+			RequestBody: &RequestBody{
+				Required: true,
+				Content:  JSON(Ref("ForgotPasswordRequest")),
+			},
+			Responses: map[string]*Response{
+				"415": {Ref: "#/components/responses/UnsupportedMediaType"},
+				"200": {
+					Description: "Request accepted",
+					Content:     JSON(Ref("MessageResponse")),
+				},
+				"400": {
+					Description: "The email is missing or malformed",
+					Content:     JSON(Ref("ErrorResponse")),
+				},
+				"429": {Description: "Rate limit exceeded"},
+				"503": {Description: "Password reset is not configured (code `50302`)"},
+			},
+		},
+	})
+	d.AddPath("/api/v1/auth/reset-password", &PathItem{
+		Post: &Operation{
+			Tags:        []string{"Auth"},
+			Summary:     "Reset password with a token",
+			Description: "Redeems a token from `POST /api/v1/auth/forgot-password` and sets the account's new password, under the same rules as registration. A token works once, even if the request then fails.\n\n**Sessions**: every token issued to the account is revoked, and its login lockout is cleared.",
+			OperationID: "resetPassword",
+			RequestBody: &RequestBody{
+				Required: true,
+				Content:  JSON(Ref("ResetPasswordRequest")),
+			},
+			Responses: map[string]*Response{
+				"415": {Ref: "#/components/responses/UnsupportedMediaType"},
+				"200": {
+					Description: "Password reset — sign in with the new password",
+					Content:     JSON(Ref("MessageResponse")),
+				},
+				"400": {
+					Description: "The new password fails validation, or the token is invalid, expired or already used (code `40010`)",
+					Content:     JSON(Ref("ErrorResponse")),
+				},
+				"429": {Description: "Rate limit exceeded"},
+				"503": {Description: "Password reset is not configured (code `50302`)"},
+			},
+		},
+	})
+	d.AddPath("/api/v1/auth/logout", &PathItem{
+		Post: &Operation{
+			Tags:        []string{"Auth"},
+			Summary:     "Logout current session",
+			Description: "Terminates the current user session. Since PASETO tokens are stateless, this endpoint returns a success response to signal the client to discard the token. The token itself remains technically valid until its expiration time.\n\n**Client responsibility**: remove the stored token from local storage, cookies, or memory upon receiving the success response.\n\n**Note**: for server-side token revocation, consider implementing a Redis-backed token blacklist.",
+			OperationID: "logout",
+			Security:    BearerAuth,
+			Responses: map[string]*Response{
+				"200": {
+					Description: "Logout acknowledged — client should discard the token",
+					Content:     JSON(Ref("LogoutResponse")),
+				},
+				"401": {
+					Description: "Not authenticated — token is invalid or missing",
+					Content:     JSON(Ref("ErrorResponse")),
+				},
+			},
+		},
+	})
+	d.AddPath("/api/v1/auth/introspect-batch", &PathItem{
+		Post: &Operation{
+			Tags:        []string{"Auth"},
+			Summary:     "Validate a batch of tokens",
+			Description: "Validates up to 100 access tokens in one call, for the API gateway that fans one client request out into many subrequests. Each result reports whether the token is `active` and, if so, its claims; otherwise `reason` is `invalid`, `expired` or `revoked`. Results are returned in request order, one per token.\n\n**Access**: requires the `tokens.introspect` permission, which the built-in policy grants to the `service` role (a machine identity, not an end-user token) and to `superadmin`.\n\n**Partial failure**: a bad token only marks its own result inactive; the call still returns `200`.\n\n**Efficiency**: identical tokens are validated once and revocation is checked for the whole batch in a single Redis round trip.",
+			OperationID: "introspectBatch",
+			Security:    BearerAuth,
+			RequestBody: &RequestBody{
+				Required: true,
+				Content:  JSON(Ref("IntrospectBatchRequest")),
+			},
+			Responses: map[string]*Response{
+				"200": {
+					Description: "Per-token results, in request order",
+					Content:     JSON(Ref("IntrospectBatchResponse")),
+				},
+				"400": {
+					Description: "Bad Request — `tokens` is empty or has more than 100 entries (error code `400`)",
+					Content:     JSON(Ref("ErrorResponse")),
+				},
+				"401": {Description: "Not authenticated — the caller's own token is invalid, expired, or missing"},
+				"403": {Description: "Forbidden — requires the `tokens.introspect` permission (the `service` role)"},
+				"415": {Ref: "#/components/responses/UnsupportedMediaType"},
+			},
+		},
+	})
+	d.AddSchema("RegisterRequest", DTOSchema(pb.RegisterRequest{}, &Schema{
+		Description: "Payload for creating a new user account",
+		Properties: map[string]*Schema{
+			"email":    {Description: "Unique email address — used for login", Example: "john.doe@example.com"},
+			"password": {Description: "Account password (hashed with bcrypt before storage)", Example: "SecureP@ss123"},
+			"name":     {Description: "Display name shown in the user profile", Example: "John Doe"},
+			"phone":    {Description: "Optional phone number for contact purposes", Example: "+62812345678"},
+		},
+	}))
+	d.AddSchema("RegisterResponse", &Schema{
+		Type:        "object",
+		Description: "Successful registration response containing the new user's identifiers",
+		Properties: map[string]*Schema{
+			"success": {Type: "boolean", Example: true},
+			"data": {
+				Type: "object",
+				Properties: map[string]*Schema{
+					"id":    {Type: "string", Format: "uuid", Description: "Auto-generated UUID v4 identifier", Example: "550e8400-e29b-41d4-a716-446655440000"},
+					"email": {Type: "string", Format: "email", Description: "Registered email address"},
+					"name":  {Type: "string", Description: "Display name"},
+				},
+			},
+		},
+	})
+	d.AddSchema("LoginRequest", DTOSchema(pb.LoginRequest{}, &Schema{
+		Description: "Login credentials for authentication",
+		Properties: map[string]*Schema{
+			"email":    {Description: "Registered email address", Example: "john.doe@example.com"},
+			"password": {Description: "Account password", Example: "SecureP@ss123"},
+		},
+	}))
+	d.AddSchema("LoginResponse", &Schema{
+		Type:        "object",
+		Description: "Successful authentication response with PASETO access token and user profile",
+		Properties: map[string]*Schema{
+			"success": {Type: "boolean", Example: true},
+			"data": {
+				Type: "object",
+				Properties: map[string]*Schema{
+					"token": {Type: "string", Description: "PASETO v4 access token — include in `Authorization: Bearer <token>` header", Example: "v4.local.xxxxxxxxxxxxxxxxxxxxx"},
+					"user":  Ref("UserProfile"),
+				},
+			},
+		},
+	})
+	d.AddSchema("RefreshTokenResponse", &Schema{
+		Type:        "object",
+		Description: "New access token issued from a valid existing token",
+		Properties: map[string]*Schema{
+			"success": {Type: "boolean", Example: true},
+			"data": {
+				Type: "object",
+				Properties: map[string]*Schema{
+					"token": {Type: "string", Description: "New PASETO v4 access token with refreshed expiration", Example: "v4.local.yyyyyyyyyyyyyyyyyyyyy"},
+				},
+			},
+		},
+	})
+	d.AddSchema("ChangePasswordRequest", DTOSchema(pb.ChangePasswordRequest{}, &Schema{
+		Properties: map[string]*Schema{
+			"currentPassword": {Description: "The account's current password", Example: "SecureP@ss123"},
+			"newPassword":     {Description: "Must contain an upper-case letter, a lower-case letter and a digit", Example: "N3wSecureP@ss"},
+		},
+	}))
+	d.AddSchema("ForgotPasswordRequest", DTOSchema(pb.ForgotPasswordRequest{}, &Schema{
+		Properties: map[string]*Schema{
+			"email": {Example: "john.doe@example.com"},
+		},
+	}))
+	d.AddSchema("ResetPasswordRequest", DTOSchema(pb.ResetPasswordRequest{}, &Schema{
+		Properties: map[string]*Schema{
+			"token":       {Description: "The token from the password reset email"},
+			"newPassword": {Description: "Must contain an upper-case letter, a lower-case letter and a digit", Example: "N3wSecureP@ss"},
+		},
+	}))
+	d.AddSchema("MessageResponse", &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"success": {Type: "boolean", Example: true},
+			"data": {
+				Type: "object",
+				Properties: map[string]*Schema{
+					"message": {Type: "string"},
+				},
+			},
+		},
+	})
+	d.AddSchema("LogoutResponse", &Schema{
+		Type:        "object",
+		Description: "Logout acknowledgment — client should discard the stored token",
+		Properties: map[string]*Schema{
+			"success": {Type: "boolean", Example: true},
+			"data": {
+				Type: "object",
+				Properties: map[string]*Schema{
+					"message": {Type: "string", Example: "successfully logged out", Description: "Confirmation message"},
+				},
+			},
+		},
+	})
+	d.AddSchema("DeleteMeResponse", &Schema{
+		Type:        "object",
+		Description: "Confirmation that the account is scheduled for deletion",
+		Properties: map[string]*Schema{
+			"success": {Type: "boolean", Example: true},
+			"data": {
+				Type: "object",
+				Properties: map[string]*Schema{
+					"message":             {Type: "string", Description: "Confirmation message"},
+					"deletionScheduledAt": {Type: "string", Format: "date-time", Description: "When the account will be purged unless reactivated"},
+				},
+			},
+		},
+	})
+	d.AddSchema("IntrospectBatchRequest", DTOSchema(pb.IntrospectBatchRequest{}, &Schema{
+		Properties: map[string]*Schema{
+			"tokens": {Description: "Access tokens to validate. Duplicates are allowed and validated once.", Example: []string{"v4.local.xxxxx...", "v4.local.yyyyy..."}},
+		},
+	}))
+	d.AddSchema("IntrospectBatchResponse", &Schema{
+		Type:        "object",
+		Description: "One introspection result per requested token, in request order",
+		Properties: map[string]*Schema{
+			"success": {Type: "boolean", Example: true},
+			"data": {
+				Type: "object",
+				Properties: map[string]*Schema{
+					"results": {
+						Type:  "array",
+						Items: Ref("TokenIntrospection"),
+					},
+				},
+			},
+		},
+	})
+	d.AddSchema("TokenIntrospection", &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"active": {Type: "boolean", Example: true},
+			"claims": {
+				Type:        "object",
+				Description: "Present only when `active` is true",
+				Properties: map[string]*Schema{
+					"userId":        {Type: "string", Format: "uuid"},
+					"email":         {Type: "string", Format: "email"},
+					"roles":         {Type: "array", Items: &Schema{Type: "string"}, Example: []string{"user"}},
+					"companyCode":   {Type: "string", Example: "COMPANY-001"},
+					"expiresAt":     {Type: "string", Format: "date-time"},
+					"impersonation": {Type: "boolean", Description: "True for impersonation tokens; absent otherwise"},
+					"actorId":       {Type: "string", Format: "uuid", Description: "The superadmin acting as `userId`; set only on impersonation tokens"},
+				},
+			},
+			"reason": {Type: "string", Enum: []string{"invalid", "expired", "revoked"}, Description: "Why the token is not active; absent when active"},
+		},
+	})
+}
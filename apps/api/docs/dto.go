@@ -0,0 +1,156 @@
+package docs
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SchemaOf derives the schema of a request DTO from its Go type: each field
+// becomes a property named by its json tag, and its validate tag becomes
+// constraints. required lists the property as required, email and uuid set
+// the format, an RFC 3339 datetime sets date-time, oneof becomes an enum,
+// and min, max, len, gte and lte bound a string's length, a slice's items or
+// a number's value. Rules after dive apply to a slice's items. Rules with no
+// OpenAPI equivalent (password, phone, required_unless...) are left to the
+// description.
+func SchemaOf(dto any) *Schema {
+	return schemaOfType(reflect.TypeOf(dto))
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func schemaOfType(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Struct:
+		s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if !f.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			prop := schemaOfType(f.Type)
+			if applyRules(prop, f.Tag.Get("validate")) {
+				s.Required = append(s.Required, name)
+			}
+			s.Properties[name] = prop
+		}
+		return s
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		return &Schema{Type: "array", Items: schemaOfType(t.Elem())}
+	case t.Kind() == reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return &Schema{Type: "number"}
+	case t.Kind() == reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaOfType(t.Elem())}
+	default:
+		return &Schema{Type: "string"}
+	}
+}
+
+// applyRules sets the constraints of the validate tag rules on s, reporting
+// whether they make the property required.
+func applyRules(s *Schema, rules string) (required bool) {
+	if rules == "" {
+		return false
+	}
+	parts := strings.Split(rules, ",")
+	for i, rule := range parts {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "dive":
+			if s.Items != nil {
+				applyRules(s.Items, strings.Join(parts[i+1:], ","))
+			}
+			return required
+		case "required":
+			required = true
+		case "email":
+			s.Format = "email"
+		case "uuid", "uuid4":
+			s.Format = "uuid"
+		case "datetime":
+			if param == time.RFC3339 {
+				s.Format = "date-time"
+			}
+		case "oneof":
+			s.Enum = strings.Fields(param)
+		case "min", "gte":
+			bound(s, param, true)
+		case "max", "lte":
+			bound(s, param, false)
+		case "len":
+			bound(s, param, true)
+			bound(s, param, false)
+		}
+	}
+	return required
+}
+
+// bound applies a lower or upper bound to whatever s measures: a string's
+// length, an array's items or a number's value.
+func bound(s *Schema, param string, lower bool) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	switch s.Type {
+	case "string":
+		if lower {
+			s.MinLength = int(n)
+		} else {
+			s.MaxLength = int(n)
+		}
+	case "array":
+		if lower {
+			s.MinItems = int(n)
+		} else {
+			s.MaxItems = int(n)
+		}
+	case "integer", "number":
+		if lower {
+			s.Minimum = Number(n)
+		} else {
+			s.Maximum = Number(n)
+		}
+	}
+}
+
+// DTOSchema is SchemaOf(dto) with doc's prose laid over it: doc's
+// description, and each of its properties' description, example and default,
+// are kept, while the type, requirements and constraints come from the DTO's
+// validation, so the documentation cannot drift from what the handler
+// enforces. Properties in doc that the DTO does not validate are added as
+// written.
+func DTOSchema(dto any, doc *Schema) *Schema {
+	s := SchemaOf(dto)
+	s.Description = doc.Description
+	for name, p := range doc.Properties {
+		gen, ok := s.Properties[name]
+		if !ok {
+			s.Properties[name] = p
+			continue
+		}
+		gen.Description, gen.Example, gen.Default = p.Description, p.Example, p.Default
+		if gen.Format == "" {
+			gen.Format = p.Format
+		}
+		if gen.Items != nil && p.Items != nil && gen.Items.Format == "" {
+			gen.Items.Format = p.Items.Format
+		}
+	}
+	return s
+}
@@ -0,0 +1,87 @@
+package docs
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	pb "veemon/handler/grpc/user"
+)
+
+func TestSchemaOf_ReadsJSONAndValidateTags(t *testing.T) {
+	type dto struct {
+		Email  string   `json:"email" validate:"required,email"`
+		Name   string   `json:"name,omitempty" validate:"omitempty,min=2,max=100"`
+		Status string   `json:"status" validate:"omitempty,oneof=active inactive"`
+		Size   int32    `json:"size" validate:"omitempty,gte=1,lte=100"`
+		From   string   `json:"from" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+		Tokens []string `json:"tokens" validate:"required,min=1,max=10,dive,required,max=64"`
+		Invite bool     `json:"invite"`
+		Secret string   `json:"-"`
+		hidden string
+	}
+
+	got := SchemaOf(dto{})
+	want := &Schema{
+		Type:     "object",
+		Required: []string{"email", "tokens"},
+		Properties: map[string]*Schema{
+			"email":  {Type: "string", Format: "email"},
+			"name":   {Type: "string", MinLength: 2, MaxLength: 100},
+			"status": {Type: "string", Enum: []string{"active", "inactive"}},
+			"size":   {Type: "integer", Minimum: Number(1), Maximum: Number(100)},
+			"from":   {Type: "string", Format: "date-time"},
+			"tokens": {Type: "array", MinItems: 1, MaxItems: 10, Items: &Schema{Type: "string", MaxLength: 64}},
+			"invite": {Type: "boolean"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		g, _ := json.Marshal(got)
+		w, _ := json.Marshal(want)
+		t.Fatalf("SchemaOf =\n%s\nwant\n%s", g, w)
+	}
+}
+
+func TestDTOSchema_KeepsProseAndTakesConstraintsFromTheDTO(t *testing.T) {
+	got := DTOSchema(pb.CreateUserRequest{}, &Schema{
+		Description: "An account",
+		Properties: map[string]*Schema{
+			"password": {Description: "Initial password", MaxLength: 128},
+			"roles":    {Type: "array", Items: &Schema{Type: "string"}},
+		},
+	})
+
+	if got.Description != "An account" || !reflect.DeepEqual(got.Required, []string{"email", "name"}) {
+		t.Fatalf("description %q, required %v", got.Description, got.Required)
+	}
+	if p := got.Properties["password"]; p.Description != "Initial password" || p.MinLength != 8 || p.MaxLength != 72 {
+		t.Fatalf("password = %+v; want the DTO's 8..72 with the doc's description", p)
+	}
+	if got.Properties["roles"] == nil {
+		t.Fatal("roles, documented but not validated by the DTO, was dropped")
+	}
+	if got.Properties["status"].Enum == nil {
+		t.Fatal("status lost its oneof enum")
+	}
+}
+
+func TestGetOpenAPISpec_ModulesAddTheirPaths(t *testing.T) {
+	saved := modules
+	t.Cleanup(func() { modules = saved })
+	Register(func(d *Document) {
+		d.AddPath("/api/v1/things", &PathItem{Get: &Operation{OperationID: "listThings", Responses: map[string]*Response{"200": {Description: "OK"}}}})
+	})
+
+	spec := GetOpenAPISpec()
+	if spec.Paths["/api/v1/things"] == nil || spec.Paths["/health"] == nil {
+		t.Fatal("registered module's path or built-in path missing")
+	}
+
+	Register(func(d *Document) { d.AddPath("/health", &PathItem{}) })
+	defer func() {
+		if recover() == nil {
+			t.Fatal("documenting /health twice did not panic")
+		}
+	}()
+	GetOpenAPISpec()
+}
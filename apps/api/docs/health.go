@@ -0,0 +1,70 @@
+package docs
+
+// healthModule documents the liveness and readiness probes.
+func healthModule(d *Document) {
+	d.AddPath("/health", &PathItem{
+		Get: &Operation{
+			Tags:        []string{"Health"},
+			Summary:     "Liveness probe",
+			Description: "Returns the liveness status of the service. Use this endpoint for Kubernetes liveness probes or basic uptime monitoring. A `200 OK` response indicates the service process is running and accepting connections. This does **not** verify downstream dependencies — use `/ready` for that.",
+			OperationID: "healthCheck",
+			Responses: map[string]*Response{
+				"200": {
+					Description: "Service is alive and accepting connections",
+					Content:     JSON(Ref("HealthResponse")),
+				},
+			},
+		},
+	})
+	d.AddPath("/ready", &PathItem{
+		Get: &Operation{
+			Tags:        []string{"Health"},
+			Summary:     "Readiness probe",
+			Description: "Returns the readiness status of the service including the health of all downstream dependencies (PostgreSQL, Redis, RabbitMQ). Use this for Kubernetes readiness probes. The checks run in parallel, each bounded by `HEALTH_CHECK_TIMEOUT`, and a report is reused for `HEALTH_CACHE_TTL`. A `503 Service Unavailable` response means one or more dependencies are unhealthy and the service should be temporarily removed from the load balancer rotation.",
+			OperationID: "readinessCheck",
+			Responses: map[string]*Response{
+				"200": {
+					Description: "All dependencies are healthy — service is ready to accept traffic",
+					Content:     JSON(Ref("ReadinessResponse")),
+				},
+				"503": {
+					Description: "One or more dependencies are unhealthy — service should not receive traffic",
+					Content:     JSON(Ref("ReadinessResponse")),
+				},
+			},
+		},
+	})
+	d.AddSchema("HealthResponse", &Schema{
+		Type:        "object",
+		Description: "Liveness probe response indicating the service process is running",
+		Properties: map[string]*Schema{
+			"status":  {Type: "string", Example: "ok", Description: "Service status — always `ok` if the endpoint responds"},
+			"service": {Type: "string", Example: "veemon", Description: "Service name from configuration"},
+		},
+	})
+	d.AddSchema("ReadinessResponse", &Schema{
+		Type:        "object",
+		Description: "Readiness probe response with individual dependency health checks",
+		Properties: map[string]*Schema{
+			"status": {Type: "string", Enum: []string{"ok", "unavailable"}, Description: "`unavailable` when any check is unhealthy"},
+			"checks": {
+				Type:        "object",
+				Description: "Result of each dependency check",
+				Properties: map[string]*Schema{
+					"database": Ref("HealthCheckResult"),
+					"redis":    Ref("HealthCheckResult"),
+					"rabbitmq": Ref("HealthCheckResult"),
+				},
+			},
+			"checkedAt": {Type: "string", Format: "date-time", Description: "When the checks ran; reports are reused for `HEALTH_CACHE_TTL`"},
+		},
+	})
+	d.AddSchema("HealthCheckResult", &Schema{
+		Type:        "object",
+		Description: "Outcome of one dependency check",
+		Properties: map[string]*Schema{
+			"status":    {Type: "string", Enum: []string{"healthy", "unhealthy", "disabled"}, Description: "`disabled` for an optional dependency that is not configured"},
+			"latencyMs": {Type: "number", Example: 1.42, Description: "How long the check took, in milliseconds"},
+		},
+	})
+}
@@ -0,0 +1,86 @@
+package docs
+
+// metaModule documents the static metadata endpoints: the error code catalog
+// and the token verification keys.
+func metaModule(d *Document) {
+	d.AddPath("/api/v1/meta/error-codes", &PathItem{
+		Get: &Operation{
+			Tags:        []string{"Meta"},
+			Summary:     "Error code catalog",
+			Description: "Lists every application error code the API can return in `error.code`, with its name, HTTP status, description and whether retrying can succeed. The catalog only changes between releases: responses carry an `ETag`, and a request with a matching `If-None-Match` returns `304 Not Modified`. The same catalog is embedded in this spec under `x-error-codes`.",
+			OperationID: "listErrorCodes",
+			Responses: map[string]*Response{
+				"200": {
+					Description: "The error code catalog, ordered by code",
+					Content:     JSON(Ref("ErrorCodeCatalogResponse")),
+				},
+				"304": {Description: "The catalog has not changed since the supplied ETag"},
+			},
+		},
+	})
+	d.AddPath("/.well-known/token-keys", &PathItem{
+		Get: &Operation{
+			Tags:        []string{"Meta"},
+			Summary:     "Token verification keys",
+			Description: "Publishes the Ed25519 public keys that verify access tokens when the service runs with `TOKEN_MODE=public` (PASETO `v4.public`), so other services can verify tokens offline — the Go `token.Verifier` reads this document. Each token names its key in the footer `kid`. The `active` key signs new tokens; `previous` keys were rotated out and still verify tokens until their `expires_at`. The document is not wrapped in the response envelope. Responses are cacheable for five minutes and carry an `ETag`; a request with a matching `If-None-Match` returns `304 Not Modified`.\n\nReturns `404` when the service issues `v4.local` tokens (`TOKEN_MODE=local`), which have no public key.",
+			OperationID: "listTokenKeys",
+			Responses: map[string]*Response{
+				"200": {
+					Description: "The active and previous verification keys",
+					Content:     JSON(Ref("TokenKeysResponse")),
+				},
+				"304": {Description: "The keys have not changed since the supplied ETag"},
+				"404": {
+					Description: "The service is in local token mode",
+					Content:     JSON(Ref("ErrorResponse")),
+				},
+			},
+		},
+	})
+	d.AddSchema("ErrorCode", &Schema{
+		Type:        "object",
+		Description: "One documented application error code",
+		Properties: map[string]*Schema{
+			"code":        {Type: "integer", Description: "Value carried in `error.code`", Example: 40901},
+			"name":        {Type: "string", Description: "Stable symbolic name", Example: "EMAIL_ALREADY_REGISTERED"},
+			"httpStatus":  {Type: "integer", Description: "HTTP status returned alongside the code", Example: 409},
+			"description": {Type: "string", Description: "What the code means", Example: "An account with this email address already exists."},
+			"retryable":   {Type: "boolean", Description: "Whether retrying the same request can succeed", Example: false},
+		},
+	})
+	d.AddSchema("ErrorCodeCatalogResponse", &Schema{
+		Type:        "object",
+		Description: "Every application error code, ordered by code",
+		Properties: map[string]*Schema{
+			"success": {Type: "boolean", Example: true},
+			"data": {
+				Type:  "array",
+				Items: Ref("ErrorCode"),
+			},
+		},
+	})
+	d.AddSchema("TokenKeysResponse", &Schema{
+		Type:        "object",
+		Description: "JWKS-like set of public keys verifying v4.public access tokens",
+		Properties: map[string]*Schema{
+			"keys": {
+				Type: "array",
+				Items: &Schema{
+					Type: "object",
+					Properties: map[string]*Schema{
+						"kid":        {Type: "string", Description: "Key id, matching the `kid` in token footers", Example: "9f86d081884c7d65"},
+						"kty":        {Type: "string", Example: "OKP"},
+						"crv":        {Type: "string", Example: "Ed25519"},
+						"alg":        {Type: "string", Example: "v4.public"},
+						"use":        {Type: "string", Example: "sig"},
+						"x":          {Type: "string", Description: "Public key, base64url without padding"},
+						"status":     {Type: "string", Enum: []string{"active", "previous"}},
+						"created_at": {Type: "string", Format: "date-time"},
+						"retired_at": {Type: "string", Format: "date-time", Description: "When the key stopped signing; previous keys only"},
+						"expires_at": {Type: "string", Format: "date-time", Description: "When tokens signed by the key stop being accepted; previous keys only"},
+					},
+				},
+			},
+		},
+	})
+}
@@ -0,0 +1,47 @@
+package docs
+
+// notificationsModule documents the notifications websocket.
+func notificationsModule(d *Document) {
+	d.AddPath("/api/v1/ws/notifications", &PathItem{
+		Get: &Operation{
+			Tags:        []string{"Notifications"},
+			Summary:     "Realtime user notifications (websocket)",
+			Description: "Upgrades to a websocket that pushes `user.registered`, `user.updated` and `user.deleted` events for the caller's company as JSON `NotificationEvent` messages, typically within a second of the change.\n\n**Authentication**: browsers cannot set headers on a websocket handshake, so pass the access token as `?token=` (rejected with `401`/`403` before the upgrade) or send `{\"type\":\"auth\",\"token\":\"...\"}` as the first message within 5 seconds (rejected with close code `4401`/`4403`). Requires the `users.read` permission. Once subscribed the server sends `{\"type\":\"ready\"}`.\n\n**Tenancy**: only events whose `companyCode` equals the caller's are delivered; self-registered users have no company code.\n\n**Keepalive**: the server pings every 30 seconds and drops a client that has not answered within 60.\n\n**Slow clients**: each connection buffers up to `NOTIFY_BUFFER_SIZE` events (default 64); a client that falls further behind is disconnected with close code `1013` and should reconnect.",
+			OperationID: "notificationsSocket",
+			Parameters: []*Parameter{
+				{
+					Name:        "token",
+					In:          "query",
+					Description: "Access token, as an alternative to the first-message handshake",
+					Schema:      &Schema{Type: "string"},
+				},
+			},
+			Responses: map[string]*Response{
+				"101": {
+					Description: "Switching Protocols — the socket then carries `NotificationEvent` messages",
+					Content:     JSON(Ref("NotificationEvent")),
+				},
+				"401": {Description: "Invalid `token`"},
+				"403": {Description: "Forbidden — requires the `users.read` permission"},
+				"426": {Description: "Upgrade Required — not a websocket handshake"},
+			},
+		},
+	})
+	d.AddSchema("NotificationEvent", &Schema{
+		Type:        "object",
+		Description: "A user event pushed over the notifications websocket",
+		Properties: map[string]*Schema{
+			"type":        {Type: "string", Enum: []string{"user.registered", "user.updated", "user.deleted"}},
+			"companyCode": {Type: "string", Example: "COMPANY-001"},
+			"occurredAt":  {Type: "string", Format: "date-time"},
+			"data": {
+				Type: "object",
+				Properties: map[string]*Schema{
+					"id":    {Type: "string", Format: "uuid"},
+					"email": {Type: "string", Format: "email"},
+					"name":  {Type: "string"},
+				},
+			},
+		},
+	})
+}
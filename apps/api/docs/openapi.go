@@ -0,0 +1,210 @@
+package docs
+
+import (
+	"fmt"
+	"net/http"
+
+	"veemon/pkg/errors"
+)
+
+// Document is an OpenAPI 3.0 document. Only the parts of the specification
+// this API uses are modelled; each marshals to its OpenAPI JSON form.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	ErrorCodes []errors.CodeInfo    `json:"x-error-codes,omitempty"`
+	Servers    []Server             `json:"servers,omitempty"`
+	Tags       []Tag                `json:"tags,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+type Info struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description,omitempty"`
+	Version     string   `json:"version"`
+	Contact     *Contact `json:"contact,omitempty"`
+	License     *License `json:"license,omitempty"`
+}
+
+type Contact struct {
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email,omitempty"`
+}
+
+type License struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem holds the operations served on one path.
+type PathItem struct {
+	Get    *Operation `json:"get,omitempty"`
+	Put    *Operation `json:"put,omitempty"`
+	Post   *Operation `json:"post,omitempty"`
+	Delete *Operation `json:"delete,omitempty"`
+	Patch  *Operation `json:"patch,omitempty"`
+}
+
+// Operations returns the item's operations by HTTP method.
+func (p *PathItem) Operations() map[string]*Operation {
+	ops := map[string]*Operation{}
+	for method, op := range map[string]*Operation{
+		http.MethodGet:    p.Get,
+		http.MethodPut:    p.Put,
+		http.MethodPost:   p.Post,
+		http.MethodDelete: p.Delete,
+		http.MethodPatch:  p.Patch,
+	} {
+		if op != nil {
+			ops[method] = op
+		}
+	}
+	return ops
+}
+
+type Operation struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	OperationID string                `json:"operationId,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []SecurityRequirement `json:"security,omitempty"`
+}
+
+// SecurityRequirement maps security scheme names to their required scopes.
+type SecurityRequirement map[string][]string
+
+// BearerAuth is the security of operations that need an access token.
+var BearerAuth = []SecurityRequirement{{"BearerAuth": {}}}
+
+type Parameter struct {
+	Ref         string  `json:"$ref,omitempty"`
+	Name        string  `json:"name,omitempty"`
+	In          string  `json:"in,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema,omitempty"`
+}
+
+type RequestBody struct {
+	Description string                `json:"description,omitempty"`
+	Required    bool                  `json:"required,omitempty"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Ref         string                `json:"$ref,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Headers     map[string]*Header    `json:"headers,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type Header struct {
+	Ref         string  `json:"$ref,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema,omitempty"`
+}
+
+type MediaType struct {
+	Schema  *Schema `json:"schema,omitempty"`
+	Example any     `json:"example,omitempty"`
+}
+
+type Components struct {
+	Headers         map[string]*Header         `json:"headers,omitempty"`
+	Parameters      map[string]*Parameter      `json:"parameters,omitempty"`
+	Responses       map[string]*Response       `json:"responses,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+	Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// Schema is an OpenAPI 3.0 schema object. Default and Example hold any
+// value that marshals to the JSON wanted.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Default              any                `json:"default,omitempty"`
+	Example              any                `json:"example,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinLength            int                `json:"minLength,omitempty"`
+	MaxLength            int                `json:"maxLength,omitempty"`
+	MinItems             int                `json:"minItems,omitempty"`
+	MaxItems             int                `json:"maxItems,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+}
+
+// Ref returns a reference to the component schema name.
+func Ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// JSON returns the content of an application/json body of schema s.
+func JSON(s *Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: s}}
+}
+
+// Number returns a pointer to v, for Schema.Minimum and Schema.Maximum.
+func Number(v float64) *float64 {
+	return &v
+}
+
+// AddPath documents the operations of path. A path may only be added once,
+// so two modules cannot silently document the same route.
+func (d *Document) AddPath(path string, item *PathItem) {
+	if _, dup := d.Paths[path]; dup {
+		panic(fmt.Sprintf("docs: path %s documented twice", path))
+	}
+	d.Paths[path] = item
+}
+
+// AddSchema adds the component schema name; like paths, each name may only
+// be added once.
+func (d *Document) AddSchema(name string, s *Schema) {
+	if _, dup := d.Components.Schemas[name]; dup {
+		panic(fmt.Sprintf("docs: schema %s documented twice", name))
+	}
+	d.Components.Schemas[name] = s
+}
+
+// A Module documents one group of routes: it adds their paths, and the
+// component schemas only they use, to the document.
+type Module func(*Document)
+
+// modules are applied by GetOpenAPISpec in order.
+var modules = []Module{healthModule, metaModule, authModule, usersModule, adminModule, notificationsModule}
+
+// Register adds m to the modules GetOpenAPISpec assembles, after the ones
+// already registered. Routes registered outside this package document
+// themselves this way; call it before the spec is first built, from an init
+// function or before SetupScalar.
+func Register(m Module) {
+	modules = append(modules, m)
+}
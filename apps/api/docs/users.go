@@ -0,0 +1,502 @@
+package docs
+
+import pb "veemon/handler/grpc/user"
+
+// usersModule documents the /api/v1/users resource, including import,
+// export, restore and impersonation.
+func usersModule(d *Document) {
+	d.AddPath("/api/v1/users", &PathItem{
+		Get: &Operation{
+			Tags:        []string{"Users"},
+			Summary:     "List all users (paginated)",
+			Description: "Returns a paginated list of all user accounts. Supports full-text search across name and email fields, configurable sorting, and adjustable page size.\n\n**Access**: requires `admin` or `superadmin` role.\n\n**Default behavior**: returns page 1 with 10 results per page, sorted by `created_at` descending (newest first).\n\n**Search**: case-insensitive partial match on `name` and `email` fields using `ILIKE`.\n\n**Deep pages**: offset pagination stops at `MAX_OFFSET` rows (default 10,000); a page starting beyond that returns `400` with code `40004`. To go further, sort by `created_at` and pass each response's `meta.nextCursor` as `cursor`.",
+			OperationID: "listUsers",
+			Security:    BearerAuth,
+			Parameters: []*Parameter{
+				{
+					Name:        "page",
+					In:          "query",
+					Description: "Page number for pagination (1-indexed). Defaults to 1. `(page - 1) × size` may not exceed `MAX_OFFSET` (default 10,000); deeper pages return `400` with code `40004` — use `cursor` instead. Ignored when `cursor` is set.",
+					Schema:      &Schema{Type: "integer", Default: 1, Minimum: Number(1), Example: 1},
+				},
+				{
+					Name:        "size",
+					In:          "query",
+					Description: "Number of records per page. Must be between 1 and 100. Defaults to 10. Together with `page` it is bounded by `MAX_OFFSET` (default 10,000 rows skipped).",
+					Schema:      &Schema{Type: "integer", Default: 10, Minimum: Number(1), Maximum: Number(100), Example: 10},
+				},
+				{
+					Name:        "search",
+					In:          "query",
+					Description: "Full-text search term. Searches across `name` and `email` fields using case-insensitive partial matching (SQL `ILIKE`).",
+					Schema:      &Schema{Type: "string", MaxLength: 100, Example: "john"},
+				},
+				{
+					Name:        "sortBy",
+					In:          "query",
+					Description: "Field to sort results by. Allowed values: `created_at`, `email`, `name`, `status`, `updated_at`. Defaults to `created_at`; any other value, or a `sortOrder` other than `asc` or `desc`, returns `400` with code `40011`.",
+					Schema:      &Schema{Type: "string", Enum: []string{"created_at", "email", "name", "status", "updated_at"}, Default: "created_at"},
+				},
+				{
+					Name:        "sortOrder",
+					In:          "query",
+					Description: "Sort direction. `asc` for ascending (A→Z, oldest first), `desc` for descending (Z→A, newest first). Defaults to `desc`.",
+					Schema:      &Schema{Type: "string", Enum: []string{"asc", "desc"}, Default: "desc"},
+				},
+				{
+					Name:        "cursor",
+					In:          "query",
+					Description: "Opaque keyset cursor: the `meta.nextCursor` of the previous response (`pagination.nextCursor` over gRPC). Continues after that row in `created_at` order, ignores `page`, and is not subject to `MAX_OFFSET`. Requires `sortBy` to be `created_at` (the default); an unknown cursor returns `400` with code `40005`.",
+					Schema:      &Schema{Type: "string", MaxLength: 256},
+				},
+				{
+					Name:        "includeDeleted",
+					In:          "query",
+					Description: "Also list soft-deleted users; they carry `deletedAt`. Defaults to `false`.",
+					Schema:      &Schema{Type: "boolean", Default: false},
+				},
+				{Ref: "#/components/parameters/Fields"},
+			},
+			Responses: map[string]*Response{
+				"200": {
+					Description: "Paginated list of users with pagination metadata (page, size, total, totalPages, nextCursor)",
+					Content:     JSON(Ref("ListUsersResponse")),
+				},
+				"400": {Ref: "#/components/responses/InvalidFields"},
+				"401": {Description: "Not authenticated — token is invalid, expired, or missing"},
+				"403": {Description: "Forbidden — requires `admin` or `superadmin` role"},
+			},
+		},
+		Post: &Operation{
+			Tags:        []string{"Users"},
+			Summary:     "Create a user",
+			Description: "Creates an account on an administrator's behalf. Email, password, name and phone follow the rules of **Register**; `roles`, `companyCode` and `status` may also be set.\n\n**Password or invitation**: send either `password` or `sendInvite: true`. An invited account gets a random password nobody knows; a single-use token, valid for `INVITE_TTL` (default `72h`), is published in a `user.invited` event for the mailing service, and the user redeems it with `POST /api/v1/auth/reset-password`. Invitations need Redis and RabbitMQ; without them the request fails with `503` (code `50303`) and nothing is created.\n\n**Roles**: default to `user`. The caller's own roles must grant every permission the new roles would, so an `admin` cannot create a `superadmin`; otherwise `403` (code `40302`).\n\n**Status**: `active` (default), `inactive` or `pending`.\n\n**Access**: requires the `users.write` permission (`admin` or `superadmin`). Every creation is written to the audit log.",
+			OperationID: "createUser",
+			Security:    BearerAuth,
+			RequestBody: &RequestBody{
+				Required:    true,
+				Description: "The new account",
+				Content:     JSON(Ref("CreateUserRequest")),
+			},
+			Responses: map[string]*Response{
+				"415": {Ref: "#/components/responses/UnsupportedMediaType"},
+				"201": {
+					Description: "User created — returns the new profile",
+					Headers: map[string]*Header{
+						"ETag": {Ref: "#/components/headers/UserETag"},
+					},
+					Content: JSON(Ref("UserProfileResponse")),
+				},
+				"400": {
+					Description: "Validation error — missing or malformed fields, both or neither of `password` and `sendInvite`, invalid roles or status, or fields the request does not define (listed in `error.details`)",
+					Content:     JSON(Ref("ErrorResponse")),
+				},
+				"401": {Description: "Not authenticated"},
+				"403": {Description: "Forbidden — requires `admin` or `superadmin` role, or the roles grant permissions the caller does not have (code `40302`)"},
+				"409": {
+					Description: "Conflict — a user with this email address already exists (`40901`), or the company already has `COMPANY_MAX_USERS` users (`40904`)",
+					Content:     JSON(Ref("ErrorResponse")),
+				},
+				"503": {Description: "Invitations are unavailable because Redis or RabbitMQ is not configured (code `50303`)"},
+			},
+		},
+	})
+	d.AddPath("/api/v1/users/import", &PathItem{
+		Post: &Operation{
+			Tags:        []string{"Users"},
+			Summary:     "Import users from CSV",
+			Description: "Creates many accounts from a CSV file uploaded as the `file` part of a `multipart/form-data` body. The first row is a header naming the columns, matched case-insensitively: `email` and `name` are required; `password`, `phone`, `companyCode`, `status`, `roles` (separated by `;`) and `sendInvite` (`true`/`false`) are optional.\n\n**Rows**: each row is validated like **Create a user** and created with the same roles, invitation and company-limit rules. Valid rows are created `USER_IMPORT_BATCH_SIZE` (default `100`) at a time, one transaction per batch; a row whose email already has an account is skipped, and an invalid row fails without affecting the others.\n\n**Limits**: the file is checked before anything is imported. More than `USER_IMPORT_MAX_ROWS` rows (default `1000`) is refused with `413` (code `41301`); the upload as a whole is capped by `HTTP_BODY_LIMIT_KB`. Each caller may upload 5 files a minute.\n\n**Access**: requires the `users.write` permission (`admin` or `superadmin`). Impersonation tokens are rejected with `403`. Every created user is written to the audit log.",
+			OperationID: "importUsers",
+			Security:    BearerAuth,
+			RequestBody: &RequestBody{
+				Required: true,
+				Content: map[string]*MediaType{
+					"multipart/form-data": {
+						Schema: &Schema{
+							Type:     "object",
+							Required: []string{"file"},
+							Properties: map[string]*Schema{
+								"file": {
+									Type:        "string",
+									Format:      "binary",
+									Description: "CSV with a header row, e.g. `email,name,password,roles` then `jane@example.com,Jane Doe,Secret123!,user;auditor`",
+								},
+							},
+						},
+					},
+				},
+			},
+			Responses: map[string]*Response{
+				"200": {
+					Description: "Import finished — the outcome of every row, in file order",
+					Content:     JSON(Ref("UserImportResponse")),
+				},
+				"400": {
+					Description: "The upload has no `file` part, is not valid CSV, has no rows, or its header is unusable (code `40012`)",
+					Content:     JSON(Ref("ErrorResponse")),
+				},
+				"401": {Description: "Not authenticated"},
+				"403": {Description: "Forbidden — requires `admin` or `superadmin` role, and not an impersonation token"},
+				"413": {Description: "More rows than `USER_IMPORT_MAX_ROWS` (code `41301`), or a body over `HTTP_BODY_LIMIT_KB` (code `413`)"},
+				"429": {Description: "More than 5 imports in a minute"},
+			},
+		},
+	})
+	d.AddPath("/api/v1/users/export", &PathItem{
+		Get: &Operation{
+			Tags:        []string{"Users"},
+			Summary:     "Export users",
+			Description: "Downloads every user matching the same `search`, `sortBy`, `sortOrder` and `includeDeleted` parameters as **List all users**, in that order, as CSV (the default) or NDJSON (one JSON object per line). Soft-deleted users are left out unless `includeDeleted=true`. The password hash is never exported.\n\n**Streaming**: the file is sent as an attachment (`Content-Disposition`), written a batch of rows at a time as they are read, so exports of any size use the same memory. An export may run for `USER_EXPORT_TIMEOUT` (default `10m`), which replaces `HTTP_WRITE_TIMEOUT` for this route. Once the first row is sent the status cannot change: an export that fails or times out midway ends early, and is logged as failed.\n\n**CSV**: a header row of the selected fields, then one user per row; `roles` are joined with `;` and timestamps are RFC 3339 in UTC, so the `email`, `name`, `phone`, `companyCode`, `status` and `roles` columns can be uploaded to **Import users from CSV**. A cell starting with `=`, `+`, `-`, `@`, a tab or a carriage return is prefixed with `'` so spreadsheets do not evaluate it; the import strips that prefix.\n\n**Access**: requires the `users.export` permission (`admin` or `superadmin`; `auditor` can only page through the list). Impersonation tokens are rejected with `403`. Each caller may start 5 exports a minute; every export is written to the audit log with its record count.",
+			OperationID: "exportUsers",
+			Security:    BearerAuth,
+			Parameters: []*Parameter{
+				{
+					Name:        "format",
+					In:          "query",
+					Description: "`csv` or `ndjson`; anything else returns `400` with code `40013`.",
+					Schema:      &Schema{Type: "string", Enum: []string{"csv", "ndjson"}, Default: "csv"},
+				},
+				{
+					Name:        "fields",
+					In:          "query",
+					Description: "Comma-separated columns to export, always in the order listed here: `id`, `email`, `name`, `phone`, `status`, `roles`, `companyCode`, `createdAt`, `updatedAt`, `deletedAt`. Defaults to all of them; an unknown name returns `400` with code `40003`.",
+					Schema:      &Schema{Type: "string", Example: "id,email,name,roles"},
+				},
+				{
+					Name:        "search",
+					In:          "query",
+					Description: "Case-insensitive partial match on `name` and `email`, as in the list.",
+					Schema:      &Schema{Type: "string", MaxLength: 100},
+				},
+				{
+					Name:        "sortBy",
+					In:          "query",
+					Description: "As in the list: `created_at`, `email`, `name`, `status` or `updated_at`; defaults to `created_at`. Any other value returns `400` with code `40011`.",
+					Schema:      &Schema{Type: "string", Enum: []string{"created_at", "email", "name", "status", "updated_at"}, Default: "created_at"},
+				},
+				{
+					Name:        "sortOrder",
+					In:          "query",
+					Description: "`asc` or `desc`; defaults to `desc`.",
+					Schema:      &Schema{Type: "string", Enum: []string{"asc", "desc"}, Default: "desc"},
+				},
+				{
+					Name:        "includeDeleted",
+					In:          "query",
+					Description: "Also export soft-deleted users, with `deletedAt` set.",
+					Schema:      &Schema{Type: "boolean", Default: false},
+				},
+			},
+			Responses: map[string]*Response{
+				"200": {
+					Description: "The export, streamed as a file download",
+					Headers: map[string]*Header{
+						"Content-Disposition": {
+							Description: "`attachment; filename=\"users-<UTC timestamp>.<format>\"`",
+							Schema:      &Schema{Type: "string", Example: `attachment; filename="users-20260115-103000.csv"`},
+						},
+					},
+					Content: map[string]*MediaType{
+						"text/csv": {
+							Schema:  &Schema{Type: "string"},
+							Example: "id,email,name,roles\n018f...,jane@example.com,Jane Doe,user;auditor\n",
+						},
+						"application/x-ndjson": {
+							Schema:  &Schema{Type: "string"},
+							Example: "{\"email\":\"jane@example.com\",\"id\":\"018f...\",\"name\":\"Jane Doe\",\"roles\":[\"user\",\"auditor\"]}\n",
+						},
+					},
+				},
+				"400": {
+					Description: "Unknown `format` (code `40013`) or field (code `40003`), or a list parameter out of range",
+					Content:     JSON(Ref("ErrorResponse")),
+				},
+				"401": {Description: "Not authenticated"},
+				"403": {Description: "Forbidden — requires the `users.export` permission, and not an impersonation token"},
+				"429": {Description: "More than 5 exports in a minute"},
+			},
+		},
+	})
+	d.AddPath("/api/v1/users/{id}", &PathItem{
+		Get: &Operation{
+			Tags:        []string{"Users"},
+			Summary:     "Get user by ID",
+			Description: "Retrieves the full profile of a specific user by their UUID. Returns all user fields including status, creation date, and contact information.\n\n**Access**: requires `admin` or `superadmin` role.\n\n**Not found**: returns `404` if the user does not exist or has been soft-deleted.\n\n**Conditional requests**: send the `ETag` in `If-None-Match` to get `304 Not Modified` with no body while the user is unchanged.",
+			OperationID: "getUser",
+			Security:    BearerAuth,
+			Parameters: []*Parameter{
+				{
+					Name:        "id",
+					In:          "path",
+					Required:    true,
+					Description: "Unique user identifier (UUID v4 format)",
+					Schema:      &Schema{Type: "string", Format: "uuid", Example: "550e8400-e29b-41d4-a716-446655440000"},
+				},
+				{Ref: "#/components/parameters/Fields"},
+				{Ref: "#/components/parameters/IfNoneMatch"},
+			},
+			Responses: map[string]*Response{
+				"200": {
+					Description: "User profile retrieved successfully",
+					Headers: map[string]*Header{
+						"ETag": {Ref: "#/components/headers/UserETag"},
+					},
+					Content: JSON(Ref("UserProfileResponse")),
+				},
+				"304": {Ref: "#/components/responses/NotModified"},
+				"400": {Ref: "#/components/responses/InvalidFields"},
+				"401": {Description: "Not authenticated"},
+				"403": {Description: "Forbidden — requires `admin` or `superadmin` role"},
+				"404": {
+					Description: "User not found or has been deleted",
+					Content:     JSON(Ref("ErrorResponse")),
+				},
+			},
+		},
+		Put: &Operation{
+			Tags:        []string{"Users"},
+			Summary:     "Update user by ID",
+			Description: "Updates the profile of a specific user. Only the fields provided in the request body will be updated — omitted fields are left unchanged (partial update / PATCH semantics).\n\n**Updatable fields**: `name`, `phone`, `status`.\n\n**Status values**: `active`, `inactive`, `pending`.\n\n**Optimistic concurrency**: send the `ETag` from `GET /api/v1/users/{id}` in `If-Match` and the update applies only if the user is unchanged since; otherwise it fails with `412` (code `41201`). Without `If-Match` the last write wins, unless the server requires it (`UPDATE_REQUIRE_IF_MATCH`), in which case it fails with `428` (code `42801`).\n\n**Access**: requires `admin` or `superadmin` role.",
+			OperationID: "updateUser",
+			Security:    BearerAuth,
+			Parameters: []*Parameter{
+				{
+					Name:        "id",
+					In:          "path",
+					Required:    true,
+					Description: "Unique user identifier (UUID v4 format)",
+					Schema:      &Schema{Type: "string", Format: "uuid"},
+				},
+				{
+					Name:        "If-Match",
+					In:          "header",
+					Description: "The user's `ETag` from a previous read. `*` means no condition. Takes a single tag; a list fails with `400` (code `40006`).",
+					Schema:      &Schema{Type: "string", Example: `W/"2026-03-01T09:30:00.123456Z"`},
+				},
+			},
+			RequestBody: &RequestBody{
+				Required:    true,
+				Description: "Fields to update — all fields are optional, only provided fields will be changed",
+				Content:     JSON(Ref("UpdateUserRequest")),
+			},
+			Responses: map[string]*Response{
+				"415": {Ref: "#/components/responses/UnsupportedMediaType"},
+				"200": {
+					Description: "User updated successfully — returns the complete updated profile",
+					Headers: map[string]*Header{
+						"ETag": {Ref: "#/components/headers/UserETag"},
+					},
+					Content: JSON(Ref("UserProfileResponse")),
+				},
+				"400": {Description: "Validation error — invalid status value or field format, an empty `name` or `status`, fields the request does not define (listed in `error.details`), or a malformed `If-Match` / `expectedUpdatedAt` (error code `40006`)"},
+				"401": {Description: "Not authenticated"},
+				"403": {Description: "Forbidden — requires `admin` or `superadmin` role"},
+				"404": {Description: "User not found"},
+				"412": {
+					Description: "Precondition Failed — the user changed since the version in `If-Match` or `expectedUpdatedAt` (error code `41201`); fetch it again and retry with the new `ETag`",
+					Content:     JSON(Ref("ErrorResponse")),
+				},
+				"428": {
+					Description: "Precondition Required — the server requires `If-Match` and none was sent (error code `42801`)",
+					Content:     JSON(Ref("ErrorResponse")),
+				},
+			},
+		},
+		Delete: &Operation{
+			Tags:        []string{"Users"},
+			Summary:     "Delete user by ID (soft or permanent)",
+			Description: "Soft-deletes a user account by setting the `deleted_at` timestamp. The user record is retained in the database but excluded from all queries unless `includeDeleted` is set; `POST /api/v1/users/{id}/restore` undoes it.\n\n**Permanent**: with `permanent=true` the row is removed for good, whether it was soft-deleted already or not. This needs the `users.purge` permission, which only `superadmin` holds by default, and every purge is written to the audit log.\n\n**Behavior**: the user's token will continue to work until expiration, but their profile will return `404` on subsequent lookups.\n\n**Access**: requires `admin` or `superadmin` role. Impersonation tokens are rejected with `403`.",
+			OperationID: "deleteUser",
+			Security:    BearerAuth,
+			Parameters: []*Parameter{
+				{
+					Name:        "id",
+					In:          "path",
+					Required:    true,
+					Description: "Unique user identifier (UUID v4 format)",
+					Schema:      &Schema{Type: "string", Format: "uuid"},
+				},
+				{
+					Name:        "permanent",
+					In:          "query",
+					Description: "Hard-delete the row instead of soft-deleting it. Requires the `users.purge` permission (`superadmin`).",
+					Schema:      &Schema{Type: "boolean", Default: false},
+				},
+			},
+			Responses: map[string]*Response{
+				"200": {
+					Description: "User deleted successfully",
+					Content:     JSON(Ref("DeleteResponse")),
+				},
+				"401": {Description: "Not authenticated"},
+				"403": {Description: "Forbidden — requires `admin` or `superadmin` role (`superadmin` for `permanent=true`), and not an impersonation token"},
+				"404": {Description: "User not found or already deleted (for `permanent=true`, not found at all)"},
+			},
+		},
+	})
+	d.AddPath("/api/v1/users/{id}/restore", &PathItem{
+		Post: &Operation{
+			Tags:        []string{"Users"},
+			Summary:     "Restore a deleted user",
+			Description: "Undoes a soft delete by clearing `deleted_at`, so the user shows up in lookups and can sign in again.\n\n**Conflicts**: returns `409` with code `40906` if the user is not deleted, with code `40901` if their email has since been registered to another account, and with code `40904` if their company is at its user limit.\n\n**Access**: requires `admin` or `superadmin` role. Impersonation tokens are rejected with `403`.",
+			OperationID: "restoreUser",
+			Security:    BearerAuth,
+			Parameters: []*Parameter{
+				{
+					Name:        "id",
+					In:          "path",
+					Required:    true,
+					Description: "Unique identifier of the deleted user (UUID v4 format)",
+					Schema:      &Schema{Type: "string", Format: "uuid"},
+				},
+			},
+			Responses: map[string]*Response{
+				"200": {
+					Description: "User restored — returns the profile",
+					Headers: map[string]*Header{
+						"ETag": {Ref: "#/components/headers/UserETag"},
+					},
+					Content: JSON(Ref("UserProfileResponse")),
+				},
+				"401": {Description: "Not authenticated"},
+				"403": {Description: "Forbidden — requires `admin` or `superadmin` role, and not an impersonation token"},
+				"404": {Description: "User not found"},
+				"409": {
+					Description: "Conflict — the user is not deleted (`40906`), their email was re-registered (`40901`), or their company is at its user limit (`40904`)",
+					Content:     JSON(Ref("ErrorResponse")),
+				},
+			},
+		},
+	})
+	d.AddPath("/api/v1/users/{id}/impersonate", &PathItem{
+		Post: &Operation{
+			Tags:        []string{"Users"},
+			Summary:     "Impersonate user",
+			Description: "Issues a short-lived token that acts as the given user, so support can see exactly what they see. The token carries the user's own claims plus `impersonation: true` and the caller's id as `actorId`.\n\n**Access**: requires `superadmin` role, and cannot be called with an impersonation token.\n\n**Lifetime**: at most 15 minutes (`ttlSeconds` above 900 or omitted is capped at 900). The token cannot be refreshed — `POST /api/v1/auth/refresh` returns `403`.\n\n**Restrictions**: sensitive endpoints (e.g. deleting accounts) return `403` for impersonation tokens.\n\n**Audit**: every issuance is logged with the actor, target, reason and expiry. With `IMPERSONATION_NOTIFY=true` the user is also emailed.",
+			OperationID: "impersonateUser",
+			Security:    BearerAuth,
+			Parameters: []*Parameter{
+				{
+					Name:        "id",
+					In:          "path",
+					Required:    true,
+					Description: "Unique identifier of the user to impersonate (UUID v4 format)",
+					Schema:      &Schema{Type: "string", Format: "uuid"},
+				},
+			},
+			RequestBody: &RequestBody{
+				Required: false,
+				Content:  JSON(Ref("ImpersonateUserRequest")),
+			},
+			Responses: map[string]*Response{
+				"200": {
+					Description: "Impersonation token issued",
+					Content:     JSON(Ref("ImpersonateUserResponse")),
+				},
+				"400": {Description: "Invalid user id (code `40002`) or request body (negative `ttlSeconds`, `reason` over 500 characters)"},
+				"401": {Description: "Not authenticated"},
+				"403": {Description: "Forbidden — requires `superadmin` role, or the caller is already impersonating"},
+				"404": {Description: "User not found"},
+			},
+		},
+	})
+	d.AddSchema("UserImportResponse", &Schema{
+		Type:        "object",
+		Description: "Standard response wrapper containing a user import report",
+		Properties: map[string]*Schema{
+			"success": {Type: "boolean", Example: true},
+			"data": {
+				Type: "object",
+				Properties: map[string]*Schema{
+					"total":   {Type: "integer", Description: "Rows in the file", Example: 3},
+					"created": {Type: "integer", Example: 1},
+					"skipped": {Type: "integer", Description: "Rows whose email already had an account", Example: 1},
+					"failed":  {Type: "integer", Example: 1},
+					"rows": {
+						Type: "array",
+						Items: &Schema{
+							Type: "object",
+							Properties: map[string]*Schema{
+								"row":     {Type: "integer", Description: "Line of the row in the file; the header is line 1", Example: 2},
+								"email":   {Type: "string", Example: "jane@example.com"},
+								"status":  {Type: "string", Enum: []string{"created", "skipped_duplicate", "error"}, Example: "created"},
+								"id":      {Type: "string", Format: "uuid", Description: "The created user's ID"},
+								"message": {Type: "string", Description: "Why the row was skipped or failed", Example: "email already registered"},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	d.AddSchema("ListUsersResponse", &Schema{
+		Type:        "object",
+		Description: "Paginated list of user profiles with metadata for building pagination UI",
+		Properties: map[string]*Schema{
+			"success": {Type: "boolean", Example: true},
+			"data": {
+				Type:        "array",
+				Description: "Array of user profiles for the current page",
+				Items:       Ref("UserProfile"),
+			},
+			"meta": Ref("Pagination"),
+		},
+	})
+	d.AddSchema("CreateUserRequest", DTOSchema(pb.CreateUserRequest{}, &Schema{
+		Description: "An account created by an administrator. Send either `password` or `sendInvite: true`.",
+		Properties: map[string]*Schema{
+			"email":       {Description: "Unique email address — used for login", Example: "jane.doe@example.com"},
+			"password":    {Description: "Initial password, with upper- and lowercase letters and a digit; must be omitted when `sendInvite` is set", Example: "SecureP@ss123"},
+			"name":        {Description: "Display name", Example: "Jane Doe"},
+			"phone":       {Description: "Optional phone number", Example: "+62812345678"},
+			"roles":       {Type: "array", Items: &Schema{Type: "string"}, MaxItems: 20, Description: "Roles to grant; defaults to `[\"user\"]`. Each must match `^[a-z0-9_-]{1,32}$` after trimming and lowercasing", Example: []string{"auditor"}},
+			"companyCode": {Description: "Company the user belongs to, subject to `COMPANY_MAX_USERS`", Example: "ACME"},
+			"status":      {Default: "active", Description: "Initial account status"},
+			"sendInvite":  {Default: false, Description: "Email the user a single-use link to choose their password instead of setting one"},
+		},
+	}))
+	d.AddSchema("UpdateUserRequest", DTOSchema(pb.UpdateUserRequest{}, &Schema{
+		Description: "Partial update payload — only include the fields you want to change. Omitted fields will not be modified; a field sent as `\"\"` is set to empty, which only `phone` allows.",
+		Properties: map[string]*Schema{
+			"name":              {Description: "Updated display name; cannot be empty", Example: "Jane Doe"},
+			"phone":             {Description: "Updated phone number; `\"\"` removes it", Example: "+62898765432"},
+			"status":            {Description: "Updated account status; cannot be empty", Example: "active"},
+			"expectedUpdatedAt": {Type: "string", Format: "date-time", Description: "Apply the update only if the user's `updatedAt` still equals this value; takes precedence over `If-Match`", Example: "2026-03-01T09:30:00.123456Z"},
+		},
+	}))
+	d.AddSchema("ImpersonateUserRequest", DTOSchema(pb.ImpersonateUserRequest{}, &Schema{
+		Description: "Optional impersonation settings",
+		Properties: map[string]*Schema{
+			"ttlSeconds": {Description: "Token lifetime in seconds; `0`, omitted or above `900` means 900 (15 minutes)", Example: 600},
+			"reason":     {Description: "Why access is needed (e.g. a ticket reference); recorded in the audit log", Example: "SUP-1234: user cannot see payslips"},
+		},
+	}))
+	d.AddSchema("ImpersonateUserResponse", &Schema{
+		Type:        "object",
+		Description: "A short-lived token acting as the user",
+		Properties: map[string]*Schema{
+			"success": {Type: "boolean", Example: true},
+			"data": {
+				Type: "object",
+				Properties: map[string]*Schema{
+					"token":     {Type: "string", Description: "PASETO v4 local token with `impersonation` and `actorId` claims; cannot be refreshed", Example: "v4.local.eyJ..."},
+					"expiresAt": {Type: "string", Format: "date-time", Description: "When the token expires (RFC 3339), at most 15 minutes after issue", Example: "2026-01-15T10:45:00Z"},
+					"user":      Ref("UserProfile"),
+				},
+			},
+		},
+	})
+	d.AddSchema("DeleteResponse", &Schema{
+		Type:        "object",
+		Description: "Confirmation that a resource was deleted successfully",
+		Properties: map[string]*Schema{
+			"success": {Type: "boolean", Example: true},
+			"data": {
+				Type: "object",
+				Properties: map[string]*Schema{
+					"message": {Type: "string", Example: "user deleted successfully", Description: "Confirmation message"},
+				},
+			},
+		},
+	})
+}
@@ -0,0 +1,15 @@
+package entity
+
+import "fmt"
+
+// CompanyUserLimitError is returned when adding a user would take a company
+// past its user limit (see COMPANY_MAX_USERS). Users are counted while not
+// soft-deleted, whatever their status.
+type CompanyUserLimitError struct {
+	CompanyCode string
+	Limit       int
+}
+
+func (e *CompanyUserLimitError) Error() string {
+	return fmt.Sprintf("user limit reached for company %s (max %d users)", e.CompanyCode, e.Limit)
+}
@@ -0,0 +1,34 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OutboxMessage is a message staged for RabbitMQ in the transaction of the
+// change it reports. A relay publishes it once that transaction commits and
+// sets SentAt; until then it is retried from NextAttemptAt on.
+type OutboxMessage struct {
+	ID         string          `gorm:"type:uuid;primaryKey" json:"id"`
+	Exchange   string          `gorm:"type:varchar(255);not null" json:"exchange"`
+	RoutingKey string          `gorm:"type:varchar(255);not null" json:"routingKey"`
+	Payload    json.RawMessage `gorm:"type:jsonb;not null" json:"payload"`
+	// Headers holds the trace context of the request that staged the
+	// message, so its publication joins that trace.
+	Headers       json.RawMessage `gorm:"type:jsonb" json:"headers,omitempty"`
+	Attempts      int             `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time       `gorm:"not null" json:"nextAttemptAt"`
+	LastError     string          `gorm:"type:text;not null;default:''" json:"lastError,omitempty"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	SentAt        *time.Time      `json:"sentAt,omitempty"`
+}
+
+func (m *OutboxMessage) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == "" {
+		m.ID = uuid.New().String()
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// MaxRoles bounds how many roles a user, and so a token, may carry.
+const MaxRoles = 20
+
+var rolePattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// ErrInvalidRoles is wrapped by every ParseRoles failure.
+var ErrInvalidRoles = errors.New("invalid roles")
+
+// DefaultRoles are given to self-registered users.
+var DefaultRoles = Roles{"user"}
+
+// Roles is a normalized role list: each entry trimmed, lowercased and
+// matching ^[a-z0-9_-]{1,32}$, without duplicates, at most MaxRoles long.
+// Build one with ParseRoles; order of first appearance is kept.
+type Roles []string
+
+// NormalizeRole trims and lowercases role, so "Admin " and "admin" compare
+// equal. It does not validate.
+func NormalizeRole(role string) string {
+	return strings.ToLower(strings.TrimSpace(role))
+}
+
+// ParseRoles normalizes raw and checks it against the limits. Blank entries
+// are dropped rather than rejected.
+func ParseRoles(raw []string) (Roles, error) {
+	roles := make(Roles, 0, len(raw))
+	seen := make(map[string]bool, len(raw))
+	for _, r := range raw {
+		r = NormalizeRole(r)
+		if r == "" || seen[r] {
+			continue
+		}
+		if !rolePattern.MatchString(r) {
+			return nil, fmt.Errorf("%w: %q must match %s", ErrInvalidRoles, r, rolePattern)
+		}
+		seen[r] = true
+		roles = append(roles, r)
+	}
+	if len(roles) > MaxRoles {
+		return nil, fmt.Errorf("%w: %d roles, at most %d allowed", ErrInvalidRoles, len(roles), MaxRoles)
+	}
+	return roles, nil
+}
+
+// HasAny reports whether r holds any of want, comparing normalized names.
+func (r Roles) HasAny(want []string) bool {
+	for _, w := range want {
+		w = NormalizeRole(w)
+		for _, have := range r {
+			if NormalizeRole(have) == w {
+				return true
+			}
+		}
+	}
+	return false
+}
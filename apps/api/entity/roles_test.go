@@ -0,0 +1,71 @@
+package entity
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRoles_Normalizes(t *testing.T) {
+	roles, err := ParseRoles([]string{" Admin", "admin ", "", "  ", "USER", "data_ops-2"})
+	require.NoError(t, err)
+	assert.Equal(t, Roles{"admin", "user", "data_ops-2"}, roles)
+}
+
+func TestParseRoles_Limits(t *testing.T) {
+	for _, bad := range [][]string{
+		{"super admin"},
+		{"admin!"},
+		{"rôle"},
+		{strings.Repeat("a", 33)},
+	} {
+		_, err := ParseRoles(bad)
+		assert.ErrorIs(t, err, ErrInvalidRoles, "%q", bad)
+	}
+
+	many := make([]string, MaxRoles+1)
+	for i := range many {
+		many[i] = "role" + strconv.Itoa(i)
+	}
+	_, err := ParseRoles(many)
+	assert.ErrorIs(t, err, ErrInvalidRoles)
+
+	// Duplicates collapse before the count is checked.
+	_, err = ParseRoles(append(many[:MaxRoles], "ROLE0"))
+	assert.NoError(t, err)
+}
+
+func TestRoles_HasAny(t *testing.T) {
+	cases := []struct {
+		have, want []string
+		ok         bool
+	}{
+		{[]string{"admin"}, []string{"admin"}, true},
+		// Variants a raw string comparison used to miss.
+		{[]string{"Admin "}, []string{"admin"}, true},
+		{[]string{"admin"}, []string{"ADMIN"}, true},
+		{[]string{" superadmin"}, []string{"admin", "superadmin"}, true},
+		{[]string{"admins"}, []string{"admin"}, false},
+		{nil, []string{"admin"}, false},
+		{[]string{"admin"}, nil, false},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.ok, Roles(tc.have).HasAny(tc.want), "%q has any of %q", tc.have, tc.want)
+	}
+}
+
+func TestUser_BeforeSaveNormalizesRoles(t *testing.T) {
+	u := &User{Roles: []string{"Admin ", "admin"}}
+	require.NoError(t, u.BeforeSave(nil))
+	assert.Equal(t, []string{"admin"}, []string(u.Roles))
+
+	u = &User{Roles: []string{"not valid"}}
+	assert.ErrorIs(t, u.BeforeSave(nil), ErrInvalidRoles)
+
+	u = &User{}
+	require.NoError(t, u.BeforeSave(nil))
+	assert.Nil(t, u.Roles, "nil roles fall through to the column default")
+}
@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+// SeedHistory records a seeder that ran to completion. Later seed runs skip
+// seeders recorded here instead of relying only on per-row conflict checks.
+type SeedHistory struct {
+	Name        string    `gorm:"type:varchar(100);primaryKey" json:"name"`
+	Environment string    `gorm:"type:varchar(50);not null" json:"environment"`
+	Created     int       `gorm:"not null;default:0" json:"created"`
+	Skipped     int       `gorm:"not null;default:0" json:"skipped"`
+	RanAt       time.Time `gorm:"not null" json:"ranAt"`
+}
@@ -17,6 +17,17 @@ const (
 	UserStatusPending  UserStatus = "pending"
 )
 
+// Valid reports whether s is one of the defined statuses.
+func (s UserStatus) Valid() bool {
+	switch s {
+	case UserStatusActive, UserStatusInactive, UserStatusPending:
+		return true
+	}
+	return false
+}
+
+// User has no TableName method: its table ("users", or e.g. "grst_users"
+// with DB_TABLE_PREFIX) comes from database.NamingStrategy.
 type User struct {
 	ID          string         `gorm:"type:uuid;primaryKey" json:"id"`
 	Email       string         `gorm:"uniqueIndex;not null" json:"email"`
@@ -26,9 +37,43 @@ type User struct {
 	Status      UserStatus     `gorm:"type:varchar(20);default:active" json:"status"`
 	Roles       pq.StringArray `gorm:"type:text[];default:ARRAY['user']::TEXT[]" json:"roles"`
 	CompanyCode string         `gorm:"type:varchar(50)" json:"companyCode"`
+	// DeletionRequestedAt is set while a self-service deletion is pending;
+	// the account is purged once the grace period after it has elapsed.
+	DeletionRequestedAt *time.Time     `gorm:"index" json:"deletionRequestedAt,omitempty"`
+	CreatedAt           time.Time      `json:"createdAt"`
+	UpdatedAt           time.Time      `json:"updatedAt"`
+	DeletedAt           gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// UserSummary is the part of a User that list queries read. It has no
+// Password field, so a listing never carries the hash out of the database.
+type UserSummary struct {
+	ID          string         `json:"id"`
+	Email       string         `json:"email"`
+	Name        string         `json:"name"`
+	Phone       string         `json:"phone"`
+	Status      UserStatus     `json:"status"`
+	Roles       pq.StringArray `gorm:"type:text[]" json:"roles"`
+	CompanyCode string         `json:"companyCode"`
 	CreatedAt   time.Time      `json:"createdAt"`
 	UpdatedAt   time.Time      `json:"updatedAt"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	DeletedAt   gorm.DeletedAt `json:"-"`
+}
+
+// Summary returns u's list projection.
+func (u *User) Summary() UserSummary {
+	return UserSummary{
+		ID:          u.ID,
+		Email:       u.Email,
+		Name:        u.Name,
+		Phone:       u.Phone,
+		Status:      u.Status,
+		Roles:       u.Roles,
+		CompanyCode: u.CompanyCode,
+		CreatedAt:   u.CreatedAt,
+		UpdatedAt:   u.UpdatedAt,
+		DeletedAt:   u.DeletedAt,
+	}
 }
 
 func (u *User) BeforeCreate(tx *gorm.DB) error {
@@ -38,6 +83,17 @@ func (u *User) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
-func (u *User) TableName() string {
-	return "users"
+// BeforeSave normalizes Roles on every Create and Save, so no write path can
+// store "Admin " beside "admin" or exceed MaxRoles. Nil Roles are left for
+// the column default.
+func (u *User) BeforeSave(tx *gorm.DB) error {
+	if u.Roles == nil {
+		return nil
+	}
+	roles, err := ParseRoles(u.Roles)
+	if err != nil {
+		return err
+	}
+	u.Roles = pq.StringArray(roles)
+	return nil
 }
@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	user "veemon/app/usecase/user"
+	"veemon/entity"
+	pb "veemon/handler/grpc/user"
+	"veemon/internal/fixtures"
+	"veemon/pkg/errors"
+	"veemon/pkg/middleware"
+	"veemon/pkg/token"
+	"veemon/repository"
+	"veemon/repository/user_repository"
+
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// memRepo keeps users in memory, implementing what the deletion flow and the
+// admin reads, creates and updates need; every other method panics. Updates bump
+// UpdatedAt like the database does.
+type memRepo struct {
+	user_repository.Repository
+	mu    sync.Mutex
+	users map[string]*entity.User
+}
+
+func (r *memRepo) get(match func(*entity.User) bool) (*entity.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if match(u) {
+			cp := *u
+			return &cp, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memRepo) FindByID(_ context.Context, id string) (*entity.User, error) {
+	return r.get(func(u *entity.User) bool { return u.ID == id })
+}
+
+func (r *memRepo) FindCredentialsByID(ctx context.Context, id string) (*entity.User, error) {
+	return r.FindByID(ctx, id)
+}
+
+// FindByIDWithColumns projects only UpdatedAt, the column the handler adds
+// to a client's fieldset.
+func (r *memRepo) FindByIDWithColumns(ctx context.Context, id string, columns []string) (*entity.User, error) {
+	u, err := r.FindByID(ctx, id)
+	if err == nil && !slices.Contains(columns, "updated_at") {
+		u.UpdatedAt = time.Time{}
+	}
+	return u, err
+}
+
+func (r *memRepo) FindByEmail(_ context.Context, email string) (*entity.User, error) {
+	return r.get(func(u *entity.User) bool { return u.Email == email })
+}
+
+// Create assigns the ID and timestamps like the database does.
+func (r *memRepo) Create(_ context.Context, u *entity.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u.ID = fmt.Sprintf("018f0000-0000-7000-8000-%012d", len(r.users)+1)
+	u.CreatedAt, u.UpdatedAt = fixtures.Epoch, fixtures.Epoch
+	cp := *u
+	r.users[u.ID] = &cp
+	return nil
+}
+
+func (r *memRepo) FindAll(_ context.Context, _ user_repository.ListParams) ([]entity.UserSummary, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []entity.UserSummary
+	for _, u := range r.users {
+		out = append(out, u.Summary())
+	}
+	return out, int64(len(out)), nil
+}
+
+func (r *memRepo) UpdateFields(ctx context.Context, id string, fields map[string]interface{}, expectedUpdatedAt *time.Time) (*entity.User, error) {
+	r.mu.Lock()
+	u, ok := r.users[id]
+	if ok && expectedUpdatedAt != nil && !u.UpdatedAt.Equal(*expectedUpdatedAt) {
+		r.mu.Unlock()
+		return nil, user_repository.ErrModified
+	}
+	if ok {
+		u.UpdatedAt = time.Now()
+		for k, v := range fields {
+			switch k {
+			case "name":
+				u.Name = v.(string)
+			case "phone":
+				u.Phone = v.(string)
+			case "password":
+				u.Password = v.(string)
+			case "status":
+				u.Status = v.(entity.UserStatus)
+			case "deletion_requested_at":
+				if at, set := v.(time.Time); set {
+					u.DeletionRequestedAt = &at
+				} else {
+					u.DeletionRequestedAt = nil
+				}
+			}
+		}
+	}
+	r.mu.Unlock()
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return r.FindByID(ctx, id)
+}
+
+func TestAccountDeletion_GracePeriodFlow(t *testing.T) {
+	const id, email, password = "018f0000-0000-7000-8000-0000000000aa", "leaving@example.com", "Password123"
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("bcrypt: %v", err)
+	}
+	repo := &memRepo{users: map[string]*entity.User{id: {
+		ID: id, Email: email, Password: string(hash), Name: "Leaving", Status: entity.UserStatusActive,
+	}}}
+	guard := newIntrospectGuard(t)
+	ts, _ := token.NewTokenService(introspectSecret, 1)
+	h := NewUserHandler(user.NewUseCase(repo), ts, guard, nil)
+	ctx := context.Background()
+
+	login, err := h.Login(ctx, &pb.LoginReq{Email: email, Password: password})
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	claims, err := ts.ValidateToken(login.Token)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+
+	// Schedule the deletion as the account owner.
+	res, err := h.DeleteMe(middleware.WithAuthContext(ctx, &middleware.AuthContext{UserID: id}), &emptypb.Empty{})
+	if err != nil {
+		t.Fatalf("DeleteMe: %v", err)
+	}
+	due, err := time.Parse(time.RFC3339, res.DeletionScheduledAt)
+	if err != nil {
+		t.Fatalf("DeletionScheduledAt %q: %v", res.DeletionScheduledAt, err)
+	}
+	if want := time.Now().Add(user.DefaultDeletionGracePeriod); due.Sub(want).Abs() > time.Minute {
+		t.Fatalf("DeletionScheduledAt = %v, want about %v", due, want)
+	}
+	if !guard.IsUserRevoked(ctx, id, claims.IssuedAt) {
+		t.Fatal("the token issued before DeleteMe should be revoked")
+	}
+
+	// Admins still see the account, as inactive, during the window.
+	profile, err := h.GetUser(ctx, &pb.GetUserReq{Id: id})
+	if err != nil || profile.Status != "inactive" {
+		t.Fatalf("GetUser = %v, %v; want status inactive", profile, err)
+	}
+	list, err := h.ListUsers(ctx, &pb.ListUsersReq{})
+	if err != nil || len(list.Users) != 1 || list.Users[0].Status != "inactive" {
+		t.Fatalf("ListUsers = %v, %v; want the account, inactive", list, err)
+	}
+
+	// A plain login points at reactivation instead of succeeding.
+	_, err = h.Login(ctx, &pb.LoginReq{Email: email, Password: password})
+	if appErr, ok := err.(*errors.AppError); !ok || appErr.HTTPStatus != 403 || appErr.Code != 40301 {
+		t.Fatalf("Login during grace period = %v, want 403/40301", err)
+	}
+
+	// Reactivation cancels the deletion and signs the user in.
+	reactivated, err := h.ReactivateAccount(ctx, &pb.ReactivateAccountReq{Email: email, Password: password})
+	if err != nil {
+		t.Fatalf("ReactivateAccount: %v", err)
+	}
+	if reactivated.Token == "" || reactivated.User.Status != "active" {
+		t.Fatalf("ReactivateAccount = %v, want a token and status active", reactivated)
+	}
+	_, err = h.ReactivateAccount(ctx, &pb.ReactivateAccountReq{Email: email, Password: password})
+	if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != 40903 {
+		t.Fatalf("second ReactivateAccount = %v, want 409/40903", err)
+	}
+	if _, err := h.Login(ctx, &pb.LoginReq{Email: email, Password: password}); err != nil {
+		t.Fatalf("Login after reactivation: %v", err)
+	}
+}
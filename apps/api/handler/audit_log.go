@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"veemon/app/usecase/audit"
+	"veemon/entity"
+	pb "veemon/handler/grpc/user"
+	"veemon/pkg/errors"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// WithAuditLog serves the audit log API from uc. Without it the log reads
+// as empty.
+func WithAuditLog(uc audit.UseCase) Option {
+	return func(h *userHandler) {
+		h.auditUC = uc
+	}
+}
+
+// ListAuditLogs pages through the audit log, newest first (superadmin only).
+func (h *userHandler) ListAuditLogs(ctx context.Context, req *pb.ListAuditLogsReq) (*pb.ListAuditLogsRes, error) {
+	if err := pb.ValidateRequest(req); err != nil {
+		return nil, err
+	}
+	// Already checked to be RFC 3339 by ValidateRequest.
+	var from, to time.Time
+	if req.From != "" {
+		from, _ = time.Parse(time.RFC3339, req.From)
+	}
+	if req.To != "" {
+		to, _ = time.Parse(time.RFC3339, req.To)
+	}
+	if h.auditUC == nil {
+		return &pb.ListAuditLogsRes{Pagination: &pb.Pagination{Page: 1, Size: audit.DefaultPageSize}}, nil
+	}
+
+	out, err := h.auditUC.List(ctx, audit.ListInput{
+		Page:         int(req.Page),
+		Size:         int(req.Size),
+		ActorID:      req.ActorId,
+		ResourceType: req.ResourceType,
+		ResourceID:   req.ResourceId,
+		From:         from,
+		To:           to,
+	})
+	if err != nil {
+		if err == audit.ErrInvalidRange {
+			return nil, errors.ValidationError("from must be before to").WithFields(errors.FieldViolation{
+				Field: "to", Description: "must be after from",
+			})
+		}
+		return nil, h.internal(50020, "failed to list audit logs", err)
+	}
+
+	entries := make([]*pb.AuditLogEntry, len(out.Entries))
+	for i := range out.Entries {
+		if entries[i], err = toAuditLogEntry(&out.Entries[i]); err != nil {
+			return nil, h.internal(50020, "failed to list audit logs", err)
+		}
+	}
+	totalPages := (out.Total + int64(out.Size) - 1) / int64(out.Size)
+	return &pb.ListAuditLogsRes{
+		Entries: entries,
+		Pagination: &pb.Pagination{
+			Page:       int32(out.Page), // #nosec G115 -- page came from the int32 request field
+			Size:       int32(out.Size), // #nosec G115 -- size is capped at audit.MaxPageSize
+			Total:      out.Total,
+			TotalPages: int32(totalPages), // #nosec G115 -- totalPages is bounded by pagination
+		},
+	}, nil
+}
+
+func toAuditLogEntry(e *entity.AuditLog) (*pb.AuditLogEntry, error) {
+	p := &pb.AuditLogEntry{
+		Id:           e.ID,
+		ActorId:      e.ActorID,
+		Action:       e.Action,
+		ResourceType: e.ResourceType,
+		ResourceId:   e.ResourceID,
+		RequestId:    e.RequestID,
+		CreatedAt:    e.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	var err error
+	if p.Before, err = jsonStruct(e.Before); err != nil {
+		return nil, err
+	}
+	if p.After, err = jsonStruct(e.After); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// jsonStruct decodes a JSON object, returning nil for an empty or null one.
+func jsonStruct(raw []byte) (*structpb.Struct, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	s := &structpb.Struct{}
+	if err := protojson.Unmarshal(raw, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
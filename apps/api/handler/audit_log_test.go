@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"veemon/app/usecase/audit"
+	user "veemon/app/usecase/user"
+	"veemon/entity"
+	pb "veemon/handler/grpc/user"
+	"veemon/internal/fixtures"
+	"veemon/pkg/middleware"
+	"veemon/repository/audit_repository"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// memAuditLog keeps entries in memory, ignoring filters and paging.
+type memAuditLog struct {
+	entries []entity.AuditLog
+}
+
+func (m *memAuditLog) Create(_ context.Context, e *entity.AuditLog) error {
+	e.ID = "entry-1"
+	e.CreatedAt = fixtures.Epoch
+	m.entries = append(m.entries, *e)
+	return nil
+}
+
+func (m *memAuditLog) FindAll(context.Context, audit_repository.ListParams) ([]entity.AuditLog, int64, error) {
+	return m.entries, int64(len(m.entries)), nil
+}
+
+func auditApp(log *memAuditLog, roles ...string) *fiber.App {
+	repo := &memRepo{users: map[string]*entity.User{
+		versionedID: fixtures.User().WithID(versionedID).WithName("Old Name").Build(),
+	}}
+	h := NewUserHandler(user.NewUseCase(repo, user.WithAuditLog(log)), nil, nil, nil, WithAuditLog(audit.NewUseCase(log)))
+	app := fiber.New()
+	pb.RegisterUserApiRoutes(app, h, func(string) (*middleware.AuthContext, error) {
+		return &middleware.AuthContext{UserID: "caller", Roles: roles}, nil
+	})
+	return app
+}
+
+func TestListAuditLogs_ShowsAdminChanges(t *testing.T) {
+	log := &memAuditLog{}
+	app := auditApp(log, "superadmin")
+
+	// The update is recorded as made by the caller.
+	put := httptest.NewRequest("PUT", "/api/v1/users/"+versionedID, strings.NewReader(`{"name":"New Name"}`))
+	put.Header.Set("Content-Type", "application/json")
+	put.Header.Set("Authorization", "Bearer x")
+	if resp, err := app.Test(put, -1); err != nil || resp.StatusCode != 200 {
+		t.Fatalf("PUT = %v, %v; want 200", resp, err)
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/audit-logs?resourceType=user&from=2026-01-01T00:00:00Z", nil)
+	req.Header.Set("Authorization", "Bearer x")
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+	var out struct {
+		Data []struct {
+			ActorID    string         `json:"actorId"`
+			Action     string         `json:"action"`
+			ResourceID string         `json:"resourceId"`
+			Before     map[string]any `json:"before"`
+			After      map[string]any `json:"after"`
+		} `json:"data"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+
+	if resp.StatusCode != 200 || len(out.Data) != 1 {
+		t.Fatalf("status %d, %d entries; want 200 with one", resp.StatusCode, len(out.Data))
+	}
+	e := out.Data[0]
+	if e.ActorID != "caller" || e.Action != "update" || e.ResourceID != versionedID ||
+		e.Before["name"] != "Old Name" || e.After["name"] != "New Name" {
+		t.Fatalf("entry = %+v", e)
+	}
+}
+
+func TestListAuditLogs_Rejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		roles  []string
+		query  string
+		status int
+	}{
+		{"admin", []string{"admin"}, "", 403},
+		{"malformed from", []string{"superadmin"}, "?from=yesterday", 400},
+		{"inverted range", []string{"superadmin"}, "?from=2026-02-01T00:00:00Z&to=2026-01-01T00:00:00Z", 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/v1/audit-logs"+tt.query, nil)
+			req.Header.Set("Authorization", "Bearer x")
+			resp, err := auditApp(&memAuditLog{}, tt.roles...).Test(req, -1)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status %d, want %d", resp.StatusCode, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	pb "veemon/handler/grpc/user"
+	"veemon/pkg/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// TestGetMeAndGetUser_IfNoneMatch polls both endpoints as the admin whose
+// own profile is versionedID: the current ETag gets a bodiless 304 until an
+// update changes the version.
+func TestGetMeAndGetUser_IfNoneMatch(t *testing.T) {
+	app := fiber.New()
+	app.Use(middleware.ConditionalGetMiddleware())
+	self := func(string) (*middleware.AuthContext, error) {
+		return &middleware.AuthContext{UserID: versionedID, Roles: []string{"admin"}}, nil
+	}
+	pb.RegisterUserApiRoutes(app, newVersionedHandler(), self)
+
+	do := func(method, path, ifNoneMatch, body string) (int, string, string) {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.Header.Set("Authorization", "Bearer x")
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatalf("app.Test: %v", err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, resp.Header.Get("ETag"), string(b)
+	}
+
+	for _, path := range []string{"/api/v1/auth/me", "/api/v1/users/" + versionedID} {
+		code, etag, body := do("GET", path, "", "")
+		if code != 200 || etag != epochETag || !strings.Contains(body, `"success":true`) {
+			t.Fatalf("GET %s: status %d, ETag %q, body %s", path, code, etag, body)
+		}
+		code, etag, body = do("GET", path, epochETag, "")
+		if code != 304 || etag != epochETag || body != "" {
+			t.Fatalf("GET %s with its ETag: status %d, ETag %q, body %q; want an empty 304", path, code, etag, body)
+		}
+		if code, _, _ := do("GET", path, `W/"2025-12-31T00:00:00Z"`, ""); code != 200 {
+			t.Fatalf("GET %s with a stale ETag: status %d, want 200", path, code)
+		}
+	}
+
+	if code, _, body := do("PUT", "/api/v1/users/"+versionedID, "", `{"name":"Renamed"}`); code != 200 {
+		t.Fatalf("PUT: status %d: %s", code, body)
+	}
+	for _, path := range []string{"/api/v1/auth/me", "/api/v1/users/" + versionedID} {
+		code, etag, body := do("GET", path, epochETag, "")
+		if code != 200 || etag == epochETag || !strings.Contains(body, "Renamed") {
+			t.Fatalf("GET %s after an update: status %d, ETag %q, body %s", path, code, etag, body)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	user "veemon/app/usecase/user"
+	"veemon/entity"
+	pb "veemon/handler/grpc/user"
+	"veemon/internal/fixtures"
+	"veemon/pkg/middleware"
+	"veemon/pkg/passwordreset"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// inviteRecorder stores invitation tokens and records the invitations.
+type inviteRecorder struct {
+	invited []passwordreset.Invited
+}
+
+func (r *inviteRecorder) Issue(_ context.Context, userID string) (string, time.Time, error) {
+	return "invite-" + userID, fixtures.Epoch.Add(72 * time.Hour), nil
+}
+
+func (r *inviteRecorder) Consume(context.Context, string) (string, error) {
+	return "", passwordreset.ErrInvalidToken
+}
+
+func (r *inviteRecorder) UserInvited(_ context.Context, inv passwordreset.Invited) error {
+	r.invited = append(r.invited, inv)
+	return nil
+}
+
+func newCreateUserHandler(opts ...user.Option) pb.UserApiServer {
+	repo := &memRepo{users: map[string]*entity.User{
+		versionedID: fixtures.User().WithID(versionedID).WithEmail("taken@example.com").Build(),
+	}}
+	return NewUserHandler(user.NewUseCase(repo, opts...), nil, nil, nil)
+}
+
+func asCaller(roles ...string) context.Context {
+	return middleware.WithAuthContext(context.Background(), &middleware.AuthContext{UserID: "caller", Roles: roles})
+}
+
+func TestCreateUser_Returns201OverREST(t *testing.T) {
+	app := fiber.New()
+	admin := func(string) (*middleware.AuthContext, error) {
+		return &middleware.AuthContext{UserID: "admin", Roles: []string{"admin"}}, nil
+	}
+	pb.RegisterUserApiRoutes(app, newCreateUserHandler(), admin)
+
+	req := httptest.NewRequest("POST", "/api/v1/users", strings.NewReader(
+		`{"email":"new@example.com","password":"Password123","name":"New User","roles":["Auditor"],"companyCode":"ACME","status":"pending"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer x")
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+	var out struct {
+		Data map[string]any `json:"data"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+
+	if resp.StatusCode != 201 || resp.Header.Get("ETag") != epochETag {
+		t.Fatalf("status %d, ETag %q; want 201 with %s", resp.StatusCode, resp.Header.Get("ETag"), epochETag)
+	}
+	if out.Data["id"] == "" || out.Data["email"] != "new@example.com" || out.Data["status"] != "pending" {
+		t.Fatalf("data = %v", out.Data)
+	}
+}
+
+func TestCreateUser_Errors(t *testing.T) {
+	valid := func() *pb.CreateUserReq {
+		return &pb.CreateUserReq{Email: "new@example.com", Password: "Password123", Name: "New User"}
+	}
+	tests := []struct {
+		name         string
+		roles        []string
+		edit         func(*pb.CreateUserReq)
+		status, code int
+	}{
+		{"admin grants superadmin", []string{"admin"}, func(r *pb.CreateUserReq) { r.Roles = []string{"superadmin"} }, 403, 40302},
+		{"invalid role", []string{"admin"}, func(r *pb.CreateUserReq) { r.Roles = []string{"not a role"} }, 400, 400},
+		{"no password or invite", []string{"admin"}, func(r *pb.CreateUserReq) { r.Password = "" }, 400, 400},
+		{"email taken", []string{"admin"}, func(r *pb.CreateUserReq) { r.Email = "taken@example.com" }, 409, 40901},
+		{"invites not configured", []string{"admin"}, func(r *pb.CreateUserReq) { r.Password, r.SendInvite = "", true }, 503, 50303},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid()
+			tt.edit(req)
+			_, err := newCreateUserHandler().CreateUser(asCaller(tt.roles...), req)
+			wantAppError(t, err, tt.status, tt.code)
+		})
+	}
+}
+
+func TestCreateUser_SuperadminGrantsAnyRole(t *testing.T) {
+	res, err := newCreateUserHandler().CreateUser(asCaller("superadmin"), &pb.CreateUserReq{
+		Email: "root@example.com", Password: "Password123", Name: "Root", Roles: []string{"superadmin"},
+	})
+	if err != nil || res.Status != "active" {
+		t.Fatalf("CreateUser = %v, %v; want an active user", res, err)
+	}
+}
+
+func TestCreateUser_Invite(t *testing.T) {
+	invites := &inviteRecorder{}
+	res, err := newCreateUserHandler(user.WithInvites(invites, invites)).CreateUser(asCaller("admin"), &pb.CreateUserReq{
+		Email: "invited@example.com", Name: "Invited", SendInvite: true,
+	})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if len(invites.invited) != 1 || invites.invited[0].UserID != res.Id || invites.invited[0].Token != "invite-"+res.Id {
+		t.Fatalf("invited = %+v, want one invitation for %s", invites.invited, res.Id)
+	}
+}
@@ -0,0 +1,89 @@
+package user
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"veemon/pkg/middleware"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// TestHandlerSpan_SameNameOverRESTAndGRPC calls Register over both
+// transports and checks each produced the same handler-layer span, a child
+// of its transport span.
+func TestHandlerSpan_SameNameOverRESTAndGRPC(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	// REST
+	app := fiber.New()
+	app.Use(middleware.TracingMiddleware("test", nil))
+	RegisterUserApiRoutes(app, stubServer{}, roleValidator)
+	if code, out := doJSON(t, app, "POST", "/api/v1/auth/register", "",
+		`{"email":"a@b.com","password":"Passw0rd!","name":"Ann"}`); code != 201 {
+		t.Fatalf("REST register: %d %v", code, out)
+	}
+
+	// gRPC
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(middleware.GRPCTracingInterceptor()),
+	)
+	RegisterUserApiServer(server, stubServer{})
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	if _, err := NewUserApiClient(conn).Register(context.Background(),
+		&RegisterReq{Email: "a@b.com", Password: "Passw0rd!", Name: "Ann"}); err != nil {
+		t.Fatalf("gRPC register: %v", err)
+	}
+
+	var handlerSpans []sdktrace.ReadOnlySpan
+	byID := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range spans.Ended() {
+		byID[s.SpanContext().SpanID().String()] = s
+		if s.Name() == middleware.HandlerSpanName("/user.UserApi/Register") {
+			handlerSpans = append(handlerSpans, s)
+		}
+	}
+	if len(handlerSpans) != 2 {
+		var names []string
+		for _, s := range spans.Ended() {
+			names = append(names, s.Name())
+		}
+		t.Fatalf("want one handler span per transport, got spans %v", names)
+	}
+	parents := map[string]bool{}
+	for _, s := range handlerSpans {
+		parent, ok := byID[s.Parent().SpanID().String()]
+		if !ok {
+			t.Fatalf("handler span %q has no recorded parent", s.Name())
+		}
+		parents[parent.Name()] = true
+	}
+	for _, want := range []string{"POST /api/v1/auth/register", "user.UserApi/Register"} {
+		if !parents[want] {
+			t.Errorf("no handler span under transport span %q (parents: %v)", want, parents)
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	structpb "google.golang.org/protobuf/types/known/structpb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -387,33 +388,1529 @@ func (x *LogoutRes) GetMessage() string {
 	return ""
 }
 
+type DeleteMeRes struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Message string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	// When the account will be purged unless reactivated (RFC 3339).
+	DeletionScheduledAt string `protobuf:"bytes,2,opt,name=deletion_scheduled_at,json=deletionScheduledAt,proto3" json:"deletion_scheduled_at,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *DeleteMeRes) Reset() {
+	*x = DeleteMeRes{}
+	mi := &file_user_user_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteMeRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteMeRes) ProtoMessage() {}
+
+func (x *DeleteMeRes) ProtoReflect() protoreflect.Message {
+	mi := &file_user_user_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteMeRes.ProtoReflect.Descriptor instead.
+func (*DeleteMeRes) Descriptor() ([]byte, []int) {
+	return file_user_user_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *DeleteMeRes) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *DeleteMeRes) GetDeletionScheduledAt() string {
+	if x != nil {
+		return x.DeletionScheduledAt
+	}
+	return ""
+}
+
+type ReactivateAccountReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Email         string                 `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
+	Password      string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReactivateAccountReq) Reset() {
+	*x = ReactivateAccountReq{}
+	mi := &file_user_user_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReactivateAccountReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReactivateAccountReq) ProtoMessage() {}
+
+func (x *ReactivateAccountReq) ProtoReflect() protoreflect.Message {
+	mi := &file_user_user_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReactivateAccountReq.ProtoReflect.Descriptor instead.
+func (*ReactivateAccountReq) Descriptor() ([]byte, []int) {
+	return file_user_user_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ReactivateAccountReq) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *ReactivateAccountReq) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+type ChangePasswordReq struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	CurrentPassword string                 `protobuf:"bytes,1,opt,name=current_password,json=currentPassword,proto3" json:"current_password,omitempty"`
+	NewPassword     string                 `protobuf:"bytes,2,opt,name=new_password,json=newPassword,proto3" json:"new_password,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ChangePasswordReq) Reset() {
+	*x = ChangePasswordReq{}
+	mi := &file_user_user_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChangePasswordReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangePasswordReq) ProtoMessage() {}
+
+func (x *ChangePasswordReq) ProtoReflect() protoreflect.Message {
+	mi := &file_user_user_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangePasswordReq.ProtoReflect.Descriptor instead.
+func (*ChangePasswordReq) Descriptor() ([]byte, []int) {
+	return file_user_user_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ChangePasswordReq) GetCurrentPassword() string {
+	if x != nil {
+		return x.CurrentPassword
+	}
+	return ""
+}
+
+func (x *ChangePasswordReq) GetNewPassword() string {
+	if x != nil {
+		return x.NewPassword
+	}
+	return ""
+}
+
+type ChangePasswordRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChangePasswordRes) Reset() {
+	*x = ChangePasswordRes{}
+	mi := &file_user_user_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChangePasswordRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangePasswordRes) ProtoMessage() {}
+
+func (x *ChangePasswordRes) ProtoReflect() protoreflect.Message {
+	mi := &file_user_user_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangePasswordRes.ProtoReflect.Descriptor instead.
+func (*ChangePasswordRes) Descriptor() ([]byte, []int) {
+	return file_user_user_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ChangePasswordRes) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type ForgotPasswordReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Email         string                 `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ForgotPasswordReq) Reset() {
+	*x = ForgotPasswordReq{}
+	mi := &file_user_user_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ForgotPasswordReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ForgotPasswordReq) ProtoMessage() {}
+
+func (x *ForgotPasswordReq) ProtoReflect() protoreflect.Message {
+	mi := &file_user_user_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ForgotPasswordReq.ProtoReflect.Descriptor instead.
+func (*ForgotPasswordReq) Descriptor() ([]byte, []int) {
+	return file_user_user_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ForgotPasswordReq) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+type ForgotPasswordRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ForgotPasswordRes) Reset() {
+	*x = ForgotPasswordRes{}
+	mi := &file_user_user_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ForgotPasswordRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ForgotPasswordRes) ProtoMessage() {}
+
+func (x *ForgotPasswordRes) ProtoReflect() protoreflect.Message {
+	mi := &file_user_user_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ForgotPasswordRes.ProtoReflect.Descriptor instead.
+func (*ForgotPasswordRes) Descriptor() ([]byte, []int) {
+	return file_user_user_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ForgotPasswordRes) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type ResetPasswordReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	NewPassword   string                 `protobuf:"bytes,2,opt,name=new_password,json=newPassword,proto3" json:"new_password,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResetPasswordReq) Reset() {
+	*x = ResetPasswordReq{}
+	mi := &file_user_user_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResetPasswordReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResetPasswordReq) ProtoMessage() {}
+
+func (x *ResetPasswordReq) ProtoReflect() protoreflect.Message {
+	mi := &file_user_user_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResetPasswordReq.ProtoReflect.Descriptor instead.
+func (*ResetPasswordReq) Descriptor() ([]byte, []int) {
+	return file_user_user_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ResetPasswordReq) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *ResetPasswordReq) GetNewPassword() string {
+	if x != nil {
+		return x.NewPassword
+	}
+	return ""
+}
+
+type ResetPasswordRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResetPasswordRes) Reset() {
+	*x = ResetPasswordRes{}
+	mi := &file_user_user_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResetPasswordRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResetPasswordRes) ProtoMessage() {}
+
+func (x *ResetPasswordRes) ProtoReflect() protoreflect.Message {
+	mi := &file_user_user_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResetPasswordRes.ProtoReflect.Descriptor instead.
+func (*ResetPasswordRes) Descriptor() ([]byte, []int) {
+	return file_user_user_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ResetPasswordRes) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type IntrospectBatchReq struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Tokens to validate (1-100). Duplicates are validated once.
+	Tokens        []string `protobuf:"bytes,1,rep,name=tokens,proto3" json:"tokens,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IntrospectBatchReq) Reset() {
+	*x = IntrospectBatchReq{}
+	mi := &file_user_user_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IntrospectBatchReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IntrospectBatchReq) ProtoMessage() {}
+
+func (x *IntrospectBatchReq) ProtoReflect() protoreflect.Message {
+	mi := &file_user_user_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IntrospectBatchReq.ProtoReflect.Descriptor instead.
+func (*IntrospectBatchReq) Descriptor() ([]byte, []int) {
+	return file_user_user_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *IntrospectBatchReq) GetTokens() []string {
+	if x != nil {
+		return x.Tokens
+	}
+	return nil
+}
+
+type IntrospectBatchRes struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// One result per requested token, in request order.
+	Results       []*TokenIntrospection `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IntrospectBatchRes) Reset() {
+	*x = IntrospectBatchRes{}
+	mi := &file_user_user_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IntrospectBatchRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IntrospectBatchRes) ProtoMessage() {}
+
+func (x *IntrospectBatchRes) ProtoReflect() protoreflect.Message {
+	mi := &file_user_user_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IntrospectBatchRes.ProtoReflect.Descriptor instead.
+func (*IntrospectBatchRes) Descriptor() ([]byte, []int) {
+	return file_user_user_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *IntrospectBatchRes) GetResults() []*TokenIntrospection {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type TokenIntrospection struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Active bool                   `protobuf:"varint,1,opt,name=active,proto3" json:"active,omitempty"`
+	// Set only when active.
+	Claims *TokenClaims `protobuf:"bytes,2,opt,name=claims,proto3" json:"claims,omitempty"`
+	// Why the token is not active: "invalid", "expired" or "revoked".
+	Reason        string `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TokenIntrospection) Reset() {
+	*x = TokenIntrospection{}
+	mi := &file_user_user_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TokenIntrospection) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TokenIntrospection) ProtoMessage() {}
+
+func (x *TokenIntrospection) ProtoReflect() protoreflect.Message {
+	mi := &file_user_user_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TokenIntrospection.ProtoReflect.Descriptor instead.
+func (*TokenIntrospection) Descriptor() ([]byte, []int) {
+	return file_user_user_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *TokenIntrospection) GetActive() bool {
+	if x != nil {
+		return x.Active
+	}
+	return false
+}
+
+func (x *TokenIntrospection) GetClaims() *TokenClaims {
+	if x != nil {
+		return x.Claims
+	}
+	return nil
+}
+
+func (x *TokenIntrospection) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type TokenClaims struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	UserId      string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Email       string                 `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	Roles       []string               `protobuf:"bytes,3,rep,name=roles,proto3" json:"roles,omitempty"`
+	CompanyCode string                 `protobuf:"bytes,4,opt,name=company_code,json=companyCode,proto3" json:"company_code,omitempty"`
+	ExpiresAt   string                 `protobuf:"bytes,5,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	// Set on impersonation tokens: the superadmin acting as user_id.
+	ActorId       string `protobuf:"bytes,6,opt,name=actor_id,json=actorId,proto3" json:"actor_id,omitempty"`
+	Impersonation bool   `protobuf:"varint,7,opt,name=impersonation,proto3" json:"impersonation,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TokenClaims) Reset() {
+	*x = TokenClaims{}
+	mi := &file_user_user_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TokenClaims) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TokenClaims) ProtoMessage() {}
+
+func (x *TokenClaims) ProtoReflect() protoreflect.Message {
+	mi := &file_user_user_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TokenClaims.ProtoReflect.Descriptor instead.
+func (*TokenClaims) Descriptor() ([]byte, []int) {
+	return file_user_user_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *TokenClaims) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *TokenClaims) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *TokenClaims) GetRoles() []string {
+	if x != nil {
+		return x.Roles
+	}
+	return nil
+}
+
+func (x *TokenClaims) GetCompanyCode() string {
+	if x != nil {
+		return x.CompanyCode
+	}
+	return ""
+}
+
+func (x *TokenClaims) GetExpiresAt() string {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return ""
+}
+
+func (x *TokenClaims) GetActorId() string {
+	if x != nil {
+		return x.ActorId
+	}
+	return ""
+}
+
+func (x *TokenClaims) GetImpersonation() bool {
+	if x != nil {
+		return x.Impersonation
+	}
+	return false
+}
+
 type UserProfile struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Email     string                 `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	Name      string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Phone     string                 `protobuf:"bytes,4,opt,name=phone,proto3" json:"phone,omitempty"`
+	Status    string                 `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`
+	CreatedAt string                 `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// Last modification, RFC 3339 with fractional seconds. It is the version
+	// GetUser and UpdateUser also send as the ETag header; pass it back as
+	// UpdateUserReq.expected_updated_at (or If-Match) to update only if the
+	// user is unchanged.
+	UpdatedAt string `protobuf:"bytes,7,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// When the user was soft-deleted (RFC 3339); empty for live users. Only
+	// ListUsers with include_deleted returns deleted users.
+	DeletedAt     string `protobuf:"bytes,8,opt,name=deleted_at,json=deletedAt,proto3" json:"deleted_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserProfile) Reset() {
+	*x = UserProfile{}
+	mi := &file_user_user_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserProfile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserProfile) ProtoMessage() {}
+
+func (x *UserProfile) ProtoReflect() protoreflect.Message {
+	mi := &file_user_user_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserProfile.ProtoReflect.Descriptor instead.
+func (*UserProfile) Descriptor() ([]byte, []int) {
+	return file_user_user_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *UserProfile) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UserProfile) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *UserProfile) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *UserProfile) GetPhone() string {
+	if x != nil {
+		return x.Phone
+	}
+	return ""
+}
+
+func (x *UserProfile) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *UserProfile) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
+func (x *UserProfile) GetUpdatedAt() string {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return ""
+}
+
+func (x *UserProfile) GetDeletedAt() string {
+	if x != nil {
+		return x.DeletedAt
+	}
+	return ""
+}
+
+type ListUsersReq struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Page      int32                  `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`
+	Size      int32                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	Search    string                 `protobuf:"bytes,3,opt,name=search,proto3" json:"search,omitempty"`
+	SortBy    string                 `protobuf:"bytes,4,opt,name=sort_by,json=sortBy,proto3" json:"sort_by,omitempty"`
+	SortOrder string                 `protobuf:"bytes,5,opt,name=sort_order,json=sortOrder,proto3" json:"sort_order,omitempty"`
+	// Comma-separated UserProfile fields to return (e.g. "id,name,email").
+	// Empty returns every field.
+	Fields string `protobuf:"bytes,6,opt,name=fields,proto3" json:"fields,omitempty"`
+	// Opaque keyset cursor (a previous response's pagination.nextCursor).
+	// When set, page is ignored and results continue after the cursor row.
+	Cursor string `protobuf:"bytes,7,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	// Also list soft-deleted users, with deleted_at set.
+	IncludeDeleted bool `protobuf:"varint,8,opt,name=include_deleted,json=includeDeleted,proto3" json:"include_deleted,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ListUsersReq) Reset() {
+	*x = ListUsersReq{}
+	mi := &file_user_user_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListUsersReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListUsersReq) ProtoMessage() {}
+
+func (x *ListUsersReq) ProtoReflect() protoreflect.Message {
+	mi := &file_user_user_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListUsersReq.ProtoReflect.Descriptor instead.
+func (*ListUsersReq) Descriptor() ([]byte, []int) {
+	return file_user_user_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ListUsersReq) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListUsersReq) GetSize() int32 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *ListUsersReq) GetSearch() string {
+	if x != nil {
+		return x.Search
+	}
+	return ""
+}
+
+func (x *ListUsersReq) GetSortBy() string {
+	if x != nil {
+		return x.SortBy
+	}
+	return ""
+}
+
+func (x *ListUsersReq) GetSortOrder() string {
+	if x != nil {
+		return x.SortOrder
+	}
+	return ""
+}
+
+func (x *ListUsersReq) GetFields() string {
+	if x != nil {
+		return x.Fields
+	}
+	return ""
+}
+
+func (x *ListUsersReq) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *ListUsersReq) GetIncludeDeleted() bool {
+	if x != nil {
+		return x.IncludeDeleted
+	}
+	return false
+}
+
+type ListUsersRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Users         []*UserProfile         `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+	Pagination    *Pagination            `protobuf:"bytes,2,opt,name=pagination,proto3" json:"pagination,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListUsersRes) Reset() {
+	*x = ListUsersRes{}
+	mi := &file_user_user_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListUsersRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListUsersRes) ProtoMessage() {}
+
+func (x *ListUsersRes) ProtoReflect() protoreflect.Message {
+	mi := &file_user_user_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListUsersRes.ProtoReflect.Descriptor instead.
+func (*ListUsersRes) Descriptor() ([]byte, []int) {
+	return file_user_user_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ListUsersRes) GetUsers() []*UserProfile {
+	if x != nil {
+		return x.Users
+	}
+	return nil
+}
+
+func (x *ListUsersRes) GetPagination() *Pagination {
+	if x != nil {
+		return x.Pagination
+	}
+	return nil
+}
+
+type Pagination struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Page       int32                  `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`
+	Size       int32                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	Total      int64                  `protobuf:"varint,3,opt,name=total,proto3" json:"total,omitempty"`
+	TotalPages int32                  `protobuf:"varint,4,opt,name=total_pages,json=totalPages,proto3" json:"total_pages,omitempty"`
+	// Cursor for the next page in created_at order; empty on the last page
+	// or when sorting by another column.
+	NextCursor string `protobuf:"bytes,5,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	// The sort actually applied, after defaults and corrections.
+	SortBy        string `protobuf:"bytes,6,opt,name=sort_by,json=sortBy,proto3" json:"sort_by,omitempty"`
+	SortOrder     string `protobuf:"bytes,7,opt,name=sort_order,json=sortOrder,proto3" json:"sort_order,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Pagination) Reset() {
+	*x = Pagination{}
+	mi := &file_user_user_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Pagination) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Pagination) ProtoMessage() {}
+
+func (x *Pagination) ProtoReflect() protoreflect.Message {
+	mi := &file_user_user_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Pagination.ProtoReflect.Descriptor instead.
+func (*Pagination) Descriptor() ([]byte, []int) {
+	return file_user_user_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *Pagination) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *Pagination) GetSize() int32 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Pagination) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *Pagination) GetTotalPages() int32 {
+	if x != nil {
+		return x.TotalPages
+	}
+	return 0
+}
+
+func (x *Pagination) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+func (x *Pagination) GetSortBy() string {
+	if x != nil {
+		return x.SortBy
+	}
+	return ""
+}
+
+func (x *Pagination) GetSortOrder() string {
+	if x != nil {
+		return x.SortOrder
+	}
+	return ""
+}
+
+type GetUserReq struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Comma-separated UserProfile fields to return; empty returns all.
+	Fields        string `protobuf:"bytes,2,opt,name=fields,proto3" json:"fields,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUserReq) Reset() {
+	*x = GetUserReq{}
+	mi := &file_user_user_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserReq) ProtoMessage() {}
+
+func (x *GetUserReq) ProtoReflect() protoreflect.Message {
+	mi := &file_user_user_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserReq.ProtoReflect.Descriptor instead.
+func (*GetUserReq) Descriptor() ([]byte, []int) {
+	return file_user_user_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *GetUserReq) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *GetUserReq) GetFields() string {
+	if x != nil {
+		return x.Fields
+	}
+	return ""
+}
+
+type CreateUserReq struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Email string                 `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
+	// Required unless send_invite is set; the two are mutually exclusive.
+	Password string `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	Name     string `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Phone    string `protobuf:"bytes,4,opt,name=phone,proto3" json:"phone,omitempty"`
+	// Roles to grant; empty grants the self-registration defaults. The
+	// caller's own roles must grant every permission these would.
+	Roles       []string `protobuf:"bytes,5,rep,name=roles,proto3" json:"roles,omitempty"`
+	CompanyCode string   `protobuf:"bytes,6,opt,name=company_code,json=companyCode,proto3" json:"company_code,omitempty"`
+	// active, inactive or pending; empty means active.
+	Status string `protobuf:"bytes,7,opt,name=status,proto3" json:"status,omitempty"`
+	// Email the user a single-use link to set their password instead.
+	SendInvite    bool `protobuf:"varint,8,opt,name=send_invite,json=sendInvite,proto3" json:"send_invite,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateUserReq) Reset() {
+	*x = CreateUserReq{}
+	mi := &file_user_user_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateUserReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateUserReq) ProtoMessage() {}
+
+func (x *CreateUserReq) ProtoReflect() protoreflect.Message {
+	mi := &file_user_user_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateUserReq.ProtoReflect.Descriptor instead.
+func (*CreateUserReq) Descriptor() ([]byte, []int) {
+	return file_user_user_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *CreateUserReq) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *CreateUserReq) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+func (x *CreateUserReq) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateUserReq) GetPhone() string {
+	if x != nil {
+		return x.Phone
+	}
+	return ""
+}
+
+func (x *CreateUserReq) GetRoles() []string {
+	if x != nil {
+		return x.Roles
+	}
+	return nil
+}
+
+func (x *CreateUserReq) GetCompanyCode() string {
+	if x != nil {
+		return x.CompanyCode
+	}
+	return ""
+}
+
+func (x *CreateUserReq) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *CreateUserReq) GetSendInvite() bool {
+	if x != nil {
+		return x.SendInvite
+	}
+	return false
+}
+
+type UpdateUserReq struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Unset fields are left unchanged. An empty phone clears it; name and
+	// status cannot be cleared.
+	Name   *string `protobuf:"bytes,2,opt,name=name,proto3,oneof" json:"name,omitempty"`
+	Phone  *string `protobuf:"bytes,3,opt,name=phone,proto3,oneof" json:"phone,omitempty"`
+	Status *string `protobuf:"bytes,4,opt,name=status,proto3,oneof" json:"status,omitempty"`
+	// When set, the update applies only if the user's updatedAt still
+	// equals it, failing with FAILED_PRECONDITION (HTTP 412) otherwise.
+	// Over REST the If-Match header carries the same condition.
+	ExpectedUpdatedAt string `protobuf:"bytes,5,opt,name=expected_updated_at,json=expectedUpdatedAt,proto3" json:"expected_updated_at,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *UpdateUserReq) Reset() {
+	*x = UpdateUserReq{}
+	mi := &file_user_user_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateUserReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateUserReq) ProtoMessage() {}
+
+func (x *UpdateUserReq) ProtoReflect() protoreflect.Message {
+	mi := &file_user_user_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateUserReq.ProtoReflect.Descriptor instead.
+func (*UpdateUserReq) Descriptor() ([]byte, []int) {
+	return file_user_user_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *UpdateUserReq) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateUserReq) GetName() string {
+	if x != nil && x.Name != nil {
+		return *x.Name
+	}
+	return ""
+}
+
+func (x *UpdateUserReq) GetPhone() string {
+	if x != nil && x.Phone != nil {
+		return *x.Phone
+	}
+	return ""
+}
+
+func (x *UpdateUserReq) GetStatus() string {
+	if x != nil && x.Status != nil {
+		return *x.Status
+	}
+	return ""
+}
+
+func (x *UpdateUserReq) GetExpectedUpdatedAt() string {
+	if x != nil {
+		return x.ExpectedUpdatedAt
+	}
+	return ""
+}
+
+type DeleteUserReq struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Remove the user for good instead of soft-deleting it. Needs the
+	// users.purge permission.
+	Permanent     bool `protobuf:"varint,2,opt,name=permanent,proto3" json:"permanent,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteUserReq) Reset() {
+	*x = DeleteUserReq{}
+	mi := &file_user_user_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteUserReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteUserReq) ProtoMessage() {}
+
+func (x *DeleteUserReq) ProtoReflect() protoreflect.Message {
+	mi := &file_user_user_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteUserReq.ProtoReflect.Descriptor instead.
+func (*DeleteUserReq) Descriptor() ([]byte, []int) {
+	return file_user_user_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *DeleteUserReq) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *DeleteUserReq) GetPermanent() bool {
+	if x != nil {
+		return x.Permanent
+	}
+	return false
+}
+
+type RestoreUserReq struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Email         string                 `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
-	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
-	Phone         string                 `protobuf:"bytes,4,opt,name=phone,proto3" json:"phone,omitempty"`
-	Status        string                 `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`
-	CreatedAt     string                 `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *UserProfile) Reset() {
-	*x = UserProfile{}
-	mi := &file_user_user_proto_msgTypes[7]
+func (x *RestoreUserReq) Reset() {
+	*x = RestoreUserReq{}
+	mi := &file_user_user_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreUserReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreUserReq) ProtoMessage() {}
+
+func (x *RestoreUserReq) ProtoReflect() protoreflect.Message {
+	mi := &file_user_user_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreUserReq.ProtoReflect.Descriptor instead.
+func (*RestoreUserReq) Descriptor() ([]byte, []int) {
+	return file_user_user_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *RestoreUserReq) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DeleteUserRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteUserRes) Reset() {
+	*x = DeleteUserRes{}
+	mi := &file_user_user_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteUserRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteUserRes) ProtoMessage() {}
+
+func (x *DeleteUserRes) ProtoReflect() protoreflect.Message {
+	mi := &file_user_user_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteUserRes.ProtoReflect.Descriptor instead.
+func (*DeleteUserRes) Descriptor() ([]byte, []int) {
+	return file_user_user_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *DeleteUserRes) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type ImpersonateUserReq struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Token lifetime in seconds; 0 or anything above 900 (15 minutes) is
+	// capped at 900.
+	TtlSeconds int32 `protobuf:"varint,2,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+	// Why the session is needed (e.g. a ticket reference); recorded in the
+	// audit log.
+	Reason        string `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImpersonateUserReq) Reset() {
+	*x = ImpersonateUserReq{}
+	mi := &file_user_user_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImpersonateUserReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImpersonateUserReq) ProtoMessage() {}
+
+func (x *ImpersonateUserReq) ProtoReflect() protoreflect.Message {
+	mi := &file_user_user_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImpersonateUserReq.ProtoReflect.Descriptor instead.
+func (*ImpersonateUserReq) Descriptor() ([]byte, []int) {
+	return file_user_user_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *ImpersonateUserReq) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ImpersonateUserReq) GetTtlSeconds() int32 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+func (x *ImpersonateUserReq) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type ImpersonateUserRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	ExpiresAt     string                 `protobuf:"bytes,2,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	User          *UserProfile           `protobuf:"bytes,3,opt,name=user,proto3" json:"user,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImpersonateUserRes) Reset() {
+	*x = ImpersonateUserRes{}
+	mi := &file_user_user_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImpersonateUserRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImpersonateUserRes) ProtoMessage() {}
+
+func (x *ImpersonateUserRes) ProtoReflect() protoreflect.Message {
+	mi := &file_user_user_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImpersonateUserRes.ProtoReflect.Descriptor instead.
+func (*ImpersonateUserRes) Descriptor() ([]byte, []int) {
+	return file_user_user_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ImpersonateUserRes) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *ImpersonateUserRes) GetExpiresAt() string {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return ""
+}
+
+func (x *ImpersonateUserRes) GetUser() *UserProfile {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+type RuntimeConfigEntry struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Key   string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// int, bool, duration (Go syntax, e.g. "90s") or string.
+	Type         string `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Value        string `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	DefaultValue string `protobuf:"bytes,4,opt,name=default_value,json=defaultValue,proto3" json:"default_value,omitempty"`
+	// Whether value was set through UpdateRuntimeConfig rather than being
+	// the default.
+	Overridden    bool   `protobuf:"varint,5,opt,name=overridden,proto3" json:"overridden,omitempty"`
+	Description   string `protobuf:"bytes,6,opt,name=description,proto3" json:"description,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RuntimeConfigEntry) Reset() {
+	*x = RuntimeConfigEntry{}
+	mi := &file_user_user_proto_msgTypes[31]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UserProfile) String() string {
+func (x *RuntimeConfigEntry) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UserProfile) ProtoMessage() {}
+func (*RuntimeConfigEntry) ProtoMessage() {}
 
-func (x *UserProfile) ProtoReflect() protoreflect.Message {
-	mi := &file_user_user_proto_msgTypes[7]
+func (x *RuntimeConfigEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_user_user_proto_msgTypes[31]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -424,79 +1921,75 @@ func (x *UserProfile) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UserProfile.ProtoReflect.Descriptor instead.
-func (*UserProfile) Descriptor() ([]byte, []int) {
-	return file_user_user_proto_rawDescGZIP(), []int{7}
+// Deprecated: Use RuntimeConfigEntry.ProtoReflect.Descriptor instead.
+func (*RuntimeConfigEntry) Descriptor() ([]byte, []int) {
+	return file_user_user_proto_rawDescGZIP(), []int{31}
 }
 
-func (x *UserProfile) GetId() string {
+func (x *RuntimeConfigEntry) GetKey() string {
 	if x != nil {
-		return x.Id
+		return x.Key
 	}
 	return ""
 }
 
-func (x *UserProfile) GetEmail() string {
+func (x *RuntimeConfigEntry) GetType() string {
 	if x != nil {
-		return x.Email
+		return x.Type
 	}
 	return ""
 }
 
-func (x *UserProfile) GetName() string {
+func (x *RuntimeConfigEntry) GetValue() string {
 	if x != nil {
-		return x.Name
+		return x.Value
 	}
 	return ""
 }
 
-func (x *UserProfile) GetPhone() string {
+func (x *RuntimeConfigEntry) GetDefaultValue() string {
 	if x != nil {
-		return x.Phone
+		return x.DefaultValue
 	}
 	return ""
 }
 
-func (x *UserProfile) GetStatus() string {
+func (x *RuntimeConfigEntry) GetOverridden() bool {
 	if x != nil {
-		return x.Status
+		return x.Overridden
 	}
-	return ""
+	return false
 }
 
-func (x *UserProfile) GetCreatedAt() string {
+func (x *RuntimeConfigEntry) GetDescription() string {
 	if x != nil {
-		return x.CreatedAt
+		return x.Description
 	}
 	return ""
 }
 
-type ListUsersReq struct {
+type RuntimeConfig struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Page          int32                  `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`
-	Size          int32                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
-	Search        string                 `protobuf:"bytes,3,opt,name=search,proto3" json:"search,omitempty"`
-	SortBy        string                 `protobuf:"bytes,4,opt,name=sort_by,json=sortBy,proto3" json:"sort_by,omitempty"`
-	SortOrder     string                 `protobuf:"bytes,5,opt,name=sort_order,json=sortOrder,proto3" json:"sort_order,omitempty"`
+	Entries       []*RuntimeConfigEntry  `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListUsersReq) Reset() {
-	*x = ListUsersReq{}
-	mi := &file_user_user_proto_msgTypes[8]
+func (x *RuntimeConfig) Reset() {
+	*x = RuntimeConfig{}
+	mi := &file_user_user_proto_msgTypes[32]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListUsersReq) String() string {
+func (x *RuntimeConfig) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListUsersReq) ProtoMessage() {}
+func (*RuntimeConfig) ProtoMessage() {}
 
-func (x *ListUsersReq) ProtoReflect() protoreflect.Message {
-	mi := &file_user_user_proto_msgTypes[8]
+func (x *RuntimeConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_user_user_proto_msgTypes[32]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -507,69 +2000,43 @@ func (x *ListUsersReq) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListUsersReq.ProtoReflect.Descriptor instead.
-func (*ListUsersReq) Descriptor() ([]byte, []int) {
-	return file_user_user_proto_rawDescGZIP(), []int{8}
-}
-
-func (x *ListUsersReq) GetPage() int32 {
-	if x != nil {
-		return x.Page
-	}
-	return 0
-}
-
-func (x *ListUsersReq) GetSize() int32 {
-	if x != nil {
-		return x.Size
-	}
-	return 0
-}
-
-func (x *ListUsersReq) GetSearch() string {
-	if x != nil {
-		return x.Search
-	}
-	return ""
-}
-
-func (x *ListUsersReq) GetSortBy() string {
-	if x != nil {
-		return x.SortBy
-	}
-	return ""
+// Deprecated: Use RuntimeConfig.ProtoReflect.Descriptor instead.
+func (*RuntimeConfig) Descriptor() ([]byte, []int) {
+	return file_user_user_proto_rawDescGZIP(), []int{32}
 }
 
-func (x *ListUsersReq) GetSortOrder() string {
+func (x *RuntimeConfig) GetEntries() []*RuntimeConfigEntry {
 	if x != nil {
-		return x.SortOrder
+		return x.Entries
 	}
-	return ""
+	return nil
 }
 
-type ListUsersRes struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Users         []*UserProfile         `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
-	Pagination    *Pagination            `protobuf:"bytes,2,opt,name=pagination,proto3" json:"pagination,omitempty"`
+type UpdateRuntimeConfigReq struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// New values by key, each written as a string of the key's type.
+	Values map[string]string `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Keys to revert to their defaults.
+	Reset_        []string `protobuf:"bytes,2,rep,name=reset,proto3" json:"reset,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListUsersRes) Reset() {
-	*x = ListUsersRes{}
-	mi := &file_user_user_proto_msgTypes[9]
+func (x *UpdateRuntimeConfigReq) Reset() {
+	*x = UpdateRuntimeConfigReq{}
+	mi := &file_user_user_proto_msgTypes[33]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListUsersRes) String() string {
+func (x *UpdateRuntimeConfigReq) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListUsersRes) ProtoMessage() {}
+func (*UpdateRuntimeConfigReq) ProtoMessage() {}
 
-func (x *ListUsersRes) ProtoReflect() protoreflect.Message {
-	mi := &file_user_user_proto_msgTypes[9]
+func (x *UpdateRuntimeConfigReq) ProtoReflect() protoreflect.Message {
+	mi := &file_user_user_proto_msgTypes[33]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -580,50 +2047,55 @@ func (x *ListUsersRes) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListUsersRes.ProtoReflect.Descriptor instead.
-func (*ListUsersRes) Descriptor() ([]byte, []int) {
-	return file_user_user_proto_rawDescGZIP(), []int{9}
+// Deprecated: Use UpdateRuntimeConfigReq.ProtoReflect.Descriptor instead.
+func (*UpdateRuntimeConfigReq) Descriptor() ([]byte, []int) {
+	return file_user_user_proto_rawDescGZIP(), []int{33}
 }
 
-func (x *ListUsersRes) GetUsers() []*UserProfile {
+func (x *UpdateRuntimeConfigReq) GetValues() map[string]string {
 	if x != nil {
-		return x.Users
+		return x.Values
 	}
 	return nil
 }
 
-func (x *ListUsersRes) GetPagination() *Pagination {
+func (x *UpdateRuntimeConfigReq) GetReset_() []string {
 	if x != nil {
-		return x.Pagination
+		return x.Reset_
 	}
 	return nil
 }
 
-type Pagination struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Page          int32                  `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`
-	Size          int32                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
-	Total         int64                  `protobuf:"varint,3,opt,name=total,proto3" json:"total,omitempty"`
-	TotalPages    int32                  `protobuf:"varint,4,opt,name=total_pages,json=totalPages,proto3" json:"total_pages,omitempty"`
+type ListAuditLogsReq struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Page    int32                  `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`
+	Size    int32                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	ActorId string                 `protobuf:"bytes,3,opt,name=actor_id,json=actorId,proto3" json:"actor_id,omitempty"`
+	// e.g. "user".
+	ResourceType string `protobuf:"bytes,4,opt,name=resource_type,json=resourceType,proto3" json:"resource_type,omitempty"`
+	ResourceId   string `protobuf:"bytes,5,opt,name=resource_id,json=resourceId,proto3" json:"resource_id,omitempty"`
+	// RFC 3339 bounds on created_at: entries at or after from and before to.
+	From          string `protobuf:"bytes,6,opt,name=from,proto3" json:"from,omitempty"`
+	To            string `protobuf:"bytes,7,opt,name=to,proto3" json:"to,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Pagination) Reset() {
-	*x = Pagination{}
-	mi := &file_user_user_proto_msgTypes[10]
+func (x *ListAuditLogsReq) Reset() {
+	*x = ListAuditLogsReq{}
+	mi := &file_user_user_proto_msgTypes[34]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Pagination) String() string {
+func (x *ListAuditLogsReq) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Pagination) ProtoMessage() {}
+func (*ListAuditLogsReq) ProtoMessage() {}
 
-func (x *Pagination) ProtoReflect() protoreflect.Message {
-	mi := &file_user_user_proto_msgTypes[10]
+func (x *ListAuditLogsReq) ProtoReflect() protoreflect.Message {
+	mi := &file_user_user_proto_msgTypes[34]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -634,108 +2106,95 @@ func (x *Pagination) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Pagination.ProtoReflect.Descriptor instead.
-func (*Pagination) Descriptor() ([]byte, []int) {
-	return file_user_user_proto_rawDescGZIP(), []int{10}
+// Deprecated: Use ListAuditLogsReq.ProtoReflect.Descriptor instead.
+func (*ListAuditLogsReq) Descriptor() ([]byte, []int) {
+	return file_user_user_proto_rawDescGZIP(), []int{34}
 }
 
-func (x *Pagination) GetPage() int32 {
+func (x *ListAuditLogsReq) GetPage() int32 {
 	if x != nil {
 		return x.Page
 	}
 	return 0
 }
 
-func (x *Pagination) GetSize() int32 {
+func (x *ListAuditLogsReq) GetSize() int32 {
 	if x != nil {
 		return x.Size
 	}
 	return 0
 }
 
-func (x *Pagination) GetTotal() int64 {
+func (x *ListAuditLogsReq) GetActorId() string {
 	if x != nil {
-		return x.Total
+		return x.ActorId
 	}
-	return 0
+	return ""
 }
 
-func (x *Pagination) GetTotalPages() int32 {
+func (x *ListAuditLogsReq) GetResourceType() string {
 	if x != nil {
-		return x.TotalPages
+		return x.ResourceType
 	}
-	return 0
-}
-
-type GetUserReq struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *GetUserReq) Reset() {
-	*x = GetUserReq{}
-	mi := &file_user_user_proto_msgTypes[11]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
-}
-
-func (x *GetUserReq) String() string {
-	return protoimpl.X.MessageStringOf(x)
+	return ""
 }
 
-func (*GetUserReq) ProtoMessage() {}
-
-func (x *GetUserReq) ProtoReflect() protoreflect.Message {
-	mi := &file_user_user_proto_msgTypes[11]
+func (x *ListAuditLogsReq) GetResourceId() string {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.ResourceId
 	}
-	return mi.MessageOf(x)
+	return ""
 }
 
-// Deprecated: Use GetUserReq.ProtoReflect.Descriptor instead.
-func (*GetUserReq) Descriptor() ([]byte, []int) {
-	return file_user_user_proto_rawDescGZIP(), []int{11}
+func (x *ListAuditLogsReq) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
 }
 
-func (x *GetUserReq) GetId() string {
+func (x *ListAuditLogsReq) GetTo() string {
 	if x != nil {
-		return x.Id
+		return x.To
 	}
 	return ""
 }
 
-type UpdateUserReq struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	Phone         string                 `protobuf:"bytes,3,opt,name=phone,proto3" json:"phone,omitempty"`
-	Status        string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
+type AuditLogEntry struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// The caller, or the superadmin behind an impersonation token; empty for
+	// changes made without one.
+	ActorId string `protobuf:"bytes,2,opt,name=actor_id,json=actorId,proto3" json:"actor_id,omitempty"`
+	// create, update, delete, restore or purge.
+	Action       string `protobuf:"bytes,3,opt,name=action,proto3" json:"action,omitempty"`
+	ResourceType string `protobuf:"bytes,4,opt,name=resource_type,json=resourceType,proto3" json:"resource_type,omitempty"`
+	ResourceId   string `protobuf:"bytes,5,opt,name=resource_id,json=resourceId,proto3" json:"resource_id,omitempty"`
+	// The resource before and after the change; before is unset for a
+	// creation and after for a deletion.
+	Before        *structpb.Struct `protobuf:"bytes,6,opt,name=before,proto3" json:"before,omitempty"`
+	After         *structpb.Struct `protobuf:"bytes,7,opt,name=after,proto3" json:"after,omitempty"`
+	RequestId     string           `protobuf:"bytes,8,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	CreatedAt     string           `protobuf:"bytes,9,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *UpdateUserReq) Reset() {
-	*x = UpdateUserReq{}
-	mi := &file_user_user_proto_msgTypes[12]
+func (x *AuditLogEntry) Reset() {
+	*x = AuditLogEntry{}
+	mi := &file_user_user_proto_msgTypes[35]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UpdateUserReq) String() string {
+func (x *AuditLogEntry) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UpdateUserReq) ProtoMessage() {}
+func (*AuditLogEntry) ProtoMessage() {}
 
-func (x *UpdateUserReq) ProtoReflect() protoreflect.Message {
-	mi := &file_user_user_proto_msgTypes[12]
+func (x *AuditLogEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_user_user_proto_msgTypes[35]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -746,105 +2205,97 @@ func (x *UpdateUserReq) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UpdateUserReq.ProtoReflect.Descriptor instead.
-func (*UpdateUserReq) Descriptor() ([]byte, []int) {
-	return file_user_user_proto_rawDescGZIP(), []int{12}
+// Deprecated: Use AuditLogEntry.ProtoReflect.Descriptor instead.
+func (*AuditLogEntry) Descriptor() ([]byte, []int) {
+	return file_user_user_proto_rawDescGZIP(), []int{35}
 }
 
-func (x *UpdateUserReq) GetId() string {
+func (x *AuditLogEntry) GetId() string {
 	if x != nil {
 		return x.Id
 	}
 	return ""
 }
 
-func (x *UpdateUserReq) GetName() string {
+func (x *AuditLogEntry) GetActorId() string {
 	if x != nil {
-		return x.Name
+		return x.ActorId
 	}
 	return ""
 }
 
-func (x *UpdateUserReq) GetPhone() string {
+func (x *AuditLogEntry) GetAction() string {
 	if x != nil {
-		return x.Phone
+		return x.Action
 	}
 	return ""
 }
 
-func (x *UpdateUserReq) GetStatus() string {
+func (x *AuditLogEntry) GetResourceType() string {
 	if x != nil {
-		return x.Status
+		return x.ResourceType
 	}
 	return ""
 }
 
-type DeleteUserReq struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *DeleteUserReq) Reset() {
-	*x = DeleteUserReq{}
-	mi := &file_user_user_proto_msgTypes[13]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *AuditLogEntry) GetResourceId() string {
+	if x != nil {
+		return x.ResourceId
+	}
+	return ""
 }
 
-func (x *DeleteUserReq) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *AuditLogEntry) GetBefore() *structpb.Struct {
+	if x != nil {
+		return x.Before
+	}
+	return nil
 }
 
-func (*DeleteUserReq) ProtoMessage() {}
-
-func (x *DeleteUserReq) ProtoReflect() protoreflect.Message {
-	mi := &file_user_user_proto_msgTypes[13]
+func (x *AuditLogEntry) GetAfter() *structpb.Struct {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.After
 	}
-	return mi.MessageOf(x)
+	return nil
 }
 
-// Deprecated: Use DeleteUserReq.ProtoReflect.Descriptor instead.
-func (*DeleteUserReq) Descriptor() ([]byte, []int) {
-	return file_user_user_proto_rawDescGZIP(), []int{13}
+func (x *AuditLogEntry) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
 }
 
-func (x *DeleteUserReq) GetId() string {
+func (x *AuditLogEntry) GetCreatedAt() string {
 	if x != nil {
-		return x.Id
+		return x.CreatedAt
 	}
 	return ""
 }
 
-type DeleteUserRes struct {
+type ListAuditLogsRes struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Entries       []*AuditLogEntry       `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	Pagination    *Pagination            `protobuf:"bytes,2,opt,name=pagination,proto3" json:"pagination,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DeleteUserRes) Reset() {
-	*x = DeleteUserRes{}
-	mi := &file_user_user_proto_msgTypes[14]
+func (x *ListAuditLogsRes) Reset() {
+	*x = ListAuditLogsRes{}
+	mi := &file_user_user_proto_msgTypes[36]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteUserRes) String() string {
+func (x *ListAuditLogsRes) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteUserRes) ProtoMessage() {}
+func (*ListAuditLogsRes) ProtoMessage() {}
 
-func (x *DeleteUserRes) ProtoReflect() protoreflect.Message {
-	mi := &file_user_user_proto_msgTypes[14]
+func (x *ListAuditLogsRes) ProtoReflect() protoreflect.Message {
+	mi := &file_user_user_proto_msgTypes[36]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -855,23 +2306,30 @@ func (x *DeleteUserRes) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteUserRes.ProtoReflect.Descriptor instead.
-func (*DeleteUserRes) Descriptor() ([]byte, []int) {
-	return file_user_user_proto_rawDescGZIP(), []int{14}
+// Deprecated: Use ListAuditLogsRes.ProtoReflect.Descriptor instead.
+func (*ListAuditLogsRes) Descriptor() ([]byte, []int) {
+	return file_user_user_proto_rawDescGZIP(), []int{36}
 }
 
-func (x *DeleteUserRes) GetMessage() string {
+func (x *ListAuditLogsRes) GetEntries() []*AuditLogEntry {
 	if x != nil {
-		return x.Message
+		return x.Entries
 	}
-	return ""
+	return nil
+}
+
+func (x *ListAuditLogsRes) GetPagination() *Pagination {
+	if x != nil {
+		return x.Pagination
+	}
+	return nil
 }
 
 var File_user_user_proto protoreflect.FileDescriptor
 
 const file_user_user_proto_rawDesc = "" +
 	"\n" +
-	"\x0fuser/user.proto\x12\x04user\x1a\x1bgoogle/protobuf/empty.proto\x1a\x18veemon/annotations.proto\"i\n" +
+	"\x0fuser/user.proto\x12\x04user\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1cgoogle/protobuf/struct.proto\x1a\x18veemon/annotations.proto\"i\n" +
 	"\vRegisterReq\x12\x14\n" +
 	"\x05email\x18\x01 \x01(\tR\x05email\x12\x1a\n" +
 	"\bpassword\x18\x02 \x01(\tR\bpassword\x12\x12\n" +
@@ -892,7 +2350,44 @@ const file_user_user_proto_rawDesc = "" +
 	"\x0fRefreshTokenRes\x12\x14\n" +
 	"\x05token\x18\x01 \x01(\tR\x05token\"%\n" +
 	"\tLogoutRes\x12\x18\n" +
-	"\amessage\x18\x01 \x01(\tR\amessage\"\x94\x01\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\"[\n" +
+	"\vDeleteMeRes\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x122\n" +
+	"\x15deletion_scheduled_at\x18\x02 \x01(\tR\x13deletionScheduledAt\"H\n" +
+	"\x14ReactivateAccountReq\x12\x14\n" +
+	"\x05email\x18\x01 \x01(\tR\x05email\x12\x1a\n" +
+	"\bpassword\x18\x02 \x01(\tR\bpassword\"a\n" +
+	"\x11ChangePasswordReq\x12)\n" +
+	"\x10current_password\x18\x01 \x01(\tR\x0fcurrentPassword\x12!\n" +
+	"\fnew_password\x18\x02 \x01(\tR\vnewPassword\"-\n" +
+	"\x11ChangePasswordRes\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\")\n" +
+	"\x11ForgotPasswordReq\x12\x14\n" +
+	"\x05email\x18\x01 \x01(\tR\x05email\"-\n" +
+	"\x11ForgotPasswordRes\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\"K\n" +
+	"\x10ResetPasswordReq\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12!\n" +
+	"\fnew_password\x18\x02 \x01(\tR\vnewPassword\",\n" +
+	"\x10ResetPasswordRes\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\",\n" +
+	"\x12IntrospectBatchReq\x12\x16\n" +
+	"\x06tokens\x18\x01 \x03(\tR\x06tokens\"H\n" +
+	"\x12IntrospectBatchRes\x122\n" +
+	"\aresults\x18\x01 \x03(\v2\x18.user.TokenIntrospectionR\aresults\"o\n" +
+	"\x12TokenIntrospection\x12\x16\n" +
+	"\x06active\x18\x01 \x01(\bR\x06active\x12)\n" +
+	"\x06claims\x18\x02 \x01(\v2\x11.user.TokenClaimsR\x06claims\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\"\xd5\x01\n" +
+	"\vTokenClaims\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x14\n" +
+	"\x05email\x18\x02 \x01(\tR\x05email\x12\x14\n" +
+	"\x05roles\x18\x03 \x03(\tR\x05roles\x12!\n" +
+	"\fcompany_code\x18\x04 \x01(\tR\vcompanyCode\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x05 \x01(\tR\texpiresAt\x12\x19\n" +
+	"\bactor_id\x18\x06 \x01(\tR\aactorId\x12$\n" +
+	"\rimpersonation\x18\a \x01(\bR\rimpersonation\"\xd2\x01\n" +
 	"\vUserProfile\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
 	"\x05email\x18\x02 \x01(\tR\x05email\x12\x12\n" +
@@ -900,65 +2395,174 @@ const file_user_user_proto_rawDesc = "" +
 	"\x05phone\x18\x04 \x01(\tR\x05phone\x12\x16\n" +
 	"\x06status\x18\x05 \x01(\tR\x06status\x12\x1d\n" +
 	"\n" +
-	"created_at\x18\x06 \x01(\tR\tcreatedAt\"\x86\x01\n" +
+	"created_at\x18\x06 \x01(\tR\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\a \x01(\tR\tupdatedAt\x12\x1d\n" +
+	"\n" +
+	"deleted_at\x18\b \x01(\tR\tdeletedAt\"\xdf\x01\n" +
 	"\fListUsersReq\x12\x12\n" +
 	"\x04page\x18\x01 \x01(\x05R\x04page\x12\x12\n" +
 	"\x04size\x18\x02 \x01(\x05R\x04size\x12\x16\n" +
 	"\x06search\x18\x03 \x01(\tR\x06search\x12\x17\n" +
 	"\asort_by\x18\x04 \x01(\tR\x06sortBy\x12\x1d\n" +
 	"\n" +
-	"sort_order\x18\x05 \x01(\tR\tsortOrder\"i\n" +
+	"sort_order\x18\x05 \x01(\tR\tsortOrder\x12\x16\n" +
+	"\x06fields\x18\x06 \x01(\tR\x06fields\x12\x16\n" +
+	"\x06cursor\x18\a \x01(\tR\x06cursor\x12'\n" +
+	"\x0finclude_deleted\x18\b \x01(\bR\x0eincludeDeleted\"i\n" +
 	"\fListUsersRes\x12'\n" +
 	"\x05users\x18\x01 \x03(\v2\x11.user.UserProfileR\x05users\x120\n" +
 	"\n" +
 	"pagination\x18\x02 \x01(\v2\x10.user.PaginationR\n" +
-	"pagination\"k\n" +
+	"pagination\"\xc4\x01\n" +
 	"\n" +
 	"Pagination\x12\x12\n" +
 	"\x04page\x18\x01 \x01(\x05R\x04page\x12\x12\n" +
 	"\x04size\x18\x02 \x01(\x05R\x04size\x12\x14\n" +
 	"\x05total\x18\x03 \x01(\x03R\x05total\x12\x1f\n" +
 	"\vtotal_pages\x18\x04 \x01(\x05R\n" +
-	"totalPages\"\x1c\n" +
+	"totalPages\x12\x1f\n" +
+	"\vnext_cursor\x18\x05 \x01(\tR\n" +
+	"nextCursor\x12\x17\n" +
+	"\asort_by\x18\x06 \x01(\tR\x06sortBy\x12\x1d\n" +
+	"\n" +
+	"sort_order\x18\a \x01(\tR\tsortOrder\"4\n" +
 	"\n" +
 	"GetUserReq\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\"a\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06fields\x18\x02 \x01(\tR\x06fields\"\xdd\x01\n" +
+	"\rCreateUserReq\x12\x14\n" +
+	"\x05email\x18\x01 \x01(\tR\x05email\x12\x1a\n" +
+	"\bpassword\x18\x02 \x01(\tR\bpassword\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x14\n" +
+	"\x05phone\x18\x04 \x01(\tR\x05phone\x12\x14\n" +
+	"\x05roles\x18\x05 \x03(\tR\x05roles\x12!\n" +
+	"\fcompany_code\x18\x06 \x01(\tR\vcompanyCode\x12\x16\n" +
+	"\x06status\x18\a \x01(\tR\x06status\x12\x1f\n" +
+	"\vsend_invite\x18\b \x01(\bR\n" +
+	"sendInvite\"\xbe\x01\n" +
 	"\rUpdateUserReq\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
-	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
-	"\x05phone\x18\x03 \x01(\tR\x05phone\x12\x16\n" +
-	"\x06status\x18\x04 \x01(\tR\x06status\"\x1f\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\x04name\x18\x02 \x01(\tH\x00R\x04name\x88\x01\x01\x12\x19\n" +
+	"\x05phone\x18\x03 \x01(\tH\x01R\x05phone\x88\x01\x01\x12\x1b\n" +
+	"\x06status\x18\x04 \x01(\tH\x02R\x06status\x88\x01\x01\x12.\n" +
+	"\x13expected_updated_at\x18\x05 \x01(\tR\x11expectedUpdatedAtB\a\n" +
+	"\x05_nameB\b\n" +
+	"\x06_phoneB\t\n" +
+	"\a_status\"=\n" +
 	"\rDeleteUserReq\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1c\n" +
+	"\tpermanent\x18\x02 \x01(\bR\tpermanent\" \n" +
+	"\x0eRestoreUserReq\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\")\n" +
 	"\rDeleteUserRes\x12\x18\n" +
-	"\amessage\x18\x01 \x01(\tR\amessage2\xf6\x06\n" +
-	"\aUserApi\x12]\n" +
-	"\bRegister\x12\x11.user.RegisterReq\x1a\x11.user.RegisterRes\"+ڼ\x18'\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\"]\n" +
+	"\x12ImpersonateUserReq\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1f\n" +
+	"\vttl_seconds\x18\x02 \x01(\x05R\n" +
+	"ttlSeconds\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\"p\n" +
+	"\x12ImpersonateUserRes\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x02 \x01(\tR\texpiresAt\x12%\n" +
+	"\x04user\x18\x03 \x01(\v2\x11.user.UserProfileR\x04user\"\xb7\x01\n" +
+	"\x12RuntimeConfigEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\tR\x05value\x12#\n" +
+	"\rdefault_value\x18\x04 \x01(\tR\fdefaultValue\x12\x1e\n" +
+	"\n" +
+	"overridden\x18\x05 \x01(\bR\n" +
+	"overridden\x12 \n" +
+	"\vdescription\x18\x06 \x01(\tR\vdescription\"C\n" +
+	"\rRuntimeConfig\x122\n" +
+	"\aentries\x18\x01 \x03(\v2\x18.user.RuntimeConfigEntryR\aentries\"\xab\x01\n" +
+	"\x16UpdateRuntimeConfigReq\x12@\n" +
+	"\x06values\x18\x01 \x03(\v2(.user.UpdateRuntimeConfigReq.ValuesEntryR\x06values\x12\x14\n" +
+	"\x05reset\x18\x02 \x03(\tR\x05reset\x1a9\n" +
+	"\vValuesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xbf\x01\n" +
+	"\x10ListAuditLogsReq\x12\x12\n" +
+	"\x04page\x18\x01 \x01(\x05R\x04page\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x05R\x04size\x12\x19\n" +
+	"\bactor_id\x18\x03 \x01(\tR\aactorId\x12#\n" +
+	"\rresource_type\x18\x04 \x01(\tR\fresourceType\x12\x1f\n" +
+	"\vresource_id\x18\x05 \x01(\tR\n" +
+	"resourceId\x12\x12\n" +
+	"\x04from\x18\x06 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\a \x01(\tR\x02to\"\xb6\x02\n" +
+	"\rAuditLogEntry\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x19\n" +
+	"\bactor_id\x18\x02 \x01(\tR\aactorId\x12\x16\n" +
+	"\x06action\x18\x03 \x01(\tR\x06action\x12#\n" +
+	"\rresource_type\x18\x04 \x01(\tR\fresourceType\x12\x1f\n" +
+	"\vresource_id\x18\x05 \x01(\tR\n" +
+	"resourceId\x12/\n" +
+	"\x06before\x18\x06 \x01(\v2\x17.google.protobuf.StructR\x06before\x12-\n" +
+	"\x05after\x18\a \x01(\v2\x17.google.protobuf.StructR\x05after\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\b \x01(\tR\trequestId\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\t \x01(\tR\tcreatedAt\"s\n" +
+	"\x10ListAuditLogsRes\x12-\n" +
+	"\aentries\x18\x01 \x03(\v2\x13.user.AuditLogEntryR\aentries\x120\n" +
+	"\n" +
+	"pagination\x18\x02 \x01(\v2\x10.user.PaginationR\n" +
+	"pagination2\x85\x12\n" +
+	"\aUserApi\x12_\n" +
+	"\bRegister\x12\x11.user.RegisterReq\x1a\x11.user.RegisterRes\"-ڼ\x18)\n" +
 	"\x04POST\x12\x15/api/v1/auth/register\x18\x01(\x012\x04\b\n" +
-	"\x10<\x12O\n" +
-	"\x05Login\x12\x0e.user.LoginReq\x1a\x0e.user.LoginRes\"&ڼ\x18\"\n" +
+	"\x10<@\x01\x12Q\n" +
+	"\x05Login\x12\x0e.user.LoginReq\x1a\x0e.user.LoginRes\"(ڼ\x18$\n" +
 	"\x04POST\x12\x12/api/v1/auth/login\x18\x012\x04\b\n" +
-	"\x10<\x12b\n" +
+	"\x10<@\x01\x12b\n" +
 	"\fRefreshToken\x12\x15.user.RefreshTokenReq\x1a\x15.user.RefreshTokenRes\"$ڼ\x18 \n" +
 	"\x04POST\x12\x14/api/v1/auth/refresh\"\x02\b\x01\x12R\n" +
 	"\x05GetMe\x12\x16.google.protobuf.Empty\x1a\x11.user.UserProfile\"\x1eڼ\x18\x1a\n" +
 	"\x03GET\x12\x0f/api/v1/auth/me\"\x02\b\x01\x12V\n" +
 	"\x06Logout\x12\x16.google.protobuf.Empty\x1a\x0f.user.LogoutRes\"#ڼ\x18\x1f\n" +
-	"\x04POST\x12\x13/api/v1/auth/logout\"\x02\b\x01\x12f\n" +
-	"\tListUsers\x12\x12.user.ListUsersReq\x1a\x12.user.ListUsersRes\"1ڼ\x18-\n" +
-	"\x03GET\x12\r/api/v1/users\"\x15\b\x01\x12\x05admin\x12\n" +
-	"superadmin(\x02\x12d\n" +
-	"\aGetUser\x12\x10.user.GetUserReq\x1a\x11.user.UserProfile\"4ڼ\x180\n" +
-	"\x03GET\x12\x12/api/v1/users/{id}\"\x15\b\x01\x12\x05admin\x12\n" +
-	"superadmin\x12l\n" +
+	"\x04POST\x12\x13/api/v1/auth/logout\"\x02\b\x01\x12X\n" +
+	"\bDeleteMe\x12\x16.google.protobuf.Empty\x1a\x11.user.DeleteMeRes\"!ڼ\x18\x1d\n" +
+	"\x06DELETE\x12\x0f/api/v1/auth/me\"\x02\b\x01\x12l\n" +
+	"\x11ReactivateAccount\x12\x1a.user.ReactivateAccountReq\x1a\x0e.user.LoginRes\"+ڼ\x18'\n" +
+	"\x04POST\x12\x17/api/v1/auth/reactivate\x18\x012\x04\b\n" +
+	"\x10<\x12r\n" +
+	"\x0eChangePassword\x12\x17.user.ChangePasswordReq\x1a\x17.user.ChangePasswordRes\".ڼ\x18*\n" +
+	"\x04POST\x12\x1c/api/v1/auth/change-password\x18\x01\"\x02\b\x01\x12t\n" +
+	"\x0eForgotPassword\x12\x17.user.ForgotPasswordReq\x1a\x17.user.ForgotPasswordRes\"0ڼ\x18,\n" +
+	"\x04POST\x12\x1c/api/v1/auth/forgot-password\x18\x012\x04\b\x05\x10<\x12p\n" +
+	"\rResetPassword\x12\x16.user.ResetPasswordReq\x1a\x16.user.ResetPasswordRes\"/ڼ\x18+\n" +
+	"\x04POST\x12\x1b/api/v1/auth/reset-password\x18\x012\x04\b\n" +
+	"\x10<\x12\x89\x01\n" +
+	"\x0fIntrospectBatch\x12\x18.user.IntrospectBatchReq\x1a\x18.user.IntrospectBatchRes\"Bڼ\x18>\n" +
+	"\x04POST\x12\x1d/api/v1/auth/introspect-batch\x18\x01\"\x15\b\x01\"\x11tokens.introspect\x12_\n" +
+	"\tListUsers\x12\x12.user.ListUsersReq\x1a\x12.user.ListUsersRes\"*ڼ\x18&\n" +
+	"\x03GET\x12\r/api/v1/users\"\x0e\b\x01\"\n" +
+	"users.read(\x02\x12f\n" +
+	"\n" +
+	"CreateUser\x12\x13.user.CreateUserReq\x1a\x11.user.UserProfile\"0ڼ\x18,\n" +
+	"\x04POST\x12\r/api/v1/users\x18\x01\"\x0f\b\x01\"\vusers.write(\x01@\x01\x12]\n" +
+	"\aGetUser\x12\x10.user.GetUserReq\x1a\x11.user.UserProfile\"-ڼ\x18)\n" +
+	"\x03GET\x12\x12/api/v1/users/{id}\"\x0e\b\x01\"\n" +
+	"users.read\x12h\n" +
 	"\n" +
-	"UpdateUser\x12\x13.user.UpdateUserReq\x1a\x11.user.UserProfile\"6ڼ\x182\n" +
-	"\x03PUT\x12\x12/api/v1/users/{id}\x18\x01\"\x15\b\x01\x12\x05admin\x12\n" +
-	"superadmin\x12o\n" +
+	"UpdateUser\x12\x13.user.UpdateUserReq\x1a\x11.user.UserProfile\"2ڼ\x18.\n" +
+	"\x03PUT\x12\x12/api/v1/users/{id}\x18\x01\"\x0f\b\x01\"\vusers.write@\x01\x12j\n" +
 	"\n" +
-	"DeleteUser\x12\x13.user.DeleteUserReq\x1a\x13.user.DeleteUserRes\"7ڼ\x183\n" +
-	"\x06DELETE\x12\x12/api/v1/users/{id}\"\x15\b\x01\x12\x05admin\x12\n" +
-	"superadminB\x1aZ\x18veemon/handler/grpc/userb\x06proto3"
+	"DeleteUser\x12\x13.user.DeleteUserReq\x1a\x13.user.DeleteUserRes\"2ڼ\x18.\n" +
+	"\x06DELETE\x12\x12/api/v1/users/{id}\"\x10\b\x01\"\fusers.delete\x12p\n" +
+	"\vRestoreUser\x12\x14.user.RestoreUserReq\x1a\x11.user.UserProfile\"8ڼ\x184\n" +
+	"\x04POST\x12\x1a/api/v1/users/{id}/restore\"\x10\b\x01\"\fusers.delete\x12\x8a\x01\n" +
+	"\x0fImpersonateUser\x12\x18.user.ImpersonateUserReq\x1a\x18.user.ImpersonateUserRes\"Cڼ\x18?\n" +
+	"\x04POST\x12\x1e/api/v1/users/{id}/impersonate\x18\x01\"\x15\b\x01\"\x11users.impersonate\x12\x81\x01\n" +
+	"\x10GetRuntimeConfig\x12\x16.google.protobuf.Empty\x1a\x13.user.RuntimeConfig\"@ڼ\x18<\n" +
+	"\x03GET\x12\x1c/api/v1/admin/runtime-config\"\x17\b\x01\"\x13runtime_config.read\x12\x8d\x01\n" +
+	"\x13UpdateRuntimeConfig\x12\x1c.user.UpdateRuntimeConfigReq\x1a\x13.user.RuntimeConfig\"Cڼ\x18?\n" +
+	"\x03PUT\x12\x1c/api/v1/admin/runtime-config\x18\x01\"\x18\b\x01\"\x14runtime_config.write\x12u\n" +
+	"\rListAuditLogs\x12\x16.user.ListAuditLogsReq\x1a\x16.user.ListAuditLogsRes\"4ڼ\x180\n" +
+	"\x03GET\x12\x12/api/v1/audit-logs\"\x13\b\x01\"\x0faudit_logs.read(\x02B\x1aZ\x18veemon/handler/grpc/userb\x06proto3"
 
 var (
 	file_user_user_proto_rawDescOnce sync.Once
@@ -972,52 +2576,109 @@ func file_user_user_proto_rawDescGZIP() []byte {
 	return file_user_user_proto_rawDescData
 }
 
-var file_user_user_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_user_user_proto_msgTypes = make([]protoimpl.MessageInfo, 38)
 var file_user_user_proto_goTypes = []any{
-	(*RegisterReq)(nil),     // 0: user.RegisterReq
-	(*RegisterRes)(nil),     // 1: user.RegisterRes
-	(*LoginReq)(nil),        // 2: user.LoginReq
-	(*LoginRes)(nil),        // 3: user.LoginRes
-	(*RefreshTokenReq)(nil), // 4: user.RefreshTokenReq
-	(*RefreshTokenRes)(nil), // 5: user.RefreshTokenRes
-	(*LogoutRes)(nil),       // 6: user.LogoutRes
-	(*UserProfile)(nil),     // 7: user.UserProfile
-	(*ListUsersReq)(nil),    // 8: user.ListUsersReq
-	(*ListUsersRes)(nil),    // 9: user.ListUsersRes
-	(*Pagination)(nil),      // 10: user.Pagination
-	(*GetUserReq)(nil),      // 11: user.GetUserReq
-	(*UpdateUserReq)(nil),   // 12: user.UpdateUserReq
-	(*DeleteUserReq)(nil),   // 13: user.DeleteUserReq
-	(*DeleteUserRes)(nil),   // 14: user.DeleteUserRes
-	(*emptypb.Empty)(nil),   // 15: google.protobuf.Empty
+	(*RegisterReq)(nil),            // 0: user.RegisterReq
+	(*RegisterRes)(nil),            // 1: user.RegisterRes
+	(*LoginReq)(nil),               // 2: user.LoginReq
+	(*LoginRes)(nil),               // 3: user.LoginRes
+	(*RefreshTokenReq)(nil),        // 4: user.RefreshTokenReq
+	(*RefreshTokenRes)(nil),        // 5: user.RefreshTokenRes
+	(*LogoutRes)(nil),              // 6: user.LogoutRes
+	(*DeleteMeRes)(nil),            // 7: user.DeleteMeRes
+	(*ReactivateAccountReq)(nil),   // 8: user.ReactivateAccountReq
+	(*ChangePasswordReq)(nil),      // 9: user.ChangePasswordReq
+	(*ChangePasswordRes)(nil),      // 10: user.ChangePasswordRes
+	(*ForgotPasswordReq)(nil),      // 11: user.ForgotPasswordReq
+	(*ForgotPasswordRes)(nil),      // 12: user.ForgotPasswordRes
+	(*ResetPasswordReq)(nil),       // 13: user.ResetPasswordReq
+	(*ResetPasswordRes)(nil),       // 14: user.ResetPasswordRes
+	(*IntrospectBatchReq)(nil),     // 15: user.IntrospectBatchReq
+	(*IntrospectBatchRes)(nil),     // 16: user.IntrospectBatchRes
+	(*TokenIntrospection)(nil),     // 17: user.TokenIntrospection
+	(*TokenClaims)(nil),            // 18: user.TokenClaims
+	(*UserProfile)(nil),            // 19: user.UserProfile
+	(*ListUsersReq)(nil),           // 20: user.ListUsersReq
+	(*ListUsersRes)(nil),           // 21: user.ListUsersRes
+	(*Pagination)(nil),             // 22: user.Pagination
+	(*GetUserReq)(nil),             // 23: user.GetUserReq
+	(*CreateUserReq)(nil),          // 24: user.CreateUserReq
+	(*UpdateUserReq)(nil),          // 25: user.UpdateUserReq
+	(*DeleteUserReq)(nil),          // 26: user.DeleteUserReq
+	(*RestoreUserReq)(nil),         // 27: user.RestoreUserReq
+	(*DeleteUserRes)(nil),          // 28: user.DeleteUserRes
+	(*ImpersonateUserReq)(nil),     // 29: user.ImpersonateUserReq
+	(*ImpersonateUserRes)(nil),     // 30: user.ImpersonateUserRes
+	(*RuntimeConfigEntry)(nil),     // 31: user.RuntimeConfigEntry
+	(*RuntimeConfig)(nil),          // 32: user.RuntimeConfig
+	(*UpdateRuntimeConfigReq)(nil), // 33: user.UpdateRuntimeConfigReq
+	(*ListAuditLogsReq)(nil),       // 34: user.ListAuditLogsReq
+	(*AuditLogEntry)(nil),          // 35: user.AuditLogEntry
+	(*ListAuditLogsRes)(nil),       // 36: user.ListAuditLogsRes
+	nil,                            // 37: user.UpdateRuntimeConfigReq.ValuesEntry
+	(*structpb.Struct)(nil),        // 38: google.protobuf.Struct
+	(*emptypb.Empty)(nil),          // 39: google.protobuf.Empty
 }
 var file_user_user_proto_depIdxs = []int32{
-	7,  // 0: user.LoginRes.user:type_name -> user.UserProfile
-	7,  // 1: user.ListUsersRes.users:type_name -> user.UserProfile
-	10, // 2: user.ListUsersRes.pagination:type_name -> user.Pagination
-	0,  // 3: user.UserApi.Register:input_type -> user.RegisterReq
-	2,  // 4: user.UserApi.Login:input_type -> user.LoginReq
-	4,  // 5: user.UserApi.RefreshToken:input_type -> user.RefreshTokenReq
-	15, // 6: user.UserApi.GetMe:input_type -> google.protobuf.Empty
-	15, // 7: user.UserApi.Logout:input_type -> google.protobuf.Empty
-	8,  // 8: user.UserApi.ListUsers:input_type -> user.ListUsersReq
-	11, // 9: user.UserApi.GetUser:input_type -> user.GetUserReq
-	12, // 10: user.UserApi.UpdateUser:input_type -> user.UpdateUserReq
-	13, // 11: user.UserApi.DeleteUser:input_type -> user.DeleteUserReq
-	1,  // 12: user.UserApi.Register:output_type -> user.RegisterRes
-	3,  // 13: user.UserApi.Login:output_type -> user.LoginRes
-	5,  // 14: user.UserApi.RefreshToken:output_type -> user.RefreshTokenRes
-	7,  // 15: user.UserApi.GetMe:output_type -> user.UserProfile
-	6,  // 16: user.UserApi.Logout:output_type -> user.LogoutRes
-	9,  // 17: user.UserApi.ListUsers:output_type -> user.ListUsersRes
-	7,  // 18: user.UserApi.GetUser:output_type -> user.UserProfile
-	7,  // 19: user.UserApi.UpdateUser:output_type -> user.UserProfile
-	14, // 20: user.UserApi.DeleteUser:output_type -> user.DeleteUserRes
-	12, // [12:21] is the sub-list for method output_type
-	3,  // [3:12] is the sub-list for method input_type
-	3,  // [3:3] is the sub-list for extension type_name
-	3,  // [3:3] is the sub-list for extension extendee
-	0,  // [0:3] is the sub-list for field type_name
+	19, // 0: user.LoginRes.user:type_name -> user.UserProfile
+	17, // 1: user.IntrospectBatchRes.results:type_name -> user.TokenIntrospection
+	18, // 2: user.TokenIntrospection.claims:type_name -> user.TokenClaims
+	19, // 3: user.ListUsersRes.users:type_name -> user.UserProfile
+	22, // 4: user.ListUsersRes.pagination:type_name -> user.Pagination
+	19, // 5: user.ImpersonateUserRes.user:type_name -> user.UserProfile
+	31, // 6: user.RuntimeConfig.entries:type_name -> user.RuntimeConfigEntry
+	37, // 7: user.UpdateRuntimeConfigReq.values:type_name -> user.UpdateRuntimeConfigReq.ValuesEntry
+	38, // 8: user.AuditLogEntry.before:type_name -> google.protobuf.Struct
+	38, // 9: user.AuditLogEntry.after:type_name -> google.protobuf.Struct
+	35, // 10: user.ListAuditLogsRes.entries:type_name -> user.AuditLogEntry
+	22, // 11: user.ListAuditLogsRes.pagination:type_name -> user.Pagination
+	0,  // 12: user.UserApi.Register:input_type -> user.RegisterReq
+	2,  // 13: user.UserApi.Login:input_type -> user.LoginReq
+	4,  // 14: user.UserApi.RefreshToken:input_type -> user.RefreshTokenReq
+	39, // 15: user.UserApi.GetMe:input_type -> google.protobuf.Empty
+	39, // 16: user.UserApi.Logout:input_type -> google.protobuf.Empty
+	39, // 17: user.UserApi.DeleteMe:input_type -> google.protobuf.Empty
+	8,  // 18: user.UserApi.ReactivateAccount:input_type -> user.ReactivateAccountReq
+	9,  // 19: user.UserApi.ChangePassword:input_type -> user.ChangePasswordReq
+	11, // 20: user.UserApi.ForgotPassword:input_type -> user.ForgotPasswordReq
+	13, // 21: user.UserApi.ResetPassword:input_type -> user.ResetPasswordReq
+	15, // 22: user.UserApi.IntrospectBatch:input_type -> user.IntrospectBatchReq
+	20, // 23: user.UserApi.ListUsers:input_type -> user.ListUsersReq
+	24, // 24: user.UserApi.CreateUser:input_type -> user.CreateUserReq
+	23, // 25: user.UserApi.GetUser:input_type -> user.GetUserReq
+	25, // 26: user.UserApi.UpdateUser:input_type -> user.UpdateUserReq
+	26, // 27: user.UserApi.DeleteUser:input_type -> user.DeleteUserReq
+	27, // 28: user.UserApi.RestoreUser:input_type -> user.RestoreUserReq
+	29, // 29: user.UserApi.ImpersonateUser:input_type -> user.ImpersonateUserReq
+	39, // 30: user.UserApi.GetRuntimeConfig:input_type -> google.protobuf.Empty
+	33, // 31: user.UserApi.UpdateRuntimeConfig:input_type -> user.UpdateRuntimeConfigReq
+	34, // 32: user.UserApi.ListAuditLogs:input_type -> user.ListAuditLogsReq
+	1,  // 33: user.UserApi.Register:output_type -> user.RegisterRes
+	3,  // 34: user.UserApi.Login:output_type -> user.LoginRes
+	5,  // 35: user.UserApi.RefreshToken:output_type -> user.RefreshTokenRes
+	19, // 36: user.UserApi.GetMe:output_type -> user.UserProfile
+	6,  // 37: user.UserApi.Logout:output_type -> user.LogoutRes
+	7,  // 38: user.UserApi.DeleteMe:output_type -> user.DeleteMeRes
+	3,  // 39: user.UserApi.ReactivateAccount:output_type -> user.LoginRes
+	10, // 40: user.UserApi.ChangePassword:output_type -> user.ChangePasswordRes
+	12, // 41: user.UserApi.ForgotPassword:output_type -> user.ForgotPasswordRes
+	14, // 42: user.UserApi.ResetPassword:output_type -> user.ResetPasswordRes
+	16, // 43: user.UserApi.IntrospectBatch:output_type -> user.IntrospectBatchRes
+	21, // 44: user.UserApi.ListUsers:output_type -> user.ListUsersRes
+	19, // 45: user.UserApi.CreateUser:output_type -> user.UserProfile
+	19, // 46: user.UserApi.GetUser:output_type -> user.UserProfile
+	19, // 47: user.UserApi.UpdateUser:output_type -> user.UserProfile
+	28, // 48: user.UserApi.DeleteUser:output_type -> user.DeleteUserRes
+	19, // 49: user.UserApi.RestoreUser:output_type -> user.UserProfile
+	30, // 50: user.UserApi.ImpersonateUser:output_type -> user.ImpersonateUserRes
+	32, // 51: user.UserApi.GetRuntimeConfig:output_type -> user.RuntimeConfig
+	32, // 52: user.UserApi.UpdateRuntimeConfig:output_type -> user.RuntimeConfig
+	36, // 53: user.UserApi.ListAuditLogs:output_type -> user.ListAuditLogsRes
+	33, // [33:54] is the sub-list for method output_type
+	12, // [12:33] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
 }
 
 func init() { file_user_user_proto_init() }
@@ -1025,13 +2686,14 @@ func file_user_user_proto_init() {
 	if File_user_user_proto != nil {
 		return
 	}
+	file_user_user_proto_msgTypes[25].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_user_user_proto_rawDesc), len(file_user_user_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   15,
+			NumMessages:   38,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
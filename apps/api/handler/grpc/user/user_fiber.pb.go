@@ -6,9 +6,14 @@ package user
 import (
 	context "context"
 	v2 "github.com/gofiber/fiber/v2"
+	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
+	peer "google.golang.org/grpc/peer"
 	proto "google.golang.org/protobuf/proto"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	net "net"
 	time "time"
+	binding "veemon/pkg/binding"
 	errors "veemon/pkg/errors"
 	middleware "veemon/pkg/middleware"
 	response "veemon/pkg/response"
@@ -18,15 +23,27 @@ import (
 // It is derived from the veemon.route auth options and consumed by the gRPC
 // auth interceptor so gRPC and REST enforce the same rules.
 var UserApiAuthConfig = map[string]middleware.AuthConfig{
-	"/user.UserApi/Register":     middleware.AuthConfig{NeedAuth: false, AllowedRoles: nil},
-	"/user.UserApi/Login":        middleware.AuthConfig{NeedAuth: false, AllowedRoles: nil},
-	"/user.UserApi/RefreshToken": middleware.AuthConfig{NeedAuth: true, AllowedRoles: nil},
-	"/user.UserApi/GetMe":        middleware.AuthConfig{NeedAuth: true, AllowedRoles: nil},
-	"/user.UserApi/Logout":       middleware.AuthConfig{NeedAuth: true, AllowedRoles: nil},
-	"/user.UserApi/ListUsers":    middleware.AuthConfig{NeedAuth: true, AllowedRoles: []string{"admin", "superadmin"}},
-	"/user.UserApi/GetUser":      middleware.AuthConfig{NeedAuth: true, AllowedRoles: []string{"admin", "superadmin"}},
-	"/user.UserApi/UpdateUser":   middleware.AuthConfig{NeedAuth: true, AllowedRoles: []string{"admin", "superadmin"}},
-	"/user.UserApi/DeleteUser":   middleware.AuthConfig{NeedAuth: true, AllowedRoles: []string{"admin", "superadmin"}},
+	"/user.UserApi/Register":            middleware.AuthConfig{NeedAuth: false, AllowedRoles: nil},
+	"/user.UserApi/Login":               middleware.AuthConfig{NeedAuth: false, AllowedRoles: nil},
+	"/user.UserApi/RefreshToken":        middleware.AuthConfig{NeedAuth: true, AllowedRoles: nil},
+	"/user.UserApi/GetMe":               middleware.AuthConfig{NeedAuth: true, AllowedRoles: nil},
+	"/user.UserApi/Logout":              middleware.AuthConfig{NeedAuth: true, AllowedRoles: nil},
+	"/user.UserApi/DeleteMe":            middleware.AuthConfig{NeedAuth: true, AllowedRoles: nil},
+	"/user.UserApi/ReactivateAccount":   middleware.AuthConfig{NeedAuth: false, AllowedRoles: nil},
+	"/user.UserApi/ChangePassword":      middleware.AuthConfig{NeedAuth: true, AllowedRoles: nil},
+	"/user.UserApi/ForgotPassword":      middleware.AuthConfig{NeedAuth: false, AllowedRoles: nil},
+	"/user.UserApi/ResetPassword":       middleware.AuthConfig{NeedAuth: false, AllowedRoles: nil},
+	"/user.UserApi/IntrospectBatch":     middleware.AuthConfig{NeedAuth: true, RequiredPermissions: []string{"tokens.introspect"}},
+	"/user.UserApi/ListUsers":           middleware.AuthConfig{NeedAuth: true, RequiredPermissions: []string{"users.read"}},
+	"/user.UserApi/CreateUser":          middleware.AuthConfig{NeedAuth: true, RequiredPermissions: []string{"users.write"}},
+	"/user.UserApi/GetUser":             middleware.AuthConfig{NeedAuth: true, RequiredPermissions: []string{"users.read"}},
+	"/user.UserApi/UpdateUser":          middleware.AuthConfig{NeedAuth: true, RequiredPermissions: []string{"users.write"}},
+	"/user.UserApi/DeleteUser":          middleware.AuthConfig{NeedAuth: true, RequiredPermissions: []string{"users.delete"}},
+	"/user.UserApi/RestoreUser":         middleware.AuthConfig{NeedAuth: true, RequiredPermissions: []string{"users.delete"}},
+	"/user.UserApi/ImpersonateUser":     middleware.AuthConfig{NeedAuth: true, RequiredPermissions: []string{"users.impersonate"}},
+	"/user.UserApi/GetRuntimeConfig":    middleware.AuthConfig{NeedAuth: true, RequiredPermissions: []string{"runtime_config.read"}},
+	"/user.UserApi/UpdateRuntimeConfig": middleware.AuthConfig{NeedAuth: true, RequiredPermissions: []string{"runtime_config.write"}},
+	"/user.UserApi/ListAuditLogs":       middleware.AuthConfig{NeedAuth: true, RequiredPermissions: []string{"audit_logs.read"}},
 }
 
 // RegisterUserApiRoutes registers all REST routes for UserApi on router,
@@ -37,20 +54,33 @@ func RegisterUserApiRoutes(router v2.Router, srv UserApiServer, validator middle
 	router.Post("/api/v1/auth/refresh", middleware.AuthMiddleware(validator, middleware.AuthConfig{NeedAuth: true, AllowedRoles: nil}), _UserApi_RefreshToken(srv))
 	router.Get("/api/v1/auth/me", middleware.AuthMiddleware(validator, middleware.AuthConfig{NeedAuth: true, AllowedRoles: nil}), _UserApi_GetMe(srv))
 	router.Post("/api/v1/auth/logout", middleware.AuthMiddleware(validator, middleware.AuthConfig{NeedAuth: true, AllowedRoles: nil}), _UserApi_Logout(srv))
-	router.Get("/api/v1/users", middleware.AuthMiddleware(validator, middleware.AuthConfig{NeedAuth: true, AllowedRoles: []string{"admin", "superadmin"}}), _UserApi_ListUsers(srv))
-	router.Get("/api/v1/users/:id", middleware.AuthMiddleware(validator, middleware.AuthConfig{NeedAuth: true, AllowedRoles: []string{"admin", "superadmin"}}), _UserApi_GetUser(srv))
-	router.Put("/api/v1/users/:id", middleware.AuthMiddleware(validator, middleware.AuthConfig{NeedAuth: true, AllowedRoles: []string{"admin", "superadmin"}}), _UserApi_UpdateUser(srv))
-	router.Delete("/api/v1/users/:id", middleware.AuthMiddleware(validator, middleware.AuthConfig{NeedAuth: true, AllowedRoles: []string{"admin", "superadmin"}}), _UserApi_DeleteUser(srv))
+	router.Delete("/api/v1/auth/me", middleware.AuthMiddleware(validator, middleware.AuthConfig{NeedAuth: true, AllowedRoles: nil}), _UserApi_DeleteMe(srv))
+	router.Post("/api/v1/auth/reactivate", _UserApi_rateLimit(10, 60*time.Second), _UserApi_ReactivateAccount(srv))
+	router.Post("/api/v1/auth/change-password", middleware.AuthMiddleware(validator, middleware.AuthConfig{NeedAuth: true, AllowedRoles: nil}), _UserApi_ChangePassword(srv))
+	router.Post("/api/v1/auth/forgot-password", _UserApi_rateLimit(5, 60*time.Second), _UserApi_ForgotPassword(srv))
+	router.Post("/api/v1/auth/reset-password", _UserApi_rateLimit(10, 60*time.Second), _UserApi_ResetPassword(srv))
+	router.Post("/api/v1/auth/introspect-batch", middleware.AuthMiddleware(validator, middleware.AuthConfig{NeedAuth: true, RequiredPermissions: []string{"tokens.introspect"}}), _UserApi_IntrospectBatch(srv))
+	router.Get("/api/v1/users", middleware.AuthMiddleware(validator, middleware.AuthConfig{NeedAuth: true, RequiredPermissions: []string{"users.read"}}), _UserApi_ListUsers(srv))
+	router.Post("/api/v1/users", middleware.AuthMiddleware(validator, middleware.AuthConfig{NeedAuth: true, RequiredPermissions: []string{"users.write"}}), _UserApi_CreateUser(srv))
+	router.Get("/api/v1/users/:id", middleware.AuthMiddleware(validator, middleware.AuthConfig{NeedAuth: true, RequiredPermissions: []string{"users.read"}}), _UserApi_GetUser(srv))
+	router.Put("/api/v1/users/:id", middleware.AuthMiddleware(validator, middleware.AuthConfig{NeedAuth: true, RequiredPermissions: []string{"users.write"}}), _UserApi_UpdateUser(srv))
+	router.Delete("/api/v1/users/:id", middleware.AuthMiddleware(validator, middleware.AuthConfig{NeedAuth: true, RequiredPermissions: []string{"users.delete"}}), _UserApi_DeleteUser(srv))
+	router.Post("/api/v1/users/:id/restore", middleware.AuthMiddleware(validator, middleware.AuthConfig{NeedAuth: true, RequiredPermissions: []string{"users.delete"}}), _UserApi_RestoreUser(srv))
+	router.Post("/api/v1/users/:id/impersonate", middleware.AuthMiddleware(validator, middleware.AuthConfig{NeedAuth: true, RequiredPermissions: []string{"users.impersonate"}}), _UserApi_ImpersonateUser(srv))
+	router.Get("/api/v1/admin/runtime-config", middleware.AuthMiddleware(validator, middleware.AuthConfig{NeedAuth: true, RequiredPermissions: []string{"runtime_config.read"}}), _UserApi_GetRuntimeConfig(srv))
+	router.Put("/api/v1/admin/runtime-config", middleware.AuthMiddleware(validator, middleware.AuthConfig{NeedAuth: true, RequiredPermissions: []string{"runtime_config.write"}}), _UserApi_UpdateRuntimeConfig(srv))
+	router.Get("/api/v1/audit-logs", middleware.AuthMiddleware(validator, middleware.AuthConfig{NeedAuth: true, RequiredPermissions: []string{"audit_logs.read"}}), _UserApi_ListAuditLogs(srv))
 }
 
 func _UserApi_Register(srv UserApiServer) v2.Handler {
 	return func(c *v2.Ctx) error {
 		var req RegisterReq
-		if err := c.BodyParser(&req); err != nil {
-			return response.BadRequest(c, 400, "invalid request body")
+		if err := _UserApi_bind(c, &req, true, "application/json"); err != nil {
+			return _UserApi_error(c, err)
 		}
-		ctx := _UserApi_ctx(c)
+		ctx, span := middleware.StartHandlerSpan(_UserApi_ctx(c, "/user.UserApi/Register"), "/user.UserApi/Register")
 		res, err := srv.Register(ctx, &req)
+		middleware.EndHandlerSpan(span, err)
 		if err != nil {
 			return _UserApi_error(c, err)
 		}
@@ -61,11 +91,12 @@ func _UserApi_Register(srv UserApiServer) v2.Handler {
 func _UserApi_Login(srv UserApiServer) v2.Handler {
 	return func(c *v2.Ctx) error {
 		var req LoginReq
-		if err := c.BodyParser(&req); err != nil {
-			return response.BadRequest(c, 400, "invalid request body")
+		if err := _UserApi_bind(c, &req, true, "application/json"); err != nil {
+			return _UserApi_error(c, err)
 		}
-		ctx := _UserApi_ctx(c)
+		ctx, span := middleware.StartHandlerSpan(_UserApi_ctx(c, "/user.UserApi/Login"), "/user.UserApi/Login")
 		res, err := srv.Login(ctx, &req)
+		middleware.EndHandlerSpan(span, err)
 		if err != nil {
 			return _UserApi_error(c, err)
 		}
@@ -76,8 +107,9 @@ func _UserApi_Login(srv UserApiServer) v2.Handler {
 func _UserApi_RefreshToken(srv UserApiServer) v2.Handler {
 	return func(c *v2.Ctx) error {
 		var req RefreshTokenReq
-		ctx := _UserApi_ctx(c)
+		ctx, span := middleware.StartHandlerSpan(_UserApi_ctx(c, "/user.UserApi/RefreshToken"), "/user.UserApi/RefreshToken")
 		res, err := srv.RefreshToken(ctx, &req)
+		middleware.EndHandlerSpan(span, err)
 		if err != nil {
 			return _UserApi_error(c, err)
 		}
@@ -88,8 +120,9 @@ func _UserApi_RefreshToken(srv UserApiServer) v2.Handler {
 func _UserApi_GetMe(srv UserApiServer) v2.Handler {
 	return func(c *v2.Ctx) error {
 		req := &emptypb.Empty{}
-		ctx := _UserApi_ctx(c)
+		ctx, span := middleware.StartHandlerSpan(_UserApi_ctx(c, "/user.UserApi/GetMe"), "/user.UserApi/GetMe")
 		res, err := srv.GetMe(ctx, req)
+		middleware.EndHandlerSpan(span, err)
 		if err != nil {
 			return _UserApi_error(c, err)
 		}
@@ -100,8 +133,102 @@ func _UserApi_GetMe(srv UserApiServer) v2.Handler {
 func _UserApi_Logout(srv UserApiServer) v2.Handler {
 	return func(c *v2.Ctx) error {
 		req := &emptypb.Empty{}
-		ctx := _UserApi_ctx(c)
+		ctx, span := middleware.StartHandlerSpan(_UserApi_ctx(c, "/user.UserApi/Logout"), "/user.UserApi/Logout")
 		res, err := srv.Logout(ctx, req)
+		middleware.EndHandlerSpan(span, err)
+		if err != nil {
+			return _UserApi_error(c, err)
+		}
+		return response.SuccessProto(c, res)
+	}
+}
+
+func _UserApi_DeleteMe(srv UserApiServer) v2.Handler {
+	return func(c *v2.Ctx) error {
+		req := &emptypb.Empty{}
+		ctx, span := middleware.StartHandlerSpan(_UserApi_ctx(c, "/user.UserApi/DeleteMe"), "/user.UserApi/DeleteMe")
+		res, err := srv.DeleteMe(ctx, req)
+		middleware.EndHandlerSpan(span, err)
+		if err != nil {
+			return _UserApi_error(c, err)
+		}
+		return response.SuccessProto(c, res)
+	}
+}
+
+func _UserApi_ReactivateAccount(srv UserApiServer) v2.Handler {
+	return func(c *v2.Ctx) error {
+		var req ReactivateAccountReq
+		if err := _UserApi_bind(c, &req, false, "application/json"); err != nil {
+			return _UserApi_error(c, err)
+		}
+		ctx, span := middleware.StartHandlerSpan(_UserApi_ctx(c, "/user.UserApi/ReactivateAccount"), "/user.UserApi/ReactivateAccount")
+		res, err := srv.ReactivateAccount(ctx, &req)
+		middleware.EndHandlerSpan(span, err)
+		if err != nil {
+			return _UserApi_error(c, err)
+		}
+		return response.SuccessProto(c, res)
+	}
+}
+
+func _UserApi_ChangePassword(srv UserApiServer) v2.Handler {
+	return func(c *v2.Ctx) error {
+		var req ChangePasswordReq
+		if err := _UserApi_bind(c, &req, false, "application/json"); err != nil {
+			return _UserApi_error(c, err)
+		}
+		ctx, span := middleware.StartHandlerSpan(_UserApi_ctx(c, "/user.UserApi/ChangePassword"), "/user.UserApi/ChangePassword")
+		res, err := srv.ChangePassword(ctx, &req)
+		middleware.EndHandlerSpan(span, err)
+		if err != nil {
+			return _UserApi_error(c, err)
+		}
+		return response.SuccessProto(c, res)
+	}
+}
+
+func _UserApi_ForgotPassword(srv UserApiServer) v2.Handler {
+	return func(c *v2.Ctx) error {
+		var req ForgotPasswordReq
+		if err := _UserApi_bind(c, &req, false, "application/json"); err != nil {
+			return _UserApi_error(c, err)
+		}
+		ctx, span := middleware.StartHandlerSpan(_UserApi_ctx(c, "/user.UserApi/ForgotPassword"), "/user.UserApi/ForgotPassword")
+		res, err := srv.ForgotPassword(ctx, &req)
+		middleware.EndHandlerSpan(span, err)
+		if err != nil {
+			return _UserApi_error(c, err)
+		}
+		return response.SuccessProto(c, res)
+	}
+}
+
+func _UserApi_ResetPassword(srv UserApiServer) v2.Handler {
+	return func(c *v2.Ctx) error {
+		var req ResetPasswordReq
+		if err := _UserApi_bind(c, &req, false, "application/json"); err != nil {
+			return _UserApi_error(c, err)
+		}
+		ctx, span := middleware.StartHandlerSpan(_UserApi_ctx(c, "/user.UserApi/ResetPassword"), "/user.UserApi/ResetPassword")
+		res, err := srv.ResetPassword(ctx, &req)
+		middleware.EndHandlerSpan(span, err)
+		if err != nil {
+			return _UserApi_error(c, err)
+		}
+		return response.SuccessProto(c, res)
+	}
+}
+
+func _UserApi_IntrospectBatch(srv UserApiServer) v2.Handler {
+	return func(c *v2.Ctx) error {
+		var req IntrospectBatchReq
+		if err := _UserApi_bind(c, &req, false, "application/json"); err != nil {
+			return _UserApi_error(c, err)
+		}
+		ctx, span := middleware.StartHandlerSpan(_UserApi_ctx(c, "/user.UserApi/IntrospectBatch"), "/user.UserApi/IntrospectBatch")
+		res, err := srv.IntrospectBatch(ctx, &req)
+		middleware.EndHandlerSpan(span, err)
 		if err != nil {
 			return _UserApi_error(c, err)
 		}
@@ -117,8 +244,12 @@ func _UserApi_ListUsers(srv UserApiServer) v2.Handler {
 		req.Search = c.Query("search")
 		req.SortBy = c.Query("sortBy")
 		req.SortOrder = c.Query("sortOrder")
-		ctx := _UserApi_ctx(c)
+		req.Fields = c.Query("fields")
+		req.Cursor = c.Query("cursor")
+		req.IncludeDeleted = c.QueryBool("includeDeleted")
+		ctx, span := middleware.StartHandlerSpan(_UserApi_ctx(c, "/user.UserApi/ListUsers"), "/user.UserApi/ListUsers")
 		res, err := srv.ListUsers(ctx, &req)
+		middleware.EndHandlerSpan(span, err)
 		if err != nil {
 			return _UserApi_error(c, err)
 		}
@@ -126,7 +257,23 @@ func _UserApi_ListUsers(srv UserApiServer) v2.Handler {
 		for i, m := range res.Users {
 			items[i] = m
 		}
-		return response.SuccessProtoList(c, items, res.Pagination)
+		return response.SuccessProtoListFields(c, items, res.Pagination, req.Fields)
+	}
+}
+
+func _UserApi_CreateUser(srv UserApiServer) v2.Handler {
+	return func(c *v2.Ctx) error {
+		var req CreateUserReq
+		if err := _UserApi_bind(c, &req, true, "application/json"); err != nil {
+			return _UserApi_error(c, err)
+		}
+		ctx, span := middleware.StartHandlerSpan(_UserApi_ctx(c, "/user.UserApi/CreateUser"), "/user.UserApi/CreateUser")
+		res, err := srv.CreateUser(ctx, &req)
+		middleware.EndHandlerSpan(span, err)
+		if err != nil {
+			return _UserApi_error(c, err)
+		}
+		return response.CreatedProto(c, res)
 	}
 }
 
@@ -134,24 +281,27 @@ func _UserApi_GetUser(srv UserApiServer) v2.Handler {
 	return func(c *v2.Ctx) error {
 		var req GetUserReq
 		req.Id = c.Params("id")
-		ctx := _UserApi_ctx(c)
+		req.Fields = c.Query("fields")
+		ctx, span := middleware.StartHandlerSpan(_UserApi_ctx(c, "/user.UserApi/GetUser"), "/user.UserApi/GetUser")
 		res, err := srv.GetUser(ctx, &req)
+		middleware.EndHandlerSpan(span, err)
 		if err != nil {
 			return _UserApi_error(c, err)
 		}
-		return response.SuccessProto(c, res)
+		return response.SuccessProtoFields(c, res, req.Fields)
 	}
 }
 
 func _UserApi_UpdateUser(srv UserApiServer) v2.Handler {
 	return func(c *v2.Ctx) error {
 		var req UpdateUserReq
-		if err := c.BodyParser(&req); err != nil {
-			return response.BadRequest(c, 400, "invalid request body")
+		if err := _UserApi_bind(c, &req, true, "application/json"); err != nil {
+			return _UserApi_error(c, err)
 		}
 		req.Id = c.Params("id")
-		ctx := _UserApi_ctx(c)
+		ctx, span := middleware.StartHandlerSpan(_UserApi_ctx(c, "/user.UserApi/UpdateUser"), "/user.UserApi/UpdateUser")
 		res, err := srv.UpdateUser(ctx, &req)
+		middleware.EndHandlerSpan(span, err)
 		if err != nil {
 			return _UserApi_error(c, err)
 		}
@@ -163,8 +313,24 @@ func _UserApi_DeleteUser(srv UserApiServer) v2.Handler {
 	return func(c *v2.Ctx) error {
 		var req DeleteUserReq
 		req.Id = c.Params("id")
-		ctx := _UserApi_ctx(c)
+		req.Permanent = c.QueryBool("permanent")
+		ctx, span := middleware.StartHandlerSpan(_UserApi_ctx(c, "/user.UserApi/DeleteUser"), "/user.UserApi/DeleteUser")
 		res, err := srv.DeleteUser(ctx, &req)
+		middleware.EndHandlerSpan(span, err)
+		if err != nil {
+			return _UserApi_error(c, err)
+		}
+		return response.SuccessProto(c, res)
+	}
+}
+
+func _UserApi_RestoreUser(srv UserApiServer) v2.Handler {
+	return func(c *v2.Ctx) error {
+		var req RestoreUserReq
+		req.Id = c.Params("id")
+		ctx, span := middleware.StartHandlerSpan(_UserApi_ctx(c, "/user.UserApi/RestoreUser"), "/user.UserApi/RestoreUser")
+		res, err := srv.RestoreUser(ctx, &req)
+		middleware.EndHandlerSpan(span, err)
 		if err != nil {
 			return _UserApi_error(c, err)
 		}
@@ -172,16 +338,117 @@ func _UserApi_DeleteUser(srv UserApiServer) v2.Handler {
 	}
 }
 
-// _UserApi_ctx propagates the auth context (if present) into the
-// request context passed to the handler.
-func _UserApi_ctx(c *v2.Ctx) context.Context {
+func _UserApi_ImpersonateUser(srv UserApiServer) v2.Handler {
+	return func(c *v2.Ctx) error {
+		var req ImpersonateUserReq
+		if err := _UserApi_bind(c, &req, false, "application/json"); err != nil {
+			return _UserApi_error(c, err)
+		}
+		req.Id = c.Params("id")
+		ctx, span := middleware.StartHandlerSpan(_UserApi_ctx(c, "/user.UserApi/ImpersonateUser"), "/user.UserApi/ImpersonateUser")
+		res, err := srv.ImpersonateUser(ctx, &req)
+		middleware.EndHandlerSpan(span, err)
+		if err != nil {
+			return _UserApi_error(c, err)
+		}
+		return response.SuccessProto(c, res)
+	}
+}
+
+func _UserApi_GetRuntimeConfig(srv UserApiServer) v2.Handler {
+	return func(c *v2.Ctx) error {
+		req := &emptypb.Empty{}
+		ctx, span := middleware.StartHandlerSpan(_UserApi_ctx(c, "/user.UserApi/GetRuntimeConfig"), "/user.UserApi/GetRuntimeConfig")
+		res, err := srv.GetRuntimeConfig(ctx, req)
+		middleware.EndHandlerSpan(span, err)
+		if err != nil {
+			return _UserApi_error(c, err)
+		}
+		return response.SuccessProto(c, res)
+	}
+}
+
+func _UserApi_UpdateRuntimeConfig(srv UserApiServer) v2.Handler {
+	return func(c *v2.Ctx) error {
+		var req UpdateRuntimeConfigReq
+		if err := _UserApi_bind(c, &req, false, "application/json"); err != nil {
+			return _UserApi_error(c, err)
+		}
+		ctx, span := middleware.StartHandlerSpan(_UserApi_ctx(c, "/user.UserApi/UpdateRuntimeConfig"), "/user.UserApi/UpdateRuntimeConfig")
+		res, err := srv.UpdateRuntimeConfig(ctx, &req)
+		middleware.EndHandlerSpan(span, err)
+		if err != nil {
+			return _UserApi_error(c, err)
+		}
+		return response.SuccessProto(c, res)
+	}
+}
+
+func _UserApi_ListAuditLogs(srv UserApiServer) v2.Handler {
+	return func(c *v2.Ctx) error {
+		var req ListAuditLogsReq
+		req.Page = int32(c.QueryInt("page", 0))
+		req.Size = int32(c.QueryInt("size", 0))
+		req.ActorId = c.Query("actorId")
+		req.ResourceType = c.Query("resourceType")
+		req.ResourceId = c.Query("resourceId")
+		req.From = c.Query("from")
+		req.To = c.Query("to")
+		ctx, span := middleware.StartHandlerSpan(_UserApi_ctx(c, "/user.UserApi/ListAuditLogs"), "/user.UserApi/ListAuditLogs")
+		res, err := srv.ListAuditLogs(ctx, &req)
+		middleware.EndHandlerSpan(span, err)
+		if err != nil {
+			return _UserApi_error(c, err)
+		}
+		items := make([]proto.Message, len(res.Entries))
+		for i, m := range res.Entries {
+			items[i] = m
+		}
+		return response.SuccessProtoList(c, items, res.Pagination)
+	}
+}
+
+// _UserApi_ctx builds the context passed to the handler: the auth
+// context (if present), the request headers as incoming gRPC metadata, the
+// client IP as the gRPC peer, and a transport stream that turns
+// grpc.SetHeader into response headers, so a handler reads and writes
+// headers and sees its caller the same way over both transports.
+func _UserApi_ctx(c *v2.Ctx, method string) context.Context {
 	ctx := c.UserContext()
 	if ac, ok := middleware.GetAuthContext(c); ok {
 		ctx = middleware.WithAuthContext(ctx, ac)
 	}
-	return ctx
+	md := metadata.MD{}
+	c.Request().Header.VisitAll(func(k, v []byte) {
+		md.Append(string(k), string(v))
+	})
+	ctx = metadata.NewIncomingContext(ctx, md)
+	ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.IPAddr{IP: net.ParseIP(c.IP())}})
+	return grpc.NewContextWithServerTransportStream(ctx, &_UserApi_stream{c: c, method: method})
+}
+
+// _UserApi_stream writes header metadata set by a handler to the HTTP
+// response. HTTP/1.1 responses have no trailers, so trailers become headers.
+type _UserApi_stream struct {
+	c      *v2.Ctx
+	method string
 }
 
+func (s *_UserApi_stream) Method() string { return s.method }
+
+func (s *_UserApi_stream) SetHeader(md metadata.MD) error {
+	for k, vs := range md {
+		for _, v := range vs {
+			s.c.Append(k, v)
+		}
+	}
+	return nil
+}
+
+func (s *_UserApi_stream) SendHeader(md metadata.MD) error { return s.SetHeader(md) }
+
+func (s *_UserApi_stream) SetTrailer(md metadata.MD) error { return s.SetHeader(md) }
+
 // _UserApi_error renders a handler error: AppError values keep their
 // status/message; anything else becomes a sanitized 500.
 func _UserApi_error(c *v2.Ctx, err error) error {
@@ -191,6 +458,23 @@ func _UserApi_error(c *v2.Ctx, err error) error {
 	return response.InternalError(c, 500, "internal server error")
 }
 
+// _UserApi_bind parses the request body after checking its Content-Type
+// against the route's accepted media types, so form or multipart bodies can
+// never reach a JSON route through BodyParser. JSON bodies are decoded by
+// binding.ProtoJSON, which rejects unknown fields on strict routes.
+func _UserApi_bind(c *v2.Ctx, req any, strict bool, consumes ...string) error {
+	if !middleware.ContentTypeAllowed(c.Get(v2.HeaderContentType), consumes...) {
+		return errors.UnsupportedMediaType("unsupported content type")
+	}
+	if m, ok := req.(proto.Message); ok && c.Is("json") {
+		return binding.ProtoJSON(c.Body(), m, strict)
+	}
+	if err := c.BodyParser(req); err != nil {
+		return errors.BadRequest(400, "invalid request body")
+	}
+	return nil
+}
+
 // _UserApi_rateLimit builds a fixed-window per-IP limiter middleware.
 func _UserApi_rateLimit(max int, window time.Duration) v2.Handler {
 	cfg := middleware.DefaultRateLimitConfig()
@@ -11,6 +11,10 @@ import (
 	"veemon/pkg/middleware"
 
 	"github.com/gofiber/fiber/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
@@ -26,7 +30,17 @@ func (stubServer) Register(_ context.Context, req *RegisterReq) (*RegisterRes, e
 }
 
 func (stubServer) GetUser(_ context.Context, req *GetUserReq) (*UserProfile, error) {
-	return &UserProfile{Id: req.Id, Email: "u@example.com"}, nil
+	return &UserProfile{Id: req.Id, Email: "u@example.com", Name: req.Fields}, nil
+}
+
+// UpdateUser echoes the If-Match request header into the ETag response
+// header through gRPC metadata, as real handlers read and set them.
+func (stubServer) UpdateUser(ctx context.Context, req *UpdateUserReq) (*UserProfile, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if tags := md.Get("if-match"); len(tags) > 0 {
+		_ = grpc.SetHeader(ctx, metadata.Pairs("etag", tags[0]))
+	}
+	return &UserProfile{Id: req.Id, Name: req.GetName(), UpdatedAt: req.ExpectedUpdatedAt}, nil
 }
 
 func (stubServer) ListUsers(_ context.Context, req *ListUsersReq) (*ListUsersRes, error) {
@@ -36,6 +50,14 @@ func (stubServer) ListUsers(_ context.Context, req *ListUsersReq) (*ListUsersRes
 	}, nil
 }
 
+func (stubServer) IntrospectBatch(_ context.Context, req *IntrospectBatchReq) (*IntrospectBatchRes, error) {
+	res := &IntrospectBatchRes{}
+	for range req.Tokens {
+		res.Results = append(res.Results, &TokenIntrospection{Active: true})
+	}
+	return res, nil
+}
+
 func (stubServer) Logout(_ context.Context, _ *emptypb.Empty) (*LogoutRes, error) {
 	return &LogoutRes{Message: "bye"}, nil
 }
@@ -111,6 +133,70 @@ func TestGeneratedRoutes_PathParamBoundAndRoleAllowed(t *testing.T) {
 	}
 }
 
+func TestGeneratedRoutes_HeadersTravelAsMetadata(t *testing.T) {
+	app := newTestApp()
+	req := httptest.NewRequest("PUT", "/api/v1/users/abc", strings.NewReader(`{"name":"Jane"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer admin")
+	req.Header.Set("If-Match", `W/"v1"`)
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("want 200, got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("ETag"); got != `W/"v1"` {
+		t.Fatalf("ETag = %q, want the If-Match value set through grpc.SetHeader", got)
+	}
+}
+
+// JSON bodies bind by a field's json_name as documented, and by its proto
+// name as before.
+func TestGeneratedRoutes_BodyBindsJSONAndProtoNames(t *testing.T) {
+	app := newTestApp()
+	for _, body := range []string{
+		`{"name":"Jane","expectedUpdatedAt":"v1"}`,
+		`{"name":"Jane","expected_updated_at":"v1"}`,
+	} {
+		code, out := doJSON(t, app, "PUT", "/api/v1/users/abc", "admin", body)
+		if code != fiber.StatusOK {
+			t.Fatalf("%s: want 200, got %d (%v)", body, code, out)
+		}
+		data, _ := out["data"].(map[string]any)
+		if data["name"] != "Jane" || data["updatedAt"] != "v1" {
+			t.Fatalf("%s: body not bound: %v", body, out)
+		}
+	}
+}
+
+// Strict routes reject unknown body fields, listing them; the others ignore
+// them.
+func TestGeneratedRoutes_StrictRoutesRejectUnknownFields(t *testing.T) {
+	app := newTestApp()
+	for _, tc := range []struct{ method, path, token, body string }{
+		{"POST", "/api/v1/auth/register", "", `{"email":"a@b.com","password":"Passw0rd!","name":"Ann","role":"admin"}`},
+		{"POST", "/api/v1/auth/login", "", `{"email":"a@b.com","password":"Passw0rd!","role":"admin"}`},
+		{"PUT", "/api/v1/users/abc", "admin", `{"name":"Jane","role":"admin"}`},
+	} {
+		code, out := doJSON(t, app, tc.method, tc.path, tc.token, tc.body)
+		if code != fiber.StatusBadRequest {
+			t.Fatalf("%s %s: want 400, got %d (%v)", tc.method, tc.path, code, out)
+		}
+		errBody, _ := out["error"].(map[string]any)
+		details, _ := errBody["details"].([]any)
+		if len(details) != 1 || details[0].(map[string]any)["field"] != "role" {
+			t.Fatalf("%s %s: details = %v, want the role field", tc.method, tc.path, errBody)
+		}
+	}
+
+	code, out := doJSON(t, app, "POST", "/api/v1/auth/introspect-batch", "service",
+		`{"tokens":["t"],"unknown":true}`)
+	if code != fiber.StatusOK {
+		t.Fatalf("non-strict route: want 200, got %d (%v)", code, out)
+	}
+}
+
 func TestGeneratedRoutes_RoleEnforced(t *testing.T) {
 	app := newTestApp()
 	code, _ := doJSON(t, app, "GET", "/api/v1/users/abc", "member", "")
@@ -119,6 +205,24 @@ func TestGeneratedRoutes_RoleEnforced(t *testing.T) {
 	}
 }
 
+func TestGeneratedRoutes_IntrospectBatchRequiresServiceRole(t *testing.T) {
+	app := newTestApp()
+	body := `{"tokens":["a","b"]}`
+
+	if code, _ := doJSON(t, app, "POST", "/api/v1/auth/introspect-batch", "admin", body); code != fiber.StatusForbidden {
+		t.Fatalf("want 403 for a user token, got %d", code)
+	}
+
+	code, out := doJSON(t, app, "POST", "/api/v1/auth/introspect-batch", "service", body)
+	if code != fiber.StatusOK {
+		t.Fatalf("want 200 for the service role, got %d (%v)", code, out)
+	}
+	data, _ := out["data"].(map[string]any)
+	if results, _ := data["results"].([]any); len(results) != 2 {
+		t.Fatalf("want 2 results, got %v", out)
+	}
+}
+
 func TestGeneratedRoutes_ListEnvelopeWithQueryAndMeta(t *testing.T) {
 	app := newTestApp()
 	code, out := doJSON(t, app, "GET", "/api/v1/users?page=3&size=25", "admin", "")
@@ -135,6 +239,46 @@ func TestGeneratedRoutes_ListEnvelopeWithQueryAndMeta(t *testing.T) {
 	}
 }
 
+func TestGeneratedRoutes_SparseFieldsetShapesJSON(t *testing.T) {
+	app := newTestApp()
+
+	// Unlisted fields are omitted entirely, not sent as zero values. "phone"
+	// is listed but empty, so it is still present.
+	code, out := doJSON(t, app, "GET", "/api/v1/users?fields=id,phone", "admin", "")
+	if code != fiber.StatusOK {
+		t.Fatalf("want 200, got %d (%v)", code, out)
+	}
+	data, _ := out["data"].([]any)
+	if len(data) != 2 {
+		t.Fatalf("want 2 items, got %v", out["data"])
+	}
+	for _, item := range data {
+		obj := item.(map[string]any)
+		if len(obj) != 2 || obj["id"] == nil || obj["phone"] != "" {
+			t.Fatalf("want only id and phone, got %v", obj)
+		}
+	}
+	if meta, _ := out["meta"].(map[string]any); meta["total"] == nil {
+		t.Fatalf("meta must not be filtered: %v", out["meta"])
+	}
+
+	// The single-resource route binds ?fields= and filters the same way.
+	code, out = doJSON(t, app, "GET", "/api/v1/users/abc?fields=name,id", "admin", "")
+	if code != fiber.StatusOK {
+		t.Fatalf("want 200, got %d (%v)", code, out)
+	}
+	obj, _ := out["data"].(map[string]any)
+	if len(obj) != 2 || obj["id"] != "abc" || obj["name"] != "name,id" {
+		t.Fatalf("want only id and name, got %v", obj)
+	}
+
+	// Without fields the full profile is returned.
+	_, out = doJSON(t, app, "GET", "/api/v1/users/abc", "admin", "")
+	if obj, _ := out["data"].(map[string]any); len(obj) != 8 {
+		t.Fatalf("want every field without ?fields=, got %v", obj)
+	}
+}
+
 func TestGeneratedRoutes_EmptyInputMethod(t *testing.T) {
 	app := newTestApp()
 	code, out := doJSON(t, app, "POST", "/api/v1/auth/logout", "admin", "")
@@ -146,3 +290,71 @@ func TestGeneratedRoutes_EmptyInputMethod(t *testing.T) {
 		t.Fatalf("unexpected logout body: %v", out)
 	}
 }
+
+func TestGeneratedRoutes_BodyContentTypeEnforced(t *testing.T) {
+	app := newTestApp()
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        int
+	}{
+		{"json", "application/json", `{"email":"a@b.com","password":"Passw0rd!","name":"Ann"}`, fiber.StatusCreated},
+		{"json with charset", "application/json; charset=utf-8", `{"email":"a@b.com","password":"Passw0rd!","name":"Ann"}`, fiber.StatusCreated},
+		{"form encoded", "application/x-www-form-urlencoded", "email=a%40b.com&password=Passw0rd%21&name=Ann", fiber.StatusUnsupportedMediaType},
+		{"multipart", "multipart/form-data; boundary=xyz", "--xyz\r\nContent-Disposition: form-data; name=\"email\"\r\n\r\na@b.com\r\n--xyz--\r\n", fiber.StatusUnsupportedMediaType},
+		{"conflicting values folded", "application/json, application/x-www-form-urlencoded", `{"email":"a@b.com"}`, fiber.StatusUnsupportedMediaType},
+		{"missing content type", "", `{"email":"a@b.com"}`, fiber.StatusUnsupportedMediaType},
+		{"malformed json", "application/json", `{"email":`, fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/v1/auth/register", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Fatalf("want %d, got %d", tt.want, resp.StatusCode)
+			}
+			if tt.want == fiber.StatusUnsupportedMediaType {
+				var out map[string]any
+				_ = json.NewDecoder(resp.Body).Decode(&out)
+				errObj, _ := out["error"].(map[string]any)
+				if out["success"] != false || errObj["code"].(float64) != 415 {
+					t.Fatalf("want standard 415 envelope, got %v", out)
+				}
+			}
+		})
+	}
+}
+
+// An auditor holds users.read but not users.write under the default policy,
+// and both transports must agree on that.
+func TestGeneratedRoutes_AuditorCanListButNotUpdate(t *testing.T) {
+	app := newTestApp()
+	if code, out := doJSON(t, app, "GET", "/api/v1/users", "auditor", ""); code != fiber.StatusOK {
+		t.Fatalf("REST list: want 200 for auditor, got %d (%v)", code, out)
+	}
+	if code, _ := doJSON(t, app, "PUT", "/api/v1/users/abc", "auditor", `{"name":"x"}`); code != fiber.StatusForbidden {
+		t.Fatalf("REST update: want 403 for auditor, got %d", code)
+	}
+
+	interceptor := middleware.GRPCAuthInterceptor(roleValidator, UserApiAuthConfig)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer auditor"))
+	call := func(method string) error {
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method},
+			func(context.Context, interface{}) (interface{}, error) { return "ok", nil })
+		return err
+	}
+	if err := call("/user.UserApi/ListUsers"); err != nil {
+		t.Fatalf("gRPC ListUsers: want ok for auditor, got %v", err)
+	}
+	if err := call("/user.UserApi/UpdateUser"); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("gRPC UpdateUser: want PermissionDenied for auditor, got %v", err)
+	}
+}
@@ -20,15 +20,27 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	UserApi_Register_FullMethodName     = "/user.UserApi/Register"
-	UserApi_Login_FullMethodName        = "/user.UserApi/Login"
-	UserApi_RefreshToken_FullMethodName = "/user.UserApi/RefreshToken"
-	UserApi_GetMe_FullMethodName        = "/user.UserApi/GetMe"
-	UserApi_Logout_FullMethodName       = "/user.UserApi/Logout"
-	UserApi_ListUsers_FullMethodName    = "/user.UserApi/ListUsers"
-	UserApi_GetUser_FullMethodName      = "/user.UserApi/GetUser"
-	UserApi_UpdateUser_FullMethodName   = "/user.UserApi/UpdateUser"
-	UserApi_DeleteUser_FullMethodName   = "/user.UserApi/DeleteUser"
+	UserApi_Register_FullMethodName            = "/user.UserApi/Register"
+	UserApi_Login_FullMethodName               = "/user.UserApi/Login"
+	UserApi_RefreshToken_FullMethodName        = "/user.UserApi/RefreshToken"
+	UserApi_GetMe_FullMethodName               = "/user.UserApi/GetMe"
+	UserApi_Logout_FullMethodName              = "/user.UserApi/Logout"
+	UserApi_DeleteMe_FullMethodName            = "/user.UserApi/DeleteMe"
+	UserApi_ReactivateAccount_FullMethodName   = "/user.UserApi/ReactivateAccount"
+	UserApi_ChangePassword_FullMethodName      = "/user.UserApi/ChangePassword"
+	UserApi_ForgotPassword_FullMethodName      = "/user.UserApi/ForgotPassword"
+	UserApi_ResetPassword_FullMethodName       = "/user.UserApi/ResetPassword"
+	UserApi_IntrospectBatch_FullMethodName     = "/user.UserApi/IntrospectBatch"
+	UserApi_ListUsers_FullMethodName           = "/user.UserApi/ListUsers"
+	UserApi_CreateUser_FullMethodName          = "/user.UserApi/CreateUser"
+	UserApi_GetUser_FullMethodName             = "/user.UserApi/GetUser"
+	UserApi_UpdateUser_FullMethodName          = "/user.UserApi/UpdateUser"
+	UserApi_DeleteUser_FullMethodName          = "/user.UserApi/DeleteUser"
+	UserApi_RestoreUser_FullMethodName         = "/user.UserApi/RestoreUser"
+	UserApi_ImpersonateUser_FullMethodName     = "/user.UserApi/ImpersonateUser"
+	UserApi_GetRuntimeConfig_FullMethodName    = "/user.UserApi/GetRuntimeConfig"
+	UserApi_UpdateRuntimeConfig_FullMethodName = "/user.UserApi/UpdateRuntimeConfig"
+	UserApi_ListAuditLogs_FullMethodName       = "/user.UserApi/ListAuditLogs"
 )
 
 // UserApiClient is the client API for UserApi service.
@@ -49,14 +61,49 @@ type UserApiClient interface {
 	GetMe(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*UserProfile, error)
 	// Protected endpoint - invalidates session
 	Logout(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*LogoutRes, error)
+	// Protected endpoint - schedule deletion of the caller's own account.
+	// The account is deactivated and its sessions revoked at once; it is
+	// purged after the grace period unless reactivated first.
+	DeleteMe(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*DeleteMeRes, error)
+	// Public endpoint - cancel a pending self-deletion by confirming the
+	// account's credentials, and sign in.
+	ReactivateAccount(ctx context.Context, in *ReactivateAccountReq, opts ...grpc.CallOption) (*LoginRes, error)
+	// Protected endpoint - replace the caller's password after confirming
+	// the current one.
+	ChangePassword(ctx context.Context, in *ChangePasswordReq, opts ...grpc.CallOption) (*ChangePasswordRes, error)
+	// Public endpoint - email a single-use reset token. Answers the same
+	// whether or not the email belongs to an account.
+	ForgotPassword(ctx context.Context, in *ForgotPasswordReq, opts ...grpc.CallOption) (*ForgotPasswordRes, error)
+	// Public endpoint - redeem a reset token and set a new password.
+	ResetPassword(ctx context.Context, in *ResetPasswordReq, opts ...grpc.CallOption) (*ResetPasswordRes, error)
+	// Machine endpoint - validate up to 100 tokens in one call (API gateway).
+	// Per-token failures are reported in the results, never as a call error.
+	IntrospectBatch(ctx context.Context, in *IntrospectBatchReq, opts ...grpc.CallOption) (*IntrospectBatchRes, error)
 	// Admin endpoint - list all users
 	ListUsers(ctx context.Context, in *ListUsersReq, opts ...grpc.CallOption) (*ListUsersRes, error)
+	// Admin endpoint - create a user. Without a password the user is sent
+	// an invitation to choose one.
+	CreateUser(ctx context.Context, in *CreateUserReq, opts ...grpc.CallOption) (*UserProfile, error)
 	// Admin endpoint - get user by ID
 	GetUser(ctx context.Context, in *GetUserReq, opts ...grpc.CallOption) (*UserProfile, error)
 	// Admin endpoint - update user by ID
 	UpdateUser(ctx context.Context, in *UpdateUserReq, opts ...grpc.CallOption) (*UserProfile, error)
-	// Admin endpoint - soft-delete user
+	// Admin endpoint - soft-delete user; with permanent, remove it for good
+	// (users.purge, superadmin only by default).
 	DeleteUser(ctx context.Context, in *DeleteUserReq, opts ...grpc.CallOption) (*DeleteUserRes, error)
+	// Admin endpoint - undo a soft delete
+	RestoreUser(ctx context.Context, in *RestoreUserReq, opts ...grpc.CallOption) (*UserProfile, error)
+	// Superadmin endpoint - issue a short-lived token acting as the user.
+	// The token cannot be refreshed, and cannot call sensitive endpoints.
+	ImpersonateUser(ctx context.Context, in *ImpersonateUserReq, opts ...grpc.CallOption) (*ImpersonateUserRes, error)
+	// Superadmin endpoint - runtime-tunable operational knobs with the value
+	// each has on this replica.
+	GetRuntimeConfig(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*RuntimeConfig, error)
+	// Superadmin endpoint - change or reset runtime config keys on every
+	// replica. Nothing is written unless every key and value is valid.
+	UpdateRuntimeConfig(ctx context.Context, in *UpdateRuntimeConfigReq, opts ...grpc.CallOption) (*RuntimeConfig, error)
+	// Superadmin endpoint - who changed which resource, newest first.
+	ListAuditLogs(ctx context.Context, in *ListAuditLogsReq, opts ...grpc.CallOption) (*ListAuditLogsRes, error)
 }
 
 type userApiClient struct {
@@ -117,6 +164,66 @@ func (c *userApiClient) Logout(ctx context.Context, in *emptypb.Empty, opts ...g
 	return out, nil
 }
 
+func (c *userApiClient) DeleteMe(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*DeleteMeRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteMeRes)
+	err := c.cc.Invoke(ctx, UserApi_DeleteMe_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userApiClient) ReactivateAccount(ctx context.Context, in *ReactivateAccountReq, opts ...grpc.CallOption) (*LoginRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LoginRes)
+	err := c.cc.Invoke(ctx, UserApi_ReactivateAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userApiClient) ChangePassword(ctx context.Context, in *ChangePasswordReq, opts ...grpc.CallOption) (*ChangePasswordRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ChangePasswordRes)
+	err := c.cc.Invoke(ctx, UserApi_ChangePassword_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userApiClient) ForgotPassword(ctx context.Context, in *ForgotPasswordReq, opts ...grpc.CallOption) (*ForgotPasswordRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ForgotPasswordRes)
+	err := c.cc.Invoke(ctx, UserApi_ForgotPassword_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userApiClient) ResetPassword(ctx context.Context, in *ResetPasswordReq, opts ...grpc.CallOption) (*ResetPasswordRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResetPasswordRes)
+	err := c.cc.Invoke(ctx, UserApi_ResetPassword_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userApiClient) IntrospectBatch(ctx context.Context, in *IntrospectBatchReq, opts ...grpc.CallOption) (*IntrospectBatchRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IntrospectBatchRes)
+	err := c.cc.Invoke(ctx, UserApi_IntrospectBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *userApiClient) ListUsers(ctx context.Context, in *ListUsersReq, opts ...grpc.CallOption) (*ListUsersRes, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ListUsersRes)
@@ -127,6 +234,16 @@ func (c *userApiClient) ListUsers(ctx context.Context, in *ListUsersReq, opts ..
 	return out, nil
 }
 
+func (c *userApiClient) CreateUser(ctx context.Context, in *CreateUserReq, opts ...grpc.CallOption) (*UserProfile, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UserProfile)
+	err := c.cc.Invoke(ctx, UserApi_CreateUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *userApiClient) GetUser(ctx context.Context, in *GetUserReq, opts ...grpc.CallOption) (*UserProfile, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(UserProfile)
@@ -157,6 +274,56 @@ func (c *userApiClient) DeleteUser(ctx context.Context, in *DeleteUserReq, opts
 	return out, nil
 }
 
+func (c *userApiClient) RestoreUser(ctx context.Context, in *RestoreUserReq, opts ...grpc.CallOption) (*UserProfile, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UserProfile)
+	err := c.cc.Invoke(ctx, UserApi_RestoreUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userApiClient) ImpersonateUser(ctx context.Context, in *ImpersonateUserReq, opts ...grpc.CallOption) (*ImpersonateUserRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ImpersonateUserRes)
+	err := c.cc.Invoke(ctx, UserApi_ImpersonateUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userApiClient) GetRuntimeConfig(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*RuntimeConfig, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RuntimeConfig)
+	err := c.cc.Invoke(ctx, UserApi_GetRuntimeConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userApiClient) UpdateRuntimeConfig(ctx context.Context, in *UpdateRuntimeConfigReq, opts ...grpc.CallOption) (*RuntimeConfig, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RuntimeConfig)
+	err := c.cc.Invoke(ctx, UserApi_UpdateRuntimeConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userApiClient) ListAuditLogs(ctx context.Context, in *ListAuditLogsReq, opts ...grpc.CallOption) (*ListAuditLogsRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAuditLogsRes)
+	err := c.cc.Invoke(ctx, UserApi_ListAuditLogs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // UserApiServer is the server API for UserApi service.
 // All implementations must embed UnimplementedUserApiServer
 // for forward compatibility.
@@ -175,14 +342,49 @@ type UserApiServer interface {
 	GetMe(context.Context, *emptypb.Empty) (*UserProfile, error)
 	// Protected endpoint - invalidates session
 	Logout(context.Context, *emptypb.Empty) (*LogoutRes, error)
+	// Protected endpoint - schedule deletion of the caller's own account.
+	// The account is deactivated and its sessions revoked at once; it is
+	// purged after the grace period unless reactivated first.
+	DeleteMe(context.Context, *emptypb.Empty) (*DeleteMeRes, error)
+	// Public endpoint - cancel a pending self-deletion by confirming the
+	// account's credentials, and sign in.
+	ReactivateAccount(context.Context, *ReactivateAccountReq) (*LoginRes, error)
+	// Protected endpoint - replace the caller's password after confirming
+	// the current one.
+	ChangePassword(context.Context, *ChangePasswordReq) (*ChangePasswordRes, error)
+	// Public endpoint - email a single-use reset token. Answers the same
+	// whether or not the email belongs to an account.
+	ForgotPassword(context.Context, *ForgotPasswordReq) (*ForgotPasswordRes, error)
+	// Public endpoint - redeem a reset token and set a new password.
+	ResetPassword(context.Context, *ResetPasswordReq) (*ResetPasswordRes, error)
+	// Machine endpoint - validate up to 100 tokens in one call (API gateway).
+	// Per-token failures are reported in the results, never as a call error.
+	IntrospectBatch(context.Context, *IntrospectBatchReq) (*IntrospectBatchRes, error)
 	// Admin endpoint - list all users
 	ListUsers(context.Context, *ListUsersReq) (*ListUsersRes, error)
+	// Admin endpoint - create a user. Without a password the user is sent
+	// an invitation to choose one.
+	CreateUser(context.Context, *CreateUserReq) (*UserProfile, error)
 	// Admin endpoint - get user by ID
 	GetUser(context.Context, *GetUserReq) (*UserProfile, error)
 	// Admin endpoint - update user by ID
 	UpdateUser(context.Context, *UpdateUserReq) (*UserProfile, error)
-	// Admin endpoint - soft-delete user
+	// Admin endpoint - soft-delete user; with permanent, remove it for good
+	// (users.purge, superadmin only by default).
 	DeleteUser(context.Context, *DeleteUserReq) (*DeleteUserRes, error)
+	// Admin endpoint - undo a soft delete
+	RestoreUser(context.Context, *RestoreUserReq) (*UserProfile, error)
+	// Superadmin endpoint - issue a short-lived token acting as the user.
+	// The token cannot be refreshed, and cannot call sensitive endpoints.
+	ImpersonateUser(context.Context, *ImpersonateUserReq) (*ImpersonateUserRes, error)
+	// Superadmin endpoint - runtime-tunable operational knobs with the value
+	// each has on this replica.
+	GetRuntimeConfig(context.Context, *emptypb.Empty) (*RuntimeConfig, error)
+	// Superadmin endpoint - change or reset runtime config keys on every
+	// replica. Nothing is written unless every key and value is valid.
+	UpdateRuntimeConfig(context.Context, *UpdateRuntimeConfigReq) (*RuntimeConfig, error)
+	// Superadmin endpoint - who changed which resource, newest first.
+	ListAuditLogs(context.Context, *ListAuditLogsReq) (*ListAuditLogsRes, error)
 	mustEmbedUnimplementedUserApiServer()
 }
 
@@ -208,9 +410,30 @@ func (UnimplementedUserApiServer) GetMe(context.Context, *emptypb.Empty) (*UserP
 func (UnimplementedUserApiServer) Logout(context.Context, *emptypb.Empty) (*LogoutRes, error) {
 	return nil, status.Error(codes.Unimplemented, "method Logout not implemented")
 }
+func (UnimplementedUserApiServer) DeleteMe(context.Context, *emptypb.Empty) (*DeleteMeRes, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeleteMe not implemented")
+}
+func (UnimplementedUserApiServer) ReactivateAccount(context.Context, *ReactivateAccountReq) (*LoginRes, error) {
+	return nil, status.Error(codes.Unimplemented, "method ReactivateAccount not implemented")
+}
+func (UnimplementedUserApiServer) ChangePassword(context.Context, *ChangePasswordReq) (*ChangePasswordRes, error) {
+	return nil, status.Error(codes.Unimplemented, "method ChangePassword not implemented")
+}
+func (UnimplementedUserApiServer) ForgotPassword(context.Context, *ForgotPasswordReq) (*ForgotPasswordRes, error) {
+	return nil, status.Error(codes.Unimplemented, "method ForgotPassword not implemented")
+}
+func (UnimplementedUserApiServer) ResetPassword(context.Context, *ResetPasswordReq) (*ResetPasswordRes, error) {
+	return nil, status.Error(codes.Unimplemented, "method ResetPassword not implemented")
+}
+func (UnimplementedUserApiServer) IntrospectBatch(context.Context, *IntrospectBatchReq) (*IntrospectBatchRes, error) {
+	return nil, status.Error(codes.Unimplemented, "method IntrospectBatch not implemented")
+}
 func (UnimplementedUserApiServer) ListUsers(context.Context, *ListUsersReq) (*ListUsersRes, error) {
 	return nil, status.Error(codes.Unimplemented, "method ListUsers not implemented")
 }
+func (UnimplementedUserApiServer) CreateUser(context.Context, *CreateUserReq) (*UserProfile, error) {
+	return nil, status.Error(codes.Unimplemented, "method CreateUser not implemented")
+}
 func (UnimplementedUserApiServer) GetUser(context.Context, *GetUserReq) (*UserProfile, error) {
 	return nil, status.Error(codes.Unimplemented, "method GetUser not implemented")
 }
@@ -220,6 +443,21 @@ func (UnimplementedUserApiServer) UpdateUser(context.Context, *UpdateUserReq) (*
 func (UnimplementedUserApiServer) DeleteUser(context.Context, *DeleteUserReq) (*DeleteUserRes, error) {
 	return nil, status.Error(codes.Unimplemented, "method DeleteUser not implemented")
 }
+func (UnimplementedUserApiServer) RestoreUser(context.Context, *RestoreUserReq) (*UserProfile, error) {
+	return nil, status.Error(codes.Unimplemented, "method RestoreUser not implemented")
+}
+func (UnimplementedUserApiServer) ImpersonateUser(context.Context, *ImpersonateUserReq) (*ImpersonateUserRes, error) {
+	return nil, status.Error(codes.Unimplemented, "method ImpersonateUser not implemented")
+}
+func (UnimplementedUserApiServer) GetRuntimeConfig(context.Context, *emptypb.Empty) (*RuntimeConfig, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetRuntimeConfig not implemented")
+}
+func (UnimplementedUserApiServer) UpdateRuntimeConfig(context.Context, *UpdateRuntimeConfigReq) (*RuntimeConfig, error) {
+	return nil, status.Error(codes.Unimplemented, "method UpdateRuntimeConfig not implemented")
+}
+func (UnimplementedUserApiServer) ListAuditLogs(context.Context, *ListAuditLogsReq) (*ListAuditLogsRes, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListAuditLogs not implemented")
+}
 func (UnimplementedUserApiServer) mustEmbedUnimplementedUserApiServer() {}
 func (UnimplementedUserApiServer) testEmbeddedByValue()                 {}
 
@@ -331,6 +569,114 @@ func _UserApi_Logout_Handler(srv interface{}, ctx context.Context, dec func(inte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _UserApi_DeleteMe_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserApiServer).DeleteMe(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserApi_DeleteMe_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserApiServer).DeleteMe(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserApi_ReactivateAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReactivateAccountReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserApiServer).ReactivateAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserApi_ReactivateAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserApiServer).ReactivateAccount(ctx, req.(*ReactivateAccountReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserApi_ChangePassword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChangePasswordReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserApiServer).ChangePassword(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserApi_ChangePassword_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserApiServer).ChangePassword(ctx, req.(*ChangePasswordReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserApi_ForgotPassword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ForgotPasswordReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserApiServer).ForgotPassword(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserApi_ForgotPassword_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserApiServer).ForgotPassword(ctx, req.(*ForgotPasswordReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserApi_ResetPassword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResetPasswordReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserApiServer).ResetPassword(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserApi_ResetPassword_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserApiServer).ResetPassword(ctx, req.(*ResetPasswordReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserApi_IntrospectBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IntrospectBatchReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserApiServer).IntrospectBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserApi_IntrospectBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserApiServer).IntrospectBatch(ctx, req.(*IntrospectBatchReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _UserApi_ListUsers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ListUsersReq)
 	if err := dec(in); err != nil {
@@ -349,6 +695,24 @@ func _UserApi_ListUsers_Handler(srv interface{}, ctx context.Context, dec func(i
 	return interceptor(ctx, in, info, handler)
 }
 
+func _UserApi_CreateUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateUserReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserApiServer).CreateUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserApi_CreateUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserApiServer).CreateUser(ctx, req.(*CreateUserReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _UserApi_GetUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetUserReq)
 	if err := dec(in); err != nil {
@@ -403,6 +767,96 @@ func _UserApi_DeleteUser_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _UserApi_RestoreUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreUserReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserApiServer).RestoreUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserApi_RestoreUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserApiServer).RestoreUser(ctx, req.(*RestoreUserReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserApi_ImpersonateUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImpersonateUserReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserApiServer).ImpersonateUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserApi_ImpersonateUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserApiServer).ImpersonateUser(ctx, req.(*ImpersonateUserReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserApi_GetRuntimeConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserApiServer).GetRuntimeConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserApi_GetRuntimeConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserApiServer).GetRuntimeConfig(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserApi_UpdateRuntimeConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateRuntimeConfigReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserApiServer).UpdateRuntimeConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserApi_UpdateRuntimeConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserApiServer).UpdateRuntimeConfig(ctx, req.(*UpdateRuntimeConfigReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserApi_ListAuditLogs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAuditLogsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserApiServer).ListAuditLogs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserApi_ListAuditLogs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserApiServer).ListAuditLogs(ctx, req.(*ListAuditLogsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // UserApi_ServiceDesc is the grpc.ServiceDesc for UserApi service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -430,10 +884,38 @@ var UserApi_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Logout",
 			Handler:    _UserApi_Logout_Handler,
 		},
+		{
+			MethodName: "DeleteMe",
+			Handler:    _UserApi_DeleteMe_Handler,
+		},
+		{
+			MethodName: "ReactivateAccount",
+			Handler:    _UserApi_ReactivateAccount_Handler,
+		},
+		{
+			MethodName: "ChangePassword",
+			Handler:    _UserApi_ChangePassword_Handler,
+		},
+		{
+			MethodName: "ForgotPassword",
+			Handler:    _UserApi_ForgotPassword_Handler,
+		},
+		{
+			MethodName: "ResetPassword",
+			Handler:    _UserApi_ResetPassword_Handler,
+		},
+		{
+			MethodName: "IntrospectBatch",
+			Handler:    _UserApi_IntrospectBatch_Handler,
+		},
 		{
 			MethodName: "ListUsers",
 			Handler:    _UserApi_ListUsers_Handler,
 		},
+		{
+			MethodName: "CreateUser",
+			Handler:    _UserApi_CreateUser_Handler,
+		},
 		{
 			MethodName: "GetUser",
 			Handler:    _UserApi_GetUser_Handler,
@@ -446,6 +928,26 @@ var UserApi_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteUser",
 			Handler:    _UserApi_DeleteUser_Handler,
 		},
+		{
+			MethodName: "RestoreUser",
+			Handler:    _UserApi_RestoreUser_Handler,
+		},
+		{
+			MethodName: "ImpersonateUser",
+			Handler:    _UserApi_ImpersonateUser_Handler,
+		},
+		{
+			MethodName: "GetRuntimeConfig",
+			Handler:    _UserApi_GetRuntimeConfig_Handler,
+		},
+		{
+			MethodName: "UpdateRuntimeConfig",
+			Handler:    _UserApi_UpdateRuntimeConfig_Handler,
+		},
+		{
+			MethodName: "ListAuditLogs",
+			Handler:    _UserApi_ListAuditLogs_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "user/user.proto",
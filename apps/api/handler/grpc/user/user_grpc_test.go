@@ -16,5 +16,5 @@ func TestRegisterUserApiServerRegistersService(t *testing.T) {
 	info, ok := services["user.UserApi"]
 
 	assert.True(t, ok)
-	assert.Len(t, info.Methods, 9)
+	assert.Len(t, info.Methods, 21)
 }
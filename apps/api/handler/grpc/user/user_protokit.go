@@ -27,18 +27,75 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
+// ChangePasswordRequest applies Register's password rules to the new
+// password; the current one is only compared.
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+	NewPassword     string `json:"newPassword" validate:"required,min=8,max=72,password"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required,max=256"`
+	NewPassword string `json:"newPassword" validate:"required,min=8,max=72,password"`
+}
+
+// ListUsersRequest only bounds sortBy and sortOrder: the usecase checks them
+// against the repository's whitelist and the handler answers 40011.
 type ListUsersRequest struct {
 	Page      int32  `json:"page" validate:"omitempty,gte=1"`
 	Size      int32  `json:"size" validate:"omitempty,gte=1,lte=100"`
 	Search    string `json:"search" validate:"omitempty,max=100"`
-	SortBy    string `json:"sortBy" validate:"omitempty,oneof=created_at name email"`
-	SortOrder string `json:"sortOrder" validate:"omitempty,oneof=asc desc"`
+	SortBy    string `json:"sortBy" validate:"omitempty,max=50"`
+	SortOrder string `json:"sortOrder" validate:"omitempty,max=50"`
+	Cursor    string `json:"cursor" validate:"omitempty,max=256"`
+}
+
+// ListAuditLogsRequest takes the date range as RFC 3339 timestamps.
+type ListAuditLogsRequest struct {
+	Page         int32  `json:"page" validate:"omitempty,gte=1"`
+	Size         int32  `json:"size" validate:"omitempty,gte=1,lte=100"`
+	ActorID      string `json:"actorId" validate:"omitempty,max=64"`
+	ResourceType string `json:"resourceType" validate:"omitempty,max=50"`
+	ResourceID   string `json:"resourceId" validate:"omitempty,max=64"`
+	From         string `json:"from" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	To           string `json:"to" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+}
+
+// CreateUserRequest applies Register's rules; the password is required
+// unless an invitation is sent, and must be left out when one is. Roles are
+// checked by entity.ParseRoles.
+type CreateUserRequest struct {
+	Email       string `json:"email" validate:"required,email"`
+	Password    string `json:"password" validate:"required_unless=SendInvite true,excluded_if=SendInvite true,omitempty,min=8,max=72,password"`
+	Name        string `json:"name" validate:"required,min=2,max=100"`
+	Phone       string `json:"phone" validate:"omitempty,phone"`
+	CompanyCode string `json:"companyCode" validate:"omitempty,max=50"`
+	Status      string `json:"status" validate:"omitempty,oneof=active inactive pending"`
+	SendInvite  bool   `json:"sendInvite"`
 }
 
+// UpdateUserRequest validates only the fields that were sent: nil means
+// unchanged, so an empty name or status fails while an empty phone clears it.
 type UpdateUserRequest struct {
-	Name   string `json:"name" validate:"omitempty,min=2,max=100"`
-	Phone  string `json:"phone" validate:"omitempty"`
-	Status string `json:"status" validate:"omitempty,oneof=active inactive pending"`
+	Name   *string `json:"name" validate:"omitnil,min=2,max=100"`
+	Phone  *string `json:"phone" validate:"omitnil"`
+	Status *string `json:"status" validate:"omitnil,oneof=active inactive pending"`
+}
+
+// ImpersonateUserRequest rejects a negative TTL; TTLs above the maximum are
+// capped by the token service instead.
+type ImpersonateUserRequest struct {
+	TTLSeconds int32  `json:"ttlSeconds" validate:"gte=0"`
+	Reason     string `json:"reason" validate:"omitempty,max=500"`
+}
+
+// IntrospectBatchRequest caps a batch at 100 tokens.
+type IntrospectBatchRequest struct {
+	Tokens []string `json:"tokens" validate:"required,min=1,max=100,dive,required,max=4096"`
 }
 
 // ValidateRequest validates proto request messages using go-playground/validator
@@ -60,27 +117,58 @@ func ValidateRequest(req interface{}) error {
 		}
 		return validation.Validate(validateReq)
 
-	case *ListUsersReq:
-		// Apply defaults
-		if r.Page == 0 {
-			r.Page = 1
-		}
-		if r.Size == 0 {
-			r.Size = 10
-		}
-		if r.SortBy == "" {
-			r.SortBy = "created_at"
-		}
-		if r.SortOrder == "" {
-			r.SortOrder = "desc"
-		}
+	case *ReactivateAccountReq:
+		return validation.Validate(LoginRequest{Email: r.Email, Password: r.Password})
 
+	case *ChangePasswordReq:
+		return validation.Validate(ChangePasswordRequest{CurrentPassword: r.CurrentPassword, NewPassword: r.NewPassword})
+
+	case *ForgotPasswordReq:
+		return validation.Validate(ForgotPasswordRequest{Email: r.Email})
+
+	case *ResetPasswordReq:
+		return validation.Validate(ResetPasswordRequest{Token: r.Token, NewPassword: r.NewPassword})
+
+	case *ListUsersReq:
+		// Zero values are left for the usecase to default; validation only
+		// rejects what was actually sent.
 		validateReq := ListUsersRequest{
 			Page:      r.Page,
 			Size:      r.Size,
 			Search:    r.Search,
 			SortBy:    r.SortBy,
 			SortOrder: r.SortOrder,
+			Cursor:    r.Cursor,
+		}
+		return validation.Validate(validateReq)
+
+	case *ListAuditLogsReq:
+		validateReq := ListAuditLogsRequest{
+			Page:         r.Page,
+			Size:         r.Size,
+			ActorID:      r.ActorId,
+			ResourceType: r.ResourceType,
+			ResourceID:   r.ResourceId,
+			From:         r.From,
+			To:           r.To,
+		}
+		return validation.Validate(validateReq)
+
+	case *IntrospectBatchReq:
+		return validation.Validate(IntrospectBatchRequest{Tokens: r.Tokens})
+
+	case *ImpersonateUserReq:
+		return validation.Validate(ImpersonateUserRequest{TTLSeconds: r.TtlSeconds, Reason: r.Reason})
+
+	case *CreateUserReq:
+		validateReq := CreateUserRequest{
+			Email:       r.Email,
+			Password:    r.Password,
+			Name:        r.Name,
+			Phone:       r.Phone,
+			CompanyCode: r.CompanyCode,
+			Status:      r.Status,
+			SendInvite:  r.SendInvite,
 		}
 		return validation.Validate(validateReq)
 
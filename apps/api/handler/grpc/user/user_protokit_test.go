@@ -0,0 +1,59 @@
+package user
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestValidateRequest_LeavesListUsersReqUntouched(t *testing.T) {
+	req := &ListUsersReq{Search: "ann"}
+	want := proto.Clone(req)
+
+	if err := ValidateRequest(req); err != nil {
+		t.Fatalf("ValidateRequest: %v", err)
+	}
+	if !proto.Equal(req, want) {
+		t.Fatalf("request mutated to %v", req)
+	}
+}
+
+func TestValidateRequest_RejectsOutOfRangeListUsersReq(t *testing.T) {
+	for _, req := range []*ListUsersReq{
+		{SortBy: strings.Repeat("x", 51)},
+		{SortOrder: strings.Repeat("x", 51)},
+		{Size: 101},
+		{Page: -1},
+	} {
+		if err := ValidateRequest(req); err == nil {
+			t.Fatalf("ValidateRequest(%v) = nil, want error", req)
+		}
+	}
+}
+
+func TestValidateRequest_CreateUserReqPasswordOrInvite(t *testing.T) {
+	tests := []struct {
+		req     *CreateUserReq
+		wantErr string
+	}{
+		{&CreateUserReq{Email: "a@example.com", Name: "Ann", Password: "Password123"}, ""},
+		{&CreateUserReq{Email: "a@example.com", Name: "Ann", SendInvite: true}, ""},
+		{&CreateUserReq{Email: "a@example.com", Name: "Ann"}, "password is required"},
+		{&CreateUserReq{Email: "a@example.com", Name: "Ann", Password: "Password123", SendInvite: true}, "password must not be set"},
+		{&CreateUserReq{Email: "a@example.com", Name: "Ann", Password: "weak"}, "password must be at least 8 characters"},
+		{&CreateUserReq{Email: "a@example.com", Name: "Ann", SendInvite: true, Status: "banned"}, "status must be one of: active inactive pending"},
+	}
+	for _, tt := range tests {
+		err := ValidateRequest(tt.req)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("ValidateRequest(%v) = %v, want nil", tt.req, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != tt.wantErr {
+			t.Errorf("ValidateRequest(%v) = %v, want %q", tt.req, err, tt.wantErr)
+		}
+	}
+}
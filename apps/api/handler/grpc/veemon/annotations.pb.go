@@ -28,6 +28,56 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// RoleMode selects whether a role check rejects or only reports.
+type RoleMode int32
+
+const (
+	// Callers without an allowed role get 403 / PermissionDenied.
+	RoleMode_ROLE_MODE_ENFORCE RoleMode = 0
+	// Callers without an allowed role are let through, logged and counted in
+	// auth_denials_shadow_total. Token validation is still enforced.
+	RoleMode_ROLE_MODE_MONITOR RoleMode = 1
+)
+
+// Enum value maps for RoleMode.
+var (
+	RoleMode_name = map[int32]string{
+		0: "ROLE_MODE_ENFORCE",
+		1: "ROLE_MODE_MONITOR",
+	}
+	RoleMode_value = map[string]int32{
+		"ROLE_MODE_ENFORCE": 0,
+		"ROLE_MODE_MONITOR": 1,
+	}
+)
+
+func (x RoleMode) Enum() *RoleMode {
+	p := new(RoleMode)
+	*p = x
+	return p
+}
+
+func (x RoleMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RoleMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_veemon_annotations_proto_enumTypes[0].Descriptor()
+}
+
+func (RoleMode) Type() protoreflect.EnumType {
+	return &file_veemon_annotations_proto_enumTypes[0]
+}
+
+func (x RoleMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RoleMode.Descriptor instead.
+func (RoleMode) EnumDescriptor() ([]byte, []int) {
+	return file_veemon_annotations_proto_rawDescGZIP(), []int{0}
+}
+
 // ResponseStyle selects how a successful handler result is serialized.
 type ResponseStyle int32
 
@@ -66,11 +116,11 @@ func (x ResponseStyle) String() string {
 }
 
 func (ResponseStyle) Descriptor() protoreflect.EnumDescriptor {
-	return file_veemon_annotations_proto_enumTypes[0].Descriptor()
+	return file_veemon_annotations_proto_enumTypes[1].Descriptor()
 }
 
 func (ResponseStyle) Type() protoreflect.EnumType {
-	return &file_veemon_annotations_proto_enumTypes[0]
+	return &file_veemon_annotations_proto_enumTypes[1]
 }
 
 func (x ResponseStyle) Number() protoreflect.EnumNumber {
@@ -79,7 +129,7 @@ func (x ResponseStyle) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use ResponseStyle.Descriptor instead.
 func (ResponseStyle) EnumDescriptor() ([]byte, []int) {
-	return file_veemon_annotations_proto_rawDescGZIP(), []int{0}
+	return file_veemon_annotations_proto_rawDescGZIP(), []int{1}
 }
 
 // Route declares how an RPC is exposed over REST. Attach it to a method:
@@ -88,7 +138,7 @@ func (ResponseStyle) EnumDescriptor() ([]byte, []int) {
 //	  option (veemon.route) = {
 //	    method: "GET"
 //	    path: "/api/v1/users/{id}"
-//	    auth: { required: true, roles: ["admin", "superadmin"] }
+//	    auth: { required: true, permissions: ["users.read"] }
 //	  };
 //	}
 //
@@ -111,7 +161,15 @@ type Route struct {
 	Response ResponseStyle `protobuf:"varint,5,opt,name=response,proto3,enum=veemon.ResponseStyle" json:"response,omitempty"`
 	// Optional per-route rate limit applied before the handler. Useful for
 	// unauthenticated credential endpoints (login/register) to blunt brute force.
-	RateLimit     *RateLimit `protobuf:"bytes,6,opt,name=rate_limit,json=rateLimit,proto3" json:"rate_limit,omitempty"`
+	RateLimit *RateLimit `protobuf:"bytes,6,opt,name=rate_limit,json=rateLimit,proto3" json:"rate_limit,omitempty"`
+	// Media types accepted for the request body when body is true. Defaults to
+	// ["application/json"]; any other Content-Type is rejected with 415 before
+	// parsing. Upload-style routes list e.g. "multipart/form-data", "text/csv".
+	Consumes []string `protobuf:"bytes,7,rep,name=consumes,proto3" json:"consumes,omitempty"`
+	// When true, a JSON body naming a field the request message does not have
+	// is rejected with 400, listing each unknown field, instead of the field
+	// being ignored.
+	Strict        bool `protobuf:"varint,8,opt,name=strict,proto3" json:"strict,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -188,6 +246,20 @@ func (x *Route) GetRateLimit() *RateLimit {
 	return nil
 }
 
+func (x *Route) GetConsumes() []string {
+	if x != nil {
+		return x.Consumes
+	}
+	return nil
+}
+
+func (x *Route) GetStrict() bool {
+	if x != nil {
+		return x.Strict
+	}
+	return false
+}
+
 // Auth is the per-route authentication policy.
 type Auth struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -195,7 +267,14 @@ type Auth struct {
 	Required bool `protobuf:"varint,1,opt,name=required,proto3" json:"required,omitempty"`
 	// If non-empty, the caller must hold at least one of these roles. Implies
 	// required = true.
-	Roles         []string `protobuf:"bytes,2,rep,name=roles,proto3" json:"roles,omitempty"`
+	Roles []string `protobuf:"bytes,2,rep,name=roles,proto3" json:"roles,omitempty"`
+	// How a failed role or permission check is handled. Use ROLE_MODE_MONITOR
+	// when tightening an existing route to measure who would be rejected first.
+	RoleMode RoleMode `protobuf:"varint,3,opt,name=role_mode,json=roleMode,proto3,enum=veemon.RoleMode" json:"role_mode,omitempty"`
+	// If non-empty, the caller's roles must grant every one of these
+	// permissions (see pkg/authz), and roles is ignored. Implies
+	// required = true.
+	Permissions   []string `protobuf:"bytes,4,rep,name=permissions,proto3" json:"permissions,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -244,6 +323,20 @@ func (x *Auth) GetRoles() []string {
 	return nil
 }
 
+func (x *Auth) GetRoleMode() RoleMode {
+	if x != nil {
+		return x.RoleMode
+	}
+	return RoleMode_ROLE_MODE_ENFORCE
+}
+
+func (x *Auth) GetPermissions() []string {
+	if x != nil {
+		return x.Permissions
+	}
+	return nil
+}
+
 // RateLimit configures a fixed-window per-IP limiter for a single route.
 type RateLimit struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -322,7 +415,7 @@ var File_veemon_annotations_proto protoreflect.FileDescriptor
 
 const file_veemon_annotations_proto_rawDesc = "" +
 	"\n" +
-	"\x18veemon/annotations.proto\x12\x06veemon\x1a google/protobuf/descriptor.proto\"\xce\x01\n" +
+	"\x18veemon/annotations.proto\x12\x06veemon\x1a google/protobuf/descriptor.proto\"\x82\x02\n" +
 	"\x05Route\x12\x16\n" +
 	"\x06method\x18\x01 \x01(\tR\x06method\x12\x12\n" +
 	"\x04path\x18\x02 \x01(\tR\x04path\x12\x12\n" +
@@ -330,13 +423,20 @@ const file_veemon_annotations_proto_rawDesc = "" +
 	"\x04auth\x18\x04 \x01(\v2\f.veemon.AuthR\x04auth\x121\n" +
 	"\bresponse\x18\x05 \x01(\x0e2\x15.veemon.ResponseStyleR\bresponse\x120\n" +
 	"\n" +
-	"rate_limit\x18\x06 \x01(\v2\x11.veemon.RateLimitR\trateLimit\"8\n" +
+	"rate_limit\x18\x06 \x01(\v2\x11.veemon.RateLimitR\trateLimit\x12\x1a\n" +
+	"\bconsumes\x18\a \x03(\tR\bconsumes\x12\x16\n" +
+	"\x06strict\x18\b \x01(\bR\x06strict\"\x89\x01\n" +
 	"\x04Auth\x12\x1a\n" +
 	"\brequired\x18\x01 \x01(\bR\brequired\x12\x14\n" +
-	"\x05roles\x18\x02 \x03(\tR\x05roles\"D\n" +
+	"\x05roles\x18\x02 \x03(\tR\x05roles\x12-\n" +
+	"\trole_mode\x18\x03 \x01(\x0e2\x10.veemon.RoleModeR\broleMode\x12 \n" +
+	"\vpermissions\x18\x04 \x03(\tR\vpermissions\"D\n" +
 	"\tRateLimit\x12\x10\n" +
 	"\x03max\x18\x01 \x01(\rR\x03max\x12%\n" +
-	"\x0ewindow_seconds\x18\x02 \x01(\rR\rwindowSeconds*[\n" +
+	"\x0ewindow_seconds\x18\x02 \x01(\rR\rwindowSeconds*8\n" +
+	"\bRoleMode\x12\x15\n" +
+	"\x11ROLE_MODE_ENFORCE\x10\x00\x12\x15\n" +
+	"\x11ROLE_MODE_MONITOR\x10\x01*[\n" +
 	"\rResponseStyle\x12\x15\n" +
 	"\x11RESPONSE_STYLE_OK\x10\x00\x12\x1a\n" +
 	"\x16RESPONSE_STYLE_CREATED\x10\x01\x12\x17\n" +
@@ -355,26 +455,28 @@ func file_veemon_annotations_proto_rawDescGZIP() []byte {
 	return file_veemon_annotations_proto_rawDescData
 }
 
-var file_veemon_annotations_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_veemon_annotations_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
 var file_veemon_annotations_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
 var file_veemon_annotations_proto_goTypes = []any{
-	(ResponseStyle)(0),                 // 0: veemon.ResponseStyle
-	(*Route)(nil),                      // 1: veemon.Route
-	(*Auth)(nil),                       // 2: veemon.Auth
-	(*RateLimit)(nil),                  // 3: veemon.RateLimit
-	(*descriptorpb.MethodOptions)(nil), // 4: google.protobuf.MethodOptions
+	(RoleMode)(0),                      // 0: veemon.RoleMode
+	(ResponseStyle)(0),                 // 1: veemon.ResponseStyle
+	(*Route)(nil),                      // 2: veemon.Route
+	(*Auth)(nil),                       // 3: veemon.Auth
+	(*RateLimit)(nil),                  // 4: veemon.RateLimit
+	(*descriptorpb.MethodOptions)(nil), // 5: google.protobuf.MethodOptions
 }
 var file_veemon_annotations_proto_depIdxs = []int32{
-	2, // 0: veemon.Route.auth:type_name -> veemon.Auth
-	0, // 1: veemon.Route.response:type_name -> veemon.ResponseStyle
-	3, // 2: veemon.Route.rate_limit:type_name -> veemon.RateLimit
-	4, // 3: veemon.route:extendee -> google.protobuf.MethodOptions
-	1, // 4: veemon.route:type_name -> veemon.Route
-	5, // [5:5] is the sub-list for method output_type
-	5, // [5:5] is the sub-list for method input_type
-	4, // [4:5] is the sub-list for extension type_name
-	3, // [3:4] is the sub-list for extension extendee
-	0, // [0:3] is the sub-list for field type_name
+	3, // 0: veemon.Route.auth:type_name -> veemon.Auth
+	1, // 1: veemon.Route.response:type_name -> veemon.ResponseStyle
+	4, // 2: veemon.Route.rate_limit:type_name -> veemon.RateLimit
+	0, // 3: veemon.Auth.role_mode:type_name -> veemon.RoleMode
+	5, // 4: veemon.route:extendee -> google.protobuf.MethodOptions
+	2, // 5: veemon.route:type_name -> veemon.Route
+	6, // [6:6] is the sub-list for method output_type
+	6, // [6:6] is the sub-list for method input_type
+	5, // [5:6] is the sub-list for extension type_name
+	4, // [4:5] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
 }
 
 func init() { file_veemon_annotations_proto_init() }
@@ -387,7 +489,7 @@ func file_veemon_annotations_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_veemon_annotations_proto_rawDesc), len(file_veemon_annotations_proto_rawDesc)),
-			NumEnums:      1,
+			NumEnums:      2,
 			NumMessages:   3,
 			NumExtensions: 1,
 			NumServices:   0,
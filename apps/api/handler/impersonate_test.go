@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	user "veemon/app/usecase/user"
+	"veemon/entity"
+	pb "veemon/handler/grpc/user"
+	"veemon/internal/fixtures"
+	"veemon/pkg/authguard"
+	"veemon/pkg/errors"
+	"veemon/pkg/middleware"
+	"veemon/pkg/token"
+)
+
+func TestImpersonatedCallerIsForbidden(t *testing.T) {
+	ts, _ := token.NewTokenService(introspectSecret, 1)
+	h := NewUserHandler(nil, ts, authguard.New(nil, 0, 0), nil)
+	ctx := middleware.WithAuthContext(context.Background(), &middleware.AuthContext{
+		UserID:         "018f0000-0000-7000-8000-000000000001",
+		Roles:          []string{"superadmin"},
+		IsImpersonated: true,
+		ActorID:        "018f0000-0000-7000-8000-000000000002",
+	})
+	target := "018f0000-0000-7000-8000-000000000003"
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"refresh", func() error { _, err := h.RefreshToken(ctx, &pb.RefreshTokenReq{}); return err }},
+		{"delete", func() error { _, err := h.DeleteUser(ctx, &pb.DeleteUserReq{Id: target}); return err }},
+		{"impersonate", func() error { _, err := h.ImpersonateUser(ctx, &pb.ImpersonateUserReq{Id: target}); return err }},
+		{"create", func() error {
+			_, err := h.CreateUser(ctx, &pb.CreateUserReq{Email: "a@example.com", Password: "Password123", Name: "Ann"})
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appErr, ok := tt.call().(*errors.AppError)
+			if !ok || appErr.HTTPStatus != 403 {
+				t.Fatalf("err = %v, want 403", appErr)
+			}
+		})
+	}
+}
+
+// impersonationUC serves GetProfile for one user and records
+// RecordImpersonation calls, failing them with err.
+type impersonationUC struct {
+	user.UseCase
+	target   *entity.User
+	err      error
+	recorded []string
+}
+
+func (u *impersonationUC) GetProfile(_ context.Context, id string) (*entity.User, error) {
+	if id != u.target.ID {
+		return nil, user.ErrNotFound
+	}
+	return u.target, nil
+}
+
+func (u *impersonationUC) RecordImpersonation(_ context.Context, targetID string, expiresAt time.Time, reason string) error {
+	if u.err != nil {
+		return u.err
+	}
+	u.recorded = append(u.recorded, targetID+" "+reason+" "+expiresAt.UTC().Format(time.RFC3339))
+	return nil
+}
+
+func TestImpersonateUser_RecordsTheIssuance(t *testing.T) {
+	ts, _ := token.NewTokenService(introspectSecret, 1)
+	target := fixtures.User().WithID("018f0000-0000-7000-8000-000000000003").Build()
+	ctx := middleware.WithAuthContext(context.Background(), &middleware.AuthContext{
+		UserID: "018f0000-0000-7000-8000-000000000002",
+		Roles:  []string{"superadmin"},
+	})
+	req := &pb.ImpersonateUserReq{Id: target.ID, Reason: "ticket 42"}
+
+	t.Run("recorded", func(t *testing.T) {
+		uc := &impersonationUC{target: target}
+		h := NewUserHandler(uc, ts, authguard.New(nil, 0, 0), nil)
+
+		res, err := h.ImpersonateUser(ctx, req)
+
+		if err != nil {
+			t.Fatalf("ImpersonateUser: %v", err)
+		}
+		if want := target.ID + " ticket 42 " + res.ExpiresAt; len(uc.recorded) != 1 || uc.recorded[0] != want {
+			t.Fatalf("recorded %v, want [%s]", uc.recorded, want)
+		}
+	})
+
+	t.Run("no token without a record", func(t *testing.T) {
+		h := NewUserHandler(&impersonationUC{target: target, err: stderrors.New("db down")}, ts, authguard.New(nil, 0, 0), nil)
+
+		res, err := h.ImpersonateUser(ctx, req)
+
+		appErr, ok := err.(*errors.AppError)
+		if !ok || appErr.HTTPStatus != 500 || res != nil {
+			t.Fatalf("got %v, %v; want a 500 and no token", res, err)
+		}
+	})
+}
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	pb "veemon/handler/grpc/user"
+	"veemon/pkg/authguard"
+	"veemon/pkg/errors"
+	"veemon/pkg/redis"
+	"veemon/pkg/token"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+var introspectSecret = strings.Repeat("s", 32)
+
+func newIntrospectGuard(t *testing.T) *authguard.Guard {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	port, _ := strconv.Atoi(mr.Port())
+	rc, err := redis.New(redis.Config{Host: mr.Host(), Port: port, MaxIdle: 1, MaxActive: 2})
+	if err != nil {
+		t.Fatalf("redis.New: %v", err)
+	}
+	t.Cleanup(func() { _ = rc.Close() })
+	return authguard.New(rc, 5, 15)
+}
+
+func mintToken(t *testing.T, ts *token.TokenService, userID string) string {
+	t.Helper()
+	tok, err := ts.GenerateToken(userID, userID+"@example.com", []string{"user"}, "COMPANY-001")
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	return tok
+}
+
+func TestIntrospectBatch_MixedTokensReportedInOrder(t *testing.T) {
+	live, _ := token.NewTokenService(introspectSecret, 1)
+	expired, _ := token.NewTokenService(introspectSecret, -1)
+	guard := newIntrospectGuard(t)
+	h := NewUserHandler(nil, live, guard, nil)
+	ctx := context.Background()
+
+	valid := mintToken(t, live, "u1")
+	revoked := mintToken(t, live, "u2")
+	claims, err := live.ValidateToken(revoked)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	if err := guard.Revoke(ctx, claims.TokenID, time.Hour); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+
+	res, err := h.IntrospectBatch(ctx, &pb.IntrospectBatchReq{Tokens: []string{
+		valid,
+		mintToken(t, expired, "u3"),
+		revoked,
+		"v4.local.not-a-token",
+	}})
+	if err != nil {
+		t.Fatalf("IntrospectBatch: %v", err)
+	}
+
+	got := res.Results
+	if len(got) != 4 {
+		t.Fatalf("want 4 results, got %d", len(got))
+	}
+	if !got[0].Active || got[0].Claims.GetUserId() != "u1" || got[0].Claims.GetEmail() != "u1@example.com" || got[0].Reason != "" {
+		t.Errorf("valid token: %v", got[0])
+	}
+	for i, want := range map[int]string{1: "expired", 2: "revoked", 3: "invalid"} {
+		if got[i].Active || got[i].Reason != want || got[i].Claims != nil {
+			t.Errorf("result %d = %v, want inactive with reason %q", i, got[i], want)
+		}
+	}
+}
+
+func TestIntrospectTokens_ValidatesEachDistinctTokenOnce(t *testing.T) {
+	calls := map[string]int{}
+	validate := func(tok string) (*token.Claims, error) {
+		calls[tok]++
+		if tok == "bad" {
+			return nil, token.ErrInvalidToken
+		}
+		return &token.Claims{UserID: tok, TokenID: "jti-" + tok}, nil
+	}
+
+	results, unique := introspectTokens(context.Background(),
+		[]string{"a", "b", "a", "bad", "a", "bad"}, validate, authguard.New(nil, 0, 0))
+
+	if unique != 3 {
+		t.Fatalf("unique = %d, want 3", unique)
+	}
+	for tok, n := range calls {
+		if n != 1 {
+			t.Errorf("token %q validated %d times, want 1", tok, n)
+		}
+	}
+	want := []string{"a", "b", "a", "", "a", ""}
+	for i, r := range results {
+		if r.Claims.GetUserId() != want[i] {
+			t.Errorf("result %d user = %q, want %q", i, r.Claims.GetUserId(), want[i])
+		}
+	}
+	if results[3].Reason != "invalid" || results[5].Reason != "invalid" {
+		t.Errorf("duplicate invalid tokens: %v / %v", results[3], results[5])
+	}
+}
+
+func TestIntrospectBatch_RejectsEmptyAndOversizedBatches(t *testing.T) {
+	live, _ := token.NewTokenService(introspectSecret, 1)
+	h := NewUserHandler(nil, live, authguard.New(nil, 0, 0), nil)
+
+	for _, n := range []int{0, 101} {
+		tokens := make([]string, n)
+		for i := range tokens {
+			tokens[i] = "t" + strconv.Itoa(i)
+		}
+		_, err := h.IntrospectBatch(context.Background(), &pb.IntrospectBatchReq{Tokens: tokens})
+		appErr, ok := err.(*errors.AppError)
+		if !ok || appErr.Code != 400 {
+			t.Errorf("%d tokens: err = %v, want 400", n, err)
+		}
+	}
+
+	tokens := make([]string, 100)
+	for i := range tokens {
+		tokens[i] = "t" + strconv.Itoa(i)
+	}
+	if _, err := h.IntrospectBatch(context.Background(), &pb.IntrospectBatchReq{Tokens: tokens}); err != nil {
+		t.Errorf("100 tokens: %v", err)
+	}
+}
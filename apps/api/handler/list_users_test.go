@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	user "veemon/app/usecase/user"
+	"veemon/entity"
+	pb "veemon/handler/grpc/user"
+	"veemon/pkg/middleware"
+	"veemon/repository/user_repository"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// listRepo serves FindAll from a fixed slice; every other method panics.
+type listRepo struct {
+	user_repository.Repository
+	users []entity.UserSummary
+}
+
+func (r listRepo) FindAll(_ context.Context, _ user_repository.ListParams) ([]entity.UserSummary, int64, error) {
+	return r.users, int64(len(r.users)), nil
+}
+
+// listUsersCases share inputs between the transports: the gRPC request and
+// the equivalent REST query string.
+var listUsersCases = []struct {
+	name      string
+	req       *pb.ListUsersReq
+	query     string
+	wantPage  int32
+	wantSize  int32
+	wantSort  string
+	wantOrder string
+}{
+	{"defaulted", &pb.ListUsersReq{}, "", 1, 10, "created_at", "desc"},
+	{"valid", &pb.ListUsersReq{Page: 2, Size: 5, SortBy: "name", SortOrder: "asc"}, "?page=2&size=5&sortBy=name&sortOrder=asc", 2, 5, "name", "asc"},
+	{"partially defaulted", &pb.ListUsersReq{SortOrder: "asc"}, "?sortOrder=asc", 1, 10, "created_at", "asc"},
+}
+
+func newListHandler() pb.UserApiServer {
+	repo := listRepo{users: []entity.UserSummary{{ID: "u1"}, {ID: "u2"}}}
+	return NewUserHandler(user.NewUseCase(repo), nil, nil, nil)
+}
+
+func TestListUsers_EchoesEffectiveParamsOverGRPC(t *testing.T) {
+	h := newListHandler()
+	for _, tt := range listUsersCases {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := h.ListUsers(context.Background(), tt.req)
+			if err != nil {
+				t.Fatalf("ListUsers: %v", err)
+			}
+			p := res.Pagination
+			if p.Page != tt.wantPage || p.Size != tt.wantSize || p.SortBy != tt.wantSort || p.SortOrder != tt.wantOrder {
+				t.Fatalf("pagination = %d/%d %s %s, want %d/%d %s %s",
+					p.Page, p.Size, p.SortBy, p.SortOrder, tt.wantPage, tt.wantSize, tt.wantSort, tt.wantOrder)
+			}
+		})
+	}
+}
+
+func TestListUsers_EchoesEffectiveParamsOverREST(t *testing.T) {
+	app := fiber.New()
+	admin := func(string) (*middleware.AuthContext, error) {
+		return &middleware.AuthContext{UserID: "admin", Roles: []string{"admin"}}, nil
+	}
+	pb.RegisterUserApiRoutes(app, newListHandler(), admin)
+
+	for _, tt := range listUsersCases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/v1/users"+tt.query, nil)
+			req.Header.Set("Authorization", "Bearer x")
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			defer resp.Body.Close()
+
+			var body struct {
+				Meta struct {
+					Page      int32  `json:"page"`
+					Size      int32  `json:"size"`
+					SortBy    string `json:"sortBy"`
+					SortOrder string `json:"sortOrder"`
+				} `json:"meta"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			m := body.Meta
+			if resp.StatusCode != 200 || m.Page != tt.wantPage || m.Size != tt.wantSize || m.SortBy != tt.wantSort || m.SortOrder != tt.wantOrder {
+				t.Fatalf("status %d meta = %+v, want %d/%d %s %s",
+					resp.StatusCode, m, tt.wantPage, tt.wantSize, tt.wantSort, tt.wantOrder)
+			}
+		})
+	}
+}
+
+func TestListUsers_RejectsMaliciousSortOverREST(t *testing.T) {
+	app := fiber.New()
+	admin := func(string) (*middleware.AuthContext, error) {
+		return &middleware.AuthContext{UserID: "admin", Roles: []string{"admin"}}, nil
+	}
+	pb.RegisterUserApiRoutes(app, newListHandler(), admin)
+
+	for _, query := range []string{
+		"?sortBy=" + url.QueryEscape("created_at; DROP TABLE users--"),
+		"?sortOrder=" + url.QueryEscape("desc; DROP TABLE users--"),
+	} {
+		req := httptest.NewRequest("GET", "/api/v1/users"+query, nil)
+		req.Header.Set("Authorization", "Bearer x")
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatalf("app.Test: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != 400 {
+			t.Errorf("%s: status %d, want 400", query, resp.StatusCode)
+		}
+	}
+}
+
+// A sort outside the repository's whitelist is a client error, not a 500,
+// and the message lists the whitelist.
+func TestListUsers_InvalidSortFieldIsBadRequest(t *testing.T) {
+	h := NewUserHandler(user.NewUseCase(sortRefusingRepo{}), nil, nil, nil)
+	_, err := h.ListUsers(context.Background(), &pb.ListUsersReq{})
+	wantAppError(t, err, 400, 40011)
+
+	_, err = h.ListUsers(context.Background(), &pb.ListUsersReq{SortBy: "password"})
+	wantAppError(t, err, 400, 40011)
+	for _, column := range user_repository.SortColumns() {
+		if !strings.Contains(err.Error(), column) {
+			t.Fatalf("message %q does not list %s", err.Error(), column)
+		}
+	}
+}
+
+type sortRefusingRepo struct{ user_repository.Repository }
+
+func (sortRefusingRepo) FindAll(context.Context, user_repository.ListParams) ([]entity.UserSummary, int64, error) {
+	return nil, 0, user_repository.ErrInvalidSortField
+}
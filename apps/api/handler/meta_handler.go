@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"veemon/pkg/errors"
+	"veemon/pkg/response"
+	"veemon/pkg/token"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ErrorCodesHandler serves the error code catalog. The catalog is fixed at
+// build time, so the body and its ETag are computed once and conditional
+// requests are answered with 304 Not Modified.
+func ErrorCodesHandler() fiber.Handler {
+	body, err := json.Marshal(response.Response{Success: true, Data: errors.Catalog()})
+	if err != nil {
+		panic(err)
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	return func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderETag, etag)
+		c.Set(fiber.HeaderCacheControl, "public, max-age=3600")
+		if c.Get(fiber.HeaderIfNoneMatch) == etag {
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return c.Send(body)
+	}
+}
+
+// TokenKeysHandler serves the public keys verifying ts's tokens, as a bare
+// token.KeyDocument (other services read it with token.Verifier). Keys
+// change on rotation, so the ETag is computed per request and the document
+// is cached briefly. It is 404 when ts issues local (symmetric) tokens.
+func TokenKeysHandler(ts *token.TokenService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		doc, ok := ts.PublicKeys()
+		if !ok {
+			return errors.NotFound("token keys are published only in public token mode").FiberError(c)
+		}
+		body, err := json.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(body)
+		etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+		c.Set(fiber.HeaderETag, etag)
+		c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+		if c.Get(fiber.HeaderIfNoneMatch) == etag {
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return c.Send(body)
+	}
+}
@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"veemon/pkg/errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorCodesHandler_ServesCatalog(t *testing.T) {
+	app := fiber.New()
+	app.Get("/api/v1/meta/error-codes", ErrorCodesHandler())
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/v1/meta/error-codes", nil))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NotEmpty(t, resp.Header.Get(fiber.HeaderETag))
+	assert.Contains(t, resp.Header.Get(fiber.HeaderCacheControl), "public")
+
+	raw, _ := io.ReadAll(resp.Body)
+	var out struct {
+		Success bool              `json:"success"`
+		Data    []errors.CodeInfo `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &out))
+	assert.True(t, out.Success)
+	assert.Equal(t, errors.Catalog(), out.Data)
+}
+
+func TestErrorCodesHandler_ETag(t *testing.T) {
+	app := fiber.New()
+	app.Get("/codes", ErrorCodesHandler())
+
+	first, err := app.Test(httptest.NewRequest(http.MethodGet, "/codes", nil))
+	require.NoError(t, err)
+	etag := first.Header.Get(fiber.HeaderETag)
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		want        int
+	}{
+		{"matching etag", etag, http.StatusNotModified},
+		{"stale etag", `"stale"`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/codes", nil)
+			req.Header.Set(fiber.HeaderIfNoneMatch, tt.ifNoneMatch)
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, resp.StatusCode)
+			assert.Equal(t, etag, resp.Header.Get(fiber.HeaderETag))
+		})
+	}
+}
+
+// fixedCodeHelpers maps the pkg/errors helpers that stamp a fixed code.
+var fixedCodeHelpers = map[string]int{
+	"Unauthorized":         401,
+	"Forbidden":            403,
+	"NotFound":             404,
+	"UnsupportedMediaType": 415,
+	"TooManyRequests":      429,
+	"ValidationError":      400,
+}
+
+// codeArgHelpers are the calls whose first argument is an explicit code.
+var codeArgHelpers = map[string]bool{
+	"BadRequest": true,
+	"Conflict":   true,
+	"Internal":   true,
+	"internal":   true, // (*userHandler).internal
+}
+
+// cataloguedDirs are the packages whose errors reach clients: this one and
+// the middleware that answers on its behalf.
+var cataloguedDirs = []string{".", "../pkg/idempotency", "../pkg/middleware", "../pkg/binding", "../config"}
+
+// appErrorCode returns the code call stamps on an AppError, if it is one of
+// the helpers above or errors.New(status, grpcCode, appCode, message) with a
+// literal appCode.
+func appErrorCode(call *ast.CallExpr) (string, int, bool) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return "", 0, false
+	}
+	fn := sel.Sel.Name
+	if code, fixed := fixedCodeHelpers[fn]; fixed {
+		return fn, code, true
+	}
+	var arg ast.Expr
+	switch {
+	case codeArgHelpers[fn] && len(call.Args) > 0:
+		arg = call.Args[0]
+	case fn == "New" && len(call.Args) == 4:
+		// The standard library's errors.New takes one argument.
+		if pkg, ok := sel.X.(*ast.Ident); !ok || pkg.Name != "errors" {
+			return "", 0, false
+		}
+		arg = call.Args[2]
+	default:
+		return "", 0, false
+	}
+	lit, ok := arg.(*ast.BasicLit)
+	if !ok || lit.Kind != token.INT {
+		return "", 0, false
+	}
+	code, _ := strconv.Atoi(lit.Value)
+	return fn, code, true
+}
+
+// TestErrorCodesAreCataloged walks the source of cataloguedDirs and fails if
+// any error code they can return is missing from the pkg/errors catalog.
+func TestErrorCodesAreCataloged(t *testing.T) {
+	fset := token.NewFileSet()
+	found := map[string]int{}
+	for _, dir := range cataloguedDirs {
+		pkgs, err := parser.ParseDir(fset, dir, nil, 0)
+		require.NoError(t, err)
+		for _, pkg := range pkgs {
+			for _, file := range pkg.Files {
+				ast.Inspect(file, func(n ast.Node) bool {
+					call, ok := n.(*ast.CallExpr)
+					if !ok {
+						return true
+					}
+					fn, code, ok := appErrorCode(call)
+					if !ok {
+						return true
+					}
+					found[fn]++
+					if _, ok := errors.Lookup(code); !ok {
+						t.Errorf("%s: %s(%d) uses an error code missing from the catalog", fset.Position(call.Pos()), fn, code)
+					}
+					return true
+				})
+			}
+		}
+	}
+	assert.NotZero(t, found["internal"], "expected to find (*userHandler).internal calls")
+	assert.NotZero(t, found["New"], "expected to find errors.New calls with an explicit code")
+}
+
+func TestAppErrorCode(t *testing.T) {
+	tests := []struct {
+		src      string
+		wantCode int
+		wantOK   bool
+	}{
+		{`errors.New(http.StatusConflict, codes.FailedPrecondition, 40904, "user limit reached")`, 40904, true},
+		{`errors.New("plain error")`, 0, false},
+		{`fmt.New(1, 2, 3, 4)`, 0, false},
+		{`errors.Conflict(40901, "email already registered")`, 40901, true},
+		{`errors.NotFound("user not found")`, 404, true},
+		{`errors.BadRequest(code, "dynamic")`, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			expr, err := parser.ParseExpr(tt.src)
+			require.NoError(t, err)
+
+			_, code, ok := appErrorCode(expr.(*ast.CallExpr))
+
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantCode, code)
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"encoding/json"
+	"time"
+
+	"veemon/pkg/errors"
+	"veemon/pkg/metrics"
+	"veemon/pkg/middleware"
+	"veemon/pkg/notify"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// NotificationsPermission is required to open the notification websocket.
+const NotificationsPermission = "users.read"
+
+// Close codes sent when a notification client fails authentication, in the
+// 4000-4999 range RFC 6455 leaves to applications (mirroring 401 and 403).
+const (
+	CloseUnauthorized = 4401
+	CloseForbidden    = 4403
+)
+
+// NotificationsConfig tunes the notification websocket. Zero values use the
+// defaults below.
+type NotificationsConfig struct {
+	// AuthTimeout bounds the wait for the first message of a client that did
+	// not pass ?token=.
+	AuthTimeout time.Duration
+	// PingInterval is how often the server pings; a client that has not
+	// answered within twice the interval is disconnected.
+	PingInterval time.Duration
+	// WriteTimeout bounds each write, so a client that stops reading cannot
+	// pin the connection's goroutine.
+	WriteTimeout time.Duration
+}
+
+const (
+	defaultNotifyAuthTimeout  = 5 * time.Second
+	defaultNotifyPingInterval = 30 * time.Second
+	defaultNotifyWriteTimeout = 10 * time.Second
+)
+
+// notifyMessage is a control message on the notification socket. Events are
+// sent as notify.Event.
+type notifyMessage struct {
+	Type  string `json:"type"`
+	Token string `json:"token,omitempty"`
+}
+
+// NotificationsHandler upgrades GET /api/v1/ws/notifications to a websocket
+// that streams the caller's company's user events from hub.
+//
+// Browsers cannot set headers on a websocket handshake, so the bearer token
+// is passed either as ?token= (checked before the upgrade, failing with 401
+// or 403) or as a first message {"type":"auth","token":"..."} (failing with
+// close code 4401 or 4403). Once subscribed the server sends {"type":"ready"}.
+//
+// Each connection queues events in a bounded buffer; a client that lets it
+// fill is disconnected with close code 1013 (try again later) and can
+// reconnect, rather than being served stale events.
+func NotificationsHandler(hub *notify.Hub, validate middleware.TokenValidator, cfg NotificationsConfig, log *zap.Logger) fiber.Handler {
+	if cfg.AuthTimeout <= 0 {
+		cfg.AuthTimeout = defaultNotifyAuthTimeout
+	}
+	if cfg.PingInterval <= 0 {
+		cfg.PingInterval = defaultNotifyPingInterval
+	}
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = defaultNotifyWriteTimeout
+	}
+	n := &notifications{hub: hub, validate: validate, cfg: cfg, log: log}
+	upgrade := websocket.New(n.serve)
+
+	return func(c *fiber.Ctx) error {
+		if !websocket.IsWebSocketUpgrade(c) {
+			return fiber.ErrUpgradeRequired
+		}
+		if token := c.Query("token"); token != "" {
+			authCtx, err := validate(token)
+			if err != nil {
+				return errors.Unauthorized("invalid token").FiberError(c)
+			}
+			if !authCtx.HasPermission(NotificationsPermission) {
+				return errors.Forbidden("insufficient permissions").FiberError(c)
+			}
+			c.Locals("auth", authCtx)
+		}
+		return upgrade(c)
+	}
+}
+
+type notifications struct {
+	hub      *notify.Hub
+	validate middleware.TokenValidator
+	cfg      NotificationsConfig
+	log      *zap.Logger
+}
+
+func (n *notifications) serve(conn *websocket.Conn) {
+	defer conn.Close() //nolint:errcheck // best-effort cleanup
+
+	authCtx, _ := conn.Locals("auth").(*middleware.AuthContext)
+	if authCtx == nil {
+		var code int
+		if authCtx, code = n.authenticate(conn); authCtx == nil {
+			n.closeWith(conn, code, "authentication failed")
+			return
+		}
+	}
+
+	sub := n.hub.Subscribe(authCtx.CompanyCode)
+	defer sub.Close()
+	if m := metrics.Get(); m != nil {
+		m.AddWebsocketConnections(1)
+		defer m.AddWebsocketConnections(-1)
+	}
+
+	if err := n.write(conn, notifyMessage{Type: "ready"}); err != nil {
+		return
+	}
+
+	// The reader handles pongs and notices the client going away; clients
+	// send nothing else after authenticating.
+	pongWait := 2 * n.cfg.PingInterval
+	_ = conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(n.cfg.PingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case e, ok := <-sub.Events():
+			if !ok {
+				if !sub.Overflowed() {
+					n.closeWith(conn, websocket.CloseGoingAway, "server shutting down")
+					return
+				}
+				if m := metrics.Get(); m != nil {
+					m.RecordWebsocketSlowClient()
+				}
+				n.log.Warn("notification client too slow; disconnecting", zap.String("user_id", authCtx.UserID))
+				n.closeWith(conn, websocket.CloseTryAgainLater, "client too slow")
+				return
+			}
+			if err := n.write(conn, e); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(n.cfg.WriteTimeout)); err != nil {
+				return
+			}
+		case <-gone:
+			return
+		}
+	}
+}
+
+// authenticate reads the {"type":"auth"} first message, returning the
+// caller or, on failure, nil and the close code to send.
+func (n *notifications) authenticate(conn *websocket.Conn) (*middleware.AuthContext, int) {
+	_ = conn.SetReadDeadline(time.Now().Add(n.cfg.AuthTimeout))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		return nil, CloseUnauthorized
+	}
+	var msg notifyMessage
+	if json.Unmarshal(data, &msg) != nil || msg.Type != "auth" || msg.Token == "" {
+		return nil, CloseUnauthorized
+	}
+	authCtx, err := n.validate(msg.Token)
+	if err != nil {
+		return nil, CloseUnauthorized
+	}
+	if !authCtx.HasPermission(NotificationsPermission) {
+		return nil, CloseForbidden
+	}
+	return authCtx, 0
+}
+
+func (n *notifications) write(conn *websocket.Conn, v interface{}) error {
+	_ = conn.SetWriteDeadline(time.Now().Add(n.cfg.WriteTimeout))
+	return conn.WriteJSON(v)
+}
+
+func (n *notifications) closeWith(conn *websocket.Conn, code int, reason string) {
+	_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(n.cfg.WriteTimeout))
+}
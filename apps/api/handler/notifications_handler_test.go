@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"veemon/pkg/middleware"
+	"veemon/pkg/notify"
+
+	"github.com/fasthttp/websocket"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// notifyTokens maps the test tokens to their callers.
+var notifyTokens = map[string]*middleware.AuthContext{
+	"admin-a":   {UserID: "u-admin-a", Roles: []string{"admin"}, CompanyCode: "A"},
+	"admin-b":   {UserID: "u-admin-b", Roles: []string{"admin"}, CompanyCode: "B"},
+	"plainuser": {UserID: "u-user", Roles: []string{"user"}, CompanyCode: "A"},
+}
+
+func notifyValidator(tok string) (*middleware.AuthContext, error) {
+	if a, ok := notifyTokens[tok]; ok {
+		return a, nil
+	}
+	return nil, errors.New("invalid token")
+}
+
+// serveNotifications runs the endpoint over a real listener, since the
+// websocket upgrade hijacks the connection, and returns its ws:// URL.
+func serveNotifications(t *testing.T, hub *notify.Hub, cfg NotificationsConfig) string {
+	t.Helper()
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Get("/api/v1/ws/notifications", NotificationsHandler(hub, notifyValidator, cfg, zap.NewNop()))
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go func() { _ = app.Listener(ln) }()
+	t.Cleanup(func() {
+		hub.Close()
+		_ = app.Shutdown()
+	})
+	return "ws://" + ln.Addr().String() + "/api/v1/ws/notifications"
+}
+
+// dialReady connects and waits for the server's ready message.
+func dialReady(t *testing.T, url string) *websocket.Conn {
+	t.Helper()
+	conn, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial: %v (status %v)", err, statusOf(resp))
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	var msg notifyMessage
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	if err := conn.ReadJSON(&msg); err != nil || msg.Type != "ready" {
+		t.Fatalf("first message = %+v, %v; want ready", msg, err)
+	}
+	return conn
+}
+
+func statusOf(resp *http.Response) int {
+	if resp == nil {
+		return 0
+	}
+	return resp.StatusCode
+}
+
+func TestNotifications_RejectsUnauthenticated(t *testing.T) {
+	url := serveNotifications(t, notify.NewHub(0), NotificationsConfig{AuthTimeout: 200 * time.Millisecond})
+
+	t.Run("query token", func(t *testing.T) {
+		for tok, want := range map[string]int{"bogus": 401, "plainuser": 403} {
+			_, resp, err := websocket.DefaultDialer.Dial(url+"?token="+tok, nil)
+			if err == nil || statusOf(resp) != want {
+				t.Errorf("token %s: dial err = %v, status %d; want %d", tok, err, statusOf(resp), want)
+			}
+		}
+	})
+
+	t.Run("first message", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			first string
+			want  int
+		}{
+			{"invalid token", `{"type":"auth","token":"bogus"}`, CloseUnauthorized},
+			{"not an auth message", `{"type":"hello"}`, CloseUnauthorized},
+			{"missing permission", `{"type":"auth","token":"plainuser"}`, CloseForbidden},
+			{"silent client", "", CloseUnauthorized},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+				if err != nil {
+					t.Fatalf("dial: %v", err)
+				}
+				defer conn.Close()
+				if tt.first != "" {
+					_ = conn.WriteMessage(websocket.TextMessage, []byte(tt.first))
+				}
+				_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+				_, _, err = conn.ReadMessage()
+				if !websocket.IsCloseError(err, tt.want) {
+					t.Fatalf("read err = %v, want close %d", err, tt.want)
+				}
+			})
+		}
+	})
+}
+
+func TestNotifications_DeliversCompanyEvents(t *testing.T) {
+	hub := notify.NewHub(0)
+	url := serveNotifications(t, hub, NotificationsConfig{})
+
+	viaQuery := dialReady(t, url+"?token=admin-a")
+
+	viaMessage, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer viaMessage.Close()
+	_ = viaMessage.WriteJSON(notifyMessage{Type: "auth", Token: "admin-a"})
+	var ready notifyMessage
+	if err := viaMessage.ReadJSON(&ready); err != nil || ready.Type != "ready" {
+		t.Fatalf("first message = %+v, %v; want ready", ready, err)
+	}
+
+	otherCompany := dialReady(t, url+"?token=admin-b")
+
+	hub.Publish(context.Background(), notify.Event{Type: notify.UserRegistered, CompanyCode: "B", Data: json.RawMessage(`{"id":"b1"}`)})
+	hub.Publish(context.Background(), notify.Event{Type: notify.UserRegistered, CompanyCode: "A", Data: json.RawMessage(`{"id":"a1"}`)})
+
+	for name, conn := range map[string]*websocket.Conn{"query": viaQuery, "message": viaMessage} {
+		var e notify.Event
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+		if err := conn.ReadJSON(&e); err != nil {
+			t.Fatalf("%s: read: %v", name, err)
+		}
+		if e.Type != notify.UserRegistered || e.CompanyCode != "A" || string(e.Data) != `{"id":"a1"}` {
+			t.Errorf("%s: event = %+v, want company A's registration only", name, e)
+		}
+	}
+
+	var e notify.Event
+	_ = otherCompany.SetReadDeadline(time.Now().Add(time.Second))
+	if err := otherCompany.ReadJSON(&e); err != nil || e.CompanyCode != "B" {
+		t.Fatalf("company B event = %+v, %v", e, err)
+	}
+}
+
+func TestNotifications_DisconnectsSlowClient(t *testing.T) {
+	hub := notify.NewHub(2)
+	url := serveNotifications(t, hub, NotificationsConfig{WriteTimeout: 100 * time.Millisecond})
+	conn := dialReady(t, url+"?token=admin-a")
+
+	// The client stops reading; large events fill the socket buffers, the
+	// server's writes stall and the subscription's buffer overflows.
+	big := json.RawMessage(`"` + strings.Repeat("x", 64<<10) + `"`)
+	deadline := time.Now().Add(5 * time.Second)
+	for hub.Len() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("slow client was never dropped")
+		}
+		hub.Publish(context.Background(), notify.Event{Type: notify.UserUpdated, CompanyCode: "A", Data: big})
+		time.Sleep(time.Millisecond)
+	}
+
+	// Draining what was buffered ends in the server closing the connection.
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				t.Fatalf("connection still open: %v", err)
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"veemon/app/usecase/user"
+	pb "veemon/handler/grpc/user"
+	"veemon/pkg/errors"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+)
+
+// forgotPasswordMessage answers every forgot-password request, so the
+// response never tells whether an email has an account.
+const forgotPasswordMessage = "if an account exists for this email, a password reset link has been sent to it"
+
+func passwordResetUnavailable() error {
+	return errors.New(http.StatusServiceUnavailable, codes.Unavailable, 50302,
+		"password reset is temporarily unavailable")
+}
+
+// ChangePassword replaces the caller's password after checking the current
+// one. Wrong current passwords count towards the login lockout, so a stolen
+// token cannot be used to guess the password. Other sessions stay signed in.
+func (h *userHandler) ChangePassword(ctx context.Context, req *pb.ChangePasswordReq) (*pb.ChangePasswordRes, error) {
+	authCtx := getAuthFromContext(ctx)
+	if authCtx == nil {
+		return nil, errors.Unauthorized("authentication required")
+	}
+	if err := forbidImpersonation(ctx); err != nil {
+		return nil, err
+	}
+	if err := pb.ValidateRequest(req); err != nil {
+		return nil, err
+	}
+	ip := clientIP(ctx)
+	if h.guard.IsLocked(ctx, authCtx.Email, ip) {
+		return nil, errors.TooManyRequests("too many failed login attempts; try again later")
+	}
+
+	err := h.userUC.ChangePassword(ctx, authCtx.UserID, req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		switch err {
+		case user.ErrInvalidCreds:
+			h.guard.RecordFailure(ctx, authCtx.Email, ip)
+			return nil, errors.BadRequest(40009, "current password is incorrect")
+		case user.ErrNotFound:
+			return nil, errors.NotFound("user not found")
+		default:
+			return nil, h.internal(50015, "failed to change password", err)
+		}
+	}
+	h.guard.Reset(ctx, authCtx.Email)
+
+	return &pb.ChangePasswordRes{Message: "password changed"}, nil
+}
+
+// ForgotPassword emails a single-use reset token to the account with the
+// given email. It answers the same for unknown emails, and for failures past
+// the lookup, which are only logged: either would reveal that the account
+// exists.
+func (h *userHandler) ForgotPassword(ctx context.Context, req *pb.ForgotPasswordReq) (*pb.ForgotPasswordRes, error) {
+	if err := pb.ValidateRequest(req); err != nil {
+		return nil, err
+	}
+
+	if err := h.userUC.RequestPasswordReset(ctx, req.Email); err != nil {
+		if err == user.ErrPasswordResetUnavailable {
+			return nil, passwordResetUnavailable()
+		}
+		h.logger.Error("failed to request password reset", zap.Error(err))
+	}
+
+	return &pb.ForgotPasswordRes{Message: forgotPasswordMessage}, nil
+}
+
+// ResetPassword redeems a reset token and sets the account's new password.
+// Since the old password may be known to someone else, every token issued
+// to the account is revoked and its login lockout cleared.
+func (h *userHandler) ResetPassword(ctx context.Context, req *pb.ResetPasswordReq) (*pb.ResetPasswordRes, error) {
+	if err := pb.ValidateRequest(req); err != nil {
+		return nil, err
+	}
+
+	userEntity, err := h.userUC.ResetPassword(ctx, req.Token, req.NewPassword)
+	if err != nil {
+		switch err {
+		case user.ErrInvalidResetToken:
+			return nil, errors.BadRequest(40010, "password reset token is invalid or expired")
+		case user.ErrPasswordResetUnavailable:
+			return nil, passwordResetUnavailable()
+		default:
+			return nil, h.internal(50016, "failed to reset password", err)
+		}
+	}
+
+	if err := h.guard.RevokeUser(ctx, userEntity.ID, h.tokenService.Lifetime()); err != nil {
+		h.logger.Warn("failed to revoke sessions after password reset",
+			zap.String("user_id", userEntity.ID), zap.Error(err))
+	}
+	h.guard.Reset(ctx, userEntity.Email)
+
+	return &pb.ResetPasswordRes{Message: "password reset; sign in with the new password"}, nil
+}
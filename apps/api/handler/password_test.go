@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	user "veemon/app/usecase/user"
+	"veemon/entity"
+	pb "veemon/handler/grpc/user"
+	"veemon/pkg/authguard"
+	"veemon/pkg/events"
+	"veemon/pkg/middleware"
+	"veemon/pkg/passwordreset"
+	"veemon/pkg/redis"
+	"veemon/pkg/token"
+
+	"github.com/alicebob/miniredis/v2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// resetOutbox records the reset events the notifier publishes.
+type resetOutbox struct{ sent []passwordreset.Requested }
+
+func (o *resetOutbox) PublishJSON(_ context.Context, _, _ string, message interface{}) error {
+	var req passwordreset.Requested
+	if err := json.Unmarshal(message.(events.Envelope).Data, &req); err != nil {
+		return err
+	}
+	o.sent = append(o.sent, req)
+	return nil
+}
+
+func TestPasswordFlows(t *testing.T) {
+	const id, email, password = "018f0000-0000-7000-8000-0000000000bb", "forgetful@example.com", "Password123"
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("bcrypt: %v", err)
+	}
+	repo := &memRepo{users: map[string]*entity.User{id: {
+		ID: id, Email: email, Password: string(hash), Name: "Forgetful", Status: entity.UserStatusActive,
+	}}}
+	mr := miniredis.RunT(t)
+	port, _ := strconv.Atoi(mr.Port())
+	rc, err := redis.New(redis.Config{Host: mr.Host(), Port: port, MaxIdle: 1, MaxActive: 2})
+	if err != nil {
+		t.Fatalf("redis.New: %v", err)
+	}
+	t.Cleanup(func() { _ = rc.Close() })
+	outbox := &resetOutbox{}
+	uc := user.NewUseCase(repo, user.WithPasswordReset(
+		passwordreset.NewStore(rc, 0), passwordreset.NewNotifier(outbox, "events", "test", nil)))
+	guard := authguard.New(rc, 5, 15)
+	ts, _ := token.NewTokenService(introspectSecret, 1)
+	h := NewUserHandler(uc, ts, guard, nil)
+	ctx := context.Background()
+	authed := middleware.WithAuthContext(ctx, &middleware.AuthContext{UserID: id, Email: email})
+
+	// Change password: the current one must be right and the new one valid.
+	_, err = h.ChangePassword(authed, &pb.ChangePasswordReq{CurrentPassword: "Wrong12345", NewPassword: "Changed123"})
+	wantAppError(t, err, 400, 40009)
+	_, err = h.ChangePassword(authed, &pb.ChangePasswordReq{CurrentPassword: password, NewPassword: "weak"})
+	wantAppError(t, err, 400, 400)
+	impersonated := middleware.WithAuthContext(ctx, &middleware.AuthContext{UserID: id, Email: email, IsImpersonated: true})
+	_, err = h.ChangePassword(impersonated, &pb.ChangePasswordReq{CurrentPassword: password, NewPassword: "Changed123"})
+	wantAppError(t, err, 403, 403)
+	if _, err := h.ChangePassword(authed, &pb.ChangePasswordReq{CurrentPassword: password, NewPassword: "Changed123"}); err != nil {
+		t.Fatalf("ChangePassword: %v", err)
+	}
+	login, err := h.Login(ctx, &pb.LoginReq{Email: email, Password: "Changed123"})
+	if err != nil {
+		t.Fatalf("Login with the changed password: %v", err)
+	}
+
+	// Forgot password answers the same for an unknown email, which gets no
+	// token.
+	unknown, err := h.ForgotPassword(ctx, &pb.ForgotPasswordReq{Email: "nobody@example.com"})
+	if err != nil {
+		t.Fatalf("ForgotPassword(unknown): %v", err)
+	}
+	known, err := h.ForgotPassword(ctx, &pb.ForgotPasswordReq{Email: email})
+	if err != nil {
+		t.Fatalf("ForgotPassword: %v", err)
+	}
+	if unknown.Message != known.Message {
+		t.Fatalf("responses differ: %q vs %q", unknown.Message, known.Message)
+	}
+	if len(outbox.sent) != 1 || outbox.sent[0].UserID != id || outbox.sent[0].Token == "" {
+		t.Fatalf("reset events = %+v, want one for %s", outbox.sent, id)
+	}
+	resetToken := outbox.sent[0].Token
+
+	// Reset: the token works once and ends every existing session.
+	_, err = h.ResetPassword(ctx, &pb.ResetPasswordReq{Token: "not-a-token", NewPassword: "Reset12345"})
+	wantAppError(t, err, 400, 40010)
+	if _, err := h.ResetPassword(ctx, &pb.ResetPasswordReq{Token: resetToken, NewPassword: "Reset12345"}); err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+	_, err = h.ResetPassword(ctx, &pb.ResetPasswordReq{Token: resetToken, NewPassword: "Again12345"})
+	wantAppError(t, err, 400, 40010)
+
+	claims, err := ts.ValidateToken(login.Token)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	if !guard.IsUserRevoked(ctx, id, claims.IssuedAt) {
+		t.Fatal("the token issued before the reset should be revoked")
+	}
+	if _, err := h.Login(ctx, &pb.LoginReq{Email: email, Password: "Reset12345"}); err != nil {
+		t.Fatalf("Login with the reset password: %v", err)
+	}
+}
+
+func TestPasswordReset_Unavailable(t *testing.T) {
+	ts, _ := token.NewTokenService(introspectSecret, 1)
+	h := NewUserHandler(user.NewUseCase(&memRepo{}), ts, authguard.New(nil, 5, 15), nil)
+
+	_, err := h.ForgotPassword(context.Background(), &pb.ForgotPasswordReq{Email: "a@example.com"})
+	wantAppError(t, err, 503, 50302)
+	_, err = h.ResetPassword(context.Background(), &pb.ResetPasswordReq{Token: "t", NewPassword: "Reset12345"})
+	wantAppError(t, err, 503, 50302)
+}
@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	user "veemon/app/usecase/user"
+	"veemon/entity"
+	pb "veemon/handler/grpc/user"
+	"veemon/internal/fixtures"
+	"veemon/pkg/middleware"
+	"veemon/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+const trashedID = "018f0000-0000-7000-8000-0000000000aa"
+
+// trashRepo is a memRepo that also keeps soft-deleted users, which the
+// memRepo finders do not see.
+type trashRepo struct {
+	*memRepo
+	trash map[string]*entity.User
+}
+
+func (r *trashRepo) FindByIDIncludingDeleted(ctx context.Context, id string) (*entity.User, error) {
+	if u, ok := r.trash[id]; ok {
+		cp := *u
+		return &cp, nil
+	}
+	return r.FindByID(ctx, id)
+}
+
+func (r *trashRepo) Restore(_ context.Context, id string, _ map[string]interface{}) (*entity.User, error) {
+	u, ok := r.trash[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	delete(r.trash, id)
+	u.DeletedAt = gorm.DeletedAt{}
+	r.users[id] = u
+	cp := *u
+	return &cp, nil
+}
+
+func (r *trashRepo) HardDelete(_ context.Context, id string) error {
+	_, live := r.users[id]
+	_, trashed := r.trash[id]
+	if !live && !trashed {
+		return repository.ErrNotFound
+	}
+	delete(r.users, id)
+	delete(r.trash, id)
+	return nil
+}
+
+func newTrashRepo() *trashRepo {
+	deleted := fixtures.User().WithID(trashedID).WithEmail("gone@example.com").Deleted().Build()
+	return &trashRepo{
+		memRepo: &memRepo{users: map[string]*entity.User{
+			versionedID: fixtures.User().WithID(versionedID).WithEmail("live@example.com").Build(),
+		}},
+		trash: map[string]*entity.User{trashedID: deleted},
+	}
+}
+
+func TestRestoreUser_RestoresOverREST(t *testing.T) {
+	repo := newTrashRepo()
+	app := fiber.New()
+	admin := func(string) (*middleware.AuthContext, error) {
+		return &middleware.AuthContext{UserID: "admin", Roles: []string{"admin"}}, nil
+	}
+	pb.RegisterUserApiRoutes(app, NewUserHandler(user.NewUseCase(repo), nil, nil, nil), admin)
+
+	req := httptest.NewRequest("POST", "/api/v1/users/"+trashedID+"/restore", nil)
+	req.Header.Set("Authorization", "Bearer x")
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != 200 || resp.Header.Get("ETag") == "" {
+		t.Fatalf("status %d, ETag %q; want 200 with an ETag", resp.StatusCode, resp.Header.Get("ETag"))
+	}
+	if _, ok := repo.users[trashedID]; !ok {
+		t.Fatal("user was not restored")
+	}
+}
+
+func TestRestoreUser_Errors(t *testing.T) {
+	tests := []struct {
+		name         string
+		id           string
+		edit         func(*trashRepo)
+		status, code int
+	}{
+		{"unknown user", "018f0000-0000-7000-8000-0000000000ff", nil, 404, 404},
+		{"live user", versionedID, nil, 409, 40906},
+		{"email re-registered", trashedID, func(r *trashRepo) { r.users[versionedID].Email = "gone@example.com" }, 409, 40901},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTrashRepo()
+			if tt.edit != nil {
+				tt.edit(repo)
+			}
+			h := NewUserHandler(user.NewUseCase(repo), nil, nil, nil)
+			_, err := h.RestoreUser(asCaller("admin"), &pb.RestoreUserReq{Id: tt.id})
+			wantAppError(t, err, tt.status, tt.code)
+		})
+	}
+}
+
+func TestDeleteUser_Permanent(t *testing.T) {
+	t.Run("admin is refused", func(t *testing.T) {
+		repo := newTrashRepo()
+		h := NewUserHandler(user.NewUseCase(repo), nil, nil, nil)
+		_, err := h.DeleteUser(asCaller("admin"), &pb.DeleteUserReq{Id: trashedID, Permanent: true})
+		wantAppError(t, err, 403, 403)
+		if _, ok := repo.trash[trashedID]; !ok {
+			t.Fatal("user was purged")
+		}
+	})
+
+	t.Run("superadmin purges a deleted user", func(t *testing.T) {
+		repo := newTrashRepo()
+		h := NewUserHandler(user.NewUseCase(repo), nil, nil, nil)
+		if _, err := h.DeleteUser(asCaller("superadmin"), &pb.DeleteUserReq{Id: trashedID, Permanent: true}); err != nil {
+			t.Fatalf("DeleteUser: %v", err)
+		}
+		if _, err := repo.FindByIDIncludingDeleted(context.Background(), trashedID); err != repository.ErrNotFound {
+			t.Fatalf("user still present: %v", err)
+		}
+	})
+
+	t.Run("permanent binds from the query string", func(t *testing.T) {
+		repo := newTrashRepo()
+		app := fiber.New()
+		superadmin := func(string) (*middleware.AuthContext, error) {
+			return &middleware.AuthContext{UserID: "root", Roles: []string{"superadmin"}}, nil
+		}
+		pb.RegisterUserApiRoutes(app, NewUserHandler(user.NewUseCase(repo), nil, nil, nil), superadmin)
+
+		req := httptest.NewRequest("DELETE", "/api/v1/users/"+versionedID+"?permanent=true", nil)
+		req.Header.Set("Authorization", "Bearer x")
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatalf("app.Test: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != 200 || len(repo.users) != 0 {
+			t.Fatalf("status %d, %d live users; want 200 and the row gone", resp.StatusCode, len(repo.users))
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+
+	pb "veemon/handler/grpc/user"
+	"veemon/pkg/errors"
+	"veemon/pkg/runtimeconfig"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// WithRuntimeConfig serves the admin runtime-config API from s. Without it
+// the API lists no keys and accepts no changes.
+func WithRuntimeConfig(s *runtimeconfig.Store) Option {
+	return func(h *userHandler) {
+		if s != nil {
+			h.runtimeConfig = s
+		}
+	}
+}
+
+// GetRuntimeConfig lists every runtime config key with its value on this
+// replica (superadmin only).
+func (h *userHandler) GetRuntimeConfig(_ context.Context, _ *emptypb.Empty) (*pb.RuntimeConfig, error) {
+	return h.runtimeConfigRes(), nil
+}
+
+// UpdateRuntimeConfig sets and resets runtime config keys on every replica
+// (superadmin only).
+func (h *userHandler) UpdateRuntimeConfig(ctx context.Context, req *pb.UpdateRuntimeConfigReq) (*pb.RuntimeConfig, error) {
+	if err := forbidImpersonation(ctx); err != nil {
+		return nil, err
+	}
+	if len(req.Values) == 0 && len(req.Reset_) == 0 {
+		return nil, errors.BadRequest(40008, "nothing to change: send values and/or reset")
+	}
+
+	var actorID string
+	if a := getAuthFromContext(ctx); a != nil {
+		actorID = a.UserID
+	}
+	err := h.runtimeConfig.Update(ctx, req.Values, req.Reset_, actorID)
+	if err != nil {
+		var unknown *runtimeconfig.UnknownKeyError
+		var invalid *runtimeconfig.InvalidValueError
+		switch {
+		case stderrors.As(err, &unknown):
+			return nil, errors.BadRequest(40007, unknown.Error()+"; GET /api/v1/admin/runtime-config lists the keys")
+		case stderrors.As(err, &invalid):
+			return nil, errors.BadRequest(40008, invalid.Error())
+		case stderrors.Is(err, runtimeconfig.ErrUnavailable):
+			h.logger.Warn("runtime config update failed", zap.Error(err))
+			return nil, errors.New(http.StatusServiceUnavailable, codes.Unavailable, 50301,
+				"runtime config cannot be changed right now; current values stay in effect")
+		}
+		return nil, h.internal(50014, "failed to update runtime config", err)
+	}
+	return h.runtimeConfigRes(), nil
+}
+
+func (h *userHandler) runtimeConfigRes() *pb.RuntimeConfig {
+	entries := h.runtimeConfig.Entries()
+	res := &pb.RuntimeConfig{Entries: make([]*pb.RuntimeConfigEntry, len(entries))}
+	for i, e := range entries {
+		res.Entries[i] = &pb.RuntimeConfigEntry{
+			Key:          e.Name,
+			Type:         string(e.Type),
+			Value:        e.Value,
+			DefaultValue: e.Default,
+			Overridden:   e.Overridden,
+			Description:  e.Description,
+		}
+	}
+	return res
+}
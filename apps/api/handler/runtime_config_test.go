@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	pb "veemon/handler/grpc/user"
+	"veemon/pkg/middleware"
+	"veemon/pkg/redis"
+	"veemon/pkg/runtimeconfig"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gofiber/fiber/v2"
+)
+
+var testRuntimeSchema = []runtimeconfig.Key{
+	{Name: "limit.max", Type: runtimeconfig.Int, Default: "100", Validate: runtimeconfig.IntRange(1, 1000)},
+	{Name: "feature.on", Type: runtimeconfig.Bool, Default: "false"},
+}
+
+func newRuntimeConfigHandler(t *testing.T, withRedis bool) (pb.UserApiServer, *runtimeconfig.Store) {
+	t.Helper()
+	var client *redis.Client
+	if withRedis {
+		mr := miniredis.RunT(t)
+		port, _ := strconv.Atoi(mr.Port())
+		var err error
+		client, err = redis.New(redis.Config{Host: mr.Host(), Port: port, MaxIdle: 2, MaxActive: 4})
+		if err != nil {
+			t.Fatalf("redis.New: %v", err)
+		}
+		t.Cleanup(func() { _ = client.Close() })
+	}
+	store := runtimeconfig.New(client, testRuntimeSchema, nil)
+	return NewUserHandler(nil, nil, nil, nil, WithRuntimeConfig(store)), store
+}
+
+func TestUpdateRuntimeConfig(t *testing.T) {
+	h, store := newRuntimeConfigHandler(t, true)
+	ctx := context.Background()
+
+	res, err := h.UpdateRuntimeConfig(ctx, &pb.UpdateRuntimeConfigReq{Values: map[string]string{"limit.max": "250"}})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if store.Int("limit.max") != 250 {
+		t.Fatalf("limit.max = %d, want 250", store.Int("limit.max"))
+	}
+	if e := res.Entries[1]; e.Key != "limit.max" || e.Value != "250" || e.DefaultValue != "100" || !e.Overridden {
+		t.Fatalf("entry = %+v", e)
+	}
+
+	// One bad entry rejects the whole request.
+	_, err = h.UpdateRuntimeConfig(ctx, &pb.UpdateRuntimeConfigReq{Values: map[string]string{"feature.on": "true", "nope": "1"}})
+	wantAppError(t, err, 400, 40007)
+	_, err = h.UpdateRuntimeConfig(ctx, &pb.UpdateRuntimeConfigReq{Values: map[string]string{"feature.on": "true", "limit.max": "5000"}})
+	wantAppError(t, err, 400, 40008)
+	if store.Bool("feature.on") {
+		t.Fatal("a rejected request changed feature.on")
+	}
+	_, err = h.UpdateRuntimeConfig(ctx, &pb.UpdateRuntimeConfigReq{})
+	wantAppError(t, err, 400, 40008)
+
+	if _, err := h.UpdateRuntimeConfig(ctx, &pb.UpdateRuntimeConfigReq{Reset_: []string{"limit.max"}}); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	if store.Int("limit.max") != 100 {
+		t.Fatalf("limit.max = %d after reset, want the default", store.Int("limit.max"))
+	}
+
+	impersonated := middleware.WithAuthContext(ctx, &middleware.AuthContext{UserID: "u", IsImpersonated: true})
+	_, err = h.UpdateRuntimeConfig(impersonated, &pb.UpdateRuntimeConfigReq{Values: map[string]string{"limit.max": "5"}})
+	wantAppError(t, err, 403, 403)
+}
+
+func TestUpdateRuntimeConfig_WithoutRedis(t *testing.T) {
+	h, _ := newRuntimeConfigHandler(t, false)
+
+	_, err := h.UpdateRuntimeConfig(context.Background(), &pb.UpdateRuntimeConfigReq{Values: map[string]string{"limit.max": "5"}})
+	wantAppError(t, err, 503, 50301)
+
+	res, err := h.GetRuntimeConfig(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if e := res.Entries[1]; e.Value != "100" || e.Overridden {
+		t.Fatalf("entry = %+v, want the default", e)
+	}
+}
+
+func TestRuntimeConfigRoutes_RequirePermission(t *testing.T) {
+	h, _ := newRuntimeConfigHandler(t, true)
+	app := fiber.New()
+	pb.RegisterUserApiRoutes(app, h, func(token string) (*middleware.AuthContext, error) {
+		return &middleware.AuthContext{UserID: "u", Roles: []string{token}}, nil
+	})
+
+	do := func(method, role, body string) (int, map[string]any) {
+		t.Helper()
+		req := httptest.NewRequest(method, "/api/v1/admin/runtime-config", strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.Header.Set("Authorization", "Bearer "+role)
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatalf("app.Test: %v", err)
+		}
+		defer resp.Body.Close()
+		var out map[string]any
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		return resp.StatusCode, out
+	}
+
+	if code, _ := do("GET", "admin", ""); code != 403 {
+		t.Fatalf("admin GET: want 403, got %d", code)
+	}
+	if code, _ := do("PUT", "admin", `{"values":{"limit.max":"5"}}`); code != 403 {
+		t.Fatalf("admin PUT: want 403, got %d", code)
+	}
+	code, out := do("PUT", "superadmin", `{"values":{"limit.max":"5"},"reset":["feature.on"]}`)
+	if code != 200 {
+		t.Fatalf("superadmin PUT: want 200, got %d (%v)", code, out)
+	}
+	data, _ := out["data"].(map[string]any)
+	entries, _ := data["entries"].([]any)
+	if len(entries) != 2 {
+		t.Fatalf("entries = %v", data)
+	}
+	if e, _ := entries[1].(map[string]any); e["value"] != "5" || e["defaultValue"] != "100" {
+		t.Fatalf("limit.max entry = %v", e)
+	}
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"veemon/pkg/token"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenKeysHandler_PublishesKeys(t *testing.T) {
+	ts, err := token.NewPublicTokenService([]token.SigningKey{token.NewSigningKey(time.Now().Add(-time.Minute))}, 24, 0)
+	require.NoError(t, err)
+	app := fiber.New()
+	app.Get(token.KeysPath, TokenKeysHandler(ts))
+
+	get := func(ifNoneMatch string) *http.Response {
+		req := httptest.NewRequest(http.MethodGet, token.KeysPath, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set(fiber.HeaderIfNoneMatch, ifNoneMatch)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := get("")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "public, max-age=300", resp.Header.Get(fiber.HeaderCacheControl))
+	etag := resp.Header.Get(fiber.HeaderETag)
+	require.NotEmpty(t, etag)
+	raw, _ := io.ReadAll(resp.Body)
+	var doc token.KeyDocument
+	require.NoError(t, json.Unmarshal(raw, &doc))
+	want, _ := ts.PublicKeys()
+	assert.Equal(t, want.Keys[0].KeyID, doc.Keys[0].KeyID)
+
+	assert.Equal(t, http.StatusNotModified, get(etag).StatusCode)
+
+	// Rotation changes the document and so the ETag.
+	require.NoError(t, ts.Rotate(token.NewSigningKey(time.Now())))
+	resp = get(etag)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NotEqual(t, etag, resp.Header.Get(fiber.HeaderETag))
+	raw, _ = io.ReadAll(resp.Body)
+	require.NoError(t, json.Unmarshal(raw, &doc))
+	require.Len(t, doc.Keys, 2)
+	assert.Equal(t, "previous", doc.Keys[1].Status)
+}
+
+func TestTokenKeysHandler_NotFoundInLocalMode(t *testing.T) {
+	ts, err := token.NewTokenService(strings.Repeat("s", 32), 24)
+	require.NoError(t, err)
+	app := fiber.New()
+	app.Get(token.KeysPath, TokenKeysHandler(ts))
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, token.KeysPath, nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
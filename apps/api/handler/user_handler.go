@@ -3,18 +3,32 @@ package handler
 
 import (
 	"context"
+	stderrors "errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
 	"time"
 
+	"veemon/app/usecase/audit"
 	"veemon/app/usecase/user"
+	"veemon/entity"
 	pb "veemon/handler/grpc/user"
 	"veemon/pkg/authguard"
 	"veemon/pkg/errors"
+	"veemon/pkg/mailer"
 	"veemon/pkg/metrics"
 	"veemon/pkg/middleware"
+	"veemon/pkg/response"
+	"veemon/pkg/runtimeconfig"
 	"veemon/pkg/token"
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
@@ -24,18 +38,38 @@ type userHandler struct {
 	tokenService *token.TokenService
 	guard        *authguard.Guard
 	logger       *zap.Logger
+	// notifier, if set, emails users when someone starts impersonating them.
+	notifier      mailer.Sender
+	runtimeConfig *runtimeconfig.Store
+	auditUC       audit.UseCase
 }
 
-func NewUserHandler(userUC user.UseCase, tokenService *token.TokenService, guard *authguard.Guard, logger *zap.Logger) pb.UserApiServer {
+// Option configures the user handler.
+type Option func(*userHandler)
+
+// WithImpersonationNotifier emails the affected user through s whenever an
+// impersonation token is issued for them.
+func WithImpersonationNotifier(s mailer.Sender) Option {
+	return func(h *userHandler) {
+		h.notifier = s
+	}
+}
+
+func NewUserHandler(userUC user.UseCase, tokenService *token.TokenService, guard *authguard.Guard, logger *zap.Logger, opts ...Option) pb.UserApiServer {
 	if logger == nil {
 		logger = zap.NewNop()
 	}
-	return &userHandler{
+	h := &userHandler{
 		userUC:       userUC,
 		tokenService: tokenService,
 		guard:        guard,
 		logger:       logger,
 	}
+	h.runtimeConfig = runtimeconfig.New(nil, nil, logger)
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 // internal logs the underlying cause of a 5xx (which is never sent to clients)
@@ -65,6 +99,13 @@ func (h *userHandler) Register(ctx context.Context, req *pb.RegisterReq) (*pb.Re
 		if err == user.ErrEmailExists {
 			return nil, errors.Conflict(40901, "email already registered")
 		}
+		if err == user.ErrEmailDeleted {
+			return nil, errors.Conflict(40902, "this email belongs to a deleted account; please contact support")
+		}
+		var limitErr *entity.CompanyUserLimitError
+		if stderrors.As(err, &limitErr) {
+			return nil, errors.New(http.StatusConflict, codes.FailedPrecondition, 40904, "user limit reached for company")
+		}
 		return nil, h.internal(50001, "failed to register user", err)
 	}
 
@@ -85,8 +126,10 @@ func (h *userHandler) Login(ctx context.Context, req *pb.LoginReq) (*pb.LoginRes
 		return nil, err
 	}
 
-	// Reject early if the account is locked out from repeated failures.
-	if h.guard.IsLocked(ctx, req.Email) {
+	// Reject early if the account or the caller's IP is locked out from
+	// repeated failures.
+	ip := clientIP(ctx)
+	if h.guard.IsLocked(ctx, req.Email, ip) {
 		return nil, errors.TooManyRequests("too many failed login attempts; try again later")
 	}
 
@@ -94,8 +137,11 @@ func (h *userHandler) Login(ctx context.Context, req *pb.LoginReq) (*pb.LoginRes
 	if err != nil {
 		switch {
 		case err == user.ErrInvalidCreds:
-			h.guard.RecordFailure(ctx, req.Email)
+			h.guard.RecordFailure(ctx, req.Email, ip)
 			return nil, errors.Unauthorized("invalid email or password")
+		case err == user.ErrDeletionPending:
+			return nil, errors.New(http.StatusForbidden, codes.PermissionDenied, 40301,
+				"account deletion is pending; confirm your credentials at POST /api/v1/auth/reactivate to cancel it")
 		case err == user.ErrUserNotActive:
 			return nil, errors.Forbidden("account is not active")
 		default:
@@ -103,8 +149,14 @@ func (h *userHandler) Login(ctx context.Context, req *pb.LoginReq) (*pb.LoginRes
 		}
 	}
 
+	return h.completeLogin(ctx, req.Email, userEntity)
+}
+
+// completeLogin clears the lockout state for email and issues a token for
+// the authenticated userEntity.
+func (h *userHandler) completeLogin(ctx context.Context, email string, userEntity *entity.User) (*pb.LoginRes, error) {
 	// Successful login clears any accumulated failure/lock state.
-	h.guard.Reset(ctx, req.Email)
+	h.guard.Reset(ctx, email)
 
 	if m := metrics.Get(); m != nil {
 		m.RecordUserLogin()
@@ -144,6 +196,11 @@ func (h *userHandler) RefreshToken(ctx context.Context, req *pb.RefreshTokenReq)
 	if authCtx == nil {
 		return nil, errors.Unauthorized("authentication required")
 	}
+	// Impersonation is time-boxed; a fresh session needs a new, audited
+	// impersonation request.
+	if authCtx.IsImpersonated {
+		return nil, errors.Forbidden("impersonation tokens cannot be refreshed")
+	}
 
 	// Reload the user so a deactivated or deleted account cannot keep
 	// refreshing, and so fresh roles are embedded in the new token.
@@ -178,7 +235,8 @@ func (h *userHandler) RefreshToken(ctx context.Context, req *pb.RefreshTokenReq)
 	}, nil
 }
 
-// GetMe returns the profile of the currently authenticated user.
+// GetMe returns the profile of the currently authenticated user, with its
+// ETag so that polling clients can send If-None-Match and get a 304.
 func (h *userHandler) GetMe(ctx context.Context, req *emptypb.Empty) (*pb.UserProfile, error) {
 	authCtx := getAuthFromContext(ctx)
 	if authCtx == nil {
@@ -193,6 +251,7 @@ func (h *userHandler) GetMe(ctx context.Context, req *emptypb.Empty) (*pb.UserPr
 		return nil, h.internal(50004, "failed to get profile", err)
 	}
 
+	setUserETag(ctx, profile)
 	return &pb.UserProfile{
 		Id:        profile.ID,
 		Email:     profile.Email,
@@ -221,44 +280,201 @@ func (h *userHandler) Logout(ctx context.Context, req *emptypb.Empty) (*pb.Logou
 	}, nil
 }
 
+// DeleteMe schedules deletion of the caller's own account. The account is
+// deactivated and every token issued to it revoked immediately; it is purged
+// once the grace period elapses unless ReactivateAccount cancels it first.
+func (h *userHandler) DeleteMe(ctx context.Context, req *emptypb.Empty) (*pb.DeleteMeRes, error) {
+	authCtx := getAuthFromContext(ctx)
+	if authCtx == nil {
+		return nil, errors.Unauthorized("authentication required")
+	}
+	if err := forbidImpersonation(ctx); err != nil {
+		return nil, err
+	}
+
+	purgeAt, err := h.userUC.RequestDeletion(ctx, authCtx.UserID)
+	if err != nil {
+		switch err {
+		case user.ErrNotFound:
+			return nil, errors.NotFound("user not found")
+		case user.ErrUserNotActive:
+			return nil, errors.Forbidden("account is not active")
+		}
+		return nil, h.internal(50012, "failed to schedule account deletion", err)
+	}
+
+	// The account is already inactive, so a failed revocation only leaves
+	// tokens that the next refresh would reject anyway.
+	if err := h.guard.RevokeUser(ctx, authCtx.UserID, h.tokenService.Lifetime()); err != nil {
+		h.logger.Warn("failed to revoke sessions of deleted account",
+			zap.String("user_id", authCtx.UserID), zap.Error(err))
+	}
+
+	return &pb.DeleteMeRes{
+		Message:             "account scheduled for deletion; sign in via POST /api/v1/auth/reactivate before then to cancel",
+		DeletionScheduledAt: purgeAt.UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// ReactivateAccount cancels a pending self-service deletion after checking
+// the account's credentials, then signs the user in as Login does.
+func (h *userHandler) ReactivateAccount(ctx context.Context, req *pb.ReactivateAccountReq) (*pb.LoginRes, error) {
+	if err := pb.ValidateRequest(req); err != nil {
+		return nil, err
+	}
+	ip := clientIP(ctx)
+	if h.guard.IsLocked(ctx, req.Email, ip) {
+		return nil, errors.TooManyRequests("too many failed login attempts; try again later")
+	}
+
+	userEntity, err := h.userUC.CancelDeletion(ctx, req.Email, req.Password)
+	if err != nil {
+		switch {
+		case err == user.ErrInvalidCreds:
+			h.guard.RecordFailure(ctx, req.Email, ip)
+			return nil, errors.Unauthorized("invalid email or password")
+		case err == user.ErrNoDeletionPending:
+			return nil, errors.Conflict(40903, "account has no pending deletion to cancel")
+		default:
+			return nil, h.internal(50013, "failed to reactivate account", err)
+		}
+	}
+
+	return h.completeLogin(ctx, req.Email, userEntity)
+}
+
+// IntrospectBatch validates many tokens for a machine caller (the API
+// gateway) and reports each one's status in request order. A bad token only
+// marks its own result inactive; it never fails the batch.
+func (h *userHandler) IntrospectBatch(ctx context.Context, req *pb.IntrospectBatchReq) (*pb.IntrospectBatchRes, error) {
+	if err := pb.ValidateRequest(req); err != nil {
+		return nil, err
+	}
+
+	results, unique := introspectTokens(ctx, req.Tokens, h.tokenService.ValidateToken, h.guard)
+
+	if m := metrics.Get(); m != nil {
+		m.ObserveIntrospectBatch(len(req.Tokens), unique)
+	}
+
+	return &pb.IntrospectBatchRes{Results: results}, nil
+}
+
+// introspectTokens validates each distinct token once, checks token
+// revocation for all of them in one lookup (user-wide revocation is checked
+// per token), and fans the results back out to every position the token
+// appeared at. It returns the results and the distinct count.
+func introspectTokens(ctx context.Context, tokens []string, validate func(string) (*token.Claims, error), guard *authguard.Guard) ([]*pb.TokenIntrospection, int) {
+	index := make(map[string]int, len(tokens))
+	var distinct []*pb.TokenIntrospection
+	var claims []*token.Claims
+	for _, t := range tokens {
+		if _, seen := index[t]; seen {
+			continue
+		}
+		index[t] = len(distinct)
+
+		c, err := validate(t)
+		switch {
+		case err == nil:
+			distinct = append(distinct, &pb.TokenIntrospection{Active: true})
+		case err == token.ErrExpiredToken:
+			distinct = append(distinct, &pb.TokenIntrospection{Reason: "expired"})
+		default:
+			distinct = append(distinct, &pb.TokenIntrospection{Reason: "invalid"})
+		}
+		claims = append(claims, c)
+	}
+
+	jtis := make([]string, len(claims))
+	for i, c := range claims {
+		if c != nil {
+			jtis[i] = c.TokenID
+		}
+	}
+	revoked := guard.RevokedMany(ctx, jtis)
+
+	for i, r := range distinct {
+		switch {
+		case !r.Active:
+		case revoked[i] || guard.IsUserRevoked(ctx, claims[i].UserID, claims[i].IssuedAt):
+			r.Active, r.Reason = false, "revoked"
+		default:
+			c := claims[i]
+			r.Claims = &pb.TokenClaims{
+				UserId:        c.UserID,
+				Email:         c.Email,
+				Roles:         c.Roles,
+				CompanyCode:   c.CompanyCode,
+				ExpiresAt:     c.ExpiresAt.UTC().Format(time.RFC3339),
+				ActorId:       c.ActorID,
+				Impersonation: c.Impersonation,
+			}
+		}
+	}
+
+	results := make([]*pb.TokenIntrospection, len(tokens))
+	for i, t := range tokens {
+		results[i] = distinct[index[t]]
+	}
+	return results, len(distinct)
+}
+
 // ListUsers returns a paginated list of all users (admin only).
 func (h *userHandler) ListUsers(ctx context.Context, req *pb.ListUsersReq) (*pb.ListUsersRes, error) {
 	if err := pb.ValidateRequest(req); err != nil {
 		return nil, err
 	}
+	want, columns, err := parseUserFields(req.Fields)
+	if err != nil {
+		return nil, err
+	}
 
-	users, total, err := h.userUC.ListAll(ctx, user.ListInput{
-		Page:      int(req.Page),
-		Size:      int(req.Size),
-		Search:    req.Search,
-		SortBy:    req.SortBy,
-		SortOrder: req.SortOrder,
-	})
+	input := user.ListInput{
+		Page:           int(req.Page),
+		Size:           int(req.Size),
+		Search:         req.Search,
+		SortBy:         req.SortBy,
+		SortOrder:      req.SortOrder,
+		Columns:        columns,
+		Cursor:         req.Cursor,
+		IncludeDeleted: req.IncludeDeleted,
+	}
+	out, err := h.userUC.ListAll(ctx, input)
 	if err != nil {
+		if e, ok := err.(*user.OffsetLimitError); ok {
+			return nil, errors.BadRequest(40004, fmt.Sprintf("page is too deep: offset pagination is limited to the first %d rows; "+
+				"use cursor pagination instead by passing nextCursor from the previous response's pagination metadata as the cursor parameter", e.Max))
+		}
+		if err == user.ErrInvalidCursor {
+			return nil, errors.BadRequest(40005, "invalid cursor: pass nextCursor from a previous response unchanged, with sortBy created_at")
+		}
+		if err == user.ErrInvalidSortField {
+			return nil, invalidSort()
+		}
 		return nil, h.internal(50005, "failed to list users", err)
 	}
 
-	pbUsers := make([]*pb.UserProfile, len(users))
-	for i, u := range users {
-		pbUsers[i] = &pb.UserProfile{
-			Id:        u.ID,
-			Email:     u.Email,
-			Name:      u.Name,
-			Phone:     u.Phone,
-			Status:    string(u.Status),
-			CreatedAt: u.CreatedAt.Format(time.RFC3339),
-		}
+	pbUsers := make([]*pb.UserProfile, len(out.Users))
+	for i := range out.Users {
+		pbUsers[i] = toSummaryProfile(&out.Users[i], want)
 	}
 
-	totalPages := (total + int64(req.Size) - 1) / int64(req.Size)
+	// Echo the parameters actually applied, not the request's, so a client
+	// sees any defaulting or correction.
+	eff := out.Params
+	totalPages := (out.Total + int64(eff.Size) - 1) / int64(eff.Size)
 
 	return &pb.ListUsersRes{
 		Users: pbUsers,
 		Pagination: &pb.Pagination{
-			Page:       req.Page,
-			Size:       req.Size,
-			Total:      total,
+			Page:       int32(eff.Page), // #nosec G115 -- page came from the int32 request field
+			Size:       int32(eff.Size), // #nosec G115 -- size is capped at user.MaxPageSize
+			Total:      out.Total,
 			TotalPages: int32(totalPages), // #nosec G115 -- totalPages is bounded by pagination
+			NextCursor: out.NextCursor,
+			SortBy:     eff.SortBy,
+			SortOrder:  eff.SortOrder,
 		},
 	}, nil
 }
@@ -268,8 +484,16 @@ func (h *userHandler) GetUser(ctx context.Context, req *pb.GetUserReq) (*pb.User
 	if err := validateUserID(req.Id); err != nil {
 		return nil, err
 	}
+	want, columns, err := parseUserFields(req.Fields)
+	if err != nil {
+		return nil, err
+	}
+	if columns != nil && !want["updatedAt"] {
+		// The ETag needs updated_at even when the client did not ask for it.
+		columns = append(columns, "updated_at")
+	}
 
-	userEntity, err := h.userUC.GetUser(ctx, req.Id)
+	userEntity, err := h.userUC.GetUser(ctx, req.Id, columns...)
 	if err != nil {
 		if err == user.ErrNotFound {
 			return nil, errors.NotFound("user not found")
@@ -277,14 +501,72 @@ func (h *userHandler) GetUser(ctx context.Context, req *pb.GetUserReq) (*pb.User
 		return nil, h.internal(50006, "failed to get user", err)
 	}
 
-	return &pb.UserProfile{
-		Id:        userEntity.ID,
-		Email:     userEntity.Email,
-		Name:      userEntity.Name,
-		Phone:     userEntity.Phone,
-		Status:    string(userEntity.Status),
-		CreatedAt: userEntity.CreatedAt.Format(time.RFC3339),
-	}, nil
+	setUserETag(ctx, userEntity)
+	return toUserProfile(userEntity, want), nil
+}
+
+// CreateUser creates an account on an administrator's behalf (admin only).
+// Callers cannot grant roles whose permissions they do not hold themselves.
+// Every creation is written to the audit log.
+func (h *userHandler) CreateUser(ctx context.Context, req *pb.CreateUserReq) (*pb.UserProfile, error) {
+	authCtx := getAuthFromContext(ctx)
+	if authCtx == nil {
+		return nil, errors.Unauthorized("authentication required")
+	}
+	if err := forbidImpersonation(ctx); err != nil {
+		return nil, err
+	}
+	if err := pb.ValidateRequest(req); err != nil {
+		return nil, err
+	}
+	roles := entity.DefaultRoles
+	if len(req.Roles) > 0 {
+		var err error
+		if roles, err = entity.ParseRoles(req.Roles); err != nil {
+			return nil, errors.ValidationError(err.Error()).WithFields(errors.FieldViolation{Field: "roles", Description: err.Error()})
+		}
+	}
+	if !authCtx.CanAssignRoles(roles) {
+		return nil, errors.New(http.StatusForbidden, codes.PermissionDenied, 40302,
+			"roles grant permissions the caller does not have")
+	}
+
+	userEntity, err := h.userUC.CreateUser(ctx, user.CreateUserInput{
+		Email:       req.Email,
+		Password:    req.Password,
+		Name:        req.Name,
+		Phone:       req.Phone,
+		CompanyCode: req.CompanyCode,
+		Roles:       roles,
+		Status:      req.Status,
+		Invite:      req.SendInvite,
+	})
+	if err != nil {
+		var limitErr *entity.CompanyUserLimitError
+		switch {
+		case err == user.ErrEmailExists:
+			return nil, errors.Conflict(40901, "email already registered")
+		case stderrors.As(err, &limitErr):
+			return nil, errors.New(http.StatusConflict, codes.FailedPrecondition, 40904, "user limit reached for company")
+		case err == user.ErrInvalidStatus:
+			return nil, errors.ValidationError("status must be one of active, inactive, pending")
+		case err == user.ErrInvitesUnavailable:
+			return nil, errors.New(http.StatusServiceUnavailable, codes.Unavailable, 50303,
+				"user invitations are temporarily unavailable")
+		}
+		return nil, h.internal(50017, "failed to create user", err)
+	}
+
+	h.logger.Info("audit: user created",
+		zap.String("audit_event", "user.create"),
+		zap.String("actor_id", authCtx.UserID),
+		zap.String("actor_email", authCtx.Email),
+		zap.String("target_id", userEntity.ID),
+		zap.Strings("roles", userEntity.Roles),
+		zap.Bool("invited", req.SendInvite),
+	)
+	setUserETag(ctx, userEntity)
+	return toUserProfile(userEntity, nil), nil
 }
 
 // UpdateUser updates a user by ID (admin only).
@@ -295,34 +577,55 @@ func (h *userHandler) UpdateUser(ctx context.Context, req *pb.UpdateUserReq) (*p
 	if err := pb.ValidateRequest(req); err != nil {
 		return nil, err
 	}
+	expected, err := expectedVersion(ctx, req)
+	if err != nil {
+		return nil, err
+	}
 
 	userEntity, err := h.userUC.UpdateUser(ctx, req.Id, user.UpdateInput{
-		Name:   req.Name,
-		Phone:  req.Phone,
-		Status: req.Status,
+		Name:              req.Name,
+		Phone:             req.Phone,
+		Status:            req.Status,
+		ExpectedUpdatedAt: expected,
 	})
 	if err != nil {
-		if err == user.ErrNotFound {
+		switch err {
+		case user.ErrNotFound:
 			return nil, errors.NotFound("user not found")
+		case user.ErrVersionMismatch:
+			return nil, errUserModified()
+		case user.ErrVersionRequired:
+			return nil, errors.New(http.StatusPreconditionRequired, codes.FailedPrecondition, 42801,
+				"send If-Match with the ETag from GET /api/v1/users/{id} (expectedUpdatedAt over gRPC)")
+		case user.ErrInvalidStatus:
+			return nil, errors.ValidationError("status must be one of active, inactive, pending")
+		case user.ErrNameRequired:
+			return nil, errors.ValidationError("name cannot be empty")
 		}
 		return nil, h.internal(50007, "failed to update user", err)
 	}
 
-	return &pb.UserProfile{
-		Id:        userEntity.ID,
-		Email:     userEntity.Email,
-		Name:      userEntity.Name,
-		Phone:     userEntity.Phone,
-		Status:    string(userEntity.Status),
-		CreatedAt: userEntity.CreatedAt.Format(time.RFC3339),
-	}, nil
+	setUserETag(ctx, userEntity)
+	return toUserProfile(userEntity, nil), nil
 }
 
-// DeleteUser soft-deletes a user by ID (admin only).
+// PurgePermission is required to permanently delete a user. The default
+// policy grants it to superadmin only.
+const PurgePermission = "users.purge"
+
+// DeleteUser soft-deletes a user by ID (admin only). With permanent set it
+// hard-deletes the row instead, soft-deleted or not; that needs
+// PurgePermission and is written to the audit log.
 func (h *userHandler) DeleteUser(ctx context.Context, req *pb.DeleteUserReq) (*pb.DeleteUserRes, error) {
+	if err := forbidImpersonation(ctx); err != nil {
+		return nil, err
+	}
 	if err := validateUserID(req.Id); err != nil {
 		return nil, err
 	}
+	if req.Permanent {
+		return h.purgeUser(ctx, req.Id)
+	}
 
 	err := h.userUC.DeleteUser(ctx, req.Id)
 	if err != nil {
@@ -337,11 +640,332 @@ func (h *userHandler) DeleteUser(ctx context.Context, req *pb.DeleteUserReq) (*p
 	}, nil
 }
 
+func (h *userHandler) purgeUser(ctx context.Context, id string) (*pb.DeleteUserRes, error) {
+	authCtx := getAuthFromContext(ctx)
+	if authCtx == nil {
+		return nil, errors.Unauthorized("authentication required")
+	}
+	if !authCtx.HasPermission(PurgePermission) {
+		return nil, errors.Forbidden("insufficient permissions")
+	}
+
+	if err := h.userUC.PurgeUser(ctx, id); err != nil {
+		if err == user.ErrNotFound {
+			return nil, errors.NotFound("user not found")
+		}
+		return nil, h.internal(50018, "failed to purge user", err)
+	}
+
+	h.logger.Info("audit: user purged",
+		zap.String("audit_event", "user.purge"),
+		zap.String("actor_id", authCtx.UserID),
+		zap.String("actor_email", authCtx.Email),
+		zap.String("target_id", id),
+	)
+	return &pb.DeleteUserRes{
+		Message: "user permanently deleted",
+	}, nil
+}
+
+// RestoreUser undoes DeleteUser's soft delete (admin only). It is refused
+// when the email has since been registered to another account.
+func (h *userHandler) RestoreUser(ctx context.Context, req *pb.RestoreUserReq) (*pb.UserProfile, error) {
+	if err := forbidImpersonation(ctx); err != nil {
+		return nil, err
+	}
+	if err := validateUserID(req.Id); err != nil {
+		return nil, err
+	}
+
+	userEntity, err := h.userUC.RestoreUser(ctx, req.Id)
+	if err != nil {
+		var limitErr *entity.CompanyUserLimitError
+		switch {
+		case err == user.ErrNotFound:
+			return nil, errors.NotFound("user not found")
+		case err == user.ErrNotDeleted:
+			return nil, errors.Conflict(40906, "user is not deleted")
+		case err == user.ErrEmailExists:
+			return nil, errors.Conflict(40901, "email already registered")
+		case stderrors.As(err, &limitErr):
+			return nil, errors.New(http.StatusConflict, codes.FailedPrecondition, 40904, "user limit reached for company")
+		}
+		return nil, h.internal(50019, "failed to restore user", err)
+	}
+
+	setUserETag(ctx, userEntity)
+	return toUserProfile(userEntity, nil), nil
+}
+
+// ImpersonateUser issues a superadmin a short-lived, non-refreshable token
+// that acts as another user, so support can see exactly what they see. Every
+// issuance is written to the audit log.
+func (h *userHandler) ImpersonateUser(ctx context.Context, req *pb.ImpersonateUserReq) (*pb.ImpersonateUserRes, error) {
+	authCtx := getAuthFromContext(ctx)
+	if authCtx == nil {
+		return nil, errors.Unauthorized("authentication required")
+	}
+	// An impersonated superadmin must not be able to start a new session as
+	// someone else.
+	if err := forbidImpersonation(ctx); err != nil {
+		return nil, err
+	}
+	if err := validateUserID(req.Id); err != nil {
+		return nil, err
+	}
+	if err := pb.ValidateRequest(req); err != nil {
+		return nil, err
+	}
+
+	target, err := h.userUC.GetProfile(ctx, req.Id)
+	if err != nil {
+		if err == user.ErrNotFound {
+			return nil, errors.NotFound("user not found")
+		}
+		return nil, h.internal(50011, "failed to impersonate user", err)
+	}
+
+	tok, expiresAt, err := h.tokenService.GenerateImpersonationToken(
+		target.ID,
+		target.Email,
+		target.Roles,
+		target.CompanyCode,
+		authCtx.UserID,
+		time.Duration(req.TtlSeconds)*time.Second,
+	)
+	if err != nil {
+		return nil, h.internal(50011, "failed to impersonate user", err)
+	}
+	// The token is only handed out once its issuance is on record.
+	if err := h.userUC.RecordImpersonation(ctx, target.ID, expiresAt, req.Reason); err != nil {
+		return nil, h.internal(50011, "failed to impersonate user", err)
+	}
+
+	h.logger.Info("audit: impersonation token issued",
+		zap.String("audit_event", "user.impersonate"),
+		zap.String("actor_id", authCtx.UserID),
+		zap.String("actor_email", authCtx.Email),
+		zap.String("target_id", target.ID),
+		zap.String("reason", req.Reason),
+		zap.Time("expires_at", expiresAt),
+	)
+	h.notifyImpersonation(ctx, target, expiresAt)
+
+	return &pb.ImpersonateUserRes{
+		Token:     tok,
+		ExpiresAt: expiresAt.UTC().Format(time.RFC3339),
+		User:      toUserProfile(target, nil),
+	}, nil
+}
+
+// notifyImpersonation tells u that support is acting as them. Delivery is
+// best-effort: the token was already issued and audited.
+func (h *userHandler) notifyImpersonation(ctx context.Context, u *entity.User, expiresAt time.Time) {
+	if h.notifier == nil {
+		return
+	}
+	err := h.notifier.Send(ctx, mailer.Message{
+		To:           u.Email,
+		Subject:      "Support accessed your account",
+		TemplateName: "impersonation",
+		Data: map[string]any{
+			"Name":      u.Name,
+			"ExpiresAt": expiresAt.UTC().Format(time.RFC1123),
+		},
+	})
+	if err != nil {
+		h.logger.Warn("failed to send impersonation notice", zap.String("target_id", u.ID), zap.Error(err))
+	}
+}
+
+// invalidSort is the 40011 error for a sort ListAll refuses, listing the
+// columns it accepts.
+func invalidSort() *errors.AppError {
+	return errors.BadRequest(40011, "invalid sort: sortBy must be one of "+strings.Join(user.SortColumns(), ", ")+
+		" and sortOrder asc or desc")
+}
+
+// forbidImpersonation rejects requests made with an impersonation token.
+// Endpoints that change credentials or delete accounts call it so support
+// staff acting as a user cannot take those actions on their behalf.
+func forbidImpersonation(ctx context.Context) error {
+	if a := getAuthFromContext(ctx); a != nil && a.IsImpersonated {
+		return errors.Forbidden("not allowed while impersonating a user")
+	}
+	return nil
+}
+
 func getAuthFromContext(ctx context.Context) *middleware.AuthContext {
 	a, _ := middleware.AuthFromContext(ctx)
 	return a
 }
 
+// clientIP returns the caller's IP address, or "" if the transport did not
+// record one. The REST gateway records the address Fiber resolved.
+func clientIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	switch a := p.Addr.(type) {
+	case *net.TCPAddr:
+		return a.IP.String()
+	case *net.IPAddr:
+		if a.IP == nil {
+			return ""
+		}
+		return a.IP.String()
+	}
+	if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+		return host
+	}
+	return ""
+}
+
+// userProfileFields lists the UserProfile json names a client may request
+// with ?fields=, in response order, with the column backing each.
+var userProfileFields = []struct{ name, column string }{
+	{"id", "id"},
+	{"email", "email"},
+	{"name", "name"},
+	{"phone", "phone"},
+	{"status", "status"},
+	{"createdAt", "created_at"},
+	{"updatedAt", "updated_at"},
+	{"deletedAt", "deleted_at"},
+}
+
+// parseUserFields validates a sparse fieldset against userProfileFields and
+// returns the requested names plus the columns to select. An empty fieldset
+// returns nil for both, meaning everything.
+func parseUserFields(fields string) (map[string]bool, []string, error) {
+	names := response.ParseFields(fields)
+	if len(names) == 0 {
+		return nil, nil, nil
+	}
+
+	columnOf := make(map[string]string, len(userProfileFields))
+	allowed := make([]string, len(userProfileFields))
+	for i, f := range userProfileFields {
+		columnOf[f.name] = f.column
+		allowed[i] = f.name
+	}
+
+	want := make(map[string]bool, len(names))
+	columns := make([]string, 0, len(names))
+	for _, n := range names {
+		column, ok := columnOf[n]
+		if !ok {
+			return nil, nil, errors.BadRequest(40003, fmt.Sprintf("unknown field %q in fields; allowed: %s", n, strings.Join(allowed, ", ")))
+		}
+		want[n] = true
+		columns = append(columns, column)
+	}
+	return want, columns, nil
+}
+
+// toUserProfile maps u to its wire form, filling only the fields in want (all
+// of them when want is nil) so unselected columns are not sent as zero values.
+func toUserProfile(u *entity.User, want map[string]bool) *pb.UserProfile {
+	s := u.Summary()
+	return toSummaryProfile(&s, want)
+}
+
+// toSummaryProfile is toUserProfile for a listed user.
+func toSummaryProfile(u *entity.UserSummary, want map[string]bool) *pb.UserProfile {
+	has := func(name string) bool { return want == nil || want[name] }
+	p := &pb.UserProfile{}
+	if has("id") {
+		p.Id = u.ID
+	}
+	if has("email") {
+		p.Email = u.Email
+	}
+	if has("name") {
+		p.Name = u.Name
+	}
+	if has("phone") {
+		p.Phone = u.Phone
+	}
+	if has("status") {
+		p.Status = string(u.Status)
+	}
+	if has("createdAt") {
+		p.CreatedAt = u.CreatedAt.Format(time.RFC3339)
+	}
+	if has("updatedAt") {
+		p.UpdatedAt = formatVersion(u.UpdatedAt)
+	}
+	if has("deletedAt") && u.DeletedAt.Valid {
+		p.DeletedAt = u.DeletedAt.Time.Format(time.RFC3339)
+	}
+	return p
+}
+
+// formatVersion renders an UpdatedAt at full precision, so it round-trips
+// as an expected version.
+func formatVersion(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// userETag is the weak entity tag of u's current version. UpdatedAt is a
+// weak validator: it tells versions apart but says nothing about the bytes
+// of any one representation.
+func userETag(u *entity.User) string {
+	return `W/"` + formatVersion(u.UpdatedAt) + `"`
+}
+
+// setUserETag sends u's ETag as response header metadata, which the REST
+// routes write as the ETag header.
+func setUserETag(ctx context.Context, u *entity.User) {
+	// SetHeader only fails outside a server call, where there is no
+	// response to carry the tag.
+	_ = grpc.SetHeader(ctx, metadata.Pairs("etag", userETag(u)))
+}
+
+// expectedVersion returns the version an update is conditional on: the
+// expectedUpdatedAt field, else the If-Match header (the "if-match" metadata
+// over gRPC). nil means unconditional, which is also what If-Match: * asks
+// for since the user exists or the update fails with 404 anyway.
+func expectedVersion(ctx context.Context, req *pb.UpdateUserReq) (*time.Time, error) {
+	if req.ExpectedUpdatedAt != "" {
+		t, err := time.Parse(time.RFC3339Nano, req.ExpectedUpdatedAt)
+		if err != nil {
+			return nil, errors.BadRequest(40006, "expectedUpdatedAt must be an RFC 3339 timestamp")
+		}
+		return &t, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("if-match")
+	if len(values) == 0 {
+		return nil, nil
+	}
+	tag := strings.TrimSpace(strings.Join(values, ","))
+	if tag == "*" {
+		return nil, nil
+	}
+	if strings.Contains(tag, ",") {
+		return nil, errors.BadRequest(40006, "If-Match must carry a single ETag")
+	}
+	// W/ is optional: the comparison is weak either way.
+	quoted := strings.TrimPrefix(tag, "W/")
+	if len(quoted) < 2 || quoted[0] != '"' || quoted[len(quoted)-1] != '"' {
+		return nil, errors.BadRequest(40006, "If-Match must be a quoted ETag")
+	}
+	t, err := time.Parse(time.RFC3339Nano, quoted[1:len(quoted)-1])
+	if err != nil {
+		// A tag this API never issued matches no version of the user.
+		return nil, errUserModified()
+	}
+	return &t, nil
+}
+
+func errUserModified() error {
+	return errors.New(http.StatusPreconditionFailed, codes.FailedPrecondition, 41201,
+		"user was modified since the given version; fetch it again and retry with the new ETag")
+}
+
 // validateUserID rejects malformed identifiers before they reach the database,
 // where an invalid UUID would surface as a 500 instead of a 400.
 func validateUserID(id string) error {
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	user "veemon/app/usecase/user"
+	"veemon/entity"
+	pb "veemon/handler/grpc/user"
+	"veemon/pkg/errors"
+
+	"google.golang.org/grpc/peer"
+)
+
+func TestParseUserFields(t *testing.T) {
+	tests := []struct {
+		name        string
+		fields      string
+		wantColumns []string
+		wantErr     string
+	}{
+		{"empty means every field", "", nil, ""},
+		{"json names map to columns", "id, name,createdAt", []string{"id", "name", "created_at"}, ""},
+		{"duplicates collapse", "id,id", []string{"id"}, ""},
+		{"unknown field names it and lists allowed", "id,password", nil, `unknown field "password" in fields; allowed: id, email, name, phone, status, createdAt, updatedAt, deletedAt`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, columns, err := parseUserFields(tt.fields)
+			if tt.wantErr != "" {
+				appErr, ok := err.(*errors.AppError)
+				if !ok || appErr.HTTPStatus != 400 || appErr.Code != 40003 || appErr.Message != tt.wantErr {
+					t.Fatalf("want 400/40003 %q, got %#v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Join(columns, ",") != strings.Join(tt.wantColumns, ",") {
+				t.Fatalf("columns = %v, want %v", columns, tt.wantColumns)
+			}
+		})
+	}
+}
+
+func TestToUserProfile_FillsOnlyRequestedFields(t *testing.T) {
+	u := &entity.User{ID: "id-1", Email: "a@example.com", Name: "A", Phone: "0812", Status: entity.UserStatusActive}
+
+	p := toUserProfile(u, map[string]bool{"id": true, "name": true})
+	if p.Id != "id-1" || p.Name != "A" || p.Email != "" || p.Phone != "" || p.Status != "" || p.CreatedAt != "" {
+		t.Fatalf("unexpected projection: %+v", p)
+	}
+
+	if full := toUserProfile(u, nil); full.Email != u.Email || full.Status != "active" {
+		t.Fatalf("nil want must fill every field: %+v", full)
+	}
+}
+
+// registerFails is a user usecase whose Register always returns err.
+type registerFails struct {
+	user.UseCase
+	err error
+}
+
+func (u registerFails) Register(context.Context, user.RegisterInput) (*user.RegisterOutput, error) {
+	return nil, u.err
+}
+
+func TestRegister_CompanyUserLimitMapsToConflict(t *testing.T) {
+	h := NewUserHandler(registerFails{err: &entity.CompanyUserLimitError{CompanyCode: "C", Limit: 5}}, nil, nil, nil)
+
+	_, err := h.Register(context.Background(), &pb.RegisterReq{Email: "a@example.com", Password: "Password123", Name: "Ann"})
+
+	appErr, ok := err.(*errors.AppError)
+	if !ok || appErr.HTTPStatus != 409 || appErr.Code != 40904 {
+		t.Fatalf("want 409/40904, got %#v", err)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name string
+		addr net.Addr
+		want string
+	}{
+		{"grpc tcp peer", &net.TCPAddr{IP: net.ParseIP("203.0.113.7"), Port: 51234}, "203.0.113.7"},
+		{"rest gateway peer", &net.IPAddr{IP: net.ParseIP("2001:db8::1")}, "2001:db8::1"},
+		{"unparsable rest address", &net.IPAddr{}, ""},
+		{"other addr with a port", &net.UnixAddr{Name: "198.51.100.1:80", Net: "unix"}, "198.51.100.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: tt.addr})
+			if got := clientIP(ctx); got != tt.want {
+				t.Errorf("clientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if got := clientIP(context.Background()); got != "" {
+		t.Errorf("clientIP without a peer = %q, want empty", got)
+	}
+}
@@ -0,0 +1,356 @@
+package handler
+
+import (
+	"context"
+	"encoding/csv"
+	stderrors "errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"veemon/app/usecase/user"
+	"veemon/entity"
+	pb "veemon/handler/grpc/user"
+	"veemon/pkg/errors"
+	"veemon/pkg/middleware"
+	"veemon/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+)
+
+// UserImportPermission is required to import users, as to create one.
+const UserImportPermission = "users.write"
+
+// Defaults for UserImportConfig fields left at zero.
+const (
+	DefaultUserImportMaxRows   = 1000
+	DefaultUserImportBatchSize = 100
+)
+
+// UserImportConfig tunes UserImportHandler.
+type UserImportConfig struct {
+	// MaxRows caps the data rows of one file; a longer file is refused with
+	// 413 before any row is imported.
+	MaxRows int
+	// BatchSize is how many rows are created per transaction.
+	BatchSize int
+}
+
+// importColumns are the CSV columns an import understands, keyed by their
+// lower-cased header. Columns are matched case-insensitively.
+var importColumns = map[string]bool{
+	"email":       true,
+	"password":    true,
+	"name":        true,
+	"phone":       true,
+	"companycode": true,
+	"status":      true,
+	"roles":       true,
+	"sendinvite":  true,
+}
+
+// UserImportHandler serves POST /api/v1/users/import: a multipart upload
+// whose "file" part is a CSV of accounts to create, with a header row naming
+// its columns (email and name, optionally password, phone, companyCode,
+// status, roles separated by ";", and sendInvite). Each row is validated
+// like a CreateUser request, and the valid ones are created BatchSize at a
+// time, one transaction per batch. The response reports every row as
+// created, skipped_duplicate or error.
+//
+// The file is read twice, a row at a time: once to check its shape and count
+// its rows, then to import them, so no more than a batch is held in memory.
+// Fasthttp spills large uploads to a temporary file; HTTP_BODY_LIMIT_KB
+// still caps their size.
+func UserImportHandler(uc user.UseCase, cfg UserImportConfig, log *zap.Logger) fiber.Handler {
+	if cfg.MaxRows <= 0 {
+		cfg.MaxRows = DefaultUserImportMaxRows
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultUserImportBatchSize
+	}
+	if log == nil {
+		log = zap.NewNop()
+	}
+	h := &userImport{uc: uc, cfg: cfg, log: log}
+	return h.serve
+}
+
+type userImport struct {
+	uc  user.UseCase
+	cfg UserImportConfig
+	log *zap.Logger
+}
+
+// importReport is the response body of an import.
+type importReport struct {
+	Total   int               `json:"total"`
+	Created int               `json:"created"`
+	Skipped int               `json:"skipped"`
+	Failed  int               `json:"failed"`
+	Rows    []importRowReport `json:"rows"`
+}
+
+// importRowReport is one row's outcome; Row is its line in the file.
+type importRowReport struct {
+	Row     int    `json:"row"`
+	Email   string `json:"email,omitempty"`
+	Status  string `json:"status"`
+	ID      string `json:"id,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+func (h *userImport) serve(c *fiber.Ctx) error {
+	authCtx, ok := middleware.GetAuthContext(c)
+	if !ok {
+		return errors.Unauthorized("authentication required").FiberError(c)
+	}
+	if authCtx.IsImpersonated {
+		return errors.Forbidden("not allowed while impersonating a user").FiberError(c)
+	}
+	ctx := middleware.WithAuthContext(c.UserContext(), authCtx)
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return errors.BadRequest(40012, "send the CSV as the file part of a multipart/form-data body").FiberError(c)
+	}
+	f, err := fh.Open()
+	if err != nil {
+		h.log.Error("open user import upload", zap.Error(err))
+		return errors.Internal(50021, "failed to import users").FiberError(c)
+	}
+	defer f.Close() //nolint:errcheck // read-only
+
+	if err := h.scan(f); err != nil {
+		return err.FiberError(c)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		h.log.Error("rewind user import upload", zap.Error(err))
+		return errors.Internal(50021, "failed to import users").FiberError(c)
+	}
+	report := h.run(ctx, authCtx, f)
+
+	h.log.Info("audit: users imported",
+		zap.String("audit_event", "user.import"),
+		zap.String("actor_id", authCtx.UserID),
+		zap.String("actor_email", authCtx.Email),
+		zap.String("file", fh.Filename),
+		zap.Int("rows", report.Total),
+		zap.Int("created", report.Created),
+		zap.Int("skipped", report.Skipped),
+		zap.Int("failed", report.Failed),
+	)
+	return response.Success(c, report)
+}
+
+// scan checks the file's header and CSV syntax and that it has at most
+// MaxRows rows, without importing anything.
+func (h *userImport) scan(r io.Reader) *errors.AppError {
+	cr, _, err := newImportReader(r)
+	if err != nil {
+		return err
+	}
+	rows := 0
+	for {
+		_, readErr := cr.Read()
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return errors.BadRequest(40012, "invalid CSV: "+readErr.Error())
+		}
+		rows++
+		if rows > h.cfg.MaxRows {
+			return errors.New(fiber.StatusRequestEntityTooLarge, codes.ResourceExhausted, 41301,
+				"the file has more than "+strconv.Itoa(h.cfg.MaxRows)+" rows; split it into smaller files")
+		}
+	}
+	if rows == 0 {
+		return errors.BadRequest(40012, "the file has no rows below its header")
+	}
+	return nil
+}
+
+// newImportReader reads the header of a CSV import, returning the reader
+// positioned at the first row and the column index of each header.
+func newImportReader(r io.Reader) (*csv.Reader, map[string]int, *errors.AppError) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	cr.ReuseRecord = true
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil, errors.BadRequest(40012, "the file is empty")
+	}
+	if err != nil {
+		return nil, nil, errors.BadRequest(40012, "invalid CSV: "+err.Error())
+	}
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			// Spreadsheets often save UTF-8 with a byte order mark.
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		key := strings.ToLower(strings.TrimSpace(name))
+		if !importColumns[key] {
+			return nil, nil, errors.BadRequest(40012, "unknown column "+strconv.Quote(name)+
+				"; allowed: email, password, name, phone, companyCode, status, roles, sendInvite")
+		}
+		if _, dup := cols[key]; dup {
+			return nil, nil, errors.BadRequest(40012, "column "+strconv.Quote(name)+" appears twice")
+		}
+		cols[key] = i
+	}
+	for _, required := range []string{"email", "name"} {
+		if _, ok := cols[required]; !ok {
+			return nil, nil, errors.BadRequest(40012, "the header has no "+required+" column")
+		}
+	}
+	return cr, cols, nil
+}
+
+// run imports the rows of a file scan has accepted.
+func (h *userImport) run(ctx context.Context, authCtx *middleware.AuthContext, r io.Reader) *importReport {
+	report := &importReport{}
+	cr, cols, _ := newImportReader(r)
+	batch := make([]user.ImportRow, 0, h.cfg.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		results, err := h.uc.ImportUsers(ctx, batch)
+		if err != nil {
+			h.log.Error("user import batch failed",
+				zap.Int("first_row", batch[0].Row), zap.Int("rows", len(batch)), zap.Error(err))
+			for _, row := range batch {
+				report.add(importRowReport{Row: row.Row, Email: row.Email, Status: string(user.ImportFailed), Message: "failed to create user"})
+			}
+		}
+		for _, res := range results {
+			rr := importRowReport{Row: res.Row, Email: res.Email, Status: string(res.Status), ID: res.UserID}
+			if res.Err != nil {
+				rr.Message = importMessage(res.Err)
+			}
+			report.add(rr)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := cr.FieldPos(0)
+		if err != nil {
+			// scan read the same bytes without error.
+			report.add(importRowReport{Row: line, Status: string(user.ImportFailed), Message: err.Error()})
+			continue
+		}
+		row, rowErr := importRow(record, cols, authCtx)
+		row.Row = line
+		if rowErr != "" {
+			report.add(importRowReport{Row: line, Email: row.Email, Status: string(user.ImportFailed), Message: rowErr})
+			continue
+		}
+		if batch = append(batch, row); len(batch) == h.cfg.BatchSize {
+			flush()
+		}
+	}
+	flush()
+	sort.SliceStable(report.Rows, func(i, j int) bool { return report.Rows[i].Row < report.Rows[j].Row })
+	return report
+}
+
+func (r *importReport) add(row importRowReport) {
+	r.Total++
+	switch user.ImportStatus(row.Status) {
+	case user.ImportCreated:
+		r.Created++
+	case user.ImportSkippedDuplicate:
+		r.Skipped++
+	default:
+		r.Failed++
+	}
+	r.Rows = append(r.Rows, row)
+}
+
+// importRow maps a CSV record to the row CreateUser would be called with,
+// or returns why the row is invalid.
+func importRow(record []string, cols map[string]int, authCtx *middleware.AuthContext) (user.ImportRow, string) {
+	get := func(name string) string {
+		if i, ok := cols[name]; ok {
+			return strings.TrimSpace(csvUncell(record[i]))
+		}
+		return ""
+	}
+	req := &pb.CreateUserReq{
+		Email:       get("email"),
+		Password:    get("password"),
+		Name:        get("name"),
+		Phone:       get("phone"),
+		CompanyCode: get("companycode"),
+		Status:      get("status"),
+	}
+	row := user.ImportRow{CreateUserInput: user.CreateUserInput{Email: req.Email}}
+	for _, role := range strings.Split(get("roles"), ";") {
+		if role = strings.TrimSpace(role); role != "" {
+			req.Roles = append(req.Roles, role)
+		}
+	}
+	if v := get("sendinvite"); v != "" {
+		invite, err := strconv.ParseBool(v)
+		if err != nil {
+			return row, "sendInvite must be true or false"
+		}
+		req.SendInvite = invite
+	}
+	if err := pb.ValidateRequest(req); err != nil {
+		var appErr *errors.AppError
+		if stderrors.As(err, &appErr) {
+			return row, appErr.Message
+		}
+		return row, err.Error()
+	}
+	roles := entity.DefaultRoles
+	if len(req.Roles) > 0 {
+		var err error
+		if roles, err = entity.ParseRoles(req.Roles); err != nil {
+			return row, err.Error()
+		}
+	}
+	if !authCtx.CanAssignRoles(roles) {
+		return row, "roles grant permissions the caller does not have"
+	}
+	row.CreateUserInput = user.CreateUserInput{
+		Email:       req.Email,
+		Password:    req.Password,
+		Name:        req.Name,
+		Phone:       req.Phone,
+		CompanyCode: req.CompanyCode,
+		Roles:       roles,
+		Status:      req.Status,
+		Invite:      req.SendInvite,
+	}
+	return row, ""
+}
+
+// importMessage is the report message for a row ImportUsers did not create,
+// worded like CreateUser's errors.
+func importMessage(err error) string {
+	var limitErr *entity.CompanyUserLimitError
+	switch {
+	case stderrors.Is(err, user.ErrEmailExists):
+		return "email already registered"
+	case stderrors.As(err, &limitErr):
+		return "user limit reached for company"
+	case stderrors.Is(err, user.ErrInvalidStatus):
+		return "status must be one of active, inactive, pending"
+	case stderrors.Is(err, user.ErrInvitesUnavailable):
+		return "user invitations are temporarily unavailable"
+	case stderrors.Is(err, entity.ErrInvalidRoles):
+		return err.Error()
+	}
+	return "failed to create user"
+}
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	user "veemon/app/usecase/user"
+	"veemon/entity"
+	"veemon/internal/fixtures"
+	"veemon/pkg/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func (r *memRepo) FindExistingEmails(_ context.Context, emails []string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var found []string
+	for _, u := range r.users {
+		for _, email := range emails {
+			if u.Email == email {
+				found = append(found, email)
+			}
+		}
+	}
+	return found, nil
+}
+
+func (r *memRepo) CreateBatch(ctx context.Context, users []*entity.User) error {
+	for _, u := range users {
+		if err := r.Create(ctx, u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func importApp(cfg UserImportConfig, roles ...string) (*fiber.App, *memRepo) {
+	repo := &memRepo{users: map[string]*entity.User{
+		versionedID: fixtures.User().WithID(versionedID).WithEmail("taken@example.com").Build(),
+	}}
+	app := fiber.New()
+	validator := func(string) (*middleware.AuthContext, error) {
+		return &middleware.AuthContext{UserID: "caller", Roles: roles}, nil
+	}
+	app.Post("/api/v1/users/import",
+		middleware.AuthMiddleware(validator, middleware.AuthConfig{NeedAuth: true, RequiredPermissions: []string{UserImportPermission}}),
+		UserImportHandler(user.NewUseCase(repo), cfg, nil))
+	return app, repo
+}
+
+// postCSV uploads csv as the file part of a multipart body.
+func postCSV(t *testing.T, app *fiber.App, csv string) (int, []byte) {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, _ := mw.CreateFormFile("file", "users.csv")
+	_, _ = part.Write([]byte(csv))
+	_ = mw.Close()
+
+	req := httptest.NewRequest("POST", "/api/v1/users/import", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer x")
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+	var buf bytes.Buffer
+	_, _ = buf.ReadFrom(resp.Body)
+	return resp.StatusCode, buf.Bytes()
+}
+
+func TestUserImport_ReportsEveryRow(t *testing.T) {
+	app, repo := importApp(UserImportConfig{BatchSize: 2}, "admin")
+
+	status, body := postCSV(t, app, "\ufeffEmail,name,Password,roles,status\n"+
+		"new1@example.com,New One,Password123,auditor;viewer,\n"+
+		"taken@example.com,Taken,Password123,,\n"+
+		"not-an-email,Bad,Password123,,\n"+
+		"new2@example.com,New Two,Password123,,archived\n"+
+		"new1@example.com,Again,Password123,,\n"+
+		"root@example.com,Root,Password123,superadmin,\n")
+
+	var out struct {
+		Data importReport `json:"data"`
+	}
+	_ = json.Unmarshal(body, &out)
+	if status != 200 {
+		t.Fatalf("status %d: %s", status, body)
+	}
+	got := out.Data
+	if got.Total != 6 || got.Created != 1 || got.Skipped != 2 || got.Failed != 3 {
+		t.Fatalf("report = %+v", got)
+	}
+	want := []string{"created", "skipped_duplicate", "error", "error", "skipped_duplicate", "error"}
+	for i, row := range got.Rows {
+		if row.Row != i+2 || row.Status != want[i] {
+			t.Errorf("rows[%d] = %+v; want line %d %s", i, row, i+2, want[i])
+		}
+	}
+	if got.Rows[0].ID == "" || got.Rows[1].Message != "email already registered" ||
+		!strings.HasPrefix(got.Rows[3].Message, "status must be one of") {
+		t.Errorf("rows = %+v", got.Rows)
+	}
+	if len(repo.users) != 2 {
+		t.Errorf("%d users stored; want the existing one and new1", len(repo.users))
+	}
+}
+
+func TestUserImport_RejectsBadFiles(t *testing.T) {
+	tests := []struct {
+		name         string
+		csv          string
+		status, code int
+	}{
+		{"empty", "", 400, 40012},
+		{"header only", "email,name\n", 400, 40012},
+		{"unknown column", "email,name,salary\na@example.com,A,1\n", 400, 40012},
+		{"no name column", "email\na@example.com\n", 400, 40012},
+		{"ragged row", "email,name\na@example.com,A,extra\n", 400, 40012},
+		{"too many rows", "email,name\na@example.com,A\nb@example.com,B\nc@example.com,C\n", 413, 41301},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, repo := importApp(UserImportConfig{MaxRows: 2}, "admin")
+			status, body := postCSV(t, app, tt.csv)
+			var out struct {
+				Error struct{ Code int } `json:"error"`
+			}
+			_ = json.Unmarshal(body, &out)
+			if status != tt.status || out.Error.Code != tt.code {
+				t.Fatalf("status %d code %d; want %d %d: %s", status, out.Error.Code, tt.status, tt.code, body)
+			}
+			if len(repo.users) != 1 {
+				t.Fatalf("a refused file created users")
+			}
+		})
+	}
+}
+
+func TestUserImport_RequiresTheFilePart(t *testing.T) {
+	app, _ := importApp(UserImportConfig{}, "admin")
+	req := httptest.NewRequest("POST", "/api/v1/users/import", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer x")
+	resp, err := app.Test(req, -1)
+	if err != nil || resp.StatusCode != 400 {
+		t.Fatalf("app.Test = %v, %v; want 400", resp, err)
+	}
+}
@@ -0,0 +1,63 @@
+package fixtures
+
+import (
+	"testing"
+	"time"
+
+	"veemon/entity"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestUser_DefaultsPassEntityHooks(t *testing.T) {
+	u := User().Build()
+
+	require.NoError(t, u.BeforeCreate(nil))
+	require.NoError(t, u.BeforeSave(nil))
+	assert.NotEmpty(t, u.ID)
+	assert.Contains(t, u.Email, u.ID)
+	assert.Equal(t, entity.UserStatusActive, u.Status)
+	assert.Equal(t, []string(entity.DefaultRoles), []string(u.Roles))
+	assert.Equal(t, Epoch, u.CreatedAt)
+	assert.False(t, u.DeletedAt.Valid)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(Password)))
+}
+
+func TestUser_EachBuildIsDistinct(t *testing.T) {
+	a, b := User().Build(), User().Build()
+	assert.NotEqual(t, a.ID, b.ID)
+	assert.NotEqual(t, a.Email, b.Email)
+
+	// Later changes to a builder do not reach users already built from it.
+	builder := User().WithRoles("admin")
+	first := builder.Build()
+	first.Roles[0] = "changed"
+	assert.Equal(t, []string{"admin"}, []string(builder.Build().Roles))
+}
+
+func TestUser_Options(t *testing.T) {
+	requested := Epoch.Add(24 * time.Hour)
+	u := User().
+		WithID("id-1").
+		WithEmail("a@example.com").
+		WithPassword("Other123").
+		WithRoles("Admin ", "user").
+		WithCompany("COMPANY-001").
+		DeletionRequested(requested).
+		Deleted().
+		Build()
+
+	assert.Equal(t, "id-1", u.ID)
+	assert.Equal(t, "a@example.com", u.Email)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(u.Password), []byte("Other123")))
+	assert.Equal(t, "COMPANY-001", u.CompanyCode)
+	assert.Equal(t, entity.UserStatusInactive, u.Status)
+	assert.Equal(t, requested, *u.DeletionRequestedAt)
+	assert.True(t, u.DeletedAt.Valid)
+
+	// Roles are normalized by the entity's hook, as on insert.
+	require.NoError(t, u.BeforeSave(nil))
+	assert.Equal(t, []string{"admin", "user"}, []string(u.Roles))
+}
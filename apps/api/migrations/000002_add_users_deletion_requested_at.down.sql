@@ -0,0 +1,5 @@
+-- 000002_add_users_deletion_requested_at.down.sql
+-- Drop pending-deletion tracking
+
+DROP INDEX IF EXISTS idx_users_deletion_requested_at;
+ALTER TABLE users DROP COLUMN IF EXISTS deletion_requested_at;
@@ -0,0 +1,8 @@
+-- 000002_add_users_deletion_requested_at.up.sql
+-- Track pending self-service account deletions
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deletion_requested_at TIMESTAMP WITH TIME ZONE;
+
+-- The purge job only ever scans live accounts with a pending deletion.
+CREATE INDEX IF NOT EXISTS idx_users_deletion_requested_at ON users(deletion_requested_at)
+    WHERE deletion_requested_at IS NOT NULL AND deleted_at IS NULL;
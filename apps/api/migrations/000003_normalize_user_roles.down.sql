@@ -0,0 +1,4 @@
+-- 000003_normalize_user_roles.down.sql
+-- Drop the roles bound. Normalized role values are not reverted.
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_users_roles_max;
@@ -0,0 +1,21 @@
+-- 000003_normalize_user_roles.up.sql
+-- Normalize stored roles (trimmed, lowercased, deduplicated) and bound their count
+
+-- Keep each role's first position; blank entries are dropped.
+UPDATE users u
+SET roles = n.roles
+FROM (
+    SELECT id, COALESCE(array_agg(role ORDER BY pos) FILTER (WHERE role <> ''), ARRAY[]::TEXT[]) AS roles
+    FROM (
+        SELECT id, lower(btrim(r)) AS role, min(pos) AS pos
+        FROM users, unnest(roles) WITH ORDINALITY AS t(r, pos)
+        GROUP BY id, lower(btrim(r))
+    ) d
+    GROUP BY id
+) n
+WHERE u.id = n.id AND u.roles IS DISTINCT FROM n.roles;
+
+-- Mirrors entity.MaxRoles. NOT VALID: enforced on new writes without failing
+-- the migration on existing oversized rows; run
+-- ALTER TABLE users VALIDATE CONSTRAINT chk_users_roles_max once they are fixed.
+ALTER TABLE users ADD CONSTRAINT chk_users_roles_max CHECK (cardinality(roles) <= 20) NOT VALID;
@@ -0,0 +1,7 @@
+-- 000004_create_audit_logs_table.down.sql
+-- Drop the audit log
+
+DROP INDEX IF EXISTS idx_audit_logs_resource;
+DROP INDEX IF EXISTS idx_audit_logs_actor_id;
+DROP INDEX IF EXISTS idx_audit_logs_created_at;
+DROP TABLE IF EXISTS audit_logs;
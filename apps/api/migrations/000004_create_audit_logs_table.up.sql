@@ -0,0 +1,20 @@
+-- 000004_create_audit_logs_table.up.sql
+-- Record who changed which resource, and how
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    -- Empty for changes made without an authenticated caller.
+    actor_id VARCHAR(64) NOT NULL DEFAULT '',
+    action VARCHAR(50) NOT NULL,
+    resource_type VARCHAR(50) NOT NULL,
+    resource_id VARCHAR(64) NOT NULL,
+    before JSONB,
+    after JSONB,
+    request_id VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Listing is newest first, filtered by actor or resource.
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs(created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor_id ON audit_logs(actor_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_resource ON audit_logs(resource_type, resource_id, created_at);
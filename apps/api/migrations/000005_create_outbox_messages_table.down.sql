@@ -0,0 +1,6 @@
+-- 000005_create_outbox_messages_table.down.sql
+-- Drop the outbox
+
+DROP INDEX IF EXISTS idx_outbox_messages_sent_at;
+DROP INDEX IF EXISTS idx_outbox_messages_pending;
+DROP TABLE IF EXISTS outbox_messages;
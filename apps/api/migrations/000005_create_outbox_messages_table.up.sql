@@ -0,0 +1,20 @@
+-- 000005_create_outbox_messages_table.up.sql
+-- Stage messages for RabbitMQ in the transaction of the change they report
+
+CREATE TABLE IF NOT EXISTS outbox_messages (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    exchange VARCHAR(255) NOT NULL,
+    routing_key VARCHAR(255) NOT NULL,
+    payload JSONB NOT NULL,
+    -- Trace context of the request that staged the message.
+    headers JSONB,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMP WITH TIME ZONE
+);
+
+-- The relay polls unsent messages that are due; cleanup deletes old sent ones.
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_pending ON outbox_messages(next_attempt_at) WHERE sent_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_sent_at ON outbox_messages(sent_at) WHERE sent_at IS NOT NULL;
@@ -0,0 +1,4 @@
+-- 000006_create_seed_histories_table.down.sql
+-- Drop the seed history
+
+DROP TABLE IF EXISTS seed_histories;
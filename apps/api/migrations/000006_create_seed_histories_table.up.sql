@@ -0,0 +1,10 @@
+-- 000006_create_seed_histories_table.up.sql
+-- Record which seeders have run, so reruns skip them
+
+CREATE TABLE IF NOT EXISTS seed_histories (
+    name VARCHAR(100) PRIMARY KEY,
+    environment VARCHAR(50) NOT NULL,
+    created INTEGER NOT NULL DEFAULT 0,
+    skipped INTEGER NOT NULL DEFAULT 0,
+    ran_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
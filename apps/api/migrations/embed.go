@@ -0,0 +1,10 @@
+// Package migrations embeds the SQL migrations, so the migrate CLI and the
+// server can apply them without a migrations directory next to the binary.
+package migrations
+
+import "embed"
+
+// FS holds the *.up.sql and *.down.sql files of this directory.
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,128 @@
+// Package activeusers estimates daily and weekly active users with Redis
+// HyperLogLogs.
+//
+// Every authenticated request adds its user ID to the HyperLogLog for the
+// current UTC day. A HyperLogLog answers "how many distinct IDs" in 12 KB no
+// matter how many users there are, at the cost of exactness: Redis' estimate
+// has a standard error of 0.81%, so at 10,000 users a reading is typically
+// within about ±80 of the true count. Multi-day windows count the union of
+// the day keys, so a user active on several days is counted once.
+//
+// Like authguard, a Tracker without Redis is a no-op.
+package activeusers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"veemon/pkg/redis"
+)
+
+// Windows maps each reported window label to its length in days.
+var Windows = []struct {
+	Label string
+	Days  int
+}{
+	{"1d", 1},
+	{"7d", 7},
+}
+
+// retention keeps day keys long enough to cover the longest window.
+const retention = 8 * 24 * time.Hour
+
+// Recorder receives window counts. *metrics.Metrics satisfies it.
+type Recorder interface {
+	SetActiveUsers(window string, count float64)
+}
+
+// Tracker records and counts active users.
+type Tracker struct {
+	redis *redis.Client
+	now   func() time.Time
+
+	// seen holds the IDs recorded today by this process, so each user costs
+	// one PFADD per day per replica rather than one per request.
+	mu   sync.Mutex
+	day  string
+	seen map[string]struct{}
+}
+
+// New builds a Tracker. A nil redis client yields a no-op tracker.
+func New(r *redis.Client) *Tracker {
+	return &Tracker{redis: r, now: time.Now}
+}
+
+func (t *Tracker) enabled() bool { return t != nil && t.redis != nil }
+
+func dayKey(day string) string { return "active_users:" + day }
+
+func dayOf(at time.Time) string { return at.UTC().Format(time.DateOnly) }
+
+// Record marks userID active today. Errors are returned for logging only;
+// the request that triggered them should proceed.
+func (t *Tracker) Record(ctx context.Context, userID string) error {
+	if !t.enabled() || userID == "" {
+		return nil
+	}
+	day := dayOf(t.now())
+
+	t.mu.Lock()
+	if t.day != day {
+		t.day, t.seen = day, make(map[string]struct{})
+	}
+	_, dup := t.seen[userID]
+	t.mu.Unlock()
+	if dup {
+		return nil
+	}
+
+	key := dayKey(day)
+	changed, err := t.redis.PFAdd(ctx, key, userID)
+	if err != nil {
+		return err
+	}
+	// Any write to the key (including its creation) refreshes the TTL.
+	if changed {
+		if err := t.redis.Expire(ctx, key, retention); err != nil {
+			return err
+		}
+	}
+
+	t.mu.Lock()
+	if t.day == day {
+		t.seen[userID] = struct{}{}
+	}
+	t.mu.Unlock()
+	return nil
+}
+
+// Count estimates the distinct users active over the last days UTC days,
+// today included.
+func (t *Tracker) Count(ctx context.Context, days int) (int64, error) {
+	if !t.enabled() || days <= 0 {
+		return 0, nil
+	}
+	today := t.now().UTC()
+	keys := make([]string, days)
+	for i := range keys {
+		keys[i] = dayKey(dayOf(today.AddDate(0, 0, -i)))
+	}
+	return t.redis.PFCount(ctx, keys...)
+}
+
+// Publish counts every window in Windows and reports it to rec. It stops at
+// the first Redis error, leaving the remaining gauges at their last value.
+func (t *Tracker) Publish(ctx context.Context, rec Recorder) error {
+	if !t.enabled() || rec == nil {
+		return nil
+	}
+	for _, w := range Windows {
+		n, err := t.Count(ctx, w.Days)
+		if err != nil {
+			return err
+		}
+		rec.SetActiveUsers(w.Label, float64(n))
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package activeusers
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"veemon/pkg/metrics"
+	"veemon/pkg/redis"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gofiber/fiber/v2"
+)
+
+func newTracker(t *testing.T, now *time.Time) (*Tracker, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	port, _ := strconv.Atoi(mr.Port())
+	rc, err := redis.New(redis.Config{Host: mr.Host(), Port: port, MaxIdle: 1, MaxActive: 2})
+	if err != nil {
+		t.Fatalf("redis.New: %v", err)
+	}
+	t.Cleanup(func() { _ = rc.Close() })
+	tr := New(rc)
+	tr.now = func() time.Time { return *now }
+	return tr, mr
+}
+
+type gauges map[string]float64
+
+func (g gauges) SetActiveUsers(window string, count float64) { g[window] = count }
+
+func TestTracker_CountsDistinctUsersPerWindow(t *testing.T) {
+	now := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	tr, _ := newTracker(t, &now)
+	ctx := context.Background()
+
+	record := func(at time.Time, ids ...string) {
+		now = at
+		for _, id := range ids {
+			if err := tr.Record(ctx, id); err != nil {
+				t.Fatalf("Record(%s): %v", id, err)
+			}
+		}
+	}
+	today := now
+	record(today.AddDate(0, 0, -7), "too-old") // just outside the 7-day window
+	record(today.AddDate(0, 0, -6), "u1")
+	record(today.AddDate(0, 0, -1), "u2", "u3")
+	record(today, "u4", "u4", "u5")
+
+	g := gauges{}
+	if err := tr.Publish(ctx, g); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if g["1d"] != 2 || g["7d"] != 5 {
+		t.Fatalf("gauges = %v, want 1d=2 7d=5", g)
+	}
+}
+
+func TestTracker_PublishSetsPrometheusGauges(t *testing.T) {
+	now := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	tr, _ := newTracker(t, &now)
+	ctx := context.Background()
+	_ = tr.Record(ctx, "u1")
+	now = now.AddDate(0, 0, -2)
+	_ = tr.Record(ctx, "u2")
+	now = now.AddDate(0, 0, 2)
+
+	m := metrics.New("test")
+	if err := tr.Publish(ctx, m); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	app := fiber.New()
+	app.Get("/metrics", m.Handler())
+	resp, err := app.Test(httptest.NewRequest("GET", "/metrics", nil), -1)
+	if err != nil {
+		t.Fatalf("scrape: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	for _, want := range []string{`test_active_users{window="1d"} 1`, `test_active_users{window="7d"} 2`} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("/metrics missing %q", want)
+		}
+	}
+}
+
+func TestTracker_RecordsEachUserOncePerDay(t *testing.T) {
+	now := time.Date(2026, 3, 10, 23, 59, 0, 0, time.UTC)
+	tr, mr := newTracker(t, &now)
+	ctx := context.Background()
+
+	if err := tr.Record(ctx, "u1"); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	key := dayKey("2026-03-10")
+	if ttl := mr.TTL(key); ttl != retention {
+		t.Fatalf("TTL = %v, want %v", ttl, retention)
+	}
+
+	// A repeat the same day is answered from memory, without Redis.
+	mr.Del(key)
+	_ = tr.Record(ctx, "u1")
+	if mr.Exists(key) {
+		t.Fatal("repeat Record the same day should not write to Redis")
+	}
+
+	// The next UTC day starts afresh.
+	now = now.Add(2 * time.Minute)
+	_ = tr.Record(ctx, "u1")
+	if !mr.Exists(dayKey("2026-03-11")) {
+		t.Fatal("Record on a new day should write that day's key")
+	}
+}
+
+func TestTracker_NoRedisIsNoop(t *testing.T) {
+	var nilTracker *Tracker
+	for _, tr := range []*Tracker{nilTracker, New(nil)} {
+		if err := tr.Record(context.Background(), "u1"); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+		g := gauges{}
+		if err := tr.Publish(context.Background(), g); err != nil || len(g) != 0 {
+			t.Fatalf("Publish = %v, gauges %v; want no-op", err, g)
+		}
+	}
+}
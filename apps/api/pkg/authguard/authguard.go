@@ -10,62 +10,146 @@ import (
 	"time"
 
 	"veemon/pkg/redis"
+
+	"go.uber.org/zap"
 )
 
-// Guard enforces account lockout and token revocation.
+// Guard enforces login lockout and token revocation.
+//
+// Failed logins are counted per account (email) and per client IP over a
+// sliding window: each failure is a timestamped entry, so the count decays
+// as failures age out instead of resetting all at once. Reaching a
+// threshold locks the account or IP for the lockout duration. A successful
+// login clears the account's count but not the IP's, so one valid account
+// cannot be used to reset an IP guessing at others.
 type Guard struct {
-	redis       *redis.Client
-	maxAttempts int
-	lockout     time.Duration
+	redis         *redis.Client
+	maxAttempts   int
+	ipMaxAttempts int
+	window        time.Duration
+	lockout       time.Duration
+	notifier      LockNotifier
+	logger        *zap.Logger
+	now           func() time.Time
+}
+
+// Option configures a Guard.
+type Option func(*Guard)
+
+// WithWindow counts failures over the last window; by default it equals the
+// lockout duration.
+func WithWindow(window time.Duration) Option {
+	return func(g *Guard) {
+		if window > 0 {
+			g.window = window
+		}
+	}
+}
+
+// WithIPLimit locks a client IP after maxAttempts failures within the
+// window, whatever emails they were for. Zero or less disables it.
+func WithIPLimit(maxAttempts int) Option {
+	return func(g *Guard) { g.ipMaxAttempts = maxAttempts }
+}
+
+// WithLockNotifier announces every new lock through n.
+func WithLockNotifier(n LockNotifier) Option {
+	return func(g *Guard) { g.notifier = n }
+}
+
+// WithLogger logs failures to announce a lock.
+func WithLogger(l *zap.Logger) Option {
+	return func(g *Guard) { g.logger = l }
 }
 
-// New builds a Guard. A nil redis client yields a no-op guard.
-func New(r *redis.Client, maxAttempts, lockoutMinutes int) *Guard {
-	return &Guard{
+// New builds a Guard locking an account for lockoutMinutes after
+// maxAttempts failures. A nil redis client yields a no-op guard.
+func New(r *redis.Client, maxAttempts, lockoutMinutes int, opts ...Option) *Guard {
+	g := &Guard{
 		redis:       r,
 		maxAttempts: maxAttempts,
 		lockout:     time.Duration(lockoutMinutes) * time.Minute,
+		logger:      zap.NewNop(),
+		now:         time.Now,
 	}
+	g.window = g.lockout
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
 }
 
 func (g *Guard) enabled() bool { return g != nil && g.redis != nil }
 
 func lockKey(email string) string  { return "login:lock:" + email }
-func failKey(email string) string  { return "login:fail:" + email }
+func failKey(email string) string  { return "login:failures:" + email }
+func ipLockKey(ip string) string   { return "login:lock-ip:" + ip }
+func ipFailKey(ip string) string   { return "login:failures-ip:" + ip }
 func revokedKey(jti string) string { return "token:revoked:" + jti }
 
-// IsLocked reports whether the account is currently locked out. On Redis error
-// it returns false (fail open) so an outage cannot lock everyone out.
-func (g *Guard) IsLocked(ctx context.Context, email string) bool {
+func userRevokedKey(userID string) string { return "token:revoked-user:" + userID }
+
+// IsLocked reports whether the account, or the client IP when known, is
+// currently locked out. On Redis error it returns false (fail open) so an
+// outage cannot lock everyone out.
+func (g *Guard) IsLocked(ctx context.Context, email, ip string) bool {
 	if !g.enabled() {
 		return false
 	}
-	locked, err := g.redis.Exists(ctx, lockKey(email))
+	keys := []string{lockKey(email)}
+	if ip != "" && g.ipMaxAttempts > 0 {
+		keys = append(keys, ipLockKey(ip))
+	}
+	locked, err := g.redis.ExistsMany(ctx, keys...)
 	if err != nil {
 		return false
 	}
-	return locked
+	for _, l := range locked {
+		if l {
+			return true
+		}
+	}
+	return false
 }
 
-// RecordFailure increments the failure counter and locks the account once the
-// configured threshold is reached, both expiring after the lockout window.
-func (g *Guard) RecordFailure(ctx context.Context, email string) {
+// RecordFailure counts a failed login for email and, when known, ip, and
+// locks whichever reaches its threshold within the window.
+func (g *Guard) RecordFailure(ctx context.Context, email, ip string) {
 	if !g.enabled() {
 		return
 	}
-	n, err := g.redis.Incr(ctx, failKey(email))
-	if err != nil {
+	now := g.now()
+	g.count(ctx, now, failKey(email), lockKey(email), g.maxAttempts, LoginLocked{
+		Scope: LockScopeAccount, Email: email, IP: ip,
+	})
+	if ip != "" && g.ipMaxAttempts > 0 {
+		g.count(ctx, now, ipFailKey(ip), ipLockKey(ip), g.ipMaxAttempts, LoginLocked{
+			Scope: LockScopeIP, Email: email, IP: ip,
+		})
+	}
+}
+
+// count adds a failure to failKey and sets lockKey once max is reached,
+// announcing the lock when it is new.
+func (g *Guard) count(ctx context.Context, now time.Time, failKey, lockKey string, max int, event LoginLocked) {
+	n, err := g.redis.WindowAdd(ctx, failKey, now, g.window)
+	if err != nil || int(n) < max {
 		return
 	}
-	if n == 1 {
-		_ = g.redis.Expire(ctx, failKey(email), g.lockout)
+	locked, err := g.redis.SetNX(ctx, lockKey, "1", g.lockout)
+	if err != nil || !locked || g.notifier == nil {
+		return
 	}
-	if int(n) >= g.maxAttempts {
-		_ = g.redis.Set(ctx, lockKey(email), "1", g.lockout)
+	event.Failures = int(n)
+	event.LockedUntil = now.Add(g.lockout).UTC()
+	if err := g.notifier.LoginLocked(ctx, event); err != nil {
+		g.logger.Warn("failed to announce login lockout",
+			zap.String("scope", event.Scope), zap.Error(err))
 	}
 }
 
-// Reset clears failure and lock state after a successful authentication.
+// Reset clears the account's failure and lock state after a successful
+// authentication. The IP's count is left to decay.
 func (g *Guard) Reset(ctx context.Context, email string) {
 	if !g.enabled() {
 		return
@@ -95,3 +179,60 @@ func (g *Guard) IsRevoked(ctx context.Context, jti string) bool {
 	}
 	return revoked
 }
+
+// RevokeUser revokes every token issued to userID before now, for ttl. ttl
+// should be the longest token lifetime, so every affected token has expired
+// by the time the entry is dropped. A non-positive ttl or empty userID is a
+// no-op.
+func (g *Guard) RevokeUser(ctx context.Context, userID string, ttl time.Duration) error {
+	if !g.enabled() || userID == "" || ttl <= 0 {
+		return nil
+	}
+	return g.redis.Set(ctx, userRevokedKey(userID), time.Now().Unix(), ttl)
+}
+
+// IsUserRevoked reports whether a token issued to userID at issuedAt predates
+// a RevokeUser call. Token issue times have one-second precision, so a token
+// issued within the same second as the revocation counts as earlier. On Redis
+// error it returns false (fail open).
+func (g *Guard) IsUserRevoked(ctx context.Context, userID string, issuedAt time.Time) bool {
+	if !g.enabled() || userID == "" {
+		return false
+	}
+	var revokedAt int64
+	if err := g.redis.Get(ctx, userRevokedKey(userID), &revokedAt); err != nil {
+		return false
+	}
+	return issuedAt.Unix() <= revokedAt
+}
+
+// RevokedMany is IsRevoked for several token ids in one Redis round trip; the
+// result is aligned with jtis. Empty ids are never revoked, and on Redis
+// error every id reports false (fail open).
+func (g *Guard) RevokedMany(ctx context.Context, jtis []string) []bool {
+	out := make([]bool, len(jtis))
+	if !g.enabled() {
+		return out
+	}
+
+	keys := make([]string, 0, len(jtis))
+	idx := make([]int, 0, len(jtis))
+	for i, jti := range jtis {
+		if jti != "" {
+			keys = append(keys, revokedKey(jti))
+			idx = append(idx, i)
+		}
+	}
+	if len(keys) == 0 {
+		return out
+	}
+
+	revoked, err := g.redis.ExistsMany(ctx, keys...)
+	if err != nil {
+		return out
+	}
+	for k, i := range idx {
+		out[i] = revoked[k]
+	}
+	return out
+}
@@ -2,8 +2,13 @@ package authguard
 
 import (
 	"context"
+	"strconv"
 	"testing"
 	"time"
+
+	"veemon/pkg/redis"
+
+	"github.com/alicebob/miniredis/v2"
 )
 
 // With no Redis client the guard must degrade to a safe no-op: never locked,
@@ -12,7 +17,7 @@ func TestGuard_NoRedis_IsNoop(t *testing.T) {
 	ctx := context.Background()
 	g := New(nil, 5, 15)
 
-	if g.IsLocked(ctx, "user@example.com") {
+	if g.IsLocked(ctx, "user@example.com", "203.0.113.7") {
 		t.Error("IsLocked should be false without Redis")
 	}
 	if g.IsRevoked(ctx, "some-jti") {
@@ -20,11 +25,17 @@ func TestGuard_NoRedis_IsNoop(t *testing.T) {
 	}
 
 	// These must be safe no-ops (no panic, no error surfaced).
-	g.RecordFailure(ctx, "user@example.com")
+	g.RecordFailure(ctx, "user@example.com", "203.0.113.7")
 	g.Reset(ctx, "user@example.com")
 	if err := g.Revoke(ctx, "some-jti", time.Minute); err != nil {
 		t.Errorf("Revoke without Redis should be a no-op, got %v", err)
 	}
+	if err := g.RevokeUser(ctx, "user-1", time.Minute); err != nil {
+		t.Errorf("RevokeUser without Redis should be a no-op, got %v", err)
+	}
+	if g.IsUserRevoked(ctx, "user-1", time.Now().Add(-time.Hour)) {
+		t.Error("IsUserRevoked should be false without Redis")
+	}
 }
 
 // A nil *Guard must also behave as a no-op so callers need not nil-check.
@@ -32,15 +43,171 @@ func TestGuard_Nil_IsNoop(t *testing.T) {
 	ctx := context.Background()
 	var g *Guard
 
-	if g.IsLocked(ctx, "user@example.com") {
+	if g.IsLocked(ctx, "user@example.com", "203.0.113.7") {
 		t.Error("nil guard IsLocked should be false")
 	}
 	if g.IsRevoked(ctx, "jti") {
 		t.Error("nil guard IsRevoked should be false")
 	}
-	g.RecordFailure(ctx, "user@example.com")
+	g.RecordFailure(ctx, "user@example.com", "203.0.113.7")
 	g.Reset(ctx, "user@example.com")
 	if err := g.Revoke(ctx, "jti", time.Minute); err != nil {
 		t.Errorf("nil guard Revoke should be nil, got %v", err)
 	}
 }
+
+func newTestRedis(t *testing.T) (*redis.Client, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	port, _ := strconv.Atoi(mr.Port())
+	rc, err := redis.New(redis.Config{Host: mr.Host(), Port: port, MaxIdle: 1, MaxActive: 2})
+	if err != nil {
+		t.Fatalf("redis.New: %v", err)
+	}
+	t.Cleanup(func() { _ = rc.Close() })
+	return rc, mr
+}
+
+type recordingNotifier struct{ events []LoginLocked }
+
+func (n *recordingNotifier) LoginLocked(_ context.Context, e LoginLocked) error {
+	n.events = append(n.events, e)
+	return nil
+}
+
+// newClockedGuard returns a guard whose clock the test advances.
+func newClockedGuard(t *testing.T, opts ...Option) (*Guard, *time.Time, *miniredis.Miniredis) {
+	t.Helper()
+	rc, mr := newTestRedis(t)
+	g := New(rc, 3, 15, opts...)
+	now := time.Unix(1_700_000_000, 0)
+	g.now = func() time.Time { return now }
+	return g, &now, mr
+}
+
+func TestGuard_LocksAccountAfterMaxFailuresWithinWindow(t *testing.T) {
+	ctx := context.Background()
+	n := &recordingNotifier{}
+	g, now, mr := newClockedGuard(t, WithWindow(10*time.Minute), WithLockNotifier(n))
+
+	for i := 0; i < 2; i++ {
+		g.RecordFailure(ctx, "a@example.com", "")
+		if g.IsLocked(ctx, "a@example.com", "") {
+			t.Fatalf("locked after %d failures, want 3", i+1)
+		}
+	}
+	g.RecordFailure(ctx, "a@example.com", "")
+	if !g.IsLocked(ctx, "a@example.com", "") {
+		t.Fatal("not locked after 3 failures")
+	}
+	if g.IsLocked(ctx, "b@example.com", "") {
+		t.Error("other accounts must stay unlocked")
+	}
+	if ttl := mr.TTL(lockKey("a@example.com")); ttl != 15*time.Minute {
+		t.Errorf("lock ttl = %v, want the 15m lockout", ttl)
+	}
+
+	// Further failures extend nothing and announce nothing new.
+	g.RecordFailure(ctx, "a@example.com", "")
+	want := LoginLocked{Scope: LockScopeAccount, Email: "a@example.com", Failures: 3, LockedUntil: now.Add(15 * time.Minute).UTC()}
+	if len(n.events) != 1 || n.events[0] != want {
+		t.Fatalf("events = %+v, want one %+v", n.events, want)
+	}
+}
+
+func TestGuard_FailureCountDecays(t *testing.T) {
+	ctx := context.Background()
+	g, now, _ := newClockedGuard(t, WithWindow(10*time.Minute))
+
+	// Three failures, but never three within ten minutes.
+	for i := 0; i < 3; i++ {
+		g.RecordFailure(ctx, "a@example.com", "")
+		*now = now.Add(6 * time.Minute)
+	}
+	if g.IsLocked(ctx, "a@example.com", "") {
+		t.Fatal("failures spread beyond the window must not lock")
+	}
+
+	// Two more close together: with the one from six minutes ago, three.
+	*now = now.Add(-2 * time.Minute)
+	g.RecordFailure(ctx, "a@example.com", "")
+	g.RecordFailure(ctx, "a@example.com", "")
+	if !g.IsLocked(ctx, "a@example.com", "") {
+		t.Fatal("three failures within the window must lock")
+	}
+}
+
+func TestGuard_LocksIPAcrossAccounts(t *testing.T) {
+	ctx := context.Background()
+	n := &recordingNotifier{}
+	g, _, _ := newClockedGuard(t, WithIPLimit(4), WithLockNotifier(n))
+	const ip = "203.0.113.7"
+
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com", "d@example.com"} {
+		g.RecordFailure(ctx, email, ip)
+	}
+	if !g.IsLocked(ctx, "e@example.com", ip) {
+		t.Fatal("4 failures from one IP must lock it for every account")
+	}
+	if g.IsLocked(ctx, "e@example.com", "198.51.100.1") {
+		t.Error("other IPs must stay unlocked")
+	}
+	if len(n.events) != 1 || n.events[0].Scope != LockScopeIP || n.events[0].IP != ip || n.events[0].Email != "d@example.com" {
+		t.Errorf("events = %+v, want one ip lock", n.events)
+	}
+}
+
+func TestGuard_ResetClearsAccountButNotIP(t *testing.T) {
+	ctx := context.Background()
+	g, _, _ := newClockedGuard(t, WithIPLimit(3))
+	const ip = "203.0.113.7"
+
+	g.RecordFailure(ctx, "a@example.com", ip)
+	g.RecordFailure(ctx, "a@example.com", ip)
+	g.Reset(ctx, "a@example.com")
+
+	g.RecordFailure(ctx, "a@example.com", ip)
+	if g.IsLocked(ctx, "a@example.com", "") {
+		t.Fatal("Reset must clear the account's failures")
+	}
+	if !g.IsLocked(ctx, "a@example.com", ip) {
+		t.Fatal("Reset must leave the IP's failures to decay")
+	}
+}
+
+func TestGuard_RedisDownFailsOpen(t *testing.T) {
+	ctx := context.Background()
+	g, _, mr := newClockedGuard(t, WithIPLimit(1))
+	mr.SetError("LOADING Redis is loading the dataset in memory")
+
+	for i := 0; i < 5; i++ {
+		g.RecordFailure(ctx, "a@example.com", "203.0.113.7")
+	}
+	if g.IsLocked(ctx, "a@example.com", "203.0.113.7") {
+		t.Fatal("a Redis outage must not lock anyone out")
+	}
+}
+
+func TestGuard_RevokeUser_RejectsOnlyEarlierTokens(t *testing.T) {
+	ctx := context.Background()
+	rc, _ := newTestRedis(t)
+	g := New(rc, 5, 15)
+
+	before := time.Now().Add(-time.Minute)
+	if g.IsUserRevoked(ctx, "user-1", before) {
+		t.Fatal("nothing revoked yet")
+	}
+	if err := g.RevokeUser(ctx, "user-1", time.Hour); err != nil {
+		t.Fatalf("RevokeUser: %v", err)
+	}
+
+	if !g.IsUserRevoked(ctx, "user-1", before) {
+		t.Error("token issued before RevokeUser should be revoked")
+	}
+	if g.IsUserRevoked(ctx, "user-1", time.Now().Add(time.Second)) {
+		t.Error("token issued after RevokeUser should stay valid")
+	}
+	if g.IsUserRevoked(ctx, "user-2", before) {
+		t.Error("other users' tokens should stay valid")
+	}
+}
@@ -0,0 +1,72 @@
+package authguard
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"veemon/pkg/events"
+
+	"github.com/google/uuid"
+)
+
+// EventTypeLoginLocked is the type (and routing key) of the event published
+// when repeated login failures lock an account or a client IP.
+const EventTypeLoginLocked = "user.login_locked"
+
+// Lock scopes.
+const (
+	LockScopeAccount = "account"
+	LockScopeIP      = "ip"
+)
+
+// LoginLocked is the Data of a user.login_locked event.
+type LoginLocked struct {
+	// Scope is LockScopeAccount when Email is locked, LockScopeIP when IP is.
+	Scope string `json:"scope"`
+	// Email is the account whose failed login set off the lock.
+	Email string `json:"email"`
+	// IP is the client the failure came from, empty when unknown.
+	IP string `json:"ip,omitempty"`
+	// Failures counts the failures within the window, this one included.
+	Failures    int       `json:"failures"`
+	LockedUntil time.Time `json:"lockedUntil"`
+}
+
+// LockNotifier announces new login locks. *Notifier satisfies it.
+type LockNotifier interface {
+	LoginLocked(ctx context.Context, event LoginLocked) error
+}
+
+// Publisher is the subset of the RabbitMQ client Notifier needs.
+type Publisher interface {
+	PublishJSON(ctx context.Context, exchange, routingKey string, message interface{}) error
+}
+
+// Notifier publishes user.login_locked events.
+type Notifier struct {
+	publisher Publisher
+	exchange  string
+	source    string
+}
+
+// NewNotifier publishes to exchange. source is recorded on the event
+// envelope (typically the service name).
+func NewNotifier(publisher Publisher, exchange, source string) *Notifier {
+	return &Notifier{publisher: publisher, exchange: exchange, source: source}
+}
+
+// LoginLocked publishes event.
+func (n *Notifier) LoginLocked(ctx context.Context, event LoginLocked) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return n.publisher.PublishJSON(ctx, n.exchange, EventTypeLoginLocked, events.Envelope{
+		ID:         uuid.NewString(),
+		Type:       EventTypeLoginLocked,
+		Source:     n.source,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	})
+}
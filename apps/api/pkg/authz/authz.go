@@ -0,0 +1,140 @@
+// Package authz maps roles to fine-grained permissions such as users.read,
+// so a route can require an action rather than a role and new roles (a
+// read-only auditor, say) need no route changes.
+//
+// Permissions are "<resource>.<action>". A grant may also be "<resource>.*"
+// for every action on a resource, or "*" for everything.
+package authz
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"veemon/entity"
+)
+
+// Wildcard grants every permission.
+const Wildcard = "*"
+
+var grantPattern = regexp.MustCompile(`^([a-z0-9_-]+\.([a-z0-9_-]+|\*)|\*)$`)
+
+// ErrInvalidPolicy is wrapped by every Load and Validate failure.
+var ErrInvalidPolicy = errors.New("invalid authz policy")
+
+// Policy maps a role to the permissions it grants. Role names are compared
+// normalized, as in entity.Roles.
+type Policy map[string][]string
+
+// DefaultPolicy is used unless AUTHZ_POLICY_FILE replaces it.
+var DefaultPolicy = Policy{
+	"superadmin": {Wildcard},
+	"admin":      {"users.read", "users.write", "users.delete", "users.export"},
+	"auditor":    {"users.read"},
+	// service is the machine identity of the API gateway.
+	"service": {"tokens.introspect"},
+}
+
+// Load reads a JSON policy file of the form
+//
+//	{"auditor": ["users.read"], "admin": ["users.*"]}
+//
+// and validates it. The file replaces DefaultPolicy rather than extending it.
+func Load(path string) (Policy, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("authz: read policy: %w", err)
+	}
+	var p Policy
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidPolicy, path, err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Validate checks every role name and grant.
+func (p Policy) Validate() error {
+	for role, grants := range p {
+		if roles, err := entity.ParseRoles([]string{role}); err != nil || len(roles) != 1 {
+			return fmt.Errorf("%w: role %q", ErrInvalidPolicy, role)
+		}
+		for _, g := range grants {
+			if !grantPattern.MatchString(strings.ToLower(g)) {
+				return fmt.Errorf("%w: role %q grants %q, want resource.action, resource.* or *", ErrInvalidPolicy, role, g)
+			}
+		}
+	}
+	return nil
+}
+
+// Permissions returns the grants of every role in roles, without
+// duplicates, in role order.
+func (p Policy) Permissions(roles []string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, r := range roles {
+		for role, grants := range p {
+			if entity.NormalizeRole(role) != entity.NormalizeRole(r) {
+				continue
+			}
+			for _, g := range grants {
+				g = strings.ToLower(g)
+				if !seen[g] {
+					seen[g] = true
+					out = append(out, g)
+				}
+			}
+		}
+	}
+	return out
+}
+
+// Grants reports whether any of roles grants perm.
+func (p Policy) Grants(roles []string, perm string) bool {
+	return Allows(p.Permissions(roles), perm)
+}
+
+// Allows reports whether grants cover perm, honouring wildcards.
+func Allows(grants []string, perm string) bool {
+	perm = strings.ToLower(strings.TrimSpace(perm))
+	resource, _, ok := strings.Cut(perm, ".")
+	if !ok {
+		return false
+	}
+	for _, g := range grants {
+		if g == Wildcard || g == perm || g == resource+".*" {
+			return true
+		}
+	}
+	return false
+}
+
+// Covers reports whether holder's roles grant everything roles would, so a
+// holder can hand roles out without escalating anyone beyond themselves.
+// Wildcards are only covered by an equal or wider wildcard: users.* needs
+// users.* or *, and * needs *.
+func (p Policy) Covers(holder, roles []string) bool {
+	have := p.Permissions(holder)
+	for _, g := range p.Permissions(roles) {
+		switch {
+		case g == Wildcard:
+			if !slices.Contains(have, Wildcard) {
+				return false
+			}
+		case strings.HasSuffix(g, ".*"):
+			if !slices.Contains(have, Wildcard) && !slices.Contains(have, g) {
+				return false
+			}
+		case !Allows(have, g):
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,129 @@
+package authz
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPolicy_Grants(t *testing.T) {
+	p := Policy{
+		"superadmin": {"*"},
+		"admin":      {"users.*"},
+		"auditor":    {"users.read", "Payslips.Read"},
+	}
+	tests := []struct {
+		roles []string
+		perm  string
+		want  bool
+	}{
+		{[]string{"auditor"}, "users.read", true},
+		{[]string{"auditor"}, "users.write", false},
+		{[]string{"auditor"}, "payslips.read", true},
+		{[]string{" Auditor"}, "USERS.READ", true},
+		{[]string{"admin"}, "users.delete", true},
+		{[]string{"admin"}, "payslips.read", false},
+		{[]string{"superadmin"}, "anything.at_all", true},
+		{[]string{"user"}, "users.read", false},
+		{[]string{"user", "auditor"}, "users.read", true},
+		{nil, "users.read", false},
+		{[]string{"superadmin"}, "users", false},
+	}
+	for _, tt := range tests {
+		if got := p.Grants(tt.roles, tt.perm); got != tt.want {
+			t.Errorf("Grants(%v, %q) = %v, want %v", tt.roles, tt.perm, got, tt.want)
+		}
+	}
+}
+
+func TestPolicy_Permissions(t *testing.T) {
+	p := Policy{"admin": {"users.read", "users.write"}, "auditor": {"users.read"}}
+	got := p.Permissions([]string{"auditor", "admin"})
+	want := []string{"users.read", "users.write"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Permissions = %v, want %v", got, want)
+	}
+}
+
+func TestDefaultPolicy(t *testing.T) {
+	if err := DefaultPolicy.Validate(); err != nil {
+		t.Fatalf("DefaultPolicy.Validate: %v", err)
+	}
+	if !DefaultPolicy.Grants([]string{"auditor"}, "users.read") || DefaultPolicy.Grants([]string{"auditor"}, "users.write") {
+		t.Error("auditor must read but not write users")
+	}
+	if !DefaultPolicy.Grants([]string{"admin"}, "users.export") || DefaultPolicy.Grants([]string{"auditor"}, "users.export") {
+		t.Error("admin, not auditor, must export users")
+	}
+	if DefaultPolicy.Grants([]string{"admin"}, "users.impersonate") || !DefaultPolicy.Grants([]string{"superadmin"}, "users.impersonate") {
+		t.Error("only superadmin may impersonate")
+	}
+	if !DefaultPolicy.Grants([]string{"service"}, "tokens.introspect") || DefaultPolicy.Grants([]string{"admin"}, "tokens.introspect") {
+		t.Error("service, not admin, must introspect tokens")
+	}
+	if DefaultPolicy.Grants([]string{"service"}, "users.read") {
+		t.Error("service must not read users")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	p, err := Load(write("ok.json", `{"auditor": ["users.read", "payslips.*"]}`))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !p.Grants([]string{"auditor"}, "payslips.read") || p.Grants([]string{"admin"}, "users.read") {
+		t.Errorf("loaded policy %v must replace the default", p)
+	}
+
+	for name, body := range map[string]string{
+		"syntax.json": `{"auditor": "users.read"}`,
+		"grant.json":  `{"auditor": ["users"]}`,
+		"role.json":   `{"Not A Role": ["users.read"]}`,
+	} {
+		if _, err := Load(write(name, body)); !errors.Is(err, ErrInvalidPolicy) {
+			t.Errorf("%s: err = %v, want ErrInvalidPolicy", name, err)
+		}
+	}
+	if _, err := Load(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("missing file: want error")
+	}
+}
+
+func TestPolicy_Covers(t *testing.T) {
+	p := Policy{
+		"superadmin": {"*"},
+		"owner":      {"users.*"},
+		"admin":      {"users.read", "users.write"},
+		"auditor":    {"users.read"},
+	}
+	tests := []struct {
+		holder, roles []string
+		want          bool
+	}{
+		{[]string{"admin"}, []string{"auditor"}, true},
+		{[]string{"admin"}, []string{"admin", "user"}, true},
+		{[]string{"admin"}, []string{"user"}, true},
+		{[]string{"admin"}, nil, true},
+		{[]string{"admin"}, []string{"owner"}, false},
+		{[]string{"admin"}, []string{"superadmin"}, false},
+		{[]string{"auditor"}, []string{"admin"}, false},
+		{[]string{"owner"}, []string{"admin", "owner"}, true},
+		{[]string{"owner"}, []string{"superadmin"}, false},
+		{[]string{"superadmin"}, []string{"superadmin", "owner"}, true},
+	}
+	for _, tt := range tests {
+		if got := p.Covers(tt.holder, tt.roles); got != tt.want {
+			t.Errorf("Covers(%v, %v) = %v, want %v", tt.holder, tt.roles, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Package binding decodes request bodies into proto request messages.
+package binding
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"veemon/pkg/errors"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ProtoJSON decodes the JSON body into m with protojson, which accepts both
+// the camelCase json_name of a field and its proto name. Unknown fields are
+// ignored unless strict is set; then the body is rejected with a 400 whose
+// details list each unknown field by its dotted path. A body that is not
+// valid JSON for m is rejected with a plain 400.
+func ProtoJSON(body []byte, m proto.Message, strict bool) error {
+	if strict {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(body, &fields); err != nil {
+			return invalidBody()
+		}
+		if unknown := unknownFields(fields, m.ProtoReflect().Descriptor(), ""); len(unknown) > 0 {
+			return unknownFieldsError(unknown)
+		}
+	}
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(body, m); err != nil {
+		return invalidBody()
+	}
+	return nil
+}
+
+func invalidBody() error {
+	return errors.BadRequest(400, "invalid request body")
+}
+
+// unknownFields returns the paths, sorted, of the keys in fields that name no
+// field of desc, descending into nested messages and lists of them. Values
+// of well-known types and maps have their own JSON forms and are left to
+// protojson.
+func unknownFields(fields map[string]json.RawMessage, desc protoreflect.MessageDescriptor, prefix string) []string {
+	var unknown []string
+	for key, raw := range fields {
+		fd := desc.Fields().ByJSONName(key)
+		if fd == nil {
+			fd = desc.Fields().ByTextName(key)
+		}
+		if fd == nil {
+			unknown = append(unknown, prefix+key)
+			continue
+		}
+		if fd.Message() == nil || fd.IsMap() || strings.HasPrefix(string(fd.Message().FullName()), "google.protobuf.") {
+			continue
+		}
+		path := prefix + key
+		if fd.IsList() {
+			var items []map[string]json.RawMessage
+			if json.Unmarshal(raw, &items) != nil {
+				continue
+			}
+			for i, item := range items {
+				unknown = append(unknown, unknownFields(item, fd.Message(), fmt.Sprintf("%s[%d].", path, i))...)
+			}
+			continue
+		}
+		var nested map[string]json.RawMessage
+		if json.Unmarshal(raw, &nested) == nil {
+			unknown = append(unknown, unknownFields(nested, fd.Message(), path+".")...)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+func unknownFieldsError(unknown []string) error {
+	messages := make([]string, len(unknown))
+	violations := make([]errors.FieldViolation, len(unknown))
+	for i, field := range unknown {
+		messages[i] = field + " is not a known field"
+		violations[i] = errors.FieldViolation{Field: field, Description: messages[i]}
+	}
+	return errors.ValidationError(strings.Join(messages, "; ")).WithFields(violations...)
+}
@@ -0,0 +1,70 @@
+package binding_test
+
+import (
+	"net/http"
+	"testing"
+
+	pb "veemon/handler/grpc/user"
+	"veemon/pkg/binding"
+	"veemon/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProtoJSON_BindsJSONAndProtoNames(t *testing.T) {
+	for _, body := range []string{`{"name":"Jane","expectedUpdatedAt":"v1"}`, `{"name":"Jane","expected_updated_at":"v1"}`} {
+		for _, strict := range []bool{false, true} {
+			var req pb.UpdateUserReq
+			require.NoError(t, binding.ProtoJSON([]byte(body), &req, strict), body)
+			assert.Equal(t, "Jane", req.GetName())
+			assert.Equal(t, "v1", req.ExpectedUpdatedAt)
+		}
+	}
+}
+
+func TestProtoJSON_IgnoresUnknownFieldsUnlessStrict(t *testing.T) {
+	body := []byte(`{"email":"a@b.com","password":"x","isAdmin":true}`)
+
+	var req pb.LoginReq
+	require.NoError(t, binding.ProtoJSON(body, &req, false))
+	assert.Equal(t, "a@b.com", req.Email)
+
+	err := binding.ProtoJSON(body, &pb.LoginReq{}, true)
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, http.StatusBadRequest, appErr.HTTPStatus)
+	assert.Equal(t, 400, appErr.Code)
+	assert.Equal(t, "isAdmin is not a known field", appErr.Message)
+	assert.Equal(t, []errors.FieldViolation{{Field: "isAdmin", Description: "isAdmin is not a known field"}}, appErr.Fields)
+}
+
+func TestProtoJSON_StrictListsNestedUnknownFields(t *testing.T) {
+	err := binding.ProtoJSON([]byte(`{
+		"token": "t",
+		"user": {"id": "1", "role": "admin"},
+		"zeta": 1
+	}`), &pb.LoginRes{}, true)
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, []errors.FieldViolation{
+		{Field: "user.role", Description: "user.role is not a known field"},
+		{Field: "zeta", Description: "zeta is not a known field"},
+	}, appErr.Fields)
+
+	err = binding.ProtoJSON([]byte(`{"users":[{"id":"1"},{"id":"2","x":1}]}`), &pb.ListUsersRes{}, true)
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, "users[1].x", appErr.Fields[0].Field)
+}
+
+func TestProtoJSON_InvalidBody(t *testing.T) {
+	for _, body := range []string{`{"name":`, `[]`, `{"name":1}`} {
+		for _, strict := range []bool{false, true} {
+			err := binding.ProtoJSON([]byte(body), &pb.UpdateUserReq{}, strict)
+			var appErr *errors.AppError
+			require.ErrorAs(t, err, &appErr, "%s strict=%v", body, strict)
+			assert.Equal(t, "invalid request body", appErr.Message)
+			assert.Empty(t, appErr.Fields)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+// Package client is the Go SDK for this service's REST API. It unwraps the
+// response envelope into the contract's generated types, rebuilds API errors
+// as *errors.AppError, and injects (and on 401 refreshes) bearer tokens from a
+// TokenSource.
+//
+// The client is versioned with the API: APIVersion is the path version it
+// speaks, and its tests run against the real handlers so the two cannot
+// drift.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"veemon/pkg/errors"
+	"veemon/pkg/resilience"
+
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// APIVersion is the REST API version this client speaks.
+const APIVersion = "v1"
+
+// userAgent identifies SDK traffic in server logs.
+const userAgent = "veemon-go-client/" + APIVersion
+
+// Doer sends HTTP requests. *resilience.HTTPClient satisfies it.
+type Doer interface {
+	Do(ctx context.Context, req *http.Request) (*http.Response, error)
+}
+
+// HTTPDoer adapts a plain *http.Client to Doer, for callers that do their
+// own retries.
+func HTTPDoer(c *http.Client) Doer {
+	return httpDoer{c}
+}
+
+type httpDoer struct{ client *http.Client }
+
+func (d httpDoer) Do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	return d.client.Do(req.WithContext(ctx))
+}
+
+// Client calls the REST API. It is safe for concurrent use.
+type Client struct {
+	baseURL string
+	http    Doer
+	tokens  TokenSource
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient replaces the default resilient HTTP client. Note that
+// *resilience.HTTPClient reports a 5xx that outlasts its retries as a plain
+// error, so only other Doers surface those as *errors.AppError.
+func WithHTTPClient(d Doer) Option {
+	return func(c *Client) {
+		c.http = d
+	}
+}
+
+// WithTokenSource authenticates every request that needs it with tokens
+// from ts.
+func WithTokenSource(ts TokenSource) Option {
+	return func(c *Client) {
+		c.tokens = ts
+	}
+}
+
+// New creates a client for the API at baseURL (e.g. "https://api.example.com").
+// By default requests go through a resilience.HTTPClient with its default
+// retry and circuit-breaker settings.
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.http == nil {
+		c.http = resilience.NewHTTPClient("veemon-api", resilience.DefaultHTTPClientConfig(), zap.NewNop())
+	}
+	return c
+}
+
+// WithTokenSource returns a copy of c that authenticates with ts.
+func (c *Client) WithTokenSource(ts TokenSource) *Client {
+	clone := *c
+	clone.tokens = ts
+	return &clone
+}
+
+// call describes one API request.
+type call struct {
+	method string
+	path   string // below /api/<APIVersion>
+	query  url.Values
+	body   proto.Message
+	// token overrides the TokenSource; auth requests a token from it.
+	token string
+	auth  bool
+}
+
+var (
+	marshaler   = protojson.MarshalOptions{}
+	unmarshaler = protojson.UnmarshalOptions{DiscardUnknown: true}
+)
+
+// envelope is the success body every endpoint returns.
+type envelope struct {
+	Success bool            `json:"success"`
+	Data    json.RawMessage `json:"data"`
+	Meta    json.RawMessage `json:"meta"`
+}
+
+// do sends req and returns its envelope. An authenticated request rejected
+// with 401 is retried once with a token from the source's Refresh, if it has
+// one.
+func (c *Client) do(ctx context.Context, req call) (*envelope, error) {
+	var body []byte
+	if req.body != nil {
+		b, err := marshaler.Marshal(req.body)
+		if err != nil {
+			return nil, fmt.Errorf("client: encode request: %w", err)
+		}
+		body = b
+	}
+
+	tok := req.token
+	if tok == "" && req.auth {
+		if c.tokens == nil {
+			return nil, fmt.Errorf("client: %s %s requires a token source", req.method, req.path)
+		}
+		t, err := c.tokens.Token(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("client: get token: %w", err)
+		}
+		tok = t
+	}
+
+	status, respBody, err := c.send(ctx, req, body, tok)
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusUnauthorized && req.token == "" && req.auth {
+		if r, ok := c.tokens.(Refresher); ok {
+			if tok, err = r.Refresh(ctx, tok); err != nil {
+				return nil, fmt.Errorf("client: refresh token: %w", err)
+			}
+			if status, respBody, err = c.send(ctx, req, body, tok); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if status >= 300 {
+		if appErr, ok := errors.FromHTTPResponse(status, respBody); ok {
+			return nil, appErr
+		}
+		return nil, fmt.Errorf("client: %s %s: unexpected status %d", req.method, req.path, status)
+	}
+	var env envelope
+	if err := json.Unmarshal(respBody, &env); err != nil {
+		return nil, fmt.Errorf("client: decode response: %w", err)
+	}
+	return &env, nil
+}
+
+// send makes one attempt and returns the status and the whole body.
+func (c *Client) send(ctx context.Context, req call, body []byte, token string) (int, []byte, error) {
+	u := c.baseURL + "/api/" + APIVersion + req.path
+	if len(req.query) > 0 {
+		u += "?" + req.query.Encode()
+	}
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, u, r)
+	if err != nil {
+		return 0, nil, fmt.Errorf("client: build request: %w", err)
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("User-Agent", userAgent)
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.http.Do(ctx, httpReq)
+	if err != nil {
+		return 0, nil, fmt.Errorf("client: %s %s: %w", req.method, req.path, err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("client: read response: %w", err)
+	}
+	return resp.StatusCode, respBody, nil
+}
+
+// decode unmarshals an envelope's data into out.
+func decode(raw json.RawMessage, out proto.Message) error {
+	if err := unmarshaler.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("client: decode %T: %w", out, err)
+	}
+	return nil
+}
@@ -0,0 +1,258 @@
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	user "veemon/app/usecase/user"
+	"veemon/entity"
+	"veemon/handler"
+	pb "veemon/handler/grpc/user"
+	"veemon/pkg/client"
+	"veemon/pkg/errors"
+	"veemon/pkg/middleware"
+	"veemon/pkg/token"
+	"veemon/repository"
+	"veemon/repository/user_repository"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/protobuf/proto"
+	"gorm.io/gorm"
+)
+
+const secret = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+// memRepo keeps users in memory, implementing what the client's endpoints
+// need; every other method panics.
+type memRepo struct {
+	user_repository.Repository
+	mu    sync.Mutex
+	users map[string]*entity.User
+}
+
+func (r *memRepo) find(match func(*entity.User) bool) (*entity.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if !u.DeletedAt.Valid && match(u) {
+			cp := *u
+			return &cp, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memRepo) Create(_ context.Context, u *entity.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u.ID = uuid.NewString()
+	u.Roles = []string{"user"}
+	u.CreatedAt = time.Now()
+	cp := *u
+	r.users[u.ID] = &cp
+	return nil
+}
+
+func (r *memRepo) FindByID(_ context.Context, id string) (*entity.User, error) {
+	return r.find(func(u *entity.User) bool { return u.ID == id })
+}
+
+func (r *memRepo) FindByEmail(_ context.Context, email string) (*entity.User, error) {
+	return r.find(func(u *entity.User) bool { return u.Email == email })
+}
+
+func (r *memRepo) FindAll(_ context.Context, p user_repository.ListParams) ([]entity.UserSummary, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var all []entity.UserSummary
+	for _, u := range r.users {
+		if !u.DeletedAt.Valid && strings.Contains(u.Name+u.Email, p.Search) {
+			all = append(all, u.Summary())
+		}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Email < all[j].Email })
+	total := int64(len(all))
+	start := min((p.Page-1)*p.Size, len(all))
+	return all[start:min(start+p.Size, len(all))], total, nil
+}
+
+func (r *memRepo) UpdateFields(ctx context.Context, id string, fields map[string]interface{}, _ *time.Time) (*entity.User, error) {
+	r.mu.Lock()
+	u, ok := r.users[id]
+	if ok {
+		for k, v := range fields {
+			switch k {
+			case "name":
+				u.Name = fmt.Sprint(v)
+			case "phone":
+				u.Phone = fmt.Sprint(v)
+			case "status":
+				u.Status = entity.UserStatus(fmt.Sprint(v))
+			}
+		}
+	}
+	r.mu.Unlock()
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return r.FindByID(ctx, id)
+}
+
+func (r *memRepo) Delete(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if u, ok := r.users[id]; ok {
+		u.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	}
+	return nil
+}
+
+// fiberDoer sends requests straight into app, in process.
+type fiberDoer struct{ app *fiber.App }
+
+func (d fiberDoer) Do(_ context.Context, req *http.Request) (*http.Response, error) {
+	return d.app.Test(req, -1)
+}
+
+// newServer runs the real REST routes and handler over repo, seeded with an
+// admin, and returns a client for them.
+func newServer(t *testing.T) (*client.Client, *memRepo) {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte("AdminPass123"), bcrypt.MinCost)
+	require.NoError(t, err)
+	repo := &memRepo{users: map[string]*entity.User{}}
+	repo.users["admin"] = &entity.User{
+		ID: uuid.NewString(), Email: "admin@example.com", Password: string(hash), Name: "Admin",
+		Status: entity.UserStatusActive, Roles: []string{"admin"}, CreatedAt: time.Now(),
+	}
+
+	ts, err := token.NewTokenService(secret, 1)
+	require.NoError(t, err)
+	validator := func(tok string) (*middleware.AuthContext, error) {
+		claims, err := ts.ValidateToken(tok)
+		if err != nil {
+			return nil, err
+		}
+		return &middleware.AuthContext{UserID: claims.UserID, Email: claims.Email, Roles: claims.Roles, Token: tok}, nil
+	}
+
+	app := fiber.New()
+	pb.RegisterUserApiRoutes(app, handler.NewUserHandler(user.NewUseCase(repo), ts, nil, nil), validator)
+	return client.New("http://api.test", client.WithHTTPClient(fiberDoer{app})), repo
+}
+
+func TestClient_UserLifecycle(t *testing.T) {
+	c, _ := newServer(t)
+	ctx := context.Background()
+
+	reg, err := c.Register(ctx, &pb.RegisterReq{Email: "jane@example.com", Password: "Password123", Name: "Jane", Phone: "081234567890"})
+	require.NoError(t, err)
+	assert.Equal(t, "jane@example.com", reg.Email)
+	assert.NotEmpty(t, reg.Id)
+
+	login, err := c.Login(ctx, "jane@example.com", "Password123")
+	require.NoError(t, err)
+	require.NotEmpty(t, login.Token)
+	assert.Equal(t, reg.Id, login.User.Id)
+
+	me, err := c.WithTokenSource(client.StaticTokenSource(login.Token)).GetMe(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "Jane", me.Name)
+	assert.Equal(t, "active", me.Status)
+
+	admin := c.WithTokenSource(client.NewLoginTokenSource(c, "admin@example.com", "AdminPass123"))
+
+	list, err := admin.ListUsers(ctx, client.WithPage(1, 1), client.WithSearch("example.com"))
+	require.NoError(t, err)
+	require.Len(t, list.Users, 1)
+	assert.Equal(t, "admin@example.com", list.Users[0].Email)
+	assert.EqualValues(t, 2, list.Pagination.Total)
+	assert.EqualValues(t, 2, list.Pagination.TotalPages)
+
+	updated, err := admin.UpdateUser(ctx, reg.Id, &pb.UpdateUserReq{Name: proto.String("Jane Doe"), Status: proto.String("inactive")})
+	require.NoError(t, err)
+	assert.Equal(t, "Jane Doe", updated.Name)
+	assert.Equal(t, "inactive", updated.Status)
+	assert.Equal(t, "081234567890", updated.Phone)
+
+	deleted, err := admin.DeleteUser(ctx, reg.Id)
+	require.NoError(t, err)
+	assert.NotEmpty(t, deleted.Message)
+
+	_, err = admin.DeleteUser(ctx, reg.Id)
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, http.StatusNotFound, appErr.HTTPStatus)
+}
+
+func TestClient_RebuildsAppErrors(t *testing.T) {
+	c, _ := newServer(t)
+	ctx := context.Background()
+
+	_, err := c.Register(ctx, &pb.RegisterReq{Email: "admin@example.com", Password: "Password123", Name: "Dup"})
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, http.StatusConflict, appErr.HTTPStatus)
+	assert.Equal(t, 40901, appErr.Code)
+
+	_, err = c.Register(ctx, &pb.RegisterReq{Email: "not-an-email", Password: "Password123", Name: "Bad"})
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, 400, appErr.Code)
+	require.NotEmpty(t, appErr.Fields, "validation failures carry per-field details")
+	assert.Equal(t, "email", appErr.Fields[0].Field)
+
+	_, err = c.WithTokenSource(client.StaticTokenSource("v4.local.bogus")).GetMe(ctx)
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, http.StatusUnauthorized, appErr.HTTPStatus)
+
+	_, err = c.GetMe(ctx)
+	assert.ErrorContains(t, err, "requires a token source")
+}
+
+// staleTokenSource starts with a token the server rejects, then refreshes
+// to a valid one.
+type staleTokenSource struct {
+	*client.LoginTokenSource
+	refreshed []string
+}
+
+func (s *staleTokenSource) Token(context.Context) (string, error) { return "v4.local.stale", nil }
+
+func (s *staleTokenSource) Refresh(ctx context.Context, rejected string) (string, error) {
+	s.refreshed = append(s.refreshed, rejected)
+	return s.LoginTokenSource.Refresh(ctx, rejected)
+}
+
+func TestClient_RefreshesRejectedToken(t *testing.T) {
+	c, _ := newServer(t)
+	src := &staleTokenSource{LoginTokenSource: client.NewLoginTokenSource(c, "admin@example.com", "AdminPass123")}
+
+	me, err := c.WithTokenSource(src).GetMe(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, "admin@example.com", me.Email)
+	assert.Equal(t, []string{"v4.local.stale"}, src.refreshed)
+}
+
+func TestClient_RefreshToken(t *testing.T) {
+	c, _ := newServer(t)
+	ctx := context.Background()
+	login, err := c.Login(ctx, "admin@example.com", "AdminPass123")
+	require.NoError(t, err)
+
+	res, err := c.RefreshToken(ctx, login.Token)
+
+	require.NoError(t, err)
+	assert.NotEmpty(t, res.Token)
+	assert.NotEqual(t, login.Token, res.Token)
+}
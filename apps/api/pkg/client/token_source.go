@@ -0,0 +1,73 @@
+package client
+
+import (
+	"context"
+	"sync"
+)
+
+// TokenSource supplies the bearer token for authenticated requests.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// Refresher is a TokenSource that can replace a token the API rejected.
+// Refresh receives the rejected token so concurrent callers that hit the same
+// 401 trigger one refresh, not one each.
+type Refresher interface {
+	TokenSource
+	Refresh(ctx context.Context, rejected string) (string, error)
+}
+
+// StaticTokenSource always returns the same token and cannot refresh it.
+type StaticTokenSource string
+
+// Token returns the token.
+func (s StaticTokenSource) Token(context.Context) (string, error) {
+	return string(s), nil
+}
+
+// LoginTokenSource logs in with fixed credentials on first use and again
+// whenever the API rejects its token (expiry, revocation). It suits service
+// accounts; interactive apps should hold the user's token instead.
+type LoginTokenSource struct {
+	client          *Client
+	email, password string
+
+	mu    sync.Mutex
+	token string
+}
+
+// NewLoginTokenSource returns a source that logs in through c.
+func NewLoginTokenSource(c *Client, email, password string) *LoginTokenSource {
+	return &LoginTokenSource{client: c, email: email, password: password}
+}
+
+// Token returns the current token, logging in if there is none.
+func (s *LoginTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token == "" {
+		return s.login(ctx)
+	}
+	return s.token, nil
+}
+
+// Refresh logs in again, unless another caller already replaced rejected.
+func (s *LoginTokenSource) Refresh(ctx context.Context, rejected string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && s.token != rejected {
+		return s.token, nil
+	}
+	return s.login(ctx)
+}
+
+// login must be called with s.mu held.
+func (s *LoginTokenSource) login(ctx context.Context) (string, error) {
+	res, err := s.client.Login(ctx, s.email, s.password)
+	if err != nil {
+		return "", err
+	}
+	s.token = res.GetToken()
+	return s.token, nil
+}
@@ -0,0 +1,159 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	pb "veemon/handler/grpc/user"
+)
+
+// Register creates an account. It needs no token.
+func (c *Client) Register(ctx context.Context, req *pb.RegisterReq) (*pb.RegisterRes, error) {
+	env, err := c.do(ctx, call{method: http.MethodPost, path: "/auth/register", body: req})
+	if err != nil {
+		return nil, err
+	}
+	res := &pb.RegisterRes{}
+	return res, decode(env.Data, res)
+}
+
+// Login exchanges credentials for a token. It needs no token; to have the
+// client use the result, see LoginTokenSource.
+func (c *Client) Login(ctx context.Context, email, password string) (*pb.LoginRes, error) {
+	env, err := c.do(ctx, call{
+		method: http.MethodPost,
+		path:   "/auth/login",
+		body:   &pb.LoginReq{Email: email, Password: password},
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := &pb.LoginRes{}
+	return res, decode(env.Data, res)
+}
+
+// RefreshToken exchanges token, which must still be valid, for a new one and
+// revokes it.
+func (c *Client) RefreshToken(ctx context.Context, token string) (*pb.RefreshTokenRes, error) {
+	env, err := c.do(ctx, call{method: http.MethodPost, path: "/auth/refresh", token: token, auth: true})
+	if err != nil {
+		return nil, err
+	}
+	res := &pb.RefreshTokenRes{}
+	return res, decode(env.Data, res)
+}
+
+// GetMe returns the profile of the token's user.
+func (c *Client) GetMe(ctx context.Context) (*pb.UserProfile, error) {
+	env, err := c.do(ctx, call{method: http.MethodGet, path: "/auth/me", auth: true})
+	if err != nil {
+		return nil, err
+	}
+	res := &pb.UserProfile{}
+	return res, decode(env.Data, res)
+}
+
+// ListOption sets a ListUsers filter.
+type ListOption func(url.Values)
+
+// WithPage selects an offset page (1-based) of size users.
+func WithPage(page, size int) ListOption {
+	return func(q url.Values) {
+		q.Set("page", strconv.Itoa(page))
+		q.Set("size", strconv.Itoa(size))
+	}
+}
+
+// WithSize sets the page size without a page number, e.g. with WithCursor.
+func WithSize(size int) ListOption {
+	return func(q url.Values) {
+		q.Set("size", strconv.Itoa(size))
+	}
+}
+
+// WithSearch filters by a name or email substring.
+func WithSearch(search string) ListOption {
+	return func(q url.Values) {
+		q.Set("search", search)
+	}
+}
+
+// WithSort orders by column ("created_at", "name", ...) in order ("asc" or
+// "desc").
+func WithSort(column, order string) ListOption {
+	return func(q url.Values) {
+		q.Set("sortBy", column)
+		q.Set("sortOrder", order)
+	}
+}
+
+// WithFields returns only the named UserProfile fields.
+func WithFields(fields ...string) ListOption {
+	return func(q url.Values) {
+		q.Set("fields", strings.Join(fields, ","))
+	}
+}
+
+// WithCursor continues after a previous page's Pagination.NextCursor.
+func WithCursor(cursor string) ListOption {
+	return func(q url.Values) {
+		q.Set("cursor", cursor)
+	}
+}
+
+// ListUsers returns one page of users and its pagination. Requires an admin
+// token.
+func (c *Client) ListUsers(ctx context.Context, opts ...ListOption) (*pb.ListUsersRes, error) {
+	q := url.Values{}
+	for _, opt := range opts {
+		opt(q)
+	}
+	env, err := c.do(ctx, call{method: http.MethodGet, path: "/users", query: q, auth: true})
+	if err != nil {
+		return nil, err
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(env.Data, &items); err != nil {
+		return nil, fmt.Errorf("client: decode users: %w", err)
+	}
+	res := &pb.ListUsersRes{Users: make([]*pb.UserProfile, len(items)), Pagination: &pb.Pagination{}}
+	for i, raw := range items {
+		res.Users[i] = &pb.UserProfile{}
+		if err := decode(raw, res.Users[i]); err != nil {
+			return nil, err
+		}
+	}
+	if len(env.Meta) > 0 {
+		if err := decode(env.Meta, res.Pagination); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// UpdateUser changes the name, phone and status of user id; empty fields
+// are left unchanged. Requires an admin token.
+func (c *Client) UpdateUser(ctx context.Context, id string, req *pb.UpdateUserReq) (*pb.UserProfile, error) {
+	env, err := c.do(ctx, call{method: http.MethodPut, path: "/users/" + url.PathEscape(id), body: req, auth: true})
+	if err != nil {
+		return nil, err
+	}
+	res := &pb.UserProfile{}
+	return res, decode(env.Data, res)
+}
+
+// DeleteUser soft-deletes user id. Requires an admin token.
+func (c *Client) DeleteUser(ctx context.Context, id string) (*pb.DeleteUserRes, error) {
+	env, err := c.do(ctx, call{method: http.MethodDelete, path: "/users/" + url.PathEscape(id), auth: true})
+	if err != nil {
+		return nil, err
+	}
+	res := &pb.DeleteUserRes{}
+	return res, decode(env.Data, res)
+}
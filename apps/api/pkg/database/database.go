@@ -3,15 +3,20 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"veemon/entity"
+	"veemon/pkg/database/metricsplugin"
+	pkglogger "veemon/pkg/logger"
 
 	"go.uber.org/zap"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
 	"gorm.io/plugin/opentelemetry/tracing"
 )
 
@@ -19,16 +24,40 @@ type zapGormLogger struct {
 	logger        *zap.Logger
 	slowThreshold time.Duration
 	level         logger.LogLevel
+
+	redaction        SQLRedaction
+	sensitiveColumns []string
+	sampleRate       int
+	// sampleCounter is shared by LogMode clones so sampling stays 1 in N
+	// across sessions.
+	sampleCounter *atomic.Uint64
 }
 
-// newZapGormLogger defaults to Warn: successful queries (whose interpolated SQL
-// can contain sensitive values such as password hashes) are not logged, only
-// slow queries and errors. Raise the level via LogMode for debugging.
-func newZapGormLogger(zapLogger *zap.Logger) *zapGormLogger {
+// newZapGormLogger defaults to Warn: successful queries are not logged, only
+// slow queries and errors. cfg.LogQueries raises it to Info, logging every
+// query at debug level (sampled by cfg.QueryLogSampleRate). Bound values are
+// masked per cfg.QueryLogRedaction in every entry.
+func newZapGormLogger(zapLogger *zap.Logger, cfg Config) *zapGormLogger {
+	level := logger.Warn
+	if cfg.LogQueries {
+		level = logger.Info
+	}
+	redaction := cfg.QueryLogRedaction
+	if redaction == "" {
+		redaction = RedactAll
+	}
+	sensitive := cfg.QueryLogSensitiveColumns
+	if len(sensitive) == 0 {
+		sensitive = DefaultSensitiveColumns
+	}
 	return &zapGormLogger{
-		logger:        zapLogger,
-		slowThreshold: 200 * time.Millisecond,
-		level:         logger.Warn,
+		logger:           zapLogger,
+		slowThreshold:    200 * time.Millisecond,
+		level:            level,
+		redaction:        redaction,
+		sensitiveColumns: sensitive,
+		sampleRate:       cfg.QueryLogSampleRate,
+		sampleCounter:    new(atomic.Uint64),
 	}
 }
 
@@ -69,13 +98,17 @@ func (l *zapGormLogger) Trace(ctx context.Context, begin time.Time, fc func() (s
 		zap.Int64("rows", rows),
 		zap.String("sql", sql),
 	}
+	if requestID := pkglogger.RequestIDFromContext(ctx); requestID != "" {
+		fields = append(fields, zap.String("request_id", requestID))
+	}
 
+	// Errors and slow queries are never sampled.
 	switch {
 	case err != nil && l.level >= logger.Error:
 		l.logger.Error("gorm query error", append(fields, zap.Error(err))...)
 	case elapsed > l.slowThreshold && l.level >= logger.Warn:
 		l.logger.Warn("gorm slow query", fields...)
-	case l.level >= logger.Info:
+	case l.level >= logger.Info && l.sampled():
 		l.logger.Debug("gorm query", fields...)
 	}
 }
@@ -83,25 +116,134 @@ func (l *zapGormLogger) Trace(ctx context.Context, begin time.Time, fc func() (s
 // Config holds database connection parameters.
 // Supports passwordless authentication when Password is empty (e.g., peer authentication).
 type Config struct {
-	Host     string
-	Port     int
-	User     string
-	Password string // Optional: leave empty for passwordless auth (e.g., peer, trust, IAM)
-	Name     string
-	SSLMode  string
-	Timezone string
+	Host     string `mapstructure:"DB_HOST"`
+	Port     int    `mapstructure:"DB_PORT"`
+	User     string `mapstructure:"DB_USER"`
+	Password string `mapstructure:"DB_PASSWORD"` // Optional: leave empty for passwordless auth (e.g., peer, trust, IAM)
+	Name     string `mapstructure:"DB_NAME"`
+	SSLMode  string `mapstructure:"DB_SSL_MODE"`
+	Timezone string `mapstructure:"DB_TIMEZONE"`
 
 	// Performance Settings
-	PrepareStmt            bool // Enable prepared statement cache (recommended: true)
-	SkipDefaultTransaction bool // Disable default transactions for write operations (use with caution)
+	PrepareStmt            bool `mapstructure:"DB_PREPARE_STMT"`             // Enable prepared statement cache (recommended: true)
+	SkipDefaultTransaction bool `mapstructure:"DB_SKIP_DEFAULT_TRANSACTION"` // Disable default transactions for write operations (use with caution)
+
+	// PoolerMode describes a connection pooler in front of Postgres (default
+	// PoolerNone). PoolerTransaction overrides PrepareStmt; see
+	// resolveConnSettings.
+	PoolerMode PoolerMode `mapstructure:"DB_POOLER_MODE"`
 
 	// Connection pool (zero values fall back to sensible defaults)
-	MaxIdleConns    int
-	MaxOpenConns    int
-	ConnMaxLifetime time.Duration
+	MaxIdleConns    int           `mapstructure:"DB_MAX_IDLE_CONNS"`
+	MaxOpenConns    int           `mapstructure:"DB_MAX_OPEN_CONNS"`
+	ConnMaxLifetime time.Duration `mapstructure:"DB_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime time.Duration `mapstructure:"DB_CONN_MAX_IDLE_TIME"`
+
+	// ReplicaHosts are read replicas ("host" or "host:port", Port by
+	// default) sharing the primary's credentials and database; reads are
+	// spread over them (see Replicas).
+	ReplicaHosts []string `mapstructure:"DB_REPLICA_HOSTS"`
+
+	// Table naming (see NamingStrategy)
+	TablePrefix   string `mapstructure:"DB_TABLE_PREFIX"`
+	SingularTable bool   `mapstructure:"DB_SINGULAR_TABLE"`
+
+	// Query logging. LogQueries logs every statement at debug level, keeping
+	// one in QueryLogSampleRate (0 or 1 keeps all). QueryLogRedaction
+	// (default RedactAll) controls which bound values are masked;
+	// QueryLogSensitiveColumns applies to RedactSensitive.
+	LogQueries               bool         `mapstructure:"DB_LOG_QUERIES"`
+	QueryLogSampleRate       int          `mapstructure:"DB_LOG_QUERY_SAMPLE_RATE"`
+	QueryLogRedaction        SQLRedaction `mapstructure:"DB_LOG_REDACTION"`
+	QueryLogSensitiveColumns []string     `mapstructure:"DB_LOG_SENSITIVE_COLUMNS"`
+}
+
+// NamingStrategy maps models to table names: an optional prefix (e.g.
+// "grst_" for grst_users) and singular instead of plural names. Models must
+// not define TableName, which would bypass it.
+func NamingStrategy(prefix string, singular bool) schema.NamingStrategy {
+	return schema.NamingStrategy{
+		TablePrefix:   prefix,
+		SingularTable: singular,
+	}
 }
 
 func New(cfg Config, zapLogger *zap.Logger) (*gorm.DB, error) {
+	conn, err := resolveConnSettings(cfg.PoolerMode, cfg.PrepareStmt)
+	if err != nil {
+		return nil, err
+	}
+	if conn.warning != "" {
+		zapLogger.Warn(conn.warning, zap.String("pooler_mode", string(cfg.PoolerMode)))
+	}
+
+	// Configure GORM with performance optimizations
+	gormConfig := &gorm.Config{
+		Logger:                 newZapGormLogger(zapLogger, cfg),
+		PrepareStmt:            conn.prepareStmt,           // (PERF) Cache prepared statements
+		SkipDefaultTransaction: cfg.SkipDefaultTransaction, // (PERF) Skip transactions for better performance
+		// Translate driver errors to GORM sentinels (e.g. unique violations to
+		// gorm.ErrDuplicatedKey) so callers can handle them driver-agnostically.
+		TranslateError: true,
+		NamingStrategy: NamingStrategy(cfg.TablePrefix, cfg.SingularTable),
+	}
+
+	db, err := open(cfg, conn, gormConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	// Add OpenTelemetry tracing plugin
+	if err := db.Use(tracing.NewPlugin()); err != nil {
+		return nil, fmt.Errorf("failed to add tracing plugin: %w", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database instance: %w", err)
+	}
+	pool := newPoolSettings(cfg)
+	pool.apply(sqlDB)
+
+	if len(cfg.ReplicaHosts) > 0 {
+		replicas, err := openReplicas(cfg, conn, pool, zapLogger)
+		if err != nil {
+			_ = sqlDB.Close()
+			return nil, err
+		}
+		if err := db.Use(replicas); err != nil {
+			_ = sqlDB.Close()
+			_ = replicas.Close()
+			return nil, fmt.Errorf("failed to add replicas plugin: %w", err)
+		}
+		if err := replicas.Check(context.Background()); err != nil {
+			zapLogger.Warn("Read replicas unreachable at startup; their reads go to the primary", zap.Error(err))
+		}
+		replicas.startChecks(ReplicaCheckInterval)
+	}
+
+	zapLogger.Info("Database connection established",
+		zap.String("host", cfg.Host),
+		zap.Int("port", cfg.Port),
+		zap.String("database", cfg.Name),
+		zap.String("pooler_mode", string(cfg.PoolerMode)),
+		zap.Strings("replicas", cfg.ReplicaHosts),
+		zap.Int("max_open_conns", pool.maxOpen),
+		zap.Int("max_idle_conns", pool.maxIdle),
+		zap.Duration("conn_max_lifetime", pool.connMaxLifetime),
+		zap.Duration("conn_max_idle_time", pool.connMaxIdleTime),
+		zap.Bool("prepare_stmt", conn.prepareStmt),
+		zap.Bool("simple_protocol", conn.simpleProtocol),
+		zap.Bool("skip_default_transaction", cfg.SkipDefaultTransaction),
+		zap.String("table_prefix", cfg.TablePrefix),
+		zap.Bool("singular_table", cfg.SingularTable),
+	)
+
+	return db, nil
+}
+
+// open connects to the Postgres server of cfg.Host and cfg.Port.
+func open(cfg Config, conn connSettings, gormConfig *gorm.Config) (*gorm.DB, error) {
 	// Build DSN with optional password support (for passwordless auth)
 	dsn := fmt.Sprintf(
 		"host=%s port=%d user=%s dbname=%s sslmode=%s TimeZone=%s",
@@ -127,63 +269,101 @@ func New(cfg Config, zapLogger *zap.Logger) (*gorm.DB, error) {
 		)
 	}
 
-	// Configure GORM with performance optimizations
-	gormConfig := &gorm.Config{
-		Logger:                 newZapGormLogger(zapLogger),
-		PrepareStmt:            cfg.PrepareStmt,            // (PERF) Cache prepared statements
-		SkipDefaultTransaction: cfg.SkipDefaultTransaction, // (PERF) Skip transactions for better performance
-		// Translate driver errors to GORM sentinels (e.g. unique violations to
-		// gorm.ErrDuplicatedKey) so callers can handle them driver-agnostically.
-		TranslateError: true,
-	}
+	return gorm.Open(postgres.New(postgres.Config{
+		DSN:                  dsn,
+		PreferSimpleProtocol: conn.simpleProtocol,
+	}), gormConfig)
+}
 
-	db, err := gorm.Open(postgres.Open(dsn), gormConfig)
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
-	}
+// poolSettings are the connection pool limits, shared by the primary and
+// the replicas.
+type poolSettings struct {
+	maxIdle, maxOpen                 int
+	connMaxLifetime, connMaxIdleTime time.Duration
+}
 
-	// Add OpenTelemetry tracing plugin
-	if err := db.Use(tracing.NewPlugin()); err != nil {
-		return nil, fmt.Errorf("failed to add tracing plugin: %w", err)
+// newPoolSettings applies the defaults to cfg's pool settings.
+func newPoolSettings(cfg Config) poolSettings {
+	p := poolSettings{
+		maxIdle:         cfg.MaxIdleConns,
+		maxOpen:         cfg.MaxOpenConns,
+		connMaxLifetime: cfg.ConnMaxLifetime,
+		connMaxIdleTime: cfg.ConnMaxIdleTime,
 	}
-
-	sqlDB, err := db.DB()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get database instance: %w", err)
+	if p.maxIdle <= 0 {
+		p.maxIdle = 10
 	}
-
-	// Connection pool settings (configurable, with defaults)
-	maxIdle := cfg.MaxIdleConns
-	if maxIdle <= 0 {
-		maxIdle = 10
+	if p.maxOpen <= 0 {
+		p.maxOpen = 100
 	}
-	maxOpen := cfg.MaxOpenConns
-	if maxOpen <= 0 {
-		maxOpen = 100
+	if p.connMaxLifetime <= 0 {
+		p.connMaxLifetime = time.Hour
 	}
-	connMaxLifetime := cfg.ConnMaxLifetime
-	if connMaxLifetime <= 0 {
-		connMaxLifetime = time.Hour
+	if p.connMaxIdleTime <= 0 {
+		p.connMaxIdleTime = 10 * time.Minute
 	}
-	sqlDB.SetMaxIdleConns(maxIdle)
-	sqlDB.SetMaxOpenConns(maxOpen)
-	sqlDB.SetConnMaxLifetime(connMaxLifetime)
+	return p
+}
 
-	zapLogger.Info("Database connection established",
-		zap.String("host", cfg.Host),
-		zap.Int("port", cfg.Port),
-		zap.String("database", cfg.Name),
-		zap.Bool("prepare_stmt", cfg.PrepareStmt),
-		zap.Bool("skip_default_transaction", cfg.SkipDefaultTransaction),
-	)
+func (p poolSettings) apply(sqlDB *sql.DB) {
+	sqlDB.SetMaxIdleConns(p.maxIdle)
+	sqlDB.SetMaxOpenConns(p.maxOpen)
+	sqlDB.SetConnMaxLifetime(p.connMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(p.connMaxIdleTime)
+}
 
-	return db, nil
+// EnableMetrics records every statement on db (and sessions derived from it)
+// to rec via metricsplugin. A nil rec is a no-op, as is a repeat call.
+func EnableMetrics(db *gorm.DB, rec metricsplugin.Recorder) error {
+	if rec == nil {
+		return nil
+	}
+	if err := db.Use(metricsplugin.New(rec)); err != nil && err != gorm.ErrRegistered {
+		return fmt.Errorf("failed to add metrics plugin: %w", err)
+	}
+	return nil
 }
 
-func AutoMigrate(db *gorm.DB) error {
-	return db.AutoMigrate(
+// models are the entities AutoMigrate manages.
+func models() []any {
+	return []any{
 		&entity.User{},
-	)
+		&entity.AuditLog{},
+		&entity.OutboxMessage{},
+		&entity.SeedHistory{},
+	}
+}
+
+func AutoMigrate(db *gorm.DB) error {
+	return db.AutoMigrate(models()...)
+}
+
+// AutoMigratePlan lists what AutoMigrate would add to db: tables that do not
+// exist and columns missing from the ones that do, e.g. "create table users"
+// or "add column users.phone". Type and index changes are not listed.
+func AutoMigratePlan(db *gorm.DB) ([]string, error) {
+	var plan []string
+	m := db.Migrator()
+	for _, model := range models() {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, err
+		}
+		table := stmt.Schema.Table
+		if !m.HasTable(model) {
+			plan = append(plan, "create table "+table)
+			continue
+		}
+		for _, f := range stmt.Schema.Fields {
+			if f.DBName == "" || f.IgnoreMigration {
+				continue
+			}
+			if !m.HasColumn(model, f.DBName) {
+				plan = append(plan, "add column "+table+"."+f.DBName)
+			}
+		}
+	}
+	return plan, nil
 }
 
 // WithContext returns a new DB with context for tracing
@@ -194,7 +374,9 @@ func WithContext(db *gorm.DB, ctx context.Context) *gorm.DB {
 // Performance Helper Functions
 
 // WithPreparedStmt creates a session with prepared statement enabled (performance boost)
-// Use this for repeated queries with different parameters
+// Use this for repeated queries with different parameters. Not behind a
+// pooler in transaction mode, where it brings back the errors PoolerMode
+// avoids.
 //
 // Example:
 //
@@ -0,0 +1,147 @@
+// Package metricsplugin is a GORM plugin that records the count, duration and
+// failures of every statement GORM runs.
+package metricsplugin
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Recorder receives query metrics. *metrics.Metrics satisfies it.
+type Recorder interface {
+	RecordDBQuery(operation, table string, duration time.Duration)
+	RecordDBQueryError(operation, table, code string)
+	RecordDBPreparedStmtError(code string)
+}
+
+const startKey = "metricsplugin:start"
+
+// registrar is a position in a GORM callback chain.
+type registrar interface {
+	Register(name string, fn func(*gorm.DB)) error
+}
+
+type plugin struct {
+	rec Recorder
+}
+
+// New returns the plugin; install it with db.Use.
+func New(rec Recorder) gorm.Plugin {
+	return &plugin{rec: rec}
+}
+
+func (p *plugin) Name() string { return "metrics" }
+
+// Initialize wraps the statement-executing callback of each GORM processor,
+// so each statement is counted once however many plugins or loggers observe
+// it. The logger's Trace runs after the whole chain and the tracing plugin
+// only opens and closes spans; neither is part of the measured interval.
+func (p *plugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	processors := []struct {
+		operation     string
+		before, after registrar
+	}{
+		{"create", cb.Create().Before("gorm:create"), cb.Create().After("gorm:create")},
+		{"query", cb.Query().Before("gorm:query"), cb.Query().After("gorm:query")},
+		{"update", cb.Update().Before("gorm:update"), cb.Update().After("gorm:update")},
+		{"delete", cb.Delete().Before("gorm:delete"), cb.Delete().After("gorm:delete")},
+		{"row", cb.Row().Before("gorm:row"), cb.Row().After("gorm:row")},
+		{"raw", cb.Raw().Before("gorm:raw"), cb.Raw().After("gorm:raw")},
+	}
+	for _, pr := range processors {
+		if err := pr.before.Register("metrics:before_"+pr.operation, before); err != nil {
+			return err
+		}
+		if err := pr.after.Register("metrics:after_"+pr.operation, p.after(pr.operation)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func before(db *gorm.DB) {
+	db.InstanceSet(startKey, time.Now())
+}
+
+func (p *plugin) after(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(startKey)
+		if !ok {
+			return
+		}
+		start, ok := v.(time.Time)
+		if !ok {
+			return
+		}
+
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		p.rec.RecordDBQuery(operation, table, time.Since(start))
+		if code := errorCode(db.Error); code != "" {
+			p.rec.RecordDBQueryError(operation, table, code)
+			if isPreparedStmtError(code, db.Error) {
+				p.rec.RecordDBPreparedStmtError(code)
+			}
+		}
+	}
+}
+
+// sqlStateError is implemented by driver errors that carry a SQLSTATE, such
+// as *pgconn.PgError.
+type sqlStateError interface {
+	SQLState() string
+}
+
+// errorCode labels err for db_query_errors_total: the SQLSTATE when the
+// driver reports one, the equivalent code for errors TranslateError already
+// mapped to GORM sentinels, or a coarse class otherwise. A missing record is
+// a normal outcome, not a failure, and returns "".
+func errorCode(err error) string {
+	if err == nil || errors.Is(err, gorm.ErrRecordNotFound) {
+		return ""
+	}
+	var state sqlStateError
+	if errors.As(err, &state) {
+		return state.SQLState()
+	}
+	switch {
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return "23505"
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		return "23503"
+	case errors.Is(err, gorm.ErrCheckConstraintViolated):
+		return "23514"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	}
+	return "unknown"
+}
+
+// SQLSTATEs of prepared statement mix-ups.
+const (
+	codeDuplicatePreparedStatement = "42P05" // "prepared statement ... already exists"
+	codeInvalidStatementName       = "26000" // "prepared statement ... does not exist"
+)
+
+// isPreparedStmtError reports whether a query failed because its prepared
+// statement was missing from, or already on, the server connection — what a
+// pooler in transaction mode causes when statements are prepared. Some paths
+// surface the server message without a SQLSTATE, so the text is checked too.
+func isPreparedStmtError(code string, err error) bool {
+	switch code {
+	case codeDuplicatePreparedStatement, codeInvalidStatementName:
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "prepared statement") &&
+		(strings.Contains(msg, "already exists") || strings.Contains(msg, "does not exist"))
+}
@@ -0,0 +1,183 @@
+package metricsplugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/plugin/opentelemetry/tracing"
+)
+
+type widget struct {
+	ID   uint
+	Name string
+}
+
+type call struct {
+	operation, table, code string
+}
+
+type fakeRecorder struct {
+	queries  []call
+	errors   []call
+	prepared []string
+}
+
+func (r *fakeRecorder) RecordDBQuery(operation, table string, duration time.Duration) {
+	r.queries = append(r.queries, call{operation: operation, table: table})
+}
+
+func (r *fakeRecorder) RecordDBQueryError(operation, table, code string) {
+	r.errors = append(r.errors, call{operation, table, code})
+}
+
+func (r *fakeRecorder) RecordDBPreparedStmtError(code string) {
+	r.prepared = append(r.prepared, code)
+}
+
+// pgError stands in for *pgconn.PgError.
+type pgError struct{ code string }
+
+func (e *pgError) Error() string    { return "pg error " + e.code }
+func (e *pgError) SQLState() string { return e.code }
+
+// dryRunDB returns a DB that builds SQL without a server, with the tracing
+// plugin installed as in database.New.
+func dryRunDB(t *testing.T, rec Recorder) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=dryrun"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("open dry-run db: %v", err)
+	}
+	if err := db.Use(tracing.NewPlugin()); err != nil {
+		t.Fatalf("tracing plugin: %v", err)
+	}
+	if err := db.Use(New(rec)); err != nil {
+		t.Fatalf("metrics plugin: %v", err)
+	}
+	return db
+}
+
+func TestPlugin_RecordsOperationAndTable(t *testing.T) {
+	tests := []struct {
+		operation string
+		table     string
+		run       func(db *gorm.DB)
+	}{
+		{"create", "widgets", func(db *gorm.DB) { db.Create(&widget{Name: "a"}) }},
+		{"query", "widgets", func(db *gorm.DB) { db.Find(&[]widget{}) }},
+		{"update", "widgets", func(db *gorm.DB) { db.Model(&widget{ID: 1}).Update("name", "b") }},
+		{"delete", "widgets", func(db *gorm.DB) { db.Delete(&widget{ID: 1}) }},
+		{"row", "widgets", func(db *gorm.DB) { db.Model(&widget{}).Select("count(*)").Row() }},
+		{"raw", "unknown", func(db *gorm.DB) { db.Exec("SELECT 1") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.operation, func(t *testing.T) {
+			rec := &fakeRecorder{}
+			tt.run(dryRunDB(t, rec))
+
+			want := []call{{operation: tt.operation, table: tt.table}}
+			if fmt.Sprint(rec.queries) != fmt.Sprint(want) {
+				t.Fatalf("queries = %v, want %v", rec.queries, want)
+			}
+			if len(rec.errors) != 0 {
+				t.Fatalf("errors = %v, want none", rec.errors)
+			}
+		})
+	}
+}
+
+func TestPlugin_RecordsErrorCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"driver SQLSTATE", fmt.Errorf("wrapped: %w", &pgError{code: "57014"}), "57014"},
+		{"translated unique violation", gorm.ErrDuplicatedKey, "23505"},
+		{"deadline", context.DeadlineExceeded, "timeout"},
+		{"other", errors.New("boom"), "unknown"},
+		{"record not found is not a failure", gorm.ErrRecordNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &fakeRecorder{}
+			db := dryRunDB(t, rec)
+			// Fail the statement where the driver would.
+			if err := db.Callback().Query().Before("gorm:query").Register("test:fail", func(db *gorm.DB) {
+				_ = db.AddError(tt.err)
+			}); err != nil {
+				t.Fatalf("register callback: %v", err)
+			}
+
+			db.Find(&[]widget{})
+
+			if len(rec.queries) != 1 {
+				t.Fatalf("queries = %v, want one", rec.queries)
+			}
+			var want []call
+			if tt.want != "" {
+				want = []call{{"query", "widgets", tt.want}}
+			}
+			if fmt.Sprint(rec.errors) != fmt.Sprint(want) {
+				t.Fatalf("errors = %v, want %v", rec.errors, want)
+			}
+		})
+	}
+}
+
+func TestPlugin_CountsPreparedStatementErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want []string
+	}{
+		{"duplicate", &pgError{code: "42P05"}, []string{"42P05"}},
+		{"missing", &pgError{code: "26000"}, []string{"26000"}},
+		{"message only", errors.New(`prepared statement "stmtcache_1" does not exist`), []string{"unknown"}},
+		{"unrelated", &pgError{code: "57014"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &fakeRecorder{}
+			db := dryRunDB(t, rec)
+			if err := db.Callback().Query().Before("gorm:query").Register("test:fail", func(db *gorm.DB) {
+				_ = db.AddError(tt.err)
+			}); err != nil {
+				t.Fatalf("register callback: %v", err)
+			}
+
+			db.Find(&[]widget{})
+
+			if fmt.Sprint(rec.prepared) != fmt.Sprint(tt.want) {
+				t.Fatalf("prepared = %v, want %v", rec.prepared, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlugin_SecondInstallIsRejected(t *testing.T) {
+	rec := &fakeRecorder{}
+	db := dryRunDB(t, rec)
+
+	if err := db.Use(New(rec)); !errors.Is(err, gorm.ErrRegistered) {
+		t.Fatalf("second Use = %v, want gorm.ErrRegistered", err)
+	}
+	db.Find(&[]widget{})
+	if len(rec.queries) != 1 {
+		t.Fatalf("queries = %v, want exactly one", rec.queries)
+	}
+}
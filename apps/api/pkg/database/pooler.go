@@ -0,0 +1,60 @@
+package database
+
+import "fmt"
+
+// PoolerMode is the pooling mode of a connection pooler, such as pgbouncer,
+// between the service and Postgres.
+type PoolerMode string
+
+const (
+	// PoolerNone connects to Postgres directly.
+	PoolerNone PoolerMode = "none"
+	// PoolerSession is a pooler that gives each client connection one
+	// server connection for as long as it stays open.
+	PoolerSession PoolerMode = "session"
+	// PoolerTransaction is a pooler that may hand each transaction of a
+	// client connection a different server connection.
+	PoolerTransaction PoolerMode = "transaction"
+)
+
+// connSettings is how New configures GORM and the pgx driver.
+type connSettings struct {
+	prepareStmt    bool
+	simpleProtocol bool
+	// warning, when set, explains a requested setting that was overridden;
+	// New logs it at startup.
+	warning string
+}
+
+// resolveConnSettings derives the driver settings from the pooler mode and
+// the requested PrepareStmt:
+//
+//	PoolerMode         PrepareStmt  GORM PrepareStmt   pgx protocol
+//	none, session, ""  true         true               extended, statement cache
+//	none, session, ""  false        false              extended, statement cache
+//	transaction        true         false (warning)    simple
+//	transaction        false        false              simple
+//
+// Prepared statements live on a server connection. Directly or in session
+// mode a client keeps its server connection, so they are safe. In
+// transaction mode the next transaction may run on another server
+// connection, where the statement is missing ("prepared statement does not
+// exist") or another client's of the same name is ("already exists"). That
+// holds for pgx's own per-connection statement cache as much as for GORM's,
+// so transaction mode also switches pgx to the simple protocol, which sends
+// arguments with the query and prepares nothing. Queries then cost a parse
+// each on the server; that is the price of transaction pooling.
+func resolveConnSettings(mode PoolerMode, prepareStmt bool) (connSettings, error) {
+	switch mode {
+	case "", PoolerNone, PoolerSession:
+		return connSettings{prepareStmt: prepareStmt}, nil
+	case PoolerTransaction:
+		s := connSettings{simpleProtocol: true}
+		if prepareStmt {
+			s.warning = "prepared statements are disabled: the pooler runs in transaction mode " +
+				"(DB_POOLER_MODE=transaction), where they break across server connections"
+		}
+		return s, nil
+	}
+	return connSettings{}, fmt.Errorf("unknown pooler mode %q (want none, session or transaction)", mode)
+}
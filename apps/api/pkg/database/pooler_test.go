@@ -0,0 +1,35 @@
+package database
+
+import "testing"
+
+func TestResolveConnSettings(t *testing.T) {
+	tests := []struct {
+		mode        PoolerMode
+		prepareStmt bool
+		wantPrepare bool
+		wantSimple  bool
+		wantWarning bool
+	}{
+		{"", true, true, false, false},
+		{PoolerNone, true, true, false, false},
+		{PoolerNone, false, false, false, false},
+		{PoolerSession, true, true, false, false},
+		{PoolerSession, false, false, false, false},
+		{PoolerTransaction, true, false, true, true},
+		{PoolerTransaction, false, false, true, false},
+	}
+	for _, tt := range tests {
+		got, err := resolveConnSettings(tt.mode, tt.prepareStmt)
+		if err != nil {
+			t.Fatalf("mode %q: %v", tt.mode, err)
+		}
+		if got.prepareStmt != tt.wantPrepare || got.simpleProtocol != tt.wantSimple || (got.warning != "") != tt.wantWarning {
+			t.Errorf("mode %q, prepareStmt %v: got %+v; want prepareStmt %v, simpleProtocol %v, warning %v",
+				tt.mode, tt.prepareStmt, got, tt.wantPrepare, tt.wantSimple, tt.wantWarning)
+		}
+	}
+
+	if _, err := resolveConnSettings("statement", false); err == nil {
+		t.Error("unknown mode must be rejected")
+	}
+}
@@ -0,0 +1,108 @@
+package database
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SQLRedaction selects which bound values the query logger writes out.
+type SQLRedaction string
+
+const (
+	// RedactAll masks every bound value; logged SQL keeps only its shape.
+	RedactAll SQLRedaction = "all"
+	// RedactSensitive masks only values bound to a column whose name
+	// contains one of SensitiveColumns (e.g. "email" matches "email" and
+	// "recovery_email").
+	RedactSensitive SQLRedaction = "sensitive"
+	// RedactNone logs fully interpolated SQL. Local debugging only.
+	RedactNone SQLRedaction = "none"
+)
+
+// DefaultSensitiveColumns is used when RedactSensitive is selected without a
+// column list.
+var DefaultSensitiveColumns = []string{"password", "email", "phone", "token", "secret"}
+
+// redactedValue replaces a masked bound value in logged SQL.
+const redactedValue = "[REDACTED]"
+
+var (
+	// comparisonRe matches a column compared or assigned to a placeholder:
+	// `"users"."email" = $1`, `email LIKE $2`, `"id" IN ($3,$4)`.
+	comparisonRe = regexp.MustCompile(`(?i)"?(\w+)"?\s*(?:=|<>|!=|<=|>=|<|>|\bI?LIKE\b|\bIN\b)\s*\(?\s*((?:\$\d+\s*,?\s*)+)`)
+	// insertRe captures an INSERT's column list and the VALUES tuples after it.
+	insertRe      = regexp.MustCompile(`(?is)^\s*INSERT\s+INTO\s+\S+\s*\(([^)]*)\)\s*VALUES\s*(.*)$`)
+	tupleRe       = regexp.MustCompile(`\(([^()]*)\)`)
+	placeholderRe = regexp.MustCompile(`\$(\d+)`)
+)
+
+// ParamsFilter implements gorm.ParamsFilter: GORM calls it before
+// interpolating bound values into the SQL passed to Trace, so masked values
+// never reach any log entry, errors and slow queries included.
+func (l *zapGormLogger) ParamsFilter(_ context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	switch l.redaction {
+	case RedactNone:
+		return sql, params
+	case RedactSensitive:
+		return sql, maskSensitive(sql, params, l.sensitiveColumns)
+	default:
+		masked := make([]interface{}, len(params))
+		for i := range masked {
+			masked[i] = redactedValue
+		}
+		return sql, masked
+	}
+}
+
+// maskSensitive masks the params bound to a sensitive column. A placeholder
+// it cannot attribute to a column is left as is.
+func maskSensitive(sql string, params []interface{}, sensitive []string) []interface{} {
+	out := append([]interface{}(nil), params...)
+	mask := func(column, placeholders string) {
+		if !isSensitiveColumn(column, sensitive) {
+			return
+		}
+		for _, m := range placeholderRe.FindAllStringSubmatch(placeholders, -1) {
+			if n, err := strconv.Atoi(m[1]); err == nil && n >= 1 && n <= len(out) {
+				out[n-1] = redactedValue
+			}
+		}
+	}
+
+	for _, m := range comparisonRe.FindAllStringSubmatch(sql, -1) {
+		mask(m[1], m[2])
+	}
+
+	if m := insertRe.FindStringSubmatch(sql); m != nil {
+		columns := strings.Split(m[1], ",")
+		for _, tuple := range tupleRe.FindAllStringSubmatch(m[2], -1) {
+			for i, value := range strings.Split(tuple[1], ",") {
+				if i < len(columns) {
+					mask(columns[i], value)
+				}
+			}
+		}
+	}
+	return out
+}
+
+func isSensitiveColumn(column string, sensitive []string) bool {
+	column = strings.ToLower(strings.Trim(strings.TrimSpace(column), `"`))
+	for _, s := range sensitive {
+		if s != "" && strings.Contains(column, strings.ToLower(s)) {
+			return true
+		}
+	}
+	return false
+}
+
+// sampled reports whether a debug "gorm query" entry should be written: one
+// in every sampleRate. Rates below 2 log everything.
+func (l *zapGormLogger) sampled() bool {
+	if l.sampleRate <= 1 {
+		return true
+	}
+	return (l.sampleCounter.Add(1)-1)%uint64(l.sampleRate) == 0
+}
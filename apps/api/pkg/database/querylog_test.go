@@ -0,0 +1,129 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"veemon/pkg/logger"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type account struct {
+	ID       uint
+	Email    string
+	Password string
+	Name     string
+}
+
+// loggedDB returns a sqlmock-backed DB using the query logger built from cfg,
+// and the entries it writes.
+func loggedDB(t *testing.T, cfg Config) (*gorm.DB, sqlmock.Sqlmock, *observer.ObservedLogs) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger:                 newZapGormLogger(zap.New(core), cfg),
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+	return db, mock, logs
+}
+
+func loggedSQL(t *testing.T, logs *observer.ObservedLogs) []string {
+	t.Helper()
+	var out []string
+	for _, e := range logs.All() {
+		out = append(out, e.ContextMap()["sql"].(string))
+	}
+	return out
+}
+
+func TestQueryLog_RedactsBoundValuesByDefault(t *testing.T) {
+	db, mock, logs := loggedDB(t, Config{LogQueries: true})
+	mock.ExpectQuery(`SELECT`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	ctx := logger.ContextWithRequestID(context.Background(), "req-1")
+	require.NoError(t, db.WithContext(ctx).Where("email = ?", "alice@example.com").Find(&[]account{}).Error)
+
+	require.Equal(t, 1, logs.Len())
+	entry := logs.All()[0]
+	assert.Equal(t, "gorm query", entry.Message)
+	assert.NotContains(t, entry.ContextMap()["sql"], "alice@example.com")
+	assert.Contains(t, entry.ContextMap()["sql"], "[REDACTED]")
+	assert.Equal(t, "req-1", entry.ContextMap()["request_id"])
+	assert.Contains(t, entry.ContextMap(), "rows")
+}
+
+func TestQueryLog_RedactsErrorEntries(t *testing.T) {
+	db, mock, logs := loggedDB(t, Config{})
+	mock.ExpectQuery(`SELECT`).WillReturnError(errors.New("boom"))
+
+	_ = db.Where("email = ?", "alice@example.com").Find(&[]account{}).Error
+
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, "gorm query error", logs.All()[0].Message)
+	assert.NotContains(t, loggedSQL(t, logs)[0], "alice@example.com")
+}
+
+func TestQueryLog_SensitiveMasksOnlySensitiveColumns(t *testing.T) {
+	db, mock, logs := loggedDB(t, Config{LogQueries: true, QueryLogRedaction: RedactSensitive})
+	mock.ExpectQuery(`INSERT`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(`SELECT`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	require.NoError(t, db.Create(&account{Email: "alice@example.com", Password: "$2a$10$hash", Name: "Alice"}).Error)
+	require.NoError(t, db.Where(`"accounts"."email" = ? AND name = ?`, "bob@example.com", "Bob").Find(&[]account{}).Error)
+
+	sql := loggedSQL(t, logs)
+	require.Len(t, sql, 2)
+	assert.NotContains(t, sql[0], "alice@example.com")
+	assert.NotContains(t, sql[0], "$2a$10$hash")
+	assert.Contains(t, sql[0], "'Alice'")
+	assert.NotContains(t, sql[1], "bob@example.com")
+	assert.Contains(t, sql[1], "'Bob'")
+}
+
+func TestQueryLog_NoneKeepsValues(t *testing.T) {
+	db, mock, logs := loggedDB(t, Config{LogQueries: true, QueryLogRedaction: RedactNone})
+	mock.ExpectQuery(`SELECT`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	require.NoError(t, db.Where("email = ?", "alice@example.com").Find(&[]account{}).Error)
+
+	assert.Contains(t, loggedSQL(t, logs)[0], "alice@example.com")
+}
+
+func TestQueryLog_SamplingNeverDropsErrors(t *testing.T) {
+	db, mock, logs := loggedDB(t, Config{LogQueries: true, QueryLogSampleRate: 3})
+	for i := 0; i < 6; i++ {
+		mock.ExpectQuery(`SELECT`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery(`SELECT`).WillReturnError(errors.New("boom"))
+	}
+
+	for i := 0; i < 6; i++ {
+		require.NoError(t, db.Find(&[]account{}).Error)
+		require.Error(t, db.Find(&[]account{}).Error)
+	}
+
+	assert.Equal(t, 2, logs.FilterMessage("gorm query").Len(), "1 in 3 successful queries")
+	assert.Equal(t, 6, logs.FilterMessage("gorm query error").Len(), "every error")
+}
+
+func TestQueryLog_DefaultLevelSkipsSuccessfulQueries(t *testing.T) {
+	db, mock, logs := loggedDB(t, Config{})
+	mock.ExpectQuery(`SELECT`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	require.NoError(t, db.Find(&[]account{}).Error)
+
+	assert.Zero(t, logs.Len())
+}
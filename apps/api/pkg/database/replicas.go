@@ -0,0 +1,285 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ReplicaCheckInterval is how often Replicas pings its replicas in the
+// background, marking them up or down.
+const ReplicaCheckInterval = 5 * time.Second
+
+// replicasPlugin names the Replicas plugin in gorm.Config.Plugins.
+const replicasPlugin = "veemon:replicas"
+
+// usePrimarySetting is the statement setting UsePrimary stores.
+const usePrimarySetting = "veemon:use_primary"
+
+// UsePrimary routes db's reads to the primary, for reads that must see a
+// write just made outside a transaction (replicas lag behind it). It is a
+// no-op without replicas.
+//
+//	err := database.UsePrimary(db.WithContext(ctx)).First(&u, "id = ?", id).Error
+func UsePrimary(db *gorm.DB) *gorm.DB {
+	return db.Set(usePrimarySetting, true)
+}
+
+type primaryKey struct{}
+
+// WithPrimary marks ctx so handles resolved by FromContext read from the
+// primary, for callers that reach the database through a repository.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// Replicas is a GORM plugin that sends reads to read replicas, round-robin,
+// and everything else to the primary. Reads stay on the primary inside a
+// transaction, with a locking clause (SELECT ... FOR UPDATE), under
+// UsePrimary, and while no replica is up. Raw statements go to a replica
+// only when they start with SELECT.
+//
+// Replicas are marked down and up again by Check, which runs every
+// ReplicaCheckInterval in the background (started by Open; Close stops it)
+// and on the /ready probe. A read that cannot reach its replica marks it
+// down and is retried on the primary, so a replica that dies between checks
+// costs no failed queries.
+//
+// It is a plugin of its own rather than GORM's dbresolver, which neither
+// health-checks replicas nor falls back to the primary when one fails.
+type Replicas struct {
+	replicas []*replica
+	next     atomic.Uint64
+	logger   *zap.Logger
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+type replica struct {
+	addr string
+	pool gorm.ConnPool
+	db   *sql.DB
+	up   atomic.Bool
+}
+
+// ReplicasOf returns db's Replicas plugin, or nil when none is configured.
+func ReplicasOf(db *gorm.DB) *Replicas {
+	if p, ok := db.Config.Plugins[replicasPlugin].(*Replicas); ok {
+		return p
+	}
+	return nil
+}
+
+// openReplicas connects to each of cfg.ReplicaHosts ("host" or "host:port",
+// cfg.Port by default) with the primary's credentials, database and pool
+// settings. A replica that cannot be reached yet is marked down, not fatal.
+func openReplicas(cfg Config, conn connSettings, pool poolSettings, zapLogger *zap.Logger) (*Replicas, error) {
+	r := &Replicas{logger: zapLogger}
+	for _, addr := range cfg.ReplicaHosts {
+		host, port, err := SplitReplicaHost(addr, cfg.Port)
+		if err != nil {
+			_ = r.Close()
+			return nil, err
+		}
+		rcfg := cfg
+		rcfg.Host, rcfg.Port = host, port
+		rdb, err := open(rcfg, conn, &gorm.Config{DisableAutomaticPing: true, PrepareStmt: conn.prepareStmt})
+		if err != nil {
+			_ = r.Close()
+			return nil, fmt.Errorf("replica %s: %w", addr, err)
+		}
+		sqlDB, err := rdb.DB()
+		if err != nil {
+			_ = r.Close()
+			return nil, fmt.Errorf("replica %s: %w", addr, err)
+		}
+		pool.apply(sqlDB)
+		rep := &replica{addr: addr, pool: rdb.ConnPool, db: sqlDB}
+		rep.up.Store(true)
+		r.replicas = append(r.replicas, rep)
+	}
+	return r, nil
+}
+
+// SplitReplicaHost parses a DB_REPLICA_HOSTS entry, "host" or "host:port".
+func SplitReplicaHost(addr string, defaultPort int) (string, int, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		// No port: the whole entry is the host.
+		return strings.TrimSpace(addr), defaultPort, nil //nolint:nilerr // a bare host is valid
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port < 1 || port > 65535 {
+		return "", 0, fmt.Errorf("DB_REPLICA_HOSTS: invalid port in %q", addr)
+	}
+	return host, port, nil
+}
+
+func (r *Replicas) Name() string { return replicasPlugin }
+
+// Initialize routes queries and row reads; creates, updates, deletes and
+// Exec always use the primary.
+func (r *Replicas) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Query().Before("gorm:query").Register(replicasPlugin, r.route); err != nil {
+		return err
+	}
+	return db.Callback().Row().Before("gorm:row").Register(replicasPlugin, r.route)
+}
+
+// route points a read statement at the next replica that is up.
+func (r *Replicas) route(db *gorm.DB) {
+	stmt := db.Statement
+	if _, inTx := stmt.ConnPool.(gorm.TxCommitter); inTx {
+		return
+	}
+	if v, ok := stmt.Settings.Load(usePrimarySetting); ok && v == true {
+		return
+	}
+	if _, locking := stmt.Clauses["FOR"]; locking {
+		return
+	}
+	// Raw SQL is built before the callbacks run; anything else is a SELECT
+	// the query builder has yet to write.
+	if raw := strings.TrimSpace(stmt.SQL.String()); raw != "" && !strings.EqualFold(firstWord(raw), "SELECT") {
+		return
+	}
+	if rep := r.pick(); rep != nil {
+		stmt.ConnPool = &failoverPool{ConnPool: rep.pool, r: r, rep: rep, primary: stmt.ConnPool}
+	}
+}
+
+// failoverPool sends reads to a replica, and to the primary once the
+// replica turns out to be unreachable.
+type failoverPool struct {
+	gorm.ConnPool
+	r       *Replicas
+	rep     *replica
+	primary gorm.ConnPool
+}
+
+func (p *failoverPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	rows, err := p.ConnPool.QueryContext(ctx, query, args...)
+	if p.unreachable(ctx, err) {
+		return p.primary.QueryContext(ctx, query, args...)
+	}
+	return rows, err
+}
+
+func (p *failoverPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	row := p.ConnPool.QueryRowContext(ctx, query, args...)
+	if p.unreachable(ctx, row.Err()) {
+		return p.primary.QueryRowContext(ctx, query, args...)
+	}
+	return row
+}
+
+// unreachable reports whether err means the replica could not be reached,
+// marking it down if so. Errors the caller's context caused do not count.
+func (p *failoverPool) unreachable(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil || !isConnError(err) {
+		return false
+	}
+	p.r.markDown(p.rep, err)
+	return true
+}
+
+// isConnError reports whether err is a failure to reach the server rather
+// than an error the query itself caused.
+func isConnError(err error) bool {
+	var connectErr *pgconn.ConnectError
+	var netErr net.Error
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.As(err, &connectErr) || errors.As(err, &netErr)
+}
+
+func (r *Replicas) markDown(rep *replica, err error) {
+	if rep.up.Swap(false) {
+		r.logger.Warn("Read replica down; its reads go to the primary", zap.String("replica", rep.addr), zap.Error(err))
+	}
+}
+
+// pick returns the next replica that is up, or nil.
+func (r *Replicas) pick() *replica {
+	n := uint64(len(r.replicas))
+	start := r.next.Add(1) - 1
+	for i := uint64(0); i < n; i++ {
+		if rep := r.replicas[(start+i)%n]; rep.up.Load() {
+			return rep
+		}
+	}
+	return nil
+}
+
+// Check pings every replica, marks each up or down for routing, and joins
+// the errors of those that are down.
+func (r *Replicas) Check(ctx context.Context) error {
+	var errs []error
+	for _, rep := range r.replicas {
+		err := rep.db.PingContext(ctx)
+		if err != nil {
+			r.markDown(rep, err)
+			errs = append(errs, fmt.Errorf("replica %s: %w", rep.addr, err))
+		} else if !rep.up.Swap(true) {
+			r.logger.Info("Read replica back up", zap.String("replica", rep.addr))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// startChecks runs Check every interval until Close.
+func (r *Replicas) startChecks(interval time.Duration) {
+	r.stop, r.done = make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), interval)
+				_ = r.Check(ctx)
+				cancel()
+			}
+		}
+	}()
+}
+
+// Close stops the background checks and closes every replica's pool.
+func (r *Replicas) Close() error {
+	r.stopOnce.Do(func() {
+		if r.stop != nil {
+			close(r.stop)
+			<-r.done
+		}
+	})
+	var errs []error
+	for _, rep := range r.replicas {
+		errs = append(errs, rep.db.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func firstWord(s string) string {
+	if i := strings.IndexFunc(s, func(c rune) bool { return c == ' ' || c == '\n' || c == '\t' || c == '(' }); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
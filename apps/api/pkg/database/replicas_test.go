@@ -0,0 +1,203 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type item struct {
+	ID   int
+	Name string
+}
+
+// withReplicas returns a primary with n mocked replicas; pings are only
+// answered as expected.
+func withReplicas(t *testing.T, n int) (*gorm.DB, sqlmock.Sqlmock, []sqlmock.Sqlmock) {
+	t.Helper()
+	db, primary := mockDB(t)
+	r := &Replicas{logger: zap.NewNop()}
+	mocks := make([]sqlmock.Sqlmock, n)
+	for i := range mocks {
+		sqlDB, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = sqlDB.Close() })
+		rep := &replica{addr: "replica", pool: sqlDB, db: sqlDB}
+		rep.up.Store(true)
+		r.replicas = append(r.replicas, rep)
+		mocks[i] = mock
+	}
+	require.NoError(t, db.Use(r))
+	require.Same(t, r, ReplicasOf(db))
+	return db, primary, mocks
+}
+
+func rows() *sqlmock.Rows { return sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a") }
+
+func TestReplicas_ReadsGoToReplicasRoundRobin(t *testing.T) {
+	db, primary, replicas := withReplicas(t, 2)
+	replicas[0].ExpectQuery(`SELECT \* FROM "items"`).WillReturnRows(rows())
+	replicas[1].ExpectQuery(`SELECT \* FROM "items"`).WillReturnRows(rows())
+	replicas[0].ExpectQuery(`SELECT count`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	var items []item
+	require.NoError(t, db.Find(&items).Error)
+	require.NoError(t, db.Find(&items).Error)
+	var n int64
+	require.NoError(t, db.Model(&item{}).Count(&n).Error)
+
+	for _, m := range append(replicas, primary) {
+		assert.NoError(t, m.ExpectationsWereMet())
+	}
+}
+
+func TestReplicas_WritesAndPinnedReadsGoToThePrimary(t *testing.T) {
+	db, primary, replicas := withReplicas(t, 1)
+	ctx := context.Background()
+
+	primary.ExpectBegin()
+	primary.ExpectQuery(`INSERT INTO "items"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	primary.ExpectCommit()
+	require.NoError(t, db.Create(&item{Name: "a"}).Error)
+
+	// In a transaction.
+	primary.ExpectBegin()
+	primary.ExpectQuery(`SELECT \* FROM "items"`).WillReturnRows(rows())
+	primary.ExpectCommit()
+	require.NoError(t, NewTxManager(db).Do(ctx, func(txCtx context.Context) error {
+		var it item
+		return FromContext(txCtx, db).First(&it).Error
+	}))
+
+	// UsePrimary, a locking read, and raw SQL that is not a SELECT.
+	primary.ExpectQuery(`SELECT \* FROM "items"`).WillReturnRows(rows())
+	primary.ExpectQuery(`FOR UPDATE`).WillReturnRows(rows())
+	primary.ExpectQuery(`UPDATE items`).WillReturnRows(rows())
+	var it item
+	require.NoError(t, UsePrimary(db.WithContext(ctx)).First(&it).Error)
+	require.NoError(t, db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&it).Error)
+	require.NoError(t, db.Raw(`UPDATE items SET name = 'b' RETURNING *`).Scan(&it).Error)
+
+	// A raw SELECT is a read.
+	replicas[0].ExpectQuery(`SELECT name FROM items`).WillReturnRows(rows())
+	require.NoError(t, db.Raw(`SELECT name FROM items`).Scan(&it).Error)
+
+	assert.NoError(t, primary.ExpectationsWereMet())
+	assert.NoError(t, replicas[0].ExpectationsWereMet())
+}
+
+func TestReplicas_DownReplicaDegradesToThePrimary(t *testing.T) {
+	db, primary, replicas := withReplicas(t, 1)
+	r := ReplicasOf(db)
+	ctx := context.Background()
+	var items []item
+
+	replicas[0].ExpectPing().WillReturnError(errors.New("connection refused"))
+	assert.Error(t, r.Check(ctx))
+	primary.ExpectQuery(`SELECT \* FROM "items"`).WillReturnRows(rows())
+	require.NoError(t, db.Find(&items).Error)
+
+	replicas[0].ExpectPing()
+	assert.NoError(t, r.Check(ctx))
+	replicas[0].ExpectQuery(`SELECT \* FROM "items"`).WillReturnRows(rows())
+	require.NoError(t, db.Find(&items).Error)
+
+	assert.NoError(t, primary.ExpectationsWereMet())
+	assert.NoError(t, replicas[0].ExpectationsWereMet())
+}
+
+func TestReplicas_UnreachableReplicaFailsOverToThePrimary(t *testing.T) {
+	db, primary, replicas := withReplicas(t, 1)
+	reset := &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}
+	var items []item
+	var n int64
+
+	replicas[0].ExpectQuery(`SELECT \* FROM "items"`).WillReturnError(reset)
+	primary.ExpectQuery(`SELECT \* FROM "items"`).WillReturnRows(rows())
+	require.NoError(t, db.Find(&items).Error)
+	assert.Len(t, items, 1)
+
+	// Marked down: the next reads go straight to the primary.
+	primary.ExpectQuery(`SELECT count`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	require.NoError(t, db.Model(&item{}).Count(&n).Error)
+
+	assert.NoError(t, primary.ExpectationsWereMet())
+	assert.NoError(t, replicas[0].ExpectationsWereMet())
+}
+
+func TestReplicas_QueryErrorsAreNotFailedOver(t *testing.T) {
+	db, primary, replicas := withReplicas(t, 1)
+	var items []item
+
+	replicas[0].ExpectQuery(`SELECT \* FROM "items"`).WillReturnError(errors.New(`relation "items" does not exist`))
+	require.Error(t, db.Find(&items).Error)
+
+	replicas[0].ExpectQuery(`SELECT \* FROM "items"`).WillReturnRows(rows())
+	require.NoError(t, db.Find(&items).Error, "the replica stays up")
+
+	assert.NoError(t, primary.ExpectationsWereMet())
+	assert.NoError(t, replicas[0].ExpectationsWereMet())
+}
+
+func TestReplicas_BackgroundChecks(t *testing.T) {
+	db, _, replicas := withReplicas(t, 1)
+	r := ReplicasOf(db)
+	replicas[0].ExpectPing().WillReturnError(errors.New("connection refused"))
+	for range 100 {
+		replicas[0].ExpectPing()
+	}
+
+	r.startChecks(20 * time.Millisecond)
+	t.Cleanup(func() { _ = r.Close() })
+
+	assert.Eventually(t, func() bool { return !r.replicas[0].up.Load() }, time.Second, time.Millisecond, "marked down")
+	assert.Eventually(t, func() bool { return r.replicas[0].up.Load() }, time.Second, time.Millisecond, "marked up again")
+}
+
+func TestWithPrimary_PinsRepositoryReads(t *testing.T) {
+	db, primary, replicas := withReplicas(t, 1)
+	ctx := context.Background()
+	var it item
+
+	primary.ExpectQuery(`SELECT \* FROM "items"`).WillReturnRows(rows())
+	require.NoError(t, FromContext(WithPrimary(ctx), db).First(&it).Error)
+	replicas[0].ExpectQuery(`SELECT \* FROM "items"`).WillReturnRows(rows())
+	require.NoError(t, FromContext(ctx, db).First(&it).Error)
+
+	assert.NoError(t, primary.ExpectationsWereMet())
+	assert.NoError(t, replicas[0].ExpectationsWereMet())
+}
+
+func TestUsePrimary_WithoutReplicas(t *testing.T) {
+	db, primary := mockDB(t)
+	assert.Nil(t, ReplicasOf(db))
+
+	primary.ExpectQuery(`SELECT \* FROM "items"`).WillReturnRows(rows())
+	var items []item
+	require.NoError(t, UsePrimary(db).Find(&items).Error)
+	assert.NoError(t, primary.ExpectationsWereMet())
+}
+
+func TestSplitReplicaHost(t *testing.T) {
+	host, port, err := SplitReplicaHost("replica-1", 5432)
+	require.NoError(t, err)
+	assert.Equal(t, "replica-1", host)
+	assert.Equal(t, 5432, port)
+
+	host, port, err = SplitReplicaHost("10.0.0.2:6432", 5432)
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.2", host)
+	assert.Equal(t, 6432, port)
+
+	_, _, err = SplitReplicaHost("replica:http", 5432)
+	assert.Error(t, err)
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultStatsInterval is how often SampleStats publishes when given a
+// non-positive interval.
+const DefaultStatsInterval = 15 * time.Second
+
+// StatsRecorder receives connection pool stats. *metrics.Metrics satisfies
+// it.
+type StatsRecorder interface {
+	// RecordDBPoolStats receives the pool occupancy and the waits for a
+	// connection accrued since the previous call.
+	RecordDBPoolStats(open, inUse, idle, maxOpen int, waits int64, waitTime time.Duration)
+}
+
+// SampleStats publishes db's pool stats to rec right away and then every
+// interval, until the returned stop is called. stop waits for the sampler to
+// exit and may be called more than once.
+func SampleStats(db *gorm.DB, rec StatsRecorder, interval time.Duration) (stop func(), err error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	if interval <= 0 {
+		interval = DefaultStatsInterval
+	}
+
+	// Only the sampler goroutine calls sample after the first call.
+	var lastWaits int64
+	var lastWaitTime time.Duration
+	sample := func() {
+		s := sqlDB.Stats()
+		rec.RecordDBPoolStats(s.OpenConnections, s.InUse, s.Idle, s.MaxOpenConnections,
+			s.WaitCount-lastWaits, s.WaitDuration-lastWaitTime)
+		lastWaits, lastWaitTime = s.WaitCount, s.WaitDuration
+	}
+	sample()
+
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				sample()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+		<-exited
+	}, nil
+}
@@ -0,0 +1,101 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type poolSample struct {
+	open, inUse, idle, maxOpen int
+	waits                      int64
+}
+
+type poolRecorder struct {
+	mu      sync.Mutex
+	samples []poolSample
+}
+
+func (r *poolRecorder) RecordDBPoolStats(open, inUse, idle, maxOpen int, waits int64, _ time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.samples = append(r.samples, poolSample{open, inUse, idle, maxOpen, waits})
+}
+
+func (r *poolRecorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.samples)
+}
+
+func (r *poolRecorder) last() poolSample {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.samples[len(r.samples)-1]
+}
+
+func (r *poolRecorder) waits() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var n int64
+	for _, s := range r.samples {
+		n += s.waits
+	}
+	return n
+}
+
+func TestSampleStats_SamplesUntilStopped(t *testing.T) {
+	db, _ := mockDB(t)
+	rec := &poolRecorder{}
+
+	stop, err := SampleStats(db, rec, 5*time.Millisecond)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, rec.count(), 1, "first sample is taken before SampleStats returns")
+
+	require.Eventually(t, func() bool { return rec.count() >= 3 }, time.Second, time.Millisecond)
+	stop()
+	stop() // idempotent
+
+	after := rec.count()
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, after, rec.count(), "no samples after stop")
+}
+
+func TestSampleStats_ReportsOccupancyAndWaitDeltas(t *testing.T) {
+	db, _ := mockDB(t)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	ctx := context.Background()
+
+	// Hold the only connection so the next caller has to wait for it.
+	held, err := sqlDB.Conn(ctx)
+	require.NoError(t, err)
+	waited := make(chan struct{})
+	go func() {
+		defer close(waited)
+		if c, err := sqlDB.Conn(ctx); err == nil {
+			_ = c.Close()
+		}
+	}()
+	require.Eventually(t, func() bool { return sqlDB.Stats().WaitCount == 1 }, time.Second, time.Millisecond)
+	require.NoError(t, held.Close())
+	<-waited
+
+	rec := &poolRecorder{}
+	stop, err := SampleStats(db, rec, 5*time.Millisecond)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return rec.count() >= 3 }, time.Second, time.Millisecond)
+	stop()
+
+	last := rec.last()
+	assert.Equal(t, 1, last.open)
+	assert.Equal(t, 0, last.inUse)
+	assert.Equal(t, 1, last.idle)
+	assert.Equal(t, 1, last.maxOpen)
+	assert.Equal(t, int64(1), rec.waits(), "waits are reported once, as deltas")
+}
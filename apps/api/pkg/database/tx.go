@@ -0,0 +1,111 @@
+package database
+
+import (
+	"context"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// Transactor runs fn inside one database transaction. Repository calls made
+// with the ctx passed to fn join that transaction.
+type Transactor interface {
+	Do(ctx context.Context, fn func(txCtx context.Context) error) error
+}
+
+type txKey struct{}
+
+// txState is what TxManager.Do stores in the context under txKey.
+type txState struct {
+	db *gorm.DB
+
+	mu          sync.Mutex
+	afterCommit []func()
+}
+
+func txFrom(ctx context.Context) (*txState, bool) {
+	st, ok := ctx.Value(txKey{}).(*txState)
+	return st, ok
+}
+
+// TxManager is the GORM-backed Transactor.
+type TxManager struct {
+	db *gorm.DB
+}
+
+// NewTxManager returns a TxManager that opens transactions on db.
+func NewTxManager(db *gorm.DB) *TxManager {
+	return &TxManager{db: db}
+}
+
+// Do begins a transaction, stores it in the context handed to fn, and commits
+// when fn returns nil or rolls back when it returns an error or panics. If ctx
+// already carries a transaction, fn joins it instead of opening a nested one,
+// so the outermost Do decides the outcome. Functions registered with
+// AfterCommit run once the outermost Do has committed.
+//
+// Example:
+//
+//	err := txm.Do(ctx, func(txCtx context.Context) error {
+//	    if err := userRepo.Create(txCtx, user); err != nil {
+//	        return err
+//	    }
+//	    return auditRepo.Create(txCtx, entry)
+//	})
+func (m *TxManager) Do(ctx context.Context, fn func(txCtx context.Context) error) error {
+	if _, ok := txFrom(ctx); ok {
+		return fn(ctx)
+	}
+	st := &txState{}
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		st.db = tx
+		return fn(context.WithValue(ctx, txKey{}, st))
+	})
+	if err != nil {
+		return err
+	}
+	st.mu.Lock()
+	hooks := st.afterCommit
+	st.mu.Unlock()
+	for _, hook := range hooks {
+		hook()
+	}
+	return nil
+}
+
+// AfterCommit runs fn once the transaction ctx carries has committed, and
+// never if it rolls back. Outside a transaction it runs fn at once. Caches
+// use it to invalidate entries only after the change is visible, so a reader
+// cannot cache the old row between the invalidation and the commit.
+func AfterCommit(ctx context.Context, fn func()) {
+	st, ok := txFrom(ctx)
+	if !ok {
+		fn()
+		return
+	}
+	st.mu.Lock()
+	st.afterCommit = append(st.afterCommit, fn)
+	st.mu.Unlock()
+}
+
+// InTx reports whether ctx carries a transaction started by TxManager.Do.
+// Caches consult it to keep uncommitted rows out of shared storage.
+func InTx(ctx context.Context) bool {
+	_, ok := txFrom(ctx)
+	return ok
+}
+
+// FromContext returns the transaction stored in ctx by TxManager.Do, or
+// fallback when there is none, bound to ctx either way. Repositories use it in
+// place of db.WithContext(ctx) so their methods work both standalone and
+// inside a caller's transaction. Outside one, a ctx marked by WithPrimary
+// reads from the primary.
+func FromContext(ctx context.Context, fallback *gorm.DB) *gorm.DB {
+	if st, ok := txFrom(ctx); ok {
+		return st.db.WithContext(ctx)
+	}
+	if ctx.Value(primaryKey{}) != nil {
+		return UsePrimary(fallback.WithContext(ctx))
+	}
+	return fallback.WithContext(ctx)
+}
@@ -0,0 +1,162 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func mockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	require.NoError(t, err)
+	return db, mock
+}
+
+// write stands in for a repository method: it resolves its handle from ctx.
+func write(ctx context.Context, db *gorm.DB, sql string) error {
+	return FromContext(ctx, db).Exec(sql).Error
+}
+
+func TestTxManager_RollbackUndoesBothWrites(t *testing.T) {
+	db, mock := mockDB(t)
+	boom := errors.New("invariant violated")
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO users`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO audit_log`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectRollback()
+
+	err := NewTxManager(db).Do(context.Background(), func(txCtx context.Context) error {
+		if err := write(txCtx, db, "INSERT INTO users VALUES (1)"); err != nil {
+			return err
+		}
+		if err := write(txCtx, db, "INSERT INTO audit_log VALUES (1)"); err != nil {
+			return err
+		}
+		return boom
+	})
+
+	assert.ErrorIs(t, err, boom)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTxManager_CommitsOnSuccess(t *testing.T) {
+	db, mock := mockDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO users`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO audit_log`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := NewTxManager(db).Do(context.Background(), func(txCtx context.Context) error {
+		if err := write(txCtx, db, "INSERT INTO users VALUES (1)"); err != nil {
+			return err
+		}
+		return write(txCtx, db, "INSERT INTO audit_log VALUES (1)")
+	})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTxManager_NestedDoJoinsOuterTransaction(t *testing.T) {
+	db, mock := mockDB(t)
+	txm := NewTxManager(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO users`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectRollback()
+
+	err := txm.Do(context.Background(), func(txCtx context.Context) error {
+		inner := txm.Do(txCtx, func(innerCtx context.Context) error {
+			return write(innerCtx, db, "INSERT INTO users VALUES (1)")
+		})
+		require.NoError(t, inner)
+		return errors.New("outer fails after inner succeeded")
+	})
+
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFromContext_WithoutTransactionUsesFallback(t *testing.T) {
+	db, mock := mockDB(t)
+
+	mock.ExpectExec(`INSERT INTO users`).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	require.NoError(t, write(context.Background(), db, "INSERT INTO users VALUES (1)"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInTx(t *testing.T) {
+	db, mock := mockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	assert.False(t, InTx(context.Background()))
+	require.NoError(t, NewTxManager(db).Do(context.Background(), func(txCtx context.Context) error {
+		assert.True(t, InTx(txCtx))
+		return nil
+	}))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAfterCommit(t *testing.T) {
+	t.Run("runs after the outermost commit", func(t *testing.T) {
+		db, mock := mockDB(t)
+		txm := NewTxManager(db)
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+		var ran []string
+
+		err := txm.Do(context.Background(), func(txCtx context.Context) error {
+			AfterCommit(txCtx, func() { ran = append(ran, "outer") })
+			require.NoError(t, txm.Do(txCtx, func(innerCtx context.Context) error {
+				AfterCommit(innerCtx, func() { ran = append(ran, "inner") })
+				return nil
+			}))
+			assert.Empty(t, ran, "nothing runs before the commit")
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"outer", "inner"}, ran)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("never runs on rollback", func(t *testing.T) {
+		db, mock := mockDB(t)
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		ran := false
+
+		err := NewTxManager(db).Do(context.Background(), func(txCtx context.Context) error {
+			AfterCommit(txCtx, func() { ran = true })
+			return errors.New("boom")
+		})
+
+		require.Error(t, err)
+		assert.False(t, ran)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("runs at once outside a transaction", func(t *testing.T) {
+		ran := false
+		AfterCommit(context.Background(), func() { ran = true })
+		assert.True(t, ran)
+	})
+}
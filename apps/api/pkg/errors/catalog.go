@@ -0,0 +1,100 @@
+package errors
+
+import (
+	"net/http"
+	"sort"
+)
+
+// CodeInfo documents one application error code for API consumers.
+type CodeInfo struct {
+	Code        int    `json:"code"`
+	Name        string `json:"name"`
+	HTTPStatus  int    `json:"httpStatus"`
+	Description string `json:"description"`
+	Retryable   bool   `json:"retryable"`
+}
+
+// catalog is the single source of truth for every code the API can return.
+// Adding a code to a handler without registering it here fails
+// TestErrorCodesAreCataloged in the handler package.
+var catalog = []CodeInfo{
+	// Generic codes used by the status helpers (Unauthorized, NotFound, ...)
+	// and by the framework-level error handlers.
+	{400, "VALIDATION_FAILED", http.StatusBadRequest, "The request failed validation; the message lists the offending fields.", false},
+	{401, "UNAUTHENTICATED", http.StatusUnauthorized, "Authentication is missing, invalid, expired or revoked.", false},
+	{403, "FORBIDDEN", http.StatusForbidden, "The caller is authenticated but not allowed to perform this action, or the account is not active.", false},
+	{404, "NOT_FOUND", http.StatusNotFound, "The requested resource or route does not exist.", false},
+	{413, "PAYLOAD_TOO_LARGE", http.StatusRequestEntityTooLarge, "The request body exceeds the server's size limit (HTTP_BODY_LIMIT_KB).", false},
+	{415, "UNSUPPORTED_MEDIA_TYPE", http.StatusUnsupportedMediaType, "The request body's Content-Type is not accepted by this endpoint.", false},
+	{429, "RATE_LIMITED", http.StatusTooManyRequests, "Too many requests; retry after the rate-limit window resets.", true},
+	{500, "INTERNAL", http.StatusInternalServerError, "An unexpected server error occurred.", true},
+
+	// Domain-specific codes.
+	{40002, "INVALID_USER_ID", http.StatusBadRequest, "The user id path parameter is not a valid UUID.", false},
+	{40003, "INVALID_FIELDS", http.StatusBadRequest, "The fields parameter names a field that cannot be selected; the message lists the allowed names.", false},
+	{40004, "PAGE_TOO_DEEP", http.StatusBadRequest, "The page reaches past the maximum offset (MAX_OFFSET rows); continue with the cursor parameter set to the previous response's nextCursor.", false},
+	{40005, "INVALID_CURSOR", http.StatusBadRequest, "The cursor parameter was not issued by this API or was combined with a sortBy other than created_at.", false},
+	{40006, "INVALID_VERSION", http.StatusBadRequest, "expectedUpdatedAt is not an RFC 3339 timestamp, or If-Match is not a single quoted ETag.", false},
+	{40007, "UNKNOWN_CONFIG_KEY", http.StatusBadRequest, "A runtime config update names a key that does not exist; GET /api/v1/admin/runtime-config lists the keys.", false},
+	{40008, "INVALID_CONFIG_VALUE", http.StatusBadRequest, "A runtime config update is empty, or a value does not parse as its key's type or is out of range; nothing was changed.", false},
+	{40009, "WRONG_CURRENT_PASSWORD", http.StatusBadRequest, "The current password sent to change the password is incorrect; repeated failures lock the account like failed logins.", false},
+	{40010, "INVALID_RESET_TOKEN", http.StatusBadRequest, "The password reset token was never issued, has expired or was already used; request a new one with POST /api/v1/auth/forgot-password.", false},
+	{40011, "INVALID_SORT", http.StatusBadRequest, "sortBy or sortOrder is not one of the allowed values.", false},
+	{40012, "INVALID_IMPORT_FILE", http.StatusBadRequest, "The user import upload has no file part, is not valid CSV, has no rows, or its header names an unknown column, repeats one, or lacks email or name.", false},
+	{40013, "INVALID_EXPORT_FORMAT", http.StatusBadRequest, "The user export format is not csv or ndjson.", false},
+	{40301, "ACCOUNT_DELETION_PENDING", http.StatusForbidden, "The account is scheduled for deletion; cancel it with POST /api/v1/auth/reactivate before the grace period ends.", false},
+	{40302, "ROLE_ESCALATION", http.StatusForbidden, "The roles would grant permissions the caller does not have; an administrator can only hand out what their own roles grant.", false},
+	{40901, "EMAIL_ALREADY_REGISTERED", http.StatusConflict, "An account with this email address already exists.", false},
+	{40902, "EMAIL_BELONGS_TO_DELETED_ACCOUNT", http.StatusConflict, "The email belongs to a deleted account that cannot be re-registered; contact support.", false},
+	{40903, "NO_PENDING_DELETION", http.StatusConflict, "The account has no pending deletion that can still be cancelled.", false},
+	{40904, "COMPANY_USER_LIMIT_REACHED", http.StatusConflict, "The company already has the maximum number of users its plan allows.", false},
+	{40905, "IDEMPOTENCY_REQUEST_IN_PROGRESS", http.StatusConflict, "Another request with the same Idempotency-Key is still running; retry after a moment to receive its response.", true},
+	{40906, "USER_NOT_DELETED", http.StatusConflict, "The user is not deleted, so there is nothing to restore.", false},
+	{41201, "USER_MODIFIED", http.StatusPreconditionFailed, "The user changed after the version in If-Match or expectedUpdatedAt; fetch it again and retry with the new ETag.", false},
+	{41301, "IMPORT_TOO_MANY_ROWS", http.StatusRequestEntityTooLarge, "The user import file has more rows than USER_IMPORT_MAX_ROWS; nothing was imported, so split it and upload the parts.", false},
+	{42201, "IDEMPOTENCY_KEY_REUSED", http.StatusUnprocessableEntity, "The Idempotency-Key was already used for a request with a different method, path, body or credentials; send a new key.", false},
+	{42801, "PRECONDITION_REQUIRED", http.StatusPreconditionRequired, "Updates must be conditional (UPDATE_REQUIRE_IF_MATCH): send If-Match with the user's ETag, or expectedUpdatedAt over gRPC.", false},
+	{50001, "REGISTER_FAILED", http.StatusInternalServerError, "The account could not be created.", true},
+	{50002, "LOGIN_FAILED", http.StatusInternalServerError, "Credentials could not be checked.", true},
+	{50003, "TOKEN_ISSUE_FAILED", http.StatusInternalServerError, "An access token could not be issued.", true},
+	{50004, "GET_PROFILE_FAILED", http.StatusInternalServerError, "The caller's profile could not be loaded.", true},
+	{50005, "LIST_USERS_FAILED", http.StatusInternalServerError, "The user list could not be loaded.", true},
+	{50006, "GET_USER_FAILED", http.StatusInternalServerError, "The user could not be loaded.", true},
+	{50007, "UPDATE_USER_FAILED", http.StatusInternalServerError, "The user could not be updated.", true},
+	{50008, "DELETE_USER_FAILED", http.StatusInternalServerError, "The user could not be deleted.", true},
+	{50010, "TOKEN_REFRESH_FAILED", http.StatusInternalServerError, "The access token could not be refreshed.", true},
+	{50011, "IMPERSONATE_FAILED", http.StatusInternalServerError, "The impersonation token could not be issued.", true},
+	{50012, "DELETE_ACCOUNT_FAILED", http.StatusInternalServerError, "The account deletion could not be scheduled.", true},
+	{50013, "REACTIVATE_FAILED", http.StatusInternalServerError, "The pending account deletion could not be cancelled.", true},
+	{50014, "RUNTIME_CONFIG_UPDATE_FAILED", http.StatusInternalServerError, "The runtime config could not be updated.", true},
+	{50015, "CHANGE_PASSWORD_FAILED", http.StatusInternalServerError, "The password could not be changed.", true},
+	{50016, "PASSWORD_RESET_FAILED", http.StatusInternalServerError, "The password could not be reset; the token has been used up, so request a new one.", true},
+	{50017, "CREATE_USER_FAILED", http.StatusInternalServerError, "The user could not be created.", true},
+	{50018, "PURGE_USER_FAILED", http.StatusInternalServerError, "The user could not be permanently deleted.", true},
+	{50019, "RESTORE_USER_FAILED", http.StatusInternalServerError, "The deleted user could not be restored.", true},
+	{50020, "LIST_AUDIT_LOGS_FAILED", http.StatusInternalServerError, "The audit log could not be read.", true},
+	{50021, "IMPORT_USERS_FAILED", http.StatusInternalServerError, "The uploaded file could not be read; nothing was imported.", true},
+	{50301, "RUNTIME_CONFIG_UNAVAILABLE", http.StatusServiceUnavailable, "The runtime config store (Redis) is unreachable or not configured; current values stay in effect.", true},
+	{50302, "PASSWORD_RESET_UNAVAILABLE", http.StatusServiceUnavailable, "Password reset needs Redis and RabbitMQ, and one of them is not configured.", true},
+	{50303, "USER_INVITE_UNAVAILABLE", http.StatusServiceUnavailable, "Invitations need Redis and RabbitMQ, and one of them is not configured; create the user with a password instead.", true},
+}
+
+func init() {
+	sort.Slice(catalog, func(i, j int) bool { return catalog[i].Code < catalog[j].Code })
+}
+
+// Catalog returns every registered error code, ordered by code.
+func Catalog() []CodeInfo {
+	out := make([]CodeInfo, len(catalog))
+	copy(out, catalog)
+	return out
+}
+
+// Lookup returns the catalog entry for code.
+func Lookup(code int) (CodeInfo, bool) {
+	i := sort.Search(len(catalog), func(i int) bool { return catalog[i].Code >= code })
+	if i < len(catalog) && catalog[i].Code == code {
+		return catalog[i], true
+	}
+	return CodeInfo{}, false
+}
@@ -4,10 +4,10 @@ package errors
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 type AppError struct {
@@ -16,6 +16,17 @@ type AppError struct {
 	Code       int
 	Message    string
 	Err        error
+	// Fields lists per-field validation failures, if any.
+	Fields []FieldViolation
+	// RetryAfter overrides the suggested backoff sent to gRPC callers for
+	// retryable codes. Zero means DefaultRetryDelay.
+	RetryAfter time.Duration
+}
+
+// FieldViolation is one failed validation rule on a request field.
+type FieldViolation struct {
+	Field       string
+	Description string
 }
 
 func (e *AppError) Error() string {
@@ -29,17 +40,29 @@ func (e *AppError) Unwrap() error {
 	return e.Err
 }
 
-func (e *AppError) GRPCStatus() *status.Status {
-	return status.New(e.GRPCCode, e.Message)
+// WithFields attaches field violations to e and returns it.
+func (e *AppError) WithFields(fields ...FieldViolation) *AppError {
+	e.Fields = append(e.Fields, fields...)
+	return e
 }
 
+// FiberError writes e as the standard error envelope. Field violations, if
+// any, are listed under error.details.
 func (e *AppError) FiberError(c *fiber.Ctx) error {
+	body := fiber.Map{
+		"code":    e.Code,
+		"message": e.Message,
+	}
+	if len(e.Fields) > 0 {
+		details := make([]fiber.Map, len(e.Fields))
+		for i, f := range e.Fields {
+			details[i] = fiber.Map{"field": f.Field, "description": f.Description}
+		}
+		body["details"] = details
+	}
 	return c.Status(e.HTTPStatus).JSON(fiber.Map{
 		"success": false,
-		"error": fiber.Map{
-			"code":    e.Code,
-			"message": e.Message,
-		},
+		"error":   body,
 	})
 }
 
@@ -82,6 +105,10 @@ func Conflict(code int, message string) *AppError {
 	return New(http.StatusConflict, codes.AlreadyExists, code, message)
 }
 
+func UnsupportedMediaType(message string) *AppError {
+	return New(http.StatusUnsupportedMediaType, codes.InvalidArgument, 415, message)
+}
+
 func TooManyRequests(message string) *AppError {
 	return New(http.StatusTooManyRequests, codes.ResourceExhausted, 429, message)
 }
@@ -0,0 +1,109 @@
+package errors
+
+import (
+	"strconv"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Domain is the ErrorInfo domain attached to every AppError sent over gRPC.
+const Domain = "veemon"
+
+// DefaultRetryDelay is the backoff suggested in RetryInfo for retryable codes
+// when the error does not set RetryAfter.
+const DefaultRetryDelay = time.Second
+
+// GRPCStatus converts e to a gRPC status carrying google.rpc details:
+//   - ErrorInfo with Domain, the catalog name as Reason and the numeric code
+//     in metadata["code"];
+//   - RetryInfo when the code is cataloged as retryable;
+//   - BadRequest with one violation per entry in Fields.
+//
+// grpc-go calls this when a handler returns an *AppError.
+func (e *AppError) GRPCStatus() *status.Status {
+	st := status.New(e.GRPCCode, e.Message)
+
+	info, cataloged := Lookup(e.Code)
+	reason := info.Name
+	if !cataloged {
+		reason = "UNKNOWN"
+	}
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{
+		Domain:   Domain,
+		Reason:   reason,
+		Metadata: map[string]string{"code": strconv.Itoa(e.Code)},
+	}}
+	if info.Retryable {
+		delay := e.RetryAfter
+		if delay <= 0 {
+			delay = DefaultRetryDelay
+		}
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(delay)})
+	}
+	if len(e.Fields) > 0 {
+		br := &errdetails.BadRequest{}
+		for _, f := range e.Fields {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       f.Field,
+				Description: f.Description,
+			})
+		}
+		details = append(details, br)
+	}
+
+	withDetails, err := st.WithDetails(details...)
+	if err != nil {
+		// Details only fail to marshal on a programming error; the bare
+		// status is still a correct answer.
+		return st
+	}
+	return withDetails
+}
+
+// FromGRPCStatus rebuilds the AppError a sibling service sent over gRPC. It
+// reports false when err is not a gRPC status carrying a veemon ErrorInfo
+// (transport failures, errors from other systems); callers should then fall
+// back to status.Code(err).
+func FromGRPCStatus(err error) (*AppError, bool) {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() == codes.OK {
+		return nil, false
+	}
+
+	var (
+		appErr = &AppError{GRPCCode: st.Code(), Message: st.Message()}
+		found  bool
+	)
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.ErrorInfo:
+			if d.GetDomain() != Domain {
+				continue
+			}
+			code, convErr := strconv.Atoi(d.GetMetadata()["code"])
+			if convErr != nil {
+				continue
+			}
+			appErr.Code = code
+			found = true
+		case *errdetails.RetryInfo:
+			appErr.RetryAfter = d.GetRetryDelay().AsDuration()
+		case *errdetails.BadRequest:
+			for _, v := range d.GetFieldViolations() {
+				appErr.Fields = append(appErr.Fields, FieldViolation{Field: v.GetField(), Description: v.GetDescription()})
+			}
+		}
+	}
+	if !found {
+		return nil, false
+	}
+	if info, ok := Lookup(appErr.Code); ok {
+		appErr.HTTPStatus = info.HTTPStatus
+	}
+	return appErr, true
+}
@@ -0,0 +1,106 @@
+package errors
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// failingHealth answers every Check with err, standing in for a handler that
+// returns an *AppError.
+type failingHealth struct {
+	healthpb.UnimplementedHealthServer
+	err error
+}
+
+func (f failingHealth) Check(context.Context, *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	return nil, f.err
+}
+
+// roundTrip returns the error a client sees when the server replies with err.
+func roundTrip(t *testing.T, err error) error {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, failingHealth{err: err})
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, dialErr := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, dialErr)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	_, callErr := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.Error(t, callErr)
+	return callErr
+}
+
+func TestGRPCStatus_RoundTripsConflictCode(t *testing.T) {
+	got, ok := FromGRPCStatus(roundTrip(t, Conflict(40902, "email belongs to a deleted account")))
+	require.True(t, ok)
+
+	assert.Equal(t, codes.AlreadyExists, got.GRPCCode)
+	assert.Equal(t, 40902, got.Code)
+	assert.Equal(t, 409, got.HTTPStatus)
+	assert.Equal(t, "email belongs to a deleted account", got.Message)
+	assert.Zero(t, got.RetryAfter, "non-retryable codes carry no RetryInfo")
+	assert.Empty(t, got.Fields)
+}
+
+func TestGRPCStatus_RoundTripsRetryInfo(t *testing.T) {
+	got, ok := FromGRPCStatus(roundTrip(t, Internal(50001, "register failed")))
+	require.True(t, ok)
+	assert.Equal(t, 50001, got.Code)
+	assert.Equal(t, DefaultRetryDelay, got.RetryAfter)
+
+	limited := TooManyRequests("slow down")
+	limited.RetryAfter = 30 * time.Second
+	got, ok = FromGRPCStatus(roundTrip(t, limited))
+	require.True(t, ok)
+	assert.Equal(t, codes.ResourceExhausted, got.GRPCCode)
+	assert.Equal(t, 30*time.Second, got.RetryAfter)
+}
+
+func TestGRPCStatus_RoundTripsFieldViolations(t *testing.T) {
+	sent := ValidationError("email is required; password must be at least 8 characters").WithFields(
+		FieldViolation{Field: "email", Description: "email is required"},
+		FieldViolation{Field: "password", Description: "password must be at least 8 characters"},
+	)
+	clientErr := roundTrip(t, sent)
+
+	got, ok := FromGRPCStatus(clientErr)
+	require.True(t, ok)
+	assert.Equal(t, codes.InvalidArgument, got.GRPCCode)
+	assert.Equal(t, 400, got.Code)
+	assert.Equal(t, sent.Fields, got.Fields)
+
+	// The raw ErrorInfo is usable by non-Go callers too.
+	var reason string
+	for _, d := range status.Convert(clientErr).Details() {
+		if info, ok := d.(interface{ GetReason() string }); ok {
+			reason = info.GetReason()
+		}
+	}
+	assert.Equal(t, "VALIDATION_FAILED", reason)
+}
+
+func TestFromGRPCStatus_RejectsForeignErrors(t *testing.T) {
+	_, ok := FromGRPCStatus(roundTrip(t, status.Error(codes.Unavailable, "upstream down")))
+	assert.False(t, ok)
+
+	_, ok = FromGRPCStatus(context.DeadlineExceeded)
+	assert.False(t, ok)
+}
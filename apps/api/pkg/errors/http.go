@@ -0,0 +1,69 @@
+package errors
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+)
+
+// errorEnvelope is the body FiberError writes.
+type errorEnvelope struct {
+	Success bool `json:"success"`
+	Error   *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Details []struct {
+			Field       string `json:"field"`
+			Description string `json:"description"`
+		} `json:"details"`
+	} `json:"error"`
+}
+
+// FromHTTPResponse rebuilds the AppError a REST endpoint returned with
+// httpStatus and body. It reports false when body is not an error envelope
+// (a proxy's error page, a success response), leaving callers to fall back
+// to the HTTP status.
+func FromHTTPResponse(httpStatus int, body []byte) (*AppError, bool) {
+	var env errorEnvelope
+	if err := json.Unmarshal(body, &env); err != nil || env.Success || env.Error == nil || env.Error.Code == 0 {
+		return nil, false
+	}
+	appErr := &AppError{
+		HTTPStatus: httpStatus,
+		GRPCCode:   grpcCodeForHTTP(httpStatus),
+		Code:       env.Error.Code,
+		Message:    env.Error.Message,
+	}
+	for _, d := range env.Error.Details {
+		appErr.Fields = append(appErr.Fields, FieldViolation{Field: d.Field, Description: d.Description})
+	}
+	return appErr, true
+}
+
+// grpcCodeForHTTP inverts the status pairs the constructors in this package
+// use.
+func grpcCodeForHTTP(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusUnsupportedMediaType:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusPreconditionFailed, http.StatusPreconditionRequired, http.StatusUnprocessableEntity:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	}
+	if httpStatus >= 500 {
+		return codes.Internal
+	}
+	return codes.Unknown
+}
@@ -0,0 +1,59 @@
+package errors
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+// httpRoundTrip returns the status and body a REST client sees when a
+// handler replies with err.FiberError.
+func httpRoundTrip(t *testing.T, err *AppError) (int, []byte) {
+	t.Helper()
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error { return err.FiberError(c) })
+	resp, testErr := app.Test(httptest.NewRequest("GET", "/", nil))
+	require.NoError(t, testErr)
+	defer resp.Body.Close()
+	body, readErr := io.ReadAll(resp.Body)
+	require.NoError(t, readErr)
+	return resp.StatusCode, body
+}
+
+func TestFromHTTPResponse_RoundTripsFiberError(t *testing.T) {
+	sent := ValidationError("email is required").WithFields(FieldViolation{Field: "email", Description: "is required"})
+
+	got, ok := FromHTTPResponse(httpRoundTrip(t, sent))
+
+	require.True(t, ok)
+	assert.Equal(t, 400, got.HTTPStatus)
+	assert.Equal(t, codes.InvalidArgument, got.GRPCCode)
+	assert.Equal(t, 400, got.Code)
+	assert.Equal(t, "email is required", got.Message)
+	assert.Equal(t, sent.Fields, got.Fields)
+}
+
+func TestFromHTTPResponse_DomainCode(t *testing.T) {
+	got, ok := FromHTTPResponse(httpRoundTrip(t, Conflict(40901, "email already registered")))
+
+	require.True(t, ok)
+	assert.Equal(t, 40901, got.Code)
+	assert.Equal(t, codes.AlreadyExists, got.GRPCCode)
+	assert.Empty(t, got.Fields)
+}
+
+func TestFromHTTPResponse_RejectsNonEnvelopes(t *testing.T) {
+	for _, body := range []string{
+		`<html>502 Bad Gateway</html>`,
+		`{"success":true,"data":{}}`,
+		`{"message":"not ours"}`,
+	} {
+		_, ok := FromHTTPResponse(502, []byte(body))
+		assert.False(t, ok, body)
+	}
+}
@@ -0,0 +1,108 @@
+// Package events defines the message envelope exchanged over RabbitMQ and the
+// content-type aware decoding applied before a message reaches a handler.
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"regexp"
+	"strings"
+	"time"
+
+	"veemon/pkg/validation"
+)
+
+const (
+	ContentTypeJSON = "application/json"
+	ContentTypeText = "text/plain"
+)
+
+// PreviewLimit bounds how much of a rejected body is written to logs.
+const PreviewLimit = 256
+
+var (
+	// ErrUnsupportedContentType is returned for content types the worker has
+	// no decoder for. Such messages can never succeed and belong in the DLQ.
+	ErrUnsupportedContentType = errors.New("unsupported content type")
+	// ErrMalformed is returned when a body does not parse for its content type.
+	ErrMalformed = errors.New("malformed message body")
+	// ErrInvalidEnvelope is returned when a decoded envelope fails validation.
+	ErrInvalidEnvelope = errors.New("invalid event envelope")
+)
+
+// Envelope is the JSON wrapper every application/json message must carry.
+// Data stays raw so each handler decodes its own payload type.
+type Envelope struct {
+	ID   string `json:"id" validate:"required"`
+	Type string `json:"type" validate:"required"`
+	// Version is the schema version of Data, for event types that version
+	// their payload. Zero (omitted) means unversioned.
+	Version    int             `json:"version,omitempty"`
+	Source     string          `json:"source,omitempty"`
+	OccurredAt time.Time       `json:"occurredAt" validate:"required"`
+	Data       json.RawMessage `json:"data,omitempty"`
+}
+
+// Message is a decoded delivery. Exactly one of Envelope (JSON) or Text
+// (text/plain) is set, according to ContentType.
+type Message struct {
+	ContentType string
+	Envelope    *Envelope
+	Text        string
+}
+
+// Decode parses body according to contentType. An empty content type is
+// treated as JSON, matching what the publisher defaults to.
+func Decode(contentType string, body []byte) (*Message, error) {
+	mediaType := ContentTypeJSON
+	if contentType != "" {
+		parsed, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrUnsupportedContentType, contentType)
+		}
+		mediaType = parsed
+	}
+
+	switch mediaType {
+	case ContentTypeJSON:
+		var env Envelope
+		if err := json.Unmarshal(body, &env); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+		}
+		if err := validation.Validate(&env); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+		}
+		return &Message{ContentType: mediaType, Envelope: &env}, nil
+	case ContentTypeText:
+		return &Message{ContentType: mediaType, Text: string(body)}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedContentType, mediaType)
+	}
+}
+
+// IsPermanent reports whether err means the message can never be processed,
+// as opposed to a transient handler failure worth retrying.
+func IsPermanent(err error) bool {
+	return errors.Is(err, ErrUnsupportedContentType) ||
+		errors.Is(err, ErrMalformed) ||
+		errors.Is(err, ErrInvalidEnvelope)
+}
+
+var sensitiveField = regexp.MustCompile(`(?i)"([^"]*(?:password|secret|token|authorization|api_?key)[^"]*)"\s*:\s*"(?:[^"\\]|\\.)*"`)
+
+// Preview returns at most PreviewLimit bytes of body, safe to log: values of
+// sensitive-looking JSON keys are masked and control characters are dropped.
+func Preview(body []byte) string {
+	redacted := sensitiveField.ReplaceAllString(string(body), `"$1":"[REDACTED]"`)
+	if len(redacted) > PreviewLimit {
+		redacted = redacted[:PreviewLimit]
+	}
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\t' {
+			return -1
+		}
+		return r
+	}, redacted)
+}
@@ -0,0 +1,101 @@
+package events
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantErr     error
+		wantText    string
+		wantType    string
+	}{
+		{
+			name:        "valid json envelope",
+			contentType: "application/json",
+			body:        `{"id":"1","type":"user.registered","occurredAt":"2024-01-01T00:00:00Z","data":{"k":"v"}}`,
+			wantType:    "user.registered",
+		},
+		{
+			name:        "json with charset parameter",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"id":"1","type":"t","occurredAt":"2024-01-01T00:00:00Z"}`,
+			wantType:    "t",
+		},
+		{
+			name:     "empty content type defaults to json",
+			body:     `{"id":"1","type":"t","occurredAt":"2024-01-01T00:00:00Z"}`,
+			wantType: "t",
+		},
+		{
+			name:        "malformed json",
+			contentType: "application/json",
+			body:        `{"id":`,
+			wantErr:     ErrMalformed,
+		},
+		{
+			name:        "envelope missing required fields",
+			contentType: "application/json",
+			body:        `{"data":{}}`,
+			wantErr:     ErrInvalidEnvelope,
+		},
+		{
+			name:        "plain text passes through",
+			contentType: "text/plain",
+			body:        "hello",
+			wantText:    "hello",
+		},
+		{
+			name:        "unknown content type",
+			contentType: "application/x-protobuf",
+			body:        "\x08\x01",
+			wantErr:     ErrUnsupportedContentType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := Decode(tt.contentType, []byte(tt.body))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("want %v, got %v", tt.wantErr, err)
+				}
+				if !IsPermanent(err) {
+					t.Fatalf("decode error %v should be permanent", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantType != "" && (msg.Envelope == nil || msg.Envelope.Type != tt.wantType) {
+				t.Fatalf("want envelope type %q, got %+v", tt.wantType, msg.Envelope)
+			}
+			if msg.Text != tt.wantText {
+				t.Fatalf("want text %q, got %q", tt.wantText, msg.Text)
+			}
+		})
+	}
+}
+
+func TestPreview_RedactsAndTruncates(t *testing.T) {
+	body := `{"email":"a@b.com","password":"hunter2","accessToken":"abc.def","note":"` +
+		strings.Repeat("x", 400) + `"}`
+
+	got := Preview([]byte(body))
+
+	if strings.Contains(got, "hunter2") || strings.Contains(got, "abc.def") {
+		t.Fatalf("preview leaked a secret: %s", got)
+	}
+	if !strings.Contains(got, `"password":"[REDACTED]"`) {
+		t.Fatalf("password not masked: %s", got)
+	}
+	if len(got) > PreviewLimit {
+		t.Fatalf("preview is %d bytes, want <= %d", len(got), PreviewLimit)
+	}
+}
@@ -0,0 +1,230 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// ReplayHeader is set to true on every replayed message, so consumers can
+// tell a replay from a live event (e.g. to skip side effects such as email).
+const ReplayHeader = "x-replay"
+
+// Offset is a position in a replay: events are replayed in ascending
+// (At, Key) order, where Key is a source-defined string that orders events
+// with the same At. A replay resumes strictly after an offset.
+type Offset struct {
+	At  time.Time
+	Key string
+}
+
+// IsZero reports whether o is the start of the replay.
+func (o Offset) IsZero() bool { return o.At.IsZero() && o.Key == "" }
+
+// String formats o for ParseOffset, e.g. for a --after flag.
+func (o Offset) String() string {
+	if o.IsZero() {
+		return ""
+	}
+	return o.At.UTC().Format(time.RFC3339Nano) + "|" + o.Key
+}
+
+// ParseOffset parses an Offset formatted by String; "" is the start.
+func ParseOffset(s string) (Offset, error) {
+	if s == "" {
+		return Offset{}, nil
+	}
+	at, key, ok := strings.Cut(s, "|")
+	if !ok || key == "" {
+		return Offset{}, fmt.Errorf("invalid offset %q: want <RFC 3339 time>|<key>", s)
+	}
+	t, err := time.Parse(time.RFC3339Nano, at)
+	if err != nil {
+		return Offset{}, fmt.Errorf("invalid offset %q: %w", s, err)
+	}
+	return Offset{At: t, Key: key}, nil
+}
+
+// ParseRate parses a publish rate such as "200/s", "600/m" or "50" (per
+// second) into events per second.
+func ParseRate(s string) (float64, error) {
+	n, unit, _ := strings.Cut(s, "/")
+	per := time.Second
+	switch unit {
+	case "", "s":
+	case "m":
+		per = time.Minute
+	case "h":
+		per = time.Hour
+	default:
+		return 0, fmt.Errorf("invalid rate %q: unit must be s, m or h", s)
+	}
+	v, err := strconv.ParseFloat(n, 64)
+	if err != nil || v <= 0 {
+		return 0, fmt.Errorf("invalid rate %q: want a positive number of events, e.g. 200/s", s)
+	}
+	return v / per.Seconds(), nil
+}
+
+// ReplayEvent is one event read from a Source.
+type ReplayEvent struct {
+	Offset   Offset
+	Envelope Envelope
+}
+
+// Source reads historical events, such as an outbox table or events
+// reconstructed from current rows.
+type Source interface {
+	// Next returns up to limit events strictly after the offset, in
+	// ascending offset order; fewer than limit means the source is drained.
+	Next(ctx context.Context, after Offset, limit int) ([]ReplayEvent, error)
+	// Count returns how many events remain after the offset, for progress.
+	Count(ctx context.Context, after Offset) (int, error)
+}
+
+// ReplayPublisher publishes one replayed event with the given headers. It
+// returns nil only once the broker has confirmed the message.
+type ReplayPublisher interface {
+	PublishReplay(ctx context.Context, env Envelope, headers map[string]any) error
+}
+
+// Replay defaults.
+const (
+	DefaultReplayBatchSize   = 500
+	DefaultReplayMaxFailures = 5
+	DefaultReplayRetryDelay  = time.Second
+)
+
+// ReplayOptions configures Replay.
+type ReplayOptions struct {
+	// Rate is the steady publish rate in events per second (token bucket);
+	// Burst is how many may go out at once after an idle spell (default 1).
+	Rate  float64
+	Burst int
+	// After resumes the replay after this offset, e.g. one reported by an
+	// earlier, interrupted run.
+	After     Offset
+	BatchSize int
+	// DryRun reads and counts every event without publishing or waiting on
+	// the rate limit.
+	DryRun bool
+	// MaxFailures is how many consecutive failed publishes (nacks or
+	// errors) of one event stop the replay; each is retried after
+	// RetryDelay, doubling.
+	MaxFailures int
+	RetryDelay  time.Duration
+	// Progress, when set, is called after each batch.
+	Progress func(ReplayProgress)
+}
+
+// ReplayProgress reports how far a replay has come. Offset is the last
+// event published (or counted, in a dry run); pass it as After to resume.
+type ReplayProgress struct {
+	Published int
+	Total     int
+	Offset    Offset
+	Elapsed   time.Duration
+}
+
+// ETA estimates the time left from the rate achieved so far.
+func (p ReplayProgress) ETA() time.Duration {
+	if p.Published == 0 || p.Total <= p.Published {
+		return 0
+	}
+	perEvent := p.Elapsed / time.Duration(p.Published)
+	return perEvent * time.Duration(p.Total-p.Published)
+}
+
+// ErrReplayStopped is returned when an event fails to publish MaxFailures
+// times in a row, e.g. because the broker keeps nacking.
+var ErrReplayStopped = errors.New("replay stopped: publishing keeps failing")
+
+// Replay reads every event from src after opts.After and publishes it
+// through pub, in order, no faster than opts.Rate, with ReplayHeader set.
+// On error the returned progress says where to resume.
+func Replay(ctx context.Context, src Source, pub ReplayPublisher, opts ReplayOptions) (ReplayProgress, error) {
+	if !opts.DryRun && opts.Rate <= 0 {
+		return ReplayProgress{}, errors.New("replay rate must be positive")
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultReplayBatchSize
+	}
+	maxFailures := opts.MaxFailures
+	if maxFailures <= 0 {
+		maxFailures = DefaultReplayMaxFailures
+	}
+	retryDelay := opts.RetryDelay
+	if retryDelay <= 0 {
+		retryDelay = DefaultReplayRetryDelay
+	}
+	burst := opts.Burst
+	if burst <= 0 {
+		burst = 1
+	}
+	limiter := rate.NewLimiter(rate.Limit(opts.Rate), burst)
+
+	start := time.Now()
+	progress := ReplayProgress{Offset: opts.After}
+	total, err := src.Count(ctx, opts.After)
+	if err != nil {
+		return progress, fmt.Errorf("count events: %w", err)
+	}
+	progress.Total = total
+
+	headers := map[string]any{ReplayHeader: true}
+	for {
+		batch, err := src.Next(ctx, progress.Offset, batchSize)
+		if err != nil {
+			return progress, fmt.Errorf("read events: %w", err)
+		}
+		for _, e := range batch {
+			if !opts.DryRun {
+				if err := limiter.Wait(ctx); err != nil {
+					return progress, err
+				}
+				if err := publishWithRetry(ctx, pub, e.Envelope, headers, maxFailures, retryDelay); err != nil {
+					return progress, err
+				}
+			}
+			progress.Published++
+			progress.Offset = e.Offset
+		}
+		progress.Elapsed = time.Since(start)
+		if progress.Published > progress.Total {
+			// Events were added behind the count; keep the ETA sane.
+			progress.Total = progress.Published
+		}
+		if opts.Progress != nil && len(batch) > 0 {
+			opts.Progress(progress)
+		}
+		if len(batch) < batchSize {
+			return progress, nil
+		}
+	}
+}
+
+// publishWithRetry publishes env, retrying the same event so order is kept,
+// and gives up after maxFailures consecutive failures.
+func publishWithRetry(ctx context.Context, pub ReplayPublisher, env Envelope, headers map[string]any, maxFailures int, delay time.Duration) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = pub.PublishReplay(ctx, env, headers); err == nil {
+			return nil
+		}
+		if attempt == maxFailures {
+			return fmt.Errorf("%w: event %s failed %d times: %v", ErrReplayStopped, env.ID, attempt, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
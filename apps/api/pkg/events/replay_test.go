@@ -0,0 +1,197 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+var replayEpoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// sliceSource serves events from memory, comparing offsets like the SQL
+// sources do.
+type sliceSource struct {
+	events []ReplayEvent
+}
+
+func newSliceSource(n int) *sliceSource {
+	s := &sliceSource{}
+	for i := 0; i < n; i++ {
+		at := replayEpoch.Add(time.Duration(i/2) * time.Minute) // two events per instant
+		key := fmt.Sprintf("u%03d", i)
+		s.events = append(s.events, ReplayEvent{
+			Offset:   Offset{At: at, Key: key},
+			Envelope: Envelope{ID: key, Type: "user.registered", OccurredAt: at},
+		})
+	}
+	return s
+}
+
+func (s *sliceSource) remaining(after Offset) []ReplayEvent {
+	for i, e := range s.events {
+		if e.Offset.At.After(after.At) || (e.Offset.At.Equal(after.At) && e.Offset.Key > after.Key) {
+			return s.events[i:]
+		}
+	}
+	return nil
+}
+
+func (s *sliceSource) Next(_ context.Context, after Offset, limit int) ([]ReplayEvent, error) {
+	rest := s.remaining(after)
+	return rest[:min(limit, len(rest))], nil
+}
+
+func (s *sliceSource) Count(_ context.Context, after Offset) (int, error) {
+	return len(s.remaining(after)), nil
+}
+
+// recordingPublisher records what it publishes after failing its first
+// failures calls with errNack.
+type recordingPublisher struct {
+	ids      []string
+	headers  []map[string]any
+	failures int
+}
+
+var errNack = errors.New("nacked")
+
+func (p *recordingPublisher) PublishReplay(_ context.Context, env Envelope, headers map[string]any) error {
+	if p.failures > 0 {
+		p.failures--
+		return errNack
+	}
+	p.ids = append(p.ids, env.ID)
+	p.headers = append(p.headers, headers)
+	return nil
+}
+
+func TestReplay_PublishesInOrderWithReplayHeader(t *testing.T) {
+	src, pub := newSliceSource(7), &recordingPublisher{}
+	var calls []ReplayProgress
+	progress, err := Replay(context.Background(), src, pub, ReplayOptions{
+		Rate: 1000, BatchSize: 3,
+		Progress: func(p ReplayProgress) { calls = append(calls, p) },
+	})
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if progress.Published != 7 || progress.Total != 7 || progress.Offset.Key != "u006" {
+		t.Fatalf("progress = %+v", progress)
+	}
+	if fmt.Sprint(pub.ids) != "[u000 u001 u002 u003 u004 u005 u006]" {
+		t.Fatalf("published %v, want every event in order", pub.ids)
+	}
+	for i, h := range pub.headers {
+		if h[ReplayHeader] != true {
+			t.Fatalf("message %d headers = %v, want %s=true", i, h, ReplayHeader)
+		}
+	}
+	if len(calls) != 3 || calls[0].Published != 3 || calls[0].Offset.Key != "u002" {
+		t.Fatalf("progress calls = %+v, want one per batch", calls)
+	}
+}
+
+func TestReplay_RateLimited(t *testing.T) {
+	src, pub := newSliceSource(11), &recordingPublisher{}
+	start := time.Now()
+	if _, err := Replay(context.Background(), src, pub, ReplayOptions{Rate: 100}); err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	// A burst of 1 sends the first event at once and one every 10ms after.
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Fatalf("11 events at 100/s took %s, want at least 100ms", elapsed)
+	}
+
+	// Canceling stops a replay waiting on the limiter.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	progress, err := Replay(ctx, newSliceSource(100), &recordingPublisher{}, ReplayOptions{Rate: 20})
+	if err == nil || progress.Published == 0 || progress.Published > 3 {
+		t.Fatalf("progress = %+v, err = %v; want a canceled replay after ~1 event", progress, err)
+	}
+}
+
+func TestReplay_ResumesAfterOffset(t *testing.T) {
+	src := newSliceSource(6)
+
+	// Stop the first run partway through by canceling from its progress.
+	ctx, cancel := context.WithCancel(context.Background())
+	first := &recordingPublisher{}
+	stopped, err := Replay(ctx, src, first, ReplayOptions{
+		Rate: 1000, BatchSize: 2,
+		Progress: func(ReplayProgress) { cancel() },
+	})
+	if err == nil || stopped.Published != 2 {
+		t.Fatalf("first run: progress = %+v, err = %v", stopped, err)
+	}
+
+	// The reported offset round-trips through its string form and resumes
+	// with the next event, including one at the same instant.
+	after, err := ParseOffset(stopped.Offset.String())
+	if err != nil || after != stopped.Offset {
+		t.Fatalf("ParseOffset(%q) = %+v, %v", stopped.Offset, after, err)
+	}
+	second := &recordingPublisher{}
+	progress, err := Replay(context.Background(), src, second, ReplayOptions{Rate: 1000, BatchSize: 2, After: after})
+	if err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	if progress.Total != 4 || fmt.Sprint(second.ids) != "[u002 u003 u004 u005]" {
+		t.Fatalf("resumed run published %v (total %d), want u002..u005", second.ids, progress.Total)
+	}
+}
+
+func TestReplay_StopsOnSustainedFailures(t *testing.T) {
+	opts := ReplayOptions{Rate: 1000, MaxFailures: 3, RetryDelay: time.Millisecond}
+
+	// Fewer failures than the limit are retried without losing the event.
+	pub := &recordingPublisher{failures: 2}
+	if _, err := Replay(context.Background(), newSliceSource(2), pub, opts); err != nil {
+		t.Fatalf("transient failures: %v", err)
+	}
+	if fmt.Sprint(pub.ids) != "[u000 u001]" {
+		t.Fatalf("published %v", pub.ids)
+	}
+
+	pub = &recordingPublisher{failures: 100}
+	progress, err := Replay(context.Background(), newSliceSource(2), pub, opts)
+	if !errors.Is(err, ErrReplayStopped) || progress.Published != 0 || !progress.Offset.IsZero() {
+		t.Fatalf("progress = %+v, err = %v; want ErrReplayStopped before the first event", progress, err)
+	}
+}
+
+func TestReplay_DryRunPublishesNothing(t *testing.T) {
+	src := newSliceSource(5)
+	progress, err := Replay(context.Background(), src, nil, ReplayOptions{DryRun: true, BatchSize: 2})
+	if err != nil || progress.Published != 5 || progress.Offset.Key != "u004" {
+		t.Fatalf("progress = %+v, err = %v", progress, err)
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	for in, want := range map[string]float64{"200/s": 200, "50": 50, "600/m": 10, "7200/h": 2} {
+		if got, err := ParseRate(in); err != nil || got != want {
+			t.Errorf("ParseRate(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "0/s", "-1", "fast", "10/d"} {
+		if _, err := ParseRate(in); err == nil {
+			t.Errorf("ParseRate(%q) succeeded", in)
+		}
+	}
+}
+
+func TestParseOffset_RejectsMissingKey(t *testing.T) {
+	if _, err := ParseOffset("2025-01-01T00:00:00Z"); err == nil {
+		t.Fatal("ParseOffset accepted an offset without a key")
+	}
+}
+
+func TestReplayProgress_ETA(t *testing.T) {
+	p := ReplayProgress{Published: 100, Total: 400, Elapsed: 10 * time.Second}
+	if p.ETA() != 30*time.Second {
+		t.Fatalf("ETA = %s, want 30s", p.ETA())
+	}
+}
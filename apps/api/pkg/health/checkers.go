@@ -0,0 +1,44 @@
+package health
+
+import (
+	"context"
+
+	"veemon/pkg/database"
+	"veemon/pkg/rabbitmq"
+	"veemon/pkg/redis"
+
+	"gorm.io/gorm"
+)
+
+// Database checks db by pinging its connection pool.
+func Database(db *gorm.DB) Checker {
+	return CheckerFunc("database", func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	})
+}
+
+// DatabaseReplicas pings the read replicas, marking those that fail down
+// so their reads go to the primary; a failure therefore only degrades the
+// report.
+func DatabaseReplicas(r *database.Replicas) Checker {
+	return CheckerFunc("database_replicas", func(ctx context.Context) error {
+		return Degraded(r.Check(ctx))
+	})
+}
+
+// Redis checks r with a PING.
+func Redis(r *redis.Client) Checker {
+	return CheckerFunc("redis", r.Ping)
+}
+
+// RabbitMQ checks that c's connection and channel are open. It does no round
+// trip, so it never blocks on the broker.
+func RabbitMQ(c *rabbitmq.Client) Checker {
+	return CheckerFunc("rabbitmq", func(context.Context) error {
+		return c.Ping()
+	})
+}
@@ -0,0 +1,97 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// DefaultWatchInterval is how often a GRPCWatcher re-runs the checks.
+const DefaultWatchInterval = 5 * time.Second
+
+// GRPCWatcher serves the gRPC health checking protocol (grpc.health.v1) from
+// a Registry: a background goroutine runs the same checks as /ready and
+// reports every service SERVING while they pass, NOT_SERVING otherwise.
+type GRPCWatcher struct {
+	registry *Registry
+	server   *grpchealth.Server
+	services []string
+	interval time.Duration
+	logger   *zap.Logger
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewGRPCWatcher reports r's status for the overall server ("") and each of
+// services. Every status is NOT_SERVING until Start runs the first check; a
+// non-positive interval means DefaultWatchInterval.
+func NewGRPCWatcher(r *Registry, interval time.Duration, services ...string) *GRPCWatcher {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	w := &GRPCWatcher{
+		registry: r,
+		server:   grpchealth.NewServer(),
+		services: append([]string{""}, services...),
+		interval: interval,
+		logger:   r.logger,
+		stop:     make(chan struct{}),
+	}
+	w.set(healthpb.HealthCheckResponse_NOT_SERVING)
+	return w
+}
+
+// Server is the health service to register on the gRPC server.
+func (w *GRPCWatcher) Server() healthpb.HealthServer { return w.server }
+
+// Start checks once, then keeps checking every interval until Shutdown.
+func (w *GRPCWatcher) Start() {
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		serving := w.check(healthpb.HealthCheckResponse_NOT_SERVING)
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C:
+				serving = w.check(serving)
+			}
+		}
+	}()
+}
+
+// Shutdown stops the checks and reports NOT_SERVING for good, so load
+// balancers drain the server before it stops; a check still running when
+// it is called cannot undo that. Call it before GracefulStop.
+func (w *GRPCWatcher) Shutdown() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+		w.server.Shutdown()
+	})
+}
+
+// check runs the registry and publishes the status, logging a change from
+// prev.
+func (w *GRPCWatcher) check(prev healthpb.HealthCheckResponse_ServingStatus) healthpb.HealthCheckResponse_ServingStatus {
+	status := healthpb.HealthCheckResponse_SERVING
+	if !w.registry.Run(context.Background()).Healthy() {
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	if status != prev {
+		w.logger.Info("gRPC health status changed", zap.Stringer("status", status))
+	}
+	w.set(status)
+	return status
+}
+
+func (w *GRPCWatcher) set(status healthpb.HealthCheckResponse_ServingStatus) {
+	for _, s := range w.services {
+		w.server.SetServingStatus(s, status)
+	}
+}
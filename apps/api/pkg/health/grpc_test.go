@@ -0,0 +1,100 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// serveHealth serves w over an in-memory listener and returns a client.
+func serveHealth(t *testing.T, w *GRPCWatcher) healthpb.HealthClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, w.Server())
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return healthpb.NewHealthClient(conn)
+}
+
+func servingStatus(t *testing.T, client healthpb.HealthClient, service string) healthpb.HealthCheckResponse_ServingStatus {
+	t.Helper()
+	resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	require.NoError(t, err)
+	return resp.GetStatus()
+}
+
+func TestGRPCWatcher_FollowsTheChecks(t *testing.T) {
+	var down atomic.Bool
+	r := New(WithCacheTTL(0))
+	r.Register(CheckerFunc("database", func(context.Context) error {
+		if down.Load() {
+			return errors.New("connection refused")
+		}
+		return nil
+	}))
+	w := NewGRPCWatcher(r, 10*time.Millisecond, "user.UserApi")
+	client := serveHealth(t, w)
+	t.Cleanup(w.Shutdown)
+
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, servingStatus(t, client, ""), "before the first check")
+	w.Start()
+	for _, service := range []string{"", "user.UserApi"} {
+		assert.Eventually(t, func() bool {
+			return servingStatus(t, client, service) == healthpb.HealthCheckResponse_SERVING
+		}, time.Second, 5*time.Millisecond, service)
+	}
+
+	down.Store(true)
+	assert.Eventually(t, func() bool {
+		return servingStatus(t, client, "user.UserApi") == healthpb.HealthCheckResponse_NOT_SERVING
+	}, time.Second, 5*time.Millisecond)
+
+	down.Store(false)
+	assert.Eventually(t, func() bool {
+		return servingStatus(t, client, "user.UserApi") == healthpb.HealthCheckResponse_SERVING
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestGRPCWatcher_ShutdownStopsServing(t *testing.T) {
+	r := New(WithCacheTTL(0))
+	r.Register(healthy("database"))
+	w := NewGRPCWatcher(r, 10*time.Millisecond)
+	client := serveHealth(t, w)
+	w.Start()
+	require.Eventually(t, func() bool {
+		return servingStatus(t, client, "") == healthpb.HealthCheckResponse_SERVING
+	}, time.Second, 5*time.Millisecond)
+
+	w.Shutdown()
+	w.Shutdown() // idempotent
+
+	// Later ticks would find the checks healthy; the status must stick.
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, servingStatus(t, client, ""))
+}
+
+func TestGRPCWatcher_UnknownService(t *testing.T) {
+	w := NewGRPCWatcher(New(), 0)
+	client := serveHealth(t, w)
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "nope"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,291 @@
+// Package health runs the dependency checks behind the /ready probe and
+// serves the /health and /ready endpoints.
+//
+// A Registry runs its checkers in parallel, each bounded by its own timeout,
+// so one hung dependency costs a probe at most that timeout and cannot hide
+// the state of the others. A check that ignores its context is abandoned at
+// the deadline and reported unhealthy; its goroutine finishes on its own.
+// Reports are cached briefly (DefaultCacheTTL), so frequent probes from
+// several orchestrators add no load on the dependencies, and concurrent
+// probes during a run wait for it rather than starting their own.
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultTimeout bounds a check registered without its own timeout.
+	DefaultTimeout = 2 * time.Second
+	// DefaultCacheTTL is how long a report is reused.
+	DefaultCacheTTL = 2 * time.Second
+)
+
+// Check statuses.
+const (
+	StatusHealthy   = "healthy"
+	StatusUnhealthy = "unhealthy"
+	// StatusDisabled marks an optional dependency that is not configured.
+	StatusDisabled = "disabled"
+	// StatusDegraded marks a failing dependency the server can work
+	// without (see Degraded); it does not fail the report.
+	StatusDegraded = "degraded"
+)
+
+// Report statuses.
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+	// StatusDraining is the report of a server shutting down (see Drain).
+	StatusDraining = "draining"
+)
+
+// ErrTimeout is the error of a check that did not finish within its timeout.
+var ErrTimeout = errors.New("health check timed out")
+
+type degradedError struct{ err error }
+
+func (e degradedError) Error() string { return e.err.Error() }
+func (e degradedError) Unwrap() error { return e.err }
+
+// Degraded wraps the error of a check whose dependency has a fallback, so
+// the check reports StatusDegraded instead of failing readiness. It returns
+// nil for a nil err.
+func Degraded(err error) error {
+	if err == nil {
+		return nil
+	}
+	return degradedError{err}
+}
+
+// Checker checks one dependency.
+type Checker interface {
+	Name() string
+	// Check returns nil when the dependency is usable. It should give up
+	// when ctx ends.
+	Check(ctx context.Context) error
+}
+
+type checkerFunc struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+func (c checkerFunc) Name() string                    { return c.name }
+func (c checkerFunc) Check(ctx context.Context) error { return c.fn(ctx) }
+
+// CheckerFunc adapts fn into a Checker named name.
+func CheckerFunc(name string, fn func(ctx context.Context) error) Checker {
+	return checkerFunc{name: name, fn: fn}
+}
+
+// Result is the outcome of one check.
+type Result struct {
+	Status string `json:"status"`
+	// LatencyMs is how long the check took; for a timed-out check, its
+	// timeout.
+	LatencyMs float64 `json:"latencyMs"`
+}
+
+// Report is the outcome of a run: StatusOK unless a check is unhealthy.
+type Report struct {
+	Status    string            `json:"status"`
+	Checks    map[string]Result `json:"checks"`
+	CheckedAt time.Time         `json:"checkedAt"`
+}
+
+// Healthy reports whether no check failed.
+func (r Report) Healthy() bool { return r.Status == StatusOK }
+
+type registered struct {
+	checker Checker
+	timeout time.Duration
+}
+
+// Registry holds the checkers and the last report.
+type Registry struct {
+	timeout  time.Duration
+	cacheTTL time.Duration
+	logger   *zap.Logger
+	now      func() time.Time
+
+	mu       sync.Mutex // held for a whole run, so concurrent probes share it
+	checkers []registered
+	disabled []string
+	last     *Report
+
+	draining atomic.Bool
+}
+
+// Option configures a Registry.
+type Option func(*Registry)
+
+// WithTimeout sets the timeout of checks registered without their own;
+// non-positive keeps DefaultTimeout.
+func WithTimeout(d time.Duration) Option {
+	return func(r *Registry) {
+		if d > 0 {
+			r.timeout = d
+		}
+	}
+}
+
+// WithCacheTTL sets how long a report is reused; zero disables caching and
+// negative keeps DefaultCacheTTL.
+func WithCacheTTL(d time.Duration) Option {
+	return func(r *Registry) {
+		if d >= 0 {
+			r.cacheTTL = d
+		}
+	}
+}
+
+// WithLogger logs failing checks with their error, which the probe response
+// leaves out.
+func WithLogger(l *zap.Logger) Option {
+	return func(r *Registry) { r.logger = l }
+}
+
+// New builds an empty Registry.
+func New(opts ...Option) *Registry {
+	r := &Registry{
+		timeout:  DefaultTimeout,
+		cacheTTL: DefaultCacheTTL,
+		logger:   zap.NewNop(),
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Register adds c with the registry's timeout.
+func (r *Registry) Register(c Checker) {
+	r.RegisterWithTimeout(c, 0)
+}
+
+// RegisterWithTimeout adds c, bounding each of its checks by timeout
+// (non-positive means the registry's).
+func (r *Registry) RegisterWithTimeout(c Checker, timeout time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checkers = append(r.checkers, registered{checker: c, timeout: timeout})
+	r.last = nil
+}
+
+// Disable lists name as an unconfigured optional dependency: it appears in
+// reports as StatusDisabled and never fails them.
+func (r *Registry) Disable(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.disabled = append(r.disabled, name)
+	r.last = nil
+}
+
+// Drain marks the server as shutting down: from then on every report is
+// StatusDraining, without running the checks, so load balancers stop
+// routing here while in-flight requests finish. It cannot be undone.
+func (r *Registry) Drain() {
+	r.draining.Store(true)
+}
+
+// Run checks every dependency, or returns the cached report while it is
+// fresh.
+func (r *Registry) Run(ctx context.Context) Report {
+	if r.draining.Load() {
+		return Report{Status: StatusDraining, Checks: map[string]Result{}, CheckedAt: r.now()}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.last != nil && r.now().Sub(r.last.CheckedAt) < r.cacheTTL {
+		return *r.last
+	}
+
+	results := make([]Result, len(r.checkers))
+	var wg sync.WaitGroup
+	for i, reg := range r.checkers {
+		wg.Add(1)
+		go func(i int, reg registered) {
+			defer wg.Done()
+			results[i] = r.check(ctx, reg)
+		}(i, reg)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(results)+len(r.disabled)), CheckedAt: r.now()}
+	for _, name := range r.disabled {
+		report.Checks[name] = Result{Status: StatusDisabled}
+	}
+	for i, reg := range r.checkers {
+		report.Checks[reg.checker.Name()] = results[i]
+		if results[i].Status == StatusUnhealthy {
+			report.Status = StatusUnavailable
+		}
+	}
+	r.last = &report
+	return report
+}
+
+// check runs one checker within its timeout.
+func (r *Registry) check(ctx context.Context, reg registered) Result {
+	timeout := reg.timeout
+	if timeout <= 0 {
+		timeout = r.timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- reg.checker.Check(ctx) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ErrTimeout
+	}
+	latency := time.Since(start)
+
+	res := Result{Status: StatusHealthy, LatencyMs: float64(latency) / float64(time.Millisecond)}
+	if err != nil {
+		res.Status = StatusUnhealthy
+		if errors.As(err, new(degradedError)) {
+			res.Status = StatusDegraded
+		}
+		r.logger.Warn("health check failed",
+			zap.String("check", reg.checker.Name()),
+			zap.Duration("latency", latency),
+			zap.Error(err),
+		)
+	}
+	return res
+}
+
+// Mount serves /health, the liveness probe, which checks nothing and always
+// answers 200 while the process serves requests, and /ready, the readiness
+// probe, which answers the report with 200, or 503 when it is not healthy.
+func (r *Registry) Mount(router fiber.Router, service string) {
+	router.Get("/health", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{
+			"status":  StatusOK,
+			"service": service,
+		})
+	})
+	router.Get("/ready", func(c *fiber.Ctx) error {
+		report := r.Run(c.UserContext())
+		status := fiber.StatusOK
+		if !report.Healthy() {
+			status = fiber.StatusServiceUnavailable
+		}
+		return c.Status(status).JSON(report)
+	})
+}
@@ -0,0 +1,232 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"veemon/pkg/rabbitmq"
+	"veemon/pkg/redis"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func healthy(name string) Checker {
+	return CheckerFunc(name, func(context.Context) error { return nil })
+}
+
+func failing(name string) Checker {
+	return CheckerFunc(name, func(context.Context) error { return errors.New("down") })
+}
+
+// hanging ignores its context and returns only when release is closed.
+func hanging(name string, release <-chan struct{}) Checker {
+	return CheckerFunc(name, func(context.Context) error {
+		<-release
+		return nil
+	})
+}
+
+func TestRun_ReportsEveryCheck(t *testing.T) {
+	reg := New(WithCacheTTL(0))
+	reg.Register(healthy("database"))
+	reg.Register(failing("redis"))
+	reg.Disable("rabbitmq")
+
+	report := reg.Run(context.Background())
+
+	assert.Equal(t, StatusUnavailable, report.Status)
+	assert.False(t, report.Healthy())
+	assert.Equal(t, StatusHealthy, report.Checks["database"].Status)
+	assert.Equal(t, StatusUnhealthy, report.Checks["redis"].Status)
+	assert.Equal(t, Result{Status: StatusDisabled}, report.Checks["rabbitmq"])
+}
+
+func TestRun_DisabledChecksDoNotFailTheReport(t *testing.T) {
+	reg := New()
+	reg.Register(healthy("database"))
+	reg.Disable("redis")
+
+	assert.True(t, reg.Run(context.Background()).Healthy())
+}
+
+func TestRun_DegradedChecksDoNotFailTheReport(t *testing.T) {
+	reg := New()
+	reg.Register(healthy("database"))
+	reg.Register(CheckerFunc("database_replicas", func(context.Context) error {
+		return Degraded(errors.New("replica down"))
+	}))
+	reg.Register(CheckerFunc("cache", func(context.Context) error { return Degraded(nil) }))
+
+	report := reg.Run(context.Background())
+	assert.True(t, report.Healthy())
+	assert.Equal(t, StatusDegraded, report.Checks["database_replicas"].Status)
+	assert.Equal(t, StatusHealthy, report.Checks["cache"].Status)
+}
+
+func TestRun_HungCheckTimesOutWithoutDelayingOthers(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	reg := New(WithTimeout(time.Second), WithCacheTTL(0))
+	reg.RegisterWithTimeout(hanging("rabbitmq", release), 50*time.Millisecond)
+	reg.Register(healthy("database"))
+
+	start := time.Now()
+	report := reg.Run(context.Background())
+
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, StatusUnhealthy, report.Checks["rabbitmq"].Status)
+	assert.GreaterOrEqual(t, report.Checks["rabbitmq"].LatencyMs, 50.0)
+	assert.Equal(t, StatusHealthy, report.Checks["database"].Status)
+}
+
+func TestRun_ChecksRunInParallel(t *testing.T) {
+	slow := func(name string) Checker {
+		return CheckerFunc(name, func(ctx context.Context) error {
+			select {
+			case <-time.After(100 * time.Millisecond):
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}
+	reg := New(WithCacheTTL(0))
+	reg.Register(slow("a"))
+	reg.Register(slow("b"))
+	reg.Register(slow("c"))
+
+	start := time.Now()
+	report := reg.Run(context.Background())
+
+	assert.True(t, report.Healthy())
+	assert.Less(t, time.Since(start), 250*time.Millisecond)
+	assert.GreaterOrEqual(t, report.Checks["a"].LatencyMs, 100.0)
+}
+
+func TestRun_CachesReports(t *testing.T) {
+	var calls atomic.Int32
+	reg := New(WithCacheTTL(time.Minute))
+	now := time.Unix(1000, 0)
+	reg.now = func() time.Time { return now }
+	reg.Register(CheckerFunc("database", func(context.Context) error {
+		calls.Add(1)
+		return nil
+	}))
+
+	reg.Run(context.Background())
+	reg.Run(context.Background())
+	assert.Equal(t, int32(1), calls.Load())
+
+	now = now.Add(time.Minute)
+	reg.Run(context.Background())
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestMount(t *testing.T) {
+	reg := New()
+	reg.Register(healthy("database"))
+	reg.Register(failing("redis"))
+	app := fiber.New()
+	reg.Mount(app, "veemon")
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/health", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/ready", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusServiceUnavailable, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	var got struct {
+		Status string                    `json:"status"`
+		Checks map[string]map[string]any `json:"checks"`
+	}
+	require.NoError(t, json.Unmarshal(body, &got))
+	assert.Equal(t, StatusUnavailable, got.Status)
+	assert.Equal(t, StatusUnhealthy, got.Checks["redis"]["status"])
+	assert.Contains(t, got.Checks["database"], "latencyMs")
+	assert.NotContains(t, string(body), "down", "check errors must not be exposed")
+}
+
+func TestDrain_FailsReadinessWithoutChecking(t *testing.T) {
+	var calls atomic.Int32
+	reg := New(WithCacheTTL(0))
+	reg.Register(CheckerFunc("database", func(context.Context) error {
+		calls.Add(1)
+		return nil
+	}))
+	app := fiber.New()
+	reg.Mount(app, "veemon")
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/ready", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	reg.Drain()
+	resp, err = app.Test(httptest.NewRequest("GET", "/ready", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusServiceUnavailable, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `"status":"draining"`)
+	assert.Equal(t, int32(1), calls.Load(), "checks ran while draining")
+
+	// Liveness is unaffected: the process is still up.
+	resp, err = app.Test(httptest.NewRequest("GET", "/health", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
+
+func TestDatabase(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	mock.ExpectPing() // gorm.Open
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{})
+	require.NoError(t, err)
+
+	check := Database(db)
+	assert.Equal(t, "database", check.Name())
+
+	mock.ExpectPing()
+	assert.NoError(t, check.Check(context.Background()))
+	mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	assert.Error(t, check.Check(context.Background()))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRedis(t *testing.T) {
+	mr := miniredis.RunT(t)
+	port, _ := strconv.Atoi(mr.Port())
+	rc, err := redis.New(redis.Config{Host: mr.Host(), Port: port, MaxIdle: 1, MaxActive: 2})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = rc.Close() })
+
+	check := Redis(rc)
+	assert.Equal(t, "redis", check.Name())
+	assert.NoError(t, check.Check(context.Background()))
+
+	mr.SetError("LOADING Redis is loading the dataset in memory")
+	assert.Error(t, check.Check(context.Background()))
+}
+
+func TestRabbitMQ_Disconnected(t *testing.T) {
+	check := RabbitMQ(&rabbitmq.Client{})
+	assert.Equal(t, "rabbitmq", check.Name())
+	assert.Error(t, check.Check(context.Background()))
+}
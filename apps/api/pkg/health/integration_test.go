@@ -0,0 +1,85 @@
+//go:build integration
+
+// Integration tests that require a real PostgreSQL (run with:
+//
+//	go test -tags integration ./pkg/health/...
+//
+// with DB_* env vars pointing at a reachable database).
+package health_test
+
+import (
+	"context"
+	"net"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"veemon/pkg/database"
+	"veemon/pkg/health"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// The gRPC health service follows the database ping: SERVING while it
+// answers, NOT_SERVING once the pool is gone, and NOT_SERVING for good after
+// Shutdown, as a load balancer would see it through the health client.
+func TestIntegration_GRPCHealthFollowsTheDatabase(t *testing.T) {
+	port, _ := strconv.Atoi(envOr("DB_PORT", "5432"))
+	db, err := database.New(database.Config{
+		Host:     envOr("DB_HOST", "localhost"),
+		Port:     port,
+		User:     envOr("DB_USER", "postgres"),
+		Password: envOr("DB_PASSWORD", "postgres"),
+		Name:     envOr("DB_NAME", "veemon_db"),
+		SSLMode:  envOr("DB_SSL_MODE", "disable"),
+		Timezone: envOr("DB_TIMEZONE", "UTC"),
+	}, zap.NewNop())
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+
+	checks := health.New(health.WithCacheTTL(0))
+	checks.Register(health.Database(db))
+	watcher := health.NewGRPCWatcher(checks, 20*time.Millisecond, "user.UserApi")
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, watcher.Server())
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+	watcher.Start()
+	t.Cleanup(watcher.Shutdown)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	client := healthpb.NewHealthClient(conn)
+	status := func() healthpb.HealthCheckResponse_ServingStatus {
+		resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "user.UserApi"})
+		require.NoError(t, err)
+		return resp.GetStatus()
+	}
+
+	require.Eventually(t, func() bool { return status() == healthpb.HealthCheckResponse_SERVING },
+		5*time.Second, 20*time.Millisecond)
+
+	require.NoError(t, sqlDB.Close())
+	require.Eventually(t, func() bool { return status() == healthpb.HealthCheckResponse_NOT_SERVING },
+		5*time.Second, 20*time.Millisecond)
+
+	watcher.Shutdown()
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status())
+}
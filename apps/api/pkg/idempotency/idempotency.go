@@ -0,0 +1,195 @@
+// Package idempotency lets clients retry mutating requests safely: a request
+// carrying an Idempotency-Key header runs once, and repeats of it are
+// answered with the stored response instead of running again.
+//
+// The first request with a key takes a lock in Redis (SET NX), runs, and
+// replaces the lock with its response (status, Content-Type and body) for
+// Config.TTL. A repeat arriving while it runs waits up to Config.Wait for the
+// response, then gets 409 and may retry later. A key reused with a different
+// request (method, path, body or Authorization header) gets 422, so one
+// caller can never be served another's response. Responses with status 5xx
+// or 429 are not stored: the request did not complete, so a retry runs it
+// again.
+//
+// Redis is only an aid: when it fails, requests run as if they carried no
+// key.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"veemon/pkg/errors"
+	"veemon/pkg/redis"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	// Header carries the client's key.
+	Header = "Idempotency-Key"
+	// ReplayedHeader is set to "true" on a replayed response.
+	ReplayedHeader = "Idempotent-Replayed"
+	// MaxKeyLength bounds the key; clients typically send a UUID.
+	MaxKeyLength = 255
+
+	DefaultTTL      = 24 * time.Hour
+	DefaultLockTTL  = time.Minute
+	DefaultWait     = 2 * time.Second
+	defaultPollStep = 50 * time.Millisecond
+)
+
+// Config tunes the middleware; zero fields take the defaults.
+type Config struct {
+	// TTL is how long a response is replayed for its key.
+	TTL time.Duration
+	// LockTTL bounds the lock of a running request, so a key whose request
+	// died with its process becomes usable again. Keep it above the request
+	// timeout.
+	LockTTL time.Duration
+	// Wait is how long a repeat waits for the running request to finish
+	// before it gets 409.
+	Wait time.Duration
+}
+
+func (c Config) withDefaults() Config {
+	if c.TTL <= 0 {
+		c.TTL = DefaultTTL
+	}
+	if c.LockTTL <= 0 {
+		c.LockTTL = DefaultLockTTL
+	}
+	if c.Wait <= 0 {
+		c.Wait = DefaultWait
+	}
+	return c
+}
+
+// record is the value stored under a key: a lock while Status is zero, then
+// the response.
+type record struct {
+	Fingerprint string `json:"fingerprint"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+func (r record) done() bool { return r.Status != 0 }
+
+func keyReused() *errors.AppError {
+	return errors.New(http.StatusUnprocessableEntity, codes.FailedPrecondition, 42201,
+		"idempotency key was already used for a different request")
+}
+
+func inProgress() *errors.AppError {
+	return errors.Conflict(40905, "a request with this idempotency key is still in progress; retry later")
+}
+
+// Middleware makes requests carrying Header idempotent; requests without it
+// pass through. Mount it only on the routes that should honor keys.
+func Middleware(r *redis.Client, cfg Config, log *zap.Logger) fiber.Handler {
+	cfg = cfg.withDefaults()
+	return func(c *fiber.Ctx) error {
+		key := c.Get(Header)
+		if key == "" {
+			return c.Next()
+		}
+		if len(key) > MaxKeyLength {
+			return errors.BadRequest(400, "Idempotency-Key must be at most 255 characters").FiberError(c)
+		}
+
+		ctx := c.UserContext()
+		redisKey := "idempotency:" + key
+		fp := fingerprint(c)
+		deadline := time.Now().Add(cfg.Wait)
+		for {
+			locked, err := r.SetNX(ctx, redisKey, record{Fingerprint: fp}, cfg.LockTTL)
+			if err != nil {
+				log.Warn("idempotency: lock failed; running without it", zap.Error(err))
+				return c.Next()
+			}
+			if locked {
+				return run(c, r, redisKey, fp, cfg, log)
+			}
+
+			var stored record
+			switch err := r.Get(ctx, redisKey, &stored); {
+			case err == redis.ErrNil:
+				// Released by a request that did not complete, or expired.
+				continue
+			case err != nil:
+				log.Warn("idempotency: read failed; running without it", zap.Error(err))
+				return c.Next()
+			}
+			if stored.Fingerprint != fp {
+				return keyReused().FiberError(c)
+			}
+			if stored.done() {
+				c.Set(ReplayedHeader, "true")
+				if stored.ContentType != "" {
+					c.Set(fiber.HeaderContentType, stored.ContentType)
+				}
+				return c.Status(stored.Status).Send(stored.Body)
+			}
+			if !time.Now().Before(deadline) {
+				return inProgress().FiberError(c)
+			}
+			select {
+			case <-time.After(defaultPollStep):
+			case <-ctx.Done():
+				return inProgress().FiberError(c)
+			}
+		}
+	}
+}
+
+// run serves the request holding the lock, then stores its response or, if
+// it did not complete, releases the key.
+func run(c *fiber.Ctx, r *redis.Client, redisKey, fp string, cfg Config, log *zap.Logger) error {
+	err := c.Next()
+	// The request's context may be canceled by now; the key must still be
+	// settled.
+	ctx := context.WithoutCancel(c.UserContext())
+
+	status := c.Response().StatusCode()
+	if err != nil || status >= 500 || status == fiber.StatusTooManyRequests {
+		// An error has not been rendered yet (Fiber's ErrorHandler runs later),
+		// and is not stored either.
+		if derr := r.Delete(ctx, redisKey); derr != nil {
+			log.Warn("idempotency: release failed; the key stays locked until it expires", zap.Error(derr))
+		}
+		return err
+	}
+
+	rec := record{
+		Fingerprint: fp,
+		Status:      status,
+		ContentType: utils.CopyString(string(c.Response().Header.ContentType())),
+		Body:        append([]byte(nil), c.Response().Body()...),
+	}
+	if serr := r.Set(ctx, redisKey, rec, cfg.TTL); serr != nil {
+		log.Warn("idempotency: storing the response failed", zap.Error(serr))
+	}
+	return nil
+}
+
+// fingerprint identifies the request a key was first used for.
+func fingerprint(c *fiber.Ctx) string {
+	h := sha256.New()
+	for _, part := range [][]byte{
+		[]byte(c.Method()),
+		[]byte(c.Path()),
+		[]byte(c.Get(fiber.HeaderAuthorization)),
+		c.Body(),
+	} {
+		h.Write(part)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,186 @@
+package idempotency
+
+import (
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"veemon/pkg/redis"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type testApp struct {
+	app   *fiber.App
+	mr    *miniredis.Miniredis
+	calls atomic.Int32
+	// status is answered by the handler.
+	status atomic.Int32
+	// release, when set, blocks the handler until closed.
+	release chan struct{}
+}
+
+func newTestApp(t *testing.T, cfg Config) *testApp {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	port, _ := strconv.Atoi(mr.Port())
+	rc, err := redis.New(redis.Config{Host: mr.Host(), Port: port, MaxIdle: 2, MaxActive: 8})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = rc.Close() })
+
+	ta := &testApp{app: fiber.New(), mr: mr}
+	ta.status.Store(fiber.StatusCreated)
+	ta.app.Use("/register", Middleware(rc, cfg, zap.NewNop()))
+	ta.app.Post("/register", func(c *fiber.Ctx) error {
+		n := ta.calls.Add(1)
+		if ta.release != nil {
+			<-ta.release
+		}
+		return c.Status(int(ta.status.Load())).JSON(fiber.Map{"call": n})
+	})
+	return ta
+}
+
+type result struct {
+	status   int
+	body     string
+	replayed bool
+}
+
+func (ta *testApp) post(t *testing.T, key, body string) result {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/register", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(Header, key)
+	}
+	resp, err := ta.app.Test(req, -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(resp.Body)
+	return result{resp.StatusCode, string(raw), resp.Header.Get(ReplayedHeader) == "true"}
+}
+
+func TestMiddleware_ReplaysStoredResponse(t *testing.T) {
+	ta := newTestApp(t, Config{TTL: time.Hour})
+
+	first := ta.post(t, "k1", `{"email":"a@b.com"}`)
+	second := ta.post(t, "k1", `{"email":"a@b.com"}`)
+
+	assert.Equal(t, result{fiber.StatusCreated, `{"call":1}`, false}, first)
+	assert.Equal(t, result{fiber.StatusCreated, `{"call":1}`, true}, second)
+	assert.Equal(t, int32(1), ta.calls.Load())
+	assert.Equal(t, time.Hour, ta.mr.TTL("idempotency:k1"))
+}
+
+func TestMiddleware_WithoutKeyAlwaysRuns(t *testing.T) {
+	ta := newTestApp(t, Config{})
+
+	ta.post(t, "", `{}`)
+	ta.post(t, "", `{}`)
+	assert.Equal(t, int32(2), ta.calls.Load())
+}
+
+func TestMiddleware_KeyReusedForDifferentRequestIs422(t *testing.T) {
+	ta := newTestApp(t, Config{})
+
+	ta.post(t, "k1", `{"email":"a@b.com"}`)
+	res := ta.post(t, "k1", `{"email":"other@b.com"}`)
+
+	assert.Equal(t, fiber.StatusUnprocessableEntity, res.status)
+	assert.Contains(t, res.body, `"code":42201`)
+	assert.Equal(t, int32(1), ta.calls.Load())
+}
+
+func TestMiddleware_ServerErrorsAreNotStored(t *testing.T) {
+	ta := newTestApp(t, Config{})
+	ta.status.Store(fiber.StatusServiceUnavailable)
+
+	assert.Equal(t, fiber.StatusServiceUnavailable, ta.post(t, "k1", `{}`).status)
+	assert.False(t, ta.mr.Exists("idempotency:k1"), "a failed request must release its key")
+
+	ta.status.Store(fiber.StatusCreated)
+	res := ta.post(t, "k1", `{}`)
+	assert.Equal(t, fiber.StatusCreated, res.status)
+	assert.False(t, res.replayed)
+	assert.Equal(t, int32(2), ta.calls.Load())
+}
+
+func TestMiddleware_ClientErrorsAreReplayed(t *testing.T) {
+	ta := newTestApp(t, Config{})
+	ta.status.Store(fiber.StatusConflict)
+
+	ta.post(t, "k1", `{}`)
+	res := ta.post(t, "k1", `{}`)
+	assert.Equal(t, fiber.StatusConflict, res.status)
+	assert.True(t, res.replayed)
+	assert.Equal(t, int32(1), ta.calls.Load())
+}
+
+func TestMiddleware_ConcurrentDuplicateWaitsForTheFirst(t *testing.T) {
+	ta := newTestApp(t, Config{Wait: 5 * time.Second})
+	ta.release = make(chan struct{})
+
+	var wg sync.WaitGroup
+	results := make([]result, 2)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		results[0] = ta.post(t, "k1", `{}`)
+	}()
+	require.Eventually(t, func() bool { return ta.calls.Load() == 1 }, time.Second, 5*time.Millisecond)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		results[1] = ta.post(t, "k1", `{}`)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	close(ta.release)
+	wg.Wait()
+
+	assert.Equal(t, result{fiber.StatusCreated, `{"call":1}`, false}, results[0])
+	assert.Equal(t, result{fiber.StatusCreated, `{"call":1}`, true}, results[1])
+	assert.Equal(t, int32(1), ta.calls.Load())
+}
+
+func TestMiddleware_ConcurrentDuplicateGets409AfterWait(t *testing.T) {
+	ta := newTestApp(t, Config{Wait: 100 * time.Millisecond})
+	ta.release = make(chan struct{})
+
+	done := make(chan result)
+	go func() { done <- ta.post(t, "k1", `{}`) }()
+	require.Eventually(t, func() bool { return ta.calls.Load() == 1 }, time.Second, 5*time.Millisecond)
+
+	res := ta.post(t, "k1", `{}`)
+	assert.Equal(t, fiber.StatusConflict, res.status)
+	assert.Contains(t, res.body, `"code":40905`)
+
+	close(ta.release)
+	assert.Equal(t, fiber.StatusCreated, (<-done).status)
+}
+
+func TestMiddleware_RedisDownRunsTheRequest(t *testing.T) {
+	ta := newTestApp(t, Config{})
+	ta.mr.SetError("LOADING Redis is loading the dataset in memory")
+
+	assert.Equal(t, fiber.StatusCreated, ta.post(t, "k1", `{}`).status)
+	assert.Equal(t, fiber.StatusCreated, ta.post(t, "k1", `{}`).status)
+	assert.Equal(t, int32(2), ta.calls.Load())
+}
+
+func TestMiddleware_RejectsOverlongKey(t *testing.T) {
+	ta := newTestApp(t, Config{})
+
+	res := ta.post(t, strings.Repeat("k", MaxKeyLength+1), `{}`)
+	assert.Equal(t, fiber.StatusBadRequest, res.status)
+	assert.Equal(t, int32(0), ta.calls.Load())
+}
@@ -0,0 +1,161 @@
+// Package journal records mutating API requests so a QA report ("updating
+// user X broke Y") can be reproduced by replaying the exact sequence of
+// payloads against a scratch environment.
+//
+// It is a debugging aid for non-production environments: bodies are kept
+// (with sensitive fields masked), which is more than the request log holds.
+// Entries go to a Sink: a size-rotated local file or a length-capped Redis
+// stream. Replay re-sends them.
+package journal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"veemon/pkg/middleware"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"go.uber.org/zap"
+)
+
+// PathPrefix limits journaling to the versioned API.
+const PathPrefix = "/api/v1/"
+
+// Redacted replaces the value of every sensitive body field.
+const Redacted = "[REDACTED]"
+
+// Entry is one journaled request.
+type Entry struct {
+	Time      time.Time `json:"time"`
+	RequestID string    `json:"requestId,omitempty"`
+	// ActorID is the authenticated user, empty for public endpoints. For an
+	// impersonation token it is the impersonated user.
+	ActorID string `json:"actorId,omitempty"`
+	Method  string `json:"method"`
+	// Path includes the query string.
+	Path string `json:"path"`
+	// Body is the redacted JSON body, or absent for an empty or non-JSON one.
+	Body   json.RawMessage `json:"body,omitempty"`
+	Status int             `json:"status"`
+}
+
+// Sink stores entries.
+type Sink interface {
+	Write(ctx context.Context, e Entry) error
+	Close() error
+}
+
+// sensitiveKeys are matched as lowercase substrings of a JSON key, the same
+// families pkg/events masks in message previews.
+var sensitiveKeys = []string{"password", "secret", "token", "authorization", "apikey", "api_key"}
+
+func sensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact returns body with the value of every sensitive key, at any depth,
+// replaced by Redacted. It returns nil for an empty or non-JSON body, which
+// is then left out of the entry rather than stored unmasked.
+func Redact(body []byte) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil
+	}
+	out, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return nil
+	}
+	return out
+}
+
+func redactValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			if sensitive(k) {
+				t[k] = Redacted
+			} else {
+				t[k] = redactValue(child)
+			}
+		}
+	case []interface{}:
+		for i, child := range t {
+			t[i] = redactValue(child)
+		}
+	}
+	return v
+}
+
+func journaled(c *fiber.Ctx) bool {
+	switch c.Method() {
+	case fiber.MethodPost, fiber.MethodPut, fiber.MethodPatch, fiber.MethodDelete:
+		return strings.HasPrefix(c.Path(), PathPrefix)
+	}
+	return false
+}
+
+// Middleware appends an Entry to sink for every POST, PUT, PATCH and DELETE
+// under PathPrefix once its status is known. A failed write is logged and
+// never fails the request.
+func Middleware(sink Sink, log *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !journaled(c) {
+			return c.Next()
+		}
+		start := time.Now()
+		err := c.Next()
+
+		// As in LoggerMiddleware, Fiber's ErrorHandler has not run yet, so an
+		// error's status comes from the error itself.
+		status := c.Response().StatusCode()
+		if err != nil {
+			if fe, ok := err.(*fiber.Error); ok {
+				status = fe.Code
+			} else if status < 400 {
+				status = fiber.StatusInternalServerError
+			}
+		}
+
+		// Fiber reuses its request buffers once the handler returns, and a sink
+		// may hold the entry longer, so the strings are copied.
+		e := Entry{
+			Time:   start.UTC(),
+			Method: utils.CopyString(c.Method()),
+			Path:   utils.CopyString(c.OriginalURL()),
+			Body:   Redact(c.Body()),
+			Status: status,
+		}
+		if id, ok := c.Locals("request_id").(string); ok {
+			e.RequestID = id
+		}
+		if auth, ok := middleware.GetAuthContext(c); ok {
+			e.ActorID = auth.UserID
+		}
+		if werr := sink.Write(c.Context(), e); werr != nil {
+			log.Warn("journal: write failed", zap.String("path", c.Path()), zap.Error(werr))
+		}
+		return err
+	}
+}
+
+// parseLine decodes one JSON-lines entry.
+func parseLine(line []byte) (Entry, error) {
+	var e Entry
+	if err := json.Unmarshal(line, &e); err != nil {
+		return Entry{}, fmt.Errorf("journal: decode entry: %w", err)
+	}
+	return e, nil
+}
@@ -0,0 +1,221 @@
+package journal
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"veemon/pkg/middleware"
+	"veemon/pkg/redis"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type memSink struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+func (m *memSink) Write(_ context.Context, e Entry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = append(m.entries, e)
+	return nil
+}
+
+func (m *memSink) Close() error { return nil }
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"top-level password", `{"email":"a@b.c","password":"hunter2"}`, `{"email":"a@b.c","password":"[REDACTED]"}`},
+		{"nested and case-insensitive", `{"user":{"name":"A","RefreshToken":"x"}}`, `{"user":{"RefreshToken":"[REDACTED]","name":"A"}}`},
+		{"inside arrays", `[{"api_key":"k","id":1}]`, `[{"api_key":"[REDACTED]","id":1}]`},
+		{"whole object masked", `{"secrets":{"a":"b"}}`, `{"secrets":"[REDACTED]"}`},
+		{"non-JSON dropped", `password=hunter2`, ``},
+		{"empty dropped", ``, ``},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(Redact([]byte(tt.in))); got != tt.want {
+				t.Errorf("Redact(%s) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddleware_JournalsMutationsOnly(t *testing.T) {
+	sink := &memSink{}
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("request_id", "req-1")
+		if c.Get("X-Test-User") != "" {
+			c.Locals("auth", &middleware.AuthContext{UserID: c.Get("X-Test-User")})
+		}
+		return c.Next()
+	})
+	app.Use(Middleware(sink, zap.NewNop()))
+	app.Post("/api/v1/users", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusCreated) })
+	app.Put("/api/v1/users/:id", func(c *fiber.Ctx) error { return fiber.NewError(fiber.StatusConflict, "taken") })
+	app.Get("/api/v1/users", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+	app.Post("/auth/login", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	send := func(method, path, body, user string) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if user != "" {
+			req.Header.Set("X-Test-User", user)
+		}
+		if _, err := app.Test(req); err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+	}
+	send(http.MethodPost, "/api/v1/users?notify=1", `{"email":"a@b.c","password":"hunter2"}`, "")
+	send(http.MethodPut, "/api/v1/users/42", `{"name":"B"}`, "admin-1")
+	send(http.MethodGet, "/api/v1/users", "", "admin-1")
+	send(http.MethodPost, "/auth/login", `{"password":"x"}`, "")
+
+	if len(sink.entries) != 2 {
+		t.Fatalf("got %d entries, want 2: %+v", len(sink.entries), sink.entries)
+	}
+	first, second := sink.entries[0], sink.entries[1]
+	if first.Method != "POST" || first.Path != "/api/v1/users?notify=1" || first.Status != fiber.StatusCreated {
+		t.Errorf("first entry = %+v", first)
+	}
+	if first.RequestID != "req-1" || first.ActorID != "" {
+		t.Errorf("first entry ids = %q/%q", first.RequestID, first.ActorID)
+	}
+	if strings.Contains(string(first.Body), "hunter2") {
+		t.Errorf("password leaked into journal: %s", first.Body)
+	}
+	if second.Status != fiber.StatusConflict || second.ActorID != "admin-1" {
+		t.Errorf("second entry = %+v", second)
+	}
+}
+
+func TestFileSink_RotatesAndCapsBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal", "requests.jsonl")
+	e := Entry{Method: "POST", Path: "/api/v1/users", Status: 201}
+	line, _ := json.Marshal(e)
+	// Room for two entries per file.
+	sink, err := NewFileSink(path, int64(2*(len(line)+1)), 2)
+	if err != nil {
+		t.Fatalf("NewFileSink: %v", err)
+	}
+	for i := 0; i < 7; i++ {
+		if err := sink.Write(context.Background(), e); err != nil {
+			t.Fatalf("Write %d: %v", i, err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	for name, want := range map[string]int{path: 1, path + ".1": 2, path + ".2": 2} {
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatalf("open %s: %v", name, err)
+		}
+		entries, err := ReadEntries(f)
+		_ = f.Close()
+		if err != nil {
+			t.Fatalf("ReadEntries(%s): %v", name, err)
+		}
+		if len(entries) != want {
+			t.Errorf("%s holds %d entries, want %d", filepath.Base(name), len(entries), want)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("backup beyond maxFiles kept: %v", err)
+	}
+	if err := sink.Write(context.Background(), e); err != os.ErrClosed {
+		t.Errorf("Write after Close = %v, want os.ErrClosed", err)
+	}
+}
+
+func TestStreamSink_CapsLengthAndReadsBack(t *testing.T) {
+	mr := miniredis.RunT(t)
+	port, _ := strconv.Atoi(mr.Port())
+	rc, err := redis.New(redis.Config{Host: mr.Host(), Port: port, MaxIdle: 1, MaxActive: 2})
+	if err != nil {
+		t.Fatalf("redis.New: %v", err)
+	}
+	t.Cleanup(func() { _ = rc.Close() })
+
+	ctx := context.Background()
+	sink := NewStreamSink(rc, "journal:test", 3)
+	for i := 1; i <= 5; i++ {
+		if err := sink.Write(ctx, Entry{Method: "DELETE", Path: "/api/v1/users/" + strconv.Itoa(i), Status: 204}); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	entries, err := ReadStream(ctx, rc, "journal:test")
+	if err != nil {
+		t.Fatalf("ReadStream: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+	if entries[0].Path != "/api/v1/users/3" || entries[2].Path != "/api/v1/users/5" {
+		t.Errorf("kept %s..%s, want the newest three", entries[0].Path, entries[2].Path)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	type received struct{ method, path, auth, body string }
+	var got []received
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		got = append(got, received{r.Method, r.URL.RequestURI(), r.Header.Get("Authorization"), string(b)})
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	entries := []Entry{
+		{Method: "POST", Path: "/api/v1/auth/register", Body: json.RawMessage(`{"password":"[REDACTED]"}`), Status: 201},
+		{Method: "DELETE", Path: "/api/v1/users/7?hard=1", ActorID: "admin-1", Status: 200},
+	}
+
+	var out strings.Builder
+	stats, err := Replay(context.Background(), entries, ReplayOptions{BaseURL: srv.URL, Token: "tok", DryRun: true, Out: &out})
+	if err != nil || len(got) != 0 || stats.Sent != 0 {
+		t.Fatalf("dry run: stats=%+v err=%v sent=%d", stats, err, len(got))
+	}
+	if strings.Count(out.String(), "\n") != 2 || !strings.Contains(out.String(), "(bearer token)") {
+		t.Errorf("dry run output:\n%s", out.String())
+	}
+
+	stats, err = Replay(context.Background(), entries, ReplayOptions{BaseURL: srv.URL + "/", Token: "tok", RedactedValue: "Passw0rd!"})
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if stats != (ReplayStats{Sent: 2, Matched: 1, Mismatched: 1}) {
+		t.Errorf("stats = %+v", stats)
+	}
+	if len(got) != 2 {
+		t.Fatalf("server got %d requests, want 2", len(got))
+	}
+	if got[0].auth != "" || got[0].body != `{"password":"Passw0rd!"}` {
+		t.Errorf("register replayed as %+v", got[0])
+	}
+	if got[1].auth != "Bearer tok" || got[1].path != "/api/v1/users/7?hard=1" {
+		t.Errorf("delete replayed as %+v", got[1])
+	}
+}
@@ -0,0 +1,120 @@
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ReadEntries decodes a JSON-lines journal, as written by FileSink.
+func ReadEntries(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for no := 1; scanner.Scan(); no++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		e, err := parseLine(scanner.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", no, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// ReplayOptions configures Replay.
+type ReplayOptions struct {
+	// BaseURL is the target, e.g. http://localhost:3000; entry paths are
+	// appended to it.
+	BaseURL string
+	// Token replaces the original credentials on entries that had an actor.
+	// Entries without one are sent unauthenticated, as they were.
+	Token string
+	// RedactedValue, when set, is substituted for every masked body value,
+	// e.g. a known password for replaying registrations.
+	RedactedValue string
+	// DryRun prints each request instead of sending it.
+	DryRun bool
+	// Client sends the requests; http.DefaultClient when nil.
+	Client *http.Client
+	// Out receives one line per entry; io.Discard when nil.
+	Out io.Writer
+}
+
+// ReplayStats summarizes a replay. Matched counts responses whose status
+// equals the recorded one.
+type ReplayStats struct {
+	Sent       int
+	Matched    int
+	Mismatched int
+}
+
+// Replay re-sends entries in order. A transport error stops it; a status
+// differing from the recorded one is reported and counted, since that is
+// usually what is being reproduced.
+func Replay(ctx context.Context, entries []Entry, opts ReplayOptions) (ReplayStats, error) {
+	client := opts.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	out := opts.Out
+	if out == nil {
+		out = io.Discard
+	}
+	base := strings.TrimSuffix(opts.BaseURL, "/")
+
+	var stats ReplayStats
+	for i, e := range entries {
+		body := []byte(e.Body)
+		if opts.RedactedValue != "" && len(body) > 0 {
+			quoted, _ := json.Marshal(opts.RedactedValue)
+			masked, _ := json.Marshal(Redacted)
+			body = bytes.ReplaceAll(body, masked, quoted)
+		}
+		authed := e.ActorID != "" && opts.Token != ""
+
+		if opts.DryRun {
+			auth := ""
+			if authed {
+				auth = " (bearer token)"
+			}
+			fmt.Fprintf(out, "#%d %s %s%s%s recorded=%d %s\n", i+1, e.Method, base, e.Path, auth, e.Status, body)
+			continue
+		}
+
+		req, err := http.NewRequestWithContext(ctx, e.Method, base+e.Path, bytes.NewReader(body))
+		if err != nil {
+			return stats, fmt.Errorf("entry %d: %w", i+1, err)
+		}
+		if len(body) > 0 {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if authed {
+			req.Header.Set("Authorization", "Bearer "+opts.Token)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return stats, fmt.Errorf("entry %d: %s %s: %w", i+1, e.Method, e.Path, err)
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+
+		stats.Sent++
+		mark := "ok"
+		if resp.StatusCode == e.Status {
+			stats.Matched++
+		} else {
+			stats.Mismatched++
+			mark = "MISMATCH"
+		}
+		fmt.Fprintf(out, "#%d %s %s recorded=%d got=%d %s\n", i+1, e.Method, e.Path, e.Status, resp.StatusCode, mark)
+	}
+	return stats, nil
+}
@@ -0,0 +1,55 @@
+package journal
+
+import (
+	"context"
+	"encoding/json"
+
+	"veemon/pkg/redis"
+)
+
+// streamField holds the JSON-encoded entry in each stream entry.
+const streamField = "entry"
+
+// StreamSink appends entries to a Redis stream capped at maxLen entries, so
+// every replica journals into one ordered log without unbounded growth.
+type StreamSink struct {
+	client *redis.Client
+	stream string
+	maxLen int64
+}
+
+// NewStreamSink returns a sink writing to stream. A non-positive maxLen
+// leaves the stream uncapped.
+func NewStreamSink(client *redis.Client, stream string, maxLen int64) *StreamSink {
+	return &StreamSink{client: client, stream: stream, maxLen: maxLen}
+}
+
+// Write adds e to the stream, trimming the oldest entries beyond the cap.
+func (s *StreamSink) Write(ctx context.Context, e Entry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.XAdd(ctx, s.stream, s.maxLen, map[string]string{streamField: string(b)})
+	return err
+}
+
+// Close is a no-op; the Redis client is owned by the caller.
+func (s *StreamSink) Close() error { return nil }
+
+// ReadStream returns every entry in stream, oldest first, for Replay.
+func ReadStream(ctx context.Context, client *redis.Client, stream string) ([]Entry, error) {
+	raw, err := client.XRange(ctx, stream, "-", "+", 0)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0, len(raw))
+	for _, r := range raw {
+		e, err := parseLine([]byte(r.Fields[streamField]))
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
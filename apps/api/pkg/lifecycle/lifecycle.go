@@ -0,0 +1,184 @@
+// Package lifecycle collects shutdown hooks from the packages that own a
+// resource, so a binary only has to call Shutdown once instead of keeping its
+// own defer stack in the right order.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Priorities for the hooks registered by this repo. Lower runs first: fail
+// readiness and wait for load balancers to stop routing here, stop taking
+// work, then close the clients that work used, then flush telemetry so spans
+// and metrics emitted during shutdown are not lost.
+const (
+	PriorityDrain     = 50
+	PriorityServers   = 100
+	PriorityClients   = 200
+	PriorityTelemetry = 300
+)
+
+// DefaultHookTimeout bounds a single hook unless the registry says otherwise.
+const DefaultHookTimeout = 10 * time.Second
+
+// ErrHookTimeout is returned (wrapped) for a hook that did not finish in time.
+var ErrHookTimeout = errors.New("shutdown hook timed out")
+
+type hook struct {
+	name     string
+	priority int
+	seq      int
+	timeout  time.Duration
+	fn       func(ctx context.Context) error
+}
+
+// Registry holds shutdown hooks. The zero value is not usable; use
+// NewRegistry, or the package-level functions for the process-wide registry.
+type Registry struct {
+	mu          sync.Mutex
+	hooks       []hook
+	seq         int
+	log         *zap.Logger
+	hookTimeout time.Duration
+	once        sync.Once
+}
+
+// NewRegistry returns an empty registry. A nil logger discards output and a
+// non-positive hookTimeout means DefaultHookTimeout.
+func NewRegistry(log *zap.Logger, hookTimeout time.Duration) *Registry {
+	if log == nil {
+		log = zap.NewNop()
+	}
+	if hookTimeout <= 0 {
+		hookTimeout = DefaultHookTimeout
+	}
+	return &Registry{log: log, hookTimeout: hookTimeout}
+}
+
+// SetLogger replaces the logger used to report hook results.
+func (r *Registry) SetLogger(log *zap.Logger) {
+	if log == nil {
+		log = zap.NewNop()
+	}
+	r.mu.Lock()
+	r.log = log
+	r.mu.Unlock()
+}
+
+// Register adds fn under name. Hooks run in ascending priority; hooks with
+// the same priority run in reverse registration order, like defers, so a
+// resource registered after the one it depends on is closed first.
+func (r *Registry) Register(name string, priority int, fn func(ctx context.Context) error) {
+	r.RegisterWithTimeout(name, priority, 0, fn)
+}
+
+// RegisterWithTimeout is Register with a hook-specific timeout, for hooks
+// such as draining in-flight work that legitimately need longer than the
+// registry default. A non-positive timeout means the registry default.
+func (r *Registry) RegisterWithTimeout(name string, priority int, timeout time.Duration, fn func(ctx context.Context) error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seq++
+	r.hooks = append(r.hooks, hook{name: name, priority: priority, seq: r.seq, timeout: timeout, fn: fn})
+}
+
+// Shutdown runs every hook once, each bounded by the hook timeout and by ctx.
+// A failing, hanging or panicking hook is logged and skipped over; the
+// returned error joins every hook failure. Calls after the first return nil.
+func (r *Registry) Shutdown(ctx context.Context) error {
+	var err error
+	r.once.Do(func() { err = r.run(ctx) })
+	return err
+}
+
+func (r *Registry) run(ctx context.Context) error {
+	r.mu.Lock()
+	hooks := make([]hook, len(r.hooks))
+	copy(hooks, r.hooks)
+	log := r.log
+	r.mu.Unlock()
+
+	sort.Slice(hooks, func(i, j int) bool {
+		if hooks[i].priority != hooks[j].priority {
+			return hooks[i].priority < hooks[j].priority
+		}
+		return hooks[i].seq > hooks[j].seq
+	})
+
+	var errs []error
+	for _, h := range hooks {
+		log.Info("Running shutdown hook", zap.String("hook", h.name), zap.Int("priority", h.priority))
+		start := time.Now()
+		err := r.runHook(ctx, h)
+		fields := []zap.Field{
+			zap.String("hook", h.name),
+			zap.Int("priority", h.priority),
+			zap.Duration("duration", time.Since(start)),
+		}
+		if err != nil {
+			log.Error("Shutdown hook failed", append(fields, zap.Error(err))...)
+			errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+			continue
+		}
+		log.Info("Shutdown hook completed", fields...)
+	}
+	return errors.Join(errs...)
+}
+
+// runHook runs h in its own goroutine so a hook that ignores its context
+// cannot hold up the rest; such a goroutine is abandoned at the deadline.
+func (r *Registry) runHook(ctx context.Context, h hook) error {
+	timeout := h.timeout
+	if timeout <= 0 {
+		timeout = r.hookTimeout
+	}
+	hookCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("panic: %v", p)
+			}
+		}()
+		done <- h.fn(hookCtx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-hookCtx.Done():
+		return fmt.Errorf("%w: %v", ErrHookTimeout, hookCtx.Err())
+	}
+}
+
+var defaultRegistry = NewRegistry(nil, DefaultHookTimeout)
+
+// Register adds a hook to the process-wide registry.
+func Register(name string, priority int, fn func(ctx context.Context) error) {
+	defaultRegistry.Register(name, priority, fn)
+}
+
+// RegisterWithTimeout adds a hook with its own timeout to the process-wide
+// registry.
+func RegisterWithTimeout(name string, priority int, timeout time.Duration, fn func(ctx context.Context) error) {
+	defaultRegistry.RegisterWithTimeout(name, priority, timeout, fn)
+}
+
+// Shutdown runs the process-wide registry's hooks.
+func Shutdown(ctx context.Context) error {
+	return defaultRegistry.Shutdown(ctx)
+}
+
+// SetLogger sets the logger of the process-wide registry.
+func SetLogger(log *zap.Logger) {
+	defaultRegistry.SetLogger(log)
+}
@@ -0,0 +1,112 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recorder struct {
+	mu    sync.Mutex
+	names []string
+}
+
+func (r *recorder) hook(name string) func(context.Context) error {
+	return func(context.Context) error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.names = append(r.names, name)
+		return nil
+	}
+}
+
+func TestShutdown_RunsInPriorityThenReverseRegistrationOrder(t *testing.T) {
+	reg := NewRegistry(nil, time.Second)
+	rec := &recorder{}
+
+	reg.Register("telemetry", PriorityTelemetry, rec.hook("telemetry"))
+	reg.Register("db", PriorityClients, rec.hook("db"))
+	reg.Register("redis", PriorityClients, rec.hook("redis"))
+	reg.Register("http", PriorityServers, rec.hook("http"))
+
+	require.NoError(t, reg.Shutdown(context.Background()))
+	assert.Equal(t, []string{"http", "redis", "db", "telemetry"}, rec.names)
+}
+
+func TestShutdown_HookTimeoutDoesNotBlockTheRest(t *testing.T) {
+	reg := NewRegistry(nil, 50*time.Millisecond)
+	rec := &recorder{}
+
+	release := make(chan struct{})
+	defer close(release)
+	reg.Register("stuck", PriorityServers, func(context.Context) error {
+		<-release // ignores its context on purpose
+		return nil
+	})
+	reg.Register("after", PriorityClients, rec.hook("after"))
+
+	start := time.Now()
+	err := reg.Shutdown(context.Background())
+
+	assert.ErrorIs(t, err, ErrHookTimeout)
+	assert.ErrorContains(t, err, "stuck")
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, []string{"after"}, rec.names)
+}
+
+func TestShutdown_PerHookTimeoutOverridesDefault(t *testing.T) {
+	reg := NewRegistry(nil, 20*time.Millisecond)
+
+	reg.RegisterWithTimeout("slow", PriorityServers, time.Second, func(ctx context.Context) error {
+		select {
+		case <-time.After(100 * time.Millisecond):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+
+	assert.NoError(t, reg.Shutdown(context.Background()))
+}
+
+func TestShutdown_HookSeesItsDeadline(t *testing.T) {
+	reg := NewRegistry(nil, 50*time.Millisecond)
+
+	reg.Register("waits", PriorityServers, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	assert.Error(t, reg.Shutdown(context.Background()))
+}
+
+func TestShutdown_PanicAndErrorDoNotAbortTheRest(t *testing.T) {
+	reg := NewRegistry(nil, time.Second)
+	rec := &recorder{}
+	boom := errors.New("close failed")
+
+	reg.Register("panics", PriorityServers, func(context.Context) error { panic("kaboom") })
+	reg.Register("fails", PriorityClients, func(context.Context) error { return boom })
+	reg.Register("last", PriorityTelemetry, rec.hook("last"))
+
+	err := reg.Shutdown(context.Background())
+
+	assert.ErrorContains(t, err, "panics: panic: kaboom")
+	assert.ErrorIs(t, err, boom)
+	assert.Equal(t, []string{"last"}, rec.names)
+}
+
+func TestShutdown_RunsOnce(t *testing.T) {
+	reg := NewRegistry(nil, time.Second)
+	rec := &recorder{}
+	reg.Register("once", PriorityServers, rec.hook("once"))
+
+	require.NoError(t, reg.Shutdown(context.Background()))
+	require.NoError(t, reg.Shutdown(context.Background()))
+	assert.Equal(t, []string{"once"}, rec.names)
+}
@@ -0,0 +1,123 @@
+package logger
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// consoleEncoder is the human-readable encoder for local development:
+// colored capital levels, wall-clock timestamps, and request-completed
+// entries from middleware.LoggerMiddleware collapsed onto one line
+// ("GET /api/v1/users 200 1.2ms <request_id>"). Everything else is rendered
+// by zap's console encoder unchanged.
+type consoleEncoder struct {
+	zapcore.Encoder // carries With() context for ordinary entries
+	// bare renders request lines. It never receives context fields, so
+	// service/environment do not trail every request.
+	bare zapcore.Encoder
+}
+
+func newConsoleEncoder(color bool) zapcore.Encoder {
+	cfg := zapcore.EncoderConfig{
+		TimeKey:          "T",
+		LevelKey:         "L",
+		NameKey:          "N",
+		CallerKey:        "C",
+		FunctionKey:      zapcore.OmitKey,
+		MessageKey:       "M",
+		StacktraceKey:    "S",
+		LineEnding:       zapcore.DefaultLineEnding,
+		EncodeLevel:      zapcore.CapitalLevelEncoder,
+		EncodeTime:       zapcore.TimeEncoderOfLayout("15:04:05.000"),
+		EncodeDuration:   zapcore.StringDurationEncoder,
+		EncodeCaller:     zapcore.ShortCallerEncoder,
+		ConsoleSeparator: "  ",
+	}
+	if color {
+		cfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	}
+	return &consoleEncoder{
+		Encoder: zapcore.NewConsoleEncoder(cfg),
+		bare:    zapcore.NewConsoleEncoder(cfg),
+	}
+}
+
+func (e *consoleEncoder) Clone() zapcore.Encoder {
+	return &consoleEncoder{Encoder: e.Encoder.Clone(), bare: e.bare}
+}
+
+func (e *consoleEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	line, rest, ok := requestLine(fields)
+	if !ok {
+		return e.Encoder.EncodeEntry(ent, fields)
+	}
+	// The caller and stack always point into the logging middleware, so they
+	// are noise on a request line.
+	ent.Message = line
+	ent.Caller = zapcore.EntryCaller{}
+	ent.Stack = ""
+	return e.bare.EncodeEntry(ent, rest)
+}
+
+// requestLine recognizes a LoggerMiddleware entry by its method, path, status
+// and duration fields and renders them, plus request_id when present, as one
+// compact string. rest holds the fields still worth showing (the error).
+func requestLine(fields []zapcore.Field) (line string, rest []zapcore.Field, ok bool) {
+	var (
+		method, path, requestID string
+		status                  int64
+		duration                time.Duration
+		seen                    int
+	)
+	for _, f := range fields {
+		switch {
+		case f.Key == "method" && f.Type == zapcore.StringType:
+			method, seen = f.String, seen+1
+		case f.Key == "path" && f.Type == zapcore.StringType:
+			path, seen = f.String, seen+1
+		case f.Key == "status" && f.Type == zapcore.Int64Type:
+			status, seen = f.Integer, seen+1
+		case f.Key == "duration" && f.Type == zapcore.DurationType:
+			duration, seen = time.Duration(f.Integer), seen+1
+		case f.Key == "request_id" && f.Type == zapcore.StringType:
+			requestID = f.String
+		case f.Type == zapcore.ErrorType:
+			rest = append(rest, f)
+		}
+	}
+	if seen != 4 {
+		return "", nil, false
+	}
+
+	var b strings.Builder
+	b.WriteString(method)
+	b.WriteByte(' ')
+	b.WriteString(path)
+	b.WriteByte(' ')
+	b.WriteString(strconv.FormatInt(status, 10))
+	b.WriteByte(' ')
+	b.WriteString(roundDuration(duration).String())
+	if requestID != "" {
+		b.WriteByte(' ')
+		b.WriteString(requestID)
+	}
+	return b.String(), rest, true
+}
+
+// roundDuration trims durations to a readable precision: 1.235ms, 42.1ms, 2.3s.
+func roundDuration(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(100 * time.Millisecond)
+	case d >= 10*time.Millisecond:
+		return d.Round(100 * time.Microsecond)
+	case d >= time.Millisecond:
+		return d.Round(time.Microsecond)
+	default:
+		return d.Round(100 * time.Nanosecond)
+	}
+}
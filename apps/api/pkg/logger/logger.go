@@ -24,19 +24,70 @@ type Logger struct {
 }
 
 type Config struct {
-	Level       string
-	Format      string // "json" or "console"
-	Environment string
-	ServiceName string
+	Level       string `mapstructure:"LOG_LEVEL"`
+	Format      string `mapstructure:"LOG_FORMAT"` // "json", "console", or "" to pick by Environment
+	Environment string `mapstructure:"ENVIRONMENT"`
+	ServiceName string `mapstructure:"SERVICE_NAME"`
 }
 
+// Log formats. An empty Config.Format resolves to FormatConsole in the
+// development environment and FormatJSON everywhere else.
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+)
+
 func New(cfg Config) (*Logger, error) {
+	logger := newLogger(cfg, zapcore.AddSync(os.Stdout))
+	globalLogger.Store(logger)
+	return &Logger{Logger: logger}, nil
+}
+
+// newLogger builds the logger New installs, writing to ws.
+func newLogger(cfg Config, ws zapcore.WriteSyncer) *zap.Logger {
 	level, err := zapcore.ParseLevel(cfg.Level)
 	if err != nil {
 		level = zapcore.InfoLevel
 	}
 
-	encoderConfig := zapcore.EncoderConfig{
+	opts := []zap.Option{
+		// No AddCallerSkip here: callers use the returned *zap.Logger directly
+		// (log.Info(...)), so the caller frame is already correct. The
+		// package-level helpers below add the skip themselves.
+		zap.AddCaller(),
+		zap.Fields(
+			zap.String("service", cfg.ServiceName),
+			zap.String("environment", cfg.Environment),
+		),
+	}
+
+	var encoder zapcore.Encoder
+	if resolveFormat(cfg) == FormatConsole {
+		encoder = newConsoleEncoder(os.Getenv("NO_COLOR") == "")
+		if cfg.Environment == "development" {
+			opts = append(opts, zap.Development(), zap.AddStacktrace(zapcore.ErrorLevel))
+		}
+	} else {
+		encoder = zapcore.NewJSONEncoder(jsonEncoderConfig())
+	}
+
+	return zap.New(zapcore.NewCore(encoder, ws, level), opts...)
+}
+
+func resolveFormat(cfg Config) string {
+	switch cfg.Format {
+	case FormatJSON, FormatConsole:
+		return cfg.Format
+	case "":
+		if cfg.Environment == "development" {
+			return FormatConsole
+		}
+	}
+	return FormatJSON
+}
+
+func jsonEncoderConfig() zapcore.EncoderConfig {
+	return zapcore.EncoderConfig{
 		TimeKey:        "timestamp",
 		LevelKey:       "level",
 		NameKey:        "logger",
@@ -50,45 +101,10 @@ func New(cfg Config) (*Logger, error) {
 		EncodeDuration: zapcore.SecondsDurationEncoder,
 		EncodeCaller:   zapcore.ShortCallerEncoder,
 	}
-
-	var encoder zapcore.Encoder
-	if cfg.Format == "console" {
-		encoder = zapcore.NewConsoleEncoder(encoderConfig)
-	} else {
-		encoder = zapcore.NewJSONEncoder(encoderConfig)
-	}
-
-	core := zapcore.NewCore(
-		encoder,
-		zapcore.AddSync(os.Stdout),
-		level,
-	)
-
-	// No AddCallerSkip here: callers use the returned *zap.Logger directly
-	// (log.Info(...)), so the caller frame is already correct. The package-level
-	// helpers below add the skip themselves.
-	logger := zap.New(core,
-		zap.AddCaller(),
-		zap.Fields(
-			zap.String("service", cfg.ServiceName),
-			zap.String("environment", cfg.Environment),
-		),
-	)
-
-	globalLogger.Store(logger)
-
-	return &Logger{Logger: logger}, nil
 }
 
 func (l *Logger) WithContext(ctx context.Context) *zap.Logger {
-	spanCtx := trace.SpanContextFromContext(ctx)
-	if spanCtx.IsValid() {
-		return l.Logger.With(
-			zap.String("trace_id", spanCtx.TraceID().String()),
-			zap.String("span_id", spanCtx.SpanID().String()),
-		)
-	}
-	return l.Logger
+	return withContext(l.Logger, ctx)
 }
 
 func (l *Logger) WithRequestID(requestID string) *zap.Logger {
@@ -152,12 +168,45 @@ func With(fields ...zap.Field) *zap.Logger {
 }
 
 func WithContext(ctx context.Context) *zap.Logger {
-	spanCtx := trace.SpanContextFromContext(ctx)
-	if spanCtx.IsValid() {
-		return L().With(
+	return withContext(L(), ctx)
+}
+
+// withContext adds the trace and span IDs and the request ID carried by ctx
+// to l.
+func withContext(l *zap.Logger, ctx context.Context) *zap.Logger {
+	var fields []zap.Field
+	if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.IsValid() {
+		fields = append(fields,
 			zap.String("trace_id", spanCtx.TraceID().String()),
 			zap.String("span_id", spanCtx.SpanID().String()),
 		)
 	}
-	return L()
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		fields = append(fields, zap.String("request_id", requestID))
+	}
+	if len(fields) == 0 {
+		return l
+	}
+	return l.With(fields...)
+}
+
+// RequestIDHeader carries the request ID between services: as an HTTP
+// header, and lowercased as gRPC metadata and an AMQP message header.
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a copy of ctx carrying the request ID, so code
+// below the transport layer (e.g. the GORM query logger) can correlate its
+// log entries with the request, and outgoing calls and messages can forward
+// it.
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID stored by ContextWithRequestID,
+// or "" if there is none.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
 }
@@ -0,0 +1,155 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/*.golden")
+
+type fixedClock struct{}
+
+func (fixedClock) Now() time.Time                         { return time.Date(2026, 3, 4, 15, 4, 5, 678e6, time.UTC) }
+func (fixedClock) NewTicker(d time.Duration) *time.Ticker { return time.NewTicker(d) }
+
+func captureLogger(t *testing.T, cfg Config) (*zap.Logger, *bytes.Buffer) {
+	t.Helper()
+	var buf bytes.Buffer
+	l := newLogger(cfg, zapcore.AddSync(&buf)).WithOptions(zap.WithClock(fixedClock{}))
+	return l, &buf
+}
+
+// logRequests writes the entries middleware.LoggerMiddleware produces, plus
+// one ordinary entry, in the same field order.
+func logRequests(l *zap.Logger) {
+	request := func(method, path string, status int, d time.Duration, reqID string) []zap.Field {
+		f := []zap.Field{
+			zap.String("method", method),
+			zap.String("path", path),
+			zap.Int("status", status),
+			zap.Duration("duration", d),
+			zap.String("ip", "127.0.0.1"),
+			zap.String("user_agent", "curl/8.5.0"),
+		}
+		if reqID != "" {
+			f = append(f, zap.String("request_id", reqID))
+		}
+		return f
+	}
+	l.Info("Request completed", request("GET", "/api/v1/users", 200, 1234567*time.Nanosecond, "req-1")...)
+	l.Warn("Request completed with client error", request("POST", "/api/v1/auth/login", 401, 250*time.Microsecond, "req-2")...)
+	l.Info("Request completed", request("GET", "/health", 200, 42*time.Millisecond+123*time.Microsecond, "")...)
+	l.Error("Request failed", append(request("PUT", "/api/v1/users/1", 500, 2*time.Second+340*time.Millisecond, "req-3"),
+		zap.Error(errors.New("db timeout")))...)
+}
+
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden (run with -update to create): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("output differs from %s\n--- got ---\n%s\n--- want ---\n%s", path, got, want)
+	}
+}
+
+func TestConsole_CompactRequestLines(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	l, buf := captureLogger(t, Config{Level: "debug", Format: FormatConsole, Environment: "development", ServiceName: "veemon"})
+	logRequests(l)
+	checkGolden(t, "console_requests.golden", buf.Bytes())
+}
+
+func TestConsole_RegularEntriesKeepStructuredFields(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	l, buf := captureLogger(t, Config{Level: "info", Format: FormatConsole, Environment: "development", ServiceName: "veemon"})
+	l.Info("consumer attached", zap.String("queue", "default_queue"))
+	l.Error("failed to process message", zap.String("queue", "default_queue"))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if !strings.HasPrefix(lines[0], "15:04:05.678  INFO  logger/logger_test.go:") ||
+		!strings.HasSuffix(lines[0], `consumer attached  {"service": "veemon", "environment": "development", "queue": "default_queue"}`) {
+		t.Fatalf("info line = %q", lines[0])
+	}
+	// Development preset: errors carry a stack trace.
+	if len(lines) < 3 || !strings.Contains(buf.String(), "veemon/pkg/logger.TestConsole_RegularEntriesKeepStructuredFields") {
+		t.Fatalf("error entry has no stack trace:\n%s", buf.String())
+	}
+}
+
+func TestConsole_ColorsLevels(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	l, buf := captureLogger(t, Config{Format: FormatConsole, Environment: "development"})
+	l.Warn("careful")
+	if !strings.Contains(buf.String(), "\x1b[33mWARN\x1b[0m") {
+		t.Fatalf("level not colorized: %q", buf.String())
+	}
+}
+
+func TestJSON_UnaffectedByConsoleMode(t *testing.T) {
+	// Production JSON must keep its exact shape, request entries included.
+	l, buf := captureLogger(t, Config{Level: "info", Format: FormatJSON, Environment: "production", ServiceName: "veemon"})
+	logRequests(l.WithOptions(zap.WithCaller(false)))
+	checkGolden(t, "json_requests.golden", buf.Bytes())
+	if strings.Contains(buf.String(), "stacktrace") {
+		t.Fatal("production JSON gained stack traces")
+	}
+}
+
+func TestResolveFormat(t *testing.T) {
+	tests := []struct {
+		format, env, want string
+	}{
+		{"", "development", FormatConsole},
+		{"", "production", FormatJSON},
+		{"", "staging", FormatJSON},
+		{FormatJSON, "development", FormatJSON},
+		{FormatConsole, "production", FormatConsole},
+		{"yaml", "development", FormatJSON},
+	}
+	for _, tt := range tests {
+		if got := resolveFormat(Config{Format: tt.format, Environment: tt.env}); got != tt.want {
+			t.Errorf("resolveFormat(%q, %q) = %q, want %q", tt.format, tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestWithContext_AddsTraceAndRequestIDs(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	l := &Logger{Logger: zap.New(core)}
+
+	ctx := ContextWithRequestID(context.Background(), "req-1")
+	ctx = trace.ContextWithSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1},
+		SpanID:  trace.SpanID{2},
+	}))
+	l.WithContext(ctx).Info("traced")
+	l.WithContext(context.Background()).Info("bare")
+
+	fields := logs.All()[0].ContextMap()
+	if fields["request_id"] != "req-1" || fields["trace_id"] != (trace.TraceID{1}).String() {
+		t.Errorf("fields = %v, want request_id and trace_id", fields)
+	}
+	if n := len(logs.All()[1].Context); n != 0 {
+		t.Errorf("bare context added %d fields", n)
+	}
+}
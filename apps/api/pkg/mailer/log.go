@@ -0,0 +1,66 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// LogSender is the development sender: it renders messages exactly as SMTP
+// would, but logs them and, when Dir is set, writes each as an .eml file
+// instead of delivering it. The log line has only the recipient, subject,
+// template and locale: bodies can hold single-use links.
+type LogSender struct {
+	registry *Registry
+	from     string
+	dir      string
+	logger   *zap.Logger
+}
+
+// NewLogSender builds a development sender. An empty dir only logs, and the
+// body is then not kept anywhere.
+func NewLogSender(registry *Registry, from, dir string, logger *zap.Logger) *LogSender {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &LogSender{registry: registry, from: from, dir: dir, logger: logger}
+}
+
+// Send renders msg and records it.
+func (s *LogSender) Send(_ context.Context, msg Message) error {
+	if msg.To == "" {
+		return ErrNoRecipient
+	}
+	rendered, err := s.registry.Render(msg.TemplateName, msg.Locale, msg.Data)
+	if err != nil {
+		return err
+	}
+	body, err := buildMIME(s.from, msg.To, msg.Subject, rendered)
+	if err != nil {
+		return err
+	}
+
+	fields := []zap.Field{
+		zap.String("to", msg.To),
+		zap.String("subject", msg.Subject),
+		zap.String("template", msg.TemplateName),
+		zap.String("locale", msg.Locale),
+	}
+	if s.dir != "" {
+		if err := os.MkdirAll(s.dir, 0o750); err != nil {
+			return fmt.Errorf("mailer: create outbox: %w", err)
+		}
+		name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), msg.TemplateName)
+		file := filepath.Join(s.dir, name)
+		if err := os.WriteFile(file, body, 0o600); err != nil {
+			return fmt.Errorf("mailer: write outbox: %w", err)
+		}
+		fields = append(fields, zap.String("file", file))
+	}
+	s.logger.Info("email captured (dev sender, not delivered)", fields...)
+	return nil
+}
@@ -0,0 +1,39 @@
+package mailer
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogSender_DoesNotLogTheBody(t *testing.T) {
+	registry := NewRegistry(fstest.MapFS{"en/reset.txt.tmpl": {Data: []byte(`Open {{.Link}}`)}}, "en")
+	core, logs := observer.New(zap.InfoLevel)
+	sender := NewLogSender(registry, "a@example.com", "", zap.New(core))
+
+	err := sender.Send(context.Background(), Message{
+		To: "b@example.com", Subject: "Reset", TemplateName: "reset",
+		Data: map[string]any{"Link": "https://app.example.com/reset-password?token=secret"},
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("logged %d entries, want 1", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["to"] != "b@example.com" || fields["template"] != "reset" {
+		t.Fatalf("fields = %v, want the recipient and template", fields)
+	}
+	for k, v := range fields {
+		if s, ok := v.(string); ok && strings.Contains(s, "secret") {
+			t.Fatalf("field %s leaks the body: %q", k, s)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+// Package mailer renders templated emails and delivers them over SMTP, to a
+// development log/outbox, or via RabbitMQ for the worker to send.
+package mailer
+
+import (
+	"context"
+	"errors"
+)
+
+// EventTypeEmail is the envelope type (and routing key) of queued emails.
+const EventTypeEmail = "notification.email"
+
+// ErrNoRecipient is returned when a message has no To address.
+var ErrNoRecipient = errors.New("mailer: message has no recipient")
+
+// Message is a templated email. TemplateName selects a template from the
+// registry; Locale picks its translation, falling back to the default locale.
+type Message struct {
+	To           string         `json:"to" validate:"required,email"`
+	Subject      string         `json:"subject" validate:"required"`
+	TemplateName string         `json:"templateName" validate:"required"`
+	Locale       string         `json:"locale,omitempty"`
+	Data         map[string]any `json:"data,omitempty"`
+}
+
+// Sender delivers a Message.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
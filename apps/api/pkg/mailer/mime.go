@@ -0,0 +1,66 @@
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"strings"
+	"time"
+)
+
+// buildMIME assembles an RFC 5322 message. With both parts present it is
+// multipart/alternative (text first, so clients prefer the HTML).
+func buildMIME(from, to, subject string, r *Rendered) ([]byte, error) {
+	if strings.ContainsAny(from+to+subject, "\r\n") {
+		return nil, fmt.Errorf("mailer: header values must not contain line breaks")
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", to)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	switch {
+	case r.HTML != "" && r.Text != "":
+		boundary, err := newBoundary()
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary)
+		writePart(&buf, boundary, "text/plain", r.Text)
+		writePart(&buf, boundary, "text/html", r.HTML)
+		fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+	case r.HTML != "":
+		writeBody(&buf, "text/html", r.HTML)
+	default:
+		writeBody(&buf, "text/plain", r.Text)
+	}
+	return buf.Bytes(), nil
+}
+
+func writePart(buf *bytes.Buffer, boundary, contentType, body string) {
+	fmt.Fprintf(buf, "--%s\r\n", boundary)
+	writeBody(buf, contentType, body)
+	buf.WriteString("\r\n")
+}
+
+func writeBody(buf *bytes.Buffer, contentType, body string) {
+	fmt.Fprintf(buf, "Content-Type: %s; charset=utf-8\r\n", contentType)
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	qp := quotedprintable.NewWriter(buf)
+	_, _ = qp.Write([]byte(body))
+	_ = qp.Close()
+}
+
+func newBoundary() (string, error) {
+	var b [12]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return "veemon-" + hex.EncodeToString(b[:]), nil
+}
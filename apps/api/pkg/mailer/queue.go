@@ -0,0 +1,75 @@
+package mailer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"veemon/pkg/events"
+	"veemon/pkg/validation"
+
+	"github.com/google/uuid"
+)
+
+// Publisher is the subset of the RabbitMQ client QueueSender needs.
+type Publisher interface {
+	PublishJSON(ctx context.Context, exchange, routingKey string, message interface{}) error
+}
+
+// QueueSender is the API-side Sender: instead of talking SMTP in the request
+// path it publishes a notification.email event for the worker to render and
+// deliver.
+type QueueSender struct {
+	publisher Publisher
+	exchange  string
+	source    string
+}
+
+// NewQueueSender publishes emails to exchange. source is recorded on the event
+// envelope (typically the service name).
+func NewQueueSender(publisher Publisher, exchange, source string) *QueueSender {
+	return &QueueSender{publisher: publisher, exchange: exchange, source: source}
+}
+
+// Send validates msg and enqueues it.
+func (s *QueueSender) Send(ctx context.Context, msg Message) error {
+	if err := validation.Validate(&msg); err != nil {
+		return err
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return s.publisher.PublishJSON(ctx, s.exchange, EventTypeEmail, events.Envelope{
+		ID:         uuid.NewString(),
+		Type:       EventTypeEmail,
+		Source:     s.source,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	})
+}
+
+// Dispatch decodes a notification.email envelope and hands it to sender. The
+// worker calls it for envelopes of type EventTypeEmail; a payload that fails
+// to decode or validate, or whose template cannot render, is returned wrapped
+// in events.ErrInvalidEnvelope so it is dead-lettered instead of retried.
+func Dispatch(ctx context.Context, sender Sender, env *events.Envelope) error {
+	var msg Message
+	if err := json.Unmarshal(env.Data, &msg); err != nil {
+		return fmt.Errorf("%w: %v", events.ErrInvalidEnvelope, err)
+	}
+	if err := validation.Validate(&msg); err != nil {
+		return fmt.Errorf("%w: %v", events.ErrInvalidEnvelope, err)
+	}
+	if err := sender.Send(ctx, msg); err != nil {
+		// A template that is missing or cannot render will fail identically
+		// on every retry.
+		if errors.Is(err, ErrTemplateNotFound) || errors.Is(err, ErrRender) {
+			return fmt.Errorf("%w: %v", events.ErrInvalidEnvelope, err)
+		}
+		return err
+	}
+	return nil
+}
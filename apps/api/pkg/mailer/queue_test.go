@@ -0,0 +1,81 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"veemon/pkg/events"
+)
+
+type capturePublisher struct {
+	exchange, routingKey string
+	message              interface{}
+}
+
+func (p *capturePublisher) PublishJSON(_ context.Context, exchange, routingKey string, message interface{}) error {
+	p.exchange, p.routingKey, p.message = exchange, routingKey, message
+	return nil
+}
+
+type captureSender struct{ got []Message }
+
+func (s *captureSender) Send(_ context.Context, msg Message) error {
+	s.got = append(s.got, msg)
+	return nil
+}
+
+func TestQueueSender_RoundTripsThroughDispatch(t *testing.T) {
+	pub := &capturePublisher{}
+	msg := Message{To: "ann@example.com", Subject: "Hi", TemplateName: "welcome", Locale: "id", Data: map[string]any{"Name": "Ann"}}
+
+	if err := NewQueueSender(pub, "default_exchange", "veemon").Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if pub.exchange != "default_exchange" || pub.routingKey != EventTypeEmail {
+		t.Fatalf("published to %s/%s", pub.exchange, pub.routingKey)
+	}
+	env, ok := pub.message.(events.Envelope)
+	if !ok || env.Type != EventTypeEmail || env.ID == "" {
+		t.Fatalf("unexpected envelope: %#v", pub.message)
+	}
+
+	sender := &captureSender{}
+	if err := Dispatch(context.Background(), sender, &env); err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	if len(sender.got) != 1 || sender.got[0].To != msg.To || sender.got[0].Locale != "id" {
+		t.Fatalf("dispatched %+v", sender.got)
+	}
+}
+
+func TestQueueSender_RejectsInvalidMessage(t *testing.T) {
+	err := NewQueueSender(&capturePublisher{}, "x", "veemon").Send(context.Background(), Message{To: "not-an-email"})
+	if err == nil {
+		t.Fatal("want validation error")
+	}
+}
+
+func TestDispatch_PermanentFailures(t *testing.T) {
+	registry := NewRegistry(fstest.MapFS{"en/t.txt.tmpl": {Data: []byte(`{{.Name}}`)}}, "en")
+	sender := NewLogSender(registry, "a@example.com", "", nil)
+
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"undecodable payload", `"nope"`},
+		{"invalid message", `{"to":"x"}`},
+		{"missing template variable", `{"to":"b@example.com","subject":"s","templateName":"t"}`},
+		{"unknown template", `{"to":"b@example.com","subject":"s","templateName":"missing"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Dispatch(context.Background(), sender, &events.Envelope{Type: EventTypeEmail, Data: []byte(tt.data)})
+			if !errors.Is(err, events.ErrInvalidEnvelope) {
+				t.Fatalf("want permanent ErrInvalidEnvelope, got %v", err)
+			}
+		})
+	}
+}
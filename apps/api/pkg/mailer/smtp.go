@@ -0,0 +1,128 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"veemon/pkg/resilience"
+
+	"go.uber.org/zap"
+)
+
+// SMTPConfig configures SMTPSender. Username empty disables AUTH.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	// From is the sender, either a bare address or "Name <address>".
+	From string
+	// StartTLS upgrades the connection before AUTH and fails if the server
+	// does not offer it.
+	StartTLS    bool
+	DialTimeout time.Duration
+}
+
+// SMTPSender renders messages and delivers them over SMTP, retrying transient
+// failures through a resilience executor.
+type SMTPSender struct {
+	cfg      SMTPConfig
+	registry *Registry
+	executor *resilience.SimpleExecutor
+	logger   *zap.Logger
+}
+
+// NewSMTPSender builds an SMTP sender. retry configures the resilience
+// executor (attempts, backoff, circuit breaker, per-send timeout).
+func NewSMTPSender(cfg SMTPConfig, registry *Registry, retry resilience.Config, logger *zap.Logger) *SMTPSender {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = 10 * time.Second
+	}
+	return &SMTPSender{
+		cfg:      cfg,
+		registry: registry,
+		executor: resilience.NewSimple("mailer.smtp", retry, logger),
+		logger:   logger,
+	}
+}
+
+// Send renders msg and delivers it.
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	if msg.To == "" {
+		return ErrNoRecipient
+	}
+	rendered, err := s.registry.Render(msg.TemplateName, msg.Locale, msg.Data)
+	if err != nil {
+		return err
+	}
+	body, err := buildMIME(s.cfg.From, msg.To, msg.Subject, rendered)
+	if err != nil {
+		return err
+	}
+	return s.executor.Run(ctx, func(ctx context.Context) error {
+		return s.deliver(ctx, msg.To, body)
+	})
+}
+
+func (s *SMTPSender) deliver(ctx context.Context, to string, body []byte) error {
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	dialer := net.Dialer{Timeout: s.cfg.DialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("mailer: dial %s: %w", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("mailer: smtp handshake: %w", err)
+	}
+	defer c.Close() //nolint:errcheck // best-effort cleanup; Quit reports real errors
+
+	if s.cfg.StartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("mailer: server %s does not support STARTTLS", addr)
+		}
+		if err := c.StartTLS(&tls.Config{ServerName: s.cfg.Host, MinVersion: tls.VersionTLS12}); err != nil {
+			return fmt.Errorf("mailer: starttls: %w", err)
+		}
+	}
+	if s.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			return fmt.Errorf("mailer: auth: %w", err)
+		}
+	}
+	from := s.cfg.From
+	if parsed, err := mail.ParseAddress(from); err == nil {
+		from = parsed.Address
+	}
+	if err := c.Mail(from); err != nil {
+		return fmt.Errorf("mailer: MAIL FROM: %w", err)
+	}
+	if err := c.Rcpt(to); err != nil {
+		return fmt.Errorf("mailer: RCPT TO: %w", err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("mailer: DATA: %w", err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("mailer: write body: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("mailer: end DATA: %w", err)
+	}
+	return c.Quit()
+}
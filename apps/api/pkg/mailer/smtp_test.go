@@ -0,0 +1,211 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"veemon/pkg/resilience"
+)
+
+// smtpStub is a minimal SMTP server that records one envelope per session.
+type smtpStub struct {
+	ln net.Listener
+
+	mu    sync.Mutex
+	from  string
+	rcpt  []string
+	data  string
+	auth  bool
+	calls int
+}
+
+func newSMTPStub(t *testing.T) *smtpStub {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &smtpStub{ln: ln}
+	go s.serve()
+	t.Cleanup(func() { _ = ln.Close() })
+	return s
+}
+
+func (s *smtpStub) port() int { return s.ln.Addr().(*net.TCPAddr).Port }
+
+func (s *smtpStub) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.session(conn)
+	}
+}
+
+func (s *smtpStub) session(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+
+	s.mu.Lock()
+	s.calls++
+	s.mu.Unlock()
+
+	reply("220 stub ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimRight(line, "\r\n")
+		upper := strings.ToUpper(cmd)
+		switch {
+		case strings.HasPrefix(upper, "EHLO"), strings.HasPrefix(upper, "HELO"):
+			reply("250-stub")
+			reply("250 AUTH PLAIN")
+		case strings.HasPrefix(upper, "AUTH"):
+			s.mu.Lock()
+			s.auth = true
+			s.mu.Unlock()
+			reply("235 ok")
+		case strings.HasPrefix(upper, "MAIL FROM:"):
+			s.mu.Lock()
+			s.from = strings.Trim(cmd[len("MAIL FROM:"):], "<> ")
+			s.mu.Unlock()
+			reply("250 ok")
+		case strings.HasPrefix(upper, "RCPT TO:"):
+			s.mu.Lock()
+			s.rcpt = append(s.rcpt, strings.Trim(cmd[len("RCPT TO:"):], "<> "))
+			s.mu.Unlock()
+			reply("250 ok")
+		case upper == "DATA":
+			reply("354 go ahead")
+			var b strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				b.WriteString(l)
+			}
+			s.mu.Lock()
+			s.data = b.String()
+			s.mu.Unlock()
+			reply("250 queued")
+		case upper == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func fastRetry() resilience.Config {
+	cfg := resilience.DefaultConfig()
+	cfg.RetryDelay = time.Millisecond
+	cfg.RetryMaxDelay = 5 * time.Millisecond
+	cfg.Timeout = 5 * time.Second
+	return cfg
+}
+
+func TestSMTPSender_DeliversRenderedMessage(t *testing.T) {
+	stub := newSMTPStub(t)
+	registry := NewRegistry(fstest.MapFS{
+		"en/greet.html.tmpl": {Data: []byte(`<p>Hello {{.Name}}</p>`)},
+		"en/greet.txt.tmpl":  {Data: []byte(`Hello {{.Name}}`)},
+	}, "en")
+	sender := NewSMTPSender(SMTPConfig{
+		Host:     "127.0.0.1",
+		Port:     stub.port(),
+		Username: "user",
+		Password: "pass",
+		From:     "Veemon <no-reply@example.com>",
+	}, registry, fastRetry(), nil)
+
+	err := sender.Send(context.Background(), Message{
+		To:           "ann@example.com",
+		Subject:      "Welcome",
+		TemplateName: "greet",
+		Data:         map[string]any{"Name": "Ann"},
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	stub.mu.Lock()
+	defer stub.mu.Unlock()
+	if !stub.auth {
+		t.Error("expected AUTH when a username is configured")
+	}
+	if stub.from != "no-reply@example.com" {
+		t.Errorf("MAIL FROM = %q", stub.from)
+	}
+	if len(stub.rcpt) != 1 || stub.rcpt[0] != "ann@example.com" {
+		t.Errorf("RCPT TO = %v", stub.rcpt)
+	}
+	for _, want := range []string{"Subject: Welcome", "multipart/alternative", "Hello Ann", "<p>Hello Ann</p>"} {
+		if !strings.Contains(stub.data, want) {
+			t.Errorf("message body missing %q:\n%s", want, stub.data)
+		}
+	}
+}
+
+func TestSMTPSender_RenderFailureIsNotRetried(t *testing.T) {
+	stub := newSMTPStub(t)
+	registry := NewRegistry(fstest.MapFS{
+		"en/greet.txt.tmpl": {Data: []byte(`Hello {{.Name}}`)},
+	}, "en")
+	sender := NewSMTPSender(SMTPConfig{Host: "127.0.0.1", Port: stub.port(), From: "a@example.com"}, registry, fastRetry(), nil)
+
+	err := sender.Send(context.Background(), Message{To: "b@example.com", Subject: "s", TemplateName: "greet"})
+	if err == nil {
+		t.Fatal("want render error for missing variable")
+	}
+	stub.mu.Lock()
+	defer stub.mu.Unlock()
+	if stub.calls != 0 {
+		t.Fatalf("render failure must not reach the SMTP server, got %d connections", stub.calls)
+	}
+}
+
+func TestSMTPSender_StartTLSRequiredButUnsupported(t *testing.T) {
+	stub := newSMTPStub(t)
+	registry := NewRegistry(fstest.MapFS{"en/t.txt.tmpl": {Data: []byte(`x`)}}, "en")
+	cfg := fastRetry()
+	cfg.RetryMaxAttempts = 1
+	sender := NewSMTPSender(SMTPConfig{Host: "127.0.0.1", Port: stub.port(), From: "a@example.com", StartTLS: true}, registry, cfg, nil)
+
+	err := sender.Send(context.Background(), Message{To: "b@example.com", Subject: "s", TemplateName: "t"})
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Fatalf("want STARTTLS error, got %v", err)
+	}
+}
+
+func TestSMTPSender_RetriesUnreachableServer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	_ = ln.Close() // nothing listens: every dial fails
+
+	registry := NewRegistry(fstest.MapFS{"en/t.txt.tmpl": {Data: []byte(`x`)}}, "en")
+	sender := NewSMTPSender(SMTPConfig{Host: "127.0.0.1", Port: port, From: "a@example.com"}, registry, fastRetry(), nil)
+
+	err = sender.Send(context.Background(), Message{To: "b@example.com", Subject: "s", TemplateName: "t"})
+	if err == nil || !strings.Contains(err.Error(), "dial 127.0.0.1:"+strconv.Itoa(port)) {
+		t.Fatalf("want dial error, got %v", err)
+	}
+}
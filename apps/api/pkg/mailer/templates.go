@@ -0,0 +1,128 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates
+var embedded embed.FS
+
+var (
+	// ErrTemplateNotFound is returned when no locale provides the template.
+	ErrTemplateNotFound = errors.New("mailer: template not found")
+	// ErrRender wraps template parse and execution failures, including
+	// variables missing from the message data.
+	ErrRender = errors.New("mailer: render failed")
+)
+
+// Rendered is the output of a template: an HTML body and a plain-text
+// alternative. Either may be empty if the template only ships one part.
+type Rendered struct {
+	HTML string
+	Text string
+}
+
+// Registry resolves templates laid out as <locale>/<name>.html.tmpl and
+// <locale>/<name>.txt.tmpl. Templates fail on missing variables rather than
+// rendering "<no value>" into a customer's inbox.
+type Registry struct {
+	fsys          fs.FS
+	defaultLocale string
+}
+
+// NewRegistry builds a registry over fsys. A nil fsys uses the templates
+// embedded in this package.
+func NewRegistry(fsys fs.FS, defaultLocale string) *Registry {
+	if fsys == nil {
+		sub, err := fs.Sub(embedded, "templates")
+		if err != nil {
+			panic(err)
+		}
+		fsys = sub
+	}
+	if defaultLocale == "" {
+		defaultLocale = "en"
+	}
+	return &Registry{fsys: fsys, defaultLocale: defaultLocale}
+}
+
+// Render renders name for locale. Resolution tries the exact locale ("id-ID"),
+// then its base language ("id"), then the registry default.
+func (r *Registry) Render(name, locale string, data map[string]any) (*Rendered, error) {
+	if strings.ContainsAny(name, "/\\") || strings.Contains(name, "..") {
+		return nil, fmt.Errorf("%w: %q", ErrTemplateNotFound, name)
+	}
+
+	for _, loc := range r.candidates(locale) {
+		out, found, err := r.render(loc, name, data)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			return out, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", ErrTemplateNotFound, name)
+}
+
+func (r *Registry) candidates(locale string) []string {
+	locale = strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+	var out []string
+	add := func(l string) {
+		for _, existing := range out {
+			if existing == l {
+				return
+			}
+		}
+		out = append(out, l)
+	}
+	if locale != "" {
+		add(locale)
+		if base, _, ok := strings.Cut(locale, "-"); ok {
+			add(base)
+		}
+	}
+	add(r.defaultLocale)
+	return out
+}
+
+func (r *Registry) render(locale, name string, data map[string]any) (*Rendered, bool, error) {
+	htmlSrc, htmlErr := fs.ReadFile(r.fsys, path.Join(locale, name+".html.tmpl"))
+	textSrc, textErr := fs.ReadFile(r.fsys, path.Join(locale, name+".txt.tmpl"))
+	if htmlErr != nil && textErr != nil {
+		return nil, false, nil
+	}
+
+	out := &Rendered{}
+	if htmlErr == nil {
+		tmpl, err := htmltemplate.New(name).Option("missingkey=error").Parse(string(htmlSrc))
+		if err != nil {
+			return nil, true, fmt.Errorf("%w: parse %s/%s html: %w", ErrRender, locale, name, err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, true, fmt.Errorf("%w: render %s/%s html: %w", ErrRender, locale, name, err)
+		}
+		out.HTML = buf.String()
+	}
+	if textErr == nil {
+		tmpl, err := texttemplate.New(name).Option("missingkey=error").Parse(string(textSrc))
+		if err != nil {
+			return nil, true, fmt.Errorf("%w: parse %s/%s text: %w", ErrRender, locale, name, err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, true, fmt.Errorf("%w: render %s/%s text: %w", ErrRender, locale, name, err)
+		}
+		out.Text = buf.String()
+	}
+	return out, true, nil
+}
@@ -0,0 +1,114 @@
+package mailer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"en/greet.html.tmpl":    {Data: []byte(`<p>Hello {{.Name}}</p>`)},
+		"en/greet.txt.tmpl":     {Data: []byte(`Hello {{.Name}}`)},
+		"id/greet.txt.tmpl":     {Data: []byte(`Halo {{.Name}}`)},
+		"en/only-text.txt.tmpl": {Data: []byte(`plain {{.Name}}`)},
+		"en/escape.html.tmpl":   {Data: []byte(`<p>{{.Name}}</p>`)},
+		"en-gb/greet.txt.tmpl":  {Data: []byte(`Hiya {{.Name}}`)},
+		"en/broken.txt.tmpl":    {Data: []byte(`{{.Name`)},
+	}
+}
+
+func TestRegistry_LocaleFallback(t *testing.T) {
+	r := NewRegistry(testFS(), "en")
+
+	tests := []struct {
+		name     string
+		locale   string
+		wantText string
+	}{
+		{"exact locale", "id", "Halo Ann"},
+		{"region falls back to base language", "id-ID", "Halo Ann"},
+		{"underscore region is normalized", "id_ID", "Halo Ann"},
+		{"regional template preferred over base", "en-GB", "Hiya Ann"},
+		{"unknown locale falls back to default", "fr", "Hello Ann"},
+		{"empty locale uses default", "", "Hello Ann"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := r.Render("greet", tt.locale, map[string]any{"Name": "Ann"})
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			if out.Text != tt.wantText {
+				t.Fatalf("want text %q, got %q", tt.wantText, out.Text)
+			}
+		})
+	}
+}
+
+func TestRegistry_RenderErrors(t *testing.T) {
+	r := NewRegistry(testFS(), "en")
+
+	tests := []struct {
+		name     string
+		template string
+		data     map[string]any
+		wantErr  error
+	}{
+		{"missing variable", "greet", map[string]any{}, ErrRender},
+		{"nil data", "greet", nil, ErrRender},
+		{"parse error", "broken", map[string]any{"Name": "Ann"}, ErrRender},
+		{"unknown template", "nope", map[string]any{"Name": "Ann"}, ErrTemplateNotFound},
+		{"path traversal", "../en/greet", map[string]any{"Name": "Ann"}, ErrTemplateNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := r.Render(tt.template, "en", tt.data)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("want %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestRegistry_HTMLIsEscapedAndPartsOptional(t *testing.T) {
+	r := NewRegistry(testFS(), "en")
+
+	out, err := r.Render("escape", "en", map[string]any{"Name": "<script>"})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if strings.Contains(out.HTML, "<script>") {
+		t.Fatalf("html not escaped: %s", out.HTML)
+	}
+
+	out, err = r.Render("only-text", "en", map[string]any{"Name": "x"})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if out.HTML != "" || out.Text != "plain x" {
+		t.Fatalf("unexpected parts: %+v", out)
+	}
+}
+
+func TestRegistry_EmbeddedTemplates(t *testing.T) {
+	r := NewRegistry(nil, "en")
+	templates := map[string]map[string]any{
+		"welcome":        {"Name": "Ann"},
+		"impersonation":  {"Name": "Ann", "ExpiresAt": "Mon, 02 Jan 2006 15:04:05 UTC"},
+		"password_reset": {"Name": "Ann", "Link": "https://app.example.com/reset-password?token=t", "ExpiresAt": "Mon, 02 Jan 2006 15:04:05 UTC"},
+		"invitation":     {"Name": "Ann", "Link": "https://app.example.com/accept-invite?token=t", "ExpiresAt": "Mon, 02 Jan 2006 15:04:05 UTC"},
+	}
+	for name, data := range templates {
+		for _, locale := range []string{"en", "id"} {
+			out, err := r.Render(name, locale, data)
+			if err != nil {
+				t.Fatalf("%s/%s: %v", locale, name, err)
+			}
+			if out.HTML == "" || out.Text == "" {
+				t.Fatalf("%s/%s: want both parts, got %+v", locale, name, out)
+			}
+		}
+	}
+}
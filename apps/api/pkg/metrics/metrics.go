@@ -2,6 +2,8 @@
 package metrics
 
 import (
+	"context"
+	"errors"
 	"strconv"
 	"time"
 
@@ -13,6 +15,10 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+// statusClientClosedRequest mirrors middleware.StatusClientClosedRequest,
+// which this package cannot import.
+const statusClientClosedRequest = 499
+
 // Metrics holds all application metrics
 type Metrics struct {
 	// HTTP metrics
@@ -20,28 +26,65 @@ type Metrics struct {
 	httpRequestDuration  *prometheus.HistogramVec
 	httpRequestsInFlight prometheus.Gauge
 	httpResponseSize     *prometheus.HistogramVec
+	httpClientClosed     *prometheus.CounterVec
+
+	// gRPC metrics
+	grpcRequestsTotal   *prometheus.CounterVec
+	grpcRequestDuration *prometheus.HistogramVec
 
 	// Business metrics
 	usersRegistered prometheus.Counter
 	usersLoggedIn   prometheus.Counter
-	activeUsers     prometheus.Gauge
+	activeUsers     *prometheus.GaugeVec
+
+	// Auth metrics
+	authDenialsShadow *prometheus.CounterVec
+
+	// Token introspection metrics
+	introspectBatchSize   prometheus.Histogram
+	introspectBatchUnique prometheus.Histogram
 
 	// Database metrics
-	dbQueriesTotal    *prometheus.CounterVec
-	dbQueryDuration   *prometheus.HistogramVec
-	dbConnectionsOpen prometheus.Gauge
+	dbQueriesTotal     *prometheus.CounterVec
+	dbQueryDuration    *prometheus.HistogramVec
+	dbQueryErrors      *prometheus.CounterVec
+	dbPreparedErrors   *prometheus.CounterVec
+	dbConnectionsOpen  prometheus.Gauge
+	dbConnectionsInUse prometheus.Gauge
+	dbConnectionsIdle  prometheus.Gauge
+	dbConnectionsMax   prometheus.Gauge
+	dbPoolWaits        prometheus.Counter
+	dbPoolWaitSeconds  prometheus.Counter
 
 	// Cache metrics
 	cacheHitsTotal   *prometheus.CounterVec
 	cacheMissesTotal *prometheus.CounterVec
 
+	// Redis metrics
+	redisPoolActive      prometheus.Gauge
+	redisPoolIdle        prometheus.Gauge
+	redisPoolWaits       prometheus.Counter
+	redisPoolWaitSeconds prometheus.Counter
+	redisCommandDuration *prometheus.HistogramVec
+
 	// Queue metrics
 	messagesPublished *prometheus.CounterVec
 	messagesConsumed  *prometheus.CounterVec
+	consumerPaused    *prometheus.GaugeVec
+	rabbitReconnects  *prometheus.CounterVec
+	publishConfirm    *prometheus.HistogramVec
+	handlerDuration   *prometheus.HistogramVec
+	eventsDropped     *prometheus.CounterVec
+	outboxPending     prometheus.Gauge
+	outboxLag         prometheus.Gauge
 
 	// Circuit breaker metrics
 	circuitBreakerState *prometheus.GaugeVec
 
+	// Websocket metrics
+	websocketConnections prometheus.Gauge
+	websocketSlowClients prometheus.Counter
+
 	// Custom registry
 	registry *prometheus.Registry
 }
@@ -77,6 +120,25 @@ func New(namespace string) *Metrics {
 			[]string{"method", "path", "status"},
 		),
 
+		grpcRequestsTotal: promauto.With(registry).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "grpc_requests_total",
+				Help:      "Total number of gRPC requests",
+			},
+			[]string{"method", "code"},
+		),
+
+		grpcRequestDuration: promauto.With(registry).NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "grpc_request_duration_seconds",
+				Help:      "gRPC request duration in seconds",
+				Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+			},
+			[]string{"method", "code"},
+		),
+
 		httpRequestsInFlight: promauto.With(registry).NewGauge(
 			prometheus.GaugeOpts{
 				Namespace: namespace,
@@ -95,6 +157,15 @@ func New(namespace string) *Metrics {
 			[]string{"method", "path"},
 		),
 
+		httpClientClosed: promauto.With(registry).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "http_requests_client_closed_total",
+				Help:      "HTTP requests abandoned because the client disconnected mid-request",
+			},
+			[]string{"method", "path"},
+		),
+
 		// Business metrics
 		usersRegistered: promauto.With(registry).NewCounter(
 			prometheus.CounterOpts{
@@ -112,11 +183,41 @@ func New(namespace string) *Metrics {
 			},
 		),
 
-		activeUsers: promauto.With(registry).NewGauge(
+		activeUsers: promauto.With(registry).NewGaugeVec(
 			prometheus.GaugeOpts{
 				Namespace: namespace,
 				Name:      "active_users",
-				Help:      "Current number of active users",
+				Help:      "Estimated distinct users who made an authenticated request in the window (HyperLogLog, ~0.81% standard error)",
+			},
+			[]string{"window"},
+		),
+
+		// Auth metrics
+		authDenialsShadow: promauto.With(registry).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "auth_denials_shadow_total",
+				Help:      "Requests a monitor-mode role check would have rejected",
+			},
+			[]string{"route"},
+		),
+
+		// Token introspection metrics
+		introspectBatchSize: promauto.With(registry).NewHistogram(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "auth_introspect_batch_size",
+				Help:      "Number of tokens per introspect-batch request",
+				Buckets:   []float64{1, 2, 5, 10, 20, 50, 100},
+			},
+		),
+
+		introspectBatchUnique: promauto.With(registry).NewHistogram(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "auth_introspect_batch_unique_tokens",
+				Help:      "Number of distinct tokens validated per introspect-batch request",
+				Buckets:   []float64{1, 2, 5, 10, 20, 50, 100},
 			},
 		),
 
@@ -140,6 +241,24 @@ func New(namespace string) *Metrics {
 			[]string{"operation", "table"},
 		),
 
+		dbQueryErrors: promauto.With(registry).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "db_query_errors_total",
+				Help:      "Total number of failed database queries by SQLSTATE or error class",
+			},
+			[]string{"operation", "table", "code"},
+		),
+
+		dbPreparedErrors: promauto.With(registry).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "db_prepared_statement_errors_total",
+				Help:      "Queries failed on a missing or duplicate prepared statement, usually a pooler in transaction mode without DB_POOLER_MODE=transaction",
+			},
+			[]string{"code"},
+		),
+
 		dbConnectionsOpen: promauto.With(registry).NewGauge(
 			prometheus.GaugeOpts{
 				Namespace: namespace,
@@ -148,6 +267,46 @@ func New(namespace string) *Metrics {
 			},
 		),
 
+		dbConnectionsInUse: promauto.With(registry).NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "db_connections_in_use",
+				Help:      "Database connections currently in use",
+			},
+		),
+
+		dbConnectionsIdle: promauto.With(registry).NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "db_connections_idle",
+				Help:      "Idle database connections",
+			},
+		),
+
+		dbConnectionsMax: promauto.With(registry).NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "db_connections_max_open",
+				Help:      "Maximum open database connections (DB_MAX_OPEN_CONNS)",
+			},
+		),
+
+		dbPoolWaits: promauto.With(registry).NewCounter(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "db_pool_waits_total",
+				Help:      "Total number of times a query waited for a database connection",
+			},
+		),
+
+		dbPoolWaitSeconds: promauto.With(registry).NewCounter(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "db_pool_wait_seconds_total",
+				Help:      "Total time queries spent waiting for a database connection",
+			},
+		),
+
 		// Cache metrics
 		cacheHitsTotal: promauto.With(registry).NewCounterVec(
 			prometheus.CounterOpts{
@@ -167,6 +326,49 @@ func New(namespace string) *Metrics {
 			[]string{"cache"},
 		),
 
+		// Redis metrics
+		redisPoolActive: promauto.With(registry).NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "redis_pool_active_connections",
+				Help:      "Redis pool connections in use or idle (pool.ActiveCount)",
+			},
+		),
+
+		redisPoolIdle: promauto.With(registry).NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "redis_pool_idle_connections",
+				Help:      "Idle Redis pool connections",
+			},
+		),
+
+		redisPoolWaits: promauto.With(registry).NewCounter(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "redis_pool_waits_total",
+				Help:      "Total number of times a caller waited for a Redis pool connection",
+			},
+		),
+
+		redisPoolWaitSeconds: promauto.With(registry).NewCounter(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "redis_pool_wait_seconds_total",
+				Help:      "Total time callers spent waiting for a Redis pool connection",
+			},
+		),
+
+		redisCommandDuration: promauto.With(registry).NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "redis_command_duration_seconds",
+				Help:      "Redis command duration in seconds",
+				Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+			},
+			[]string{"command"},
+		),
+
 		// Queue metrics
 		messagesPublished: promauto.With(registry).NewCounterVec(
 			prometheus.CounterOpts{
@@ -177,6 +379,31 @@ func New(namespace string) *Metrics {
 			[]string{"exchange", "routing_key"},
 		),
 
+		eventsDropped: promauto.With(registry).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "events_dropped_total",
+				Help:      "Domain events that could not be published",
+			},
+			[]string{"type"},
+		),
+
+		outboxPending: promauto.With(registry).NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "outbox_pending_messages",
+				Help:      "Outbox messages not yet published",
+			},
+		),
+
+		outboxLag: promauto.With(registry).NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "outbox_lag_seconds",
+				Help:      "Age of the oldest outbox message not yet published",
+			},
+		),
+
 		messagesConsumed: promauto.With(registry).NewCounterVec(
 			prometheus.CounterOpts{
 				Namespace: namespace,
@@ -186,6 +413,44 @@ func New(namespace string) *Metrics {
 			[]string{"queue"},
 		),
 
+		consumerPaused: promauto.With(registry).NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "rabbitmq_consumer_paused",
+				Help:      "Whether consumption of a queue is paused (1) or running (0)",
+			},
+			[]string{"queue"},
+		),
+
+		rabbitReconnects: promauto.With(registry).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "rabbitmq_reconnects_total",
+				Help:      "Total number of RabbitMQ reconnects, of the whole connection or only the publish channel",
+			},
+			[]string{"scope"},
+		),
+
+		publishConfirm: promauto.With(registry).NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "rabbitmq_publish_confirm_duration_seconds",
+				Help:      "Time from a confirmed publish to the broker's ack, nack or the confirm timeout",
+				Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+			},
+			[]string{"outcome"},
+		),
+
+		handlerDuration: promauto.With(registry).NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "worker_handler_duration_seconds",
+				Help:      "Time a worker handler took per message, by handler and outcome",
+				Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+			},
+			[]string{"handler", "outcome"},
+		),
+
 		// Circuit breaker metrics
 		circuitBreakerState: promauto.With(registry).NewGaugeVec(
 			prometheus.GaugeOpts{
@@ -195,6 +460,22 @@ func New(namespace string) *Metrics {
 			},
 			[]string{"name"},
 		),
+
+		// Websocket metrics
+		websocketConnections: promauto.With(registry).NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "websocket_connections",
+				Help:      "Number of open notification websocket connections",
+			},
+		),
+		websocketSlowClients: promauto.With(registry).NewCounter(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "websocket_slow_client_disconnects_total",
+				Help:      "Notification websocket clients disconnected for falling behind",
+			},
+		),
 	}
 
 	return m
@@ -207,8 +488,10 @@ func (m *Metrics) Handler() fiber.Handler {
 	}))
 }
 
-// Middleware returns a Fiber middleware that records HTTP metrics
-func (m *Metrics) Middleware() fiber.Handler {
+// Middleware returns a Fiber middleware that records HTTP metrics. Requests
+// for which skip (optional) returns true are left out of the request
+// counters and histograms.
+func (m *Metrics) Middleware(skip func(*fiber.Ctx) bool) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		start := time.Now()
 
@@ -218,6 +501,11 @@ func (m *Metrics) Middleware() fiber.Handler {
 		// Process request
 		err := c.Next()
 
+		// Checked after the handler so route-level overrides are visible.
+		if skip != nil && skip(c) {
+			return err
+		}
+
 		// Derive the true status: on a handler error, Fiber's ErrorHandler runs
 		// after this middleware, so c.Response().StatusCode() is still the
 		// default here.
@@ -229,6 +517,12 @@ func (m *Metrics) Middleware() fiber.Handler {
 				statusCode = fiber.StatusInternalServerError
 			}
 		}
+		// A cancelled (not timed-out) request context means the client
+		// disconnected (middleware.ClientDisconnectMiddleware), whatever error
+		// the handler made of it.
+		if errors.Is(c.UserContext().Err(), context.Canceled) {
+			statusCode = statusClientClosedRequest
+		}
 
 		// Record metrics
 		duration := time.Since(start).Seconds()
@@ -247,6 +541,19 @@ func (m *Metrics) Middleware() fiber.Handler {
 	}
 }
 
+// RecordGRPCRequest records a finished gRPC call. method is the full method
+// name and code its status code (e.g. "OK", "NotFound").
+func (m *Metrics) RecordGRPCRequest(method, code string, duration time.Duration) {
+	m.grpcRequestsTotal.WithLabelValues(method, code).Inc()
+	m.grpcRequestDuration.WithLabelValues(method, code).Observe(duration.Seconds())
+}
+
+// RecordClientClosed counts a request whose client disconnected before the
+// response was written. path is the route pattern.
+func (m *Metrics) RecordClientClosed(method, path string) {
+	m.httpClientClosed.WithLabelValues(method, path).Inc()
+}
+
 // RecordUserRegistered increments user registration counter
 func (m *Metrics) RecordUserRegistered() {
 	m.usersRegistered.Inc()
@@ -257,9 +564,23 @@ func (m *Metrics) RecordUserLogin() {
 	m.usersLoggedIn.Inc()
 }
 
-// SetActiveUsers sets the number of active users
-func (m *Metrics) SetActiveUsers(count float64) {
-	m.activeUsers.Set(count)
+// SetActiveUsers sets the number of distinct active users over window
+// (e.g. "1d", "7d").
+func (m *Metrics) SetActiveUsers(window string, count float64) {
+	m.activeUsers.WithLabelValues(window).Set(count)
+}
+
+// RecordShadowAuthDenial counts a request that a monitor-mode role check let
+// through but would have rejected under enforcement.
+func (m *Metrics) RecordShadowAuthDenial(route string) {
+	m.authDenialsShadow.WithLabelValues(route).Inc()
+}
+
+// ObserveIntrospectBatch records the size of an introspect-batch request and
+// how many distinct tokens it contained.
+func (m *Metrics) ObserveIntrospectBatch(size, unique int) {
+	m.introspectBatchSize.Observe(float64(size))
+	m.introspectBatchUnique.Observe(float64(unique))
 }
 
 // RecordDBQuery records a database query
@@ -268,9 +589,30 @@ func (m *Metrics) RecordDBQuery(operation, table string, duration time.Duration)
 	m.dbQueryDuration.WithLabelValues(operation, table).Observe(duration.Seconds())
 }
 
-// SetDBConnections sets the number of open database connections
-func (m *Metrics) SetDBConnections(count float64) {
-	m.dbConnectionsOpen.Set(count)
+// RecordDBQueryError records a failed database query
+func (m *Metrics) RecordDBQueryError(operation, table, code string) {
+	m.dbQueryErrors.WithLabelValues(operation, table, code).Inc()
+}
+
+// RecordDBPreparedStmtError records a query that failed on a prepared
+// statement the server connection did not have, or already had.
+func (m *Metrics) RecordDBPreparedStmtError(code string) {
+	m.dbPreparedErrors.WithLabelValues(code).Inc()
+}
+
+// RecordDBPoolStats publishes database pool occupancy. waits and waitTime
+// are the totals accrued since the previous call.
+func (m *Metrics) RecordDBPoolStats(open, inUse, idle, maxOpen int, waits int64, waitTime time.Duration) {
+	m.dbConnectionsOpen.Set(float64(open))
+	m.dbConnectionsInUse.Set(float64(inUse))
+	m.dbConnectionsIdle.Set(float64(idle))
+	m.dbConnectionsMax.Set(float64(maxOpen))
+	if waits > 0 {
+		m.dbPoolWaits.Add(float64(waits))
+	}
+	if waitTime > 0 {
+		m.dbPoolWaitSeconds.Add(waitTime.Seconds())
+	}
 }
 
 // RecordCacheHit records a cache hit
@@ -283,16 +625,74 @@ func (m *Metrics) RecordCacheMiss(cache string) {
 	m.cacheMissesTotal.WithLabelValues(cache).Inc()
 }
 
+// ObserveRedisCommand records the duration of one Redis command
+func (m *Metrics) ObserveRedisCommand(command string, duration time.Duration) {
+	m.redisCommandDuration.WithLabelValues(command).Observe(duration.Seconds())
+}
+
+// RecordRedisPoolStats publishes Redis pool occupancy. waits and waitTime are
+// the increase since the previous call, since the pool reports running totals.
+func (m *Metrics) RecordRedisPoolStats(active, idle int, waits int64, waitTime time.Duration) {
+	m.redisPoolActive.Set(float64(active))
+	m.redisPoolIdle.Set(float64(idle))
+	if waits > 0 {
+		m.redisPoolWaits.Add(float64(waits))
+	}
+	if waitTime > 0 {
+		m.redisPoolWaitSeconds.Add(waitTime.Seconds())
+	}
+}
+
 // RecordMessagePublished records a published message
 func (m *Metrics) RecordMessagePublished(exchange, routingKey string) {
 	m.messagesPublished.WithLabelValues(exchange, routingKey).Inc()
 }
 
+// RecordEventDropped counts a domain event of eventType that could not be
+// published.
+func (m *Metrics) RecordEventDropped(eventType string) {
+	m.eventsDropped.WithLabelValues(eventType).Inc()
+}
+
+// SetOutboxBacklog publishes the number of unpublished outbox messages and
+// the age of the oldest (0 when there are none).
+func (m *Metrics) SetOutboxBacklog(pending int64, lag time.Duration) {
+	m.outboxPending.Set(float64(pending))
+	m.outboxLag.Set(lag.Seconds())
+}
+
 // RecordMessageConsumed records a consumed message
 func (m *Metrics) RecordMessageConsumed(queue string) {
 	m.messagesConsumed.WithLabelValues(queue).Inc()
 }
 
+// SetConsumerPaused records whether consumption of queue is paused.
+func (m *Metrics) SetConsumerPaused(queue string, paused bool) {
+	v := 0.0
+	if paused {
+		v = 1
+	}
+	m.consumerPaused.WithLabelValues(queue).Set(v)
+}
+
+// RecordRabbitMQReconnect counts a RabbitMQ reconnect; scope is
+// "connection" or "channel".
+func (m *Metrics) RecordRabbitMQReconnect(scope string) {
+	m.rabbitReconnects.WithLabelValues(scope).Inc()
+}
+
+// ObservePublishConfirm records the confirm latency of a confirmed publish;
+// outcome is ack, nack, timeout or error.
+func (m *Metrics) ObservePublishConfirm(outcome string, d time.Duration) {
+	m.publishConfirm.WithLabelValues(outcome).Observe(d.Seconds())
+}
+
+// ObserveHandler records one message handled by a worker handler; outcome is
+// ok or the failure type (error, validation, timeout or panic).
+func (m *Metrics) ObserveHandler(handler, outcome string, d time.Duration) {
+	m.handlerDuration.WithLabelValues(handler, outcome).Observe(d.Seconds())
+}
+
 // SetCircuitBreakerState sets the circuit breaker state
 // 0 = closed, 1 = half-open, 2 = open
 func (m *Metrics) SetCircuitBreakerState(name string, state int) {
@@ -302,6 +702,18 @@ func (m *Metrics) SetCircuitBreakerState(name string, state int) {
 // Global metrics instance
 var globalMetrics *Metrics
 
+// AddWebsocketConnections adjusts the open websocket connection count by
+// delta (1 on connect, -1 on disconnect).
+func (m *Metrics) AddWebsocketConnections(delta float64) {
+	m.websocketConnections.Add(delta)
+}
+
+// RecordWebsocketSlowClient counts a websocket client disconnected because
+// its send buffer filled up.
+func (m *Metrics) RecordWebsocketSlowClient() {
+	m.websocketSlowClients.Inc()
+}
+
 // Init initializes the global metrics instance
 func Init(namespace string) *Metrics {
 	globalMetrics = New(namespace)
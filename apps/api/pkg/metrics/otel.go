@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"context"
+	"math"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// Producer bridges the metrics registry into an OpenTelemetry meter
+// provider (see telemetry.New), so the same counters can be pushed over
+// OTLP where nothing scrapes /metrics. It reads the global instance on each
+// collection, so it may be created before Init.
+func Producer() sdkmetric.Producer {
+	return &producer{start: time.Now()}
+}
+
+type producer struct {
+	// start is reported as the start time of every cumulative point: the
+	// registry does not record when each series was created.
+	start time.Time
+}
+
+func (p *producer) Produce(context.Context) ([]metricdata.ScopeMetrics, error) {
+	m := Get()
+	if m == nil {
+		return nil, nil
+	}
+	families, err := m.registry.Gather()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	scope := metricdata.ScopeMetrics{Scope: instrumentation.Scope{Name: "veemon/pkg/metrics"}}
+	for _, f := range families {
+		if data := p.convert(f, now); data != nil {
+			scope.Metrics = append(scope.Metrics, metricdata.Metrics{
+				Name:        f.GetName(),
+				Description: f.GetHelp(),
+				Data:        data,
+			})
+		}
+	}
+	return []metricdata.ScopeMetrics{scope}, nil
+}
+
+// convert maps a Prometheus family onto the matching OTel aggregation:
+// counters to monotonic cumulative sums, gauges to gauges, histograms
+// (cumulative buckets) to per-bucket counts, and summaries to summaries.
+func (p *producer) convert(f *dto.MetricFamily, now time.Time) metricdata.Aggregation {
+	switch f.GetType() {
+	case dto.MetricType_COUNTER:
+		sum := metricdata.Sum[float64]{Temporality: metricdata.CumulativeTemporality, IsMonotonic: true}
+		for _, m := range f.GetMetric() {
+			sum.DataPoints = append(sum.DataPoints, metricdata.DataPoint[float64]{
+				Attributes: labels(m), StartTime: p.start, Time: now, Value: m.GetCounter().GetValue(),
+			})
+		}
+		return sum
+	case dto.MetricType_GAUGE, dto.MetricType_UNTYPED:
+		var gauge metricdata.Gauge[float64]
+		for _, m := range f.GetMetric() {
+			v := m.GetGauge().GetValue()
+			if f.GetType() == dto.MetricType_UNTYPED {
+				v = m.GetUntyped().GetValue()
+			}
+			gauge.DataPoints = append(gauge.DataPoints, metricdata.DataPoint[float64]{
+				Attributes: labels(m), Time: now, Value: v,
+			})
+		}
+		return gauge
+	case dto.MetricType_HISTOGRAM:
+		hist := metricdata.Histogram[float64]{Temporality: metricdata.CumulativeTemporality}
+		for _, m := range f.GetMetric() {
+			h := m.GetHistogram()
+			dp := metricdata.HistogramDataPoint[float64]{
+				Attributes: labels(m), StartTime: p.start, Time: now,
+				Count: h.GetSampleCount(), Sum: h.GetSampleSum(),
+			}
+			var prev uint64
+			for _, b := range h.GetBucket() {
+				if math.IsInf(b.GetUpperBound(), +1) {
+					continue
+				}
+				dp.Bounds = append(dp.Bounds, b.GetUpperBound())
+				dp.BucketCounts = append(dp.BucketCounts, b.GetCumulativeCount()-prev)
+				prev = b.GetCumulativeCount()
+			}
+			dp.BucketCounts = append(dp.BucketCounts, h.GetSampleCount()-prev) // +Inf
+			hist.DataPoints = append(hist.DataPoints, dp)
+		}
+		return hist
+	case dto.MetricType_SUMMARY:
+		var summary metricdata.Summary
+		for _, m := range f.GetMetric() {
+			s := m.GetSummary()
+			dp := metricdata.SummaryDataPoint{
+				Attributes: labels(m), StartTime: p.start, Time: now,
+				Count: s.GetSampleCount(), Sum: s.GetSampleSum(),
+			}
+			for _, q := range s.GetQuantile() {
+				dp.QuantileValues = append(dp.QuantileValues, metricdata.QuantileValue{Quantile: q.GetQuantile(), Value: q.GetValue()})
+			}
+			summary.DataPoints = append(summary.DataPoints, dp)
+		}
+		return summary
+	}
+	return nil
+}
+
+func labels(m *dto.Metric) attribute.Set {
+	kvs := make([]attribute.KeyValue, 0, len(m.GetLabel()))
+	for _, l := range m.GetLabel() {
+		kvs = append(kvs, attribute.String(l.GetName(), l.GetValue()))
+	}
+	return attribute.NewSet(kvs...)
+}
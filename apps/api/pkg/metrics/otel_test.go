@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestProducer_BridgesTheRegistry(t *testing.T) {
+	prev := Get()
+	t.Cleanup(func() { globalMetrics = prev })
+	m := Init("test")
+	m.RecordUserLogin()
+	m.RecordUserLogin()
+	m.ObserveRedisCommand("GET", 3*time.Millisecond)
+
+	scopes, err := Producer().Produce(context.Background())
+	if err != nil || len(scopes) != 1 {
+		t.Fatalf("Produce = %v, %v", scopes, err)
+	}
+	byName := make(map[string]metricdata.Aggregation)
+	for _, metric := range scopes[0].Metrics {
+		byName[metric.Name] = metric.Data
+	}
+
+	logins, ok := byName["test_users_logged_in_total"].(metricdata.Sum[float64])
+	if !ok || !logins.IsMonotonic || logins.DataPoints[0].Value != 2 {
+		t.Errorf("users_logged_in_total = %+v", byName["test_users_logged_in_total"])
+	}
+	redis, ok := byName["test_redis_command_duration_seconds"].(metricdata.Histogram[float64])
+	if !ok {
+		t.Fatalf("redis_command_duration_seconds = %T", byName["test_redis_command_duration_seconds"])
+	}
+	dp := redis.DataPoints[0]
+	if dp.Count != 1 || len(dp.BucketCounts) != len(dp.Bounds)+1 {
+		t.Errorf("histogram point = %+v", dp)
+	}
+	var total uint64
+	for _, c := range dp.BucketCounts {
+		total += c
+	}
+	if total != 1 {
+		t.Errorf("bucket counts %v sum to %d, want 1", dp.BucketCounts, total)
+	}
+}
+
+func TestProducer_BeforeInit(t *testing.T) {
+	prev := Get()
+	t.Cleanup(func() { globalMetrics = prev })
+	globalMetrics = nil
+
+	if scopes, err := Producer().Produce(context.Background()); err != nil || scopes != nil {
+		t.Errorf("Produce = %v, %v; want nothing", scopes, err)
+	}
+}
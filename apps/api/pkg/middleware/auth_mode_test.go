@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"veemon/pkg/authz"
+	"veemon/pkg/metrics"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func userValidator(string) (*AuthContext, error) {
+	return &AuthContext{UserID: "user-1", Roles: []string{"user"}}, nil
+}
+
+// shadowDenials scrapes m and returns the auth_denials_shadow_total sample
+// line for route, or "" if it was never incremented.
+func shadowDenials(t *testing.T, m *metrics.Metrics, route string) string {
+	t.Helper()
+	app := fiber.New()
+	app.Get("/metrics", m.Handler())
+	resp, err := app.Test(httptest.NewRequest("GET", "/metrics", nil))
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	prefix := `auth_test_auth_denials_shadow_total{route="` + route + `"} `
+	for _, line := range strings.Split(string(body), "\n") {
+		if strings.HasPrefix(line, prefix) {
+			return strings.TrimPrefix(line, prefix)
+		}
+	}
+	return ""
+}
+
+func fiberStatus(t *testing.T, cfg AuthConfig) int {
+	t.Helper()
+	app := fiber.New()
+	app.Get("/things", AuthMiddleware(userValidator, cfg), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	req := httptest.NewRequest("GET", "/things", nil)
+	req.Header.Set("Authorization", "Bearer t")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp.StatusCode
+}
+
+func TestAuthMiddleware_RoleMode(t *testing.T) {
+	m := metrics.Init("auth_test")
+	t.Cleanup(func() { SetAuthModeOverride("") })
+	roles := []string{"admin"}
+
+	assert.Equal(t, fiber.StatusForbidden, fiberStatus(t, AuthConfig{NeedAuth: true, AllowedRoles: roles}))
+	assert.Equal(t, fiber.StatusForbidden, fiberStatus(t, AuthConfig{NeedAuth: true, AllowedRoles: roles, Mode: AuthModeEnforce}))
+	assert.Empty(t, shadowDenials(t, m, "GET /things"), "enforce mode must not count shadow denials")
+
+	assert.Equal(t, fiber.StatusOK, fiberStatus(t, AuthConfig{NeedAuth: true, AllowedRoles: roles, Mode: AuthModeMonitor}))
+	assert.Equal(t, "1", shadowDenials(t, m, "GET /things"))
+
+	// The global override wins over the per-route mode, in both directions.
+	SetAuthModeOverride(AuthModeEnforce)
+	assert.Equal(t, fiber.StatusForbidden, fiberStatus(t, AuthConfig{NeedAuth: true, AllowedRoles: roles, Mode: AuthModeMonitor}))
+	SetAuthModeOverride(AuthModeMonitor)
+	assert.Equal(t, fiber.StatusOK, fiberStatus(t, AuthConfig{NeedAuth: true, AllowedRoles: roles}))
+	assert.Equal(t, "2", shadowDenials(t, m, "GET /things"))
+}
+
+func TestAuthMiddleware_MonitorModeStillRequiresValidToken(t *testing.T) {
+	app := fiber.New()
+	app.Get("/things", AuthMiddleware(userValidator, AuthConfig{
+		NeedAuth: true, AllowedRoles: []string{"admin"}, Mode: AuthModeMonitor,
+	}), func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/things", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestGRPCAuthInterceptor_RoleMode(t *testing.T) {
+	m := metrics.Init("auth_test")
+	const method = "/user.UserApi/ListUsers"
+	call := func(mode AuthMode) error {
+		interceptor := GRPCAuthInterceptor(userValidator, map[string]AuthConfig{
+			method: {NeedAuth: true, AllowedRoles: []string{"admin"}, Mode: mode},
+		})
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer t"))
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method},
+			func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil })
+		return err
+	}
+
+	assert.Equal(t, codes.PermissionDenied, status.Code(call(AuthModeEnforce)))
+	assert.Empty(t, shadowDenials(t, m, method))
+
+	require.NoError(t, call(AuthModeMonitor))
+	assert.Equal(t, "1", shadowDenials(t, m, method))
+}
+
+func TestAuthMiddleware_RoleCheckNormalizes(t *testing.T) {
+	validator := func(string) (*AuthContext, error) {
+		return &AuthContext{UserID: "user-1", Roles: []string{"Admin "}}, nil
+	}
+	app := fiber.New()
+	app.Get("/things", AuthMiddleware(validator, AuthConfig{NeedAuth: true, AllowedRoles: []string{"admin"}}), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	req := httptest.NewRequest("GET", "/things", nil)
+	req.Header.Set("Authorization", "Bearer t")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
+
+func TestAuthMiddleware_RequiredPermissions(t *testing.T) {
+	t.Cleanup(func() { SetPermissionPolicy(nil) })
+
+	// userValidator carries the "user" role, which the default policy grants
+	// nothing; RequiredPermissions takes precedence over AllowedRoles.
+	assert.Equal(t, fiber.StatusForbidden, fiberStatus(t, AuthConfig{NeedAuth: true, RequiredPermissions: []string{"users.read"}}))
+	assert.Equal(t, fiber.StatusForbidden, fiberStatus(t, AuthConfig{
+		NeedAuth: true, AllowedRoles: []string{"user"}, RequiredPermissions: []string{"users.read"},
+	}))
+
+	SetPermissionPolicy(authz.Policy{"user": {"users.read"}})
+	assert.Equal(t, fiber.StatusOK, fiberStatus(t, AuthConfig{NeedAuth: true, RequiredPermissions: []string{"users.read"}}))
+	assert.Equal(t, fiber.StatusForbidden, fiberStatus(t, AuthConfig{NeedAuth: true, RequiredPermissions: []string{"users.read", "users.write"}}))
+}
+
+func TestAuthContext_HasPermission(t *testing.T) {
+	auditor := &AuthContext{Roles: []string{"auditor"}}
+	assert.True(t, auditor.HasPermission("users.read"))
+	assert.False(t, auditor.HasPermission("users.write"))
+}
@@ -0,0 +1,205 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// DefaultBodyLogMaxBytes caps each body BodyLogMiddleware logs when the
+// config sets no cap.
+const DefaultBodyLogMaxBytes = 4096
+
+// DefaultSensitiveFields are masked in every logged body and header,
+// matched as lowercase substrings of the field or header name: the same
+// families the journal masks.
+var DefaultSensitiveFields = []string{"password", "secret", "token", "authorization", "apikey", "api_key", "cookie"}
+
+// redactedValue replaces the value of a masked field or header.
+const redactedValue = "[REDACTED]"
+
+// BodyLogConfig configures BodyLogMiddleware.
+type BodyLogConfig struct {
+	// SkipPaths are never logged (see ShouldSkip).
+	SkipPaths []string
+	// MaxBytes is the largest body logged; a larger one is logged as its
+	// size only. Zero means DefaultBodyLogMaxBytes.
+	MaxBytes int
+	// SensitiveFields are masked in addition to DefaultSensitiveFields.
+	SensitiveFields []string
+}
+
+// BodyLogMiddleware logs each request's headers and body and the response
+// body, for debugging integrations. Sensitive JSON and form fields, at any
+// depth, sensitive headers and sensitive query parameters (such as the
+// notification websocket's ?token=) are masked; other content types are logged
+// as their type and size only, since they cannot be masked. Register it
+// after RequestIDMiddleware so the entry carries request_id. The response
+// body of a handler that returns an error is written later by the error
+// handler, so only its error is logged.
+func BodyLogMiddleware(logger *zap.Logger, cfg BodyLogConfig) fiber.Handler {
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = DefaultBodyLogMaxBytes
+	}
+	r := redactor{keys: append(append([]string(nil), DefaultSensitiveFields...), lower(cfg.SensitiveFields)...)}
+
+	return func(c *fiber.Ctx) error {
+		if ShouldSkip(c, cfg.SkipPaths) {
+			return c.Next()
+		}
+		// Captured before the handler runs: Fiber may reuse the buffers.
+		fields := []zap.Field{
+			zap.String("method", c.Method()),
+			zap.String("path", r.url(c.OriginalURL())),
+			zap.Any("request_headers", r.headers(c)),
+			zap.String("request_body", r.body(c.Body(), string(c.Request().Header.ContentType()), cfg.MaxBytes)),
+		}
+		if requestID, ok := c.Locals("request_id").(string); ok {
+			fields = append(fields, zap.String("request_id", requestID))
+		}
+
+		err := c.Next()
+
+		resp := c.Response()
+		fields = append(fields, zap.Int("status", resp.StatusCode()))
+		switch {
+		case err != nil:
+			fields = append(fields, zap.Error(err))
+		case resp.IsBodyStream():
+			// Reading a stream would buffer all of it and consume it.
+			fields = append(fields, zap.String("response_body", "[stream]"))
+		default:
+			fields = append(fields, zap.String("response_body", r.body(resp.Body(), string(resp.Header.ContentType()), cfg.MaxBytes)))
+		}
+		logger.Info("HTTP bodies", fields...)
+		return err
+	}
+}
+
+// redactor masks the values of keys containing any of keys.
+type redactor struct {
+	keys []string
+}
+
+func (r redactor) sensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, k := range r.keys {
+		if strings.Contains(name, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// url masks the values of sensitive query parameters in u.
+func (r redactor) url(u string) string {
+	path, query, ok := strings.Cut(u, "?")
+	if !ok {
+		return u
+	}
+	params := strings.Split(query, "&")
+	for i, p := range params {
+		key, _, _ := strings.Cut(p, "=")
+		name, err := url.QueryUnescape(key)
+		if err != nil {
+			name = key
+		}
+		if r.sensitive(name) {
+			params[i] = key + "=" + redactedValue
+		}
+	}
+	return path + "?" + strings.Join(params, "&")
+}
+
+// headers returns the request headers with sensitive ones masked.
+func (r redactor) headers(c *fiber.Ctx) map[string]string {
+	out := make(map[string]string)
+	c.Request().Header.VisitAll(func(k, v []byte) {
+		name := string(k)
+		if r.sensitive(name) {
+			out[name] = redactedValue
+			return
+		}
+		out[name] = string(v)
+	})
+	return out
+}
+
+// body renders body for the log: masked JSON or form data, or a
+// placeholder for an empty, oversized or other body.
+func (r redactor) body(body []byte, contentType string, maxBytes int) string {
+	switch {
+	case len(body) == 0:
+		return ""
+	case len(body) > maxBytes:
+		return fmt.Sprintf("[%d bytes omitted]", len(body))
+	}
+	mediaType, _, _ := strings.Cut(strings.ToLower(contentType), ";")
+	switch mediaType = strings.TrimSpace(mediaType); {
+	case mediaType == fiber.MIMEApplicationForm:
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			break
+		}
+		for k := range values {
+			if r.sensitive(k) {
+				values[k] = []string{redactedValue}
+			}
+		}
+		return values.Encode()
+	case mediaType == fiber.MIMEApplicationJSON || strings.HasSuffix(mediaType, "+json"):
+		var v interface{}
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		if err := dec.Decode(&v); err != nil {
+			break
+		}
+		out, err := json.Marshal(r.value(v))
+		if err != nil {
+			break
+		}
+		return string(out)
+	}
+	return fmt.Sprintf("[%d bytes of %s]", len(body), contentTypeOr(mediaType))
+}
+
+// value masks sensitive keys in a decoded JSON value, at any depth.
+func (r redactor) value(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			if r.sensitive(k) {
+				t[k] = redactedValue
+			} else {
+				t[k] = r.value(child)
+			}
+		}
+	case []interface{}:
+		for i, child := range t {
+			t[i] = r.value(child)
+		}
+	}
+	return v
+}
+
+func contentTypeOr(mediaType string) string {
+	if mediaType == "" {
+		return "unknown type"
+	}
+	return mediaType
+}
+
+func lower(ss []string) []string {
+	out := make([]string, 0, len(ss))
+	for _, s := range ss {
+		if s = strings.ToLower(strings.TrimSpace(s)); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
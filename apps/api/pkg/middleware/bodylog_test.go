@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// newBodyLoggedApp echoes request bodies on POST /echo behind
+// RequestIDMiddleware and BodyLogMiddleware.
+func newBodyLoggedApp(t *testing.T, cfg BodyLogConfig) (*fiber.App, *observer.ObservedLogs) {
+	t.Helper()
+	core, logs := observer.New(zap.InfoLevel)
+	app := fiber.New(fiber.Config{Immutable: true})
+	app.Use(RequestIDMiddleware())
+	app.Use(BodyLogMiddleware(zap.New(core), cfg))
+	app.Post("/echo", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, string(c.Request().Header.ContentType()))
+		return c.Send(c.Body())
+	})
+	return app, logs
+}
+
+func post(t *testing.T, app *fiber.App, contentType, body string, headers map[string]string) {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/echo", strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, contentType)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	_, err := app.Test(req, -1)
+	require.NoError(t, err)
+}
+
+func TestBodyLogMiddleware_RedactsNestedJSONAndHeaders(t *testing.T) {
+	app, logs := newBodyLoggedApp(t, BodyLogConfig{SensitiveFields: []string{"SSN"}})
+
+	post(t, app, fiber.MIMEApplicationJSON,
+		`{"email":"a@example.com","password":"hunter2","profile":{"ssn":"123","tags":[{"refresh_token":"r"}]},"age":30}`,
+		map[string]string{"Authorization": "Bearer abc", "X-Request-ID": "req-1"})
+
+	entries := logs.All()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "req-1", fields["request_id"])
+	want := `{"age":30,"email":"a@example.com","password":"[REDACTED]","profile":{"ssn":"[REDACTED]","tags":[{"refresh_token":"[REDACTED]"}]}}`
+	assert.Equal(t, want, fields["request_body"])
+	assert.Equal(t, want, fields["response_body"])
+
+	headers := fields["request_headers"].(map[string]string)
+	assert.Equal(t, "[REDACTED]", headers["Authorization"])
+	assert.Equal(t, "req-1", headers["X-Request-Id"])
+}
+
+func TestBodyLogMiddleware_RedactsFormFields(t *testing.T) {
+	app, logs := newBodyLoggedApp(t, BodyLogConfig{})
+
+	post(t, app, fiber.MIMEApplicationForm, "username=ann&password=hunter2", nil)
+
+	require.Len(t, logs.All(), 1)
+	assert.Equal(t, "password=%5BREDACTED%5D&username=ann", logs.All()[0].ContextMap()["request_body"])
+}
+
+func TestBodyLogMiddleware_OmitsOversizedAndOpaqueBodies(t *testing.T) {
+	app, logs := newBodyLoggedApp(t, BodyLogConfig{MaxBytes: 16})
+
+	post(t, app, fiber.MIMEApplicationJSON, `{"note":"longer than sixteen bytes"}`, nil)
+	post(t, app, fiber.MIMETextPlain, "password=hunter2", nil)
+
+	entries := logs.All()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "[36 bytes omitted]", entries[0].ContextMap()["request_body"])
+	assert.Equal(t, "[16 bytes of text/plain]", entries[1].ContextMap()["request_body"])
+}
+
+func TestBodyLogMiddleware_SkipPaths(t *testing.T) {
+	app, logs := newBodyLoggedApp(t, BodyLogConfig{SkipPaths: []string{"/echo"}})
+
+	post(t, app, fiber.MIMEApplicationJSON, `{}`, nil)
+
+	assert.Zero(t, logs.Len())
+}
+
+func TestBodyLogMiddleware_RedactsQueryParameters(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	app := fiber.New()
+	app.Use(BodyLogMiddleware(zap.New(core), BodyLogConfig{}))
+	app.Get("/api/v1/ws/notifications", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) })
+
+	_, err := app.Test(httptest.NewRequest("GET", "/api/v1/ws/notifications?token=secret&since=5&access%5Ftoken=other", nil), -1)
+	require.NoError(t, err)
+
+	entries := logs.All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "/api/v1/ws/notifications?token=[REDACTED]&since=5&access%5Ftoken=[REDACTED]", entries[0].ContextMap()["path"])
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ConditionalGetMiddleware answers a GET or HEAD with 304 Not Modified and
+// no body when the handler's 200 response carries an ETag that the request's
+// If-None-Match matches. Handlers only set the ETag; the response envelope
+// is built as usual and dropped here, so a client that already holds the
+// current version is spared the payload but not the handler's work. A 304
+// is a success: the handler returned no error, so tracing and logging treat
+// it as one.
+func ConditionalGetMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if err := c.Next(); err != nil {
+			return err
+		}
+		if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+			return nil
+		}
+		if c.Response().StatusCode() != fiber.StatusOK {
+			return nil
+		}
+		etag := string(c.Response().Header.Peek(fiber.HeaderETag))
+		if etag == "" || !ETagMatches(c.Get(fiber.HeaderIfNoneMatch), etag) {
+			return nil
+		}
+		c.Response().ResetBody()
+		c.Response().Header.Del(fiber.HeaderContentType)
+		c.Status(fiber.StatusNotModified)
+		return nil
+	}
+}
+
+// ETagMatches reports whether the If-None-Match header value ifNoneMatch
+// matches etag, by the weak comparison RFC 9110 requires for it: "*" matches
+// any tag, otherwise one of the comma-separated tags must equal etag once the
+// W/ prefixes are ignored.
+func ETagMatches(ifNoneMatch, etag string) bool {
+	ifNoneMatch = strings.TrimSpace(ifNoneMatch)
+	if ifNoneMatch == "" {
+		return false
+	}
+	if ifNoneMatch == "*" {
+		return true
+	}
+	opaque := strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == opaque {
+			return true
+		}
+	}
+	return false
+}
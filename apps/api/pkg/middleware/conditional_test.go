@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestETagMatches(t *testing.T) {
+	const etag = `W/"2026-01-01T00:00:00Z"`
+	tests := []struct {
+		ifNoneMatch string
+		want        bool
+	}{
+		{`W/"2026-01-01T00:00:00Z"`, true},
+		{`"2026-01-01T00:00:00Z"`, true},
+		{`"other", W/"2026-01-01T00:00:00Z"`, true},
+		{`*`, true},
+		{`"2026-01-02T00:00:00Z"`, false},
+		{`2026-01-01T00:00:00Z`, false},
+		{``, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ETagMatches(tt.ifNoneMatch, etag), "If-None-Match: %s", tt.ifNoneMatch)
+	}
+}
+
+func TestConditionalGetMiddleware(t *testing.T) {
+	const etag = `W/"v1"`
+	app := fiber.New()
+	app.Use(ConditionalGetMiddleware())
+	tagged := func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderETag, etag)
+		return c.JSON(fiber.Map{"success": true})
+	}
+	app.Get("/tagged", tagged)
+	app.Post("/tagged", tagged)
+	app.Get("/untagged", func(c *fiber.Ctx) error { return c.JSON(fiber.Map{"success": true}) })
+	app.Get("/missing", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderETag, etag)
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false})
+	})
+
+	tests := []struct {
+		name, method, path, ifNoneMatch string
+		status                          int
+	}{
+		{"matching", "GET", "/tagged", `"v1"`, 304},
+		{"matching HEAD", "HEAD", "/tagged", `W/"v1"`, 304},
+		{"stale", "GET", "/tagged", `W/"v0"`, 200},
+		{"no If-None-Match", "GET", "/tagged", "", 200},
+		{"not a GET", "POST", "/tagged", `W/"v1"`, 200},
+		{"no ETag", "GET", "/untagged", "*", 200},
+		{"error response", "GET", "/missing", `W/"v1"`, 404},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			resp, err := app.Test(req, -1)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+
+			assert.Equal(t, tt.status, resp.StatusCode)
+			if tt.status == fiber.StatusNotModified {
+				assert.Empty(t, body)
+				assert.Equal(t, etag, resp.Header.Get("ETag"), "a 304 still carries the ETag")
+			}
+		})
+	}
+}
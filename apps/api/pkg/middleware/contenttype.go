@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"mime"
+	"strings"
+)
+
+// ContentTypeAllowed reports whether a request Content-Type header names one of
+// the allowed media types. Parameters (charset, multipart boundary) are
+// ignored; comparison is case-insensitive. A missing, malformed, or
+// comma-joined header (e.g. two conflicting Content-Type values folded into
+// one) never matches, so a body cannot be smuggled past a JSON-only route as
+// form or multipart data.
+func ContentTypeAllowed(contentType string, allowed ...string) bool {
+	if contentType == "" || strings.Contains(contentType, ",") {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, a := range allowed {
+		if strings.EqualFold(mediaType, a) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"veemon/pkg/metrics"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// StatusClientClosedRequest is the non-standard status (nginx's 499) used in
+// logs and metrics for requests whose client went away before the response.
+const StatusClientClosedRequest = 499
+
+// DefaultDisconnectPollInterval is how often ClientDisconnectMiddleware checks
+// the connection. Requests that finish sooner are never checked.
+const DefaultDisconnectPollInterval = 200 * time.Millisecond
+
+// ErrClientClosed is the cancellation cause of a request context whose client
+// disconnected.
+var ErrClientClosed = errors.New("client closed request")
+
+// ClientDisconnectMiddleware cancels the request's user context, with cause
+// ErrClientClosed, when the client closes its connection mid-request, so
+// queries and calls made with that context stop instead of running to
+// completion for nobody.
+//
+// fasthttp does not notice a disconnect while a handler runs, so a watchdog
+// polls the socket every interval with a non-blocking peek that reads
+// nothing. Connections it cannot peek (TLS terminated in-process, test
+// connections, unsupported platforms) are left alone.
+//
+// When the client is gone the handler's error is dropped (there is nobody to
+// send it to), the response status is set to 499 for LoggerMiddleware and
+// metrics, and http_requests_client_closed_total is incremented.
+func ClientDisconnectMiddleware(interval time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		conn := c.Context().Conn()
+		if interval <= 0 || !canPeek(conn) {
+			return c.Next()
+		}
+
+		ctx, cancel := context.WithCancelCause(c.UserContext())
+		defer cancel(nil)
+		c.SetUserContext(ctx)
+
+		// The watchdog must exit before the handler returns: fasthttp reuses
+		// the connection for the next request as soon as it does.
+		done := make(chan struct{})
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					if peerClosed(conn) {
+						cancel(ErrClientClosed)
+						return
+					}
+				}
+			}
+		}()
+
+		err := c.Next()
+		close(done)
+		wg.Wait()
+
+		if !ClientGone(c) {
+			return err
+		}
+		c.Status(StatusClientClosedRequest)
+		if m := metrics.Get(); m != nil {
+			m.RecordClientClosed(c.Method(), c.Route().Path)
+		}
+		return nil
+	}
+}
+
+// ClientGone reports whether the request's client disconnected mid-request.
+func ClientGone(c *fiber.Ctx) bool {
+	return errors.Is(context.Cause(c.UserContext()), ErrClientClosed)
+}
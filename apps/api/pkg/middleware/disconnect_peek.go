@@ -0,0 +1,45 @@
+//go:build linux || darwin
+
+package middleware
+
+import (
+	"errors"
+	"net"
+	"syscall"
+)
+
+func canPeek(conn net.Conn) bool {
+	_, ok := conn.(syscall.Conn)
+	return ok
+}
+
+// peerClosed peeks one byte without blocking or consuming it. A zero-byte
+// read means the peer sent FIN; EAGAIN means the connection is open and idle;
+// pending bytes (a pipelined request) mean it is open too.
+func peerClosed(conn net.Conn) bool {
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return false
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return false
+	}
+	var n int
+	var rerr error
+	buf := make([]byte, 1)
+	if err := raw.Read(func(fd uintptr) bool {
+		n, _, rerr = syscall.Recvfrom(int(fd), buf, syscall.MSG_PEEK|syscall.MSG_DONTWAIT)
+		return true
+	}); err != nil {
+		return true // the descriptor is already closed
+	}
+	switch {
+	case rerr == nil:
+		return n == 0
+	case errors.Is(rerr, syscall.EAGAIN), errors.Is(rerr, syscall.EINTR):
+		return false
+	default:
+		return true // ECONNRESET and friends
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin
+
+package middleware
+
+import "net"
+
+func canPeek(net.Conn) bool { return false }
+
+func peerClosed(net.Conn) bool { return false }
@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"veemon/pkg/metrics"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// serveDisconnectAware runs app over a real TCP listener, since the peek
+// needs a socket, and returns its address.
+func serveDisconnectAware(t *testing.T, app *fiber.App) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = app.Listener(ln) }()
+	t.Cleanup(func() { _ = app.Shutdown() })
+	return ln.Addr().String()
+}
+
+func TestClientDisconnect_CancelsUsecaseContext(t *testing.T) {
+	m := metrics.Init("disconnect_test")
+	core, logs := observer.New(zap.InfoLevel)
+
+	started := make(chan struct{})
+	observed := make(chan error, 1)
+	// listUsers stands in for a usecase whose query honours ctx.
+	listUsers := func(ctx context.Context) error {
+		close(started)
+		select {
+		case <-ctx.Done():
+			observed <- context.Cause(ctx)
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+			observed <- nil
+			return nil
+		}
+	}
+
+	app := fiber.New()
+	app.Use(LoggerMiddleware(zap.New(core), LoggerConfig{}))
+	app.Use(ClientDisconnectMiddleware(10 * time.Millisecond))
+	app.Use(m.Middleware(nil))
+	app.Get("/users", func(c *fiber.Ctx) error { return listUsers(c.UserContext()) })
+	addr := serveDisconnectAware(t, app)
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("GET /users HTTP/1.1\r\nHost: test\r\n\r\n"))
+	require.NoError(t, err)
+	<-started
+	require.NoError(t, conn.Close())
+
+	select {
+	case cause := <-observed:
+		require.ErrorIs(t, cause, ErrClientClosed)
+	case <-time.After(2 * time.Second):
+		t.Fatal("usecase context was not cancelled after the client disconnected")
+	}
+
+	require.Eventually(t, func() bool { return logs.Len() == 1 }, time.Second, 10*time.Millisecond)
+	entry := logs.All()[0]
+	assert.Equal(t, "Request cancelled by client", entry.Message)
+	assert.EqualValues(t, StatusClientClosedRequest, entry.ContextMap()["status"])
+	body := scrape(t, m)
+	assert.Contains(t, body, `disconnect_test_http_requests_client_closed_total{method="GET",path="/users"} 1`)
+	assert.Contains(t, body, `disconnect_test_http_requests_total{method="GET",path="/users",status="499"} 1`)
+}
+
+func TestClientDisconnect_ConnectedClientUnaffected(t *testing.T) {
+	app := fiber.New()
+	app.Use(ClientDisconnectMiddleware(5 * time.Millisecond))
+	app.Get("/slow", func(c *fiber.Ctx) error {
+		// Outlive several polls.
+		select {
+		case <-c.UserContext().Done():
+			return c.SendStatus(fiber.StatusServiceUnavailable)
+		case <-time.After(50 * time.Millisecond):
+			return c.SendString("done")
+		}
+	})
+	addr := serveDisconnectAware(t, app)
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+	// Two requests on one keep-alive connection: the peek must not consume
+	// the second one while the first is in flight.
+	_, err = conn.Write([]byte("GET /slow HTTP/1.1\r\nHost: test\r\n\r\nGET /slow HTTP/1.1\r\nHost: test\r\n\r\n"))
+	require.NoError(t, err)
+
+	r := bufio.NewReader(conn)
+	for i := 0; i < 2; i++ {
+		resp, err := http.ReadResponse(r, nil)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		_ = resp.Body.Close()
+	}
+}
@@ -10,35 +10,70 @@ import (
 	"google.golang.org/grpc/metadata"
 )
 
+// GRPCAuthInterceptor authenticates unary calls against authConfig (the
+// generated <Service>AuthConfig map): it validates the bearer token from the
+// `authorization` metadata, checks the method's roles, and passes the
+// AuthContext to the handler in ctx. Failures are Unauthenticated or
+// PermissionDenied, like 401/403 over HTTP.
 func GRPCAuthInterceptor(validator TokenValidator, authConfig map[string]AuthConfig) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		config, ok := authConfig[info.FullMethod]
-		if !ok {
-			// Fail closed: a method with no explicit auth policy is denied
-			// rather than served without authentication.
-			return nil, errors.Unauthorized("no auth policy configured for method").GRPCStatus().Err()
-		}
-		if !config.NeedAuth {
-			return handler(ctx, req)
-		}
-
-		token, err := extractBearerToken(ctx)
+		ctx, err := authenticateGRPC(ctx, info.FullMethod, validator, authConfig)
 		if err != nil {
-			return nil, errors.Unauthorized("missing authorization").GRPCStatus().Err()
+			return nil, err
 		}
+		return handler(ctx, req)
+	}
+}
 
-		authCtx, err := validator(token)
+// GRPCStreamAuthInterceptor is GRPCAuthInterceptor for streaming calls; the
+// handler's stream.Context() carries the AuthContext.
+func GRPCStreamAuthInterceptor(validator TokenValidator, authConfig map[string]AuthConfig) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticateGRPC(ss.Context(), info.FullMethod, validator, authConfig)
 		if err != nil {
-			return nil, errors.Unauthorized("invalid token").GRPCStatus().Err()
+			return err
 		}
+		return handler(srv, &authServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
 
-		if len(config.AllowedRoles) > 0 && !hasAnyRole(authCtx.Roles, config.AllowedRoles) {
-			return nil, errors.Forbidden("insufficient permissions").GRPCStatus().Err()
-		}
+// authServerStream overrides the context of a server stream.
+type authServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authServerStream) Context() context.Context { return s.ctx }
 
-		authCtx.Token = token
-		return handler(WithAuthContext(ctx, authCtx), req)
+// authenticateGRPC applies method's policy and returns ctx with the caller's
+// AuthContext, or ctx unchanged for a public method.
+func authenticateGRPC(ctx context.Context, method string, validator TokenValidator, authConfig map[string]AuthConfig) (context.Context, error) {
+	config, ok := authConfig[method]
+	if !ok {
+		// Fail closed: a method with no explicit auth policy is denied
+		// rather than served without authentication.
+		return nil, errors.Unauthorized("no auth policy configured for method").GRPCStatus().Err()
+	}
+	if !config.NeedAuth {
+		return ctx, nil
 	}
+
+	token, err := extractBearerToken(ctx)
+	if err != nil {
+		return nil, errors.Unauthorized("missing authorization").GRPCStatus().Err()
+	}
+
+	authCtx, err := validator(token)
+	if err != nil {
+		return nil, errors.Unauthorized("invalid token").GRPCStatus().Err()
+	}
+
+	if !authorized(config, authCtx, method) {
+		return nil, errors.Forbidden("insufficient permissions").GRPCStatus().Err()
+	}
+
+	authCtx.Token = token
+	return WithAuthContext(ctx, authCtx), nil
 }
 
 func GetGRPCAuthContext(ctx context.Context) (*AuthContext, bool) {
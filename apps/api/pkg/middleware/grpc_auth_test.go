@@ -120,3 +120,57 @@ func TestGRPCAuthInterceptor_AddsAuthContextForProtectedMethod(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "ok", resp)
 }
+
+// fakeServerStream is a grpc.ServerStream with only a context.
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeServerStream) Context() context.Context { return s.ctx }
+
+func TestGRPCStreamAuthInterceptor(t *testing.T) {
+	validator := func(token string) (*AuthContext, error) {
+		if token != "valid-token" {
+			return nil, errors.New("invalid token")
+		}
+		return &AuthContext{UserID: "user-1", Roles: []string{"user"}}, nil
+	}
+	interceptor := GRPCStreamAuthInterceptor(validator, map[string]AuthConfig{
+		"/user.UserApi/Watch":      {NeedAuth: true},
+		"/user.UserApi/WatchAdmin": {NeedAuth: true, AllowedRoles: []string{"admin"}},
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		auth   string
+		want   codes.Code
+	}{
+		{"authenticated", "/user.UserApi/Watch", "Bearer valid-token", codes.OK},
+		{"missing authorization", "/user.UserApi/Watch", "", codes.Unauthenticated},
+		{"invalid token", "/user.UserApi/Watch", "Bearer bad-token", codes.Unauthenticated},
+		{"missing role", "/user.UserApi/WatchAdmin", "Bearer valid-token", codes.PermissionDenied},
+		{"no policy", "/user.UserApi/Unknown", "Bearer valid-token", codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.auth != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", tt.auth))
+			}
+			called := false
+			err := interceptor(nil, &fakeServerStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: tt.method},
+				func(srv interface{}, ss grpc.ServerStream) error {
+					called = true
+					auth, ok := GetGRPCAuthContext(ss.Context())
+					require.True(t, ok)
+					assert.Equal(t, "user-1", auth.UserID)
+					assert.Equal(t, "valid-token", auth.Token)
+					return nil
+				})
+			assert.Equal(t, tt.want, status.Code(err))
+			assert.Equal(t, tt.want == codes.OK, called)
+		})
+	}
+}
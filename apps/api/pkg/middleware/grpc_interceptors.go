@@ -5,6 +5,8 @@ import (
 	"runtime/debug"
 	"time"
 
+	pkglogger "veemon/pkg/logger"
+
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -29,8 +31,26 @@ func GRPCRecoveryInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
 	}
 }
 
-// GRPCLoggingInterceptor logs each unary call with its method, status code, and
-// latency.
+// GRPCStreamRecoveryInterceptor is GRPCRecoveryInterceptor for streaming
+// handlers: a panic ends the stream with Internal.
+func GRPCStreamRecoveryInterceptor(logger *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Error("grpc stream handler panic recovered",
+					zap.Any("panic", r),
+					zap.String("method", info.FullMethod),
+					zap.String("stack", string(debug.Stack())),
+				)
+				err = status.Error(codes.Internal, "internal server error")
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+// GRPCLoggingInterceptor logs each unary call with its method, status code,
+// latency and, behind GRPCRequestIDInterceptor, request ID.
 func GRPCLoggingInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 		start := time.Now()
@@ -40,6 +60,9 @@ func GRPCLoggingInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
 			zap.String("code", status.Code(err).String()),
 			zap.Duration("latency", time.Since(start)),
 		}
+		if requestID := pkglogger.RequestIDFromContext(ctx); requestID != "" {
+			fields = append(fields, zap.String("request_id", requestID))
+		}
 		if err != nil {
 			logger.Error("grpc call failed", append(fields, zap.Error(err))...)
 		} else {
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCRecorder receives finished gRPC calls. *metrics.Metrics satisfies it.
+type GRPCRecorder interface {
+	RecordGRPCRequest(method, code string, duration time.Duration)
+}
+
+// GRPCMetricsInterceptor records grpc_requests_total and
+// grpc_request_duration_seconds for each unary call, labelled with its full
+// method and status code. Place it outside GRPCRecoveryInterceptor so
+// recovered panics are counted as Internal.
+func GRPCMetricsInterceptor(rec GRPCRecorder) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		rec.RecordGRPCRequest(info.FullMethod, status.Code(err).String(), time.Since(start))
+		return resp, err
+	}
+}
+
+// GRPCStreamMetricsInterceptor is GRPCMetricsInterceptor for streaming
+// calls; the duration spans the whole stream.
+func GRPCStreamMetricsInterceptor(rec GRPCRecorder) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		rec.RecordGRPCRequest(info.FullMethod, status.Code(err).String(), time.Since(start))
+		return err
+	}
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type grpcCall struct{ method, code string }
+
+type grpcRecorder struct{ calls []grpcCall }
+
+func (r *grpcRecorder) RecordGRPCRequest(method, code string, _ time.Duration) {
+	r.calls = append(r.calls, grpcCall{method, code})
+}
+
+func TestGRPCMetricsInterceptor_RecordsMethodAndCode(t *testing.T) {
+	rec := &grpcRecorder{}
+	interceptor := GRPCMetricsInterceptor(rec)
+	info := &grpc.UnaryServerInfo{FullMethod: "/user.UserApi/GetUser"}
+
+	_, err := interceptor(context.Background(), nil, info, func(context.Context, interface{}) (interface{}, error) {
+		return "ok", nil
+	})
+	require.NoError(t, err)
+	_, err = interceptor(context.Background(), nil, info, func(context.Context, interface{}) (interface{}, error) {
+		return nil, status.Error(codes.NotFound, "user not found")
+	})
+	require.Error(t, err)
+
+	assert.Equal(t, []grpcCall{
+		{"/user.UserApi/GetUser", "OK"},
+		{"/user.UserApi/GetUser", "NotFound"},
+	}, rec.calls)
+}
+
+func TestGRPCMetricsInterceptor_CountsRecoveredPanicsAsInternal(t *testing.T) {
+	rec := &grpcRecorder{}
+	metricsInterceptor := GRPCMetricsInterceptor(rec)
+	recovery := GRPCRecoveryInterceptor(zap.NewNop())
+	info := &grpc.UnaryServerInfo{FullMethod: "/user.UserApi/Login"}
+
+	_, err := metricsInterceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return recovery(ctx, req, info, func(context.Context, interface{}) (interface{}, error) {
+			panic("boom")
+		})
+	})
+
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, []grpcCall{{"/user.UserApi/Login", "Internal"}}, rec.calls)
+}
+
+func TestGRPCStreamMetricsInterceptor_CountsRecoveredPanicsAsInternal(t *testing.T) {
+	rec := &grpcRecorder{}
+	metricsInterceptor := GRPCStreamMetricsInterceptor(rec)
+	recovery := GRPCStreamRecoveryInterceptor(zap.NewNop())
+	info := &grpc.StreamServerInfo{FullMethod: "/grpc.health.v1.Health/Watch"}
+
+	err := metricsInterceptor(nil, &fakeServerStream{ctx: context.Background()}, info, func(srv interface{}, ss grpc.ServerStream) error {
+		return recovery(srv, ss, info, func(interface{}, grpc.ServerStream) error {
+			panic("boom")
+		})
+	})
+
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, []grpcCall{{"/grpc.health.v1.Health/Watch", "Internal"}}, rec.calls)
+}
+
+func TestGRPCStreamMetricsInterceptor_RecordsMethodAndCode(t *testing.T) {
+	rec := &grpcRecorder{}
+	interceptor := GRPCStreamMetricsInterceptor(rec)
+
+	err := interceptor(nil, &fakeServerStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: "/user.UserApi/Watch"},
+		func(interface{}, grpc.ServerStream) error {
+			return status.Error(codes.PermissionDenied, "nope")
+		})
+
+	require.Error(t, err)
+	assert.Equal(t, []grpcCall{{"/user.UserApi/Watch", "PermissionDenied"}}, rec.calls)
+}
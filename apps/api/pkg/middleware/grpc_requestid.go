@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+
+	"veemon/pkg/logger"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// requestIDMetadataKey is logger.RequestIDHeader as gRPC metadata, whose
+// keys are lowercase.
+var requestIDMetadataKey = strings.ToLower(logger.RequestIDHeader)
+
+// GRPCRequestIDInterceptor is RequestIDMiddleware for unary calls: it takes
+// the request ID from the caller's x-request-id metadata, or generates one,
+// puts it in the handler's context for logger.WithContext and outgoing
+// calls, tags the server span with it and returns it as header metadata.
+// Place it before the logging interceptor.
+func GRPCRequestIDInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(grpcRequestID(ctx), req)
+	}
+}
+
+// GRPCStreamRequestIDInterceptor is GRPCRequestIDInterceptor for streaming
+// calls.
+func GRPCStreamRequestIDInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &authServerStream{ServerStream: ss, ctx: grpcRequestID(ss.Context())})
+	}
+}
+
+func grpcRequestID(ctx context.Context) context.Context {
+	var requestID string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(requestIDMetadataKey); len(ids) > 0 {
+			requestID = ids[0]
+		}
+	}
+	if requestID == "" {
+		requestID = uuid.New().String()
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("request.id", requestID))
+	// Fails only outside a server call (e.g. in tests): nothing to send to.
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadataKey, requestID))
+	return logger.ContextWithRequestID(ctx, requestID)
+}
+
+// GRPCClientRequestIDInterceptor forwards the request ID in ctx (see
+// logger.ContextWithRequestID) to the called service as x-request-id
+// metadata, unless the caller set one.
+func GRPCClientRequestIDInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(outgoingRequestID(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+// GRPCStreamClientRequestIDInterceptor is GRPCClientRequestIDInterceptor for
+// streaming calls.
+func GRPCStreamClientRequestIDInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(outgoingRequestID(ctx), desc, cc, method, opts...)
+	}
+}
+
+func outgoingRequestID(ctx context.Context) context.Context {
+	requestID := logger.RequestIDFromContext(ctx)
+	if requestID == "" {
+		return ctx
+	}
+	if md, ok := metadata.FromOutgoingContext(ctx); ok && len(md.Get(requestIDMetadataKey)) > 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, requestIDMetadataKey, requestID)
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"veemon/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestGRPCRequestIDInterceptor(t *testing.T) {
+	serve := func(ctx context.Context) string {
+		var got string
+		_, err := GRPCRequestIDInterceptor()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/user.UserApi/GetUser"},
+			func(ctx context.Context, _ interface{}) (interface{}, error) {
+				got = logger.RequestIDFromContext(ctx)
+				return nil, nil
+			})
+		require.NoError(t, err)
+		return got
+	}
+
+	incoming := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-request-id", "req-1"))
+	assert.Equal(t, "req-1", serve(incoming), "caller's ID kept")
+	assert.NotEmpty(t, serve(context.Background()), "ID generated when absent")
+}
+
+func TestGRPCClientRequestIDInterceptor(t *testing.T) {
+	call := func(ctx context.Context) []string {
+		var got []string
+		err := GRPCClientRequestIDInterceptor()(ctx, "/user.UserApi/GetUser", nil, nil, nil,
+			func(ctx context.Context, _ string, _, _ interface{}, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+				md, _ := metadata.FromOutgoingContext(ctx)
+				got = md.Get("x-request-id")
+				return nil
+			})
+		require.NoError(t, err)
+		return got
+	}
+
+	ctx := logger.ContextWithRequestID(context.Background(), "req-1")
+	assert.Equal(t, []string{"req-1"}, call(ctx))
+	assert.Equal(t, []string{"caller"}, call(metadata.AppendToOutgoingContext(ctx, "x-request-id", "caller")))
+	assert.Empty(t, call(context.Background()))
+}
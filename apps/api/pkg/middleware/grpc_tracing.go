@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// handlerTracer starts the handler-layer spans. The transport spans come
+// from TracingMiddleware (REST) and the otelgrpc stats handler (gRPC), which
+// also extracts the incoming trace context; the handler span is their child.
+var handlerTracer = otel.Tracer("handler")
+
+// HandlerSpanName names the handler-layer span of a full gRPC method, e.g.
+// "handler user.UserApi/Login". The generated REST routes call the same
+// handlers with the same method name, so one RPC yields the same handler
+// span whichever transport carried it.
+func HandlerSpanName(fullMethod string) string {
+	return "handler " + strings.TrimPrefix(fullMethod, "/")
+}
+
+// StartHandlerSpan starts the handler-layer span of a call to fullMethod.
+// GRPCTracingInterceptor and the generated REST routes both use it.
+func StartHandlerSpan(ctx context.Context, fullMethod string) (context.Context, trace.Span) {
+	return handlerTracer.Start(ctx, HandlerSpanName(fullMethod),
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(attribute.String("rpc.method", fullMethod)),
+	)
+}
+
+// EndHandlerSpan records the handler's status code on span and ends it.
+// Only server-side failures mark the span as errored; rejections such as
+// NotFound or InvalidArgument are the handler working as intended.
+func EndHandlerSpan(span trace.Span, err error) {
+	code := status.Code(err)
+	span.SetAttributes(attribute.String("rpc.grpc.status_code", code.String()))
+	if err != nil && isServerFault(code) {
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, err.Error())
+	}
+	span.End()
+}
+
+func isServerFault(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.DeadlineExceeded, codes.Unimplemented,
+		codes.Internal, codes.Unavailable, codes.DataLoss:
+		return true
+	}
+	return false
+}
+
+// GRPCTracingInterceptor wraps each unary handler in its handler-layer
+// span. Place it innermost, after auth, matching where the generated REST
+// routes start theirs.
+func GRPCTracingInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, span := StartHandlerSpan(ctx, info.FullMethod)
+		resp, err := handler(ctx, req)
+		EndHandlerSpan(span, err)
+		return resp, err
+	}
+}
+
+// GRPCStreamTracingInterceptor is GRPCTracingInterceptor for streaming
+// calls.
+func GRPCStreamTracingInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, span := StartHandlerSpan(ss.Context(), info.FullMethod)
+		err := handler(srv, &authServerStream{ServerStream: ss, ctx: ctx})
+		EndHandlerSpan(span, err)
+		return err
+	}
+}
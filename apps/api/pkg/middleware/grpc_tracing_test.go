@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func recordHandlerSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	spans := tracetest.NewSpanRecorder()
+	prev := handlerTracer
+	handlerTracer = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)).Tracer("test")
+	t.Cleanup(func() { handlerTracer = prev })
+	return spans
+}
+
+func TestHandlerSpanName(t *testing.T) {
+	assert.Equal(t, "handler user.UserApi/Login", HandlerSpanName("/user.UserApi/Login"))
+}
+
+func TestGRPCTracingInterceptor_WrapsHandlerInChildSpan(t *testing.T) {
+	spans := recordHandlerSpans(t)
+	parent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{2},
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+	ctx := trace.ContextWithRemoteSpanContext(context.Background(), parent)
+
+	var inner trace.SpanContext
+	_, err := GRPCTracingInterceptor()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/user.UserApi/GetUser"},
+		func(ctx context.Context, _ interface{}) (interface{}, error) {
+			inner = trace.SpanContextFromContext(ctx)
+			return "ok", nil
+		})
+	require.NoError(t, err)
+
+	ended := spans.Ended()
+	require.Len(t, ended, 1)
+	span := ended[0]
+	assert.Equal(t, "handler user.UserApi/GetUser", span.Name())
+	assert.Equal(t, parent.TraceID(), span.SpanContext().TraceID(), "continues the incoming trace")
+	assert.Equal(t, parent.SpanID(), span.Parent().SpanID())
+	assert.Equal(t, span.SpanContext().SpanID(), inner.SpanID(), "handler runs inside the span")
+	assert.Equal(t, otelcodes.Unset, span.Status().Code)
+}
+
+func TestEndHandlerSpan_OnlyServerFaultsAreErrors(t *testing.T) {
+	tests := []struct {
+		err  error
+		want otelcodes.Code
+	}{
+		{nil, otelcodes.Unset},
+		{status.Error(codes.NotFound, "missing"), otelcodes.Unset},
+		{status.Error(codes.InvalidArgument, "bad"), otelcodes.Unset},
+		{status.Error(codes.Internal, "boom"), otelcodes.Error},
+		{status.Error(codes.Unavailable, "down"), otelcodes.Error},
+	}
+	for _, tt := range tests {
+		t.Run(status.Code(tt.err).String(), func(t *testing.T) {
+			spans := recordHandlerSpans(t)
+			_, span := StartHandlerSpan(context.Background(), "/user.UserApi/GetUser")
+			EndHandlerSpan(span, tt.err)
+
+			require.Len(t, spans.Ended(), 1)
+			assert.Equal(t, tt.want, spans.Ended()[0].Status().Code)
+		})
+	}
+}
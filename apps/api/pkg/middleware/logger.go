@@ -1,6 +1,10 @@
 package middleware
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -8,11 +12,68 @@ import (
 	"go.uber.org/zap"
 )
 
-func LoggerMiddleware(logger *zap.Logger) fiber.Handler {
+// LoggerConfig configures LoggerMiddleware.
+type LoggerConfig struct {
+	// SkipPaths are never logged (see ShouldSkip).
+	SkipPaths []string
+	// SlowThreshold marks requests taking at least this long as slow: they
+	// are logged at Warn or above with slow=true and never sampled out.
+	// Zero disables it.
+	SlowThreshold time.Duration
+	// SlowRoutes override SlowThreshold for the paths they match; the first
+	// match wins. A route registered with Observe and a SlowThreshold
+	// overrides both.
+	SlowRoutes []SlowRoute
+	// SuccessSampleRate logs 1 in N of the 2xx and 304 responses that are
+	// not slow; 0 or 1 logs them all.
+	SuccessSampleRate int
+}
+
+// SlowRoute is a slow-request threshold for the paths matching Pattern, in
+// the ShouldSkip syntax.
+type SlowRoute struct {
+	Pattern   string
+	Threshold time.Duration
+}
+
+// ParseSlowRoutes parses "pattern=threshold" entries such as
+// "/api/v1/users/import=10s". A bare number threshold is milliseconds.
+func ParseSlowRoutes(entries []string) ([]SlowRoute, error) {
+	routes := make([]SlowRoute, 0, len(entries))
+	for _, e := range entries {
+		pattern, value, ok := strings.Cut(e, "=")
+		pattern, value = strings.TrimSpace(pattern), strings.TrimSpace(value)
+		if !ok || !strings.HasPrefix(pattern, "/") {
+			return nil, fmt.Errorf("%q is not /path=threshold", e)
+		}
+		threshold, err := time.ParseDuration(value)
+		if ms, numErr := strconv.ParseFloat(value, 64); numErr == nil {
+			threshold, err = time.Duration(ms*float64(time.Millisecond)), nil
+		}
+		if err != nil || threshold <= 0 {
+			return nil, fmt.Errorf("%q: threshold must be a positive duration", e)
+		}
+		routes = append(routes, SlowRoute{Pattern: pattern, Threshold: threshold})
+	}
+	return routes, nil
+}
+
+// LoggerMiddleware logs one entry per request, except for paths matching
+// cfg.SkipPaths, routes that opt out via Observe and 2xx or 304 responses
+// sampled out by cfg.SuccessSampleRate.
+func LoggerMiddleware(logger *zap.Logger, cfg LoggerConfig) fiber.Handler {
+	var successes atomic.Uint64
 	return func(c *fiber.Ctx) error {
+		if ShouldSkip(c, cfg.SkipPaths) {
+			return c.Next()
+		}
 		start := time.Now()
 
 		err := c.Next()
+		override := routeOverride(c)
+		if override.SkipLogging {
+			return err
+		}
 
 		duration := time.Since(start)
 
@@ -28,6 +89,15 @@ func LoggerMiddleware(logger *zap.Logger) fiber.Handler {
 			}
 		}
 
+		threshold := cfg.slowThreshold(c.Path(), override)
+		slow := threshold > 0 && duration >= threshold
+		// 304s answer polling clients and are sampled like other successes.
+		success := status >= 200 && status < 300 || status == fiber.StatusNotModified
+		sampled := !slow && err == nil && success && cfg.SuccessSampleRate > 1
+		if sampled && (successes.Add(1)-1)%uint64(cfg.SuccessSampleRate) != 0 {
+			return err
+		}
+
 		fields := []zap.Field{
 			zap.String("method", c.Method()),
 			zap.String("path", c.Path()),
@@ -49,7 +119,18 @@ func LoggerMiddleware(logger *zap.Logger) fiber.Handler {
 			)
 		}
 
+		if slow {
+			fields = append(fields, zap.Bool("slow", true), zap.Duration("slow_threshold", threshold))
+		}
+		if sampled {
+			// One line stands for this many requests.
+			fields = append(fields, zap.Int("sample_rate", cfg.SuccessSampleRate))
+		}
+
 		switch {
+		case status == StatusClientClosedRequest:
+			// Set by ClientDisconnectMiddleware; nobody received a response.
+			logger.Info("Request cancelled by client", fields...)
 		case err != nil:
 			fields = append(fields, zap.Error(err))
 			logger.Error("Request failed", fields...)
@@ -57,6 +138,8 @@ func LoggerMiddleware(logger *zap.Logger) fiber.Handler {
 			logger.Error("Request completed with server error", fields...)
 		case status >= 400:
 			logger.Warn("Request completed with client error", fields...)
+		case slow:
+			logger.Warn("Slow request", fields...)
 		default:
 			logger.Info("Request completed", fields...)
 		}
@@ -64,3 +147,16 @@ func LoggerMiddleware(logger *zap.Logger) fiber.Handler {
 		return err
 	}
 }
+
+// slowThreshold returns the slow-request threshold for path.
+func (cfg LoggerConfig) slowThreshold(path string, override ObservabilityOverride) time.Duration {
+	if override.SlowThreshold > 0 {
+		return override.SlowThreshold
+	}
+	for _, r := range cfg.SlowRoutes {
+		if matchPath(path, r.Pattern) {
+			return r.Threshold
+		}
+	}
+	return cfg.SlowThreshold
+}
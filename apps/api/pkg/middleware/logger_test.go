@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// newLoggedApp serves /fast, /slow (sleeps 20ms), /export (as slow, with a
+// SlowRoutes entry), /pinned (as slow, with a route override) and /missing
+// (404) behind LoggerMiddleware.
+func newLoggedApp(t *testing.T, cfg LoggerConfig) (*fiber.App, *observer.ObservedLogs) {
+	t.Helper()
+	core, logs := observer.New(zap.InfoLevel)
+	// Immutable: the observer keeps fields that would otherwise point into
+	// request buffers Fiber reuses.
+	app := fiber.New(fiber.Config{Immutable: true})
+	app.Use(LoggerMiddleware(zap.New(core), cfg))
+	sleep := func(c *fiber.Ctx) error {
+		time.Sleep(20 * time.Millisecond)
+		return c.SendString("ok")
+	}
+	app.Get("/fast", func(c *fiber.Ctx) error { return c.SendString("ok") })
+	app.Get("/slow", sleep)
+	app.Get("/export", sleep)
+	app.Get("/pinned", Observe(ObservabilityOverride{SlowThreshold: time.Hour}), sleep)
+	app.Get("/missing", func(c *fiber.Ctx) error { return fiber.ErrNotFound })
+	return app, logs
+}
+
+func get(t *testing.T, app *fiber.App, path string) {
+	t.Helper()
+	_, err := app.Test(httptest.NewRequest("GET", path, nil), -1)
+	require.NoError(t, err)
+}
+
+func TestLoggerMiddleware_SlowRequestLoggedAtWarn(t *testing.T) {
+	app, logs := newLoggedApp(t, LoggerConfig{SlowThreshold: 10 * time.Millisecond})
+
+	get(t, app, "/slow")
+	get(t, app, "/fast")
+
+	entries := logs.All()
+	require.Len(t, entries, 2)
+	assert.Equal(t, zapcore.WarnLevel, entries[0].Level)
+	assert.Equal(t, "Slow request", entries[0].Message)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, true, fields["slow"])
+	assert.Equal(t, 10*time.Millisecond, fields["slow_threshold"])
+	assert.Equal(t, "/slow", fields["path"])
+
+	assert.Equal(t, zapcore.InfoLevel, entries[1].Level)
+	assert.NotContains(t, entries[1].ContextMap(), "slow")
+}
+
+func TestLoggerMiddleware_RouteThresholdsOverrideTheDefault(t *testing.T) {
+	app, logs := newLoggedApp(t, LoggerConfig{
+		SlowThreshold: 10 * time.Millisecond,
+		SlowRoutes:    []SlowRoute{{Pattern: "/export", Threshold: time.Hour}},
+	})
+
+	get(t, app, "/export")
+	get(t, app, "/pinned")
+
+	for _, e := range logs.All() {
+		assert.Equal(t, "Request completed", e.Message, e.ContextMap()["path"])
+		assert.NotContains(t, e.ContextMap(), "slow")
+	}
+	assert.Equal(t, 2, logs.Len())
+}
+
+func TestLoggerMiddleware_SamplesSuccessfulRequests(t *testing.T) {
+	app, logs := newLoggedApp(t, LoggerConfig{SlowThreshold: 10 * time.Millisecond, SuccessSampleRate: 3})
+
+	for i := 0; i < 6; i++ {
+		get(t, app, "/fast")
+	}
+	require.Equal(t, 2, logs.Len(), "1 in 3 successful requests")
+	for _, e := range logs.All() {
+		assert.EqualValues(t, 3, e.ContextMap()["sample_rate"])
+	}
+
+	// Errors and slow requests are never sampled out.
+	get(t, app, "/missing")
+	get(t, app, "/slow")
+	entries := logs.All()
+	require.Len(t, entries, 4)
+	assert.Equal(t, int64(fiber.StatusNotFound), entries[2].ContextMap()["status"])
+	assert.NotContains(t, entries[2].ContextMap(), "sample_rate")
+	assert.Equal(t, true, entries[3].ContextMap()["slow"])
+	assert.NotContains(t, entries[3].ContextMap(), "sample_rate")
+}
+
+func TestParseSlowRoutes(t *testing.T) {
+	routes, err := ParseSlowRoutes([]string{"/api/v1/users/import=10s", " /reports/* = 1500 "})
+	require.NoError(t, err)
+	assert.Equal(t, []SlowRoute{
+		{Pattern: "/api/v1/users/import", Threshold: 10 * time.Second},
+		{Pattern: "/reports/*", Threshold: 1500 * time.Millisecond},
+	}, routes)
+
+	for _, bad := range []string{"/import", "import=1s", "/import=soon", "/import=0", "/import=-1s"} {
+		_, err := ParseSlowRoutes([]string{bad})
+		assert.Error(t, err, bad)
+	}
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DefaultObservabilitySkipPaths are the probe, scrape and docs endpoints that
+// would otherwise drown real traffic in spans, log lines and metric samples.
+var DefaultObservabilitySkipPaths = []string{"/health", "/ready", "/metrics", "/docs/*"}
+
+// ShouldSkip reports whether the request path matches any of patterns. A
+// pattern is either an exact path or a prefix ending in "/*", which matches
+// the prefix itself and anything below it at a segment boundary: "/docs/*"
+// matches /docs and /docs/openapi.json, but not /docsearch.
+func ShouldSkip(c *fiber.Ctx, patterns []string) bool {
+	path := c.Path()
+	for _, p := range patterns {
+		if matchPath(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchPath(path, pattern string) bool {
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	prefix, wildcard := strings.CutSuffix(pattern, "/*")
+	if !wildcard {
+		return path == pattern
+	}
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// ObservabilityOverride opts a single route out of request logging or
+// metrics, while ShouldSkip patterns apply to whole paths in every
+// middleware. A trace cannot be opted out per route because its span starts
+// before routing picks a handler; use a skip pattern for that.
+type ObservabilityOverride struct {
+	SkipLogging bool
+	SkipMetrics bool
+	// SlowThreshold, when positive, replaces LoggerConfig's slow-request
+	// threshold for the route.
+	SlowThreshold time.Duration
+}
+
+const observabilityKey = "observability_override"
+
+// Observe applies o to the route it is registered on, e.g.
+//
+//	app.Post("/webhooks/ingest", middleware.Observe(middleware.ObservabilityOverride{SkipLogging: true}), h)
+//
+// LoggerMiddleware and metrics.Middleware read it after the handler returns.
+func Observe(o ObservabilityOverride) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals(observabilityKey, o)
+		return c.Next()
+	}
+}
+
+func routeOverride(c *fiber.Ctx) ObservabilityOverride {
+	o, _ := c.Locals(observabilityKey).(ObservabilityOverride)
+	return o
+}
+
+// MetricsSkipper returns the skip func for metrics.Middleware: it skips
+// requests matching patterns and routes registered with SkipMetrics. It lives
+// here because pkg/metrics cannot import this package.
+func MetricsSkipper(patterns []string) func(*fiber.Ctx) bool {
+	return func(c *fiber.Ctx) bool {
+		return ShouldSkip(c, patterns) || routeOverride(c).SkipMetrics
+	}
+}
@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"veemon/pkg/metrics"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestMatchPath(t *testing.T) {
+	cases := []struct {
+		path, pattern string
+		want          bool
+	}{
+		{"/health", "/health", true},
+		{"/health/", "/health", true},
+		{"/healthz", "/health", false},
+		{"/health/deep", "/health", false},
+		{"/docs", "/docs/*", true},
+		{"/docs/", "/docs/*", true},
+		{"/docs/openapi.json", "/docs/*", true},
+		{"/docs/a/b", "/docs/*", true},
+		{"/docsearch", "/docs/*", false},
+		{"/api/docs", "/docs/*", false},
+		{"/anything", "/*", true},
+		{"/", "/*", true},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, matchPath(tc.path, tc.pattern), "%s vs %s", tc.path, tc.pattern)
+	}
+}
+
+// newObservedApp wires tracing, logging and metrics the way config.NewFiber
+// and registerObservabilityRoutes do, with recorders for each.
+func newObservedApp(t *testing.T) (*fiber.App, *tracetest.SpanRecorder, *observer.ObservedLogs, *metrics.Metrics) {
+	t.Helper()
+	spans := tracetest.NewSpanRecorder()
+	prev := tracer
+	tracer = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)).Tracer("test")
+	t.Cleanup(func() { tracer = prev })
+
+	core, logs := observer.New(zap.InfoLevel)
+	m := metrics.New("obs")
+	skip := DefaultObservabilitySkipPaths
+
+	app := fiber.New()
+	app.Use(TracingMiddleware("test", skip))
+	app.Use(LoggerMiddleware(zap.New(core), LoggerConfig{SkipPaths: skip}))
+	app.Use(m.Middleware(MetricsSkipper(skip)))
+	ok := func(c *fiber.Ctx) error { return c.SendString("ok") }
+	app.Get("/health", ok)
+	app.Get("/docs/*", ok)
+	app.Get("/docsearch", ok)
+	app.Post("/ingest", Observe(ObservabilityOverride{SkipLogging: true}), ok)
+	return app, spans, logs, m
+}
+
+func scrape(t *testing.T, m *metrics.Metrics) string {
+	t.Helper()
+	app := fiber.New()
+	app.Get("/metrics", m.Handler())
+	resp, err := app.Test(httptest.NewRequest("GET", "/metrics", nil), -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return string(body)
+}
+
+func TestObservability_SkippedPathsProduceNothing(t *testing.T) {
+	app, spans, logs, m := newObservedApp(t)
+
+	for _, path := range []string{"/health", "/docs", "/docs/openapi.json"} {
+		resp, err := app.Test(httptest.NewRequest("GET", path, nil), -1)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode, path)
+	}
+
+	assert.Empty(t, spans.Ended(), "skipped paths must not start spans")
+	assert.Zero(t, logs.Len(), "skipped paths must not be logged")
+	assert.NotContains(t, scrape(t, m), "obs_http_requests_total{")
+
+	// A path that only shares a prefix with a wildcard is still observed.
+	_, err := app.Test(httptest.NewRequest("GET", "/docsearch", nil), -1)
+	require.NoError(t, err)
+	assert.Len(t, spans.Ended(), 1)
+	assert.Equal(t, 1, logs.Len())
+	assert.Contains(t, scrape(t, m), `obs_http_requests_total{method="GET",path="/docsearch",status="200"} 1`)
+}
+
+func TestObservability_RouteOverrideKeepsMetrics(t *testing.T) {
+	app, spans, logs, m := newObservedApp(t)
+
+	_, err := app.Test(httptest.NewRequest("POST", "/ingest", strings.NewReader("{}")), -1)
+	require.NoError(t, err)
+
+	assert.Zero(t, logs.Len(), "SkipLogging route must not be logged")
+	assert.Len(t, spans.Ended(), 1, "route overrides do not affect tracing")
+	assert.Contains(t, scrape(t, m), `obs_http_requests_total{method="POST",path="/ingest",status="200"} 1`)
+}
+
+func TestTracingMiddleware_NamesSpanAfterMatchedRoute(t *testing.T) {
+	app, spans, _, _ := newObservedApp(t)
+	app.Get("/users/:id", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	_, err := app.Test(httptest.NewRequest("GET", "/users/42", nil), -1)
+	require.NoError(t, err)
+
+	require.Len(t, spans.Ended(), 1)
+	assert.Equal(t, "GET /users/:id", spans.Ended()[0].Name())
+}
+
+func TestRedactURL(t *testing.T) {
+	cases := map[string]string{
+		"/api/v1/ws/notifications":                  "/api/v1/ws/notifications",
+		"/api/v1/ws/notifications?token=abc.def":    "/api/v1/ws/notifications?token=REDACTED",
+		"/api/v1/users?page=2&token=x&size=5":       "/api/v1/users?page=2&token=REDACTED&size=5",
+		"/api/v1/users?tokens=x&search=token%3Dabc": "/api/v1/users?tokens=x&search=token%3Dabc",
+	}
+	for in, want := range cases {
+		assert.Equal(t, want, redactURL(in), in)
+	}
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"sync/atomic"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -79,6 +80,35 @@ func RateLimitMiddleware(cfg RateLimitConfig) fiber.Handler {
 	}
 }
 
+// TunableRateLimitMiddleware is RateLimitMiddleware with Max and Duration
+// taken from limits on every request, so they can change at runtime. A
+// change rebuilds the limiter, which starts every client's window afresh.
+func TunableRateLimitMiddleware(cfg RateLimitConfig, limits func() (max int, window time.Duration)) fiber.Handler {
+	type built struct {
+		max     int
+		window  time.Duration
+		handler fiber.Handler
+	}
+	var current atomic.Pointer[built]
+
+	return func(c *fiber.Ctx) error {
+		max, window := limits()
+		b := current.Load()
+		if b == nil || b.max != max || b.window != window {
+			next := cfg
+			next.Max, next.Duration = max, window
+			nb := &built{max: max, window: window, handler: RateLimitMiddleware(next)}
+			// Of concurrent rebuilds one wins; the rest use its limiter.
+			if current.CompareAndSwap(b, nb) {
+				b = nb
+			} else {
+				b = current.Load()
+			}
+		}
+		return b.handler(c)
+	}
+}
+
 // EndpointRateLimit configures a rate limit for a specific method+path.
 type EndpointRateLimit struct {
 	Path     string
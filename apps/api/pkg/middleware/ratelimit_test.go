@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTunableRateLimit_FollowsLimitChanges(t *testing.T) {
+	var max atomic.Int64
+	max.Store(2)
+	app := fiber.New()
+	app.Use(TunableRateLimitMiddleware(DefaultRateLimitConfig(), func() (int, time.Duration) {
+		return int(max.Load()), time.Minute
+	}))
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	get := func() int {
+		resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, 200, get())
+	assert.Equal(t, 200, get())
+	assert.Equal(t, 429, get())
+
+	// Raising the limit takes effect on the next request, with a fresh window.
+	max.Store(3)
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, 200, get(), "request %d after raising the limit", i+1)
+	}
+	assert.Equal(t, 429, get())
+}
@@ -1,19 +1,22 @@
 package middleware
 
 import (
+	"veemon/pkg/logger"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
 
 func RequestIDMiddleware() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		requestID := c.Get("X-Request-ID")
+		requestID := c.Get(logger.RequestIDHeader)
 		if requestID == "" {
 			requestID = uuid.New().String()
 		}
 
 		c.Locals("request_id", requestID)
-		c.Set("X-Request-ID", requestID)
+		c.SetUserContext(logger.ContextWithRequestID(c.UserContext(), requestID))
+		c.Set(logger.RequestIDHeader, requestID)
 
 		return c.Next()
 	}
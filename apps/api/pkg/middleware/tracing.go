@@ -1,11 +1,16 @@
 package middleware
 
 import (
+	"errors"
+	"strings"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/utils"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/propagation"
 	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
 	"go.opentelemetry.io/otel/trace"
@@ -13,8 +18,43 @@ import (
 
 var tracer = otel.Tracer("fiber-middleware")
 
-func TracingMiddleware(serviceName string) fiber.Handler {
+// serverDuration is the OTel http.server.duration histogram. It records
+// through the global meter provider, so it is only exported when
+// METRICS_EXPORTER=otlp installs one (see telemetry.New).
+var serverDuration, _ = otel.Meter("fiber-middleware").Float64Histogram("http.server.duration",
+	metric.WithUnit("ms"),
+	metric.WithDescription("Duration of inbound HTTP requests"),
+)
+
+// redactedQueryParams are masked in the http.url span attribute. The
+// notification websocket accepts its bearer token as ?token=, since browsers
+// cannot set headers on a websocket handshake.
+var redactedQueryParams = map[string]bool{"token": true}
+
+// redactURL replaces the values of redactedQueryParams in u with REDACTED.
+func redactURL(u string) string {
+	path, query, ok := strings.Cut(u, "?")
+	if !ok {
+		return u
+	}
+	params := strings.Split(query, "&")
+	for i, p := range params {
+		if key, _, _ := strings.Cut(p, "="); redactedQueryParams[key] {
+			params[i] = key + "=REDACTED"
+		}
+	}
+	return path + "?" + strings.Join(params, "&")
+}
+
+// TracingMiddleware starts a server span per request, except for paths
+// matching skipPaths (see ShouldSkip), and records its duration in the
+// http.server.duration histogram.
+func TracingMiddleware(serviceName string, skipPaths []string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
+		if ShouldSkip(c, skipPaths) {
+			return c.Next()
+		}
+		start := time.Now()
 		// Extract trace context from incoming request headers.
 		propagator := otel.GetTextMapPropagator()
 		ctx := propagator.Extract(c.UserContext(), propagation.HeaderCarrier(c.GetReqHeaders()))
@@ -28,7 +68,7 @@ func TracingMiddleware(serviceName string) fiber.Handler {
 		if routePath == "" {
 			routePath = utils.CopyString(c.Path())
 		}
-		originalURL := utils.CopyString(c.OriginalURL())
+		originalURL := redactURL(utils.CopyString(c.OriginalURL()))
 		hostname := utils.CopyString(c.Hostname())
 		userAgent := utils.CopyString(c.Get("User-Agent"))
 		clientIP := utils.CopyString(c.IP())
@@ -62,6 +102,14 @@ func TracingMiddleware(serviceName string) fiber.Handler {
 
 		err := c.Next()
 
+		// Before c.Next() c.Route() is this middleware's own app.Use route;
+		// once the request was routed it is the matched handler's pattern.
+		if matched := c.Route().Path; matched != "" && matched != routePath {
+			routePath = utils.CopyString(matched)
+			span.SetName(method + " " + routePath)
+			span.SetAttributes(semconv.HTTPRouteKey.String(routePath))
+		}
+
 		statusCode := c.Response().StatusCode()
 		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(statusCode))
 
@@ -73,6 +121,21 @@ func TracingMiddleware(serviceName string) fiber.Handler {
 			span.SetStatus(codes.Error, "server error")
 		}
 
+		// The error handler has not written the status of a failed request yet.
+		if err != nil {
+			var fe *fiber.Error
+			if errors.As(err, &fe) {
+				statusCode = fe.Code
+			} else if statusCode < 400 {
+				statusCode = fiber.StatusInternalServerError
+			}
+		}
+		serverDuration.Record(ctx, float64(time.Since(start))/float64(time.Millisecond), metric.WithAttributes(
+			semconv.HTTPMethodKey.String(method),
+			semconv.HTTPRouteKey.String(routePath),
+			semconv.HTTPStatusCodeKey.Int(statusCode),
+		))
+
 		return err
 	}
 }
@@ -0,0 +1,145 @@
+// Package notify fans user events out to connected clients, such as the
+// admin UI's notification websocket. A Hub delivers within one process;
+// RedisRelay carries events between replicas over Redis pub/sub so a client
+// connected to any replica sees events raised on every other.
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Event types published by the user usecase.
+const (
+	UserRegistered = "user.registered"
+	UserUpdated    = "user.updated"
+	UserDeleted    = "user.deleted"
+)
+
+// Event is one notification. Subscribers only receive events whose
+// CompanyCode equals their own.
+type Event struct {
+	Type        string          `json:"type"`
+	CompanyCode string          `json:"companyCode"`
+	OccurredAt  time.Time       `json:"occurredAt"`
+	Data        json.RawMessage `json:"data,omitempty"`
+}
+
+// Publisher accepts events. Publishing is fire-and-forget: a failure is
+// logged by the publisher and never fails the operation that raised it.
+type Publisher interface {
+	Publish(ctx context.Context, e Event)
+}
+
+// DefaultBufferSize is the number of events a subscription queues before it
+// is treated as a slow client.
+const DefaultBufferSize = 64
+
+// Hub delivers events to in-process subscriptions.
+type Hub struct {
+	bufferSize int
+
+	mu     sync.Mutex
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+// NewHub returns a hub whose subscriptions queue up to bufferSize events;
+// bufferSize < 1 uses DefaultBufferSize.
+func NewHub(bufferSize int) *Hub {
+	if bufferSize < 1 {
+		bufferSize = DefaultBufferSize
+	}
+	return &Hub{bufferSize: bufferSize, subs: make(map[*Subscription]struct{})}
+}
+
+// Subscription receives the events of one company until it is closed.
+type Subscription struct {
+	hub         *Hub
+	companyCode string
+	events      chan Event
+	closed      bool // guarded by hub.mu
+	overflowed  bool // guarded by hub.mu
+}
+
+// Subscribe registers a subscription for companyCode's events. After Close
+// it returns a subscription that is already closed.
+func (h *Hub) Subscribe(companyCode string) *Subscription {
+	s := &Subscription{hub: h, companyCode: companyCode, events: make(chan Event, h.bufferSize)}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subs[s] = struct{}{}
+	if h.closed {
+		s.closeLocked()
+	}
+	return s
+}
+
+// Publish delivers e to every matching subscription without blocking. A
+// subscription whose buffer is full is dropped: its channel is closed and
+// Overflowed reports true, so the reader can disconnect its client rather
+// than let it fall silently behind.
+func (h *Hub) Publish(_ context.Context, e Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subs {
+		if s.companyCode != e.CompanyCode {
+			continue
+		}
+		select {
+		case s.events <- e:
+		default:
+			s.overflowed = true
+			s.closeLocked()
+		}
+	}
+}
+
+// Close closes every subscription, ending their readers, and refuses new
+// ones. It is called at shutdown, since hijacked websocket connections
+// outlive the HTTP server's graceful shutdown.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for s := range h.subs {
+		s.closeLocked()
+	}
+}
+
+// Len returns the number of open subscriptions.
+func (h *Hub) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
+
+// Events returns the channel events are delivered on. It is closed when the
+// subscription is closed or overflows.
+func (s *Subscription) Events() <-chan Event { return s.events }
+
+// Overflowed reports whether the subscription was dropped for falling
+// behind.
+func (s *Subscription) Overflowed() bool {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	return s.overflowed
+}
+
+// Close unregisters the subscription. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.closeLocked()
+}
+
+func (s *Subscription) closeLocked() {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	delete(s.hub.subs, s)
+	close(s.events)
+}
@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"veemon/pkg/redis"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestHub_FiltersByCompany(t *testing.T) {
+	h := NewHub(4)
+	a, b := h.Subscribe("A"), h.Subscribe("B")
+	defer a.Close()
+	defer b.Close()
+
+	h.Publish(context.Background(), Event{Type: UserRegistered, CompanyCode: "A"})
+
+	select {
+	case e := <-a.Events():
+		if e.CompanyCode != "A" {
+			t.Fatalf("A received %+v", e)
+		}
+	default:
+		t.Fatal("A received nothing")
+	}
+	select {
+	case e := <-b.Events():
+		t.Fatalf("B received another company's event %+v", e)
+	default:
+	}
+}
+
+func TestHub_DropsOverflowingSubscription(t *testing.T) {
+	h := NewHub(2)
+	slow := h.Subscribe("A")
+	for i := 0; i < 3; i++ {
+		h.Publish(context.Background(), Event{Type: UserUpdated, CompanyCode: "A"})
+	}
+
+	if !slow.Overflowed() || h.Len() != 0 {
+		t.Fatalf("Overflowed = %v, Len = %d; want the subscription dropped", slow.Overflowed(), h.Len())
+	}
+	// The buffered events stay readable, then the channel reports closed.
+	n := 0
+	for range slow.Events() {
+		n++
+	}
+	if n != 2 {
+		t.Fatalf("drained %d events, want 2", n)
+	}
+	slow.Close() // idempotent
+}
+
+func TestHub_Close(t *testing.T) {
+	h := NewHub(0)
+	s := h.Subscribe("A")
+	h.Close()
+	if _, ok := <-s.Events(); ok || s.Overflowed() {
+		t.Fatal("Close must close subscriptions without marking them overflowed")
+	}
+	if _, ok := <-h.Subscribe("A").Events(); ok {
+		t.Fatal("Subscribe after Close must return a closed subscription")
+	}
+}
+
+func TestRedisRelay_FansOutAcrossReplicas(t *testing.T) {
+	mr := miniredis.RunT(t)
+	port, _ := strconv.Atoi(mr.Port())
+	client, err := redis.New(redis.Config{Host: mr.Host(), Port: port, MaxIdle: 2, MaxActive: 4})
+	if err != nil {
+		t.Fatalf("redis.New: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	// Two replicas share the channel; an event published on one reaches
+	// subscribers on the other.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	hubA, hubB := NewHub(0), NewHub(0)
+	relayA := NewRedisRelay(client, DefaultChannel, hubA, nil)
+	go NewRedisRelay(client, DefaultChannel, hubB, nil).Run(ctx)
+	sub := hubB.Subscribe("A")
+	defer sub.Close()
+
+	// Run subscribes asynchronously; publish until it is listening.
+	deadline := time.After(2 * time.Second)
+	for {
+		relayA.Publish(context.Background(), Event{Type: UserRegistered, CompanyCode: "A"})
+		select {
+		case e := <-sub.Events():
+			if e.Type != UserRegistered {
+				t.Fatalf("event = %+v", e)
+			}
+			return
+		case <-time.After(20 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("event never reached the other replica")
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"veemon/pkg/redis"
+
+	"go.uber.org/zap"
+)
+
+// DefaultChannel is the Redis pub/sub channel RedisRelay uses by default.
+const DefaultChannel = "notifications"
+
+const (
+	relayMinBackoff = 1 * time.Second
+	relayMaxBackoff = 30 * time.Second
+)
+
+// RedisRelay publishes events to a Redis channel and, while Run is active,
+// feeds every event on that channel (from any replica) into a local Hub.
+type RedisRelay struct {
+	client  *redis.Client
+	channel string
+	hub     *Hub
+	logger  *zap.Logger
+}
+
+// NewRedisRelay returns a relay between channel and hub.
+func NewRedisRelay(client *redis.Client, channel string, hub *Hub, logger *zap.Logger) *RedisRelay {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &RedisRelay{client: client, channel: channel, hub: hub, logger: logger}
+}
+
+// Publish sends e to the channel. If Redis is unreachable it delivers e to
+// the local hub instead, so this replica's clients still see it.
+func (r *RedisRelay) Publish(ctx context.Context, e Event) {
+	if err := r.client.Publish(ctx, r.channel, e); err != nil {
+		r.logger.Warn("notification publish failed; delivering locally only",
+			zap.String("type", e.Type), zap.Error(err))
+		r.hub.Publish(ctx, e)
+	}
+}
+
+// Run subscribes to the channel until ctx is cancelled, re-subscribing with
+// backoff when the connection drops.
+func (r *RedisRelay) Run(ctx context.Context) {
+	backoff := relayMinBackoff
+	for {
+		start := time.Now()
+		err := r.client.Subscribe(ctx, r.channel, r.deliver)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(start) > relayMaxBackoff {
+			backoff = relayMinBackoff
+		}
+		r.logger.Warn("notification subscription lost; retrying",
+			zap.String("channel", r.channel), zap.Error(err), zap.Duration("backoff", backoff))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > relayMaxBackoff {
+			backoff = relayMaxBackoff
+		}
+	}
+}
+
+func (r *RedisRelay) deliver(b []byte) {
+	var e Event
+	if err := json.Unmarshal(b, &e); err != nil {
+		r.logger.Warn("dropping malformed notification", zap.String("channel", r.channel), zap.Error(err))
+		return
+	}
+	r.hub.Publish(context.Background(), e)
+}
@@ -0,0 +1,216 @@
+// Package passwordreset issues the single-use tokens of the forgot-password
+// flow and announces reset requests on RabbitMQ. Invitations to accounts an
+// administrator created without a password use the same tokens, from a
+// Store with a longer TTL, and are redeemed the same way.
+//
+// A token is 32 random bytes, base64url encoded. Redis holds only its
+// SHA-256 under "password_reset:<hash>", mapped to the user ID and expiring
+// with the token, so a Redis dump does not leak usable tokens. Consume reads
+// and deletes the entry in one GETDEL, so a token works at most once even
+// when two requests race.
+//
+// Unlike authguard, a Store without Redis is not a no-op: resets cannot work
+// without it, so Issue and Consume return ErrUnavailable.
+package passwordreset
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"veemon/pkg/events"
+	"veemon/pkg/redis"
+
+	"github.com/google/uuid"
+)
+
+// EventTypeRequested is the type (and routing key) of the event published
+// when a user asks for a password reset.
+const EventTypeRequested = "user.password_reset_requested"
+
+// EventTypeInvited is the type (and routing key) of the event published
+// when an administrator creates an account and invites its user to choose a
+// password.
+const EventTypeInvited = "user.invited"
+
+// ResetPath and InvitePath are the frontend pages the emailed links open,
+// with the token in the "token" query parameter.
+const (
+	ResetPath  = "/reset-password"
+	InvitePath = "/accept-invite"
+)
+
+// DefaultTTL is how long a reset token stays valid.
+const DefaultTTL = 30 * time.Minute
+
+var (
+	// ErrInvalidToken is returned by Consume for a token that was never
+	// issued, has expired or was already used.
+	ErrInvalidToken = errors.New("invalid or expired password reset token")
+	// ErrUnavailable is returned when the store has no Redis client.
+	ErrUnavailable = errors.New("password reset store unavailable")
+)
+
+// Store keeps reset tokens in Redis.
+type Store struct {
+	redis *redis.Client
+	ttl   time.Duration
+	now   func() time.Time
+}
+
+// NewStore builds a Store whose tokens expire after ttl; ttl <= 0 means
+// DefaultTTL.
+func NewStore(r *redis.Client, ttl time.Duration) *Store {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Store{redis: r, ttl: ttl, now: time.Now}
+}
+
+func (s *Store) enabled() bool { return s != nil && s.redis != nil }
+
+func tokenKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "password_reset:" + hex.EncodeToString(sum[:])
+}
+
+// Issue creates a token for userID and returns it with its expiry.
+func (s *Store) Issue(ctx context.Context, userID string) (string, time.Time, error) {
+	if !s.enabled() {
+		return "", time.Time{}, ErrUnavailable
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", time.Time{}, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	expiresAt := s.now().Add(s.ttl)
+	if err := s.redis.Set(ctx, tokenKey(token), userID, s.ttl); err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// Consume redeems token and returns the user it was issued to. The token
+// is gone afterwards whatever the caller does next.
+func (s *Store) Consume(ctx context.Context, token string) (string, error) {
+	if !s.enabled() {
+		return "", ErrUnavailable
+	}
+	if token == "" {
+		return "", ErrInvalidToken
+	}
+	raw, err := s.redis.GetDelString(ctx, tokenKey(token))
+	if err != nil {
+		if errors.Is(err, redis.ErrNil) {
+			return "", ErrInvalidToken
+		}
+		return "", err
+	}
+	// Set stores values JSON-encoded.
+	var userID string
+	if err := json.Unmarshal([]byte(raw), &userID); err != nil {
+		return "", ErrInvalidToken
+	}
+	return userID, nil
+}
+
+// Publisher is the subset of the RabbitMQ client Notifier needs.
+type Publisher interface {
+	PublishJSON(ctx context.Context, exchange, routingKey string, message interface{}) error
+}
+
+// LinkBuilder builds the frontend links mailed to users;
+// *urlpolicy.Policy implements it.
+type LinkBuilder interface {
+	BuildFrontendURL(path string, params map[string]string) (string, error)
+}
+
+// Requested is the Data of a user.password_reset_requested event. It
+// carries the token and the reset link built from it, so the consumer can
+// mail the link; the queue must be treated as confidential.
+type Requested struct {
+	UserID    string    `json:"userId"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	Token     string    `json:"token"`
+	Link      string    `json:"link,omitempty"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Invited is the Data of a user.invited event. Like Requested it carries
+// the token and its link, which opens the same password reset flow.
+type Invited struct {
+	UserID    string    `json:"userId"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	Token     string    `json:"token"`
+	Link      string    `json:"link,omitempty"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Notifier publishes user.password_reset_requested and user.invited events.
+type Notifier struct {
+	publisher Publisher
+	exchange  string
+	source    string
+	links     LinkBuilder
+}
+
+// NewNotifier publishes to exchange. source is recorded on the event
+// envelope (typically the service name). links builds the Link of each
+// event from ResetPath or InvitePath; nil publishes events without one.
+func NewNotifier(publisher Publisher, exchange, source string, links LinkBuilder) *Notifier {
+	return &Notifier{publisher: publisher, exchange: exchange, source: source, links: links}
+}
+
+// PasswordResetRequested fills in req.Link and publishes req.
+func (n *Notifier) PasswordResetRequested(ctx context.Context, req Requested) error {
+	link, err := n.link(ResetPath, req.Token)
+	if err != nil {
+		return err
+	}
+	req.Link = link
+	return n.publish(ctx, EventTypeRequested, req)
+}
+
+// UserInvited fills in inv.Link and publishes inv.
+func (n *Notifier) UserInvited(ctx context.Context, inv Invited) error {
+	link, err := n.link(InvitePath, inv.Token)
+	if err != nil {
+		return err
+	}
+	inv.Link = link
+	return n.publish(ctx, EventTypeInvited, inv)
+}
+
+func (n *Notifier) link(path, token string) (string, error) {
+	if n.links == nil {
+		return "", nil
+	}
+	link, err := n.links.BuildFrontendURL(path, map[string]string{"token": token})
+	if err != nil {
+		return "", fmt.Errorf("build %s link: %w", path, err)
+	}
+	return link, nil
+}
+
+func (n *Notifier) publish(ctx context.Context, eventType string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return n.publisher.PublishJSON(ctx, n.exchange, eventType, events.Envelope{
+		ID:         uuid.NewString(),
+		Type:       eventType,
+		Source:     n.source,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	})
+}
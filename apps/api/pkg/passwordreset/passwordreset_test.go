@@ -0,0 +1,174 @@
+package passwordreset
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"veemon/pkg/events"
+	"veemon/pkg/redis"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newStore(t *testing.T, ttl time.Duration) (*Store, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	port, _ := strconv.Atoi(mr.Port())
+	rc, err := redis.New(redis.Config{Host: mr.Host(), Port: port, MaxIdle: 1, MaxActive: 2})
+	if err != nil {
+		t.Fatalf("redis.New: %v", err)
+	}
+	t.Cleanup(func() { _ = rc.Close() })
+	return NewStore(rc, ttl), mr
+}
+
+func TestStore_TokenWorksOnce(t *testing.T) {
+	s, mr := newStore(t, time.Hour)
+	ctx := context.Background()
+
+	token, expiresAt, err := s.Issue(ctx, "u1")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if d := time.Until(expiresAt); d < 59*time.Minute || d > time.Hour {
+		t.Fatalf("expiresAt in %v, want about an hour", d)
+	}
+	// Only the hash is stored.
+	for _, k := range mr.Keys() {
+		if k == "password_reset:"+token {
+			t.Fatal("the raw token is stored as a key")
+		}
+	}
+
+	userID, err := s.Consume(ctx, token)
+	if err != nil || userID != "u1" {
+		t.Fatalf("Consume = %q, %v; want u1", userID, err)
+	}
+	if _, err := s.Consume(ctx, token); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("second Consume err = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestStore_RejectsExpiredAndUnknownTokens(t *testing.T) {
+	s, mr := newStore(t, 10*time.Minute)
+	ctx := context.Background()
+
+	token, _, err := s.Issue(ctx, "u1")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	mr.FastForward(11 * time.Minute)
+
+	for _, tok := range []string{token, "never-issued", ""} {
+		if _, err := s.Consume(ctx, tok); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("Consume(%q) err = %v, want ErrInvalidToken", tok, err)
+		}
+	}
+}
+
+func TestStore_WithoutRedis(t *testing.T) {
+	s := NewStore(nil, 0)
+	if s.ttl != DefaultTTL {
+		t.Fatalf("ttl = %v, want DefaultTTL", s.ttl)
+	}
+	if _, _, err := s.Issue(context.Background(), "u1"); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("Issue err = %v, want ErrUnavailable", err)
+	}
+	if _, err := s.Consume(context.Background(), "t"); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("Consume err = %v, want ErrUnavailable", err)
+	}
+}
+
+type capturePublisher struct {
+	exchange, routingKey string
+	message              interface{}
+}
+
+func (p *capturePublisher) PublishJSON(_ context.Context, exchange, routingKey string, message interface{}) error {
+	p.exchange, p.routingKey, p.message = exchange, routingKey, message
+	return nil
+}
+
+func TestNotifier_PublishesEnvelope(t *testing.T) {
+	pub := &capturePublisher{}
+	req := Requested{UserID: "u1", Email: "a@example.com", Name: "A", Token: "tok", ExpiresAt: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+
+	if err := NewNotifier(pub, "events", "api", nil).PasswordResetRequested(context.Background(), req); err != nil {
+		t.Fatalf("PasswordResetRequested: %v", err)
+	}
+
+	env, ok := pub.message.(events.Envelope)
+	if !ok {
+		t.Fatalf("published %T, want events.Envelope", pub.message)
+	}
+	if pub.exchange != "events" || pub.routingKey != EventTypeRequested || env.Type != EventTypeRequested || env.Source != "api" || env.ID == "" {
+		t.Fatalf("published to %s/%s: %+v", pub.exchange, pub.routingKey, env)
+	}
+	var got Requested
+	if err := json.Unmarshal(env.Data, &got); err != nil || got != req {
+		t.Fatalf("data = %+v, %v; want %+v", got, err, req)
+	}
+}
+
+func TestNotifier_UserInvited(t *testing.T) {
+	pub := &capturePublisher{}
+	inv := Invited{UserID: "u1", Email: "a@example.com", Name: "A", Token: "tok", ExpiresAt: time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)}
+
+	if err := NewNotifier(pub, "events", "api", nil).UserInvited(context.Background(), inv); err != nil {
+		t.Fatalf("UserInvited: %v", err)
+	}
+
+	env, ok := pub.message.(events.Envelope)
+	if !ok {
+		t.Fatalf("published %T, want events.Envelope", pub.message)
+	}
+	if pub.routingKey != EventTypeInvited || env.Type != EventTypeInvited {
+		t.Fatalf("published to %s/%s: %+v", pub.exchange, pub.routingKey, env)
+	}
+	var got Invited
+	if err := json.Unmarshal(env.Data, &got); err != nil || got != inv {
+		t.Fatalf("data = %+v, %v; want %+v", got, err, inv)
+	}
+}
+
+type fakeLinks struct{ base string }
+
+func (f fakeLinks) BuildFrontendURL(path string, params map[string]string) (string, error) {
+	if f.base == "" {
+		return "", errors.New("no base")
+	}
+	return f.base + path + "?token=" + params["token"], nil
+}
+
+func TestNotifier_BuildsLinks(t *testing.T) {
+	pub := &capturePublisher{}
+	n := NewNotifier(pub, "events", "api", fakeLinks{base: "https://app.example.com"})
+
+	if err := n.PasswordResetRequested(context.Background(), Requested{UserID: "u1", Token: "tok"}); err != nil {
+		t.Fatalf("PasswordResetRequested: %v", err)
+	}
+	var req Requested
+	if err := json.Unmarshal(pub.message.(events.Envelope).Data, &req); err != nil || req.Link != "https://app.example.com/reset-password?token=tok" {
+		t.Fatalf("reset link = %q, %v", req.Link, err)
+	}
+
+	if err := n.UserInvited(context.Background(), Invited{UserID: "u1", Token: "inv"}); err != nil {
+		t.Fatalf("UserInvited: %v", err)
+	}
+	var inv Invited
+	if err := json.Unmarshal(pub.message.(events.Envelope).Data, &inv); err != nil || inv.Link != "https://app.example.com/accept-invite?token=inv" {
+		t.Fatalf("invite link = %q, %v", inv.Link, err)
+	}
+}
+
+func TestNotifier_LinkErrorIsNotPublished(t *testing.T) {
+	pub := &capturePublisher{}
+	err := NewNotifier(pub, "events", "api", fakeLinks{}).PasswordResetRequested(context.Background(), Requested{Token: "tok"})
+	if err == nil || pub.message != nil {
+		t.Fatalf("err = %v, published %v; want an error and nothing published", err, pub.message)
+	}
+}
@@ -0,0 +1,104 @@
+package rabbitmq
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// BatchFailure is a message of a PublishBatch that was not confirmed.
+type BatchFailure struct {
+	Index int // position in the messages passed to PublishBatch
+	Err   error
+}
+
+// BatchError lists the messages of a PublishBatch the broker did not
+// confirm; the others were.
+type BatchError struct {
+	Failures []BatchFailure
+}
+
+func (e *BatchError) Error() string {
+	parts := make([]string, len(e.Failures))
+	for i, f := range e.Failures {
+		parts[i] = fmt.Sprintf("message %d: %v", f.Index, f.Err)
+	}
+	return fmt.Sprintf("rabbitmq: %d message(s) not confirmed: %s", len(e.Failures), strings.Join(parts, "; "))
+}
+
+// Unwrap lets errors.Is match the failures, e.g. ErrPublishNacked.
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for i, f := range e.Failures {
+		errs[i] = f.Err
+	}
+	return errs
+}
+
+// PublishBatch publishes messages, JSON-encoded like Publish, on the
+// confirm-mode channel and waits for all of their confirms at once, which is
+// much faster than confirming each in turn. opts.Confirm is implied. It
+// returns a *BatchError naming the messages that were nacked, timed out, or
+// not published because the channel failed part way; there is no retry, so
+// the caller decides what to republish.
+func (c *Client) PublishBatch(ctx context.Context, opts PublishOptions, messages []interface{}) error {
+	ctx, span := tracer.Start(ctx, "rabbitmq.PublishBatch",
+		trace.WithAttributes(
+			attribute.String("messaging.system", "rabbitmq"),
+			attribute.String("messaging.destination", opts.Exchange),
+			attribute.String("messaging.rabbitmq.routing_key", opts.RoutingKey),
+			attribute.Int("messaging.batch.message_count", len(messages)),
+		))
+	defer span.End()
+
+	var failures []BatchFailure
+	confirms := make([]deferredConfirm, len(messages))
+
+	c.confirmMu.Lock()
+	defer c.confirmMu.Unlock()
+
+	start := time.Now()
+	var publishErr error
+	for i, m := range messages {
+		if publishErr != nil {
+			failures = append(failures, BatchFailure{Index: i, Err: publishErr})
+			continue
+		}
+		p, err := newPublishing(ctx, opts, m)
+		if err != nil {
+			failures = append(failures, BatchFailure{Index: i, Err: err})
+			continue
+		}
+		confirms[i], err = c.publishDeferredLocked(ctx, opts.Exchange, opts.RoutingKey, p)
+		if err != nil {
+			// The channel is unusable; the rest of the batch cannot go out.
+			publishErr = err
+			failures = append(failures, BatchFailure{Index: i, Err: err})
+		}
+	}
+
+	// One deadline for the whole batch: confirms arrive in parallel.
+	waitCtx, cancel := context.WithTimeout(ctx, confirmTimeout)
+	defer cancel()
+	for i, confirm := range confirms {
+		if confirm == nil {
+			continue
+		}
+		if err := c.awaitConfirm(waitCtx, confirm, start); err != nil {
+			failures = append(failures, BatchFailure{Index: i, Err: err})
+		}
+	}
+
+	if len(failures) == 0 {
+		return nil
+	}
+	sort.Slice(failures, func(i, j int) bool { return failures[i].Index < failures[j].Index })
+	err := &BatchError{Failures: failures}
+	span.RecordError(err)
+	return err
+}
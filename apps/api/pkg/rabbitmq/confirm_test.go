@@ -0,0 +1,155 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+// fakeConfirm resolves to acked delay after it was published; a negative
+// delay never resolves.
+type fakeConfirm struct {
+	acked bool
+	delay time.Duration
+	due   time.Time
+}
+
+func (f fakeConfirm) WaitContext(ctx context.Context) (bool, error) {
+	if f.delay < 0 {
+		<-ctx.Done()
+		return false, ctx.Err()
+	}
+	select {
+	case <-time.After(time.Until(f.due)):
+		return f.acked, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+type confirmRecorder struct {
+	mu       sync.Mutex
+	outcomes []string
+}
+
+func (r *confirmRecorder) SetConsumerPaused(string, bool) {}
+
+func (r *confirmRecorder) ObservePublishConfirm(outcome string, _ time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.outcomes = append(r.outcomes, outcome)
+}
+
+// confirmingClient answers the n-th confirmed publish with confirms[n].
+func confirmingClient(confirms ...any) (*Client, *[]amqp.Publishing, *confirmRecorder) {
+	var published []amqp.Publishing
+	rec := &confirmRecorder{}
+	c := &Client{logger: zap.NewNop(), done: make(chan struct{}), ready: make(chan struct{})}
+	c.publishDeferred = func(_ context.Context, _, _ string, p amqp.Publishing) (deferredConfirm, error) {
+		n := len(published)
+		published = append(published, p)
+		switch v := confirms[n].(type) {
+		case error:
+			return nil, v
+		default:
+			f := v.(fakeConfirm)
+			f.due = time.Now().Add(f.delay)
+			return f, nil
+		}
+	}
+	c.EnableMetrics(rec)
+	return c, &published, rec
+}
+
+func TestPublish_ConfirmWaitsForAck(t *testing.T) {
+	c, published, rec := confirmingClient(fakeConfirm{acked: true, delay: 10 * time.Millisecond})
+
+	err := c.Publish(context.Background(), PublishOptions{Exchange: "ex", RoutingKey: "k", Confirm: true}, map[string]int{"n": 1})
+	if err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if len(*published) != 1 || string((*published)[0].Body) != `{"n":1}` {
+		t.Fatalf("published %v", *published)
+	}
+	if len(rec.outcomes) != 1 || rec.outcomes[0] != "ack" {
+		t.Fatalf("outcomes = %v", rec.outcomes)
+	}
+}
+
+func TestPublish_ConfirmNackAndTimeout(t *testing.T) {
+	c, _, rec := confirmingClient(fakeConfirm{acked: false})
+	err := c.Publish(context.Background(), PublishOptions{Confirm: true}, "x")
+	if !errors.Is(err, ErrPublishNacked) {
+		t.Fatalf("nack: err = %v, want ErrPublishNacked", err)
+	}
+
+	c, _, rec = confirmingClient(fakeConfirm{delay: -1})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = c.Publish(ctx, PublishOptions{Confirm: true}, "x")
+	if !errors.Is(err, ErrConfirmTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("timeout: err = %v, want ErrConfirmTimeout", err)
+	}
+	if len(rec.outcomes) != 1 || rec.outcomes[0] != "timeout" {
+		t.Fatalf("outcomes = %v", rec.outcomes)
+	}
+}
+
+func TestPublishBatch_WaitsForAllConfirms(t *testing.T) {
+	c, published, rec := confirmingClient(
+		fakeConfirm{acked: true, delay: 100 * time.Millisecond},
+		fakeConfirm{acked: true, delay: 100 * time.Millisecond},
+		fakeConfirm{acked: true, delay: 100 * time.Millisecond},
+	)
+
+	start := time.Now()
+	if err := c.PublishBatch(context.Background(), PublishOptions{Exchange: "ex"}, []interface{}{1, 2, 3}); err != nil {
+		t.Fatalf("PublishBatch: %v", err)
+	}
+	if len(*published) != 3 {
+		t.Fatalf("published %d messages, want 3", len(*published))
+	}
+	if len(rec.outcomes) != 3 {
+		t.Fatalf("outcomes = %v", rec.outcomes)
+	}
+	// The confirms are awaited together, not one after another.
+	if d := time.Since(start); d >= 250*time.Millisecond {
+		t.Fatalf("batch took %v, want the confirms awaited in parallel", d)
+	}
+}
+
+func TestPublishBatch_ReportsFailedMessages(t *testing.T) {
+	lost := errors.New("channel closed")
+	c, published, _ := confirmingClient(
+		fakeConfirm{acked: true},
+		fakeConfirm{acked: false},
+		lost,
+	)
+
+	err := c.PublishBatch(context.Background(), PublishOptions{}, []interface{}{"a", "b", "c", "d"})
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("err = %v, want *BatchError", err)
+	}
+	if len(*published) != 3 {
+		t.Fatalf("published %d messages, want publishing to stop at the failed one", len(*published))
+	}
+	want := []BatchFailure{{1, ErrPublishNacked}, {2, lost}, {3, lost}}
+	if len(batchErr.Failures) != len(want) {
+		t.Fatalf("failures = %v", batchErr.Failures)
+	}
+	for i, f := range batchErr.Failures {
+		if f.Index != want[i].Index || !errors.Is(f.Err, want[i].Err) {
+			t.Errorf("failure %d = %+v, want %+v", i, f, want[i])
+		}
+	}
+	if !errors.Is(err, ErrPublishNacked) {
+		t.Error("BatchError does not unwrap to ErrPublishNacked")
+	}
+}
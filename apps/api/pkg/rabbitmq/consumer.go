@@ -0,0 +1,270 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+// ErrUnknownQueue is returned by PauseQueue and ResumeQueue for a queue with
+// no consumers on this client.
+var ErrUnknownQueue = errors.New("rabbitmq: no consumers for queue")
+
+// Recorder receives consumer state changes and publish confirm latencies;
+// *metrics.Metrics implements it.
+type Recorder interface {
+	SetConsumerPaused(queue string, paused bool)
+	// ObservePublishConfirm records how long a confirmed publish waited for
+	// the broker; outcome is ack, nack, timeout or error.
+	ObservePublishConfirm(outcome string, d time.Duration)
+}
+
+// consumerChannel is the part of *amqp.Channel a consumer uses, so tests can
+// drive the consume loop without a broker.
+type consumerChannel interface {
+	Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error)
+	Cancel(consumer string, noWait bool) error
+	Close() error
+}
+
+var consumerSeq atomic.Uint64
+
+// Consumer is the handle returned by ConsumeWithHandler. It can be paused and
+// resumed at runtime without tearing down its channel or queue bindings.
+type Consumer struct {
+	client *Client
+	opts   ConsumeOptions
+
+	mu     sync.Mutex
+	paused bool
+	resume chan struct{}   // closed by Resume to wake a paused loop
+	ch     consumerChannel // set while the broker is delivering to us
+}
+
+// Queue returns the queue this consumer reads from.
+func (c *Consumer) Queue() string { return c.opts.Queue }
+
+// Tag returns the consumer tag registered with the broker.
+func (c *Consumer) Tag() string { return c.opts.ConsumerTag }
+
+// Paused reports whether the consumer is paused.
+func (c *Consumer) Paused() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paused
+}
+
+// Pause stops delivery by canceling the consumer tag (basic.cancel). Messages
+// already prefetched to this consumer are still handled and settled; the
+// channel stays open. It is a no-op on a paused consumer.
+func (c *Consumer) Pause() {
+	c.mu.Lock()
+	if c.paused {
+		c.mu.Unlock()
+		return
+	}
+	c.paused = true
+	c.resume = make(chan struct{})
+	ch := c.ch
+	c.mu.Unlock()
+
+	if ch != nil {
+		if err := ch.Cancel(c.opts.ConsumerTag, false); err != nil {
+			// Closing the channel also stops delivery; the loop stays parked
+			// until Resume and then re-attaches on a fresh channel.
+			c.client.logger.Warn("consumer cancel failed; closing channel",
+				zap.String("queue", c.opts.Queue), zap.String("consumer_tag", c.opts.ConsumerTag), zap.Error(err))
+			_ = ch.Close()
+		}
+	}
+	c.client.logger.Info("consumer paused", zap.String("queue", c.opts.Queue), zap.String("consumer_tag", c.opts.ConsumerTag))
+	c.client.recordQueueState(c.opts.Queue)
+}
+
+// Resume re-issues basic.consume on a paused consumer. It is a no-op on a
+// running one.
+func (c *Consumer) Resume() {
+	c.mu.Lock()
+	if !c.paused {
+		c.mu.Unlock()
+		return
+	}
+	c.paused = false
+	close(c.resume)
+	c.mu.Unlock()
+
+	c.client.logger.Info("consumer resumed", zap.String("queue", c.opts.Queue), zap.String("consumer_tag", c.opts.ConsumerTag))
+	c.client.recordQueueState(c.opts.Queue)
+}
+
+// waitRunning blocks while the consumer is paused. It returns false if ctx or
+// done fires first.
+func (c *Consumer) waitRunning(ctx context.Context, done <-chan struct{}) bool {
+	for {
+		c.mu.Lock()
+		paused, resume := c.paused, c.resume
+		c.mu.Unlock()
+		if !paused {
+			return true
+		}
+		select {
+		case <-resume:
+		case <-ctx.Done():
+			return false
+		case <-done:
+			return false
+		}
+	}
+}
+
+// consume starts delivery on ch unless the consumer was paused in the
+// meantime, in which case it returns nil deliveries and no error. Holding the
+// lock across basic.consume means a concurrent Pause either happens first or
+// sees ch and cancels it.
+func (c *Consumer) consume(ch consumerChannel) (<-chan amqp.Delivery, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.paused {
+		return nil, nil
+	}
+	o := c.opts
+	deliveries, err := ch.Consume(o.Queue, o.ConsumerTag, o.AutoAck, o.Exclusive, o.NoLocal, o.NoWait, o.Args)
+	if err != nil {
+		return nil, err
+	}
+	c.ch = ch
+	return deliveries, nil
+}
+
+func (c *Consumer) detach() {
+	c.mu.Lock()
+	c.ch = nil
+	c.mu.Unlock()
+}
+
+// QueueState summarizes the consumers attached to one queue.
+type QueueState struct {
+	Queue     string `json:"queue"`
+	State     string `json:"state"` // "running", "paused" or "partial"
+	Consumers int    `json:"consumers"`
+	Paused    int    `json:"paused"`
+}
+
+// ConsumerStates reports the state of every queue with consumers, ordered by
+// queue name.
+func (c *Client) ConsumerStates() []QueueState {
+	c.consumersMu.Lock()
+	queues := make([]string, 0, len(c.consumers))
+	for q := range c.consumers {
+		queues = append(queues, q)
+	}
+	c.consumersMu.Unlock()
+
+	sort.Strings(queues)
+	out := make([]QueueState, 0, len(queues))
+	for _, q := range queues {
+		out = append(out, c.queueState(q))
+	}
+	return out
+}
+
+// PauseQueue pauses every consumer on queue.
+func (c *Client) PauseQueue(queue string) error {
+	consumers := c.queueConsumers(queue)
+	if len(consumers) == 0 {
+		return fmt.Errorf("%w: %s", ErrUnknownQueue, queue)
+	}
+	for _, cons := range consumers {
+		cons.Pause()
+	}
+	return nil
+}
+
+// ResumeQueue resumes every consumer on queue.
+func (c *Client) ResumeQueue(queue string) error {
+	consumers := c.queueConsumers(queue)
+	if len(consumers) == 0 {
+		return fmt.Errorf("%w: %s", ErrUnknownQueue, queue)
+	}
+	for _, cons := range consumers {
+		cons.Resume()
+	}
+	return nil
+}
+
+// EnableMetrics publishes each queue's paused state and the publish confirm
+// latency to rec.
+func (c *Client) EnableMetrics(rec Recorder) {
+	c.consumersMu.Lock()
+	c.recorder = rec
+	queues := make([]string, 0, len(c.consumers))
+	for q := range c.consumers {
+		queues = append(queues, q)
+	}
+	c.consumersMu.Unlock()
+	for _, q := range queues {
+		c.recordQueueState(q)
+	}
+}
+
+func (c *Client) addConsumer(cons *Consumer) {
+	c.consumersMu.Lock()
+	if c.consumers == nil {
+		c.consumers = map[string][]*Consumer{}
+	}
+	c.consumers[cons.opts.Queue] = append(c.consumers[cons.opts.Queue], cons)
+	c.consumersMu.Unlock()
+	c.recordQueueState(cons.opts.Queue)
+}
+
+func (c *Client) queueConsumers(queue string) []*Consumer {
+	c.consumersMu.Lock()
+	defer c.consumersMu.Unlock()
+	return append([]*Consumer(nil), c.consumers[queue]...)
+}
+
+func (c *Client) queueState(queue string) QueueState {
+	consumers := c.queueConsumers(queue)
+	s := QueueState{Queue: queue, Consumers: len(consumers)}
+	for _, cons := range consumers {
+		if cons.Paused() {
+			s.Paused++
+		}
+	}
+	switch s.Paused {
+	case 0:
+		s.State = "running"
+	case s.Consumers:
+		s.State = "paused"
+	default:
+		s.State = "partial"
+	}
+	return s
+}
+
+// recordQueueState reports queue as paused once all of its consumers are.
+func (c *Client) recordQueueState(queue string) {
+	c.consumersMu.Lock()
+	rec := c.recorder
+	c.consumersMu.Unlock()
+	if rec == nil {
+		return
+	}
+	rec.SetConsumerPaused(queue, c.queueState(queue).State == "paused")
+}
+
+func (c *Client) recordConfirm(outcome string, d time.Duration) {
+	c.consumersMu.Lock()
+	rec := c.recorder
+	c.consumersMu.Unlock()
+	if rec != nil {
+		rec.ObservePublishConfirm(outcome, d)
+	}
+}
@@ -0,0 +1,309 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"veemon/pkg/events"
+	"veemon/pkg/logger"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// recordingAck captures how a delivery was settled.
+type recordingAck struct {
+	acked, nacked, requeued bool
+}
+
+func (r *recordingAck) Ack(uint64, bool) error { r.acked = true; return nil }
+func (r *recordingAck) Nack(_ uint64, _ bool, requeue bool) error {
+	r.nacked, r.requeued = true, requeue
+	return nil
+}
+func (r *recordingAck) Reject(_ uint64, requeue bool) error {
+	r.nacked, r.requeued = true, requeue
+	return nil
+}
+
+// decodingHandler mirrors the worker: permanent decode failures are rejected
+// to the DLQ, everything else succeeds.
+func decodingHandler(_ context.Context, msg amqp.Delivery) error {
+	if _, err := events.Decode(msg.ContentType, msg.Body); err != nil {
+		if events.IsPermanent(err) {
+			return Reject(err.Error(), err)
+		}
+		return err
+	}
+	return nil
+}
+
+func TestHandleDelivery_Dispositions(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		handler     func(context.Context, amqp.Delivery) error
+		publishErr  error
+		dlq         string
+		wantAck     bool
+		wantNack    bool
+		wantRequeue bool
+		wantDLQ     bool
+	}{
+		{
+			name:        "valid json is acked",
+			contentType: "application/json",
+			body:        `{"id":"1","type":"t","occurredAt":"2024-01-01T00:00:00Z"}`,
+			dlq:         "q.dlq",
+			wantAck:     true,
+		},
+		{
+			name:        "malformed json is dead-lettered",
+			contentType: "application/json",
+			body:        `{not json`,
+			dlq:         "q.dlq",
+			wantAck:     true,
+			wantDLQ:     true,
+		},
+		{
+			name:        "plain text is acked",
+			contentType: "text/plain",
+			body:        "hello",
+			dlq:         "q.dlq",
+			wantAck:     true,
+		},
+		{
+			name:        "unknown content type is dead-lettered",
+			contentType: "application/x-protobuf",
+			body:        "\x08\x01",
+			dlq:         "q.dlq",
+			wantAck:     true,
+			wantDLQ:     true,
+		},
+		{
+			name:        "rejection without a dlq is dropped",
+			contentType: "application/x-protobuf",
+			body:        "\x08\x01",
+			wantNack:    true,
+		},
+		{
+			name:        "failed dead-letter publish is requeued, not acked",
+			contentType: "application/x-protobuf",
+			body:        "\x08\x01",
+			dlq:         "q.dlq",
+			publishErr:  errors.New("channel closed"),
+			wantNack:    true,
+			wantRequeue: true,
+			wantDLQ:     true,
+		},
+		{
+			name:        "transient failure is requeued",
+			contentType: "application/json",
+			handler:     func(context.Context, amqp.Delivery) error { return errors.New("db down") },
+			wantNack:    true,
+			wantRequeue: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var published *amqp.Publishing
+			var publishedKey string
+			c := &Client{
+				logger: zap.NewNop(),
+				publish: func(_ context.Context, _, key string, p amqp.Publishing) error {
+					published, publishedKey = &p, key
+					return tt.publishErr
+				},
+			}
+			handler := tt.handler
+			if handler == nil {
+				handler = decodingHandler
+			}
+			ack := &recordingAck{}
+			msg := amqp.Delivery{
+				Acknowledger: ack,
+				ContentType:  tt.contentType,
+				Body:         []byte(tt.body),
+			}
+
+			c.handleDelivery(context.Background(), ConsumeOptions{Queue: "q", DeadLetterQueue: tt.dlq}, handler, msg)
+
+			if ack.acked != tt.wantAck || ack.nacked != tt.wantNack || ack.requeued != tt.wantRequeue {
+				t.Fatalf("got ack=%v nack=%v requeue=%v, want ack=%v nack=%v requeue=%v",
+					ack.acked, ack.nacked, ack.requeued, tt.wantAck, tt.wantNack, tt.wantRequeue)
+			}
+			if (published != nil) != tt.wantDLQ {
+				t.Fatalf("dead-lettered=%v, want %v", published != nil, tt.wantDLQ)
+			}
+			if published != nil {
+				if publishedKey != tt.dlq {
+					t.Fatalf("published to %q, want %q", publishedKey, tt.dlq)
+				}
+				if reason, _ := published.Headers[RejectionReasonHeader].(string); reason == "" {
+					t.Fatalf("dead-lettered message missing %s header", RejectionReasonHeader)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleDelivery_DeadLetterFailuresAddsDiagnosticHeaders(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithRemoteSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID, SpanID: spanID, TraceFlags: trace.FlagsSampled,
+	}))
+	opts := ConsumeOptions{Queue: "q", ConsumerTag: "worker-3", DeadLetterQueue: "q.dlq", DeadLetterFailures: true}
+
+	tests := []struct {
+		name        string
+		handler     func(context.Context, amqp.Delivery) error
+		redelivered bool
+		wantType    FailureType
+		wantDLQ     bool
+	}{
+		{"first failure is still retried", func(context.Context, amqp.Delivery) error { return errors.New("db down") }, false, "", false},
+		{"failed retry is dead-lettered", func(context.Context, amqp.Delivery) error { return errors.New("db down") }, true, FailureError, true},
+		{"panic on retry is dead-lettered", func(context.Context, amqp.Delivery) error { panic("nil map") }, true, FailurePanic, true},
+		{"timeout on retry is dead-lettered", func(context.Context, amqp.Delivery) error {
+			return fmt.Errorf("call billing: %w", context.DeadlineExceeded)
+		}, true, FailureTimeout, true},
+		{"rejection is dead-lettered at once", func(context.Context, amqp.Delivery) error {
+			return Reject("schema", errors.New("missing id"))
+		}, false, FailureValidation, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ack := &recordingAck{}
+			var published *amqp.Publishing
+			var ackedBeforeConfirm bool
+			c := &Client{
+				logger: zap.NewNop(),
+				publish: func(_ context.Context, _, _ string, p amqp.Publishing) error {
+					published, ackedBeforeConfirm = &p, ack.acked || ack.nacked
+					return nil
+				},
+			}
+			msg := amqp.Delivery{
+				Acknowledger: ack,
+				ContentType:  "application/json",
+				MessageId:    "m-1",
+				Body:         []byte(`{"id":"1"}`),
+				Headers:      amqp.Table{"x-origin": "api"},
+				Redelivered:  tt.redelivered,
+			}
+			before := time.Now().UTC()
+
+			c.handleDelivery(ctx, opts, tt.handler, msg)
+
+			if (published != nil) != tt.wantDLQ {
+				t.Fatalf("dead-lettered=%v, want %v", published != nil, tt.wantDLQ)
+			}
+			if !tt.wantDLQ {
+				if !ack.nacked || !ack.requeued {
+					t.Fatalf("want nack with requeue, got ack=%v nack=%v requeue=%v", ack.acked, ack.nacked, ack.requeued)
+				}
+				return
+			}
+			if ackedBeforeConfirm || !ack.acked || ack.nacked {
+				t.Fatalf("original must be acked after the DLQ publish: ackedBeforeConfirm=%v ack=%v nack=%v",
+					ackedBeforeConfirm, ack.acked, ack.nacked)
+			}
+			if string(published.Body) != `{"id":"1"}` || published.MessageId != "m-1" || published.Headers["x-origin"] != "api" {
+				t.Fatalf("original message not preserved: %+v", published)
+			}
+			f := FailureFromHeaders(published.Headers)
+			if f == nil {
+				t.Fatal("missing failure headers")
+			}
+			if f.Type != tt.wantType || f.Reason == "" || f.ConsumerTag != "worker-3" || f.TraceID != traceID.String() {
+				t.Fatalf("failure headers = %+v", f)
+			}
+			at, err := time.Parse(time.RFC3339Nano, f.FailedAt)
+			if err != nil || at.Before(before) {
+				t.Fatalf("x-failed-at = %q (%v)", f.FailedAt, err)
+			}
+		})
+	}
+}
+
+func TestHandleDelivery_UnconfirmedDeadLetterIsRequeued(t *testing.T) {
+	ack := &recordingAck{}
+	c := &Client{
+		logger: zap.NewNop(),
+		publish: func(context.Context, string, string, amqp.Publishing) error {
+			return ErrPublishNacked
+		},
+	}
+	msg := amqp.Delivery{Acknowledger: ack, Redelivered: true}
+	c.handleDelivery(context.Background(), ConsumeOptions{Queue: "q", DeadLetterQueue: "q.dlq", DeadLetterFailures: true},
+		func(context.Context, amqp.Delivery) error { return errors.New("boom") }, msg)
+
+	if ack.acked || !ack.nacked || !ack.requeued {
+		t.Fatalf("got ack=%v nack=%v requeue=%v, want a nack with requeue", ack.acked, ack.nacked, ack.requeued)
+	}
+}
+
+func TestHandleDelivery_FailedRetryWithoutOptionIsNacked(t *testing.T) {
+	ack := &recordingAck{}
+	c := &Client{
+		logger: zap.NewNop(),
+		publish: func(context.Context, string, string, amqp.Publishing) error {
+			t.Fatal("must not republish without DeadLetterFailures")
+			return nil
+		},
+	}
+	msg := amqp.Delivery{Acknowledger: ack, Redelivered: true}
+	c.handleDelivery(context.Background(), ConsumeOptions{Queue: "q", DeadLetterQueue: "q.dlq"},
+		func(context.Context, amqp.Delivery) error { return errors.New("boom") }, msg)
+
+	if ack.acked || !ack.nacked || ack.requeued {
+		t.Fatalf("got ack=%v nack=%v requeue=%v, want a nack without requeue", ack.acked, ack.nacked, ack.requeued)
+	}
+}
+
+func TestTruncateReason(t *testing.T) {
+	long := strings.Repeat("é", MaxFailureReasonLen) // 2 bytes per rune
+	got := truncateReason(long)
+	if len(got) > MaxFailureReasonLen || !utf8.ValidString(got) {
+		t.Fatalf("truncateReason: len=%d valid=%v", len(got), utf8.ValidString(got))
+	}
+	if truncateReason("short") != "short" {
+		t.Fatal("short reasons must be kept whole")
+	}
+}
+
+func TestRequestID_RoundTripsThroughHeaders(t *testing.T) {
+	ctx := logger.ContextWithRequestID(context.Background(), "req-1")
+	p, err := newPublishing(ctx, PublishOptions{}, map[string]string{"id": "1"})
+	if err != nil {
+		t.Fatalf("newPublishing: %v", err)
+	}
+	if p.Headers[RequestIDHeader] != "req-1" {
+		t.Fatalf("headers = %v, want %s=req-1", p.Headers, RequestIDHeader)
+	}
+	p, _ = newPublishing(ctx, PublishOptions{Headers: map[string]interface{}{RequestIDHeader: "caller"}}, nil)
+	if p.Headers[RequestIDHeader] != "caller" {
+		t.Errorf("caller's %s overwritten: %v", RequestIDHeader, p.Headers[RequestIDHeader])
+	}
+
+	var got string
+	c := &Client{logger: zap.NewNop()}
+	c.handleDelivery(context.Background(), ConsumeOptions{Queue: "q", AutoAck: true},
+		func(ctx context.Context, _ amqp.Delivery) error {
+			got = logger.RequestIDFromContext(ctx)
+			return nil
+		},
+		amqp.Delivery{Headers: amqp.Table{RequestIDHeader: "req-1"}})
+	if got != "req-1" {
+		t.Errorf("handler request ID = %q, want req-1", got)
+	}
+}
@@ -0,0 +1,111 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Diagnostic headers added to every message the consumer dead-letters, so a
+// DLQ entry explains itself without searching logs across pods.
+const (
+	FailureReasonHeader = "x-failure-reason"
+	FailureTypeHeader   = "x-failure-type"
+	FailedAtHeader      = "x-failed-at"
+	ConsumerTagHeader   = "x-consumer-tag"
+	TraceIDHeader       = "x-trace-id"
+)
+
+// MaxFailureReasonLen bounds the x-failure-reason header in bytes.
+const MaxFailureReasonLen = 512
+
+// FailureType classifies why a handler failed.
+type FailureType string
+
+const (
+	FailurePanic FailureType = "panic"
+	FailureError FailureType = "error"
+	// FailureValidation is a RejectError: the message can never succeed.
+	FailureValidation FailureType = "validation"
+	// FailureTimeout is a handler error wrapping context.DeadlineExceeded.
+	FailureTimeout FailureType = "timeout"
+)
+
+// PanicError is returned for a handler that panicked.
+type PanicError struct {
+	Value interface{}
+}
+
+func (e *PanicError) Error() string { return fmt.Sprintf("handler panic: %v", e.Value) }
+
+// ClassifyFailure returns the FailureType of a handler error.
+func ClassifyFailure(err error) FailureType {
+	var panicErr *PanicError
+	var rejectErr *RejectError
+	switch {
+	case errors.As(err, &panicErr):
+		return FailurePanic
+	case errors.Is(err, context.DeadlineExceeded):
+		return FailureTimeout
+	case errors.As(err, &rejectErr):
+		return FailureValidation
+	default:
+		return FailureError
+	}
+}
+
+// Failure is the diagnostic header set of a dead-lettered message.
+type Failure struct {
+	Reason      string      `json:"reason"`
+	Type        FailureType `json:"type"`
+	FailedAt    string      `json:"failedAt"`
+	ConsumerTag string      `json:"consumerTag,omitempty"`
+	TraceID     string      `json:"traceId,omitempty"`
+}
+
+// FailureFromHeaders reads the diagnostic headers, or returns nil for a
+// message the consumer did not dead-letter.
+func FailureFromHeaders(h amqp.Table) *Failure {
+	str := func(k string) string { s, _ := h[k].(string); return s }
+	f := &Failure{
+		Reason:      str(FailureReasonHeader),
+		Type:        FailureType(str(FailureTypeHeader)),
+		FailedAt:    str(FailedAtHeader),
+		ConsumerTag: str(ConsumerTagHeader),
+		TraceID:     str(TraceIDHeader),
+	}
+	if f.Type == "" && f.Reason == "" {
+		return nil
+	}
+	return f
+}
+
+// failureHeaders sets the diagnostic headers for err on h. The trace ID is
+// the consume span's, so the DLQ entry links to the failed attempt's trace.
+func failureHeaders(ctx context.Context, h amqp.Table, consumerTag string, err error, now time.Time) {
+	h[FailureReasonHeader] = truncateReason(err.Error())
+	h[FailureTypeHeader] = string(ClassifyFailure(err))
+	h[FailedAtHeader] = now.UTC().Format(time.RFC3339Nano)
+	h[ConsumerTagHeader] = consumerTag
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		h[TraceIDHeader] = sc.TraceID().String()
+	}
+}
+
+// truncateReason cuts s to MaxFailureReasonLen bytes without splitting a
+// UTF-8 sequence.
+func truncateReason(s string) string {
+	if len(s) <= MaxFailureReasonLen {
+		return s
+	}
+	s = s[:MaxFailureReasonLen]
+	for len(s) > 0 && !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,203 @@
+package rabbitmq
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+// fakeBroker is a single-queue stand-in for a channel: messages published
+// while no consumer is registered wait in the queue, and Cancel closes the
+// delivery channel the way basic.cancel-ok does.
+type fakeBroker struct {
+	mu       sync.Mutex
+	queue    []amqp.Delivery
+	out      chan amqp.Delivery
+	consumes int
+	cancels  int
+}
+
+func (b *fakeBroker) Consume(_, _ string, _, _, _, _ bool, _ amqp.Table) (<-chan amqp.Delivery, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.consumes++
+	b.out = make(chan amqp.Delivery, 64)
+	for _, d := range b.queue {
+		b.out <- d
+	}
+	b.queue = nil
+	return b.out, nil
+}
+
+func (b *fakeBroker) Cancel(string, bool) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.cancels++
+	if b.out != nil {
+		close(b.out)
+		b.out = nil
+	}
+	return nil
+}
+
+func (b *fakeBroker) Close() error { return b.Cancel("", false) }
+
+func (b *fakeBroker) publish(body string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	d := amqp.Delivery{Acknowledger: &recordingAck{}, ContentType: "text/plain", Body: []byte(body)}
+	if b.out != nil {
+		b.out <- d
+		return
+	}
+	b.queue = append(b.queue, d)
+}
+
+func (b *fakeBroker) queued() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.queue)
+}
+
+type pausedRecorder struct {
+	mu   sync.Mutex
+	last map[string]bool
+}
+
+func (r *pausedRecorder) SetConsumerPaused(queue string, paused bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.last == nil {
+		r.last = map[string]bool{}
+	}
+	r.last[queue] = paused
+}
+
+func (r *pausedRecorder) ObservePublishConfirm(string, time.Duration) {}
+
+func (r *pausedRecorder) paused(queue string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.last[queue]
+}
+
+func expectMessage(t *testing.T, handled <-chan string, want string) {
+	t.Helper()
+	select {
+	case got := <-handled:
+		if got != want {
+			t.Fatalf("handled %q, want %q", got, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for %q", want)
+	}
+}
+
+func TestConsumer_PauseStopsDeliveryAndResumePicksUpQueued(t *testing.T) {
+	broker := &fakeBroker{}
+	rec := &pausedRecorder{}
+	c := &Client{
+		logger:      zap.NewNop(),
+		done:        make(chan struct{}),
+		openChannel: func(ConsumeOptions) (consumerChannel, error) { return broker, nil },
+	}
+	c.EnableMetrics(rec)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		c.WaitConsumers(context.Background())
+	}()
+
+	handled := make(chan string, 8)
+	cons, err := c.ConsumeWithHandler(ctx, ConsumeOptions{Queue: "jobs", ConsumerTag: "w-1"}, func(_ context.Context, msg amqp.Delivery) error {
+		handled <- string(msg.Body)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ConsumeWithHandler: %v", err)
+	}
+
+	broker.publish("before")
+	expectMessage(t, handled, "before")
+
+	if err := c.PauseQueue("jobs"); err != nil {
+		t.Fatalf("PauseQueue: %v", err)
+	}
+	if !cons.Paused() || !rec.paused("jobs") {
+		t.Fatalf("paused=%v gauge=%v, want both true", cons.Paused(), rec.paused("jobs"))
+	}
+
+	broker.publish("during-1")
+	broker.publish("during-2")
+	select {
+	case got := <-handled:
+		t.Fatalf("handler ran while paused (got %q)", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if n := broker.queued(); n != 2 {
+		t.Fatalf("queued = %d, want 2 left on the broker", n)
+	}
+	if got := c.ConsumerStates(); len(got) != 1 || got[0].State != "paused" || got[0].Paused != 1 {
+		t.Fatalf("ConsumerStates = %+v", got)
+	}
+
+	if err := c.ResumeQueue("jobs"); err != nil {
+		t.Fatalf("ResumeQueue: %v", err)
+	}
+	expectMessage(t, handled, "during-1")
+	expectMessage(t, handled, "during-2")
+	if rec.paused("jobs") {
+		t.Fatal("gauge still reports paused after resume")
+	}
+
+	broker.mu.Lock()
+	consumes, cancels := broker.consumes, broker.cancels
+	broker.mu.Unlock()
+	if consumes != 2 || cancels != 1 {
+		t.Fatalf("consumes=%d cancels=%d, want 2 and 1 (same channel reused)", consumes, cancels)
+	}
+}
+
+func TestConsumer_PauseHandlesPrefetchedMessages(t *testing.T) {
+	broker := &fakeBroker{}
+	c := &Client{
+		logger:      zap.NewNop(),
+		done:        make(chan struct{}),
+		openChannel: func(ConsumeOptions) (consumerChannel, error) { return broker, nil },
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		c.WaitConsumers(context.Background())
+	}()
+
+	release := make(chan struct{})
+	handled := make(chan string, 8)
+	cons, _ := c.ConsumeWithHandler(ctx, ConsumeOptions{Queue: "jobs", ConsumerTag: "w-1"}, func(_ context.Context, msg amqp.Delivery) error {
+		<-release
+		handled <- string(msg.Body)
+		return nil
+	})
+
+	// Both messages reach the consumer's buffer before the pause.
+	broker.publish("a")
+	broker.publish("b")
+	time.Sleep(20 * time.Millisecond)
+	cons.Pause()
+	close(release)
+
+	expectMessage(t, handled, "a")
+	expectMessage(t, handled, "b")
+}
+
+func TestClient_PauseUnknownQueue(t *testing.T) {
+	c := &Client{logger: zap.NewNop()}
+	if err := c.PauseQueue("missing"); err == nil {
+		t.Fatal("PauseQueue on an unknown queue succeeded")
+	}
+}
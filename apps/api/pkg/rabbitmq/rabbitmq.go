@@ -3,12 +3,17 @@ package rabbitmq
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"veemon/pkg/logger"
+	"veemon/pkg/tlsconfig"
+
 	amqp "github.com/rabbitmq/amqp091-go"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -23,34 +28,94 @@ var tracer = otel.Tracer("pkg/rabbitmq")
 // live connection (e.g. during a reconnect window).
 var ErrNotConnected = errors.New("rabbitmq: not connected")
 
+// ErrPublishNacked is returned when the broker refuses a confirmed publish.
+var ErrPublishNacked = errors.New("rabbitmq: publish not confirmed by broker")
+
+// ErrConfirmTimeout is returned when the broker's confirm for a publish does
+// not arrive within confirmTimeout or the caller's deadline, whichever is
+// first. The message may or may not have been accepted.
+var ErrConfirmTimeout = errors.New("rabbitmq: timed out waiting for publish confirm")
+
+// Reconnect scopes passed to an OnReconnect hook.
+const (
+	// ReconnectConnection: the connection was re-dialed.
+	ReconnectConnection = "connection"
+	// ReconnectChannel: only the publish channel was reopened.
+	ReconnectChannel = "channel"
+)
+
 const (
 	reconnectMinBackoff = 1 * time.Second
 	reconnectMaxBackoff = 30 * time.Second
+	// publishRetryDelay spaces Publish attempts while waiting out a reconnect.
+	publishRetryDelay = 100 * time.Millisecond
+	// confirmTimeout bounds the wait for a publish confirm.
+	confirmTimeout = 5 * time.Second
 )
 
-// Client is an auto-reconnecting RabbitMQ client. A dropped connection is
-// re-established in the background; publishers see a transient error and
-// consumers automatically re-attach once connectivity returns.
+// Client is an auto-reconnecting RabbitMQ client. A dropped connection or
+// publish channel is re-established in the background; Publish waits for it
+// within its context deadline and consumers automatically re-attach once
+// connectivity returns.
 type Client struct {
 	config Config
 	logger *zap.Logger
 	url    string
+	tls    *tls.Config // nil dials without TLS
 
 	mu      sync.RWMutex
 	conn    *amqp.Connection
 	channel *amqp.Channel // dedicated to publishing
+	// ready is closed while connected and replaced by an open channel when
+	// the connection or publish channel is lost, for waiters to block on.
+	ready       chan struct{}
+	onReconnect func(scope string)
+
+	// confirmCh is a confirm-mode channel for dead-letter republishing and
+	// PublishConfirmed, opened on first use and reopened after a reconnect.
+	confirmMu sync.Mutex
+	confirmCh *amqp.Channel
 
 	consumerWG sync.WaitGroup
-	done       chan struct{}
-	closeOnce  sync.Once
+	// Shutdown accounting reported by WaitConsumers: messages being handled,
+	// those that finished after their consumer's context was canceled, and
+	// prefetched ones handed back to the broker unstarted.
+	inFlight     atomic.Int64
+	drainHandled atomic.Int64
+	requeued     atomic.Int64
+	done         chan struct{}
+	closeOnce    sync.Once
+
+	// publish overrides publishRaw; nil publishes on confirmCh.
+	publish func(ctx context.Context, exchange, routingKey string, p amqp.Publishing) error
+	// publishDeferred overrides publishing on confirmCh, returning the
+	// pending confirm; nil publishes on confirmCh.
+	publishDeferred func(ctx context.Context, exchange, routingKey string, p amqp.Publishing) (deferredConfirm, error)
+	// openChannel overrides openConsumerChannel; nil opens a channel on the
+	// live connection.
+	openChannel func(opts ConsumeOptions) (consumerChannel, error)
+
+	consumersMu sync.Mutex
+	consumers   map[string][]*Consumer // by queue
+	recorder    Recorder
 }
 
 type Config struct {
-	Host     string
-	Port     int
-	User     string
-	Password string
-	VHost    string
+	Host     string `mapstructure:"RABBITMQ_HOST"`
+	Port     int    `mapstructure:"RABBITMQ_PORT"`
+	User     string `mapstructure:"RABBITMQ_USER"`
+	Password string `mapstructure:"RABBITMQ_PASSWORD"`
+	VHost    string `mapstructure:"RABBITMQ_VHOST"`
+
+	// TLSEnabled connects with amqps, checking the certificate against Host
+	// unless TLSSkipVerify turns verification off. TLSCAFile replaces the
+	// system roots and TLSCertFile with TLSKeyFile is a client certificate,
+	// all PEM.
+	TLSEnabled    bool   `mapstructure:"RABBITMQ_TLS_ENABLED"`
+	TLSSkipVerify bool   `mapstructure:"RABBITMQ_TLS_SKIP_VERIFY"`
+	TLSCAFile     string `mapstructure:"RABBITMQ_TLS_CA_FILE"`
+	TLSCertFile   string `mapstructure:"RABBITMQ_TLS_CERT_FILE"`
+	TLSKeyFile    string `mapstructure:"RABBITMQ_TLS_KEY_FILE"`
 }
 
 type PublishOptions struct {
@@ -60,6 +125,10 @@ type PublishOptions struct {
 	Immediate   bool
 	ContentType string
 	Headers     map[string]interface{}
+	// Confirm publishes on a confirm-mode channel and returns only once the
+	// broker has acked the message: ErrPublishNacked if it refuses it,
+	// ErrConfirmTimeout if the ack does not arrive in time.
+	Confirm bool
 }
 
 type ConsumeOptions struct {
@@ -72,6 +141,41 @@ type ConsumeOptions struct {
 	Args        amqp.Table
 	// PrefetchCount sets QoS on the consumer's dedicated channel (0 = unlimited).
 	PrefetchCount int
+	// DeadLetterQueue receives messages rejected with Reject, republished via
+	// the default exchange with a RejectionReasonHeader and the failure
+	// headers (FailureReasonHeader etc.). Empty drops them.
+	DeadLetterQueue string
+	// DeadLetterFailures also republishes to DeadLetterQueue a message whose
+	// handler failed or panicked on redelivery, instead of nacking it for the
+	// queue's own dead-letter exchange (or discarding it). The copy carries
+	// the failure headers, which a broker-side dead-letter cannot add. The
+	// original is acked once the broker confirms the copy, so a crash in
+	// between can leave a duplicate in the DLQ.
+	DeadLetterFailures bool
+}
+
+// RejectionReasonHeader carries the reason a message was dead-lettered.
+const RejectionReasonHeader = "x-rejection-reason"
+
+// RejectError marks a message as permanently unprocessable: it is routed to
+// the dead-letter queue instead of being retried.
+type RejectError struct {
+	Reason string
+	Err    error
+}
+
+func (e *RejectError) Error() string {
+	if e.Err != nil {
+		return e.Reason + ": " + e.Err.Error()
+	}
+	return e.Reason
+}
+
+func (e *RejectError) Unwrap() error { return e.Err }
+
+// Reject wraps err so the consumer dead-letters the message with reason.
+func Reject(reason string, err error) error {
+	return &RejectError{Reason: reason, Err: err}
 }
 
 type Message struct {
@@ -80,14 +184,32 @@ type Message struct {
 }
 
 func New(cfg Config, logger *zap.Logger) (*Client, error) {
-	url := fmt.Sprintf("amqp://%s:%s@%s:%d/%s",
-		cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.VHost)
+	scheme := "amqp"
+	var tlsCfg *tls.Config
+	if cfg.TLSEnabled {
+		var err error
+		tlsCfg, err = tlsconfig.New(tlsconfig.Options{
+			ServerName: cfg.Host,
+			SkipVerify: cfg.TLSSkipVerify,
+			CAFile:     cfg.TLSCAFile,
+			CertFile:   cfg.TLSCertFile,
+			KeyFile:    cfg.TLSKeyFile,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("rabbitmq TLS: %w", err)
+		}
+		scheme = "amqps"
+	}
+	url := fmt.Sprintf("%s://%s:%s@%s:%d/%s",
+		scheme, cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.VHost)
 
 	client := &Client{
 		config: cfg,
 		logger: logger,
 		url:    url,
+		tls:    tlsCfg,
 		done:   make(chan struct{}),
+		ready:  make(chan struct{}),
 	}
 
 	if err := client.connect(); err != nil {
@@ -102,7 +224,13 @@ func New(cfg Config, logger *zap.Logger) (*Client, error) {
 
 // connect (re)establishes the connection and the publish channel.
 func (c *Client) connect() error {
-	conn, err := amqp.Dial(c.url)
+	var conn *amqp.Connection
+	var err error
+	if c.tls != nil {
+		conn, err = amqp.DialTLS(c.url, c.tls)
+	} else {
+		conn, err = amqp.Dial(c.url)
+	}
 	if err != nil {
 		return err
 	}
@@ -116,30 +244,116 @@ func (c *Client) connect() error {
 	c.conn = conn
 	c.channel = ch
 	c.mu.Unlock()
+	c.markUp()
+	return nil
+}
+
+// reopenChannel replaces a closed publish channel on the live connection.
+func (c *Client) reopenChannel(conn *amqp.Connection) error {
+	ch, err := conn.Channel()
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.channel = ch
+	c.mu.Unlock()
+	c.markUp()
 	return nil
 }
 
-// supervise blocks on the current connection's close notification and, unless
-// the client was intentionally closed, reconnects with capped exponential
-// backoff.
+// markUp wakes everything waiting for a connection.
+func (c *Client) markUp() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ready == nil {
+		c.ready = make(chan struct{})
+	}
+	select {
+	case <-c.ready:
+	default:
+		close(c.ready)
+	}
+}
+
+// markDown makes waiters block until the next markUp.
+func (c *Client) markDown() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	select {
+	case <-c.ready:
+		c.ready = make(chan struct{})
+	default:
+	}
+}
+
+// readyCh is closed once the client is connected.
+func (c *Client) readyCh() <-chan struct{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.ready
+}
+
+// OnReconnect registers fn to be called after each reconnect with its scope,
+// ReconnectConnection or ReconnectChannel, e.g. to count reconnects in
+// metrics. It replaces any earlier hook.
+func (c *Client) OnReconnect(fn func(scope string)) {
+	c.mu.Lock()
+	c.onReconnect = fn
+	c.mu.Unlock()
+}
+
+func (c *Client) reconnected(scope string) {
+	c.mu.RLock()
+	fn := c.onReconnect
+	c.mu.RUnlock()
+	if fn != nil {
+		fn(scope)
+	}
+}
+
+// supervise blocks on the close notifications of the current connection and
+// publish channel and, unless the client was intentionally closed, restores
+// them: a lost channel is reopened on the same connection, a lost connection
+// is re-dialed with capped exponential backoff.
 func (c *Client) supervise() {
 	for {
 		c.mu.RLock()
-		conn := c.conn
+		conn, ch := c.conn, c.channel
 		c.mu.RUnlock()
 		if conn == nil {
 			return
 		}
 
-		closeErr := <-conn.NotifyClose(make(chan *amqp.Error, 1))
+		var connErr, chErr *amqp.Error
+		channelOnly := false
+		select {
+		case connErr = <-conn.NotifyClose(make(chan *amqp.Error, 1)):
+		case chErr = <-ch.NotifyClose(make(chan *amqp.Error, 1)):
+			channelOnly = !conn.IsClosed()
+		case <-c.done:
+			return
+		}
 
 		select {
 		case <-c.done:
 			return // intentional shutdown
 		default:
 		}
+		c.markDown()
 
-		c.logger.Warn("rabbitmq connection lost; reconnecting", zap.Error(closeErr))
+		if channelOnly {
+			c.logger.Warn("rabbitmq publish channel closed; reopening", zap.Error(chErr))
+			err := c.reopenChannel(conn)
+			if err == nil {
+				c.logger.Info("rabbitmq publish channel reopened")
+				c.reconnected(ReconnectChannel)
+				continue
+			}
+			c.logger.Error("rabbitmq channel reopen failed; reconnecting", zap.Error(err))
+			_ = conn.Close()
+		} else {
+			c.logger.Warn("rabbitmq connection lost; reconnecting", zap.Error(connErr))
+		}
 
 		backoff := reconnectMinBackoff
 		for {
@@ -154,6 +368,7 @@ func (c *Client) supervise() {
 				continue
 			}
 			c.logger.Info("rabbitmq reconnected")
+			c.reconnected(ReconnectConnection)
 			break
 		}
 	}
@@ -170,6 +385,12 @@ func nextBackoff(d time.Duration) time.Duration {
 func (c *Client) Close() error {
 	c.closeOnce.Do(func() { close(c.done) })
 
+	c.confirmMu.Lock()
+	if c.confirmCh != nil {
+		_ = c.confirmCh.Close()
+	}
+	c.confirmMu.Unlock()
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	if c.channel != nil {
@@ -185,12 +406,19 @@ func (c *Client) Close() error {
 func (c *Client) currentChannel() (*amqp.Channel, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	if c.channel == nil || c.conn == nil || c.conn.IsClosed() {
+	if c.channel == nil || c.channel.IsClosed() || c.conn == nil || c.conn.IsClosed() {
 		return nil, ErrNotConnected
 	}
 	return c.channel, nil
 }
 
+// IsConnected reports whether the client has a live connection and publish
+// channel, i.e. is not in a reconnect window.
+func (c *Client) IsConnected() bool {
+	_, err := c.currentChannel()
+	return err == nil
+}
+
 // Channel returns the current publish channel (may be nil during a reconnect).
 func (c *Client) Channel() *amqp.Channel {
 	c.mu.RLock()
@@ -233,6 +461,63 @@ func (c *Client) BindQueue(queueName, routingKey, exchangeName string, noWait bo
 	return ch.QueueBind(queueName, routingKey, exchangeName, noWait, args)
 }
 
+// PeekedMessage is a message read by PeekQueue.
+type PeekedMessage struct {
+	ContentType string
+	Body        []byte
+	Headers     amqp.Table
+	Redelivered bool
+	// Failure holds the diagnostic headers of a dead-lettered message.
+	Failure *Failure
+}
+
+// PeekQueue returns up to limit messages from the head of queue without
+// consuming them: each is fetched with basic.get and all are requeued at the
+// end. Requeued messages are flagged redelivered, and ones published in the
+// meantime may overtake them, so use it on queues nothing consumes, such as
+// a DLQ.
+func (c *Client) PeekQueue(queue string, limit int) ([]PeekedMessage, error) {
+	c.mu.RLock()
+	conn := c.conn
+	c.mu.RUnlock()
+	if conn == nil || conn.IsClosed() {
+		return nil, ErrNotConnected
+	}
+	// A dedicated channel: closing it requeues anything still unacked, even
+	// if the explicit nack below is never reached.
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = ch.Close() }()
+
+	var out []PeekedMessage
+	var last uint64
+	for len(out) < limit {
+		d, ok, err := ch.Get(queue, false)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		last = d.DeliveryTag
+		out = append(out, PeekedMessage{
+			ContentType: d.ContentType,
+			Body:        d.Body,
+			Headers:     d.Headers,
+			Redelivered: d.Redelivered,
+			Failure:     FailureFromHeaders(d.Headers),
+		})
+	}
+	if last > 0 {
+		if err := ch.Nack(last, true, true); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
 // SetQoS sets QoS on the publish channel. Consumers set their own QoS via
 // ConsumeOptions.PrefetchCount on their dedicated channels.
 func (c *Client) SetQoS(prefetchCount, prefetchSize int, global bool) error {
@@ -243,8 +528,9 @@ func (c *Client) SetQoS(prefetchCount, prefetchSize int, global bool) error {
 	return ch.Qos(prefetchCount, prefetchSize, global)
 }
 
-// Publish publishes a message with tracing. On a transient channel error it
-// retries once after a short delay to ride out a reconnect.
+// Publish publishes a message with tracing. While the client is reconnecting
+// it waits for the connection until ctx's deadline; a ctx without a deadline
+// fails fast. Either way the error then wraps ErrNotConnected.
 func (c *Client) Publish(ctx context.Context, opts PublishOptions, message interface{}) error {
 	ctx, span := tracer.Start(ctx, "rabbitmq.Publish",
 		trace.WithAttributes(
@@ -254,10 +540,70 @@ func (c *Client) Publish(ctx context.Context, opts PublishOptions, message inter
 		))
 	defer span.End()
 
-	body, err := json.Marshal(message)
+	publishing, err := newPublishing(ctx, opts, message)
 	if err != nil {
 		span.RecordError(err)
-		return fmt.Errorf("failed to marshal message: %w", err)
+		return err
+	}
+
+	for {
+		err = c.publishOnce(ctx, opts, publishing)
+		if err == nil {
+			return nil
+		}
+		if !isConnectionError(err) {
+			span.RecordError(err)
+			return err
+		}
+		if _, ok := ctx.Deadline(); !ok {
+			span.RecordError(err)
+			return ErrNotConnected
+		}
+		if waitErr := c.waitConnected(ctx); waitErr != nil {
+			err = fmt.Errorf("%w: %w", ErrNotConnected, waitErr)
+			span.RecordError(err)
+			return err
+		}
+	}
+}
+
+// isConnectionError reports whether err comes from a lost connection or
+// channel, which a reconnect resolves.
+func isConnectionError(err error) bool {
+	return errors.Is(err, ErrNotConnected) || errors.Is(err, amqp.ErrClosed)
+}
+
+// waitConnected pauses briefly, for the supervisor to notice a loss, then
+// blocks until the client is connected, ctx ends or the client is closed.
+// Publish and consumers use it to ride out a reconnect.
+func (c *Client) waitConnected(ctx context.Context) error {
+	if !sleepOrDone(ctx, c.done, publishRetryDelay) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return errors.New("client closed")
+	}
+	select {
+	case <-c.readyCh():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.done:
+		return errors.New("client closed")
+	}
+}
+
+// RequestIDHeader carries the request ID of the context a message was
+// published in (see logger.ContextWithRequestID); the consumer puts it back
+// in the handler's context.
+const RequestIDHeader = "x-request-id"
+
+// newPublishing encodes message as JSON with opts' headers and the trace
+// context and request ID of ctx.
+func newPublishing(ctx context.Context, opts PublishOptions, message interface{}) (amqp.Publishing, error) {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return amqp.Publishing{}, fmt.Errorf("failed to marshal message: %w", err)
 	}
 
 	headers := make(amqp.Table)
@@ -271,33 +617,30 @@ func (c *Client) Publish(ctx context.Context, opts PublishOptions, message inter
 	for k, v := range carrier {
 		headers[k] = v
 	}
+	if _, set := headers[RequestIDHeader]; !set {
+		if requestID := logger.RequestIDFromContext(ctx); requestID != "" {
+			headers[RequestIDHeader] = requestID
+		}
+	}
 
 	contentType := opts.ContentType
 	if contentType == "" {
 		contentType = "application/json"
 	}
 
-	publishing := amqp.Publishing{
+	return amqp.Publishing{
 		ContentType:  contentType,
 		Body:         body,
 		Headers:      headers,
 		Timestamp:    time.Now(),
 		DeliveryMode: amqp.Persistent,
-	}
-
-	err = c.publishOnce(ctx, opts, publishing)
-	if err != nil {
-		// Give the supervisor a moment to reconnect, then retry once.
-		time.Sleep(reconnectMinBackoff)
-		if retryErr := c.publishOnce(ctx, opts, publishing); retryErr != nil {
-			span.RecordError(retryErr)
-			return retryErr
-		}
-	}
-	return nil
+	}, nil
 }
 
 func (c *Client) publishOnce(ctx context.Context, opts PublishOptions, publishing amqp.Publishing) error {
+	if opts.Confirm {
+		return c.publishRaw(ctx, opts.Exchange, opts.RoutingKey, publishing)
+	}
 	ch, err := c.currentChannel()
 	if err != nil {
 		return err
@@ -307,20 +650,47 @@ func (c *Client) publishOnce(ctx context.Context, opts PublishOptions, publishin
 
 // ConsumeWithHandler starts a self-healing consumer on its own channel. The
 // consumer survives channel/connection loss (re-attaching automatically) and
-// stops when ctx is canceled or the client is closed.
-func (c *Client) ConsumeWithHandler(ctx context.Context, opts ConsumeOptions, handler func(ctx context.Context, msg amqp.Delivery) error) error {
+// stops when ctx is canceled or the client is closed. The returned handle
+// pauses and resumes delivery at runtime. An empty ConsumerTag is replaced by
+// a generated one, since pausing cancels by tag.
+func (c *Client) ConsumeWithHandler(ctx context.Context, opts ConsumeOptions, handler func(ctx context.Context, msg amqp.Delivery) error) (*Consumer, error) {
+	if opts.ConsumerTag == "" {
+		opts.ConsumerTag = fmt.Sprintf("%s-%d", opts.Queue, consumerSeq.Add(1))
+	}
+	cons := &Consumer{client: c, opts: opts}
+	c.addConsumer(cons)
+
 	c.consumerWG.Add(1)
 	go func() {
 		defer c.consumerWG.Done()
-		c.consumeLoop(ctx, opts, handler)
+		c.consumeLoop(ctx, cons, handler)
 	}()
-	return nil
+	return cons, nil
+}
+
+// DrainStats describes a consumer shutdown.
+type DrainStats struct {
+	// Handled counts messages that were being handled when their consumer's
+	// context was canceled and finished afterwards.
+	Handled int64
+	// Requeued counts prefetched messages nacked back to the queue without
+	// being handled.
+	Requeued int64
+	// Unfinished counts messages still being handled when WaitConsumers gave
+	// up. The broker redelivers them once the connection closes.
+	Unfinished int64
 }
 
 // WaitConsumers blocks until all consumer loops have exited (their contexts
 // canceled and in-flight messages finished) or ctx expires. Use it during
-// graceful shutdown before Close so in-flight messages are not cut off.
-func (c *Client) WaitConsumers(ctx context.Context) {
+// graceful shutdown, after canceling the consumers' context and before
+// Close, so in-flight messages are not cut off.
+//
+// A canceled consumer stops delivery with basic.cancel and nacks, with
+// requeue, whatever the broker had already prefetched to it. The message
+// it was handling runs to completion: handlers get a context that is not
+// canceled with the consumer's, so only ctx bounds them.
+func (c *Client) WaitConsumers(ctx context.Context) DrainStats {
 	done := make(chan struct{})
 	go func() {
 		c.consumerWG.Wait()
@@ -330,21 +700,30 @@ func (c *Client) WaitConsumers(ctx context.Context) {
 	case <-done:
 	case <-ctx.Done():
 	}
+	return DrainStats{
+		Handled:    c.drainHandled.Load(),
+		Requeued:   c.requeued.Load(),
+		Unfinished: c.inFlight.Load(),
+	}
 }
 
-func (c *Client) consumeLoop(ctx context.Context, opts ConsumeOptions, handler func(ctx context.Context, msg amqp.Delivery) error) {
+func (c *Client) consumeLoop(ctx context.Context, cons *Consumer, handler func(ctx context.Context, msg amqp.Delivery) error) {
+	opts := cons.opts
 	backoff := reconnectMinBackoff
 	for {
-		if ctx.Err() != nil {
+		if c.stopped(ctx) {
 			return
 		}
-		select {
-		case <-c.done:
-			return
-		default:
-		}
 
-		ch, deliveries, err := c.startConsumer(opts)
+		ch, err := c.openConsumerChannel(opts)
+		if errors.Is(err, ErrNotConnected) {
+			// Re-attach as soon as the supervisor has reconnected.
+			c.logger.Warn("consumer waiting for rabbitmq reconnect", zap.String("queue", opts.Queue))
+			if c.waitConnected(ctx) != nil {
+				return
+			}
+			continue
+		}
 		if err != nil {
 			c.logger.Warn("consumer attach failed; retrying",
 				zap.String("queue", opts.Queue), zap.Error(err), zap.Duration("backoff", backoff))
@@ -355,54 +734,90 @@ func (c *Client) consumeLoop(ctx context.Context, opts ConsumeOptions, handler f
 			continue
 		}
 
-		c.logger.Info("consumer attached", zap.String("queue", opts.Queue), zap.String("consumer_tag", opts.ConsumerTag))
 		backoff = reconnectMinBackoff
-		c.runConsumer(ctx, opts, handler, deliveries)
+		c.serveChannel(ctx, cons, ch, handler)
 		_ = ch.Close()
+		// Channel lost (connection/channel drop) or shutting down — the loop
+		// re-attaches unless stopped.
+	}
+}
 
-		if ctx.Err() != nil {
+// serveChannel consumes from ch until ctx ends or the channel fails. While the
+// consumer is paused it keeps ch open and waits for Resume, then re-issues
+// basic.consume on the same channel.
+func (c *Client) serveChannel(ctx context.Context, cons *Consumer, ch consumerChannel, handler func(ctx context.Context, msg amqp.Delivery) error) {
+	opts := cons.opts
+	for {
+		if !cons.waitRunning(ctx, c.done) {
+			return
+		}
+		deliveries, err := cons.consume(ch)
+		if err != nil {
+			c.logger.Warn("consumer attach failed; retrying",
+				zap.String("queue", opts.Queue), zap.Error(err))
 			return
 		}
-		// Delivery channel closed (connection/channel drop) — loop re-attaches.
+		if deliveries == nil {
+			continue // paused between waitRunning and consume
+		}
+
+		c.logger.Info("consumer attached", zap.String("queue", opts.Queue), zap.String("consumer_tag", opts.ConsumerTag))
+		c.runConsumer(ctx, opts, ch, handler, deliveries)
+		cons.detach()
+
+		if c.stopped(ctx) || !cons.Paused() {
+			return
+		}
+		// Paused: deliveries closed after basic.cancel and any prefetched
+		// messages were handled. Wait on the same channel.
 	}
 }
 
-// startConsumer opens a dedicated channel from the current connection and
-// begins consuming.
-func (c *Client) startConsumer(opts ConsumeOptions) (*amqp.Channel, <-chan amqp.Delivery, error) {
+// openConsumerChannel opens a dedicated channel from the current connection
+// and applies the consumer's QoS.
+func (c *Client) openConsumerChannel(opts ConsumeOptions) (consumerChannel, error) {
+	if c.openChannel != nil {
+		return c.openChannel(opts)
+	}
+
 	c.mu.RLock()
 	conn := c.conn
 	c.mu.RUnlock()
 	if conn == nil || conn.IsClosed() {
-		return nil, nil, ErrNotConnected
+		return nil, ErrNotConnected
 	}
 
 	ch, err := conn.Channel()
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 	if opts.PrefetchCount > 0 {
 		if err := ch.Qos(opts.PrefetchCount, 0, false); err != nil {
 			_ = ch.Close()
-			return nil, nil, err
+			return nil, err
 		}
 	}
+	return ch, nil
+}
 
-	deliveries, err := ch.Consume(
-		opts.Queue, opts.ConsumerTag, opts.AutoAck,
-		opts.Exclusive, opts.NoLocal, opts.NoWait, opts.Args,
-	)
-	if err != nil {
-		_ = ch.Close()
-		return nil, nil, err
+// stopped reports whether ctx is canceled or the client closed.
+func (c *Client) stopped(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return true
+	}
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
 	}
-	return ch, deliveries, nil
 }
 
-func (c *Client) runConsumer(ctx context.Context, opts ConsumeOptions, handler func(ctx context.Context, msg amqp.Delivery) error, deliveries <-chan amqp.Delivery) {
+func (c *Client) runConsumer(ctx context.Context, opts ConsumeOptions, ch consumerChannel, handler func(ctx context.Context, msg amqp.Delivery) error, deliveries <-chan amqp.Delivery) {
 	for {
 		select {
 		case <-ctx.Done():
+			c.stopConsumer(opts, ch, deliveries)
 			return
 		case <-c.done:
 			return
@@ -410,12 +825,57 @@ func (c *Client) runConsumer(ctx context.Context, opts ConsumeOptions, handler f
 			if !ok {
 				return // channel closed; caller re-attaches
 			}
+			if ctx.Err() != nil {
+				// Canceled while this delivery was also ready.
+				c.requeue(opts, msg)
+				c.stopConsumer(opts, ch, deliveries)
+				return
+			}
 			c.handleDelivery(ctx, opts, handler, msg)
 		}
 	}
 }
 
+// stopConsumer cancels the consumer tag so the broker sends nothing more,
+// then requeues the deliveries it had already prefetched. basic.cancel-ok
+// closes deliveries once the last of them is read. If the cancel fails the
+// prefetched messages are left to the broker, which requeues them when the
+// channel closes.
+func (c *Client) stopConsumer(opts ConsumeOptions, ch consumerChannel, deliveries <-chan amqp.Delivery) {
+	if err := ch.Cancel(opts.ConsumerTag, false); err != nil {
+		c.logger.Warn("consumer cancel failed on shutdown",
+			zap.String("queue", opts.Queue), zap.String("consumer_tag", opts.ConsumerTag), zap.Error(err))
+		return
+	}
+	for msg := range deliveries {
+		c.requeue(opts, msg)
+	}
+}
+
+// requeue hands an unstarted delivery back to the queue.
+func (c *Client) requeue(opts ConsumeOptions, msg amqp.Delivery) {
+	if opts.AutoAck {
+		return // already settled; nothing to give back
+	}
+	if err := msg.Nack(false, true); err != nil {
+		c.logger.Error("failed to requeue message on shutdown", zap.Error(err), zap.String("queue", opts.Queue))
+		return
+	}
+	c.requeued.Add(1)
+}
+
 func (c *Client) handleDelivery(ctx context.Context, opts ConsumeOptions, handler func(ctx context.Context, msg amqp.Delivery) error, msg amqp.Delivery) {
+	c.inFlight.Add(1)
+	defer func(consumerCtx context.Context) {
+		c.inFlight.Add(-1)
+		if consumerCtx.Err() != nil {
+			c.drainHandled.Add(1)
+		}
+	}(ctx)
+	// The consumer's context ends at shutdown; the message being handled
+	// must still be able to finish (see WaitConsumers).
+	ctx = context.WithoutCancel(ctx)
+
 	// Extract trace context from headers.
 	carrier := make(propagation.MapCarrier)
 	for k, v := range msg.Headers {
@@ -428,19 +888,37 @@ func (c *Client) handleDelivery(ctx context.Context, opts ConsumeOptions, handle
 		trace.WithAttributes(attribute.String("messaging.system", "rabbitmq"),
 			attribute.String("messaging.destination", opts.Queue)))
 	defer span.End()
+	requestID := carrier[RequestIDHeader]
+	if requestID != "" {
+		msgCtx = logger.ContextWithRequestID(msgCtx, requestID)
+		span.SetAttributes(attribute.String("request.id", requestID))
+	}
 
 	err := safeHandle(msgCtx, handler, msg)
 	if err != nil {
 		span.RecordError(err)
-		c.logger.Error("failed to process message", zap.Error(err), zap.String("queue", opts.Queue))
+		fields := []zap.Field{zap.Error(err), zap.String("queue", opts.Queue)}
+		if requestID != "" {
+			fields = append(fields, zap.String("request_id", requestID))
+		}
+		c.logger.Error("failed to process message", fields...)
 		if opts.AutoAck {
 			return
 		}
+		var rejectErr *RejectError
+		if errors.As(err, &rejectErr) {
+			c.deadLetter(msgCtx, opts, msg, err, rejectErr.Reason)
+			return
+		}
 		// Poison-message guard: a message that already failed once (Redelivered)
 		// is dropped (requeue=false) instead of being requeued forever. With a
 		// dead-letter exchange configured on the queue it will be routed there;
 		// otherwise it is discarded. This bounds retries without a DLX.
 		requeue := !msg.Redelivered
+		if !requeue && opts.DeadLetterFailures && opts.DeadLetterQueue != "" {
+			c.deadLetter(msgCtx, opts, msg, err, "")
+			return
+		}
 		if nackErr := msg.Nack(false, requeue); nackErr != nil {
 			c.logger.Error("failed to nack message", zap.Error(nackErr), zap.String("queue", opts.Queue))
 		}
@@ -454,12 +932,140 @@ func (c *Client) handleDelivery(ctx context.Context, opts ConsumeOptions, handle
 	}
 }
 
+// deadLetter republishes a failed message to the DLQ with the original body
+// and headers plus the failure headers for err; rejection, if set, also goes
+// in RejectionReasonHeader. The original is acked only once the broker has
+// confirmed the copy. If the republish fails it is requeued, to be
+// dead-lettered again on redelivery, so it is never lost. Without a DLQ it
+// is nacked without requeue: the queue's own dead-letter exchange gets it,
+// or the broker discards it.
+func (c *Client) deadLetter(ctx context.Context, opts ConsumeOptions, msg amqp.Delivery, err error, rejection string) {
+	if opts.DeadLetterQueue != "" {
+		headers := make(amqp.Table, len(msg.Headers)+6)
+		for k, v := range msg.Headers {
+			headers[k] = v
+		}
+		if rejection != "" {
+			headers[RejectionReasonHeader] = rejection
+		}
+		failureHeaders(ctx, headers, opts.ConsumerTag, err, time.Now())
+
+		pubErr := c.publishRaw(ctx, "", opts.DeadLetterQueue, amqp.Publishing{
+			ContentType:     msg.ContentType,
+			ContentEncoding: msg.ContentEncoding,
+			MessageId:       msg.MessageId,
+			CorrelationId:   msg.CorrelationId,
+			Type:            msg.Type,
+			AppId:           msg.AppId,
+			Body:            msg.Body,
+			Headers:         headers,
+			Timestamp:       time.Now(),
+			DeliveryMode:    amqp.Persistent,
+		})
+		if pubErr == nil {
+			if ackErr := msg.Ack(false); ackErr != nil {
+				c.logger.Error("failed to ack message", zap.Error(ackErr), zap.String("queue", opts.Queue))
+			}
+			return
+		}
+		c.logger.Error("failed to dead-letter message, requeueing it", zap.Error(pubErr), zap.String("queue", opts.Queue))
+		if nackErr := msg.Nack(false, true); nackErr != nil {
+			c.logger.Error("failed to nack message", zap.Error(nackErr), zap.String("queue", opts.Queue))
+		}
+		return
+	}
+	if nackErr := msg.Nack(false, false); nackErr != nil {
+		c.logger.Error("failed to nack message", zap.Error(nackErr), zap.String("queue", opts.Queue))
+	}
+}
+
+// publishRaw publishes a prebuilt message and waits for the broker's
+// confirm, indirected through c.publish so tests can observe dead-lettering
+// without a broker.
+func (c *Client) publishRaw(ctx context.Context, exchange, routingKey string, p amqp.Publishing) error {
+	if c.publish != nil {
+		return c.publish(ctx, exchange, routingKey, p)
+	}
+	return c.publishConfirmed(ctx, exchange, routingKey, p)
+}
+
+// deferredConfirm is the part of *amqp.DeferredConfirmation awaited here.
+type deferredConfirm interface {
+	WaitContext(ctx context.Context) (bool, error)
+}
+
+// publishConfirmed publishes on confirmCh and returns nil only once the
+// broker has acked the message.
+func (c *Client) publishConfirmed(ctx context.Context, exchange, routingKey string, p amqp.Publishing) error {
+	c.confirmMu.Lock()
+	defer c.confirmMu.Unlock()
+
+	start := time.Now()
+	confirm, err := c.publishDeferredLocked(ctx, exchange, routingKey, p)
+	if err != nil {
+		return err
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, confirmTimeout)
+	defer cancel()
+	return c.awaitConfirm(waitCtx, confirm, start)
+}
+
+// publishDeferredLocked publishes on confirmCh, opening it if needed, and
+// returns the pending confirm. The caller holds confirmMu.
+func (c *Client) publishDeferredLocked(ctx context.Context, exchange, routingKey string, p amqp.Publishing) (deferredConfirm, error) {
+	if c.publishDeferred != nil {
+		return c.publishDeferred(ctx, exchange, routingKey, p)
+	}
+	if c.confirmCh == nil || c.confirmCh.IsClosed() {
+		c.mu.RLock()
+		conn := c.conn
+		c.mu.RUnlock()
+		if conn == nil || conn.IsClosed() {
+			return nil, ErrNotConnected
+		}
+		ch, err := conn.Channel()
+		if err != nil {
+			return nil, err
+		}
+		if err := ch.Confirm(false); err != nil {
+			_ = ch.Close()
+			return nil, fmt.Errorf("enable publisher confirms: %w", err)
+		}
+		c.confirmCh = ch
+	}
+
+	confirm, err := c.confirmCh.PublishWithDeferredConfirmWithContext(ctx, exchange, routingKey, false, false, p)
+	if err != nil {
+		return nil, err
+	}
+	return confirm, nil
+}
+
+// awaitConfirm waits for confirm until waitCtx ends and records the confirm
+// latency since start.
+func (c *Client) awaitConfirm(waitCtx context.Context, confirm deferredConfirm, start time.Time) error {
+	acked, err := confirm.WaitContext(waitCtx)
+	outcome := "ack"
+	switch {
+	case err != nil && waitCtx.Err() != nil:
+		outcome = "timeout"
+		err = fmt.Errorf("%w: %w", ErrConfirmTimeout, err)
+	case err != nil:
+		outcome = "error"
+	case !acked:
+		outcome = "nack"
+		err = ErrPublishNacked
+	}
+	c.recordConfirm(outcome, time.Since(start))
+	return err
+}
+
 // safeHandle runs the handler with panic recovery so one bad message cannot
-// crash the worker.
+// crash the worker. A panic is returned as a *PanicError.
 func safeHandle(ctx context.Context, handler func(ctx context.Context, msg amqp.Delivery) error, msg amqp.Delivery) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			err = fmt.Errorf("handler panic: %v", r)
+			err = &PanicError{Value: r}
 		}
 	}()
 	return handler(ctx, msg)
@@ -477,6 +1083,13 @@ func sleepOrDone(ctx context.Context, done <-chan struct{}, d time.Duration) boo
 	}
 }
 
+// PublishConfirmed publishes a prebuilt message and returns nil only once the
+// broker has acked it; a nack returns ErrPublishNacked. Unlike Publish it
+// does not retry, so the caller decides how to handle a failure.
+func (c *Client) PublishConfirmed(ctx context.Context, exchange, routingKey string, p amqp.Publishing) error {
+	return c.publishRaw(ctx, exchange, routingKey, p)
+}
+
 // PublishJSON is a convenience method for publishing JSON messages.
 func (c *Client) PublishJSON(ctx context.Context, exchange, routingKey string, message interface{}) error {
 	return c.Publish(ctx, PublishOptions{
@@ -0,0 +1,142 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+// disconnectedClient is a client in a reconnect window.
+func disconnectedClient() *Client {
+	return &Client{logger: zap.NewNop(), done: make(chan struct{}), ready: make(chan struct{})}
+}
+
+func TestPublish_FailsFastWithoutDeadline(t *testing.T) {
+	c := disconnectedClient()
+	if c.IsConnected() {
+		t.Fatal("IsConnected() = true without a connection")
+	}
+
+	start := time.Now()
+	err := c.PublishJSON(context.Background(), "ex", "key", map[string]string{"a": "b"})
+	if !errors.Is(err, ErrNotConnected) {
+		t.Fatalf("err = %v, want ErrNotConnected", err)
+	}
+	if d := time.Since(start); d > 50*time.Millisecond {
+		t.Fatalf("Publish took %v, want an immediate failure", d)
+	}
+}
+
+func TestPublish_WaitsUntilDeadline(t *testing.T) {
+	c := disconnectedClient()
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := c.PublishJSON(ctx, "ex", "key", map[string]string{"a": "b"})
+	if !errors.Is(err, ErrNotConnected) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want ErrNotConnected wrapping the deadline", err)
+	}
+	if d := time.Since(start); d < 150*time.Millisecond {
+		t.Fatalf("Publish gave up after %v, want it to wait for the deadline", d)
+	}
+}
+
+func TestWaitConnected_WakesOnReconnect(t *testing.T) {
+	c := disconnectedClient()
+	go func() {
+		time.Sleep(150 * time.Millisecond)
+		c.markUp()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := c.waitConnected(ctx); err != nil {
+		t.Fatalf("waitConnected: %v", err)
+	}
+
+	// Lost again: waiters block until the next markUp.
+	c.markDown()
+	short, cancelShort := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancelShort()
+	if err := c.waitConnected(short); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("waitConnected after markDown = %v, want deadline exceeded", err)
+	}
+}
+
+func TestWaitConnected_ClientClosed(t *testing.T) {
+	c := disconnectedClient()
+	_ = c.Close()
+	if err := c.waitConnected(context.Background()); err == nil {
+		t.Fatal("waitConnected on a closed client returned nil")
+	}
+}
+
+func TestConsumer_ReattachesAsSoonAsReconnected(t *testing.T) {
+	broker := &fakeBroker{}
+	var up atomic.Bool
+	c := disconnectedClient()
+	c.openChannel = func(ConsumeOptions) (consumerChannel, error) {
+		if !up.Load() {
+			return nil, ErrNotConnected
+		}
+		return broker, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		c.WaitConsumers(context.Background())
+	}()
+
+	handled := make(chan string, 1)
+	if _, err := c.ConsumeWithHandler(ctx, ConsumeOptions{Queue: "jobs"}, func(_ context.Context, msg amqp.Delivery) error {
+		handled <- string(msg.Body)
+		return nil
+	}); err != nil {
+		t.Fatalf("ConsumeWithHandler: %v", err)
+	}
+
+	broker.publish("queued")
+	time.Sleep(100 * time.Millisecond)
+	up.Store(true)
+	c.markUp()
+
+	// Well within reconnectMinBackoff: the consumer waits on the reconnect,
+	// not on its attach backoff.
+	select {
+	case got := <-handled:
+		if got != "queued" {
+			t.Fatalf("handled %q", got)
+		}
+	case <-time.After(reconnectMinBackoff / 2):
+		t.Fatal("consumer did not re-attach promptly after reconnect")
+	}
+}
+
+func TestOnReconnect(t *testing.T) {
+	c := disconnectedClient()
+	var scopes []string
+	c.OnReconnect(func(scope string) { scopes = append(scopes, scope) })
+
+	c.reconnected(ReconnectChannel)
+	c.reconnected(ReconnectConnection)
+
+	if len(scopes) != 2 || scopes[0] != ReconnectChannel || scopes[1] != ReconnectConnection {
+		t.Fatalf("hook saw %v", scopes)
+	}
+}
+
+func TestNew_TLSFilesFailBeforeDialing(t *testing.T) {
+	// Nothing listens on port 1: an error about it would mean New dialed.
+	_, err := New(Config{Host: "127.0.0.1", Port: 1, TLSEnabled: true, TLSCertFile: "client.pem"}, zap.NewNop())
+	if err == nil || !strings.Contains(err.Error(), "rabbitmq TLS") {
+		t.Fatalf("New err = %v, want a rabbitmq TLS error", err)
+	}
+}
@@ -0,0 +1,103 @@
+package rabbitmq
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+func TestWaitConsumers_FinishesInFlightAndRequeuesPrefetched(t *testing.T) {
+	acks := map[string]*recordingAck{"a": {}, "b": {}, "c": {}}
+	broker := &fakeBroker{}
+	for _, body := range []string{"a", "b", "c"} {
+		broker.queue = append(broker.queue, amqp.Delivery{Acknowledger: acks[body], Body: []byte(body)})
+	}
+	c := &Client{
+		logger:      zap.NewNop(),
+		done:        make(chan struct{}),
+		openChannel: func(ConsumeOptions) (consumerChannel, error) { return broker, nil },
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var handlerErr error
+	handled := make(chan string, 3)
+	_, _ = c.ConsumeWithHandler(ctx, ConsumeOptions{Queue: "jobs", ConsumerTag: "w-1"}, func(hctx context.Context, msg amqp.Delivery) error {
+		if string(msg.Body) == "a" {
+			close(started)
+			<-release
+			handlerErr = hctx.Err()
+		}
+		handled <- string(msg.Body)
+		return nil
+	})
+
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("first message never reached the handler")
+	}
+	cancel()
+	time.AfterFunc(20*time.Millisecond, func() { close(release) })
+
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer waitCancel()
+	stats := c.WaitConsumers(waitCtx)
+
+	if want := (DrainStats{Handled: 1, Requeued: 2}); stats != want {
+		t.Fatalf("stats = %+v, want %+v", stats, want)
+	}
+	if handlerErr != nil {
+		t.Fatalf("in-flight handler saw its context canceled: %v", handlerErr)
+	}
+	if len(handled) != 1 {
+		t.Fatalf("handled %d messages, want only the in-flight one", len(handled))
+	}
+	if !acks["a"].acked {
+		t.Error("in-flight message was not acked")
+	}
+	for _, body := range []string{"b", "c"} {
+		if a := acks[body]; !a.nacked || !a.requeued {
+			t.Errorf("prefetched %q: nacked=%v requeued=%v, want both", body, a.nacked, a.requeued)
+		}
+	}
+	broker.mu.Lock()
+	cancels := broker.cancels
+	broker.mu.Unlock()
+	if cancels < 1 {
+		t.Error("consumer tag was not canceled")
+	}
+}
+
+func TestWaitConsumers_ReportsUnfinishedOnTimeout(t *testing.T) {
+	broker := &fakeBroker{}
+	broker.queue = append(broker.queue, amqp.Delivery{Acknowledger: &recordingAck{}, Body: []byte("slow")})
+	c := &Client{
+		logger:      zap.NewNop(),
+		done:        make(chan struct{}),
+		openChannel: func(ConsumeOptions) (consumerChannel, error) { return broker, nil },
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	_, _ = c.ConsumeWithHandler(ctx, ConsumeOptions{Queue: "jobs", ConsumerTag: "w-1"}, func(context.Context, amqp.Delivery) error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+	cancel()
+
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer waitCancel()
+	if stats := c.WaitConsumers(waitCtx); stats.Unfinished != 1 || stats.Handled != 0 {
+		t.Fatalf("stats = %+v, want 1 unfinished", stats)
+	}
+}
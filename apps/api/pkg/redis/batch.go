@@ -0,0 +1,161 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// pipelineCommand is the command name the metrics and slow log use for a
+// flushed pipeline.
+const pipelineCommand = "PIPELINE"
+
+// Pipeline runs the commands build queues with conn.Send in one round trip
+// and returns their replies in order. build must only call Send: Do would
+// flush the pipeline early. A command the server rejects has a redis.Error
+// as its reply; err reports only build and connection failures. In cluster
+// mode the pipeline runs on the node of its first key, so all its keys must
+// share a hash slot.
+func (c *Client) Pipeline(ctx context.Context, build func(conn redis.Conn) error) ([]interface{}, error) {
+	ctx, span := tracer.Start(ctx, "redis.Pipeline")
+	defer span.End()
+
+	conn := c.pool.Get()
+	// Close receives anything build queued before failing.
+	defer conn.Close() //nolint:errcheck // best-effort cleanup
+
+	if err := build(conn); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	replies, err := redis.Values(c.do(ctx, conn, ""))
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("redis.command_count", len(replies)))
+	return replies, nil
+}
+
+// MGet reads keys in one MGET round trip (one per hash slot in cluster mode)
+// and unmarshals their values into
+// dest, which must point to a slice. The slice is set to one element per
+// key, in order; a missing key leaves its element the zero value, so use a
+// slice of pointers to tell misses apart.
+func (c *Client) MGet(ctx context.Context, keys []string, dest interface{}) error {
+	ctx, span := tracer.Start(ctx, "redis.MGet",
+		trace.WithAttributes(attribute.Int("redis.key_count", len(keys))))
+	defer span.End()
+
+	out := reflect.ValueOf(dest)
+	if out.Kind() != reflect.Pointer || out.Elem().Kind() != reflect.Slice {
+		err := fmt.Errorf("redis: MGet dest must be a pointer to a slice, got %T", dest)
+		span.RecordError(err)
+		return err
+	}
+	slice := reflect.MakeSlice(out.Elem().Type(), len(keys), len(keys))
+	if len(keys) == 0 {
+		out.Elem().Set(slice)
+		return nil
+	}
+
+	err := c.eachSlot(keys, func(conn redis.Conn, idx []int) error {
+		values, err := redis.ByteSlices(c.do(ctx, conn, "MGET", keyArgs(keys, idx)...))
+		if err != nil {
+			return err
+		}
+		for i, data := range values {
+			if data == nil {
+				continue
+			}
+			j := idx[i]
+			if err := json.Unmarshal(data, slice.Index(j).Addr().Interface()); err != nil {
+				return fmt.Errorf("unmarshal %s: %w", keys[j], err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+	out.Elem().Set(slice)
+	return nil
+}
+
+// MSet stores values JSON-encoded, like Set, in one round trip. With an
+// expiration every key is written by SETEX inside MULTI/EXEC, since MSET
+// cannot set one; either way all keys are written or none. In cluster mode
+// that holds per hash slot, each written by its own command.
+func (c *Client) MSet(ctx context.Context, values map[string]interface{}, expiration time.Duration) error {
+	ctx, span := tracer.Start(ctx, "redis.MSet",
+		trace.WithAttributes(attribute.Int("redis.key_count", len(values))))
+	defer span.End()
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	encoded := make([][]byte, len(keys))
+	for i, key := range keys {
+		data, err := json.Marshal(values[key])
+		if err != nil {
+			span.RecordError(err)
+			return fmt.Errorf("failed to marshal value for %s: %w", key, err)
+		}
+		encoded[i] = data
+	}
+
+	err := c.eachSlot(keys, func(conn redis.Conn, idx []int) error {
+		if expiration > 0 {
+			return c.msetEx(ctx, conn, keys, encoded, idx, int(expiration.Seconds()))
+		}
+		args := make([]interface{}, 0, 2*len(idx))
+		for _, i := range idx {
+			args = append(args, keys[i], encoded[i])
+		}
+		_, err := c.do(ctx, conn, "MSET", args...)
+		return err
+	})
+	if err != nil {
+		span.RecordError(err)
+	}
+	return err
+}
+
+// msetEx queues MULTI, a SETEX per key at idx and EXEC, and sends them
+// together.
+func (c *Client) msetEx(ctx context.Context, conn redis.Conn, keys []string, encoded [][]byte, idx []int, seconds int) error {
+	if err := conn.Send("MULTI"); err != nil {
+		return err
+	}
+	for _, i := range idx {
+		if err := conn.Send("SETEX", keys[i], seconds, encoded[i]); err != nil {
+			return err
+		}
+	}
+	// EXEC's reply is the last of the pipeline; a rejected SETEX aborts the
+	// transaction and fails EXEC.
+	replies, err := redis.Values(c.do(ctx, conn, "EXEC"))
+	if err != nil {
+		return err
+	}
+	for _, r := range replies {
+		if e, ok := r.(redis.Error); ok {
+			return e
+		}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"veemon/pkg/metrics"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+type profile struct {
+	Name string `json:"name"`
+}
+
+func TestPipeline_ReturnsRepliesInOrder(t *testing.T) {
+	c := newTestClient(t, -1, nil)
+	ctx := context.Background()
+	m := metrics.New("test")
+	c.EnableMetrics(m, 0)
+
+	replies, err := c.Pipeline(ctx, func(conn redis.Conn) error {
+		for _, cmd := range [][]interface{}{
+			{"SET", "p:a", "1"},
+			{"INCR", "p:a"},
+			{"HSET", "p:a", "f", "v"}, // wrong type
+			{"GET", "p:a"},
+		} {
+			if err := conn.Send(cmd[0].(string), cmd[1:]...); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Pipeline: %v", err)
+	}
+	if len(replies) != 4 {
+		t.Fatalf("got %d replies, want 4", len(replies))
+	}
+	if n, err := redis.Int64(replies[1], nil); err != nil || n != 2 {
+		t.Errorf("INCR reply = %v, %v; want 2", n, err)
+	}
+	if _, ok := replies[2].(redis.Error); !ok {
+		t.Errorf("HSET on a string reply = %#v, want a redis.Error", replies[2])
+	}
+	if s, err := redis.String(replies[3], nil); err != nil || s != "2" {
+		t.Errorf("GET reply = %q, %v; want 2", s, err)
+	}
+	if body := scrape(t, m); !strings.Contains(body, `test_redis_command_duration_seconds_count{command="PIPELINE"} 1`) {
+		t.Errorf("pipeline not observed:\n%s", body)
+	}
+}
+
+func TestPipeline_BuildErrorIsReturned(t *testing.T) {
+	c := newTestClient(t, -1, nil)
+	ctx := context.Background()
+	boom := errors.New("boom")
+
+	_, err := c.Pipeline(ctx, func(conn redis.Conn) error {
+		_ = conn.Send("SET", "p:b", "1")
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("Pipeline err = %v, want %v", err, boom)
+	}
+	// The abandoned command must not leak into the next user of the
+	// connection.
+	if ok, err := c.Exists(ctx, "p:missing"); err != nil || ok {
+		t.Fatalf("Exists after abandoned pipeline = %v, %v", ok, err)
+	}
+}
+
+func TestMSetMGet(t *testing.T) {
+	c := newTestClient(t, -1, nil)
+	ctx := context.Background()
+
+	if err := c.MSet(ctx, map[string]interface{}{
+		"u:1": profile{Name: "Ann"},
+		"u:2": profile{Name: "Bob"},
+	}, 0); err != nil {
+		t.Fatalf("MSet: %v", err)
+	}
+
+	var got []*profile
+	if err := c.MGet(ctx, []string{"u:2", "u:missing", "u:1"}, &got); err != nil {
+		t.Fatalf("MGet: %v", err)
+	}
+	if len(got) != 3 || got[0] == nil || got[0].Name != "Bob" || got[1] != nil || got[2] == nil || got[2].Name != "Ann" {
+		t.Fatalf("MGet = %+v, want [Bob <nil> Ann]", got)
+	}
+
+	var values []profile
+	if err := c.MGet(ctx, []string{"u:missing", "u:1"}, &values); err != nil {
+		t.Fatalf("MGet values: %v", err)
+	}
+	if len(values) != 2 || values[0] != (profile{}) || values[1].Name != "Ann" {
+		t.Fatalf("MGet values = %+v", values)
+	}
+}
+
+func TestMSet_WithExpirationSetsTTL(t *testing.T) {
+	c := newTestClient(t, -1, nil)
+	ctx := context.Background()
+
+	if err := c.MSet(ctx, map[string]interface{}{"t:1": 1, "t:2": 2}, time.Minute); err != nil {
+		t.Fatalf("MSet: %v", err)
+	}
+
+	conn := c.Conn()
+	defer conn.Close() //nolint:errcheck // best-effort cleanup
+	for _, key := range []string{"t:1", "t:2"} {
+		ttl, err := redis.Int(conn.Do("TTL", key))
+		if err != nil || ttl <= 0 || ttl > 60 {
+			t.Errorf("TTL %s = %d, %v; want (0, 60]", key, ttl, err)
+		}
+	}
+	var got []int
+	if err := c.MGet(ctx, []string{"t:1", "t:2"}, &got); err != nil || len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Fatalf("MGet = %v, %v; want [1 2]", got, err)
+	}
+}
+
+func TestMGet_RejectsNonSliceDest(t *testing.T) {
+	c := newTestClient(t, -1, nil)
+
+	var dest profile
+	if err := c.MGet(context.Background(), []string{"k"}, &dest); err == nil {
+		t.Fatal("MGet into a struct succeeded")
+	}
+}
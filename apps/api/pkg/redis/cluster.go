@@ -0,0 +1,592 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// clusterSlots is the number of hash slots a Redis Cluster shards keys into.
+const clusterSlots = 16384
+
+// maxRedirects bounds how many MOVED replies one command follows.
+const maxRedirects = 3
+
+// clusterPool keeps a pool per cluster node and a map of which node serves
+// each hash slot, loaded from CLUSTER SLOTS and corrected by MOVED replies.
+type clusterPool struct {
+	seeds   []string
+	newPool func(addr string) *redis.Pool
+
+	mu     sync.RWMutex
+	nodes  map[string]*redis.Pool
+	slots  []string // slot -> master address
+	closed bool
+
+	refreshing atomic.Bool
+}
+
+func newClusterPool(seeds []string, newPool func(addr string) *redis.Pool) (*clusterPool, error) {
+	p := &clusterPool{
+		seeds:   seeds,
+		newPool: newPool,
+		nodes:   make(map[string]*redis.Pool),
+		slots:   make([]string, clusterSlots),
+	}
+	if err := p.refresh(); err != nil {
+		_ = p.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// refresh reloads the slot map from the first known node that serves it.
+func (p *clusterPool) refresh() error {
+	var errs []error
+	for _, addr := range p.candidates() {
+		slots, err := p.loadSlots(addr)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", addr, err))
+			continue
+		}
+		p.mu.Lock()
+		p.slots = slots
+		p.mu.Unlock()
+		return nil
+	}
+	return fmt.Errorf("redis: no cluster node returned the slot map: %w", errors.Join(errs...))
+}
+
+// refreshAsync reloads the slot map in the background, once at a time.
+func (p *clusterPool) refreshAsync() {
+	if !p.refreshing.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer p.refreshing.Store(false)
+		_ = p.refresh()
+	}()
+}
+
+// candidates lists the known masters, then any seed not among them.
+func (p *clusterPool) candidates() []string {
+	addrs := p.masters()
+	seen := make(map[string]bool, len(addrs))
+	for _, addr := range addrs {
+		seen[addr] = true
+	}
+	for _, addr := range p.seeds {
+		if !seen[addr] {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
+// loadSlots reads CLUSTER SLOTS from the node at addr. Each entry is
+// [start, end, [host, port, id], replicas...]; an empty host means addr's.
+func (p *clusterPool) loadSlots(addr string) ([]string, error) {
+	conn := p.node(addr).Get()
+	defer conn.Close() //nolint:errcheck // best-effort cleanup
+
+	ranges, err := redis.Values(conn.Do("CLUSTER", "SLOTS"))
+	if err != nil {
+		return nil, err
+	}
+	slots := make([]string, clusterSlots)
+	for _, r := range ranges {
+		entry, err := redis.Values(r, nil)
+		if err != nil {
+			return nil, err
+		}
+		var start, end int
+		var master interface{}
+		if _, err := redis.Scan(entry, &start, &end, &master); err != nil {
+			return nil, err
+		}
+		node, err := redis.Values(master, nil)
+		if err != nil {
+			return nil, err
+		}
+		var host string
+		var port int
+		if _, err := redis.Scan(node, &host, &port); err != nil {
+			return nil, err
+		}
+		if host == "" {
+			host, _, _ = net.SplitHostPort(addr)
+		}
+		if start < 0 || end >= clusterSlots || start > end {
+			return nil, fmt.Errorf("slot range %d-%d out of bounds", start, end)
+		}
+		owner := net.JoinHostPort(host, strconv.Itoa(port))
+		for s := start; s <= end; s++ {
+			slots[s] = owner
+		}
+	}
+	return slots, nil
+}
+
+// node returns the pool of the node at addr, creating it on first use.
+func (p *clusterPool) node(addr string) *redis.Pool {
+	p.mu.RLock()
+	pool, ok := p.nodes[addr]
+	p.mu.RUnlock()
+	if ok {
+		return pool
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if pool, ok := p.nodes[addr]; ok {
+		return pool
+	}
+	pool = p.newPool(addr)
+	if p.closed {
+		// Its connections fail with "get on closed pool".
+		_ = pool.Close()
+		return pool
+	}
+	p.nodes[addr] = pool
+	return pool
+}
+
+// addrOf returns the address of the master serving key's slot.
+func (p *clusterPool) addrOf(key string) string {
+	p.mu.RLock()
+	addr := p.slots[slotOf(key)]
+	p.mu.RUnlock()
+	if addr == "" {
+		// An unserved slot: any node will redirect.
+		return p.anyAddr()
+	}
+	return addr
+}
+
+// anyAddr returns a node address for keyless commands.
+func (p *clusterPool) anyAddr() string {
+	if masters := p.masters(); len(masters) > 0 {
+		return masters[0]
+	}
+	return p.seeds[0]
+}
+
+// masters lists the addresses of the masters serving slots, sorted.
+func (p *clusterPool) masters() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	seen := make(map[string]bool)
+	var addrs []string
+	for _, addr := range p.slots {
+		if addr != "" && !seen[addr] {
+			seen[addr] = true
+			addrs = append(addrs, addr)
+		}
+	}
+	sort.Strings(addrs)
+	return addrs
+}
+
+// redirection reports whether err is a MOVED or ASK reply, and to where. A
+// MOVED reply also updates the slot map.
+func (p *clusterPool) redirection(err error) (ask bool, addr string, ok bool) {
+	var rerr redis.Error
+	if !errors.As(err, &rerr) {
+		return false, "", false
+	}
+	// "MOVED 3999 127.0.0.1:6381" or "ASK 3999 127.0.0.1:6381"
+	fields := strings.Fields(string(rerr))
+	if len(fields) != 3 || (fields[0] != "MOVED" && fields[0] != "ASK") {
+		return false, "", false
+	}
+	slot, convErr := strconv.Atoi(fields[1])
+	if convErr != nil || slot < 0 || slot >= clusterSlots {
+		return false, "", false
+	}
+	if fields[0] == "ASK" {
+		return true, fields[2], true
+	}
+	p.mu.Lock()
+	p.slots[slot] = fields[2]
+	p.mu.Unlock()
+	// One slot moving usually means a resharding or failover moved more.
+	p.refreshAsync()
+	return false, fields[2], true
+}
+
+func (p *clusterPool) Get() redis.Conn {
+	return &clusterConn{pool: p}
+}
+
+// GetContext returns a connection whose node connection, taken when its
+// first command is sent, waits for a free slot in the node's pool no longer
+// than ctx allows.
+func (p *clusterPool) GetContext(ctx context.Context) (redis.Conn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &clusterConn{pool: p, ctx: ctx}, nil
+}
+
+// Stats sums the stats of every node's pool.
+func (p *clusterPool) Stats() redis.PoolStats {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	var total redis.PoolStats
+	for _, pool := range p.nodes {
+		s := pool.Stats()
+		total.ActiveCount += s.ActiveCount
+		total.IdleCount += s.IdleCount
+		total.WaitCount += s.WaitCount
+		total.WaitDuration += s.WaitDuration
+	}
+	return total
+}
+
+func (p *clusterPool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	var errs []error
+	for _, pool := range p.nodes {
+		errs = append(errs, pool.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// queuedCommand is a command sent before its connection was bound to a node.
+type queuedCommand struct {
+	name string
+	args []interface{}
+}
+
+// clusterConn is a connection bound lazily to the node serving the first
+// key it is sent, so a pipeline or transaction runs where its keys live.
+// Keyless commands sent before that (MULTI, SUBSCRIBE) are held back and
+// replayed once it binds; a connection only ever sent keyless commands
+// binds to any master. All keys on one connection must share a hash slot.
+type clusterConn struct {
+	pool   *clusterPool
+	ctx    context.Context // bounds taking the node connection; nil for Get
+	conn   redis.Conn
+	queued []queuedCommand
+	err    error
+}
+
+func (c *clusterConn) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
+
+func (c *clusterConn) Err() error {
+	if c.err != nil {
+		return c.err
+	}
+	if c.conn != nil {
+		return c.conn.Err()
+	}
+	return nil
+}
+
+func (c *clusterConn) Send(cmd string, args ...interface{}) error {
+	if c.conn == nil {
+		key, keyed := keyOf(cmd, args)
+		if !keyed {
+			c.queued = append(c.queued, queuedCommand{name: cmd, args: args})
+			return nil
+		}
+		if err := c.bind(c.pool.addrOf(key)); err != nil {
+			return err
+		}
+	}
+	err := c.conn.Send(cmd, args...)
+	c.noteMoved(err)
+	return err
+}
+
+func (c *clusterConn) Flush() error {
+	if c.conn == nil {
+		if len(c.queued) == 0 {
+			return nil
+		}
+		if err := c.bind(c.pool.anyAddr()); err != nil {
+			return err
+		}
+	}
+	return c.conn.Flush()
+}
+
+func (c *clusterConn) Receive() (interface{}, error) {
+	return c.receive(func(conn redis.Conn) (interface{}, error) {
+		return conn.Receive()
+	})
+}
+
+func (c *clusterConn) ReceiveWithTimeout(timeout time.Duration) (interface{}, error) {
+	return c.receive(func(conn redis.Conn) (interface{}, error) {
+		return redis.ReceiveWithTimeout(conn, timeout)
+	})
+}
+
+func (c *clusterConn) ReceiveContext(ctx context.Context) (interface{}, error) {
+	return c.receive(func(conn redis.Conn) (interface{}, error) {
+		return redis.ReceiveContext(conn, ctx)
+	})
+}
+
+func (c *clusterConn) receive(run func(redis.Conn) (interface{}, error)) (interface{}, error) {
+	if c.conn == nil {
+		if err := c.bind(c.pool.anyAddr()); err != nil {
+			return nil, err
+		}
+	}
+	reply, err := run(c.conn)
+	c.noteMoved(err)
+	return reply, err
+}
+
+func (c *clusterConn) Do(cmd string, args ...interface{}) (interface{}, error) {
+	return c.do(cmd, args, func(conn redis.Conn) (interface{}, error) {
+		return conn.Do(cmd, args...)
+	})
+}
+
+func (c *clusterConn) DoWithTimeout(timeout time.Duration, cmd string, args ...interface{}) (interface{}, error) {
+	return c.do(cmd, args, func(conn redis.Conn) (interface{}, error) {
+		return redis.DoWithTimeout(conn, timeout, cmd, args...)
+	})
+}
+
+func (c *clusterConn) DoContext(ctx context.Context, cmd string, args ...interface{}) (interface{}, error) {
+	return c.do(cmd, args, func(conn redis.Conn) (interface{}, error) {
+		return redis.DoContext(conn, ctx, cmd, args...)
+	})
+}
+
+// do binds the connection for cmd if needed and runs it. Only a command
+// that is the first on its connection follows redirections: one inside a
+// pipeline or transaction cannot be replayed alone.
+func (c *clusterConn) do(cmd string, args []interface{}, run func(redis.Conn) (interface{}, error)) (interface{}, error) {
+	if c.conn == nil {
+		key, keyed := keyOf(cmd, args)
+		if !keyed && strings.EqualFold(cmd, "MULTI") {
+			// Held back until the transaction's first key is known.
+			c.queued = append(c.queued, queuedCommand{name: cmd})
+			return "OK", nil
+		}
+		addr := c.pool.anyAddr()
+		if keyed {
+			addr = c.pool.addrOf(key)
+		}
+		first := len(c.queued) == 0
+		if err := c.bind(addr); err != nil {
+			return nil, err
+		}
+		if first {
+			return c.redirect(run)
+		}
+	}
+	reply, err := run(c.conn)
+	c.noteMoved(err)
+	return reply, err
+}
+
+// redirect runs the connection's first command, following MOVED by
+// rebinding to the named node and ASK by running it once there.
+func (c *clusterConn) redirect(run func(redis.Conn) (interface{}, error)) (interface{}, error) {
+	for i := 0; ; i++ {
+		reply, err := run(c.conn)
+		ask, addr, ok := c.pool.redirection(err)
+		if !ok || i == maxRedirects {
+			return reply, err
+		}
+		if ask {
+			conn, err := c.get(addr)
+			if err != nil {
+				return nil, err
+			}
+			defer conn.Close() //nolint:errcheck // best-effort cleanup
+			if err := conn.Send("ASKING"); err != nil {
+				return nil, err
+			}
+			return run(conn)
+		}
+		_ = c.conn.Close()
+		c.conn = nil
+		if err := c.bind(addr); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// noteMoved updates the slot map from a MOVED reply the caller gets back.
+func (c *clusterConn) noteMoved(err error) {
+	c.pool.redirection(err)
+}
+
+// bind takes a connection to the node at addr and sends it the held-back
+// commands.
+func (c *clusterConn) bind(addr string) error {
+	conn, err := c.get(addr)
+	if err != nil {
+		c.err = err
+		return err
+	}
+	c.conn = conn
+	for _, q := range c.queued {
+		if err := conn.Send(q.name, q.args...); err != nil {
+			return err
+		}
+	}
+	c.queued = nil
+	return nil
+}
+
+func (c *clusterConn) get(addr string) (redis.Conn, error) {
+	if c.ctx != nil {
+		return c.pool.node(addr).GetContext(c.ctx)
+	}
+	conn := c.pool.node(addr).Get()
+	if err := conn.Err(); err != nil {
+		conn.Close() //nolint:errcheck // best-effort cleanup
+		return nil, err
+	}
+	return conn, nil
+}
+
+// keyOf returns the key a command routes by: its first argument, or the
+// first key of EVAL and EVALSHA. Server, connection, transaction and pub/sub
+// commands have none.
+func keyOf(cmd string, args []interface{}) (string, bool) {
+	i := 0
+	switch strings.ToUpper(cmd) {
+	case "", "PING", "ECHO", "AUTH", "SELECT", "ASKING", "ROLE", "INFO", "CLUSTER",
+		"MULTI", "EXEC", "DISCARD", "SCAN", "DBSIZE", "FLUSHDB", "FLUSHALL",
+		"PUBLISH", "SUBSCRIBE", "UNSUBSCRIBE", "PSUBSCRIBE", "PUNSUBSCRIBE":
+		return "", false
+	case "EVAL", "EVALSHA":
+		if len(args) < 3 || fmt.Sprint(args[1]) == "0" {
+			return "", false
+		}
+		i = 2
+	}
+	if len(args) <= i {
+		return "", false
+	}
+	switch k := args[i].(type) {
+	case string:
+		return k, true
+	case []byte:
+		return string(k), true
+	default:
+		return fmt.Sprint(k), true
+	}
+}
+
+// slotOf returns key's hash slot: the CRC16 of its hash tag, the part
+// between the first { and the next }, if non-empty, else of the whole key.
+func slotOf(key string) int {
+	if i := strings.IndexByte(key, '{'); i >= 0 {
+		if j := strings.IndexByte(key[i+1:], '}'); j > 0 {
+			key = key[i+1 : i+1+j]
+		}
+	}
+	return int(crc16(key)) % clusterSlots
+}
+
+// crc16 is CRC-16/XMODEM, the checksum Redis Cluster hashes keys with.
+func crc16(s string) uint16 {
+	var crc uint16
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+		for b := 0; b < 8; b++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// slotGroups splits the indexes of keys by hash slot in cluster mode, in
+// order of first appearance, so each group can go in one command; outside
+// cluster mode all keys form one group.
+func (c *Client) slotGroups(keys []string) [][]int {
+	if _, ok := c.pool.(*clusterPool); !ok {
+		all := make([]int, len(keys))
+		for i := range all {
+			all[i] = i
+		}
+		return [][]int{all}
+	}
+	bySlot := make(map[int]int)
+	var groups [][]int
+	for i, key := range keys {
+		slot := slotOf(key)
+		g, ok := bySlot[slot]
+		if !ok {
+			g = len(groups)
+			bySlot[slot] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+	return groups
+}
+
+// eachSlot calls fn with a connection for each group of slotGroups,
+// stopping at the first error.
+func (c *Client) eachSlot(keys []string, fn func(conn redis.Conn, idx []int) error) error {
+	for _, idx := range c.slotGroups(keys) {
+		if err := c.withConn(func(conn redis.Conn) error { return fn(conn, idx) }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// eachNode calls fn with a connection to every master in cluster mode, or
+// once with a pooled connection otherwise, stopping at the first error.
+func (c *Client) eachNode(fn func(conn redis.Conn) error) error {
+	cp, ok := c.pool.(*clusterPool)
+	if !ok {
+		return c.withConn(fn)
+	}
+	for _, addr := range cp.masters() {
+		conn := cp.node(addr).Get()
+		err := fn(conn)
+		conn.Close() //nolint:errcheck // best-effort cleanup
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) withConn(fn func(conn redis.Conn) error) error {
+	conn := c.pool.Get()
+	defer conn.Close() //nolint:errcheck // best-effort cleanup
+	return fn(conn)
+}
+
+// keyArgs returns the keys at idx as command arguments.
+func keyArgs(keys []string, idx []int) []interface{} {
+	args := make([]interface{}, len(idx))
+	for i, j := range idx {
+		args[i] = keys[j]
+	}
+	return args
+}
@@ -0,0 +1,298 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/alicebob/miniredis/v2/server"
+)
+
+// fakeCluster is two miniredis nodes sharing the hash slots: those below
+// split are served by nodes[0], the rest by nodes[1]. Each answers CLUSTER
+// SLOTS and redirects keys it does not serve with MOVED.
+type fakeCluster struct {
+	nodes [2]*miniredis.Miniredis
+	addrs [2]string
+
+	mu    sync.Mutex
+	split int
+}
+
+func newFakeCluster(t *testing.T) *fakeCluster {
+	t.Helper()
+	fc := &fakeCluster{split: clusterSlots / 2}
+	for i := range fc.nodes {
+		fc.nodes[i] = miniredis.RunT(t)
+		// Read once: a background slot refresh may outlive the test.
+		fc.addrs[i] = fc.nodes[i].Addr()
+	}
+	for i, mr := range fc.nodes {
+		mr.Server().SetPreHook(func(peer *server.Peer, cmd string, args ...string) bool {
+			return fc.hook(i, peer, cmd, args)
+		})
+	}
+	return fc
+}
+
+func (fc *fakeCluster) setSplit(split int) {
+	fc.mu.Lock()
+	fc.split = split
+	fc.mu.Unlock()
+}
+
+func (fc *fakeCluster) owner(slot int) int {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	if slot < fc.split {
+		return 0
+	}
+	return 1
+}
+
+func (fc *fakeCluster) hook(node int, peer *server.Peer, cmd string, args []string) bool {
+	if cmd == "CLUSTER" && len(args) == 1 && args[0] == "SLOTS" {
+		fc.mu.Lock()
+		split := fc.split
+		fc.mu.Unlock()
+		peer.WriteLen(2)
+		for i, r := range [][2]int{{0, split - 1}, {split, clusterSlots - 1}} {
+			peer.WriteLen(3)
+			peer.WriteInt(r[0])
+			peer.WriteInt(r[1])
+			peer.WriteLen(3)
+			host, port, _ := net.SplitHostPort(fc.addrs[i])
+			peer.WriteBulk(host)
+			p, _ := strconv.Atoi(port)
+			peer.WriteInt(p)
+			peer.WriteBulk(fmt.Sprintf("node%d", i))
+		}
+		return true
+	}
+	iargs := make([]interface{}, len(args))
+	for i, a := range args {
+		iargs[i] = a
+	}
+	key, keyed := keyOf(cmd, iargs)
+	if !keyed {
+		return false
+	}
+	if slot := slotOf(key); fc.owner(slot) != node {
+		peer.WriteError(fmt.Sprintf("MOVED %d %s", slot, fc.addrs[1-node]))
+		return true
+	}
+	return false
+}
+
+// keyOn returns a key with the given prefix served by node.
+func (fc *fakeCluster) keyOn(t *testing.T, prefix string, node int) string {
+	t.Helper()
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("%s%d", prefix, i)
+		if fc.owner(slotOf(key)) == node {
+			return key
+		}
+	}
+	t.Fatalf("no %s key on node %d", prefix, node)
+	return ""
+}
+
+func newClusterClient(t *testing.T, fc *fakeCluster) *Client {
+	t.Helper()
+	// Only one seed: the other node is found through CLUSTER SLOTS.
+	c, err := New(Config{Mode: ModeCluster, ClusterAddrs: []string{fc.addrs[0]}, MaxIdle: 2, MaxActive: 4, SlowThreshold: -1})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func TestCluster_RoutesKeysToTheirNode(t *testing.T) {
+	fc := newFakeCluster(t)
+	c := newClusterClient(t, fc)
+	ctx := context.Background()
+	a, b := fc.keyOn(t, "k:", 0), fc.keyOn(t, "k:", 1)
+
+	if err := c.Ping(ctx); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	for _, key := range []string{a, b} {
+		if err := c.Set(ctx, key, key, 0); err != nil {
+			t.Fatalf("Set %s: %v", key, err)
+		}
+	}
+	if !fc.nodes[0].Exists(a) || !fc.nodes[1].Exists(b) {
+		t.Fatal("keys not stored on the nodes serving their slots")
+	}
+
+	var got []string
+	if err := c.MGet(ctx, []string{b, "k:missing", a}, &got); err != nil {
+		t.Fatalf("MGet: %v", err)
+	}
+	if fmt.Sprint(got) != fmt.Sprintf("[%s  %s]", b, a) {
+		t.Errorf("MGet = %q", got)
+	}
+	exists, err := c.ExistsMany(ctx, a, b)
+	if err != nil || !exists[0] || !exists[1] {
+		t.Errorf("ExistsMany = %v, %v; want [true true]", exists, err)
+	}
+	if err := c.MSet(ctx, map[string]interface{}{a: 1, b: 2}, time.Minute); err != nil {
+		t.Fatalf("MSet: %v", err)
+	}
+	if fc.nodes[0].TTL(a) != time.Minute || fc.nodes[1].TTL(b) != time.Minute {
+		t.Error("MSet did not set the TTL on both nodes")
+	}
+	if err := c.Delete(ctx, a, b); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if fc.nodes[0].Exists(a) || fc.nodes[1].Exists(b) {
+		t.Error("Delete left keys behind")
+	}
+}
+
+func TestCluster_ScansEveryNode(t *testing.T) {
+	fc := newFakeCluster(t)
+	c := newClusterClient(t, fc)
+	ctx := context.Background()
+	for i := 0; i < 20; i++ {
+		if err := c.Set(ctx, fmt.Sprintf("users:list:%d", i), i, 0); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+	if len(fc.nodes[0].Keys()) == 0 || len(fc.nodes[1].Keys()) == 0 {
+		t.Fatal("keys all landed on one node")
+	}
+
+	n, err := c.DeleteByPattern(ctx, "users:list:*")
+	if err != nil || n != 20 {
+		t.Fatalf("DeleteByPattern = %d, %v; want 20", n, err)
+	}
+	if len(fc.nodes[0].Keys())+len(fc.nodes[1].Keys()) != 0 {
+		t.Error("keys survived DeleteByPattern")
+	}
+}
+
+func TestCluster_FollowsMoved(t *testing.T) {
+	fc := newFakeCluster(t)
+	c := newClusterClient(t, fc)
+	ctx := context.Background()
+	key := fc.keyOn(t, "k:", 1)
+
+	// Move every slot to nodes[0] behind the client's back.
+	fc.setSplit(clusterSlots)
+	if err := c.Set(ctx, key, "v", 0); err != nil {
+		t.Fatalf("Set after resharding: %v", err)
+	}
+	if !fc.nodes[0].Exists(key) || fc.nodes[1].Exists(key) {
+		t.Fatal("Set did not follow MOVED to the new owner")
+	}
+	var v string
+	if err := c.Get(ctx, key, &v); err != nil || v != "v" {
+		t.Fatalf("Get = %q, %v", v, err)
+	}
+}
+
+func TestSlotOf(t *testing.T) {
+	if got := crc16("123456789"); got != 0x31C3 {
+		t.Errorf("crc16 = %#x, want 0x31c3", got)
+	}
+	if got := slotOf("foo"); got != 12182 {
+		t.Errorf("slotOf(foo) = %d, want 12182", got)
+	}
+	if slotOf("{user1000}.following") != slotOf("user1000") {
+		t.Error("hash tag ignored")
+	}
+	if slotOf("foo{}{bar}") != int(crc16("foo{}{bar}"))%clusterSlots {
+		t.Error("empty hash tag used")
+	}
+}
+
+// fakeSentinel serves SENTINEL get-master-addr-by-name for master, naming
+// mr.
+func fakeSentinel(t *testing.T, master string, mr *miniredis.Miniredis) string {
+	t.Helper()
+	srv, err := server.NewServer("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("sentinel: %v", err)
+	}
+	t.Cleanup(srv.Close)
+	host, port := mr.Host(), mr.Port()
+	err = srv.Register("SENTINEL", func(peer *server.Peer, _ string, args []string) {
+		if len(args) != 2 || args[0] != "get-master-addr-by-name" || args[1] != master {
+			peer.WriteNull()
+			return
+		}
+		peer.WriteStrings([]string{host, port})
+	})
+	if err != nil {
+		t.Fatalf("register SENTINEL: %v", err)
+	}
+	return srv.Addr().String()
+}
+
+// withRole makes mr answer ROLE with role.
+func withRole(mr *miniredis.Miniredis, role string) {
+	mr.Server().SetPreHook(func(peer *server.Peer, cmd string, _ ...string) bool {
+		if cmd != "ROLE" {
+			return false
+		}
+		peer.WriteLen(3)
+		peer.WriteBulk(role)
+		peer.WriteInt(0)
+		peer.WriteLen(0)
+		return true
+	})
+}
+
+func TestSentinel_ConnectsToTheMaster(t *testing.T) {
+	mr := miniredis.RunT(t)
+	withRole(mr, "master")
+	down := miniredis.RunT(t)
+	downAddr := down.Addr()
+	down.Close()
+
+	c, err := New(Config{
+		Mode:           ModeSentinel,
+		SentinelMaster: "mymaster",
+		// The first sentinel is down; the second answers.
+		SentinelAddrs: []string{downAddr, fakeSentinel(t, "mymaster", mr)},
+		SlowThreshold: -1,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer c.Close() //nolint:errcheck // best-effort cleanup
+
+	if err := c.Set(context.Background(), "k", "v", 0); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if !mr.Exists("k") {
+		t.Error("key not written to the master")
+	}
+}
+
+func TestSentinel_RejectsADemotedMaster(t *testing.T) {
+	mr := miniredis.RunT(t)
+	withRole(mr, "slave")
+
+	_, err := New(Config{
+		Mode:           ModeSentinel,
+		SentinelMaster: "mymaster",
+		SentinelAddrs:  []string{fakeSentinel(t, "mymaster", mr)},
+	})
+	if err == nil {
+		t.Fatal("New connected to a replica")
+	}
+}
+
+func TestNew_RejectsUnknownMode(t *testing.T) {
+	if _, err := New(Config{Mode: "replicated"}); err == nil {
+		t.Fatal("New accepted an unknown mode")
+	}
+}
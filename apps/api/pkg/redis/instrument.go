@@ -0,0 +1,117 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// DefaultSlowThreshold is the slow-command threshold used when the config
+// leaves it unset.
+const DefaultSlowThreshold = 50 * time.Millisecond
+
+// DefaultStatsInterval is how often pool stats are published when
+// EnableMetrics is given a non-positive interval.
+const DefaultStatsInterval = 15 * time.Second
+
+// Recorder receives Redis metrics. *metrics.Metrics satisfies it.
+type Recorder interface {
+	ObserveRedisCommand(command string, duration time.Duration)
+	RecordRedisPoolStats(active, idle int, waits int64, waitTime time.Duration)
+}
+
+type recorderBox struct{ Recorder }
+
+// EnableMetrics starts publishing command durations and pool stats to rec,
+// collecting the stats every interval until Close. Until it is called the
+// command path records nothing, so a binary without metrics pays nothing.
+func (c *Client) EnableMetrics(rec Recorder, interval time.Duration) {
+	if rec == nil {
+		return
+	}
+	if interval <= 0 {
+		interval = DefaultStatsInterval
+	}
+	if !c.recorder.CompareAndSwap(nil, &recorderBox{rec}) {
+		return // already enabled
+	}
+
+	c.collectPoolStats()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				c.collectPoolStats()
+			case <-c.done:
+				return
+			}
+		}
+	}()
+}
+
+// collectPoolStats publishes the pool's current occupancy and the wait
+// totals accrued since the previous collection.
+func (c *Client) collectPoolStats() {
+	box := c.recorder.Load()
+	if box == nil {
+		return
+	}
+	s := c.pool.Stats()
+
+	c.statsMu.Lock()
+	waits := s.WaitCount - c.lastWaitCount
+	waitTime := s.WaitDuration - c.lastWaitDuration
+	c.lastWaitCount, c.lastWaitDuration = s.WaitCount, s.WaitDuration
+	c.statsMu.Unlock()
+
+	box.RecordRedisPoolStats(s.ActiveCount, s.IdleCount, waits, waitTime)
+}
+
+// do runs one command on conn, timing it for the span in ctx, the command
+// histogram and the slow log. The first argument is taken to be the key;
+// the remaining arguments are values and are never logged. An empty command
+// flushes the commands queued with Send and is recorded as PIPELINE.
+func (c *Client) do(ctx context.Context, conn redis.Conn, command string, args ...interface{}) (interface{}, error) {
+	box := c.recorder.Load()
+	span := trace.SpanFromContext(ctx)
+	if box == nil && c.slowThreshold <= 0 && !span.IsRecording() {
+		return conn.Do(command, args...)
+	}
+
+	start := time.Now()
+	reply, err := conn.Do(command, args...)
+	elapsed := time.Since(start)
+	if command == "" {
+		command = pipelineCommand
+	}
+
+	if span.IsRecording() {
+		span.SetAttributes(
+			attribute.String("db.operation", command),
+			attribute.Float64("redis.command_duration_ms", float64(elapsed)/float64(time.Millisecond)),
+		)
+	}
+	if box != nil {
+		box.ObserveRedisCommand(command, elapsed)
+	}
+	if c.slowThreshold > 0 && elapsed >= c.slowThreshold {
+		fields := []zap.Field{
+			zap.String("command", command),
+			zap.Duration("duration", elapsed),
+			zap.Duration("threshold", c.slowThreshold),
+		}
+		if len(args) > 0 {
+			if key, ok := args[0].(string); ok {
+				fields = append(fields, zap.String("key", key))
+			}
+		}
+		c.logger.Warn("Slow Redis command", fields...)
+	}
+	return reply, err
+}
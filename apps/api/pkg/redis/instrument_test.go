@@ -0,0 +1,119 @@
+package redis
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"veemon/pkg/metrics"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newTestClient(t *testing.T, slow time.Duration, logger *zap.Logger) *Client {
+	t.Helper()
+	c, _ := newTestClientServer(t, slow, logger)
+	return c
+}
+
+// newTestClientServer is newTestClient plus the miniredis it talks to.
+func newTestClientServer(t *testing.T, slow time.Duration, logger *zap.Logger) (*Client, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	port, err := strconv.Atoi(mr.Port())
+	if err != nil {
+		t.Fatalf("miniredis port: %v", err)
+	}
+	c, err := New(Config{Host: mr.Host(), Port: port, MaxIdle: 2, MaxActive: 4, SlowThreshold: slow, Logger: logger})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	return c, mr
+}
+
+func scrape(t *testing.T, m *metrics.Metrics) string {
+	t.Helper()
+	app := fiber.New()
+	app.Get("/metrics", m.Handler())
+	resp, err := app.Test(httptest.NewRequest("GET", "/metrics", nil), -1)
+	if err != nil {
+		t.Fatalf("scrape: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return string(body)
+}
+
+func TestEnableMetrics_PopulatesCommandHistogramAndPoolStats(t *testing.T) {
+	c := newTestClient(t, -1, nil)
+	m := metrics.New("test")
+	c.EnableMetrics(m, time.Hour)
+
+	ctx := context.Background()
+	if err := c.Set(ctx, "k", "v", 0); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	var got string
+	if err := c.Get(ctx, "k", &got); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	c.collectPoolStats()
+
+	out := scrape(t, m)
+	for _, want := range []string{
+		`test_redis_command_duration_seconds_count{command="SET"} 1`,
+		`test_redis_command_duration_seconds_count{command="GET"} 1`,
+		`test_redis_command_duration_seconds_bucket{command="GET",le="+Inf"} 1`,
+		`test_redis_pool_idle_connections 1`,
+		`test_redis_pool_waits_total 0`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics output missing %q", want)
+		}
+	}
+}
+
+func TestDo_SlowCommandLogsKeyButNotValue(t *testing.T) {
+	core, logs := observer.New(zapcore.WarnLevel)
+	// A 1ns threshold makes every command "slow".
+	c := newTestClient(t, time.Nanosecond, zap.New(core))
+
+	if err := c.Set(context.Background(), "session:42", "top-secret-value", 0); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	entries := logs.FilterMessage("Slow Redis command").All()
+	if len(entries) != 1 {
+		t.Fatalf("want 1 slow-command log, got %d", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["command"] != "SET" || fields["key"] != "session:42" {
+		t.Fatalf("unexpected fields: %v", fields)
+	}
+	for _, v := range fields {
+		if s, ok := v.(string); ok && strings.Contains(s, "top-secret-value") {
+			t.Fatalf("value leaked into slow log: %v", fields)
+		}
+	}
+}
+
+func TestDo_FastCommandBelowThresholdIsNotLogged(t *testing.T) {
+	core, logs := observer.New(zapcore.WarnLevel)
+	c := newTestClient(t, time.Hour, zap.New(core))
+
+	if _, err := c.Exists(context.Background(), "k"); err != nil {
+		t.Fatalf("Exists: %v", err)
+	}
+	if n := logs.Len(); n != 0 {
+		t.Fatalf("want no logs below threshold, got %d", n)
+	}
+}
@@ -0,0 +1,202 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrNotAcquired is returned by Acquire and WithLock when another owner
+	// holds the lock.
+	ErrNotAcquired = errors.New("redis: lock held by another owner")
+	// ErrLockLost is returned when a lock expired or was taken over before
+	// it was extended or released, so the work it guarded may have
+	// overlapped with another owner's.
+	ErrLockLost = errors.New("redis: lock lost")
+)
+
+// releaseTimeout bounds the Release at the end of WithLock, which runs even
+// when the caller's context has ended.
+const releaseTimeout = 5 * time.Second
+
+// The scripts are bytes so the slow log, which prints a string first
+// argument as the key, never prints them.
+var (
+	// releaseScript deletes KEYS[1] only if it still holds the token ARGV[1].
+	releaseScript = []byte(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) else return 0 end`)
+	// extendScript resets the TTL of KEYS[1] to ARGV[2] ms only if it still
+	// holds the token ARGV[1].
+	extendScript = []byte(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("PEXPIRE", KEYS[1], ARGV[2]) else return 0 end`)
+)
+
+// Lock is a lock on a Redis key, held under a random token so only its owner
+// can extend or release it.
+type Lock struct {
+	client *Client
+	key    string
+	token  string
+	ttl    time.Duration
+
+	mu       sync.Mutex
+	stop     chan struct{}
+	watchdog chan struct{}
+	cancel   context.CancelCauseFunc
+}
+
+// Acquire takes the lock on key for ttl, returning ErrNotAcquired when it is
+// held. The lock expires after ttl unless extended, by Extend or KeepAlive.
+func (c *Client) Acquire(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	ctx, span := tracer.Start(ctx, "redis.Acquire",
+		trace.WithAttributes(attribute.String("redis.key", key)))
+	defer span.End()
+
+	if ttl < time.Millisecond {
+		err := fmt.Errorf("redis: lock ttl %s is under 1ms", ttl)
+		span.RecordError(err)
+		return nil, err
+	}
+
+	conn := c.pool.Get()
+	defer conn.Close() //nolint:errcheck // best-effort cleanup
+
+	token := uuid.NewString()
+	reply, err := c.do(ctx, conn, "SET", key, token, "NX", "PX", ttl.Milliseconds())
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if reply == nil {
+		return nil, ErrNotAcquired
+	}
+	return &Lock{client: c, key: key, token: token, ttl: ttl}, nil
+}
+
+// Key returns the locked key.
+func (l *Lock) Key() string { return l.key }
+
+// Extend resets the lock's TTL, returning ErrLockLost if it is no longer
+// held.
+func (l *Lock) Extend(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "redis.Lock.Extend",
+		trace.WithAttributes(attribute.String("redis.key", l.key)))
+	defer span.End()
+
+	if err := l.eval(ctx, extendScript, l.ttl.Milliseconds()); err != nil {
+		span.RecordError(err)
+		return err
+	}
+	return nil
+}
+
+// Release stops KeepAlive and deletes the lock, returning ErrLockLost if it
+// was no longer held.
+func (l *Lock) Release(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "redis.Lock.Release",
+		trace.WithAttributes(attribute.String("redis.key", l.key)))
+	defer span.End()
+
+	l.mu.Lock()
+	if l.stop != nil {
+		close(l.stop)
+		<-l.watchdog
+		l.cancel(nil)
+		l.stop = nil
+	}
+	l.mu.Unlock()
+
+	if err := l.eval(ctx, releaseScript); err != nil {
+		span.RecordError(err)
+		return err
+	}
+	return nil
+}
+
+// KeepAlive extends the lock every third of its TTL until Release, and
+// returns a context derived from ctx for the guarded work. That context is
+// cancelled with cause ErrLockLost once the lock is found taken, or could
+// not be extended for a whole TTL. Calling it again returns ctx unchanged.
+func (l *Lock) KeepAlive(ctx context.Context) context.Context {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stop != nil {
+		return ctx
+	}
+	ctx, l.cancel = context.WithCancelCause(ctx)
+	l.stop = make(chan struct{})
+	l.watchdog = make(chan struct{})
+	go l.keepAlive(ctx, l.stop, l.watchdog)
+	return ctx
+}
+
+func (l *Lock) keepAlive(ctx context.Context, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	extended := time.Now()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		err := l.Extend(ctx)
+		switch {
+		case err == nil:
+			extended = time.Now()
+			continue
+		case errors.Is(err, ErrLockLost):
+		case time.Since(extended) < l.ttl:
+			l.client.logger.Warn("Failed to extend Redis lock", zap.String("key", l.key), zap.Error(err))
+			continue
+		}
+		l.client.logger.Warn("Redis lock lost", zap.String("key", l.key), zap.Error(err))
+		l.cancel(ErrLockLost)
+		return
+	}
+}
+
+// eval runs script on the lock's key and token, returning ErrLockLost when
+// the script finds another token.
+func (l *Lock) eval(ctx context.Context, script []byte, args ...interface{}) error {
+	conn := l.client.pool.Get()
+	defer conn.Close() //nolint:errcheck // best-effort cleanup
+
+	n, err := redis.Int64(l.client.do(ctx, conn, "EVAL", append([]interface{}{script, 1, l.key, l.token}, args...)...))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrLockLost
+	}
+	return nil
+}
+
+// WithLock runs fn while holding the lock on key, kept alive for as long as
+// fn runs, and releases it afterwards. It returns ErrNotAcquired without
+// running fn when the lock is held. fn's context is cancelled if the lock is
+// lost; fn's error is returned first, then any error releasing the lock.
+func (c *Client) WithLock(ctx context.Context, key string, ttl time.Duration, fn func(ctx context.Context) error) error {
+	lock, err := c.Acquire(ctx, key, ttl)
+	if err != nil {
+		return err
+	}
+	err = fn(lock.KeepAlive(ctx))
+
+	releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), releaseTimeout)
+	defer cancel()
+	if releaseErr := lock.Release(releaseCtx); err == nil {
+		err = releaseErr
+	}
+	return err
+}
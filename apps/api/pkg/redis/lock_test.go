@@ -0,0 +1,136 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAcquire_IsExclusiveUntilReleased(t *testing.T) {
+	c, _ := newTestClientServer(t, -1, nil)
+	ctx := context.Background()
+
+	lock, err := c.Acquire(ctx, "lock:seed", time.Minute)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	if _, err := c.Acquire(ctx, "lock:seed", time.Minute); !errors.Is(err, ErrNotAcquired) {
+		t.Fatalf("second Acquire err = %v, want ErrNotAcquired", err)
+	}
+	if err := lock.Release(ctx); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	again, err := c.Acquire(ctx, "lock:seed", time.Minute)
+	if err != nil {
+		t.Fatalf("Acquire after Release: %v", err)
+	}
+	_ = again.Release(ctx)
+}
+
+func TestRelease_LeavesAnotherOwnersLock(t *testing.T) {
+	c, mr := newTestClientServer(t, -1, nil)
+	ctx := context.Background()
+
+	lock, err := c.Acquire(ctx, "lock:seed", time.Second)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	mr.FastForward(2 * time.Second) // expired
+	other, err := c.Acquire(ctx, "lock:seed", time.Minute)
+	if err != nil {
+		t.Fatalf("Acquire by other owner: %v", err)
+	}
+
+	if err := lock.Release(ctx); !errors.Is(err, ErrLockLost) {
+		t.Fatalf("stale Release err = %v, want ErrLockLost", err)
+	}
+	if err := lock.Extend(ctx); !errors.Is(err, ErrLockLost) {
+		t.Fatalf("stale Extend err = %v, want ErrLockLost", err)
+	}
+	if !mr.Exists("lock:seed") {
+		t.Fatal("stale owner deleted the new owner's lock")
+	}
+	if err := other.Release(ctx); err != nil {
+		t.Fatalf("Release by owner: %v", err)
+	}
+}
+
+func TestKeepAlive_ExtendsTheLock(t *testing.T) {
+	c, mr := newTestClientServer(t, -1, nil)
+	ctx := context.Background()
+
+	lock, err := c.Acquire(ctx, "lock:job", 30*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	workCtx := lock.KeepAlive(ctx)
+	mr.SetTTL("lock:job", time.Millisecond)
+
+	deadline := time.Now().Add(time.Second)
+	for mr.TTL("lock:job") != 30*time.Millisecond {
+		if time.Now().After(deadline) {
+			t.Fatalf("TTL never extended: %s", mr.TTL("lock:job"))
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := lock.Release(ctx); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if workCtx.Err() == nil {
+		t.Error("work context outlived Release")
+	}
+}
+
+func TestKeepAlive_CancelsWorkWhenLockIsLost(t *testing.T) {
+	c, mr := newTestClientServer(t, -1, nil)
+	ctx := context.Background()
+
+	lock, err := c.Acquire(ctx, "lock:job", 30*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	workCtx := lock.KeepAlive(ctx)
+	mr.Set("lock:job", "someone-else")
+
+	select {
+	case <-workCtx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("work context not cancelled")
+	}
+	if cause := context.Cause(workCtx); !errors.Is(cause, ErrLockLost) {
+		t.Errorf("cause = %v, want ErrLockLost", cause)
+	}
+	_ = lock.Release(ctx)
+}
+
+func TestWithLock(t *testing.T) {
+	c, mr := newTestClientServer(t, -1, nil)
+	ctx := context.Background()
+
+	ran := false
+	err := c.WithLock(ctx, "lock:seed", time.Minute, func(ctx context.Context) error {
+		ran = true
+		if err := c.WithLock(ctx, "lock:seed", time.Minute, func(context.Context) error {
+			t.Error("nested WithLock ran")
+			return nil
+		}); !errors.Is(err, ErrNotAcquired) {
+			t.Errorf("nested WithLock err = %v, want ErrNotAcquired", err)
+		}
+		return nil
+	})
+	if err != nil || !ran {
+		t.Fatalf("WithLock = %v (ran %v)", err, ran)
+	}
+	if mr.Exists("lock:seed") {
+		t.Error("lock not released")
+	}
+
+	boom := errors.New("boom")
+	if err := c.WithLock(ctx, "lock:seed", time.Minute, func(context.Context) error { return boom }); !errors.Is(err, boom) {
+		t.Errorf("WithLock err = %v, want fn's", err)
+	}
+	if mr.Exists("lock:seed") {
+		t.Error("lock not released after fn failed")
+	}
+}
@@ -1,35 +1,102 @@
-// Package redis wraps a Redigo connection pool with tracing helpers.
+// Package redis wraps a Redigo connection pool with tracing, metrics and
+// slow-command logging helpers.
 package redis
 
 import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"veemon/pkg/tlsconfig"
+
 	"github.com/gomodule/redigo/redis"
+	"github.com/google/uuid"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
 )
 
 var tracer = otel.Tracer("pkg/redis")
 
+// Modes of Config.Mode.
+const (
+	ModeStandalone = "standalone"
+	ModeSentinel   = "sentinel"
+	ModeCluster    = "cluster"
+)
+
+// connPool hands out connections. *redis.Pool serves standalone and
+// sentinel mode; *clusterPool routes each connection to a cluster node.
+type connPool interface {
+	Get() redis.Conn
+	GetContext(ctx context.Context) (redis.Conn, error)
+	Stats() redis.PoolStats
+	Close() error
+}
+
 type Client struct {
-	pool *redis.Pool
+	pool          connPool
+	logger        *zap.Logger
+	slowThreshold time.Duration
+	scanCount     int
+
+	recorder         atomic.Pointer[recorderBox]
+	statsMu          sync.Mutex
+	lastWaitCount    int64
+	lastWaitDuration time.Duration
+
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
 type Config struct {
-	Host         string
-	Port         int
-	Password     string
-	DB           int
-	MaxIdle      int
-	MaxActive    int
-	IdleTimeout  int // seconds
-	DialTimeout  int // seconds
-	ReadTimeout  int // seconds
-	WriteTimeout int // seconds
+	// Mode is ModeStandalone (the default, Host and Port), ModeSentinel or
+	// ModeCluster.
+	Mode         string `mapstructure:"REDIS_MODE"`
+	Host         string `mapstructure:"REDIS_HOST"`
+	Port         int    `mapstructure:"REDIS_PORT"`
+	Password     string `mapstructure:"REDIS_PASSWORD"`
+	DB           int    `mapstructure:"REDIS_DB"`
+	MaxIdle      int    `mapstructure:"REDIS_MAX_IDLE"`
+	MaxActive    int    `mapstructure:"REDIS_MAX_ACTIVE"`
+	IdleTimeout  int    `mapstructure:"REDIS_IDLE_TIMEOUT"`  // seconds
+	DialTimeout  int    `mapstructure:"REDIS_DIAL_TIMEOUT"`  // seconds
+	ReadTimeout  int    `mapstructure:"REDIS_READ_TIMEOUT"`  // seconds
+	WriteTimeout int    `mapstructure:"REDIS_WRITE_TIMEOUT"` // seconds
+
+	// TLSEnabled connects over TLS, to the sentinels too in sentinel mode.
+	// The server name checked is the dialed host unless TLSSkipVerify turns
+	// verification off; TLSCAFile replaces the system roots and TLSCertFile
+	// with TLSKeyFile is a client certificate, all PEM.
+	TLSEnabled    bool   `mapstructure:"REDIS_TLS_ENABLED"`
+	TLSSkipVerify bool   `mapstructure:"REDIS_TLS_SKIP_VERIFY"`
+	TLSCAFile     string `mapstructure:"REDIS_TLS_CA_FILE"`
+	TLSCertFile   string `mapstructure:"REDIS_TLS_CERT_FILE"`
+	TLSKeyFile    string `mapstructure:"REDIS_TLS_KEY_FILE"`
+
+	// SentinelMaster is the master name the sentinels at SentinelAddrs
+	// (host:port) monitor, in sentinel mode. SentinelPassword authenticates
+	// to the sentinels; Password to the master.
+	SentinelMaster   string   `mapstructure:"REDIS_SENTINEL_MASTER"`
+	SentinelAddrs    []string `mapstructure:"REDIS_SENTINEL_ADDRS"`
+	SentinelPassword string   `mapstructure:"REDIS_SENTINEL_PASSWORD"`
+	// ClusterAddrs (host:port) are the nodes first asked for the slot map in
+	// cluster mode. MaxIdle and MaxActive then apply per node.
+	ClusterAddrs []string `mapstructure:"REDIS_CLUSTER_ADDRS"`
+
+	// SlowThreshold logs commands at or above this duration; zero means
+	// DefaultSlowThreshold and a negative value disables the slow log.
+	SlowThreshold time.Duration `mapstructure:"REDIS_SLOW_THRESHOLD_MS"`
+	// ScanCount is the COUNT hint of each SCAN call; zero or less means
+	// DefaultScanCount.
+	ScanCount int `mapstructure:"REDIS_SCAN_COUNT"`
+	// Logger receives slow-command warnings; nil discards them.
+	Logger *zap.Logger `mapstructure:"-"`
 }
 
 // durationOrDefault converts a seconds value to a Duration, falling back to def
@@ -42,49 +109,57 @@ func durationOrDefault(seconds int, def time.Duration) time.Duration {
 }
 
 func New(cfg Config) (*Client, error) {
-	addr := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
-
 	// Sensible non-zero fallbacks so a hung Redis can never block a caller
 	// indefinitely, even if a config value is omitted.
-	dialTimeout := durationOrDefault(cfg.DialTimeout, 5*time.Second)
-	readTimeout := durationOrDefault(cfg.ReadTimeout, 3*time.Second)
-	writeTimeout := durationOrDefault(cfg.WriteTimeout, 3*time.Second)
-
-	pool := &redis.Pool{
-		MaxIdle:     cfg.MaxIdle,
-		MaxActive:   cfg.MaxActive,
-		IdleTimeout: time.Duration(cfg.IdleTimeout) * time.Second,
-		Wait:        true,
-		Dial: func() (redis.Conn, error) {
-			c, err := redis.Dial("tcp", addr,
-				redis.DialConnectTimeout(dialTimeout),
-				redis.DialReadTimeout(readTimeout),
-				redis.DialWriteTimeout(writeTimeout),
-			)
-			if err != nil {
-				return nil, err
-			}
-			if cfg.Password != "" {
-				if _, err := c.Do("AUTH", cfg.Password); err != nil {
-					c.Close() //nolint:errcheck // best-effort cleanup on auth failure
-					return nil, err
-				}
-			}
-			if cfg.DB != 0 {
-				if _, err := c.Do("SELECT", cfg.DB); err != nil {
-					c.Close() //nolint:errcheck // best-effort cleanup on select failure
-					return nil, err
-				}
-			}
-			return c, nil
-		},
-		TestOnBorrow: func(c redis.Conn, t time.Time) error {
-			if time.Since(t) < time.Minute {
-				return nil
-			}
-			_, err := c.Do("PING")
-			return err
-		},
+	dialOpts := []redis.DialOption{
+		redis.DialConnectTimeout(durationOrDefault(cfg.DialTimeout, 5*time.Second)),
+		redis.DialReadTimeout(durationOrDefault(cfg.ReadTimeout, 3*time.Second)),
+		redis.DialWriteTimeout(durationOrDefault(cfg.WriteTimeout, 3*time.Second)),
+	}
+	if cfg.TLSEnabled {
+		tlsCfg, err := tlsconfig.New(tlsconfig.Options{
+			SkipVerify: cfg.TLSSkipVerify,
+			CAFile:     cfg.TLSCAFile,
+			CertFile:   cfg.TLSCertFile,
+			KeyFile:    cfg.TLSKeyFile,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("redis TLS: %w", err)
+		}
+		dialOpts = append(dialOpts, redis.DialUseTLS(true), redis.DialTLSConfig(tlsCfg))
+	}
+
+	var pool connPool
+	switch cfg.Mode {
+	case "", ModeStandalone:
+		addr := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
+		pool = newPool(cfg, dialNode(addr, cfg, dialOpts), pingIdle)
+	case ModeSentinel:
+		if cfg.SentinelMaster == "" || len(cfg.SentinelAddrs) == 0 {
+			return nil, fmt.Errorf("redis: sentinel mode needs a master name and sentinel addresses")
+		}
+		s := &sentinel{
+			addrs:    cfg.SentinelAddrs,
+			master:   cfg.SentinelMaster,
+			dialOpts: append(dialOpts, redis.DialPassword(cfg.SentinelPassword)),
+		}
+		pool = newPool(cfg, s.dial(cfg, dialOpts), checkRole)
+	case ModeCluster:
+		if len(cfg.ClusterAddrs) == 0 {
+			return nil, fmt.Errorf("redis: cluster mode needs node addresses")
+		}
+		if cfg.DB != 0 {
+			return nil, fmt.Errorf("redis: cluster mode only has database 0 (got %d)", cfg.DB)
+		}
+		cp, err := newClusterPool(cfg.ClusterAddrs, func(addr string) *redis.Pool {
+			return newPool(cfg, dialNode(addr, cfg, dialOpts), pingIdle)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to redis: %w", err)
+		}
+		pool = cp
+	default:
+		return nil, fmt.Errorf("redis: unknown mode %q", cfg.Mode)
 	}
 
 	// Test connection
@@ -92,27 +167,110 @@ func New(cfg Config) (*Client, error) {
 	defer conn.Close() //nolint:errcheck // best-effort cleanup
 
 	if _, err := conn.Do("PING"); err != nil {
+		_ = pool.Close()
 		return nil, fmt.Errorf("failed to connect to redis: %w", err)
 	}
 
-	return &Client{pool: pool}, nil
+	slow := cfg.SlowThreshold
+	if slow == 0 {
+		slow = DefaultSlowThreshold
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	scanCount := cfg.ScanCount
+	if scanCount <= 0 {
+		scanCount = DefaultScanCount
+	}
+
+	return &Client{
+		pool:          pool,
+		logger:        logger,
+		slowThreshold: slow,
+		scanCount:     scanCount,
+		done:          make(chan struct{}),
+	}, nil
+}
+
+// newPool builds a pool of connections made by dial, checked by
+// testOnBorrow.
+func newPool(cfg Config, dial func() (redis.Conn, error), testOnBorrow func(redis.Conn, time.Time) error) *redis.Pool {
+	return &redis.Pool{
+		MaxIdle:      cfg.MaxIdle,
+		MaxActive:    cfg.MaxActive,
+		IdleTimeout:  time.Duration(cfg.IdleTimeout) * time.Second,
+		Wait:         true,
+		Dial:         dial,
+		TestOnBorrow: testOnBorrow,
+	}
+}
+
+// dialNode returns a dialer for the server at addr that authenticates and
+// selects cfg.DB.
+func dialNode(addr string, cfg Config, opts []redis.DialOption) func() (redis.Conn, error) {
+	return func() (redis.Conn, error) {
+		c, err := redis.Dial("tcp", addr, opts...)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Password != "" {
+			if _, err := c.Do("AUTH", cfg.Password); err != nil {
+				c.Close() //nolint:errcheck // best-effort cleanup on auth failure
+				return nil, err
+			}
+		}
+		if cfg.DB != 0 {
+			if _, err := c.Do("SELECT", cfg.DB); err != nil {
+				c.Close() //nolint:errcheck // best-effort cleanup on select failure
+				return nil, err
+			}
+		}
+		return c, nil
+	}
+}
+
+// pingIdle checks a connection idle for over a minute still answers.
+func pingIdle(c redis.Conn, t time.Time) error {
+	if time.Since(t) < time.Minute {
+		return nil
+	}
+	_, err := c.Do("PING")
+	return err
 }
 
 func (c *Client) Close() error {
+	c.closeOnce.Do(func() { close(c.done) })
 	return c.pool.Close()
 }
 
+// Pool returns the connection pool, or nil in cluster mode, which keeps a
+// pool per node.
 func (c *Client) Pool() *redis.Pool {
-	return c.pool
+	p, _ := c.pool.(*redis.Pool)
+	return p
 }
 
 func (c *Client) Conn() redis.Conn {
 	return c.pool.Get()
 }
 
+// Ping checks the server answers, giving up when ctx ends: waiting for a
+// pool connection and the round trip both honor its deadline.
+func (c *Client) Ping(ctx context.Context) error {
+	conn, err := c.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close() //nolint:errcheck // best-effort cleanup
+
+	_, err = redis.DoContext(conn, ctx, "PING")
+	return err
+}
+
 // Set stores a value with optional expiration
 func (c *Client) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
-	_, span := tracer.Start(ctx, "redis.Set",
+	ctx, span := tracer.Start(ctx, "redis.Set",
 		trace.WithAttributes(attribute.String("redis.key", key)))
 	defer span.End()
 
@@ -126,9 +284,9 @@ func (c *Client) Set(ctx context.Context, key string, value interface{}, expirat
 	}
 
 	if expiration > 0 {
-		_, err = conn.Do("SETEX", key, int(expiration.Seconds()), data)
+		_, err = c.do(ctx, conn, "SETEX", key, int(expiration.Seconds()), data)
 	} else {
-		_, err = conn.Do("SET", key, data)
+		_, err = c.do(ctx, conn, "SET", key, data)
 	}
 
 	if err != nil {
@@ -139,14 +297,14 @@ func (c *Client) Set(ctx context.Context, key string, value interface{}, expirat
 
 // Get retrieves a value and unmarshals it into dest
 func (c *Client) Get(ctx context.Context, key string, dest interface{}) error {
-	_, span := tracer.Start(ctx, "redis.Get",
+	ctx, span := tracer.Start(ctx, "redis.Get",
 		trace.WithAttributes(attribute.String("redis.key", key)))
 	defer span.End()
 
 	conn := c.pool.Get()
 	defer conn.Close() //nolint:errcheck // best-effort cleanup
 
-	data, err := redis.Bytes(conn.Do("GET", key))
+	data, err := redis.Bytes(c.do(ctx, conn, "GET", key))
 	if err != nil {
 		if err == redis.ErrNil {
 			return ErrNil
@@ -164,14 +322,14 @@ func (c *Client) Get(ctx context.Context, key string, dest interface{}) error {
 
 // GetString retrieves a string value
 func (c *Client) GetString(ctx context.Context, key string) (string, error) {
-	_, span := tracer.Start(ctx, "redis.GetString",
+	ctx, span := tracer.Start(ctx, "redis.GetString",
 		trace.WithAttributes(attribute.String("redis.key", key)))
 	defer span.End()
 
 	conn := c.pool.Get()
 	defer conn.Close() //nolint:errcheck // best-effort cleanup
 
-	val, err := redis.String(conn.Do("GET", key))
+	val, err := redis.String(c.do(ctx, conn, "GET", key))
 	if err != nil {
 		if err == redis.ErrNil {
 			return "", ErrNil
@@ -182,21 +340,38 @@ func (c *Client) GetString(ctx context.Context, key string) (string, error) {
 	return val, nil
 }
 
-// Delete removes keys
-func (c *Client) Delete(ctx context.Context, keys ...string) error {
-	_, span := tracer.Start(ctx, "redis.Delete",
-		trace.WithAttributes(attribute.StringSlice("redis.keys", keys)))
+// GetDelString retrieves a string value and deletes its key in one step
+// (GETDEL, Redis 6.2+), so only one caller can ever read it.
+func (c *Client) GetDelString(ctx context.Context, key string) (string, error) {
+	ctx, span := tracer.Start(ctx, "redis.GetDelString",
+		trace.WithAttributes(attribute.String("redis.key", key)))
 	defer span.End()
 
 	conn := c.pool.Get()
 	defer conn.Close() //nolint:errcheck // best-effort cleanup
 
-	args := make([]interface{}, len(keys))
-	for i, key := range keys {
-		args[i] = key
+	val, err := redis.String(c.do(ctx, conn, "GETDEL", key))
+	if err != nil {
+		if err == redis.ErrNil {
+			return "", ErrNil
+		}
+		span.RecordError(err)
+		return "", err
 	}
+	return val, nil
+}
 
-	_, err := conn.Do("DEL", args...)
+// Delete removes keys
+func (c *Client) Delete(ctx context.Context, keys ...string) error {
+	ctx, span := tracer.Start(ctx, "redis.Delete",
+		trace.WithAttributes(attribute.StringSlice("redis.keys", keys)))
+	defer span.End()
+
+	// In cluster mode keys in different hash slots need separate commands.
+	err := c.eachSlot(keys, func(conn redis.Conn, idx []int) error {
+		_, err := c.do(ctx, conn, "DEL", keyArgs(keys, idx)...)
+		return err
+	})
 	if err != nil {
 		span.RecordError(err)
 	}
@@ -205,14 +380,14 @@ func (c *Client) Delete(ctx context.Context, keys ...string) error {
 
 // Exists checks if a key exists
 func (c *Client) Exists(ctx context.Context, key string) (bool, error) {
-	_, span := tracer.Start(ctx, "redis.Exists",
+	ctx, span := tracer.Start(ctx, "redis.Exists",
 		trace.WithAttributes(attribute.String("redis.key", key)))
 	defer span.End()
 
 	conn := c.pool.Get()
 	defer conn.Close() //nolint:errcheck // best-effort cleanup
 
-	exists, err := redis.Bool(conn.Do("EXISTS", key))
+	exists, err := redis.Bool(c.do(ctx, conn, "EXISTS", key))
 	if err != nil {
 		span.RecordError(err)
 		return false, err
@@ -220,9 +395,39 @@ func (c *Client) Exists(ctx context.Context, key string) (bool, error) {
 	return exists, nil
 }
 
+// ExistsMany reports, for each key in order, whether it holds a string value,
+// using a single MGET round trip (one per hash slot in cluster mode). Keys of
+// other types report false.
+func (c *Client) ExistsMany(ctx context.Context, keys ...string) ([]bool, error) {
+	ctx, span := tracer.Start(ctx, "redis.ExistsMany",
+		trace.WithAttributes(attribute.Int("redis.key_count", len(keys))))
+	defer span.End()
+
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	out := make([]bool, len(keys))
+	err := c.eachSlot(keys, func(conn redis.Conn, idx []int) error {
+		values, err := redis.Values(c.do(ctx, conn, "MGET", keyArgs(keys, idx)...))
+		if err != nil {
+			return err
+		}
+		for i, j := range idx {
+			out[j] = i < len(values) && values[i] != nil
+		}
+		return nil
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	return out, nil
+}
+
 // SetNX sets a value only if the key doesn't exist (for distributed locks)
 func (c *Client) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
-	_, span := tracer.Start(ctx, "redis.SetNX",
+	ctx, span := tracer.Start(ctx, "redis.SetNX",
 		trace.WithAttributes(attribute.String("redis.key", key)))
 	defer span.End()
 
@@ -237,9 +442,9 @@ func (c *Client) SetNX(ctx context.Context, key string, value interface{}, expir
 
 	var reply interface{}
 	if expiration > 0 {
-		reply, err = conn.Do("SET", key, data, "NX", "EX", int(expiration.Seconds()))
+		reply, err = c.do(ctx, conn, "SET", key, data, "NX", "EX", int(expiration.Seconds()))
 	} else {
-		reply, err = conn.Do("SETNX", key, data)
+		reply, err = c.do(ctx, conn, "SETNX", key, data)
 	}
 
 	if err != nil {
@@ -262,14 +467,14 @@ func (c *Client) SetNX(ctx context.Context, key string, value interface{}, expir
 
 // Incr increments an integer value
 func (c *Client) Incr(ctx context.Context, key string) (int64, error) {
-	_, span := tracer.Start(ctx, "redis.Incr",
+	ctx, span := tracer.Start(ctx, "redis.Incr",
 		trace.WithAttributes(attribute.String("redis.key", key)))
 	defer span.End()
 
 	conn := c.pool.Get()
 	defer conn.Close() //nolint:errcheck // best-effort cleanup
 
-	val, err := redis.Int64(conn.Do("INCR", key))
+	val, err := redis.Int64(c.do(ctx, conn, "INCR", key))
 	if err != nil {
 		span.RecordError(err)
 		return 0, err
@@ -277,16 +482,175 @@ func (c *Client) Incr(ctx context.Context, key string) (int64, error) {
 	return val, nil
 }
 
+// PFAdd adds elements to the HyperLogLog at key, creating it if needed. It
+// reports whether the estimated cardinality changed.
+func (c *Client) PFAdd(ctx context.Context, key string, elements ...string) (bool, error) {
+	ctx, span := tracer.Start(ctx, "redis.PFAdd",
+		trace.WithAttributes(attribute.String("redis.key", key)))
+	defer span.End()
+
+	conn := c.pool.Get()
+	defer conn.Close() //nolint:errcheck // best-effort cleanup
+
+	args := make([]interface{}, 0, len(elements)+1)
+	args = append(args, key)
+	for _, e := range elements {
+		args = append(args, e)
+	}
+	changed, err := redis.Bool(c.do(ctx, conn, "PFADD", args...))
+	if err != nil {
+		span.RecordError(err)
+		return false, err
+	}
+	return changed, nil
+}
+
+// PFCount returns the estimated number of distinct elements in the union of
+// the HyperLogLogs at keys; missing keys count as empty. The estimate has a
+// standard error of 0.81%. In cluster mode the keys must share a hash slot,
+// e.g. through a {hash tag}, or the server rejects the command with
+// CROSSSLOT.
+func (c *Client) PFCount(ctx context.Context, keys ...string) (int64, error) {
+	ctx, span := tracer.Start(ctx, "redis.PFCount",
+		trace.WithAttributes(attribute.Int("redis.key_count", len(keys))))
+	defer span.End()
+
+	conn := c.pool.Get()
+	defer conn.Close() //nolint:errcheck // best-effort cleanup
+
+	args := make([]interface{}, len(keys))
+	for i, k := range keys {
+		args[i] = k
+	}
+	n, err := redis.Int64(c.do(ctx, conn, "PFCOUNT", args...))
+	if err != nil {
+		span.RecordError(err)
+		return 0, err
+	}
+	return n, nil
+}
+
+// StreamEntry is one entry read from a Redis stream.
+type StreamEntry struct {
+	ID     string
+	Fields map[string]string
+}
+
+// XAdd appends an entry with fields to stream and returns its ID. A positive
+// maxLen trims the stream to that many newest entries (exact MAXLEN).
+func (c *Client) XAdd(ctx context.Context, stream string, maxLen int64, fields map[string]string) (string, error) {
+	ctx, span := tracer.Start(ctx, "redis.XAdd",
+		trace.WithAttributes(attribute.String("redis.key", stream)))
+	defer span.End()
+
+	conn := c.pool.Get()
+	defer conn.Close() //nolint:errcheck // best-effort cleanup
+
+	args := []interface{}{stream}
+	if maxLen > 0 {
+		args = append(args, "MAXLEN", maxLen)
+	}
+	args = append(args, "*")
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		args = append(args, name, fields[name])
+	}
+	id, err := redis.String(c.do(ctx, conn, "XADD", args...))
+	if err != nil {
+		span.RecordError(err)
+		return "", err
+	}
+	return id, nil
+}
+
+// XRange returns up to count entries of stream with IDs between start and
+// end ("-" and "+" for the whole stream), oldest first. A non-positive count
+// returns them all.
+func (c *Client) XRange(ctx context.Context, stream, start, end string, count int) ([]StreamEntry, error) {
+	ctx, span := tracer.Start(ctx, "redis.XRange",
+		trace.WithAttributes(attribute.String("redis.key", stream)))
+	defer span.End()
+
+	conn := c.pool.Get()
+	defer conn.Close() //nolint:errcheck // best-effort cleanup
+
+	args := []interface{}{stream, start, end}
+	if count > 0 {
+		args = append(args, "COUNT", count)
+	}
+	replies, err := redis.Values(c.do(ctx, conn, "XRANGE", args...))
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	entries := make([]StreamEntry, 0, len(replies))
+	for _, reply := range replies {
+		parts, err := redis.Values(reply, nil)
+		if err != nil || len(parts) != 2 {
+			return nil, fmt.Errorf("redis: unexpected XRANGE entry %v", reply)
+		}
+		id, err := redis.String(parts[0], nil)
+		if err != nil {
+			return nil, err
+		}
+		fields, err := redis.StringMap(parts[1], nil)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, StreamEntry{ID: id, Fields: fields})
+	}
+	return entries, nil
+}
+
+// WindowAdd records an event at now in the sorted set at key, drops the
+// events older than window and returns how many remain, in one MULTI/EXEC.
+// Events are scored by time, so the count is a sliding-window counter that
+// decays as they age out; the key expires window after the newest one.
+func (c *Client) WindowAdd(ctx context.Context, key string, now time.Time, window time.Duration) (int64, error) {
+	ctx, span := tracer.Start(ctx, "redis.WindowAdd",
+		trace.WithAttributes(attribute.String("redis.key", key)))
+	defer span.End()
+
+	conn := c.pool.Get()
+	defer conn.Close() //nolint:errcheck // best-effort cleanup
+
+	nowMs := now.UnixMilli()
+	for _, cmd := range [][]interface{}{
+		{"MULTI"},
+		{"ZREMRANGEBYSCORE", key, "-inf", nowMs - window.Milliseconds()},
+		// A random member keeps simultaneous events apart.
+		{"ZADD", key, nowMs, uuid.NewString()},
+		{"ZCARD", key},
+		{"PEXPIRE", key, window.Milliseconds()},
+	} {
+		if _, err := c.do(ctx, conn, cmd[0].(string), cmd[1:]...); err != nil {
+			span.RecordError(err)
+			_, _ = conn.Do("DISCARD")
+			return 0, err
+		}
+	}
+	replies, err := redis.Values(c.do(ctx, conn, "EXEC"))
+	if err != nil {
+		span.RecordError(err)
+		return 0, err
+	}
+	return redis.Int64(replies[2], nil)
+}
+
 // Expire sets expiration on a key
 func (c *Client) Expire(ctx context.Context, key string, expiration time.Duration) error {
-	_, span := tracer.Start(ctx, "redis.Expire",
+	ctx, span := tracer.Start(ctx, "redis.Expire",
 		trace.WithAttributes(attribute.String("redis.key", key)))
 	defer span.End()
 
 	conn := c.pool.Get()
 	defer conn.Close() //nolint:errcheck // best-effort cleanup
 
-	_, err := conn.Do("EXPIRE", key, int(expiration.Seconds()))
+	_, err := c.do(ctx, conn, "EXPIRE", key, int(expiration.Seconds()))
 	if err != nil {
 		span.RecordError(err)
 	}
@@ -295,7 +659,7 @@ func (c *Client) Expire(ctx context.Context, key string, expiration time.Duratio
 
 // HSet sets a hash field
 func (c *Client) HSet(ctx context.Context, key, field string, value interface{}) error {
-	_, span := tracer.Start(ctx, "redis.HSet",
+	ctx, span := tracer.Start(ctx, "redis.HSet",
 		trace.WithAttributes(
 			attribute.String("redis.key", key),
 			attribute.String("redis.field", field),
@@ -311,7 +675,7 @@ func (c *Client) HSet(ctx context.Context, key, field string, value interface{})
 		return fmt.Errorf("failed to marshal value: %w", err)
 	}
 
-	_, err = conn.Do("HSET", key, field, data)
+	_, err = c.do(ctx, conn, "HSET", key, field, data)
 	if err != nil {
 		span.RecordError(err)
 	}
@@ -320,7 +684,7 @@ func (c *Client) HSet(ctx context.Context, key, field string, value interface{})
 
 // HGet gets a hash field
 func (c *Client) HGet(ctx context.Context, key, field string, dest interface{}) error {
-	_, span := tracer.Start(ctx, "redis.HGet",
+	ctx, span := tracer.Start(ctx, "redis.HGet",
 		trace.WithAttributes(
 			attribute.String("redis.key", key),
 			attribute.String("redis.field", field),
@@ -330,7 +694,7 @@ func (c *Client) HGet(ctx context.Context, key, field string, dest interface{})
 	conn := c.pool.Get()
 	defer conn.Close() //nolint:errcheck // best-effort cleanup
 
-	data, err := redis.Bytes(conn.Do("HGET", key, field))
+	data, err := redis.Bytes(c.do(ctx, conn, "HGET", key, field))
 	if err != nil {
 		if err == redis.ErrNil {
 			return ErrNil
@@ -348,14 +712,14 @@ func (c *Client) HGet(ctx context.Context, key, field string, dest interface{})
 
 // HGetAll gets all fields in a hash
 func (c *Client) HGetAll(ctx context.Context, key string) (map[string]string, error) {
-	_, span := tracer.Start(ctx, "redis.HGetAll",
+	ctx, span := tracer.Start(ctx, "redis.HGetAll",
 		trace.WithAttributes(attribute.String("redis.key", key)))
 	defer span.End()
 
 	conn := c.pool.Get()
 	defer conn.Close() //nolint:errcheck // best-effort cleanup
 
-	result, err := redis.StringMap(conn.Do("HGETALL", key))
+	result, err := redis.StringMap(c.do(ctx, conn, "HGETALL", key))
 	if err != nil {
 		span.RecordError(err)
 		return nil, err
@@ -363,9 +727,30 @@ func (c *Client) HGetAll(ctx context.Context, key string) (map[string]string, er
 	return result, nil
 }
 
+// HDel removes hash fields
+func (c *Client) HDel(ctx context.Context, key string, fields ...string) error {
+	ctx, span := tracer.Start(ctx, "redis.HDel",
+		trace.WithAttributes(attribute.String("redis.key", key)))
+	defer span.End()
+
+	conn := c.pool.Get()
+	defer conn.Close() //nolint:errcheck // best-effort cleanup
+
+	args := make([]interface{}, 0, len(fields)+1)
+	args = append(args, key)
+	for _, f := range fields {
+		args = append(args, f)
+	}
+	_, err := c.do(ctx, conn, "HDEL", args...)
+	if err != nil {
+		span.RecordError(err)
+	}
+	return err
+}
+
 // Publish publishes a message to a channel
 func (c *Client) Publish(ctx context.Context, channel string, message interface{}) error {
-	_, span := tracer.Start(ctx, "redis.Publish",
+	ctx, span := tracer.Start(ctx, "redis.Publish",
 		trace.WithAttributes(attribute.String("redis.channel", channel)))
 	defer span.End()
 
@@ -378,13 +763,59 @@ func (c *Client) Publish(ctx context.Context, channel string, message interface{
 		return fmt.Errorf("failed to marshal message: %w", err)
 	}
 
-	_, err = conn.Do("PUBLISH", channel, data)
+	_, err = c.do(ctx, conn, "PUBLISH", channel, data)
 	if err != nil {
 		span.RecordError(err)
 	}
 	return err
 }
 
+// Subscribe delivers every message published to channel to handle until
+// ctx is cancelled or the connection fails. It blocks, holding one pooled
+// connection for its lifetime, and returns nil only when ctx ends; callers
+// that must survive a Redis restart re-subscribe on error. handle runs on
+// the receiving goroutine and should not block.
+func (c *Client) Subscribe(ctx context.Context, channel string, handle func([]byte)) error {
+	psc := redis.PubSubConn{Conn: c.pool.Get()}
+	defer psc.Close() //nolint:errcheck // best-effort cleanup
+
+	if err := psc.Subscribe(channel); err != nil {
+		return fmt.Errorf("subscribe %s: %w", channel, err)
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		for {
+			switch v := psc.ReceiveWithTimeout(0).(type) {
+			case redis.Message:
+				handle(v.Data)
+			case redis.Subscription:
+				if v.Kind == "unsubscribe" && v.Count == 0 {
+					errc <- nil
+					return
+				}
+			case error:
+				errc <- v
+				return
+			}
+		}
+	}()
+
+	select {
+	case err := <-errc:
+		if err == nil {
+			err = fmt.Errorf("subscribe %s: unsubscribed", channel)
+		}
+		return err
+	case <-ctx.Done():
+		if err := psc.Unsubscribe(channel); err != nil {
+			return nil
+		}
+		<-errc
+		return nil
+	}
+}
+
 // ErrNil is returned when a key doesn't exist
 var ErrNil = redis.ErrNil
 
@@ -0,0 +1,186 @@
+package redis
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestPFAddPFCount(t *testing.T) {
+	c := newTestClient(t, -1, nil)
+	ctx := context.Background()
+
+	changed, err := c.PFAdd(ctx, "hll:a", "u1", "u2")
+	if err != nil || !changed {
+		t.Fatalf("PFAdd new elements = %v, %v; want true", changed, err)
+	}
+	changed, err = c.PFAdd(ctx, "hll:a", "u1")
+	if err != nil || changed {
+		t.Fatalf("PFAdd repeat = %v, %v; want false", changed, err)
+	}
+	if _, err := c.PFAdd(ctx, "hll:b", "u3"); err != nil {
+		t.Fatalf("PFAdd: %v", err)
+	}
+
+	tests := []struct {
+		keys []string
+		want int64
+	}{
+		{[]string{"hll:a"}, 2},
+		// Redis counts the union; miniredis sums per-key counts instead, so
+		// the keys here hold disjoint elements.
+		{[]string{"hll:a", "hll:b"}, 3},
+		{[]string{"hll:a", "hll:missing"}, 2},
+		{[]string{"hll:missing"}, 0},
+	}
+	for _, tt := range tests {
+		got, err := c.PFCount(ctx, tt.keys...)
+		if err != nil {
+			t.Fatalf("PFCount(%v): %v", tt.keys, err)
+		}
+		if got != tt.want {
+			t.Errorf("PFCount(%v) = %d, want %d", tt.keys, got, tt.want)
+		}
+	}
+}
+
+func TestWindowAdd_CountsEventsWithinTheWindow(t *testing.T) {
+	c := newTestClient(t, -1, nil)
+	ctx := context.Background()
+	start := time.Unix(1_700_000_000, 0)
+
+	steps := []struct {
+		at   time.Duration
+		want int64
+	}{
+		{0, 1},
+		{0, 2}, // simultaneous events both count
+		{30 * time.Second, 3},
+		{60 * time.Second, 2}, // the first two are a minute old
+		{150 * time.Second, 1},
+	}
+	for _, s := range steps {
+		got, err := c.WindowAdd(ctx, "win", start.Add(s.at), time.Minute)
+		if err != nil {
+			t.Fatalf("WindowAdd at +%v: %v", s.at, err)
+		}
+		if got != s.want {
+			t.Errorf("WindowAdd at +%v = %d, want %d", s.at, got, s.want)
+		}
+	}
+}
+
+func TestXAddXRange(t *testing.T) {
+	c := newTestClient(t, -1, nil)
+	ctx := context.Background()
+
+	for _, v := range []string{"a", "b", "c"} {
+		if _, err := c.XAdd(ctx, "stream", 2, map[string]string{"v": v}); err != nil {
+			t.Fatalf("XAdd(%s): %v", v, err)
+		}
+	}
+
+	entries, err := c.XRange(ctx, "stream", "-", "+", 0)
+	if err != nil {
+		t.Fatalf("XRange: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Fields["v"] != "b" || entries[1].Fields["v"] != "c" {
+		t.Fatalf("XRange = %+v, want the newest 2 entries b, c", entries)
+	}
+
+	entries, err = c.XRange(ctx, "stream", "-", "+", 1)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("XRange COUNT 1 = %+v, %v", entries, err)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	c := newTestClient(t, -1, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	got := make(chan string, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Subscribe(ctx, "events", func(b []byte) { got <- string(b) })
+	}()
+
+	// The subscription is registered asynchronously; publish until it lands.
+	deadline := time.After(2 * time.Second)
+	for delivered := false; !delivered; {
+		if err := c.Publish(context.Background(), "events", map[string]string{"k": "v"}); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+		select {
+		case msg := <-got:
+			if msg != `{"k":"v"}` {
+				t.Fatalf("message = %s, want the JSON-encoded payload", msg)
+			}
+			delivered = true
+		case <-time.After(20 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("no message delivered")
+		}
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Subscribe after cancel = %v, want nil", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Subscribe did not return after cancel")
+	}
+}
+
+func TestNew_TLS(t *testing.T) {
+	// httptest's certificate is valid for 127.0.0.1.
+	srv := httptest.NewTLSServer(nil)
+	t.Cleanup(srv.Close)
+	mr := miniredis.NewMiniRedis()
+	if err := mr.StartTLS(srv.TLS); err != nil {
+		t.Fatalf("StartTLS: %v", err)
+	}
+	t.Cleanup(mr.Close)
+	port, _ := strconv.Atoi(mr.Port())
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0o600); err != nil {
+		t.Fatalf("write CA: %v", err)
+	}
+	cfg := Config{Host: "127.0.0.1", Port: port, TLSEnabled: true, TLSCAFile: caFile}
+
+	c, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New over TLS: %v", err)
+	}
+	defer c.Close() //nolint:errcheck // best-effort cleanup
+	if err := c.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping over TLS: %v", err)
+	}
+
+	untrusted := cfg
+	untrusted.TLSCAFile = ""
+	if _, err := New(untrusted); err == nil {
+		t.Error("New trusted a certificate outside the system roots")
+	}
+	untrusted.TLSSkipVerify = true
+	if c, err := New(untrusted); err != nil {
+		t.Errorf("New with TLSSkipVerify: %v", err)
+	} else {
+		_ = c.Close()
+	}
+
+	missing := cfg
+	missing.TLSCAFile = filepath.Join(t.TempDir(), "none.pem")
+	if _, err := New(missing); err == nil || !strings.Contains(err.Error(), "redis TLS") {
+		t.Errorf("missing CA file err = %v, want a redis TLS error", err)
+	}
+}
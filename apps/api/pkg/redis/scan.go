@@ -0,0 +1,120 @@
+package redis
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gomodule/redigo/redis"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultScanCount is the SCAN COUNT hint used when the config sets none.
+const DefaultScanCount = 100
+
+// ErrUnsafePattern is returned by DeleteByPattern for a pattern that does not
+// start with a literal prefix, such as "*" or "?ser:*", which could match
+// keys of any kind.
+var ErrUnsafePattern = errors.New("redis: pattern has no literal prefix")
+
+// ScanKeys calls fn with each batch of keys matching pattern, walking the
+// keyspace with SCAN so Redis is never blocked the way KEYS would. A key may
+// be passed more than once, and keys added or removed during the walk may
+// be missed. In cluster mode it walks every master in turn. It stops at the
+// first error from fn or when ctx ends.
+func (c *Client) ScanKeys(ctx context.Context, pattern string, fn func(keys []string) error) error {
+	ctx, span := tracer.Start(ctx, "redis.ScanKeys",
+		trace.WithAttributes(attribute.String("redis.pattern", pattern)))
+	defer span.End()
+
+	err := c.eachNode(func(conn redis.Conn) error {
+		return c.scan(ctx, conn, pattern, fn)
+	})
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+	return nil
+}
+
+// DeleteByPattern deletes the keys matching pattern, one DEL per SCAN batch,
+// and returns how many it deleted. The pattern must start with a literal
+// prefix (e.g. "users:list:*"), else it returns ErrUnsafePattern. Keys
+// written while it runs may survive.
+func (c *Client) DeleteByPattern(ctx context.Context, pattern string) (int64, error) {
+	ctx, span := tracer.Start(ctx, "redis.DeleteByPattern",
+		trace.WithAttributes(attribute.String("redis.pattern", pattern)))
+	defer span.End()
+
+	if literalPrefix(pattern) == "" {
+		span.RecordError(ErrUnsafePattern)
+		return 0, ErrUnsafePattern
+	}
+
+	var deleted int64
+	err := c.eachNode(func(conn redis.Conn) error {
+		return c.scan(ctx, conn, pattern, func(keys []string) error {
+			// A node's keys may span several of its hash slots.
+			for _, idx := range c.slotGroups(keys) {
+				n, err := redis.Int64(c.do(ctx, conn, "DEL", keyArgs(keys, idx)...))
+				deleted += n
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+	span.SetAttributes(attribute.Int64("redis.deleted", deleted))
+	if err != nil {
+		span.RecordError(err)
+	}
+	return deleted, err
+}
+
+// scan runs SCAN on conn until the cursor wraps, passing each non-empty
+// batch to fn.
+func (c *Client) scan(ctx context.Context, conn redis.Conn, pattern string, fn func(keys []string) error) error {
+	// The cursor is passed as a number so the slow log never reports it as
+	// a key.
+	var cursor int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		reply, err := redis.Values(c.do(ctx, conn, "SCAN", cursor, "MATCH", pattern, "COUNT", c.scanCount))
+		if err != nil {
+			return err
+		}
+		var keys []string
+		if _, err := redis.Scan(reply, &cursor, &keys); err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			if err := fn(keys); err != nil {
+				return err
+			}
+		}
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
+// literalPrefix returns the part of a glob-style pattern before its first
+// unescaped *, ? or [.
+func literalPrefix(pattern string) string {
+	prefix := make([]byte, 0, len(pattern))
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '*', '?', '[':
+			return string(prefix)
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+		}
+		prefix = append(prefix, pattern[i])
+	}
+	return string(prefix)
+}
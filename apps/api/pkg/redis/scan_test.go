@@ -0,0 +1,96 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+)
+
+func TestDeleteByPattern_DeletesOnlyMatchingKeys(t *testing.T) {
+	c := newTestClient(t, -1, nil)
+	c.scanCount = 3 // force several SCAN batches
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		if err := c.Set(ctx, fmt.Sprintf("users:list:%d", i), i, 0); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+	if err := c.Set(ctx, "user:1", "keep", 0); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	n, err := c.DeleteByPattern(ctx, "users:list:*")
+	if err != nil {
+		t.Fatalf("DeleteByPattern: %v", err)
+	}
+	if n != 10 {
+		t.Errorf("deleted %d keys, want 10", n)
+	}
+	if ok, _ := c.Exists(ctx, "user:1"); !ok {
+		t.Error("non-matching key deleted")
+	}
+	if ok, _ := c.Exists(ctx, "users:list:3"); ok {
+		t.Error("matching key survived")
+	}
+}
+
+func TestDeleteByPattern_RefusesPatternWithoutLiteralPrefix(t *testing.T) {
+	c := newTestClient(t, -1, nil)
+	if err := c.Set(context.Background(), "user:1", "keep", 0); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	for _, pattern := range []string{"", "*", "?ser:*", "[u]ser:*"} {
+		if _, err := c.DeleteByPattern(context.Background(), pattern); !errors.Is(err, ErrUnsafePattern) {
+			t.Errorf("DeleteByPattern(%q) err = %v, want ErrUnsafePattern", pattern, err)
+		}
+	}
+	if ok, _ := c.Exists(context.Background(), "user:1"); !ok {
+		t.Error("key deleted by a refused pattern")
+	}
+}
+
+func TestScanKeys(t *testing.T) {
+	c := newTestClient(t, -1, nil)
+	c.scanCount = 2
+	ctx := context.Background()
+	for _, key := range []string{"a:1", "a:2", "a:3", "b:1"} {
+		if err := c.Set(ctx, key, 1, 0); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+
+	var got []string
+	if err := c.ScanKeys(ctx, "a:*", func(keys []string) error {
+		got = append(got, keys...)
+		return nil
+	}); err != nil {
+		t.Fatalf("ScanKeys: %v", err)
+	}
+	sort.Strings(got)
+	if fmt.Sprint(got) != "[a:1 a:2 a:3]" {
+		t.Errorf("ScanKeys = %v, want [a:1 a:2 a:3]", got)
+	}
+
+	stop := errors.New("stop")
+	if err := c.ScanKeys(ctx, "a:*", func([]string) error { return stop }); !errors.Is(err, stop) {
+		t.Errorf("ScanKeys err = %v, want the callback's", err)
+	}
+}
+
+func TestLiteralPrefix(t *testing.T) {
+	for pattern, want := range map[string]string{
+		"users:list:*": "users:list:",
+		"user:?":       "user:",
+		"*":            "",
+		`a\*b*`:        "a*b",
+		"plain":        "plain",
+	} {
+		if got := literalPrefix(pattern); got != want {
+			t.Errorf("literalPrefix(%q) = %q, want %q", pattern, got, want)
+		}
+	}
+}
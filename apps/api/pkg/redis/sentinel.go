@@ -0,0 +1,89 @@
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// sentinel finds the current master of a Sentinel-monitored deployment.
+type sentinel struct {
+	addrs    []string
+	master   string
+	dialOpts []redis.DialOption
+}
+
+// masterAddr asks each sentinel in turn for the master's address, returning
+// the first answer.
+func (s *sentinel) masterAddr() (string, error) {
+	var errs []error
+	for _, addr := range s.addrs {
+		addr, err := s.query(addr)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		return addr, nil
+	}
+	return "", fmt.Errorf("redis: no sentinel knows master %q: %w", s.master, errors.Join(errs...))
+}
+
+func (s *sentinel) query(sentinelAddr string) (string, error) {
+	conn, err := redis.Dial("tcp", sentinelAddr, s.dialOpts...)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close() //nolint:errcheck // best-effort cleanup
+
+	reply, err := redis.Strings(conn.Do("SENTINEL", "get-master-addr-by-name", s.master))
+	if err != nil {
+		return "", fmt.Errorf("sentinel %s: %w", sentinelAddr, err)
+	}
+	if len(reply) != 2 {
+		return "", fmt.Errorf("sentinel %s: unexpected master address %q", sentinelAddr, reply)
+	}
+	return net.JoinHostPort(reply[0], reply[1]), nil
+}
+
+// dial returns a dialer that connects to whichever node the sentinels name
+// master, so a new connection follows a failover.
+func (s *sentinel) dial(cfg Config, opts []redis.DialOption) func() (redis.Conn, error) {
+	return func() (redis.Conn, error) {
+		addr, err := s.masterAddr()
+		if err != nil {
+			return nil, err
+		}
+		c, err := dialNode(addr, cfg, opts)()
+		if err != nil {
+			return nil, err
+		}
+		// The sentinels may not have noticed a failover yet.
+		if err := checkRole(c, time.Time{}); err != nil {
+			c.Close() //nolint:errcheck // best-effort cleanup on a demoted master
+			return nil, fmt.Errorf("%s: %w", addr, err)
+		}
+		return c, nil
+	}
+}
+
+// checkRole fails a connection whose server is no longer the master, so the
+// pool drops connections to a demoted master after a failover instead of
+// handing them out for writes that would fail with READONLY. It costs a
+// ROLE round trip per borrow.
+func checkRole(c redis.Conn, _ time.Time) error {
+	reply, err := redis.Values(c.Do("ROLE"))
+	if err != nil {
+		return err
+	}
+	var role string
+	if _, err := redis.Scan(reply, &role); err != nil {
+		return err
+	}
+	if role != "master" {
+		return fmt.Errorf("redis: server is a %s, not the master", role)
+	}
+	return nil
+}
@@ -8,6 +8,8 @@ import (
 	"net/http"
 	"time"
 
+	pkglogger "veemon/pkg/logger"
+
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.uber.org/zap"
 )
@@ -70,7 +72,8 @@ func NewHTTPClient(name string, cfg HTTPClientConfig, logger *zap.Logger) *HTTPC
 
 // Do executes an HTTP request with resilience. The request body is buffered so
 // that each retry attempt can re-send it — without this, the body is consumed on
-// the first attempt and every retry would send an empty body.
+// the first attempt and every retry would send an empty body. The request ID
+// in ctx, if any, is sent as X-Request-ID unless req already sets one.
 func (c *HTTPClient) Do(ctx context.Context, req *http.Request) (*http.Response, error) {
 	var bodyBytes []byte
 	if req.Body != nil {
@@ -86,6 +89,9 @@ func (c *HTTPClient) Do(ctx context.Context, req *http.Request) (*http.Response,
 		// Clone per attempt so headers/context are not mutated across retries,
 		// and attach a fresh body reader from the buffered bytes.
 		attempt := req.Clone(ctx)
+		if requestID := pkglogger.RequestIDFromContext(ctx); requestID != "" && attempt.Header.Get(pkglogger.RequestIDHeader) == "" {
+			attempt.Header.Set(pkglogger.RequestIDHeader, requestID)
+		}
 		if bodyBytes != nil {
 			attempt.Body = io.NopCloser(bytes.NewReader(bodyBytes))
 			attempt.ContentLength = int64(len(bodyBytes))
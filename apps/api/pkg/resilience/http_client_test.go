@@ -0,0 +1,41 @@
+package resilience
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"veemon/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestHTTPClientDo_ForwardsRequestID(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get(logger.RequestIDHeader))
+	}))
+	defer srv.Close()
+	client := NewHTTPClient("test", DefaultHTTPClientConfig(), zap.NewNop())
+	ctx := logger.ContextWithRequestID(context.Background(), "req-1")
+
+	resp, err := client.Get(ctx, srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close() //nolint:errcheck // test cleanup
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set(logger.RequestIDHeader, "caller")
+	resp, err = client.Do(ctx, req)
+	require.NoError(t, err)
+	resp.Body.Close() //nolint:errcheck // test cleanup
+
+	resp, err = client.Get(context.Background(), srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close() //nolint:errcheck // test cleanup
+
+	assert.Equal(t, []string{"req-1", "caller", ""}, got)
+}
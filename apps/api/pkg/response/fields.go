@@ -0,0 +1,130 @@
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/protobuf/proto"
+)
+
+// ParseFields splits a sparse-fieldset parameter ("id,name,email") into its
+// trimmed, de-duplicated names. An empty parameter yields nil.
+func ParseFields(fields string) []string {
+	if strings.TrimSpace(fields) == "" {
+		return nil
+	}
+	var out []string
+	seen := map[string]bool{}
+	for _, f := range strings.Split(fields, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" || seen[f] {
+			continue
+		}
+		seen[f] = true
+		out = append(out, f)
+	}
+	return out
+}
+
+// SuccessProtoFields is SuccessProto restricted to the comma-separated json
+// field names in fields. Unlisted fields are left out of the JSON rather than
+// sent as zero values; an empty fields behaves like SuccessProto.
+func SuccessProtoFields(c *fiber.Ctx, msg proto.Message, fields string) error {
+	keep := fieldSet(fields)
+	if keep == nil {
+		return SuccessProto(c, msg)
+	}
+	raw, err := marshalProtoFields(msg, keep)
+	if err != nil {
+		return InternalError(c, 500, "failed to encode response")
+	}
+	return c.JSON(Response{Success: true, Data: raw})
+}
+
+// SuccessProtoListFields is SuccessProtoList with each item restricted to
+// fields. The meta payload is never filtered.
+func SuccessProtoListFields(c *fiber.Ctx, msgs []proto.Message, meta proto.Message, fields string) error {
+	keep := fieldSet(fields)
+	if keep == nil {
+		return SuccessProtoList(c, msgs, meta)
+	}
+
+	items := make([]json.RawMessage, len(msgs))
+	for i, m := range msgs {
+		raw, err := marshalProtoFields(m, keep)
+		if err != nil {
+			return InternalError(c, 500, "failed to encode response")
+		}
+		items[i] = raw
+	}
+
+	resp := Response{Success: true, Data: items}
+	if meta != nil {
+		metaRaw, err := marshalProto(meta)
+		if err != nil {
+			return InternalError(c, 500, "failed to encode response")
+		}
+		resp.Meta = metaRaw
+	}
+	return c.JSON(resp)
+}
+
+func fieldSet(fields string) map[string]bool {
+	names := ParseFields(fields)
+	if len(names) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(names))
+	for _, n := range names {
+		set[n] = true
+	}
+	return set
+}
+
+func marshalProtoFields(msg proto.Message, keep map[string]bool) (json.RawMessage, error) {
+	raw, err := marshalProto(msg)
+	if err != nil {
+		return nil, err
+	}
+	return filterObject(raw, keep)
+}
+
+// filterObject copies the members of a JSON object whose keys are in keep,
+// preserving their order (protojson emits fields in declaration order).
+func filterObject(raw json.RawMessage, keep map[string]bool) (json.RawMessage, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("expected JSON object")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	first := true
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		if !keep[key] {
+			continue
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		k, _ := json.Marshal(key)
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return json.RawMessage(buf.Bytes()), nil
+}
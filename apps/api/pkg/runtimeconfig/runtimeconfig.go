@@ -0,0 +1,368 @@
+// Package runtimeconfig holds operational knobs that can change while the
+// service runs, such as rate limits. Every known key is declared up front in
+// a schema with its type, compiled-in default and validation.
+//
+// Overrides live in one Redis hash shared by every replica. Each replica
+// keeps them in memory, so reads never touch Redis, and a write publishes the
+// change on a channel that every replica's Run applies at once. While Redis
+// is unreachable the getters serve the last values loaded, or the defaults.
+package runtimeconfig
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"veemon/pkg/redis"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultHash is the Redis hash holding the overrides.
+	DefaultHash = "runtime_config"
+	// DefaultChannel is the Redis channel changes are published on.
+	DefaultChannel = "runtime_config:changed"
+)
+
+const (
+	runMinBackoff = 1 * time.Second
+	runMaxBackoff = 30 * time.Second
+)
+
+// Type is the type of a key's value.
+type Type string
+
+const (
+	Int      Type = "int"
+	Bool     Type = "bool"
+	Duration Type = "duration" // Go syntax, e.g. "90s" or "1m30s"
+	String   Type = "string"
+)
+
+// Key declares one knob.
+type Key struct {
+	Name        string
+	Type        Type
+	Default     string
+	Description string
+	// Validate, when set, checks a value that already parses as Type.
+	Validate func(string) error
+}
+
+// IntRange accepts integers from min to max inclusive.
+func IntRange(min, max int) func(string) error {
+	return func(v string) error {
+		n, _ := strconv.Atoi(v)
+		if n < min || n > max {
+			return fmt.Errorf("must be between %d and %d", min, max)
+		}
+		return nil
+	}
+}
+
+// DurationRange accepts durations from min to max inclusive.
+func DurationRange(min, max time.Duration) func(string) error {
+	return func(v string) error {
+		d, _ := time.ParseDuration(v)
+		if d < min || d > max {
+			return fmt.Errorf("must be between %s and %s", min, max)
+		}
+		return nil
+	}
+}
+
+// ErrUnavailable is returned by Update when the overrides cannot be written,
+// because there is no Redis client or Redis failed.
+var ErrUnavailable = errors.New("runtime config store unavailable")
+
+// UnknownKeyError is returned by Update for a key missing from the schema.
+type UnknownKeyError struct {
+	Key string
+}
+
+func (e *UnknownKeyError) Error() string {
+	return fmt.Sprintf("unknown runtime config key %q", e.Key)
+}
+
+// InvalidValueError is returned by Update for a value its key rejects.
+type InvalidValueError struct {
+	Key    string
+	Value  string
+	Reason string
+}
+
+func (e *InvalidValueError) Error() string {
+	return fmt.Sprintf("invalid value %q for %s: %s", e.Value, e.Key, e.Reason)
+}
+
+// Entry is a key with the value in effect.
+type Entry struct {
+	Key
+	Value string
+	// Overridden reports whether Value comes from the store rather than
+	// Key.Default.
+	Overridden bool
+}
+
+// change is the message published for every write; a nil Value means the
+// key was reset to its default.
+type change struct {
+	Key   string  `json:"key"`
+	Value *string `json:"value"`
+}
+
+// Store serves the runtime config of one replica.
+type Store struct {
+	client *redis.Client
+	keys   map[string]Key
+	logger *zap.Logger
+
+	mu        sync.RWMutex
+	overrides map[string]string
+}
+
+// New returns a store for schema, holding only defaults until Load or Run.
+// client may be nil, which makes the defaults final. New panics on an
+// invalid schema, as that is a programming error.
+func New(client *redis.Client, schema []Key, logger *zap.Logger) *Store {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	keys := make(map[string]Key, len(schema))
+	for _, k := range schema {
+		if _, dup := keys[k.Name]; dup {
+			panic("runtimeconfig: duplicate key " + k.Name)
+		}
+		if err := k.check(k.Default); err != nil {
+			panic(fmt.Sprintf("runtimeconfig: default of %s: %v", k.Name, err))
+		}
+		keys[k.Name] = k
+	}
+	return &Store{client: client, keys: keys, logger: logger, overrides: map[string]string{}}
+}
+
+// check reports whether v is a valid value for k.
+func (k Key) check(v string) error {
+	var err error
+	switch k.Type {
+	case Int:
+		_, err = strconv.Atoi(v)
+	case Bool:
+		_, err = strconv.ParseBool(v)
+	case Duration:
+		_, err = time.ParseDuration(v)
+	case String:
+	default:
+		return fmt.Errorf("unknown type %q", k.Type)
+	}
+	if err != nil {
+		return fmt.Errorf("not a valid %s", k.Type)
+	}
+	if k.Validate != nil {
+		return k.Validate(v)
+	}
+	return nil
+}
+
+// Load replaces the overrides with those in Redis. On error the previous
+// ones stay in effect.
+func (s *Store) Load(ctx context.Context) error {
+	if s.client == nil {
+		return ErrUnavailable
+	}
+	raw, err := s.client.HGetAll(ctx, DefaultHash)
+	if err != nil {
+		return err
+	}
+	overrides := make(map[string]string, len(raw))
+	for name, encoded := range raw {
+		var v string
+		if err := json.Unmarshal([]byte(encoded), &v); err != nil {
+			s.logger.Warn("ignoring malformed runtime config value", zap.String("key", name), zap.Error(err))
+			continue
+		}
+		overrides[name] = v
+	}
+	s.mu.Lock()
+	s.overrides = overrides
+	s.mu.Unlock()
+	return nil
+}
+
+// Run keeps the overrides current until ctx is cancelled: it loads them,
+// then applies every change published by any replica, reloading and
+// re-subscribing with backoff when the connection drops.
+func (s *Store) Run(ctx context.Context) {
+	if s.client == nil {
+		return
+	}
+	backoff := runMinBackoff
+	for {
+		start := time.Now()
+		// Load on every (re)subscription so changes published while
+		// disconnected are not missed.
+		if err := s.Load(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Warn("runtime config load failed; keeping current values", zap.Error(err))
+		}
+		err := s.client.Subscribe(ctx, DefaultChannel, s.apply)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(start) > runMaxBackoff {
+			backoff = runMinBackoff
+		}
+		s.logger.Warn("runtime config subscription lost; retrying",
+			zap.String("channel", DefaultChannel), zap.Error(err), zap.Duration("backoff", backoff))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > runMaxBackoff {
+			backoff = runMaxBackoff
+		}
+	}
+}
+
+func (s *Store) apply(b []byte) {
+	var c change
+	if err := json.Unmarshal(b, &c); err != nil {
+		s.logger.Warn("dropping malformed runtime config change", zap.Error(err))
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c.Value == nil {
+		delete(s.overrides, c.Key)
+	} else {
+		s.overrides[c.Key] = *c.Value
+	}
+}
+
+// Update validates every value in set and every key in reset, then writes
+// set and deletes reset, which reverts those keys to their defaults. Nothing
+// is written unless all are valid. Each change is audit-logged with actorID.
+func (s *Store) Update(ctx context.Context, set map[string]string, reset []string, actorID string) error {
+	for _, name := range reset {
+		if _, ok := s.keys[name]; !ok {
+			return &UnknownKeyError{Key: name}
+		}
+	}
+	names := make([]string, 0, len(set))
+	for name, v := range set {
+		k, ok := s.keys[name]
+		if !ok {
+			return &UnknownKeyError{Key: name}
+		}
+		if err := k.check(v); err != nil {
+			return &InvalidValueError{Key: name, Value: v, Reason: err.Error()}
+		}
+		names = append(names, name)
+	}
+	if s.client == nil {
+		return ErrUnavailable
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		v := set[name]
+		if err := s.client.HSet(ctx, DefaultHash, name, v); err != nil {
+			return fmt.Errorf("%w: %v", ErrUnavailable, err)
+		}
+		s.changed(ctx, name, &v, actorID)
+	}
+	for _, name := range reset {
+		if err := s.client.HDel(ctx, DefaultHash, name); err != nil {
+			return fmt.Errorf("%w: %v", ErrUnavailable, err)
+		}
+		s.changed(ctx, name, nil, actorID)
+	}
+	return nil
+}
+
+// changed applies a written change locally, publishes it to the other
+// replicas and records it in the audit log.
+func (s *Store) changed(ctx context.Context, name string, value *string, actorID string) {
+	old := s.String(name)
+	c := change{Key: name, Value: value}
+	b, _ := json.Marshal(c)
+	s.apply(b)
+	if err := s.client.Publish(ctx, DefaultChannel, c); err != nil {
+		// Replicas still pick the value up when they next reload.
+		s.logger.Warn("runtime config change not published", zap.String("key", name), zap.Error(err))
+	}
+
+	newValue := s.String(name)
+	s.logger.Info("audit: runtime config changed",
+		zap.String("audit_event", "runtime_config.update"),
+		zap.String("actor_id", actorID),
+		zap.String("key", name),
+		zap.String("old_value", old),
+		zap.String("new_value", newValue),
+		zap.Bool("reset", value == nil),
+	)
+}
+
+// Entries returns every key with the value in effect, ordered by name.
+func (s *Store) Entries() []Entry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Entry, 0, len(s.keys))
+	for _, k := range s.keys {
+		v, overridden := s.valueLocked(k)
+		out = append(out, Entry{Key: k, Value: v, Overridden: overridden})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// valueLocked returns k's override if it is valid, else its default.
+func (s *Store) valueLocked(k Key) (string, bool) {
+	if v, ok := s.overrides[k.Name]; ok && k.check(v) == nil {
+		return v, true
+	}
+	return k.Default, false
+}
+
+// value returns the value in effect for name, panicking unless name is a
+// key of type t.
+func (s *Store) value(name string, t Type) string {
+	k, ok := s.keys[name]
+	if !ok || (t != String && k.Type != t) {
+		panic(fmt.Sprintf("runtimeconfig: %s is not a declared %s key", name, t))
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, _ := s.valueLocked(k)
+	return v
+}
+
+// Int returns the value of an Int key.
+func (s *Store) Int(name string) int {
+	n, _ := strconv.Atoi(s.value(name, Int))
+	return n
+}
+
+// Bool returns the value of a Bool key.
+func (s *Store) Bool(name string) bool {
+	b, _ := strconv.ParseBool(s.value(name, Bool))
+	return b
+}
+
+// Duration returns the value of a Duration key.
+func (s *Store) Duration(name string) time.Duration {
+	d, _ := time.ParseDuration(s.value(name, Duration))
+	return d
+}
+
+// String returns the value of any key in its string form.
+func (s *Store) String(name string) string {
+	return s.value(name, String)
+}
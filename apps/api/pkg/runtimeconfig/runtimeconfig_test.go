@@ -0,0 +1,163 @@
+package runtimeconfig
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"veemon/pkg/redis"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+var testSchema = []Key{
+	{Name: "limit.max", Type: Int, Default: "100", Validate: IntRange(1, 1000)},
+	{Name: "limit.window", Type: Duration, Default: "1m", Validate: DurationRange(time.Second, time.Hour)},
+	{Name: "feature.on", Type: Bool, Default: "false"},
+	{Name: "banner", Type: String, Default: ""},
+}
+
+func newRedis(t *testing.T, mr *miniredis.Miniredis) *redis.Client {
+	t.Helper()
+	port, _ := strconv.Atoi(mr.Port())
+	client, err := redis.New(redis.Config{Host: mr.Host(), Port: port, MaxIdle: 2, MaxActive: 8})
+	if err != nil {
+		t.Fatalf("redis.New: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func TestStore_DefaultsWithoutOverrides(t *testing.T) {
+	s := New(nil, testSchema, nil)
+	if s.Int("limit.max") != 100 || s.Duration("limit.window") != time.Minute || s.Bool("feature.on") || s.String("banner") != "" {
+		t.Fatalf("defaults not served: %+v", s.Entries())
+	}
+	if err := s.Update(context.Background(), map[string]string{"limit.max": "5"}, nil, "admin"); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("Update without Redis = %v, want ErrUnavailable", err)
+	}
+}
+
+func TestStore_UpdateValidatesEveryKeyFirst(t *testing.T) {
+	s := New(newRedis(t, miniredis.RunT(t)), testSchema, nil)
+	ctx := context.Background()
+
+	var unknown *UnknownKeyError
+	if err := s.Update(ctx, map[string]string{"limit.max": "5", "nope": "1"}, nil, "admin"); !errors.As(err, &unknown) || unknown.Key != "nope" {
+		t.Fatalf("unknown key: err = %v", err)
+	}
+	if err := s.Update(ctx, nil, []string{"nope"}, "admin"); !errors.As(err, &unknown) {
+		t.Fatalf("unknown reset key: err = %v", err)
+	}
+	var invalid *InvalidValueError
+	for _, v := range []string{"many", "0", "1001"} {
+		if err := s.Update(ctx, map[string]string{"limit.max": v}, nil, "admin"); !errors.As(err, &invalid) {
+			t.Fatalf("limit.max=%s: err = %v, want InvalidValueError", v, err)
+		}
+	}
+	if s.Int("limit.max") != 100 {
+		t.Fatal("a rejected update changed a value")
+	}
+
+	if err := s.Update(ctx, map[string]string{"limit.max": "5", "feature.on": "true"}, nil, "admin"); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if s.Int("limit.max") != 5 || !s.Bool("feature.on") {
+		t.Fatalf("update not applied locally: %+v", s.Entries())
+	}
+	if err := s.Update(ctx, nil, []string{"limit.max"}, "admin"); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	if s.Int("limit.max") != 100 {
+		t.Fatal("reset did not restore the default")
+	}
+}
+
+func TestStore_ChangesReachOtherReplicasWithinASecond(t *testing.T) {
+	mr := miniredis.RunT(t)
+	writer := New(newRedis(t, mr), testSchema, nil)
+	reader := New(newRedis(t, mr), testSchema, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go reader.Run(ctx)
+
+	// Run subscribes asynchronously; write until the reader is listening,
+	// then time one change end to end.
+	deadline := time.Now().Add(2 * time.Second)
+	for reader.Int("limit.max") != 7 {
+		if time.Now().After(deadline) {
+			t.Fatal("reader never subscribed")
+		}
+		if err := writer.Update(ctx, map[string]string{"limit.max": "7"}, nil, "admin"); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	start := time.Now()
+	if err := writer.Update(ctx, map[string]string{"limit.window": "30s"}, nil, "admin"); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	for reader.Duration("limit.window") != 30*time.Second {
+		if time.Since(start) > time.Second {
+			t.Fatal("change took longer than a second to propagate")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestStore_LoadKeepsValuesWhenRedisIsDown(t *testing.T) {
+	mr := miniredis.RunT(t)
+	s := New(newRedis(t, mr), testSchema, nil)
+	ctx := context.Background()
+	if err := s.Update(ctx, map[string]string{"limit.max": "9"}, nil, "admin"); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	// A fresh replica starting while Redis is down serves the defaults, and
+	// one that already loaded keeps its values.
+	fresh := New(newRedis(t, mr), testSchema, nil)
+	mr.Close()
+	if err := fresh.Load(ctx); err == nil {
+		t.Fatal("Load succeeded with Redis down")
+	}
+	if err := s.Load(ctx); err == nil {
+		t.Fatal("Load succeeded with Redis down")
+	}
+	if fresh.Int("limit.max") != 100 || s.Int("limit.max") != 9 {
+		t.Fatalf("fresh = %d, loaded = %d; want 100 and 9", fresh.Int("limit.max"), s.Int("limit.max"))
+	}
+	if err := s.Update(ctx, map[string]string{"limit.max": "10"}, nil, "admin"); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("Update with Redis down = %v, want ErrUnavailable", err)
+	}
+}
+
+func TestStore_InvalidStoredValueFallsBackToDefault(t *testing.T) {
+	mr := miniredis.RunT(t)
+	mr.HSet(DefaultHash, "limit.max", `"lots"`)
+	s := New(newRedis(t, mr), testSchema, nil)
+	if err := s.Load(context.Background()); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if s.Int("limit.max") != 100 {
+		t.Fatalf("limit.max = %d, want the default", s.Int("limit.max"))
+	}
+}
+
+func TestNew_PanicsOnInvalidSchema(t *testing.T) {
+	for name, schema := range map[string][]Key{
+		"duplicate":       {{Name: "a", Type: Int, Default: "1"}, {Name: "a", Type: Int, Default: "1"}},
+		"invalid default": {{Name: "a", Type: Int, Default: "x"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("New did not panic")
+				}
+			}()
+			New(nil, schema, nil)
+		})
+	}
+}
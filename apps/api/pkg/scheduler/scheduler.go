@@ -0,0 +1,102 @@
+// Package scheduler runs periodic background jobs in-process.
+//
+// Each job runs on its own goroutine, one run at a time: a run that outlasts
+// its interval delays the next one rather than overlapping it. Jobs are not
+// coordinated across processes, so with several replicas every replica runs
+// them; a job must therefore be safe to run concurrently with itself.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type job struct {
+	name     string
+	interval time.Duration
+	run      func(ctx context.Context) error
+}
+
+// Scheduler runs registered jobs every interval until stopped.
+type Scheduler struct {
+	log    *zap.Logger
+	jobs   []job
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New builds an empty scheduler. A nil log discards job logs.
+func New(log *zap.Logger) *Scheduler {
+	if log == nil {
+		log = zap.NewNop()
+	}
+	return &Scheduler{log: log}
+}
+
+// Every registers run to be called every interval, starting one interval
+// after Start. It must be called before Start.
+func (s *Scheduler) Every(name string, interval time.Duration, run func(ctx context.Context) error) {
+	s.jobs = append(s.jobs, job{name: name, interval: interval, run: run})
+}
+
+// Start launches every registered job. Jobs stop when ctx is cancelled or
+// Stop is called.
+func (s *Scheduler) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+	for _, j := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, j)
+	}
+}
+
+// Stop cancels the jobs and waits for in-flight runs to return, up to ctx's
+// deadline.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("scheduled jobs did not stop: %w", ctx.Err())
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, j job) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.runOnce(ctx, j)
+		}
+	}
+}
+
+// runOnce runs j, logging a failure or panic instead of ending the loop.
+func (s *Scheduler) runOnce(ctx context.Context, j job) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.log.Error("scheduled job panicked", zap.String("job", j.name), zap.Any("panic", r))
+		}
+	}()
+	start := time.Now()
+	if err := j.run(ctx); err != nil {
+		s.log.Error("scheduled job failed", zap.String("job", j.name), zap.Error(err))
+		return
+	}
+	s.log.Debug("scheduled job finished", zap.String("job", j.name), zap.Duration("duration", time.Since(start)))
+}
@@ -0,0 +1,81 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScheduler_RunsJobRepeatedlyDespiteFailures(t *testing.T) {
+	s := New(nil)
+	var runs atomic.Int32
+	s.Every("flaky", time.Millisecond, func(context.Context) error {
+		if runs.Add(1)%2 == 0 {
+			panic("boom")
+		}
+		return errors.New("failed")
+	})
+
+	s.Start(context.Background())
+	deadline := time.Now().Add(time.Second)
+	for runs.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if runs.Load() < 3 {
+		t.Fatalf("runs = %d, want at least 3", runs.Load())
+	}
+}
+
+func TestScheduler_StopWaitsForInFlightRun(t *testing.T) {
+	s := New(nil)
+	started := make(chan struct{})
+	var finished atomic.Bool
+	s.Every("slow", time.Millisecond, func(ctx context.Context) error {
+		select {
+		case started <- struct{}{}:
+		default:
+			return nil
+		}
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		finished.Store(true)
+		return nil
+	})
+
+	s.Start(context.Background())
+	<-started
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if !finished.Load() {
+		t.Fatal("Stop returned before the in-flight run finished")
+	}
+}
+
+func TestScheduler_StopGivesUpAtDeadline(t *testing.T) {
+	s := New(nil)
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	defer close(release)
+	s.Every("stuck", time.Millisecond, func(context.Context) error {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+		return nil
+	})
+
+	s.Start(context.Background())
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Stop = %v, want deadline exceeded", err)
+	}
+}
@@ -0,0 +1,101 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// OTLP/HTTP paths of each signal, below the collector's base URL.
+const (
+	tracesPath  = "/v1/traces"
+	metricsPath = "/v1/metrics"
+)
+
+// httpClient is an otlptrace.Client that posts spans to a collector as
+// OTLP/HTTP protobuf; the metric exporter posts through it too.
+type httpClient struct {
+	base    string
+	headers map[string]string
+	client  *http.Client
+}
+
+func newHTTPClient(endpoint string, headers map[string]string) *httpClient {
+	return &httpClient{
+		base:    collectorURL(endpoint),
+		headers: headers,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// collectorURL turns OTEL_ENDPOINT into the collector's base URL: a bare
+// host:port means plain http, and a path, if any, prefixes the signal paths
+// (https://example.com/otlp receives https://example.com/otlp/v1/traces).
+func collectorURL(endpoint string) string {
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "http://" + endpoint
+	}
+	return strings.TrimSuffix(endpoint, "/")
+}
+
+func (c *httpClient) Start(context.Context) error { return nil }
+
+func (c *httpClient) Stop(context.Context) error {
+	c.client.CloseIdleConnections()
+	return nil
+}
+
+func (c *httpClient) UploadTraces(ctx context.Context, spans []*tracepb.ResourceSpans) error {
+	return c.post(ctx, tracesPath, &coltracepb.ExportTraceServiceRequest{ResourceSpans: spans})
+}
+
+func (c *httpClient) post(ctx context.Context, path string, msg proto.Message) error {
+	body, err := proto.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("otlphttp: marshal: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.base+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("otlphttp: %w", err)
+	}
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("otlphttp: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // best-effort cleanup
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512)) //nolint:errcheck // best-effort read for error message
+		return fmt.Errorf("otlphttp: collector returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body) // let the connection be reused
+	return nil
+}
+
+// hostPort returns the host:port of a collector URL.
+func hostPort(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	if u.Port() != "" {
+		return u.Host
+	}
+	if u.Scheme == "https" {
+		return u.Hostname() + ":443"
+	}
+	return u.Hostname() + ":80"
+}
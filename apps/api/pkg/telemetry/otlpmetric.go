@@ -0,0 +1,243 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// metricExporter is an sdkmetric.Exporter that sends metrics to a collector
+// over OTLP, with cumulative temporality (what Prometheus-style backends
+// expect) and the SDK's default aggregations.
+type metricExporter struct {
+	send func(context.Context, *colmetricpb.ExportMetricsServiceRequest) error
+	stop func() error
+}
+
+// newHTTPMetricExporter posts metrics through client.
+func newHTTPMetricExporter(client *httpClient) *metricExporter {
+	return &metricExporter{
+		send: func(ctx context.Context, req *colmetricpb.ExportMetricsServiceRequest) error {
+			return client.post(ctx, metricsPath, req)
+		},
+		stop: func() error { return client.Stop(context.Background()) },
+	}
+}
+
+// newGRPCMetricExporter sends metrics to the collector at endpoint over
+// plaintext gRPC, like the trace exporter. The connection is made lazily.
+func newGRPCMetricExporter(endpoint string, headers map[string]string) (*metricExporter, error) {
+	conn, err := grpc.NewClient(endpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	client := colmetricpb.NewMetricsServiceClient(conn)
+	md := metadata.New(headers)
+	return &metricExporter{
+		send: func(ctx context.Context, req *colmetricpb.ExportMetricsServiceRequest) error {
+			_, err := client.Export(metadata.NewOutgoingContext(ctx, md), req)
+			return err
+		},
+		stop: conn.Close,
+	}, nil
+}
+
+func (e *metricExporter) Temporality(k sdkmetric.InstrumentKind) metricdata.Temporality {
+	return sdkmetric.DefaultTemporalitySelector(k)
+}
+
+func (e *metricExporter) Aggregation(k sdkmetric.InstrumentKind) sdkmetric.Aggregation {
+	return sdkmetric.DefaultAggregationSelector(k)
+}
+
+func (e *metricExporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	if len(rm.ScopeMetrics) == 0 {
+		return nil
+	}
+	if err := e.send(ctx, &colmetricpb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*metricpb.ResourceMetrics{resourceMetrics(rm)},
+	}); err != nil {
+		return fmt.Errorf("export metrics: %w", err)
+	}
+	return nil
+}
+
+// ForceFlush is a no-op: Export sends synchronously.
+func (e *metricExporter) ForceFlush(context.Context) error { return nil }
+
+func (e *metricExporter) Shutdown(context.Context) error { return e.stop() }
+
+// resourceMetrics converts the SDK's metrics to OTLP. Exponential
+// histograms, which only a custom view produces, are dropped.
+func resourceMetrics(rm *metricdata.ResourceMetrics) *metricpb.ResourceMetrics {
+	out := &metricpb.ResourceMetrics{Resource: otlpResource(rm.Resource)}
+	for _, sm := range rm.ScopeMetrics {
+		scope := &metricpb.ScopeMetrics{
+			Scope: &commonpb.InstrumentationScope{Name: sm.Scope.Name, Version: sm.Scope.Version},
+		}
+		for _, m := range sm.Metrics {
+			if pm := otlpMetric(m); pm != nil {
+				scope.Metrics = append(scope.Metrics, pm)
+			}
+		}
+		out.ScopeMetrics = append(out.ScopeMetrics, scope)
+	}
+	return out
+}
+
+func otlpResource(res *resource.Resource) *resourcepb.Resource {
+	if res == nil {
+		return nil
+	}
+	iter := res.Iter()
+	return &resourcepb.Resource{Attributes: keyValues(&iter)}
+}
+
+func otlpMetric(m metricdata.Metrics) *metricpb.Metric {
+	out := &metricpb.Metric{Name: m.Name, Description: m.Description, Unit: m.Unit}
+	switch d := m.Data.(type) {
+	case metricdata.Gauge[int64]:
+		out.Data = &metricpb.Metric_Gauge{Gauge: &metricpb.Gauge{DataPoints: numberPoints(d.DataPoints)}}
+	case metricdata.Gauge[float64]:
+		out.Data = &metricpb.Metric_Gauge{Gauge: &metricpb.Gauge{DataPoints: numberPoints(d.DataPoints)}}
+	case metricdata.Sum[int64]:
+		out.Data = &metricpb.Metric_Sum{Sum: &metricpb.Sum{
+			DataPoints: numberPoints(d.DataPoints), AggregationTemporality: temporality(d.Temporality), IsMonotonic: d.IsMonotonic,
+		}}
+	case metricdata.Sum[float64]:
+		out.Data = &metricpb.Metric_Sum{Sum: &metricpb.Sum{
+			DataPoints: numberPoints(d.DataPoints), AggregationTemporality: temporality(d.Temporality), IsMonotonic: d.IsMonotonic,
+		}}
+	case metricdata.Histogram[int64]:
+		out.Data = &metricpb.Metric_Histogram{Histogram: &metricpb.Histogram{
+			DataPoints: histogramPoints(d.DataPoints), AggregationTemporality: temporality(d.Temporality),
+		}}
+	case metricdata.Histogram[float64]:
+		out.Data = &metricpb.Metric_Histogram{Histogram: &metricpb.Histogram{
+			DataPoints: histogramPoints(d.DataPoints), AggregationTemporality: temporality(d.Temporality),
+		}}
+	case metricdata.Summary:
+		out.Data = &metricpb.Metric_Summary{Summary: &metricpb.Summary{DataPoints: summaryPoints(d.DataPoints)}}
+	default:
+		return nil
+	}
+	return out
+}
+
+func temporality(t metricdata.Temporality) metricpb.AggregationTemporality {
+	if t == metricdata.DeltaTemporality {
+		return metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA
+	}
+	return metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE
+}
+
+func numberPoints[N int64 | float64](dps []metricdata.DataPoint[N]) []*metricpb.NumberDataPoint {
+	out := make([]*metricpb.NumberDataPoint, 0, len(dps))
+	for _, dp := range dps {
+		p := &metricpb.NumberDataPoint{
+			Attributes:        attributes(dp.Attributes),
+			StartTimeUnixNano: unixNano(dp.StartTime),
+			TimeUnixNano:      unixNano(dp.Time),
+		}
+		switch v := any(dp.Value).(type) {
+		case int64:
+			p.Value = &metricpb.NumberDataPoint_AsInt{AsInt: v}
+		case float64:
+			p.Value = &metricpb.NumberDataPoint_AsDouble{AsDouble: v}
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+func histogramPoints[N int64 | float64](dps []metricdata.HistogramDataPoint[N]) []*metricpb.HistogramDataPoint {
+	out := make([]*metricpb.HistogramDataPoint, 0, len(dps))
+	for _, dp := range dps {
+		sum := float64(dp.Sum)
+		p := &metricpb.HistogramDataPoint{
+			Attributes:        attributes(dp.Attributes),
+			StartTimeUnixNano: unixNano(dp.StartTime),
+			TimeUnixNano:      unixNano(dp.Time),
+			Count:             dp.Count,
+			Sum:               &sum,
+			BucketCounts:      dp.BucketCounts,
+			ExplicitBounds:    dp.Bounds,
+		}
+		if v, ok := dp.Min.Value(); ok {
+			f := float64(v)
+			p.Min = &f
+		}
+		if v, ok := dp.Max.Value(); ok {
+			f := float64(v)
+			p.Max = &f
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+func summaryPoints(dps []metricdata.SummaryDataPoint) []*metricpb.SummaryDataPoint {
+	out := make([]*metricpb.SummaryDataPoint, 0, len(dps))
+	for _, dp := range dps {
+		p := &metricpb.SummaryDataPoint{
+			Attributes:        attributes(dp.Attributes),
+			StartTimeUnixNano: unixNano(dp.StartTime),
+			TimeUnixNano:      unixNano(dp.Time),
+			Count:             dp.Count,
+			Sum:               dp.Sum,
+		}
+		for _, q := range dp.QuantileValues {
+			p.QuantileValues = append(p.QuantileValues, &metricpb.SummaryDataPoint_ValueAtQuantile{Quantile: q.Quantile, Value: q.Value})
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+func attributes(set attribute.Set) []*commonpb.KeyValue {
+	iter := set.Iter()
+	return keyValues(&iter)
+}
+
+func keyValues(iter *attribute.Iterator) []*commonpb.KeyValue {
+	out := make([]*commonpb.KeyValue, 0, iter.Len())
+	for iter.Next() {
+		kv := iter.Attribute()
+		out = append(out, &commonpb.KeyValue{Key: string(kv.Key), Value: anyValue(kv.Value)})
+	}
+	return out
+}
+
+func anyValue(v attribute.Value) *commonpb.AnyValue {
+	switch v.Type() {
+	case attribute.BOOL:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: v.AsBool()}}
+	case attribute.INT64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: v.AsInt64()}}
+	case attribute.FLOAT64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: v.AsFloat64()}}
+	case attribute.STRING:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v.AsString()}}
+	}
+	// Slices are rare on metrics; keep them readable.
+	return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v.Emit()}}
+}
+
+func unixNano(t time.Time) uint64 {
+	if t.IsZero() {
+		return 0
+	}
+	return uint64(t.UnixNano()) //nolint:gosec // timestamps after 1970
+}
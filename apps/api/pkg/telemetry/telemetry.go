@@ -1,42 +1,90 @@
-// Package telemetry configures OpenTelemetry tracing.
+// Package telemetry configures OpenTelemetry tracing and, optionally,
+// metrics.
 package telemetry
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
 	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
 	"go.opentelemetry.io/otel/trace"
 )
 
+// Samplers selectable with OTEL_SAMPLER.
+const (
+	SamplerAlways = "always"
+	SamplerNever  = "never"
+	SamplerRatio  = "ratio"
+)
+
+// Metrics exporters selectable with METRICS_EXPORTER.
+const (
+	// MetricsPrometheus serves pkg/metrics on /metrics for scraping.
+	MetricsPrometheus = "prometheus"
+	// MetricsOTLP pushes pkg/metrics and OTel instruments to the collector.
+	MetricsOTLP = "otlp"
+)
+
 type Config struct {
-	ServiceName  string
-	Environment  string
-	Endpoint     string
-	ExporterType string  // "otlp", "stdout", or "noop"
-	SampleRatio  float64 // 0.0-1.0 (parent-based ratio sampler)
-	Enabled      bool
+	ServiceName string `mapstructure:"OTEL_SERVICE_NAME"`
+	Environment string `mapstructure:"ENVIRONMENT"`
+	// Endpoint is the collector's host:port; for "otlphttp" it may also be
+	// a base URL, e.g. https://collector.example.com (signals are posted to
+	// /v1/traces and /v1/metrics below it).
+	Endpoint     string  `mapstructure:"OTEL_ENDPOINT"`
+	ExporterType string  `mapstructure:"OTEL_EXPORTER_TYPE"` // "otlp" (gRPC), "otlphttp", "stdout", or "noop"
+	Sampler      string  `mapstructure:"OTEL_SAMPLER"`       // "always", "never" or "ratio" (default)
+	SampleRatio  float64 `mapstructure:"OTEL_SAMPLE_RATIO"`  // 0.0-1.0, for the ratio sampler
+	// Headers are sent with every export, as comma-separated key=value
+	// pairs with URL-encoded values (e.g. "x-api-key=abc").
+	Headers string `mapstructure:"OTEL_HEADERS"`
+	Enabled bool   `mapstructure:"OTEL_ENABLED"`
+	// MetricsExporter is MetricsPrometheus (default) or MetricsOTLP, which
+	// exports every MetricsInterval over the trace exporter's protocol.
+	MetricsExporter string        `mapstructure:"METRICS_EXPORTER"`
+	MetricsInterval time.Duration `mapstructure:"OTEL_METRICS_INTERVAL"`
 }
 
 type Telemetry struct {
 	provider *sdktrace.TracerProvider
 	tracer   trace.Tracer
+	// meterProvider is nil unless metrics are exported over OTLP.
+	meterProvider *sdkmetric.MeterProvider
+	meter         metric.Meter
+	// collector is the exporter's host:port, empty when not exporting over
+	// the network.
+	collector string
 }
 
-func New(ctx context.Context, cfg Config) (*Telemetry, error) {
+// New installs the tracer provider and, with MetricsExporter set to
+// MetricsOTLP, a meter provider that also exports what producers (e.g.
+// metrics.Producer) report.
+func New(ctx context.Context, cfg Config, producers ...sdkmetric.Producer) (*Telemetry, error) {
 	// Disabled, or an explicit no-op exporter: install a tracer that records
 	// nothing and never touches the network. Previously "noop" silently fell
 	// through to the stdout exporter.
 	if !cfg.Enabled || cfg.ExporterType == "noop" {
+		if cfg.MetricsExporter == MetricsOTLP {
+			return nil, errors.New("OTLP metrics need tracing enabled with an otlp or otlphttp exporter")
+		}
 		return &Telemetry{
 			tracer: otel.Tracer(cfg.ServiceName),
+			meter:  otel.Meter(cfg.ServiceName),
 		}, nil
 	}
 
@@ -51,34 +99,60 @@ func New(ctx context.Context, cfg Config) (*Telemetry, error) {
 		return nil, fmt.Errorf("failed to create resource: %w", err)
 	}
 
+	sampler, err := newSampler(cfg)
+	if err != nil {
+		return nil, err
+	}
+	headers, err := ParseHeaders(cfg.Headers)
+	if err != nil {
+		return nil, err
+	}
+
 	var exporter sdktrace.SpanExporter
+	var metrics *metricExporter
+	var collector string
 
 	switch cfg.ExporterType {
 	case "otlp":
 		// Non-blocking: the exporter connects lazily, so an unreachable
 		// collector no longer hangs startup (previously grpc.WithBlock did).
-		exporter, err = otlptracegrpc.New(ctx,
+		opts := []otlptracegrpc.Option{
 			otlptracegrpc.WithEndpoint(cfg.Endpoint),
 			otlptracegrpc.WithInsecure(),
-		)
+		}
+		if len(headers) > 0 {
+			opts = append(opts, otlptracegrpc.WithHeaders(headers))
+		}
+		exporter, err = otlptracegrpc.New(ctx, opts...)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
 		}
+		collector = cfg.Endpoint
+		if cfg.MetricsExporter == MetricsOTLP {
+			if metrics, err = newGRPCMetricExporter(cfg.Endpoint, headers); err != nil {
+				return nil, fmt.Errorf("failed to create OTLP metric exporter: %w", err)
+			}
+		}
+	case "otlphttp":
+		client := newHTTPClient(cfg.Endpoint, headers)
+		exporter, err = otlptrace.New(ctx, client)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP/HTTP exporter: %w", err)
+		}
+		collector = hostPort(client.base)
+		if cfg.MetricsExporter == MetricsOTLP {
+			metrics = newHTTPMetricExporter(client)
+		}
 	default: // "stdout" and any unknown value
+		if cfg.MetricsExporter == MetricsOTLP {
+			return nil, fmt.Errorf("OTLP metrics need an otlp or otlphttp exporter, not %q", cfg.ExporterType)
+		}
 		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
 		if err != nil {
 			return nil, fmt.Errorf("failed to create stdout exporter: %w", err)
 		}
 	}
 
-	// Parent-based ratio sampler: honor the incoming sampling decision, and for
-	// roots sample at the configured ratio (default 1.0 = always).
-	ratio := cfg.SampleRatio
-	if ratio <= 0 {
-		ratio = 1.0
-	}
-	sampler := sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))
-
 	provider := sdktrace.NewTracerProvider(
 		sdktrace.WithBatcher(exporter),
 		sdktrace.WithResource(res),
@@ -91,21 +165,106 @@ func New(ctx context.Context, cfg Config) (*Telemetry, error) {
 		propagation.Baggage{},
 	))
 
-	return &Telemetry{
-		provider: provider,
-		tracer:   provider.Tracer(cfg.ServiceName),
-	}, nil
+	t := &Telemetry{
+		provider:  provider,
+		tracer:    provider.Tracer(cfg.ServiceName),
+		meter:     otel.Meter(cfg.ServiceName),
+		collector: collector,
+	}
+	if metrics != nil {
+		opts := []sdkmetric.PeriodicReaderOption{}
+		if cfg.MetricsInterval > 0 {
+			opts = append(opts, sdkmetric.WithInterval(cfg.MetricsInterval))
+		}
+		for _, p := range producers {
+			opts = append(opts, sdkmetric.WithProducer(p))
+		}
+		t.meterProvider = sdkmetric.NewMeterProvider(
+			sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metrics, opts...)),
+			sdkmetric.WithResource(res),
+		)
+		otel.SetMeterProvider(t.meterProvider)
+		t.meter = t.meterProvider.Meter(cfg.ServiceName)
+	}
+	return t, nil
+}
+
+// newSampler maps OTEL_SAMPLER to a sampler. "ratio" is parent-based:
+// it honors the caller's sampling decision and samples new traces at
+// SampleRatio (0 is taken as unset, i.e. 1).
+func newSampler(cfg Config) (sdktrace.Sampler, error) {
+	switch cfg.Sampler {
+	case SamplerAlways:
+		return sdktrace.AlwaysSample(), nil
+	case SamplerNever:
+		return sdktrace.NeverSample(), nil
+	case SamplerRatio, "":
+		ratio := cfg.SampleRatio
+		if ratio <= 0 {
+			ratio = 1.0
+		}
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio)), nil
+	}
+	return nil, fmt.Errorf("unknown sampler %q (want always, never or ratio)", cfg.Sampler)
+}
+
+// ParseHeaders parses OTEL_HEADERS: comma-separated key=value pairs whose
+// values may be URL-encoded.
+func ParseHeaders(s string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(pair, "=")
+		if k = strings.TrimSpace(k); !ok || k == "" {
+			return nil, fmt.Errorf("header %q is not key=value", pair)
+		}
+		v, err := url.QueryUnescape(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("header %s: %w", k, err)
+		}
+		headers[k] = v
+	}
+	return headers, nil
+}
+
+// CheckCollector reports whether the collector accepts connections. The
+// exporters connect lazily, so an unreachable collector does not fail New;
+// spans are dropped until it comes up. It returns nil when not exporting
+// over the network.
+func (t *Telemetry) CheckCollector(ctx context.Context) error {
+	if t.collector == "" {
+		return nil
+	}
+	d := net.Dialer{Timeout: 2 * time.Second}
+	conn, err := d.DialContext(ctx, "tcp", t.collector)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
 }
 
 func (t *Telemetry) Tracer() trace.Tracer {
 	return t.tracer
 }
 
+// Meter returns the service's meter. Without OTLP metrics it is the global
+// meter, which records nothing unless another provider is installed.
+func (t *Telemetry) Meter() metric.Meter {
+	return t.meter
+}
+
+// Shutdown flushes and stops the tracer and meter providers.
 func (t *Telemetry) Shutdown(ctx context.Context) error {
+	var errs []error
 	if t.provider != nil {
-		return t.provider.Shutdown(ctx)
+		errs = append(errs, t.provider.Shutdown(ctx))
+	}
+	if t.meterProvider != nil {
+		errs = append(errs, t.meterProvider.Shutdown(ctx))
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
 // StartSpan starts a new span
@@ -0,0 +1,219 @@
+package telemetry
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestNewSampler(t *testing.T) {
+	tests := []struct {
+		cfg  Config
+		want string
+	}{
+		{Config{Sampler: SamplerAlways}, "AlwaysOnSampler"},
+		{Config{Sampler: SamplerNever}, "AlwaysOffSampler"},
+		{Config{Sampler: SamplerRatio, SampleRatio: 0.25}, "ParentBased{root:TraceIDRatioBased{0.25},"},
+		{Config{SampleRatio: 0.5}, "ParentBased{root:TraceIDRatioBased{0.5},"},
+		{Config{Sampler: SamplerRatio}, "ParentBased{root:TraceIDRatioBased{1},"},
+	}
+	for _, tt := range tests {
+		s, err := newSampler(tt.cfg)
+		if err != nil {
+			t.Fatalf("newSampler(%+v): %v", tt.cfg, err)
+		}
+		if got := s.Description(); !strings.HasPrefix(got, tt.want) {
+			t.Errorf("newSampler(%+v) = %s, want prefix %s", tt.cfg, got, tt.want)
+		}
+	}
+	if _, err := newSampler(Config{Sampler: "sometimes"}); err == nil {
+		t.Error("unknown sampler accepted")
+	}
+}
+
+func TestNewSampler_RatioHonorsParent(t *testing.T) {
+	s, _ := newSampler(Config{Sampler: SamplerRatio, SampleRatio: 0.000001})
+	parent := trace.ContextWithRemoteSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{2},
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	}))
+	res := s.ShouldSample(sdktrace.SamplingParameters{ParentContext: parent, TraceID: trace.TraceID{1}, Name: "op"})
+	if res.Decision != sdktrace.RecordAndSample {
+		t.Errorf("sampled parent: decision = %v, want RecordAndSample", res.Decision)
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	got, err := ParseHeaders(" x-api-key=abc , authorization=Bearer%20t%3D1,")
+	if err != nil {
+		t.Fatalf("ParseHeaders: %v", err)
+	}
+	if len(got) != 2 || got["x-api-key"] != "abc" || got["authorization"] != "Bearer t=1" {
+		t.Errorf("ParseHeaders = %v", got)
+	}
+	if got, err := ParseHeaders(""); err != nil || len(got) != 0 {
+		t.Errorf("ParseHeaders(\"\") = %v, %v", got, err)
+	}
+	if _, err := ParseHeaders("novalue"); err == nil {
+		t.Error("pair without = accepted")
+	}
+}
+
+func TestCollectorURL(t *testing.T) {
+	for in, want := range map[string]string{
+		"localhost:4318":                 "http://localhost:4318",
+		"https://otlp.example.com/":      "https://otlp.example.com",
+		"https://otlp.example.com/otlp/": "https://otlp.example.com/otlp",
+	} {
+		if got := collectorURL(in); got != want {
+			t.Errorf("collectorURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNew_OTLPHTTPExportsWithHeaders(t *testing.T) {
+	type export struct {
+		path, key, contentType string
+		spans                  int
+	}
+	got := make(chan export, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req coltracepb.ExportTraceServiceRequest
+		if err := proto.Unmarshal(body, &req); err != nil {
+			t.Errorf("unmarshal export: %v", err)
+		}
+		spans := 0
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				spans += len(ss.Spans)
+			}
+		}
+		got <- export{r.URL.Path, r.Header.Get("x-api-key"), r.Header.Get("Content-Type"), spans}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	tel, err := New(ctx, Config{
+		ServiceName:  "test",
+		Endpoint:     srv.URL,
+		ExporterType: "otlphttp",
+		Sampler:      SamplerAlways,
+		Headers:      "x-api-key=abc",
+		Enabled:      true,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := tel.CheckCollector(ctx); err != nil {
+		t.Errorf("CheckCollector: %v", err)
+	}
+	_, span := tel.StartSpan(ctx, "op")
+	span.End()
+	if err := tel.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	e := <-got
+	if e.path != "/v1/traces" || e.key != "abc" || e.contentType != "application/x-protobuf" || e.spans != 1 {
+		t.Errorf("export = %+v", e)
+	}
+}
+
+func TestCheckCollector_Unreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	addr := srv.Listener.Addr().String()
+	srv.Close()
+
+	tel := &Telemetry{collector: addr}
+	if err := tel.CheckCollector(context.Background()); err == nil {
+		t.Error("CheckCollector reached a closed port")
+	}
+	if err := (&Telemetry{}).CheckCollector(context.Background()); err != nil {
+		t.Errorf("no collector: %v", err)
+	}
+}
+
+// staticProducer reports one gauge, standing in for metrics.Producer.
+type staticProducer struct{}
+
+func (staticProducer) Produce(context.Context) ([]metricdata.ScopeMetrics, error) {
+	return []metricdata.ScopeMetrics{{Metrics: []metricdata.Metrics{{
+		Name: "bridged_gauge",
+		Data: metricdata.Gauge[float64]{DataPoints: []metricdata.DataPoint[float64]{{Value: 3}}},
+	}}}}, nil
+}
+
+func TestNew_OTLPHTTPMetricsFlushOnShutdown(t *testing.T) {
+	names := make(chan []string, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/metrics" {
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		var req colmetricpb.ExportMetricsServiceRequest
+		if err := proto.Unmarshal(body, &req); err != nil {
+			t.Errorf("unmarshal export: %v", err)
+		}
+		var got []string
+		for _, rm := range req.ResourceMetrics {
+			for _, sm := range rm.ScopeMetrics {
+				for _, m := range sm.Metrics {
+					got = append(got, m.Name)
+				}
+			}
+		}
+		names <- got
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	tel, err := New(ctx, Config{
+		ServiceName:     "test",
+		Endpoint:        srv.URL,
+		ExporterType:    "otlphttp",
+		Enabled:         true,
+		MetricsExporter: MetricsOTLP,
+		MetricsInterval: time.Hour,
+	}, staticProducer{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	counter, err := tel.Meter().Int64Counter("jobs_total")
+	if err != nil {
+		t.Fatalf("Int64Counter: %v", err)
+	}
+	counter.Add(ctx, 2)
+	if err := tel.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	got := strings.Join(<-names, ",")
+	if got != "jobs_total,bridged_gauge" && got != "bridged_gauge,jobs_total" {
+		t.Errorf("exported metrics = %s", got)
+	}
+}
+
+func TestNew_OTLPMetricsNeedAnOTLPExporter(t *testing.T) {
+	for _, cfg := range []Config{
+		{Enabled: false, MetricsExporter: MetricsOTLP},
+		{Enabled: true, ExporterType: "stdout", MetricsExporter: MetricsOTLP},
+	} {
+		if _, err := New(context.Background(), cfg); err == nil {
+			t.Errorf("New(%+v) succeeded", cfg)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Package tlsconfig builds the client TLS settings of the Redis and RabbitMQ
+// connections.
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Options configures a client TLS connection. The zero value verifies the
+// server against the system roots.
+type Options struct {
+	// ServerName is checked against the server certificate; empty leaves it
+	// to the dialer, which uses the host it dials.
+	ServerName string
+	// SkipVerify accepts any server certificate. Only for development.
+	SkipVerify bool
+	// CAFile is a PEM bundle trusted instead of the system roots.
+	CAFile string
+	// CertFile and KeyFile are a PEM client certificate and its key, for
+	// servers that require mutual TLS. Both or neither must be set.
+	CertFile string
+	KeyFile  string
+}
+
+// New builds a tls.Config from o, reading its files now so a missing or
+// malformed one fails at startup rather than on the first connection.
+func New(o Options) (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName:         o.ServerName,
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: o.SkipVerify, //nolint:gosec // opt-in, for development only
+	}
+
+	if o.CAFile != "" {
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA file %s holds no PEM certificates", o.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	switch {
+	case o.CertFile == "" && o.KeyFile == "":
+	case o.CertFile == "" || o.KeyFile == "":
+		return nil, errors.New("client certificate and key files must be set together")
+	default:
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate %s: %w", o.CertFile, err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,97 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io/fs"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testCert writes httptest's certificate and key as PEM files.
+func testCert(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+	srv := httptest.NewTLSServer(nil)
+	t.Cleanup(srv.Close)
+	cert := srv.TLS.Certificates[0]
+	key, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	dir := t.TempDir()
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	writeFile(t, certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}))
+	writeFile(t, keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key}))
+	return certFile, keyFile
+}
+
+func writeFile(t *testing.T, name string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(name, data, 0o600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+}
+
+func TestNew_Defaults(t *testing.T) {
+	cfg, err := New(Options{ServerName: "redis.internal"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if cfg.ServerName != "redis.internal" || cfg.MinVersion != tls.VersionTLS12 {
+		t.Errorf("ServerName %q, MinVersion %x", cfg.ServerName, cfg.MinVersion)
+	}
+	if cfg.InsecureSkipVerify || cfg.RootCAs != nil || len(cfg.Certificates) != 0 {
+		t.Errorf("zero Options changed verification: %+v", cfg)
+	}
+}
+
+func TestNew_LoadsCAAndClientCertificate(t *testing.T) {
+	certFile, keyFile := testCert(t)
+
+	cfg, err := New(Options{CAFile: certFile, CertFile: certFile, KeyFile: keyFile, SkipVerify: true})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if cfg.RootCAs == nil {
+		t.Error("CA file not loaded")
+	}
+	if len(cfg.Certificates) != 1 {
+		t.Errorf("got %d client certificates, want 1", len(cfg.Certificates))
+	}
+	if !cfg.InsecureSkipVerify {
+		t.Error("SkipVerify ignored")
+	}
+}
+
+func TestNew_RejectsBadFiles(t *testing.T) {
+	certFile, keyFile := testCert(t)
+	notPEM := filepath.Join(t.TempDir(), "ca.pem")
+	writeFile(t, notPEM, []byte("not a certificate"))
+
+	for name, tc := range map[string]struct {
+		opts Options
+		want string
+	}{
+		"missing CA":     {Options{CAFile: filepath.Join(t.TempDir(), "none.pem")}, "read CA file"},
+		"CA without PEM": {Options{CAFile: notPEM}, "holds no PEM certificates"},
+		"cert only":      {Options{CertFile: certFile}, "must be set together"},
+		"key only":       {Options{KeyFile: keyFile}, "must be set together"},
+		"key mismatch":   {Options{CertFile: certFile, KeyFile: certFile}, "load client certificate"},
+	} {
+		_, err := New(tc.opts)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: err = %v, want %q", name, err, tc.want)
+		}
+	}
+
+	_, err := New(Options{CAFile: filepath.Join(t.TempDir(), "none.pem")})
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing CA err = %v, want fs.ErrNotExist", err)
+	}
+}
@@ -0,0 +1,159 @@
+package token
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+)
+
+// Token modes (TOKEN_MODE).
+const (
+	// ModeLocal issues v4.local tokens, encrypted with a shared secret that
+	// every verifier must hold.
+	ModeLocal = "local"
+	// ModePublic issues v4.public tokens, signed with an Ed25519 key whose
+	// public half is published, so other services verify tokens offline.
+	ModePublic = "public"
+)
+
+// KeyAlgorithm is the "alg" of published keys: PASETO v4.public (Ed25519).
+const KeyAlgorithm = "v4.public"
+
+// ErrUnknownKey is returned for a v4.public token whose key id is not an
+// active or recently retired key. It wraps ErrInvalidToken.
+var ErrUnknownKey = fmt.Errorf("%w: unknown or retired signing key", ErrInvalidToken)
+
+// SigningKey is an Ed25519 key pair for v4.public tokens.
+type SigningKey struct {
+	ID        string
+	CreatedAt time.Time
+	secret    paseto.V4AsymmetricSecretKey
+}
+
+// Public returns the public half of k.
+func (k SigningKey) Public() paseto.V4AsymmetricPublicKey { return k.secret.Public() }
+
+// NewSigningKey returns a fresh random key pair created at now.
+func NewSigningKey(now time.Time) SigningKey {
+	return signingKey(paseto.NewV4AsymmetricSecretKey(), now)
+}
+
+func signingKey(secret paseto.V4AsymmetricSecretKey, createdAt time.Time) SigningKey {
+	return SigningKey{ID: keyID(secret.Public()), CreatedAt: createdAt.UTC(), secret: secret}
+}
+
+// keyID derives a stable id from a public key, so a key keeps its id
+// wherever it is configured.
+func keyID(pub paseto.V4AsymmetricPublicKey) string {
+	sum := sha256.Sum256(pub.ExportBytes())
+	return hex.EncodeToString(sum[:8])
+}
+
+// String formats k as a TOKEN_SIGNING_KEYS entry: "<seed hex>@<created_at>".
+// It contains the private key.
+func (k SigningKey) String() string {
+	return k.secret.ExportSeedHex() + "@" + k.CreatedAt.Format(time.RFC3339)
+}
+
+// ParseSigningKeys parses TOKEN_SIGNING_KEYS entries, each a 32-byte Ed25519
+// seed in hex (e.g. from `openssl rand -hex 32`) and the time the key was
+// created, "<seed>@<RFC 3339 time>". The first key signs new tokens; the
+// rest are earlier keys, newest first, each retired when the key before it
+// was created.
+func ParseSigningKeys(entries []string) ([]SigningKey, error) {
+	if len(entries) == 0 {
+		return nil, errors.New("no signing keys")
+	}
+	keys := make([]SigningKey, len(entries))
+	for i, e := range entries {
+		seed, created, ok := strings.Cut(strings.TrimSpace(e), "@")
+		if !ok {
+			return nil, fmt.Errorf("signing key %d: want <seed hex>@<created_at>", i+1)
+		}
+		secret, err := paseto.NewV4AsymmetricSecretKeyFromSeed(seed)
+		if err != nil {
+			return nil, fmt.Errorf("signing key %d: %w", i+1, err)
+		}
+		at, err := time.Parse(time.RFC3339, created)
+		if err != nil {
+			return nil, fmt.Errorf("signing key %d: created_at: %w", i+1, err)
+		}
+		if i > 0 && !at.Before(keys[i-1].CreatedAt) {
+			return nil, fmt.Errorf("signing key %d: keys must be listed newest first", i+1)
+		}
+		keys[i] = signingKey(secret, at)
+	}
+	return keys, nil
+}
+
+// KeyDocument is the JWKS-like document served at /.well-known/token-keys.
+type KeyDocument struct {
+	Keys []PublishedKey `json:"keys"`
+}
+
+// PublishedKey is a public verification key. Status is "active" for the key
+// signing new tokens and "previous" for a retired key whose tokens are still
+// accepted until ExpiresAt.
+type PublishedKey struct {
+	KeyID     string     `json:"kid"`
+	KeyType   string     `json:"kty"`
+	Curve     string     `json:"crv"`
+	Algorithm string     `json:"alg"`
+	Use       string     `json:"use"`
+	X         string     `json:"x"` // base64url public key
+	Status    string     `json:"status"`
+	CreatedAt time.Time  `json:"created_at"`
+	RetiredAt *time.Time `json:"retired_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// publicKey decodes the key material of k.
+func (k PublishedKey) publicKey() (paseto.V4AsymmetricPublicKey, error) {
+	if k.Algorithm != KeyAlgorithm {
+		return paseto.V4AsymmetricPublicKey{}, fmt.Errorf("key %s: unsupported algorithm %q", k.KeyID, k.Algorithm)
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil {
+		return paseto.V4AsymmetricPublicKey{}, fmt.Errorf("key %s: %w", k.KeyID, err)
+	}
+	return paseto.NewV4AsymmetricPublicKeyFromBytes(raw)
+}
+
+func publishedKey(k SigningKey, status string) PublishedKey {
+	return PublishedKey{
+		KeyID:     k.ID,
+		KeyType:   "OKP",
+		Curve:     "Ed25519",
+		Algorithm: KeyAlgorithm,
+		Use:       "sig",
+		X:         base64.RawURLEncoding.EncodeToString(k.Public().ExportBytes()),
+		Status:    status,
+		CreatedAt: k.CreatedAt,
+	}
+}
+
+// footer is the unencrypted, authenticated footer of v4.public tokens.
+type footer struct {
+	KeyID string `json:"kid"`
+}
+
+// tokenKeyID reads the key id from a v4.public token's footer. The footer
+// is not verified yet; it only selects the key to verify with.
+func tokenKeyID(tokenString string) (string, error) {
+	raw, err := paseto.NewParser().UnsafeParseFooter(paseto.V4Public, tokenString)
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	var f footer
+	if err := json.Unmarshal(raw, &f); err != nil || f.KeyID == "" {
+		return "", ErrInvalidToken
+	}
+	return f.KeyID, nil
+}
@@ -0,0 +1,152 @@
+package token
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustNewPublicTokenService(t *testing.T, overlap time.Duration, keys ...SigningKey) *TokenService {
+	t.Helper()
+	ts, err := NewPublicTokenService(keys, 24, overlap)
+	require.NoError(t, err)
+	return ts
+}
+
+func TestPublicTokenService_SignAndVerify(t *testing.T) {
+	key := NewSigningKey(time.Now())
+	ts := mustNewPublicTokenService(t, 0, key)
+
+	tok, err := ts.GenerateToken("user123", "test@example.com", []string{"admin"}, "COMP001")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(tok, "v4.public."))
+	kid, err := tokenKeyID(tok)
+	require.NoError(t, err)
+	assert.Equal(t, key.ID, kid)
+
+	claims, err := ts.ValidateToken(tok)
+	require.NoError(t, err)
+	assert.Equal(t, "user123", claims.UserID)
+	assert.Equal(t, []string{"admin"}, claims.Roles)
+
+	// A service with another key does not accept it, nor does a local one.
+	other := mustNewPublicTokenService(t, 0, NewSigningKey(time.Now()))
+	_, err = other.ValidateToken(tok)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+	_, err = mustNewTokenService(t, testSecretA, 24).ValidateToken(tok)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestPublicTokenService_TamperedTokenRejected(t *testing.T) {
+	ts := mustNewPublicTokenService(t, 0, NewSigningKey(time.Now()))
+	tok, err := ts.GenerateToken("user123", "test@example.com", []string{"user"}, "COMP001")
+	require.NoError(t, err)
+
+	// Flip a character of the signed payload, keeping the footer.
+	i := len("v4.public.") + 5
+	b := []byte(tok)
+	if b[i] == 'A' {
+		b[i] = 'B'
+	} else {
+		b[i] = 'A'
+	}
+	_, err = ts.ValidateToken(string(b))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestPublicTokenService_RotationUpdatesKeyDocument(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	first := NewSigningKey(now.Add(-time.Hour))
+	ts := mustNewPublicTokenService(t, 2*time.Hour, first)
+	ts.now = func() time.Time { return now }
+
+	doc, ok := ts.PublicKeys()
+	require.True(t, ok)
+	require.Len(t, doc.Keys, 1)
+	assert.Equal(t, first.ID, doc.Keys[0].KeyID)
+	assert.Equal(t, "active", doc.Keys[0].Status)
+
+	second := NewSigningKey(now)
+	require.NoError(t, ts.Rotate(second))
+
+	doc, _ = ts.PublicKeys()
+	require.Len(t, doc.Keys, 2)
+	assert.Equal(t, second.ID, doc.Keys[0].KeyID)
+	assert.Equal(t, "active", doc.Keys[0].Status)
+	assert.Nil(t, doc.Keys[0].ExpiresAt)
+	assert.Equal(t, first.ID, doc.Keys[1].KeyID)
+	assert.Equal(t, "previous", doc.Keys[1].Status)
+	assert.Equal(t, now, *doc.Keys[1].RetiredAt)
+	assert.Equal(t, now.Add(2*time.Hour), *doc.Keys[1].ExpiresAt)
+
+	// Past the overlap window the previous key is no longer published.
+	ts.now = func() time.Time { return now.Add(2 * time.Hour) }
+	doc, _ = ts.PublicKeys()
+	require.Len(t, doc.Keys, 1)
+	assert.Equal(t, second.ID, doc.Keys[0].KeyID)
+}
+
+func TestPublicTokenService_RetiredKeyRejectedAfterOverlap(t *testing.T) {
+	now := time.Now()
+	ts := mustNewPublicTokenService(t, time.Hour, NewSigningKey(now.Add(-time.Minute)))
+	ts.now = func() time.Time { return now }
+
+	old, err := ts.GenerateToken("user123", "test@example.com", []string{"user"}, "COMP001")
+	require.NoError(t, err)
+	require.NoError(t, ts.Rotate(NewSigningKey(now)))
+	fresh, err := ts.GenerateToken("user123", "test@example.com", []string{"user"}, "COMP001")
+	require.NoError(t, err)
+
+	// Within the overlap window both tokens verify.
+	_, err = ts.ValidateToken(old)
+	require.NoError(t, err)
+	_, err = ts.ValidateToken(fresh)
+	require.NoError(t, err)
+
+	ts.now = func() time.Time { return now.Add(time.Hour) }
+	_, err = ts.ValidateToken(old)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+	_, err = ts.ValidateToken(fresh)
+	assert.NoError(t, err)
+}
+
+func TestPublicTokenService_LocalModeHasNoKeys(t *testing.T) {
+	ts := mustNewTokenService(t, testSecretA, 24)
+	_, ok := ts.PublicKeys()
+	assert.False(t, ok)
+	assert.Equal(t, ModeLocal, ts.Mode())
+	assert.Error(t, ts.Rotate(NewSigningKey(time.Now())))
+}
+
+func TestParseSigningKeys(t *testing.T) {
+	newer := NewSigningKey(time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC))
+	older := NewSigningKey(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	keys, err := ParseSigningKeys([]string{newer.String(), older.String()})
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, newer.ID, keys[0].ID)
+	assert.Equal(t, newer.CreatedAt, keys[0].CreatedAt)
+	assert.Equal(t, older.ID, keys[1].ID)
+
+	tests := []struct {
+		name    string
+		entries []string
+		wantErr string
+	}{
+		{"none", nil, "no signing keys"},
+		{"missing created_at", []string{strings.Repeat("ab", 32)}, "want <seed hex>@<created_at>"},
+		{"bad seed", []string{"zz@2026-01-01T00:00:00Z"}, "signing key 1"},
+		{"bad time", []string{strings.Repeat("ab", 32) + "@yesterday"}, "created_at"},
+		{"oldest first", []string{older.String(), newer.String()}, "newest first"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSigningKeys(tt.entries)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
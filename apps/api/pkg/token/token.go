@@ -1,13 +1,22 @@
-// Package token issues and validates PASETO v4 access tokens.
+// Package token issues and validates PASETO v4 access tokens: v4.local
+// (encrypted with a shared secret) or, in public mode, v4.public (signed with
+// an Ed25519 key whose public half other services fetch through a Verifier).
 package token
 
 import (
+	"crypto/hkdf"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"strings"
+	"sync"
 	"time"
 
+	"veemon/entity"
+
 	"aidanwoods.dev/go-paseto"
 )
 
@@ -19,8 +28,14 @@ var (
 	// least 32 raw bytes). Short secrets are rejected rather than padded, since
 	// padding a guessable secret produces a guessable, forgeable key.
 	ErrWeakSecret = errors.New("token secret must be a 64-character hex string or at least 32 bytes")
+	// ErrTooManyRoles rejects a token whose roles claim is longer than
+	// entity.MaxRoles. It wraps ErrInvalidToken.
+	ErrTooManyRoles = fmt.Errorf("%w: roles claim exceeds %d entries", ErrInvalidToken, entity.MaxRoles)
 )
 
+// MaxImpersonationTTL caps the lifetime of impersonation tokens.
+const MaxImpersonationTTL = 15 * time.Minute
+
 type Claims struct {
 	UserID      string   `json:"userId"`
 	Email       string   `json:"email"`
@@ -31,11 +46,28 @@ type Claims struct {
 	// ExpiresAt is the token's natural expiry, used to bound how long a
 	// revocation entry must be retained.
 	ExpiresAt time.Time `json:"-"`
+	// IssuedAt lets a user-wide revocation reject tokens issued before it.
+	IssuedAt time.Time `json:"-"`
+	// Impersonation marks a token a superadmin obtained to act as UserID;
+	// ActorID is that superadmin.
+	Impersonation bool   `json:"impersonation"`
+	ActorID       string `json:"actorId"`
 }
 
 type TokenService struct {
-	secretKey  paseto.V4SymmetricKey
-	expiration time.Duration
+	mode      string // ModeLocal ("" too) or ModePublic
+	secretKey paseto.V4SymmetricKey
+	// previousKeys still decrypt tokens encrypted before a secret rotation.
+	previousKeys []paseto.V4SymmetricKey
+	expiration   time.Duration
+
+	// Public mode: keys holds the signing key first, then retired keys,
+	// newest first. A retired key verifies tokens for overlap after the key
+	// replacing it was created.
+	mu      sync.RWMutex
+	keys    []SigningKey
+	overlap time.Duration
+	now     func() time.Time
 }
 
 // NewTokenService creates a new token service with PASETO v4.
@@ -45,22 +77,61 @@ type TokenService struct {
 // Weaker secrets are rejected with ErrWeakSecret — short secrets are never
 // padded, because deriving a key from a guessable secret produces a guessable
 // key and PASETO v4.local tokens would then be forgeable by anyone.
-func NewTokenService(secretKeyString string, expirationHours int) (*TokenService, error) {
+//
+// previousSecrets are secrets rotated out, newest first, in the same format.
+// Tokens are encrypted with secretKeyString only, but validation also tries
+// the previous secrets, so tokens issued before a rotation keep working
+// until they expire; drop a previous secret once the token lifetime has
+// passed.
+func NewTokenService(secretKeyString string, expirationHours int, previousSecrets ...string) (*TokenService, error) {
 	key, err := deriveKey(secretKeyString)
 	if err != nil {
 		return nil, err
 	}
+	previous := make([]paseto.V4SymmetricKey, len(previousSecrets))
+	for i, s := range previousSecrets {
+		if previous[i], err = deriveKey(s); err != nil {
+			return nil, fmt.Errorf("previous secret %d: %w", i+1, err)
+		}
+	}
+	return &TokenService{
+		secretKey:    key,
+		previousKeys: previous,
+		expiration:   time.Duration(expirationHours) * time.Hour,
+	}, nil
+}
+
+// NewPublicTokenService creates a token service issuing v4.public tokens
+// signed with keys[0]; the other keys are earlier ones, newest first (see
+// ParseSigningKeys). Tokens signed by a retired key are accepted for overlap
+// after its retirement, and for the token lifetime if overlap is not
+// positive, so rotating keys never invalidates tokens already issued.
+func NewPublicTokenService(keys []SigningKey, expirationHours int, overlap time.Duration) (*TokenService, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("public token mode needs a signing key")
+	}
+	expiration := time.Duration(expirationHours) * time.Hour
+	if overlap <= 0 {
+		overlap = expiration
+	}
 	return &TokenService{
-		secretKey:  key,
-		expiration: time.Duration(expirationHours) * time.Hour,
+		mode:       ModePublic,
+		expiration: expiration,
+		keys:       append([]SigningKey(nil), keys...),
+		overlap:    overlap,
+		now:        time.Now,
 	}, nil
 }
 
+// secretKeyInfo is the HKDF info binding keys derived from raw secrets to
+// their use, so the same secret used elsewhere yields a different key.
+const secretKeyInfo = "veemon token v4.local"
+
 // deriveKey turns a configured secret string into a PASETO v4 symmetric key.
 func deriveKey(secretKeyString string) (paseto.V4SymmetricKey, error) {
 	var zero paseto.V4SymmetricKey
 
-	// 64 hex chars decode to exactly 32 bytes.
+	// 64 hex chars decode to exactly 32 bytes, used as the key itself.
 	if len(secretKeyString) == 64 {
 		keyBytes, err := hex.DecodeString(secretKeyString)
 		if err == nil && len(keyBytes) == 32 {
@@ -68,11 +139,16 @@ func deriveKey(secretKeyString string) (paseto.V4SymmetricKey, error) {
 		}
 	}
 
-	// Otherwise require at least 32 raw bytes and use the first 32.
+	// Otherwise require at least 32 raw bytes and derive the key from all of
+	// them with HKDF-SHA256, so every byte of a long secret counts.
 	if len(secretKeyString) < 32 {
 		return zero, ErrWeakSecret
 	}
-	return paseto.V4SymmetricKeyFromBytes([]byte(secretKeyString)[:32])
+	keyBytes, err := hkdf.Key(sha256.New, []byte(secretKeyString), nil, secretKeyInfo, 32)
+	if err != nil {
+		return zero, err
+	}
+	return paseto.V4SymmetricKeyFromBytes(keyBytes)
 }
 
 // GenerateSecretKey generates a new random 32-byte secret key and returns it as hex
@@ -84,32 +160,79 @@ func GenerateSecretKey() string {
 // GenerateToken creates a new PASETO token with user claims. Each token is
 // stamped with a unique jti so it can be individually revoked.
 func (ts *TokenService) GenerateToken(userID, email string, roles []string, companyCode string) (string, error) {
+	token, _, err := ts.newToken(userID, email, roles, companyCode, ts.expiration)
+	if err != nil {
+		return "", err
+	}
+	return ts.encode(token), nil
+}
+
+// GenerateImpersonationToken issues a token carrying the target user's claims
+// for actorID to act as them. ttl is capped at MaxImpersonationTTL (as is a
+// non-positive ttl). It returns the token and its expiry.
+func (ts *TokenService) GenerateImpersonationToken(userID, email string, roles []string, companyCode, actorID string, ttl time.Duration) (string, time.Time, error) {
+	if ttl <= 0 || ttl > MaxImpersonationTTL {
+		ttl = MaxImpersonationTTL
+	}
+	token, exp, err := ts.newToken(userID, email, roles, companyCode, ttl)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if err := token.Set("impersonation", true); err != nil {
+		return "", time.Time{}, err
+	}
+	token.SetString("actorId", actorID)
+	return ts.encode(token), exp, nil
+}
+
+// encode encrypts token, or in public mode signs it with the active key and
+// names that key in the footer.
+func (ts *TokenService) encode(token *paseto.Token) string {
+	if ts.mode != ModePublic {
+		return token.V4Encrypt(ts.secretKey, nil)
+	}
+	ts.mu.RLock()
+	key := ts.keys[0]
+	ts.mu.RUnlock()
+	f, _ := json.Marshal(footer{KeyID: key.ID})
+	token.SetFooter(f)
+	return token.V4Sign(key.secret, nil)
+}
+
+// newToken builds an unencrypted token with the user claims, expiring after
+// ttl.
+func (ts *TokenService) newToken(userID, email string, roles []string, companyCode string, ttl time.Duration) (*paseto.Token, time.Time, error) {
 	token := paseto.NewToken()
 
 	now := time.Now()
+	exp := now.Add(ttl)
 
 	jti, err := newTokenID()
 	if err != nil {
-		return "", err
+		return nil, time.Time{}, err
 	}
 
 	// Set registered claims
 	token.SetIssuedAt(now)
 	token.SetNotBefore(now)
-	token.SetExpiration(now.Add(ts.expiration))
+	token.SetExpiration(exp)
 	token.SetJti(jti)
 
+	// Normalized so every token carries comparable, bounded roles.
+	normalized, err := entity.ParseRoles(roles)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
 	// Set custom claims
 	token.SetString("userId", userID)
 	token.SetString("email", email)
-	if err := token.Set("roles", roles); err != nil {
-		return "", err
+	if err := token.Set("roles", []string(normalized)); err != nil {
+		return nil, time.Time{}, err
 	}
 	token.SetString("companyCode", companyCode)
 
-	// Encrypt the token (v4.local)
-	encrypted := token.V4Encrypt(ts.secretKey, nil)
-	return encrypted, nil
+	return &token, exp, nil
 }
 
 // newTokenID returns a cryptographically random 128-bit token identifier (jti).
@@ -123,25 +246,65 @@ func newTokenID() (string, error) {
 
 // ValidateToken validates and decrypts a PASETO token
 func (ts *TokenService) ValidateToken(tokenString string) (*Claims, error) {
-	parser := paseto.NewParser()
+	token, err := ts.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	return claimsFromToken(token)
+}
 
-	// Add validation rules
+// newParser returns a parser enforcing the registered time claims.
+func newParser() paseto.Parser {
+	parser := paseto.NewParser()
 	parser.AddRule(paseto.NotExpired())
 	parser.AddRule(paseto.ValidAt(time.Now()))
+	return parser
+}
 
-	// Parse and decrypt the token
-	token, err := parser.ParseV4Local(ts.secretKey, tokenString, nil)
-	if err != nil {
-		// Distinguish an expired token from an otherwise invalid one. The
-		// paseto NotExpired rule reports "this token has expired"; match only
-		// the unambiguous "expired" substring to avoid false positives from
-		// words like "unexpected".
-		if strings.Contains(err.Error(), "expired") {
-			return nil, ErrExpiredToken
+// parse decrypts a v4.local token with the current or a previous secret, or
+// in public mode verifies a v4.public token against the key its footer
+// names.
+func (ts *TokenService) parse(tokenString string) (*paseto.Token, error) {
+	if ts.mode != ModePublic {
+		token, err := newParser().ParseV4Local(ts.secretKey, tokenString, nil)
+		for _, key := range ts.previousKeys {
+			// An expired token decrypted fine: no other key applies.
+			if err == nil || parseError(err) == ErrExpiredToken {
+				break
+			}
+			token, err = newParser().ParseV4Local(key, tokenString, nil)
 		}
-		return nil, ErrInvalidToken
+		return token, parseError(err)
+	}
+	kid, err := tokenKeyID(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := ts.verificationKey(kid)
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	token, err := newParser().ParseV4Public(key, tokenString, nil)
+	return token, parseError(err)
+}
+
+// parseError maps a paseto parse error to ErrExpiredToken or ErrInvalidToken.
+func parseError(err error) error {
+	if err == nil {
+		return nil
+	}
+	// Distinguish an expired token from an otherwise invalid one. The
+	// paseto NotExpired rule reports "this token has expired"; match only
+	// the unambiguous "expired" substring to avoid false positives from
+	// words like "unexpected".
+	if strings.Contains(err.Error(), "expired") {
+		return ErrExpiredToken
 	}
+	return ErrInvalidToken
+}
 
+// claimsFromToken reads the claims of a verified token.
+func claimsFromToken(token *paseto.Token) (*Claims, error) {
 	// Extract claims
 	claims := &Claims{}
 
@@ -164,6 +327,9 @@ func (ts *TokenService) ValidateToken(tokenString string) (*Claims, error) {
 	if err := token.Get("roles", &claims.Roles); err != nil {
 		return nil, ErrInvalidToken
 	}
+	if len(claims.Roles) > entity.MaxRoles {
+		return nil, ErrTooManyRoles
+	}
 
 	// Registered claims (best-effort).
 	if jti, err := token.GetJti(); err == nil {
@@ -172,10 +338,99 @@ func (ts *TokenService) ValidateToken(tokenString string) (*Claims, error) {
 	if exp, err := token.GetExpiration(); err == nil {
 		claims.ExpiresAt = exp
 	}
+	if iat, err := token.GetIssuedAt(); err == nil {
+		claims.IssuedAt = iat
+	}
+
+	// Impersonation claims are absent from ordinary tokens. One that claims
+	// impersonation must name its actor.
+	if err := token.Get("impersonation", &claims.Impersonation); err == nil && claims.Impersonation {
+		if err := token.Get("actorId", &claims.ActorID); err != nil || claims.ActorID == "" {
+			return nil, ErrInvalidToken
+		}
+	}
 
 	return claims, nil
 }
 
+// Lifetime is how long regular (non-impersonation) tokens stay valid.
+func (ts *TokenService) Lifetime() time.Duration {
+	return ts.expiration
+}
+
+// Mode is ModePublic for a service from NewPublicTokenService, else ModeLocal.
+func (ts *TokenService) Mode() string {
+	if ts.mode == ModePublic {
+		return ModePublic
+	}
+	return ModeLocal
+}
+
+// verificationKey returns the public key with the given id if it is the
+// signing key or a retired key still within the overlap window.
+func (ts *TokenService) verificationKey(kid string) (paseto.V4AsymmetricPublicKey, bool) {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	now := ts.now()
+	for i, k := range ts.keys {
+		if k.ID != kid {
+			continue
+		}
+		if i > 0 && !now.Before(ts.keys[i-1].CreatedAt.Add(ts.overlap)) {
+			return paseto.V4AsymmetricPublicKey{}, false
+		}
+		return k.Public(), true
+	}
+	return paseto.V4AsymmetricPublicKey{}, false
+}
+
+// Rotate makes key the signing key. The previous one keeps verifying the
+// tokens it signed for the overlap window; keys past it are dropped.
+func (ts *TokenService) Rotate(key SigningKey) error {
+	if ts.mode != ModePublic {
+		return errors.New("keys rotate only in public token mode")
+	}
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	keys := []SigningKey{key}
+	now := ts.now()
+	for i, k := range ts.keys {
+		retired := key.CreatedAt
+		if i > 0 {
+			retired = ts.keys[i-1].CreatedAt
+		}
+		if now.Before(retired.Add(ts.overlap)) {
+			keys = append(keys, k)
+		}
+	}
+	ts.keys = keys
+	return nil
+}
+
+// PublicKeys lists the signing key and the retired keys still accepted,
+// for /.well-known/token-keys. ok is false in local mode, which has no
+// public keys.
+func (ts *TokenService) PublicKeys() (doc KeyDocument, ok bool) {
+	if ts.mode != ModePublic {
+		return KeyDocument{}, false
+	}
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	now := ts.now()
+	doc.Keys = []PublishedKey{publishedKey(ts.keys[0], "active")}
+	for i, k := range ts.keys[1:] {
+		retired := ts.keys[i].CreatedAt
+		expires := retired.Add(ts.overlap)
+		if !now.Before(expires) {
+			continue
+		}
+		pk := publishedKey(k, "previous")
+		pk.RetiredAt, pk.ExpiresAt = &retired, &expires
+		doc.Keys = append(doc.Keys, pk)
+	}
+	return doc, true
+}
+
 // GetSecretKeyHex exports the secret key as hex string (for backup/migration)
 func (ts *TokenService) GetSecretKeyHex() string {
 	return hex.EncodeToString(ts.secretKey.ExportBytes())
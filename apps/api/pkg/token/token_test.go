@@ -1,9 +1,14 @@
 package token
 
 import (
+	"encoding/hex"
+	"fmt"
 	"testing"
 	"time"
 
+	"veemon/entity"
+
+	"aidanwoods.dev/go-paseto"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -112,8 +117,8 @@ func TestTokenService_HexSecretAccepted(t *testing.T) {
 }
 
 func TestTokenService_LongSecretKey(t *testing.T) {
-	// A secret longer than 32 bytes is truncated to the first 32 bytes.
-	longKey := "this-is-a-very-long-secret-key-that-exceeds-32-bytes-and-should-be-truncated"
+	// Every byte of a secret longer than 32 bytes goes into the key.
+	longKey := "this-is-a-very-long-secret-key-that-exceeds-32-bytes-and-is-derived-in-full"
 	ts := mustNewTokenService(t, longKey, 24)
 
 	token, err := ts.GenerateToken("user123", "test@example.com", []string{"user"}, "COMP001")
@@ -122,6 +127,65 @@ func TestTokenService_LongSecretKey(t *testing.T) {
 	claims, err := ts.ValidateToken(token)
 	require.NoError(t, err)
 	assert.Equal(t, "user123", claims.UserID)
+
+	// A secret sharing only the first 32 bytes is a different key.
+	other := mustNewTokenService(t, longKey[:32]+"-but-with-another-ending", 24)
+	_, err = other.ValidateToken(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestTokenService_RawSecretIsNotTheKey(t *testing.T) {
+	// Raw secrets go through HKDF rather than being used as key bytes.
+	ts := mustNewTokenService(t, testSecretA, 24)
+	assert.NotEqual(t, hex.EncodeToString([]byte(testSecretA)[:32]), ts.GetSecretKeyHex())
+
+	// A hex secret is the key itself.
+	hexKey := GenerateSecretKey()
+	assert.Equal(t, hexKey, mustNewTokenService(t, hexKey, 24).GetSecretKeyHex())
+}
+
+func TestTokenService_SecretRotation(t *testing.T) {
+	old := mustNewTokenService(t, testSecretA, 24)
+	oldToken, err := old.GenerateToken("user123", "test@example.com", []string{"user"}, "COMP001")
+	require.NoError(t, err)
+
+	rotated, err := NewTokenService(testSecretB, 24, testSecretA)
+	require.NoError(t, err)
+
+	// Tokens issued before the rotation still validate ...
+	claims, err := rotated.ValidateToken(oldToken)
+	require.NoError(t, err)
+	assert.Equal(t, "user123", claims.UserID)
+
+	// ... while new ones are encrypted with the current secret only.
+	newToken, err := rotated.GenerateToken("user123", "test@example.com", []string{"user"}, "COMP001")
+	require.NoError(t, err)
+	_, err = old.ValidateToken(newToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = mustNewTokenService(t, testSecretB, 24).ValidateToken(newToken)
+	require.NoError(t, err)
+
+	// Once the previous secret is dropped, its tokens are rejected.
+	_, err = mustNewTokenService(t, testSecretB, 24).ValidateToken(oldToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestTokenService_SecretRotation_ExpiredOldToken(t *testing.T) {
+	old := mustNewTokenService(t, testSecretA, 0)
+	token, err := old.GenerateToken("user123", "test@example.com", []string{"user"}, "COMP001")
+	require.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+
+	rotated, err := NewTokenService(testSecretB, 24, testSecretA)
+	require.NoError(t, err)
+	_, err = rotated.ValidateToken(token)
+	assert.ErrorIs(t, err, ErrExpiredToken)
+}
+
+func TestTokenService_MalformedPreviousSecretRejected(t *testing.T) {
+	_, err := NewTokenService(testSecretA, 24, GenerateSecretKey(), "short")
+	assert.ErrorIs(t, err, ErrWeakSecret)
+	assert.ErrorContains(t, err, "previous secret 2")
 }
 
 func TestTokenService_EmptyRoles(t *testing.T) {
@@ -147,3 +211,81 @@ func TestTokenService_MultipleRoles(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, roles, claims.Roles)
 }
+
+func TestTokenService_ImpersonationToken(t *testing.T) {
+	ts := mustNewTokenService(t, testSecretA, 24)
+
+	token, exp, err := ts.GenerateImpersonationToken("user123", "test@example.com", []string{"user"}, "COMP001", "admin1", 5*time.Minute)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(5*time.Minute), exp, 5*time.Second)
+
+	claims, err := ts.ValidateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, "user123", claims.UserID)
+	assert.Equal(t, []string{"user"}, claims.Roles)
+	assert.True(t, claims.Impersonation)
+	assert.Equal(t, "admin1", claims.ActorID)
+}
+
+func TestTokenService_ImpersonationTTLIsCapped(t *testing.T) {
+	ts := mustNewTokenService(t, testSecretA, 24)
+
+	for _, ttl := range []time.Duration{0, MaxImpersonationTTL + time.Second, 24 * time.Hour} {
+		token, exp, err := ts.GenerateImpersonationToken("user123", "test@example.com", nil, "", "admin1", ttl)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(MaxImpersonationTTL), exp, 5*time.Second, "ttl %s", ttl)
+
+		claims, err := ts.ValidateToken(token)
+		require.NoError(t, err)
+		assert.False(t, claims.ExpiresAt.After(time.Now().Add(MaxImpersonationTTL)), "ttl %s", ttl)
+	}
+}
+
+func TestTokenService_OrdinaryTokenIsNotImpersonation(t *testing.T) {
+	ts := mustNewTokenService(t, testSecretA, 24)
+
+	token, err := ts.GenerateToken("user123", "test@example.com", nil, "")
+	require.NoError(t, err)
+
+	claims, err := ts.ValidateToken(token)
+	require.NoError(t, err)
+	assert.False(t, claims.Impersonation)
+	assert.Empty(t, claims.ActorID)
+}
+
+func TestTokenService_RolesNormalizedOnIssue(t *testing.T) {
+	ts := mustNewTokenService(t, testSecretA, 24)
+
+	token, err := ts.GenerateToken("user123", "test@example.com", []string{"Admin ", "admin", " USER"}, "COMP001")
+	require.NoError(t, err)
+
+	claims, err := ts.ValidateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"admin", "user"}, claims.Roles)
+
+	_, err = ts.GenerateToken("user123", "test@example.com", []string{"super admin"}, "COMP001")
+	assert.ErrorIs(t, err, entity.ErrInvalidRoles)
+}
+
+func TestTokenService_OversizedRolesClaimRejected(t *testing.T) {
+	ts := mustNewTokenService(t, testSecretA, 24)
+
+	// Mint directly, as a token issued before the limit (or forged with a
+	// leaked key) would be.
+	roles := make([]string, entity.MaxRoles+1)
+	for i := range roles {
+		roles[i] = fmt.Sprintf("role-%d", i)
+	}
+	tok := paseto.NewToken()
+	tok.SetIssuedAt(time.Now())
+	tok.SetNotBefore(time.Now())
+	tok.SetExpiration(time.Now().Add(time.Hour))
+	tok.SetString("userId", "user123")
+	tok.SetString("email", "test@example.com")
+	tok.SetString("companyCode", "COMP001")
+	require.NoError(t, tok.Set("roles", roles))
+
+	_, err := ts.ValidateToken(tok.V4Encrypt(ts.secretKey, nil))
+	assert.ErrorIs(t, err, ErrTooManyRoles)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
@@ -0,0 +1,155 @@
+package token
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+)
+
+// KeysPath is where a public-mode service publishes its KeyDocument.
+const KeysPath = "/.well-known/token-keys"
+
+const (
+	// defaultKeysMaxAge is how long fetched keys are trusted when the
+	// response has no Cache-Control max-age.
+	defaultKeysMaxAge = 5 * time.Minute
+	// minRefetchInterval limits refetches triggered by unknown key ids, so
+	// garbage tokens cannot turn the verifier into a request amplifier.
+	minRefetchInterval = 30 * time.Second
+)
+
+// Verifier validates v4.public tokens in another service, with keys fetched
+// from the issuer's KeysPath and cached. A token whose key id is not cached
+// triggers a refetch, rate limited, so a rotation is picked up before the
+// cache expires. It is safe for concurrent use.
+type Verifier struct {
+	url    string
+	client *http.Client
+	now    func() time.Time
+
+	mu        sync.Mutex
+	keys      map[string]verifierKey
+	expires   time.Time // cache freshness, from max-age
+	lastFetch time.Time
+}
+
+type verifierKey struct {
+	public    paseto.V4AsymmetricPublicKey
+	expiresAt *time.Time
+}
+
+// VerifierOption configures a Verifier.
+type VerifierOption func(*Verifier)
+
+// WithVerifierHTTPClient replaces http.DefaultClient for fetching keys.
+func WithVerifierHTTPClient(c *http.Client) VerifierOption {
+	return func(v *Verifier) {
+		v.client = c
+	}
+}
+
+// NewVerifier creates a verifier for the keys document at url, e.g.
+// "https://auth.example.com" + KeysPath. Keys are fetched on first use.
+func NewVerifier(url string, opts ...VerifierOption) *Verifier {
+	v := &Verifier{url: url, client: http.DefaultClient, now: time.Now}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// Verify checks tokenString's signature and time claims and returns its
+// claims. It fails with ErrUnknownKey if the issuer does not publish the
+// token's key (e.g. retired past its overlap window), ErrExpiredToken or
+// ErrInvalidToken, or an error if the keys cannot be fetched.
+func (v *Verifier) Verify(ctx context.Context, tokenString string) (*Claims, error) {
+	kid, err := tokenKeyID(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	key, err := v.key(ctx, kid)
+	if err != nil {
+		return nil, err
+	}
+	token, err := newParser().ParseV4Public(key, tokenString, nil)
+	if err != nil {
+		return nil, parseError(err)
+	}
+	return claimsFromToken(token)
+}
+
+// key returns the cached key kid, fetching the document when the cache is
+// stale or, at most every minRefetchInterval, when kid is missing.
+func (v *Verifier) key(ctx context.Context, kid string) (paseto.V4AsymmetricPublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	now := v.now()
+	k, ok := v.keys[kid]
+	stale := v.keys == nil || !now.Before(v.expires)
+	if stale || (!ok && now.Sub(v.lastFetch) >= minRefetchInterval) {
+		if err := v.fetch(ctx, now); err != nil {
+			if v.keys == nil {
+				return paseto.V4AsymmetricPublicKey{}, err
+			}
+			// Keep verifying with the last keys while the issuer is down.
+		}
+		k, ok = v.keys[kid]
+	}
+	if !ok || (k.expiresAt != nil && !now.Before(*k.expiresAt)) {
+		return paseto.V4AsymmetricPublicKey{}, ErrUnknownKey
+	}
+	return k.public, nil
+}
+
+// fetch replaces the cached keys with the published document. The caller
+// holds v.mu.
+func (v *Verifier) fetch(ctx context.Context, now time.Time) error {
+	v.lastFetch = now
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetch token keys: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetch token keys: %s", resp.Status)
+	}
+	var doc KeyDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return fmt.Errorf("decode token keys: %w", err)
+	}
+	keys := make(map[string]verifierKey, len(doc.Keys))
+	for _, pk := range doc.Keys {
+		public, err := pk.publicKey()
+		if err != nil {
+			return fmt.Errorf("decode token keys: %w", err)
+		}
+		keys[pk.KeyID] = verifierKey{public: public, expiresAt: pk.ExpiresAt}
+	}
+	v.keys = keys
+	v.expires = now.Add(maxAge(resp.Header.Get("Cache-Control")))
+	return nil
+}
+
+// maxAge reads max-age from a Cache-Control header, or defaultKeysMaxAge.
+func maxAge(cacheControl string) time.Duration {
+	for _, d := range strings.Split(cacheControl, ",") {
+		name, val, _ := strings.Cut(strings.TrimSpace(d), "=")
+		if strings.EqualFold(name, "max-age") {
+			if s, err := strconv.Atoi(val); err == nil && s >= 0 {
+				return time.Duration(s) * time.Second
+			}
+		}
+	}
+	return defaultKeysMaxAge
+}
@@ -0,0 +1,125 @@
+package token
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// keyServer serves ts's key document and counts the fetches.
+func keyServer(t *testing.T, ts *TokenService) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		doc, _ := ts.PublicKeys()
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		_ = json.NewEncoder(w).Encode(doc)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &fetches
+}
+
+func TestVerifier_VerifiesOfflineWithCachedKeys(t *testing.T) {
+	ts := mustNewPublicTokenService(t, 0, NewSigningKey(time.Now()))
+	srv, fetches := keyServer(t, ts)
+	v := NewVerifier(srv.URL+KeysPath, WithVerifierHTTPClient(srv.Client()))
+
+	for i := 0; i < 3; i++ {
+		tok, err := ts.GenerateToken("user123", "test@example.com", []string{"admin"}, "COMP001")
+		require.NoError(t, err)
+		claims, err := v.Verify(context.Background(), tok)
+		require.NoError(t, err)
+		assert.Equal(t, "user123", claims.UserID)
+	}
+	assert.EqualValues(t, 1, fetches.Load())
+
+	// Keys are cached: verification keeps working with the issuer down.
+	srv.Close()
+	tok, err := ts.GenerateToken("user123", "test@example.com", []string{"admin"}, "COMP001")
+	require.NoError(t, err)
+	_, err = v.Verify(context.Background(), tok)
+	assert.NoError(t, err)
+}
+
+func TestVerifier_RefetchesOnRotation(t *testing.T) {
+	ts := mustNewPublicTokenService(t, 0, NewSigningKey(time.Now().Add(-time.Minute)))
+	srv, fetches := keyServer(t, ts)
+	v := NewVerifier(srv.URL, WithVerifierHTTPClient(srv.Client()))
+	now := time.Now()
+	v.now = func() time.Time { return now }
+
+	tok, err := ts.GenerateToken("user123", "test@example.com", nil, "COMP001")
+	require.NoError(t, err)
+	_, err = v.Verify(context.Background(), tok)
+	require.NoError(t, err)
+
+	require.NoError(t, ts.Rotate(NewSigningKey(time.Now())))
+	rotated, err := ts.GenerateToken("user123", "test@example.com", nil, "COMP001")
+	require.NoError(t, err)
+
+	// A token from the new key is verified after a refetch, once the
+	// minimum interval since the last fetch has passed.
+	_, err = v.Verify(context.Background(), rotated)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+	assert.EqualValues(t, 1, fetches.Load())
+	now = now.Add(minRefetchInterval)
+	_, err = v.Verify(context.Background(), rotated)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, fetches.Load())
+
+	// A foreign kid does not refetch within the minimum interval.
+	other := mustNewPublicTokenService(t, 0, NewSigningKey(time.Now()))
+	foreign, err := other.GenerateToken("user123", "test@example.com", nil, "COMP001")
+	require.NoError(t, err)
+	_, err = v.Verify(context.Background(), foreign)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+	assert.EqualValues(t, 2, fetches.Load())
+
+	// The old key is still published as previous, so its tokens verify.
+	_, err = v.Verify(context.Background(), tok)
+	assert.NoError(t, err)
+}
+
+func TestVerifier_RejectsKeysPastExpiry(t *testing.T) {
+	now := time.Now()
+	ts := mustNewPublicTokenService(t, time.Hour, NewSigningKey(now.Add(-time.Minute)))
+	old, err := ts.GenerateToken("user123", "test@example.com", nil, "COMP001")
+	require.NoError(t, err)
+	require.NoError(t, ts.Rotate(NewSigningKey(now)))
+
+	srv, _ := keyServer(t, ts)
+	v := NewVerifier(srv.URL, WithVerifierHTTPClient(srv.Client()))
+	_, err = v.Verify(context.Background(), old)
+	require.NoError(t, err)
+
+	// The cached document still lists the key, but it has expired.
+	v.now = func() time.Time { return now.Add(time.Hour) }
+	v.expires = now.Add(2 * time.Hour)
+	_, err = v.Verify(context.Background(), old)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+}
+
+func TestVerifier_FetchFailure(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	ts := mustNewPublicTokenService(t, 0, NewSigningKey(time.Now()))
+	tok, err := ts.GenerateToken("user123", "test@example.com", nil, "COMP001")
+	require.NoError(t, err)
+
+	_, err = NewVerifier(srv.URL, WithVerifierHTTPClient(srv.Client())).Verify(context.Background(), tok)
+	assert.ErrorContains(t, err, "404")
+}
+
+func TestMaxAge(t *testing.T) {
+	assert.Equal(t, 60*time.Second, maxAge("public, max-age=60"))
+	assert.Equal(t, defaultKeysMaxAge, maxAge("no-store"))
+	assert.Equal(t, defaultKeysMaxAge, maxAge(""))
+}
@@ -0,0 +1,189 @@
+// Package urlpolicy builds and validates URLs that point users back to a
+// frontend (email links, post-action redirects) so they only ever land on an
+// allow-listed origin.
+package urlpolicy
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrNoOrigins is returned by BuildFrontendURL when no frontend base URL
+	// is configured.
+	ErrNoOrigins = errors.New("urlpolicy: no frontend base URLs configured")
+	// ErrNotAllowed is returned for URLs or paths that could leave the
+	// allowed origins.
+	ErrNotAllowed = errors.New("urlpolicy: URL not allowed")
+)
+
+// Policy holds the allowed frontend origins. The first base URL is the one
+// BuildFrontendURL links to; every configured origin is accepted by
+// ValidateReturnURL.
+type Policy struct {
+	bases   []*url.URL
+	origins map[string]bool
+}
+
+// New parses baseURLs (e.g. "https://app.example.com", optionally with a path
+// prefix such as "https://example.com/app"). Each must be an absolute http(s)
+// URL without credentials, query or fragment. An empty list yields a policy
+// that accepts only relative return paths.
+func New(baseURLs []string) (*Policy, error) {
+	p := &Policy{origins: map[string]bool{}}
+	for _, raw := range baseURLs {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("frontend base URL %q: %w", raw, err)
+		}
+		switch {
+		case u.Scheme != "http" && u.Scheme != "https":
+			return nil, fmt.Errorf("frontend base URL %q: scheme must be http or https", raw)
+		case u.Host == "":
+			return nil, fmt.Errorf("frontend base URL %q: missing host", raw)
+		case u.User != nil:
+			return nil, fmt.Errorf("frontend base URL %q: must not contain credentials", raw)
+		case u.RawQuery != "" || u.Fragment != "":
+			return nil, fmt.Errorf("frontend base URL %q: must not contain a query or fragment", raw)
+		}
+		u.Path = strings.TrimRight(u.Path, "/")
+		u.RawPath = ""
+		p.bases = append(p.bases, u)
+		p.origins[origin(u)] = true
+	}
+	return p, nil
+}
+
+// ParseList splits a comma-separated FRONTEND_BASE_URLS value.
+func ParseList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// BuildFrontendURL returns the primary frontend base URL joined with path and
+// the query params (encoded in key order). path is a path below the base,
+// such as "/verify-email"; anything that could change the origin (schemes,
+// "//host", backslashes, "..") is rejected with ErrNotAllowed.
+func (p *Policy) BuildFrontendURL(path string, params map[string]string) (string, error) {
+	if len(p.bases) == 0 {
+		return "", ErrNoOrigins
+	}
+	if err := checkPath(path); err != nil {
+		return "", err
+	}
+
+	u := *p.bases[0]
+	u.Path = u.Path + "/" + strings.TrimLeft(path, "/")
+	if len(params) > 0 {
+		q := url.Values{}
+		keys := make([]string, 0, len(params))
+		for k := range params {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			q.Set(k, params[k])
+		}
+		u.RawQuery = q.Encode()
+	}
+
+	out := u.String()
+	// Belt and braces: the result must parse back onto an allowed origin.
+	if err := p.ValidateReturnURL(out); err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+// ValidateReturnURL accepts a post-action redirect target that is either a
+// rooted relative path ("/settings?tab=2") or an absolute http(s) URL on an
+// allowed origin. It rejects protocol-relative URLs ("//evil.com"),
+// non-http schemes (javascript:, data:), embedded credentials
+// ("https://allowed.com@evil.com"), backslashes and control characters, and
+// look-alike hosts such as allowed.com.evil.com.
+func (p *Policy) ValidateReturnURL(raw string) error {
+	if raw == "" {
+		return fmt.Errorf("%w: empty", ErrNotAllowed)
+	}
+	// Browsers treat "\" as "/" and strip tabs/newlines, so either can turn
+	// an innocent-looking value into a different origin.
+	for _, r := range raw {
+		if r == '\\' || r < 0x20 || r == 0x7f || r == ' ' {
+			return fmt.Errorf("%w: forbidden character", ErrNotAllowed)
+		}
+	}
+
+	if strings.HasPrefix(raw, "/") {
+		if strings.HasPrefix(raw, "//") {
+			return fmt.Errorf("%w: protocol-relative URL", ErrNotAllowed)
+		}
+		return nil
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNotAllowed, err)
+	}
+	switch {
+	case u.Scheme != "http" && u.Scheme != "https":
+		return fmt.Errorf("%w: scheme %q", ErrNotAllowed, u.Scheme)
+	case u.User != nil:
+		return fmt.Errorf("%w: credentials in URL", ErrNotAllowed)
+	case u.Host == "":
+		return fmt.Errorf("%w: missing host", ErrNotAllowed)
+	case !p.origins[origin(u)]:
+		return fmt.Errorf("%w: origin %s", ErrNotAllowed, origin(u))
+	}
+	return nil
+}
+
+// checkPath rejects BuildFrontendURL paths that are not plain paths.
+func checkPath(path string) error {
+	switch {
+	case strings.HasPrefix(path, "//"),
+		strings.ContainsAny(path, "\\?#"),
+		strings.Contains(path, ":"):
+		return fmt.Errorf("%w: path %q", ErrNotAllowed, path)
+	}
+	for _, seg := range strings.Split(path, "/") {
+		if seg == ".." {
+			return fmt.Errorf("%w: path %q", ErrNotAllowed, path)
+		}
+	}
+	for _, r := range path {
+		if r < 0x20 || r == 0x7f {
+			return fmt.Errorf("%w: path %q", ErrNotAllowed, path)
+		}
+	}
+	return nil
+}
+
+// origin is scheme://host[:port] with the host lowercased and default ports
+// dropped, so "HTTPS://App.example.com:443" matches "https://app.example.com".
+func origin(u *url.URL) string {
+	scheme := strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if (scheme == "https" && port == "443") || (scheme == "http" && port == "80") {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	return scheme + "://" + host
+}
@@ -0,0 +1,184 @@
+// Package userevents publishes user domain events to RabbitMQ for other
+// services to consume. Publishing is fire-and-forget: a failure is logged and
+// counted as a dropped event, never returned to the operation that raised it.
+package userevents
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"veemon/pkg/events"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Exchange is the topic exchange user events are published to. The event
+// type is the routing key.
+const Exchange = "user.events"
+
+// Event types, which are also the routing keys.
+const (
+	Registered = "user.registered"
+	Updated    = "user.updated"
+	Deleted    = "user.deleted"
+	LoggedIn   = "user.logged_in"
+)
+
+// SchemaVersion is the version of User carried in the envelope. Bump it on
+// any change consumers must handle differently.
+const SchemaVersion = 1
+
+// DefaultTimeout bounds one publish, so a broker outage delays the request
+// that raised the event by at most this long.
+const DefaultTimeout = 2 * time.Second
+
+// User is the Data of every user event.
+type User struct {
+	ID          string `json:"id"`
+	Email       string `json:"email"`
+	Name        string `json:"name"`
+	CompanyCode string `json:"companyCode,omitempty"`
+}
+
+// Event is one user event.
+type Event struct {
+	Type       string
+	OccurredAt time.Time
+	User       User
+}
+
+// Publisher is the subset of the RabbitMQ client Notifier needs. The client
+// adds the caller's trace context to the message headers.
+type Publisher interface {
+	PublishJSON(ctx context.Context, exchange, routingKey string, message interface{}) error
+}
+
+// DropRecorder counts events that could not be published. *metrics.Metrics
+// satisfies it.
+type DropRecorder interface {
+	RecordEventDropped(eventType string)
+}
+
+// Notifier publishes user events to RabbitMQ.
+type Notifier struct {
+	publisher Publisher
+	exchange  string
+	source    string
+	timeout   time.Duration
+	logger    *zap.Logger
+	drops     DropRecorder
+}
+
+// Option configures a Notifier.
+type Option func(*Notifier)
+
+// WithLogger logs events that could not be published.
+func WithLogger(l *zap.Logger) Option {
+	return func(n *Notifier) { n.logger = l }
+}
+
+// WithDropRecorder counts events that could not be published.
+func WithDropRecorder(r DropRecorder) Option {
+	return func(n *Notifier) { n.drops = r }
+}
+
+// WithTimeout bounds each publish; d <= 0 keeps DefaultTimeout.
+func WithTimeout(d time.Duration) Option {
+	return func(n *Notifier) {
+		if d > 0 {
+			n.timeout = d
+		}
+	}
+}
+
+// NewNotifier publishes to exchange. source is recorded on the event
+// envelope (typically the service name).
+func NewNotifier(publisher Publisher, exchange, source string, opts ...Option) *Notifier {
+	n := &Notifier{
+		publisher: publisher,
+		exchange:  exchange,
+		source:    source,
+		timeout:   DefaultTimeout,
+		logger:    zap.NewNop(),
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+	return n
+}
+
+// Publish publishes e, dropping it if the broker does not accept it in
+// time. The request's cancellation does not apply, since the change the
+// event reports has already been committed, but its trace does.
+func (n *Notifier) Publish(ctx context.Context, e Event) {
+	env, err := NewEnvelope(e, n.source)
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), n.timeout)
+		defer cancel()
+		err = n.publisher.PublishJSON(ctx, n.exchange, e.Type, env)
+	}
+	if err != nil {
+		n.logger.Warn("user event dropped",
+			zap.String("type", e.Type),
+			zap.String("user_id", e.User.ID),
+			zap.Error(err),
+		)
+		if n.drops != nil {
+			n.drops.RecordEventDropped(e.Type)
+		}
+	}
+}
+
+// NewEnvelope wraps e in a new envelope. source is recorded on it
+// (typically the service name).
+func NewEnvelope(e Event, source string) (events.Envelope, error) {
+	data, err := json.Marshal(e.User)
+	if err != nil {
+		return events.Envelope{}, err
+	}
+	return events.Envelope{
+		ID:         uuid.NewString(),
+		Type:       e.Type,
+		Version:    SchemaVersion,
+		Source:     source,
+		OccurredAt: e.OccurredAt.UTC(),
+		Data:       data,
+	}, nil
+}
+
+// Stager stages an envelope for a relay to publish. The outbox usecase
+// satisfies it.
+type Stager interface {
+	Add(ctx context.Context, exchange, routingKey string, env events.Envelope) error
+}
+
+// Outbox stages user events in the transactional outbox instead of
+// publishing them, so they commit with the change they report.
+type Outbox struct {
+	stager Stager
+	source string
+}
+
+// NewOutbox stages events through stager for Exchange. source is recorded
+// on the event envelope (typically the service name).
+func NewOutbox(stager Stager, source string) *Outbox {
+	return &Outbox{stager: stager, source: source}
+}
+
+// Enqueue stages e. Unlike Publish it returns its error, which must fail
+// the transaction e is staged in.
+func (o *Outbox) Enqueue(ctx context.Context, e Event) error {
+	env, err := NewEnvelope(e, o.source)
+	if err != nil {
+		return err
+	}
+	return o.stager.Add(ctx, Exchange, e.Type, env)
+}
+
+// Nop discards events. It stands in for Notifier when RabbitMQ is disabled.
+type Nop struct{}
+
+// Publish does nothing.
+func (Nop) Publish(context.Context, Event) {}
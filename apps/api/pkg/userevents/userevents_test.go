@@ -0,0 +1,71 @@
+package userevents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"veemon/pkg/events"
+)
+
+type capturePublisher struct {
+	exchange, routingKey string
+	message              interface{}
+	deadline             bool
+	err                  error
+}
+
+func (p *capturePublisher) PublishJSON(ctx context.Context, exchange, routingKey string, message interface{}) error {
+	p.exchange, p.routingKey, p.message = exchange, routingKey, message
+	_, p.deadline = ctx.Deadline()
+	return p.err
+}
+
+type countingDrops map[string]int
+
+func (c countingDrops) RecordEventDropped(eventType string) { c[eventType]++ }
+
+func TestNotifier_PublishesVersionedEnvelope(t *testing.T) {
+	pub := &capturePublisher{}
+	drops := countingDrops{}
+	u := User{ID: "u1", Email: "a@example.com", Name: "A", CompanyCode: "COMPANY-001"}
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// A cancelled request still gets its event out.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	NewNotifier(pub, Exchange, "api", WithDropRecorder(drops)).Publish(ctx, Event{Type: LoggedIn, OccurredAt: at, User: u})
+
+	env, ok := pub.message.(events.Envelope)
+	if !ok {
+		t.Fatalf("published %T, want events.Envelope", pub.message)
+	}
+	if pub.exchange != Exchange || pub.routingKey != LoggedIn || env.Type != LoggedIn ||
+		env.Version != SchemaVersion || env.Source != "api" || env.ID == "" || !env.OccurredAt.Equal(at) {
+		t.Fatalf("published to %s/%s: %+v", pub.exchange, pub.routingKey, env)
+	}
+	if !pub.deadline {
+		t.Fatal("published without a deadline")
+	}
+	var got User
+	if err := json.Unmarshal(env.Data, &got); err != nil || got != u {
+		t.Fatalf("data = %+v, %v; want %+v", got, err, u)
+	}
+	if len(drops) != 0 {
+		t.Fatalf("drops = %v, want none", drops)
+	}
+}
+
+func TestNotifier_CountsDroppedEvents(t *testing.T) {
+	drops := countingDrops{}
+	n := NewNotifier(&capturePublisher{err: errors.New("not connected")}, Exchange, "api", WithDropRecorder(drops))
+
+	n.Publish(context.Background(), Event{Type: Updated, User: User{ID: "u1"}})
+	n.Publish(context.Background(), Event{Type: Updated, User: User{ID: "u1"}})
+
+	if drops[Updated] != 2 {
+		t.Fatalf("drops = %v, want 2 %s", drops, Updated)
+	}
+}
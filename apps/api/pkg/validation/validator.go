@@ -62,18 +62,23 @@ func ValidateVarWithValue(field interface{}, other interface{}, tag string) erro
 
 func formatValidationErrors(errs validator.ValidationErrors) error {
 	var messages []string
+	var fields []errors.FieldViolation
 	for _, e := range errs {
-		messages = append(messages, formatFieldError(e))
+		msg := formatFieldError(e)
+		messages = append(messages, msg)
+		fields = append(fields, errors.FieldViolation{Field: e.Field(), Description: msg})
 	}
-	return errors.ValidationError(strings.Join(messages, "; "))
+	return errors.ValidationError(strings.Join(messages, "; ")).WithFields(fields...)
 }
 
 func formatFieldError(e validator.FieldError) string {
 	field := e.Field()
 
 	switch e.Tag() {
-	case "required":
+	case "required", "required_if", "required_unless":
 		return fmt.Sprintf("%s is required", field)
+	case "excluded_if", "excluded_unless":
+		return fmt.Sprintf("%s must not be set", field)
 	case "email":
 		return fmt.Sprintf("%s must be a valid email", field)
 	case "min":
@@ -3,7 +3,10 @@ package validation
 import (
 	"testing"
 
+	"veemon/pkg/errors"
+
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type TestUser struct {
@@ -38,6 +41,17 @@ func TestValidate_RequiredFields(t *testing.T) {
 	assert.Contains(t, err.Error(), "email is required")
 }
 
+func TestValidate_ReportsFieldViolations(t *testing.T) {
+	err := Validate(TestUser{Email: "not-an-email", Password: "password123"})
+
+	appErr, ok := err.(*errors.AppError)
+	require.True(t, ok)
+	assert.Equal(t, []errors.FieldViolation{
+		{Field: "email", Description: "email must be a valid email"},
+		{Field: "name", Description: "name is required"},
+	}, appErr.Fields)
+}
+
 func TestValidate_InvalidEmail(t *testing.T) {
 	user := TestUser{
 		Email:    "not-an-email",
@@ -0,0 +1,295 @@
+// Package worker routes consumed RabbitMQ messages to handlers by routing
+// key. A Router is itself a rabbitmq handler: pass its Dispatch method to
+// rabbitmq.Client.ConsumeWithHandler and register one handler per routing
+// key pattern.
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"veemon/pkg/events"
+	"veemon/pkg/rabbitmq"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+// OutcomeOK is the outcome of a handled message; a failed one's is its
+// rabbitmq.FailureType.
+const OutcomeOK = "ok"
+
+// Message is a decoded delivery handed to a handler.
+type Message struct {
+	RoutingKey  string
+	Headers     amqp.Table
+	Redelivered bool
+	// ContentType, Envelope and Text are as decoded by events.Decode.
+	*events.Message
+}
+
+// HandlerFunc handles one message. Returning a *rabbitmq.RejectError
+// dead-letters it; any other error nacks it, so the consumer requeues it once.
+type HandlerFunc func(ctx context.Context, msg *Message) error
+
+// JSON adapts fn to a HandlerFunc that unmarshals the envelope's Data into a
+// T. A message without an envelope, or whose Data does not fit T, is
+// rejected rather than retried.
+func JSON[T any](fn func(ctx context.Context, msg *Message, payload T) error) HandlerFunc {
+	return func(ctx context.Context, msg *Message) error {
+		if msg.Envelope == nil {
+			err := fmt.Errorf("%w: %s has no envelope", events.ErrMalformed, msg.ContentType)
+			return rabbitmq.Reject(err.Error(), err)
+		}
+		var payload T
+		if err := json.Unmarshal(msg.Envelope.Data, &payload); err != nil {
+			err = fmt.Errorf("%w: %s data: %v", events.ErrMalformed, msg.Envelope.Type, err)
+			return rabbitmq.Reject(err.Error(), err)
+		}
+		return fn(ctx, msg, payload)
+	}
+}
+
+// Recorder records every handled message. *metrics.Metrics satisfies it.
+type Recorder interface {
+	ObserveHandler(handler, outcome string, d time.Duration)
+}
+
+// HandlerOption configures one registered handler.
+type HandlerOption func(*route)
+
+// WithName names the handler in logs and metrics; the pattern is the
+// default.
+func WithName(name string) HandlerOption {
+	return func(r *route) { r.name = name }
+}
+
+// WithConcurrency caps how many messages the handler works on at once
+// across all consumers; n < 1 means no cap. A consumer whose message finds
+// the handler full waits for a slot.
+func WithConcurrency(n int) HandlerOption {
+	return func(r *route) {
+		if n > 0 {
+			r.sem = make(chan struct{}, n)
+		} else {
+			r.sem = nil
+		}
+	}
+}
+
+// WithRetry retries a failed message in place up to attempts times in all,
+// waiting delay before the second attempt and doubling it after each.
+// Rejections and panics are not retried. Without it a message is tried once
+// before it is nacked.
+func WithRetry(attempts int, delay time.Duration) HandlerOption {
+	return func(r *route) {
+		r.attempts = max(attempts, 1)
+		r.delay = delay
+	}
+}
+
+// WithTimeout bounds each attempt; d <= 0 means no bound.
+func WithTimeout(d time.Duration) HandlerOption {
+	return func(r *route) { r.timeout = d }
+}
+
+type route struct {
+	pattern  []string
+	name     string
+	handler  HandlerFunc
+	sem      chan struct{}
+	attempts int
+	delay    time.Duration
+	timeout  time.Duration
+}
+
+// Option configures a Router.
+type Option func(*Router)
+
+// WithLogger logs unrouted messages and handler failures.
+func WithLogger(l *zap.Logger) Option {
+	return func(r *Router) { r.logger = l }
+}
+
+// WithRecorder records the duration and outcome of every handled message.
+func WithRecorder(rec Recorder) Option {
+	return func(r *Router) { r.recorder = rec }
+}
+
+// Router dispatches messages to the first handler whose pattern matches the
+// routing key. Handle must not be called once Dispatch is in use.
+type Router struct {
+	routes   []*route
+	logger   *zap.Logger
+	recorder Recorder
+}
+
+// NewRouter returns a Router with no handlers.
+func NewRouter(opts ...Option) *Router {
+	r := &Router{logger: zap.NewNop()}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Handle registers h for routing keys matching pattern. Patterns follow
+// topic exchange syntax: words are separated by dots, "*" matches exactly
+// one word and "#" zero or more. Handlers are tried in registration order,
+// so register specific patterns before general ones.
+func (r *Router) Handle(pattern string, h HandlerFunc, opts ...HandlerOption) {
+	rt := &route{pattern: strings.Split(pattern, "."), name: pattern, handler: h, attempts: 1}
+	for _, opt := range opts {
+		opt(rt)
+	}
+	r.routes = append(r.routes, rt)
+}
+
+// Dispatch decodes msg and runs the handler for its routing key. A body that
+// can never decode is rejected. A message no handler matches is logged and
+// acknowledged, so an extra binding cannot wedge the queue.
+func (r *Router) Dispatch(ctx context.Context, msg amqp.Delivery) error {
+	r.logger.Info("Processing message",
+		zap.String("routing_key", msg.RoutingKey),
+		zap.String("content_type", msg.ContentType),
+		zap.Int("body_size", len(msg.Body)),
+	)
+
+	decoded, err := events.Decode(msg.ContentType, msg.Body)
+	if err != nil {
+		// Only a redacted, truncated preview is logged — bodies may carry PII
+		// or secrets.
+		r.logger.Error("Rejecting message",
+			zap.Error(err),
+			zap.String("routing_key", msg.RoutingKey),
+			zap.String("content_type", msg.ContentType),
+			zap.Int("body_size", len(msg.Body)),
+			zap.String("body_preview", events.Preview(msg.Body)),
+		)
+		if events.IsPermanent(err) {
+			return rabbitmq.Reject(err.Error(), err)
+		}
+		return fmt.Errorf("invalid message format: %w", err)
+	}
+
+	rt := r.match(msg.RoutingKey)
+	if rt == nil {
+		r.logger.Warn("No handler for message", zap.String("routing_key", msg.RoutingKey))
+		return nil
+	}
+
+	m := &Message{
+		RoutingKey:  msg.RoutingKey,
+		Headers:     msg.Headers,
+		Redelivered: msg.Redelivered,
+		Message:     decoded,
+	}
+	start := time.Now()
+	err = rt.run(ctx, m)
+	outcome := outcomeOf(err)
+	if r.recorder != nil {
+		r.recorder.ObserveHandler(rt.name, outcome, time.Since(start))
+	}
+	if err != nil {
+		fields := []zap.Field{
+			zap.String("handler", rt.name),
+			zap.String("routing_key", msg.RoutingKey),
+			zap.String("outcome", outcome),
+			zap.Error(err),
+		}
+		if decoded.Envelope != nil {
+			fields = append(fields, zap.String("event_id", decoded.Envelope.ID))
+		}
+		r.logger.Error("Handler failed", fields...)
+	}
+	return err
+}
+
+func (r *Router) match(routingKey string) *route {
+	words := strings.Split(routingKey, ".")
+	for _, rt := range r.routes {
+		if matchTopic(rt.pattern, words) {
+			return rt
+		}
+	}
+	return nil
+}
+
+// run calls the handler within its concurrency cap, retrying as configured.
+func (rt *route) run(ctx context.Context, msg *Message) error {
+	if rt.sem != nil {
+		select {
+		case rt.sem <- struct{}{}:
+			defer func() { <-rt.sem }()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	delay := rt.delay
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = rt.call(ctx, msg)
+		if err == nil || attempt >= rt.attempts || !retryable(err) {
+			return err
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return err
+		}
+		delay *= 2
+	}
+}
+
+// call runs one attempt, returning a panic as a *rabbitmq.PanicError so the
+// message is nacked and the consumer goroutine survives.
+func (rt *route) call(ctx context.Context, msg *Message) (err error) {
+	if rt.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, rt.timeout)
+		defer cancel()
+	}
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = &rabbitmq.PanicError{Value: rec}
+		}
+	}()
+	return rt.handler(ctx, msg)
+}
+
+func retryable(err error) bool {
+	var rejectErr *rabbitmq.RejectError
+	var panicErr *rabbitmq.PanicError
+	return !errors.As(err, &rejectErr) && !errors.As(err, &panicErr)
+}
+
+func outcomeOf(err error) string {
+	if err == nil {
+		return OutcomeOK
+	}
+	return string(rabbitmq.ClassifyFailure(err))
+}
+
+// matchTopic reports whether words matches pattern under topic exchange
+// rules.
+func matchTopic(pattern, words []string) bool {
+	if len(pattern) == 0 {
+		return len(words) == 0
+	}
+	if pattern[0] == "#" {
+		for i := 0; i <= len(words); i++ {
+			if matchTopic(pattern[1:], words[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(words) == 0 || (pattern[0] != "*" && pattern[0] != words[0]) {
+		return false
+	}
+	return matchTopic(pattern[1:], words[1:])
+}
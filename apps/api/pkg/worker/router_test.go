@@ -0,0 +1,173 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"veemon/pkg/events"
+	"veemon/pkg/rabbitmq"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recorder struct {
+	mu       sync.Mutex
+	outcomes []string
+}
+
+func (r *recorder) ObserveHandler(handler, outcome string, _ time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.outcomes = append(r.outcomes, handler+":"+outcome)
+}
+
+func delivery(routingKey, body string) amqp.Delivery {
+	return amqp.Delivery{RoutingKey: routingKey, ContentType: events.ContentTypeJSON, Body: []byte(body)}
+}
+
+const registered = `{"id":"e1","type":"user.registered","occurredAt":"2026-03-01T12:00:00Z","data":{"id":"u1","email":"a@example.com"}}`
+
+func TestMatchTopic(t *testing.T) {
+	for _, tc := range []struct {
+		pattern, key string
+		want         bool
+	}{
+		{"user.registered", "user.registered", true},
+		{"user.registered", "user.updated", false},
+		{"user.*", "user.registered", true},
+		{"user.*", "user.registered.v2", false},
+		{"user.*", "user", false},
+		{"user.#", "user", true},
+		{"user.#", "user.registered.v2", true},
+		{"#", "anything.at.all", true},
+		{"*.registered", "user.registered", true},
+		{"#.registered", "a.b.registered", true},
+		{"#.registered", "a.b.updated", false},
+		{"user.#.v2", "user.v2", true},
+	} {
+		got := matchTopic(strings.Split(tc.pattern, "."), strings.Split(tc.key, "."))
+		assert.Equal(t, tc.want, got, "%s ~ %s", tc.pattern, tc.key)
+	}
+}
+
+func TestDispatch_FirstMatchDecodesTypedPayload(t *testing.T) {
+	rec := &recorder{}
+	r := NewRouter(WithRecorder(rec))
+	var got struct {
+		ID    string `json:"id"`
+		Email string `json:"email"`
+	}
+	r.Handle("user.registered", JSON(func(_ context.Context, msg *Message, p struct {
+		ID    string `json:"id"`
+		Email string `json:"email"`
+	}) error {
+		got = p
+		assert.Equal(t, "e1", msg.Envelope.ID)
+		return nil
+	}), WithName("welcome"))
+	r.Handle("user.#", func(context.Context, *Message) error {
+		t.Error("second match ran")
+		return nil
+	})
+
+	require.NoError(t, r.Dispatch(context.Background(), delivery("user.registered", registered)))
+	assert.Equal(t, "u1", got.ID)
+	assert.Equal(t, "a@example.com", got.Email)
+	assert.Equal(t, []string{"welcome:ok"}, rec.outcomes)
+}
+
+func TestDispatch_RejectsUndecodablePayload(t *testing.T) {
+	r := NewRouter()
+	r.Handle("#", JSON(func(context.Context, *Message, []string) error { return nil }))
+
+	err := r.Dispatch(context.Background(), delivery("user.registered", registered))
+
+	var rejectErr *rabbitmq.RejectError
+	assert.ErrorAs(t, err, &rejectErr)
+	assert.ErrorIs(t, err, events.ErrMalformed)
+}
+
+func TestDispatch_UnmatchedIsAcked(t *testing.T) {
+	r := NewRouter()
+	r.Handle("user.#", func(context.Context, *Message) error { return errors.New("ran") })
+
+	assert.NoError(t, r.Dispatch(context.Background(), delivery("order.created", `{"id":"e1","type":"order.created","occurredAt":"2026-03-01T12:00:00Z"}`)))
+}
+
+func TestDispatch_RetriesUntilSuccess(t *testing.T) {
+	rec := &recorder{}
+	r := NewRouter(WithRecorder(rec))
+	calls := 0
+	r.Handle("#", func(context.Context, *Message) error {
+		calls++
+		if calls < 3 {
+			return errors.New("transient")
+		}
+		return nil
+	}, WithRetry(3, time.Millisecond))
+
+	require.NoError(t, r.Dispatch(context.Background(), delivery("a", registered)))
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, []string{"#:ok"}, rec.outcomes)
+}
+
+func TestDispatch_DoesNotRetryRejections(t *testing.T) {
+	r := NewRouter()
+	calls := 0
+	r.Handle("#", func(context.Context, *Message) error {
+		calls++
+		return rabbitmq.Reject("bad", nil)
+	}, WithRetry(3, time.Millisecond))
+
+	assert.Error(t, r.Dispatch(context.Background(), delivery("a", registered)))
+	assert.Equal(t, 1, calls)
+}
+
+func TestDispatch_PanicBecomesError(t *testing.T) {
+	rec := &recorder{}
+	r := NewRouter(WithRecorder(rec))
+	r.Handle("#", func(context.Context, *Message) error { panic("boom") }, WithRetry(3, time.Millisecond))
+
+	err := r.Dispatch(context.Background(), delivery("a", registered))
+
+	var panicErr *rabbitmq.PanicError
+	require.ErrorAs(t, err, &panicErr)
+	assert.Equal(t, "boom", panicErr.Value)
+	assert.Equal(t, []string{"#:panic"}, rec.outcomes)
+}
+
+func TestDispatch_ConcurrencyCap(t *testing.T) {
+	r := NewRouter()
+	var running, peak atomic.Int32
+	r.Handle("#", func(context.Context, *Message) error {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		running.Add(-1)
+		return nil
+	}, WithConcurrency(2))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, r.Dispatch(context.Background(), delivery("a", registered)))
+		}()
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, peak.Load(), int32(2))
+}
@@ -0,0 +1,93 @@
+// Package audit_repository provides data access for the audit log.
+package audit_repository
+
+import (
+	"context"
+	"time"
+
+	"veemon/entity"
+	"veemon/pkg/database"
+	repoerr "veemon/repository"
+
+	"gorm.io/gorm"
+)
+
+// Repository methods return the errors of package repository (imported as
+// repoerr) in place of GORM and driver errors.
+type Repository interface {
+	// Create appends entry to the log. Inside a database.TxManager
+	// transaction it commits or rolls back with the change it records.
+	Create(ctx context.Context, entry *entity.AuditLog) error
+	// FindAll returns one page of the entries matching params, newest first,
+	// and how many match in total.
+	FindAll(ctx context.Context, params ListParams) ([]entity.AuditLog, int64, error)
+}
+
+// ListParams filters and pages FindAll. Empty filters match everything.
+type ListParams struct {
+	Page         int
+	Size         int
+	ActorID      string
+	ResourceType string
+	ResourceID   string
+	// From and To bound created_at: entries at or after From and before To.
+	// A zero time leaves that end open.
+	From time.Time
+	To   time.Time
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// conn returns the handle for ctx, joining the caller's transaction when
+// ctx carries one (see database.TxManager).
+func (r *repository) conn(ctx context.Context) *gorm.DB {
+	return database.FromContext(ctx, r.db)
+}
+
+func (r *repository) Create(ctx context.Context, entry *entity.AuditLog) error {
+	return repoerr.Translate(r.conn(ctx).Create(entry).Error)
+}
+
+func (r *repository) FindAll(ctx context.Context, params ListParams) ([]entity.AuditLog, int64, error) {
+	var entries []entity.AuditLog
+	var total int64
+
+	query := r.conn(ctx).Model(&entity.AuditLog{})
+	if params.ActorID != "" {
+		query = query.Where("actor_id = ?", params.ActorID)
+	}
+	if params.ResourceType != "" {
+		query = query.Where("resource_type = ?", params.ResourceType)
+	}
+	if params.ResourceID != "" {
+		query = query.Where("resource_id = ?", params.ResourceID)
+	}
+	if !params.From.IsZero() {
+		query = query.Where("created_at >= ?", params.From)
+	}
+	if !params.To.IsZero() {
+		query = query.Where("created_at < ?", params.To)
+	}
+
+	// Count on its own session so its statement is not reused by the Find.
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, repoerr.Translate(err)
+	}
+
+	// id breaks ties so pages are stable.
+	err := query.
+		Order("created_at desc, id desc").
+		Offset((params.Page - 1) * params.Size).
+		Limit(params.Size).
+		Find(&entries).Error
+	if err != nil {
+		return nil, 0, repoerr.Translate(err)
+	}
+	return entries, total, nil
+}
@@ -0,0 +1,62 @@
+package audit_repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// dryRunDB returns a DB that builds SQL without a server, plus the last
+// SELECT it produced.
+func dryRunDB(t *testing.T) (*gorm.DB, *string) {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=dryrun"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatalf("open dry-run db: %v", err)
+	}
+	var sql string
+	if err := db.Callback().Query().After("gorm:query").Register("test:capture", func(tx *gorm.DB) {
+		sql = tx.Statement.SQL.String()
+	}); err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+	return db, &sql
+}
+
+func TestFindAll_AppliesFiltersNewestFirst(t *testing.T) {
+	db, sql := dryRunDB(t)
+
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, _, err := New(db).FindAll(context.Background(), ListParams{
+		Page: 3, Size: 20, ActorID: "admin-1", ResourceType: "user", ResourceID: "user-9", From: from, To: from.AddDate(0, 1, 0),
+	})
+	if err != nil {
+		t.Fatalf("FindAll: %v", err)
+	}
+	for _, want := range []string{
+		"actor_id = $1", "resource_type = $2", "resource_id = $3", "created_at >= $4", "created_at < $5",
+		"ORDER BY created_at desc, id desc", "LIMIT $6 OFFSET $7",
+	} {
+		if !strings.Contains(*sql, want) {
+			t.Errorf("SQL %q missing %q", *sql, want)
+		}
+	}
+}
+
+func TestFindAll_NoFiltersMatchesEverything(t *testing.T) {
+	db, sql := dryRunDB(t)
+
+	if _, _, err := New(db).FindAll(context.Background(), ListParams{Page: 1, Size: 10}); err != nil {
+		t.Fatalf("FindAll: %v", err)
+	}
+	if strings.Contains(*sql, "WHERE") {
+		t.Errorf("unfiltered query has a WHERE clause: %s", *sql)
+	}
+}
@@ -0,0 +1,64 @@
+// Package repository holds what the repository packages share: the errors
+// their methods return in place of GORM's and the driver's, so callers can
+// tell the outcomes apart without importing either.
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrNotFound is returned when no row matches.
+	ErrNotFound = errors.New("record not found")
+	// ErrDuplicate is returned when a write hits a unique constraint.
+	ErrDuplicate = errors.New("duplicate record")
+	// ErrConstraint is returned when a write violates any other integrity
+	// constraint: a foreign key, check, not-null or exclusion constraint.
+	ErrConstraint = errors.New("constraint violation")
+)
+
+// Postgres SQLSTATEs Translate maps.
+const (
+	codeUniqueViolation     = "23505"
+	codeForeignKeyViolation = "23503"
+	codeNotNullViolation    = "23502"
+	codeCheckViolation      = "23514"
+	codeExclusionViolation  = "23P01"
+)
+
+// sqlStateError is implemented by driver errors that carry a SQLSTATE:
+// *pgconn.PgError and *pq.Error.
+type sqlStateError interface {
+	SQLState() string
+}
+
+// Translate maps err from GORM or the driver to ErrNotFound, ErrDuplicate or
+// ErrConstraint, and returns any other error unchanged. It recognizes both
+// the GORM sentinels a connection with TranslateError produces and the raw
+// pgx or lib/pq errors of one without. ErrDuplicate and ErrConstraint wrap
+// err, so its message and constraint name still reach the logs.
+func Translate(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	code := ""
+	var state sqlStateError
+	if errors.As(err, &state) {
+		code = state.SQLState()
+	}
+	switch {
+	case code == codeUniqueViolation, errors.Is(err, gorm.ErrDuplicatedKey):
+		return fmt.Errorf("%w: %w", ErrDuplicate, err)
+	case code == codeForeignKeyViolation, code == codeNotNullViolation,
+		code == codeCheckViolation, code == codeExclusionViolation,
+		errors.Is(err, gorm.ErrForeignKeyViolated), errors.Is(err, gorm.ErrCheckConstraintViolated):
+		return fmt.Errorf("%w: %w", ErrConstraint, err)
+	}
+	return err
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+func TestTranslate(t *testing.T) {
+	driverErr := errors.New("connection reset by peer")
+	tests := []struct {
+		name string
+		err  error
+		want error // nil: err is returned unchanged
+	}{
+		{"record not found", gorm.ErrRecordNotFound, ErrNotFound},
+		{"wrapped record not found", fmt.Errorf("find user: %w", gorm.ErrRecordNotFound), ErrNotFound},
+		{"unique violation, translated by gorm", gorm.ErrDuplicatedKey, ErrDuplicate},
+		{"unique violation, pgx", &pgconn.PgError{Code: "23505", ConstraintName: "idx_users_email_live"}, ErrDuplicate},
+		{"unique violation, lib/pq", &pq.Error{Code: "23505"}, ErrDuplicate},
+		{"foreign key violation, translated by gorm", gorm.ErrForeignKeyViolated, ErrConstraint},
+		{"foreign key violation, pgx", &pgconn.PgError{Code: "23503", ConstraintName: "fk_audit_logs_actor"}, ErrConstraint},
+		{"foreign key violation, lib/pq", &pq.Error{Code: "23503"}, ErrConstraint},
+		{"check violation", &pgconn.PgError{Code: "23514"}, ErrConstraint},
+		{"not-null violation", &pgconn.PgError{Code: "23502"}, ErrConstraint},
+		{"other SQLSTATE", &pgconn.PgError{Code: "40001"}, nil},
+		{"other error", driverErr, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Translate(tt.err)
+
+			if tt.want == nil {
+				if err != tt.err {
+					t.Fatalf("Translate(%v) = %v, want it unchanged", tt.err, err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Translate(%v) = %v, want %v", tt.err, err, tt.want)
+			}
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				t.Errorf("Translate(%v) still matches gorm.ErrRecordNotFound", tt.err)
+			}
+		})
+	}
+}
+
+func TestTranslate_KeepsTheDriverError(t *testing.T) {
+	pgErr := &pgconn.PgError{Code: "23503", ConstraintName: "fk_audit_logs_actor"}
+
+	err := Translate(pgErr)
+
+	var got *pgconn.PgError
+	if !errors.As(err, &got) || got.ConstraintName != "fk_audit_logs_actor" {
+		t.Fatalf("Translate(%v) = %v, want the *pgconn.PgError reachable", pgErr, err)
+	}
+}
+
+func TestTranslate_Nil(t *testing.T) {
+	if err := Translate(nil); err != nil {
+		t.Fatalf("Translate(nil) = %v, want nil", err)
+	}
+}
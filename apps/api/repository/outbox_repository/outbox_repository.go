@@ -0,0 +1,107 @@
+// Package outbox_repository provides data access for the transactional
+// outbox.
+package outbox_repository
+
+import (
+	"context"
+	"time"
+
+	"veemon/entity"
+	"veemon/pkg/database"
+	repoerr "veemon/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repository methods return the errors of package repository (imported as
+// repoerr) in place of GORM and driver errors.
+type Repository interface {
+	// Create stages msg. Inside a database.TxManager transaction it commits
+	// or rolls back with the change it reports.
+	Create(ctx context.Context, msg *entity.OutboxMessage) error
+	// ClaimDue returns up to limit unsent messages due at now, oldest first,
+	// locking them until the caller's transaction ends. Rows another relay
+	// has locked are skipped, so relays can run side by side.
+	ClaimDue(ctx context.Context, now time.Time, limit int) ([]entity.OutboxMessage, error)
+	// MarkSent records that id was published at at.
+	MarkSent(ctx context.Context, id string, at time.Time) error
+	// MarkFailed records a failed attempt to publish id, to be retried from
+	// next on.
+	MarkFailed(ctx context.Context, id string, attempts int, next time.Time, lastErr string) error
+	// DeleteSentBefore deletes messages sent before before, returning how
+	// many.
+	DeleteSentBefore(ctx context.Context, before time.Time) (int64, error)
+	// Backlog counts the unsent messages and returns the creation time of
+	// the oldest, zero when there are none.
+	Backlog(ctx context.Context) (int64, time.Time, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// conn returns the handle for ctx, joining the caller's transaction when
+// ctx carries one (see database.TxManager).
+func (r *repository) conn(ctx context.Context) *gorm.DB {
+	return database.FromContext(ctx, r.db)
+}
+
+func (r *repository) Create(ctx context.Context, msg *entity.OutboxMessage) error {
+	return repoerr.Translate(r.conn(ctx).Create(msg).Error)
+}
+
+func (r *repository) ClaimDue(ctx context.Context, now time.Time, limit int) ([]entity.OutboxMessage, error) {
+	var msgs []entity.OutboxMessage
+	err := r.conn(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("sent_at IS NULL AND next_attempt_at <= ?", now).
+		Order("created_at, id").
+		Limit(limit).
+		Find(&msgs).Error
+	return msgs, repoerr.Translate(err)
+}
+
+func (r *repository) MarkSent(ctx context.Context, id string, at time.Time) error {
+	err := r.conn(ctx).Model(&entity.OutboxMessage{}).
+		Where("id = ?", id).
+		Update("sent_at", at).Error
+	return repoerr.Translate(err)
+}
+
+func (r *repository) MarkFailed(ctx context.Context, id string, attempts int, next time.Time, lastErr string) error {
+	err := r.conn(ctx).Model(&entity.OutboxMessage{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"attempts":        attempts,
+			"next_attempt_at": next,
+			"last_error":      lastErr,
+		}).Error
+	return repoerr.Translate(err)
+}
+
+func (r *repository) DeleteSentBefore(ctx context.Context, before time.Time) (int64, error) {
+	res := r.conn(ctx).
+		Where("sent_at IS NOT NULL AND sent_at < ?", before).
+		Delete(&entity.OutboxMessage{})
+	return res.RowsAffected, repoerr.Translate(res.Error)
+}
+
+func (r *repository) Backlog(ctx context.Context) (int64, time.Time, error) {
+	var row struct {
+		Pending int64
+		Oldest  *time.Time
+	}
+	err := r.conn(ctx).Model(&entity.OutboxMessage{}).
+		Select("count(*) AS pending, min(created_at) AS oldest").
+		Where("sent_at IS NULL").
+		Scan(&row).Error
+	if err != nil || row.Oldest == nil {
+		return row.Pending, time.Time{}, repoerr.Translate(err)
+	}
+	return row.Pending, *row.Oldest, nil
+}
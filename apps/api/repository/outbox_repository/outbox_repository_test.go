@@ -0,0 +1,46 @@
+package outbox_repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// dryRunDB returns a DB that builds SQL without a server, plus the last
+// SELECT it produced.
+func dryRunDB(t *testing.T) (*gorm.DB, *string) {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=dryrun"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatalf("open dry-run db: %v", err)
+	}
+	var sql string
+	if err := db.Callback().Query().After("gorm:query").Register("test:capture", func(tx *gorm.DB) {
+		sql = tx.Statement.SQL.String()
+	}); err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+	return db, &sql
+}
+
+func TestClaimDue_LocksDueUnsentRowsOldestFirst(t *testing.T) {
+	db, sql := dryRunDB(t)
+
+	if _, err := New(db).ClaimDue(context.Background(), time.Now(), 50); err != nil {
+		t.Fatalf("ClaimDue: %v", err)
+	}
+	for _, want := range []string{
+		"sent_at IS NULL AND next_attempt_at <= $1", "ORDER BY created_at, id", "LIMIT $2", "FOR UPDATE SKIP LOCKED",
+	} {
+		if !strings.Contains(*sql, want) {
+			t.Errorf("SQL %q missing %q", *sql, want)
+		}
+	}
+}
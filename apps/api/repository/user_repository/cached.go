@@ -0,0 +1,129 @@
+package user_repository
+
+import (
+	"context"
+	"time"
+
+	"veemon/entity"
+	"veemon/pkg/database"
+	"veemon/pkg/metrics"
+	"veemon/pkg/redis"
+)
+
+// cacheName labels the user cache in the cache hit/miss metrics.
+const cacheName = "user"
+
+// CacheRecorder receives cache hits and misses. *metrics.Metrics satisfies
+// it.
+type CacheRecorder interface {
+	RecordCacheHit(cache string)
+	RecordCacheMiss(cache string)
+}
+
+// cached serves FindByID from Redis and forwards everything else to the
+// wrapped Repository.
+type cached struct {
+	Repository
+	redis    *redis.Client
+	ttl      time.Duration
+	recorder CacheRecorder
+}
+
+// NewCached wraps repo so FindByID results are cached as JSON in Redis
+// under "user:<id>" for ttl. A nil redis client or a ttl under one second
+// (the SETEX resolution) returns repo unchanged.
+//
+// Writes made through the wrapper drop the key of the user they touch, and
+// inside a transaction drop it again once the transaction commits: a reader
+// outside it still sees the old row until then and may cache it meanwhile.
+// Redis is only an optimization: a failed read falls through to repo and a
+// failed write or invalidation is ignored, leaving a stale entry to expire
+// with ttl. The same bound applies to rows changed without the wrapper,
+// such as those purged by PurgeDeletionsRequestedBefore, whose ids it never
+// sees, or by another process.
+//
+// Cached users carry no password hash (entity.User never serializes it), so
+// callers that need the hash use FindCredentialsByID or FindByEmail, which
+// the wrapper does not cache. Reads inside a transaction bypass the cache
+// both ways, so a transaction sees current rows and never publishes
+// uncommitted ones.
+func NewCached(repo Repository, r *redis.Client, ttl time.Duration) Repository {
+	if r == nil || ttl < time.Second {
+		return repo
+	}
+	c := &cached{Repository: repo, redis: r, ttl: ttl}
+	if m := metrics.Get(); m != nil {
+		c.recorder = m
+	}
+	return c
+}
+
+func cacheKey(id string) string { return "user:" + id }
+
+func (c *cached) FindByID(ctx context.Context, id string) (*entity.User, error) {
+	if database.InTx(ctx) {
+		return c.Repository.FindByID(ctx, id)
+	}
+
+	var user entity.User
+	if err := c.redis.Get(ctx, cacheKey(id), &user); err == nil {
+		c.record(true)
+		return &user, nil
+	}
+	c.record(false)
+
+	// From the primary: a lagging replica could cache a row an update has
+	// just invalidated for the whole TTL.
+	found, err := c.Repository.FindByID(database.WithPrimary(ctx), id)
+	if err != nil {
+		return nil, err
+	}
+	_ = c.redis.Set(ctx, cacheKey(id), found, c.ttl)
+	return found, nil
+}
+
+func (c *cached) UpdateFields(ctx context.Context, id string, fields map[string]interface{}, expectedUpdatedAt *time.Time) (*entity.User, error) {
+	user, err := c.Repository.UpdateFields(ctx, id, fields, expectedUpdatedAt)
+	c.invalidate(ctx, id)
+	return user, err
+}
+
+func (c *cached) Restore(ctx context.Context, id string, fields map[string]interface{}) (*entity.User, error) {
+	user, err := c.Repository.Restore(ctx, id, fields)
+	c.invalidate(ctx, id)
+	return user, err
+}
+
+func (c *cached) Delete(ctx context.Context, id string) error {
+	err := c.Repository.Delete(ctx, id)
+	c.invalidate(ctx, id)
+	return err
+}
+
+func (c *cached) HardDelete(ctx context.Context, id string) error {
+	err := c.Repository.HardDelete(ctx, id)
+	c.invalidate(ctx, id)
+	return err
+}
+
+// invalidate drops id's entry whatever the write returned, and again after
+// the caller's transaction, if any, commits; a spurious miss costs one query,
+// a missed invalidation up to ttl of stale reads.
+func (c *cached) invalidate(ctx context.Context, id string) {
+	_ = c.redis.Delete(ctx, cacheKey(id))
+	if database.InTx(ctx) {
+		ctx = context.WithoutCancel(ctx)
+		database.AfterCommit(ctx, func() { _ = c.redis.Delete(ctx, cacheKey(id)) })
+	}
+}
+
+func (c *cached) record(hit bool) {
+	if c.recorder == nil {
+		return
+	}
+	if hit {
+		c.recorder.RecordCacheHit(cacheName)
+	} else {
+		c.recorder.RecordCacheMiss(cacheName)
+	}
+}
@@ -0,0 +1,313 @@
+package user_repository
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"veemon/entity"
+	"veemon/internal/fixtures"
+	"veemon/pkg/database"
+	"veemon/pkg/redis"
+	repoerr "veemon/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// countingRepo serves FindByID from a map and counts the calls that reach
+// it. Methods the cache does not override panic through the nil embed.
+type countingRepo struct {
+	Repository
+	users map[string]*entity.User
+	finds int
+}
+
+func (r *countingRepo) FindByID(_ context.Context, id string) (*entity.User, error) {
+	r.finds++
+	u, ok := r.users[id]
+	if !ok {
+		return nil, repoerr.ErrNotFound
+	}
+	clone := *u
+	return &clone, nil
+}
+
+func (r *countingRepo) FindCredentialsByID(ctx context.Context, id string) (*entity.User, error) {
+	return r.FindByID(ctx, id)
+}
+
+func (r *countingRepo) UpdateFields(_ context.Context, id string, fields map[string]interface{}, _ *time.Time) (*entity.User, error) {
+	u, ok := r.users[id]
+	if !ok {
+		return nil, repoerr.ErrNotFound
+	}
+	if name, ok := fields["name"].(string); ok {
+		u.Name = name
+	}
+	clone := *u
+	return &clone, nil
+}
+
+func (r *countingRepo) Delete(_ context.Context, id string) error {
+	delete(r.users, id)
+	return nil
+}
+
+type countingRecorder struct{ hits, misses int }
+
+func (r *countingRecorder) RecordCacheHit(string)  { r.hits++ }
+func (r *countingRecorder) RecordCacheMiss(string) { r.misses++ }
+
+func newMiniredisClient(t *testing.T) (*redis.Client, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	port, _ := strconv.Atoi(mr.Port())
+	rc, err := redis.New(redis.Config{Host: mr.Host(), Port: port, MaxIdle: 1, MaxActive: 2})
+	if err != nil {
+		t.Fatalf("redis.New: %v", err)
+	}
+	t.Cleanup(func() { _ = rc.Close() })
+	return rc, mr
+}
+
+func newCachedRepo(t *testing.T, users ...*entity.User) (*cached, *countingRepo, *countingRecorder, *miniredis.Miniredis) {
+	t.Helper()
+	rc, mr := newMiniredisClient(t)
+
+	inner := &countingRepo{users: map[string]*entity.User{}}
+	for _, u := range users {
+		inner.users[u.ID] = u
+	}
+	rec := &countingRecorder{}
+	c := NewCached(inner, rc, time.Minute).(*cached)
+	c.recorder = rec
+	return c, inner, rec, mr
+}
+
+func TestCached_FindByIDServesRepeatReadsFromRedis(t *testing.T) {
+	u := fixtures.User().WithName("Cached").Build()
+	c, inner, rec, mr := newCachedRepo(t, u)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		got, err := c.FindByID(ctx, u.ID)
+		if err != nil {
+			t.Fatalf("FindByID #%d: %v", i, err)
+		}
+		if got.Name != "Cached" || got.Email != u.Email {
+			t.Fatalf("FindByID #%d = %+v", i, got)
+		}
+	}
+	if inner.finds != 1 {
+		t.Errorf("repository reads = %d, want 1", inner.finds)
+	}
+	if rec.hits != 2 || rec.misses != 1 {
+		t.Errorf("hits/misses = %d/%d, want 2/1", rec.hits, rec.misses)
+	}
+	if !mr.Exists("user:" + u.ID) {
+		t.Fatalf("user:%s not cached", u.ID)
+	}
+	if ttl := mr.TTL("user:" + u.ID); ttl != time.Minute {
+		t.Errorf("ttl = %v, want 1m", ttl)
+	}
+	if raw, _ := mr.Get("user:" + u.ID); raw == "" || strings.Contains(raw, u.Password) {
+		t.Errorf("cached entry %q must hold the user without its password hash", raw)
+	}
+}
+
+func TestCached_NotFoundIsNotCached(t *testing.T) {
+	c, inner, _, _ := newCachedRepo(t)
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.FindByID(context.Background(), "missing"); !errors.Is(err, repoerr.ErrNotFound) {
+			t.Fatalf("FindByID #%d err = %v, want ErrRecordNotFound", i, err)
+		}
+	}
+	if inner.finds != 2 {
+		t.Errorf("repository reads = %d, want 2", inner.finds)
+	}
+}
+
+func TestCached_WritesInvalidate(t *testing.T) {
+	u := fixtures.User().WithName("Before").Build()
+	c, inner, _, mr := newCachedRepo(t, u)
+	ctx := context.Background()
+
+	if _, err := c.FindByID(ctx, u.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.UpdateFields(ctx, u.ID, map[string]interface{}{"name": "After"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if mr.Exists("user:" + u.ID) {
+		t.Fatal("UpdateFields left the entry cached")
+	}
+	got, err := c.FindByID(ctx, u.ID)
+	if err != nil || got.Name != "After" {
+		t.Fatalf("FindByID after update = %+v, %v; want name After", got, err)
+	}
+
+	if err := c.Delete(ctx, u.ID); err != nil {
+		t.Fatal(err)
+	}
+	if mr.Exists("user:" + u.ID) {
+		t.Fatal("Delete left the entry cached")
+	}
+	if _, err := c.FindByID(ctx, u.ID); !errors.Is(err, repoerr.ErrNotFound) {
+		t.Fatalf("FindByID after delete err = %v, want ErrRecordNotFound", err)
+	}
+	if inner.finds != 3 {
+		t.Errorf("repository reads = %d, want 3", inner.finds)
+	}
+}
+
+func TestCached_RedisErrorsFallThrough(t *testing.T) {
+	u := fixtures.User().Build()
+	c, inner, rec, mr := newCachedRepo(t, u)
+	ctx := context.Background()
+
+	mr.SetError("LOADING Redis is loading the dataset in memory")
+	for i := 0; i < 2; i++ {
+		got, err := c.FindByID(ctx, u.ID)
+		if err != nil || got.ID != u.ID {
+			t.Fatalf("FindByID #%d = %+v, %v", i, got, err)
+		}
+	}
+	if _, err := c.UpdateFields(ctx, u.ID, map[string]interface{}{"name": "x"}, nil); err != nil {
+		t.Fatalf("UpdateFields with Redis down: %v", err)
+	}
+	if inner.finds != 2 || rec.misses != 2 {
+		t.Errorf("repository reads/misses = %d/%d, want 2/2", inner.finds, rec.misses)
+	}
+}
+
+func TestCached_FindCredentialsByIDIsNeverCached(t *testing.T) {
+	u := fixtures.User().Build()
+	c, inner, rec, mr := newCachedRepo(t, u)
+	ctx := context.Background()
+	if _, err := c.FindByID(ctx, u.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.FindCredentialsByID(ctx, u.ID)
+
+	if err != nil || got.Password != u.Password {
+		t.Fatalf("FindCredentialsByID = %+v, %v, want the user with its password hash", got, err)
+	}
+	if inner.finds != 2 || rec.hits != 0 {
+		t.Errorf("repository reads/cache hits = %d/%d, want 2/0", inner.finds, rec.hits)
+	}
+	if raw, _ := mr.Get("user:" + u.ID); strings.Contains(raw, u.Password) {
+		t.Errorf("cached entry %q holds the password hash", raw)
+	}
+}
+
+func TestCached_CorruptEntryIsAMiss(t *testing.T) {
+	u := fixtures.User().Build()
+	c, inner, _, mr := newCachedRepo(t, u)
+	if err := mr.Set("user:"+u.ID, "{not json"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.FindByID(context.Background(), u.ID)
+	if err != nil || got.ID != u.ID {
+		t.Fatalf("FindByID = %+v, %v", got, err)
+	}
+	if inner.finds != 1 {
+		t.Errorf("repository reads = %d, want 1", inner.finds)
+	}
+}
+
+func TestCached_TransactionsBypassTheCache(t *testing.T) {
+	u := fixtures.User().Build()
+	c, inner, rec, mr := newCachedRepo(t, u)
+	txm, mock := mockTxManager(t)
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	err := txm.Do(context.Background(), func(txCtx context.Context) error {
+		for i := 0; i < 2; i++ {
+			if _, err := c.FindByID(txCtx, u.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inner.finds != 2 {
+		t.Errorf("repository reads = %d, want 2", inner.finds)
+	}
+	if mr.Exists("user:" + u.ID) {
+		t.Error("a read inside a transaction populated the cache")
+	}
+	if rec.hits+rec.misses != 0 {
+		t.Errorf("transaction reads were recorded as cache lookups: %+v", rec)
+	}
+}
+
+// A reader outside the transaction caches the row it still sees between the
+// write's invalidation and the commit; the commit drops that entry again.
+func TestCached_InvalidatesAgainAfterCommit(t *testing.T) {
+	u := fixtures.User().WithName("Before").Build()
+	c, _, _, mr := newCachedRepo(t, u)
+	txm, mock := mockTxManager(t)
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	err := txm.Do(context.Background(), func(txCtx context.Context) error {
+		if _, err := c.UpdateFields(txCtx, u.ID, map[string]interface{}{"name": "After"}, nil); err != nil {
+			return err
+		}
+		if _, err := c.FindByID(context.Background(), u.ID); err != nil {
+			return err
+		}
+		if !mr.Exists("user:" + u.ID) {
+			t.Error("the concurrent read was not cached")
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mr.Exists("user:" + u.ID) {
+		t.Error("the entry cached before the commit survived it")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func mockTxManager(t *testing.T) (*database.TxManager, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return database.NewTxManager(db), mock
+}
+
+func TestNewCached_DisabledReturnsRepo(t *testing.T) {
+	inner := &countingRepo{}
+	rc, _ := newMiniredisClient(t)
+
+	if got := NewCached(inner, nil, time.Minute); got != Repository(inner) {
+		t.Error("nil Redis should return the repository unchanged")
+	}
+	if got := NewCached(inner, rc, 0); got != Repository(inner) {
+		t.Error("ttl 0 should return the repository unchanged")
+	}
+}
@@ -9,16 +9,35 @@ package user_repository_test
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"veemon/app/usecase/outbox"
+	"veemon/app/usecase/user"
+	"veemon/database/seeds"
 	"veemon/entity"
+	"veemon/handler"
+	pb "veemon/handler/grpc/user"
+	"veemon/internal/fixtures"
+	"veemon/pkg/authguard"
 	"veemon/pkg/database"
+	"veemon/pkg/middleware"
+	"veemon/pkg/token"
+	"veemon/pkg/userevents"
+	repoerr "veemon/repository"
+	"veemon/repository/audit_repository"
+	"veemon/repository/outbox_repository"
 	"veemon/repository/user_repository"
 
+	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
-	"github.com/lib/pq"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -31,17 +50,24 @@ func envOr(key, def string) string {
 	return def
 }
 
+func ptr[T any](v T) *T { return &v }
+
 func testDB(t *testing.T) *gorm.DB {
+	return testDBWithPrefix(t, "")
+}
+
+func testDBWithPrefix(t *testing.T, prefix string) *gorm.DB {
 	t.Helper()
 	port, _ := strconv.Atoi(envOr("DB_PORT", "5432"))
 	db, err := database.New(database.Config{
-		Host:     envOr("DB_HOST", "localhost"),
-		Port:     port,
-		User:     envOr("DB_USER", "postgres"),
-		Password: envOr("DB_PASSWORD", "postgres"),
-		Name:     envOr("DB_NAME", "veemon_db"),
-		SSLMode:  envOr("DB_SSL_MODE", "disable"),
-		Timezone: envOr("DB_TIMEZONE", "UTC"),
+		Host:        envOr("DB_HOST", "localhost"),
+		Port:        port,
+		User:        envOr("DB_USER", "postgres"),
+		Password:    envOr("DB_PASSWORD", "postgres"),
+		Name:        envOr("DB_NAME", "veemon_db"),
+		SSLMode:     envOr("DB_SSL_MODE", "disable"),
+		Timezone:    envOr("DB_TIMEZONE", "UTC"),
+		TablePrefix: prefix,
 	}, zap.NewNop())
 	require.NoError(t, err)
 	return db
@@ -55,13 +81,7 @@ func TestIntegration_UserRolesRoundTrip(t *testing.T) {
 	ctx := context.Background()
 
 	email := "int-" + uuid.NewString() + "@example.com"
-	u := &entity.User{
-		Email:    email,
-		Password: "hash",
-		Name:     "Integration User",
-		Roles:    pq.StringArray{"admin", "user"},
-		Status:   entity.UserStatusActive,
-	}
+	u := fixtures.User().WithEmail(email).WithName("Integration User").WithRoles("admin", "user").Build()
 	require.NoError(t, repo.Create(ctx, u))
 	t.Cleanup(func() { _ = repo.Delete(ctx, u.ID) })
 
@@ -79,6 +99,12 @@ func TestIntegration_UserRolesRoundTrip(t *testing.T) {
 	require.NoError(t, err)
 	require.GreaterOrEqual(t, total, int64(1))
 	require.NotEmpty(t, list)
+	for _, s := range list {
+		if s.ID == u.ID {
+			require.Equal(t, u.Summary().Roles, s.Roles)
+			require.Equal(t, email, s.Email)
+		}
+	}
 }
 
 // Proves the partial unique index: a soft-deleted user's email can be reused.
@@ -87,11 +113,439 @@ func TestIntegration_SoftDeletedEmailReusable(t *testing.T) {
 	ctx := context.Background()
 
 	email := "reuse-" + uuid.NewString() + "@example.com"
-	first := &entity.User{Email: email, Password: "h", Name: "First", Status: entity.UserStatusActive}
+	first := fixtures.User().WithEmail(email).WithName("First").Build()
 	require.NoError(t, repo.Create(ctx, first))
 	require.NoError(t, repo.Delete(ctx, first.ID)) // soft delete
 
-	second := &entity.User{Email: email, Password: "h", Name: "Second", Status: entity.UserStatusActive}
+	second := fixtures.User().WithEmail(email).WithName("Second").Build()
 	require.NoError(t, repo.Create(ctx, second), "re-registering a soft-deleted email should succeed")
 	t.Cleanup(func() { _ = repo.Delete(ctx, second.ID) })
 }
+
+// Restore brings back the soft-deleted row itself, so its id (and anything
+// referencing it) survives.
+func TestIntegration_RestoreSoftDeletedUser(t *testing.T) {
+	repo := user_repository.New(testDB(t))
+	ctx := context.Background()
+
+	email := "restore-" + uuid.NewString() + "@example.com"
+	u := fixtures.User().WithEmail(email).WithName("Restored").Build()
+	require.NoError(t, repo.Create(ctx, u))
+	require.NoError(t, repo.Delete(ctx, u.ID))
+	t.Cleanup(func() { _ = repo.Delete(ctx, u.ID) })
+
+	deleted, err := repo.FindByEmailIncludingDeleted(ctx, email)
+	require.NoError(t, err)
+	require.Equal(t, u.ID, deleted.ID)
+	require.True(t, deleted.DeletedAt.Valid)
+
+	restored, err := repo.Restore(ctx, u.ID, map[string]interface{}{
+		"password": "new",
+		"status":   entity.UserStatusPending,
+	})
+	require.NoError(t, err)
+	require.Equal(t, u.ID, restored.ID)
+	require.Equal(t, "new", restored.Password)
+	require.Equal(t, entity.UserStatusPending, restored.Status)
+
+	_, err = repo.Restore(ctx, u.ID, nil)
+	require.ErrorIs(t, err, repoerr.ErrNotFound, "a live row cannot be restored again")
+}
+
+// HardDelete removes soft-deleted rows too, and listing with
+// IncludeDeleted shows a soft-deleted row until then.
+func TestIntegration_HardDeleteAndIncludeDeleted(t *testing.T) {
+	repo := user_repository.New(testDB(t))
+	ctx := context.Background()
+
+	marker := "harddelete-" + uuid.NewString()
+	u := fixtures.User().WithEmail(marker + "@example.com").Build()
+	require.NoError(t, repo.Create(ctx, u))
+	require.NoError(t, repo.Delete(ctx, u.ID))
+
+	live, _, err := repo.FindAll(ctx, user_repository.ListParams{Page: 1, Size: 10, Search: marker})
+	require.NoError(t, err)
+	require.Empty(t, live)
+	all, total, err := repo.FindAll(ctx, user_repository.ListParams{Page: 1, Size: 10, Search: marker, IncludeDeleted: true})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), total)
+	require.True(t, all[0].DeletedAt.Valid)
+
+	found, err := repo.FindByIDIncludingDeleted(ctx, u.ID)
+	require.NoError(t, err)
+	require.Equal(t, u.ID, found.ID)
+
+	require.NoError(t, repo.HardDelete(ctx, u.ID))
+	_, err = repo.FindByIDIncludingDeleted(ctx, u.ID)
+	require.ErrorIs(t, err, repoerr.ErrNotFound)
+	require.ErrorIs(t, repo.HardDelete(ctx, u.ID), repoerr.ErrNotFound)
+}
+
+// CreateBatch assigns every row its ID; a soft-deleted email is not found as
+// existing, and a live one fails the whole batch.
+func TestIntegration_CreateBatchAndFindExistingEmails(t *testing.T) {
+	repo := user_repository.New(testDB(t))
+	ctx := context.Background()
+
+	marker := "batch-" + uuid.NewString()
+	deleted := fixtures.User().WithEmail(marker + "-deleted@example.com").Build()
+	require.NoError(t, repo.Create(ctx, deleted))
+	require.NoError(t, repo.Delete(ctx, deleted.ID))
+	t.Cleanup(func() { _ = repo.HardDelete(ctx, deleted.ID) })
+
+	users := []*entity.User{
+		fixtures.User().WithEmail(marker + "-1@example.com").Build(),
+		fixtures.User().WithEmail(marker + "-2@example.com").Build(),
+	}
+	require.NoError(t, repo.CreateBatch(ctx, users))
+	for _, u := range users {
+		require.NotEmpty(t, u.ID)
+		t.Cleanup(func() { _ = repo.HardDelete(ctx, u.ID) })
+	}
+
+	found, err := repo.FindExistingEmails(ctx, []string{users[0].Email, deleted.Email, marker + "-3@example.com"})
+	require.NoError(t, err)
+	require.Equal(t, []string{users[0].Email}, found)
+
+	again := []*entity.User{
+		fixtures.User().WithEmail(marker + "-4@example.com").Build(),
+		fixtures.User().WithEmail(users[1].Email).Build(),
+	}
+	require.ErrorIs(t, repo.CreateBatch(ctx, again), user_repository.ErrDuplicateEmail)
+	found, err = repo.FindExistingEmails(ctx, []string{again[0].Email})
+	require.NoError(t, err)
+	require.Empty(t, found, "a failed batch creates none of its rows")
+}
+
+// Batches seek past ties in the sort column by id, so every matching row is
+// read once, in order.
+func TestIntegration_FindAllInBatchesReadsEveryRowOnce(t *testing.T) {
+	repo := user_repository.New(testDB(t))
+	ctx := context.Background()
+
+	marker := "export-" + uuid.NewString()
+	for _, name := range []string{"Bea", "Al", "Bea", "Cy", "Al"} {
+		u := fixtures.User().WithEmail(uuid.NewString() + "-" + marker + "@example.com").WithName(name).Build()
+		require.NoError(t, repo.Create(ctx, u))
+		t.Cleanup(func() { _ = repo.HardDelete(ctx, u.ID) })
+	}
+
+	var names []string
+	seen := map[string]bool{}
+	err := repo.FindAllInBatches(ctx, user_repository.ListParams{Search: marker, SortBy: "name", SortOrder: "asc", Columns: []string{"email"}}, 2,
+		func(batch []entity.UserSummary) error {
+			for _, u := range batch {
+				require.False(t, seen[u.ID], "row read twice")
+				seen[u.ID] = true
+				names = append(names, u.Name)
+			}
+			return nil
+		})
+	require.NoError(t, err)
+	require.Equal(t, []string{"Al", "Al", "Bea", "Bea", "Cy"}, names)
+}
+
+// An update's audit entry commits with it, its JSON snapshots round-tripping
+// through jsonb.
+func TestIntegration_AuditLogCommitsWithTheChange(t *testing.T) {
+	db := testDB(t)
+	repo := user_repository.New(db)
+	audits := audit_repository.New(db)
+	uc := user.NewUseCase(repo, user.WithTransactor(database.NewTxManager(db)), user.WithAuditLog(audits))
+	ctx := middleware.WithAuthContext(context.Background(), &middleware.AuthContext{UserID: "admin-" + uuid.NewString()})
+
+	u := fixtures.User().WithEmail("audit-" + uuid.NewString() + "@example.com").Build()
+	require.NoError(t, repo.Create(ctx, u))
+	t.Cleanup(func() {
+		_ = repo.HardDelete(ctx, u.ID)
+		db.Where("resource_id = ?", u.ID).Delete(&entity.AuditLog{})
+	})
+	_, err := uc.UpdateUser(ctx, u.ID, user.UpdateInput{Name: ptr("Audited Name")})
+	require.NoError(t, err)
+
+	entries, total, err := audits.FindAll(ctx, audit_repository.ListParams{Page: 1, Size: 10, ResourceType: entity.AuditResourceUser, ResourceID: u.ID})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), total)
+	require.Equal(t, entity.AuditActionUpdate, entries[0].Action)
+	require.Contains(t, string(entries[0].After), `"Audited Name"`)
+	require.NotContains(t, string(entries[0].Before), `"Audited Name"`)
+}
+
+// A user event is staged only if its change commits.
+func TestIntegration_OutboxMessageCommitsWithTheChange(t *testing.T) {
+	db := testDB(t)
+	repo := user_repository.New(db)
+	uc := user.NewUseCase(repo, user.WithTransactor(database.NewTxManager(db)),
+		user.WithEventOutbox(userevents.NewOutbox(outbox.NewUseCase(outbox_repository.New(db)), "test")))
+	ctx := context.Background()
+
+	u := fixtures.User().WithEmail("outbox-" + uuid.NewString() + "@example.com").Build()
+	require.NoError(t, repo.Create(ctx, u))
+	staged := db.Model(&entity.OutboxMessage{}).Where("payload->'data'->>'id' = ?", u.ID)
+	t.Cleanup(func() {
+		_ = repo.HardDelete(ctx, u.ID)
+		db.Where("payload->'data'->>'id' = ?", u.ID).Delete(&entity.OutboxMessage{})
+	})
+
+	stale := u.UpdatedAt.Add(-time.Hour)
+	_, err := uc.UpdateUser(ctx, u.ID, user.UpdateInput{Name: ptr("Lost"), ExpectedUpdatedAt: &stale})
+	require.ErrorIs(t, err, user.ErrVersionMismatch)
+	var n int64
+	require.NoError(t, staged.Session(&gorm.Session{}).Count(&n).Error)
+	require.Zero(t, n, "a rolled-back update staged an event")
+
+	_, err = uc.UpdateUser(ctx, u.ID, user.UpdateInput{Name: ptr("Kept")})
+	require.NoError(t, err)
+	var msg entity.OutboxMessage
+	require.NoError(t, staged.Session(&gorm.Session{}).First(&msg).Error)
+	require.Equal(t, userevents.Exchange, msg.Exchange)
+	require.Equal(t, userevents.Updated, msg.RoutingKey)
+	require.Nil(t, msg.SentAt)
+}
+
+// Purging anonymizes and soft-deletes only accounts whose deletion was
+// requested by the cutoff; a more recent request is left alone.
+func TestIntegration_PurgeDeletionsRequestedBefore(t *testing.T) {
+	repo := user_repository.New(testDB(t))
+	ctx := context.Background()
+	cutoff := time.Now().Add(-14 * 24 * time.Hour)
+
+	expired := fixtures.User().WithName("Expired").WithStatus(entity.UserStatusInactive).Build()
+	recent := fixtures.User().WithName("Recent").WithStatus(entity.UserStatusInactive).Build()
+	for u, requestedAt := range map[*entity.User]time.Time{expired: cutoff.Add(-time.Hour), recent: cutoff.Add(time.Hour)} {
+		require.NoError(t, repo.Create(ctx, u))
+		_, err := repo.UpdateFields(ctx, u.ID, map[string]interface{}{"deletion_requested_at": requestedAt}, nil)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = repo.Delete(ctx, u.ID) })
+	}
+
+	n, err := repo.PurgeDeletionsRequestedBefore(ctx, cutoff)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, n, int64(1))
+
+	_, err = repo.FindByID(ctx, expired.ID)
+	require.ErrorIs(t, err, repoerr.ErrNotFound, "a purged account is soft-deleted")
+	_, err = repo.FindByEmailIncludingDeleted(ctx, expired.Email)
+	require.ErrorIs(t, err, repoerr.ErrNotFound, "a purged account keeps no trace of its email")
+
+	kept, err := repo.FindByID(ctx, recent.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Recent", kept.Name)
+	require.NotNil(t, kept.DeletionRequestedAt)
+}
+
+// With DB_TABLE_PREFIX the whole user lifecycle, AutoMigrate and the seeder
+// must hit the prefixed table only. The table is created per run and dropped
+// afterwards, so the shared migrated schema is untouched.
+func TestIntegration_UserCRUDWithTablePrefix(t *testing.T) {
+	prefix := "it_" + uuid.NewString()[:8] + "_"
+	db := testDBWithPrefix(t, prefix)
+	ctx := context.Background()
+
+	require.NoError(t, database.AutoMigrate(db))
+	t.Cleanup(func() { _ = db.Migrator().DropTable(&entity.User{}) })
+	require.True(t, db.Migrator().HasTable(prefix+"users"))
+
+	repo := user_repository.New(db)
+	email := "prefix-" + uuid.NewString() + "@example.com"
+	u := fixtures.User().WithEmail(email).WithName("Prefixed").WithRoles("admin").Build()
+	require.NoError(t, repo.Create(ctx, u))
+
+	got, err := repo.FindByID(ctx, u.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{"admin"}, []string(got.Roles))
+
+	_, err = repo.FindByEmail(ctx, email)
+	require.NoError(t, err)
+
+	list, total, err := repo.FindAll(ctx, user_repository.ListParams{Page: 1, Size: 10, Search: "Prefixed", Columns: []string{"id", "name"}})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), total)
+	require.Len(t, list, 1)
+
+	updated, err := repo.UpdateFields(ctx, u.ID, map[string]interface{}{"name": "Renamed"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, "Renamed", updated.Name)
+
+	require.NoError(t, repo.Delete(ctx, u.ID))
+	_, err = repo.FindByID(ctx, u.ID)
+	require.ErrorIs(t, err, repoerr.ErrNotFound)
+
+	restored, err := repo.Restore(ctx, u.ID, nil)
+	require.NoError(t, err)
+	require.Equal(t, u.ID, restored.ID)
+
+	_, err = seeds.New(db).SeedUsers(ctx)
+	require.NoError(t, err)
+	var seeded int64
+	require.NoError(t, db.Table(prefix+"users").Where("? = ANY(roles)", "superadmin").Count(&seeded).Error)
+	require.Equal(t, int64(1), seeded)
+}
+
+// Two repository writes inside TxManager.Do are undone together when the
+// callback fails, and committed together when it succeeds.
+func TestIntegration_TxManagerRollbackUndoesBothWrites(t *testing.T) {
+	db := testDB(t)
+	repo := user_repository.New(db)
+	txm := database.NewTxManager(db)
+	ctx := context.Background()
+
+	a, b := fixtures.User().Build(), fixtures.User().Build()
+	boom := errors.New("abort")
+
+	err := txm.Do(ctx, func(txCtx context.Context) error {
+		require.NoError(t, repo.Create(txCtx, a))
+		require.NoError(t, repo.Create(txCtx, b))
+		// Reads inside the transaction see its own writes.
+		_, err := repo.FindByEmail(txCtx, b.Email)
+		require.NoError(t, err)
+		return boom
+	})
+	require.ErrorIs(t, err, boom)
+
+	_, err = repo.FindByEmail(ctx, a.Email)
+	require.ErrorIs(t, err, repoerr.ErrNotFound)
+	_, err = repo.FindByEmail(ctx, b.Email)
+	require.ErrorIs(t, err, repoerr.ErrNotFound)
+
+	a.ID, b.ID = "", ""
+	require.NoError(t, txm.Do(ctx, func(txCtx context.Context) error {
+		if err := repo.Create(txCtx, a); err != nil {
+			return err
+		}
+		return repo.Create(txCtx, b)
+	}))
+	t.Cleanup(func() { _ = repo.Delete(ctx, a.ID); _ = repo.Delete(ctx, b.ID) })
+
+	_, err = repo.FindByEmail(ctx, a.Email)
+	require.NoError(t, err)
+	_, err = repo.FindByEmail(ctx, b.Email)
+	require.NoError(t, err)
+}
+
+// Concurrent registrations into a company one short of its limit: the
+// advisory lock serializes them, so exactly one gets the last seat.
+func TestIntegration_CompanyUserLimitUnderConcurrency(t *testing.T) {
+	db := testDB(t)
+	repo := user_repository.New(db)
+	ctx := context.Background()
+	company := "LIMIT-" + uuid.NewString()[:8]
+
+	const limit = 3
+	for i := 0; i < limit-1; i++ {
+		fixtures.User().WithCompany(company).Persist(t, db)
+	}
+	// The users registered below.
+	t.Cleanup(func() { db.Unscoped().Where("company_code = ?", company).Delete(&entity.User{}) })
+
+	uc := user.NewUseCase(repo, user.WithTransactor(database.NewTxManager(db)), user.WithCompanyUserLimit(limit))
+	const n = 6
+	errs := make(chan error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := uc.Register(ctx, user.RegisterInput{
+				Email: "limit-" + uuid.NewString() + "@example.com", Password: "password123", Name: "Racer", CompanyCode: company,
+			})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		var limitErr *entity.CompanyUserLimitError
+		if err == nil {
+			succeeded++
+		} else {
+			require.ErrorAs(t, err, &limitErr)
+		}
+	}
+	require.Equal(t, 1, succeeded)
+
+	var count int64
+	require.NoError(t, db.Model(&entity.User{}).Where("company_code = ?", company).Count(&count).Error)
+	require.Equal(t, int64(limit), count)
+}
+
+// Of two writers holding the same version, only the first applies; the
+// second sees ErrModified, and a missing user stays ErrRecordNotFound.
+func TestIntegration_UpdateFieldsExpectedVersion(t *testing.T) {
+	db := testDB(t)
+	repo := user_repository.New(db)
+	ctx := context.Background()
+
+	u := fixtures.User().Persist(t, db)
+	read, err := repo.FindByID(ctx, u.ID)
+	require.NoError(t, err)
+	version := read.UpdatedAt
+
+	first, err := repo.UpdateFields(ctx, u.ID, map[string]interface{}{"name": "First"}, &version)
+	require.NoError(t, err)
+	require.Equal(t, "First", first.Name)
+	require.True(t, first.UpdatedAt.After(version))
+
+	_, err = repo.UpdateFields(ctx, u.ID, map[string]interface{}{"name": "Second"}, &version)
+	require.ErrorIs(t, err, user_repository.ErrModified)
+
+	// The version read back round-trips: it matches what is stored.
+	next := first.UpdatedAt
+	_, err = repo.UpdateFields(ctx, u.ID, map[string]interface{}{"name": "Third"}, &next)
+	require.NoError(t, err)
+
+	_, err = repo.UpdateFields(ctx, uuid.NewString(), map[string]interface{}{"name": "Nobody"}, &version)
+	require.ErrorIs(t, err, repoerr.ErrNotFound)
+}
+
+// Concurrent registrations of one email over REST: the unique index, not the
+// FindByEmail fast path, decides, so exactly one succeeds and every other
+// request gets 409 rather than a 500 from the raw constraint violation.
+func TestIntegration_ConcurrentRegisterSameEmailConflicts(t *testing.T) {
+	db := testDB(t)
+	repo := user_repository.New(db)
+	email := "dup-" + uuid.NewString() + "@example.com"
+	t.Cleanup(func() { db.Unscoped().Where("email = ?", email).Delete(&entity.User{}) })
+
+	// Create maps the violation itself.
+	first := fixtures.User().WithEmail(email).Build()
+	require.NoError(t, repo.Create(context.Background(), first))
+	err := repo.Create(context.Background(), fixtures.User().WithEmail(email).Build())
+	require.ErrorIs(t, err, user_repository.ErrDuplicateEmail)
+	require.NoError(t, db.Unscoped().Delete(first).Error)
+
+	ts, err := token.NewTokenService(strings.Repeat("k", 32), 1)
+	require.NoError(t, err)
+	uc := user.NewUseCase(repo, user.WithTransactor(database.NewTxManager(db)))
+	app := fiber.New()
+	pb.RegisterUserApiRoutes(app, handler.NewUserHandler(uc, ts, authguard.New(nil, 5, 15), nil), nil)
+
+	const n = 8
+	statuses := make(chan int, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			body := `{"email":"` + email + `","password":"Password123","name":"Racer"}`
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/register", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				statuses <- 0
+				return
+			}
+			statuses <- resp.StatusCode
+		}()
+	}
+	wg.Wait()
+	close(statuses)
+
+	counts := map[int]int{}
+	for s := range statuses {
+		counts[s]++
+	}
+	require.Equal(t, map[int]int{http.StatusCreated: 1, http.StatusConflict: n - 1}, counts)
+}
@@ -3,23 +3,101 @@ package user_repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
 
 	"veemon/entity"
+	"veemon/pkg/database"
+	repoerr "veemon/repository"
 
 	"gorm.io/gorm"
 )
 
+// ErrModified is returned by UpdateFields when the row exists but its
+// updated_at no longer matches the expected version.
+var ErrModified = errors.New("user was modified since the expected version")
+
+// ErrInvalidSortField is returned by FindAll for a SortBy or SortOrder
+// outside the whitelists. Such values are never concatenated into SQL.
+var ErrInvalidSortField = errors.New("invalid sort field")
+
+// ErrDuplicateEmail is returned by Create, CreateBatch and Restore when the
+// unique index on live users' emails rejects the row: another live user has
+// the email. It is a repoerr.ErrDuplicate; the email index is the only
+// unique constraint a users write can realistically hit, since ids are
+// generated UUIDs.
+var ErrDuplicateEmail = fmt.Errorf("%w: a live user already has this email", repoerr.ErrDuplicate)
+
+// translate is repoerr.Translate, reporting any duplicate as
+// ErrDuplicateEmail.
+func translate(err error) error {
+	err = repoerr.Translate(err)
+	if errors.Is(err, repoerr.ErrDuplicate) {
+		return ErrDuplicateEmail
+	}
+	return err
+}
+
+// Repository methods return the errors of package repository (imported as
+// repoerr) in place of GORM and driver errors.
 type Repository interface {
+	// Create inserts user, failing with ErrDuplicateEmail if a live user
+	// already has its email.
 	Create(ctx context.Context, user *entity.User) error
+	// FindByID returns repoerr.ErrNotFound if no live row matches.
 	FindByID(ctx context.Context, id string) (*entity.User, error)
+	// FindByIDIncludingDeleted is FindByID also matching a soft-deleted row.
+	FindByIDIncludingDeleted(ctx context.Context, id string) (*entity.User, error)
+	// FindCredentialsByID is FindByID for callers that check the password:
+	// it reads the live row, hash included, from the primary and is never
+	// served from a cache.
+	FindCredentialsByID(ctx context.Context, id string) (*entity.User, error)
+	// FindByIDWithColumns is FindByID reading only the given columns.
+	FindByIDWithColumns(ctx context.Context, id string, columns []string) (*entity.User, error)
 	FindByEmail(ctx context.Context, email string) (*entity.User, error)
-	FindAll(ctx context.Context, params ListParams) ([]entity.User, int64, error)
+	// FindByEmailIncludingDeleted also matches soft-deleted rows, returning the
+	// most recently deleted one when several share the email.
+	FindByEmailIncludingDeleted(ctx context.Context, email string) (*entity.User, error)
+	FindAll(ctx context.Context, params ListParams) ([]entity.UserSummary, int64, error)
+	// FindAllInBatches calls fn with every row FindAll would list for params,
+	// in the same order, batchSize rows per query; Page, Size, After and
+	// Probe are ignored. It stops at fn's first error and returns it.
+	FindAllInBatches(ctx context.Context, params ListParams, batchSize int, fn func([]entity.UserSummary) error) error
+	// FindExistingEmails returns those of emails that belong to live users.
+	FindExistingEmails(ctx context.Context, emails []string) ([]string, error)
+	// CreateBatch inserts users in multi-row INSERTs, returning
+	// ErrDuplicateEmail if any email is taken. Run it in a transaction so a
+	// failure leaves none of them behind.
+	CreateBatch(ctx context.Context, users []*entity.User) error
 	// UpdateFields applies a partial update to only the given columns and
-	// returns the refreshed row. It returns gorm.ErrRecordNotFound if no live
+	// returns the refreshed row. It returns repoerr.ErrNotFound if no live
 	// row matches. Using column-scoped updates (instead of Save on a
 	// previously-read struct) avoids clobbering columns changed concurrently.
-	UpdateFields(ctx context.Context, id string, fields map[string]interface{}) (*entity.User, error)
+	// With expectedUpdatedAt set the update applies only while the row's
+	// updated_at still equals it, and fails with ErrModified otherwise.
+	UpdateFields(ctx context.Context, id string, fields map[string]interface{}, expectedUpdatedAt *time.Time) (*entity.User, error)
+	// Restore clears deleted_at on a soft-deleted row, applies fields in the
+	// same statement and returns the refreshed row. It returns
+	// repoerr.ErrNotFound if no soft-deleted row matches, and
+	// ErrDuplicateEmail if a live user has taken the email meanwhile.
+	Restore(ctx context.Context, id string, fields map[string]interface{}) (*entity.User, error)
 	Delete(ctx context.Context, id string) error
+	// HardDelete removes the row, live or soft-deleted, for good. It returns
+	// repoerr.ErrNotFound if no row matches.
+	HardDelete(ctx context.Context, id string) error
+	// PurgeDeletionsRequestedBefore anonymizes and soft-deletes every live
+	// account whose deletion was requested at or before cutoff, returning how
+	// many were purged.
+	PurgeDeletionsRequestedBefore(ctx context.Context, cutoff time.Time) (int64, error)
+	// LockCompanyUsers takes a transaction-scoped lock on companyCode's user
+	// count and returns the number of its live (not soft-deleted) users.
+	// Concurrent callers for the same company queue until the holder's
+	// transaction ends, so check-then-insert cannot exceed a limit. Outside a
+	// transaction the lock is released as soon as it is taken.
+	LockCompanyUsers(ctx context.Context, companyCode string) (int64, error)
 }
 
 type ListParams struct {
@@ -28,6 +106,24 @@ type ListParams struct {
 	Search    string
 	SortBy    string
 	SortOrder string
+	// Columns limits the SELECT list; empty selects the UserSummary columns.
+	// Names not in selectableColumns are dropped.
+	Columns []string
+	// After switches to keyset pagination: rows strictly after this position
+	// in (created_at, id) order, in SortOrder direction. Page and SortBy are
+	// ignored when it is set.
+	After *Cursor
+	// Probe reads one row beyond Size, so the caller can tell whether
+	// another page follows. Offsets still step by Size.
+	Probe bool
+	// IncludeDeleted also lists soft-deleted rows, in the same order.
+	IncludeDeleted bool
+}
+
+// Cursor is a keyset position: the created_at and id of the last row seen.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        string
 }
 
 // allowedSortColumns whitelists the columns that may appear in ORDER BY, since
@@ -40,6 +136,84 @@ var allowedSortColumns = map[string]bool{
 	"status":     true,
 }
 
+// SortColumns lists the columns FindAll sorts by, in alphabetical order.
+func SortColumns() []string {
+	return slices.Sorted(maps.Keys(allowedSortColumns))
+}
+
+// ValidateSort returns ErrInvalidSortField unless sortBy is empty or one of
+// SortColumns and sortOrder is empty, "asc" or "desc".
+func ValidateSort(sortBy, sortOrder string) error {
+	_, _, err := orderBy(sortBy, sortOrder)
+	return err
+}
+
+// orderBy resolves the ORDER BY column and direction. Both are concatenated
+// into the SQL (GORM cannot parameterize identifiers), so only whitelisted
+// values get through: empty ones default to created_at desc, and anything
+// else is rejected with ErrInvalidSortField rather than silently replaced.
+func orderBy(sortBy, sortOrder string) (string, string, error) {
+	column := "created_at"
+	if sortBy != "" {
+		if !allowedSortColumns[sortBy] {
+			return "", "", ErrInvalidSortField
+		}
+		column = sortBy
+	}
+	switch sortOrder {
+	case "":
+		return column, "desc", nil
+	case "asc", "desc":
+		return column, sortOrder, nil
+	default:
+		return "", "", ErrInvalidSortField
+	}
+}
+
+// selectableColumns whitelists the columns a caller may project. Like the sort
+// whitelist it keeps identifiers out of raw SQL, and it never exposes the
+// password hash.
+var selectableColumns = map[string]bool{
+	"id":           true,
+	"email":        true,
+	"name":         true,
+	"phone":        true,
+	"status":       true,
+	"roles":        true,
+	"company_code": true,
+	"created_at":   true,
+	"updated_at":   true,
+	"deleted_at":   true,
+}
+
+// summaryColumns are the columns of entity.UserSummary, which FindAll reads
+// unless the caller projects fewer.
+var summaryColumns = []string{
+	"id", "email", "name", "phone", "status", "roles", "company_code",
+	"created_at", "updated_at", "deleted_at",
+}
+
+// allowedColumns returns the whitelisted subset of columns.
+func allowedColumns(columns []string) []string {
+	allowed := make([]string, 0, len(columns))
+	for _, c := range columns {
+		if selectableColumns[c] {
+			allowed = append(allowed, c)
+		}
+	}
+	return allowed
+}
+
+// selectColumns applies the whitelisted subset of columns to query, leaving it
+// untouched (SELECT *) when none remain.
+func selectColumns(query *gorm.DB, columns []string) *gorm.DB {
+	allowed := allowedColumns(columns)
+	if len(allowed) == 0 {
+		return query
+	}
+	return database.SelectFields(query, allowed...)
+}
+
 type repository struct {
 	db *gorm.DB
 }
@@ -48,88 +222,328 @@ func New(db *gorm.DB) Repository {
 	return &repository{db: db}
 }
 
+// conn returns the handle for ctx, joining the caller's transaction when
+// ctx carries one (see database.TxManager).
+func (r *repository) conn(ctx context.Context) *gorm.DB {
+	return database.FromContext(ctx, r.db)
+}
+
 func (r *repository) Create(ctx context.Context, user *entity.User) error {
-	return r.db.WithContext(ctx).Create(user).Error
+	return translate(r.conn(ctx).Create(user).Error)
+}
+
+// createBatchSize bounds the rows of one INSERT, well under PostgreSQL's
+// limit of 65535 bind parameters.
+const createBatchSize = 500
+
+func (r *repository) CreateBatch(ctx context.Context, users []*entity.User) error {
+	return translate(r.conn(ctx).CreateInBatches(users, createBatchSize).Error)
 }
 
 func (r *repository) FindByID(ctx context.Context, id string) (*entity.User, error) {
 	var user entity.User
-	err := r.db.WithContext(ctx).Where("id = ?", id).First(&user).Error
+	err := r.conn(ctx).Where("id = ?", id).First(&user).Error
+	if err != nil {
+		return nil, repoerr.Translate(err)
+	}
+	return &user, nil
+}
+
+func (r *repository) FindByIDIncludingDeleted(ctx context.Context, id string) (*entity.User, error) {
+	var user entity.User
+	err := r.conn(ctx).Unscoped().Where("id = ?", id).First(&user).Error
 	if err != nil {
-		return nil, err
+		return nil, repoerr.Translate(err)
 	}
 	return &user, nil
 }
 
+func (r *repository) FindByIDWithColumns(ctx context.Context, id string, columns []string) (*entity.User, error) {
+	var user entity.User
+	err := selectColumns(r.conn(ctx), columns).Where("id = ?", id).First(&user).Error
+	if err != nil {
+		return nil, repoerr.Translate(err)
+	}
+	return &user, nil
+}
+
+func (r *repository) FindCredentialsByID(ctx context.Context, id string) (*entity.User, error) {
+	var user entity.User
+	err := r.conn(database.WithPrimary(ctx)).Where("id = ?", id).First(&user).Error
+	if err != nil {
+		return nil, repoerr.Translate(err)
+	}
+	return &user, nil
+}
+
+// FindByEmail and FindByEmailIncludingDeleted read from the primary: they
+// back login and the registration checks, which must see a password change
+// or a registration the moment it commits.
 func (r *repository) FindByEmail(ctx context.Context, email string) (*entity.User, error) {
 	var user entity.User
-	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
+	err := r.conn(database.WithPrimary(ctx)).Where("email = ?", email).First(&user).Error
+	if err != nil {
+		return nil, repoerr.Translate(err)
+	}
+	return &user, nil
+}
+
+func (r *repository) FindByEmailIncludingDeleted(ctx context.Context, email string) (*entity.User, error) {
+	var user entity.User
+	err := r.conn(database.WithPrimary(ctx)).Unscoped().
+		Where("email = ?", email).
+		Order("deleted_at IS NULL DESC, deleted_at DESC").
+		First(&user).Error
 	if err != nil {
-		return nil, err
+		return nil, repoerr.Translate(err)
 	}
 	return &user, nil
 }
 
-func (r *repository) FindAll(ctx context.Context, params ListParams) ([]entity.User, int64, error) {
-	var users []entity.User
+// FindAll never reads the password column: it selects the UserSummary
+// columns, or the whitelisted subset of params.Columns.
+func (r *repository) FindAll(ctx context.Context, params ListParams) ([]entity.UserSummary, int64, error) {
+	var users []entity.UserSummary
 	var total int64
 
-	query := r.db.WithContext(ctx).Model(&entity.User{})
+	// Checked before any query runs.
+	sortColumn, sortOrder, err := orderBy(params.SortBy, params.SortOrder)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := r.listQuery(ctx, params)
+
+	// Count on its own session so its statement is not reused by the Find.
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, repoerr.Translate(err)
+	}
+
+	columns := allowedColumns(params.Columns)
+	if len(columns) == 0 {
+		columns = summaryColumns
+	}
+	query = database.SelectFields(query, columns...)
+	if c := params.After; c != nil {
+		// Keyset: seek past the cursor row instead of scanning and discarding
+		// an offset.
+		sortColumn = "created_at"
+		op := "<"
+		if sortOrder == "asc" {
+			op = ">"
+		}
+		query = query.Where("(created_at, id) "+op+" (?, ?)", c.CreatedAt, c.ID)
+	} else {
+		query = query.Offset((params.Page - 1) * params.Size)
+	}
+	// id breaks ties so pages are stable and a cursor taken from the last row
+	// neither skips nor repeats rows.
+	limit := params.Size
+	if params.Probe {
+		limit++
+	}
+	err = query.
+		Order(sortColumn + " " + sortOrder + ", id " + sortOrder).
+		Limit(limit).
+		Find(&users).Error
 
+	if err != nil {
+		return nil, 0, repoerr.Translate(err)
+	}
+
+	return users, total, nil
+}
+
+// listQuery filters the users table as params ask, before any projection,
+// order or paging.
+func (r *repository) listQuery(ctx context.Context, params ListParams) *gorm.DB {
+	query := r.conn(ctx).Model(&entity.User{})
+	if params.IncludeDeleted {
+		query = query.Unscoped()
+	}
 	if params.Search != "" {
 		searchPattern := "%" + params.Search + "%"
 		query = query.Where("name ILIKE ? OR email ILIKE ?", searchPattern, searchPattern)
 	}
+	return query
+}
 
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
+// FindAllInBatches seeks past the last row's (sort column, id) for each
+// batch, as FindAll does with a cursor. database.FindInBatches is no use
+// here: it pages by id alone, which skips rows under any other order.
+func (r *repository) FindAllInBatches(ctx context.Context, params ListParams, batchSize int, fn func([]entity.UserSummary) error) error {
+	sortColumn, sortOrder, err := orderBy(params.SortBy, params.SortOrder)
+	if err != nil {
+		return err
 	}
-
-	// Whitelist sort column and direction. These are concatenated into the SQL
-	// ORDER BY clause (GORM cannot parameterize identifiers), so they must never
-	// come straight from user input.
-	sortColumn := "created_at"
-	if allowedSortColumns[params.SortBy] {
-		sortColumn = params.SortBy
+	columns := allowedColumns(params.Columns)
+	if len(columns) == 0 {
+		columns = summaryColumns
+	}
+	// The seek needs the last row's keys whatever the caller projected.
+	for _, key := range []string{"id", sortColumn} {
+		if !slices.Contains(columns, key) {
+			columns = append(columns, key)
+		}
 	}
-	sortOrder := "desc"
-	if params.SortOrder == "asc" {
-		sortOrder = "asc"
+	op := "<"
+	if sortOrder == "asc" {
+		op = ">"
 	}
 
-	offset := (params.Page - 1) * params.Size
-	err := query.
-		Order(sortColumn + " " + sortOrder).
-		Offset(offset).
-		Limit(params.Size).
-		Find(&users).Error
+	var last *entity.UserSummary
+	for {
+		query := database.SelectFields(r.listQuery(ctx, params), columns...)
+		if last != nil {
+			query = query.Where("("+sortColumn+", id) "+op+" (?, ?)", sortKey(last, sortColumn), last.ID)
+		}
+		var batch []entity.UserSummary
+		err := query.
+			Order(sortColumn + " " + sortOrder + ", id " + sortOrder).
+			Limit(batchSize).
+			Find(&batch).Error
+		if err != nil {
+			return repoerr.Translate(err)
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := fn(batch); err != nil {
+			return err
+		}
+		if len(batch) < batchSize {
+			return nil
+		}
+		last = &batch[len(batch)-1]
+	}
+}
 
-	if err != nil {
-		return nil, 0, err
+// sortKey is u's value in column, one of allowedSortColumns.
+func sortKey(u *entity.UserSummary, column string) any {
+	switch column {
+	case "updated_at":
+		return u.UpdatedAt
+	case "name":
+		return u.Name
+	case "email":
+		return u.Email
+	case "status":
+		return u.Status
 	}
+	return u.CreatedAt
+}
 
-	return users, total, nil
+// FindExistingEmails reads from the primary, like FindByEmail, so an email
+// registered a moment ago is seen.
+func (r *repository) FindExistingEmails(ctx context.Context, emails []string) ([]string, error) {
+	if len(emails) == 0 {
+		return nil, nil
+	}
+	var found []string
+	err := r.conn(database.WithPrimary(ctx)).Model(&entity.User{}).
+		Where("email IN ?", emails).
+		Pluck("email", &found).Error
+	return found, repoerr.Translate(err)
 }
 
-func (r *repository) UpdateFields(ctx context.Context, id string, fields map[string]interface{}) (*entity.User, error) {
-	result := r.db.WithContext(ctx).
+func (r *repository) UpdateFields(ctx context.Context, id string, fields map[string]interface{}, expectedUpdatedAt *time.Time) (*entity.User, error) {
+	query := r.conn(ctx).
 		Model(&entity.User{}).
-		Where("id = ?", id).
-		Updates(fields)
+		Where("id = ?", id)
+	if expectedUpdatedAt != nil {
+		// The version check rides in the UPDATE itself, so no write can land
+		// between comparing and writing.
+		query = query.Where("updated_at = ?", *expectedUpdatedAt)
+	}
+	result := query.Updates(fields)
 	if result.Error != nil {
-		return nil, result.Error
+		return nil, translate(result.Error)
 	}
 	if result.RowsAffected == 0 {
-		return nil, gorm.ErrRecordNotFound
+		if expectedUpdatedAt == nil {
+			return nil, repoerr.ErrNotFound
+		}
+		// Tell a stale version apart from a missing row.
+		var n int64
+		if err := r.conn(ctx).Model(&entity.User{}).Where("id = ?", id).Count(&n).Error; err != nil {
+			return nil, repoerr.Translate(err)
+		}
+		if n == 0 {
+			return nil, repoerr.ErrNotFound
+		}
+		return nil, ErrModified
 	}
 
 	var user entity.User
-	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&user).Error; err != nil {
-		return nil, err
+	if err := r.conn(ctx).Where("id = ?", id).First(&user).Error; err != nil {
+		return nil, repoerr.Translate(err)
 	}
 	return &user, nil
 }
 
+func (r *repository) Restore(ctx context.Context, id string, fields map[string]interface{}) (*entity.User, error) {
+	updates := make(map[string]interface{}, len(fields)+1)
+	for k, v := range fields {
+		updates[k] = v
+	}
+	updates["deleted_at"] = nil
+
+	result := r.conn(ctx).Unscoped().
+		Model(&entity.User{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Updates(updates)
+	if result.Error != nil {
+		return nil, translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, repoerr.ErrNotFound
+	}
+	return r.FindByID(ctx, id)
+}
+
 func (r *repository) Delete(ctx context.Context, id string) error {
-	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&entity.User{}).Error
+	return repoerr.Translate(r.conn(ctx).Where("id = ?", id).Delete(&entity.User{}).Error)
+}
+
+func (r *repository) HardDelete(ctx context.Context, id string) error {
+	result := r.conn(ctx).Unscoped().Where("id = ?", id).Delete(&entity.User{})
+	if result.Error != nil {
+		return repoerr.Translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return repoerr.ErrNotFound
+	}
+	return nil
+}
+
+func (r *repository) LockCompanyUsers(ctx context.Context, companyCode string) (int64, error) {
+	db := r.conn(ctx)
+	// An advisory lock rather than SELECT ... FOR UPDATE: row locks cannot
+	// stop a concurrent INSERT of a new row for the company.
+	if err := db.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "company_users:"+companyCode).Error; err != nil {
+		return 0, repoerr.Translate(err)
+	}
+	var n int64
+	err := db.Model(&entity.User{}).Where("company_code = ?", companyCode).Count(&n).Error
+	return n, repoerr.Translate(err)
+}
+
+func (r *repository) PurgeDeletionsRequestedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	// The row is kept (soft-deleted) so references to the user stay valid,
+	// but nothing identifying survives. The placeholder email is unique per
+	// row and can never be delivered to.
+	result := r.conn(ctx).
+		Model(&entity.User{}).
+		Where("deletion_requested_at IS NOT NULL AND deletion_requested_at <= ?", cutoff).
+		Updates(map[string]interface{}{
+			"email":                 gorm.Expr("'deleted-' || id::text || '@deleted.invalid'"),
+			"name":                  "Deleted user",
+			"phone":                 "",
+			"password":              "",
+			"company_code":          "",
+			"status":                entity.UserStatusInactive,
+			"deletion_requested_at": nil,
+			"deleted_at":            gorm.Expr("CURRENT_TIMESTAMP"),
+		})
+	return result.RowsAffected, translate(result.Error)
 }
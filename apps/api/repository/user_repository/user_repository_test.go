@@ -0,0 +1,397 @@
+package user_repository
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"veemon/entity"
+	"veemon/pkg/database"
+	repoerr "veemon/repository"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// dryRunDB returns a DB that builds SQL without a server, plus the statements
+// it produced for SELECT-style queries.
+func dryRunDB(t *testing.T) (*gorm.DB, *[]string) {
+	return dryRunDBWithNaming(t, database.NamingStrategy("", false))
+}
+
+func dryRunDBWithNaming(t *testing.T, naming schema.NamingStrategy) (*gorm.DB, *[]string) {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=dryrun"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		NamingStrategy:       naming,
+	})
+	if err != nil {
+		t.Fatalf("open dry-run db: %v", err)
+	}
+	var statements []string
+	if err := db.Callback().Query().After("gorm:query").Register("test:capture", func(tx *gorm.DB) {
+		statements = append(statements, tx.Statement.SQL.String())
+	}); err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+	return db, &statements
+}
+
+func lastSelectList(t *testing.T, statements []string) string {
+	t.Helper()
+	if len(statements) == 0 {
+		t.Fatal("no SQL captured")
+	}
+	sql := statements[len(statements)-1]
+	from := strings.Index(sql, " FROM ")
+	if !strings.HasPrefix(sql, "SELECT ") || from < 0 {
+		t.Fatalf("unexpected SQL: %s", sql)
+	}
+	return sql[len("SELECT "):from]
+}
+
+func TestFindAll_SelectsOnlyRequestedColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns []string
+		want    string
+	}{
+		{"no projection selects the summary", nil, summarySelect},
+		{"requested columns only", []string{"id", "name", "email"}, `"id","name","email"`},
+		{"unknown and sensitive columns are dropped", []string{"id", "password", "1; DROP TABLE users"}, `"id"`},
+		{"nothing allowed selects the summary", []string{"password"}, summarySelect},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, statements := dryRunDB(t)
+			repo := New(db)
+
+			if _, _, err := repo.FindAll(context.Background(), ListParams{Page: 1, Size: 10, Columns: tt.columns}); err != nil {
+				t.Fatalf("FindAll: %v", err)
+			}
+			got := lastSelectList(t, *statements)
+			if got != tt.want {
+				t.Fatalf("SELECT list = %s, want %s", got, tt.want)
+			}
+			if strings.Contains(got, "password") || got == "*" {
+				t.Fatalf("SELECT list %s reads the password hash", got)
+			}
+		})
+	}
+}
+
+const summarySelect = `"id","email","name","phone","status","roles","company_code","created_at","updated_at","deleted_at"`
+
+func TestFindAll_CursorSeeksInsteadOfOffset(t *testing.T) {
+	db, statements := dryRunDB(t)
+	var vars []interface{}
+	if err := db.Callback().Query().After("gorm:query").Register("test:vars", func(tx *gorm.DB) {
+		vars = tx.Statement.Vars
+	}); err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+	repo := New(db)
+	after := &Cursor{CreatedAt: time.Date(2026, 1, 15, 10, 30, 0, 0, time.UTC), ID: "user-9"}
+
+	if _, _, err := repo.FindAll(context.Background(), ListParams{Page: 5000, Size: 10, SortBy: "name", SortOrder: "asc", After: after, Probe: true}); err != nil {
+		t.Fatalf("FindAll: %v", err)
+	}
+	sql := (*statements)[len(*statements)-1]
+	for _, want := range []string{"(created_at, id) > (", "ORDER BY created_at asc, id asc"} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("SQL %q missing %q", sql, want)
+		}
+	}
+	if strings.Contains(sql, "OFFSET") {
+		t.Errorf("cursor query should not use OFFSET: %s", sql)
+	}
+	// Probe reads one row past the page.
+	if len(vars) == 0 || vars[len(vars)-1] != 11 {
+		t.Errorf("limit = %v, want 11", vars)
+	}
+}
+
+// Each batch seeks past the last row's sort key and id, and the projection
+// gains the columns the seek needs.
+func TestFindAllInBatches_SeeksPastEachBatch(t *testing.T) {
+	db, statements := dryRunDB(t)
+	var vars [][]interface{}
+	// Stand in for the database: two full batches, then one short row.
+	if err := db.Callback().Query().After("gorm:query").Register("test:rows", func(tx *gorm.DB) {
+		vars = append(vars, tx.Statement.Vars)
+		n := 2
+		if len(vars) == 3 {
+			n = 1
+		}
+		dest := tx.Statement.Dest.(*[]entity.UserSummary)
+		for i := 0; i < n; i++ {
+			id := len(vars)*10 + i
+			*dest = append(*dest, entity.UserSummary{ID: "user-" + strconv.Itoa(id), Name: "name-" + strconv.Itoa(id)})
+		}
+	}); err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+
+	var seen []string
+	err := New(db).FindAllInBatches(context.Background(), ListParams{SortBy: "name", SortOrder: "asc", Search: "ann", Columns: []string{"email"}}, 2,
+		func(batch []entity.UserSummary) error {
+			for _, u := range batch {
+				seen = append(seen, u.ID)
+			}
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("FindAllInBatches: %v", err)
+	}
+	if want := []string{"user-10", "user-11", "user-20", "user-21", "user-30"}; strings.Join(seen, ",") != strings.Join(want, ",") {
+		t.Fatalf("rows = %v, want %v", seen, want)
+	}
+	if len(*statements) != 3 {
+		t.Fatalf("%d queries, want 3", len(*statements))
+	}
+	first, third := (*statements)[0], (*statements)[2]
+	if got := lastSelectList(t, (*statements)[:1]); got != `"email","id","name"` {
+		t.Errorf("SELECT list = %s", got)
+	}
+	if strings.Contains(first, "(name, id)") || !strings.Contains(third, "(name, id) > (") ||
+		!strings.Contains(third, "ORDER BY name asc, id asc") || !strings.Contains(third, "ILIKE") {
+		t.Errorf("SQL = %q then %q", first, third)
+	}
+	if v := vars[2]; len(v) < 4 || v[2] != "name-21" || v[3] != "user-21" {
+		t.Errorf("third query vars = %v, want the seek from user-21", v)
+	}
+}
+
+func TestFindByIDWithColumns_SelectsOnlyRequestedColumns(t *testing.T) {
+	db, statements := dryRunDB(t)
+	repo := New(db)
+
+	if _, err := repo.FindByIDWithColumns(context.Background(), "00000000-0000-0000-0000-000000000001", []string{"id", "name"}); err != nil {
+		t.Fatalf("FindByIDWithColumns: %v", err)
+	}
+	if got, want := lastSelectList(t, *statements), `"id","name"`; got != want {
+		t.Fatalf("SELECT list = %s, want %s", got, want)
+	}
+}
+
+func TestQueries_UseConfiguredTableName(t *testing.T) {
+	tests := []struct {
+		name     string
+		prefix   string
+		singular bool
+		want     string
+	}{
+		{"default", "", false, `FROM "users"`},
+		{"prefix", "grst_", false, `FROM "grst_users"`},
+		{"singular", "", true, `FROM "user"`},
+		{"prefix and singular", "grst_", true, `FROM "grst_user"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, statements := dryRunDBWithNaming(t, database.NamingStrategy(tt.prefix, tt.singular))
+			repo := New(db)
+			ctx := context.Background()
+
+			_, _ = repo.FindByID(ctx, "00000000-0000-0000-0000-000000000001")
+			_, _ = repo.FindByEmailIncludingDeleted(ctx, "a@example.com")
+			if _, _, err := repo.FindAll(ctx, ListParams{Page: 1, Size: 10, Search: "a"}); err != nil {
+				t.Fatalf("FindAll: %v", err)
+			}
+
+			if len(*statements) == 0 {
+				t.Fatal("no SQL captured")
+			}
+			for _, sql := range *statements {
+				if !strings.Contains(sql, tt.want) {
+					t.Errorf("SQL %q does not target %s", sql, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPurgeDeletionsRequestedBefore_AnonymizesLiveExpiredRows(t *testing.T) {
+	db, _ := dryRunDB(t)
+	var sql string
+	var vars []interface{}
+	if err := db.Callback().Update().After("gorm:update").Register("test:capture", func(tx *gorm.DB) {
+		sql, vars = tx.Statement.SQL.String(), tx.Statement.Vars
+	}); err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+	cutoff := time.Date(2026, 2, 15, 12, 0, 0, 0, time.UTC)
+
+	// Writes open a transaction by default, which needs a server.
+	db = db.Session(&gorm.Session{SkipDefaultTransaction: true})
+	if _, err := New(db).PurgeDeletionsRequestedBefore(context.Background(), cutoff); err != nil {
+		t.Fatalf("PurgeDeletionsRequestedBefore: %v", err)
+	}
+
+	for _, want := range []string{
+		`"email"='deleted-' || id::text || '@deleted.invalid'`,
+		`"deleted_at"=CURRENT_TIMESTAMP`,
+		`deletion_requested_at IS NOT NULL AND deletion_requested_at <= $`,
+		`"users"."deleted_at" IS NULL`,
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("SQL %q does not contain %s", sql, want)
+		}
+	}
+	found := false
+	for _, v := range vars {
+		if ts, ok := v.(time.Time); ok && ts.Equal(cutoff) {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("cutoff %v not bound in %v", cutoff, vars)
+	}
+}
+
+func TestLockCompanyUsers_LocksThenCountsLiveUsers(t *testing.T) {
+	db, statements := dryRunDB(t)
+	var lockSQL string
+	var lockVars []interface{}
+	if err := db.Callback().Raw().After("gorm:raw").Register("test:capture", func(tx *gorm.DB) {
+		lockSQL, lockVars = tx.Statement.SQL.String(), tx.Statement.Vars
+	}); err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+
+	if _, err := New(db).LockCompanyUsers(context.Background(), "COMPANY-001"); err != nil {
+		t.Fatalf("LockCompanyUsers: %v", err)
+	}
+
+	if !strings.Contains(lockSQL, "pg_advisory_xact_lock(hashtext($1))") || len(lockVars) != 1 || lockVars[0] != "company_users:COMPANY-001" {
+		t.Errorf("lock = %q %v", lockSQL, lockVars)
+	}
+	if len(*statements) == 0 {
+		t.Fatal("no count query")
+	}
+	count := (*statements)[len(*statements)-1]
+	for _, want := range []string{"SELECT count(*)", "company_code = $1", `"users"."deleted_at" IS NULL`} {
+		if !strings.Contains(count, want) {
+			t.Errorf("count SQL %q does not contain %s", count, want)
+		}
+	}
+}
+
+func TestUpdateFields_ExpectedVersionGuardsTheUpdate(t *testing.T) {
+	db, statements := dryRunDB(t)
+	var sql string
+	var vars []interface{}
+	if err := db.Callback().Update().After("gorm:update").Register("test:capture", func(tx *gorm.DB) {
+		sql, vars = tx.Statement.SQL.String(), tx.Statement.Vars
+	}); err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+	db = db.Session(&gorm.Session{SkipDefaultTransaction: true})
+	repo := New(db)
+	expected := time.Date(2026, 3, 1, 9, 30, 0, 123456000, time.UTC)
+
+	// A dry run affects no rows, so the repository goes on to check whether
+	// the user exists, finds nothing and reports it missing.
+	_, err := repo.UpdateFields(context.Background(), "u1", map[string]interface{}{"name": "B"}, &expected)
+	if err != repoerr.ErrNotFound {
+		t.Fatalf("err = %v, want repoerr.ErrNotFound", err)
+	}
+	if !strings.Contains(sql, "id = $") || !strings.Contains(sql, "updated_at = $") {
+		t.Errorf("SQL %q does not check the version", sql)
+	}
+	found := false
+	for _, v := range vars {
+		if ts, ok := v.(time.Time); ok && ts.Equal(expected) {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected version %v not bound in %v", expected, vars)
+	}
+	if n := len(*statements); n == 0 || !strings.Contains((*statements)[n-1], "SELECT count(*)") {
+		t.Errorf("no existence check after the update: %v", *statements)
+	}
+
+	_, _ = repo.UpdateFields(context.Background(), "u1", map[string]interface{}{"name": "B"}, nil)
+	if strings.Contains(sql, "updated_at = $") {
+		t.Errorf("unconditional update checks the version: %q", sql)
+	}
+}
+
+func TestCreate_MapsUniqueViolationsToErrDuplicateEmail(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"translated by gorm", gorm.ErrDuplicatedKey, ErrDuplicateEmail},
+		{"pgx", &pgconn.PgError{Code: "23505", ConstraintName: "idx_users_email_live"}, ErrDuplicateEmail},
+		{"lib/pq", &pq.Error{Code: "23505"}, ErrDuplicateEmail},
+		{"other constraint class", &pgconn.PgError{Code: "23503"}, repoerr.ErrConstraint},
+		{"not a constraint", errors.New("connection reset"), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _ := dryRunDB(t)
+			if err := db.Callback().Create().Before("gorm:create").Register("test:fail", func(tx *gorm.DB) {
+				_ = tx.AddError(tt.err)
+			}); err != nil {
+				t.Fatalf("register callback: %v", err)
+			}
+			db = db.Session(&gorm.Session{SkipDefaultTransaction: true})
+
+			err := New(db).Create(context.Background(), &entity.User{Email: "taken@example.com"})
+
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if tt.want == nil && err != tt.err {
+				t.Fatalf("err = %v, want the driver error unchanged", err)
+			}
+		})
+	}
+}
+
+func TestFindAll_RejectsSortFieldsOutsideTheWhitelist(t *testing.T) {
+	tests := []struct {
+		name      string
+		sortBy    string
+		sortOrder string
+	}{
+		{"statement injection", "created_at; DROP TABLE users--", "desc"},
+		{"subquery", "(SELECT password FROM users LIMIT 1)", ""},
+		{"unknown column", "password", "asc"},
+		{"order injection", "name", "asc; DROP TABLE users--"},
+		{"upper-case order", "name", "ASC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, statements := dryRunDB(t)
+			_, _, err := New(db).FindAll(context.Background(), ListParams{Page: 1, Size: 10, SortBy: tt.sortBy, SortOrder: tt.sortOrder})
+			if err != ErrInvalidSortField {
+				t.Fatalf("err = %v, want ErrInvalidSortField", err)
+			}
+			if len(*statements) != 0 {
+				t.Fatalf("queries ran: %v", *statements)
+			}
+		})
+	}
+}
+
+func TestFindAll_EmptySortDefaultsToNewestFirst(t *testing.T) {
+	db, statements := dryRunDB(t)
+	if _, _, err := New(db).FindAll(context.Background(), ListParams{Page: 1, Size: 10}); err != nil {
+		t.Fatalf("FindAll: %v", err)
+	}
+	if sql := (*statements)[len(*statements)-1]; !strings.Contains(sql, "ORDER BY created_at desc, id desc") {
+		t.Fatalf("SQL %q does not default to created_at desc", sql)
+	}
+}
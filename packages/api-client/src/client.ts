@@ -4,15 +4,22 @@
 // options) and apps/api/handler/grpc/user/user_fiber.pb.go. The Go server wraps
 // every REST response in the pkg/response envelope:
 //   success: { success: true, data, meta? }
-//   error:   { success: false, error: { code, message } }
+//   error:   { success: false, error: { code, message, details? } }
 // Field names are camelCase protojson. These lean DTOs mirror the proto message
 // wire shapes; the full protobuf-es message types are exported as `proto` from
 // the package index for consumers that need them.
 
+/** One failed validation rule, listed in a 400 error's details. */
+export interface FieldViolation {
+  field: string;
+  description: string;
+}
+
 export class ApiError extends Error {
   constructor(
     public readonly code: number,
     message: string,
+    public readonly details: FieldViolation[] = [],
   ) {
     super(message);
     this.name = "ApiError";
@@ -33,6 +40,11 @@ export interface Pagination {
   size: number;
   total: number;
   totalPages: number;
+  /** Pass as `cursor` to continue in created_at order; empty on the last page. */
+  nextCursor?: string;
+  /** The sort actually applied, after server defaults. */
+  sortBy: string;
+  sortOrder: string;
 }
 
 export interface RegisterReq {
@@ -62,6 +74,53 @@ export interface RefreshTokenRes {
 export interface LogoutRes {
   message: string;
 }
+export interface DeleteMeRes {
+  message: string;
+  /** When the account is purged unless reactivated (RFC 3339). */
+  deletionScheduledAt: string;
+}
+export interface ChangePasswordReq {
+  currentPassword: string;
+  newPassword: string;
+}
+export interface ResetPasswordReq {
+  /** The token from the password reset email. */
+  token: string;
+  newPassword: string;
+}
+export interface MessageRes {
+  message: string;
+}
+export interface TokenClaims {
+  userId: string;
+  email: string;
+  roles: string[];
+  companyCode: string;
+  expiresAt: string;
+  /** Set on impersonation tokens, with the impersonating superadmin's id. */
+  impersonation?: boolean;
+  actorId?: string;
+}
+export interface TokenIntrospection {
+  active: boolean;
+  claims?: TokenClaims;
+  /** "invalid" | "expired" | "revoked" when not active. */
+  reason?: string;
+}
+export interface IntrospectBatchRes {
+  results: TokenIntrospection[];
+}
+
+export interface ImpersonateUserReq {
+  /** Token lifetime; 0 or anything above 900 means 900 (15 minutes). */
+  ttlSeconds?: number;
+  reason?: string;
+}
+export interface ImpersonateUserRes {
+  token: string;
+  expiresAt: string;
+  user: UserProfile;
+}
 
 export interface ListUsersQuery {
   page?: number;
@@ -69,6 +128,10 @@ export interface ListUsersQuery {
   search?: string;
   sortBy?: string;
   sortOrder?: string;
+  /** Sparse fieldset: only these UserProfile fields are returned. */
+  fields?: string[];
+  /** A previous page's `pagination.nextCursor`; `page` is then ignored. */
+  cursor?: string;
 }
 export interface ListUsersResult {
   users: UserProfile[];
@@ -79,7 +142,7 @@ interface Envelope<T> {
   success: boolean;
   data?: T;
   meta?: unknown;
-  error?: { code: number; message: string };
+  error?: { code: number; message: string; details?: FieldViolation[] };
 }
 
 export interface ApiClientOptions {
@@ -122,6 +185,7 @@ export function createApiClient(opts: ApiClientOptions) {
       throw new ApiError(
         json.error?.code ?? res.status,
         json.error?.message ?? res.statusText,
+        json.error?.details,
       );
     }
     return json;
@@ -150,6 +214,28 @@ export function createApiClient(opts: ApiClientOptions) {
 
     logout: () => request<LogoutRes>("POST", "/api/v1/auth/logout"),
 
+    /** Schedules deletion of the caller's account; its tokens stop working. */
+    deleteMe: () => request<DeleteMeRes>("DELETE", "/api/v1/auth/me"),
+
+    /** Cancels a pending deletion during its grace period and signs in. */
+    reactivateAccount: (body: LoginReq) =>
+      request<LoginRes>("POST", "/api/v1/auth/reactivate", body, false),
+
+    changePassword: (body: ChangePasswordReq) =>
+      request<MessageRes>("POST", "/api/v1/auth/change-password", body),
+
+    /** Answers the same whether or not the email has an account. */
+    forgotPassword: (email: string) =>
+      request<MessageRes>("POST", "/api/v1/auth/forgot-password", { email }, false),
+
+    /** Redeems a single-use reset token; every session of the account ends. */
+    resetPassword: (body: ResetPasswordReq) =>
+      request<MessageRes>("POST", "/api/v1/auth/reset-password", body, false),
+
+    /** Requires a token with the `service` role. */
+    introspectBatch: (tokens: string[]) =>
+      request<IntrospectBatchRes>("POST", "/api/v1/auth/introspect-batch", { tokens }),
+
     listUsers: async (query: ListUsersQuery = {}): Promise<ListUsersResult> => {
       const params = new URLSearchParams();
       if (query.page != null) params.set("page", String(query.page));
@@ -157,6 +243,8 @@ export function createApiClient(opts: ApiClientOptions) {
       if (query.search) params.set("search", query.search);
       if (query.sortBy) params.set("sortBy", query.sortBy);
       if (query.sortOrder) params.set("sortOrder", query.sortOrder);
+      if (query.fields?.length) params.set("fields", query.fields.join(","));
+      if (query.cursor) params.set("cursor", query.cursor);
       const qs = params.toString();
       const env = await raw<UserProfile[]>(
         "GET",
@@ -167,6 +255,14 @@ export function createApiClient(opts: ApiClientOptions) {
         pagination: env.meta as Pagination | undefined,
       };
     },
+
+    /** Requires a token with the `superadmin` role. */
+    impersonateUser: (id: string, body: ImpersonateUserReq = {}) =>
+      request<ImpersonateUserRes>(
+        "POST",
+        `/api/v1/users/${encodeURIComponent(id)}/impersonate`,
+        body,
+      ),
   };
 }
 
@@ -4,8 +4,8 @@
 
 import type { GenFile, GenMessage, GenService } from "@bufbuild/protobuf/codegenv2";
 import { fileDesc, messageDesc, serviceDesc } from "@bufbuild/protobuf/codegenv2";
-import type { EmptySchema } from "@bufbuild/protobuf/wkt";
-import { file_google_protobuf_empty } from "@bufbuild/protobuf/wkt";
+import type { EmptySchema, Struct } from "@bufbuild/protobuf/wkt";
+import { file_google_protobuf_empty, file_google_protobuf_struct } from "@bufbuild/protobuf/wkt";
 import { file_veemon_annotations } from "../veemon/annotations_pb.js";
 import type { Message } from "@bufbuild/protobuf";
 
@@ -13,7 +13,7 @@ import type { Message } from "@bufbuild/protobuf";
  * Describes the file user/user.proto.
  */
 export const file_user_user: GenFile = /*@__PURE__*/
-  fileDesc("Cg91c2VyL3VzZXIucHJvdG8SBHVzZXIiSwoLUmVnaXN0ZXJSZXESDQoFZW1haWwYASABKAkSEAoIcGFzc3dvcmQYAiABKAkSDAoEbmFtZRgDIAEoCRINCgVwaG9uZRgEIAEoCSI2CgtSZWdpc3RlclJlcxIKCgJpZBgBIAEoCRINCgVlbWFpbBgCIAEoCRIMCgRuYW1lGAMgASgJIisKCExvZ2luUmVxEg0KBWVtYWlsGAEgASgJEhAKCHBhc3N3b3JkGAIgASgJIjoKCExvZ2luUmVzEg0KBXRva2VuGAEgASgJEh8KBHVzZXIYAiABKAsyES51c2VyLlVzZXJQcm9maWxlIiAKD1JlZnJlc2hUb2tlblJlcRINCgV0b2tlbhgBIAEoCSIgCg9SZWZyZXNoVG9rZW5SZXMSDQoFdG9rZW4YASABKAkiHAoJTG9nb3V0UmVzEg8KB21lc3NhZ2UYASABKAkiaQoLVXNlclByb2ZpbGUSCgoCaWQYASABKAkSDQoFZW1haWwYAiABKAkSDAoEbmFtZRgDIAEoCRINCgVwaG9uZRgEIAEoCRIOCgZzdGF0dXMYBSABKAkSEgoKY3JlYXRlZF9hdBgGIAEoCSJfCgxMaXN0VXNlcnNSZXESDAoEcGFnZRgBIAEoBRIMCgRzaXplGAIgASgFEg4KBnNlYXJjaBgDIAEoCRIPCgdzb3J0X2J5GAQgASgJEhIKCnNvcnRfb3JkZXIYBSABKAkiVgoMTGlzdFVzZXJzUmVzEiAKBXVzZXJzGAEgAygLMhEudXNlci5Vc2VyUHJvZmlsZRIkCgpwYWdpbmF0aW9uGAIgASgLMhAudXNlci5QYWdpbmF0aW9uIkwKClBhZ2luYXRpb24SDAoEcGFnZRgBIAEoBRIMCgRzaXplGAIgASgFEg0KBXRvdGFsGAMgASgDEhMKC3RvdGFsX3BhZ2VzGAQgASgFIhgKCkdldFVzZXJSZXESCgoCaWQYASABKAkiSAoNVXBkYXRlVXNlclJlcRIKCgJpZBgBIAEoCRIMCgRuYW1lGAIgASgJEg0KBXBob25lGAMgASgJEg4KBnN0YXR1cxgEIAEoCSIbCg1EZWxldGVVc2VyUmVxEgoKAmlkGAEgASgJIiAKDURlbGV0ZVVzZXJSZXMSDwoHbWVzc2FnZRgBIAEoCTL2BgoHVXNlckFwaRJdCghSZWdpc3RlchIRLnVzZXIuUmVnaXN0ZXJSZXEaES51c2VyLlJlZ2lzdGVyUmVzIivavBgnCgRQT1NUEhUvYXBpL3YxL2F1dGgvcmVnaXN0ZXIYASgBMgQIChA8Ek8KBUxvZ2luEg4udXNlci5Mb2dpblJlcRoOLnVzZXIuTG9naW5SZXMiJtq8GCIKBFBPU1QSEi9hcGkvdjEvYXV0aC9sb2dpbhgBMgQIChA8EmIKDFJlZnJlc2hUb2tlbhIVLnVzZXIuUmVmcmVzaFRva2VuUmVxGhUudXNlci5SZWZyZXNoVG9rZW5SZXMiJNq8GCAKBFBPU1QSFC9hcGkvdjEvYXV0aC9yZWZyZXNoIgIIARJSCgVHZXRNZRIWLmdvb2dsZS5wcm90b2J1Zi5FbXB0eRoRLnVzZXIuVXNlclByb2ZpbGUiHtq8GBoKA0dFVBIPL2FwaS92MS9hdXRoL21lIgIIARJWCgZMb2dvdXQSFi5nb29nbGUucHJvdG9idWYuRW1wdHkaDy51c2VyLkxvZ291dFJlcyIj2rwYHwoEUE9TVBITL2FwaS92MS9hdXRoL2xvZ291dCICCAESZgoJTGlzdFVzZXJzEhIudXNlci5MaXN0VXNlcnNSZXEaEi51c2VyLkxpc3RVc2Vyc1JlcyIx2rwYLQoDR0VUEg0vYXBpL3YxL3VzZXJzIhUIARIFYWRtaW4SCnN1cGVyYWRtaW4oAhJkCgdHZXRVc2VyEhAudXNlci5HZXRVc2VyUmVxGhEudXNlci5Vc2VyUHJvZmlsZSI02rwYMAoDR0VUEhIvYXBpL3YxL3VzZXJzL3tpZH0iFQgBEgVhZG1pbhIKc3VwZXJhZG1pbhJsCgpVcGRhdGVVc2VyEhMudXNlci5VcGRhdGVVc2VyUmVxGhEudXNlci5Vc2VyUHJvZmlsZSI22rwYMgoDUFVUEhIvYXBpL3YxL3VzZXJzL3tpZH0YASIVCAESBWFkbWluEgpzdXBlcmFkbWluEm8KCkRlbGV0ZVVzZXISEy51c2VyLkRlbGV0ZVVzZXJSZXEaEy51c2VyLkRlbGV0ZVVzZXJSZXMiN9q8GDMKBkRFTEVURRISL2FwaS92MS91c2Vycy97aWR9IhUIARIFYWRtaW4SCnN1cGVyYWRtaW5CGloYdmVlbW9uL2hhbmRsZXIvZ3JwYy91c2VyYgZwcm90bzM", [file_google_protobuf_empty, file_veemon_annotations]);
+  fileDesc("Cg91c2VyL3VzZXIucHJvdG8SBHVzZXIiSwoLUmVnaXN0ZXJSZXESDQoFZW1haWwYASABKAkSEAoIcGFzc3dvcmQYAiABKAkSDAoEbmFtZRgDIAEoCRINCgVwaG9uZRgEIAEoCSI2CgtSZWdpc3RlclJlcxIKCgJpZBgBIAEoCRINCgVlbWFpbBgCIAEoCRIMCgRuYW1lGAMgASgJIisKCExvZ2luUmVxEg0KBWVtYWlsGAEgASgJEhAKCHBhc3N3b3JkGAIgASgJIjoKCExvZ2luUmVzEg0KBXRva2VuGAEgASgJEh8KBHVzZXIYAiABKAsyES51c2VyLlVzZXJQcm9maWxlIiAKD1JlZnJlc2hUb2tlblJlcRINCgV0b2tlbhgBIAEoCSIgCg9SZWZyZXNoVG9rZW5SZXMSDQoFdG9rZW4YASABKAkiHAoJTG9nb3V0UmVzEg8KB21lc3NhZ2UYASABKAkiPQoLRGVsZXRlTWVSZXMSDwoHbWVzc2FnZRgBIAEoCRIdChVkZWxldGlvbl9zY2hlZHVsZWRfYXQYAiABKAkiNwoUUmVhY3RpdmF0ZUFjY291bnRSZXESDQoFZW1haWwYASABKAkSEAoIcGFzc3dvcmQYAiABKAkiQwoRQ2hhbmdlUGFzc3dvcmRSZXESGAoQY3VycmVudF9wYXNzd29yZBgBIAEoCRIUCgxuZXdfcGFzc3dvcmQYAiABKAkiJAoRQ2hhbmdlUGFzc3dvcmRSZXMSDwoHbWVzc2FnZRgBIAEoCSIiChFGb3Jnb3RQYXNzd29yZFJlcRINCgVlbWFpbBgBIAEoCSIkChFGb3Jnb3RQYXNzd29yZFJlcxIPCgdtZXNzYWdlGAEgASgJIjcKEFJlc2V0UGFzc3dvcmRSZXESDQoFdG9rZW4YASABKAkSFAoMbmV3X3Bhc3N3b3JkGAIgASgJIiMKEFJlc2V0UGFzc3dvcmRSZXMSDwoHbWVzc2FnZRgBIAEoCSIkChJJbnRyb3NwZWN0QmF0Y2hSZXESDgoGdG9rZW5zGAEgAygJIj8KEkludHJvc3BlY3RCYXRjaFJlcxIpCgdyZXN1bHRzGAEgAygLMhgudXNlci5Ub2tlbkludHJvc3BlY3Rpb24iVwoSVG9rZW5JbnRyb3NwZWN0aW9uEg4KBmFjdGl2ZRgBIAEoCBIhCgZjbGFpbXMYAiABKAsyES51c2VyLlRva2VuQ2xhaW1zEg4KBnJlYXNvbhgDIAEoCSKPAQoLVG9rZW5DbGFpbXMSDwoHdXNlcl9pZBgBIAEoCRINCgVlbWFpbBgCIAEoCRINCgVyb2xlcxgDIAMoCRIUCgxjb21wYW55X2NvZGUYBCABKAkSEgoKZXhwaXJlc19hdBgFIAEoCRIQCghhY3Rvcl9pZBgGIAEoCRIVCg1pbXBlcnNvbmF0aW9uGAcgASgIIpEBCgtVc2VyUHJvZmlsZRIKCgJpZBgBIAEoCRINCgVlbWFpbBgCIAEoCRIMCgRuYW1lGAMgASgJEg0KBXBob25lGAQgASgJEg4KBnN0YXR1cxgFIAEoCRISCgpjcmVhdGVkX2F0GAYgASgJEhIKCnVwZGF0ZWRfYXQYByABKAkSEgoKZGVsZXRlZF9hdBgIIAEoCSKYAQoMTGlzdFVzZXJzUmVxEgwKBHBhZ2UYASABKAUSDAoEc2l6ZRgCIAEoBRIOCgZzZWFyY2gYAyABKAkSDwoHc29ydF9ieRgEIAEoCRISCgpzb3J0X29yZGVyGAUgASgJEg4KBmZpZWxkcxgGIAEoCRIOCgZjdXJzb3IYByABKAkSFwoPaW5jbHVkZV9kZWxldGVkGAggASgIIlYKDExpc3RVc2Vyc1JlcxIgCgV1c2VycxgBIAMoCzIRLnVzZXIuVXNlclByb2ZpbGUSJAoKcGFnaW5hdGlvbhgCIAEoCzIQLnVzZXIuUGFnaW5hdGlvbiKGAQoKUGFnaW5hdGlvbhIMCgRwYWdlGAEgASgFEgwKBHNpemUYAiABKAUSDQoFdG90YWwYAyABKAMSEwoLdG90YWxfcGFnZXMYBCABKAUSEwoLbmV4dF9jdXJzb3IYBSABKAkSDwoHc29ydF9ieRgGIAEoCRISCgpzb3J0X29yZGVyGAcgASgJIigKCkdldFVzZXJSZXESCgoCaWQYASABKAkSDgoGZmllbGRzGAIgASgJIpcBCg1DcmVhdGVVc2VyUmVxEg0KBWVtYWlsGAEgASgJEhAKCHBhc3N3b3JkGAIgASgJEgwKBG5hbWUYAyABKAkSDQoFcGhvbmUYBCABKAkSDQoFcm9sZXMYBSADKAkSFAoMY29tcGFueV9jb2RlGAYgASgJEg4KBnN0YXR1cxgHIAEoCRITCgtzZW5kX2ludml0ZRgIIAEoCCKSAQoNVXBkYXRlVXNlclJlcRIKCgJpZBgBIAEoCRIRCgRuYW1lGAIgASgJSACIAQESEgoFcGhvbmUYAyABKAlIAYgBARITCgZzdGF0dXMYBCABKAlIAogBARIbChNleHBlY3RlZF91cGRhdGVkX2F0GAUgASgJQgcKBV9uYW1lQggKBl9waG9uZUIJCgdfc3RhdHVzIi4KDURlbGV0ZVVzZXJSZXESCgoCaWQYASABKAkSEQoJcGVybWFuZW50GAIgASgIIhwKDlJlc3RvcmVVc2VyUmVxEgoKAmlkGAEgASgJIiAKDURlbGV0ZVVzZXJSZXMSDwoHbWVzc2FnZRgBIAEoCSJFChJJbXBlcnNvbmF0ZVVzZXJSZXESCgoCaWQYASABKAkSEwoLdHRsX3NlY29uZHMYAiABKAUSDgoGcmVhc29uGAMgASgJIlgKEkltcGVyc29uYXRlVXNlclJlcxINCgV0b2tlbhgBIAEoCRISCgpleHBpcmVzX2F0GAIgASgJEh8KBHVzZXIYAyABKAsyES51c2VyLlVzZXJQcm9maWxlIn4KElJ1bnRpbWVDb25maWdFbnRyeRILCgNrZXkYASABKAkSDAoEdHlwZRgCIAEoCRINCgV2YWx1ZRgDIAEoCRIVCg1kZWZhdWx0X3ZhbHVlGAQgASgJEhIKCm92ZXJyaWRkZW4YBSABKAgSEwoLZGVzY3JpcHRpb24YBiABKAkiOgoNUnVudGltZUNvbmZpZxIpCgdlbnRyaWVzGAEgAygLMhgudXNlci5SdW50aW1lQ29uZmlnRW50cnkikAEKFlVwZGF0ZVJ1bnRpbWVDb25maWdSZXESOAoGdmFsdWVzGAEgAygLMigudXNlci5VcGRhdGVSdW50aW1lQ29uZmlnUmVxLlZhbHVlc0VudHJ5Eg0KBXJlc2V0GAIgAygJGi0KC1ZhbHVlc0VudHJ5EgsKA2tleRgBIAEoCRINCgV2YWx1ZRgCIAEoCToCOAEihgEKEExpc3RBdWRpdExvZ3NSZXESDAoEcGFnZRgBIAEoBRIMCgRzaXplGAIgASgFEhAKCGFjdG9yX2lkGAMgASgJEhUKDXJlc291cmNlX3R5cGUYBCABKAkSEwoLcmVzb3VyY2VfaWQYBSABKAkSDAoEZnJvbRgGIAEoCRIKCgJ0bxgHIAEoCSLiAQoNQXVkaXRMb2dFbnRyeRIKCgJpZBgBIAEoCRIQCghhY3Rvcl9pZBgCIAEoCRIOCgZhY3Rpb24YAyABKAkSFQoNcmVzb3VyY2VfdHlwZRgEIAEoCRITCgtyZXNvdXJjZV9pZBgFIAEoCRInCgZiZWZvcmUYBiABKAsyFy5nb29nbGUucHJvdG9idWYuU3RydWN0EiYKBWFmdGVyGAcgASgLMhcuZ29vZ2xlLnByb3RvYnVmLlN0cnVjdBISCgpyZXF1ZXN0X2lkGAggASgJEhIKCmNyZWF0ZWRfYXQYCSABKAkiXgoQTGlzdEF1ZGl0TG9nc1JlcxIkCgdlbnRyaWVzGAEgAygLMhMudXNlci5BdWRpdExvZ0VudHJ5EiQKCnBhZ2luYXRpb24YAiABKAsyEC51c2VyLlBhZ2luYXRpb24yhRIKB1VzZXJBcGkSXwoIUmVnaXN0ZXISES51c2VyLlJlZ2lzdGVyUmVxGhEudXNlci5SZWdpc3RlclJlcyIt2rwYKQoEUE9TVBIVL2FwaS92MS9hdXRoL3JlZ2lzdGVyGAEoATIECAoQPEABElEKBUxvZ2luEg4udXNlci5Mb2dpblJlcRoOLnVzZXIuTG9naW5SZXMiKNq8GCQKBFBPU1QSEi9hcGkvdjEvYXV0aC9sb2dpbhgBMgQIChA8QAESYgoMUmVmcmVzaFRva2VuEhUudXNlci5SZWZyZXNoVG9rZW5SZXEaFS51c2VyLlJlZnJlc2hUb2tlblJlcyIk2rwYIAoEUE9TVBIUL2FwaS92MS9hdXRoL3JlZnJlc2giAggBElIKBUdldE1lEhYuZ29vZ2xlLnByb3RvYnVmLkVtcHR5GhEudXNlci5Vc2VyUHJvZmlsZSIe2rwYGgoDR0VUEg8vYXBpL3YxL2F1dGgvbWUiAggBElYKBkxvZ291dBIWLmdvb2dsZS5wcm90b2J1Zi5FbXB0eRoPLnVzZXIuTG9nb3V0UmVzIiPavBgfCgRQT1NUEhMvYXBpL3YxL2F1dGgvbG9nb3V0IgIIARJYCghEZWxldGVNZRIWLmdvb2dsZS5wcm90b2J1Zi5FbXB0eRoRLnVzZXIuRGVsZXRlTWVSZXMiIdq8GB0KBkRFTEVURRIPL2FwaS92MS9hdXRoL21lIgIIARJsChFSZWFjdGl2YXRlQWNjb3VudBIaLnVzZXIuUmVhY3RpdmF0ZUFjY291bnRSZXEaDi51c2VyLkxvZ2luUmVzIivavBgnCgRQT1NUEhcvYXBpL3YxL2F1dGgvcmVhY3RpdmF0ZRgBMgQIChA8EnIKDkNoYW5nZVBhc3N3b3JkEhcudXNlci5DaGFuZ2VQYXNzd29yZFJlcRoXLnVzZXIuQ2hhbmdlUGFzc3dvcmRSZXMiLtq8GCoKBFBPU1QSHC9hcGkvdjEvYXV0aC9jaGFuZ2UtcGFzc3dvcmQYASICCAESdAoORm9yZ290UGFzc3dvcmQSFy51c2VyLkZvcmdvdFBhc3N3b3JkUmVxGhcudXNlci5Gb3Jnb3RQYXNzd29yZFJlcyIw2rwYLAoEUE9TVBIcL2FwaS92MS9hdXRoL2ZvcmdvdC1wYXNzd29yZBgBMgQIBRA8EnAKDVJlc2V0UGFzc3dvcmQSFi51c2VyLlJlc2V0UGFzc3dvcmRSZXEaFi51c2VyLlJlc2V0UGFzc3dvcmRSZXMiL9q8GCsKBFBPU1QSGy9hcGkvdjEvYXV0aC9yZXNldC1wYXNzd29yZBgBMgQIChA8EokBCg9JbnRyb3NwZWN0QmF0Y2gSGC51c2VyLkludHJvc3BlY3RCYXRjaFJlcRoYLnVzZXIuSW50cm9zcGVjdEJhdGNoUmVzIkLavBg+CgRQT1NUEh0vYXBpL3YxL2F1dGgvaW50cm9zcGVjdC1iYXRjaBgBIhUIASIRdG9rZW5zLmludHJvc3BlY3QSXwoJTGlzdFVzZXJzEhIudXNlci5MaXN0VXNlcnNSZXEaEi51c2VyLkxpc3RVc2Vyc1JlcyIq2rwYJgoDR0VUEg0vYXBpL3YxL3VzZXJzIg4IASIKdXNlcnMucmVhZCgCEmYKCkNyZWF0ZVVzZXISEy51c2VyLkNyZWF0ZVVzZXJSZXEaES51c2VyLlVzZXJQcm9maWxlIjDavBgsCgRQT1NUEg0vYXBpL3YxL3VzZXJzGAEiDwgBIgt1c2Vycy53cml0ZSgBQAESXQoHR2V0VXNlchIQLnVzZXIuR2V0VXNlclJlcRoRLnVzZXIuVXNlclByb2ZpbGUiLdq8GCkKA0dFVBISL2FwaS92MS91c2Vycy97aWR9Ig4IASIKdXNlcnMucmVhZBJoCgpVcGRhdGVVc2VyEhMudXNlci5VcGRhdGVVc2VyUmVxGhEudXNlci5Vc2VyUHJvZmlsZSIy2rwYLgoDUFVUEhIvYXBpL3YxL3VzZXJzL3tpZH0YASIPCAEiC3VzZXJzLndyaXRlQAESagoKRGVsZXRlVXNlchITLnVzZXIuRGVsZXRlVXNlclJlcRoTLnVzZXIuRGVsZXRlVXNlclJlcyIy2rwYLgoGREVMRVRFEhIvYXBpL3YxL3VzZXJzL3tpZH0iEAgBIgx1c2Vycy5kZWxldGUScAoLUmVzdG9yZVVzZXISFC51c2VyLlJlc3RvcmVVc2VyUmVxGhEudXNlci5Vc2VyUHJvZmlsZSI42rwYNAoEUE9TVBIaL2FwaS92MS91c2Vycy97aWR9L3Jlc3RvcmUiEAgBIgx1c2Vycy5kZWxldGUSigEKD0ltcGVyc29uYXRlVXNlchIYLnVzZXIuSW1wZXJzb25hdGVVc2VyUmVxGhgudXNlci5JbXBlcnNvbmF0ZVVzZXJSZXMiQ9q8GD8KBFBPU1QSHi9hcGkvdjEvdXNlcnMve2lkfS9pbXBlcnNvbmF0ZRgBIhUIASIRdXNlcnMuaW1wZXJzb25hdGUSgQEKEEdldFJ1bnRpbWVDb25maWcSFi5nb29nbGUucHJvdG9idWYuRW1wdHkaEy51c2VyLlJ1bnRpbWVDb25maWciQNq8GDwKA0dFVBIcL2FwaS92MS9hZG1pbi9ydW50aW1lLWNvbmZpZyIXCAEiE3J1bnRpbWVfY29uZmlnLnJlYWQSjQEKE1VwZGF0ZVJ1bnRpbWVDb25maWcSHC51c2VyLlVwZGF0ZVJ1bnRpbWVDb25maWdSZXEaEy51c2VyLlJ1bnRpbWVDb25maWciQ9q8GD8KA1BVVBIcL2FwaS92MS9hZG1pbi9ydW50aW1lLWNvbmZpZxgBIhgIASIUcnVudGltZV9jb25maWcud3JpdGUSdQoNTGlzdEF1ZGl0TG9ncxIWLnVzZXIuTGlzdEF1ZGl0TG9nc1JlcRoWLnVzZXIuTGlzdEF1ZGl0TG9nc1JlcyI02rwYMAoDR0VUEhIvYXBpL3YxL2F1ZGl0LWxvZ3MiEwgBIg9hdWRpdF9sb2dzLnJlYWQoAkIaWhh2ZWVtb24vaGFuZGxlci9ncnBjL3VzZXJiBnByb3RvMw", [file_google_protobuf_empty, file_google_protobuf_struct, file_veemon_annotations]);
 
 /**
  * @generated from message user.RegisterReq
@@ -169,111 +169,818 @@ export type LogoutRes = Message<"user.LogoutRes"> & {
 export const LogoutResSchema: GenMessage<LogoutRes> = /*@__PURE__*/
   messageDesc(file_user_user, 6);
 
+/**
+ * @generated from message user.DeleteMeRes
+ */
+export type DeleteMeRes = Message<"user.DeleteMeRes"> & {
+  /**
+   * @generated from field: string message = 1;
+   */
+  message: string;
+
+  /**
+   * When the account will be purged unless reactivated (RFC 3339).
+   *
+   * @generated from field: string deletion_scheduled_at = 2;
+   */
+  deletionScheduledAt: string;
+};
+
+/**
+ * Describes the message user.DeleteMeRes.
+ * Use `create(DeleteMeResSchema)` to create a new message.
+ */
+export const DeleteMeResSchema: GenMessage<DeleteMeRes> = /*@__PURE__*/
+  messageDesc(file_user_user, 7);
+
+/**
+ * @generated from message user.ReactivateAccountReq
+ */
+export type ReactivateAccountReq = Message<"user.ReactivateAccountReq"> & {
+  /**
+   * @generated from field: string email = 1;
+   */
+  email: string;
+
+  /**
+   * @generated from field: string password = 2;
+   */
+  password: string;
+};
+
+/**
+ * Describes the message user.ReactivateAccountReq.
+ * Use `create(ReactivateAccountReqSchema)` to create a new message.
+ */
+export const ReactivateAccountReqSchema: GenMessage<ReactivateAccountReq> = /*@__PURE__*/
+  messageDesc(file_user_user, 8);
+
+/**
+ * @generated from message user.ChangePasswordReq
+ */
+export type ChangePasswordReq = Message<"user.ChangePasswordReq"> & {
+  /**
+   * @generated from field: string current_password = 1;
+   */
+  currentPassword: string;
+
+  /**
+   * @generated from field: string new_password = 2;
+   */
+  newPassword: string;
+};
+
+/**
+ * Describes the message user.ChangePasswordReq.
+ * Use `create(ChangePasswordReqSchema)` to create a new message.
+ */
+export const ChangePasswordReqSchema: GenMessage<ChangePasswordReq> = /*@__PURE__*/
+  messageDesc(file_user_user, 9);
+
+/**
+ * @generated from message user.ChangePasswordRes
+ */
+export type ChangePasswordRes = Message<"user.ChangePasswordRes"> & {
+  /**
+   * @generated from field: string message = 1;
+   */
+  message: string;
+};
+
+/**
+ * Describes the message user.ChangePasswordRes.
+ * Use `create(ChangePasswordResSchema)` to create a new message.
+ */
+export const ChangePasswordResSchema: GenMessage<ChangePasswordRes> = /*@__PURE__*/
+  messageDesc(file_user_user, 10);
+
+/**
+ * @generated from message user.ForgotPasswordReq
+ */
+export type ForgotPasswordReq = Message<"user.ForgotPasswordReq"> & {
+  /**
+   * @generated from field: string email = 1;
+   */
+  email: string;
+};
+
+/**
+ * Describes the message user.ForgotPasswordReq.
+ * Use `create(ForgotPasswordReqSchema)` to create a new message.
+ */
+export const ForgotPasswordReqSchema: GenMessage<ForgotPasswordReq> = /*@__PURE__*/
+  messageDesc(file_user_user, 11);
+
+/**
+ * @generated from message user.ForgotPasswordRes
+ */
+export type ForgotPasswordRes = Message<"user.ForgotPasswordRes"> & {
+  /**
+   * @generated from field: string message = 1;
+   */
+  message: string;
+};
+
+/**
+ * Describes the message user.ForgotPasswordRes.
+ * Use `create(ForgotPasswordResSchema)` to create a new message.
+ */
+export const ForgotPasswordResSchema: GenMessage<ForgotPasswordRes> = /*@__PURE__*/
+  messageDesc(file_user_user, 12);
+
+/**
+ * @generated from message user.ResetPasswordReq
+ */
+export type ResetPasswordReq = Message<"user.ResetPasswordReq"> & {
+  /**
+   * @generated from field: string token = 1;
+   */
+  token: string;
+
+  /**
+   * @generated from field: string new_password = 2;
+   */
+  newPassword: string;
+};
+
+/**
+ * Describes the message user.ResetPasswordReq.
+ * Use `create(ResetPasswordReqSchema)` to create a new message.
+ */
+export const ResetPasswordReqSchema: GenMessage<ResetPasswordReq> = /*@__PURE__*/
+  messageDesc(file_user_user, 13);
+
+/**
+ * @generated from message user.ResetPasswordRes
+ */
+export type ResetPasswordRes = Message<"user.ResetPasswordRes"> & {
+  /**
+   * @generated from field: string message = 1;
+   */
+  message: string;
+};
+
+/**
+ * Describes the message user.ResetPasswordRes.
+ * Use `create(ResetPasswordResSchema)` to create a new message.
+ */
+export const ResetPasswordResSchema: GenMessage<ResetPasswordRes> = /*@__PURE__*/
+  messageDesc(file_user_user, 14);
+
+/**
+ * @generated from message user.IntrospectBatchReq
+ */
+export type IntrospectBatchReq = Message<"user.IntrospectBatchReq"> & {
+  /**
+   * Tokens to validate (1-100). Duplicates are validated once.
+   *
+   * @generated from field: repeated string tokens = 1;
+   */
+  tokens: string[];
+};
+
+/**
+ * Describes the message user.IntrospectBatchReq.
+ * Use `create(IntrospectBatchReqSchema)` to create a new message.
+ */
+export const IntrospectBatchReqSchema: GenMessage<IntrospectBatchReq> = /*@__PURE__*/
+  messageDesc(file_user_user, 15);
+
+/**
+ * @generated from message user.IntrospectBatchRes
+ */
+export type IntrospectBatchRes = Message<"user.IntrospectBatchRes"> & {
+  /**
+   * One result per requested token, in request order.
+   *
+   * @generated from field: repeated user.TokenIntrospection results = 1;
+   */
+  results: TokenIntrospection[];
+};
+
+/**
+ * Describes the message user.IntrospectBatchRes.
+ * Use `create(IntrospectBatchResSchema)` to create a new message.
+ */
+export const IntrospectBatchResSchema: GenMessage<IntrospectBatchRes> = /*@__PURE__*/
+  messageDesc(file_user_user, 16);
+
+/**
+ * @generated from message user.TokenIntrospection
+ */
+export type TokenIntrospection = Message<"user.TokenIntrospection"> & {
+  /**
+   * @generated from field: bool active = 1;
+   */
+  active: boolean;
+
+  /**
+   * Set only when active.
+   *
+   * @generated from field: user.TokenClaims claims = 2;
+   */
+  claims?: TokenClaims | undefined;
+
+  /**
+   * Why the token is not active: "invalid", "expired" or "revoked".
+   *
+   * @generated from field: string reason = 3;
+   */
+  reason: string;
+};
+
+/**
+ * Describes the message user.TokenIntrospection.
+ * Use `create(TokenIntrospectionSchema)` to create a new message.
+ */
+export const TokenIntrospectionSchema: GenMessage<TokenIntrospection> = /*@__PURE__*/
+  messageDesc(file_user_user, 17);
+
+/**
+ * @generated from message user.TokenClaims
+ */
+export type TokenClaims = Message<"user.TokenClaims"> & {
+  /**
+   * @generated from field: string user_id = 1;
+   */
+  userId: string;
+
+  /**
+   * @generated from field: string email = 2;
+   */
+  email: string;
+
+  /**
+   * @generated from field: repeated string roles = 3;
+   */
+  roles: string[];
+
+  /**
+   * @generated from field: string company_code = 4;
+   */
+  companyCode: string;
+
+  /**
+   * @generated from field: string expires_at = 5;
+   */
+  expiresAt: string;
+
+  /**
+   * Set on impersonation tokens: the superadmin acting as user_id.
+   *
+   * @generated from field: string actor_id = 6;
+   */
+  actorId: string;
+
+  /**
+   * @generated from field: bool impersonation = 7;
+   */
+  impersonation: boolean;
+};
+
+/**
+ * Describes the message user.TokenClaims.
+ * Use `create(TokenClaimsSchema)` to create a new message.
+ */
+export const TokenClaimsSchema: GenMessage<TokenClaims> = /*@__PURE__*/
+  messageDesc(file_user_user, 18);
+
 /**
  * @generated from message user.UserProfile
  */
-export type UserProfile = Message<"user.UserProfile"> & {
+export type UserProfile = Message<"user.UserProfile"> & {
+  /**
+   * @generated from field: string id = 1;
+   */
+  id: string;
+
+  /**
+   * @generated from field: string email = 2;
+   */
+  email: string;
+
+  /**
+   * @generated from field: string name = 3;
+   */
+  name: string;
+
+  /**
+   * @generated from field: string phone = 4;
+   */
+  phone: string;
+
+  /**
+   * @generated from field: string status = 5;
+   */
+  status: string;
+
+  /**
+   * @generated from field: string created_at = 6;
+   */
+  createdAt: string;
+
+  /**
+   * Last modification, RFC 3339 with fractional seconds. It is the version
+   * GetUser and UpdateUser also send as the ETag header; pass it back as
+   * UpdateUserReq.expected_updated_at (or If-Match) to update only if the
+   * user is unchanged.
+   *
+   * @generated from field: string updated_at = 7;
+   */
+  updatedAt: string;
+
+  /**
+   * When the user was soft-deleted (RFC 3339); empty for live users. Only
+   * ListUsers with include_deleted returns deleted users.
+   *
+   * @generated from field: string deleted_at = 8;
+   */
+  deletedAt: string;
+};
+
+/**
+ * Describes the message user.UserProfile.
+ * Use `create(UserProfileSchema)` to create a new message.
+ */
+export const UserProfileSchema: GenMessage<UserProfile> = /*@__PURE__*/
+  messageDesc(file_user_user, 19);
+
+/**
+ * @generated from message user.ListUsersReq
+ */
+export type ListUsersReq = Message<"user.ListUsersReq"> & {
+  /**
+   * @generated from field: int32 page = 1;
+   */
+  page: number;
+
+  /**
+   * @generated from field: int32 size = 2;
+   */
+  size: number;
+
+  /**
+   * @generated from field: string search = 3;
+   */
+  search: string;
+
+  /**
+   * @generated from field: string sort_by = 4;
+   */
+  sortBy: string;
+
+  /**
+   * @generated from field: string sort_order = 5;
+   */
+  sortOrder: string;
+
+  /**
+   * Comma-separated UserProfile fields to return (e.g. "id,name,email").
+   * Empty returns every field.
+   *
+   * @generated from field: string fields = 6;
+   */
+  fields: string;
+
+  /**
+   * Opaque keyset cursor (a previous response's pagination.nextCursor).
+   * When set, page is ignored and results continue after the cursor row.
+   *
+   * @generated from field: string cursor = 7;
+   */
+  cursor: string;
+
+  /**
+   * Also list soft-deleted users, with deleted_at set.
+   *
+   * @generated from field: bool include_deleted = 8;
+   */
+  includeDeleted: boolean;
+};
+
+/**
+ * Describes the message user.ListUsersReq.
+ * Use `create(ListUsersReqSchema)` to create a new message.
+ */
+export const ListUsersReqSchema: GenMessage<ListUsersReq> = /*@__PURE__*/
+  messageDesc(file_user_user, 20);
+
+/**
+ * @generated from message user.ListUsersRes
+ */
+export type ListUsersRes = Message<"user.ListUsersRes"> & {
+  /**
+   * @generated from field: repeated user.UserProfile users = 1;
+   */
+  users: UserProfile[];
+
+  /**
+   * @generated from field: user.Pagination pagination = 2;
+   */
+  pagination?: Pagination | undefined;
+};
+
+/**
+ * Describes the message user.ListUsersRes.
+ * Use `create(ListUsersResSchema)` to create a new message.
+ */
+export const ListUsersResSchema: GenMessage<ListUsersRes> = /*@__PURE__*/
+  messageDesc(file_user_user, 21);
+
+/**
+ * @generated from message user.Pagination
+ */
+export type Pagination = Message<"user.Pagination"> & {
+  /**
+   * @generated from field: int32 page = 1;
+   */
+  page: number;
+
+  /**
+   * @generated from field: int32 size = 2;
+   */
+  size: number;
+
+  /**
+   * @generated from field: int64 total = 3;
+   */
+  total: bigint;
+
+  /**
+   * @generated from field: int32 total_pages = 4;
+   */
+  totalPages: number;
+
+  /**
+   * Cursor for the next page in created_at order; empty on the last page
+   * or when sorting by another column.
+   *
+   * @generated from field: string next_cursor = 5;
+   */
+  nextCursor: string;
+
+  /**
+   * The sort actually applied, after defaults and corrections.
+   *
+   * @generated from field: string sort_by = 6;
+   */
+  sortBy: string;
+
+  /**
+   * @generated from field: string sort_order = 7;
+   */
+  sortOrder: string;
+};
+
+/**
+ * Describes the message user.Pagination.
+ * Use `create(PaginationSchema)` to create a new message.
+ */
+export const PaginationSchema: GenMessage<Pagination> = /*@__PURE__*/
+  messageDesc(file_user_user, 22);
+
+/**
+ * @generated from message user.GetUserReq
+ */
+export type GetUserReq = Message<"user.GetUserReq"> & {
+  /**
+   * @generated from field: string id = 1;
+   */
+  id: string;
+
+  /**
+   * Comma-separated UserProfile fields to return; empty returns all.
+   *
+   * @generated from field: string fields = 2;
+   */
+  fields: string;
+};
+
+/**
+ * Describes the message user.GetUserReq.
+ * Use `create(GetUserReqSchema)` to create a new message.
+ */
+export const GetUserReqSchema: GenMessage<GetUserReq> = /*@__PURE__*/
+  messageDesc(file_user_user, 23);
+
+/**
+ * @generated from message user.CreateUserReq
+ */
+export type CreateUserReq = Message<"user.CreateUserReq"> & {
+  /**
+   * @generated from field: string email = 1;
+   */
+  email: string;
+
+  /**
+   * Required unless send_invite is set; the two are mutually exclusive.
+   *
+   * @generated from field: string password = 2;
+   */
+  password: string;
+
+  /**
+   * @generated from field: string name = 3;
+   */
+  name: string;
+
+  /**
+   * @generated from field: string phone = 4;
+   */
+  phone: string;
+
+  /**
+   * Roles to grant; empty grants the self-registration defaults. The
+   * caller's own roles must grant every permission these would.
+   *
+   * @generated from field: repeated string roles = 5;
+   */
+  roles: string[];
+
+  /**
+   * @generated from field: string company_code = 6;
+   */
+  companyCode: string;
+
+  /**
+   * active, inactive or pending; empty means active.
+   *
+   * @generated from field: string status = 7;
+   */
+  status: string;
+
+  /**
+   * Email the user a single-use link to set their password instead.
+   *
+   * @generated from field: bool send_invite = 8;
+   */
+  sendInvite: boolean;
+};
+
+/**
+ * Describes the message user.CreateUserReq.
+ * Use `create(CreateUserReqSchema)` to create a new message.
+ */
+export const CreateUserReqSchema: GenMessage<CreateUserReq> = /*@__PURE__*/
+  messageDesc(file_user_user, 24);
+
+/**
+ * @generated from message user.UpdateUserReq
+ */
+export type UpdateUserReq = Message<"user.UpdateUserReq"> & {
+  /**
+   * @generated from field: string id = 1;
+   */
+  id: string;
+
+  /**
+   * Unset fields are left unchanged. An empty phone clears it; name and
+   * status cannot be cleared.
+   *
+   * @generated from field: optional string name = 2;
+   */
+  name?: string;
+
+  /**
+   * @generated from field: optional string phone = 3;
+   */
+  phone?: string;
+
+  /**
+   * @generated from field: optional string status = 4;
+   */
+  status?: string;
+
+  /**
+   * When set, the update applies only if the user's updatedAt still
+   * equals it, failing with FAILED_PRECONDITION (HTTP 412) otherwise.
+   * Over REST the If-Match header carries the same condition.
+   *
+   * @generated from field: string expected_updated_at = 5;
+   */
+  expectedUpdatedAt: string;
+};
+
+/**
+ * Describes the message user.UpdateUserReq.
+ * Use `create(UpdateUserReqSchema)` to create a new message.
+ */
+export const UpdateUserReqSchema: GenMessage<UpdateUserReq> = /*@__PURE__*/
+  messageDesc(file_user_user, 25);
+
+/**
+ * @generated from message user.DeleteUserReq
+ */
+export type DeleteUserReq = Message<"user.DeleteUserReq"> & {
+  /**
+   * @generated from field: string id = 1;
+   */
+  id: string;
+
+  /**
+   * Remove the user for good instead of soft-deleting it. Needs the
+   * users.purge permission.
+   *
+   * @generated from field: bool permanent = 2;
+   */
+  permanent: boolean;
+};
+
+/**
+ * Describes the message user.DeleteUserReq.
+ * Use `create(DeleteUserReqSchema)` to create a new message.
+ */
+export const DeleteUserReqSchema: GenMessage<DeleteUserReq> = /*@__PURE__*/
+  messageDesc(file_user_user, 26);
+
+/**
+ * @generated from message user.RestoreUserReq
+ */
+export type RestoreUserReq = Message<"user.RestoreUserReq"> & {
   /**
    * @generated from field: string id = 1;
    */
   id: string;
+};
+
+/**
+ * Describes the message user.RestoreUserReq.
+ * Use `create(RestoreUserReqSchema)` to create a new message.
+ */
+export const RestoreUserReqSchema: GenMessage<RestoreUserReq> = /*@__PURE__*/
+  messageDesc(file_user_user, 27);
 
+/**
+ * @generated from message user.DeleteUserRes
+ */
+export type DeleteUserRes = Message<"user.DeleteUserRes"> & {
   /**
-   * @generated from field: string email = 2;
+   * @generated from field: string message = 1;
    */
-  email: string;
+  message: string;
+};
+
+/**
+ * Describes the message user.DeleteUserRes.
+ * Use `create(DeleteUserResSchema)` to create a new message.
+ */
+export const DeleteUserResSchema: GenMessage<DeleteUserRes> = /*@__PURE__*/
+  messageDesc(file_user_user, 28);
 
+/**
+ * @generated from message user.ImpersonateUserReq
+ */
+export type ImpersonateUserReq = Message<"user.ImpersonateUserReq"> & {
   /**
-   * @generated from field: string name = 3;
+   * @generated from field: string id = 1;
    */
-  name: string;
+  id: string;
 
   /**
-   * @generated from field: string phone = 4;
+   * Token lifetime in seconds; 0 or anything above 900 (15 minutes) is
+   * capped at 900.
+   *
+   * @generated from field: int32 ttl_seconds = 2;
    */
-  phone: string;
+  ttlSeconds: number;
 
   /**
-   * @generated from field: string status = 5;
+   * Why the session is needed (e.g. a ticket reference); recorded in the
+   * audit log.
+   *
+   * @generated from field: string reason = 3;
    */
-  status: string;
+  reason: string;
+};
+
+/**
+ * Describes the message user.ImpersonateUserReq.
+ * Use `create(ImpersonateUserReqSchema)` to create a new message.
+ */
+export const ImpersonateUserReqSchema: GenMessage<ImpersonateUserReq> = /*@__PURE__*/
+  messageDesc(file_user_user, 29);
 
+/**
+ * @generated from message user.ImpersonateUserRes
+ */
+export type ImpersonateUserRes = Message<"user.ImpersonateUserRes"> & {
   /**
-   * @generated from field: string created_at = 6;
+   * @generated from field: string token = 1;
    */
-  createdAt: string;
+  token: string;
+
+  /**
+   * @generated from field: string expires_at = 2;
+   */
+  expiresAt: string;
+
+  /**
+   * @generated from field: user.UserProfile user = 3;
+   */
+  user?: UserProfile | undefined;
 };
 
 /**
- * Describes the message user.UserProfile.
- * Use `create(UserProfileSchema)` to create a new message.
+ * Describes the message user.ImpersonateUserRes.
+ * Use `create(ImpersonateUserResSchema)` to create a new message.
  */
-export const UserProfileSchema: GenMessage<UserProfile> = /*@__PURE__*/
-  messageDesc(file_user_user, 7);
+export const ImpersonateUserResSchema: GenMessage<ImpersonateUserRes> = /*@__PURE__*/
+  messageDesc(file_user_user, 30);
 
 /**
- * @generated from message user.ListUsersReq
+ * @generated from message user.RuntimeConfigEntry
  */
-export type ListUsersReq = Message<"user.ListUsersReq"> & {
+export type RuntimeConfigEntry = Message<"user.RuntimeConfigEntry"> & {
   /**
-   * @generated from field: int32 page = 1;
+   * @generated from field: string key = 1;
    */
-  page: number;
+  key: string;
 
   /**
-   * @generated from field: int32 size = 2;
+   * int, bool, duration (Go syntax, e.g. "90s") or string.
+   *
+   * @generated from field: string type = 2;
    */
-  size: number;
+  type: string;
 
   /**
-   * @generated from field: string search = 3;
+   * @generated from field: string value = 3;
    */
-  search: string;
+  value: string;
 
   /**
-   * @generated from field: string sort_by = 4;
+   * @generated from field: string default_value = 4;
    */
-  sortBy: string;
+  defaultValue: string;
 
   /**
-   * @generated from field: string sort_order = 5;
+   * Whether value was set through UpdateRuntimeConfig rather than being
+   * the default.
+   *
+   * @generated from field: bool overridden = 5;
    */
-  sortOrder: string;
+  overridden: boolean;
+
+  /**
+   * @generated from field: string description = 6;
+   */
+  description: string;
 };
 
 /**
- * Describes the message user.ListUsersReq.
- * Use `create(ListUsersReqSchema)` to create a new message.
+ * Describes the message user.RuntimeConfigEntry.
+ * Use `create(RuntimeConfigEntrySchema)` to create a new message.
  */
-export const ListUsersReqSchema: GenMessage<ListUsersReq> = /*@__PURE__*/
-  messageDesc(file_user_user, 8);
+export const RuntimeConfigEntrySchema: GenMessage<RuntimeConfigEntry> = /*@__PURE__*/
+  messageDesc(file_user_user, 31);
 
 /**
- * @generated from message user.ListUsersRes
+ * @generated from message user.RuntimeConfig
  */
-export type ListUsersRes = Message<"user.ListUsersRes"> & {
+export type RuntimeConfig = Message<"user.RuntimeConfig"> & {
   /**
-   * @generated from field: repeated user.UserProfile users = 1;
+   * @generated from field: repeated user.RuntimeConfigEntry entries = 1;
    */
-  users: UserProfile[];
+  entries: RuntimeConfigEntry[];
+};
+
+/**
+ * Describes the message user.RuntimeConfig.
+ * Use `create(RuntimeConfigSchema)` to create a new message.
+ */
+export const RuntimeConfigSchema: GenMessage<RuntimeConfig> = /*@__PURE__*/
+  messageDesc(file_user_user, 32);
 
+/**
+ * @generated from message user.UpdateRuntimeConfigReq
+ */
+export type UpdateRuntimeConfigReq = Message<"user.UpdateRuntimeConfigReq"> & {
   /**
-   * @generated from field: user.Pagination pagination = 2;
+   * New values by key, each written as a string of the key's type.
+   *
+   * @generated from field: map<string, string> values = 1;
    */
-  pagination?: Pagination | undefined;
+  values: { [key: string]: string };
+
+  /**
+   * Keys to revert to their defaults.
+   *
+   * @generated from field: repeated string reset = 2;
+   */
+  reset: string[];
 };
 
 /**
- * Describes the message user.ListUsersRes.
- * Use `create(ListUsersResSchema)` to create a new message.
+ * Describes the message user.UpdateRuntimeConfigReq.
+ * Use `create(UpdateRuntimeConfigReqSchema)` to create a new message.
  */
-export const ListUsersResSchema: GenMessage<ListUsersRes> = /*@__PURE__*/
-  messageDesc(file_user_user, 9);
+export const UpdateRuntimeConfigReqSchema: GenMessage<UpdateRuntimeConfigReq> = /*@__PURE__*/
+  messageDesc(file_user_user, 33);
 
 /**
- * @generated from message user.Pagination
+ * @generated from message user.ListAuditLogsReq
  */
-export type Pagination = Message<"user.Pagination"> & {
+export type ListAuditLogsReq = Message<"user.ListAuditLogsReq"> & {
   /**
    * @generated from field: int32 page = 1;
    */
@@ -285,105 +992,128 @@ export type Pagination = Message<"user.Pagination"> & {
   size: number;
 
   /**
-   * @generated from field: int64 total = 3;
+   * @generated from field: string actor_id = 3;
    */
-  total: bigint;
+  actorId: string;
 
   /**
-   * @generated from field: int32 total_pages = 4;
+   * e.g. "user".
+   *
+   * @generated from field: string resource_type = 4;
    */
-  totalPages: number;
-};
+  resourceType: string;
 
-/**
- * Describes the message user.Pagination.
- * Use `create(PaginationSchema)` to create a new message.
- */
-export const PaginationSchema: GenMessage<Pagination> = /*@__PURE__*/
-  messageDesc(file_user_user, 10);
+  /**
+   * @generated from field: string resource_id = 5;
+   */
+  resourceId: string;
 
-/**
- * @generated from message user.GetUserReq
- */
-export type GetUserReq = Message<"user.GetUserReq"> & {
   /**
-   * @generated from field: string id = 1;
+   * RFC 3339 bounds on created_at: entries at or after from and before to.
+   *
+   * @generated from field: string from = 6;
    */
-  id: string;
+  from: string;
+
+  /**
+   * @generated from field: string to = 7;
+   */
+  to: string;
 };
 
 /**
- * Describes the message user.GetUserReq.
- * Use `create(GetUserReqSchema)` to create a new message.
+ * Describes the message user.ListAuditLogsReq.
+ * Use `create(ListAuditLogsReqSchema)` to create a new message.
  */
-export const GetUserReqSchema: GenMessage<GetUserReq> = /*@__PURE__*/
-  messageDesc(file_user_user, 11);
+export const ListAuditLogsReqSchema: GenMessage<ListAuditLogsReq> = /*@__PURE__*/
+  messageDesc(file_user_user, 34);
 
 /**
- * @generated from message user.UpdateUserReq
+ * @generated from message user.AuditLogEntry
  */
-export type UpdateUserReq = Message<"user.UpdateUserReq"> & {
+export type AuditLogEntry = Message<"user.AuditLogEntry"> & {
   /**
    * @generated from field: string id = 1;
    */
   id: string;
 
   /**
-   * @generated from field: string name = 2;
+   * The caller, or the superadmin behind an impersonation token; empty for
+   * changes made without one.
+   *
+   * @generated from field: string actor_id = 2;
    */
-  name: string;
+  actorId: string;
 
   /**
-   * @generated from field: string phone = 3;
+   * create, update, delete, restore or purge.
+   *
+   * @generated from field: string action = 3;
    */
-  phone: string;
+  action: string;
 
   /**
-   * @generated from field: string status = 4;
+   * @generated from field: string resource_type = 4;
    */
-  status: string;
-};
+  resourceType: string;
 
-/**
- * Describes the message user.UpdateUserReq.
- * Use `create(UpdateUserReqSchema)` to create a new message.
- */
-export const UpdateUserReqSchema: GenMessage<UpdateUserReq> = /*@__PURE__*/
-  messageDesc(file_user_user, 12);
+  /**
+   * @generated from field: string resource_id = 5;
+   */
+  resourceId: string;
 
-/**
- * @generated from message user.DeleteUserReq
- */
-export type DeleteUserReq = Message<"user.DeleteUserReq"> & {
   /**
-   * @generated from field: string id = 1;
+   * The resource before and after the change; before is unset for a
+   * creation and after for a deletion.
+   *
+   * @generated from field: google.protobuf.Struct before = 6;
    */
-  id: string;
+  before?: Struct | undefined;
+
+  /**
+   * @generated from field: google.protobuf.Struct after = 7;
+   */
+  after?: Struct | undefined;
+
+  /**
+   * @generated from field: string request_id = 8;
+   */
+  requestId: string;
+
+  /**
+   * @generated from field: string created_at = 9;
+   */
+  createdAt: string;
 };
 
 /**
- * Describes the message user.DeleteUserReq.
- * Use `create(DeleteUserReqSchema)` to create a new message.
+ * Describes the message user.AuditLogEntry.
+ * Use `create(AuditLogEntrySchema)` to create a new message.
  */
-export const DeleteUserReqSchema: GenMessage<DeleteUserReq> = /*@__PURE__*/
-  messageDesc(file_user_user, 13);
+export const AuditLogEntrySchema: GenMessage<AuditLogEntry> = /*@__PURE__*/
+  messageDesc(file_user_user, 35);
 
 /**
- * @generated from message user.DeleteUserRes
+ * @generated from message user.ListAuditLogsRes
  */
-export type DeleteUserRes = Message<"user.DeleteUserRes"> & {
+export type ListAuditLogsRes = Message<"user.ListAuditLogsRes"> & {
   /**
-   * @generated from field: string message = 1;
+   * @generated from field: repeated user.AuditLogEntry entries = 1;
    */
-  message: string;
+  entries: AuditLogEntry[];
+
+  /**
+   * @generated from field: user.Pagination pagination = 2;
+   */
+  pagination?: Pagination | undefined;
 };
 
 /**
- * Describes the message user.DeleteUserRes.
- * Use `create(DeleteUserResSchema)` to create a new message.
+ * Describes the message user.ListAuditLogsRes.
+ * Use `create(ListAuditLogsResSchema)` to create a new message.
  */
-export const DeleteUserResSchema: GenMessage<DeleteUserRes> = /*@__PURE__*/
-  messageDesc(file_user_user, 14);
+export const ListAuditLogsResSchema: GenMessage<ListAuditLogsRes> = /*@__PURE__*/
+  messageDesc(file_user_user, 36);
 
 /**
  * UserApi is exposed over both gRPC and REST. The REST surface is declared
@@ -443,6 +1173,72 @@ export const UserApi: GenService<{
     input: typeof EmptySchema;
     output: typeof LogoutResSchema;
   },
+  /**
+   * Protected endpoint - schedule deletion of the caller's own account.
+   * The account is deactivated and its sessions revoked at once; it is
+   * purged after the grace period unless reactivated first.
+   *
+   * @generated from rpc user.UserApi.DeleteMe
+   */
+  deleteMe: {
+    methodKind: "unary";
+    input: typeof EmptySchema;
+    output: typeof DeleteMeResSchema;
+  },
+  /**
+   * Public endpoint - cancel a pending self-deletion by confirming the
+   * account's credentials, and sign in.
+   *
+   * @generated from rpc user.UserApi.ReactivateAccount
+   */
+  reactivateAccount: {
+    methodKind: "unary";
+    input: typeof ReactivateAccountReqSchema;
+    output: typeof LoginResSchema;
+  },
+  /**
+   * Protected endpoint - replace the caller's password after confirming
+   * the current one.
+   *
+   * @generated from rpc user.UserApi.ChangePassword
+   */
+  changePassword: {
+    methodKind: "unary";
+    input: typeof ChangePasswordReqSchema;
+    output: typeof ChangePasswordResSchema;
+  },
+  /**
+   * Public endpoint - email a single-use reset token. Answers the same
+   * whether or not the email belongs to an account.
+   *
+   * @generated from rpc user.UserApi.ForgotPassword
+   */
+  forgotPassword: {
+    methodKind: "unary";
+    input: typeof ForgotPasswordReqSchema;
+    output: typeof ForgotPasswordResSchema;
+  },
+  /**
+   * Public endpoint - redeem a reset token and set a new password.
+   *
+   * @generated from rpc user.UserApi.ResetPassword
+   */
+  resetPassword: {
+    methodKind: "unary";
+    input: typeof ResetPasswordReqSchema;
+    output: typeof ResetPasswordResSchema;
+  },
+  /**
+   * Machine endpoint - validate up to 100 tokens in one call (API gateway).
+   * Per-token failures are reported in the results, never as a call error.
+   *
+   * @generated from rpc user.UserApi.IntrospectBatch
+   */
+  introspectBatch: {
+    methodKind: "unary";
+    input: typeof IntrospectBatchReqSchema;
+    output: typeof IntrospectBatchResSchema;
+  },
   /**
    * Admin endpoint - list all users
    *
@@ -453,6 +1249,17 @@ export const UserApi: GenService<{
     input: typeof ListUsersReqSchema;
     output: typeof ListUsersResSchema;
   },
+  /**
+   * Admin endpoint - create a user. Without a password the user is sent
+   * an invitation to choose one.
+   *
+   * @generated from rpc user.UserApi.CreateUser
+   */
+  createUser: {
+    methodKind: "unary";
+    input: typeof CreateUserReqSchema;
+    output: typeof UserProfileSchema;
+  },
   /**
    * Admin endpoint - get user by ID
    *
@@ -474,7 +1281,8 @@ export const UserApi: GenService<{
     output: typeof UserProfileSchema;
   },
   /**
-   * Admin endpoint - soft-delete user
+   * Admin endpoint - soft-delete user; with permanent, remove it for good
+   * (users.purge, superadmin only by default).
    *
    * @generated from rpc user.UserApi.DeleteUser
    */
@@ -483,6 +1291,59 @@ export const UserApi: GenService<{
     input: typeof DeleteUserReqSchema;
     output: typeof DeleteUserResSchema;
   },
+  /**
+   * Admin endpoint - undo a soft delete
+   *
+   * @generated from rpc user.UserApi.RestoreUser
+   */
+  restoreUser: {
+    methodKind: "unary";
+    input: typeof RestoreUserReqSchema;
+    output: typeof UserProfileSchema;
+  },
+  /**
+   * Superadmin endpoint - issue a short-lived token acting as the user.
+   * The token cannot be refreshed, and cannot call sensitive endpoints.
+   *
+   * @generated from rpc user.UserApi.ImpersonateUser
+   */
+  impersonateUser: {
+    methodKind: "unary";
+    input: typeof ImpersonateUserReqSchema;
+    output: typeof ImpersonateUserResSchema;
+  },
+  /**
+   * Superadmin endpoint - runtime-tunable operational knobs with the value
+   * each has on this replica.
+   *
+   * @generated from rpc user.UserApi.GetRuntimeConfig
+   */
+  getRuntimeConfig: {
+    methodKind: "unary";
+    input: typeof EmptySchema;
+    output: typeof RuntimeConfigSchema;
+  },
+  /**
+   * Superadmin endpoint - change or reset runtime config keys on every
+   * replica. Nothing is written unless every key and value is valid.
+   *
+   * @generated from rpc user.UserApi.UpdateRuntimeConfig
+   */
+  updateRuntimeConfig: {
+    methodKind: "unary";
+    input: typeof UpdateRuntimeConfigReqSchema;
+    output: typeof RuntimeConfigSchema;
+  },
+  /**
+   * Superadmin endpoint - who changed which resource, newest first.
+   *
+   * @generated from rpc user.UserApi.ListAuditLogs
+   */
+  listAuditLogs: {
+    methodKind: "unary";
+    input: typeof ListAuditLogsReqSchema;
+    output: typeof ListAuditLogsResSchema;
+  },
 }> = /*@__PURE__*/
   serviceDesc(file_user_user, 0);
 
@@ -18,7 +18,7 @@ import type { Message } from "@bufbuild/protobuf";
  * Describes the file veemon/annotations.proto.
  */
 export const file_veemon_annotations: GenFile = /*@__PURE__*/
-  fileDesc("Chh2ZWVtb24vYW5ub3RhdGlvbnMucHJvdG8SBnZlZW1vbiKfAQoFUm91dGUSDgoGbWV0aG9kGAEgASgJEgwKBHBhdGgYAiABKAkSDAoEYm9keRgDIAEoCBIaCgRhdXRoGAQgASgLMgwudmVlbW9uLkF1dGgSJwoIcmVzcG9uc2UYBSABKA4yFS52ZWVtb24uUmVzcG9uc2VTdHlsZRIlCgpyYXRlX2xpbWl0GAYgASgLMhEudmVlbW9uLlJhdGVMaW1pdCInCgRBdXRoEhAKCHJlcXVpcmVkGAEgASgIEg0KBXJvbGVzGAIgAygJIjAKCVJhdGVMaW1pdBILCgNtYXgYASABKA0SFgoOd2luZG93X3NlY29uZHMYAiABKA0qWwoNUmVzcG9uc2VTdHlsZRIVChFSRVNQT05TRV9TVFlMRV9PSxAAEhoKFlJFU1BPTlNFX1NUWUxFX0NSRUFURUQQARIXChNSRVNQT05TRV9TVFlMRV9MSVNUEAI6RQoFcm91dGUSHi5nb29nbGUucHJvdG9idWYuTWV0aG9kT3B0aW9ucxjLhwMgASgLMg0udmVlbW9uLlJvdXRlUgVyb3V0ZUIjWiF2ZWVtb24vaGFuZGxlci9ncnBjL3ZlZW1vbjt2ZWVtb25iBnByb3RvMw", [file_google_protobuf_descriptor]);
+  fileDesc("Chh2ZWVtb24vYW5ub3RhdGlvbnMucHJvdG8SBnZlZW1vbiLBAQoFUm91dGUSDgoGbWV0aG9kGAEgASgJEgwKBHBhdGgYAiABKAkSDAoEYm9keRgDIAEoCBIaCgRhdXRoGAQgASgLMgwudmVlbW9uLkF1dGgSJwoIcmVzcG9uc2UYBSABKA4yFS52ZWVtb24uUmVzcG9uc2VTdHlsZRIlCgpyYXRlX2xpbWl0GAYgASgLMhEudmVlbW9uLlJhdGVMaW1pdBIQCghjb25zdW1lcxgHIAMoCRIOCgZzdHJpY3QYCCABKAgiYQoEQXV0aBIQCghyZXF1aXJlZBgBIAEoCBINCgVyb2xlcxgCIAMoCRIjCglyb2xlX21vZGUYAyABKA4yEC52ZWVtb24uUm9sZU1vZGUSEwoLcGVybWlzc2lvbnMYBCADKAkiMAoJUmF0ZUxpbWl0EgsKA21heBgBIAEoDRIWCg53aW5kb3dfc2Vjb25kcxgCIAEoDSo4CghSb2xlTW9kZRIVChFST0xFX01PREVfRU5GT1JDRRAAEhUKEVJPTEVfTU9ERV9NT05JVE9SEAEqWwoNUmVzcG9uc2VTdHlsZRIVChFSRVNQT05TRV9TVFlMRV9PSxAAEhoKFlJFU1BPTlNFX1NUWUxFX0NSRUFURUQQARIXChNSRVNQT05TRV9TVFlMRV9MSVNUEAI6RQoFcm91dGUSHi5nb29nbGUucHJvdG9idWYuTWV0aG9kT3B0aW9ucxjLhwMgASgLMg0udmVlbW9uLlJvdXRlUgVyb3V0ZUIjWiF2ZWVtb24vaGFuZGxlci9ncnBjL3ZlZW1vbjt2ZWVtb25iBnByb3RvMw", [file_google_protobuf_descriptor]);
 
 /**
  * Route declares how an RPC is exposed over REST. Attach it to a method:
@@ -27,7 +27,7 @@ export const file_veemon_annotations: GenFile = /*@__PURE__*/
  *     option (veemon.route) = {
  *       method: "GET"
  *       path: "/api/v1/users/{id}"
- *       auth: { required: true, roles: ["admin", "superadmin"] }
+ *       auth: { required: true, permissions: ["users.read"] }
  *     };
  *   }
  *
@@ -82,6 +82,24 @@ export type Route = Message<"veemon.Route"> & {
    * @generated from field: veemon.RateLimit rate_limit = 6;
    */
   rateLimit?: RateLimit | undefined;
+
+  /**
+   * Media types accepted for the request body when body is true. Defaults to
+   * ["application/json"]; any other Content-Type is rejected with 415 before
+   * parsing. Upload-style routes list e.g. "multipart/form-data", "text/csv".
+   *
+   * @generated from field: repeated string consumes = 7;
+   */
+  consumes: string[];
+
+  /**
+   * When true, a JSON body naming a field the request message does not have
+   * is rejected with 400, listing each unknown field, instead of the field
+   * being ignored.
+   *
+   * @generated from field: bool strict = 8;
+   */
+  strict: boolean;
 };
 
 /**
@@ -111,6 +129,23 @@ export type Auth = Message<"veemon.Auth"> & {
    * @generated from field: repeated string roles = 2;
    */
   roles: string[];
+
+  /**
+   * How a failed role or permission check is handled. Use ROLE_MODE_MONITOR
+   * when tightening an existing route to measure who would be rejected first.
+   *
+   * @generated from field: veemon.RoleMode role_mode = 3;
+   */
+  roleMode: RoleMode;
+
+  /**
+   * If non-empty, the caller's roles must grant every one of these
+   * permissions (see pkg/authz), and roles is ignored. Implies
+   * required = true.
+   *
+   * @generated from field: repeated string permissions = 4;
+   */
+  permissions: string[];
 };
 
 /**
@@ -148,6 +183,34 @@ export type RateLimit = Message<"veemon.RateLimit"> & {
 export const RateLimitSchema: GenMessage<RateLimit> = /*@__PURE__*/
   messageDesc(file_veemon_annotations, 2);
 
+/**
+ * RoleMode selects whether a role check rejects or only reports.
+ *
+ * @generated from enum veemon.RoleMode
+ */
+export enum RoleMode {
+  /**
+   * Callers without an allowed role get 403 / PermissionDenied.
+   *
+   * @generated from enum value: ROLE_MODE_ENFORCE = 0;
+   */
+  ENFORCE = 0,
+
+  /**
+   * Callers without an allowed role are let through, logged and counted in
+   * auth_denials_shadow_total. Token validation is still enforced.
+   *
+   * @generated from enum value: ROLE_MODE_MONITOR = 1;
+   */
+  MONITOR = 1,
+}
+
+/**
+ * Describes the enum veemon.RoleMode.
+ */
+export const RoleModeSchema: GenEnum<RoleMode> = /*@__PURE__*/
+  enumDesc(file_veemon_annotations, 0);
+
 /**
  * ResponseStyle selects how a successful handler result is serialized.
  *
@@ -181,7 +244,7 @@ export enum ResponseStyle {
  * Describes the enum veemon.ResponseStyle.
  */
 export const ResponseStyleSchema: GenEnum<ResponseStyle> = /*@__PURE__*/
-  enumDesc(file_veemon_annotations, 0);
+  enumDesc(file_veemon_annotations, 1);
 
 /**
  * Field number in the internal (50000-99999) extension range.